@@ -4,8 +4,11 @@ go 1.16
 
 require (
 	bitbucket.org/bertimus9/systemstat v0.0.0-20180207000608-0eeff89b0690
+	github.com/Azure/azure-amqp-common-go/v3 v3.0.1
+	github.com/Azure/azure-event-hubs-go/v3 v3.3.13
+	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/diegoholiveira/jsonlogic v1.0.1-0.20200220175622-ab7989be08b9
-	github.com/eclipse/paho.mqtt.golang v1.3.5
+	github.com/eclipse/paho.mqtt.golang v1.4.2
 	github.com/edgexfoundry/go-mod-bootstrap/v2 v2.1.0
 	github.com/edgexfoundry/go-mod-core-contracts/v2 v2.1.0
 	github.com/edgexfoundry/go-mod-messaging/v2 v2.2.0-dev.1
@@ -13,7 +16,17 @@ require (
 	github.com/fxamacker/cbor/v2 v2.3.0
 	github.com/gomodule/redigo v2.0.0+incompatible
 	github.com/google/uuid v1.3.0
+	github.com/gopcua/opcua v0.2.3
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/hashicorp/go-multierror v1.1.1
+	github.com/lib/pq v1.10.7
+	github.com/linkedin/goavro/v2 v2.11.1
+	github.com/nats-io/nats.go v1.11.0
+	github.com/owulveryck/onnx-go v0.5.0
+	github.com/pion/dtls/v2 v2.1.5
 	github.com/stretchr/testify v1.7.0
+	github.com/yuin/gopher-lua v0.0.0-20210529063254-f4c35e4016d9
+	google.golang.org/protobuf v1.27.1
+	gorgonia.org/tensor v0.9.3
 )
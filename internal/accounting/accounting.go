@@ -0,0 +1,231 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package accounting
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+// FunctionStats contains the costs of a pipeline function's executions
+type FunctionStats struct {
+	// Function is the index of the function in the pipeline
+	Function   int    `json:"function"`
+	Executions uint64 `json:"executions"`
+	// CPUTime is the CPU time, in nanoseconds, used by the function's executions
+	CPUTime int64 `json:"cpuTime"`
+	// BytesProduced is the total length of the function's results that are []byte or string, i.e. the results of the
+	// conversion, compression and encryption functions
+	BytesProduced uint64 `json:"bytesProduced"`
+}
+
+// PipelineStats contains the costs of a pipeline's executions, which are the totals of the costs of its functions
+type PipelineStats struct {
+	PipelineId    string `json:"pipelineId"`
+	Executions    uint64 `json:"executions"`
+	CPUTime       int64  `json:"cpuTime"`
+	BytesProduced uint64 `json:"bytesProduced"`
+	// OverBudget is the number of executions that used more CPU time than the ExecutionBudget
+	OverBudget uint64          `json:"overBudget"`
+	Functions  []FunctionStats `json:"functions"`
+}
+
+// Accountant totals the CPU time used and the bytes produced by the functions of each pipeline.
+// The CPU time is that of the thread executing the function, so the CPU time of any go routines the function starts
+// isn't included. Where the thread's CPU time isn't available, the time taken by the function is used instead.
+type Accountant struct {
+	lock      sync.Mutex
+	budget    time.Duration
+	lc        logger.LoggingClient
+	pipelines map[string]*pipelineCosts
+}
+
+type pipelineCosts struct {
+	executions uint64
+	overBudget uint64
+	functions  map[int]*FunctionStats
+}
+
+// Execution is a pipeline execution whose costs are being measured
+type Execution struct {
+	accountant    *Accountant
+	pipelineId    string
+	correlationId string
+	cpuTime       time.Duration
+	functions     []FunctionStats
+}
+
+// NewAccountant creates, initializes and returns a new instance of Accountant for the configuration
+func NewAccountant(config sdkCommon.CostAccountingInfo, lc logger.LoggingClient) (*Accountant, error) {
+	var budget time.Duration
+	if strings.TrimSpace(config.ExecutionBudget) != "" {
+		var err error
+		budget, err = time.ParseDuration(strings.TrimSpace(config.ExecutionBudget))
+		if err != nil || budget <= 0 {
+			return nil, fmt.Errorf("invalid CostAccounting ExecutionBudget '%s', must be a duration greater than 0", config.ExecutionBudget)
+		}
+	}
+
+	return &Accountant{
+		budget:    budget,
+		lc:        lc,
+		pipelines: make(map[string]*pipelineCosts),
+	}, nil
+}
+
+// Begin starts measuring the costs of an execution of the pipeline
+func (accountant *Accountant) Begin(pipelineId string, correlationId string) *Execution {
+	return &Execution{
+		accountant:    accountant,
+		pipelineId:    pipelineId,
+		correlationId: correlationId,
+	}
+}
+
+// Measure calls the pipeline function at the index, measuring the CPU time it uses and the bytes it produces, and
+// returns its results. Only calls the function when the execution is nil, so the runtime doesn't need to check
+// whether cost accounting is enabled.
+func (execution *Execution) Measure(index int, function func() (bool, interface{})) (bool, interface{}) {
+	if execution == nil {
+		return function()
+	}
+
+	// The thread's CPU time only measures the function while the go routine stays on the thread
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	start, threadTime := threadCPUTime()
+	started := time.Now()
+
+	continuePipeline, result := function()
+
+	var used time.Duration
+	if end, ok := threadCPUTime(); ok && threadTime {
+		used = end - start
+	} else {
+		used = time.Since(started)
+	}
+
+	execution.cpuTime += used
+	execution.functions = append(execution.functions, FunctionStats{
+		Function:      index,
+		Executions:    1,
+		CPUTime:       used.Nanoseconds(),
+		BytesProduced: bytesProduced(result),
+	})
+
+	return continuePipeline, result
+}
+
+// End adds the costs of the execution, which has completed, to the totals of the pipeline and logs the execution
+// when it used more CPU time than the ExecutionBudget. Does nothing when the execution is nil.
+func (execution *Execution) End() {
+	if execution == nil {
+		return
+	}
+
+	accountant := execution.accountant
+	overBudget := accountant.budget > 0 && execution.cpuTime > accountant.budget
+
+	accountant.lock.Lock()
+	costs, found := accountant.pipelines[execution.pipelineId]
+	if !found {
+		costs = &pipelineCosts{functions: make(map[int]*FunctionStats)}
+		accountant.pipelines[execution.pipelineId] = costs
+	}
+
+	costs.executions++
+	if overBudget {
+		costs.overBudget++
+	}
+
+	for _, function := range execution.functions {
+		stats, found := costs.functions[function.Function]
+		if !found {
+			stats = &FunctionStats{Function: function.Function}
+			costs.functions[function.Function] = stats
+		}
+
+		stats.Executions++
+		stats.CPUTime += function.CPUTime
+		stats.BytesProduced += function.BytesProduced
+	}
+	accountant.lock.Unlock()
+
+	if overBudget {
+		accountant.lc.Warnf("Execution of pipeline '%s' used %s of CPU time, over the budget of %s (%s=%s)",
+			execution.pipelineId, execution.cpuTime.String(), accountant.budget.String(), common.CorrelationHeader,
+			execution.correlationId)
+	}
+}
+
+// Stats returns the costs of each pipeline executed, the pipeline that used the most CPU time first
+func (accountant *Accountant) Stats() []PipelineStats {
+	accountant.lock.Lock()
+	defer accountant.lock.Unlock()
+
+	all := make([]PipelineStats, 0, len(accountant.pipelines))
+	for pipelineId, costs := range accountant.pipelines {
+		stats := PipelineStats{
+			PipelineId: pipelineId,
+			Executions: costs.executions,
+			OverBudget: costs.overBudget,
+			Functions:  make([]FunctionStats, 0, len(costs.functions)),
+		}
+
+		for _, function := range costs.functions {
+			stats.CPUTime += function.CPUTime
+			stats.BytesProduced += function.BytesProduced
+			stats.Functions = append(stats.Functions, *function)
+		}
+
+		sort.Slice(stats.Functions, func(i, j int) bool {
+			return stats.Functions[i].Function < stats.Functions[j].Function
+		})
+
+		all = append(all, stats)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CPUTime != all[j].CPUTime {
+			return all[i].CPUTime > all[j].CPUTime
+		}
+		return all[i].PipelineId < all[j].PipelineId
+	})
+
+	return all
+}
+
+func bytesProduced(result interface{}) uint64 {
+	switch value := result.(type) {
+	case []byte:
+		return uint64(len(value))
+	case string:
+		return uint64(len(value))
+	default:
+		return 0
+	}
+}
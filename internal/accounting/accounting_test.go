@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+var lc = logger.NewMockClient()
+
+func TestNewAccountant(t *testing.T) {
+	accountant, err := NewAccountant(common.CostAccountingInfo{}, lc)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), accountant.budget)
+
+	accountant, err = NewAccountant(common.CostAccountingInfo{ExecutionBudget: " 50ms "}, lc)
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Millisecond, accountant.budget)
+
+	_, err = NewAccountant(common.CostAccountingInfo{ExecutionBudget: "cheap"}, lc)
+	assert.Error(t, err)
+
+	_, err = NewAccountant(common.CostAccountingInfo{ExecutionBudget: "-1s"}, lc)
+	assert.Error(t, err)
+}
+
+// busy uses CPU time until the duration has passed
+func busy(duration time.Duration) {
+	for start := time.Now(); time.Since(start) < duration; {
+	}
+}
+
+func TestAccountant_Stats(t *testing.T) {
+	accountant, err := NewAccountant(common.CostAccountingInfo{ExecutionBudget: "5ms"}, lc)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		execution := accountant.Begin("light", "123")
+		continuePipeline, result := execution.Measure(0, func() (bool, interface{}) { return true, "four" })
+		require.True(t, continuePipeline)
+		assert.Equal(t, "four", result)
+		execution.Measure(1, func() (bool, interface{}) { return false, nil })
+		execution.End()
+	}
+
+	execution := accountant.Begin("heavy", "456")
+	execution.Measure(0, func() (bool, interface{}) {
+		busy(20 * time.Millisecond)
+		return true, []byte{1, 2, 3}
+	})
+	execution.End()
+
+	stats := accountant.Stats()
+	require.Len(t, stats, 2)
+
+	heavy := stats[0]
+	assert.Equal(t, "heavy", heavy.PipelineId, "pipeline using the most CPU time should be first")
+	assert.Equal(t, uint64(1), heavy.Executions)
+	assert.Equal(t, uint64(1), heavy.OverBudget)
+	assert.Equal(t, uint64(3), heavy.BytesProduced)
+	assert.GreaterOrEqual(t, heavy.CPUTime, (10 * time.Millisecond).Nanoseconds())
+
+	light := stats[1]
+	assert.Equal(t, "light", light.PipelineId)
+	assert.Equal(t, uint64(2), light.Executions)
+	assert.Equal(t, uint64(0), light.OverBudget)
+	assert.Equal(t, uint64(8), light.BytesProduced)
+	require.Len(t, light.Functions, 2)
+	assert.Equal(t, FunctionStats{Function: 0, Executions: 2, CPUTime: light.Functions[0].CPUTime, BytesProduced: 8}, light.Functions[0])
+	assert.Equal(t, 1, light.Functions[1].Function)
+	assert.Equal(t, uint64(2), light.Functions[1].Executions)
+	assert.Equal(t, light.Functions[0].CPUTime+light.Functions[1].CPUTime, light.CPUTime)
+}
+
+func TestExecution_Nil(t *testing.T) {
+	var execution *Execution
+
+	continuePipeline, result := execution.Measure(0, func() (bool, interface{}) { return true, "data" })
+	assert.True(t, continuePipeline)
+	assert.Equal(t, "data", result)
+	execution.End()
+}
@@ -18,9 +18,14 @@
 package app
 
 import (
+	"context"
 	"fmt"
+
 	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/google/uuid"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 )
 
@@ -29,12 +34,27 @@ type backgroundPublisher struct {
 	output chan<- interfaces.BackgroundMessage
 }
 
-// Publish provided message through the configured MessageBus output
+// Publish provided message through the configured MessageBus output. The message is formatted the same as the
+// response data of the pipelines: the content type is the context's response content type, or its input content type,
+// and otherwise detected from the payload. The context is optional for messages not produced by a pipeline, a new
+// correlation id is then generated.
 func (pub *backgroundPublisher) Publish(payload []byte, context interfaces.AppFunctionContext) error {
+	if context == nil {
+		context = appfunction.NewContext(uuid.NewString(), nil, "")
+	}
+
+	contentType := context.ResponseContentType()
+	if contentType == "" {
+		contentType = context.InputContentType()
+	}
+	if contentType == "" {
+		contentType = codec.DetectContentType(payload)
+	}
+
 	outputEnvelope := types.MessageEnvelope{
 		CorrelationID: context.CorrelationID(),
 		Payload:       payload,
-		ContentType:   context.InputContentType(),
+		ContentType:   contentType,
 	}
 
 	topic, err := context.ApplyValues(pub.topic)
@@ -55,3 +75,32 @@ func newBackgroundPublisher(baseTopic string, capacity int) (<-chan interfaces.B
 	backgroundChannel := make(chan interfaces.BackgroundMessage, capacity)
 	return backgroundChannel, &backgroundPublisher{topic: baseTopic, output: backgroundChannel}
 }
+
+// mergeBackgroundChannels returns a channel receiving the messages sent to either channel until the context is
+// cancelled. The second channel is returned as is when the first is nil.
+func mergeBackgroundChannels(ctx context.Context, first <-chan interfaces.BackgroundMessage, second <-chan interfaces.BackgroundMessage) <-chan interfaces.BackgroundMessage {
+	if first == nil {
+		return second
+	}
+
+	merged := make(chan interfaces.BackgroundMessage)
+	forward := func(input <-chan interfaces.BackgroundMessage) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message := <-input:
+				select {
+				case <-ctx.Done():
+					return
+				case merged <- message:
+				}
+			}
+		}
+	}
+
+	go forward(first)
+	go forward(second)
+
+	return merged
+}
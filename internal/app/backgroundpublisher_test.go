@@ -106,3 +106,21 @@ func TestPublish_Topic_Formatting_Error(t *testing.T) {
 
 	require.Equal(t, fmt.Sprintf("Failed to prepare topic for publishing: failed to replace all context placeholders in input ('%s' after replacements)", topic), err.Error())
 }
+
+func TestPublish_Formatted_Like_Response(t *testing.T) {
+	background, pub := newBackgroundPublisher("topic", 2)
+
+	appCtx := appfunction.NewContext("id", nil, "type")
+	appCtx.SetResponseContentType("response-type")
+	require.NoError(t, pub.Publish([]byte("something"), appCtx))
+
+	msg := (<-background).Message()
+	assert.Equal(t, "id", msg.CorrelationID)
+	assert.Equal(t, "response-type", msg.ContentType, "response content type should be used like for the response data")
+
+	require.NoError(t, pub.Publish([]byte(`{"key":"value"}`), nil))
+
+	msg = (<-background).Message()
+	assert.NotEmpty(t, msg.CorrelationID, "correlation id should be generated without a context")
+	assert.Equal(t, "application/json", msg.ContentType, "content type should be detected without a context")
+}
@@ -30,6 +30,7 @@ import (
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/handlers"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 )
 
 // ConfigUpdateProcessor contains the data need to process configuration updates
@@ -85,10 +86,26 @@ func (processor *ConfigUpdateProcessor) WaitForConfigUpdates(configUpdated confi
 					processor.processConfigChangedStoreForwardRetryInterval()
 					lc.Infof("StoreAndForward RetryInterval changed to %s", currentWritable.StoreAndForward.RetryInterval)
 
+				case previousWriteable.StoreAndForward.OfflineMode != currentWritable.StoreAndForward.OfflineMode:
+					processor.processConfigChangedStoreForwardOfflineMode()
+					lc.Infof("StoreAndForward OfflineMode changed to %+v", currentWritable.StoreAndForward.OfflineMode)
+
 				case previousWriteable.StoreAndForward.Enabled != currentWritable.StoreAndForward.Enabled:
 					processor.processConfigChangedStoreForwardEnabled()
 					lc.Infof("StoreAndForward Enabled changed to %v", currentWritable.StoreAndForward.Enabled)
 
+				case previousWriteable.Maintenance != currentWritable.Maintenance:
+					processor.processConfigChangedMaintenance(previousWriteable.Maintenance)
+					lc.Infof("Maintenance changed to %+v", currentWritable.Maintenance)
+
+				case previousWriteable.Capture != currentWritable.Capture:
+					// The runtime reads the capture settings for each message so no further processing is needed
+					lc.Infof("Capture changed to %+v", currentWritable.Capture)
+
+				case previousWriteable.Lineage != currentWritable.Lineage:
+					// The runtime reads the lineage settings for each message so no further processing is needed
+					lc.Infof("Lineage changed to %+v", currentWritable.Lineage)
+
 				default:
 					// Assume change is in the pipeline since all others have been checked appropriately
 					processor.processConfigChangedPipeline()
@@ -110,6 +127,16 @@ func (processor *ConfigUpdateProcessor) processConfigChangedStoreForwardRetryInt
 	}
 }
 
+func (processor *ConfigUpdateProcessor) processConfigChangedStoreForwardOfflineMode() {
+	sdk := processor.svc
+
+	// Restarting the retry loop also restarts (or stops) the connectivity probe with the new settings
+	if sdk.config.Writable.StoreAndForward.Enabled {
+		sdk.stopStoreForward()
+		sdk.startStoreForward()
+	}
+}
+
 func (processor *ConfigUpdateProcessor) processConfigChangedStoreForwardEnabled() {
 	sdk := processor.svc
 
@@ -140,6 +167,34 @@ func (processor *ConfigUpdateProcessor) processConfigChangedStoreForwardEnabled(
 	}
 }
 
+func (processor *ConfigUpdateProcessor) processConfigChangedMaintenance(previous common.MaintenanceInfo) {
+	sdk := processor.svc
+	current := sdk.config.Writable.Maintenance
+
+	wasPaused := previous.Enabled && previous.PauseTrigger
+	paused := current.Enabled && current.PauseTrigger
+
+	switch {
+	case paused && !wasPaused:
+		sdk.stopTrigger()
+		sdk.LoggingClient().Info("Trigger paused for maintenance")
+
+	case wasPaused && !paused:
+		if err := sdk.restartTrigger(); err != nil {
+			sdk.LoggingClient().Errorf("Unable to resume the trigger after maintenance: %s", err.Error())
+		}
+	}
+
+	if current.Enabled && !sdk.config.Writable.StoreAndForward.Enabled {
+		sdk.LoggingClient().Warn("Maintenance mode enabled without StoreAndForward, the data exported will be lost")
+	}
+
+	if previous.Enabled && !current.Enabled {
+		// Send the data held back during maintenance without waiting for the next retry interval
+		sdk.runtime.RetryStoredDataNow()
+	}
+}
+
 func (processor *ConfigUpdateProcessor) processConfigChangedPipeline() {
 	sdk := processor.svc
 
@@ -154,12 +209,11 @@ func (processor *ConfigUpdateProcessor) processConfigChangedPipeline() {
 
 		sdk.runtime.TargetType = sdk.targetType
 
-		// Update the pipelines with their new transforms
-		for _, pipeline := range pipelines {
-			sdk.runtime.SetFunctionsPipelineTransforms(pipeline.Id, pipeline.Transforms)
-		}
+		// Swap all the pipelines at once, so the next message is processed with the new pipelines, including any
+		// pipelines added or removed
+		sdk.runtime.ReplaceFunctionsPipelines(pipelines)
 
-		sdk.LoggingClient().Info("Configurable Pipeline successfully reloaded from new configuration")
+		sdk.LoggingClient().Infof("Configurable Pipeline successfully reloaded from new configuration with %d pipeline(s)", len(pipelines))
 	}
 }
 
@@ -18,9 +18,14 @@ package app
 
 import (
 	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/transforms"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
@@ -32,6 +37,7 @@ import (
 const (
 	ProfileNames        = "profilenames"
 	DeviceNames         = "devicenames"
+	DeviceGroup         = "devicegroup"
 	SourceNames         = "sourcenames"
 	ResourceNames       = "resourcenames"
 	FilterOut           = "filterout"
@@ -69,6 +75,8 @@ const (
 	TransformXml        = "xml"
 	TransformJson       = "json"
 	AuthMode            = "authmode"
+	AuthSecretPath      = "authsecretpath"
+	TLSSecretPath       = "tlssecretpath"
 	Tags                = "tags"
 	ResponseContentType = "responsecontenttype"
 	Algorithm           = "algorithm"
@@ -76,17 +84,166 @@ const (
 	CompressZLIB        = "zlib"
 	EncryptAES          = "aes"
 	EncryptAES256       = "aes256"
+	EncryptAESGCM       = "aesgcm"
+	EncryptHybrid       = "hybrid"
+	PublicKey           = "publickey"
+	SignHMAC            = "hmac"
+	SignECDSA           = "ecdsa"
+	Headers             = "headers"
 	Mode                = "mode"
 	BatchByCount        = "bycount"
 	BatchByTime         = "bytime"
 	BatchByTimeAndCount = "bytimecount"
+	BatchByCountOrTime  = "bycountortime"
 	IsEventData         = "iseventdata"
+	RuleNames           = "rules"
+	ModelLocation       = "model"
+	PredictionName      = "predictionname"
+	ImageCrop           = "crop"
+	ImageResize         = "resize"
+	ImageGrayscale      = "grayscale"
+	ImageFormat         = "format"
+	ImageQuality        = "quality"
+	SampleRate          = "samplerate"
+	WindowSize          = "windowsize"
+	FrequencyBands      = "bands"
+	SmoothEWMA          = "ewma"
+	SmoothKalman        = "kalman"
+	Alpha               = "alpha"
+	ProcessNoise        = "processnoise"
+	MeasurementNoise    = "measurementnoise"
+	AnomalyZScore       = "zscore"
+	AnomalyEWMA         = "ewma"
+	AnomalyTag          = "tag"
+	AnomalyDrop         = "drop"
+	Threshold           = "threshold"
+	Delta               = "delta"
+	Hysteresis          = "hysteresis"
+	SessionGap          = "gap"
+	Statement           = "statement"
+	Window              = "window"
+	WindowType          = "windowtype"
+	TumblingWindow      = "tumbling"
+	SlidingWindow       = "sliding"
+	AggregateFunctions  = "functions"
+	Expression          = "expression"
+	Template            = "template"
+	TemplateSetting     = "templatesetting"
+	MessageName         = "messagename"
+	DescriptorSet       = "descriptorset"
+	TargetAddress       = "targetaddress"
+	MessageBus          = "messagebus"
+	Schema              = "schema"
+	SchemaSetting       = "schemasetting"
+	RegistryURL         = "registryurl"
+	Subject             = "subject"
+	AutoRegister        = "autoregister"
+	ReceiptHeader       = "receiptheader"
+	NamePattern         = "namepattern"
+	MinValue            = "minvalue"
+	MaxValue            = "maxvalue"
+	EqualValue          = "equalvalue"
+	Tolerance           = "tolerance"
+	MaxEntries          = "maxentries"
+	PersistFile         = "persistfile"
+	Limit               = "limit"
+	ThrottleDrop        = "drop"
+	ThrottleQueue       = "queue"
+	MaxQueued           = "maxqueued"
+	MaxAge              = "maxage"
+	AgeBasis            = "agebasis"
+	AgeByOrigin         = "origin"
+	AgeByReceived       = "received"
+	StaleUrl            = "staleurl"
+	Conversions         = "conversions"
+	MetadataFields      = "metadatafields"
+	CacheTTL            = "cachettl"
+	Script              = "script"
+	ScriptFile          = "scriptfile"
+	ScriptTimeout       = "scripttimeout"
+	ScriptMemoryLimit   = "scriptmemorylimit"
+	FallbackUrls        = "fallbackurls"
+	FileDirectory       = "filedirectory"
+	ChunkSize           = "chunksize"
+	ChunkTimeout        = "chunktimeout"
+	Envelope            = "envelope"
+	AlignFlush          = "alignflush"
+	EventElement        = "eventelement"
+	ReadingElement      = "readingelement"
+	ReadingsElement     = "readingselement"
+	FieldNames          = "fieldnames"
+	Attributes          = "attributes"
+	FieldNaming         = "fieldnaming"
+	OmitEmpty           = "omitempty"
+	FlattenTags         = "flattentags"
+	SourceName          = "sourcename"
+	Origin              = "origin"
+	Readings            = "readings"
+	CommandName         = "commandname"
+	CommandMethod       = "method"
+	CommandSettings     = "settings"
+	MaxConcurrent       = "maxconcurrent"
+	Destination         = "destination"
+	Unit                = "unit"
+	Period              = "period"
+	DownsampleRate      = "downsamplerate"
+	FailureThreshold    = "failurethreshold"
+	ProbeInterval       = "probeinterval"
+	BatchFormat         = "format"
+	CompressBatch       = "compress"
+	Retries             = "retries"
+	MaxRetryAfter       = "maxretryafter"
+	ProjectId           = "projectid"
+	OrderingKey         = "orderingkey"
+	Endpoint            = "endpoint"
+	Region              = "region"
+	RegistryId          = "registryid"
+	DeviceId            = "deviceid"
+	SubFolder           = "subfolder"
+	TokenLifetime       = "tokenlifetime"
+	Bucket              = "bucket"
+	ObjectKey           = "objectkey"
+	PathStyle           = "pathstyle"
+	AccountURL          = "accounturl"
+	Container           = "container"
+	BlobName            = "blobname"
+	BlobType            = "blobtype"
+	IdentityEndpoint    = "identityendpoint"
+	Host                = "host"
+	Port                = "port"
+	Database            = "database"
+	SSLMode             = "sslmode"
+	Table               = "table"
+	Columns             = "columns"
+	BatchSize           = "batchsize"
+	UseCopy             = "usecopy"
+	Nodes               = "nodes"
+	SecurityPolicy      = "securitypolicy"
+	SecurityMode        = "securitymode"
+	WriteTimeout        = "writetimeout"
+	From                = "from"
+	To                  = "to"
+	ImplicitTLS         = "implicittls"
+	AccountSid          = "accountsid"
+	Index               = "index"
+	IngestPipeline      = "ingestpipeline"
+	DataStream          = "datastream"
+	PersistentSession   = "persistentsession"
+	StoreDirectory      = "storedirectory"
+	InflightWindow      = "inflightwindow"
+	SharedConnection    = "sharedconnection"
+	Security            = "security"
+	BlockSize           = "blocksize"
 )
 
 // Configurable contains the helper functions that return the function pointers for building the configurable function pipeline.
 // They transform the parameters map from the Pipeline configuration in to the actual actual parameters required by the function.
 type Configurable struct {
-	lc logger.LoggingClient
+	lc          logger.LoggingClient
+	rules       map[string]sdkCommon.RuleInfo
+	appSettings map[string]string
+	// flushCoordinator aligns the flushes of the functions with the AlignFlush parameter set
+	flushCoordinator *transforms.FlushCoordinator
 }
 
 // NewConfigurable returns a new instance of Configurable
@@ -105,7 +262,7 @@ func NewConfigurable(lc logger.LoggingClient) *Configurable {
 // For example, data generated by a motor does not get passed to functions only interested in data from a thermostat.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) FilterByProfileName(parameters map[string]string) interfaces.AppFunction {
-	transform, ok := app.processFilterParameters("FilterByProfileName", parameters, ProfileNames)
+	transform, ok := app.processFilterParameters("FilterByProfileName", parameters, ProfileNames, false)
 	if !ok {
 		return nil
 	}
@@ -116,13 +273,14 @@ func (app *Configurable) FilterByProfileName(parameters map[string]string) inter
 // FilterByDeviceName - Specify the device names of interest to filter for data coming from certain sensors.
 // The Filter by Device Name transform looks at the Event in the message and looks at the device names of interest list,
 // provided by this function, and filters out those messages whose Event is for device names not in the
-// device names of interest.
+// device names of interest. The DeviceGroup parameter adds the members of the device group, configured in the
+// DeviceGroups section, to the device names of interest.
 // This function will return an error and stop the pipeline if a non-edgex
 // event is received or if no data is received.
 // For example, data generated by a motor does not get passed to functions only interested in data from a thermostat.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) FilterByDeviceName(parameters map[string]string) interfaces.AppFunction {
-	transform, ok := app.processFilterParameters("FilterByDeviceName", parameters, DeviceNames)
+	transform, ok := app.processFilterParameters("FilterByDeviceName", parameters, DeviceNames, false)
 	if !ok {
 		return nil
 	}
@@ -139,7 +297,7 @@ func (app *Configurable) FilterByDeviceName(parameters map[string]string) interf
 // For example, data generated by a motor does not get passed to functions only interested in data from a thermostat.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) FilterBySourceName(parameters map[string]string) interfaces.AppFunction {
-	transform, ok := app.processFilterParameters("FilterBySourceName", parameters, SourceNames)
+	transform, ok := app.processFilterParameters("FilterBySourceName", parameters, SourceNames, false)
 	if !ok {
 		return nil
 	}
@@ -154,9 +312,11 @@ func (app *Configurable) FilterBySourceName(parameters map[string]string) interf
 // This function will return an error and stop the pipeline if a non-edgex
 // event is received or if no data is received.
 // For example, pressure reading data does not go to functions only interested in motion data.
+// The optional MinValue, MaxValue and EqualValue, with Tolerance, parameters further limit the readings matched to
+// those with a numeric value in the range, so readings can be filtered by threshold.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) FilterByResourceName(parameters map[string]string) interfaces.AppFunction {
-	transform, ok := app.processFilterParameters("FilterByResourceName", parameters, ResourceNames)
+	transform, ok := app.processFilterParameters("FilterByResourceName", parameters, ResourceNames, true)
 	if !ok {
 		return nil
 	}
@@ -166,6 +326,11 @@ func (app *Configurable) FilterByResourceName(parameters map[string]string) inte
 
 // Transform transforms an EdgeX event to XML or JSON based on specified transform type.
 // It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+// For XML the optional EventElement, ReadingElement and ReadingsElement parameters name the elements, the FieldNames
+// parameter, a comma separated list of 'field:name', renames or, with a name of '-', omits fields and the Attributes
+// parameter lists the fields written as attributes.
+// For JSON the optional FieldNaming parameter, camelCase or snake_case, names the fields, the OmitEmpty parameter omits
+// the empty fields and the FlattenTags parameter writes the Event's tags as fields of the Event.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) Transform(parameters map[string]string) interfaces.AppFunction {
 	transformType, ok := parameters[TransformType]
@@ -178,8 +343,27 @@ func (app *Configurable) Transform(parameters map[string]string) interfaces.AppF
 
 	switch strings.ToLower(transformType) {
 	case TransformXml:
+		options, ok := app.processXMLOptions(parameters)
+		if !ok {
+			return nil
+		}
+		if options != nil {
+			transform = transforms.NewConversionWithXMLOptions(*options)
+		}
 		return transform.TransformToXML
 	case TransformJson:
+		options, ok := app.processJSONOptions(parameters)
+		if !ok {
+			return nil
+		}
+		if options != nil {
+			var err error
+			transform, err = transforms.NewConversionWithJSONOptions(*options)
+			if err != nil {
+				app.lc.Errorf("Unable to create JSON Transform: %s", err.Error())
+				return nil
+			}
+		}
 		return transform.TransformToJSON
 	default:
 		app.lc.Errorf(
@@ -191,6 +375,121 @@ func (app *Configurable) Transform(parameters map[string]string) interfaces.AppF
 	}
 }
 
+// processXMLOptions returns the XMLOptions from the optional EventElement, ReadingElement, ReadingsElement,
+// FieldNames and Attributes parameters, or nil if none are set
+func (app *Configurable) processXMLOptions(parameters map[string]string) (*transforms.XMLOptions, bool) {
+	options := transforms.XMLOptions{
+		EventElement:    strings.TrimSpace(parameters[EventElement]),
+		ReadingElement:  strings.TrimSpace(parameters[ReadingElement]),
+		ReadingsElement: strings.TrimSpace(parameters[ReadingsElement]),
+		Attributes:      util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[Attributes], util.SplitComma)),
+	}
+
+	fieldNames := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[FieldNames], util.SplitComma))
+	if len(fieldNames) > 0 {
+		options.FieldNames = make(map[string]string)
+	}
+	for _, fieldName := range fieldNames {
+		names := strings.Split(fieldName, ":")
+		if len(names) != 2 || len(strings.TrimSpace(names[0])) == 0 || len(strings.TrimSpace(names[1])) == 0 {
+			app.lc.Errorf("Bad FieldNames specification format. Expect comma separated list of 'field:name'. Got '%s'", parameters[FieldNames])
+			return nil, false
+		}
+
+		options.FieldNames[strings.TrimSpace(names[0])] = strings.TrimSpace(names[1])
+	}
+
+	if options.EventElement == "" && options.ReadingElement == "" && options.ReadingsElement == "" &&
+		len(options.Attributes) == 0 && len(options.FieldNames) == 0 {
+		return nil, true
+	}
+
+	return &options, true
+}
+
+// processJSONOptions returns the JSONOptions from the optional FieldNaming, OmitEmpty and FlattenTags parameters, or
+// nil if none are set
+func (app *Configurable) processJSONOptions(parameters map[string]string) (*transforms.JSONOptions, bool) {
+	options := transforms.JSONOptions{
+		FieldNaming: strings.TrimSpace(parameters[FieldNaming]),
+	}
+
+	set := options.FieldNaming != ""
+	for parameter, option := range map[string]*bool{OmitEmpty: &options.OmitEmpty, FlattenTags: &options.FlattenTags} {
+		value, ok := parameters[parameter]
+		if !ok {
+			continue
+		}
+
+		var err error
+		*option, err = strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, parameter, err.Error())
+			return nil, false
+		}
+		set = true
+	}
+
+	if !set {
+		return nil, true
+	}
+
+	return &options, true
+}
+
+// FromXML decodes XML documents, such as those published by legacy MES or SCADA systems, into Events.
+// The ProfileName, DeviceName and SourceName parameters are XPath expressions, or quoted literals, selecting the
+// Event's names and the optional Origin parameter selects its origin. The Readings parameter is a comma separated list
+// of 'resourceName:ValueType:xpath' creating a reading of the resource for each value the XPath expression selects,
+// i.e. "temperature:Float64:/Report/Value[@name='temperature']". The ValueType may be empty for String readings.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) FromXML(parameters map[string]string) interfaces.AppFunction {
+	mapping := transforms.XMLEventMapping{
+		ProfileName: strings.TrimSpace(parameters[ProfileName]),
+		DeviceName:  strings.TrimSpace(parameters[DeviceName]),
+		SourceName:  strings.TrimSpace(parameters[SourceName]),
+		Origin:      strings.TrimSpace(parameters[Origin]),
+	}
+
+	for _, reading := range util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[Readings], util.SplitComma)) {
+		// Only split on the first two colons so the XPath expressions may contain namespace prefixes
+		fields := strings.SplitN(reading, ":", 3)
+		if len(fields) != 3 {
+			app.lc.Errorf("Bad Readings specification format. Expect comma separated list of 'resourceName:ValueType:xpath'. Got '%s'", parameters[Readings])
+			return nil
+		}
+
+		mapping.Readings = append(mapping.Readings, transforms.XMLReadingMapping{
+			ResourceName: strings.TrimSpace(fields[0]),
+			ValueType:    strings.TrimSpace(fields[1]),
+			Path:         strings.TrimSpace(fields[2]),
+		})
+	}
+
+	decoder, err := transforms.NewXMLDecoder(mapping)
+	if err != nil {
+		app.lc.Errorf("Unable to create FromXML: %s", err.Error())
+		return nil
+	}
+
+	return decoder.FromXML
+}
+
+// SplitByReading continues the pipeline separately for each of the Event's readings with a copy of the Event
+// containing only that reading, until the Merge function. It will return an error and stop the pipeline if a
+// non-edgex event is received or if no data is received.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SplitByReading(parameters map[string]string) interfaces.AppFunction {
+	return transforms.NewSplitter().SplitByReading
+}
+
+// Merge continues the pipeline with an Event containing the readings split by SplitByReading that reached it.
+// It will return an error and stop the pipeline if no data is received.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Merge(parameters map[string]string) interfaces.AppFunction {
+	return transforms.NewSplitter().Merge
+}
+
 // PushToCore pushes the provided value as an event to CoreData using the device name and reading name that have been set. If validation is turned on in
 // CoreServices then your deviceName and readingName must exist in the CoreMetadata and be properly registered in EdgeX.
 // This function is a configuration function and returns a function pointer.
@@ -256,6 +555,34 @@ func (app *Configurable) PushToCore(parameters map[string]string) interfaces.App
 	return transform.PushToCoreData
 }
 
+// PushEventsToCore pushes the Events built by the pipeline, such as historical aggregates, to Core Data with the Origin
+// timestamps they were given. The optional MaxConcurrent parameter limits the Events pushed concurrently and the
+// optional PersistOnError parameter enables Store and Forward for the Events that failed to be pushed.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) PushEventsToCore(parameters map[string]string) interfaces.AppFunction {
+	var err error
+	maxConcurrent := 0
+	if value, ok := parameters[MaxConcurrent]; ok {
+		maxConcurrent, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || maxConcurrent <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for PushEventsToCore, must be an integer greater than 0", MaxConcurrent)
+			return nil
+		}
+	}
+
+	persistOnError := false
+	if value, ok := parameters[PersistOnError]; ok {
+		persistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+			return nil
+		}
+	}
+
+	transform := transforms.NewCoreDataBackfill(maxConcurrent, persistOnError)
+	return transform.PushEventsToCoreData
+}
+
 // Compress compresses data received as either a string,[]byte, or json.Marshaller using the specified algorithm (GZIP or ZLIB)
 // and returns a base64 encoded string as a []byte.
 // This function is a configuration function and returns a function pointer.
@@ -283,8 +610,35 @@ func (app *Configurable) Compress(parameters map[string]string) interfaces.AppFu
 	}
 }
 
+// Decompress decompresses data received as either a string or []byte, Base64 encoded or not, using the specified
+// algorithm (GZIP or ZLIB) and returns the decompressed data as a []byte.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Decompress(parameters map[string]string) interfaces.AppFunction {
+	algorithm, ok := parameters[Algorithm]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Decompress", Algorithm)
+		return nil
+	}
+
+	transform := transforms.Compression{}
+
+	switch strings.ToLower(algorithm) {
+	case CompressGZIP:
+		return transform.GUnzip
+	case CompressZLIB:
+		return transform.UnZLIB
+	default:
+		app.lc.Errorf(
+			"Invalid decompression algorithm '%s'. Must be '%s' or '%s'",
+			algorithm,
+			CompressGZIP,
+			CompressZLIB)
+		return nil
+	}
+}
+
 // Encrypt encrypts either a string, []byte, or json.Marshaller type using specified encryption
-// algorithm (AES only at this time). It will return a byte[] of the encrypted data.
+// algorithm, AES, AES-GCM or hybrid encryption to a public key. It will return a byte[] of the encrypted data.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) Encrypt(parameters map[string]string) interfaces.AppFunction {
 	algorithm, ok := parameters[Algorithm]
@@ -296,13 +650,14 @@ func (app *Configurable) Encrypt(parameters map[string]string) interfaces.AppFun
 	secretPath := parameters[SecretPath]
 	secretName := parameters[SecretName]
 	encryptionKey := parameters[EncryptionKey]
+	publicKey := parameters[PublicKey]
 
-	// SecretPath & SecretName are optional if EncryptionKey specified
-	// EncryptionKey is optional if SecretPath & SecretName are specified
+	// SecretPath & SecretName are optional if EncryptionKey or PublicKey specified
+	// EncryptionKey and PublicKey are optional if SecretPath & SecretName are specified
 
-	// If EncryptionKey not specified, then SecretPath & SecretName must be specified
-	if len(encryptionKey) == 0 && (len(secretPath) == 0 || len(secretName) == 0) {
-		app.lc.Errorf("Could not find '%s' or '%s' and '%s' in configuration", EncryptionKey, SecretPath, SecretName)
+	// If EncryptionKey or PublicKey not specified, then SecretPath & SecretName must be specified
+	if len(encryptionKey) == 0 && len(publicKey) == 0 && (len(secretPath) == 0 || len(secretName) == 0) {
+		app.lc.Errorf("Could not find '%s', '%s' or '%s' and '%s' in configuration", EncryptionKey, PublicKey, SecretPath, SecretName)
 		return nil
 	}
 
@@ -338,19 +693,104 @@ func (app *Configurable) Encrypt(parameters map[string]string) interfaces.AppFun
 		}
 		app.lc.Error("secretPath / secretKey are required for AES 256 encryption")
 		return nil
+	case EncryptAESGCM:
+		if len(encryptionKey) == 0 && len(secretPath) == 0 {
+			app.lc.Errorf("'%s' or secretPath / secretName are required for AES-GCM encryption", EncryptionKey)
+			return nil
+		}
+		protector := transforms.AESGCMProtection{
+			EncryptionKey: encryptionKey,
+			SecretPath:    secretPath,
+			SecretName:    secretName,
+		}
+		return protector.Encrypt
+	case EncryptHybrid:
+		if len(publicKey) == 0 && len(secretPath) == 0 {
+			app.lc.Errorf("'%s' or secretPath / secretName are required for hybrid encryption", PublicKey)
+			return nil
+		}
+		protector := transforms.HybridProtection{
+			PublicKey:  publicKey,
+			SecretPath: secretPath,
+			SecretName: secretName,
+		}
+		return protector.Encrypt
 	default:
 		app.lc.Errorf(
-			"Invalid encryption algorithm '%s'. Must be one of '%s', '%s",
+			"Invalid encryption algorithm '%s'. Must be one of '%s', '%s', '%s', '%s'",
 			algorithm,
 			EncryptAES,
-			EncryptAES256)
+			EncryptAES256,
+			EncryptAESGCM,
+			EncryptHybrid)
+		return nil
+	}
+}
+
+// Decrypt decrypts data received as either a string or []byte, Base64 encoded or not, that was encrypted using the
+// specified algorithm (AES256 or AES-GCM) and returns the decrypted data as a []byte. The key is the Key parameter,
+// for AES-GCM only, or the secret at the SecretPath and SecretName in the Secret Store.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Decrypt(parameters map[string]string) interfaces.AppFunction {
+	algorithm, ok := parameters[Algorithm]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Decrypt", Algorithm)
+		return nil
+	}
+
+	secretPath := parameters[SecretPath]
+	secretName := parameters[SecretName]
+	encryptionKey := parameters[EncryptionKey]
+
+	// SecretPath & SecretName both must be specified it one of them is.
+	if (len(secretPath) != 0 && len(secretName) == 0) || (len(secretPath) == 0 && len(secretName) != 0) {
+		app.lc.Errorf("'%s' and '%s' both must be set in configuration", SecretPath, SecretName)
+		return nil
+	}
+
+	switch strings.ToLower(algorithm) {
+	case EncryptAES256:
+		if len(secretPath) == 0 {
+			app.lc.Error("secretPath / secretName are required for AES 256 decryption")
+			return nil
+		}
+		protector := transforms.NewAESProtection(secretPath, secretName)
+		return protector.Decrypt
+	case EncryptAESGCM:
+		if len(encryptionKey) == 0 && len(secretPath) == 0 {
+			app.lc.Errorf("'%s' or secretPath / secretName are required for AES-GCM decryption", EncryptionKey)
+			return nil
+		}
+		protector := transforms.AESGCMProtection{
+			EncryptionKey: encryptionKey,
+			SecretPath:    secretPath,
+			SecretName:    secretName,
+		}
+		return protector.Decrypt
+	default:
+		app.lc.Errorf(
+			"Invalid decryption algorithm '%s'. Must be '%s' or '%s'",
+			algorithm,
+			EncryptAES256,
+			EncryptAESGCM)
 		return nil
 	}
 }
 
 // HTTPExport will send data from the previous function to the specified Endpoint via http POST or PUT. If no previous function exists,
 // then the event that triggered the pipeline will be used. Passing an empty string to the mimetype
-// method will default to application/json.
+// method will default to application/json. The Url may contain placeholders replaced with the context's values or
+// tags for each message, i.e. "http://host/devices/{deviceName}/events/{eventId}". The optional ReceiptHeader parameter is the response header containing
+// the destination's receipt id, which is required to acknowledge the delivery when delivery receipts are tracked.
+// The optional ChunkSize parameter splits the data into chunks of at most that many bytes, each sent separately,
+// for the receiving app service to reassemble with ReassembleChunks. The optional FailureThreshold parameter opens a
+// circuit breaker after that many consecutive failures, skipping the exports until the optional ProbeInterval, 30s by
+// default, has passed. The optional Headers parameter is a comma separated list of 'key:value' sent with each request,
+// whose values may contain placeholders replaced with the context's values or tags, i.e. "X-Site:{site}". The optional
+// AuthMode parameter, "bearer" or "basic", sends the Authorization header with the "token", or "username" and
+// "password", secrets at AuthSecretPath. The optional TLSSecretPath parameter is the path of the "cacert" secret used
+// to verify the destination, and the optional "clientcert" and "clientkey" secrets used for mutual TLS. The optional
+// SkipVerify parameter disables the verification of the destination's certificate.
 // This function is a configuration function and returns a function pointer.
 func (app *Configurable) HTTPExport(parameters map[string]string) interfaces.AppFunction {
 	options, method, err := app.processHttpExportParameters(parameters)
@@ -359,13 +799,30 @@ func (app *Configurable) HTTPExport(parameters map[string]string) interfaces.App
 		return nil
 	}
 
+	breaker, ok := app.processCircuitBreaker("HTTPExport", parameters)
+	if !ok {
+		return nil
+	}
+	options.CircuitBreaker = breaker
+
+	chunkSize, ok := app.processChunkSize("HTTPExport", parameters)
+	if !ok {
+		return nil
+	}
+
+	persistOnError := options.PersistOnError
+	if chunkSize > 0 {
+		// The chunker persists the complete payload rather than the chunk
+		options.PersistOnError = false
+	}
+
 	transform := transforms.NewHTTPSenderWithOptions(options)
 
 	switch strings.ToLower(method) {
 	case ExportMethodPost:
-		return transform.HTTPPost
+		return app.chunked(chunkSize, persistOnError, transform.HTTPPost)
 	case ExportMethodPut:
-		return transform.HTTPPut
+		return app.chunked(chunkSize, persistOnError, transform.HTTPPut)
 	default:
 		app.lc.Errorf(
 			"Invalid HTTPExport method of '%s'. Must be '%s' or '%s'",
@@ -376,336 +833,1466 @@ func (app *Configurable) HTTPExport(parameters map[string]string) interfaces.App
 	}
 }
 
-//
-// MQTTExport will send data from the previous function to the specified Endpoint via MQTT publish. If no previous function exists,
-// then the event that triggered the pipeline will be used.
+// HTTPExportWithFailover will send data from the previous function to the first of the specified Url and the
+// optional comma separated FallbackUrls that accepts it via http POST or PUT, and finally to a new file in the
+// optional FileDirectory when all the URLs fail. The MimeType, header and secret parameters are the same as
+// HTTPExport and apply to all the URLs. PersistOnError enables use of store & forward when all destinations fail.
+// ContinueOnSendError and ReturnInputData are not supported.
 // This function is a configuration function and returns a function pointer.
-func (app *Configurable) MQTTExport(parameters map[string]string) interfaces.AppFunction {
-	var err error
-	qos := 0
-	retain := false
-	autoReconnect := false
-	skipCertVerify := false
-
-	brokerAddress, ok := parameters[BrokerAddress]
-	if !ok {
-		app.lc.Error("Could not find " + BrokerAddress)
+func (app *Configurable) HTTPExportWithFailover(parameters map[string]string) interfaces.AppFunction {
+	options, method, err := app.processHttpExportParameters(parameters)
+	if err != nil {
+		app.lc.Error(err.Error())
 		return nil
 	}
-	topic, ok := parameters[Topic]
-	if !ok {
-		app.lc.Error("Could not find " + Topic)
+
+	if options.ContinueOnSendError || options.ReturnInputData {
+		app.lc.Errorf("HTTPExportWithFailover does not support '%s' or '%s'", ContinueOnSendError, ReturnInputData)
 		return nil
 	}
 
-	secretPath, ok := parameters[SecretPath]
-	if !ok {
-		app.lc.Error("Could not find " + SecretPath)
+	method = strings.ToLower(method)
+	if method != ExportMethodPost && method != ExportMethodPut {
+		app.lc.Errorf(
+			"Invalid HTTPExportWithFailover method of '%s'. Must be '%s' or '%s'",
+			method,
+			ExportMethodPost,
+			ExportMethodPut)
 		return nil
 	}
-	authMode, ok := parameters[AuthMode]
-	if !ok {
-		app.lc.Error("Could not find " + AuthMode)
-		return nil
+
+	persistOnError := options.PersistOnError
+	// The destinations don't persist on error so the next destination is tried
+	options.PersistOnError = false
+
+	urls := append([]string{options.URL}, util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[FallbackUrls], util.SplitComma))...)
+	var destinations []transforms.FailoverDestination
+	for _, url := range urls {
+		options.URL = url
+		sender := transforms.NewHTTPSenderWithOptions(options)
+		export := sender.HTTPPost
+		if method == ExportMethodPut {
+			export = sender.HTTPPut
+		}
+		destinations = append(destinations, transforms.FailoverDestination{Name: url, Export: export})
 	}
-	clientID, ok := parameters[ClientID]
-	if !ok {
-		app.lc.Error("Could not find " + ClientID)
+
+	if directory := strings.TrimSpace(parameters[FileDirectory]); len(directory) > 0 {
+		destinations = append(destinations, transforms.FailoverDestination{Name: directory, Export: transforms.NewFileSender(directory).Write})
+	}
+
+	transform := transforms.NewFailoverExporter(persistOnError, destinations...)
+	return transform.Export
+}
+
+// HTTPBatchPost will send the batch from the previous function, i.e. Batch, to the specified Url in a single http
+// POST request. The optional Format parameter is "json", the default, to send the batch as a JSON array or "ndjson" to
+// send it as newline delimited JSON. The optional Compress parameter enables gzip encoding of the request body. A
+// request refused with a 429 or 503 status is sent again after its Retry-After delay, up to the optional Retries
+// parameter, 3 by default, when the delay is no longer than the optional MaxRetryAfter parameter, 1m by default.
+// PersistOnError enables use of store & forward for the whole batch on error. The header, secret, auth, TLS and
+// circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) HTTPBatchPost(parameters map[string]string) interfaces.AppFunction {
+	options := transforms.HTTPBatchOptions{}
+
+	options.URL = strings.TrimSpace(parameters[Url])
+	if len(options.URL) == 0 {
+		app.lc.Errorf("Could not find '%s' parameter for HTTPBatchPost", Url)
 		return nil
 	}
-	qosVal, ok := parameters[Qos]
-	if ok {
-		qos, err = strconv.Atoi(qosVal)
+
+	if value, ok := parameters[PersistOnError]; ok {
+		var err error
+		options.PersistOnError, err = strconv.ParseBool(value)
 		if err != nil {
-			app.lc.Error("Unable to parse " + Qos + " value")
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
 			return nil
 		}
 	}
-	retainVal, ok := parameters[Retain]
-	if ok {
-		retain, err = strconv.ParseBool(retainVal)
+
+	if value, ok := parameters[CompressBatch]; ok {
+		var err error
+		options.Compress, err = strconv.ParseBool(value)
 		if err != nil {
-			app.lc.Error("Unable to parse " + Retain + " value")
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, CompressBatch, err.Error())
 			return nil
 		}
 	}
-	autoreconnectVal, ok := parameters[AutoReconnect]
-	if ok {
-		autoReconnect, err = strconv.ParseBool(autoreconnectVal)
-		if err != nil {
-			app.lc.Error("Unable to parse " + AutoReconnect + " value")
-			return nil
-		}
+
+	if err := processHttpRequestParameters("HTTPBatchPost", parameters, &options.HTTPSenderOptions); err != nil {
+		app.lc.Error(err.Error())
+		return nil
 	}
-	skipVerifyVal, ok := parameters[SkipVerify]
-	if ok {
-		skipCertVerify, err = strconv.ParseBool(skipVerifyVal)
-		if err != nil {
-			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", skipVerifyVal, SkipVerify, err.Error())
+
+	if value := strings.TrimSpace(parameters[Retries]); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for HTTPBatchPost, must be an integer greater than or equal to 0", Retries)
 			return nil
 		}
+		// The sender retries by default, so no retries are requested with a negative number
+		options.Retries = retries
+		if retries == 0 {
+			options.Retries = -1
+		}
 	}
 
-	// These are optional and blank values result in MQTT defaults being used.
-	keepAlive := parameters[KeepAlive]
-	connectTimeout := parameters[ConnectTimeout]
-
-	mqttConfig := transforms.MQTTSecretConfig{
-		Retain:         retain,
-		SkipCertVerify: skipCertVerify,
-		AutoReconnect:  autoReconnect,
-		ConnectTimeout: connectTimeout,
-		KeepAlive:      keepAlive,
-		QoS:            byte(qos),
-		BrokerAddress:  brokerAddress,
-		ClientId:       clientID,
-		SecretPath:     secretPath,
-		Topic:          topic,
-		AuthMode:       authMode,
-	}
-	// PersistOnError is optional and is false by default.
-	persistOnError := false
-	value, ok := parameters[PersistOnError]
-	if ok {
-		persistOnError, err = strconv.ParseBool(value)
-		if err != nil {
-			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+	if value := strings.TrimSpace(parameters[MaxRetryAfter]); value != "" {
+		maxRetryAfter, err := time.ParseDuration(value)
+		if err != nil || maxRetryAfter <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for HTTPBatchPost, must be a duration greater than 0, i.e. 30s", MaxRetryAfter)
 			return nil
 		}
+		options.MaxRetryAfter = maxRetryAfter
 	}
-	transform := transforms.NewMQTTSecretSender(mqttConfig, persistOnError)
-	return transform.MQTTSend
-}
 
-// SetResponseData sets the response data to that passed in from the previous function and the response content type
-// to that set in the ResponseContentType configuration parameter. It will return an error and stop the pipeline if
-// data passed in is not of type []byte, string or json.Marshaller
-// This function is a configuration function and returns a function pointer.
-func (app *Configurable) SetResponseData(parameters map[string]string) interfaces.AppFunction {
-	transform := transforms.ResponseData{}
+	breaker, ok := app.processCircuitBreaker("HTTPBatchPost", parameters)
+	if !ok {
+		return nil
+	}
+	options.CircuitBreaker = breaker
+	options.Format = parameters[BatchFormat]
 
-	value, ok := parameters[ResponseContentType]
-	if ok && len(value) > 0 {
-		transform.ResponseContentType = value
+	transform, err := transforms.NewHTTPBatchSender(options)
+	if err != nil {
+		app.lc.Errorf("Unable to create HTTPBatchPost: %s", err.Error())
+		return nil
 	}
 
-	return transform.SetResponseData
+	return transform.HTTPBatchPost
 }
 
-// Batch sets up Batching of events based on the specified mode parameter (BatchByCount, BatchByTime or BatchByTimeAndCount)
-// and mode specific parameters.
+// GCPPubSubExport will publish data from the previous function to the Google Cloud Pub/Sub Topic of the ProjectId,
+// authenticated with a JWT signed by the service account's "privatekey" and "clientemail" secrets at SecretPath. The
+// Topic and the optional OrderingKey parameter, i.e. "{device}" so each device's messages are delivered in order, may
+// contain placeholders. The optional Endpoint parameter replaces the Pub/Sub API, i.e. with the Pub/Sub emulator.
+// PersistOnError enables use of store & forward on error, and the circuit breaker parameters are the same as
+// HTTPExport.
 // This function is a configuration function and returns a function pointer.
-func (app *Configurable) Batch(parameters map[string]string) interfaces.AppFunction {
-	mode, ok := parameters[Mode]
-	if !ok {
-		app.lc.Errorf("Could not find '%s' parameter for Batch", Mode)
-		return nil
+func (app *Configurable) GCPPubSubExport(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.GCPPubSubConfig{
+		ProjectId:   parameters[ProjectId],
+		Topic:       parameters[Topic],
+		OrderingKey: strings.TrimSpace(parameters[OrderingKey]),
+		SecretPath:  parameters[SecretPath],
+		Endpoint:    parameters[Endpoint],
 	}
 
-	var err error
-	var transform *transforms.BatchConfig
-	switch strings.ToLower(mode) {
-	case BatchByCount:
-		batchThreshold, ok := parameters[BatchThreshold]
-		if !ok {
-			app.lc.Errorf("Could not find '%s' parameter for BatchByCount", BatchThreshold)
+	if value, ok := parameters[PersistOnError]; ok {
+		var err error
+		config.PersistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
 			return nil
 		}
+	}
 
-		thresholdValue, err := strconv.Atoi(batchThreshold)
-		if err != nil {
-			app.lc.Errorf(
-				"Could not parse '%s' to an int for '%s' parameter for BatchByCount: %s",
-				batchThreshold, BatchThreshold, err.Error())
+	breaker, ok := app.processCircuitBreaker("GCPPubSubExport", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewGCPPubSubSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create GCPPubSubExport: %s", err.Error())
+		return nil
+	}
+
+	return transform.PublishToPubSub
+}
+
+// GCPIoTCoreExport will publish data from the previous function as a telemetry event of the DeviceId, in the
+// RegistryId of the ProjectId and Region, to the Google Cloud IoT Core MQTT bridge, authenticated with a JWT signed by
+// the device's "privatekey" secret at SecretPath. The optional SubFolder parameter, which may contain placeholders, is
+// appended to the events topic. The optional BrokerAddress, Qos (0 or 1), KeepAlive, ConnectTimeout and TokenLifetime,
+// 1h by default, parameters configure the connection. PersistOnError enables use of store & forward on error, and the
+// circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) GCPIoTCoreExport(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.GCPIoTCoreConfig{
+		ProjectId:      strings.TrimSpace(parameters[ProjectId]),
+		Region:         strings.TrimSpace(parameters[Region]),
+		RegistryId:     strings.TrimSpace(parameters[RegistryId]),
+		DeviceId:       strings.TrimSpace(parameters[DeviceId]),
+		SecretPath:     strings.TrimSpace(parameters[SecretPath]),
+		BrokerAddress:  strings.TrimSpace(parameters[BrokerAddress]),
+		SubFolder:      strings.TrimSpace(parameters[SubFolder]),
+		KeepAlive:      parameters[KeepAlive],
+		ConnectTimeout: parameters[ConnectTimeout],
+	}
+
+	if value := strings.TrimSpace(parameters[Qos]); value != "" {
+		qos, err := strconv.Atoi(value)
+		if err != nil || qos < 0 || qos > 1 {
+			app.lc.Errorf("Invalid '%s' parameter for GCPIoTCoreExport, must be 0 or 1", Qos)
 			return nil
 		}
+		config.QoS = byte(qos)
+	}
 
-		transform, err = transforms.NewBatchByCount(thresholdValue)
+	if value := strings.TrimSpace(parameters[TokenLifetime]); value != "" {
+		lifetime, err := time.ParseDuration(value)
+		if err != nil || lifetime <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for GCPIoTCoreExport, must be a duration greater than 0, i.e. 1h", TokenLifetime)
+			return nil
+		}
+		config.TokenLifetime = lifetime
+	}
+
+	if value, ok := parameters[PersistOnError]; ok {
+		var err error
+		config.PersistOnError, err = strconv.ParseBool(value)
 		if err != nil {
-			app.lc.Error(err.Error())
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
 			return nil
 		}
+	}
 
-	case BatchByTime:
-		timeInterval, ok := parameters[TimeInterval]
+	breaker, ok := app.processCircuitBreaker("GCPIoTCoreExport", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewGCPIoTCoreSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create GCPIoTCoreExport: %s", err.Error())
+		return nil
+	}
+
+	return transform.PublishToIoTCore
+}
+
+// ObjectStorageExport will write data from the previous function to a new object in the Bucket of S3-compatible
+// object storage, signed with the "accesskeyid" and "secretaccesskey" secrets at SecretPath. The ObjectKey parameter is
+// a template, i.e. "{date}/{device}/{uuid}.json", with the {date}, {year}, {month}, {day}, {hour}, {timestamp} and
+// {uuid} placeholders as well as the Event's names, context values and tags. The optional Endpoint parameter, i.e.
+// "http://minio:9000", defaults to AWS S3 in the optional Region, and the optional PathStyle parameter addresses the
+// bucket in the path as most S3-compatible services require. The optional Format parameter, "json" or "ndjson",
+// combines a batch from the Batch function into a single object, and the optional Compress parameter enables gzip
+// encoding. The optional MimeType parameter is the objects' content type. PersistOnError enables use of store &
+// forward on error, and the circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ObjectStorageExport(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.ObjectStorageConfig{
+		Endpoint:    parameters[Endpoint],
+		Region:      parameters[Region],
+		Bucket:      parameters[Bucket],
+		KeyTemplate: parameters[ObjectKey],
+		SecretPath:  parameters[SecretPath],
+		ContentType: strings.TrimSpace(parameters[MimeType]),
+		Format:      parameters[BatchFormat],
+	}
+
+	for name, target := range map[string]*bool{
+		PathStyle:      &config.PathStyle,
+		CompressBatch:  &config.Compress,
+		PersistOnError: &config.PersistOnError,
+	} {
+		value, ok := parameters[name]
 		if !ok {
-			app.lc.Errorf("Could not find '%s' parameter for BatchByTime", TimeInterval)
-			return nil
+			continue
 		}
 
-		transform, err = transforms.NewBatchByTime(timeInterval)
+		var err error
+		*target, err = strconv.ParseBool(value)
 		if err != nil {
-			app.lc.Error(err.Error())
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
 			return nil
 		}
+	}
 
-	case BatchByTimeAndCount:
-		timeInterval, ok := parameters[TimeInterval]
+	breaker, ok := app.processCircuitBreaker("ObjectStorageExport", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewObjectStorageSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create ObjectStorageExport: %s", err.Error())
+		return nil
+	}
+
+	return transform.PutObject
+}
+
+// AzureBlobExport will write data from the previous function to a new block blob, or append it to an append blob, in
+// the Container of the Azure Blob Storage, or Data Lake Storage Gen2, account at AccountURL. The BlobName parameter is
+// a template with the same placeholders as ObjectStorageExport's ObjectKey, i.e. "{device}/{date}.ndjson" for
+// continuous telemetry files. The optional BlobType parameter is "block", the default, or "append". The optional
+// AuthMode parameter is "sas", the default, using the "sastoken" secret at SecretPath, or "managedidentity" using the
+// optional ClientId of a user-assigned identity and IdentityEndpoint. The optional Format, Compress and MimeType
+// parameters are the same as ObjectStorageExport. PersistOnError enables use of store & forward on error, and the
+// circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) AzureBlobExport(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.AzureBlobConfig{
+		AccountURL:       parameters[AccountURL],
+		Container:        parameters[Container],
+		BlobTemplate:     parameters[BlobName],
+		BlobType:         parameters[BlobType],
+		AuthMode:         parameters[AuthMode],
+		SecretPath:       parameters[SecretPath],
+		ClientId:         parameters[ClientID],
+		IdentityEndpoint: strings.TrimSpace(parameters[IdentityEndpoint]),
+		ContentType:      strings.TrimSpace(parameters[MimeType]),
+		Format:           parameters[BatchFormat],
+	}
+
+	for name, target := range map[string]*bool{
+		CompressBatch:  &config.Compress,
+		PersistOnError: &config.PersistOnError,
+	} {
+		value, ok := parameters[name]
 		if !ok {
-			app.lc.Error("Could not find " + TimeInterval)
+			continue
+		}
+
+		var err error
+		*target, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
 			return nil
 		}
-		batchThreshold, ok := parameters[BatchThreshold]
-		if !ok {
-			app.lc.Error("Could not find " + BatchThreshold)
+	}
+
+	breaker, ok := app.processCircuitBreaker("AzureBlobExport", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewAzureBlobSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create AzureBlobExport: %s", err.Error())
+		return nil
+	}
+
+	return transform.PutBlob
+}
+
+// PostgresWrite will write the readings of the Event, or batch of Events, from the previous function to the Table of
+// the Postgres or TimescaleDB Database at Host, one row per reading, using the "username" and "password" secrets at
+// SecretPath. The optional Columns parameter is a comma separated list of 'field:column' mapping the device, profile,
+// source, name, value, valuetype, origin and tags fields to the table's columns, otherwise the device, name, value,
+// origin and tags fields are written to the columns of the same names. The rows are inserted with multi-row INSERT
+// statements of up to the optional BatchSize, 500 by default, or COPY when the optional UseCopy parameter is true. The
+// optional Port and SSLMode parameters configure the connection. PersistOnError enables use of store & forward on
+// error, and the circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) PostgresWrite(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.PostgresWriterConfig{
+		Host:       parameters[Host],
+		Database:   parameters[Database],
+		SSLMode:    parameters[SSLMode],
+		SecretPath: parameters[SecretPath],
+		Table:      parameters[Table],
+	}
+
+	if value := strings.TrimSpace(parameters[Port]); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil || port <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for PostgresWrite, must be an integer greater than 0", Port)
 			return nil
 		}
-		thresholdValue, err := strconv.Atoi(batchThreshold)
-		if err != nil {
-			app.lc.Errorf("Could not parse '%s' to an int for '%s' parameter: %s", batchThreshold, BatchThreshold, err.Error())
+		config.Port = port
+	}
+
+	if value := strings.TrimSpace(parameters[BatchSize]); value != "" {
+		batchSize, err := strconv.Atoi(value)
+		if err != nil || batchSize <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for PostgresWrite, must be an integer greater than 0", BatchSize)
 			return nil
 		}
+		config.BatchSize = batchSize
+	}
 
-		transform, err = transforms.NewBatchByTimeAndCount(timeInterval, thresholdValue)
+	if spec := strings.TrimSpace(parameters[Columns]); spec != "" {
+		config.Columns = make(map[string]string)
+		for _, mapping := range util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma)) {
+			fieldColumn := strings.Split(mapping, ":")
+			if len(fieldColumn) != 2 {
+				app.lc.Errorf("Bad Columns specification format. Expect comma separated list of 'field:column'. Got '%s'", spec)
+				return nil
+			}
+			config.Columns[strings.ToLower(strings.TrimSpace(fieldColumn[0]))] = strings.TrimSpace(fieldColumn[1])
+		}
+	}
+
+	for name, target := range map[string]*bool{
+		UseCopy:        &config.UseCopy,
+		PersistOnError: &config.PersistOnError,
+	} {
+		value, ok := parameters[name]
+		if !ok {
+			continue
+		}
+
+		var err error
+		*target, err = strconv.ParseBool(value)
 		if err != nil {
-			app.lc.Error(err.Error())
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
 			return nil
 		}
+	}
 
-	default:
-		app.lc.Errorf(
-			"Invalid batch mode '%s'. Must be '%s', '%s' or '%s'",
-			mode,
-			BatchByCount,
-			BatchByTime,
-			BatchByTimeAndCount)
+	breaker, ok := app.processCircuitBreaker("PostgresWrite", parameters)
+	if !ok {
 		return nil
 	}
+	config.CircuitBreaker = breaker
 
-	// IsEventData is optional
-	isEventDataValue, ok := parameters[IsEventData]
-	if ok {
-		isEventData, err := strconv.ParseBool(isEventDataValue)
-		if err != nil {
-			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", isEventDataValue, isEventData, err.Error())
+	transform, err := transforms.NewPostgresWriter(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create PostgresWrite: %s", err.Error())
+		return nil
+	}
+
+	return transform.PostgresWrite
+}
+
+// OPCUAWrite will write the values of the readings of the Event, or batch of Events, from the previous function to
+// the nodes of the OPC-UA server at Endpoint, i.e. to update PLC or SCADA tags. The Nodes parameter is a comma
+// separated list of 'reading:nodeid', the reading being 'device/resource' or 'resource' for all the devices, i.e.
+// 'pump-1/speed:ns=2;s=Pump1.Speed', and the readings not mapped are skipped. The optional SecurityPolicy and
+// SecurityMode parameters select the server's endpoint, None by default, and the optional SecretPath holds the
+// "username" and "password" secrets, and the "clientcert" and "clientkey" secrets required by the Sign and
+// SignAndEncrypt modes. The optional WriteTimeout parameter is the connection and request timeout, 10s by default.
+// PersistOnError enables use of store & forward on error, and the circuit breaker parameters are the same as
+// HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) OPCUAWrite(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.OPCUAWriterConfig{
+		Endpoint:       parameters[Endpoint],
+		SecurityPolicy: parameters[SecurityPolicy],
+		SecurityMode:   parameters[SecurityMode],
+		SecretPath:     parameters[SecretPath],
+	}
+
+	spec := strings.TrimSpace(parameters[Nodes])
+	if spec == "" {
+		app.lc.Errorf("Could not find '%s' parameter for OPCUAWrite", Nodes)
+		return nil
+	}
+
+	// Node ids contain semicolons but no commas, and the reading is split on the first colon as node ids contain colons
+	config.Nodes = make(map[string]string)
+	for _, mapping := range util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma)) {
+		readingNode := strings.SplitN(mapping, ":", 2)
+		if len(readingNode) != 2 || strings.TrimSpace(readingNode[0]) == "" {
+			app.lc.Errorf("Bad Nodes specification format. Expect comma separated list of 'reading:nodeid'. Got '%s'", spec)
 			return nil
 		}
+		config.Nodes[strings.TrimSpace(readingNode[0])] = strings.TrimSpace(readingNode[1])
+	}
 
-		transform.IsEventData = isEventData
+	if value := strings.TrimSpace(parameters[WriteTimeout]); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for OPCUAWrite, must be a duration greater than 0, i.e. 10s", WriteTimeout)
+			return nil
+		}
+		config.Timeout = timeout
 	}
 
-	return transform.Batch
-}
+	if value, ok := parameters[PersistOnError]; ok {
+		var err error
+		config.PersistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+			return nil
+		}
+	}
 
-// JSONLogic ...
-func (app *Configurable) JSONLogic(parameters map[string]string) interfaces.AppFunction {
-	rule, ok := parameters[Rule]
+	breaker, ok := app.processCircuitBreaker("OPCUAWrite", parameters)
 	if !ok {
-		app.lc.Error("Could not find " + Rule)
 		return nil
 	}
+	config.CircuitBreaker = breaker
 
-	transform := transforms.NewJSONLogic(rule)
-	return transform.Evaluate
+	transform, err := transforms.NewOPCUAWriter(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create OPCUAWrite: %s", err.Error())
+		return nil
+	}
+
+	return transform.WriteNodes
 }
 
-// AddTags adds the configured list of tags to Events passed to the transform.
+// EmailNotification will email the data from the previous function, i.e. an alert, to the comma separated list of
+// addresses of the To parameter from the From address via the SMTP server at Host. The Subject parameter and the body
+// are templates rendered with the data as TransformWithTemplate, the body being given inline by the Template parameter
+// or read from the ApplicationSettings entry named by the TemplateSetting parameter, otherwise the data is sent as JSON.
+// The optional Port parameter is 587 by default, the optional ImplicitTLS parameter connects with TLS, i.e. to port
+// 465, rather than STARTTLS, and the optional MimeType parameter is "text/plain", the default, or "text/html". The
+// optional SecretPath parameter holds the "username" and "password" secrets to authenticate. PersistOnError enables
+// use of store & forward on error, and the circuit breaker parameters are the same as HTTPExport.
 // This function is a configuration function and returns a function pointer.
-func (app *Configurable) AddTags(parameters map[string]string) interfaces.AppFunction {
-	tagsSpec, ok := parameters[Tags]
+func (app *Configurable) EmailNotification(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.EmailConfig{
+		Host:        parameters[Host],
+		From:        strings.TrimSpace(parameters[From]),
+		To:          util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[To], util.SplitComma)),
+		Subject:     parameters[Subject],
+		ContentType: strings.TrimSpace(parameters[MimeType]),
+		SecretPath:  strings.TrimSpace(parameters[SecretPath]),
+	}
+
+	body, ok := app.notificationBody("EmailNotification", parameters)
 	if !ok {
-		app.lc.Error(fmt.Sprintf("Could not find '%s' parameter", Tags))
 		return nil
 	}
+	config.Body = body
 
-	tagKeyValues := util.DeleteEmptyAndTrim(strings.FieldsFunc(tagsSpec, util.SplitComma))
-
-	tags := make(map[string]interface{})
-	for _, tag := range tagKeyValues {
-		keyValue := util.DeleteEmptyAndTrim(strings.FieldsFunc(tag, util.SplitColon))
-		if len(keyValue) != 2 {
-			app.lc.Errorf("Bad Tags specification format. Expect comma separated list of 'key:value'. Got `%s`", tagsSpec)
+	if value := strings.TrimSpace(parameters[Port]); value != "" {
+		port, err := strconv.Atoi(value)
+		if err != nil || port <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for EmailNotification, must be an integer greater than 0", Port)
 			return nil
 		}
+		config.Port = port
+	}
 
-		if len(keyValue[0]) == 0 {
-			app.lc.Errorf("Tag key missing. Got '%s'", tag)
-			return nil
+	for name, target := range map[string]*bool{
+		ImplicitTLS:    &config.ImplicitTLS,
+		PersistOnError: &config.PersistOnError,
+	} {
+		value, ok := parameters[name]
+		if !ok {
+			continue
 		}
-		if len(keyValue[1]) == 0 {
-			app.lc.Errorf("Tag value missing. Got '%s'", tag)
+
+		var err error
+		*target, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
 			return nil
 		}
+	}
 
-		tags[keyValue[0]] = keyValue[1]
+	breaker, ok := app.processCircuitBreaker("EmailNotification", parameters)
+	if !ok {
+		return nil
 	}
+	config.CircuitBreaker = breaker
 
-	transform := transforms.NewGenericTags(tags)
-	return transform.AddTags
+	transform, err := transforms.NewEmailSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create EmailNotification: %s", err.Error())
+		return nil
+	}
+
+	return transform.SendEmail
 }
 
-func (app *Configurable) processFilterParameters(
-	funcName string,
-	parameters map[string]string,
-	paramName string) (*transforms.Filter, bool) {
-	names, ok := parameters[paramName]
+// WebhookNotification will POST the body rendered from the data from the previous function, i.e. an alert, to the
+// webhook at the specified Url, i.e. a chat or incident management service. The body is a template rendered with the
+// data as TransformWithTemplate, given inline by the Template parameter or read from the ApplicationSettings entry
+// named by the TemplateSetting parameter, otherwise the data is sent as JSON. The optional MimeType parameter is
+// application/json by default. The data is passed on so notifications can be chained. PersistOnError enables use of
+// store & forward on error, and the header, secret, auth, TLS and circuit breaker parameters are the same as
+// HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) WebhookNotification(parameters map[string]string) interfaces.AppFunction {
+	options := transforms.HTTPSenderOptions{
+		URL:      strings.TrimSpace(parameters[Url]),
+		MimeType: strings.TrimSpace(parameters[MimeType]),
+	}
+	if len(options.URL) == 0 {
+		app.lc.Errorf("Could not find '%s' parameter for WebhookNotification", Url)
+		return nil
+	}
+
+	body, ok := app.notificationBody("WebhookNotification", parameters)
 	if !ok {
-		app.lc.Errorf("Could not find '%s' parameter for %s", paramName, funcName)
-		return nil, false
+		return nil
 	}
 
-	filterOutBool := false
-	filterOut, ok := parameters[FilterOut]
-	if ok {
+	if value, ok := parameters[PersistOnError]; ok {
 		var err error
-		filterOutBool, err = strconv.ParseBool(filterOut)
+		options.PersistOnError, err = strconv.ParseBool(value)
 		if err != nil {
-			app.lc.Errorf("Could not convert filterOut value `%s` to bool for %s", filterOut, funcName)
-			return nil, false
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+			return nil
 		}
 	}
 
-	namesCleaned := util.DeleteEmptyAndTrim(strings.FieldsFunc(names, util.SplitComma))
-	transform := transforms.Filter{
-		FilterValues: namesCleaned,
-		FilterOut:    filterOutBool,
+	if err := processHttpRequestParameters("WebhookNotification", parameters, &options); err != nil {
+		app.lc.Error(err.Error())
+		return nil
 	}
 
-	return &transform, true
-}
+	breaker, ok := app.processCircuitBreaker("WebhookNotification", parameters)
+	if !ok {
+		return nil
+	}
+	options.CircuitBreaker = breaker
 
-func (app *Configurable) processHttpExportParameters(
-	parameters map[string]string) (transforms.HTTPSenderOptions, string, error) {
+	transform, err := transforms.NewWebhookSender(body, options)
+	if err != nil {
+		app.lc.Errorf("Unable to create WebhookNotification: %s", err.Error())
+		return nil
+	}
 
-	result := transforms.HTTPSenderOptions{}
+	return transform.PostWebhook
+}
 
-	method, ok := parameters[ExportMethod]
-	if !ok {
-		return result, "", fmt.Errorf("HTTPExport Could not find %s", ExportMethod)
+// SMSNotification will text the body rendered from the data from the previous function, i.e. an alert, to the comma
+// separated list of phone numbers of the To parameter via Twilio, from the Twilio phone number, or messaging service
+// sid, of the From parameter with the Twilio AccountSid. The body is a template rendered with the data as
+// TransformWithTemplate, given inline by the Template parameter or read from the ApplicationSettings entry named by
+// the TemplateSetting parameter, otherwise the data is sent as JSON. The SecretPath parameter holds the "authtoken"
+// secret, and the optional Url parameter overrides the Twilio API's URL. PersistOnError enables use of store &
+// forward on error, and the circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SMSNotification(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.SMSConfig{
+		AccountSid: strings.TrimSpace(parameters[AccountSid]),
+		From:       strings.TrimSpace(parameters[From]),
+		To:         util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[To], util.SplitComma)),
+		SecretPath: strings.TrimSpace(parameters[SecretPath]),
+		URL:        strings.TrimSpace(parameters[Url]),
 	}
 
-	result.URL, ok = parameters[Url]
-	if !ok {
-		return result, "", fmt.Errorf("HTTPExport Could not find %s", Url)
-	}
-	result.MimeType, ok = parameters[MimeType]
+	body, ok := app.notificationBody("SMSNotification", parameters)
 	if !ok {
-		return result, "", fmt.Errorf("HTTPExport Could not find %s", MimeType)
+		return nil
 	}
+	config.Body = body
 
-	// PersistOnError is optional and is false by default.
-	var value string
-	result.PersistOnError = false
-	value, ok = parameters[PersistOnError]
-	if ok {
+	if value, ok := parameters[PersistOnError]; ok {
 		var err error
-		result.PersistOnError, err = strconv.ParseBool(value)
+		config.PersistOnError, err = strconv.ParseBool(value)
 		if err != nil {
-			return result, "",
-				fmt.Errorf("HTTPExport Could not parse '%s' to a bool for '%s' parameter: %s",
-					value,
-					PersistOnError,
-					err.Error())
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+			return nil
 		}
 	}
 
-	// ContinueOnSendError is optional and is false by default.
-	result.ContinueOnSendError = false
-	value, ok = parameters[ContinueOnSendError]
-	if ok {
-		var err error
-		result.ContinueOnSendError, err = strconv.ParseBool(value)
-		if err != nil {
+	breaker, ok := app.processCircuitBreaker("SMSNotification", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewSMSSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create SMSNotification: %s", err.Error())
+		return nil
+	}
+
+	return transform.SendSMS
+}
+
+// notificationBody returns the body template given inline by the Template parameter or read from the
+// ApplicationSettings entry named by the TemplateSetting parameter, or empty for the default body when neither is set
+func (app *Configurable) notificationBody(funcName string, parameters map[string]string) (string, bool) {
+	templateText, inline := parameters[Template]
+	settingName, fromSetting := parameters[TemplateSetting]
+	if inline && fromSetting {
+		app.lc.Errorf("Only one of the '%s' or '%s' parameters can be specified for %s", Template, TemplateSetting, funcName)
+		return "", false
+	}
+
+	if fromSetting {
+		settingName = strings.TrimSpace(settingName)
+		var ok bool
+		templateText, ok = app.appSettings[settingName]
+		if !ok {
+			app.lc.Errorf("Could not find ApplicationSettings entry '%s' for %s", settingName, funcName)
+			return "", false
+		}
+	}
+
+	return templateText, true
+}
+
+// ElasticsearchExport will bulk index the Event, or batch of Events, from the previous function into the
+// Elasticsearch or OpenSearch cluster at the specified Url. The optional Index parameter is the template of the index
+// name, "edgex-{date}" by default, whose {device}, {profile}, {source}, {date}, {year}, {month} and {day} placeholders
+// are replaced with each Event's names and origin. The optional AuthMode parameter is "basic", with the "username"
+// and "password" secrets at SecretPath, or "apikey", with the "apikey" secret at SecretPath. The optional
+// IngestPipeline parameter is the ingest pipeline the Events are processed by, and the optional DataStream parameter
+// creates the documents as required by data streams. PersistOnError enables use of store & forward for the Events
+// that failed to be indexed, and the circuit breaker parameters are the same as HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ElasticsearchExport(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.ElasticsearchConfig{
+		URL:        strings.TrimSpace(parameters[Url]),
+		Index:      parameters[Index],
+		AuthMode:   parameters[AuthMode],
+		SecretPath: strings.TrimSpace(parameters[SecretPath]),
+		Pipeline:   strings.TrimSpace(parameters[IngestPipeline]),
+	}
+	if len(config.URL) == 0 {
+		app.lc.Errorf("Could not find '%s' parameter for ElasticsearchExport", Url)
+		return nil
+	}
+
+	for name, target := range map[string]*bool{
+		DataStream:     &config.DataStream,
+		PersistOnError: &config.PersistOnError,
+	} {
+		value, ok := parameters[name]
+		if !ok {
+			continue
+		}
+
+		var err error
+		*target, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
+			return nil
+		}
+	}
+
+	breaker, ok := app.processCircuitBreaker("ElasticsearchExport", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewElasticsearchSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create ElasticsearchExport: %s", err.Error())
+		return nil
+	}
+
+	return transform.BulkIndex
+}
+
+// CoAPExport will send data from the previous function to the CoAP resource at the specified Url, "coap://" or
+// "coaps://" for DTLS, in a confirmable request. The optional ExportMethod parameter is "post", the default, or "put",
+// and the optional MimeType parameter is sent as the CoAP content format, "application/json" by default. The optional
+// Security parameter is "psk", with the "identity" and hex encoded "psk" secrets at SecretPath, or "cert", with the
+// "clientcert", "clientkey" and optional "cacert" secrets at SecretPath, and is required by coaps. The optional
+// SkipVerify parameter disables the verification of the server's certificate, the optional WriteTimeout parameter is
+// the time to wait for the response, and the optional BlockSize parameter is the size of the blocks larger payloads
+// are sent in. PersistOnError enables use of store & forward, and the circuit breaker parameters are the same as
+// HTTPExport.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) CoAPExport(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.CoAPConfig{
+		URL:        strings.TrimSpace(parameters[Url]),
+		Method:     parameters[ExportMethod],
+		MimeType:   strings.TrimSpace(parameters[MimeType]),
+		Security:   parameters[Security],
+		SecretPath: strings.TrimSpace(parameters[SecretPath]),
+	}
+	if len(config.URL) == 0 {
+		app.lc.Errorf("Could not find '%s' parameter for CoAPExport", Url)
+		return nil
+	}
+
+	for name, target := range map[string]*bool{
+		SkipVerify:     &config.SkipCertVerify,
+		PersistOnError: &config.PersistOnError,
+	} {
+		value, ok := parameters[name]
+		if !ok {
+			continue
+		}
+
+		var err error
+		*target, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
+			return nil
+		}
+	}
+
+	if value := strings.TrimSpace(parameters[WriteTimeout]); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for CoAPExport, must be a duration greater than 0, i.e. 10s", WriteTimeout)
+			return nil
+		}
+		config.Timeout = timeout
+	}
+
+	if value := strings.TrimSpace(parameters[BlockSize]); value != "" {
+		blockSize, err := strconv.Atoi(value)
+		if err != nil || blockSize <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for CoAPExport, must be an integer greater than 0", BlockSize)
+			return nil
+		}
+		config.BlockSize = blockSize
+	}
+
+	breaker, ok := app.processCircuitBreaker("CoAPExport", parameters)
+	if !ok {
+		return nil
+	}
+	config.CircuitBreaker = breaker
+
+	transform, err := transforms.NewCoAPSender(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create CoAPExport: %s", err.Error())
+		return nil
+	}
+
+	return transform.CoAPSend
+}
+
+// FileExport will write data from the previous function to a new file in the specified FileDirectory.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) FileExport(parameters map[string]string) interfaces.AppFunction {
+	directory := strings.TrimSpace(parameters[FileDirectory])
+	if len(directory) == 0 {
+		app.lc.Errorf("Could not find '%s' parameter for FileExport", FileDirectory)
+		return nil
+	}
+
+	transform := transforms.NewFileSender(directory)
+	return transform.Write
+}
+
+// LoopbackExport sends the data from the previous function to the pipelines of the same service matching the Topic
+// parameter, which may contain placeholders, without going through the message bus. Requires the Trigger's Loopback
+// to be enabled. The optional PersistOnError parameter enables use of store & forward when the data can't be sent back.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) LoopbackExport(parameters map[string]string) interfaces.AppFunction {
+	topic := strings.TrimSpace(parameters[Topic])
+	if len(topic) == 0 {
+		app.lc.Errorf("Could not find '%s' parameter for LoopbackExport", Topic)
+		return nil
+	}
+
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	if value, ok := parameters[PersistOnError]; ok {
+		var err error
+		persistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+			return nil
+		}
+	}
+
+	transform := transforms.NewLoopbackSender(topic, persistOnError)
+	return transform.LoopbackSend
+}
+
+//
+// SkipExported stops the pipeline for data already exported, according to the checksums saved by MarkExported.
+// Requires ExportGuard to be enabled in the configuration.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SkipExported(parameters map[string]string) interfaces.AppFunction {
+	transform := transforms.NewIdempotentExport()
+	return transform.SkipExported
+}
+
+// MarkExported saves the checksum of the data that has been exported by the preceding export function.
+// Requires ExportGuard to be enabled in the configuration.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) MarkExported(parameters map[string]string) interfaces.AppFunction {
+	transform := transforms.NewIdempotentExport()
+	return transform.MarkExported
+}
+
+// MQTTExport will send data from the previous function to the specified Endpoint via MQTT publish. If no previous function exists,
+// then the event that triggered the pipeline will be used. The optional ChunkSize parameter splits the data into
+// chunks of at most that many bytes, each published separately, for the receiving app service to reassemble with
+// ReassembleChunks. The optional FailureThreshold and ProbeInterval parameters add a circuit breaker, as for HTTPExport.
+// The optional PersistentSession parameter connects with a persistent session, keeping the unacknowledged QoS 1 and 2
+// publishes across reconnects, and the optional StoreDirectory parameter persists them to disk across restarts. The
+// optional InflightWindow parameter limits the QoS 1 and 2 publishes awaiting acknowledgement, and the optional
+// SharedConnection parameter shares one connection with the other MQTTExports to the same broker with the same
+// ClientId.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) MQTTExport(parameters map[string]string) interfaces.AppFunction {
+	var err error
+	qos := 0
+	retain := false
+	autoReconnect := false
+	skipCertVerify := false
+
+	brokerAddress, ok := parameters[BrokerAddress]
+	if !ok {
+		app.lc.Error("Could not find " + BrokerAddress)
+		return nil
+	}
+	topic, ok := parameters[Topic]
+	if !ok {
+		app.lc.Error("Could not find " + Topic)
+		return nil
+	}
+
+	secretPath, ok := parameters[SecretPath]
+	if !ok {
+		app.lc.Error("Could not find " + SecretPath)
+		return nil
+	}
+	authMode, ok := parameters[AuthMode]
+	if !ok {
+		app.lc.Error("Could not find " + AuthMode)
+		return nil
+	}
+	clientID, ok := parameters[ClientID]
+	if !ok {
+		app.lc.Error("Could not find " + ClientID)
+		return nil
+	}
+	qosVal, ok := parameters[Qos]
+	if ok {
+		qos, err = strconv.Atoi(qosVal)
+		if err != nil {
+			app.lc.Error("Unable to parse " + Qos + " value")
+			return nil
+		}
+	}
+	retainVal, ok := parameters[Retain]
+	if ok {
+		retain, err = strconv.ParseBool(retainVal)
+		if err != nil {
+			app.lc.Error("Unable to parse " + Retain + " value")
+			return nil
+		}
+	}
+	autoreconnectVal, ok := parameters[AutoReconnect]
+	if ok {
+		autoReconnect, err = strconv.ParseBool(autoreconnectVal)
+		if err != nil {
+			app.lc.Error("Unable to parse " + AutoReconnect + " value")
+			return nil
+		}
+	}
+	skipVerifyVal, ok := parameters[SkipVerify]
+	if ok {
+		skipCertVerify, err = strconv.ParseBool(skipVerifyVal)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", skipVerifyVal, SkipVerify, err.Error())
+			return nil
+		}
+	}
+
+	// These are optional and blank values result in MQTT defaults being used.
+	keepAlive := parameters[KeepAlive]
+	connectTimeout := parameters[ConnectTimeout]
+
+	var persistentSession, sharedConnection bool
+	for name, target := range map[string]*bool{
+		PersistentSession: &persistentSession,
+		SharedConnection:  &sharedConnection,
+	} {
+		value, ok := parameters[name]
+		if !ok {
+			continue
+		}
+
+		*target, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, name, err.Error())
+			return nil
+		}
+	}
+
+	if persistentSession && len(strings.TrimSpace(clientID)) == 0 {
+		app.lc.Errorf("'%s' parameter must be set for MQTTExport with '%s' enabled", ClientID, PersistentSession)
+		return nil
+	}
+
+	storeDirectory := strings.TrimSpace(parameters[StoreDirectory])
+	if len(storeDirectory) > 0 && !persistentSession {
+		app.lc.Errorf("'%s' parameter for MQTTExport requires '%s' to be enabled", StoreDirectory, PersistentSession)
+		return nil
+	}
+
+	inflightWindow := 0
+	if value := strings.TrimSpace(parameters[InflightWindow]); value != "" {
+		inflightWindow, err = strconv.Atoi(value)
+		if err != nil || inflightWindow < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for MQTTExport, must be an integer greater than or equal to 0", InflightWindow)
+			return nil
+		}
+	}
+
+	mqttConfig := transforms.MQTTSecretConfig{
+		Retain:         retain,
+		SkipCertVerify: skipCertVerify,
+		AutoReconnect:  autoReconnect,
+		ConnectTimeout: connectTimeout,
+		KeepAlive:      keepAlive,
+		QoS:            byte(qos),
+		BrokerAddress:  brokerAddress,
+		ClientId:       clientID,
+		SecretPath:     secretPath,
+		Topic:          topic,
+		AuthMode:       authMode,
+
+		PersistentSession: persistentSession,
+		StoreDirectory:    storeDirectory,
+		InflightWindow:    inflightWindow,
+		SharedConnection:  sharedConnection,
+	}
+	// PersistOnError is optional and is false by default.
+	persistOnError := false
+	value, ok := parameters[PersistOnError]
+	if ok {
+		persistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, PersistOnError, err.Error())
+			return nil
+		}
+	}
+	chunkSize, ok := app.processChunkSize("MQTTExport", parameters)
+	if !ok {
+		return nil
+	}
+
+	breaker, ok := app.processCircuitBreaker("MQTTExport", parameters)
+	if !ok {
+		return nil
+	}
+
+	// The chunker persists the complete payload rather than the chunk
+	transform := transforms.NewMQTTSecretSenderWithCircuitBreaker(mqttConfig, persistOnError && chunkSize == 0, breaker)
+	return app.chunked(chunkSize, persistOnError, transform.MQTTSend)
+}
+
+// ReassembleChunks reassembles the payloads split into chunks by HTTPExport or MQTTExport with the ChunkSize
+// parameter, continuing the pipeline with the complete payload once all its chunks are received. The optional
+// ChunkTimeout parameter is the time to wait for the remaining chunks, 1m by default. The pipeline's TargetType must
+// be []byte.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ReassembleChunks(parameters map[string]string) interfaces.AppFunction {
+	timeout := transforms.DefaultChunkTimeout
+	if value := strings.TrimSpace(parameters[ChunkTimeout]); value != "" {
+		var err error
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for ReassembleChunks, must be a duration greater than 0, i.e. 30s", ChunkTimeout)
+			return nil
+		}
+	}
+
+	transform := transforms.NewChunkAssembler(timeout)
+	return transform.Reassemble
+}
+
+// SetResponseData sets the response data to that passed in from the previous function and the response content type
+// to that set in the ResponseContentType configuration parameter. It will return an error and stop the pipeline if
+// data passed in is not of type []byte, string or json.Marshaller
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SetResponseData(parameters map[string]string) interfaces.AppFunction {
+	transform := transforms.ResponseData{}
+
+	value, ok := parameters[ResponseContentType]
+	if ok && len(value) > 0 {
+		transform.ResponseContentType = value
+	}
+
+	return transform.SetResponseData
+}
+
+// SetResponseMetadata sets the response content type to that set in the optional ResponseContentType parameter and
+// the response headers to those in the optional Headers parameter, a comma separated list of 'key:value' whose values
+// may contain context value placeholders, i.e. "X-Device:{devicename}". The headers are sent with the response by the
+// HTTP and NATS triggers. The data is passed through unchanged.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SetResponseMetadata(parameters map[string]string) interfaces.AppFunction {
+	headers, err := parseHeaders(parameters[Headers])
+	if err != nil {
+		app.lc.Error(err.Error())
+		return nil
+	}
+
+	transform := transforms.NewResponseMetadata(strings.TrimSpace(parameters[ResponseContentType]), headers)
+	return transform.SetResponseMetadata
+}
+
+// Batch sets up Batching of events based on the specified mode parameter (BatchByCount, BatchByTime, BatchByTimeAndCount
+// or BatchByCountOrTime)
+// and mode specific parameters. The optional AlignFlush parameter aligns the release of batches on the time interval
+// to wall clock boundaries, shared with all the other functions with AlignFlush set.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Batch(parameters map[string]string) interfaces.AppFunction {
+	mode, ok := parameters[Mode]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Batch", Mode)
+		return nil
+	}
+
+	var err error
+	var transform *transforms.BatchConfig
+	switch strings.ToLower(mode) {
+	case BatchByCount:
+		batchThreshold, ok := parameters[BatchThreshold]
+		if !ok {
+			app.lc.Errorf("Could not find '%s' parameter for BatchByCount", BatchThreshold)
+			return nil
+		}
+
+		thresholdValue, err := strconv.Atoi(batchThreshold)
+		if err != nil {
+			app.lc.Errorf(
+				"Could not parse '%s' to an int for '%s' parameter for BatchByCount: %s",
+				batchThreshold, BatchThreshold, err.Error())
+			return nil
+		}
+
+		transform, err = transforms.NewBatchByCount(thresholdValue)
+		if err != nil {
+			app.lc.Error(err.Error())
+			return nil
+		}
+
+	case BatchByTime:
+		timeInterval, ok := parameters[TimeInterval]
+		if !ok {
+			app.lc.Errorf("Could not find '%s' parameter for BatchByTime", TimeInterval)
+			return nil
+		}
+
+		transform, err = transforms.NewBatchByTime(timeInterval)
+		if err != nil {
+			app.lc.Error(err.Error())
+			return nil
+		}
+
+	case BatchByTimeAndCount:
+		timeInterval, ok := parameters[TimeInterval]
+		if !ok {
+			app.lc.Error("Could not find " + TimeInterval)
+			return nil
+		}
+		batchThreshold, ok := parameters[BatchThreshold]
+		if !ok {
+			app.lc.Error("Could not find " + BatchThreshold)
+			return nil
+		}
+		thresholdValue, err := strconv.Atoi(batchThreshold)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to an int for '%s' parameter: %s", batchThreshold, BatchThreshold, err.Error())
+			return nil
+		}
+
+		transform, err = transforms.NewBatchByTimeAndCount(timeInterval, thresholdValue)
+		if err != nil {
+			app.lc.Error(err.Error())
+			return nil
+		}
+
+	case BatchByCountOrTime:
+		timeInterval, ok := parameters[TimeInterval]
+		if !ok {
+			app.lc.Errorf("Could not find '%s' parameter for BatchByCountOrTime", TimeInterval)
+			return nil
+		}
+		batchThreshold, ok := parameters[BatchThreshold]
+		if !ok {
+			app.lc.Errorf("Could not find '%s' parameter for BatchByCountOrTime", BatchThreshold)
+			return nil
+		}
+		thresholdValue, err := strconv.Atoi(batchThreshold)
+		if err != nil {
+			app.lc.Errorf(
+				"Could not parse '%s' to an int for '%s' parameter for BatchByCountOrTime: %s",
+				batchThreshold, BatchThreshold, err.Error())
+			return nil
+		}
+
+		transform, err = transforms.NewBatchByCountOrTime(timeInterval, thresholdValue)
+		if err != nil {
+			app.lc.Error(err.Error())
+			return nil
+		}
+
+	default:
+		app.lc.Errorf(
+			"Invalid batch mode '%s'. Must be '%s', '%s', '%s' or '%s'",
+			mode,
+			BatchByCount,
+			BatchByTime,
+			BatchByTimeAndCount,
+			BatchByCountOrTime)
+		return nil
+	}
+
+	// IsEventData is optional
+	isEventDataValue, ok := parameters[IsEventData]
+	if ok {
+		isEventData, err := strconv.ParseBool(isEventDataValue)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", isEventDataValue, isEventData, err.Error())
+			return nil
+		}
+
+		transform.IsEventData = isEventData
+	}
+
+	// AlignFlush is optional and only applies to the modes with a time interval
+	alignFlush, ok := app.processAlignFlush("Batch", parameters)
+	if !ok {
+		return nil
+	}
+	if alignFlush {
+		transform.AlignFlush(app.getFlushCoordinator())
+	}
+
+	return transform.Batch
+}
+
+// JSONLogic ...
+func (app *Configurable) JSONLogic(parameters map[string]string) interfaces.AppFunction {
+	rule, ok := parameters[Rule]
+	if !ok {
+		app.lc.Error("Could not find " + Rule)
+		return nil
+	}
+
+	transform := transforms.NewJSONLogic(rule)
+	return transform.Evaluate
+}
+
+// EvaluateRules evaluates the local rules from the Writable.Rules configuration against the data and runs the
+// actions of those rules whose condition is met. The optional Rules parameter is a comma separated list of the
+// rule names to evaluate, otherwise all rules are evaluated in name order.
+// The data is always passed on to the next function in the pipeline.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) EvaluateRules(parameters map[string]string) interfaces.AppFunction {
+	var names []string
+	if spec, ok := parameters[RuleNames]; ok {
+		names = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	if len(names) == 0 {
+		for name := range app.rules {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	if len(names) == 0 {
+		app.lc.Error("No rules found in Writable.Rules configuration")
+		return nil
+	}
+
+	var rules []transforms.Rule
+	for _, name := range names {
+		ruleConfig, ok := app.rules[name]
+		if !ok {
+			app.lc.Errorf("Rule '%s' not found in Writable.Rules configuration", name)
+			return nil
+		}
+
+		actionNames := make([]string, 0, len(ruleConfig.Actions))
+		for actionName := range ruleConfig.Actions {
+			actionNames = append(actionNames, actionName)
+		}
+		sort.Strings(actionNames)
+
+		rule := transforms.Rule{
+			Name:      name,
+			Condition: ruleConfig.Condition,
+		}
+		for _, actionName := range actionNames {
+			action := ruleConfig.Actions[actionName]
+			rule.Actions = append(rule.Actions, transforms.RuleAction{Type: action.Type, Parameters: action.Parameters})
+		}
+
+		if err := rule.Validate(); err != nil {
+			app.lc.Errorf("Invalid rule configuration: %s", err.Error())
+			return nil
+		}
+
+		rules = append(rules, rule)
+	}
+
+	transform := transforms.NewRulesEngine(rules)
+	return transform.Evaluate
+}
+
+// AddTags adds the configured list of tags to Events passed to the transform.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) AddTags(parameters map[string]string) interfaces.AppFunction {
+	tagsSpec, ok := parameters[Tags]
+	if !ok {
+		app.lc.Error(fmt.Sprintf("Could not find '%s' parameter", Tags))
+		return nil
+	}
+
+	tagKeyValues := util.DeleteEmptyAndTrim(strings.FieldsFunc(tagsSpec, util.SplitComma))
+
+	tags := make(map[string]interface{})
+	for _, tag := range tagKeyValues {
+		keyValue := util.DeleteEmptyAndTrim(strings.FieldsFunc(tag, util.SplitColon))
+		if len(keyValue) != 2 {
+			app.lc.Errorf("Bad Tags specification format. Expect comma separated list of 'key:value'. Got `%s`", tagsSpec)
+			return nil
+		}
+
+		if len(keyValue[0]) == 0 {
+			app.lc.Errorf("Tag key missing. Got '%s'", tag)
+			return nil
+		}
+		if len(keyValue[1]) == 0 {
+			app.lc.Errorf("Tag value missing. Got '%s'", tag)
+			return nil
+		}
+
+		tags[keyValue[0]] = keyValue[1]
+	}
+
+	transform := transforms.NewGenericTags(tags)
+	return transform.AddTags
+}
+
+// processFilterParameters returns the Filter for the names in the paramName parameter and the optional NamePattern
+// parameter, a regular expression names are also matched against. When rangeAllowed, the optional MinValue, MaxValue,
+// EqualValue and Tolerance parameters set the Filter's ValueRange. The names are not required when the NamePattern or
+// a range is given.
+func (app *Configurable) processFilterParameters(
+	funcName string,
+	parameters map[string]string,
+	paramName string,
+	rangeAllowed bool) (*transforms.Filter, bool) {
+	transform := transforms.Filter{}
+
+	if pattern, ok := parameters[NamePattern]; ok {
+		compiled, err := regexp.Compile(strings.TrimSpace(pattern))
+		if err != nil {
+			app.lc.Errorf("Invalid '%s' parameter for %s: %s", NamePattern, funcName, err.Error())
+			return nil, false
+		}
+		transform.FilterPatterns = []*regexp.Regexp{compiled}
+	}
+
+	if rangeAllowed {
+		valueRange, ok := app.processValueRangeParameters(funcName, parameters)
+		if !ok {
+			return nil, false
+		}
+		transform.ValueRange = valueRange
+	}
+
+	// The members of a device group are matched in addition to the device names
+	if paramName == DeviceNames {
+		transform.DeviceGroup = strings.TrimSpace(parameters[DeviceGroup])
+	}
+
+	names, ok := parameters[paramName]
+	if !ok && transform.FilterPatterns == nil && transform.ValueRange == nil && transform.DeviceGroup == "" {
+		app.lc.Errorf("Could not find '%s' parameter for %s", paramName, funcName)
+		return nil, false
+	}
+
+	filterOut, ok := parameters[FilterOut]
+	if ok {
+		var err error
+		transform.FilterOut, err = strconv.ParseBool(filterOut)
+		if err != nil {
+			app.lc.Errorf("Could not convert filterOut value `%s` to bool for %s", filterOut, funcName)
+			return nil, false
+		}
+	}
+
+	transform.FilterValues = util.DeleteEmptyAndTrim(strings.FieldsFunc(names, util.SplitComma))
+
+	return &transform, true
+}
+
+// processValueRangeParameters returns the range of the MinValue, MaxValue, EqualValue and Tolerance parameters,
+// or nil when none are given
+func (app *Configurable) processValueRangeParameters(funcName string, parameters map[string]string) (*transforms.ValueRange, bool) {
+	var valueRange transforms.ValueRange
+	found := false
+
+	limits := []struct {
+		name  string
+		limit **float64
+	}{
+		{MinValue, &valueRange.Min},
+		{MaxValue, &valueRange.Max},
+		{EqualValue, &valueRange.Equal},
+	}
+
+	for _, limit := range limits {
+		value, ok := parameters[limit.name]
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a number for '%s' parameter for %s", value, limit.name, funcName)
+			return nil, false
+		}
+		*limit.limit = &parsed
+		found = true
+	}
+
+	if value, ok := parameters[Tolerance]; ok {
+		var err error
+		valueRange.Tolerance, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || valueRange.Tolerance < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for %s, must be a number that isn't negative", Tolerance, funcName)
+			return nil, false
+		}
+
+		if valueRange.Equal == nil {
+			app.lc.Errorf("The '%s' parameter for %s requires the '%s' parameter", Tolerance, funcName, EqualValue)
+			return nil, false
+		}
+	}
+
+	if !found {
+		return nil, true
+	}
+
+	if valueRange.Min != nil && valueRange.Max != nil && *valueRange.Min > *valueRange.Max {
+		app.lc.Errorf("The '%s' parameter for %s must not be greater than the '%s' parameter", MinValue, funcName, MaxValue)
+		return nil, false
+	}
+
+	return &valueRange, true
+}
+
+func (app *Configurable) processHttpExportParameters(
+	parameters map[string]string) (transforms.HTTPSenderOptions, string, error) {
+
+	result := transforms.HTTPSenderOptions{}
+
+	method, ok := parameters[ExportMethod]
+	if !ok {
+		return result, "", fmt.Errorf("HTTPExport Could not find %s", ExportMethod)
+	}
+
+	result.URL, ok = parameters[Url]
+	if !ok {
+		return result, "", fmt.Errorf("HTTPExport Could not find %s", Url)
+	}
+	result.MimeType, ok = parameters[MimeType]
+	if !ok {
+		return result, "", fmt.Errorf("HTTPExport Could not find %s", MimeType)
+	}
+
+	// PersistOnError is optional and is false by default.
+	var value string
+	result.PersistOnError = false
+	value, ok = parameters[PersistOnError]
+	if ok {
+		var err error
+		result.PersistOnError, err = strconv.ParseBool(value)
+		if err != nil {
+			return result, "",
+				fmt.Errorf("HTTPExport Could not parse '%s' to a bool for '%s' parameter: %s",
+					value,
+					PersistOnError,
+					err.Error())
+		}
+	}
+
+	// ContinueOnSendError is optional and is false by default.
+	result.ContinueOnSendError = false
+	value, ok = parameters[ContinueOnSendError]
+	if ok {
+		var err error
+		result.ContinueOnSendError, err = strconv.ParseBool(value)
+		if err != nil {
 			return result, "",
 				fmt.Errorf("HTTPExport Could not parse '%s' to a bool for '%s' parameter: %s",
 					value,
@@ -714,39 +2301,1297 @@ func (app *Configurable) processHttpExportParameters(
 		}
 	}
 
-	// ReturnInputData is optional and is false by default.
-	result.ReturnInputData = false
-	value, ok = parameters[ReturnInputData]
-	if ok {
+	// ReturnInputData is optional and is false by default.
+	result.ReturnInputData = false
+	value, ok = parameters[ReturnInputData]
+	if ok {
+		var err error
+		result.ReturnInputData, err = strconv.ParseBool(value)
+		if err != nil {
+			return result, "",
+				fmt.Errorf("HTTPExport Could not parse '%s' to a bool for '%s' parameter: %s",
+					value,
+					ReturnInputData,
+					err.Error())
+		}
+	}
+
+	result.URL = strings.TrimSpace(result.URL)
+	result.MimeType = strings.TrimSpace(result.MimeType)
+
+	if err := processHttpRequestParameters("HTTPExport", parameters, &result); err != nil {
+		return result, "", err
+	}
+
+	return result, method, nil
+}
+
+// processHttpRequestParameters processes the header, secret, auth and TLS parameters shared by the HTTP exports
+func processHttpRequestParameters(funcName string, parameters map[string]string, result *transforms.HTTPSenderOptions) error {
+	result.HTTPHeaderName = strings.TrimSpace(parameters[HeaderName])
+	result.SecretPath = strings.TrimSpace(parameters[SecretPath])
+	result.SecretName = strings.TrimSpace(parameters[SecretName])
+	result.ReceiptHeader = strings.TrimSpace(parameters[ReceiptHeader])
+
+	if len(result.HTTPHeaderName) == 0 && len(result.SecretPath) != 0 && len(result.SecretName) != 0 {
+		return fmt.Errorf("%s missing %s since %s & %s are specified", funcName, HeaderName, SecretPath, SecretName)
+	}
+	if len(result.SecretPath) == 0 && len(result.HTTPHeaderName) != 0 && len(result.SecretName) != 0 {
+		return fmt.Errorf("%s missing %s since %s & %s are specified", funcName, SecretPath, HeaderName, SecretName)
+	}
+	if len(result.SecretName) == 0 && len(result.SecretPath) != 0 && len(result.HTTPHeaderName) != 0 {
+		return fmt.Errorf("%s missing %s since %s & %s are specified", funcName, SecretName, SecretPath, HeaderName)
+	}
+
+	headers, err := parseHeaders(parameters[Headers])
+	if err != nil {
+		return fmt.Errorf("%s %s", funcName, err.Error())
+	}
+	if len(headers) > 0 {
+		result.Headers = headers
+	}
+
+	result.AuthMode = strings.ToLower(strings.TrimSpace(parameters[AuthMode]))
+	result.AuthSecretPath = strings.TrimSpace(parameters[AuthSecretPath])
+	switch result.AuthMode {
+	case "", transforms.HTTPAuthModeNone:
+	case transforms.HTTPAuthModeBearer, transforms.HTTPAuthModeBasic:
+		if len(result.AuthSecretPath) == 0 {
+			return fmt.Errorf("%s missing %s since %s is '%s'", funcName, AuthSecretPath, AuthMode, result.AuthMode)
+		}
+	default:
+		return fmt.Errorf("%s invalid %s of '%s'. Must be '%s', '%s' or '%s'", funcName, AuthMode, result.AuthMode,
+			transforms.HTTPAuthModeNone, transforms.HTTPAuthModeBearer, transforms.HTTPAuthModeBasic)
+	}
+
+	result.TLSSecretPath = strings.TrimSpace(parameters[TLSSecretPath])
+	if value, ok := parameters[SkipVerify]; ok {
+		result.SkipCertVerify, err = strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s Could not parse '%s' to a bool for '%s' parameter: %s",
+				funcName,
+				value,
+				SkipVerify,
+				err.Error())
+		}
+	}
+
+	return nil
+}
+
+// parseHeaders parses the Headers parameter, a comma separated list of 'key:value'
+func parseHeaders(spec string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, header := range util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma)) {
+		// Only split on the first colon so values, i.e. URLs, may contain colons
+		keyValue := strings.SplitN(header, ":", 2)
+		if len(keyValue) != 2 || len(strings.TrimSpace(keyValue[0])) == 0 {
+			return nil, fmt.Errorf("Bad Headers specification format. Expect comma separated list of 'key:value'. Got '%s'", spec)
+		}
+
+		headers[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+	}
+
+	return headers, nil
+}
+
+// ONNXInference runs the ONNX model, from the file path or URL in the Model parameter, against the Event's readings
+// and adds the predictions to the Event as new readings. The optional ResourceNames parameter is a comma separated list
+// of the readings used as the model's input, in order, otherwise all numeric readings or the image reading are used.
+// The optional PredictionName parameter is the resource name of the prediction readings and defaults to "prediction".
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ONNXInference(parameters map[string]string) interfaces.AppFunction {
+	modelLocation := strings.TrimSpace(parameters[ModelLocation])
+	if modelLocation == "" {
+		app.lc.Errorf("Could not find '%s' parameter for ONNXInference", ModelLocation)
+		return nil
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	transform := transforms.NewONNXInference(modelLocation, resourceNames, strings.TrimSpace(parameters[PredictionName]))
+	return transform.Predict
+}
+
+// ProcessImages decodes the JPEG or PNG image readings of the Event, applies the configured operations and re-encodes
+// the images. The operations are applied in the order crop, resize then grayscale. The optional Crop parameter is the
+// rectangle to crop in the form "x,y,width,height", Resize is the size in the form "widthxheight" where either may be 0
+// to keep the aspect ratio, and Grayscale is "true" to convert to grayscale. The optional Format parameter is "jpeg" or
+// "png", otherwise the received format is kept, and Quality is the JPEG quality. The optional ResourceNames parameter
+// limits the readings processed, otherwise all image readings are processed.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ProcessImages(parameters map[string]string) interfaces.AppFunction {
+	var operations []transforms.ImageOperation
+
+	if spec := strings.TrimSpace(parameters[ImageCrop]); spec != "" {
+		values, err := parseImageDimensions(spec, ",", 4)
+		if err != nil {
+			app.lc.Errorf("Invalid '%s' parameter for ProcessImages, must be in the form 'x,y,width,height': %s", ImageCrop, err.Error())
+			return nil
+		}
+		operations = append(operations, transforms.CropImage(values[0], values[1], values[2], values[3]))
+	}
+
+	if spec := strings.TrimSpace(parameters[ImageResize]); spec != "" {
+		values, err := parseImageDimensions(strings.ToLower(spec), "x", 2)
+		if err != nil || (values[0] == 0 && values[1] == 0) {
+			app.lc.Errorf("Invalid '%s' parameter for ProcessImages, must be in the form 'widthxheight'", ImageResize)
+			return nil
+		}
+		operations = append(operations, transforms.ResizeImage(values[0], values[1]))
+	}
+
+	if value, ok := parameters[ImageGrayscale]; ok {
+		grayscale, err := strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, ImageGrayscale, err.Error())
+			return nil
+		}
+		if grayscale {
+			operations = append(operations, transforms.GrayscaleImage())
+		}
+	}
+
+	format := strings.ToLower(strings.TrimSpace(parameters[ImageFormat]))
+	if format != "" && format != transforms.ImageFormatJPEG && format != transforms.ImageFormatPNG {
+		app.lc.Errorf("Invalid '%s' parameter for ProcessImages, must be '%s' or '%s'", ImageFormat, transforms.ImageFormatJPEG, transforms.ImageFormatPNG)
+		return nil
+	}
+
+	quality := 0
+	if value, ok := parameters[ImageQuality]; ok {
+		var err error
+		quality, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || quality < 1 || quality > 100 {
+			app.lc.Errorf("Invalid '%s' parameter for ProcessImages, must be a number from 1 to 100", ImageQuality)
+			return nil
+		}
+	}
+
+	if len(operations) == 0 && format == "" {
+		app.lc.Error("ProcessImages requires at least one of the Crop, Resize, Grayscale or Format parameters")
+		return nil
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	transform := transforms.NewImageProcessor(format, quality, resourceNames, operations...)
+	return transform.Process
+}
+
+// Smooth replaces the values of the Event's numeric readings with the values smoothed per device and resource using
+// the specified algorithm, either an exponentially weighted moving average or a Kalman filter.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Smooth(parameters map[string]string) interfaces.AppFunction {
+	algorithm, ok := parameters[Algorithm]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Smooth", Algorithm)
+		return nil
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	switch strings.ToLower(algorithm) {
+	case SmoothEWMA:
+		alpha, err := strconv.ParseFloat(strings.TrimSpace(parameters[Alpha]), 64)
+		if err != nil || alpha <= 0 || alpha > 1 {
+			app.lc.Errorf("Invalid '%s' parameter for Smooth, must be a number greater than 0 and not more than 1", Alpha)
+			return nil
+		}
+
+		transform := transforms.NewEWMAFilter(alpha, resourceNames)
+		return transform.Smooth
+
+	case SmoothKalman:
+		processNoise, err := strconv.ParseFloat(strings.TrimSpace(parameters[ProcessNoise]), 64)
+		if err != nil || processNoise < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for Smooth, must be a number not less than 0", ProcessNoise)
+			return nil
+		}
+
+		measurementNoise, err := strconv.ParseFloat(strings.TrimSpace(parameters[MeasurementNoise]), 64)
+		if err != nil || measurementNoise <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for Smooth, must be a number greater than 0", MeasurementNoise)
+			return nil
+		}
+
+		transform := transforms.NewKalmanFilter(processNoise, measurementNoise, resourceNames)
+		return transform.Smooth
+
+	default:
+		app.lc.Errorf(
+			"Invalid smoothing algorithm '%s'. Must be '%s' or '%s'",
+			algorithm,
+			SmoothEWMA,
+			SmoothKalman)
+		return nil
+	}
+}
+
+// DetectAnomalies tags the Event with, or drops, the numeric readings whose values deviate from the recent values of
+// the same device and resource by more than the threshold number of standard deviations, using either the z-score
+// over a window of values or an exponentially weighted moving average.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) DetectAnomalies(parameters map[string]string) interfaces.AppFunction {
+	algorithm, ok := parameters[Algorithm]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for DetectAnomalies", Algorithm)
+		return nil
+	}
+
+	threshold, err := strconv.ParseFloat(strings.TrimSpace(parameters[Threshold]), 64)
+	if err != nil || threshold <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for DetectAnomalies, must be a number greater than 0", Threshold)
+		return nil
+	}
+
+	// Mode is optional and defaults to tagging the Event with the anomalous readings
+	var drop bool
+	mode := strings.ToLower(strings.TrimSpace(parameters[Mode]))
+	switch mode {
+	case AnomalyTag, "":
+	case AnomalyDrop:
+		drop = true
+	default:
+		app.lc.Errorf("Invalid mode '%s' for DetectAnomalies. Must be '%s' or '%s'", mode, AnomalyTag, AnomalyDrop)
+		return nil
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	switch strings.ToLower(algorithm) {
+	case AnomalyZScore:
+		windowSize, err := strconv.Atoi(strings.TrimSpace(parameters[WindowSize]))
+		if err != nil || windowSize < 2 {
+			app.lc.Errorf("Invalid '%s' parameter for DetectAnomalies, must be a number greater than 1", WindowSize)
+			return nil
+		}
+
+		transform := transforms.NewZScoreAnomalyDetector(windowSize, threshold, drop, resourceNames)
+		return transform.DetectAnomalies
+
+	case AnomalyEWMA:
+		alpha, err := strconv.ParseFloat(strings.TrimSpace(parameters[Alpha]), 64)
+		if err != nil || alpha <= 0 || alpha >= 1 {
+			app.lc.Errorf("Invalid '%s' parameter for DetectAnomalies, must be a number greater than 0 and less than 1", Alpha)
+			return nil
+		}
+
+		transform := transforms.NewEWMAAnomalyDetector(alpha, threshold, drop, resourceNames)
+		return transform.DetectAnomalies
+
+	default:
+		app.lc.Errorf(
+			"Invalid anomaly detection algorithm '%s'. Must be '%s' or '%s'",
+			algorithm,
+			AnomalyZScore,
+			AnomalyEWMA)
+		return nil
+	}
+}
+
+// SessionWindow groups the Events of each device into sessions that close after the gap without an Event from the
+// device and returns a summary Event, with the session's duration, Event count and reading aggregates, for each.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SessionWindow(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[SessionGap]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for SessionWindow", SessionGap)
+		return nil
+	}
+
+	gap, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || gap <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for SessionWindow, must be a duration greater than 0, i.e. 5m", SessionGap)
+		return nil
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	transform := transforms.NewSessionWindow(gap, resourceNames)
+	return transform.Summarize
+}
+
+// DedupeByChecksum stops the pipeline for an Event with the same readings as the last Event forwarded for the device.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) DedupeByChecksum(parameters map[string]string) interfaces.AppFunction {
+	transform, ok := app.processDedupeParameters("DedupeByChecksum", parameters)
+	if !ok {
+		return nil
+	}
+
+	return transform.DedupeByChecksum
+}
+
+// DedupeByReadingValue removes the readings with the same value as the last reading forwarded for the device resource.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) DedupeByReadingValue(parameters map[string]string) interfaces.AppFunction {
+	transform, ok := app.processDedupeParameters("DedupeByReadingValue", parameters)
+	if !ok {
+		return nil
+	}
+
+	return transform.DedupeByReadingValue
+}
+
+// FilterOnChange removes the readings whose value hasn't changed by more than the Delta, or Delta plus Hysteresis when
+// reversing direction, from the value last forwarded for the device resource.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) FilterOnChange(parameters map[string]string) interfaces.AppFunction {
+	// Delta and Hysteresis are optional, so any change is forwarded by default
+	var delta float64
+	if value, ok := parameters[Delta]; ok {
+		var err error
+		delta, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || delta < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for FilterOnChange, must be a number not less than 0", Delta)
+			return nil
+		}
+	}
+
+	var hysteresis float64
+	if value, ok := parameters[Hysteresis]; ok {
+		var err error
+		hysteresis, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || hysteresis < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for FilterOnChange, must be a number not less than 0", Hysteresis)
+			return nil
+		}
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	transform := transforms.NewChangeFilter(delta, hysteresis, resourceNames)
+	return transform.FilterOnChange
+}
+
+// processDedupeParameters returns the Deduplicator for the optional Window, MaxEntries and PersistFile parameters
+func (app *Configurable) processDedupeParameters(funcName string, parameters map[string]string) (*transforms.Deduplicator, bool) {
+	var window time.Duration
+	if value, ok := parameters[Window]; ok {
+		var err error
+		window, err = time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || window < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for %s, must be a duration not less than 0, i.e. 15m", Window, funcName)
+			return nil, false
+		}
+	}
+
+	maxEntries := 0
+	if value, ok := parameters[MaxEntries]; ok {
+		var err error
+		maxEntries, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || maxEntries <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for %s, must be a number greater than 0", MaxEntries, funcName)
+			return nil, false
+		}
+	}
+
+	return transforms.NewDeduplicator(window, maxEntries, strings.TrimSpace(parameters[PersistFile])), true
+}
+
+// Throttle passes at most Limit Events per TimeInterval for each device, dropping the Events beyond the limit or, when
+// the Mode is queue, delaying up to MaxQueued Events per device until the following intervals have capacity.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Throttle(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[Limit]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Throttle", Limit)
+		return nil
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || limit <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for Throttle, must be a number greater than 0", Limit)
+		return nil
+	}
+
+	value, ok = parameters[TimeInterval]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Throttle", TimeInterval)
+		return nil
+	}
+
+	interval, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || interval <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for Throttle, must be a duration greater than 0, i.e. 1s", TimeInterval)
+		return nil
+	}
+
+	// Mode is optional and defaults to dropping the Events beyond the limit
+	mode := strings.ToLower(strings.TrimSpace(parameters[Mode]))
+	switch mode {
+	case ThrottleDrop, "":
+		return transforms.NewThrottle(limit, interval).Throttle
+	case ThrottleQueue:
+		maxQueued := limit
+		if value, ok := parameters[MaxQueued]; ok {
+			maxQueued, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || maxQueued <= 0 {
+				app.lc.Errorf("Invalid '%s' parameter for Throttle, must be a number greater than 0", MaxQueued)
+				return nil
+			}
+		}
+		return transforms.NewQueueingThrottle(limit, interval, maxQueued).Throttle
+	default:
+		app.lc.Errorf("Invalid mode '%s' for Throttle. Must be '%s' or '%s'", mode, ThrottleDrop, ThrottleQueue)
+		return nil
+	}
+}
+
+// EnforceQuota passes at most Limit bytes or messages, per the Unit, per day or month, per the Period, to the export
+// function following it in the pipeline, which sends to the named Destination. Once the quota is exceeded the data is
+// dropped, downsampled to 1 in DownsampleRate messages or stored for retry by Store and Forward, per the Mode.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) EnforceQuota(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[Limit]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for EnforceQuota", Limit)
+		return nil
+	}
+
+	limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		app.lc.Errorf("Invalid '%s' parameter for EnforceQuota, must be a number greater than 0", Limit)
+		return nil
+	}
+
+	options := transforms.QuotaOptions{
+		Destination: strings.TrimSpace(parameters[Destination]),
+		Limit:       limit,
+		Unit:        parameters[Unit],
+		Period:      parameters[Period],
+		Exceeded:    parameters[Mode],
+		PersistFile: strings.TrimSpace(parameters[PersistFile]),
+	}
+
+	if value, ok := parameters[DownsampleRate]; ok {
+		options.DownsampleRate, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			app.lc.Errorf("Invalid '%s' parameter for EnforceQuota, must be a number greater than 0", DownsampleRate)
+			return nil
+		}
+	}
+
+	transform, err := transforms.NewQuota(options)
+	if err != nil {
+		app.lc.Errorf("Invalid parameters for EnforceQuota: %s", err.Error())
+		return nil
+	}
+
+	return transform.EnforceQuota
+}
+
+// DropStale stops the pipeline for data older than the MaxAge, measured from the Event's Origin or, when the AgeBasis
+// is received, from the time the message was received. Stale data is HTTP POSTed to the optional StaleUrl.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) DropStale(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[MaxAge]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for DropStale", MaxAge)
+		return nil
+	}
+
+	maxAge, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || maxAge <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for DropStale, must be a duration greater than 0, i.e. 30s", MaxAge)
+		return nil
+	}
+
+	transform := transforms.NewStalenessFilter(maxAge)
+
+	// AgeBasis is optional and defaults to the Event's Origin
+	basis := strings.ToLower(strings.TrimSpace(parameters[AgeBasis]))
+	switch basis {
+	case AgeByOrigin, "":
+	case AgeByReceived:
+		transform.ByReceivedTime = true
+	default:
+		app.lc.Errorf("Invalid age basis '%s' for DropStale. Must be '%s' or '%s'", basis, AgeByOrigin, AgeByReceived)
+		return nil
+	}
+
+	if staleUrl := strings.TrimSpace(parameters[StaleUrl]); staleUrl != "" {
+		sender := transforms.NewHTTPSender(staleUrl, common.ContentTypeJSON, false)
+		transform.StaleHandler = sender.HTTPPost
+	}
+
+	return transform.DropStale
+}
+
+// ConvertUnits converts the values of readings between units using the mapping table in the Conversions parameter, a
+// comma separated list of 'resource:from:to' entries with an optional ':rename' of the resource, i.e.
+// 'TemperatureF:°F:°C:TemperatureC, Pressure:psi:kPa'.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ConvertUnits(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[Conversions]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for ConvertUnits", Conversions)
+		return nil
+	}
+
+	var conversions []transforms.UnitConversion
+	for _, entry := range util.DeleteEmptyAndTrim(strings.FieldsFunc(value, util.SplitComma)) {
+		fields := strings.Split(entry, ":")
+		if len(fields) < 3 || len(fields) > 4 {
+			app.lc.Errorf("Invalid conversion '%s' in '%s' parameter for ConvertUnits, must be 'resource:from:to[:rename]'", entry, Conversions)
+			return nil
+		}
+
+		conversion := transforms.UnitConversion{
+			ResourceName: strings.TrimSpace(fields[0]),
+			From:         strings.TrimSpace(fields[1]),
+			To:           strings.TrimSpace(fields[2]),
+		}
+		if len(fields) == 4 {
+			conversion.RenameTo = strings.TrimSpace(fields[3])
+		}
+
+		conversions = append(conversions, conversion)
+	}
+
+	if len(conversions) == 0 {
+		app.lc.Errorf("No conversions in '%s' parameter for ConvertUnits", Conversions)
+		return nil
+	}
+
+	transform, err := transforms.NewUnitConverter(conversions)
+	if err != nil {
+		app.lc.Errorf("Unable to create ConvertUnits: %s", err.Error())
+		return nil
+	}
+
+	return transform.ConvertUnits
+}
+
+// EnrichWithDeviceMetadata adds the metadata of the Event's device from Core Metadata to the Event's tags. The
+// optional MetadataFields parameter is a comma separated list of the fields added, i.e. 'labels, location', all fields
+// by default. The optional CacheTTL parameter is how long a device's metadata is cached, i.e. '10m'.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) EnrichWithDeviceMetadata(parameters map[string]string) interfaces.AppFunction {
+	fields := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[MetadataFields], util.SplitComma))
+	for _, field := range fields {
+		switch field {
+		case transforms.DeviceMetadataLabels, transforms.DeviceMetadataLocation, transforms.DeviceMetadataDescription,
+			transforms.DeviceMetadataProfileName, transforms.DeviceMetadataServiceName:
+		default:
+			app.lc.Errorf("Invalid field '%s' in '%s' parameter for EnrichWithDeviceMetadata. Must be one of '%s', '%s', '%s', '%s' or '%s'",
+				field, MetadataFields, transforms.DeviceMetadataLabels, transforms.DeviceMetadataLocation,
+				transforms.DeviceMetadataDescription, transforms.DeviceMetadataProfileName, transforms.DeviceMetadataServiceName)
+			return nil
+		}
+	}
+
+	var ttl time.Duration
+	if value := strings.TrimSpace(parameters[CacheTTL]); value != "" {
+		var err error
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for EnrichWithDeviceMetadata, must be a duration greater than 0, i.e. 10m", CacheTTL)
+			return nil
+		}
+	}
+
+	transform := transforms.NewDeviceMetadataEnricher(fields, ttl)
+	return transform.EnrichWithDeviceMetadata
+}
+
+// BulkCommand issues the same command, named by the CommandName parameter, to the devices of the DeviceNames and
+// DeviceGroup parameters concurrently via Core Command, and continues the pipeline with the report of the result for
+// each device. The optional Method parameter is 'get' or 'set', the default, whose resource values are given by the
+// Settings parameter as a comma separated list of 'resource:value'. The optional MaxConcurrent parameter limits the
+// commands issued concurrently. A bulk command request received, i.e. by the HTTP trigger, replaces the devices and
+// settings configured.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) BulkCommand(parameters map[string]string) interfaces.AppFunction {
+	options := transforms.BulkCommandOptions{
+		DeviceNames: util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[DeviceNames], util.SplitComma)),
+		DeviceGroup: strings.TrimSpace(parameters[DeviceGroup]),
+		CommandName: strings.TrimSpace(parameters[CommandName]),
+		Method:      parameters[CommandMethod],
+	}
+
+	if settings, ok := parameters[CommandSettings]; ok {
+		options.Settings = make(map[string]string)
+		for _, setting := range util.DeleteEmptyAndTrim(strings.FieldsFunc(settings, util.SplitComma)) {
+			keyValue := strings.SplitN(setting, ":", 2)
+			if len(keyValue) != 2 || len(strings.TrimSpace(keyValue[0])) == 0 {
+				app.lc.Errorf("Bad Settings specification format for BulkCommand. Expect comma separated list of 'resource:value'. Got '%s'", settings)
+				return nil
+			}
+
+			options.Settings[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+		}
+	}
+
+	if value, ok := parameters[MaxConcurrent]; ok {
+		var err error
+		options.MaxConcurrent, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || options.MaxConcurrent <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for BulkCommand, must be an integer greater than 0", MaxConcurrent)
+			return nil
+		}
+	}
+
+	transform, err := transforms.NewBulkCommand(options)
+	if err != nil {
+		app.lc.Errorf("Unable to create BulkCommand: %s", err.Error())
+		return nil
+	}
+
+	return transform.IssueCommand
+}
+
+// ScriptTransform runs a Lua script on the data, given inline by the Script parameter or read from the file named by
+// the ScriptFile parameter. The optional ScriptTimeout parameter is the maximum time the script runs for each
+// execution, i.e. '500ms', and the optional ScriptMemoryLimit parameter the maximum bytes of values it holds, i.e.
+// '1048576'. Executions exceeding either are aborted and reported to the pipeline error handler.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ScriptTransform(parameters map[string]string) interfaces.AppFunction {
+	script, inline := parameters[Script]
+	scriptFile, fromFile := parameters[ScriptFile]
+	if inline == fromFile {
+		app.lc.Errorf("One of the '%s' or '%s' parameters must be specified for ScriptTransform", Script, ScriptFile)
+		return nil
+	}
+
+	name := "inline"
+	if fromFile {
+		name = strings.TrimSpace(scriptFile)
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			app.lc.Errorf("Unable to read '%s' parameter file for ScriptTransform: %s", ScriptFile, err.Error())
+			return nil
+		}
+		script = string(content)
+	}
+
+	var timeout time.Duration
+	if value := strings.TrimSpace(parameters[ScriptTimeout]); value != "" {
+		var err error
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for ScriptTransform, must be a duration greater than 0, i.e. 500ms", ScriptTimeout)
+			return nil
+		}
+	}
+
+	var memoryLimit int64
+	if value := strings.TrimSpace(parameters[ScriptMemoryLimit]); value != "" {
+		var err error
+		memoryLimit, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || memoryLimit <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for ScriptTransform, must be a number of bytes greater than 0", ScriptMemoryLimit)
+			return nil
+		}
+	}
+
+	transform, err := transforms.NewSandboxedLuaScript(name, script, interfaces.SandboxLimits{
+		CPUTime: timeout,
+		Memory:  memoryLimit,
+	})
+	if err != nil {
+		app.lc.Errorf("Unable to create ScriptTransform: %s", err.Error())
+		return nil
+	}
+
+	return transform.ScriptTransform
+}
+
+// SignPayloadHMAC signs the data to be exported with HMAC-SHA256 using the key at the SecretPath and SecretName in
+// the Secret Store. The signature is sent in the X-Signature header by HTTP exports, or when the optional Envelope
+// parameter is true the data is replaced by a JSON envelope containing the data and signature.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SignPayloadHMAC(parameters map[string]string) interfaces.AppFunction {
+	signer, ok := app.processSignerParameters("SignPayloadHMAC", parameters)
+	if !ok {
+		return nil
+	}
+
+	return signer.SignPayloadHMAC
+}
+
+// SignPayloadECDSA signs the data to be exported with ECDSA using the PEM encoded private key at the SecretPath and
+// SecretName in the Secret Store. The signature is sent in the X-Signature header by HTTP exports, or when the
+// optional Envelope parameter is true the data is replaced by a JSON envelope containing the data and signature.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) SignPayloadECDSA(parameters map[string]string) interfaces.AppFunction {
+	signer, ok := app.processSignerParameters("SignPayloadECDSA", parameters)
+	if !ok {
+		return nil
+	}
+
+	return signer.SignPayloadECDSA
+}
+
+func (app *Configurable) processSignerParameters(functionName string, parameters map[string]string) (transforms.PayloadSigner, bool) {
+	secretPath := strings.TrimSpace(parameters[SecretPath])
+	secretName := strings.TrimSpace(parameters[SecretName])
+	if len(secretPath) == 0 || len(secretName) == 0 {
+		app.lc.Errorf("'%s' and '%s' are required for %s", SecretPath, SecretName, functionName)
+		return transforms.PayloadSigner{}, false
+	}
+
+	envelope := false
+	if value := strings.TrimSpace(parameters[Envelope]); value != "" {
+		var err error
+		envelope, err = strconv.ParseBool(value)
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, Envelope, err.Error())
+			return transforms.PayloadSigner{}, false
+		}
+	}
+
+	return transforms.NewPayloadSigner(secretPath, secretName, envelope), true
+}
+
+// ChainEvents links the Events exported from each device into a hash chain and replaces each Event with a JSON record
+// containing the Event and its link, so downstream auditors can detect missing or altered Events. When the optional
+// Algorithm parameter is set to hmac or ecdsa, each link is signed using the key at the SecretPath and SecretName in
+// the Secret Store. Requires HashChain to be enabled in the configuration.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ChainEvents(parameters map[string]string) interfaces.AppFunction {
+	algorithm := strings.ToLower(strings.TrimSpace(parameters[Algorithm]))
+	if algorithm == "" {
+		transform := transforms.NewEventChainer()
+		return transform.ChainEvent
+	}
+
+	var signatureAlgorithm string
+	switch algorithm {
+	case SignHMAC:
+		signatureAlgorithm = transforms.SignatureAlgorithmHMAC
+	case SignECDSA:
+		signatureAlgorithm = transforms.SignatureAlgorithmECDSA
+	default:
+		app.lc.Errorf("Invalid %s '%s' for ChainEvents, must be '%s' or '%s'", Algorithm, algorithm, SignHMAC, SignECDSA)
+		return nil
+	}
+
+	secretPath := strings.TrimSpace(parameters[SecretPath])
+	secretName := strings.TrimSpace(parameters[SecretName])
+	if len(secretPath) == 0 || len(secretName) == 0 {
+		app.lc.Errorf("'%s' and '%s' are required for ChainEvents when '%s' is set", SecretPath, SecretName, Algorithm)
+		return nil
+	}
+
+	transform := transforms.NewSignedEventChainer(secretPath, secretName, signatureAlgorithm)
+	return transform.ChainEvent
+}
+
+// processChunkSize parses the optional ChunkSize parameter of the export functions, returning 0 when not set
+func (app *Configurable) processChunkSize(functionName string, parameters map[string]string) (int, bool) {
+	value := strings.TrimSpace(parameters[ChunkSize])
+	if value == "" {
+		return 0, true
+	}
+
+	chunkSize, err := strconv.Atoi(value)
+	if err != nil || chunkSize <= transforms.ChunkHeaderSize {
+		app.lc.Errorf("Invalid '%s' parameter for %s, must be an integer greater than %d",
+			ChunkSize, functionName, transforms.ChunkHeaderSize)
+		return 0, false
+	}
+
+	return chunkSize, true
+}
+
+// processCircuitBreaker returns the circuit breaker for the export function's FailureThreshold and ProbeInterval
+// parameters, or nil when no FailureThreshold is set
+func (app *Configurable) processCircuitBreaker(functionName string, parameters map[string]string) (*transforms.CircuitBreaker, bool) {
+	value := strings.TrimSpace(parameters[FailureThreshold])
+	if value == "" {
+		return nil, true
+	}
+
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for %s, must be an integer greater than 0", FailureThreshold, functionName)
+		return nil, false
+	}
+
+	var interval time.Duration
+	if value := strings.TrimSpace(parameters[ProbeInterval]); value != "" {
+		interval, err = time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			app.lc.Errorf("Invalid '%s' parameter for %s, must be a duration greater than 0, i.e. 30s", ProbeInterval, functionName)
+			return nil, false
+		}
+	}
+
+	return transforms.NewCircuitBreaker(threshold, interval), true
+}
+
+// chunked wraps the export function with a Chunker when a chunk size is set
+func (app *Configurable) chunked(chunkSize int, persistOnError bool, export interfaces.AppFunction) interfaces.AppFunction {
+	if chunkSize == 0 {
+		return export
+	}
+
+	transform := transforms.NewChunker(chunkSize, persistOnError, export)
+	return transform.ExportChunks
+}
+
+// Aggregate computes the min, max, avg and/or sum of each device's numeric readings over a tumbling or sliding time
+// window and returns a summary Event in place of the raw readings. The optional AlignFlush parameter aligns the end of
+// tumbling windows to wall clock boundaries, shared with all the other functions with AlignFlush set.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Aggregate(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[Window]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Aggregate", Window)
+		return nil
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		app.lc.Errorf("Could not parse '%s' to a duration for '%s' parameter for Aggregate: %s", value, Window, err.Error())
+		return nil
+	}
+
+	var functions []string
+	if spec, ok := parameters[AggregateFunctions]; ok {
+		functions = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	// WindowType is optional and defaults to tumbling
+	var transform *transforms.Aggregation
+	windowType := strings.ToLower(strings.TrimSpace(parameters[WindowType]))
+	switch windowType {
+	case TumblingWindow, "":
+		transform, err = transforms.NewTumblingAggregation(window, functions, resourceNames)
+	case SlidingWindow:
+		transform, err = transforms.NewSlidingAggregation(window, functions, resourceNames)
+	default:
+		app.lc.Errorf("Invalid window type '%s' for Aggregate. Must be '%s' or '%s'", windowType, TumblingWindow, SlidingWindow)
+		return nil
+	}
+
+	if err != nil {
+		app.lc.Errorf("Unable to create Aggregate: %s", err.Error())
+		return nil
+	}
+
+	alignFlush, ok := app.processAlignFlush("Aggregate", parameters)
+	if !ok {
+		return nil
+	}
+	if alignFlush {
+		transform.AlignFlush(app.getFlushCoordinator())
+	}
+
+	return transform.Aggregate
+}
+
+// processAlignFlush parses the optional AlignFlush parameter, returning false when not set
+func (app *Configurable) processAlignFlush(functionName string, parameters map[string]string) (bool, bool) {
+	value := strings.TrimSpace(parameters[AlignFlush])
+	if value == "" {
+		return false, true
+	}
+
+	alignFlush, err := strconv.ParseBool(value)
+	if err != nil {
+		app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter for %s: %s", value, AlignFlush, functionName, err.Error())
+		return false, false
+	}
+
+	return alignFlush, true
+}
+
+// getFlushCoordinator returns the FlushCoordinator shared by the functions created by this Configurable
+func (app *Configurable) getFlushCoordinator() *transforms.FlushCoordinator {
+	if app.flushCoordinator == nil {
+		app.flushCoordinator = transforms.NewFlushCoordinator()
+	}
+
+	return app.flushCoordinator
+}
+
+// Query runs the SQL like statement, compiled when the pipeline is built, over the readings of the Events received and
+// returns the resulting rows. See transforms.Query for the statements supported.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) Query(parameters map[string]string) interfaces.AppFunction {
+	statement, ok := parameters[Statement]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for Query", Statement)
+		return nil
+	}
+
+	transform, err := transforms.NewQuery(statement)
+	if err != nil {
+		app.lc.Errorf("Unable to create Query: %s", err.Error())
+		return nil
+	}
+
+	return transform.Execute
+}
+
+// FilterByJSONPath passes the data, which can be any JSON, not only an Event, on to the next function in the pipeline
+// when the JSONPath expression selects at least one value from it, otherwise the pipeline stops.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) FilterByJSONPath(parameters map[string]string) interfaces.AppFunction {
+	transform, ok := app.processJSONPathParameters("FilterByJSONPath", parameters)
+	if !ok {
+		return nil
+	}
+
+	return transform.FilterByJSONPath
+}
+
+// ExtractByJSONPath replaces the data, which can be any JSON, not only an Event, with the JSON of the values the
+// JSONPath expression selects from it. The pipeline stops when nothing is selected.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ExtractByJSONPath(parameters map[string]string) interfaces.AppFunction {
+	transform, ok := app.processJSONPathParameters("ExtractByJSONPath", parameters)
+	if !ok {
+		return nil
+	}
+
+	return transform.ExtractByJSONPath
+}
+
+func (app *Configurable) processJSONPathParameters(funcName string, parameters map[string]string) (*transforms.JSONPath, bool) {
+	expression, ok := parameters[Expression]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for %s", Expression, funcName)
+		return nil, false
+	}
+
+	transform, err := transforms.NewJSONPath(expression)
+	if err != nil {
+		app.lc.Errorf("Unable to create %s: %s", funcName, err.Error())
+		return nil, false
+	}
+
+	return transform, true
+}
+
+// TransformWithTemplate renders the data with a Go text/template and passes the rendered string on to the next
+// function in the pipeline. The template is either given inline by the Template parameter or read from the
+// ApplicationSettings entry named by the TemplateSetting parameter, so long templates can be kept out of the
+// pipeline parameters.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) TransformWithTemplate(parameters map[string]string) interfaces.AppFunction {
+	templateText, inline := parameters[Template]
+	settingName, fromSetting := parameters[TemplateSetting]
+	if inline == fromSetting {
+		app.lc.Errorf("Exactly one of the '%s' or '%s' parameters must be specified for TransformWithTemplate",
+			Template, TemplateSetting)
+		return nil
+	}
+
+	if fromSetting {
+		settingName = strings.TrimSpace(settingName)
+		var ok bool
+		templateText, ok = app.appSettings[settingName]
+		if !ok {
+			app.lc.Errorf("Could not find ApplicationSettings entry '%s' for TransformWithTemplate", settingName)
+			return nil
+		}
+	}
+
+	transform, err := transforms.NewTemplateTransform(templateText)
+	if err != nil {
+		app.lc.Errorf("Unable to create TransformWithTemplate: %s", err.Error())
+		return nil
+	}
+
+	return transform.TransformWithTemplate
+}
+
+// ForwardToAppService forwards the data to the trigger of another application service, i.e. a site server's, and passes
+// it on unchanged. The TargetAddress parameter is the service's HTTP trigger address, i.e. https://site-server:59700,
+// or the address of the MQTT broker its trigger subscribes to, i.e. mqtts://site-server:8883, which also requires the
+// Topic parameter. The optional MessageBus parameter wraps the data in a message envelope for the EdgeX MessageBus
+// trigger. The optional SecretPath parameter holds the client certificate and key used for mutual TLS. Data that
+// fails to forward is always stored for later retry when Store and Forward is enabled.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ForwardToAppService(parameters map[string]string) interfaces.AppFunction {
+	config := transforms.AppServiceForwarderConfig{
+		TargetAddress: strings.TrimSpace(parameters[TargetAddress]),
+		Topic:         strings.TrimSpace(parameters[Topic]),
+		ClientId:      strings.TrimSpace(parameters[ClientID]),
+		SecretPath:    strings.TrimSpace(parameters[SecretPath]),
+	}
+
+	if config.TargetAddress == "" {
+		app.lc.Errorf("Could not find '%s' parameter for ForwardToAppService", TargetAddress)
+		return nil
+	}
+
+	var err error
+	if value, ok := parameters[MessageBus]; ok {
+		config.MessageBus, err = strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, MessageBus, err.Error())
+			return nil
+		}
+	}
+
+	if value, ok := parameters[SkipVerify]; ok {
+		config.SkipCertVerify, err = strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, SkipVerify, err.Error())
+			return nil
+		}
+	}
+
+	if value, ok := parameters[Qos]; ok {
+		qos, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || qos < 0 || qos > 2 {
+			app.lc.Errorf("Invalid '%s' parameter for ForwardToAppService, must be 0, 1 or 2", Qos)
+			return nil
+		}
+		config.QoS = byte(qos)
+	}
+
+	transform, err := transforms.NewAppServiceForwarder(config)
+	if err != nil {
+		app.lc.Errorf("Unable to create ForwardToAppService: %s", err.Error())
+		return nil
+	}
+
+	return transform.Forward
+}
+
+// TransformToProtobuf encodes the data as a protobuf message. The optional MessageName parameter is the full name of
+// the message and defaults to the EdgeX Event message. The message's schema is either compiled into the service or,
+// when the optional DescriptorSet parameter is given, read from that file containing a serialized FileDescriptorSet.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) TransformToProtobuf(parameters map[string]string) interfaces.AppFunction {
+	transform, ok := app.processProtobufParameters("TransformToProtobuf", parameters)
+	if !ok {
+		return nil
+	}
+
+	return transform.TransformToProtobuf
+}
+
+// TransformFromProtobuf decodes the data from a protobuf message to an Event, for the EdgeX Event message, or
+// otherwise to the JSON of the message. It takes the same parameters as TransformToProtobuf.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) TransformFromProtobuf(parameters map[string]string) interfaces.AppFunction {
+	transform, ok := app.processProtobufParameters("TransformFromProtobuf", parameters)
+	if !ok {
+		return nil
+	}
+
+	return transform.TransformFromProtobuf
+}
+
+func (app *Configurable) processProtobufParameters(funcName string, parameters map[string]string) (*transforms.ProtobufConversion, bool) {
+	messageName := strings.TrimSpace(parameters[MessageName])
+	descriptorSet := strings.TrimSpace(parameters[DescriptorSet])
+
+	if descriptorSet == "" {
+		if messageName == "" {
+			return transforms.NewProtobufConversion(), true
+		}
+
+		transform, err := transforms.NewProtobufConversionForMessage(messageName)
+		if err != nil {
+			app.lc.Errorf("Unable to create %s: %s", funcName, err.Error())
+			return nil, false
+		}
+		return transform, true
+	}
+
+	if messageName == "" {
+		app.lc.Errorf("Could not find '%s' parameter for %s, required with '%s'", MessageName, funcName, DescriptorSet)
+		return nil, false
+	}
+
+	content, err := ioutil.ReadFile(descriptorSet)
+	if err != nil {
+		app.lc.Errorf("Unable to read '%s' parameter file for %s: %s", DescriptorSet, funcName, err.Error())
+		return nil, false
+	}
+
+	transform, err := transforms.NewProtobufConversionFromDescriptorSet(content, messageName)
+	if err != nil {
+		app.lc.Errorf("Unable to create %s: %s", funcName, err.Error())
+		return nil, false
+	}
+
+	return transform, true
+}
+
+// TransformToAvro serializes the data, which must be JSON matching the Avro schema, to the Avro binary encoding. The
+// schema is given inline by the Schema parameter or by the ApplicationSettings entry named by the SchemaSetting
+// parameter. When the optional RegistryURL parameter is given the schema is looked up in the Confluent compatible
+// schema registry under the Subject parameter, or registered when the optional AutoRegister parameter is true, and
+// the encoded data is prefixed with its schema id. With a registry, the latest schema of the subject is used when no
+// schema is given. The optional SecretPath parameter holds the username and password for the registry.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) TransformToAvro(parameters map[string]string) interfaces.AppFunction {
+	schema, inline := parameters[Schema]
+	settingName, fromSetting := parameters[SchemaSetting]
+	if inline && fromSetting {
+		app.lc.Errorf("Only one of the '%s' or '%s' parameters may be specified for TransformToAvro", Schema, SchemaSetting)
+		return nil
+	}
+
+	if fromSetting {
+		settingName = strings.TrimSpace(settingName)
+		var ok bool
+		schema, ok = app.appSettings[settingName]
+		if !ok {
+			app.lc.Errorf("Could not find ApplicationSettings entry '%s' for TransformToAvro", settingName)
+			return nil
+		}
+	}
+
+	registryUrl := strings.TrimSpace(parameters[RegistryURL])
+	if registryUrl == "" {
+		if strings.TrimSpace(schema) == "" {
+			app.lc.Errorf("One of the '%s' or '%s' parameters must be specified for TransformToAvro without a schema registry",
+				Schema, SchemaSetting)
+			return nil
+		}
+
+		serializer, err := transforms.NewAvroSerializer(schema)
+		if err != nil {
+			app.lc.Errorf("Unable to create TransformToAvro: %s", err.Error())
+			return nil
+		}
+		return serializer.TransformToAvro
+	}
+
+	registry := transforms.AvroSchemaRegistryConfig{
+		URL:        registryUrl,
+		Subject:    strings.TrimSpace(parameters[Subject]),
+		SecretPath: strings.TrimSpace(parameters[SecretPath]),
+	}
+
+	if value, ok := parameters[AutoRegister]; ok {
 		var err error
-		result.ReturnInputData, err = strconv.ParseBool(value)
+		registry.AutoRegister, err = strconv.ParseBool(strings.TrimSpace(value))
 		if err != nil {
-			return result, "",
-				fmt.Errorf("HTTPExport Could not parse '%s' to a bool for '%s' parameter: %s",
-					value,
-					ReturnInputData,
-					err.Error())
+			app.lc.Errorf("Could not parse '%s' to a bool for '%s' parameter: %s", value, AutoRegister, err.Error())
+			return nil
 		}
 	}
 
-	result.URL = strings.TrimSpace(result.URL)
-	result.MimeType = strings.TrimSpace(result.MimeType)
-	result.HTTPHeaderName = strings.TrimSpace(parameters[HeaderName])
-	result.SecretPath = strings.TrimSpace(parameters[SecretPath])
-	result.SecretName = strings.TrimSpace(parameters[SecretName])
+	serializer, err := transforms.NewAvroSerializerWithRegistry(strings.TrimSpace(schema), registry)
+	if err != nil {
+		app.lc.Errorf("Unable to create TransformToAvro: %s", err.Error())
+		return nil
+	}
 
-	if len(result.HTTPHeaderName) == 0 && len(result.SecretPath) != 0 && len(result.SecretName) != 0 {
-		return result, "",
-			fmt.Errorf("HTTPExport missing %s since %s & %s are specified", HeaderName, SecretPath, SecretName)
+	return serializer.TransformToAvro
+}
+
+// ExtractSpectralFeatures computes the RMS, dominant frequency and frequency band energies over windows of the samples
+// of the Event's waveform readings and returns a feature Event for each full window.
+// This function is a configuration function and returns a function pointer.
+func (app *Configurable) ExtractSpectralFeatures(parameters map[string]string) interfaces.AppFunction {
+	value, ok := parameters[SampleRate]
+	if !ok {
+		app.lc.Errorf("Could not find '%s' parameter for ExtractSpectralFeatures", SampleRate)
+		return nil
 	}
-	if len(result.SecretPath) == 0 && len(result.HTTPHeaderName) != 0 && len(result.SecretName) != 0 {
-		return result, "",
-			fmt.Errorf("HTTPExport missing %s since %s & %s are specified", SecretPath, HeaderName, SecretName)
+
+	sampleRate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || sampleRate <= 0 {
+		app.lc.Errorf("Invalid '%s' parameter for ExtractSpectralFeatures, must be a number greater than 0", SampleRate)
+		return nil
 	}
-	if len(result.SecretName) == 0 && len(result.SecretPath) != 0 && len(result.HTTPHeaderName) != 0 {
-		return result, "",
-			fmt.Errorf("HTTPExport missing %s since %s & %s are specified", SecretName, SecretPath, HeaderName)
+
+	windowSize := 0
+	if value, ok := parameters[WindowSize]; ok {
+		windowSize, err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || windowSize < 0 {
+			app.lc.Errorf("Invalid '%s' parameter for ExtractSpectralFeatures, must be a number not less than 0", WindowSize)
+			return nil
+		}
 	}
 
-	return result, method, nil
+	var bands []transforms.FrequencyBand
+	if spec, ok := parameters[FrequencyBands]; ok {
+		for _, field := range util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma)) {
+			band, err := parseFrequencyBand(field)
+			if err != nil {
+				app.lc.Errorf("Invalid '%s' parameter for ExtractSpectralFeatures, must be a comma separated list of 'low-high' frequencies: %s",
+					FrequencyBands, err.Error())
+				return nil
+			}
+			bands = append(bands, band)
+		}
+	}
+
+	var resourceNames []string
+	if spec, ok := parameters[ResourceNames]; ok {
+		resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma))
+	}
+
+	transform := transforms.NewSpectralFeatures(sampleRate, windowSize, bands, resourceNames)
+	return transform.Extract
+}
+
+func parseFrequencyBand(spec string) (transforms.FrequencyBand, error) {
+	fields := strings.Split(spec, "-")
+	if len(fields) != 2 {
+		return transforms.FrequencyBand{}, fmt.Errorf("band '%s' must be in the form 'low-high'", spec)
+	}
+
+	low, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil {
+		return transforms.FrequencyBand{}, err
+	}
+
+	high, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return transforms.FrequencyBand{}, err
+	}
+
+	if low < 0 || high <= low {
+		return transforms.FrequencyBand{}, fmt.Errorf("band '%s' must have a low frequency not less than 0 and below the high frequency", spec)
+	}
+
+	return transforms.FrequencyBand{Low: low, High: high}, nil
+}
+
+func parseImageDimensions(spec string, separator string, count int) ([]int, error) {
+	fields := strings.Split(spec, separator)
+	if len(fields) != count {
+		return nil, fmt.Errorf("expected %d values", count)
+	}
+
+	values := make([]int, count)
+	for index, field := range fields {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("value %d must not be negative", value)
+		}
+		values[index] = value
+	}
+
+	return values, nil
 }
@@ -16,14 +16,21 @@
 package app
 
 import (
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 )
 
 func TestFilterByProfileName(t *testing.T) {
@@ -65,6 +72,9 @@ func TestFilterByDeviceName(t *testing.T) {
 		{"Valid Parameters", map[string]string{DeviceNames: "GS1-AC-Drive01, GS1-AC-Drive02, GS1-AC-Drive03"}, false},
 		{"Empty FilterOut Parameters", map[string]string{DeviceNames: "GS1-AC-Drive01, GS1-AC-Drive02, GS1-AC-Drive03", FilterOut: ""}, true},
 		{"Valid FilterOut Parameters", map[string]string{DeviceNames: "GS1-AC-Drive01, GS1-AC-Drive02, GS1-AC-Drive03", FilterOut: "true"}, false},
+		{"Valid NamePattern", map[string]string{NamePattern: "^GS1-AC-Drive"}, false},
+		{"Invalid NamePattern", map[string]string{NamePattern: "(GS1"}, true},
+		{"Valid DeviceGroup", map[string]string{DeviceGroup: "drives"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -117,6 +127,14 @@ func TestFilterByResourceName(t *testing.T) {
 		{"Valid Parameters", map[string]string{ResourceNames: "GS1-AC-Drive01, GS1-AC-Drive02, GS1-AC-Drive03"}, false},
 		{"Empty FilterOut Parameters", map[string]string{ResourceNames: "GS1-AC-Drive01, GS1-AC-Drive02, GS1-AC-Drive03", FilterOut: ""}, true},
 		{"Valid FilterOut Parameters", map[string]string{ResourceNames: "GS1-AC-Drive01, GS1-AC-Drive02, GS1-AC-Drive03", FilterOut: "true"}, false},
+		{"Valid NamePattern", map[string]string{NamePattern: "^GS1-AC-Drive0[1-3]$"}, false},
+		{"Invalid NamePattern", map[string]string{NamePattern: "^GS1-AC-Drive0[1-3$"}, true},
+		{"Valid Range", map[string]string{MinValue: "-10", MaxValue: "10.5"}, false},
+		{"Valid Equal", map[string]string{ResourceNames: "GS1-AC-Drive01", EqualValue: "100", Tolerance: "0.5"}, false},
+		{"Invalid MinValue", map[string]string{MinValue: "cold"}, true},
+		{"Min Greater Than Max", map[string]string{MinValue: "10", MaxValue: "5"}, true},
+		{"Tolerance Without EqualValue", map[string]string{MinValue: "10", Tolerance: "1"}, true},
+		{"Negative Tolerance", map[string]string{EqualValue: "10", Tolerance: "-1"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -153,6 +171,75 @@ func TestTransform(t *testing.T) {
 	}
 }
 
+func TestTransformXMLOptions(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name        string
+		Parameters  map[string]string
+		ExpectValid bool
+	}{
+		{"Good - element names", map[string]string{TransformType: "xml", EventElement: "Report", ReadingElement: "Value", ReadingsElement: "Values"}, true},
+		{"Good - field names and attributes", map[string]string{TransformType: "xml", FieldNames: "DeviceName:Station, ApiVersion:-", Attributes: "Id, DeviceName"}, true},
+		{"Bad - field names", map[string]string{TransformType: "xml", FieldNames: "DeviceName"}, false},
+		{"Bad - empty field name", map[string]string{TransformType: "xml", FieldNames: "DeviceName: "}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.Transform(test.Parameters)
+			assert.Equal(t, test.ExpectValid, transform != nil)
+		})
+	}
+}
+
+func TestTransformJSONOptions(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name        string
+		Parameters  map[string]string
+		ExpectValid bool
+	}{
+		{"Good - snake case", map[string]string{TransformType: "json", FieldNaming: "snake_case"}, true},
+		{"Good - omit empty and flatten tags", map[string]string{TransformType: "json", OmitEmpty: "true", FlattenTags: "true"}, true},
+		{"Bad - field naming", map[string]string{TransformType: "json", FieldNaming: "kebab-case"}, false},
+		{"Bad - omit empty", map[string]string{TransformType: "json", OmitEmpty: "yes please"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.Transform(test.Parameters)
+			assert.Equal(t, test.ExpectValid, transform != nil)
+		})
+	}
+}
+
+func TestFromXML(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name        string
+		Parameters  map[string]string
+		ExpectValid bool
+	}{
+		{"Good", map[string]string{ProfileName: "'mes'", DeviceName: "/Report/@station", SourceName: "'report'", Readings: "temperature:Float64:/Report/Value[@name='temperature'], line::/mes:Report/@line"}, true},
+		{"Good - origin", map[string]string{ProfileName: "'mes'", DeviceName: "'press-1'", SourceName: "'report'", Origin: "/Report/Timestamp", Readings: "line::/Report/@line"}, true},
+		{"Bad - missing device name", map[string]string{ProfileName: "'mes'", SourceName: "'report'", Readings: "line::/Report/@line"}, false},
+		{"Bad - missing readings", map[string]string{ProfileName: "'mes'", DeviceName: "'press-1'", SourceName: "'report'"}, false},
+		{"Bad - readings format", map[string]string{ProfileName: "'mes'", DeviceName: "'press-1'", SourceName: "'report'", Readings: "line:/Report"}, false},
+		{"Bad - value type", map[string]string{ProfileName: "'mes'", DeviceName: "'press-1'", SourceName: "'report'", Readings: "line:Binary:/Report"}, false},
+		{"Bad - XPath", map[string]string{ProfileName: "'mes'", DeviceName: "'press-1'", SourceName: "'report'", Readings: "line::/Report["}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.FromXML(test.Parameters)
+			assert.Equal(t, test.ExpectValid, transform != nil)
+		})
+	}
+}
+
 func TestHTTPExport(t *testing.T) {
 	configurable := Configurable{lc: lc}
 
@@ -247,8 +334,412 @@ func TestHTTPExport(t *testing.T) {
 			assert.Equal(t, test.ExpectValid, transform != nil)
 		})
 	}
+
+	chunked := map[string]string{ExportMethod: ExportMethodPost, Url: testUrl, MimeType: testMimeType, ChunkSize: "1024"}
+	assert.NotNil(t, configurable.HTTPExport(chunked), "chunked HTTPExport should not be nil")
+	chunked[ChunkSize] = "many"
+	assert.Nil(t, configurable.HTTPExport(chunked), "invalid chunk size should be rejected")
+}
+
+func TestHTTPExportWithFailover(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - only url", map[string]string{ExportMethod: ExportMethodPost, Url: "http://primary", MimeType: ""}, false},
+		{"Valid - fallbacks", map[string]string{ExportMethod: http.MethodPut, Url: "http://primary", MimeType: "",
+			FallbackUrls: "http://secondary, http://tertiary", FileDirectory: "/tmp/exports", PersistOnError: "true"}, false},
+		{"Invalid - no url", map[string]string{ExportMethod: ExportMethodPost, MimeType: "", FallbackUrls: "http://secondary"}, true},
+		{"Invalid - bad method", map[string]string{ExportMethod: "get", Url: "http://primary", MimeType: ""}, true},
+		{"Invalid - continue on send error", map[string]string{ExportMethod: ExportMethodPost, Url: "http://primary", MimeType: "",
+			ContinueOnSendError: "true", ReturnInputData: "true"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.HTTPExportWithFailover(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestHTTPBatchPost(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - only url", map[string]string{Url: "http://primary"}, false},
+		{"Valid - all parameters", map[string]string{Url: "http://primary", BatchFormat: "ndjson", CompressBatch: "true",
+			Retries: "0", MaxRetryAfter: "30s", PersistOnError: "true", Headers: "X-Site:{site}", FailureThreshold: "3"}, false},
+		{"Invalid - no url", map[string]string{BatchFormat: "json"}, true},
+		{"Invalid - bad format", map[string]string{Url: "http://primary", BatchFormat: "csv"}, true},
+		{"Invalid - bad compress", map[string]string{Url: "http://primary", CompressBatch: "bogus"}, true},
+		{"Invalid - bad retries", map[string]string{Url: "http://primary", Retries: "-1"}, true},
+		{"Invalid - bad max retry after", map[string]string{Url: "http://primary", MaxRetryAfter: "0s"}, true},
+		{"Invalid - bad auth mode", map[string]string{Url: "http://primary", AuthMode: "digest"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.HTTPBatchPost(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestGCPPubSubExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.GCPPubSubExport(map[string]string{ProjectId: "project", Topic: "readings-{profile}",
+		SecretPath: "gcp", OrderingKey: "{device}", PersistOnError: "true", FailureThreshold: "3"}))
+	assert.Nil(t, configurable.GCPPubSubExport(map[string]string{Topic: "readings", SecretPath: "gcp"}))
+	assert.Nil(t, configurable.GCPPubSubExport(map[string]string{ProjectId: "project", Topic: "readings"}))
+	assert.Nil(t, configurable.GCPPubSubExport(map[string]string{ProjectId: "project", Topic: "readings", SecretPath: "gcp",
+		PersistOnError: "bogus"}))
+}
+
+func TestGCPIoTCoreExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	valid := map[string]string{ProjectId: "project", Region: "us-central1", RegistryId: "registry", DeviceId: "gateway",
+		SecretPath: "device", Qos: "1", TokenLifetime: "20m", PersistOnError: "true"}
+	assert.NotNil(t, configurable.GCPIoTCoreExport(valid))
+
+	tests := []struct {
+		Name      string
+		Parameter string
+		Value     string
+	}{
+		{"No device id", DeviceId, ""},
+		{"Bad QoS", Qos, "2"},
+		{"Bad token lifetime", TokenLifetime, "soon"},
+		{"Bad persist on error", PersistOnError, "bogus"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			parameters[test.Parameter] = test.Value
+			assert.Nil(t, configurable.GCPIoTCoreExport(parameters))
+		})
+	}
+}
+
+func TestObjectStorageExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - AWS", map[string]string{Bucket: "archive", ObjectKey: "{date}/{device}/{uuid}.json", SecretPath: "s3"}, false},
+		{"Valid - MinIO batch", map[string]string{Endpoint: "http://minio:9000", Bucket: "archive", ObjectKey: "{uuid}.ndjson",
+			SecretPath: "s3", PathStyle: "true", BatchFormat: "ndjson", CompressBatch: "true", PersistOnError: "true"}, false},
+		{"Invalid - no bucket", map[string]string{ObjectKey: "{uuid}.json", SecretPath: "s3"}, true},
+		{"Invalid - no object key", map[string]string{Bucket: "archive", SecretPath: "s3"}, true},
+		{"Invalid - bad path style", map[string]string{Bucket: "archive", ObjectKey: "{uuid}.json", SecretPath: "s3", PathStyle: "bogus"}, true},
+		{"Invalid - bad format", map[string]string{Bucket: "archive", ObjectKey: "{uuid}.json", SecretPath: "s3", BatchFormat: "csv"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.ObjectStorageExport(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestAzureBlobExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - SAS block", map[string]string{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry",
+			BlobName: "{date}/{uuid}.json", SecretPath: "azure"}, false},
+		{"Valid - managed identity append", map[string]string{AccountURL: "https://account.blob.core.windows.net",
+			Container: "telemetry", BlobName: "{device}/{date}.ndjson", BlobType: "append", AuthMode: "managedidentity",
+			ClientID: "client-1", CompressBatch: "true", PersistOnError: "true"}, false},
+		{"Invalid - no container", map[string]string{AccountURL: "https://account.blob.core.windows.net", BlobName: "{uuid}.json",
+			SecretPath: "azure"}, true},
+		{"Invalid - bad blob type", map[string]string{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry",
+			BlobName: "{uuid}.json", SecretPath: "azure", BlobType: "page"}, true},
+		{"Invalid - bad persist on error", map[string]string{AccountURL: "https://account.blob.core.windows.net",
+			Container: "telemetry", BlobName: "{uuid}.json", SecretPath: "azure", PersistOnError: "bogus"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.AzureBlobExport(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestPostgresWrite(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	valid := map[string]string{Host: "timescale", Database: "telemetry", SecretPath: "postgres", Table: "readings"}
+	assert.NotNil(t, configurable.PostgresWrite(valid))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - all parameters", map[string]string{Port: "5433", SSLMode: "disable", BatchSize: "100", UseCopy: "true",
+			Columns: "device:device_name, name:resource, value:value, origin:time, tags:tags", PersistOnError: "true"}, false},
+		{"Invalid - no host", map[string]string{Host: ""}, true},
+		{"Invalid - bad port", map[string]string{Port: "-1"}, true},
+		{"Invalid - bad batch size", map[string]string{BatchSize: "many"}, true},
+		{"Invalid - bad columns", map[string]string{Columns: "device"}, true},
+		{"Invalid - unknown field", map[string]string{Columns: "units:units"}, true},
+		{"Invalid - bad use copy", map[string]string{UseCopy: "bogus"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			for key, value := range test.Parameters {
+				parameters[key] = value
+			}
+
+			transform := configurable.PostgresWrite(parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestOPCUAWrite(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	valid := map[string]string{Endpoint: "opc.tcp://plc:4840", Nodes: "pump-1/speed:ns=2;s=Pump1.Speed, running:ns=2;i=1001"}
+	assert.NotNil(t, configurable.OPCUAWrite(valid))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - all parameters", map[string]string{SecurityPolicy: "Basic256Sha256", SecurityMode: "SignAndEncrypt",
+			SecretPath: "opcua", WriteTimeout: "5s", PersistOnError: "true"}, false},
+		{"Invalid - no endpoint", map[string]string{Endpoint: ""}, true},
+		{"Invalid - no nodes", map[string]string{Nodes: " "}, true},
+		{"Invalid - bad nodes", map[string]string{Nodes: "speed"}, true},
+		{"Invalid - bad node id", map[string]string{Nodes: "speed:ns=x"}, true},
+		{"Invalid - bad security mode", map[string]string{SecurityMode: "Encrypt"}, true},
+		{"Invalid - bad timeout", map[string]string{WriteTimeout: "10"}, true},
+		{"Invalid - bad persist on error", map[string]string{PersistOnError: "bogus"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			for key, value := range test.Parameters {
+				parameters[key] = value
+			}
+
+			transform := configurable.OPCUAWrite(parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestEmailNotification(t *testing.T) {
+	configurable := Configurable{lc: lc, appSettings: map[string]string{"alertEmail": "{{.deviceName}} alarm"}}
+
+	valid := map[string]string{Host: "smtp.example.com", From: "edgex@example.com", To: "ops@example.com, oncall@example.com",
+		Subject: "Alarm from {{.deviceName}}"}
+	assert.NotNil(t, configurable.EmailNotification(valid))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - inline template", map[string]string{Template: "{{json .}}", Port: "465", ImplicitTLS: "true",
+			MimeType: "text/html", SecretPath: "smtp", PersistOnError: "true"}, false},
+		{"Valid - template setting", map[string]string{TemplateSetting: "alertEmail"}, false},
+		{"Invalid - both templates", map[string]string{Template: "{{json .}}", TemplateSetting: "alertEmail"}, true},
+		{"Invalid - missing template setting", map[string]string{TemplateSetting: "unknown"}, true},
+		{"Invalid - no host", map[string]string{Host: ""}, true},
+		{"Invalid - no recipients", map[string]string{To: " , "}, true},
+		{"Invalid - bad port", map[string]string{Port: "smtp"}, true},
+		{"Invalid - bad implicit TLS", map[string]string{ImplicitTLS: "bogus"}, true},
+		{"Invalid - bad subject", map[string]string{Subject: "{{.deviceName"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			for key, value := range test.Parameters {
+				parameters[key] = value
+			}
+
+			transform := configurable.EmailNotification(parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestWebhookNotification(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.WebhookNotification(map[string]string{Url: "https://hooks.example.com/alerts"}))
+	assert.NotNil(t, configurable.WebhookNotification(map[string]string{Url: "https://hooks.example.com/alerts",
+		Template: `{"text": "{{.deviceName}} alarm"}`, Headers: "X-Source:edgex", PersistOnError: "true"}))
+	assert.Nil(t, configurable.WebhookNotification(map[string]string{Url: " "}))
+	assert.Nil(t, configurable.WebhookNotification(map[string]string{Url: "https://hooks.example.com/alerts", Template: "{{.text"}))
+	assert.Nil(t, configurable.WebhookNotification(map[string]string{Url: "https://hooks.example.com/alerts", PersistOnError: "bogus"}))
+}
+
+func TestSMSNotification(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	valid := map[string]string{AccountSid: "AC123", From: "+15550001111", To: "+15552223333, +15554445555", SecretPath: "twilio"}
+	assert.NotNil(t, configurable.SMSNotification(valid))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - all parameters", map[string]string{Template: "{{.deviceName}} alarm", Url: "https://twilio.example.com",
+			PersistOnError: "true", FailureThreshold: "3"}, false},
+		{"Invalid - no account sid", map[string]string{AccountSid: ""}, true},
+		{"Invalid - no recipients", map[string]string{To: ""}, true},
+		{"Invalid - no secret path", map[string]string{SecretPath: ""}, true},
+		{"Invalid - bad persist on error", map[string]string{PersistOnError: "bogus"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			for key, value := range test.Parameters {
+				parameters[key] = value
+			}
+
+			transform := configurable.SMSNotification(parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestElasticsearchExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	valid := map[string]string{Url: "https://elasticsearch:9200"}
+	assert.NotNil(t, configurable.ElasticsearchExport(valid))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - all parameters", map[string]string{Index: "edgex-{device}-{date}", AuthMode: "apikey", SecretPath: "elasticsearch",
+			IngestPipeline: "edgex", DataStream: "true", PersistOnError: "true", FailureThreshold: "3"}, false},
+		{"Invalid - no url", map[string]string{Url: " "}, true},
+		{"Invalid - bad index", map[string]string{Index: "_edgex"}, true},
+		{"Invalid - auth without secret path", map[string]string{AuthMode: "basic"}, true},
+		{"Invalid - bad data stream", map[string]string{DataStream: "bogus"}, true},
+		{"Invalid - bad persist on error", map[string]string{PersistOnError: "bogus"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			for key, value := range test.Parameters {
+				parameters[key] = value
+			}
+
+			transform := configurable.ElasticsearchExport(parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestCoAPExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	valid := map[string]string{Url: "coap://collector/telemetry"}
+	assert.NotNil(t, configurable.CoAPExport(valid))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - all parameters", map[string]string{Url: "coaps://collector/{deviceName}", ExportMethod: "put", MimeType: "application/cbor",
+			Security: "psk", SecretPath: "coap", SkipVerify: "true", WriteTimeout: "5s", BlockSize: "256", PersistOnError: "true", FailureThreshold: "3"}, false},
+		{"Invalid - no url", map[string]string{Url: " "}, true},
+		{"Invalid - http url", map[string]string{Url: "http://collector/telemetry"}, true},
+		{"Invalid - bad method", map[string]string{ExportMethod: "get"}, true},
+		{"Invalid - bad mime type", map[string]string{MimeType: "image/png"}, true},
+		{"Invalid - coaps without security", map[string]string{Url: "coaps://collector/telemetry"}, true},
+		{"Invalid - security without secret path", map[string]string{Url: "coaps://collector/telemetry", Security: "cert"}, true},
+		{"Invalid - bad timeout", map[string]string{WriteTimeout: "bogus"}, true},
+		{"Invalid - bad block size", map[string]string{BlockSize: "100"}, true},
+		{"Invalid - bad persist on error", map[string]string{PersistOnError: "bogus"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			parameters := make(map[string]string)
+			for key, value := range valid {
+				parameters[key] = value
+			}
+			for key, value := range test.Parameters {
+				parameters[key] = value
+			}
+
+			transform := configurable.CoAPExport(parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestFileExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.FileExport(map[string]string{FileDirectory: "/tmp/exports"}))
+	assert.Nil(t, configurable.FileExport(map[string]string{FileDirectory: " "}))
 }
 
+func TestLoopbackExport(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.LoopbackExport(map[string]string{Topic: "stage2/{devicename}"}))
+	assert.NotNil(t, configurable.LoopbackExport(map[string]string{Topic: "stage2", PersistOnError: "true"}))
+	assert.Nil(t, configurable.LoopbackExport(map[string]string{Topic: " "}))
+	assert.Nil(t, configurable.LoopbackExport(map[string]string{Topic: "stage2", PersistOnError: "bogus"}))
+}
 func TestSetOutputData(t *testing.T) {
 	configurable := Configurable{lc: lc}
 
@@ -310,6 +801,39 @@ func TestBatchByTimeAndCount(t *testing.T) {
 	assert.NotNil(t, trx, "return result for BatchByTimeAndCount should not be nil")
 }
 
+func TestBatchByCountOrTime(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	params := make(map[string]string)
+	params[Mode] = BatchByCountOrTime
+	params[BatchThreshold] = "30"
+	params[TimeInterval] = "10s"
+	params[IsEventData] = "true"
+
+	trx := configurable.Batch(params)
+	assert.NotNil(t, trx, "return result for BatchByCountOrTime should not be nil")
+
+	params[BatchThreshold] = "0"
+	trx = configurable.Batch(params)
+	assert.Nil(t, trx, "return result for BatchByCountOrTime with zero threshold should be nil")
+}
+
+func TestBatchAlignFlush(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	params := make(map[string]string)
+	params[Mode] = BatchByTime
+	params[TimeInterval] = "15m"
+	params[AlignFlush] = "true"
+
+	trx := configurable.Batch(params)
+	assert.NotNil(t, trx, "return result for BatchByTime with AlignFlush should not be nil")
+
+	params[AlignFlush] = "maybe"
+	trx = configurable.Batch(params)
+	assert.Nil(t, trx, "return result for BatchByTime with invalid AlignFlush should be nil")
+}
+
 func TestJSONLogic(t *testing.T) {
 	params := make(map[string]string)
 	params[Rule] = "{}"
@@ -321,6 +845,47 @@ func TestJSONLogic(t *testing.T) {
 
 }
 
+func TestEvaluateRules(t *testing.T) {
+	exportAction := sdkCommon.RuleActionInfo{Type: "export", Parameters: map[string]string{"Url": "http://localhost"}}
+	rules := map[string]sdkCommon.RuleInfo{
+		"high": {Condition: `{ ">" : [ { "var" : "value" }, 10 ] }`, Actions: map[string]sdkCommon.RuleActionInfo{"export": exportAction}},
+		"low":  {Condition: `{ "<" : [ { "var" : "value" }, 0 ] }`, Actions: map[string]sdkCommon.RuleActionInfo{"export": exportAction}},
+		"bad":  {Condition: `{ "<" : [ { "var" : "value" }, 0 ] }`, Actions: map[string]sdkCommon.RuleActionInfo{"email": {Type: "email"}}},
+	}
+
+	tests := []struct {
+		Name      string
+		Rules     map[string]sdkCommon.RuleInfo
+		RuleNames string
+		ExpectNil bool
+	}{
+		{"Valid - selected rules", rules, "high, low", false},
+		{"Valid - all rules", map[string]sdkCommon.RuleInfo{"high": rules["high"]}, "", false},
+		{"Invalid - rule not found", rules, "high, missing", true},
+		{"Invalid - bad action", rules, "bad", true},
+		{"Invalid - invalid rule in all rules", rules, "", true},
+		{"Invalid - no rules", nil, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configurable := Configurable{lc: lc, rules: test.Rules}
+
+			params := make(map[string]string)
+			if test.RuleNames != "" {
+				params[RuleNames] = test.RuleNames
+			}
+
+			trx := configurable.EvaluateRules(params)
+			if test.ExpectNil {
+				assert.Nil(t, trx, "return result from EvaluateRules should be nil")
+			} else {
+				assert.NotNil(t, trx, "return result from EvaluateRules should not be nil")
+			}
+		})
+	}
+}
+
 func TestMQTTExport(t *testing.T) {
 	configurable := Configurable{lc: lc}
 
@@ -340,9 +905,86 @@ func TestMQTTExport(t *testing.T) {
 
 	trx := configurable.MQTTExport(params)
 	assert.NotNil(t, trx, "return result from MQTTSecretSend should not be nil")
-}
 
-func TestAddTags(t *testing.T) {
+	params[ChunkSize] = "1024"
+	assert.NotNil(t, configurable.MQTTExport(params), "chunked MQTTExport should not be nil")
+	params[ChunkSize] = "10"
+	assert.Nil(t, configurable.MQTTExport(params), "chunk size smaller than the header should be rejected")
+	delete(params, ChunkSize)
+
+	params[FailureThreshold] = "3"
+	params[ProbeInterval] = "1m"
+	assert.NotNil(t, configurable.MQTTExport(params), "MQTTExport with circuit breaker should not be nil")
+	params[ProbeInterval] = "later"
+	assert.Nil(t, configurable.MQTTExport(params), "bad probe interval should be rejected")
+	delete(params, ProbeInterval)
+
+	params[Qos] = "1"
+	params[PersistentSession] = "true"
+	params[StoreDirectory] = "/tmp/mqtt-export"
+	params[InflightWindow] = "10"
+	params[SharedConnection] = "true"
+	assert.NotNil(t, configurable.MQTTExport(params), "MQTTExport with persistent session should not be nil")
+	params[InflightWindow] = "-1"
+	assert.Nil(t, configurable.MQTTExport(params), "negative inflight window should be rejected")
+	params[InflightWindow] = "10"
+	params[ClientID] = ""
+	assert.Nil(t, configurable.MQTTExport(params), "persistent session without a client id should be rejected")
+	params[ClientID] = "clientid"
+	params[PersistentSession] = "false"
+	assert.Nil(t, configurable.MQTTExport(params), "store directory without a persistent session should be rejected")
+	params[PersistentSession] = "maybe"
+	assert.Nil(t, configurable.MQTTExport(params), "bad persistent session should be rejected")
+}
+
+func TestProcessCircuitBreaker(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name          string
+		Parameters    map[string]string
+		ExpectBreaker bool
+		ExpectOk      bool
+	}{
+		{"No breaker", map[string]string{}, false, true},
+		{"Default interval", map[string]string{FailureThreshold: "5"}, true, true},
+		{"Interval", map[string]string{FailureThreshold: "5", ProbeInterval: "10s"}, true, true},
+		{"Bad threshold", map[string]string{FailureThreshold: "0"}, false, false},
+		{"Bad interval", map[string]string{FailureThreshold: "5", ProbeInterval: "-1s"}, false, false},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			breaker, ok := configurable.processCircuitBreaker("HTTPExport", testCase.Parameters)
+			assert.Equal(t, testCase.ExpectOk, ok)
+			assert.Equal(t, testCase.ExpectBreaker, breaker != nil)
+		})
+	}
+}
+
+func TestReassembleChunks(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Default timeout", map[string]string{}, false},
+		{"Timeout", map[string]string{ChunkTimeout: "30s"}, false},
+		{"Bad timeout", map[string]string{ChunkTimeout: "soon"}, true},
+		{"Zero timeout", map[string]string{ChunkTimeout: "0s"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.ReassembleChunks(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestAddTags(t *testing.T) {
 	configurable := Configurable{lc: lc}
 
 	tests := []struct {
@@ -371,6 +1013,703 @@ func TestAddTags(t *testing.T) {
 	}
 }
 
+func TestONNXInference(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - model only", map[string]string{ModelLocation: "/models/model.onnx"}, false},
+		{"Good - all parameters", map[string]string{ModelLocation: "https://models/model.onnx", ResourceNames: "x, y", PredictionName: "score"}, false},
+		{"Bad - no model", map[string]string{ResourceNames: "x"}, true},
+		{"Bad - empty model", map[string]string{ModelLocation: " "}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ONNXInference(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestProcessImages(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - all operations", map[string]string{ImageCrop: "0, 0, 640, 480", ImageResize: "224x224", ImageGrayscale: "true", ImageFormat: "JPEG", ImageQuality: "90"}, false},
+		{"Good - resize keeping aspect ratio", map[string]string{ImageResize: "320X0", ResourceNames: "frame"}, false},
+		{"Good - format only", map[string]string{ImageFormat: "png"}, false},
+		{"Bad - no operations", map[string]string{ImageGrayscale: "false"}, true},
+		{"Bad - crop", map[string]string{ImageCrop: "0,0,640"}, true},
+		{"Bad - negative crop", map[string]string{ImageCrop: "-1,0,640,480"}, true},
+		{"Bad - resize", map[string]string{ImageResize: "224"}, true},
+		{"Bad - zero resize", map[string]string{ImageResize: "0x0"}, true},
+		{"Bad - grayscale", map[string]string{ImageGrayscale: "yes please"}, true},
+		{"Bad - format", map[string]string{ImageFormat: "gif"}, true},
+		{"Bad - quality", map[string]string{ImageFormat: "jpeg", ImageQuality: "101"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ProcessImages(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestExtractSpectralFeatures(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - all parameters", map[string]string{SampleRate: "1000", WindowSize: "256", FrequencyBands: "0-10, 10-100,100.5-500", ResourceNames: "vibration"}, false},
+		{"Good - sample rate only", map[string]string{SampleRate: "25.6"}, false},
+		{"Bad - no sample rate", map[string]string{WindowSize: "256"}, true},
+		{"Bad - sample rate", map[string]string{SampleRate: "0"}, true},
+		{"Bad - window size", map[string]string{SampleRate: "1000", WindowSize: "-1"}, true},
+		{"Bad - band", map[string]string{SampleRate: "1000", FrequencyBands: "0-10,100"}, true},
+		{"Bad - band order", map[string]string{SampleRate: "1000", FrequencyBands: "100-10"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ExtractSpectralFeatures(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - EWMA", map[string]string{Algorithm: "EWMA", Alpha: "0.2", ResourceNames: "temperature, humidity"}, false},
+		{"Good - Kalman", map[string]string{Algorithm: SmoothKalman, ProcessNoise: "0.01", MeasurementNoise: "4"}, false},
+		{"Bad - no algorithm", map[string]string{Alpha: "0.2"}, true},
+		{"Bad - algorithm", map[string]string{Algorithm: "median"}, true},
+		{"Bad - no alpha", map[string]string{Algorithm: SmoothEWMA}, true},
+		{"Bad - alpha", map[string]string{Algorithm: SmoothEWMA, Alpha: "1.5"}, true},
+		{"Bad - process noise", map[string]string{Algorithm: SmoothKalman, ProcessNoise: "-1", MeasurementNoise: "4"}, true},
+		{"Bad - measurement noise", map[string]string{Algorithm: SmoothKalman, ProcessNoise: "0.01", MeasurementNoise: "0"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.Smooth(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - z-score", map[string]string{Algorithm: "ZScore", WindowSize: "30", Threshold: "3", ResourceNames: "temperature"}, false},
+		{"Good - EWMA drop", map[string]string{Algorithm: AnomalyEWMA, Alpha: "0.1", Threshold: "2.5", Mode: "Drop"}, false},
+		{"Bad - no algorithm", map[string]string{WindowSize: "30", Threshold: "3"}, true},
+		{"Bad - algorithm", map[string]string{Algorithm: "iqr", Threshold: "3"}, true},
+		{"Bad - no threshold", map[string]string{Algorithm: AnomalyZScore, WindowSize: "30"}, true},
+		{"Bad - threshold", map[string]string{Algorithm: AnomalyZScore, WindowSize: "30", Threshold: "-1"}, true},
+		{"Bad - window size", map[string]string{Algorithm: AnomalyZScore, WindowSize: "1", Threshold: "3"}, true},
+		{"Bad - alpha", map[string]string{Algorithm: AnomalyEWMA, Alpha: "1", Threshold: "3"}, true},
+		{"Bad - mode", map[string]string{Algorithm: AnomalyEWMA, Alpha: "0.1", Threshold: "3", Mode: "alert"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.DetectAnomalies(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestFilterOnChange(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - any change", map[string]string{}, false},
+		{"Good - delta and hysteresis", map[string]string{Delta: "0.5", Hysteresis: " 1 ", ResourceNames: "level, pressure"}, false},
+		{"Bad - delta", map[string]string{Delta: "half"}, true},
+		{"Bad - negative hysteresis", map[string]string{Delta: "0.5", Hysteresis: "-1"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.FilterOnChange(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestSessionWindow(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good", map[string]string{SessionGap: "5m"}, false},
+		{"Good - resource names", map[string]string{SessionGap: "30s", ResourceNames: "power, current"}, false},
+		{"Bad - no gap", map[string]string{ResourceNames: "power"}, true},
+		{"Bad - gap", map[string]string{SessionGap: "five minutes"}, true},
+		{"Bad - zero gap", map[string]string{SessionGap: "0s"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.SessionWindow(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - tumbling default", map[string]string{Window: "1m"}, false},
+		{"Good - sliding", map[string]string{Window: "30s", WindowType: "Sliding", AggregateFunctions: "min, max", ResourceNames: "temperature"}, false},
+		{"Bad - no window", map[string]string{WindowType: TumblingWindow}, true},
+		{"Bad - window", map[string]string{Window: "a minute"}, true},
+		{"Bad - zero window", map[string]string{Window: "0s"}, true},
+		{"Bad - window type", map[string]string{Window: "1m", WindowType: "hopping"}, true},
+		{"Bad - function", map[string]string{Window: "1m", AggregateFunctions: "median"}, true},
+		{"Good - align flush", map[string]string{Window: "15m", AlignFlush: "true"}, false},
+		{"Bad - align flush", map[string]string{Window: "15m", AlignFlush: "maybe"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.Aggregate(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good", map[string]string{Statement: "SELECT deviceName, avg(value) WHERE resourceName = 'temperature' GROUP BY deviceName WINDOW '1m'"}, false},
+		{"Bad - no statement", map[string]string{}, true},
+		{"Bad - statement", map[string]string{Statement: "SELECT FROM readings"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.Query(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good", map[string]string{Expression: "$.readings[?(@.resourceName == 'temperature')].value"}, false},
+		{"Bad - no expression", map[string]string{}, true},
+		{"Bad - expression", map[string]string{Expression: "readings[0]"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			filter := configurable.FilterByJSONPath(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, filter == nil)
+
+			extract := configurable.ExtractByJSONPath(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, extract == nil)
+		})
+	}
+}
+
+func TestTransformWithTemplate(t *testing.T) {
+	configurable := Configurable{
+		lc:          lc,
+		appSettings: map[string]string{"PayloadTemplate": `{"device":"{{.DeviceName}}"}`, "BadTemplate": "{{.DeviceName"},
+	}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - inline", map[string]string{Template: "{{.DeviceName}}"}, false},
+		{"Good - setting", map[string]string{TemplateSetting: " PayloadTemplate "}, false},
+		{"Bad - no template", map[string]string{}, true},
+		{"Bad - both", map[string]string{Template: "{{.DeviceName}}", TemplateSetting: "PayloadTemplate"}, true},
+		{"Bad - missing setting", map[string]string{TemplateSetting: "Unknown"}, true},
+		{"Bad - inline template", map[string]string{Template: "{{.DeviceName"}, true},
+		{"Bad - setting template", map[string]string{TemplateSetting: "BadTemplate"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.TransformWithTemplate(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestProtobuf(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:        proto.String("telemetry/measurement.proto"),
+				Package:     proto.String("telemetry"),
+				Syntax:      proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Measurement")}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	descriptorFile := filepath.Join(t.TempDir(), "measurement.pb")
+	require.NoError(t, ioutil.WriteFile(descriptorFile, descriptorSet, 0600))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - Event", map[string]string{}, false},
+		{"Good - registered message", map[string]string{MessageName: "google.protobuf.Struct"}, false},
+		{"Good - descriptor set", map[string]string{MessageName: "telemetry.Measurement", DescriptorSet: descriptorFile}, false},
+		{"Bad - unknown message", map[string]string{MessageName: "telemetry.Measurement"}, true},
+		{"Bad - no message name", map[string]string{DescriptorSet: descriptorFile}, true},
+		{"Bad - missing file", map[string]string{MessageName: "telemetry.Measurement", DescriptorSet: descriptorFile + ".missing"}, true},
+		{"Bad - message not in set", map[string]string{MessageName: "telemetry.Unknown", DescriptorSet: descriptorFile}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			to := configurable.TransformToProtobuf(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, to == nil)
+
+			from := configurable.TransformFromProtobuf(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, from == nil)
+		})
+	}
+}
+
+func TestForwardToAppService(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - HTTP", map[string]string{TargetAddress: "https://site-server:59700", SecretPath: "mtls"}, false},
+		{"Good - MQTT", map[string]string{TargetAddress: "mqtts://site-server:8883", Topic: "edgex/events", MessageBus: "true", Qos: "1", SkipVerify: "false"}, false},
+		{"Bad - no target", map[string]string{}, true},
+		{"Bad - target", map[string]string{TargetAddress: "site-server"}, true},
+		{"Bad - no topic", map[string]string{TargetAddress: "mqtts://site-server:8883"}, true},
+		{"Bad - message bus", map[string]string{TargetAddress: "mqtts://site-server:8883", Topic: "edgex/events", MessageBus: "yes please"}, true},
+		{"Bad - skip verify", map[string]string{TargetAddress: "https://site-server:59700", SkipVerify: "maybe"}, true},
+		{"Bad - qos", map[string]string{TargetAddress: "mqtts://site-server:8883", Topic: "edgex/events", Qos: "3"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ForwardToAppService(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestTransformToAvro(t *testing.T) {
+	schema := `{"type": "record", "name": "Reading", "fields": [{"name": "value", "type": "double"}]}`
+	configurable := Configurable{
+		lc:          lc,
+		appSettings: map[string]string{"ReadingSchema": schema, "BadSchema": `{"type": "record"}`},
+	}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - inline", map[string]string{Schema: schema}, false},
+		{"Good - setting", map[string]string{SchemaSetting: " ReadingSchema "}, false},
+		{"Good - registry", map[string]string{SchemaSetting: "ReadingSchema", RegistryURL: "http://schema-registry:8081", Subject: "readings-value", AutoRegister: "true", SecretPath: "registry"}, false},
+		{"Good - registry latest", map[string]string{RegistryURL: "http://schema-registry:8081", Subject: "readings-value"}, false},
+		{"Bad - no schema", map[string]string{}, true},
+		{"Bad - both", map[string]string{Schema: schema, SchemaSetting: "ReadingSchema"}, true},
+		{"Bad - missing setting", map[string]string{SchemaSetting: "Unknown"}, true},
+		{"Bad - schema", map[string]string{SchemaSetting: "BadSchema"}, true},
+		{"Bad - no subject", map[string]string{Schema: schema, RegistryURL: "http://schema-registry:8081"}, true},
+		{"Bad - auto register", map[string]string{Schema: schema, RegistryURL: "http://schema-registry:8081", Subject: "readings-value", AutoRegister: "always"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.TransformToAvro(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - no parameters", map[string]string{}, false},
+		{"Good - all parameters", map[string]string{Window: "15m", MaxEntries: "500", PersistFile: "/tmp/dedupe.json"}, false},
+		{"Bad - window", map[string]string{Window: "fifteen minutes"}, true},
+		{"Bad - negative window", map[string]string{Window: "-1m"}, true},
+		{"Bad - max entries", map[string]string{MaxEntries: "0"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.DedupeByChecksum(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+			transform = configurable.DedupeByReadingValue(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestExportGuardFunctions(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.SkipExported(map[string]string{}))
+	assert.NotNil(t, configurable.MarkExported(map[string]string{}))
+}
+
+func TestThrottle(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - drop", map[string]string{Limit: "10", TimeInterval: "1s"}, false},
+		{"Good - queue", map[string]string{Limit: "10", TimeInterval: "1m", Mode: "Queue", MaxQueued: "100"}, false},
+		{"Good - queue default max", map[string]string{Limit: "10", TimeInterval: "1m", Mode: ThrottleQueue}, false},
+		{"Bad - no limit", map[string]string{TimeInterval: "1s"}, true},
+		{"Bad - limit", map[string]string{Limit: "0", TimeInterval: "1s"}, true},
+		{"Bad - no interval", map[string]string{Limit: "10"}, true},
+		{"Bad - interval", map[string]string{Limit: "10", TimeInterval: "second"}, true},
+		{"Bad - mode", map[string]string{Limit: "10", TimeInterval: "1s", Mode: "sample"}, true},
+		{"Bad - max queued", map[string]string{Limit: "10", TimeInterval: "1s", Mode: ThrottleQueue, MaxQueued: "-1"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.Throttle(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestEnforceQuota(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - defaults", map[string]string{Limit: "1000000"}, false},
+		{"Good - all", map[string]string{Destination: "cloud", Limit: "5000", Unit: "Messages", Period: "Month",
+			Mode: "Downsample", DownsampleRate: "5", PersistFile: "/tmp/quota.json"}, false},
+		{"Good - store", map[string]string{Limit: "1000000", Mode: "store"}, false},
+		{"Bad - no limit", map[string]string{Unit: "bytes"}, true},
+		{"Bad - limit", map[string]string{Limit: "lots"}, true},
+		{"Bad - zero limit", map[string]string{Limit: "0"}, true},
+		{"Bad - unit", map[string]string{Limit: "10", Unit: "kb"}, true},
+		{"Bad - period", map[string]string{Limit: "10", Period: "week"}, true},
+		{"Bad - mode", map[string]string{Limit: "10", Mode: "queue"}, true},
+		{"Bad - downsample rate", map[string]string{Limit: "10", Mode: "downsample", DownsampleRate: "every"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.EnforceQuota(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestDropStale(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - origin", map[string]string{MaxAge: "30s"}, false},
+		{"Good - received", map[string]string{MaxAge: "5m", AgeBasis: "Received", StaleUrl: "http://localhost/late"}, false},
+		{"Bad - no max age", map[string]string{AgeBasis: AgeByOrigin}, true},
+		{"Bad - max age", map[string]string{MaxAge: "-1s"}, true},
+		{"Bad - age basis", map[string]string{MaxAge: "30s", AgeBasis: "sent"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.DropStale(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestConvertUnits(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good", map[string]string{Conversions: "TemperatureF:°F:°C:TemperatureC, Pressure:psi:kPa"}, false},
+		{"Bad - no conversions", map[string]string{}, true},
+		{"Bad - empty conversions", map[string]string{Conversions: " , "}, true},
+		{"Bad - format", map[string]string{Conversions: "Pressure:psi"}, true},
+		{"Bad - unit", map[string]string{Conversions: "Pressure:psi:furlong"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ConvertUnits(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestEnrichWithDeviceMetadata(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - defaults", map[string]string{}, false},
+		{"Good - fields and TTL", map[string]string{MetadataFields: "labels, profileName", CacheTTL: "10m"}, false},
+		{"Bad - field", map[string]string{MetadataFields: "labels, firmware"}, true},
+		{"Bad - TTL", map[string]string{CacheTTL: "forever"}, true},
+		{"Bad - zero TTL", map[string]string{CacheTTL: "0s"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.EnrichWithDeviceMetadata(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestBulkCommand(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Valid - set", map[string]string{DeviceNames: "fan-1, fan-2", CommandName: "Switch", CommandSettings: "State:on, Speed:3"}, false},
+		{"Valid - get group", map[string]string{DeviceGroup: "fans", CommandName: "State", CommandMethod: "get", MaxConcurrent: "5"}, false},
+		{"Valid - devices in request", map[string]string{CommandName: "Switch"}, false},
+		{"Invalid - no command", map[string]string{DeviceNames: "fan-1"}, true},
+		{"Invalid - bad method", map[string]string{CommandName: "Switch", CommandMethod: "delete"}, true},
+		{"Invalid - bad settings", map[string]string{CommandName: "Switch", CommandSettings: "State"}, true},
+		{"Invalid - bad max concurrent", map[string]string{CommandName: "Switch", MaxConcurrent: "0"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.BulkCommand(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestScriptTransform(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	scriptFile := filepath.Join(t.TempDir(), "transform.lua")
+	require.NoError(t, ioutil.WriteFile(scriptFile, []byte("return data"), 0644))
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - inline", map[string]string{Script: "return data", ScriptTimeout: "500ms"}, false},
+		{"Good - file", map[string]string{ScriptFile: scriptFile}, false},
+		{"Bad - no script", map[string]string{}, true},
+		{"Bad - both", map[string]string{Script: "return data", ScriptFile: scriptFile}, true},
+		{"Bad - missing file", map[string]string{ScriptFile: filepath.Join(t.TempDir(), "missing.lua")}, true},
+		{"Bad - syntax", map[string]string{Script: "return data +"}, true},
+		{"Bad - timeout", map[string]string{Script: "return data", ScriptTimeout: "-1s"}, true},
+		{"Good - memory limit", map[string]string{Script: "return data", ScriptMemoryLimit: "1048576"}, false},
+		{"Bad - memory limit", map[string]string{Script: "return data", ScriptMemoryLimit: "1MB"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ScriptTransform(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - secrets", map[string]string{SecretPath: "signing", SecretName: "key"}, false},
+		{"Good - envelope", map[string]string{SecretPath: "signing", SecretName: "key", Envelope: "true"}, false},
+		{"Bad - no secret name", map[string]string{SecretPath: "signing"}, true},
+		{"Bad - envelope", map[string]string{SecretPath: "signing", SecretName: "key", Envelope: "yes please"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.ExpectNil, configurable.SignPayloadHMAC(test.Parameters) == nil)
+			assert.Equal(t, test.ExpectNil, configurable.SignPayloadECDSA(test.Parameters) == nil)
+		})
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.Decompress(map[string]string{Algorithm: CompressGZIP}))
+	assert.NotNil(t, configurable.Decompress(map[string]string{Algorithm: "ZLIB"}))
+	assert.Nil(t, configurable.Decompress(map[string]string{Algorithm: "brotli"}))
+	assert.Nil(t, configurable.Decompress(map[string]string{}))
+}
+
+func TestDecrypt(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"AES256 - good - secrets", map[string]string{Algorithm: EncryptAES256, SecretPath: "/aes", SecretName: "key"}, false},
+		{"AES256 - bad - key", map[string]string{Algorithm: EncryptAES256, EncryptionKey: "xyz12345"}, true},
+		{"AESGCM - good - key", map[string]string{Algorithm: EncryptAESGCM, EncryptionKey: "xyz12345"}, false},
+		{"AESGCM - good - secrets", map[string]string{Algorithm: EncryptAESGCM, SecretPath: "/aes", SecretName: "key"}, false},
+		{"AESGCM - bad - no key", map[string]string{Algorithm: EncryptAESGCM}, true},
+		{"Bad - missing secretName", map[string]string{Algorithm: EncryptAESGCM, SecretPath: "/aes"}, true},
+		{"Bad - algorithm", map[string]string{Algorithm: EncryptAES, EncryptionKey: "xyz12345"}, true},
+		{"Bad - no algorithm", map[string]string{EncryptionKey: "xyz12345"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform := configurable.Decrypt(test.Parameters)
+			assert.Equal(t, test.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestChainEvents(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - not signed", map[string]string{}, false},
+		{"Good - HMAC", map[string]string{Algorithm: "HMAC", SecretPath: "chain", SecretName: "key"}, false},
+		{"Good - ECDSA", map[string]string{Algorithm: SignECDSA, SecretPath: "chain", SecretName: "key"}, false},
+		{"Bad - algorithm", map[string]string{Algorithm: "rsa", SecretPath: "chain", SecretName: "key"}, true},
+		{"Bad - no secret", map[string]string{Algorithm: SignHMAC}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.ChainEvents(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestSetResponseMetadata(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	tests := []struct {
+		Name       string
+		Parameters map[string]string
+		ExpectNil  bool
+	}{
+		{"Good - content type", map[string]string{ResponseContentType: "application/xml"}, false},
+		{"Good - headers", map[string]string{Headers: "X-Device:{devicename}, Link:http://localhost:8080"}, false},
+		{"Good - empty value", map[string]string{Headers: "X-Empty:"}, false},
+		{"Bad - no colon", map[string]string{Headers: "X-Device"}, true},
+		{"Bad - no key", map[string]string{Headers: ":value"}, true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			transform := configurable.SetResponseMetadata(testCase.Parameters)
+			assert.Equal(t, testCase.ExpectNil, transform == nil)
+		})
+	}
+}
+
+func TestSplitByReadingAndMerge(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.SplitByReading(map[string]string{}))
+	assert.NotNil(t, configurable.Merge(map[string]string{}))
+}
+
 func TestEncrypt(t *testing.T) {
 	configurable := Configurable{lc: lc}
 
@@ -397,6 +1736,10 @@ func TestEncrypt(t *testing.T) {
 		{"Bad - Missing secretName", EncryptAES, "", vector, secretsPath, "", true},
 		{"AES256 - Bad - No secrets ", EncryptAES256, "", "", "", "", true},
 		{"AES256 - good - secrets", EncryptAES256, "", "", uuid.NewString(), uuid.NewString(), false},
+		{"AESGCM - good - key", EncryptAESGCM, key, "", "", "", false},
+		{"AESGCM - good - secrets", "AESGCM", "", "", secretsPath, secretName, false},
+		{"Hybrid - good - secrets", EncryptHybrid, "", "", secretsPath, secretName, false},
+		{"Hybrid - Bad - Key instead of public key", EncryptHybrid, key, "", "", "", true},
 	}
 
 	for _, testCase := range tests {
@@ -422,6 +1765,20 @@ func TestEncrypt(t *testing.T) {
 			assert.Equal(t, testCase.ExpectNil, transform == nil)
 		})
 	}
+
+	publicKeyParams := map[string]string{Algorithm: EncryptHybrid, PublicKey: "-----BEGIN PUBLIC KEY-----"}
+	assert.NotNil(t, configurable.Encrypt(publicKeyParams), "hybrid encryption should accept a public key")
+	publicKeyParams[Algorithm] = EncryptAESGCM
+	assert.Nil(t, configurable.Encrypt(publicKeyParams), "AES-GCM encryption should require a key")
+}
+
+func TestConfigurable_PushEventsToCore(t *testing.T) {
+	configurable := Configurable{lc: lc}
+
+	assert.NotNil(t, configurable.PushEventsToCore(map[string]string{}))
+	assert.NotNil(t, configurable.PushEventsToCore(map[string]string{MaxConcurrent: "4", PersistOnError: "true"}))
+	assert.Nil(t, configurable.PushEventsToCore(map[string]string{MaxConcurrent: "none"}))
+	assert.Nil(t, configurable.PushEventsToCore(map[string]string{PersistOnError: "bogus"}))
 }
 
 func TestConfigurable_PushToCore(t *testing.T) {
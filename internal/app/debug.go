@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/google/uuid"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/controller/rest"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// StartDebugSession starts a session stepping the payload through the functions of the pipeline, the default pipeline
+// when no id is given. When no payload is given, the payload of the most recent captured sample with the correlation
+// id is stepped through the pipeline it was processed by. The content type defaults to the sample's, or JSON.
+func (svc *Service) StartDebugSession(pipelineId string, correlationId string, contentType string, payload []byte) (rest.DebugSession, error) {
+	pipelineId = strings.TrimSpace(pipelineId)
+	contentType = strings.TrimSpace(contentType)
+
+	if len(payload) == 0 {
+		sample, err := svc.findCapturedSample(strings.TrimSpace(correlationId))
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err = sample.Payload.Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("unable to use payload of sample '%s': %s", sample.CorrelationId, err.Error())
+		}
+
+		if pipelineId == "" {
+			pipelineId = sample.PipelineId
+		}
+		if contentType == "" {
+			contentType = sample.ContentType
+		}
+	}
+
+	if pipelineId == "" {
+		pipelineId = interfaces.DefaultPipelineId
+	}
+	if contentType == "" {
+		contentType = common.ContentTypeJSON
+	}
+	if correlationId == "" {
+		correlationId = uuid.NewString()
+	}
+
+	pipeline := svc.runtime.GetPipelineById(pipelineId)
+	if pipeline == nil {
+		return nil, fmt.Errorf("pipeline '%s' not found", pipelineId)
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: correlationId,
+		ContentType:   contentType,
+		Payload:       payload,
+	}
+
+	session, err := svc.runtime.StartDebugSession(envelope, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.lc.Debugf("Started debug session of pipeline '%s' with correlation id '%s'", pipelineId, correlationId)
+
+	return session, nil
+}
+
+// findCapturedSample returns the most recent captured sample with the correlation id
+func (svc *Service) findCapturedSample(correlationId string) (capture.Sample, error) {
+	if correlationId == "" {
+		return capture.Sample{}, errors.New("payload or correlationId of a captured sample is required")
+	}
+
+	captureBuffer := container.CaptureBufferFrom(svc.dic.Get)
+	if captureBuffer == nil {
+		return capture.Sample{}, errors.New("capture is not enabled")
+	}
+
+	samples := captureBuffer.Samples()
+	for index := len(samples) - 1; index >= 0; index-- {
+		if samples[index].CorrelationId == correlationId {
+			return samples[index], nil
+		}
+	}
+
+	return capture.Sample{}, fmt.Errorf("no captured sample with correlation id '%s'", correlationId)
+}
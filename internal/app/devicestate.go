@@ -0,0 +1,140 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/devicestate"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/models"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// deviceStateChannelCapacity is the number of changes of state buffered for publishing by the trigger
+	deviceStateChannelCapacity = 100
+	deviceStateLabel           = "devicestate"
+)
+
+// startDeviceMonitor creates the device monitor, adds it to the DIC so it is fed by the runtime and served by the
+// devices endpoint, and starts checking for the devices gone offline
+func (svc *Service) startDeviceMonitor() error {
+	monitorConfig := svc.config.DeviceMonitor
+
+	if monitorConfig.PublishTopic != "" {
+		if triggerType := strings.ToUpper(svc.config.Trigger.Type); triggerType == TriggerTypeHTTP || triggerType == TriggerTypeMQTT {
+			return fmt.Errorf("DeviceMonitor PublishTopic not supported for %s trigger", svc.config.Trigger.Type)
+		}
+
+		svc.deviceStateChannel = make(chan interfaces.BackgroundMessage, deviceStateChannelCapacity)
+	}
+
+	deviceMonitor, err := devicestate.NewMonitor(monitorConfig, svc.emitDeviceStateChange, svc.lc)
+	if err != nil {
+		return err
+	}
+
+	svc.dic.Update(di.ServiceConstructorMap{
+		container.DeviceMonitorName: func(get di.Get) interface{} {
+			return deviceMonitor
+		},
+	})
+
+	deviceMonitor.Start(svc.ctx.appWg, svc.ctx.appCtx)
+	return nil
+}
+
+// emitDeviceStateChange publishes the change of state to the PublishTopic and sends it as a notification,
+// as configured. Neither blocks the pipelines feeding the monitor.
+func (svc *Service) emitDeviceStateChange(change devicestate.StateChange) {
+	monitorConfig := svc.config.DeviceMonitor
+
+	if svc.deviceStateChannel != nil {
+		payload, err := json.Marshal(change)
+		if err != nil {
+			svc.lc.Errorf("Unable to marshal state change of device '%s': %s", change.DeviceName, err.Error())
+			return
+		}
+
+		replacer := strings.NewReplacer("{devicename}", change.DeviceName, "{state}", change.State)
+		message := BackgroundMessage{
+			PublishTopic: replacer.Replace(monitorConfig.PublishTopic),
+			Payload: types.MessageEnvelope{
+				CorrelationID: uuid.NewString(),
+				ContentType:   common.ContentTypeJSON,
+				Payload:       payload,
+			},
+		}
+
+		select {
+		case svc.deviceStateChannel <- message:
+		default:
+			svc.lc.Warnf("Dropped state change of device '%s', too many state changes waiting to be published", change.DeviceName)
+		}
+	}
+
+	if monitorConfig.NotificationCategory != "" {
+		svc.ctx.appWg.Add(1)
+		go func() {
+			defer svc.ctx.appWg.Done()
+			if err := svc.sendDeviceStateNotification(change); err != nil {
+				svc.lc.Errorf("Unable to send notification of state change of device '%s': %s", change.DeviceName, err.Error())
+			}
+		}()
+	}
+}
+
+func (svc *Service) sendDeviceStateNotification(change devicestate.StateChange) error {
+	client := container.NotificationClientFrom(svc.dic.Get)
+	if client == nil {
+		return errors.New("NotificationClient not initialized. Support Notifications is missing from clients configuration")
+	}
+
+	severity := models.Normal
+	content := fmt.Sprintf("Device '%s' is online again", change.DeviceName)
+	if change.State == devicestate.StateOffline {
+		severity = models.Minor
+		content = fmt.Sprintf("Device '%s' is offline, last seen at %s", change.DeviceName,
+			time.Unix(0, change.LastSeen).UTC().Format(time.RFC3339))
+	}
+
+	notification := dtos.NewNotification(
+		[]string{deviceStateLabel, change.State},
+		svc.config.DeviceMonitor.NotificationCategory,
+		content,
+		svc.serviceKey,
+		severity)
+	notification.ContentType = common.ContentTypeText
+
+	request := requests.NewAddNotificationRequest(notification)
+	_, err := client.SendNotification(context.Background(), []requests.AddNotificationRequest{request})
+	return err
+}
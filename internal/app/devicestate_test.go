@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/devicestate"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestService_StartDeviceMonitor(t *testing.T) {
+	svc := Service{
+		lc:  lc,
+		dic: dic,
+		config: &common.ConfigurationStruct{
+			Trigger:       common.TriggerInfo{Type: "http"},
+			DeviceMonitor: common.DeviceMonitorInfo{Enabled: true, PublishTopic: "devices/{devicename}/{state}"},
+		},
+	}
+
+	err := svc.startDeviceMonitor()
+	require.Error(t, err, "publishing should not be supported by the HTTP trigger")
+	assert.Contains(t, err.Error(), "not supported")
+
+	svc.config.DeviceMonitor.PublishTopic = ""
+	svc.config.DeviceMonitor.OfflineThreshold = "never"
+	require.Error(t, svc.startDeviceMonitor())
+}
+
+func TestService_EmitDeviceStateChange(t *testing.T) {
+	svc := Service{
+		lc:                 lc,
+		dic:                dic,
+		deviceStateChannel: make(chan interfaces.BackgroundMessage, 1),
+		config: &common.ConfigurationStruct{
+			DeviceMonitor: common.DeviceMonitorInfo{Enabled: true, PublishTopic: "devices/{devicename}/{state}"},
+		},
+	}
+
+	change := devicestate.StateChange{
+		DeviceState: devicestate.DeviceState{DeviceName: "pump-1", State: devicestate.StateOffline, LastSeen: 1},
+		Timestamp:   2,
+	}
+	svc.emitDeviceStateChange(change)
+	// The channel is full, so this change is dropped rather than blocking
+	svc.emitDeviceStateChange(change)
+
+	message := <-svc.deviceStateChannel
+	assert.Equal(t, "devices/pump-1/offline", message.Topic())
+	assert.Equal(t, "application/json", message.Message().ContentType)
+
+	var published devicestate.StateChange
+	require.NoError(t, json.Unmarshal(message.Message().Payload, &published))
+	assert.Equal(t, change, published)
+	assert.Empty(t, svc.deviceStateChannel)
+}
+
+func TestMergeBackgroundChannels(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	second := make(chan interfaces.BackgroundMessage, 1)
+	assert.Equal(t, (<-chan interfaces.BackgroundMessage)(second), mergeBackgroundChannels(ctx, nil, second))
+
+	first := make(chan interfaces.BackgroundMessage, 1)
+	merged := mergeBackgroundChannels(ctx, first, second)
+
+	first <- BackgroundMessage{PublishTopic: "first"}
+	second <- BackgroundMessage{PublishTopic: "second"}
+
+	var topics []string
+	for len(topics) < 2 {
+		select {
+		case message := <-merged:
+			topics = append(topics, message.Topic())
+		case <-time.After(time.Second):
+			require.Fail(t, "message not received from merged channel")
+		}
+	}
+	assert.ElementsMatch(t, []string{"first", "second"}, topics)
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/google/uuid"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// previewPipelineId is the pipeline id of the context the previewed functions are executed with
+const previewPipelineId = "preview"
+
+// unpreviewableFunctions are the configurable functions that send data out of the service, so can't be previewed
+var unpreviewableFunctions = []string{
+	"PushToCore",
+	"HTTPExport",
+	"FileExport",
+	"MarkExported",
+	"MQTTExport",
+	"ChainEvents",
+	"ForwardToAppService",
+}
+
+// PreviewFunction creates the named built-in pipeline function with the parameters and executes it with the JSON
+// payload, decoded as the pipelines receive it. Returns whether the pipeline would continue and the function's result,
+// which is an error if the function failed. Returns an error if the function can't be created or previewed, or the
+// payload can't be decoded.
+func (svc *Service) PreviewFunction(functionName string, parameters map[string]string, payload []byte) (bool, interface{}, error) {
+	functionName = strings.TrimSpace(functionName)
+	for _, name := range unpreviewableFunctions {
+		// Configured function names are matched by prefix, i.e. HTTPExport2
+		if strings.HasPrefix(functionName, name) {
+			return false, nil, fmt.Errorf("function %s sends data out of the service so can't be previewed", functionName)
+		}
+	}
+
+	if parameters == nil {
+		parameters = make(map[string]string)
+	}
+
+	function, err := svc.createConfigurableFunction(reflect.ValueOf(svc.newConfigurable()), functionName, parameters)
+	if err != nil {
+		return false, nil, err
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: uuid.NewString(),
+		ContentType:   common.ContentTypeJSON,
+		Payload:       payload,
+	}
+
+	data, err := svc.runtime.DecodePayload(envelope)
+	if err != nil {
+		return false, nil, fmt.Errorf("unable to decode payload: %s", err.Error())
+	}
+
+	appContext := appfunction.NewContext(envelope.CorrelationID, svc.dic, envelope.ContentType)
+	appContext.AddValue(interfaces.PIPELINEID, previewPipelineId)
+	// The SDK's export functions log the data rather than send it in a shadow pipeline of this mode
+	appContext.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+	if event, ok := data.(dtos.Event); ok {
+		appContext.AddValue(interfaces.DEVICENAME, event.DeviceName)
+		appContext.AddValue(interfaces.PROFILENAME, event.ProfileName)
+		appContext.AddValue(interfaces.SOURCENAME, event.SourceName)
+		appContext.AddValue(interfaces.EVENTID, event.Id)
+	}
+
+	continuePipeline, result := executePreview(function, appContext, data)
+	svc.lc.Debugf("Previewed function %s with parameters: [%s]", functionName, listParameters(parameters))
+
+	return continuePipeline, result, nil
+}
+
+// executePreview executes the function, returning a panic, i.e. caused by the parameters, as the function's error
+func executePreview(function interfaces.AppFunction, appContext interfaces.AppFunctionContext, data interface{}) (continuePipeline bool, result interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			continuePipeline = false
+			result = fmt.Errorf("function panicked: %v", recovered)
+		}
+	}()
+
+	return function(appContext, data)
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+)
+
+func TestService_PreviewFunction(t *testing.T) {
+	event := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	require.NoError(t, event.AddSimpleReading("temperature", "Int32", int32(21)))
+	payload, err := json.Marshal(requests.NewAddEventRequest(event))
+	require.NoError(t, err)
+
+	svc := Service{
+		lc:      lc,
+		dic:     dic,
+		config:  &common.ConfigurationStruct{},
+		runtime: runtime.NewGolangRuntime("", nil, dic),
+	}
+
+	continuePipeline, result, err := svc.PreviewFunction("FilterByDeviceName", map[string]string{"DeviceNames": "thermostat-1"}, payload)
+	require.NoError(t, err)
+	assert.True(t, continuePipeline)
+	assert.Equal(t, event, result)
+
+	continuePipeline, result, err = svc.PreviewFunction("FilterByDeviceName", map[string]string{"DeviceNames": "fan-1"}, payload)
+	require.NoError(t, err)
+	assert.False(t, continuePipeline, "event should be filtered out")
+	assert.Nil(t, result)
+
+	continuePipeline, result, err = svc.PreviewFunction("Transform", map[string]string{"Type": "xml"}, payload)
+	require.NoError(t, err)
+	assert.True(t, continuePipeline)
+	assert.Contains(t, result, "<DeviceName>thermostat-1</DeviceName>")
+
+	_, _, err = svc.PreviewFunction("Transform", map[string]string{"Type": "yaml"}, payload)
+	assert.Error(t, err, "function with invalid parameters should not be created")
+
+	_, _, err = svc.PreviewFunction("HTTPExportWithFailover", map[string]string{}, payload)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't be previewed")
+
+	_, _, err = svc.PreviewFunction("Bogus", nil, payload)
+	assert.Error(t, err)
+
+	_, _, err = svc.PreviewFunction("FilterByDeviceName", map[string]string{"DeviceNames": "thermostat-1"}, []byte(`{"readings":"none"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to decode payload")
+}
@@ -0,0 +1,137 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/secret"
+	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/clientswap"
+)
+
+const (
+	// secretUpdatesInterval is how often the secret store is checked for updated secrets
+	secretUpdatesInterval = 5 * time.Second
+
+	// DatabaseClient is the name the store Database client's swaps are reported under
+	DatabaseClient = "Database"
+)
+
+// SecretUpdateProcessor contains the data need to process secret updates
+type SecretUpdateProcessor struct {
+	svc         *Service
+	tracker     *clientswap.Tracker
+	lastUpdated time.Time
+	credentials *bootstrapConfig.Credentials
+}
+
+// NewSecretUpdateProcessor creates a new SecretUpdateProcessor which rebuilds the clients whose credentials are updated
+// in the secret store, whether by the /secret endpoint, StoreSecret or the InsecureSecrets configuration
+func NewSecretUpdateProcessor(svc *Service, tracker *clientswap.Tracker) *SecretUpdateProcessor {
+	return &SecretUpdateProcessor{svc: svc, tracker: tracker}
+}
+
+// WaitForSecretUpdates checks the secret store for updated secrets and rebuilds the store Database client with the
+// updated credentials, instead of requiring a restart. The export functions using secrets rebuild their own clients
+// the next time they export.
+func (processor *SecretUpdateProcessor) WaitForSecretUpdates() {
+	svc := processor.svc
+	svc.ctx.appWg.Add(1)
+
+	secretProvider := bootstrapContainer.SecretProviderFrom(svc.dic.Get)
+	processor.lastUpdated = secretProvider.SecretsLastUpdated()
+	processor.credentials, _ = processor.databaseCredentials()
+
+	go func() {
+		defer svc.ctx.appWg.Done()
+		lc := svc.LoggingClient()
+		lc.Info("Waiting for App Service secret updates...")
+
+		ticker := time.NewTicker(secretUpdatesInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-svc.ctx.appCtx.Done():
+				lc.Info("Exiting waiting for App Service secret updates")
+				return
+
+			case <-ticker.C:
+				lastUpdated := secretProvider.SecretsLastUpdated()
+				if !processor.lastUpdated.Before(lastUpdated) {
+					// The store client is created later when StoreAndForward is enabled, so keep the credentials it
+					// was created with to know whether they are updated
+					if processor.credentials == nil {
+						processor.credentials, _ = processor.databaseCredentials()
+					}
+					continue
+				}
+
+				processor.lastUpdated = lastUpdated
+				lc.Debug("Processing App Service secret updates")
+				processor.processSecretsUpdatedDatabase()
+			}
+		}
+	}()
+}
+
+func (processor *SecretUpdateProcessor) processSecretsUpdatedDatabase() {
+	lc := processor.svc.LoggingClient()
+
+	credentials, err := processor.databaseCredentials()
+	if err != nil {
+		lc.Errorf("Unable to get the updated Database credentials: %s", err.Error())
+		return
+	}
+
+	if credentials == nil || (processor.credentials != nil && *processor.credentials == *credentials) {
+		return
+	}
+
+	storeClient := container.StoreClientFrom(processor.svc.dic.Get)
+	if err := storeClient.UpdateCredentials(*credentials); err != nil {
+		lc.Errorf("Unable to rebuild the Database client with the updated credentials, keeping the previous connections: %s", err.Error())
+		processor.tracker.Failed(DatabaseClient, err)
+		return
+	}
+
+	processor.credentials = credentials
+	processor.tracker.Swapped(DatabaseClient)
+	lc.Infof("Database client rebuilt with the updated credentials for '%s'", processor.svc.config.Database.Type)
+}
+
+// databaseCredentials returns the current credentials of the store Database, or nil when there is no store client
+func (processor *SecretUpdateProcessor) databaseCredentials() (*bootstrapConfig.Credentials, error) {
+	sdk := processor.svc
+
+	if container.StoreClientFrom(sdk.dic.Get) == nil {
+		return nil, nil
+	}
+
+	secrets, err := bootstrapContainer.SecretProviderFrom(sdk.dic.Get).GetSecret(sdk.config.Database.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bootstrapConfig.Credentials{
+		Username: secrets[secret.UsernameKey],
+		Password: secrets[secret.PasswordKey],
+	}, nil
+}
@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+)
+
+// selfTestTopic is the topic the self-test payload is received on
+const selfTestTopic = "selftest"
+
+// skippedCheck is returned by a self-test check that doesn't apply to the service's configuration
+type skippedCheck string
+
+func (reason skippedCheck) Error() string {
+	return string(reason)
+}
+
+// connectionChecker is implemented by the triggers whose connection can be checked without receiving messages
+type connectionChecker interface {
+	CheckConnection() error
+}
+
+// selfTest validates the configuration, checks the connections to the store, secret store and message bus, and runs a
+// payload through each function pipeline with the export functions logging the data rather than sending it. The
+// result of each check is logged and an error is returned if any check failed.
+func (svc *Service) selfTest() error {
+	checks := []struct {
+		name  string
+		check func() error
+	}{
+		{"configuration", svc.checkConfiguration},
+		{"store", svc.checkStore},
+		{"secret store", svc.checkSecretStore},
+		{"trigger connection", svc.checkTriggerConnection},
+		{"pipelines", svc.checkPipelines},
+	}
+
+	failed := 0
+	for _, check := range checks {
+		err := check.check()
+
+		var skipped skippedCheck
+		switch {
+		case err == nil:
+			svc.lc.Infof("Self-test %s check passed", check.name)
+		case errors.As(err, &skipped):
+			svc.lc.Infof("Self-test %s check skipped: %s", check.name, skipped.Error())
+		default:
+			svc.lc.Errorf("Self-test %s check failed: %s", check.name, err.Error())
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("self-test failed %d of %d checks", failed, len(checks))
+	}
+
+	svc.lc.Info("Self-test passed")
+	return nil
+}
+
+func (svc *Service) checkConfiguration() error {
+	var result error
+
+	if svc.setupTrigger(svc.config, svc.runtime) == nil {
+		result = multierror.Append(result, fmt.Errorf("unable to create Trigger of type '%s'", svc.config.Trigger.Type))
+	}
+
+	if _, err := svc.config.Trigger.DrainTimeoutDuration(); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	if err := runtime.ValidatePriorityLanes(svc.config.Trigger.WorkerPool.PriorityLanes); err != nil {
+		result = multierror.Append(result, err)
+	}
+
+	return result
+}
+
+func (svc *Service) checkStore() error {
+	if !svc.config.Writable.StoreAndForward.Enabled && !svc.config.ExportGuard.Enabled && !svc.config.HashChain.Enabled {
+		return skippedCheck("store not used as StoreAndForward, ExportGuard and HashChain are disabled")
+	}
+
+	storeClient := container.StoreClientFrom(svc.dic.Get)
+	if storeClient == nil {
+		return errors.New("store client not created")
+	}
+
+	_, err := storeClient.ExportKeyExists(svc.serviceKey, selfTestTopic)
+	return err
+}
+
+// checkSecretStore retrieves the secrets at the paths listed in the InsecureSecrets configuration, which are the
+// paths the service uses whether or not security is enabled
+func (svc *Service) checkSecretStore() error {
+	var paths []string
+	for _, secrets := range svc.config.Writable.InsecureSecrets {
+		if secrets.Path != "" {
+			paths = append(paths, secrets.Path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return skippedCheck("no secret paths in InsecureSecrets configuration")
+	}
+
+	secretProvider := bootstrapContainer.SecretProviderFrom(svc.dic.Get)
+	if secretProvider == nil {
+		return errors.New("secret provider not created")
+	}
+
+	sort.Strings(paths)
+
+	var result error
+	for _, path := range paths {
+		if _, err := secretProvider.GetSecret(path); err != nil {
+			result = multierror.Append(result, fmt.Errorf("unable to retrieve secrets at path '%s': %s", path, err.Error()))
+		}
+	}
+
+	return result
+}
+
+// checkTriggerConnection connects to, and disconnects from, the message bus of triggers that support checking their
+// connection without receiving messages
+func (svc *Service) checkTriggerConnection() error {
+	trigger := svc.setupTrigger(svc.config, svc.runtime)
+	if trigger == nil {
+		return fmt.Errorf("unable to create Trigger of type '%s'", svc.config.Trigger.Type)
+	}
+
+	checker, ok := trigger.(connectionChecker)
+	if !ok {
+		return skippedCheck(fmt.Sprintf("connection check not supported by the '%s' trigger", svc.config.Trigger.Type))
+	}
+
+	return checker.CheckConnection()
+}
+
+// checkPipelines runs the self-test payload through each function pipeline. A pipeline stopped by a function without
+// an error, i.e. a filter, passes.
+func (svc *Service) checkPipelines() error {
+	payload, err := svc.selfTestPayload()
+	if err != nil {
+		return err
+	}
+
+	pipelines := svc.runtime.GetProductionPipelines()
+	if len(pipelines) == 0 {
+		return errors.New("no function pipelines configured")
+	}
+
+	var result error
+	for _, pipeline := range pipelines {
+		envelope := types.MessageEnvelope{
+			CorrelationID: uuid.NewString(),
+			ContentType:   common.ContentTypeJSON,
+			Payload:       payload,
+			ReceivedTopic: selfTestTopic,
+		}
+
+		if messageError := svc.runtime.DryRun(envelope, pipeline); messageError != nil {
+			result = multierror.Append(result, fmt.Errorf("pipeline '%s' failed: %s", pipeline.Id, messageError.Err.Error()))
+			continue
+		}
+
+		svc.lc.Infof("Self-test pipeline '%s' completed", pipeline.Id)
+	}
+
+	return result
+}
+
+// selfTestPayload returns the content of the self-test payload file, or a sample Event when no file was given
+func (svc *Service) selfTestPayload() ([]byte, error) {
+	if svc.commandLine.selfTestPayload != "" {
+		payload, err := ioutil.ReadFile(svc.commandLine.selfTestPayload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read self-test payload: %s", err.Error())
+		}
+		return payload, nil
+	}
+
+	event := dtos.NewEvent("selftest-profile", "selftest-device", "selftest-source")
+	if err := event.AddSimpleReading("selftest-resource", common.ValueTypeFloat64, 21.5); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(requests.NewAddEventRequest(event))
+}
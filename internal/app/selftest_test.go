@@ -0,0 +1,126 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package app
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func newSelfTestService(t *testing.T, triggerType string, secretErr error) *Service {
+	config := &common.ConfigurationStruct{
+		Trigger: common.TriggerInfo{Type: triggerType},
+		Writable: common.WritableInfo{
+			InsecureSecrets: bootstrapConfig.InsecureSecrets{
+				"mqtt": bootstrapConfig.InsecureSecretsInfo{Path: "mqtt"},
+			},
+		},
+	}
+
+	secretProvider := &mocks.SecretProvider{}
+	secretProvider.On("GetSecret", "mqtt").Return(map[string]string{"username": "user"}, secretErr)
+
+	selfTestDic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return lc
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return secretProvider
+		},
+	})
+
+	return &Service{
+		dic:     selfTestDic,
+		lc:      lc,
+		config:  config,
+		runtime: runtime.NewGolangRuntime("", nil, selfTestDic),
+	}
+}
+
+func TestService_SelfTest(t *testing.T) {
+	service := newSelfTestService(t, TriggerTypeHTTP, nil)
+
+	var received []dtos.Event
+	var shadowMode string
+	require.NoError(t, service.SetDefaultFunctionsPipeline(func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		received = append(received, data.(dtos.Event))
+		shadowMode, _ = ctx.GetValue(interfaces.SHADOW)
+		return false, nil
+	}))
+
+	require.NoError(t, service.selfTest())
+	require.Len(t, received, 1)
+	assert.Equal(t, "selftest-device", received[0].DeviceName)
+	assert.Equal(t, interfaces.ShadowModeLog, shadowMode, "exports should be logged rather than sent")
+}
+
+func TestService_SelfTest_Payload(t *testing.T) {
+	service := newSelfTestService(t, TriggerTypeHTTP, nil)
+	service.commandLine.selfTestPayload = filepath.Join(t.TempDir(), "payload.json")
+	require.NoError(t, ioutil.WriteFile(service.commandLine.selfTestPayload,
+		[]byte(`{"apiVersion":"v2","event":{"apiVersion":"v2","id":"7a1707f0-166f-4c4b-bc9d-1d54c74e0137","deviceName":"boiler",`+
+			`"profileName":"boilers","sourceName":"status","origin":1,"readings":[{"apiVersion":"v2","id":"82eb2e26-0f24-48aa-ae4c-de9dac3fb9bc",`+
+			`"deviceName":"boiler","profileName":"boilers","resourceName":"pressure","origin":1,"valueType":"Int32","value":"12"}]}}`), 0644))
+
+	var deviceName string
+	require.NoError(t, service.SetDefaultFunctionsPipeline(func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		deviceName = data.(dtos.Event).DeviceName
+		return true, data
+	}))
+
+	require.NoError(t, service.selfTest())
+	assert.Equal(t, "boiler", deviceName)
+
+	service.commandLine.selfTestPayload = filepath.Join(t.TempDir(), "missing.json")
+	assert.Error(t, service.selfTest())
+}
+
+func TestService_SelfTest_Failed(t *testing.T) {
+	service := newSelfTestService(t, "carrier-pigeon", errors.New("secret store unavailable"))
+
+	require.NoError(t, service.AddFunctionsPipelineForTopics("export", []string{"edgex/events/#"}, func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return false, errors.New("destination rejected the data")
+	}))
+
+	err := service.selfTest()
+	require.Error(t, err)
+	assert.Equal(t, "self-test failed 4 of 5 checks", err.Error())
+
+	assert.Error(t, service.checkPipelines())
+	assert.Error(t, service.checkSecretStore())
+	assert.Error(t, service.checkTriggerConnection())
+	assert.Error(t, service.checkConfiguration())
+	assert.True(t, errors.As(service.checkStore(), new(skippedCheck)), "store check should be skipped")
+}
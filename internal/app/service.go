@@ -27,14 +27,29 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/accounting"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/handlers"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/cache"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/clientswap"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/devicegroups"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/exportguard"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/hashchain"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/history"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/receipts"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/tenancy"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/loopback"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/replay"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/watchdog"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/webserver"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
 
@@ -81,8 +96,11 @@ type Service struct {
 	runtime                   *runtime.GolangRuntime
 	webserver                 *webserver.WebServer
 	ctx                       contextGroup
+	trigger                   triggerGroup
 	deferredFunctions         []bootstrap.Deferred
 	backgroundPublishChannel  <-chan interfaces.BackgroundMessage
+	backgroundChannels        []<-chan interfaces.BackgroundMessage
+	deviceStateChannel        chan interfaces.BackgroundMessage
 	customTriggerFactories    map[string]func(sdk *Service) (interfaces.Trigger, error)
 	profileSuffixPlaceholder  string
 	commandLine               commandLineFlags
@@ -93,6 +111,8 @@ type Service struct {
 type commandLineFlags struct {
 	skipVersionCheck   bool
 	serviceKeyOverride string
+	selfTest           bool
+	selfTestPayload    string
 }
 
 type contextGroup struct {
@@ -104,6 +124,14 @@ type contextGroup struct {
 	stop                  context.CancelFunc
 }
 
+// triggerGroup is the running trigger, which the watchdog stops and starts again when pipeline executions stall
+type triggerGroup struct {
+	lock     sync.Mutex
+	wg       *sync.WaitGroup
+	cancel   context.CancelFunc
+	deferred bootstrap.Deferred
+}
+
 // AddRoute allows you to leverage the existing webserver to add routes.
 func (svc *Service) AddRoute(route string, handler func(nethttp.ResponseWriter, *nethttp.Request), methods ...string) error {
 	if route == commonConstants.ApiPingRoute ||
@@ -117,7 +145,8 @@ func (svc *Service) AddRoute(route string, handler func(nethttp.ResponseWriter,
 }
 
 // AddBackgroundPublisher will create a channel of provided capacity to be
-// consumed by the MessageBus output and return a publisher that writes to it
+// consumed by the MessageBus output and return a publisher that writes to it.
+// Several publishers can be added, their messages are all published by the trigger.
 func (svc *Service) AddBackgroundPublisher(capacity int) (interfaces.BackgroundPublisher, error) {
 	topic := svc.config.Trigger.EdgexMessageBus.PublishHost.PublishTopic
 
@@ -138,7 +167,12 @@ func (svc *Service) AddBackgroundPublisherWithTopic(capacity int, topic string)
 	}
 
 	bgChan, pub := newBackgroundPublisher(topic, capacity)
-	svc.backgroundPublishChannel = bgChan
+	if svc.backgroundPublishChannel == nil {
+		svc.backgroundPublishChannel = bgChan
+	} else {
+		// The channels of the additional publishers are merged into the trigger's once it runs
+		svc.backgroundChannels = append(svc.backgroundChannels, bgChan)
+	}
 	return pub, nil
 }
 
@@ -153,8 +187,12 @@ func (svc *Service) MakeItStop() {
 
 // MakeItRun initializes and starts the trigger as specified in the
 // configuration. It will also configure the webserver and start listening on
-// the specified port.
+// the specified port. When started with --selftest, the self-test is run instead and its result returned.
 func (svc *Service) MakeItRun() error {
+	if svc.commandLine.selfTest {
+		return svc.runSelfTest()
+	}
+
 	runCtx, stop := context.WithCancel(context.Background())
 
 	svc.ctx.stop = stop
@@ -165,15 +203,64 @@ func (svc *Service) MakeItRun() error {
 		return errors.New("failed to create Trigger")
 	}
 
-	// Initialize the trigger (i.e. start a web server, or connect to message bus)
-	deferred, err := t.Initialize(svc.ctx.appWg, svc.ctx.appCtx, svc.backgroundPublishChannel)
-	if err != nil {
+	pipelineTimeout, timeoutErr := svc.config.Trigger.PipelineTimeoutDuration()
+	if timeoutErr != nil {
+		svc.lc.Error(timeoutErr.Error())
+		return errors.New("failed to configure the pipeline timeout")
+	}
+	svc.runtime.SetPipelineTimeout(pipelineTimeout)
+	svc.runtime.SetContext(svc.ctx.appCtx)
+
+	// Workers must be running before the trigger starts receiving messages
+	if err := svc.runtime.StartWorkerPool(svc.ctx.appWg, svc.ctx.appCtx, svc.config.Trigger.WorkerPool); err != nil {
+		svc.lc.Error(err.Error())
+		return errors.New("failed to start the worker pool")
+	}
+
+	if svc.config.Trigger.Loopback.Enabled {
+		if err := svc.startLoopback(); err != nil {
+			svc.lc.Error(err.Error())
+			return errors.New("failed to initialize the Loopback Trigger")
+		}
+	}
+
+	if err := svc.runtime.ConfigureDeduplication(svc.config.Trigger.Deduplication); err != nil {
+		svc.lc.Error(err.Error())
+		return errors.New("failed to configure the trigger deduplication")
+	}
+
+	if err := svc.runtime.ConfigureCommitLog(svc.config.Trigger.CommitLog); err != nil {
+		svc.lc.Error(err.Error())
+		return errors.New("failed to open the trigger commit log")
+	}
+
+	// Messages whose processing was interrupted when the service last stopped are processed before new ones are received
+	if replayed := svc.runtime.ReplayCommitLog(); replayed > 0 {
+		svc.lc.Infof("Replayed %d message(s) from the trigger commit log", replayed)
+	}
+
+	for _, channel := range svc.backgroundChannels {
+		svc.backgroundPublishChannel = mergeBackgroundChannels(svc.ctx.appCtx, svc.backgroundPublishChannel, channel)
+	}
+
+	// The changes of state of the devices are published by the trigger along with the application's background messages
+	if svc.deviceStateChannel != nil {
+		svc.backgroundPublishChannel = mergeBackgroundChannels(svc.ctx.appCtx, svc.backgroundPublishChannel, svc.deviceStateChannel)
+	}
+
+	// Initialize the trigger (i.e. start a web server, or connect to message bus), unless paused for maintenance in
+	// which case it's started once the maintenance mode is disabled
+	var err error
+	if maintenance := svc.config.Writable.Maintenance; maintenance.Enabled && maintenance.PauseTrigger {
+		svc.lc.Info("Maintenance mode enabled, trigger paused")
+	} else if err = svc.startTrigger(t); err != nil {
 		svc.lc.Error(err.Error())
 		return errors.New("failed to initialize Trigger")
 	}
 
-	// deferred is a function that needs to be called when services exits.
-	svc.addDeferred(deferred)
+	// stopping the trigger calls its deferred function, which needs to be called when services exits.
+	svc.addDeferred(svc.stopTrigger)
+	svc.addDeferred(svc.runtime.CloseCommitLog)
 
 	if svc.config.Writable.StoreAndForward.Enabled {
 		svc.startStoreForward()
@@ -205,6 +292,18 @@ func (svc *Service) MakeItRun() error {
 
 	svc.ctx.stop = nil
 
+	// Stop the trigger receiving messages, so those arriving while draining are left with the broker rather than
+	// rejected, then stop scheduling pipeline executions and let those in-flight complete so they aren't cut off
+	// when the clients they use are disconnected below.
+	svc.stopTriggerReceiving()
+
+	drainTimeout, drainErr := svc.config.Trigger.DrainTimeoutDuration()
+	if drainErr != nil {
+		svc.lc.Warnf("%s. Using default of %s", drainErr.Error(), drainTimeout.String())
+	}
+	svc.runtime.Drain(drainTimeout)
+
+	// The retry loop stops once draining, after the item being retried, and saves the state of the retried items
 	if svc.config.Writable.StoreAndForward.Enabled {
 		svc.ctx.storeForwardCancelCtx()
 		svc.ctx.storeForwardWg.Wait()
@@ -221,6 +320,139 @@ func (svc *Service) MakeItRun() error {
 	return err
 }
 
+// startLoopback starts the Loopback Trigger, which feeds the data sent by LoopbackSend to the pipelines matching its
+// topic alongside the configured trigger, and adds it to the DIC so the pipeline functions can send to it
+func (svc *Service) startLoopback() error {
+	loopbackTrigger, err := loopback.NewTrigger(svc.dic, svc.runtime, svc.config.Trigger.Loopback)
+	if err != nil {
+		return err
+	}
+
+	svc.dic.Update(di.ServiceConstructorMap{
+		container.LoopbackName: func(get di.Get) interface{} {
+			return loopbackTrigger
+		},
+	})
+
+	loopbackTrigger.Initialize(svc.ctx.appWg, svc.ctx.appCtx)
+	svc.lc.Info("Loopback enabled, feeding the data sent by LoopbackSend to the matching pipelines")
+
+	return nil
+}
+
+// runSelfTest runs the self-test rather than the trigger and then stops the service, so the error returned sets the
+// exit status of the service
+func (svc *Service) runSelfTest() error {
+	err := svc.selfTest()
+
+	svc.ctx.appCancelCtx()
+	svc.ctx.appWg.Wait()
+	for _, deferredFunc := range svc.deferredFunctions {
+		deferredFunc()
+	}
+
+	return err
+}
+
+// startTrigger initializes the trigger with its own context and wait group, derived from the service's, so it can be
+// stopped and started again while the service runs
+func (svc *Service) startTrigger(t interfaces.Trigger) error {
+	svc.trigger.lock.Lock()
+	defer svc.trigger.lock.Unlock()
+
+	return svc.initializeTrigger(t)
+}
+
+// stopTrigger stops the running trigger and calls its deferred function, i.e. to disconnect from the message bus
+func (svc *Service) stopTrigger() {
+	svc.trigger.lock.Lock()
+	defer svc.trigger.lock.Unlock()
+
+	svc.stopRunningTrigger()
+}
+
+// stopTriggerReceiving cancels the running trigger's context so it stops receiving messages. The trigger stays
+// connected, i.e. to acknowledge the messages being processed, until stopped.
+func (svc *Service) stopTriggerReceiving() {
+	svc.trigger.lock.Lock()
+	defer svc.trigger.lock.Unlock()
+
+	if svc.trigger.cancel != nil {
+		svc.trigger.cancel()
+	}
+}
+
+// restartTrigger stops the running trigger and creates and initializes a new one, i.e. so a trigger whose connection
+// no longer delivers messages reconnects. Called by the watchdog when pipeline executions stall.
+func (svc *Service) restartTrigger() error {
+	svc.trigger.lock.Lock()
+	defer svc.trigger.lock.Unlock()
+
+	if svc.ctx.appCtx.Err() != nil || svc.runtime.IsDraining() {
+		return errors.New("service is stopping")
+	}
+
+	svc.stopRunningTrigger()
+
+	t := svc.setupTrigger(svc.config, svc.runtime)
+	if t == nil {
+		return errors.New("failed to create Trigger")
+	}
+
+	if err := svc.initializeTrigger(t); err != nil {
+		return fmt.Errorf("failed to initialize Trigger: %s", err.Error())
+	}
+
+	svc.lc.Info("Trigger restarted")
+	return nil
+}
+
+func (svc *Service) initializeTrigger(t interfaces.Trigger) error {
+	triggerCtx, cancel := context.WithCancel(svc.ctx.appCtx)
+	triggerWg := &sync.WaitGroup{}
+
+	deferred, err := t.Initialize(triggerWg, triggerCtx, svc.backgroundPublishChannel)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	svc.trigger.wg = triggerWg
+	svc.trigger.cancel = cancel
+	svc.trigger.deferred = deferred
+	return nil
+}
+
+func (svc *Service) stopRunningTrigger() {
+	if svc.trigger.cancel == nil {
+		return
+	}
+
+	svc.trigger.cancel()
+
+	// The trigger's go routines are waited on for no longer than the in-flight executions are on shutdown
+	timeout, _ := svc.config.Trigger.DrainTimeoutDuration()
+	stopped := make(chan struct{})
+	go func() {
+		svc.trigger.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		svc.lc.Warnf("Trigger did not stop within %s", timeout.String())
+	}
+
+	if svc.trigger.deferred != nil {
+		svc.trigger.deferred()
+	}
+
+	svc.trigger.wg = nil
+	svc.trigger.cancel = nil
+	svc.trigger.deferred = nil
+}
+
 // LoadConfigurablePipeline sets the function pipeline from configuration
 // Note this API has been deprecated, replaced by LoadConfigurableFunctionPipelines and will be removed in a future release
 // TODO: Remove this API in 3.0 release
@@ -241,6 +473,7 @@ func (svc *Service) LoadConfigurablePipeline() ([]interfaces.AppFunction, error)
 // LoadConfigurableFunctionPipelines return the configured function pipelines (default and per topic) from configuration.
 func (svc *Service) LoadConfigurableFunctionPipelines() (map[string]interfaces.FunctionPipeline, error) {
 	pipelines := make(map[string]interfaces.FunctionPipeline)
+	errorPolicies := make(map[string]map[int]interfaces.ErrorPolicy)
 
 	svc.usingConfigurablePipeline = true
 
@@ -250,7 +483,7 @@ func (svc *Service) LoadConfigurableFunctionPipelines() (map[string]interfaces.F
 		svc.targetType = &[]byte{}
 	}
 
-	configurable := reflect.ValueOf(NewConfigurable(svc.lc))
+	configurable := reflect.ValueOf(svc.newConfigurable())
 	pipelineConfig := svc.config.Writable.Pipeline
 
 	defaultExecutionOrder := strings.TrimSpace(pipelineConfig.ExecutionOrder)
@@ -263,10 +496,11 @@ func (svc *Service) LoadConfigurableFunctionPipelines() (map[string]interfaces.F
 		svc.lc.Debugf("Default Function Pipeline Execution Order: [%s]", pipelineConfig.ExecutionOrder)
 		functionNames := util.DeleteEmptyAndTrim(strings.FieldsFunc(defaultExecutionOrder, util.SplitComma))
 
-		transforms, err := svc.loadConfigurablePipelineTransforms(interfaces.DefaultPipelineId, functionNames, pipelineConfig.Functions, configurable)
+		transforms, policies, err := svc.loadConfigurablePipelineTransforms(interfaces.DefaultPipelineId, functionNames, pipelineConfig.Functions, configurable)
 		if err != nil {
 			return nil, err
 		}
+		errorPolicies[interfaces.DefaultPipelineId] = policies
 		pipeline := interfaces.FunctionPipeline{
 			Id:         interfaces.DefaultPipelineId,
 			Transforms: transforms,
@@ -281,75 +515,74 @@ func (svc *Service) LoadConfigurableFunctionPipelines() (map[string]interfaces.F
 
 			functionNames := util.DeleteEmptyAndTrim(strings.FieldsFunc(perTopicPipeline.ExecutionOrder, util.SplitComma))
 
-			transforms, err := svc.loadConfigurablePipelineTransforms(perTopicPipeline.Id, functionNames, pipelineConfig.Functions, configurable)
+			transforms, policies, err := svc.loadConfigurablePipelineTransforms(perTopicPipeline.Id, functionNames, pipelineConfig.Functions, configurable)
 			if err != nil {
 				return nil, err
 			}
+			errorPolicies[perTopicPipeline.Id] = policies
+
+			shadowMode := strings.ToLower(strings.TrimSpace(perTopicPipeline.ShadowMode))
+			if shadowMode != "" && shadowMode != interfaces.ShadowModeLog && shadowMode != interfaces.ShadowModeSend {
+				return nil, fmt.Errorf("invalid ShadowMode '%s' for '%s' pipeline, must be '%s' or '%s'",
+					perTopicPipeline.ShadowMode, perTopicPipeline.Id, interfaces.ShadowModeLog, interfaces.ShadowModeSend)
+			}
 
 			pipeline := interfaces.FunctionPipeline{
 				Id:         perTopicPipeline.Id,
 				Transforms: transforms,
 				Topics:     util.DeleteEmptyAndTrim(strings.FieldsFunc(perTopicPipeline.Topics, util.SplitComma)),
+				ShadowMode: shadowMode,
 			}
 
 			pipelines[pipeline.Id] = pipeline
 		}
 	}
 
+	// The configured error policies replace any set previously, so they are removed along with their functions
+	// when the configuration changes. The runtime only exists once the service has been initialized.
+	if svc.runtime != nil {
+		if err := svc.runtime.ReplaceFunctionErrorPolicies(errorPolicies); err != nil {
+			return nil, err
+		}
+	}
+
 	return pipelines, nil
 }
 
+// newConfigurable returns a new instance of Configurable with the service's rules and application settings
+func (svc *Service) newConfigurable() *Configurable {
+	configurableFunctions := NewConfigurable(svc.lc)
+	configurableFunctions.rules = svc.config.Writable.Rules
+	configurableFunctions.appSettings = svc.config.ApplicationSettings
+	return configurableFunctions
+}
+
 func (svc *Service) loadConfigurablePipelineTransforms(
 	pipelineId string,
 	executionOrder []string,
 	functions map[string]common.PipelineFunction,
-	configurable reflect.Value) ([]interfaces.AppFunction, error) {
+	configurable reflect.Value) ([]interfaces.AppFunction, map[int]interfaces.ErrorPolicy, error) {
 	var transforms []interfaces.AppFunction
+	policies := make(map[int]interfaces.ErrorPolicy)
 
 	for _, functionName := range executionOrder {
 		functionName = strings.TrimSpace(functionName)
 		configuration, ok := functions[functionName]
 		if !ok {
-			return nil, fmt.Errorf("function '%s' configuration not found in Pipeline.Functions section for pipeline '%s'", functionName, pipelineId)
+			return nil, nil, fmt.Errorf("function '%s' configuration not found in Pipeline.Functions section for pipeline '%s'", functionName, pipelineId)
 		}
 
-		functionValue, functionType, err := svc.findMatchingFunction(configurable, functionName)
+		function, err := svc.createConfigurableFunction(configurable, functionName, configuration.Parameters)
 		if err != nil {
-			return nil, fmt.Errorf("%s for pipeline '%s'", err.Error(), pipelineId)
+			return nil, nil, fmt.Errorf("%s for pipeline '%s'", err.Error(), pipelineId)
 		}
 
-		// determine number of parameters required for function call
-		inputParameters := make([]reflect.Value, functionType.NumIn())
-		// set keys to be all lowercase to avoid casing issues from configuration
-		for key := range configuration.Parameters {
-			value := configuration.Parameters[key]
-			delete(configuration.Parameters, key) // Make sure the old key has been removed so don't have multiples
-			configuration.Parameters[strings.ToLower(key)] = value
-		}
-		for index := range inputParameters {
-			parameter := functionType.In(index)
-
-			switch parameter {
-			case reflect.TypeOf(map[string]string{}):
-				inputParameters[index] = reflect.ValueOf(configuration.Parameters)
-
-			default:
-				return nil, fmt.Errorf(
-					"function %s for pipeline '%s' has an unsupported parameter type: %s",
-					pipelineId,
-					functionName,
-					parameter.String(),
-				)
+		if configuration.ErrorPolicy.Action != "" {
+			policy, err := svc.createErrorPolicy(configurable, functionName, configuration.ErrorPolicy, functions)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s for pipeline '%s'", err.Error(), pipelineId)
 			}
-		}
-
-		function, ok := functionValue.Call(inputParameters)[0].Interface().(interfaces.AppFunction)
-		if !ok {
-			return nil, fmt.Errorf("failed to cast function %s as AppFunction type for pipeline '%s'", functionName, pipelineId)
-		}
-
-		if function == nil {
-			return nil, fmt.Errorf("%s from configuration failed for pipeline '%s'", functionName, pipelineId)
+			policies[len(transforms)] = policy
 		}
 
 		transforms = append(transforms, function)
@@ -359,7 +592,85 @@ func (svc *Service) loadConfigurablePipelineTransforms(
 			listParameters(configuration.Parameters))
 	}
 
-	return transforms, nil
+	return transforms, policies, nil
+}
+
+// createErrorPolicy creates the error policy of the named configurable function, including its dead letter function
+func (svc *Service) createErrorPolicy(
+	configurable reflect.Value,
+	functionName string,
+	info common.ErrorPolicyInfo,
+	functions map[string]common.PipelineFunction) (interfaces.ErrorPolicy, error) {
+	policy := interfaces.ErrorPolicy{
+		Action:  strings.ToLower(strings.TrimSpace(info.Action)),
+		Retries: info.Retries,
+	}
+
+	if info.RetryInterval != "" {
+		interval, err := time.ParseDuration(info.RetryInterval)
+		if err != nil {
+			return policy, fmt.Errorf("invalid ErrorPolicy RetryInterval '%s' for function '%s': %s", info.RetryInterval, functionName, err.Error())
+		}
+		policy.RetryInterval = interval
+	}
+
+	deadLetterName := strings.TrimSpace(info.DeadLetterFunction)
+	if deadLetterName != "" {
+		configuration, ok := functions[deadLetterName]
+		if !ok {
+			return policy, fmt.Errorf("dead letter function '%s' of function '%s' configuration not found in Pipeline.Functions section", deadLetterName, functionName)
+		}
+
+		deadLetter, err := svc.createConfigurableFunction(configurable, deadLetterName, configuration.Parameters)
+		if err != nil {
+			return policy, err
+		}
+		policy.DeadLetter = deadLetter
+	}
+
+	return policy, nil
+}
+
+// createConfigurableFunction creates the named configurable pipeline function with the parameters, whose keys are
+// made lowercase to avoid casing issues from configuration
+func (svc *Service) createConfigurableFunction(
+	configurable reflect.Value,
+	functionName string,
+	parameters map[string]string) (interfaces.AppFunction, error) {
+	functionValue, functionType, err := svc.findMatchingFunction(configurable, functionName)
+	if err != nil {
+		return nil, err
+	}
+
+	// determine number of parameters required for function call
+	inputParameters := make([]reflect.Value, functionType.NumIn())
+	for key := range parameters {
+		value := parameters[key]
+		delete(parameters, key) // Make sure the old key has been removed so don't have multiples
+		parameters[strings.ToLower(key)] = value
+	}
+	for index := range inputParameters {
+		parameter := functionType.In(index)
+
+		switch parameter {
+		case reflect.TypeOf(map[string]string{}):
+			inputParameters[index] = reflect.ValueOf(parameters)
+
+		default:
+			return nil, fmt.Errorf("function %s has an unsupported parameter type: %s", functionName, parameter.String())
+		}
+	}
+
+	function, ok := functionValue.Call(inputParameters)[0].Interface().(interfaces.AppFunction)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast function %s as AppFunction type", functionName)
+	}
+
+	if function == nil {
+		return nil, fmt.Errorf("%s from configuration failed", functionName)
+	}
+
+	return function, nil
 }
 
 // SetFunctionsPipeline has been deprecated and replaced by SetDefaultFunctionsPipeline.
@@ -383,6 +694,62 @@ func (svc *Service) SetDefaultFunctionsPipeline(transforms ...interfaces.AppFunc
 
 // AddFunctionsPipelineForTopics adds a functions pipeline for the specified for the specified id and topics
 func (svc *Service) AddFunctionsPipelineForTopics(id string, topics []string, transforms ...interfaces.AppFunction) error {
+	if err := validatePipeline(topics, transforms); err != nil {
+		return err
+	}
+
+	err := svc.runtime.AddFunctionsPipeline(id, topics, transforms)
+	if err != nil {
+		return err
+	}
+
+	svc.lc.Debugf("Pipeline '%s' added for topics '%v' with %d transform(s)", id, topics, len(transforms))
+	return nil
+}
+
+// AddShadowFunctionsPipelineForTopics adds a shadow functions pipeline, in the specified mode, for the specified id
+// and topics
+func (svc *Service) AddShadowFunctionsPipelineForTopics(id string, topics []string, mode string, transforms ...interfaces.AppFunction) error {
+	if err := validatePipeline(topics, transforms); err != nil {
+		return err
+	}
+
+	err := svc.runtime.AddShadowFunctionsPipeline(id, topics, mode, transforms)
+	if err != nil {
+		return err
+	}
+
+	svc.lc.Infof("Shadow pipeline '%s' added in '%s' mode for topics '%v' with %d transform(s)", id, mode, topics, len(transforms))
+	return nil
+}
+
+// SetFunctionErrorPolicy sets the policy applied when the function at the specified index of the pipeline fails
+func (svc *Service) SetFunctionErrorPolicy(pipelineId string, functionIndex int, policy interfaces.ErrorPolicy) error {
+	if err := svc.runtime.SetFunctionErrorPolicy(pipelineId, functionIndex, policy); err != nil {
+		return err
+	}
+
+	svc.lc.Debugf("Error policy '%s' set for function #%d of pipeline '%s'", policy.Action, functionIndex, pipelineId)
+	return nil
+}
+
+// SetPipelineErrorHandler sets the handler called each time a function of any pipeline fails
+func (svc *Service) SetPipelineErrorHandler(handler interfaces.PipelineErrorHandler) {
+	svc.runtime.SetPipelineErrorHandler(handler)
+}
+
+// SetPipelineRouter sets the router choosing the pipelines that handle each message received by the trigger
+func (svc *Service) SetPipelineRouter(router interfaces.PipelineRouter) {
+	svc.runtime.SetPipelineRouter(router)
+}
+
+// UseMiddleware adds middleware wrapping every function of every pipeline
+func (svc *Service) UseMiddleware(middleware ...interfaces.Middleware) {
+	svc.runtime.UseMiddleware(middleware...)
+	svc.lc.Debugf("%d middleware added to the pipeline functions", len(middleware))
+}
+
+func validatePipeline(topics []string, transforms []interfaces.AppFunction) error {
 	if len(transforms) == 0 {
 		return errors.New("no transforms provided to pipeline")
 	}
@@ -396,12 +763,7 @@ func (svc *Service) AddFunctionsPipelineForTopics(id string, topics []string, tr
 			return errors.New("blank topic not allowed")
 		}
 	}
-	err := svc.runtime.AddFunctionsPipeline(id, topics, transforms)
-	if err != nil {
-		return err
-	}
 
-	svc.lc.Debugf("Pipeline '%s' added for topics '%v' with %d transform(s)", id, topics, len(transforms))
 	return nil
 }
 
@@ -448,13 +810,18 @@ func (svc *Service) Initialize() error {
 		"    -s/--skipVersionCheck           Indicates the service should skip the Core Service's version compatibility check.\n" +
 			"    -sk/--serviceKey                Overrides the service service key used with Registry and/or Configuration Providers.\n" +
 			"                                    If the name provided contains the text `<profile>`, this text will be replaced with\n" +
-			"                                    the name of the profile used."
+			"                                    the name of the profile used.\n" +
+			"    --selftest                      Runs the self-test, validating the configuration, checking the connections and\n" +
+			"                                    running a payload through the pipelines without exporting, instead of the trigger.\n" +
+			"    --selftestPayload <file>        File containing the JSON payload used by the self-test instead of a sample Event."
 
 	svc.flags = flags.NewWithUsage(additionalUsage)
 	svc.flags.FlagSet.BoolVar(&svc.commandLine.skipVersionCheck, "skipVersionCheck", false, "")
 	svc.flags.FlagSet.BoolVar(&svc.commandLine.skipVersionCheck, "s", false, "")
 	svc.flags.FlagSet.StringVar(&svc.commandLine.serviceKeyOverride, "serviceKey", "", "")
 	svc.flags.FlagSet.StringVar(&svc.commandLine.serviceKeyOverride, "sk", "", "")
+	svc.flags.FlagSet.BoolVar(&svc.commandLine.selfTest, "selftest", false, "")
+	svc.flags.FlagSet.StringVar(&svc.commandLine.selfTestPayload, "selftestPayload", "", "")
 
 	svc.flags.Parse(os.Args[1:])
 
@@ -491,6 +858,7 @@ func (svc *Service) Initialize() error {
 		svc.dic,
 		true,
 		[]bootstrapInterfaces.BootstrapHandler{
+			handlers.NewSecrets().BootstrapHandler,
 			handlers.NewDatabase().BootstrapHandler,
 			handlers.NewClients().BootstrapHandler,
 			handlers.NewTelemetry().BootstrapHandler,
@@ -514,14 +882,212 @@ func (svc *Service) Initialize() error {
 	// to wait to be signaled when the configuration has been updated and then process the changes
 	NewConfigUpdateProcessor(svc).WaitForConfigUpdates(configUpdated)
 
+	// Rebuild the clients whose credentials are updated in the secret store instead of requiring a restart
+	clientSwapTracker := clientswap.NewTracker()
+	svc.dic.Update(di.ServiceConstructorMap{
+		container.ClientSwapTrackerName: func(get di.Get) interface{} {
+			return clientSwapTracker
+		},
+	})
+	NewSecretUpdateProcessor(svc, clientSwapTracker).WaitForSecretUpdates()
+
+	if svc.config.LastValueCache.Enabled {
+		if err := svc.startLastValueCache(); err != nil {
+			return err
+		}
+	}
+
+	if svc.config.Tenancy.Enabled {
+		tenantRouter, err := tenancy.NewRouter(svc.config.Tenancy)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.TenantRouterName: func(get di.Get) interface{} {
+				return tenantRouter
+			},
+		})
+
+		svc.lc.Infof("Tenancy enabled, routing messages for %d tenant(s) by %s", len(svc.config.Tenancy.Tenants), svc.config.Tenancy.Source)
+	}
+
+	if svc.config.DeliveryReceipts.Enabled {
+		tracker, err := receipts.NewTracker(svc.config.DeliveryReceipts)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.DeliveryTrackerName: func(get di.Get) interface{} {
+				return tracker
+			},
+		})
+
+		svc.lc.Info("Delivery receipts enabled, tracking the acknowledgement of exported data")
+	}
+
+	if svc.config.ExportGuard.Enabled {
+		guard, err := exportguard.NewGuard(svc.config.ExportGuard, container.StoreClientFrom(svc.dic.Get), svc.serviceKey)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.ExportGuardName: func(get di.Get) interface{} {
+				return guard
+			},
+		})
+
+		svc.lc.Info("Export guard enabled, skipping the export of data already exported")
+	}
+
+	if svc.config.HashChain.Enabled {
+		chain, err := hashchain.NewChain(container.StoreClientFrom(svc.dic.Get), svc.serviceKey)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.HashChainName: func(get di.Get) interface{} {
+				return chain
+			},
+		})
+
+		svc.lc.Info("Hash chain enabled, linking the Events exported into hash chains")
+	}
+
+	if svc.config.DeviceGroups.Enabled {
+		registry, err := devicegroups.NewRegistry(svc.config.DeviceGroups, svc.lc)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.DeviceGroupsName: func(get di.Get) interface{} {
+				return registry
+			},
+		})
+
+		registry.Start(svc.ctx.appWg, svc.ctx.appCtx, container.DeviceClientFrom(svc.dic.Get))
+		svc.lc.Info("Device groups enabled, retrieving the members of the device groups from Core Metadata")
+	}
+
+	if svc.config.Trigger.Watchdog.Enabled {
+		executionWatchdog, err := watchdog.NewWatchdog(svc.config.Trigger.Watchdog, svc.restartTrigger, svc.lc)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.WatchdogName: func(get di.Get) interface{} {
+				return executionWatchdog
+			},
+		})
+
+		executionWatchdog.Start(svc.ctx.appWg, svc.ctx.appCtx)
+		svc.lc.Info("Watchdog enabled, detecting stalled pipeline executions")
+	}
+
+	if svc.config.CostAccounting.Enabled {
+		accountant, err := accounting.NewAccountant(svc.config.CostAccounting, svc.lc)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.AccountantName: func(get di.Get) interface{} {
+				return accountant
+			},
+		})
+
+		svc.lc.Info("Cost accounting enabled, tracking the CPU time and bytes produced by each pipeline")
+	}
+
+	if svc.config.MetricsHistory.Enabled {
+		metricsHistory, err := history.NewHistory(svc.config.MetricsHistory, svc.lc)
+		if err != nil {
+			return err
+		}
+
+		svc.dic.Update(di.ServiceConstructorMap{
+			container.MetricsHistoryName: func(get di.Get) interface{} {
+				return metricsHistory
+			},
+		})
+
+		svc.addDeferred(metricsHistory.Close)
+		svc.lc.Info("Metrics history enabled, keeping hourly rollups of each pipeline's executions")
+	}
+
+	if svc.config.DeviceMonitor.Enabled {
+		if err := svc.startDeviceMonitor(); err != nil {
+			return err
+		}
+
+		svc.lc.Info("Device monitor enabled, detecting the devices that have gone silent")
+	}
+
+	// The capture buffer is always available as capturing is enabled and disabled via the Writable configuration
+	captureBuffer := capture.NewBuffer()
+	svc.dic.Update(di.ServiceConstructorMap{
+		container.CaptureBufferName: func(get di.Get) interface{} {
+			return captureBuffer
+		},
+	})
+
 	svc.webserver = webserver.NewWebServer(svc.dic, mux.NewRouter())
 	svc.webserver.ConfigureStandardRoutes()
+	svc.webserver.SetupReplayRoute(svc.ReplayEvents, svc.ctx.appWg)
+	svc.webserver.SetupPreviewRoute(svc.PreviewFunction)
+	svc.webserver.SetupDebugRoute(svc.StartDebugSession)
 
 	svc.lc.Info("Service started in: " + startupTimer.SinceAsString())
 
 	return nil
 }
 
+// startLastValueCache creates the last value cache, restoring the readings previously persisted, and adds it to the
+// DIC so it is fed by the runtime and served by the cache endpoint
+func (svc *Service) startLastValueCache() error {
+	cacheConfig := svc.config.LastValueCache
+	lastValueCache := cache.NewLastValueCache(cacheConfig.PersistFile)
+
+	if cacheConfig.PersistFile != "" {
+		interval, err := time.ParseDuration(cacheConfig.PersistInterval)
+		if err != nil || interval <= 0 {
+			return fmt.Errorf("invalid LastValueCache PersistInterval '%s', must be a duration greater than 0", cacheConfig.PersistInterval)
+		}
+
+		if err := lastValueCache.Load(); err != nil {
+			svc.lc.Warnf("Unable to load last value cache from '%s', starting empty: %s", cacheConfig.PersistFile, err.Error())
+		}
+
+		lastValueCache.StartPersisting(svc.ctx.appWg, svc.ctx.appCtx, interval, svc.lc)
+	}
+
+	svc.dic.Update(di.ServiceConstructorMap{
+		container.LastValueCacheName: func(get di.Get) interface{} {
+			return lastValueCache
+		},
+	})
+
+	svc.lc.Infof("Last value cache enabled, persisted to '%s'", cacheConfig.PersistFile)
+	return nil
+}
+
+// ReplayEvents replays the Events for the devices, or all devices if none are specified, that originated in the time
+// range from Core Data through the function pipeline(s). Replay stops when the service is stopped.
+func (svc *Service) ReplayEvents(start time.Time, end time.Time, deviceNames ...string) (int, error) {
+	return replay.Events(svc.ctx.appCtx, svc.dic, svc.runtime, start, end, deviceNames)
+}
+
+// RegisterCodec registers the codec for the content type with the SDK's codec registry, so the content type is
+// supported by the triggers, exports and response data
+func (svc *Service) RegisterCodec(contentType string, contentCodec codec.Codec) error {
+	return codec.Register(contentType, contentCodec)
+}
+
 // LoadCustomConfig uses the Config Processor from go-mod-bootstrap to attempt to load service's
 // custom configuration. It uses the same command line flags to process the custom config in the same manner
 // as the standard configuration.
@@ -552,6 +1118,22 @@ func (svc *Service) GetSecret(path string, keys ...string) (map[string]string, e
 	return secretProvider.GetSecret(path, keys...)
 }
 
+// RegisterSecretProvider registers the provider, asked for the secrets after the providers already registered and
+// before the Secret Store
+func (svc *Service) RegisterSecretProvider(provider interfaces.SecretProvider) error {
+	if provider == nil {
+		return errors.New("secret provider can not be nil")
+	}
+
+	chain := container.SecretChainFrom(svc.dic.Get)
+	if chain == nil {
+		return errors.New("secret providers can not be registered before the service is initialized")
+	}
+
+	chain.Register(provider)
+	return nil
+}
+
 // StoreSecret stores the secret data to a secret store at the specified path.
 func (svc *Service) StoreSecret(path string, secretData map[string]string) error {
 	secretProvider := bootstrapContainer.SecretProviderFrom(svc.dic.Get)
@@ -193,6 +193,20 @@ func TestAddBackgroundPublisher_Custom_Topic(t *testing.T) {
 		"same channel should be referenced by the BackgroundPublisher and the SDK.")
 }
 
+func TestAddBackgroundPublisher_Multiple(t *testing.T) {
+	sdk := Service{config: &common.ConfigurationStruct{}}
+
+	first, err := sdk.AddBackgroundPublisherWithTopic(1, "first")
+	require.NoError(t, err)
+	second, err := sdk.AddBackgroundPublisherWithTopic(1, "second")
+	require.NoError(t, err)
+
+	assert.Equal(t, fmt.Sprintf("%p", sdk.backgroundPublishChannel), fmt.Sprintf("%p", first.(*backgroundPublisher).output),
+		"first publisher's channel should be kept")
+	require.Len(t, sdk.backgroundChannels, 1, "additional publisher's channel should be merged once the service runs")
+	assert.Equal(t, fmt.Sprintf("%p", sdk.backgroundChannels[0]), fmt.Sprintf("%p", second.(*backgroundPublisher).output))
+}
+
 func TestAddBackgroundPublisher_MQTT(t *testing.T) {
 	sdk := Service{
 		config: &common.ConfigurationStruct{
@@ -350,6 +364,51 @@ func TestService_AddFunctionsPipelineForTopics(t *testing.T) {
 		})
 	}
 }
+func TestService_AddShadowFunctionsPipelineForTopics(t *testing.T) {
+	service := Service{
+		lc:      lc,
+		dic:     dic,
+		runtime: runtime.NewGolangRuntime("", nil, dic),
+	}
+
+	tags := builtin.NewTags(nil)
+	transforms := []interfaces.AppFunction{tags.AddTags}
+	require.NoError(t, service.AddFunctionsPipelineForTopics("production", []string{"#"}, transforms...))
+
+	tests := []struct {
+		name        string
+		id          string
+		topics      []string
+		mode        string
+		transforms  []interfaces.AppFunction
+		expectError bool
+	}{
+		{"Happy Path Log", "shadow-log", []string{"#"}, interfaces.ShadowModeLog, transforms, false},
+		{"Happy Path Send", "shadow-send", []string{"edgex/events/#"}, interfaces.ShadowModeSend, transforms, false},
+		{"Duplicate Id", "production", []string{"#"}, interfaces.ShadowModeLog, transforms, true},
+		{"Invalid Mode", "shadow-bad", []string{"#"}, "drop", transforms, true},
+		{"Empty Topics", "shadow-none", nil, interfaces.ShadowModeLog, transforms, true},
+		{"No Transforms", "shadow-empty", []string{"#"}, interfaces.ShadowModeLog, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := service.AddShadowFunctionsPipelineForTopics(test.id, test.topics, test.mode, test.transforms...)
+			if test.expectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			actual := service.runtime.GetPipelineById(test.id)
+			require.NotNil(t, actual)
+			assert.Equal(t, test.mode, actual.ShadowMode)
+		})
+	}
+
+	assert.Empty(t, service.runtime.GetPipelineById("production").ShadowMode)
+}
+
 func TestApplicationSettings(t *testing.T) {
 	expectedSettingKey := "ApplicationName"
 	expectedSettingValue := "simple-filter-xml"
@@ -561,6 +620,105 @@ func TestLoadConfigurableFunctionPipelinesNumFunctions(t *testing.T) {
 	pipeline, found = pipelines[perTopicPipelineId]
 	require.True(t, found)
 	assert.Equal(t, expectedTransformsCount, len(pipeline.Transforms))
+	assert.Empty(t, pipeline.ShadowMode)
+}
+
+func TestLoadConfigurableFunctionPipelinesShadowMode(t *testing.T) {
+	transforms := map[string]common.PipelineFunction{
+		"SetResponseData": {},
+	}
+
+	tests := []struct {
+		Name         string
+		ShadowMode   string
+		ExpectedMode string
+		ExpectError  bool
+	}{
+		{"Log", " Log ", interfaces.ShadowModeLog, false},
+		{"Send", "send", interfaces.ShadowModeSend, false},
+		{"Invalid", "discard", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sdk := Service{
+				lc: lc,
+				config: &common.ConfigurationStruct{
+					Writable: common.WritableInfo{
+						Pipeline: common.PipelineInfo{
+							PerTopicPipelines: map[string]common.TopicPipeline{
+								"shadow": {
+									Id:             "shadow",
+									Topics:         "#",
+									ExecutionOrder: "SetResponseData",
+									ShadowMode:     test.ShadowMode,
+								},
+							},
+							Functions: transforms,
+						},
+					},
+				},
+			}
+
+			pipelines, err := sdk.LoadConfigurableFunctionPipelines()
+			if test.ExpectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid ShadowMode")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedMode, pipelines["shadow"].ShadowMode)
+		})
+	}
+}
+
+func TestLoadConfigurableFunctionPipelinesErrorPolicy(t *testing.T) {
+	tests := []struct {
+		Name          string
+		ErrorPolicy   common.ErrorPolicyInfo
+		ExpectedError string
+	}{
+		{"Skip", common.ErrorPolicyInfo{Action: " Skip "}, ""},
+		{"Retry", common.ErrorPolicyInfo{Action: "retry", Retries: 3, RetryInterval: "100ms"}, ""},
+		{"Dead letter", common.ErrorPolicyInfo{Action: "deadletter", DeadLetterFunction: "SetResponseDataDLQ"}, ""},
+		{"Invalid action", common.ErrorPolicyInfo{Action: "ignore"}, "invalid action"},
+		{"Invalid interval", common.ErrorPolicyInfo{Action: "retry", Retries: 3, RetryInterval: "soon"}, "invalid ErrorPolicy RetryInterval"},
+		{"Dead letter not found", common.ErrorPolicyInfo{Action: "deadletter", DeadLetterFunction: "Missing"}, "dead letter function 'Missing'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sdk := Service{
+				lc:      lc,
+				runtime: runtime.NewGolangRuntime("", nil, dic),
+				config: &common.ConfigurationStruct{
+					Writable: common.WritableInfo{
+						Pipeline: common.PipelineInfo{
+							ExecutionOrder: "SetResponseData, Compress",
+							Functions: map[string]common.PipelineFunction{
+								"SetResponseData": {},
+								"Compress": {
+									Parameters:  map[string]string{Algorithm: CompressGZIP},
+									ErrorPolicy: test.ErrorPolicy,
+								},
+								"SetResponseDataDLQ": {},
+							},
+						},
+					},
+				},
+			}
+
+			_, err := sdk.LoadConfigurableFunctionPipelines()
+			if test.ExpectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedError)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
 }
 
 func TestUseTargetTypeOfByteArrayTrue(t *testing.T) {
@@ -22,9 +22,13 @@ import (
 	"fmt"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/eventhubs"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/http"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/messagebus"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/mqtt"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/nats"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/replay"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/socket"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 	"strings"
 )
@@ -34,6 +38,10 @@ const (
 	TriggerTypeMessageBus = "EDGEX-MESSAGEBUS"
 	TriggerTypeMQTT       = "EXTERNAL-MQTT"
 	TriggerTypeHTTP       = "HTTP"
+	TriggerTypeNATS       = "NATS"
+	TriggerTypeEventHubs  = "AZURE-EVENTHUBS"
+	TriggerTypeReplay     = "REPLAY"
+	TriggerTypeSocket     = "SOCKET"
 )
 
 func (svc *Service) setupTrigger(configuration *common.ConfigurationStruct, runtime *runtime.GolangRuntime) interfaces.Trigger {
@@ -52,6 +60,22 @@ func (svc *Service) setupTrigger(configuration *common.ConfigurationStruct, runt
 		svc.LoggingClient().Info("External MQTT trigger selected")
 		t = mqtt.NewTrigger(svc.dic, svc.runtime)
 
+	case TriggerTypeNATS:
+		svc.LoggingClient().Info("NATS trigger selected")
+		t = nats.NewTrigger(svc.dic, svc.runtime)
+
+	case TriggerTypeEventHubs:
+		svc.LoggingClient().Info("Azure Event Hubs trigger selected")
+		t = eventhubs.NewTrigger(svc.dic, svc.runtime)
+
+	case TriggerTypeReplay:
+		svc.LoggingClient().Info("Replay trigger selected")
+		t = replay.NewTrigger(svc.dic, svc.runtime)
+
+	case TriggerTypeSocket:
+		svc.LoggingClient().Info("Socket trigger selected")
+		t = socket.NewTrigger(svc.dic, svc.runtime)
+
 	default:
 		if factory, found := svc.customTriggerFactories[triggerType]; found {
 			var err error
@@ -75,7 +99,11 @@ func (svc *Service) RegisterCustomTriggerFactory(name string,
 
 	if nu == TriggerTypeMessageBus ||
 		nu == TriggerTypeHTTP ||
-		nu == TriggerTypeMQTT {
+		nu == TriggerTypeMQTT ||
+		nu == TriggerTypeNATS ||
+		nu == TriggerTypeEventHubs ||
+		nu == TriggerTypeReplay ||
+		nu == TriggerTypeSocket {
 		return fmt.Errorf("cannot register custom trigger for builtin type (%s)", name)
 	}
 
@@ -29,9 +29,13 @@ import (
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/eventhubs"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/http"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/messagebus"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/mqtt"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/nats"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/replay"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/trigger/socket"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap"
@@ -70,6 +74,46 @@ func TestRegisterCustomTriggerFactory_MQTT(t *testing.T) {
 	require.Zero(t, len(sdk.customTriggerFactories), "nothing should be registered")
 }
 
+func TestRegisterCustomTriggerFactory_NATS(t *testing.T) {
+	name := strings.ToLower(TriggerTypeNATS)
+
+	sdk := Service{}
+	err := sdk.RegisterCustomTriggerFactory(name, nil)
+
+	require.Error(t, err, "should throw error")
+	require.Zero(t, len(sdk.customTriggerFactories), "nothing should be registered")
+}
+
+func TestRegisterCustomTriggerFactory_EventHubs(t *testing.T) {
+	name := strings.ToLower(TriggerTypeEventHubs)
+
+	sdk := Service{}
+	err := sdk.RegisterCustomTriggerFactory(name, nil)
+
+	require.Error(t, err, "should throw error")
+	require.Zero(t, len(sdk.customTriggerFactories), "nothing should be registered")
+}
+
+func TestRegisterCustomTriggerFactory_Replay(t *testing.T) {
+	name := strings.ToLower(TriggerTypeReplay)
+
+	sdk := Service{}
+	err := sdk.RegisterCustomTriggerFactory(name, nil)
+
+	require.Error(t, err, "should throw error")
+	require.Zero(t, len(sdk.customTriggerFactories), "nothing should be registered")
+}
+
+func TestRegisterCustomTriggerFactory_Socket(t *testing.T) {
+	name := strings.ToLower(TriggerTypeSocket)
+
+	sdk := Service{}
+	err := sdk.RegisterCustomTriggerFactory(name, nil)
+
+	require.Error(t, err, "should throw error")
+	require.Zero(t, len(sdk.customTriggerFactories), "nothing should be registered")
+}
+
 func TestRegisterCustomTrigger(t *testing.T) {
 	name := "cUsToM tRiGgEr"
 	trig := mockCustomTrigger{}
@@ -149,6 +193,106 @@ func TestSetupTrigger_MQTT(t *testing.T) {
 	require.IsType(t, &mqtt.Trigger{}, trigger, "should be an external-MQTT trigger")
 }
 
+func TestSetupTrigger_NATS(t *testing.T) {
+	config := &common.ConfigurationStruct{
+		Trigger: common.TriggerInfo{
+			Type: TriggerTypeNATS,
+		},
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+
+	sdk := Service{
+		dic:    dic,
+		config: config,
+		lc:     lc,
+	}
+
+	trigger := sdk.setupTrigger(sdk.config, nil)
+
+	require.NotNil(t, trigger, "should be defined")
+	require.IsType(t, &nats.Trigger{}, trigger, "should be a NATS trigger")
+}
+
+func TestSetupTrigger_EventHubs(t *testing.T) {
+	config := &common.ConfigurationStruct{
+		Trigger: common.TriggerInfo{
+			Type: TriggerTypeEventHubs,
+		},
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+
+	sdk := Service{
+		dic:    dic,
+		config: config,
+		lc:     lc,
+	}
+
+	trigger := sdk.setupTrigger(sdk.config, nil)
+
+	require.NotNil(t, trigger, "should be defined")
+	require.IsType(t, &eventhubs.Trigger{}, trigger, "should be an Azure Event Hubs trigger")
+}
+
+func TestSetupTrigger_Replay(t *testing.T) {
+	config := &common.ConfigurationStruct{
+		Trigger: common.TriggerInfo{
+			Type: TriggerTypeReplay,
+		},
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+
+	sdk := Service{
+		dic:    dic,
+		config: config,
+		lc:     lc,
+	}
+
+	trigger := sdk.setupTrigger(sdk.config, nil)
+
+	require.NotNil(t, trigger, "should be defined")
+	require.IsType(t, &replay.Trigger{}, trigger, "should be a Replay trigger")
+}
+
+func TestSetupTrigger_Socket(t *testing.T) {
+	config := &common.ConfigurationStruct{
+		Trigger: common.TriggerInfo{
+			Type: TriggerTypeSocket,
+		},
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+
+	sdk := Service{
+		dic:    dic,
+		config: config,
+		lc:     lc,
+	}
+
+	trigger := sdk.setupTrigger(sdk.config, nil)
+
+	require.NotNil(t, trigger, "should be defined")
+	require.IsType(t, &socket.Trigger{}, trigger, "should be a Socket trigger")
+}
+
 type mockCustomTrigger struct {
 }
 
@@ -98,17 +98,23 @@ func (mp *triggerMessageProcessor) MessageReceived(ctx interfaces.AppFunctionCon
 		ctx = mp.bnd.BuildContext(envelope)
 	}
 
-	pipelines := mp.bnd.GetMatchingPipelines(envelope.ReceivedTopic)
+	done, duplicate := mp.bnd.BeginMessage(envelope)
+	if duplicate {
+		return nil
+	}
+
+	pipelines := mp.bnd.RoutePipelines(envelope)
 
 	lc.Debugf("trigger found %d pipeline(s) that match the incoming topic '%s'", len(pipelines), envelope.ReceivedTopic)
 
 	var finalErr error
+	var errorsLock sync.Mutex
 
 	pipelinesWaitGroup := sync.WaitGroup{}
 
 	for _, pipeline := range pipelines {
 		pipelinesWaitGroup.Add(1)
-		go func(p *interfaces.FunctionPipeline, wg *sync.WaitGroup, errCollector func(error)) {
+		execute := func(p *interfaces.FunctionPipeline, wg *sync.WaitGroup, errCollector func(error)) {
 			defer wg.Done()
 
 			lc.Debugf("trigger sending message to pipeline %s (%s)", p.Id, envelope.CorrelationID)
@@ -126,10 +132,28 @@ func (mp *triggerMessageProcessor) MessageReceived(ctx interfaces.AppFunctionCon
 				}
 				lc.Debugf("trigger successfully processed message '%s' in pipeline %s", p.Id, envelope.CorrelationID)
 			}
-		}(pipeline, &pipelinesWaitGroup, func(e error) { finalErr = multierror.Append(finalErr, e) })
+		}
+
+		p := pipeline
+		errCollector := func(e error) {
+			errorsLock.Lock()
+			defer errorsLock.Unlock()
+			finalErr = multierror.Append(finalErr, e)
+		}
+
+		scheduled := mp.bnd.ScheduleExecution(envelope, func() {
+			execute(p, &pipelinesWaitGroup, errCollector)
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			errCollector(fmt.Errorf("message '%s' not processed by pipeline %s, service is shutting down", envelope.CorrelationID, p.Id))
+		}
 	}
 
 	pipelinesWaitGroup.Wait()
 
+	done(finalErr == nil)
+
 	return finalErr
 }
@@ -129,8 +129,14 @@ func Test_triggerMessageProcessor_MessageReceived(t *testing.T) {
 			tsb := triggerMocks.ServiceBinding{}
 
 			tsb.On("ProcessMessage", mock.Anything, mock.Anything, mock.Anything).Return(tt.setup.runtimeProcessor)
-			tsb.On("GetMatchingPipelines", tt.args.envelope.ReceivedTopic).Return(tt.setup.pipelineMatcher)
+			tsb.On("RoutePipelines", tt.args.envelope).Return(tt.setup.pipelineMatcher)
 			tsb.On("LoggingClient").Return(lc)
+			tsb.On("BeginMessage", tt.args.envelope).Return(func(succeeded bool) {
+				assert.Equal(t, tt.wantErr == 0, succeeded)
+			}, false)
+			tsb.On("ScheduleExecution", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				go args.Get(1).(func())()
+			}).Return(true)
 
 			bnd := &triggerMessageProcessor{
 				&tsb,
@@ -157,3 +163,19 @@ func Test_triggerMessageProcessor_MessageReceived(t *testing.T) {
 		})
 	}
 }
+
+func Test_triggerMessageProcessor_MessageReceivedDuplicate(t *testing.T) {
+	envelope := types.MessageEnvelope{CorrelationID: uuid.NewString(), ReceivedTopic: uuid.NewString()}
+
+	tsb := triggerMocks.ServiceBinding{}
+	tsb.On("LoggingClient").Return(lc)
+	tsb.On("BeginMessage", envelope).Return(func(bool) {}, true)
+
+	bnd := &triggerMessageProcessor{
+		&tsb,
+	}
+
+	err := bnd.MessageReceived(&appfunction.Context{}, envelope, nil)
+	require.NoError(t, err)
+	tsb.AssertNotCalled(t, "RoutePipelines", mock.Anything)
+}
@@ -28,6 +28,7 @@ import (
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/tenancy"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
@@ -59,8 +60,12 @@ type Context struct {
 	responseData         []byte
 	retryData            []byte
 	responseContentType  string
+	responseHeaders      map[string]string
+	topicResponses       []interfaces.TopicResponse
+	tags                 map[string]string
 	contextData          map[string]string
 	valuePlaceholderSpec *regexp.Regexp
+	ctx                  context.Context
 }
 
 // Clone returns a copy of the context that can be manipulated independently.
@@ -71,6 +76,22 @@ func (appContext *Context) Clone() interfaces.AppFunctionContext {
 		contextCopy[k] = v
 	}
 
+	var headersCopy map[string]string
+	if appContext.responseHeaders != nil {
+		headersCopy = make(map[string]string, len(appContext.responseHeaders))
+		for k, v := range appContext.responseHeaders {
+			headersCopy[k] = v
+		}
+	}
+
+	var tagsCopy map[string]string
+	if appContext.tags != nil {
+		tagsCopy = make(map[string]string, len(appContext.tags))
+		for k, v := range appContext.tags {
+			tagsCopy[k] = v
+		}
+	}
+
 	return &Context{
 		Dic:                  appContext.Dic,
 		correlationID:        appContext.correlationID,
@@ -78,8 +99,12 @@ func (appContext *Context) Clone() interfaces.AppFunctionContext {
 		responseData:         appContext.responseData,
 		retryData:            appContext.retryData,
 		responseContentType:  appContext.responseContentType,
+		responseHeaders:      headersCopy,
+		topicResponses:       append([]interfaces.TopicResponse(nil), appContext.topicResponses...),
+		tags:                 tagsCopy,
 		contextData:          contextCopy,
 		valuePlaceholderSpec: appContext.valuePlaceholderSpec,
+		ctx:                  appContext.ctx,
 	}
 }
 
@@ -94,6 +119,21 @@ func (appContext *Context) CorrelationID() string {
 	return appContext.correlationID
 }
 
+// SetContext sets the context.Context of the pipeline execution. This function is not part of the AppFunctionContext
+// interface, so it is internal SDK use only
+func (appContext *Context) SetContext(ctx context.Context) {
+	appContext.ctx = ctx
+}
+
+// Context returns the context.Context of the pipeline execution, which is done once the service is shutting down, the
+// execution exceeds the Trigger's PipelineTimeout or the trigger's context, i.e. the HTTP request's, is done.
+func (appContext *Context) Context() context.Context {
+	if appContext.ctx == nil {
+		return context.Background()
+	}
+	return appContext.ctx
+}
+
 // SetInputContentType sets the inputContentType. This function is not part of the AppFunctionContext interface,
 // so it is internal SDK use only
 func (appContext *Context) SetInputContentType(contentType string) {
@@ -127,6 +167,53 @@ func (appContext *Context) ResponseContentType() string {
 	return appContext.responseContentType
 }
 
+// SetResponseHeader sets a header, or metadata, returned with the response data to the trigger, replacing any
+// value previously set for the key
+func (appContext *Context) SetResponseHeader(key string, value string) {
+	if appContext.responseHeaders == nil {
+		appContext.responseHeaders = make(map[string]string)
+	}
+	appContext.responseHeaders[key] = value
+}
+
+// ResponseHeaders returns the context's response headers
+func (appContext *Context) ResponseHeaders() map[string]string {
+	return appContext.responseHeaders
+}
+
+// PublishToTopic adds data that will be published to the topic by the trigger when the pipeline completes
+func (appContext *Context) PublishToTopic(topic string, data []byte, contentType string) {
+	appContext.topicResponses = append(appContext.topicResponses, interfaces.TopicResponse{
+		Topic:       topic,
+		Data:        data,
+		ContentType: contentType,
+	})
+}
+
+// TopicResponses returns the data that will be published to topics by the trigger when the pipeline completes
+func (appContext *Context) TopicResponses() []interfaces.TopicResponse {
+	return appContext.topicResponses
+}
+
+// ClearTopicResponses discards the data added with PublishToTopic, so the trigger doesn't publish it
+func (appContext *Context) ClearTopicResponses() {
+	appContext.topicResponses = nil
+}
+
+// SetTag sets a tag identifying the data, which is sent as a header with the data exported via HTTP and the
+// trigger's response, replacing any value previously set for the key
+func (appContext *Context) SetTag(key string, value string) {
+	if appContext.tags == nil {
+		appContext.tags = make(map[string]string)
+	}
+	appContext.tags[key] = value
+}
+
+// Tags returns the context's tags
+func (appContext *Context) Tags() map[string]string {
+	return appContext.tags
+}
+
 // SetRetryData sets the context's retryData to the specified payload to be stored for later retry
 // when the pipeline function returns an error.
 func (appContext *Context) SetRetryData(payload []byte) {
@@ -139,21 +226,39 @@ func (appContext *Context) RetryData() []byte {
 	return appContext.retryData
 }
 
-// GetSecret returns the secret data from the secret store (secure or insecure) for the specified path.
-func (appContext *Context) GetSecret(path string, keys ...string) (map[string]string, error) {
+// GetSecrets returns the secret data for the specified path from the registered SecretProviders, or else the secret
+// store (secure or insecure). When the message is for a tenant with a SecretPath, the path is under the tenant's
+// SecretPath.
+func (appContext *Context) GetSecrets(path string, keys ...string) (map[string]string, error) {
+	if tenant, found := appContext.GetValue(interfaces.TENANT); found {
+		if router := container.TenantRouterFrom(appContext.Dic.Get); router != nil {
+			path = router.SecretPath(tenant, path)
+		}
+	}
+
 	secretProvider := bootstrapContainer.SecretProviderFrom(appContext.Dic.Get)
 	return secretProvider.GetSecret(path, keys...)
 }
 
+// GetSecret returns the same secret data as GetSecrets
+func (appContext *Context) GetSecret(path string, keys ...string) (map[string]string, error) {
+	return appContext.GetSecrets(path, keys...)
+}
+
 // SecretsLastUpdated returns that timestamp for when the secrets in the SecretStore where last updated.
 func (appContext *Context) SecretsLastUpdated() time.Time {
 	secretProvider := bootstrapContainer.SecretProviderFrom(appContext.Dic.Get)
 	return secretProvider.SecretsLastUpdated()
 }
 
-// LoggingClient returns the Logging client from the dependency injection container
+// LoggingClient returns the Logging client from the dependency injection container. When the message is for a tenant,
+// the tenant is added to the messages logged.
 func (appContext *Context) LoggingClient() logger.LoggingClient {
-	return bootstrapContainer.LoggingClientFrom(appContext.Dic.Get)
+	lc := bootstrapContainer.LoggingClientFrom(appContext.Dic.Get)
+	if tenant, found := appContext.GetValue(interfaces.TENANT); found && tenant != "" {
+		return tenancy.NewTaggedLogger(lc, tenant)
+	}
+	return lc
 }
 
 // EventClient returns the Event client, which may be nil, from the dependency injection container
@@ -201,6 +306,54 @@ func (appContext *Context) RemoveValue(key string) {
 	delete(appContext.contextData, strings.ToLower(key))
 }
 
+// DeliveryReceipts returns the tracker of the deliveries of exported data, which may be nil, from the dependency
+// injection container
+func (appContext *Context) DeliveryReceipts() interfaces.DeliveryReceiptTracker {
+	tracker := container.DeliveryTrackerFrom(appContext.Dic.Get)
+	if tracker == nil {
+		// A nil *receipts.Tracker must not be returned as a non-nil interface
+		return nil
+	}
+	return tracker
+}
+
+// ExportGuard returns the guard against exporting data already exported, which may be nil, from the dependency
+// injection container
+func (appContext *Context) ExportGuard() interfaces.ExportGuard {
+	guard := container.ExportGuardFrom(appContext.Dic.Get)
+	if guard == nil {
+		// A nil *exportguard.Guard must not be returned as a non-nil interface
+		return nil
+	}
+	return guard
+}
+
+// HashChain returns the hash chain of the Events exported, which may be nil, from the dependency injection container
+func (appContext *Context) HashChain() interfaces.HashChain {
+	chain := container.HashChainFrom(appContext.Dic.Get)
+	if chain == nil {
+		// A nil *hashchain.Chain must not be returned as a non-nil interface
+		return nil
+	}
+	return chain
+}
+
+// Loopback returns the loopback feeding data to the pipelines of the same service, which may be nil, from the
+// dependency injection container
+func (appContext *Context) Loopback() interfaces.Loopback {
+	return container.LoopbackFrom(appContext.Dic.Get)
+}
+
+// DeviceGroups returns the members of the device groups, which may be nil, from the dependency injection container
+func (appContext *Context) DeviceGroups() interfaces.DeviceGroups {
+	registry := container.DeviceGroupsFrom(appContext.Dic.Get)
+	if registry == nil {
+		// A nil *devicegroups.Registry must not be returned as a non-nil interface
+		return nil
+	}
+	return registry
+}
+
 // PushToCore pushes a new event to Core Data.
 func (appContext *Context) PushToCore(event dtos.Event) (common.BaseWithIdResponse, error) {
 	client := appContext.EventClient()
@@ -277,6 +430,51 @@ func (appContext *Context) ApplyValues(format string) (string, error) {
 	return result, nil
 }
 
+// topicLevelReplacer replaces the characters of the values in a topic that would split the topic level or be wildcards
+var topicLevelReplacer = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+
+// topicPlaceholderKeys are the context values replacing the topic placeholders of the Event's names
+var topicPlaceholderKeys = map[string]string{
+	interfaces.TopicPlaceholderDevice:  interfaces.DEVICENAME,
+	interfaces.TopicPlaceholderProfile: interfaces.PROFILENAME,
+	interfaces.TopicPlaceholderSource:  interfaces.SOURCENAME,
+}
+
+// FormatTopic replaces the placeholders of the topic template with the Event's names, the context's values or the
+// context's tags, each replaced value being a single topic level. An error will be returned if any placeholders are
+// not matched.
+func (appContext *Context) FormatTopic(template string) (string, error) {
+	var missing []string
+
+	topic := appContext.valuePlaceholderSpec.ReplaceAllStringFunc(template, func(placeholder string) string {
+		key := strings.TrimRight(strings.TrimLeft(placeholder, "{"), "}")
+
+		value, found := "", false
+		if valueKey, isEventName := topicPlaceholderKeys[strings.ToLower(key)]; isEventName {
+			value, found = appContext.GetValue(valueKey)
+		}
+		if !found {
+			value, found = appContext.GetValue(key)
+		}
+		if !found {
+			value, found = appContext.tags[key]
+		}
+
+		if !found {
+			missing = append(missing, placeholder)
+			return placeholder
+		}
+
+		return topicLevelReplacer.Replace(value)
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("failed to replace topic placeholders %s in '%s'", strings.Join(missing, ", "), template)
+	}
+
+	return topic, nil
+}
+
 // PipelineId returns the ID of the pipeline that is executing
 func (appContext *Context) PipelineId() string {
 	id, _ := appContext.GetValue(interfaces.PIPELINEID)
@@ -26,6 +26,8 @@ import (
 	"time"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/secretchain"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
@@ -255,6 +257,20 @@ func TestContext_RetryData(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestContext_Tags(t *testing.T) {
+	sut := NewContext("123", dic, "")
+	assert.Empty(t, sut.Tags())
+
+	sut.SetTag("GatewayId", "HoustonStore000123")
+	sut.SetTag("Latitude", "29.630771")
+
+	expected := map[string]string{
+		"GatewayId": "HoustonStore000123",
+		"Latitude":  "29.630771",
+	}
+	assert.Equal(t, expected, sut.Tags())
+}
+
 func TestContext_GetSecret(t *testing.T) {
 	// setup mock secret client
 	expected := map[string]string{
@@ -276,6 +292,41 @@ func TestContext_GetSecret(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+// fileProvider holds the "mqtt" secrets, as a registered SecretProvider reading them from files would
+type fileProvider struct{}
+
+func (fileProvider) GetSecrets(path string, _ ...string) (map[string]string, bool, error) {
+	if path != "mqtt" {
+		return nil, false, nil
+	}
+	return map[string]string{"password": "FILE_PASS"}, true, nil
+}
+
+func (fileProvider) SecretsLastUpdated() time.Time {
+	return time.Time{}
+}
+
+func TestContext_GetSecrets(t *testing.T) {
+	mockSecretProvider := &mocks.SecretProvider{}
+	mockSecretProvider.On("GetSecret", "redisdb", "password").Return(map[string]string{"password": "STORE_PASS"}, nil)
+
+	chain := secretchain.NewChain(mockSecretProvider)
+	chain.Register(fileProvider{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return chain
+		},
+	})
+
+	actual, err := target.GetSecrets("mqtt", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "FILE_PASS"}, actual)
+
+	actual, err = target.GetSecrets("redisdb", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "STORE_PASS"}, actual)
+}
+
 func TestContext_SecretsLastUpdated(t *testing.T) {
 	expected := time.Now()
 	mockSecretProvider := &mocks.SecretProvider{}
@@ -414,6 +465,33 @@ func TestContext_ApplyValues_MissingPlaceholder(t *testing.T) {
 	require.Equal(t, "", res)
 }
 
+func TestContext_FormatTopic(t *testing.T) {
+	target.contextData = map[string]string{
+		interfaces.DEVICENAME:  "thermostat/1",
+		interfaces.PROFILENAME: "thermostats",
+		interfaces.SOURCENAME:  "temperature",
+		"device":               "not the device name",
+		"site":                 "value",
+	}
+	target.tags = map[string]string{"site": "tag", "Region": "emea+west"}
+	defer func() {
+		target.contextData = map[string]string{}
+		target.tags = nil
+	}()
+
+	res, err := target.FormatTopic("edgex/events/{profile}/{device}/{source}")
+	require.NoError(t, err)
+	assert.Equal(t, "edgex/events/thermostats/thermostat_1/temperature", res, "values should be a single topic level")
+
+	res, err = target.FormatTopic("{site}/{Region}/{devicename}")
+	require.NoError(t, err)
+	assert.Equal(t, "value/emea_west/thermostat_1", res, "context values should take precedence over tags")
+
+	_, err = target.FormatTopic("edgex/{unknown}/{device}")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "{unknown}")
+}
+
 func TestContext_PushToCore(t *testing.T) {
 	mockClient := clientMocks.EventClient{}
 	mockClient.On("Add", mock.Anything, mock.Anything).Return(commonDtos.BaseWithIdResponse{}, nil)
@@ -466,6 +544,25 @@ func TestContext_GetDeviceResource_Error(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestContext_PublishToTopic(t *testing.T) {
+	appContext := NewContext(uuid.NewString(), dic, "")
+	assert.Empty(t, appContext.TopicResponses())
+
+	appContext.PublishToTopic("alerts/{deviceName}", []byte("alert"), common.ContentTypeText)
+	appContext.PublishToTopic("telemetry", []byte("{}"), "")
+
+	expected := []interfaces.TopicResponse{
+		{Topic: "alerts/{deviceName}", Data: []byte("alert"), ContentType: common.ContentTypeText},
+		{Topic: "telemetry", Data: []byte("{}")},
+	}
+	assert.Equal(t, expected, appContext.TopicResponses())
+
+	clone := appContext.Clone()
+	clone.PublishToTopic("other", []byte("other"), "")
+	assert.Len(t, clone.TopicResponses(), 3)
+	assert.Equal(t, expected, appContext.TopicResponses(), "topic responses of the clone should be independent")
+}
+
 func TestContext_Clone(t *testing.T) {
 	sut := Context{
 		Dic:                 dic,
@@ -478,6 +575,9 @@ func TestContext_Clone(t *testing.T) {
 			"test":  "val1",
 			"test2": "val2",
 		},
+		tags: map[string]string{
+			"GatewayId": "HoustonStore000123",
+		},
 		valuePlaceholderSpec: regexp.MustCompile(""),
 	}
 
@@ -501,4 +601,8 @@ func TestContext_Clone(t *testing.T) {
 	for k, v := range sut.contextData {
 		assert.Equal(t, v, clone.contextData[k])
 	}
+
+	assert.Equal(t, sut.tags, clone.tags)
+	clone.SetTag("Latitude", "29.630771")
+	assert.NotContains(t, sut.tags, "Latitude", "tags of the clone should be independent")
 }
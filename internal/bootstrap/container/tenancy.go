@@ -0,0 +1,37 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/tenancy"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+)
+
+// TenantRouterName contains the name of the tenancy.Router instance in the DIC.
+var TenantRouterName = di.TypeInstanceToName((*tenancy.Router)(nil))
+
+// TenantRouterFrom helper function queries the DIC and returns the tenancy.Router instance,
+// or nil when it hasn't been added.
+func TenantRouterFrom(get di.Get) *tenancy.Router {
+	item := get(TenantRouterName)
+
+	if item == nil {
+		return nil
+	}
+
+	return item.(*tenancy.Router)
+}
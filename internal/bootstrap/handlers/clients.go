@@ -20,8 +20,10 @@ import (
 	"sync"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/discovery"
 	clients "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/http"
 
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/startup"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
@@ -45,6 +47,17 @@ func (_ *Clients) BootstrapHandler(
 	dic *di.Container) bool {
 
 	config := container.ConfigurationFrom(dic.Get)
+	lc := bootstrapContainer.LoggingClientFrom(dic.Get)
+
+	serviceDiscovery, err := discovery.NewDiscovery(config.Discovery, bootstrapContainer.RegistryFrom(dic.Get), lc)
+	if err != nil {
+		lc.Error(err.Error())
+		return false
+	}
+
+	if serviceDiscovery.Type() != discovery.TypeStatic {
+		lc.Infof("Resolving the URLs of the Clients with %s discovery", serviceDiscovery.Type())
+	}
 
 	var eventClient interfaces.EventClient
 	var commandClient interfaces.CommandClient
@@ -57,28 +70,33 @@ func (_ *Clients) BootstrapHandler(
 	// Use of these client interfaces is optional, so they are not required to be configured. For instance if not
 	// sending commands, then don't need to have the Command client in the configuration.
 	if val, ok := config.Clients[common.CoreDataServiceKey]; ok {
-		eventClient = clients.NewEventClient(val.Url())
+		eventClient = clients.NewEventClient(serviceDiscovery.ServiceUrl(common.CoreDataServiceKey, val))
 	}
 
 	if val, ok := config.Clients[common.CoreCommandServiceKey]; ok {
-		commandClient = clients.NewCommandClient(val.Url())
+		commandClient = clients.NewCommandClient(serviceDiscovery.ServiceUrl(common.CoreCommandServiceKey, val))
 	}
 
 	if val, ok := config.Clients[common.CoreMetaDataServiceKey]; ok {
-		deviceServiceClient = clients.NewDeviceServiceClient(val.Url())
-		deviceProfileClient = clients.NewDeviceProfileClient(val.Url())
-		deviceClient = clients.NewDeviceClient(val.Url())
+		url := serviceDiscovery.ServiceUrl(common.CoreMetaDataServiceKey, val)
+		deviceServiceClient = clients.NewDeviceServiceClient(url)
+		deviceProfileClient = clients.NewDeviceProfileClient(url)
+		deviceClient = clients.NewDeviceClient(url)
 	}
 
 	if val, ok := config.Clients[common.SupportNotificationsServiceKey]; ok {
-		notificationClient = clients.NewNotificationClient(val.Url())
-		subscriptionClient = clients.NewSubscriptionClient(val.Url())
+		url := serviceDiscovery.ServiceUrl(common.SupportNotificationsServiceKey, val)
+		notificationClient = clients.NewNotificationClient(url)
+		subscriptionClient = clients.NewSubscriptionClient(url)
 	}
 
 	// Note that all the clients are optional so some or all these clients may be nil
 	// Code that uses them must verify the client was defined and created prior to using it.
 	// This information is provided in the documentation.
 	dic.Update(di.ServiceConstructorMap{
+		container.DiscoveryName: func(get di.Get) interface{} {
+			return serviceDiscovery
+		},
 		container.EventClientName: func(get di.Get) interface{} {
 			return eventClient
 		},
@@ -177,3 +177,34 @@ func TestClientsBootstrapHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestClientsBootstrapHandlerDiscovery(t *testing.T) {
+	configuration := &sdkCommon.ConfigurationStruct{
+		Discovery: sdkCommon.DiscoveryInfo{Type: "kubernetes", ServiceNamePrefix: "edgex-"},
+		Clients: map[string]config.ClientInfo{
+			common.CoreDataServiceKey: {Host: "localhost", Port: 59880, Protocol: "http"},
+		},
+	}
+
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return configuration
+		},
+	})
+
+	startupTimer := startup.NewStartUpTimer("unit-test")
+	require.True(t, NewClients().BootstrapHandler(context.Background(), &sync.WaitGroup{}, startupTimer, dic))
+
+	serviceDiscovery := container.DiscoveryFrom(dic.Get)
+	require.NotNil(t, serviceDiscovery)
+	assert.Equal(t, "http://edgex-core-data:59880",
+		serviceDiscovery.ServiceUrl(common.CoreDataServiceKey, configuration.Clients[common.CoreDataServiceKey]))
+	assert.NotNil(t, container.EventClientFrom(dic.Get))
+
+	configuration.Discovery.Type = "registry"
+	assert.False(t, NewClients().BootstrapHandler(context.Background(), &sync.WaitGroup{}, startupTimer, dic),
+		"registry discovery should fail without the Registry")
+}
@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handlers
+
+import (
+	"context"
+	"sync"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/startup"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/secretchain"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/secure"
+)
+
+// Secrets contains references to dependencies required by the Secrets bootstrap implementation.
+type Secrets struct {
+}
+
+// NewSecrets create a new instance of Secrets
+func NewSecrets() *Secrets {
+	return &Secrets{}
+}
+
+// BootstrapHandler replaces the SecretProvider with a secretchain.Chain, so the SecretProviders registered by the
+// application are asked for the secrets before the Secret Store or InsecureSecrets. A FileSecretProvider reading the
+// SecretsDirectory is registered first when the SecretsDirectory is enabled.
+func (_ *Secrets) BootstrapHandler(
+	_ context.Context,
+	_ *sync.WaitGroup,
+	_ startup.Timer,
+	dic *di.Container) bool {
+
+	lc := bootstrapContainer.LoggingClientFrom(dic.Get)
+	config := container.ConfigurationFrom(dic.Get)
+
+	chain := secretchain.NewChain(bootstrapContainer.SecretProviderFrom(dic.Get))
+
+	if config.SecretsDirectory.Enabled {
+		directory := config.SecretsDirectory.Path
+		if len(directory) == 0 {
+			directory = common.DefaultSecretsDirectory
+		}
+
+		pollInterval, err := config.SecretsDirectory.PollIntervalDuration()
+		if err != nil {
+			lc.Error(err.Error())
+			return false
+		}
+
+		provider, err := secure.NewFileSecretProvider(directory, pollInterval)
+		if err != nil {
+			lc.Error(err.Error())
+			return false
+		}
+
+		chain.Register(provider)
+		lc.Infof("Secrets are read from directory '%s', falling back to the Secret Store", directory)
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.SecretChainName: func(get di.Get) interface{} {
+			return chain
+		},
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return chain
+		},
+	})
+
+	return true
+}
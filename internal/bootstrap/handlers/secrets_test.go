@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package handlers
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/secretchain"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/startup"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsBootstrapHandler(t *testing.T) {
+	directory := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(directory, "mqtt"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "mqtt", "password"), []byte("secret"), 0600))
+
+	tests := []struct {
+		Name            string
+		Enabled         bool
+		Path            string
+		PollInterval    string
+		ExpectedSuccess bool
+		ExpectedFiles   bool
+	}{
+		{"Directory disabled", false, directory, "", true, false},
+		{"Directory enabled", true, directory, "30s", true, true},
+		{"Default poll interval", true, directory, "", true, true},
+		{"Missing directory", true, filepath.Join(directory, "missing"), "", false, false},
+		{"Invalid poll interval", true, directory, "often", false, false},
+		{"Negative poll interval", true, directory, "-1s", false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			configuration := &sdkCommon.ConfigurationStruct{
+				SecretsDirectory: sdkCommon.SecretsDirectoryInfo{
+					Enabled:      test.Enabled,
+					Path:         test.Path,
+					PollInterval: test.PollInterval,
+				},
+			}
+			secretStore := &mocks.SecretProvider{}
+			secretStore.On("GetSecret", "mqtt", "password").Return(map[string]string{"password": "vault"}, nil)
+
+			dic := di.NewContainer(di.ServiceConstructorMap{
+				bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+					return logger.NewMockClient()
+				},
+				container.ConfigurationName: func(get di.Get) interface{} {
+					return configuration
+				},
+				bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+					return secretStore
+				},
+			})
+
+			success := NewSecrets().BootstrapHandler(context.Background(), &sync.WaitGroup{},
+				startup.NewStartUpTimer("unit-test"), dic)
+			require.Equal(t, test.ExpectedSuccess, success)
+
+			provider := bootstrapContainer.SecretProviderFrom(dic.Get)
+			if !test.ExpectedSuccess {
+				assert.Equal(t, secretStore, provider, "secret provider should not be replaced")
+				return
+			}
+
+			require.IsType(t, &secretchain.Chain{}, provider)
+			assert.Equal(t, provider, container.SecretChainFrom(dic.Get), "chain should be available for registering providers")
+
+			expected := "vault"
+			if test.ExpectedFiles {
+				expected = "secret"
+			}
+			secrets, err := provider.GetSecret("mqtt", "password")
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"password": expected}, secrets)
+		})
+	}
+}
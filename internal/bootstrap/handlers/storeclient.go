@@ -44,6 +44,7 @@ func NewDatabase() *Database {
 }
 
 // BootstrapHandler creates the new interfaces.StoreClient use for database access by Store & Forward capability
+// and the ExportGuard and HashChain
 func (_ *Database) BootstrapHandler(
 	_ context.Context,
 	_ *sync.WaitGroup,
@@ -52,8 +53,8 @@ func (_ *Database) BootstrapHandler(
 
 	config := container.ConfigurationFrom(dic.Get)
 
-	// Only need the database client if Store and Forward is enabled
-	if !config.Writable.StoreAndForward.Enabled {
+	// Only need the database client if Store and Forward, the ExportGuard or the HashChain is enabled
+	if !config.Writable.StoreAndForward.Enabled && !config.ExportGuard.Enabled && !config.HashChain.Enabled {
 		dic.Update(di.ServiceConstructorMap{
 			container.StoreClientName: func(get di.Get) interface{} {
 				return nil
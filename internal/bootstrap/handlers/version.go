@@ -89,7 +89,12 @@ func (vv *VersionValidator) BootstrapHandler(
 		return false
 	}
 
-	client := clients.NewCommonClient(val.Url())
+	url := val.Url()
+	if serviceDiscovery := container.DiscoveryFrom(dic.Get); serviceDiscovery != nil {
+		url = serviceDiscovery.ServiceUrl(common.CoreMetaDataServiceKey, val)
+	}
+
+	client := clients.NewCommonClient(url)
 
 	var response commonDtos.VersionResponse
 	var err error
@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+// LastValueCache holds the latest reading of each device resource, as processed by the function pipelines, so local
+// clients can read the current values without querying Core Data. The cache is optionally persisted to a file so the
+// values survive a restart of the service.
+type LastValueCache struct {
+	lock        sync.RWMutex
+	devices     map[string]map[string]dtos.BaseReading
+	persistFile string
+	dirty       bool
+}
+
+// NewLastValueCache creates, initializes and returns a new instance of LastValueCache. persistFile is the file the
+// cache is saved to and loaded from, the cache isn't persisted when empty.
+func NewLastValueCache(persistFile string) *LastValueCache {
+	return &LastValueCache{
+		devices:     make(map[string]map[string]dtos.BaseReading),
+		persistFile: persistFile,
+	}
+}
+
+// Update caches the Event's readings, unless a reading with a later origin is already cached for the resource
+func (c *LastValueCache) Update(event dtos.Event) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, reading := range event.Readings {
+		deviceName := reading.DeviceName
+		if deviceName == "" {
+			deviceName = event.DeviceName
+		}
+
+		resources, found := c.devices[deviceName]
+		if !found {
+			resources = make(map[string]dtos.BaseReading)
+			c.devices[deviceName] = resources
+		}
+
+		if cached, found := resources[reading.ResourceName]; found && cached.Origin > reading.Origin {
+			continue
+		}
+
+		resources[reading.ResourceName] = reading
+		c.dirty = true
+	}
+}
+
+// Readings returns the cached readings of the device ordered by resource name, or false if none are cached
+func (c *LastValueCache) Readings(deviceName string) ([]dtos.BaseReading, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	resources, found := c.devices[deviceName]
+	if !found {
+		return nil, false
+	}
+
+	readings := make([]dtos.BaseReading, 0, len(resources))
+	for _, reading := range resources {
+		readings = append(readings, reading)
+	}
+
+	sort.Slice(readings, func(i, j int) bool {
+		return readings[i].ResourceName < readings[j].ResourceName
+	})
+
+	return readings, true
+}
+
+// Load replaces the cached readings with those saved to the persist file. A missing file isn't an error.
+func (c *LastValueCache) Load() error {
+	if c.persistFile == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(c.persistFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	devices := make(map[string]map[string]dtos.BaseReading)
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.devices = devices
+	c.dirty = false
+	return nil
+}
+
+// Save writes the cached readings to the persist file if they changed since last saved. The file is replaced
+// atomically so a failure while saving doesn't lose the previously saved readings.
+func (c *LastValueCache) Save() error {
+	if c.persistFile == "" {
+		return nil
+	}
+
+	c.lock.Lock()
+	if !c.dirty {
+		c.lock.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(c.devices)
+	c.dirty = false
+	c.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := c.write(data); err != nil {
+		// Make sure the next save is attempted
+		c.lock.Lock()
+		c.dirty = true
+		c.lock.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (c *LastValueCache) write(data []byte) error {
+	temp, err := ioutil.TempFile(filepath.Dir(c.persistFile), filepath.Base(c.persistFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	return os.Rename(temp.Name(), c.persistFile)
+}
+
+// StartPersisting saves the cache to the persist file at the interval, and a final time once the context is cancelled
+func (c *LastValueCache) StartPersisting(appWg *sync.WaitGroup, appCtx context.Context, interval time.Duration, lc logger.LoggingClient) {
+	if c.persistFile == "" {
+		return
+	}
+
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-appCtx.Done():
+				if err := c.Save(); err != nil {
+					lc.Errorf("Unable to save last value cache to '%s': %s", c.persistFile, err.Error())
+				}
+				lc.Info("Exiting last value cache persistence")
+				return
+
+			case <-ticker.C:
+				if err := c.Save(); err != nil {
+					lc.Errorf("Unable to save last value cache to '%s': %s", c.persistFile, err.Error())
+				}
+			}
+		}
+	}()
+}
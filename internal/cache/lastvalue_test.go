@@ -0,0 +1,115 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvent(t *testing.T, deviceName string, origin int64, temperature float64, humidity int32) dtos.Event {
+	event := dtos.NewEvent("thermostat", deviceName, "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, temperature))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeInt32, humidity))
+	for index := range event.Readings {
+		event.Readings[index].Origin = origin
+	}
+	return event
+}
+
+func TestLastValueCache_Update(t *testing.T) {
+	target := NewLastValueCache("")
+
+	_, found := target.Readings("thermostat-1")
+	assert.False(t, found)
+
+	target.Update(newEvent(t, "thermostat-1", 2, 20, 40))
+	target.Update(newEvent(t, "thermostat-2", 2, 30, 50))
+
+	// Readings older than those cached are ignored
+	target.Update(newEvent(t, "thermostat-1", 1, 10, 10))
+
+	partial := dtos.NewEvent("thermostat", "thermostat-1", "temperature")
+	require.NoError(t, partial.AddSimpleReading("temperature", common.ValueTypeFloat64, 21.0))
+	partial.Readings[0].Origin = 3
+	target.Update(partial)
+
+	readings, found := target.Readings("thermostat-1")
+	require.True(t, found)
+	require.Len(t, readings, 2)
+	assert.Equal(t, "humidity", readings[0].ResourceName)
+	assert.Equal(t, "40", readings[0].Value)
+	assert.Equal(t, "temperature", readings[1].ResourceName)
+	assert.Equal(t, "2.100000e+01", readings[1].Value)
+
+	readings, found = target.Readings("thermostat-2")
+	require.True(t, found)
+	assert.Equal(t, "50", readings[0].Value)
+}
+
+func TestLastValueCache_SaveAndLoad(t *testing.T) {
+	persistFile := filepath.Join(t.TempDir(), "lastvalues.json")
+
+	// A missing file isn't an error
+	target := NewLastValueCache(persistFile)
+	require.NoError(t, target.Load())
+
+	target.Update(newEvent(t, "thermostat-1", 1, 20, 40))
+	require.NoError(t, target.Save())
+
+	restored := NewLastValueCache(persistFile)
+	require.NoError(t, restored.Load())
+
+	readings, found := restored.Readings("thermostat-1")
+	require.True(t, found)
+	expected, _ := target.Readings("thermostat-1")
+	assert.Equal(t, expected, readings)
+
+	require.NoError(t, os.WriteFile(persistFile, []byte("not json"), 0600))
+	assert.Error(t, NewLastValueCache(persistFile).Load())
+}
+
+func TestLastValueCache_StartPersisting(t *testing.T) {
+	persistFile := filepath.Join(t.TempDir(), "lastvalues.json")
+	target := NewLastValueCache(persistFile)
+
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	target.StartPersisting(appWg, appCtx, time.Hour, logger.NewMockClient())
+
+	target.Update(newEvent(t, "thermostat-1", 1, 20, 40))
+	_, err := os.Stat(persistFile)
+	assert.True(t, os.IsNotExist(err), "cache should not be saved before the interval")
+
+	cancel()
+	appWg.Wait()
+
+	restored := NewLastValueCache(persistFile)
+	require.NoError(t, restored.Load())
+	_, found := restored.Readings("thermostat-1")
+	assert.True(t, found, "cache should be saved on shutdown")
+}
@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package capture
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxDataSize is the number of bytes of a payload or function output kept in a sample, the rest is truncated
+const MaxDataSize = 64 * 1024
+
+// Data is a captured payload or function output
+type Data struct {
+	// Type is the Go type of the data
+	Type string `json:"type"`
+	// Value is the data, or its JSON for types other than []byte and string. Data that isn't valid UTF-8 is base64 encoded.
+	Value string `json:"value,omitempty"`
+	// Base64 indicates the Value is base64 encoded
+	Base64 bool `json:"base64,omitempty"`
+	// Truncated indicates the Value only contains the first MaxDataSize bytes of the data
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Stage is the captured result of a function in the pipeline
+type Stage struct {
+	// Index is the position of the function in the pipeline
+	Index int `json:"index"`
+	// ContinuePipeline is the value returned by the function to continue, or stop, the pipeline
+	ContinuePipeline bool `json:"continuePipeline"`
+	// Output is the data returned by the function, if any and not an error
+	Output *Data `json:"output,omitempty"`
+	// Error is the error returned by the function
+	Error string `json:"error,omitempty"`
+}
+
+// Sample is a captured trigger payload and the outputs of the functions of the pipeline it was processed by
+type Sample struct {
+	CorrelationId string    `json:"correlationId"`
+	PipelineId    string    `json:"pipelineId"`
+	ReceivedTopic string    `json:"receivedTopic,omitempty"`
+	ContentType   string    `json:"contentType,omitempty"`
+	Captured      time.Time `json:"captured"`
+	Payload       Data      `json:"payload"`
+	Stages        []Stage   `json:"stages"`
+}
+
+// AddStage captures the result of the function at index in the pipeline
+func (sample *Sample) AddStage(index int, continuePipeline bool, result interface{}) {
+	sample.Stages = append(sample.Stages, NewStage(index, continuePipeline, result))
+}
+
+// NewStage captures the result of the function at index in the pipeline
+func NewStage(index int, continuePipeline bool, result interface{}) Stage {
+	stage := Stage{Index: index, ContinuePipeline: continuePipeline}
+
+	if err, ok := result.(error); ok {
+		stage.Error = err.Error()
+	} else if result != nil {
+		output := NewData(result)
+		stage.Output = &output
+	}
+
+	return stage
+}
+
+// NewData snapshots the data, as the functions later in the pipeline may modify it
+func NewData(data interface{}) Data {
+	captured := Data{Type: fmt.Sprintf("%T", data)}
+
+	var content []byte
+	switch value := data.(type) {
+	case []byte:
+		content = value
+	case string:
+		content = []byte(value)
+	default:
+		var err error
+		content, err = json.Marshal(value)
+		if err != nil {
+			content = []byte(fmt.Sprintf("%+v", value))
+		}
+	}
+
+	if len(content) > MaxDataSize {
+		content = content[:MaxDataSize]
+		captured.Truncated = true
+	}
+
+	if utf8.Valid(content) {
+		captured.Value = string(content)
+	} else {
+		captured.Value = base64.StdEncoding.EncodeToString(content)
+		captured.Base64 = true
+	}
+
+	return captured
+}
+
+// Bytes returns the data's content, decoding the Value when base64 encoded. Returns an error if the data was truncated.
+func (data Data) Bytes() ([]byte, error) {
+	if data.Truncated {
+		return nil, fmt.Errorf("data was truncated to %d bytes", MaxDataSize)
+	}
+
+	if data.Base64 {
+		return base64.StdEncoding.DecodeString(data.Value)
+	}
+
+	return []byte(data.Value), nil
+}
+
+// Buffer holds the most recent samples of the messages processed by the function pipelines, so issues in the field
+// can be diagnosed from the actual payloads and the output of each function without redeploying with debug code.
+type Buffer struct {
+	lock     sync.Mutex
+	received uint64
+	samples  []Sample
+}
+
+// NewBuffer creates, initializes and returns a new instance of Buffer
+func NewBuffer() *Buffer {
+	return &Buffer{}
+}
+
+// Start returns a new Sample for the received payload when it is the sampleRate'th message received since the
+// previous sample, otherwise nil. No samples are taken when sampleRate is less than 1.
+func (buffer *Buffer) Start(sampleRate int, pipelineId string, correlationId string, receivedTopic string,
+	contentType string, payload []byte) *Sample {
+	if sampleRate < 1 {
+		return nil
+	}
+
+	buffer.lock.Lock()
+	buffer.received++
+	selected := buffer.received%uint64(sampleRate) == 0
+	buffer.lock.Unlock()
+
+	if !selected {
+		return nil
+	}
+
+	return &Sample{
+		CorrelationId: correlationId,
+		PipelineId:    pipelineId,
+		ReceivedTopic: receivedTopic,
+		ContentType:   contentType,
+		Captured:      time.Now().UTC(),
+		Payload:       NewData(payload),
+	}
+}
+
+// Add adds the completed sample to the buffer, dropping the oldest samples so no more than maxSamples are kept
+func (buffer *Buffer) Add(sample *Sample, maxSamples int) {
+	if maxSamples < 1 {
+		maxSamples = 1
+	}
+
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	buffer.samples = append(buffer.samples, *sample)
+	if excess := len(buffer.samples) - maxSamples; excess > 0 {
+		buffer.samples = append([]Sample{}, buffer.samples[excess:]...)
+	}
+}
+
+// Samples returns the samples in the buffer, oldest first
+func (buffer *Buffer) Samples() []Sample {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	return append([]Sample{}, buffer.samples...)
+}
+
+// Clear removes all samples from the buffer
+func (buffer *Buffer) Clear() {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	buffer.samples = nil
+}
@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package capture
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer_Start(t *testing.T) {
+	buffer := NewBuffer()
+	assert.Nil(t, buffer.Start(0, "default", "123", "", "", []byte("{}")), "sampling should be disabled")
+
+	var selected []int
+	for index := 1; index <= 9; index++ {
+		if buffer.Start(3, "default", "123", "", "", []byte("{}")) != nil {
+			selected = append(selected, index)
+		}
+	}
+
+	assert.Equal(t, []int{3, 6, 9}, selected)
+}
+
+func TestBuffer_Add(t *testing.T) {
+	buffer := NewBuffer()
+
+	for _, id := range []string{"1", "2", "3"} {
+		buffer.Add(buffer.Start(1, "default", id, "edgex/events", "application/json", []byte(`{"id":"`+id+`"}`)), 2)
+	}
+
+	samples := buffer.Samples()
+	require.Len(t, samples, 2)
+	assert.Equal(t, "2", samples[0].CorrelationId, "oldest sample should be dropped")
+	assert.Equal(t, "3", samples[1].CorrelationId)
+	assert.Equal(t, `{"id":"3"}`, samples[1].Payload.Value)
+
+	buffer.Clear()
+	assert.Empty(t, buffer.Samples())
+}
+
+func TestSample_AddStage(t *testing.T) {
+	sample := &Sample{}
+	sample.AddStage(0, true, map[string]int{"value": 1})
+	sample.AddStage(1, true, []byte{0xFF, 0x00})
+	sample.AddStage(2, true, strings.Repeat("x", MaxDataSize+1))
+	sample.AddStage(3, false, errors.New("failed"))
+	sample.AddStage(4, false, nil)
+
+	require.Len(t, sample.Stages, 5)
+	assert.Equal(t, Data{Type: "map[string]int", Value: `{"value":1}`}, *sample.Stages[0].Output)
+	assert.Equal(t, Data{Type: "[]uint8", Value: "/wA=", Base64: true}, *sample.Stages[1].Output)
+	assert.True(t, sample.Stages[2].Output.Truncated)
+	assert.Len(t, sample.Stages[2].Output.Value, MaxDataSize)
+	assert.Equal(t, Stage{Index: 3, Error: "failed"}, sample.Stages[3])
+	assert.Equal(t, Stage{Index: 4}, sample.Stages[4])
+}
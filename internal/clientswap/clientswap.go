@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientswap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Swap contains the rebuilds of a client whose credentials were updated
+type Swap struct {
+	Client string `json:"client"`
+	// Swaps is the number of times the client was rebuilt with updated credentials
+	Swaps uint64 `json:"swaps"`
+	// Failures is the number of times the client couldn't be rebuilt, so kept using the previous credentials
+	Failures  uint64    `json:"failures"`
+	LastSwap  time.Time `json:"lastSwap,omitempty"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// Tracker counts the clients rebuilt on the fly when their credentials are updated in the secret store, so the swaps
+// can be reported without reading the logs.
+type Tracker struct {
+	lock    sync.Mutex
+	clients map[string]*Swap
+}
+
+// NewTracker creates, initializes and returns a new instance of Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		clients: make(map[string]*Swap),
+	}
+}
+
+// Swapped records the client was rebuilt with updated credentials
+func (tracker *Tracker) Swapped(client string) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	swap := tracker.swap(client)
+	swap.Swaps++
+	swap.LastSwap = time.Now()
+	swap.LastError = ""
+}
+
+// Failed records the client couldn't be rebuilt with updated credentials
+func (tracker *Tracker) Failed(client string, err error) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	swap := tracker.swap(client)
+	swap.Failures++
+	swap.LastError = err.Error()
+}
+
+// Swaps returns the swaps of each client, sorted by client
+func (tracker *Tracker) Swaps() []Swap {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	swaps := make([]Swap, 0, len(tracker.clients))
+	for _, swap := range tracker.clients {
+		swaps = append(swaps, *swap)
+	}
+
+	sort.Slice(swaps, func(i, j int) bool {
+		return swaps[i].Client < swaps[j].Client
+	})
+
+	return swaps
+}
+
+func (tracker *Tracker) swap(client string) *Swap {
+	swap, found := tracker.clients[client]
+	if !found {
+		swap = &Swap{Client: client}
+		tracker.clients[client] = swap
+	}
+
+	return swap
+}
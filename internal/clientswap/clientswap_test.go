@@ -0,0 +1,47 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientswap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	assert.Empty(t, tracker.Swaps())
+
+	tracker.Swapped("Database")
+	tracker.Failed("MQTT", errors.New("not authorized"))
+	tracker.Failed("Database", errors.New("invalid password"))
+	tracker.Swapped("Database")
+
+	swaps := tracker.Swaps()
+	require.Len(t, swaps, 2)
+
+	assert.Equal(t, "Database", swaps[0].Client)
+	assert.Equal(t, uint64(2), swaps[0].Swaps)
+	assert.Equal(t, uint64(1), swaps[0].Failures)
+	assert.False(t, swaps[0].LastSwap.IsZero())
+	assert.Empty(t, swaps[0].LastError, "error should be cleared by a successful swap")
+
+	assert.Equal(t, "MQTT", swaps[1].Client)
+	assert.Equal(t, uint64(0), swaps[1].Swaps)
+	assert.Equal(t, "not authorized", swaps[1].LastError)
+}
@@ -0,0 +1,409 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package commitlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+)
+
+const (
+	// FileName is the name of the commit log file in the configured directory
+	FileName = "commitlog.jsonl"
+	// DeadLetterFileName is the name of the file in the configured directory the entries that exceeded the maximum
+	// number of replays are moved to, one JSON entry per line
+	DeadLetterFileName = "commitlog.deadletter.jsonl"
+
+	// truncateSize is the size the log file must exceed before it is compacted to the pending entries
+	truncateSize = 1024 * 1024
+
+	opAppend   = "append"
+	opComplete = "complete"
+)
+
+// Entry is a message received by the trigger that has been appended to the commit log
+type Entry struct {
+	Id            uint64 `json:"id"`
+	CorrelationId string `json:"correlationId"`
+	ContentType   string `json:"contentType"`
+	ReceivedTopic string `json:"receivedTopic"`
+	Payload       []byte `json:"payload"`
+	// Received is the time, in nanoseconds since the epoch, the message was appended
+	Received int64 `json:"received"`
+	// Replays is the number of times the entry has been returned as pending when the log was opened
+	Replays int `json:"replays,omitempty"`
+}
+
+// Envelope returns the message envelope the entry was appended for
+func (entry Entry) Envelope() types.MessageEnvelope {
+	return types.MessageEnvelope{
+		CorrelationID: entry.CorrelationId,
+		ContentType:   entry.ContentType,
+		ReceivedTopic: entry.ReceivedTopic,
+		Payload:       entry.Payload,
+	}
+}
+
+// record is a line of the log file, either appending an entry or completing the entry with the id
+type record struct {
+	Op    string `json:"op"`
+	Entry *Entry `json:"entry,omitempty"`
+	Id    uint64 `json:"id,omitempty"`
+}
+
+// Log is a write-ahead log of the messages received, kept in a file of JSON records, one per line. A message is
+// appended, and synced to storage, before its pipelines execute and completed once they have, so the entries still
+// pending when the service restarts are the messages whose processing was interrupted.
+type Log struct {
+	lock    sync.Mutex
+	lc      logger.LoggingClient
+	path    string
+	file    *os.File
+	nextId  uint64
+	pending map[uint64]Entry
+	size    int64
+	// compactedSize is the size of the log file when it was last rewritten
+	compactedSize int64
+}
+
+// Open opens the commit log in the directory, creating it if needed, and returns the entries that were still pending,
+// in the order they were appended. The log file is rewritten with only the pending entries, counting the replay of
+// each. The entries already replayed maxReplays times are moved to the dead letter file instead of being returned,
+// so a message that keeps failing or crashing the service isn't replayed forever. 0 doesn't limit the replays.
+// A partially written last record, i.e. from a crash while appending, is ignored.
+func Open(directory string, maxReplays int, lc logger.LoggingClient) (*Log, []Entry, error) {
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, nil, fmt.Errorf("unable to create commit log directory '%s': %s", directory, err.Error())
+	}
+
+	path := filepath.Join(directory, FileName)
+	entries, err := readPending(path, lc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pending []Entry
+	var dead []Entry
+	for _, entry := range entries {
+		if maxReplays > 0 && entry.Replays >= maxReplays {
+			dead = append(dead, entry)
+			continue
+		}
+
+		entry.Replays++
+		pending = append(pending, entry)
+	}
+
+	if len(dead) > 0 {
+		deadLetterPath := filepath.Join(directory, DeadLetterFileName)
+		if err := writeDeadLetters(deadLetterPath, dead); err != nil {
+			return nil, nil, err
+		}
+		lc.Warnf("Moved %d commit log entries replayed %d times to '%s'", len(dead), maxReplays, deadLetterPath)
+	}
+
+	log := &Log{
+		lc:      lc,
+		path:    path,
+		nextId:  1,
+		pending: make(map[uint64]Entry),
+	}
+
+	if err := log.rewrite(pending); err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range pending {
+		log.pending[entry.Id] = entry
+		if entry.Id >= log.nextId {
+			log.nextId = entry.Id + 1
+		}
+	}
+
+	for _, entry := range dead {
+		if entry.Id >= log.nextId {
+			log.nextId = entry.Id + 1
+		}
+	}
+
+	return log, pending, nil
+}
+
+// writeDeadLetters appends the entries to the dead letter file and syncs it to storage
+func writeDeadLetters(path string, entries []Entry) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open commit log dead letter file '%s': %s", path, err.Error())
+	}
+
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err == nil {
+			_, err = file.Write(append(line, '\n'))
+		}
+		if err != nil {
+			_ = file.Close()
+			return fmt.Errorf("unable to write commit log dead letter file '%s': %s", path, err.Error())
+		}
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to sync commit log dead letter file '%s': %s", path, err.Error())
+	}
+
+	return file.Close()
+}
+
+// readPending reads the records of the log file, if it exists, and returns the entries that weren't completed
+func readPending(path string, lc logger.LoggingClient) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open commit log '%s': %s", path, err.Error())
+	}
+	defer file.Close()
+
+	var entries []Entry
+	completed := make(map[uint64]bool)
+
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				lc.Warnf("Ignoring partially written record at line %d of commit log '%s'", lineNumber, path)
+			}
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read commit log '%s': %s", path, err.Error())
+		}
+
+		var item record
+		if err := json.Unmarshal(line, &item); err != nil {
+			lc.Warnf("Ignoring invalid record at line %d of commit log '%s': %s", lineNumber, path, err.Error())
+			continue
+		}
+
+		switch {
+		case item.Op == opAppend && item.Entry != nil:
+			entries = append(entries, *item.Entry)
+		case item.Op == opComplete:
+			completed[item.Id] = true
+		default:
+			lc.Warnf("Ignoring unknown record at line %d of commit log '%s'", lineNumber, path)
+		}
+	}
+
+	var pending []Entry
+	for _, entry := range entries {
+		if !completed[entry.Id] {
+			pending = append(pending, entry)
+		}
+	}
+
+	return pending, nil
+}
+
+// rewrite replaces the log file with one containing only the entries, then opens it for appending in place of the
+// file previously open
+func (log *Log) rewrite(entries []Entry) error {
+	temporaryPath := log.path + ".tmp"
+	file, err := os.OpenFile(temporaryPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to create commit log '%s': %s", temporaryPath, err.Error())
+	}
+
+	writer := bufio.NewWriter(file)
+	for index := range entries {
+		line, err := encode(record{Op: opAppend, Entry: &entries[index]})
+		if err == nil {
+			_, err = writer.Write(line)
+		}
+		if err != nil {
+			_ = file.Close()
+			return fmt.Errorf("unable to write commit log '%s': %s", temporaryPath, err.Error())
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to write commit log '%s': %s", temporaryPath, err.Error())
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to sync commit log '%s': %s", temporaryPath, err.Error())
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("unable to close commit log '%s': %s", temporaryPath, err.Error())
+	}
+
+	if err := os.Rename(temporaryPath, log.path); err != nil {
+		return fmt.Errorf("unable to replace commit log '%s': %s", log.path, err.Error())
+	}
+
+	file, err = os.OpenFile(log.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open commit log '%s': %s", log.path, err.Error())
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("unable to open commit log '%s': %s", log.path, err.Error())
+	}
+
+	if log.file != nil {
+		_ = log.file.Close()
+	}
+
+	log.file = file
+	log.size = info.Size()
+	log.compactedSize = log.size
+	return nil
+}
+
+// Append appends the message to the log and syncs the log to storage before returning the id of its entry
+func (log *Log) Append(envelope types.MessageEnvelope) (uint64, error) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+
+	entry := Entry{
+		Id:            log.nextId,
+		CorrelationId: envelope.CorrelationID,
+		ContentType:   envelope.ContentType,
+		ReceivedTopic: envelope.ReceivedTopic,
+		Payload:       envelope.Payload,
+		Received:      time.Now().UnixNano(),
+	}
+
+	if err := log.write(record{Op: opAppend, Entry: &entry}); err != nil {
+		return 0, err
+	}
+
+	if err := log.file.Sync(); err != nil {
+		return 0, fmt.Errorf("unable to sync commit log '%s': %s", log.path, err.Error())
+	}
+
+	log.nextId++
+	log.pending[entry.Id] = entry
+	return entry.Id, nil
+}
+
+// Complete marks the entry as complete so it isn't returned as pending when the log is next opened. The record isn't
+// synced to storage, as losing it only results in the message being processed again. Once the log file has grown
+// large it is truncated when no entries are pending, otherwise rewritten with only the pending entries.
+func (log *Log) Complete(id uint64) error {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+
+	if _, found := log.pending[id]; !found {
+		return fmt.Errorf("commit log entry %d is not pending", id)
+	}
+
+	if err := log.write(record{Op: opComplete, Id: id}); err != nil {
+		return err
+	}
+
+	delete(log.pending, id)
+
+	if log.size <= truncateSize {
+		return nil
+	}
+
+	if len(log.pending) == 0 {
+		if err := log.file.Truncate(0); err != nil {
+			return fmt.Errorf("unable to truncate commit log '%s': %s", log.path, err.Error())
+		}
+		log.size = 0
+		log.compactedSize = 0
+		return nil
+	}
+
+	// Pending entries that are large themselves would otherwise be rewritten on every completion
+	if log.size <= 2*log.compactedSize {
+		return nil
+	}
+
+	return log.rewrite(log.pendingEntries())
+}
+
+// pendingEntries returns the pending entries in the order they were appended
+func (log *Log) pendingEntries() []Entry {
+	entries := make([]Entry, 0, len(log.pending))
+	for _, entry := range log.pending {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Id < entries[j].Id
+	})
+
+	return entries
+}
+
+// Pending returns the number of entries that haven't been completed
+func (log *Log) Pending() int {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	return len(log.pending)
+}
+
+// Close closes the log file
+func (log *Log) Close() error {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	return log.file.Close()
+}
+
+func (log *Log) write(item record) error {
+	line, err := encode(item)
+	if err != nil {
+		return fmt.Errorf("unable to encode commit log record: %s", err.Error())
+	}
+
+	written, err := log.file.Write(line)
+	log.size += int64(written)
+	if err != nil {
+		return fmt.Errorf("unable to write commit log '%s': %s", log.path, err.Error())
+	}
+
+	return nil
+}
+
+func encode(item record) ([]byte, error) {
+	line, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
@@ -0,0 +1,202 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package commitlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lc = logger.NewMockClient()
+
+func message(correlationId string) types.MessageEnvelope {
+	return types.MessageEnvelope{
+		CorrelationID: correlationId,
+		ContentType:   "application/json",
+		ReceivedTopic: "edgex/events/device/thermostat",
+		Payload:       []byte(`{"id":"` + correlationId + `"}`),
+	}
+}
+
+func TestLog_ReplaysPendingEntries(t *testing.T) {
+	directory := t.TempDir()
+
+	log, pending, err := Open(directory, 0, lc)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	first, err := log.Append(message("1"))
+	require.NoError(t, err)
+	second, err := log.Append(message("2"))
+	require.NoError(t, err)
+	_, err = log.Append(message("3"))
+	require.NoError(t, err)
+
+	require.NoError(t, log.Complete(second))
+	assert.Error(t, log.Complete(second), "entry should not be completed twice")
+	assert.Equal(t, 2, log.Pending())
+	require.NoError(t, log.Close())
+
+	log, pending, err = Open(directory, 0, lc)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, first, pending[0].Id)
+	assert.Equal(t, message("1"), pending[0].Envelope())
+	assert.Equal(t, message("3"), pending[1].Envelope())
+
+	// Ids continue after the pending entries so completing a new entry doesn't complete a pending one
+	next, err := log.Append(message("4"))
+	require.NoError(t, err)
+	assert.Greater(t, next, pending[1].Id)
+
+	require.NoError(t, log.Complete(pending[0].Id))
+	require.NoError(t, log.Complete(next))
+	require.NoError(t, log.Close())
+
+	log, pending, err = Open(directory, 0, lc)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, message("3"), pending[0].Envelope())
+	require.NoError(t, log.Close())
+}
+
+func TestLog_IgnoresPartialRecord(t *testing.T) {
+	directory := t.TempDir()
+
+	log, _, err := Open(directory, 0, lc)
+	require.NoError(t, err)
+	_, err = log.Append(message("1"))
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	// Simulate a crash while appending
+	file, err := os.OpenFile(filepath.Join(directory, FileName), os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"op":"append","entry":{"id":2,"corr`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	log, pending, err := Open(directory, 0, lc)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, message("1"), pending[0].Envelope())
+
+	_, err = log.Append(message("2"))
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	log, pending, err = Open(directory, 0, lc)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2, "entries appended after the partial record should be read")
+	require.NoError(t, log.Close())
+}
+
+func TestLog_TruncatesWhenNothingPending(t *testing.T) {
+	log, _, err := Open(t.TempDir(), 0, lc)
+	require.NoError(t, err)
+	defer log.Close()
+
+	large := message("1")
+	large.Payload = make([]byte, truncateSize)
+
+	id, err := log.Append(large)
+	require.NoError(t, err)
+
+	require.NoError(t, log.Complete(id))
+	assert.Zero(t, log.size)
+
+	info, err := os.Stat(log.path)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestLog_CompactsPendingEntries(t *testing.T) {
+	directory := t.TempDir()
+	log, _, err := Open(directory, 0, lc)
+	require.NoError(t, err)
+
+	large := message("1")
+	large.Payload = make([]byte, truncateSize)
+
+	id, err := log.Append(large)
+	require.NoError(t, err)
+	other, err := log.Append(message("2"))
+	require.NoError(t, err)
+
+	require.NoError(t, log.Complete(id))
+	assert.Less(t, log.size, int64(truncateSize), "log should be rewritten with only the pending entries")
+
+	info, err := os.Stat(log.path)
+	require.NoError(t, err)
+	assert.Equal(t, log.size, info.Size())
+
+	// Appending after compacting goes to the rewritten file
+	next, err := log.Append(message("3"))
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	log, pending, err := Open(directory, 0, lc)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, other, pending[0].Id)
+	assert.Equal(t, message("2"), pending[0].Envelope())
+	assert.Equal(t, next, pending[1].Id)
+	require.NoError(t, log.Close())
+}
+
+func TestLog_DeadLettersEntriesReplayedTooOften(t *testing.T) {
+	directory := t.TempDir()
+	log, _, err := Open(directory, 2, lc)
+	require.NoError(t, err)
+
+	_, err = log.Append(message("1"))
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	// The message is never completed, i.e. as it crashes the service every time it's processed
+	for replay := 1; replay <= 2; replay++ {
+		log, pending, err := Open(directory, 2, lc)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, replay, pending[0].Replays)
+		require.NoError(t, log.Close())
+	}
+
+	log, pending, err := Open(directory, 2, lc)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "entry replayed the maximum number of times should not be replayed again")
+
+	// Ids of the entries moved to the dead letter file aren't reused
+	next, err := log.Append(message("2"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), next)
+	require.NoError(t, log.Close())
+
+	data, err := ioutil.ReadFile(filepath.Join(directory, DeadLetterFileName))
+	require.NoError(t, err)
+	var dead Entry
+	require.NoError(t, json.Unmarshal(data, &dead))
+	assert.Equal(t, message("1"), dead.Envelope())
+}
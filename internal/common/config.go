@@ -17,11 +17,53 @@
 package common
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db"
 )
 
+const (
+	// AckPolicyReceipt acknowledges messages received by the trigger immediately
+	AckPolicyReceipt = "receipt"
+	// AckPolicyProcessed acknowledges messages received by the trigger once the matching pipelines complete successfully
+	AckPolicyProcessed = "processed"
+
+	// DefaultDrainTimeout is the time waited on shutdown for in-flight pipeline executions when DrainTimeout isn't set
+	DefaultDrainTimeout = 10 * time.Second
+
+	// DeduplicationKeyChecksum identifies duplicate messages by the checksum of their payloads
+	DeduplicationKeyChecksum = "checksum"
+	// DeduplicationKeyEventId identifies duplicate messages by the id of their Events
+	DeduplicationKeyEventId = "eventid"
+	// DefaultDeduplicationWindow is how long received messages are remembered when the Deduplication Window isn't set
+	DefaultDeduplicationWindow = 5 * time.Minute
+	// DefaultCommitLogDirectory is where the commit log file is kept when the CommitLog Directory isn't set
+	DefaultCommitLogDirectory = "./commitlog"
+	// DefaultCommitLogMaxReplays is how many times a pending commit log message is replayed when MaxReplays isn't set
+	DefaultCommitLogMaxReplays = 3
+	// DefaultSecretsDirectory is where the secret files are read from when the SecretsDirectory Path isn't set
+	DefaultSecretsDirectory = "/run/secrets"
+	// DefaultSecretsPollInterval is how often the secret files are checked for updates when PollInterval isn't set
+	DefaultSecretsPollInterval = 10 * time.Second
+	// DefaultPreviewSecretPath is the path of the preview endpoint's token secret when the Preview SecretPath isn't set
+	DefaultPreviewSecretPath = "preview"
+	// PreviewTokenKey is the key of the preview endpoint's token in its secret
+	PreviewTokenKey = "token"
+	// DefaultDebugSecretPath is the path of the debug endpoint's token secret when the Debug SecretPath isn't set
+	DefaultDebugSecretPath = "debug"
+	// DebugTokenKey is the key of the debug endpoint's token in its secret
+	DebugTokenKey = "token"
+	// DefaultMetricsRetention is how long the hourly rollups are kept when the MetricsHistory Retention isn't set
+	DefaultMetricsRetention = 7 * 24 * time.Hour
+	// DefaultOfflineThreshold is how long a device is silent before it is offline when the DeviceMonitor
+	// OfflineThreshold isn't set
+	DefaultOfflineThreshold = 5 * time.Minute
+)
+
 // WritableInfo is used to hold configuration information that is considered "live" or can be changed on the fly without a restart of the service.
 type WritableInfo struct {
 	// Set level of logging to report
@@ -32,6 +74,15 @@ type WritableInfo struct {
 	LogLevel        string
 	Pipeline        PipelineInfo
 	StoreAndForward StoreAndForwardInfo
+	// Rules is a collection of local rules evaluated by the EvaluateRules pipeline function.
+	// The map key is the unique name of the rule.
+	Rules map[string]RuleInfo
+	// Capture contains the configuration for capturing samples of the messages processed by the pipelines
+	Capture CaptureInfo
+	// Maintenance contains the configuration for holding back the exports while downstream systems are serviced
+	Maintenance MaintenanceInfo
+	// Lineage contains the configuration for identifying the service and pipeline that exported the data
+	Lineage         LineageInfo
 	InsecureSecrets bootstrapConfig.InsecureSecrets
 }
 
@@ -42,12 +93,36 @@ type ConfigurationStruct struct {
 	Writable WritableInfo
 	// Registry contains the configuration for connecting the Registry service
 	Registry bootstrapConfig.RegistryInfo
+	// Discovery contains the configuration for resolving the URLs of the EdgeX services in Clients
+	Discovery DiscoveryInfo
 	// Service contains the standard 'service' configuration for the Application service
 	Service bootstrapConfig.ServiceInfo
 	// HttpServer contains the configuration for the HTTP Server
 	HttpServer HttpConfig
 	// Trigger contains the configuration for the Function Pipeline Trigger
 	Trigger TriggerInfo
+	// LastValueCache contains the configuration for the cache of the latest readings received by the pipelines
+	LastValueCache LastValueCacheInfo
+	// Tenancy contains the configuration for serving several tenants from the one service
+	Tenancy TenancyInfo
+	// DeliveryReceipts contains the configuration for tracking the acknowledgement of the deliveries of exported data
+	DeliveryReceipts DeliveryReceiptsInfo
+	// ExportGuard contains the configuration for suppressing the export of data already exported
+	ExportGuard ExportGuardInfo
+	// HashChain contains the configuration for linking the Events exported into tamper-evident hash chains
+	HashChain HashChainInfo
+	// CostAccounting contains the configuration for tracking the resources consumed by each pipeline
+	CostAccounting CostAccountingInfo
+	// MetricsHistory contains the configuration for keeping hourly rollups of the pipeline executions
+	MetricsHistory MetricsHistoryInfo
+	// DeviceMonitor contains the configuration for detecting the devices that have gone silent
+	DeviceMonitor DeviceMonitorInfo
+	// DeviceGroups contains the configuration for the groups of devices found by their labels in Core Metadata
+	DeviceGroups DeviceGroupsInfo
+	// Preview contains the configuration for the endpoint previewing the result of a pipeline function on a payload
+	Preview PreviewInfo
+	// Debug contains the configuration for the WebSocket stepping a payload through a pipeline one function at a time
+	Debug DebugInfo
 	// ApplicationSettings contains the custom configuration for the Application service
 	ApplicationSettings map[string]string
 	// Clients contains the configuration for connecting to the dependent Edgex clients
@@ -56,17 +131,453 @@ type ConfigurationStruct struct {
 	Database db.DatabaseInfo
 	// SecretStore contains the configuration for connection to the Secret Store when in secure mode
 	SecretStore bootstrapConfig.SecretStoreInfo
+	// SecretsDirectory contains the configuration for reading the secrets from files, i.e. Docker or Kubernetes secrets
+	SecretsDirectory SecretsDirectoryInfo
+}
+
+// DiscoveryInfo contains the configuration for resolving the URLs of the EdgeX services the clients call, so the
+// service runs in Kubernetes without the Registry
+type DiscoveryInfo struct {
+	// Type is "static", using the host and port in Clients, "registry", looking up the services registered with the
+	// Registry and requiring the service be started with it, or "kubernetes", using the DNS names of the Kubernetes
+	// services with the ports in Clients. Defaults to "static".
+	Type string
+	// ServiceNamePrefix is prepended to the service keys to get the names of the Kubernetes services, i.e. "edgex-"
+	ServiceNamePrefix string
+	// Namespace is the namespace of the Kubernetes services. The pod's namespace is used when not set.
+	Namespace string
+	// ClusterDomain is the domain of the Kubernetes cluster, used with the Namespace. Defaults to "cluster.local".
+	ClusterDomain string
 }
 
 // TriggerInfo contains Metadata associated with each Trigger
 type TriggerInfo struct {
 	// Type of trigger to start pipeline
-	// enum: http, edgex-messagebus, or external-mqtt
+	// enum: http, edgex-messagebus, external-mqtt, nats, azure-eventhubs, replay or socket
 	Type string
 	// Used when Type=edgex-messagebus
 	EdgexMessageBus MessageBusConfig
 	// Used when Type=external-mqtt
 	ExternalMqtt ExternalMqttConfig
+	// Used when Type=nats
+	Nats NatsConfig
+	// Used when Type=azure-eventhubs
+	EventHubs EventHubsConfig
+	// Used when Type=replay
+	Replay ReplayConfig
+	// Used when Type=socket
+	Socket SocketConfig
+	// WorkerPool contains the configuration for concurrent pipeline execution of messages received by the trigger
+	WorkerPool WorkerPoolConfig
+	// AckPolicy is when received messages are acknowledged. Options are "receipt", acknowledged immediately when received,
+	// or "processed", acknowledged only once all matching pipelines complete successfully and redelivered otherwise.
+	// Used when Type=external-mqtt, nats with JetStream or azure-eventhubs. Defaults to "receipt" for external-mqtt
+	// and "processed" for the others. MQTT messages are acknowledged in the order received and, as MQTT can't
+	// request redelivery, messages that fail are acknowledged too; enable StoreAndForward to retry their exports.
+	AckPolicy string
+	// DrainTimeout is the maximum time to wait on shutdown for in-flight pipeline executions to complete before the
+	// clients are disconnected, i.e. 30s. Defaults to 10s, 0s doesn't wait. The trigger stops receiving messages
+	// before waiting, and executions still queued when it expires fail rather than run.
+	DrainTimeout string
+	// PipelineTimeout is the maximum time a pipeline execution may take for a message, i.e. 5s. Executions exceeding
+	// it are aborted before their next function and logged with the function still running. Cancellation is
+	// cooperative: the function running isn't interrupted, it must return once the context it is passed is done,
+	// i.e. by passing the context to its requests. Not set doesn't time out.
+	PipelineTimeout string
+	// Watchdog contains the configuration for detecting pipeline executions that have stalled
+	Watchdog WatchdogInfo
+	// Deduplication contains the configuration for suppressing messages redelivered to the trigger
+	Deduplication DeduplicationInfo
+	// CommitLog contains the configuration for the local write-ahead log of the messages received by the trigger
+	CommitLog CommitLogInfo
+	// Loopback contains the configuration for feeding the output of a pipeline as the input of other pipelines in the
+	// same service, alongside the messages received by the trigger
+	Loopback LoopbackConfig
+}
+
+// LoopbackConfig contains the configuration for the Loopback Trigger, which executes the pipelines whose topics match
+// the topic the LoopbackSend export function sends data to, in the same process without a round-trip through a broker
+type LoopbackConfig struct {
+	// Enabled indicates whether the data sent by LoopbackSend is fed back to the pipelines
+	Enabled bool
+	// QueueSize is the number of messages sent back that can be pending before LoopbackSend fails. Defaults to 100.
+	QueueSize int
+	// MaxHops is the maximum number of times data can be sent back through the pipelines, so pipelines sending to
+	// their own topics don't loop forever. Defaults to 8.
+	MaxHops int
+}
+
+// CommitLogInfo contains the configuration for the local write-ahead log of the messages received by the message bus,
+// external MQTT, NATS, Event Hubs and custom triggers. Each message is appended to the log before its pipelines
+// execute and marked complete once they have, so the messages whose processing was interrupted, i.e. by a crash or
+// power loss, are processed again when the service restarts.
+type CommitLogInfo struct {
+	// Enabled indicates whether the messages received are appended to the commit log
+	Enabled bool
+	// Directory is where the commit log file is kept, which must be on persistent storage. Defaults to ./commitlog.
+	Directory string
+	// ReplayFailed indicates whether the messages whose pipelines failed are also processed again on restart,
+	// otherwise only the messages whose pipelines didn't complete are.
+	ReplayFailed bool
+	// MaxReplays is the maximum number of times a message is processed again on restart, after which it is moved to
+	// the commitlog.deadletter.jsonl file in the Directory. Defaults to 3.
+	MaxReplays int
+}
+
+// DeduplicationInfo contains the configuration for suppressing the messages redelivered to the message bus, external
+// MQTT, NATS, Event Hubs and custom triggers, i.e. QoS 1 re-sends, so their pipelines aren't executed and the data
+// isn't exported twice. A message is remembered from when it is received, and for the Window once its pipelines
+// complete successfully. A message whose pipelines failed is forgotten so its redelivery is processed.
+type DeduplicationInfo struct {
+	// Enabled indicates whether duplicate messages are suppressed
+	Enabled bool
+	// Window is how long a processed message is remembered, i.e. 10m. Defaults to 5m.
+	Window string
+	// Key is what identifies duplicate messages. Options are "checksum" (default), the checksum of the payload, or
+	// "eventid", the id of the Event, falling back to the checksum for payloads without an Event id.
+	Key string
+}
+
+// WindowDuration returns the Window as a duration, or DefaultDeduplicationWindow when not set
+func (d DeduplicationInfo) WindowDuration() (time.Duration, error) {
+	if strings.TrimSpace(d.Window) == "" {
+		return DefaultDeduplicationWindow, nil
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(d.Window))
+	if err != nil || window <= 0 {
+		return DefaultDeduplicationWindow, fmt.Errorf("invalid Trigger Deduplication Window '%s', must be a duration greater than 0", d.Window)
+	}
+
+	return window, nil
+}
+
+// WatchdogInfo contains the configuration for detecting pipeline executions that run longer than expected, i.e. hung
+// on a destination that never responds. The stack of each stalled execution is logged and the number of stalled
+// executions is served by the /api/v2/watchdog endpoint.
+type WatchdogInfo struct {
+	// Enabled indicates whether pipeline executions are watched
+	Enabled bool
+	// ExecutionTimeout is how long a pipeline execution runs before it is considered stalled, i.e. 30s. Defaults to 1m.
+	ExecutionTimeout string
+	// RestartTrigger indicates whether the trigger is stopped and initialized again when executions stall, i.e. to
+	// reconnect to the message bus. The trigger is restarted at most once per ExecutionTimeout.
+	RestartTrigger bool
+}
+
+// AckAfterProcessing returns whether the trigger acknowledges messages only once processed, using the trigger's
+// default policy when AckPolicy isn't set
+func (t TriggerInfo) AckAfterProcessing(defaultPolicy string) (bool, error) {
+	policy := strings.ToLower(strings.TrimSpace(t.AckPolicy))
+	if policy == "" {
+		policy = defaultPolicy
+	}
+
+	switch policy {
+	case AckPolicyReceipt:
+		return false, nil
+	case AckPolicyProcessed:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid Trigger AckPolicy '%s', must be '%s' or '%s'", t.AckPolicy, AckPolicyReceipt, AckPolicyProcessed)
+	}
+}
+
+// DrainTimeoutDuration returns the parsed DrainTimeout, or the default when DrainTimeout isn't set
+func (t TriggerInfo) DrainTimeoutDuration() (time.Duration, error) {
+	if strings.TrimSpace(t.DrainTimeout) == "" {
+		return DefaultDrainTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(strings.TrimSpace(t.DrainTimeout))
+	if err != nil {
+		return DefaultDrainTimeout, fmt.Errorf("invalid Trigger DrainTimeout '%s': %s", t.DrainTimeout, err.Error())
+	}
+
+	if timeout < 0 {
+		return DefaultDrainTimeout, fmt.Errorf("invalid Trigger DrainTimeout '%s', must not be negative", t.DrainTimeout)
+	}
+
+	return timeout, nil
+}
+
+// PipelineTimeoutDuration returns the parsed PipelineTimeout, or 0 when PipelineTimeout isn't set
+func (t TriggerInfo) PipelineTimeoutDuration() (time.Duration, error) {
+	if strings.TrimSpace(t.PipelineTimeout) == "" {
+		return 0, nil
+	}
+
+	timeout, err := time.ParseDuration(strings.TrimSpace(t.PipelineTimeout))
+	if err != nil {
+		return 0, fmt.Errorf("invalid Trigger PipelineTimeout '%s': %s", t.PipelineTimeout, err.Error())
+	}
+
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid Trigger PipelineTimeout '%s', must not be negative", t.PipelineTimeout)
+	}
+
+	return timeout, nil
+}
+
+// WorkerPoolConfig contains the configuration for the pool of workers executing the function pipelines
+// for messages received by the message bus, external MQTT and custom triggers
+type WorkerPoolConfig struct {
+	// Workers is the number of pipeline executions run concurrently.
+	// 0 (default) runs each pipeline execution in its own go routine without limit.
+	Workers int
+	// QueueSize is the number of pipeline executions buffered (per worker when ordered) before the trigger is blocked
+	QueueSize int
+	// OrderByDeviceName indicates messages for the same device are executed by the same worker in the order received
+	OrderByDeviceName bool
+	// PriorityLanes contains the lanes, keyed by name, the messages are classified into. Each lane has its own workers
+	// so its messages, i.e. alarms, are executed ahead of the bulk telemetry queued for the other workers.
+	// Messages that don't match any lane are executed by the Workers above.
+	PriorityLanes map[string]PriorityLaneInfo
+}
+
+// PriorityLaneInfo contains the configuration of a priority lane. A message matches the lane when its Event is from one
+// of the DeviceNames, has a reading for one of the ResourceNames or the Expression results in true.
+type PriorityLaneInfo struct {
+	// Priority orders the lanes a message matches, the lane with the highest Priority is used
+	Priority int
+	// Workers is the number of the lane's messages executed concurrently, must be greater than 0
+	Workers int
+	// QueueSize is the number of the lane's messages buffered (per worker when ordered) before the trigger is blocked
+	QueueSize int
+	// DeviceNames are the names of the devices whose Events match the lane
+	DeviceNames []string
+	// ResourceNames are the names of the resources whose readings match the lane
+	ResourceNames []string
+	// Expression is a JSONLogic expression evaluated against the message's payload, i.e. the AddEventRequest
+	Expression string
+}
+
+// CaptureInfo contains the configuration for capturing a sample of the trigger payloads and the output of each pipeline
+// function, which are served by the /api/v2/capture endpoint for diagnosing issues in the field
+type CaptureInfo struct {
+	// Enabled indicates whether samples are captured
+	Enabled bool
+	// SampleRate is N when one in N of the messages received is captured, i.e. 1 captures every message
+	SampleRate int
+	// MaxSamples is the number of the most recent samples kept
+	MaxSamples int
+}
+
+// MaintenanceInfo contains the configuration for the maintenance mode, during which the exports store their data for
+// Store and Forward to retry once the maintenance mode is disabled, so downstream systems can be serviced without
+// losing data or restarting the service. StoreAndForward must be enabled for the data to be stored.
+type MaintenanceInfo struct {
+	// Enabled indicates whether the service is in maintenance mode
+	Enabled bool
+	// PauseTrigger indicates whether the trigger is stopped while in maintenance mode, so no messages are received.
+	// Otherwise the messages are still processed with the data they export stored.
+	PauseTrigger bool
+}
+
+// LineageInfo contains the configuration for identifying the service and pipeline that exported the data, so the
+// systems ingesting the data can route or debug it based on the edge pipeline that produced it
+type LineageInfo struct {
+	// Enabled indicates whether the service key, pipeline id and version and correlation id are sent as headers with
+	// the data exported via HTTP and set as context values usable as placeholders, i.e. in MQTT export topics
+	Enabled bool
+}
+
+// LastValueCacheInfo contains the configuration for the cache of the latest reading of each device resource as passed
+// on by the function pipelines, which is served by the /api/v2/cache/{device} endpoint
+type LastValueCacheInfo struct {
+	// Enabled indicates whether the latest readings are cached
+	Enabled bool
+	// PersistFile is the file the cache is saved to, and loaded from on startup. The cache is kept in memory only when empty.
+	PersistFile string
+	// PersistInterval is how often the cache is saved to the PersistFile, i.e. 30s. The cache is also saved on shutdown.
+	PersistInterval string
+}
+
+// DeliveryReceiptsInfo contains the configuration for tracking the deliveries of exported data. Each payload exported
+// is recorded with an id until the destination acknowledges it, and the counts of each destination's deliveries and
+// the overdue deliveries are served by the /api/v2/receipts endpoint for data completeness audits.
+type DeliveryReceiptsInfo struct {
+	// Enabled indicates whether deliveries are tracked
+	Enabled bool
+	// AckTimeout is how long after being exported an unacknowledged delivery is overdue, i.e. 30s. Defaults to 1m.
+	AckTimeout string
+	// MaxPending is the maximum number of unacknowledged deliveries tracked, beyond which the oldest are no longer
+	// tracked and are counted as evicted. Defaults to 10000.
+	MaxPending int
+}
+
+// ExportGuardInfo contains the configuration for guarding exports against duplicates. The checksums of the data exported
+// are saved in the store database, configured by the Database section, so data redelivered by the trigger or retried
+// within the window isn't exported again to destinations that can't deduplicate themselves.
+type ExportGuardInfo struct {
+	// Enabled indicates whether the checksums of the data exported are saved
+	Enabled bool
+	// Window is how long the checksum of data exported is saved, i.e. 24h. Defaults to 1h.
+	Window string
+}
+
+// HashChainInfo contains the configuration for linking the Events exported from each device into a hash chain by the
+// ChainEvents function. The head of each device's chain is saved in the store database, configured by the Database
+// section, so the chain continues across restarts.
+type HashChainInfo struct {
+	// Enabled indicates whether the heads of the hash chains are saved
+	Enabled bool
+}
+
+// CostAccountingInfo contains the configuration for tracking the CPU time used and the bytes produced by each pipeline
+// function, totalled per pipeline and served by the /api/v2/stats endpoint, so the pipelines consuming the most of a
+// gateway running many of them can be found.
+type CostAccountingInfo struct {
+	// Enabled indicates whether the costs of the pipeline executions are tracked
+	Enabled bool
+	// ExecutionBudget is the CPU time a single pipeline execution is expected to use, i.e. 50ms. Executions that use
+	// more are logged and counted as over budget. Empty doesn't limit the executions.
+	ExecutionBudget string
+}
+
+// MetricsHistoryInfo contains the configuration for keeping hourly rollups of the executions of each pipeline, served
+// by the /api/v2/history endpoint, so sites without monitoring infrastructure can review the throughput and export
+// success rates of the last week.
+type MetricsHistoryInfo struct {
+	// Enabled indicates whether the hourly rollups are kept
+	Enabled bool
+	// Retention is how long the rollups are kept, at least 1h. Defaults to a week.
+	Retention string
+	// File is the file the rollups are saved to, so they are kept across restarts. Empty keeps them in memory only.
+	File string
+}
+
+// DeviceMonitorInfo contains the configuration for tracking when each device was last seen in the Events processed
+// by the pipelines. A device silent for longer than the OfflineThreshold is offline, and online again once an Event
+// from it is processed. Each change of state is published to the message bus and/or sent as a notification, and the
+// state of the devices is served by the /api/v2/devices endpoint.
+type DeviceMonitorInfo struct {
+	// Enabled indicates whether the devices are tracked
+	Enabled bool
+	// OfflineThreshold is how long a device is silent before it is offline, i.e. 10m. Defaults to 5m.
+	OfflineThreshold string
+	// Devices are the names of the devices expected to send Events, which are tracked from the time the service
+	// starts so they go offline even if no Event from them is processed.
+	Devices []string
+	// PublishTopic is the message bus topic the changes of state are published to. Empty doesn't publish them.
+	// The topic may contain the {devicename} and {state} placeholders. Not supported by the HTTP and MQTT triggers.
+	PublishTopic string
+	// NotificationCategory is the category of the notifications sent to Support Notifications for the changes of
+	// state. Empty doesn't send notifications. Support Notifications must be configured in the Clients section.
+	NotificationCategory string
+}
+
+// DeviceGroupsInfo contains the configuration for the groups of devices whose members are the devices in Core Metadata
+// with the group's labels. The members are retrieved again every RefreshInterval, so a device is added to a group by
+// labelling it rather than by editing the device names of the filter functions. Core Metadata must be configured in
+// the Clients section.
+type DeviceGroupsInfo struct {
+	// Enabled indicates whether the members of the device groups are retrieved
+	Enabled bool
+	// RefreshInterval is how often the members are retrieved from Core Metadata, i.e. 30s. Defaults to 1m.
+	RefreshInterval string
+	// Groups is the configuration of each group. The map key is the unique name of the group.
+	Groups map[string]DeviceGroupInfo
+}
+
+// DeviceGroupInfo contains the configuration of a group of devices
+type DeviceGroupInfo struct {
+	// Labels is a comma separated list of labels. The devices with all the labels are the members of the group.
+	Labels string
+	// SubscribeTopic, when set, is the topic subscribed to for each member when the edgex-messagebus trigger is used,
+	// with "{device}" replaced by the device's name, i.e. "edgex/events/device/+/{device}/#". The topics of devices
+	// removed from the group remain subscribed until the service restarts.
+	SubscribeTopic string
+}
+
+// PreviewInfo contains the configuration for the /api/v2/preview endpoint, which executes a built-in pipeline function,
+// created with the parameters in the request, with the payload in the request and responds with the function's
+// result, so the settings of a function can be tried against sample data before being added to the pipeline
+// configuration. Functions that send data out of the service can't be previewed.
+type PreviewInfo struct {
+	// Enabled indicates whether the endpoint is served
+	Enabled bool
+	// SecretPath is the path of the secret in the Secret Store, or InsecureSecrets, whose "token" value must be sent
+	// as the request's bearer token. Defaults to "preview". Requests are rejected until the secret is stored.
+	SecretPath string
+}
+
+// SecretsDirectory contains the configuration for reading the secrets from files rather than, or side by side with,
+// the Secret Store or InsecureSecrets. Each secret path is a subdirectory of the Path whose files are the secret's
+// keys, so the Docker or Kubernetes secrets mounted in the container are available to the pipeline functions'
+// GetSecrets. The paths not found in the directory are read from the other registered SecretProviders, or else the
+// Secret Store or InsecureSecrets.
+type SecretsDirectoryInfo struct {
+	// Enabled indicates whether the secrets are read from the directory
+	Enabled bool
+	// Path is the directory the secrets are read from. Defaults to "/run/secrets".
+	Path string
+	// PollInterval is how often the files are checked for updates, i.e. 30s, the connections using the secrets being
+	// redone when they are. Defaults to 10s.
+	PollInterval string
+}
+
+// PollIntervalDuration returns the parsed PollInterval, or the default when PollInterval isn't set
+func (s SecretsDirectoryInfo) PollIntervalDuration() (time.Duration, error) {
+	if strings.TrimSpace(s.PollInterval) == "" {
+		return DefaultSecretsPollInterval, nil
+	}
+
+	interval, err := time.ParseDuration(strings.TrimSpace(s.PollInterval))
+	if err != nil {
+		return DefaultSecretsPollInterval, fmt.Errorf("invalid SecretsDirectory PollInterval '%s': %s", s.PollInterval, err.Error())
+	}
+
+	if interval <= 0 {
+		return DefaultSecretsPollInterval, fmt.Errorf("invalid SecretsDirectory PollInterval '%s', must be positive", s.PollInterval)
+	}
+
+	return interval, nil
+}
+
+// DebugInfo contains the configuration for the /api/v2/debug WebSocket, over which a client steps a payload, or the
+// payload of a captured sample, through a pipeline one function at a time and inspects the data passed between the
+// functions. The SDK's export functions log the data rather than send it, as for the Preview.
+type DebugInfo struct {
+	// Enabled indicates whether the WebSocket is served
+	Enabled bool
+	// SecretPath is the path of the secret in the Secret Store, or InsecureSecrets, whose "token" value must be sent
+	// as the bearer token of the WebSocket's handshake. Defaults to "debug". Connections are rejected until the
+	// secret is stored.
+	SecretPath string
+}
+
+// TenancyInfo contains the configuration for serving several tenants from the one service. The tenant id of each
+// message selects the tenant's destination settings and credentials for the pipeline functions, and is added to the
+// pipeline functions' log messages and the per tenant statistics served by the /api/v2/tenants endpoint.
+type TenancyInfo struct {
+	// Enabled indicates whether messages are routed by tenant
+	Enabled bool
+	// Source is where the tenant id of a message is found. Options are "topic", "header" (HTTP trigger only) or "label"
+	Source string
+	// TopicLevel is the 0 based index of the level of the received topic that is the tenant id when Source is "topic",
+	// i.e. 1 for the topic customers/<tenant>/events
+	TopicLevel int
+	// Header is the HTTP header containing the tenant id when Source is "header". Defaults to "X-Tenant-ID".
+	Header string
+	// LabelPrefix is the prefix of the label of the Event's device containing the tenant id when Source is "label".
+	// Defaults to "tenant:", so the device label "tenant:acme" is for the tenant "acme".
+	LabelPrefix string
+	// DefaultTenant is the tenant of messages without a tenant id. Messages without a tenant id are rejected when empty.
+	DefaultTenant string
+	// Tenants is the collection of tenants served, messages for any other tenant are rejected.
+	// The map key is the tenant id.
+	Tenants map[string]TenantInfo
+}
+
+// TenantInfo defines the destination settings and credentials of a tenant
+type TenantInfo struct {
+	// Settings are added to the context of the tenant's pipeline executions, so the tenant's endpoints are used by the
+	// pipeline functions via placeholders, i.e. {exporturl} for the ExportUrl setting
+	Settings map[string]string
+	// SecretPath, when set, is prefixed to the secret store paths of the secrets read by the pipeline functions for
+	// the tenant, so each tenant's credentials are kept separate
+	SecretPath string
 }
 
 // HttpConfig contains the addition configuration for HTTP Server
@@ -116,7 +627,9 @@ type PublishHostInfo struct {
 	Port int
 	// Protocol indicates the protocol to use when accessing the message queue.
 	Protocol string
-	// PublishTopic is the topic in which to publish pipeline output (if any)
+	// PublishTopic is the topic in which to publish pipeline output (if any). It may be a template, i.e.
+	// "edgex/events/{profile}/{device}/{source}", resolved from the Event's names and the context's values and tags
+	// for each message.
 	PublishTopic string
 }
 
@@ -126,7 +639,7 @@ type ExternalMqttConfig struct {
 	Url string
 	// SubscribeTopics is a comma separated list of topics in which to subscribe
 	SubscribeTopics string
-	// PublishTopic is the topic to publish pipeline output (if any)
+	// PublishTopic is the topic to publish pipeline output (if any), which may be a template as for the MessageBus
 	PublishTopic string
 	// ClientId to connect to the broker with.
 	ClientId string
@@ -149,6 +662,117 @@ type ExternalMqttConfig struct {
 	AuthMode string
 }
 
+// NatsConfig contains the NATS server configuration for the NATS Trigger
+type NatsConfig struct {
+	// Url contains the fully qualified URL to connect to the NATS server, i.e. nats://localhost:4222
+	Url string
+	// SubscribeTopics is a comma separated list of subjects in which to subscribe. NATS wildcards ('*' and '>') are supported.
+	// The '.' separators in received subjects are converted to '/' for matching against the per topic pipeline topics.
+	SubscribeTopics string
+	// PublishTopic is the subject to publish pipeline output (if any)
+	PublishTopic string
+	// ClientId is the name the connection is identified by on the NATS server
+	ClientId string
+	// QueueGroup is the optional queue group to subscribe with so messages are load balanced across service instances
+	QueueGroup string
+	// ConnectTimeout is a time duration indicating how long to wait timing out on the server connection
+	ConnectTimeout string
+	// SkipCertVerify indicates if the certificate verification should be skipped
+	SkipCertVerify bool
+	// SecretPath is the name of the path in secret provider to retrieve your secrets
+	SecretPath string
+	// AuthMode indicates what to use when connecting to the server. Options are "none", "cacert" , "usernamepassword", "clientcert".
+	// If a CA Cert exists in the SecretPath then it will be used for all modes except "none".
+	AuthMode string
+	// JetStream indicates if JetStream is used for subscribing and publishing rather than core NATS.
+	// By default JetStream messages are acknowledged once all matching pipelines complete without error, giving at-least-once
+	// processing. See the Trigger AckPolicy.
+	JetStream bool
+	// Durable is the name of the JetStream durable consumers so processing resumes where it left off after a restart.
+	// Each subject has its own consumer, named the Durable followed by the subject, i.e. "app-service-edgex_events__"
+	// for "edgex.events.>".
+	Durable string
+	// DeliverPolicy is where a new JetStream consumer starts. Options are "all" (default), "new" or "last"
+	DeliverPolicy string
+	// MaxDeliver is the maximum number of times a JetStream message is redelivered when not acknowledged. 0 is unlimited.
+	MaxDeliver int
+}
+
+// EventHubsConfig contains the Azure Event Hubs configuration for the Azure Event Hubs Trigger
+type EventHubsConfig struct {
+	// SecretPath is the name of the path in secret provider containing the 'connectionstring' secret for the Event Hub.
+	// The connection string must include the EntityPath, i.e. Endpoint=sb://...;SharedAccessKeyName=...;SharedAccessKey=...;EntityPath=<hub>
+	SecretPath string
+	// ConsumerGroup is the consumer group to receive with. Defaults to "$Default".
+	ConsumerGroup string
+	// Partitions is an optional comma separated list of partition IDs to receive from. Defaults to all partitions of the Event Hub.
+	Partitions string
+	// StartPosition is where to start receiving when no checkpoint exists for a partition. Options are "earliest" (default) or "latest".
+	StartPosition string
+	// PrefetchCount is the number of events the receiver requests ahead of processing. 0 uses the Event Hubs client default.
+	PrefetchCount uint32
+	// CheckpointStore is where the offset of the last processed event of each partition is saved so receiving resumes
+	// where it left off after a restart. Options are "file" (default) or "memory".
+	CheckpointStore string
+	// CheckpointDirectory is the directory the checkpoints are saved in when CheckpointStore is "file"
+	CheckpointDirectory string
+}
+
+// ReplayConfig contains the query for the historical Events the Replay Trigger retrieves from Core Data
+// and replays through the function pipeline(s)
+type ReplayConfig struct {
+	// DeviceName limits the Events replayed to those from the device
+	DeviceName string
+	// ResourceNames is an optional comma separated list of the resource (value descriptor) names of the readings to replay.
+	// Other readings are removed from the Events and Events without any of the readings are skipped.
+	ResourceNames string
+	// Start is the optional RFC3339 time of the oldest Event to replay, i.e. 2021-06-01T00:00:00Z
+	Start string
+	// End is the optional RFC3339 time of the newest Event to replay. Defaults to when the replay starts.
+	End string
+	// Rate is the number of Events per second replayed. 0 replays the Events as fast as the pipelines process them.
+	Rate float64
+	// PageSize is the number of Events retrieved from Core Data per request. Defaults to 100.
+	PageSize int
+	// MaxEvents is the maximum number of Events replayed, 0 is unlimited
+	MaxEvents int
+	// BaseTopic is the topic the profile, device and source names are appended to for the received topic of each Event,
+	// which is used for matching against the per topic pipeline topics. Defaults to "edgex/events".
+	BaseTopic string
+}
+
+// SocketConfig contains the configuration for the Socket Trigger, which listens for the raw telemetry pushed over UDP
+// or TCP by equipment that can't publish to a message bus
+type SocketConfig struct {
+	// Protocol is the transport listened on. Options are "tcp" (default) or "udp".
+	Protocol string
+	// Address is the host and port listened on, i.e. ":5000"
+	Address string
+	// Framing is how the messages are delimited in a TCP stream. Options are "newline" (default), each message ends
+	// with '\n', or "length", each message is preceded by its length as a big-endian unsigned integer of
+	// LengthFieldSize bytes. Each UDP datagram is one message.
+	Framing string
+	// LengthFieldSize is the number of bytes, 1, 2 or 4, of the length preceding each message when Framing is "length".
+	// Defaults to 4.
+	LengthFieldSize int
+	// MaxMessageSize is the maximum size in bytes of a message. Larger UDP datagrams are dropped and TCP connections
+	// sending larger messages are closed. Defaults to 65507.
+	MaxMessageSize int
+	// ContentType is the content type of the messages received, i.e. "text/plain". Detected from each message when
+	// not set. Use UseTargetTypeOfByteArray for payloads that aren't Events.
+	ContentType string
+	// BaseTopic is the topic the sender's IP address is appended to for the received topic of each message, which is
+	// used for matching against the per topic pipeline topics. Defaults to "socket".
+	BaseTopic string
+	// IdleTimeout is how long a TCP connection is kept open without receiving a message, i.e. 5m. Not set never times out.
+	IdleTimeout string
+	// MaxConnections is the maximum number of TCP connections accepted at once, 0 is unlimited
+	MaxConnections int
+	// SendResponse indicates whether the response data of the pipelines is sent back to the sender, framed the same
+	// as the messages received
+	SendResponse bool
+}
+
 // PipelineInfo defines the top level data for configurable pipelines
 type PipelineInfo struct {
 	// ExecutionOrder is a list of functions, in execution order, for the default configurable pipeline
@@ -170,6 +794,9 @@ type TopicPipeline struct {
 	Topics string
 	// ExecutionOrder is a list of functions, in execution order, for the pipeline instance
 	ExecutionOrder string
+	// ShadowMode, when set to "log" or "send", makes the pipeline a shadow pipeline whose exports are logged or sent
+	// to the test endpoints it is configured with. Empty for a production pipeline.
+	ShadowMode string
 }
 
 // PipelineFunction is a collection of built-in pipeline functions configurations.
@@ -177,12 +804,62 @@ type TopicPipeline struct {
 type PipelineFunction struct {
 	// Parameters is the collection of configurable parameters specific to the built-in configurable function specified by the map key.
 	Parameters map[string]string
+	// ErrorPolicy is the optional policy applied when the function fails
+	ErrorPolicy ErrorPolicyInfo
+}
+
+// ErrorPolicyInfo defines how a pipeline handles its configurable function failing
+type ErrorPolicyInfo struct {
+	// Action is "abort", "skip", "retry" or "deadletter". No policy is applied when empty.
+	Action string
+	// Retries is the number of times the function is executed again before the Action is taken
+	Retries int
+	// RetryInterval is the time waited before each retry, i.e. "500ms"
+	RetryInterval string
+	// DeadLetterFunction is the name of the function in the Pipeline.Functions section the failed function's input
+	// is diverted to, i.e. an export to a dead letter topic or endpoint. Required for the "deadletter" Action.
+	DeadLetterFunction string
+}
+
+// RuleInfo defines a local rule as a condition and the actions to run when the condition is met
+type RuleInfo struct {
+	// Condition is the JSONLogic expression evaluated against the data in the pipeline
+	Condition string
+	// Actions is the collection of actions run, in name order, when the condition is met.
+	// The map key is the unique name of the action within the rule.
+	Actions map[string]RuleActionInfo
+}
+
+// RuleActionInfo defines an action run when a rule's condition is met
+type RuleActionInfo struct {
+	// Type of the action. Options are "command", "notification" or "export"
+	Type string
+	// Parameters is the collection of parameters specific to the action Type
+	Parameters map[string]string
 }
 
 type StoreAndForwardInfo struct {
 	Enabled       bool
 	RetryInterval string
 	MaxRetryCount int
+	// OfflineMode contains the configuration for routing all exports through the store while the network is down
+	OfflineMode OfflineModeInfo
+}
+
+// OfflineModeInfo contains the configuration for the offline-first export mode. When enabled, connectivity is
+// periodically probed and while the probe fails all exports are stored for later retry rather than attempted.
+// Once connectivity returns the stored data is drained in the order it was stored.
+type OfflineModeInfo struct {
+	// Enabled indicates if the offline-first mode is enabled. Requires StoreAndForward to also be enabled.
+	Enabled bool
+	// ProbeUrl is the target probed to detect connectivity. Supports http://, https:// and tcp://host:port
+	ProbeUrl string
+	// ProbeInterval is a time duration indicating how often connectivity is probed
+	ProbeInterval string
+	// ProbeTimeout is a time duration indicating how long to wait for the probe to succeed
+	ProbeTimeout string
+	// DrainRate is the maximum number of stored items retried per second when catching up. 0 means unlimited.
+	DrainRate int
 }
 
 // Credentials encapsulates username-password attributes.
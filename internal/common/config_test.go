@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerInfo_AckAfterProcessing(t *testing.T) {
+	tests := []struct {
+		Name          string
+		AckPolicy     string
+		DefaultPolicy string
+		Expected      bool
+		ExpectError   bool
+	}{
+		{"Default receipt", "", AckPolicyReceipt, false, false},
+		{"Default processed", "", AckPolicyProcessed, true, false},
+		{"Receipt", "Receipt", AckPolicyProcessed, false, false},
+		{"Processed", " processed ", AckPolicyReceipt, true, false},
+		{"Invalid", "never", AckPolicyReceipt, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			actual, err := TriggerInfo{AckPolicy: test.AckPolicy}.AckAfterProcessing(test.DefaultPolicy)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+func TestTriggerInfo_DrainTimeoutDuration(t *testing.T) {
+	tests := []struct {
+		Name         string
+		DrainTimeout string
+		Expected     time.Duration
+		ExpectError  bool
+	}{
+		{"Default", "", DefaultDrainTimeout, false},
+		{"Valid", " 30s ", 30 * time.Second, false},
+		{"No wait", "0s", 0, false},
+		{"Invalid", "soon", DefaultDrainTimeout, true},
+		{"Negative", "-5s", DefaultDrainTimeout, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			actual, err := TriggerInfo{DrainTimeout: test.DrainTimeout}.DrainTimeoutDuration()
+			if test.ExpectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+func TestTriggerInfo_PipelineTimeoutDuration(t *testing.T) {
+	tests := []struct {
+		Name            string
+		PipelineTimeout string
+		Expected        time.Duration
+		ExpectError     bool
+	}{
+		{"Not set", "", 0, false},
+		{"Valid", " 5s ", 5 * time.Second, false},
+		{"Invalid", "soon", 0, true},
+		{"Negative", "-5s", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			actual, err := TriggerInfo{PipelineTimeout: test.PipelineTimeout}.PipelineTimeoutDuration()
+			if test.ExpectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+func TestDeduplicationInfo_WindowDuration(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Window      string
+		Expected    time.Duration
+		ExpectError bool
+	}{
+		{"Default", "", DefaultDeduplicationWindow, false},
+		{"Valid", " 10m ", 10 * time.Minute, false},
+		{"Invalid", "a while", DefaultDeduplicationWindow, true},
+		{"Zero", "0s", DefaultDeduplicationWindow, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			actual, err := DeduplicationInfo{Window: test.Window}.WindowDuration()
+			if test.ExpectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
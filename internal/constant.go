@@ -25,6 +25,31 @@ const (
 
 	ApiTriggerRoute   = common.ApiBase + "/trigger"
 	ApiAddSecretRoute = common.ApiBase + "/secret"
+
+	Device                 = "device"
+	ApiLastValueCacheRoute = common.ApiBase + "/cache/{" + Device + "}"
+
+	ApiReplayRoute = common.ApiBase + "/replay"
+
+	ApiCaptureRoute = common.ApiBase + "/capture"
+
+	ApiTenantsRoute = common.ApiBase + "/tenants"
+
+	ApiReceiptsRoute = common.ApiBase + "/receipts"
+
+	ApiWatchdogRoute = common.ApiBase + "/watchdog"
+
+	ApiStatsRoute = common.ApiBase + "/stats"
+
+	ApiPreviewRoute = common.ApiBase + "/preview"
+
+	ApiDebugRoute = common.ApiBase + "/debug"
+
+	ApiHistoryRoute = common.ApiBase + "/history"
+
+	ApiDevicesRoute = common.ApiBase + "/devices"
+
+	ApiHealthRoute = common.ApiBase + "/health"
 )
 
 // SDKVersion indicates the version of the SDK - will be overwritten by build
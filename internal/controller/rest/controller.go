@@ -18,17 +18,28 @@ package rest
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
 	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/accounting"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/cache"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/clientswap"
 	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/devicestate"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/history"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/receipts"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/telemetry"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/tenancy"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/watchdog"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
@@ -44,6 +55,85 @@ type Controller struct {
 	secretProvider interfaces.SecretProvider
 	lc             logger.LoggingClient
 	config         *sdkCommon.ConfigurationStruct
+	lastValueCache *cache.LastValueCache
+	captureBuffer  *capture.Buffer
+	tenantRouter   *tenancy.Router
+	tracker        *receipts.Tracker
+	watchdog       *watchdog.Watchdog
+	accountant     *accounting.Accountant
+	history        *history.History
+	deviceMonitor  *devicestate.Monitor
+	clientSwaps    *clientswap.Tracker
+}
+
+// CaptureResponse is the response of the /capture endpoint
+type CaptureResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Enabled and SampleRate are the current Writable.Capture configuration
+	Enabled    bool             `json:"enabled"`
+	SampleRate int              `json:"sampleRate"`
+	Samples    []capture.Sample `json:"samples"`
+}
+
+// TenantsResponse is the response of the /tenants endpoint
+type TenantsResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Rejected is the number of messages rejected for not having a configured tenant
+	Rejected uint64          `json:"rejected"`
+	Tenants  []tenancy.Stats `json:"tenants"`
+}
+
+// DeliveryReceiptsResponse is the response of the /receipts endpoint
+type DeliveryReceiptsResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	Destinations            []receipts.DestinationStats `json:"destinations"`
+	// Overdue are the deliveries unacknowledged for longer than the AckTimeout, oldest first
+	Overdue []receipts.Delivery `json:"overdue"`
+}
+
+// WatchdogResponse is the response of the /watchdog endpoint
+type WatchdogResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	watchdog.Report         `json:",inline"`
+}
+
+// StatsResponse is the response of the /stats endpoint
+type StatsResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Pipelines are the costs of each pipeline executed, the pipeline that used the most CPU time first
+	Pipelines []accounting.PipelineStats `json:"pipelines"`
+}
+
+// HistoryResponse is the response of the /history endpoint
+type HistoryResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Rollups are the hourly rollups of the pipeline executions, oldest first
+	Rollups []history.Rollup `json:"rollups"`
+}
+
+// DevicesResponse is the response of the /devices endpoint
+type DevicesResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Devices are the states of the devices tracked, sorted by device name
+	Devices []devicestate.DeviceState `json:"devices"`
+}
+
+const (
+	// HealthStatusUp is the status of a service processing and exporting data
+	HealthStatusUp = "up"
+	// HealthStatusMaintenance is the status of a service in maintenance mode, holding back the data exported
+	HealthStatusMaintenance = "maintenance"
+)
+
+// HealthResponse is the response of the /health endpoint
+type HealthResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Status is HealthStatusUp or HealthStatusMaintenance
+	Status string `json:"status"`
+	// TriggerPaused indicates whether the trigger is stopped for maintenance
+	TriggerPaused bool `json:"triggerPaused"`
+	// CredentialSwaps are the clients rebuilt on the fly when their credentials were updated
+	CredentialSwaps []clientswap.Swap `json:"credentialSwaps,omitempty"`
 }
 
 // NewController creates and initializes an Controller
@@ -53,6 +143,15 @@ func NewController(router *mux.Router, dic *di.Container) *Controller {
 		secretProvider: bootstrapContainer.SecretProviderFrom(dic.Get),
 		lc:             bootstrapContainer.LoggingClientFrom(dic.Get),
 		config:         container.ConfigurationFrom(dic.Get),
+		lastValueCache: container.LastValueCacheFrom(dic.Get),
+		captureBuffer:  container.CaptureBufferFrom(dic.Get),
+		tenantRouter:   container.TenantRouterFrom(dic.Get),
+		tracker:        container.DeliveryTrackerFrom(dic.Get),
+		watchdog:       container.WatchdogFrom(dic.Get),
+		accountant:     container.AccountantFrom(dic.Get),
+		history:        container.MetricsHistoryFrom(dic.Get),
+		deviceMonitor:  container.DeviceMonitorFrom(dic.Get),
+		clientSwaps:    container.ClientSwapTrackerFrom(dic.Get),
 	}
 }
 
@@ -63,6 +162,27 @@ func (c *Controller) Ping(writer http.ResponseWriter, request *http.Request) {
 	c.sendResponse(writer, request, common.ApiPingRoute, response, http.StatusOK)
 }
 
+// Health handles the request to the /health endpoint, returning whether the service is in maintenance mode and the
+// clients rebuilt with updated credentials. The status code is 200 either way, as a service in maintenance mode is
+// still healthy.
+func (c *Controller) Health(writer http.ResponseWriter, request *http.Request) {
+	maintenance := c.config.Writable.Maintenance
+
+	response := HealthResponse{
+		BaseResponse:  commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Status:        HealthStatusUp,
+		TriggerPaused: maintenance.Enabled && maintenance.PauseTrigger,
+	}
+	if maintenance.Enabled {
+		response.Status = HealthStatusMaintenance
+	}
+	if c.clientSwaps != nil {
+		response.CredentialSwaps = c.clientSwaps.Swaps()
+	}
+
+	c.sendResponse(writer, request, internal.ApiHealthRoute, response, http.StatusOK)
+}
+
 // Version handles the request to /version endpoint. Is used to request the service's versions
 // It returns a response as specified by the V2 API swagger in openapi/v2
 func (c *Controller) Version(writer http.ResponseWriter, request *http.Request) {
@@ -95,6 +215,146 @@ func (c *Controller) Metrics(writer http.ResponseWriter, request *http.Request)
 	c.sendResponse(writer, request, common.ApiMetricsRoute, response, http.StatusOK)
 }
 
+// LastValues handles the request to the /cache/{device} endpoint, returning the latest reading of each of the
+// device's resources received by the function pipelines.
+func (c *Controller) LastValues(writer http.ResponseWriter, request *http.Request) {
+	if c.lastValueCache == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "Last value cache is not enabled", nil, "")
+		return
+	}
+
+	deviceName := mux.Vars(request)[internal.Device]
+	readings, found := c.lastValueCache.Readings(deviceName)
+	if !found {
+		c.sendError(writer, request, errors.KindEntityDoesNotExist,
+			fmt.Sprintf("No readings cached for device '%s'", deviceName), nil, "")
+		return
+	}
+
+	response := responses.NewMultiReadingsResponse("", "", http.StatusOK, uint32(len(readings)), readings)
+	c.sendResponse(writer, request, internal.ApiLastValueCacheRoute, response, http.StatusOK)
+}
+
+// CaptureSamples handles the request to the /capture endpoint, returning the captured samples of the trigger payloads
+// and the output of each pipeline function, oldest first.
+func (c *Controller) CaptureSamples(writer http.ResponseWriter, request *http.Request) {
+	if c.captureBuffer == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "Capture is not available", nil, "")
+		return
+	}
+
+	response := CaptureResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Enabled:      c.config.Writable.Capture.Enabled,
+		SampleRate:   c.config.Writable.Capture.SampleRate,
+		Samples:      c.captureBuffer.Samples(),
+	}
+	c.sendResponse(writer, request, internal.ApiCaptureRoute, response, http.StatusOK)
+}
+
+// ClearCaptureSamples handles the request to the /capture endpoint to remove all captured samples
+func (c *Controller) ClearCaptureSamples(writer http.ResponseWriter, request *http.Request) {
+	if c.captureBuffer == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "Capture is not available", nil, "")
+		return
+	}
+
+	c.captureBuffer.Clear()
+	c.sendResponse(writer, request, internal.ApiCaptureRoute, commonDtos.NewBaseResponse("", "", http.StatusOK), http.StatusOK)
+}
+
+// Tenants handles the request to the /tenants endpoint, returning the counts of each tenant's pipeline executions
+func (c *Controller) Tenants(writer http.ResponseWriter, request *http.Request) {
+	if c.tenantRouter == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "Tenancy is not enabled", nil, "")
+		return
+	}
+
+	stats, rejected := c.tenantRouter.Stats()
+	response := TenantsResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Rejected:     rejected,
+		Tenants:      stats,
+	}
+	c.sendResponse(writer, request, internal.ApiTenantsRoute, response, http.StatusOK)
+}
+
+// DeliveryReceipts handles the request to the /receipts endpoint, returning the counts of the deliveries to each
+// destination and the overdue deliveries
+func (c *Controller) DeliveryReceipts(writer http.ResponseWriter, request *http.Request) {
+	if c.tracker == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "Delivery receipts are not enabled", nil, "")
+		return
+	}
+
+	destinations, overdue := c.tracker.Report()
+	response := DeliveryReceiptsResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Destinations: destinations,
+		Overdue:      overdue,
+	}
+	c.sendResponse(writer, request, internal.ApiReceiptsRoute, response, http.StatusOK)
+}
+
+// Watchdog handles the request to the /watchdog endpoint, returning the counts of the stalled pipeline executions and
+// the executions currently stalled
+func (c *Controller) Watchdog(writer http.ResponseWriter, request *http.Request) {
+	if c.watchdog == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "Watchdog is not enabled", nil, "")
+		return
+	}
+
+	response := WatchdogResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Report:       c.watchdog.Report(),
+	}
+	c.sendResponse(writer, request, internal.ApiWatchdogRoute, response, http.StatusOK)
+}
+
+// Stats handles the request to the /stats endpoint, returning the CPU time used and the bytes produced by each
+// pipeline and its functions
+func (c *Controller) Stats(writer http.ResponseWriter, request *http.Request) {
+	if c.accountant == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "CostAccounting is not enabled", nil, "")
+		return
+	}
+
+	response := StatsResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Pipelines:    c.accountant.Stats(),
+	}
+	c.sendResponse(writer, request, internal.ApiStatsRoute, response, http.StatusOK)
+}
+
+// History handles the request to the /history endpoint, returning the hourly rollups of each pipeline's executions
+func (c *Controller) History(writer http.ResponseWriter, request *http.Request) {
+	if c.history == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "MetricsHistory is not enabled", nil, "")
+		return
+	}
+
+	response := HistoryResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Rollups:      c.history.Rollups(),
+	}
+	c.sendResponse(writer, request, internal.ApiHistoryRoute, response, http.StatusOK)
+}
+
+// Devices handles the request to the /devices endpoint, returning whether each device tracked is online or offline
+// and when it was last seen
+func (c *Controller) Devices(writer http.ResponseWriter, request *http.Request) {
+	if c.deviceMonitor == nil {
+		c.sendError(writer, request, errors.KindServiceUnavailable, "DeviceMonitor is not enabled", nil, "")
+		return
+	}
+
+	response := DevicesResponse{
+		BaseResponse: commonDtos.NewBaseResponse("", "", http.StatusOK),
+		Devices:      c.deviceMonitor.Devices(),
+	}
+	c.sendResponse(writer, request, internal.ApiDevicesRoute, response, http.StatusOK)
+}
+
 // AddSecret handles the request to add App Service exclusive secret to the Secret Store
 // It returns a response as specified by the V2 API swagger in openapi/v2
 func (c *Controller) AddSecret(writer http.ResponseWriter, request *http.Request) {
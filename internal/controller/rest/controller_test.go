@@ -28,8 +28,17 @@ import (
 	"time"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/accounting"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/cache"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/clientswap"
 	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/devicestate"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/history"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/receipts"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/tenancy"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/watchdog"
 
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
@@ -38,9 +47,12 @@ import (
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -71,6 +83,53 @@ func TestPingRequest(t *testing.T) {
 	require.Equal(t, common.ApiVersion, actual.ApiVersion)
 }
 
+func TestHealthRequest(t *testing.T) {
+	target := NewController(nil, dic)
+	target.config = &sdkCommon.ConfigurationStruct{}
+
+	tests := []struct {
+		Name                  string
+		Maintenance           sdkCommon.MaintenanceInfo
+		ExpectedStatus        string
+		ExpectedTriggerPaused bool
+	}{
+		{"Up", sdkCommon.MaintenanceInfo{PauseTrigger: true}, HealthStatusUp, false},
+		{"Maintenance", sdkCommon.MaintenanceInfo{Enabled: true}, HealthStatusMaintenance, false},
+		{"Maintenance paused", sdkCommon.MaintenanceInfo{Enabled: true, PauseTrigger: true}, HealthStatusMaintenance, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			target.config.Writable.Maintenance = test.Maintenance
+
+			recorder := doRequest(t, http.MethodGet, internal.ApiHealthRoute, target.Health, nil)
+			require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+			actual := HealthResponse{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actual))
+			assert.Equal(t, test.ExpectedStatus, actual.Status)
+			assert.Equal(t, test.ExpectedTriggerPaused, actual.TriggerPaused)
+			assert.Empty(t, actual.CredentialSwaps)
+		})
+	}
+}
+
+func TestHealthRequestCredentialSwaps(t *testing.T) {
+	target := NewController(nil, dic)
+	target.config = &sdkCommon.ConfigurationStruct{}
+	target.clientSwaps = clientswap.NewTracker()
+	target.clientSwaps.Swapped("Database")
+
+	recorder := doRequest(t, http.MethodGet, internal.ApiHealthRoute, target.Health, nil)
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+	actual := HealthResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actual))
+	require.Len(t, actual.CredentialSwaps, 1)
+	assert.Equal(t, "Database", actual.CredentialSwaps[0].Client)
+	assert.Equal(t, uint64(1), actual.CredentialSwaps[0].Swaps)
+}
+
 func TestVersionRequest(t *testing.T) {
 	expectedAppVersion := "1.2.5"
 	expectedSdkVersion := "1.3.1"
@@ -253,6 +312,104 @@ func TestAddSecretRequest(t *testing.T) {
 	}
 }
 
+func TestLastValuesRequest(t *testing.T) {
+	lastValueCache := cache.NewLastValueCache("")
+	event := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, 20.5))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeInt32, int32(40)))
+	lastValueCache.Update(event)
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+
+	tests := []struct {
+		Name               string
+		Cache              *cache.LastValueCache
+		DeviceName         string
+		ExpectedStatusCode int
+	}{
+		{"Valid", lastValueCache, "thermostat-1", http.StatusOK},
+		{"Invalid - device not cached", lastValueCache, "thermostat-2", http.StatusNotFound},
+		{"Invalid - cache not enabled", nil, "thermostat-1", http.StatusServiceUnavailable},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			target := NewController(nil, dic)
+			target.lastValueCache = testCase.Cache
+
+			req, err := http.NewRequest(http.MethodGet, internal.ApiLastValueCacheRoute, nil)
+			require.NoError(t, err)
+			req = mux.SetURLVars(req, map[string]string{internal.Device: testCase.DeviceName})
+
+			recorder := httptest.NewRecorder()
+			http.HandlerFunc(target.LastValues).ServeHTTP(recorder, req)
+
+			actualResponse := responses.MultiReadingsResponse{}
+			err = json.Unmarshal(recorder.Body.Bytes(), &actualResponse)
+			require.NoError(t, err)
+
+			require.Equal(t, testCase.ExpectedStatusCode, recorder.Result().StatusCode, "HTTP status code not as expected")
+			assert.Equal(t, testCase.ExpectedStatusCode, actualResponse.StatusCode)
+			if testCase.ExpectedStatusCode != http.StatusOK {
+				assert.NotEmpty(t, actualResponse.Message)
+				return
+			}
+
+			assert.Equal(t, uint32(2), actualResponse.TotalCount)
+			require.Len(t, actualResponse.Readings, 2)
+			assert.Equal(t, "humidity", actualResponse.Readings[0].ResourceName)
+			assert.Equal(t, "40", actualResponse.Readings[0].Value)
+			assert.Equal(t, "temperature", actualResponse.Readings[1].ResourceName)
+		})
+	}
+}
+
+func TestCaptureRequest(t *testing.T) {
+	captureBuffer := capture.NewBuffer()
+	captureBuffer.Add(captureBuffer.Start(1, "default", "123", "edgex/events", common.ContentTypeJSON, []byte(`{"id":"1"}`)), 10)
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{
+				Writable: sdkCommon.WritableInfo{Capture: sdkCommon.CaptureInfo{Enabled: true, SampleRate: 10}},
+			}
+		},
+	})
+
+	target := NewController(nil, dic)
+	target.captureBuffer = captureBuffer
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiCaptureRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.CaptureSamples).ServeHTTP(recorder, req)
+
+	actualResponse := CaptureResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	assert.True(t, actualResponse.Enabled)
+	assert.Equal(t, 10, actualResponse.SampleRate)
+	require.Len(t, actualResponse.Samples, 1)
+	assert.Equal(t, "123", actualResponse.Samples[0].CorrelationId)
+	assert.Equal(t, `{"id":"1"}`, actualResponse.Samples[0].Payload.Value)
+
+	req, err = http.NewRequest(http.MethodDelete, internal.ApiCaptureRoute, nil)
+	require.NoError(t, err)
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.ClearCaptureSamples).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	assert.Empty(t, captureBuffer.Samples())
+
+	target.captureBuffer = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.CaptureSamples).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
+
 func doRequest(t *testing.T, method string, api string, handler http.HandlerFunc, body io.Reader) *httptest.ResponseRecorder {
 	req, err := http.NewRequest(method, api, body)
 	require.NoError(t, err)
@@ -275,3 +432,174 @@ func doRequest(t *testing.T, method string, api string, handler http.HandlerFunc
 
 	return recorder
 }
+
+func TestTenantsRequest(t *testing.T) {
+	router, err := tenancy.NewRouter(sdkCommon.TenancyInfo{
+		Source:  tenancy.SourceTopic,
+		Tenants: map[string]sdkCommon.TenantInfo{"acme": {}, "globex": {}},
+	})
+	require.NoError(t, err)
+	router.Record("acme", true)
+	router.Record("acme", false)
+
+	target := NewController(nil, dic)
+	target.tenantRouter = router
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiTenantsRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.Tenants).ServeHTTP(recorder, req)
+
+	actualResponse := TenantsResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Len(t, actualResponse.Tenants, 2)
+	assert.Equal(t, "acme", actualResponse.Tenants[0].Tenant)
+	assert.Equal(t, uint64(1), actualResponse.Tenants[0].Succeeded)
+	assert.Equal(t, uint64(1), actualResponse.Tenants[0].Failed)
+	assert.Equal(t, tenancy.Stats{Tenant: "globex"}, actualResponse.Tenants[1])
+
+	target.tenantRouter = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.Tenants).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
+
+func TestDeliveryReceiptsRequest(t *testing.T) {
+	tracker, err := receipts.NewTracker(sdkCommon.DeliveryReceiptsInfo{AckTimeout: "1ns"})
+	require.NoError(t, err)
+	tracker.Acknowledge(tracker.Record("https://cloud/ingest", "1"), "r-1")
+	tracker.Record("https://cloud/ingest", "2")
+
+	target := NewController(nil, dic)
+	target.tracker = tracker
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiReceiptsRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.DeliveryReceipts).ServeHTTP(recorder, req)
+
+	actualResponse := DeliveryReceiptsResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Len(t, actualResponse.Destinations, 1)
+	assert.Equal(t, uint64(2), actualResponse.Destinations[0].Exported)
+	assert.Equal(t, uint64(1), actualResponse.Destinations[0].Acknowledged)
+	assert.Equal(t, "r-1", actualResponse.Destinations[0].LastReceipt)
+	require.Len(t, actualResponse.Overdue, 1)
+	assert.Equal(t, "2", actualResponse.Overdue[0].CorrelationId)
+
+	target.tracker = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.DeliveryReceipts).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
+
+func TestWatchdogRequest(t *testing.T) {
+	executionWatchdog, err := watchdog.NewWatchdog(sdkCommon.WatchdogInfo{}, nil, logger.NewMockClient())
+	require.NoError(t, err)
+
+	target := NewController(nil, dic)
+	target.watchdog = executionWatchdog
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiWatchdogRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.Watchdog).ServeHTTP(recorder, req)
+
+	actualResponse := WatchdogResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	assert.Equal(t, uint64(0), actualResponse.StalledTotal)
+	assert.Empty(t, actualResponse.Stalled)
+
+	target.watchdog = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.Watchdog).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
+
+func TestStatsRequest(t *testing.T) {
+	accountant, err := accounting.NewAccountant(sdkCommon.CostAccountingInfo{}, logger.NewMockClient())
+	require.NoError(t, err)
+
+	execution := accountant.Begin("default-pipeline", "123")
+	execution.Measure(0, func() (bool, interface{}) { return true, "data" })
+	execution.End()
+
+	target := NewController(nil, dic)
+	target.accountant = accountant
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiStatsRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.Stats).ServeHTTP(recorder, req)
+
+	actualResponse := StatsResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Len(t, actualResponse.Pipelines, 1)
+	assert.Equal(t, "default-pipeline", actualResponse.Pipelines[0].PipelineId)
+	assert.Equal(t, uint64(4), actualResponse.Pipelines[0].BytesProduced)
+
+	target.accountant = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.Stats).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
+
+func TestHistoryRequest(t *testing.T) {
+	metricsHistory, err := history.NewHistory(sdkCommon.MetricsHistoryInfo{}, logger.NewMockClient())
+	require.NoError(t, err)
+	metricsHistory.Record("default-pipeline", false)
+	metricsHistory.Record("default-pipeline", true)
+
+	target := NewController(nil, dic)
+	target.history = metricsHistory
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiHistoryRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.History).ServeHTTP(recorder, req)
+
+	actualResponse := HistoryResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Len(t, actualResponse.Rollups, 1)
+	require.Len(t, actualResponse.Rollups[0].Pipelines, 1)
+	assert.Equal(t, uint64(2), actualResponse.Rollups[0].Pipelines[0].Executions)
+	assert.Equal(t, 0.5, actualResponse.Rollups[0].Pipelines[0].SuccessRate)
+
+	target.history = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.History).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
+
+func TestDevicesRequest(t *testing.T) {
+	deviceMonitor, err := devicestate.NewMonitor(sdkCommon.DeviceMonitorInfo{Devices: []string{"pump-2"}},
+		func(devicestate.StateChange) {}, logger.NewMockClient())
+	require.NoError(t, err)
+	deviceMonitor.Seen("pump-1", time.Now())
+
+	target := NewController(nil, dic)
+	target.deviceMonitor = deviceMonitor
+
+	req, err := http.NewRequest(http.MethodGet, internal.ApiDevicesRoute, nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	http.HandlerFunc(target.Devices).ServeHTTP(recorder, req)
+
+	actualResponse := DevicesResponse{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+	require.Len(t, actualResponse.Devices, 2)
+	assert.Equal(t, "pump-1", actualResponse.Devices[0].DeviceName)
+	assert.Equal(t, devicestate.StateOnline, actualResponse.Devices[0].State)
+	assert.Equal(t, "pump-2", actualResponse.Devices[1].DeviceName)
+
+	target.deviceMonitor = nil
+	recorder = httptest.NewRecorder()
+	http.HandlerFunc(target.Devices).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Result().StatusCode)
+}
@@ -0,0 +1,210 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+)
+
+const (
+	// DebugCommandStart starts a session stepping the command's payload, or the payload of the captured sample with
+	// the command's correlation id, through the command's pipeline
+	DebugCommandStart = "start"
+	// DebugCommandStep executes the next function of the session's pipeline
+	DebugCommandStep = "step"
+	// DebugCommandRun executes the remaining functions of the session's pipeline
+	DebugCommandRun = "run"
+	// DebugCommandStop ends the session
+	DebugCommandStop = "stop"
+
+	// DebugMessageStarted is sent with the pipeline's functions and the decoded payload when a session starts
+	DebugMessageStarted = "started"
+	// DebugMessageStep is sent with the result of each function executed
+	DebugMessageStep = "step"
+	// DebugMessageCompleted is sent when a function stopped the pipeline or all the functions have executed
+	DebugMessageCompleted = "completed"
+	// DebugMessageError is sent when a command fails
+	DebugMessageError = "error"
+)
+
+// DebugSession steps a payload through the functions of a pipeline one at a time
+type DebugSession interface {
+	// Functions returns the names of the pipeline's functions
+	Functions() []string
+	// Input returns the decoded payload passed to the first function
+	Input() capture.Data
+	// Step executes the next function and returns its result, or false once the session has completed
+	Step() (capture.Stage, bool)
+	// Completed returns whether a function stopped the pipeline or all the functions have executed
+	Completed() bool
+}
+
+// DebugFunc starts a DebugSession stepping the payload through the pipeline. When no payload is given, the payload
+// of the most recent captured sample with the correlation id is used.
+type DebugFunc func(pipelineId string, correlationId string, contentType string, payload []byte) (DebugSession, error)
+
+// DebugCommand is a message sent by the client of the /debug WebSocket. The PipelineId, CorrelationId, ContentType
+// and Payload only apply to the DebugCommandStart command.
+type DebugCommand struct {
+	Command       string          `json:"command"`
+	PipelineId    string          `json:"pipelineId,omitempty"`
+	CorrelationId string          `json:"correlationId,omitempty"`
+	ContentType   string          `json:"contentType,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+}
+
+// DebugMessage is a message sent to the client of the /debug WebSocket
+type DebugMessage struct {
+	// Type is DebugMessageStarted, DebugMessageStep, DebugMessageCompleted or DebugMessageError
+	Type string `json:"type"`
+	// Functions are the names of the pipeline's functions, sent when the session starts
+	Functions []string `json:"functions,omitempty"`
+	// Input is the decoded payload passed to the first function, sent when the session starts
+	Input *capture.Data `json:"input,omitempty"`
+	// Function is the name of the function executed by the step
+	Function string `json:"function,omitempty"`
+	// Stage is the result of the function executed by the step
+	Stage *capture.Stage `json:"stage,omitempty"`
+	// Error is the reason the command failed
+	Error string `json:"error,omitempty"`
+}
+
+// debugUpgrader upgrades the /debug requests to WebSockets. The bearer token authorizes the clients, so requests
+// from any origin are accepted.
+var debugUpgrader = websocket.Upgrader{
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// Debug returns the handler for the /debug WebSocket, over which the client sends DebugCommands to step a payload
+// through a pipeline and receives a DebugMessage for each. The handshake's bearer token must match the Debug token
+// secret.
+func (c *Controller) Debug(start DebugFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if err := c.authorizeBearer(request, c.config.Debug.SecretPath, sdkCommon.DefaultDebugSecretPath, sdkCommon.DebugTokenKey); err != nil {
+			c.lc.Errorf("Debug request not authorized: %s", err.Error())
+			writer.Header().Set("WWW-Authenticate", "Bearer")
+			response := commonDtos.NewBaseResponse("", "Debug request not authorized", http.StatusUnauthorized)
+			c.sendResponse(writer, request, internal.ApiDebugRoute, response, http.StatusUnauthorized)
+			return
+		}
+
+		connection, err := debugUpgrader.Upgrade(writer, request, nil)
+		if err != nil {
+			// The upgrader has already responded with the error
+			c.lc.Errorf("Unable to upgrade debug request to a WebSocket: %s", err.Error())
+			return
+		}
+		defer func() {
+			_ = connection.Close()
+		}()
+
+		c.lc.Info("Debug session client connected")
+		c.serveDebug(connection, start)
+		c.lc.Info("Debug session client disconnected")
+	}
+}
+
+// serveDebug executes the client's commands until it sends DebugCommandStop or disconnects
+func (c *Controller) serveDebug(connection *websocket.Conn, start DebugFunc) {
+	var session DebugSession
+	var functions []string
+
+	for {
+		_, content, err := connection.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		command := DebugCommand{}
+		if err := json.Unmarshal(content, &command); err != nil {
+			if !c.sendDebug(connection, DebugMessage{Type: DebugMessageError, Error: "JSON decode failed: " + err.Error()}) {
+				return
+			}
+			continue
+		}
+
+		var messages []DebugMessage
+
+		switch command.Command {
+		case DebugCommandStart:
+			started, err := start(command.PipelineId, command.CorrelationId, command.ContentType, command.Payload)
+			if err != nil {
+				messages = append(messages, DebugMessage{Type: DebugMessageError, Error: fmt.Sprintf("unable to start session: %s", err.Error())})
+				break
+			}
+
+			session = started
+			functions = session.Functions()
+			input := session.Input()
+			messages = append(messages, DebugMessage{Type: DebugMessageStarted, Functions: functions, Input: &input})
+
+		case DebugCommandStep, DebugCommandRun:
+			if session == nil {
+				messages = append(messages, DebugMessage{Type: DebugMessageError, Error: "no session started"})
+				break
+			}
+
+			for {
+				stage, ok := session.Step()
+				if ok {
+					messages = append(messages, DebugMessage{Type: DebugMessageStep, Function: functions[stage.Index], Stage: &stage})
+				}
+
+				if session.Completed() {
+					messages = append(messages, DebugMessage{Type: DebugMessageCompleted})
+					session = nil
+					break
+				}
+
+				if command.Command == DebugCommandStep {
+					break
+				}
+			}
+
+		case DebugCommandStop:
+			return
+
+		default:
+			messages = append(messages, DebugMessage{Type: DebugMessageError, Error: fmt.Sprintf("unknown command '%s'", command.Command)})
+		}
+
+		for _, message := range messages {
+			if !c.sendDebug(connection, message) {
+				return
+			}
+		}
+	}
+}
+
+// sendDebug sends the message to the client, returning false if the connection failed
+func (c *Controller) sendDebug(connection *websocket.Conn, message DebugMessage) bool {
+	if err := connection.WriteJSON(message); err != nil {
+		c.lc.Errorf("Unable to send debug message: %s", err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDebugSession steps through functions each appending its name to the data
+type testDebugSession struct {
+	functions []string
+	data      string
+	next      int
+}
+
+func (session *testDebugSession) Functions() []string { return session.functions }
+
+func (session *testDebugSession) Input() capture.Data { return capture.NewData("input") }
+
+func (session *testDebugSession) Step() (capture.Stage, bool) {
+	if session.Completed() {
+		return capture.Stage{}, false
+	}
+	session.data += "," + session.functions[session.next]
+	session.next++
+	return capture.NewStage(session.next-1, true, session.data), true
+}
+
+func (session *testDebugSession) Completed() bool { return session.next == len(session.functions) }
+
+func TestDebug(t *testing.T) {
+	mockProvider := &mocks.SecretProvider{}
+	mockProvider.On("GetSecret", "debug", "token").Return(map[string]string{"token": "secret-token"}, nil)
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{Debug: sdkCommon.DebugInfo{Enabled: true}}
+		},
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockProvider
+		},
+	})
+
+	start := func(pipelineId string, _ string, _ string, payload []byte) (DebugSession, error) {
+		if pipelineId != "default" {
+			return nil, errors.New("pipeline not found")
+		}
+		return &testDebugSession{functions: []string{"Filter", "Transform", "Export"}, data: string(payload)}, nil
+	}
+
+	target := NewController(nil, dic)
+	server := httptest.NewServer(target.Debug(start))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, response, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer guess"}})
+	require.Error(t, err)
+	require.NotNil(t, response)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	connection, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer secret-token"}})
+	require.NoError(t, err)
+	defer func() {
+		_ = connection.Close()
+	}()
+
+	exchange := func(command string, count int) []DebugMessage {
+		require.NoError(t, connection.WriteMessage(websocket.TextMessage, []byte(command)))
+		messages := make([]DebugMessage, count)
+		for index := range messages {
+			require.NoError(t, connection.ReadJSON(&messages[index]))
+		}
+		return messages
+	}
+
+	messages := exchange(`{"command":"step"}`, 1)
+	assert.Equal(t, DebugMessageError, messages[0].Type, "step without a session should fail")
+
+	messages = exchange(`{"command":"start","pipelineId":"unknown","payload":"x"}`, 1)
+	assert.Equal(t, DebugMessageError, messages[0].Type)
+	assert.Contains(t, messages[0].Error, "pipeline not found")
+
+	messages = exchange(`{"command":"start","pipelineId":"default","payload":"x"}`, 1)
+	require.Equal(t, DebugMessageStarted, messages[0].Type)
+	assert.Equal(t, []string{"Filter", "Transform", "Export"}, messages[0].Functions)
+
+	messages = exchange(`{"command":"step"}`, 1)
+	assert.Equal(t, "Filter", messages[0].Function)
+	require.NotNil(t, messages[0].Stage)
+	assert.Equal(t, `"x",Filter`, messages[0].Stage.Output.Value)
+
+	messages = exchange(`{"command":"run"}`, 3)
+	assert.Equal(t, "Transform", messages[0].Function)
+	assert.Equal(t, "Export", messages[1].Function)
+	assert.Equal(t, `"x",Filter,Transform,Export`, messages[1].Stage.Output.Value)
+	assert.Equal(t, DebugMessageCompleted, messages[2].Type)
+
+	messages = exchange(`{"command":"bogus"}`, 1)
+	assert.Equal(t, DebugMessageError, messages[0].Type)
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
+)
+
+// PreviewFunc executes the named built-in pipeline function, created with the parameters, with the JSON payload and
+// returns whether the pipeline would continue and the function's result
+type PreviewFunc func(functionName string, parameters map[string]string, payload []byte) (bool, interface{}, error)
+
+// PreviewRequest is the request body for the /preview endpoint. FunctionName and Parameters are specified as in the
+// Pipeline.Functions configuration and Payload is the sample data, i.e. an Event.
+type PreviewRequest struct {
+	commonDtos.BaseRequest `json:",inline"`
+	FunctionName           string            `json:"functionName"`
+	Parameters             map[string]string `json:"parameters,omitempty"`
+	Payload                json.RawMessage   `json:"payload"`
+}
+
+// PreviewResponse is the response of the /preview endpoint
+type PreviewResponse struct {
+	commonDtos.BaseResponse `json:",inline"`
+	// Continue indicates whether the pipeline would continue to the next function
+	Continue bool `json:"continue"`
+	// Result is the data the function returned, which is passed to the next function
+	Result interface{} `json:"result,omitempty"`
+	// Error is the error the function returned, which stops the pipeline
+	Error string `json:"error,omitempty"`
+}
+
+// Preview returns the handler for requests to the /preview endpoint, which executes the requested function with the
+// payload and responds with the function's result. The request's bearer token must match the Preview token secret.
+func (c *Controller) Preview(preview PreviewFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		defer func() {
+			_ = request.Body.Close()
+		}()
+
+		if err := c.authorizePreview(request); err != nil {
+			c.lc.Errorf("Preview request not authorized: %s", err.Error())
+			writer.Header().Set("WWW-Authenticate", "Bearer")
+			response := commonDtos.NewBaseResponse("", "Preview request not authorized", http.StatusUnauthorized)
+			c.sendResponse(writer, request, internal.ApiPreviewRoute, response, http.StatusUnauthorized)
+			return
+		}
+
+		previewRequest := PreviewRequest{}
+		if err := json.NewDecoder(request.Body).Decode(&previewRequest); err != nil {
+			c.sendError(writer, request, errors.KindContractInvalid, "JSON decode failed", err, "")
+			return
+		}
+
+		if strings.TrimSpace(previewRequest.FunctionName) == "" || len(previewRequest.Payload) == 0 {
+			c.sendError(writer, request, errors.KindContractInvalid, "functionName and payload are required", nil, previewRequest.RequestId)
+			return
+		}
+
+		continuePipeline, result, err := preview(previewRequest.FunctionName, previewRequest.Parameters, previewRequest.Payload)
+		if err != nil {
+			c.sendError(writer, request, errors.KindContractInvalid, "Unable to preview function", err, previewRequest.RequestId)
+			return
+		}
+
+		response := PreviewResponse{
+			BaseResponse: commonDtos.NewBaseResponse(previewRequest.RequestId, "", http.StatusOK),
+			Continue:     continuePipeline,
+		}
+
+		switch data := result.(type) {
+		case error:
+			response.Error = data.Error()
+		case []byte:
+			// Functions that format the data, i.e. Transform, return bytes, which are embedded when valid JSON
+			if json.Valid(data) {
+				response.Result = json.RawMessage(data)
+			} else {
+				response.Result = string(data)
+			}
+		default:
+			response.Result = data
+		}
+
+		c.sendResponse(writer, request, internal.ApiPreviewRoute, response, http.StatusOK)
+	}
+}
+
+// authorizePreview returns an error unless the request's bearer token matches the Preview token secret
+func (c *Controller) authorizePreview(request *http.Request) error {
+	return c.authorizeBearer(request, c.config.Preview.SecretPath, sdkCommon.DefaultPreviewSecretPath, sdkCommon.PreviewTokenKey)
+}
+
+// authorizeBearer returns an error unless the request's bearer token matches the token secret at the secret path, or
+// the default path when not set
+func (c *Controller) authorizeBearer(request *http.Request, secretPath string, defaultPath string, tokenKey string) error {
+	secretPath = strings.TrimSpace(secretPath)
+	if secretPath == "" {
+		secretPath = defaultPath
+	}
+
+	secrets, err := c.secretProvider.GetSecret(secretPath, tokenKey)
+	if err != nil {
+		return fmt.Errorf("unable to get token secret '%s': %s", secretPath, err.Error())
+	}
+
+	expected := secrets[tokenKey]
+	if expected == "" {
+		return fmt.Errorf("token secret '%s' has no %s value", secretPath, tokenKey)
+	}
+
+	token := strings.TrimSpace(strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer "))
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return fmt.Errorf("bearer token is missing or invalid")
+	}
+
+	return nil
+}
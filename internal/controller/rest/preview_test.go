@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewRequest(t *testing.T) {
+	mockProvider := &mocks.SecretProvider{}
+	mockProvider.On("GetSecret", "preview", "token").Return(map[string]string{"token": "secret-token"}, nil)
+	mockProvider.On("GetSecret", "missing", "token").Return(nil, errors.New("not found"))
+
+	preview := func(functionName string, parameters map[string]string, payload []byte) (bool, interface{}, error) {
+		switch functionName {
+		case "Transform":
+			return true, []byte(`{"converted":true}`), nil
+		case "FilterByDeviceName":
+			return false, nil, nil
+		case "Encrypt":
+			return false, errors.New("invalid key"), nil
+		default:
+			return false, nil, errors.New("function is not a built in SDK function")
+		}
+	}
+
+	validBody := `{"functionName":"Transform","parameters":{"Type":"json"},"payload":{"id":"1"}}`
+
+	tests := []struct {
+		Name               string
+		SecretPath         string
+		Token              string
+		Body               string
+		ExpectedStatusCode int
+		ExpectedContinue   bool
+		ExpectedResult     string
+		ExpectedError      string
+	}{
+		{"Valid", "", "Bearer secret-token", validBody, http.StatusOK, true, `{"converted":true}`, ""},
+		{"Valid - filtered", "", "Bearer secret-token", `{"functionName":"FilterByDeviceName","payload":{}}`, http.StatusOK, false, "", ""},
+		{"Valid - function error", "", "Bearer secret-token", `{"functionName":"Encrypt","payload":{}}`, http.StatusOK, false, "", "invalid key"},
+		{"Invalid - no token", "", "", validBody, http.StatusUnauthorized, false, "", ""},
+		{"Invalid - wrong token", "", "Bearer guess", validBody, http.StatusUnauthorized, false, "", ""},
+		{"Invalid - no token secret", "missing", "Bearer secret-token", validBody, http.StatusUnauthorized, false, "", ""},
+		{"Invalid - bad JSON", "", "Bearer secret-token", `{"functionName":`, http.StatusBadRequest, false, "", ""},
+		{"Invalid - no payload", "", "Bearer secret-token", `{"functionName":"Transform"}`, http.StatusBadRequest, false, "", ""},
+		{"Invalid - unknown function", "", "Bearer secret-token", `{"functionName":"Unknown","payload":{}}`, http.StatusBadRequest, false, "", ""},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			dic.Update(di.ServiceConstructorMap{
+				container.ConfigurationName: func(get di.Get) interface{} {
+					return &sdkCommon.ConfigurationStruct{Preview: sdkCommon.PreviewInfo{Enabled: true, SecretPath: testCase.SecretPath}}
+				},
+				bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+					return mockProvider
+				},
+			})
+
+			target := NewController(nil, dic)
+			req, err := http.NewRequest(http.MethodPost, internal.ApiPreviewRoute, strings.NewReader(testCase.Body))
+			require.NoError(t, err)
+			if testCase.Token != "" {
+				req.Header.Set("Authorization", testCase.Token)
+			}
+
+			recorder := httptest.NewRecorder()
+			target.Preview(preview).ServeHTTP(recorder, req)
+
+			require.Equal(t, testCase.ExpectedStatusCode, recorder.Result().StatusCode, recorder.Body.String())
+
+			actualResponse := struct {
+				PreviewResponse
+				Result json.RawMessage `json:"result"`
+			}{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+			assert.Equal(t, testCase.ExpectedStatusCode, actualResponse.StatusCode)
+
+			assert.Equal(t, testCase.ExpectedContinue, actualResponse.Continue)
+			assert.Equal(t, testCase.ExpectedError, actualResponse.Error)
+			if testCase.ExpectedResult != "" {
+				assert.JSONEq(t, testCase.ExpectedResult, string(actualResponse.Result))
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
+)
+
+// ReplayFunc replays the Events from Core Data that originated in the time range through the function pipeline(s)
+type ReplayFunc func(start time.Time, end time.Time, deviceNames ...string) (int, error)
+
+// ReplayRequest is the request body for the /replay endpoint. Start and End are RFC3339 timestamps, End defaults to
+// the time of the request and DeviceNames, when empty, replays the Events of all devices.
+type ReplayRequest struct {
+	commonDtos.BaseRequest `json:",inline"`
+	DeviceNames            []string `json:"deviceNames,omitempty"`
+	Start                  string   `json:"start"`
+	End                    string   `json:"end,omitempty"`
+}
+
+// Replay returns the handler for requests to the /replay endpoint, which starts replaying the requested time range
+// of Events from Core Data through the function pipeline(s) in the background and responds with 202 Accepted, or
+// 409 Conflict while a replay is already running. The replay is added to the wait group so the service waits for it
+// to stop on shutdown, and its outcome is logged.
+func (c *Controller) Replay(replayEvents ReplayFunc, appWg *sync.WaitGroup) http.HandlerFunc {
+	var running int32
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		defer func() {
+			_ = request.Body.Close()
+		}()
+
+		replayRequest := ReplayRequest{}
+		if err := json.NewDecoder(request.Body).Decode(&replayRequest); err != nil {
+			c.sendError(writer, request, errors.KindContractInvalid, "JSON decode failed", err, "")
+			return
+		}
+
+		start, end, err := replayRequest.timeRange(time.Now())
+		if err != nil {
+			c.sendError(writer, request, errors.KindContractInvalid, "Invalid replay time range", err, replayRequest.RequestId)
+			return
+		}
+
+		if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+			c.sendError(writer, request, errors.KindStatusConflict, "A replay is already running", nil, replayRequest.RequestId)
+			return
+		}
+
+		correlationID := request.Header.Get(common.CorrelationHeader)
+		appWg.Add(1)
+		go func() {
+			defer appWg.Done()
+			defer atomic.StoreInt32(&running, 0)
+
+			replayed, err := replayEvents(start, end, replayRequest.DeviceNames...)
+			if err != nil {
+				c.lc.Errorf("Replay of Events from %s to %s failed after %d Event(s): %s, %s=%s",
+					start.Format(time.RFC3339), end.Format(time.RFC3339), replayed, err.Error(), common.CorrelationHeader, correlationID)
+				return
+			}
+
+			c.lc.Infof("Replay of Events from %s to %s completed, %d Event(s) replayed, %s=%s",
+				start.Format(time.RFC3339), end.Format(time.RFC3339), replayed, common.CorrelationHeader, correlationID)
+		}()
+
+		message := fmt.Sprintf("Replay of Events from %s to %s started", start.Format(time.RFC3339), end.Format(time.RFC3339))
+		response := commonDtos.NewBaseResponse(replayRequest.RequestId, message, http.StatusAccepted)
+		c.sendResponse(writer, request, internal.ApiReplayRoute, response, http.StatusAccepted)
+	}
+}
+
+func (request ReplayRequest) timeRange(now time.Time) (time.Time, time.Time, error) {
+	start, err := time.Parse(time.RFC3339, strings.TrimSpace(request.Start))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start must be an RFC3339 timestamp: %s", err.Error())
+	}
+
+	end := now
+	if strings.TrimSpace(request.End) != "" {
+		end, err = time.Parse(time.RFC3339, strings.TrimSpace(request.End))
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("end must be an RFC3339 timestamp: %s", err.Error())
+		}
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("start '%s' must be before end '%s'", request.Start, end.Format(time.RFC3339))
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type replayArgs struct {
+	start       time.Time
+	end         time.Time
+	deviceNames []string
+}
+
+func TestReplayRequest(t *testing.T) {
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+
+	tests := []struct {
+		Name               string
+		Body               string
+		ExpectedStatusCode int
+		ExpectedDevices    []string
+	}{
+		{"Valid", `{"deviceNames":["Thermostat","Fan"],"start":"2021-06-01T00:00:00Z","end":"2021-06-02T00:00:00Z"}`, http.StatusAccepted, []string{"Thermostat", "Fan"}},
+		{"Valid - all devices until now", `{"start":"2021-06-01T00:00:00Z"}`, http.StatusAccepted, nil},
+		{"Invalid - bad JSON", `{"start":`, http.StatusBadRequest, nil},
+		{"Invalid - missing start", `{"deviceNames":["Thermostat"]}`, http.StatusBadRequest, nil},
+		{"Invalid - bad end", `{"start":"2021-06-01T00:00:00Z","end":"tomorrow"}`, http.StatusBadRequest, nil},
+		{"Invalid - start after end", `{"start":"2021-06-02T00:00:00Z","end":"2021-06-01T00:00:00Z"}`, http.StatusBadRequest, nil},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.Name, func(t *testing.T) {
+			replayed := make(chan replayArgs, 1)
+			replayEvents := func(start time.Time, end time.Time, deviceNames ...string) (int, error) {
+				replayed <- replayArgs{start: start, end: end, deviceNames: deviceNames}
+				return 0, nil
+			}
+
+			target := NewController(nil, dic)
+			req, err := http.NewRequest(http.MethodPost, internal.ApiReplayRoute, strings.NewReader(testCase.Body))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			target.Replay(replayEvents, &sync.WaitGroup{}).ServeHTTP(recorder, req)
+
+			actualResponse := commonDtos.BaseResponse{}
+			err = json.Unmarshal(recorder.Body.Bytes(), &actualResponse)
+			require.NoError(t, err)
+
+			require.Equal(t, testCase.ExpectedStatusCode, recorder.Result().StatusCode, "HTTP status code not as expected")
+			assert.Equal(t, testCase.ExpectedStatusCode, actualResponse.StatusCode)
+			assert.NotEmpty(t, actualResponse.Message)
+			if testCase.ExpectedStatusCode != http.StatusAccepted {
+				assert.Empty(t, replayed, "replay should not be started for an invalid request")
+				return
+			}
+
+			select {
+			case actual := <-replayed:
+				assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), actual.start.UTC())
+				assert.True(t, actual.start.Before(actual.end))
+				assert.Equal(t, testCase.ExpectedDevices, actual.deviceNames)
+			case <-time.After(time.Second):
+				require.Fail(t, "replay not started")
+			}
+		})
+	}
+}
+
+func TestReplayAlreadyRunning(t *testing.T) {
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+
+	release := make(chan struct{})
+	replayEvents := func(start time.Time, end time.Time, deviceNames ...string) (int, error) {
+		<-release
+		return 0, nil
+	}
+
+	appWg := &sync.WaitGroup{}
+	handler := NewController(nil, dic).Replay(replayEvents, appWg)
+	body := `{"start":"2021-06-01T00:00:00Z"}`
+
+	replay := func() int {
+		req, err := http.NewRequest(http.MethodPost, internal.ApiReplayRoute, strings.NewReader(body))
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder.Result().StatusCode
+	}
+
+	require.Equal(t, http.StatusAccepted, replay())
+	assert.Equal(t, http.StatusConflict, replay(), "only one replay should run at a time")
+
+	close(release)
+	appWg.Wait()
+
+	assert.Equal(t, http.StatusAccepted, replay(), "replay should be allowed once the previous one completed")
+	appWg.Wait()
+}
@@ -0,0 +1,284 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devicegroups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	DefaultRefreshInterval = time.Minute
+
+	// DeviceNamePlaceholder is replaced by the name of each member in a group's SubscribeTopic
+	DeviceNamePlaceholder = "{device}"
+
+	// pageSize is the number of devices retrieved from Core Metadata per request
+	pageSize = 100
+)
+
+// Registry implements interfaces.DeviceGroups, keeping the members of each configured device group, which are
+// retrieved from Core Metadata every RefreshInterval
+type Registry struct {
+	lock     sync.RWMutex
+	interval time.Duration
+	groups   map[string]group
+	members  map[string][]string
+	topics   map[string]bool
+	watchers map[int]func(topics []string)
+	nextId   int
+	lc       logger.LoggingClient
+}
+
+type group struct {
+	labels         []string
+	subscribeTopic string
+}
+
+// NewRegistry creates, initializes and returns a new instance of Registry for the configuration
+func NewRegistry(config common.DeviceGroupsInfo, lc logger.LoggingClient) (*Registry, error) {
+	interval := DefaultRefreshInterval
+	if strings.TrimSpace(config.RefreshInterval) != "" {
+		var err error
+		interval, err = time.ParseDuration(strings.TrimSpace(config.RefreshInterval))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid DeviceGroups RefreshInterval '%s', must be a duration greater than 0", config.RefreshInterval)
+		}
+	}
+
+	registry := &Registry{
+		interval: interval,
+		groups:   make(map[string]group),
+		members:  make(map[string][]string),
+		topics:   make(map[string]bool),
+		watchers: make(map[int]func(topics []string)),
+		lc:       lc,
+	}
+
+	for name, groupConfig := range config.Groups {
+		labels := util.DeleteEmptyAndTrim(strings.FieldsFunc(groupConfig.Labels, util.SplitComma))
+		if len(labels) == 0 {
+			return nil, fmt.Errorf("DeviceGroups group '%s' has no Labels", name)
+		}
+
+		subscribeTopic := strings.TrimSpace(groupConfig.SubscribeTopic)
+		if subscribeTopic != "" && !strings.Contains(subscribeTopic, DeviceNamePlaceholder) {
+			return nil, fmt.Errorf("DeviceGroups group '%s' SubscribeTopic '%s' must contain '%s'", name, subscribeTopic, DeviceNamePlaceholder)
+		}
+
+		registry.groups[name] = group{labels: labels, subscribeTopic: subscribeTopic}
+	}
+
+	return registry, nil
+}
+
+// Start retrieves the members of the groups, and again every RefreshInterval until the context is cancelled.
+// The first retrieval completes before Start returns, so the members are known before the trigger starts.
+// The members retrieved previously are kept when a retrieval fails.
+func (registry *Registry) Start(wg *sync.WaitGroup, ctx context.Context, client interfaces.DeviceClient) {
+	if err := registry.Refresh(ctx, client); err != nil {
+		registry.lc.Errorf("Unable to retrieve the members of the device groups: %s", err.Error())
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(registry.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := registry.Refresh(ctx, client); err != nil {
+					registry.lc.Errorf("Unable to retrieve the members of the device groups: %s", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Refresh retrieves the members of each group from Core Metadata. A group whose members can't be retrieved keeps its
+// previous members. The watchers added by WatchTopics are called with the topics of the new members.
+func (registry *Registry) Refresh(ctx context.Context, client interfaces.DeviceClient) error {
+	if client == nil {
+		return errors.New("DeviceClient not initialized. Core Metadata is missing from clients configuration")
+	}
+
+	var failed []string
+	retrieved := make(map[string][]string)
+	for name, group := range registry.groups {
+		members, err := retrieveMembers(ctx, client, group.labels)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("group '%s': %s", name, err.Error()))
+			continue
+		}
+
+		retrieved[name] = members
+	}
+
+	var added []string
+	var watchers []func(topics []string)
+
+	registry.lock.Lock()
+	for name, members := range retrieved {
+		if !equal(registry.members[name], members) {
+			registry.lc.Infof("Device group '%s' has %d member(s): %s", name, len(members), strings.Join(members, ", "))
+		}
+		registry.members[name] = members
+
+		subscribeTopic := registry.groups[name].subscribeTopic
+		if subscribeTopic == "" {
+			continue
+		}
+
+		for _, deviceName := range members {
+			topic := strings.ReplaceAll(subscribeTopic, DeviceNamePlaceholder, deviceName)
+			if !registry.topics[topic] {
+				registry.topics[topic] = true
+				added = append(added, topic)
+			}
+		}
+	}
+
+	// The watchers are copied with the topics added so a watcher added concurrently receives each topic only once
+	for _, watcher := range registry.watchers {
+		watchers = append(watchers, watcher)
+	}
+	registry.lock.Unlock()
+
+	if len(added) > 0 {
+		sort.Strings(added)
+		for _, watcher := range watchers {
+			watcher(added)
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.New(strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// DeviceNames returns the names of the members of the group, ordered by name, and whether the group is configured
+func (registry *Registry) DeviceNames(groupName string) ([]string, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+
+	if _, found := registry.groups[groupName]; !found {
+		return nil, false
+	}
+
+	return registry.members[groupName], true
+}
+
+// WatchTopics returns the SubscribeTopics of the current members of the groups, ordered by topic, and adds the watcher
+// called with the SubscribeTopics of the members added later, i.e. to subscribe the trigger to them. The returned
+// function removes the watcher.
+func (registry *Registry) WatchTopics(watcher func(topics []string)) ([]string, func()) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+
+	topics := make([]string, 0, len(registry.topics))
+	for topic := range registry.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	registry.nextId++
+	id := registry.nextId
+	registry.watchers[id] = watcher
+
+	return topics, func() {
+		registry.lock.Lock()
+		defer registry.lock.Unlock()
+		delete(registry.watchers, id)
+	}
+}
+
+// retrieveMembers returns the names, ordered by name, of the devices with all the labels. Core Metadata returns the
+// devices with any of the labels, so the devices without all of them are removed.
+func retrieveMembers(ctx context.Context, client interfaces.DeviceClient, labels []string) ([]string, error) {
+	members := []string{}
+
+	for offset := 0; ; offset += pageSize {
+		response, err := client.AllDevices(ctx, labels, offset, pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, device := range response.Devices {
+			if hasAll(device.Labels, labels) {
+				members = append(members, device.Name)
+			}
+		}
+
+		if len(response.Devices) < pageSize {
+			break
+		}
+	}
+
+	sort.Strings(members)
+	return members, nil
+}
+
+func hasAll(deviceLabels []string, labels []string) bool {
+	for _, label := range labels {
+		found := false
+		for _, deviceLabel := range deviceLabels {
+			if deviceLabel == label {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equal(previous []string, current []string) bool {
+	if previous == nil || len(previous) != len(current) {
+		return false
+	}
+
+	for index := range previous {
+		if previous[index] != current[index] {
+			return false
+		}
+	}
+
+	return true
+}
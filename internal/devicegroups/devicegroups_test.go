@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devicegroups
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clientMocks "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
+	edgexErrors "github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+var lc = logger.NewMockClient()
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		Name             string
+		Config           common.DeviceGroupsInfo
+		ExpectedInterval time.Duration
+		ExpectError      bool
+	}{
+		{"Default interval", common.DeviceGroupsInfo{}, DefaultRefreshInterval, false},
+		{"Valid", common.DeviceGroupsInfo{RefreshInterval: " 30s ", Groups: map[string]common.DeviceGroupInfo{
+			"thermostats": {Labels: "thermostat, floor1", SubscribeTopic: "edgex/events/device/+/{device}/#"},
+		}}, 30 * time.Second, false},
+		{"Invalid interval", common.DeviceGroupsInfo{RefreshInterval: "often"}, 0, true},
+		{"Zero interval", common.DeviceGroupsInfo{RefreshInterval: "0s"}, 0, true},
+		{"No labels", common.DeviceGroupsInfo{Groups: map[string]common.DeviceGroupInfo{"thermostats": {Labels: " , "}}}, 0, true},
+		{"Topic without device", common.DeviceGroupsInfo{Groups: map[string]common.DeviceGroupInfo{
+			"thermostats": {Labels: "thermostat", SubscribeTopic: "edgex/events/#"},
+		}}, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			registry, err := NewRegistry(test.Config, lc)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedInterval, registry.interval)
+		})
+	}
+}
+
+func devicesResponse(devices ...dtos.Device) responses.MultiDevicesResponse {
+	return responses.MultiDevicesResponse{Devices: devices}
+}
+
+func TestRegistry_Refresh(t *testing.T) {
+	registry, err := NewRegistry(common.DeviceGroupsInfo{Groups: map[string]common.DeviceGroupInfo{
+		"thermostats": {Labels: "thermostat, floor1", SubscribeTopic: "edgex/events/device/+/{device}/#"},
+		"pumps":       {Labels: "pump"},
+	}}, lc)
+	require.NoError(t, err)
+
+	thermostat1 := dtos.Device{Name: "thermostat-1", Labels: []string{"floor1", "thermostat"}}
+	thermostat2 := dtos.Device{Name: "thermostat-2", Labels: []string{"thermostat", "floor2"}}
+	thermostat3 := dtos.Device{Name: "thermostat-3", Labels: []string{"thermostat", "floor1"}}
+
+	client := &clientMocks.DeviceClient{}
+	client.On("AllDevices", mock.Anything, []string{"thermostat", "floor1"}, 0, pageSize).
+		Return(devicesResponse(thermostat2, thermostat1), nil).Once()
+	client.On("AllDevices", mock.Anything, []string{"pump"}, 0, pageSize).
+		Return(responses.MultiDevicesResponse{}, edgexErrors.NewCommonEdgeX(edgexErrors.KindServerError, "unavailable", nil)).Once()
+
+	topics, stop := registry.WatchTopics(func(topics []string) {
+		t.Errorf("watcher should not be called before the members are retrieved, called with %v", topics)
+	})
+	assert.Empty(t, topics)
+	stop()
+
+	err = registry.Refresh(context.Background(), client)
+	require.Error(t, err, "error retrieving the pumps should be returned")
+	assert.Contains(t, err.Error(), "group 'pumps'")
+
+	members, found := registry.DeviceNames("thermostats")
+	require.True(t, found)
+	assert.Equal(t, []string{"thermostat-1"}, members, "devices without all the labels should not be members")
+
+	members, found = registry.DeviceNames("pumps")
+	assert.True(t, found)
+	assert.Empty(t, members)
+
+	_, found = registry.DeviceNames("valves")
+	assert.False(t, found)
+
+	var added []string
+	topics, stop = registry.WatchTopics(func(topics []string) {
+		added = append(added, topics...)
+	})
+	defer stop()
+	assert.Equal(t, []string{"edgex/events/device/+/thermostat-1/#"}, topics)
+
+	// thermostat-1 has been removed from the group, its topic remains subscribed
+	client.On("AllDevices", mock.Anything, []string{"thermostat", "floor1"}, 0, pageSize).
+		Return(devicesResponse(thermostat3), nil).Once()
+	client.On("AllDevices", mock.Anything, []string{"pump"}, 0, pageSize).
+		Return(devicesResponse(dtos.Device{Name: "pump-1", Labels: []string{"pump"}}), nil).Once()
+
+	require.NoError(t, registry.Refresh(context.Background(), client))
+
+	members, _ = registry.DeviceNames("thermostats")
+	assert.Equal(t, []string{"thermostat-3"}, members)
+	members, _ = registry.DeviceNames("pumps")
+	assert.Equal(t, []string{"pump-1"}, members)
+	assert.Equal(t, []string{"edgex/events/device/+/thermostat-3/#"}, added)
+
+	client.AssertExpectations(t)
+}
+
+func TestRegistry_RefreshPages(t *testing.T) {
+	registry, err := NewRegistry(common.DeviceGroupsInfo{Groups: map[string]common.DeviceGroupInfo{
+		"pumps": {Labels: "pump"},
+	}}, lc)
+	require.NoError(t, err)
+
+	var firstPage []dtos.Device
+	for i := 0; i < pageSize; i++ {
+		firstPage = append(firstPage, dtos.Device{Name: "pump", Labels: []string{"pump"}})
+	}
+
+	client := &clientMocks.DeviceClient{}
+	client.On("AllDevices", mock.Anything, []string{"pump"}, 0, pageSize).Return(devicesResponse(firstPage...), nil)
+	client.On("AllDevices", mock.Anything, []string{"pump"}, pageSize, pageSize).
+		Return(devicesResponse(dtos.Device{Name: "last-pump", Labels: []string{"pump"}}), nil)
+
+	require.NoError(t, registry.Refresh(context.Background(), client))
+
+	members, _ := registry.DeviceNames("pumps")
+	assert.Len(t, members, pageSize+1)
+	assert.Equal(t, "last-pump", members[0])
+}
+
+func TestRegistry_RefreshNoClient(t *testing.T) {
+	registry, err := NewRegistry(common.DeviceGroupsInfo{}, lc)
+	require.NoError(t, err)
+
+	err = registry.Refresh(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Core Metadata is missing")
+}
@@ -0,0 +1,192 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devicestate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+const (
+	// StateOnline is the state of a device seen within the offline threshold
+	StateOnline = "online"
+	// StateOffline is the state of a device silent for longer than the offline threshold
+	StateOffline = "offline"
+)
+
+// DeviceState is the state of a device and when it was last seen
+type DeviceState struct {
+	DeviceName string `json:"deviceName"`
+	State      string `json:"state"`
+	// LastSeen is the time, in nanoseconds since the epoch, an Event from the device was last processed. It is the
+	// time the service started for an expected device not yet seen.
+	LastSeen int64 `json:"lastSeen"`
+}
+
+// StateChange is emitted when a device goes offline or comes back online
+type StateChange struct {
+	DeviceState
+	// Timestamp is the time, in nanoseconds since the epoch, the change was detected
+	Timestamp int64 `json:"timestamp"`
+}
+
+type device struct {
+	lastSeen time.Time
+	offline  bool
+}
+
+// Monitor tracks when each device was last seen in the pipeline traffic and emits a StateChange when a device goes
+// silent for longer than the offline threshold, and again when it is seen after going offline.
+type Monitor struct {
+	lock      sync.Mutex
+	threshold time.Duration
+	emit      func(change StateChange)
+	lc        logger.LoggingClient
+	devices   map[string]*device
+}
+
+// NewMonitor creates, initializes and returns a new instance of Monitor for the configuration. emit is called, from
+// the go routine detecting it, with each change of state.
+func NewMonitor(config sdkCommon.DeviceMonitorInfo, emit func(change StateChange), lc logger.LoggingClient) (*Monitor, error) {
+	threshold := sdkCommon.DefaultOfflineThreshold
+	if strings.TrimSpace(config.OfflineThreshold) != "" {
+		var err error
+		threshold, err = time.ParseDuration(strings.TrimSpace(config.OfflineThreshold))
+		if err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid DeviceMonitor OfflineThreshold '%s', must be a duration greater than 0", config.OfflineThreshold)
+		}
+	}
+
+	monitor := &Monitor{
+		threshold: threshold,
+		emit:      emit,
+		lc:        lc,
+		devices:   make(map[string]*device),
+	}
+
+	now := time.Now()
+	for _, deviceName := range config.Devices {
+		if deviceName = strings.TrimSpace(deviceName); deviceName != "" {
+			monitor.devices[deviceName] = &device{lastSeen: now}
+		}
+	}
+
+	return monitor, nil
+}
+
+// Start checks for the devices gone offline every quarter of the offline threshold until the context is cancelled
+func (monitor *Monitor) Start(wg *sync.WaitGroup, ctx context.Context) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(monitor.threshold / 4)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				monitor.check(now)
+			}
+		}
+	}()
+}
+
+// Seen records that an Event from the device was processed, emitting a change of state if the device was offline
+func (monitor *Monitor) Seen(deviceName string, now time.Time) {
+	if deviceName == "" {
+		return
+	}
+
+	monitor.lock.Lock()
+	state, found := monitor.devices[deviceName]
+	if !found {
+		state = &device{}
+		monitor.devices[deviceName] = state
+	}
+	cameOnline := state.offline
+	state.lastSeen = now
+	state.offline = false
+	monitor.lock.Unlock()
+
+	if cameOnline {
+		monitor.lc.Infof("Device '%s' is online again", deviceName)
+		monitor.emit(StateChange{
+			DeviceState: DeviceState{DeviceName: deviceName, State: StateOnline, LastSeen: now.UnixNano()},
+			Timestamp:   now.UnixNano(),
+		})
+	}
+}
+
+// Devices returns the state of each device tracked, sorted by device name
+func (monitor *Monitor) Devices() []DeviceState {
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+
+	devices := make([]DeviceState, 0, len(monitor.devices))
+	for deviceName, state := range monitor.devices {
+		devices = append(devices, state.deviceState(deviceName))
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].DeviceName < devices[j].DeviceName
+	})
+
+	return devices
+}
+
+// check emits a change of state for each device that has gone silent for longer than the offline threshold
+func (monitor *Monitor) check(now time.Time) {
+	var changes []StateChange
+
+	monitor.lock.Lock()
+	for deviceName, state := range monitor.devices {
+		if !state.offline && now.Sub(state.lastSeen) > monitor.threshold {
+			state.offline = true
+			changes = append(changes, StateChange{DeviceState: state.deviceState(deviceName), Timestamp: now.UnixNano()})
+		}
+	}
+	monitor.lock.Unlock()
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].DeviceName < changes[j].DeviceName
+	})
+
+	for _, change := range changes {
+		monitor.lc.Warnf("Device '%s' is offline, last seen %s ago", change.DeviceName,
+			now.Sub(time.Unix(0, change.LastSeen)).Round(time.Second).String())
+		monitor.emit(change)
+	}
+}
+
+func (state *device) deviceState(deviceName string) DeviceState {
+	deviceState := DeviceState{DeviceName: deviceName, State: StateOnline, LastSeen: state.lastSeen.UnixNano()}
+	if state.offline {
+		deviceState.State = StateOffline
+	}
+	return deviceState
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package devicestate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+var lc = logger.NewMockClient()
+
+func TestNewMonitor(t *testing.T) {
+	emit := func(StateChange) {}
+
+	monitor, err := NewMonitor(common.DeviceMonitorInfo{}, emit, lc)
+	require.NoError(t, err)
+	assert.Equal(t, common.DefaultOfflineThreshold, monitor.threshold)
+	assert.Empty(t, monitor.Devices())
+
+	monitor, err = NewMonitor(common.DeviceMonitorInfo{OfflineThreshold: " 30s ", Devices: []string{"pump-1", " "}}, emit, lc)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, monitor.threshold)
+	require.Len(t, monitor.Devices(), 1, "expected devices should be tracked from the start")
+	assert.Equal(t, "pump-1", monitor.Devices()[0].DeviceName)
+	assert.Equal(t, StateOnline, monitor.Devices()[0].State)
+
+	_, err = NewMonitor(common.DeviceMonitorInfo{OfflineThreshold: "soon"}, emit, lc)
+	assert.Error(t, err)
+
+	_, err = NewMonitor(common.DeviceMonitorInfo{OfflineThreshold: "0s"}, emit, lc)
+	assert.Error(t, err)
+}
+
+func TestMonitor_OfflineAndOnline(t *testing.T) {
+	var changes []StateChange
+	monitor, err := NewMonitor(common.DeviceMonitorInfo{OfflineThreshold: "1m"}, func(change StateChange) {
+		changes = append(changes, change)
+	}, lc)
+	require.NoError(t, err)
+
+	start := time.Now()
+	monitor.Seen("pump-1", start)
+	monitor.Seen("pump-2", start.Add(30*time.Second))
+	monitor.Seen("", start)
+	assert.Empty(t, changes, "devices seen for the first time should not emit a change")
+
+	monitor.check(start.Add(time.Minute))
+	assert.Empty(t, changes, "devices seen within the threshold should not go offline")
+
+	monitor.check(start.Add(61 * time.Second))
+	require.Len(t, changes, 1)
+	assert.Equal(t, "pump-1", changes[0].DeviceName)
+	assert.Equal(t, StateOffline, changes[0].State)
+	assert.Equal(t, start.UnixNano(), changes[0].LastSeen)
+	assert.Equal(t, start.Add(61*time.Second).UnixNano(), changes[0].Timestamp)
+
+	monitor.check(start.Add(62 * time.Second))
+	assert.Len(t, changes, 1, "offline devices should only emit a change once")
+
+	devices := monitor.Devices()
+	require.Len(t, devices, 2)
+	assert.Equal(t, DeviceState{DeviceName: "pump-1", State: StateOffline, LastSeen: start.UnixNano()}, devices[0])
+	assert.Equal(t, StateOnline, devices[1].State)
+
+	seen := start.Add(2 * time.Minute)
+	monitor.Seen("pump-1", seen)
+	require.Len(t, changes, 2)
+	assert.Equal(t, StateChange{
+		DeviceState: DeviceState{DeviceName: "pump-1", State: StateOnline, LastSeen: seen.UnixNano()},
+		Timestamp:   seen.UnixNano(),
+	}, changes[1])
+
+	monitor.Seen("pump-1", seen.Add(time.Second))
+	assert.Len(t, changes, 2, "online devices should not emit a change when seen")
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package discovery
+
+import (
+	"fmt"
+	"strings"
+
+	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-registry/v2/registry"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+const (
+	// TypeStatic uses the host and port of the Clients configuration
+	TypeStatic = "static"
+	// TypeRegistry looks up the services registered with the Registry, i.e. Consul
+	TypeRegistry = "registry"
+	// TypeKubernetes uses the DNS names Kubernetes gives the services
+	TypeKubernetes = "kubernetes"
+
+	DefaultClusterDomain = "cluster.local"
+)
+
+// Discovery resolves the URLs of the EdgeX services the SDK's clients call, so the clients work whether the services
+// are registered with the Registry or deployed to Kubernetes without it. The service itself is registered, and its
+// health checked on the ping route, by the Registry when the service is started with it, i.e. with -r, otherwise by
+// the orchestrator, i.e. Kubernetes liveness and readiness probes.
+type Discovery interface {
+	// Type returns TypeStatic, TypeRegistry or TypeKubernetes
+	Type() string
+	// ServiceUrl returns the base URL of the EdgeX service with the key, i.e. core-data, whose client is configured
+	// with the client info
+	ServiceUrl(serviceKey string, client bootstrapConfig.ClientInfo) string
+}
+
+// NewDiscovery creates and returns the Discovery for the configuration. The registry client is nil when the service
+// wasn't started with the Registry, which TypeRegistry requires.
+func NewDiscovery(config common.DiscoveryInfo, registryClient registry.Client, lc logger.LoggingClient) (Discovery, error) {
+	switch strings.ToLower(strings.TrimSpace(config.Type)) {
+	case "", TypeStatic:
+		return staticDiscovery{}, nil
+
+	case TypeRegistry:
+		if registryClient == nil {
+			return nil, fmt.Errorf("unable to use Discovery Type '%s' without the Registry, start the service with -r", TypeRegistry)
+		}
+		return &registryDiscovery{client: registryClient, lc: lc}, nil
+
+	case TypeKubernetes:
+		clusterDomain := strings.TrimSpace(config.ClusterDomain)
+		if clusterDomain == "" {
+			clusterDomain = DefaultClusterDomain
+		}
+		return &kubernetesDiscovery{
+			prefix:        strings.TrimSpace(config.ServiceNamePrefix),
+			namespace:     strings.TrimSpace(config.Namespace),
+			clusterDomain: clusterDomain,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid Discovery Type '%s', must be '%s', '%s' or '%s'",
+			config.Type, TypeStatic, TypeRegistry, TypeKubernetes)
+	}
+}
+
+type staticDiscovery struct{}
+
+func (staticDiscovery) Type() string {
+	return TypeStatic
+}
+
+func (staticDiscovery) ServiceUrl(_ string, client bootstrapConfig.ClientInfo) string {
+	return client.Url()
+}
+
+// registryDiscovery looks up the endpoint each service registered with the Registry, falling back to the Clients
+// configuration when the service isn't registered yet
+type registryDiscovery struct {
+	client registry.Client
+	lc     logger.LoggingClient
+}
+
+func (discovery *registryDiscovery) Type() string {
+	return TypeRegistry
+}
+
+func (discovery *registryDiscovery) ServiceUrl(serviceKey string, client bootstrapConfig.ClientInfo) string {
+	endpoint, err := discovery.client.GetServiceEndpoint(serviceKey)
+	if err != nil {
+		discovery.lc.Warnf("Unable to find '%s' in the Registry, using the Clients configuration: %s", serviceKey, err.Error())
+		return client.Url()
+	}
+
+	return fmt.Sprintf("%s://%s:%d", client.Protocol, endpoint.Host, endpoint.Port)
+}
+
+// kubernetesDiscovery uses the DNS names of the Kubernetes services, which are named after the service keys with the
+// prefix, i.e. edgex-core-data, with the ports of the Clients configuration. The services are looked up in the pod's
+// namespace unless a namespace is configured.
+type kubernetesDiscovery struct {
+	prefix        string
+	namespace     string
+	clusterDomain string
+}
+
+func (discovery *kubernetesDiscovery) Type() string {
+	return TypeKubernetes
+}
+
+func (discovery *kubernetesDiscovery) ServiceUrl(serviceKey string, client bootstrapConfig.ClientInfo) string {
+	host := discovery.prefix + serviceKey
+	if discovery.namespace != "" {
+		host = fmt.Sprintf("%s.%s.svc.%s", host, discovery.namespace, discovery.clusterDomain)
+	}
+
+	return fmt.Sprintf("%s://%s:%d", client.Protocol, host, client.Port)
+}
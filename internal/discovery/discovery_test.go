@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package discovery
+
+import (
+	"errors"
+	"testing"
+
+	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-registry/v2/pkg/types"
+	"github.com/edgexfoundry/go-mod-registry/v2/registry"
+	registryMocks "github.com/edgexfoundry/go-mod-registry/v2/registry/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+var metadataClient = bootstrapConfig.ClientInfo{Host: "localhost", Port: 59881, Protocol: "http"}
+
+func TestNewDiscovery(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Config       common.DiscoveryInfo
+		UseRegistry  bool
+		ExpectedType string
+		ExpectError  bool
+	}{
+		{"Default", common.DiscoveryInfo{}, false, TypeStatic, false},
+		{"Registry", common.DiscoveryInfo{Type: "Registry"}, true, TypeRegistry, false},
+		{"Registry not started", common.DiscoveryInfo{Type: TypeRegistry}, false, "", true},
+		{"Kubernetes", common.DiscoveryInfo{Type: TypeKubernetes}, false, TypeKubernetes, false},
+		{"Invalid", common.DiscoveryInfo{Type: "mdns"}, false, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var registryClient registry.Client
+			if test.UseRegistry {
+				registryClient = &registryMocks.Client{}
+			}
+
+			discovery, err := NewDiscovery(test.Config, registryClient, logger.NewMockClient())
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedType, discovery.Type())
+		})
+	}
+}
+
+func TestDiscovery_ServiceUrl(t *testing.T) {
+	static, err := NewDiscovery(common.DiscoveryInfo{Type: TypeStatic}, nil, logger.NewMockClient())
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:59881", static.ServiceUrl("core-metadata", metadataClient))
+
+	kubernetes, err := NewDiscovery(common.DiscoveryInfo{Type: TypeKubernetes, ServiceNamePrefix: "edgex-"}, nil, logger.NewMockClient())
+	require.NoError(t, err)
+	assert.Equal(t, "http://edgex-core-metadata:59881", kubernetes.ServiceUrl("core-metadata", metadataClient))
+
+	namespaced, err := NewDiscovery(common.DiscoveryInfo{Type: TypeKubernetes, Namespace: "edgex"}, nil, logger.NewMockClient())
+	require.NoError(t, err)
+	assert.Equal(t, "http://core-metadata.edgex.svc.cluster.local:59881", namespaced.ServiceUrl("core-metadata", metadataClient))
+}
+
+func TestRegistryDiscovery_ServiceUrl(t *testing.T) {
+	registryClient := &registryMocks.Client{}
+	registryClient.On("GetServiceEndpoint", "core-metadata").Return(types.ServiceEndpoint{Host: "10.0.0.5", Port: 59881}, nil)
+	registryClient.On("GetServiceEndpoint", "core-command").Return(types.ServiceEndpoint{}, errors.New("not registered"))
+
+	discovery, err := NewDiscovery(common.DiscoveryInfo{Type: TypeRegistry}, registryClient, logger.NewMockClient())
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://10.0.0.5:59881", discovery.ServiceUrl("core-metadata", metadataClient))
+	assert.Equal(t, "http://localhost:59882", discovery.ServiceUrl("core-command",
+		bootstrapConfig.ClientInfo{Host: "localhost", Port: 59882, Protocol: "http"}), "configuration should be used when not registered")
+}
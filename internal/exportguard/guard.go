@@ -0,0 +1,70 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package exportguard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db/interfaces"
+)
+
+const DefaultWindow = time.Hour
+
+// Guard saves the idempotency keys of the data exported in the store database for the window, so the export functions
+// can skip data already exported when it is redelivered or retried. The keys are saved per app service so services
+// sharing the database don't suppress each other's exports.
+type Guard struct {
+	storeClient   interfaces.StoreClient
+	appServiceKey string
+	window        time.Duration
+}
+
+// NewGuard creates, initializes and returns a new instance of Guard using the store client
+func NewGuard(config common.ExportGuardInfo, storeClient interfaces.StoreClient, appServiceKey string) (*Guard, error) {
+	if storeClient == nil {
+		return nil, errors.New("ExportGuard requires the store Database, which failed to initialize")
+	}
+
+	window := DefaultWindow
+	if strings.TrimSpace(config.Window) != "" {
+		var err error
+		window, err = time.ParseDuration(strings.TrimSpace(config.Window))
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid ExportGuard Window '%s', must be a duration greater than 0", config.Window)
+		}
+	}
+
+	return &Guard{
+		storeClient:   storeClient,
+		appServiceKey: appServiceKey,
+		window:        window,
+	}, nil
+}
+
+// Exported returns whether data with the idempotency key was exported within the window
+func (guard *Guard) Exported(key string) (bool, error) {
+	return guard.storeClient.ExportKeyExists(guard.appServiceKey, key)
+}
+
+// MarkExported saves the idempotency key of data that has been exported for the window
+func (guard *Guard) MarkExported(key string) error {
+	return guard.storeClient.StoreExportKey(guard.appServiceKey, key, guard.window)
+}
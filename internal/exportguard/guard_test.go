@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package exportguard
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db/interfaces/mocks"
+)
+
+func TestNewGuard(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Window         string
+		ExpectedWindow time.Duration
+		ExpectError    bool
+	}{
+		{"Default", "", DefaultWindow, false},
+		{"Valid", " 24h ", 24 * time.Hour, false},
+		{"Invalid", "a day", 0, true},
+		{"Zero", "0s", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			guard, err := NewGuard(common.ExportGuardInfo{Enabled: true, Window: test.Window}, &mocks.StoreClient{}, "app")
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedWindow, guard.window)
+		})
+	}
+
+	_, err := NewGuard(common.ExportGuardInfo{Enabled: true}, nil, "app")
+	require.Error(t, err, "store client should be required")
+}
+
+func TestGuard(t *testing.T) {
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("ExportKeyExists", "app", "exported").Return(true, nil)
+	storeClient.On("ExportKeyExists", "app", "new").Return(false, nil)
+	storeClient.On("ExportKeyExists", "app", "unavailable").Return(false, errors.New("connection refused"))
+	storeClient.On("StoreExportKey", "app", "new", 10*time.Minute).Return(nil)
+
+	guard, err := NewGuard(common.ExportGuardInfo{Enabled: true, Window: "10m"}, storeClient, "app")
+	require.NoError(t, err)
+
+	exported, err := guard.Exported("exported")
+	require.NoError(t, err)
+	assert.True(t, exported)
+
+	exported, err = guard.Exported("new")
+	require.NoError(t, err)
+	assert.False(t, exported)
+
+	_, err = guard.Exported("unavailable")
+	require.Error(t, err)
+
+	require.NoError(t, guard.MarkExported("new"))
+	storeClient.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package hashchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db/interfaces"
+	sdkInterfaces "github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// Chain links the records exported into hash chains whose heads are saved in the store database, so the chains
+// continue across restarts. The heads are saved per app service, and the records of a chain are linked one at a time,
+// so only one instance of the app service may link records into the same chains.
+type Chain struct {
+	storeClient   interfaces.StoreClient
+	appServiceKey string
+	lock          sync.Mutex
+}
+
+// NewChain creates, initializes and returns a new instance of Chain using the store client
+func NewChain(storeClient interfaces.StoreClient, appServiceKey string) (*Chain, error) {
+	if storeClient == nil {
+		return nil, errors.New("HashChain requires the store Database, which failed to initialize")
+	}
+
+	return &Chain{
+		storeClient:   storeClient,
+		appServiceKey: appServiceKey,
+	}, nil
+}
+
+// Link appends the record with the payload hash to the chain with the key, saves the record's link as the chain's new
+// head and returns it. The chain isn't changed if the new head can't be saved.
+func (chain *Chain) Link(chainKey string, payloadHash string) (sdkInterfaces.ChainLink, error) {
+	chain.lock.Lock()
+	defer chain.lock.Unlock()
+
+	var previous sdkInterfaces.ChainLink
+	head, err := chain.storeClient.RetrieveChainHead(chain.appServiceKey, chainKey)
+	if err != nil {
+		return previous, fmt.Errorf("unable to retrieve head of chain '%s': %s", chainKey, err.Error())
+	}
+
+	if head != "" {
+		if err := json.Unmarshal([]byte(head), &previous); err != nil {
+			return previous, fmt.Errorf("unable to unmarshal head of chain '%s': %s", chainKey, err.Error())
+		}
+	}
+
+	link := sdkInterfaces.ChainLink{
+		Sequence:     previous.Sequence + 1,
+		PreviousHash: previous.Hash,
+		PayloadHash:  payloadHash,
+	}
+	link.Hash = LinkHash(link.Sequence, link.PreviousHash, link.PayloadHash)
+
+	// Can't fail as ChainLink only has simple fields
+	encoded, _ := json.Marshal(link)
+	if err := chain.storeClient.StoreChainHead(chain.appServiceKey, chainKey, string(encoded)); err != nil {
+		return sdkInterfaces.ChainLink{}, fmt.Errorf("unable to save head of chain '%s': %s", chainKey, err.Error())
+	}
+
+	return link, nil
+}
+
+// LinkHash returns the hex encoded SHA-256 digest of the link's sequence, previous hash and payload hash, separated
+// by colons, i.e. "2:<previous hash>:<payload hash>"
+func LinkHash(sequence uint64, previousHash string, payloadHash string) string {
+	digest := sha256.Sum256([]byte(strconv.FormatUint(sequence, 10) + ":" + previousHash + ":" + payloadHash))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package hashchain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db/interfaces/mocks"
+)
+
+func TestNewChain(t *testing.T) {
+	_, err := NewChain(nil, "app")
+	require.Error(t, err, "store client should be required")
+
+	_, err = NewChain(&mocks.StoreClient{}, "app")
+	require.NoError(t, err)
+}
+
+func TestChain_Link(t *testing.T) {
+	heads := make(map[string]string)
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("RetrieveChainHead", "app", "unavailable").Return("", errors.New("connection refused"))
+	storeClient.On("RetrieveChainHead", "app", mock.Anything).Return(
+		func(_ string, chainKey string) string { return heads[chainKey] }, nil)
+	storeClient.On("StoreChainHead", "app", mock.Anything, mock.Anything).Return(
+		func(_ string, chainKey string, head string) error {
+			heads[chainKey] = head
+			return nil
+		})
+
+	chain, err := NewChain(storeClient, "app")
+	require.NoError(t, err)
+
+	first, err := chain.Link("device-1", "payload-1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), first.Sequence)
+	assert.Empty(t, first.PreviousHash)
+	assert.Equal(t, "payload-1", first.PayloadHash)
+	assert.Equal(t, LinkHash(1, "", "payload-1"), first.Hash)
+
+	second, err := chain.Link("device-1", "payload-2")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), second.Sequence)
+	assert.Equal(t, first.Hash, second.PreviousHash)
+	assert.Equal(t, LinkHash(2, first.Hash, "payload-2"), second.Hash)
+
+	other, err := chain.Link("device-2", "payload-1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), other.Sequence, "each chain key should have its own chain")
+
+	_, err = chain.Link("unavailable", "payload-1")
+	require.Error(t, err)
+}
+
+func TestChain_LinkNotSaved(t *testing.T) {
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("RetrieveChainHead", "app", "device-1").Return(`{"sequence":4,"hash":"abc"}`, nil)
+	storeClient.On("StoreChainHead", "app", "device-1", mock.Anything).Return(errors.New("connection refused"))
+
+	chain, err := NewChain(storeClient, "app")
+	require.NoError(t, err)
+
+	_, err = chain.Link("device-1", "payload")
+	require.Error(t, err)
+
+	storeClient = &mocks.StoreClient{}
+	storeClient.On("RetrieveChainHead", "app", "device-1").Return("not json", nil)
+	chain, err = NewChain(storeClient, "app")
+	require.NoError(t, err)
+
+	_, err = chain.Link("device-1", "payload")
+	require.Error(t, err)
+}
+
+func TestLinkHash(t *testing.T) {
+	// sha256("1::abc")
+	assert.Equal(t, "038541a34978a5eaee7fccbec57b786ae450fafa97fdb9fa8c3849953839b0a8", LinkHash(1, "", "abc"))
+	assert.NotEqual(t, LinkHash(1, "", "abc"), LinkHash(2, "", "abc"))
+	assert.NotEqual(t, LinkHash(1, "", "abc"), LinkHash(1, "def", "abc"))
+}
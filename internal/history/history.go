@@ -0,0 +1,251 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+// PipelineRollup contains the counts of a pipeline's executions during an hour
+type PipelineRollup struct {
+	PipelineId string `json:"pipelineId"`
+	Executions uint64 `json:"executions"`
+	Succeeded  uint64 `json:"succeeded"`
+	Failed     uint64 `json:"failed"`
+	// SuccessRate is the fraction, from 0 to 1, of the executions that didn't fail. Pipelines end with the function
+	// exporting the data, so this is the success rate of the pipeline's exports.
+	SuccessRate float64 `json:"successRate"`
+}
+
+// Rollup contains the counts of the executions of each pipeline during an hour
+type Rollup struct {
+	// Start is the start of the hour, in nanoseconds since the epoch
+	Start     int64            `json:"start"`
+	Pipelines []PipelineRollup `json:"pipelines"`
+}
+
+// History keeps the hourly rollups of the pipeline executions for the retention period, oldest first, so the
+// behavior of a service can be reviewed without monitoring infrastructure. When a file is configured the rollups are
+// saved to it whenever an hour completes and when the history is closed, so they are kept across restarts.
+type History struct {
+	lock     sync.Mutex
+	capacity int
+	file     string
+	lc       logger.LoggingClient
+	rollups  []*hourRollup
+}
+
+type hourRollup struct {
+	start     time.Time
+	pipelines map[string]*PipelineRollup
+}
+
+// NewHistory creates, initializes and returns a new instance of History for the configuration, loading the rollups
+// previously saved to the configured file, if any
+func NewHistory(config sdkCommon.MetricsHistoryInfo, lc logger.LoggingClient) (*History, error) {
+	retention := sdkCommon.DefaultMetricsRetention
+	if strings.TrimSpace(config.Retention) != "" {
+		var err error
+		retention, err = time.ParseDuration(strings.TrimSpace(config.Retention))
+		if err != nil || retention < time.Hour {
+			return nil, fmt.Errorf("invalid MetricsHistory Retention '%s', must be a duration of at least 1h", config.Retention)
+		}
+	}
+
+	history := &History{
+		capacity: int(retention / time.Hour),
+		file:     strings.TrimSpace(config.File),
+		lc:       lc,
+	}
+
+	if err := history.load(); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Record counts an execution of the pipeline that completed now, failed when it stopped with an error
+func (history *History) Record(pipelineId string, failed bool) {
+	history.record(pipelineId, failed, time.Now())
+}
+
+func (history *History) record(pipelineId string, failed bool, now time.Time) {
+	history.lock.Lock()
+	defer history.lock.Unlock()
+
+	start := now.Truncate(time.Hour)
+	var current *hourRollup
+	if count := len(history.rollups); count > 0 && !history.rollups[count-1].start.Before(start) {
+		current = history.rollups[count-1]
+	} else {
+		if count > 0 {
+			// The previous hour is complete
+			history.save()
+		}
+
+		current = &hourRollup{start: start, pipelines: make(map[string]*PipelineRollup)}
+		history.rollups = append(history.rollups, current)
+		history.expire(start)
+	}
+
+	pipeline, found := current.pipelines[pipelineId]
+	if !found {
+		pipeline = &PipelineRollup{PipelineId: pipelineId}
+		current.pipelines[pipelineId] = pipeline
+	}
+
+	pipeline.Executions++
+	if failed {
+		pipeline.Failed++
+	} else {
+		pipeline.Succeeded++
+	}
+}
+
+// expire removes the rollups of the hours before the retention period ending with the hour starting at the start
+func (history *History) expire(start time.Time) {
+	oldest := start.Add(-time.Duration(history.capacity-1) * time.Hour)
+
+	expired := 0
+	for expired < len(history.rollups) && history.rollups[expired].start.Before(oldest) {
+		expired++
+	}
+
+	history.rollups = history.rollups[expired:]
+}
+
+// Rollups returns the hourly rollups in the retention period, oldest first, including the hour in progress. The
+// pipelines of each rollup are sorted by their id.
+func (history *History) Rollups() []Rollup {
+	history.lock.Lock()
+	defer history.lock.Unlock()
+
+	return history.snapshot()
+}
+
+func (history *History) snapshot() []Rollup {
+	rollups := make([]Rollup, 0, len(history.rollups))
+	for _, hour := range history.rollups {
+		rollup := Rollup{
+			Start:     hour.start.UnixNano(),
+			Pipelines: make([]PipelineRollup, 0, len(hour.pipelines)),
+		}
+
+		for _, pipeline := range hour.pipelines {
+			copied := *pipeline
+			if copied.Executions > 0 {
+				copied.SuccessRate = float64(copied.Succeeded) / float64(copied.Executions)
+			}
+			rollup.Pipelines = append(rollup.Pipelines, copied)
+		}
+
+		sort.Slice(rollup.Pipelines, func(i, j int) bool {
+			return rollup.Pipelines[i].PipelineId < rollup.Pipelines[j].PipelineId
+		})
+
+		rollups = append(rollups, rollup)
+	}
+
+	return rollups
+}
+
+// Close saves the rollups, including the hour in progress, to the configured file
+func (history *History) Close() {
+	history.lock.Lock()
+	defer history.lock.Unlock()
+
+	history.save()
+}
+
+// save writes the rollups to a temporary file renamed to the configured file, so a crash while saving doesn't lose
+// the rollups saved before. Errors are logged as the history is only informational.
+func (history *History) save() {
+	if history.file == "" {
+		return
+	}
+
+	data, err := json.Marshal(history.snapshot())
+	if err != nil {
+		history.lc.Errorf("Unable to marshal the metrics history: %s", err.Error())
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(history.file), 0700); err != nil {
+		history.lc.Errorf("Unable to create the directory of the metrics history file '%s': %s", history.file, err.Error())
+		return
+	}
+
+	temporary := history.file + ".tmp"
+	if err := ioutil.WriteFile(temporary, data, 0600); err != nil {
+		history.lc.Errorf("Unable to save the metrics history to '%s': %s", temporary, err.Error())
+		return
+	}
+
+	if err := os.Rename(temporary, history.file); err != nil {
+		history.lc.Errorf("Unable to replace the metrics history file '%s': %s", history.file, err.Error())
+	}
+}
+
+// load reads the rollups saved to the configured file, keeping those still in the retention period
+func (history *History) load() error {
+	if history.file == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(history.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to read the metrics history file '%s': %s", history.file, err.Error())
+	}
+
+	var saved []Rollup
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("unable to parse the metrics history file '%s': %s", history.file, err.Error())
+	}
+
+	for _, rollup := range saved {
+		hour := &hourRollup{
+			start:     time.Unix(0, rollup.Start),
+			pipelines: make(map[string]*PipelineRollup, len(rollup.Pipelines)),
+		}
+
+		for index := range rollup.Pipelines {
+			pipeline := rollup.Pipelines[index]
+			hour.pipelines[pipeline.PipelineId] = &pipeline
+		}
+
+		history.rollups = append(history.rollups, hour)
+	}
+
+	history.expire(time.Now().Truncate(time.Hour))
+	return nil
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+var lc = logger.NewMockClient()
+
+func TestNewHistory(t *testing.T) {
+	history, err := NewHistory(common.MetricsHistoryInfo{}, lc)
+	require.NoError(t, err)
+	assert.Equal(t, 168, history.capacity)
+
+	history, err = NewHistory(common.MetricsHistoryInfo{Retention: " 24h "}, lc)
+	require.NoError(t, err)
+	assert.Equal(t, 24, history.capacity)
+
+	_, err = NewHistory(common.MetricsHistoryInfo{Retention: "30m"}, lc)
+	assert.Error(t, err)
+
+	_, err = NewHistory(common.MetricsHistoryInfo{Retention: "a week"}, lc)
+	assert.Error(t, err)
+}
+
+func TestHistory_Rollups(t *testing.T) {
+	history, err := NewHistory(common.MetricsHistoryInfo{Retention: "2h"}, lc)
+	require.NoError(t, err)
+
+	hour := time.Now().Truncate(time.Hour).Add(-5 * time.Hour)
+
+	history.record("export", false, hour.Add(10*time.Minute))
+	history.record("export", false, hour.Add(20*time.Minute))
+	history.record("export", true, hour.Add(30*time.Minute))
+	history.record("alerts", false, hour.Add(40*time.Minute))
+
+	rollups := history.Rollups()
+	require.Len(t, rollups, 1)
+	assert.Equal(t, hour.UnixNano(), rollups[0].Start)
+	require.Len(t, rollups[0].Pipelines, 2)
+	assert.Equal(t, "alerts", rollups[0].Pipelines[0].PipelineId, "pipelines should be sorted by id")
+	assert.Equal(t, PipelineRollup{PipelineId: "export", Executions: 3, Succeeded: 2, Failed: 1, SuccessRate: 2.0 / 3.0},
+		rollups[0].Pipelines[1])
+
+	history.record("export", false, hour.Add(time.Hour))
+	history.record("export", true, hour.Add(2*time.Hour))
+
+	rollups = history.Rollups()
+	require.Len(t, rollups, 2, "rollups older than the retention period should be removed")
+	assert.Equal(t, hour.Add(time.Hour).UnixNano(), rollups[0].Start)
+	assert.Equal(t, hour.Add(2*time.Hour).UnixNano(), rollups[1].Start)
+	assert.Equal(t, float64(0), rollups[1].Pipelines[0].SuccessRate)
+}
+
+func TestHistory_File(t *testing.T) {
+	config := common.MetricsHistoryInfo{File: filepath.Join(t.TempDir(), "history", "rollups.json")}
+
+	history, err := NewHistory(config, lc)
+	require.NoError(t, err)
+
+	hour := time.Now().Truncate(time.Hour)
+	history.record("export", false, hour.Add(-200*time.Hour))
+	history.record("export", true, hour.Add(-time.Hour))
+	history.Record("export", false)
+	history.Close()
+
+	reloaded, err := NewHistory(config, lc)
+	require.NoError(t, err)
+
+	rollups := reloaded.Rollups()
+	require.Len(t, rollups, 2, "rollups saved that are no longer in the retention period should not be loaded")
+	assert.Equal(t, uint64(1), rollups[0].Pipelines[0].Failed)
+	assert.Equal(t, uint64(1), rollups[1].Pipelines[0].Succeeded)
+
+	reloaded.Record("export", false)
+	rollups = reloaded.Rollups()
+	require.Len(t, rollups, 2)
+	assert.Equal(t, uint64(2), rollups[1].Pipelines[0].Succeeded, "the hour in progress should continue once loaded")
+}
@@ -0,0 +1,208 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package receipts
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+const (
+	DefaultAckTimeout = time.Minute
+	DefaultMaxPending = 10000
+)
+
+// DestinationStats contains the counts of the deliveries to a destination
+type DestinationStats struct {
+	Destination  string `json:"destination"`
+	Exported     uint64 `json:"exported"`
+	Acknowledged uint64 `json:"acknowledged"`
+	Failed       uint64 `json:"failed"`
+	// Unacknowledged is the number of deliveries tracked that haven't been acknowledged or failed
+	Unacknowledged uint64 `json:"unacknowledged"`
+	// Overdue is the number of the unacknowledged deliveries exported longer than the AckTimeout ago
+	Overdue uint64 `json:"overdue"`
+	// Evicted is the number of unacknowledged deliveries no longer tracked as MaxPending was exceeded
+	Evicted uint64 `json:"evicted"`
+	// LastReceipt is the receipt of the delivery acknowledged last, i.e. the latest Kafka offset
+	LastReceipt string `json:"lastReceipt,omitempty"`
+	// LastError is the error of the delivery that failed last
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Delivery is an unacknowledged delivery of exported data
+type Delivery struct {
+	Id            string `json:"id"`
+	Destination   string `json:"destination"`
+	CorrelationId string `json:"correlationId"`
+	// Exported is the time, in nanoseconds since the epoch, the data was exported
+	Exported int64 `json:"exported"`
+}
+
+// Tracker implements interfaces.DeliveryReceiptTracker, keeping the unacknowledged deliveries in the order exported
+// and the counts of the deliveries to each destination
+type Tracker struct {
+	lock       sync.Mutex
+	ackTimeout time.Duration
+	maxPending int
+	order      *list.List
+	pending    map[string]*list.Element
+	stats      map[string]*DestinationStats
+}
+
+// NewTracker creates, initializes and returns a new instance of Tracker for the configuration
+func NewTracker(config common.DeliveryReceiptsInfo) (*Tracker, error) {
+	ackTimeout := DefaultAckTimeout
+	if strings.TrimSpace(config.AckTimeout) != "" {
+		var err error
+		ackTimeout, err = time.ParseDuration(strings.TrimSpace(config.AckTimeout))
+		if err != nil || ackTimeout <= 0 {
+			return nil, fmt.Errorf("invalid DeliveryReceipts AckTimeout '%s', must be a duration greater than 0", config.AckTimeout)
+		}
+	}
+
+	maxPending := config.MaxPending
+	if maxPending <= 0 {
+		maxPending = DefaultMaxPending
+	}
+
+	return &Tracker{
+		ackTimeout: ackTimeout,
+		maxPending: maxPending,
+		order:      list.New(),
+		pending:    make(map[string]*list.Element),
+		stats:      make(map[string]*DestinationStats),
+	}, nil
+}
+
+// Record records that a payload is being exported to the destination and returns the id of the delivery
+func (tracker *Tracker) Record(destination string, correlationId string) string {
+	delivery := &Delivery{
+		Id:            uuid.NewString(),
+		Destination:   destination,
+		CorrelationId: correlationId,
+		Exported:      time.Now().UnixNano(),
+	}
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	stats := tracker.destinationStats(destination)
+	stats.Exported++
+	stats.Unacknowledged++
+
+	tracker.pending[delivery.Id] = tracker.order.PushBack(delivery)
+
+	for tracker.order.Len() > tracker.maxPending {
+		oldest := tracker.remove(tracker.order.Front())
+		tracker.stats[oldest.Destination].Evicted++
+	}
+
+	return delivery.Id
+}
+
+// Acknowledge reconciles the destination's acknowledgement of the delivery. Acknowledgements of deliveries no longer
+// tracked are ignored.
+func (tracker *Tracker) Acknowledge(deliveryId string, receipt string) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	element, found := tracker.pending[deliveryId]
+	if !found {
+		return
+	}
+
+	delivery := tracker.remove(element)
+	stats := tracker.stats[delivery.Destination]
+	stats.Acknowledged++
+	if receipt != "" {
+		stats.LastReceipt = receipt
+	}
+}
+
+// Fail records that the delivery failed
+func (tracker *Tracker) Fail(deliveryId string, err error) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	element, found := tracker.pending[deliveryId]
+	if !found {
+		return
+	}
+
+	delivery := tracker.remove(element)
+	stats := tracker.stats[delivery.Destination]
+	stats.Failed++
+	if err != nil {
+		stats.LastError = err.Error()
+	}
+}
+
+// Report returns the counts of the deliveries to each destination, ordered by destination, and the overdue
+// deliveries, oldest first
+func (tracker *Tracker) Report() ([]DestinationStats, []Delivery) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	overdueBefore := time.Now().Add(-tracker.ackTimeout).UnixNano()
+	overdueCounts := make(map[string]uint64)
+	var overdue []Delivery
+	for element := tracker.order.Front(); element != nil; element = element.Next() {
+		delivery := element.Value.(*Delivery)
+		if delivery.Exported > overdueBefore {
+			// Deliveries are in the order exported so the rest aren't overdue either
+			break
+		}
+		overdue = append(overdue, *delivery)
+		overdueCounts[delivery.Destination]++
+	}
+
+	all := make([]DestinationStats, 0, len(tracker.stats))
+	for destination, stats := range tracker.stats {
+		copied := *stats
+		copied.Overdue = overdueCounts[destination]
+		all = append(all, copied)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Destination < all[j].Destination })
+	return all, overdue
+}
+
+func (tracker *Tracker) destinationStats(destination string) *DestinationStats {
+	stats, found := tracker.stats[destination]
+	if !found {
+		stats = &DestinationStats{Destination: destination}
+		tracker.stats[destination] = stats
+	}
+	return stats
+}
+
+// remove stops tracking the delivery, which is no longer unacknowledged
+func (tracker *Tracker) remove(element *list.Element) *Delivery {
+	delivery := tracker.order.Remove(element).(*Delivery)
+	delete(tracker.pending, delivery.Id)
+	tracker.stats[delivery.Destination].Unacknowledged--
+	return delivery
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package receipts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+func TestNewTracker(t *testing.T) {
+	tracker, err := NewTracker(common.DeliveryReceiptsInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAckTimeout, tracker.ackTimeout)
+	assert.Equal(t, DefaultMaxPending, tracker.maxPending)
+
+	_, err = NewTracker(common.DeliveryReceiptsInfo{AckTimeout: "soon"})
+	assert.Error(t, err)
+
+	_, err = NewTracker(common.DeliveryReceiptsInfo{AckTimeout: "-1s"})
+	assert.Error(t, err)
+}
+
+func TestTracker_Reconcile(t *testing.T) {
+	tracker, err := NewTracker(common.DeliveryReceiptsInfo{AckTimeout: "1h"})
+	require.NoError(t, err)
+
+	first := tracker.Record("kafka://broker/events", "1")
+	second := tracker.Record("kafka://broker/events", "2")
+	failed := tracker.Record("https://cloud/ingest", "3")
+	pending := tracker.Record("https://cloud/ingest", "4")
+
+	tracker.Acknowledge(first, "offset-10")
+	tracker.Acknowledge(second, "offset-11")
+	tracker.Acknowledge(second, "offset-12")
+	tracker.Fail(failed, errors.New("timed out"))
+	tracker.Acknowledge("unknown", "offset-13")
+
+	destinations, overdue := tracker.Report()
+	assert.Empty(t, overdue, "deliveries should not be overdue within the AckTimeout")
+	require.Len(t, destinations, 2)
+	assert.Equal(t, DestinationStats{Destination: "https://cloud/ingest", Exported: 2, Failed: 1, Unacknowledged: 1, LastError: "timed out"}, destinations[0])
+	assert.Equal(t, DestinationStats{Destination: "kafka://broker/events", Exported: 2, Acknowledged: 2, LastReceipt: "offset-11"}, destinations[1],
+		"duplicate acknowledgements should be ignored")
+
+	tracker.ackTimeout = time.Nanosecond
+	destinations, overdue = tracker.Report()
+	require.Len(t, overdue, 1)
+	assert.Equal(t, pending, overdue[0].Id)
+	assert.Equal(t, "4", overdue[0].CorrelationId)
+	assert.Equal(t, uint64(1), destinations[0].Overdue)
+}
+
+func TestTracker_Evict(t *testing.T) {
+	tracker, err := NewTracker(common.DeliveryReceiptsInfo{MaxPending: 2, AckTimeout: "1ns"})
+	require.NoError(t, err)
+
+	evicted := tracker.Record("mqtt://broker/events", "1")
+	tracker.Record("mqtt://broker/events", "2")
+	tracker.Record("mqtt://broker/events", "3")
+	tracker.Acknowledge(evicted, "")
+
+	destinations, overdue := tracker.Report()
+	require.Len(t, destinations, 1)
+	assert.Equal(t, uint64(3), destinations[0].Exported)
+	assert.Equal(t, uint64(1), destinations[0].Evicted)
+	assert.Equal(t, uint64(2), destinations[0].Unacknowledged)
+	assert.Equal(t, uint64(0), destinations[0].Acknowledged, "evicted delivery should no longer be acknowledged")
+	require.Len(t, overdue, 2)
+	assert.Equal(t, "2", overdue[0].CorrelationId)
+}
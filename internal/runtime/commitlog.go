@@ -0,0 +1,191 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/commitlog"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+// commitLogInfo holds the commit log, when enabled, and the entries that were pending when it was opened
+type commitLogInfo struct {
+	log          *commitlog.Log
+	replayFailed bool
+	pending      []commitlog.Entry
+}
+
+// ConfigureCommitLog opens the commit log, when enabled in the configuration, to which the triggers that call
+// BeginMessage append the messages received. The entries pending from before the service restarted are processed by
+// ReplayCommitLog. Returns an error if the commit log can't be opened.
+func (gr *GolangRuntime) ConfigureCommitLog(config sdkCommon.CommitLogInfo) error {
+	if !config.Enabled {
+		gr.commitLog = commitLogInfo{}
+		return nil
+	}
+
+	directory := strings.TrimSpace(config.Directory)
+	if directory == "" {
+		directory = sdkCommon.DefaultCommitLogDirectory
+	}
+
+	maxReplays := config.MaxReplays
+	if maxReplays <= 0 {
+		maxReplays = sdkCommon.DefaultCommitLogMaxReplays
+	}
+
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	log, pending, err := commitlog.Open(directory, maxReplays, lc)
+	if err != nil {
+		return err
+	}
+
+	lc.Infof("Trigger commit log enabled in '%s' with %d message(s) pending", directory, len(pending))
+
+	gr.commitLog = commitLogInfo{
+		log:          log,
+		replayFailed: config.ReplayFailed,
+		pending:      pending,
+	}
+	return nil
+}
+
+// ReplayCommitLog processes the messages that were pending in the commit log when it was opened, oldest first,
+// waiting for the pipelines to complete for each. Any response data is discarded as the trigger that received the
+// messages isn't running yet. Returns the number of messages replayed.
+func (gr *GolangRuntime) ReplayCommitLog() int {
+	pending := gr.commitLog.pending
+	gr.commitLog.pending = nil
+
+	if len(pending) == 0 {
+		return 0
+	}
+
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+	lc.Infof("Replaying %d message(s) pending in the commit log", len(pending))
+
+	for _, entry := range pending {
+		envelope := entry.Envelope()
+
+		// The message may also be redelivered by the trigger, which is suppressed when deduplication is enabled
+		endDuplicate, _ := gr.checkDuplicate(envelope)
+		succeeded := gr.replayMessage(envelope)
+		endDuplicate(succeeded)
+		gr.completeEntry(entry.Id, envelope, succeeded)
+	}
+
+	return len(pending)
+}
+
+// replayMessage executes the pipelines matching the message's topic and waits for them to complete. Returns false if
+// any of them failed or weren't scheduled.
+func (gr *GolangRuntime) replayMessage(envelope types.MessageEnvelope) bool {
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	pipelines := gr.RoutePipelines(envelope)
+	lc.Debugf("Commit log replay found %d pipeline(s) that match the topic '%s' (%s=%s)",
+		len(pipelines), envelope.ReceivedTopic, common.CorrelationHeader, envelope.CorrelationID)
+
+	pipelinesWaitGroup := sync.WaitGroup{}
+	var failed int32
+
+	for _, pipeline := range pipelines {
+		p := pipeline
+		pipelinesWaitGroup.Add(1)
+		scheduled := gr.ScheduleExecution(envelope, func() {
+			defer pipelinesWaitGroup.Done()
+			appContext := appfunction.NewContext(envelope.CorrelationID, gr.dic, envelope.ContentType)
+			// ProcessMessage logs any error, so no need to log it here.
+			if gr.ProcessMessage(appContext, envelope, p) != nil {
+				atomic.StoreInt32(&failed, 1)
+			}
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			atomic.StoreInt32(&failed, 1)
+		}
+	}
+
+	pipelinesWaitGroup.Wait()
+	return atomic.LoadInt32(&failed) == 0
+}
+
+// BeginMessage is called by the trigger when a message is received, before its pipelines execute. Returns true if the
+// message is a duplicate, when deduplication is enabled, in which case the trigger doesn't execute the pipelines and
+// treats the message as processed. Otherwise the message is appended to the commit log, when enabled, and the
+// returned function must be called once the message's pipelines complete, with whether they all succeeded.
+func (gr *GolangRuntime) BeginMessage(envelope types.MessageEnvelope) (func(succeeded bool), bool) {
+	endDuplicate, duplicate := gr.checkDuplicate(envelope)
+	if duplicate {
+		return endDuplicate, true
+	}
+
+	log := gr.commitLog.log
+	if log == nil {
+		return endDuplicate, false
+	}
+
+	id, err := log.Append(envelope)
+	if err != nil {
+		// The message is still processed, it just won't be replayed if the service stops before it completes
+		lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+		lc.Errorf("Unable to append message to the commit log: %s (%s=%s)", err.Error(), common.CorrelationHeader, envelope.CorrelationID)
+		return endDuplicate, false
+	}
+
+	return func(succeeded bool) {
+		endDuplicate(succeeded)
+		gr.completeEntry(id, envelope, succeeded)
+	}, false
+}
+
+// completeEntry marks the message's commit log entry complete, unless its pipelines failed and either ReplayFailed is
+// set or the service is shutting down, in which case the entry is kept to be replayed on restart.
+func (gr *GolangRuntime) completeEntry(id uint64, envelope types.MessageEnvelope, succeeded bool) {
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	if !succeeded && (gr.commitLog.replayFailed || gr.draining.Value()) {
+		lc.Debugf("Commit log entry %d kept to be replayed on restart (%s=%s)", id, common.CorrelationHeader, envelope.CorrelationID)
+		return
+	}
+
+	if err := gr.commitLog.log.Complete(id); err != nil {
+		lc.Errorf("Unable to complete commit log entry %d: %s (%s=%s)", id, err.Error(), common.CorrelationHeader, envelope.CorrelationID)
+	}
+}
+
+// CloseCommitLog closes the commit log, if open. The entries still pending are replayed when the service restarts.
+func (gr *GolangRuntime) CloseCommitLog() {
+	if gr.commitLog.log == nil {
+		return
+	}
+
+	if err := gr.commitLog.log.Close(); err != nil {
+		lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+		lc.Errorf("Unable to close the commit log: %s", err.Error())
+	}
+}
@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestConfigureCommitLog(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	require.NoError(t, runtime.ConfigureCommitLog(sdkCommon.CommitLogInfo{Directory: t.TempDir()}))
+	assert.Nil(t, runtime.commitLog.log)
+
+	done, duplicate := runtime.BeginMessage(types.MessageEnvelope{Payload: []byte("data")})
+	require.False(t, duplicate)
+	done(true)
+	assert.Zero(t, runtime.ReplayCommitLog())
+	runtime.CloseCommitLog()
+}
+
+func TestReplayCommitLog(t *testing.T) {
+	tests := []struct {
+		Name           string
+		ReplayFailed   bool
+		ExpectedReplay int
+	}{
+		{"Interrupted only", false, 1},
+		{"Failed also", true, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			directory := t.TempDir()
+			config := sdkCommon.CommitLogInfo{Enabled: true, Directory: directory, ReplayFailed: test.ReplayFailed}
+
+			runtime := NewGolangRuntime(serviceKey, nil, dic)
+			require.NoError(t, runtime.ConfigureCommitLog(config))
+
+			exported := types.MessageEnvelope{CorrelationID: "1", ReceivedTopic: "edgex/events", Payload: []byte("exported")}
+			failed := types.MessageEnvelope{CorrelationID: "2", ReceivedTopic: "edgex/events", Payload: []byte("failed")}
+			interrupted := types.MessageEnvelope{CorrelationID: "3", ReceivedTopic: "edgex/events", Payload: []byte("interrupted")}
+
+			done, _ := runtime.BeginMessage(exported)
+			done(true)
+			done, _ = runtime.BeginMessage(failed)
+			done(false)
+			_, _ = runtime.BeginMessage(interrupted)
+			runtime.CloseCommitLog()
+
+			// Restart the service
+			var lock sync.Mutex
+			var replayed []string
+			var fail bool
+			export := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+				lock.Lock()
+				defer lock.Unlock()
+				replayed = append(replayed, ctx.CorrelationID())
+				if fail {
+					return false, errors.New("export failed")
+				}
+				return true, nil
+			}
+
+			runtime = NewGolangRuntime(serviceKey, &[]byte{}, dic)
+			runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{export})
+
+			require.NoError(t, runtime.ConfigureCommitLog(config))
+			assert.Equal(t, test.ExpectedReplay, runtime.ReplayCommitLog())
+			assert.Zero(t, runtime.ReplayCommitLog(), "pending messages should only be replayed once")
+			assert.Equal(t, test.ExpectedReplay, len(replayed))
+			assert.Equal(t, "3", replayed[len(replayed)-1])
+			assert.Zero(t, runtime.commitLog.log.Pending(), "replayed messages should be completed")
+			runtime.CloseCommitLog()
+
+			// A replayed message that fails again is only kept when ReplayFailed is set
+			runtime = NewGolangRuntime(serviceKey, nil, dic)
+			require.NoError(t, runtime.ConfigureCommitLog(config))
+			_, _ = runtime.BeginMessage(interrupted)
+			runtime.CloseCommitLog()
+
+			fail = true
+			runtime = NewGolangRuntime(serviceKey, &[]byte{}, dic)
+			runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{export})
+			require.NoError(t, runtime.ConfigureCommitLog(config))
+			require.Equal(t, 1, runtime.ReplayCommitLog())
+			if test.ReplayFailed {
+				assert.Equal(t, 1, runtime.commitLog.log.Pending())
+			} else {
+				assert.Zero(t, runtime.commitLog.log.Pending())
+			}
+			runtime.CloseCommitLog()
+		})
+	}
+}
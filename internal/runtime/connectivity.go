@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+const (
+	defaultProbeInterval = 10 * time.Second
+	defaultProbeTimeout  = 2 * time.Second
+)
+
+// connectivityMonitor tracks whether the network uplink is available based on a periodic probe.
+// The zero value reports online so exports are attempted when offline mode is not enabled.
+type connectivityMonitor struct {
+	offline  int32
+	restored chan struct{}
+	probe    func() error
+}
+
+func newConnectivityMonitor(probe func() error) *connectivityMonitor {
+	return &connectivityMonitor{
+		restored: make(chan struct{}, 1),
+		probe:    probe,
+	}
+}
+
+// isOffline returns true when the last probe failed.
+func (m *connectivityMonitor) isOffline() bool {
+	return m != nil && atomic.LoadInt32(&m.offline) == 1
+}
+
+// setOffline records the connectivity state and returns true if the state changed.
+// Transitioning back to online signals the restored channel so stored data can be drained right away.
+func (m *connectivityMonitor) setOffline(offline bool) bool {
+	var value int32
+	if offline {
+		value = 1
+	}
+
+	if atomic.SwapInt32(&m.offline, value) == value {
+		return false
+	}
+
+	if !offline {
+		select {
+		case m.restored <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+// check runs the probe once and updates the connectivity state.
+func (m *connectivityMonitor) check(lc logger.LoggingClient) {
+	err := m.probe()
+	if !m.setOffline(err != nil) {
+		return
+	}
+
+	if err != nil {
+		lc.Warnf("Network connectivity lost, exports will be stored for later retry: %s", err.Error())
+	} else {
+		lc.Info("Network connectivity restored, draining stored data")
+	}
+}
+
+// start probes connectivity on the configured interval until either context is done.
+// The monitor reports online once it exits so exports are no longer held back.
+func (m *connectivityMonitor) start(
+	appWg *sync.WaitGroup,
+	appCtx context.Context,
+	enabledWg *sync.WaitGroup,
+	enabledCtx context.Context,
+	interval time.Duration,
+	lc logger.LoggingClient) {
+
+	appWg.Add(1)
+	enabledWg.Add(1)
+
+	go func() {
+		defer appWg.Done()
+		defer enabledWg.Done()
+
+		m.check(lc)
+
+		for {
+			select {
+			case <-appCtx.Done():
+				m.setOffline(false)
+				return
+
+			case <-enabledCtx.Done():
+				m.setOffline(false)
+				return
+
+			case <-time.After(interval):
+				m.check(lc)
+			}
+		}
+	}()
+}
+
+// newConnectivityProbe builds the probe for the configured ProbeUrl. HTTP(S) targets are considered reachable
+// when any response is received and TCP targets when a connection can be established.
+func newConnectivityProbe(probeUrl string, timeout time.Duration) (func() error, error) {
+	target, err := url.Parse(probeUrl)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse ProbeUrl '%s': %s", probeUrl, err.Error())
+	}
+
+	switch strings.ToLower(target.Scheme) {
+	case "http", "https":
+		client := http.Client{Timeout: timeout}
+		return func() error {
+			response, err := client.Get(probeUrl)
+			if err != nil {
+				return err
+			}
+			_ = response.Body.Close()
+			return nil
+		}, nil
+
+	case "tcp":
+		if target.Host == "" {
+			return nil, fmt.Errorf("ProbeUrl '%s' is missing host:port", probeUrl)
+		}
+		return func() error {
+			conn, err := net.DialTimeout("tcp", target.Host, timeout)
+			if err != nil {
+				return err
+			}
+			_ = conn.Close()
+			return nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("ProbeUrl '%s' has unsupported scheme, must be http, https or tcp", probeUrl)
+	}
+}
+
+// parseProbeDurations parses the offline mode probe interval and timeout, falling back to the defaults when invalid.
+func parseProbeDurations(config common.OfflineModeInfo, lc logger.LoggingClient) (time.Duration, time.Duration) {
+	interval, err := time.ParseDuration(config.ProbeInterval)
+	if err != nil || interval <= 0 {
+		lc.Warnf("StoreAndForward OfflineMode ProbeInterval '%s' is invalid, defaulting to %s",
+			config.ProbeInterval, defaultProbeInterval.String())
+		interval = defaultProbeInterval
+	}
+
+	timeout, err := time.ParseDuration(config.ProbeTimeout)
+	if err != nil || timeout <= 0 {
+		lc.Warnf("StoreAndForward OfflineMode ProbeTimeout '%s' is invalid, defaulting to %s",
+			config.ProbeTimeout, defaultProbeTimeout.String())
+		timeout = defaultProbeTimeout
+	}
+
+	return interval, timeout
+}
@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+func TestConnectivityMonitor_SetOffline(t *testing.T) {
+	monitor := newConnectivityMonitor(nil)
+	assert.False(t, monitor.isOffline())
+
+	assert.False(t, monitor.setOffline(false), "no change expected when already online")
+	assert.True(t, monitor.setOffline(true))
+	assert.True(t, monitor.isOffline())
+	assert.False(t, monitor.setOffline(true), "no change expected when already offline")
+
+	select {
+	case <-monitor.restored:
+		t.Fatal("restored should not be signaled when going offline")
+	default:
+	}
+
+	assert.True(t, monitor.setOffline(false))
+	assert.False(t, monitor.isOffline())
+
+	select {
+	case <-monitor.restored:
+	default:
+		t.Fatal("restored should be signaled when coming back online")
+	}
+}
+
+func TestConnectivityMonitor_NilIsOnline(t *testing.T) {
+	var monitor *connectivityMonitor
+	assert.False(t, monitor.isOffline())
+}
+
+func TestConnectivityMonitor_Start(t *testing.T) {
+	var probeErr error
+	var mutex sync.Mutex
+	probe := func() error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return probeErr
+	}
+	setProbeErr := func(err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		probeErr = err
+	}
+
+	setProbeErr(errors.New("network down"))
+	monitor := newConnectivityMonitor(probe)
+
+	appWg := &sync.WaitGroup{}
+	enabledWg := &sync.WaitGroup{}
+	enabledCtx, cancel := context.WithCancel(context.Background())
+
+	monitor.start(appWg, context.Background(), enabledWg, enabledCtx, 10*time.Millisecond, logger.NewMockClient())
+
+	require.Eventually(t, monitor.isOffline, time.Second, 5*time.Millisecond)
+
+	setProbeErr(nil)
+	require.Eventually(t, func() bool { return !monitor.isOffline() }, time.Second, 5*time.Millisecond)
+
+	setProbeErr(errors.New("network down again"))
+	require.Eventually(t, monitor.isOffline, time.Second, 5*time.Millisecond)
+
+	cancel()
+	enabledWg.Wait()
+	assert.False(t, monitor.isOffline(), "monitor should report online once stopped")
+}
+
+func TestNewConnectivityProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	listenerAddr := listener.Addr().String()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	defer func() { _ = listener.Close() }()
+
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closedListener.Addr().String()
+	_ = closedListener.Close()
+
+	tests := []struct {
+		Name          string
+		ProbeUrl      string
+		ExpectedError string
+		ExpectOffline bool
+	}{
+		{"HTTP reachable", server.URL, "", false},
+		{"HTTP unreachable", "http://" + closedAddr, "", true},
+		{"TCP reachable", "tcp://" + listenerAddr, "", false},
+		{"TCP unreachable", "tcp://" + closedAddr, "", true},
+		{"TCP missing host", "tcp://", "missing host:port", false},
+		{"Unsupported scheme", "udp://" + listenerAddr, "unsupported scheme", false},
+		{"Bad URL", "http://bad url:%", "unable to parse", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			probe, err := newConnectivityProbe(test.ProbeUrl, time.Second)
+			if test.ExpectedError != "" {
+				require.Error(t, err)
+				assert.True(t, strings.Contains(err.Error(), test.ExpectedError), err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			if test.ExpectOffline {
+				assert.Error(t, probe())
+			} else {
+				assert.NoError(t, probe())
+			}
+		})
+	}
+}
+
+func TestParseProbeDurations(t *testing.T) {
+	lc := logger.NewMockClient()
+
+	interval, timeout := parseProbeDurations(common.OfflineModeInfo{ProbeInterval: "30s", ProbeTimeout: "5s"}, lc)
+	assert.Equal(t, 30*time.Second, interval)
+	assert.Equal(t, 5*time.Second, timeout)
+
+	interval, timeout = parseProbeDurations(common.OfflineModeInfo{ProbeInterval: "bogus", ProbeTimeout: "-1s"}, lc)
+	assert.Equal(t, defaultProbeInterval, interval)
+	assert.Equal(t, defaultProbeTimeout, timeout)
+}
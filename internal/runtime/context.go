@@ -0,0 +1,60 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+)
+
+// SetContext sets the context the pipeline executions' contexts are derived from, which is cancelled on shutdown
+// once the in-flight executions have been drained or the DrainTimeout has expired
+func (gr *GolangRuntime) SetContext(ctx context.Context) {
+	gr.ctx = ctx
+}
+
+// startContext sets the context of the execution, done once the service is shutting down or the context set by the
+// trigger, i.e. the HTTP request's, is done. Returns the function to call once the execution has completed, which
+// restores the trigger's context.
+func (gr *GolangRuntime) startContext(appContext *appfunction.Context) context.CancelFunc {
+	triggerCtx := appContext.Context()
+
+	parent := gr.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	appContext.SetContext(ctx)
+
+	// Background and the like are never done
+	if triggerCtx.Done() != nil {
+		go func() {
+			select {
+			case <-triggerCtx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return func() {
+		cancel()
+		appContext.SetContext(triggerCtx)
+	}
+}
@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestGolangRuntime_ContextCancelledOnShutdown(t *testing.T) {
+	appCtx, shutdown := context.WithCancel(context.Background())
+
+	var cancelled bool
+	waiting := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		shutdown()
+		<-ctx.Context().Done()
+		cancelled = true
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{waiting})
+	runtime.SetContext(appCtx)
+
+	ctx := appfunction.NewContext("123", dic, "")
+	require.Nil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.True(t, cancelled, "context passed to the functions should be done on shutdown")
+}
+
+func TestGolangRuntime_ContextFromTrigger(t *testing.T) {
+	triggerCtx, cancelRequest := context.WithCancel(context.Background())
+
+	var executionCtx context.Context
+	waiting := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		executionCtx = ctx.Context()
+		cancelRequest()
+		<-executionCtx.Done()
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{waiting})
+
+	ctx := appfunction.NewContext("123", dic, "")
+	ctx.SetContext(triggerCtx)
+	require.Nil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.Error(t, executionCtx.Err(), "context passed to the functions should be done once the trigger's is")
+	assert.Equal(t, triggerCtx, ctx.Context(), "trigger's context should be restored once the execution completes")
+}
@@ -0,0 +1,131 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// DebugSession steps a payload through the functions of a pipeline one at a time, so the data between each function
+// can be inspected. The SDK's export functions log the data rather than send it, as in a shadow pipeline.
+type DebugSession struct {
+	lock       sync.Mutex
+	pipeline   *interfaces.FunctionPipeline
+	functions  []interfaces.AppFunction
+	appContext *appfunction.Context
+	input      interface{}
+	data       interface{}
+	next       int
+	completed  bool
+}
+
+// StartDebugSession decodes the message's payload, as the pipelines receive it, and returns a DebugSession stepping it
+// through the pipeline's functions. Returns an error if the pipeline has no functions or the payload can't be decoded.
+func (gr *GolangRuntime) StartDebugSession(envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) (*DebugSession, error) {
+	if pipeline == nil || len(pipeline.Transforms) == 0 {
+		return nil, errors.New("pipeline has no functions to debug")
+	}
+
+	data, err := gr.DecodePayload(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode payload: %s", err.Error())
+	}
+
+	appContext := appfunction.NewContext(envelope.CorrelationID, gr.dic, envelope.ContentType)
+	appContext.AddValue(interfaces.RECEIVEDTOPIC, envelope.ReceivedTopic)
+	appContext.AddValue(interfaces.PIPELINEID, pipeline.Id)
+	appContext.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+	if event, ok := data.(dtos.Event); ok {
+		appContext.AddValue(interfaces.DEVICENAME, event.DeviceName)
+		appContext.AddValue(interfaces.PROFILENAME, event.ProfileName)
+		appContext.AddValue(interfaces.SOURCENAME, event.SourceName)
+		appContext.AddValue(interfaces.EVENTID, event.Id)
+	}
+
+	return &DebugSession{
+		pipeline:   pipeline,
+		functions:  gr.applyMiddleware(pipeline.Transforms),
+		appContext: appContext,
+		input:      data,
+		data:       data,
+	}, nil
+}
+
+// Functions returns the names of the pipeline's functions in the order they are stepped through
+func (session *DebugSession) Functions() []string {
+	names := make([]string, len(session.pipeline.Transforms))
+	for index, function := range session.pipeline.Transforms {
+		names[index] = functionName(function)
+	}
+	return names
+}
+
+// Input returns a snapshot of the decoded payload passed to the first function
+func (session *DebugSession) Input() capture.Data {
+	return capture.NewData(session.input)
+}
+
+// Completed returns whether a function stopped the pipeline or all the functions have executed
+func (session *DebugSession) Completed() bool {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	return session.completed
+}
+
+// Step executes the next function with the previous function's result and returns a snapshot of its result. A panic
+// in the function is returned as its error, which stops the pipeline. Returns false once the session has completed.
+func (session *DebugSession) Step() (capture.Stage, bool) {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	if session.completed {
+		return capture.Stage{}, false
+	}
+
+	index := session.next
+	continuePipeline, result := executeDebugStep(session.functions[index], session.appContext, session.data)
+	session.next++
+	session.data = result
+
+	if !continuePipeline || session.next == len(session.functions) {
+		session.completed = true
+	}
+
+	return capture.NewStage(index, continuePipeline, result), true
+}
+
+// executeDebugStep executes the function, returning a panic as the function's error
+func executeDebugStep(function interfaces.AppFunction, appContext interfaces.AppFunctionContext, data interface{}) (continuePipeline bool, result interface{}) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			continuePipeline = false
+			result = fmt.Errorf("function panicked: %v", recovered)
+		}
+	}()
+
+	return function(appContext, data)
+}
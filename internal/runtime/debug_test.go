@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestGolangRuntime_StartDebugSession(t *testing.T) {
+	var shadowMode string
+	upper := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		shadowMode, _ = ctx.GetValue(interfaces.SHADOW)
+		return true, string(data.([]byte)) + "!"
+	}
+	fail := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		return false, errors.New("failed")
+	}
+	unreached := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return true, data
+	}
+
+	runtime := NewGolangRuntime("", &[]byte{}, dic)
+	pipeline := &interfaces.FunctionPipeline{Id: "debug", Transforms: []interfaces.AppFunction{upper, fail, unreached}}
+
+	_, err := runtime.StartDebugSession(types.MessageEnvelope{}, &interfaces.FunctionPipeline{Id: "empty"})
+	require.Error(t, err)
+
+	session, err := runtime.StartDebugSession(types.MessageEnvelope{CorrelationID: "123", Payload: []byte("data")}, pipeline)
+	require.NoError(t, err)
+	require.Len(t, session.Functions(), 3)
+	assert.Equal(t, "data", session.Input().Value)
+
+	stage, ok := session.Step()
+	require.True(t, ok)
+	assert.Equal(t, 0, stage.Index)
+	assert.True(t, stage.ContinuePipeline)
+	require.NotNil(t, stage.Output)
+	assert.Equal(t, "data!", stage.Output.Value)
+	assert.Equal(t, interfaces.ShadowModeLog, shadowMode, "exports should log rather than send the data")
+	assert.False(t, session.Completed())
+
+	stage, ok = session.Step()
+	require.True(t, ok)
+	assert.Equal(t, 1, stage.Index)
+	assert.Equal(t, "failed", stage.Error)
+	assert.True(t, session.Completed(), "function stopping the pipeline should complete the session")
+
+	_, ok = session.Step()
+	assert.False(t, ok)
+}
+
+func TestDebugSession_StepPanic(t *testing.T) {
+	panics := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		panic("bad data")
+	}
+
+	runtime := NewGolangRuntime("", &[]byte{}, dic)
+	pipeline := &interfaces.FunctionPipeline{Id: "debug", Transforms: []interfaces.AppFunction{panics}}
+
+	session, err := runtime.StartDebugSession(types.MessageEnvelope{Payload: []byte("data")}, pipeline)
+	require.NoError(t, err)
+
+	stage, ok := session.Step()
+	require.True(t, ok)
+	assert.False(t, stage.ContinuePipeline)
+	assert.Contains(t, stage.Error, "bad data")
+	assert.True(t, session.Completed())
+}
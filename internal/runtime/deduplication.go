@@ -0,0 +1,200 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+// duplicateFilter remembers the keys of the messages received, while their pipelines execute and for the window once
+// they have completed successfully, so the trigger can skip the messages redelivered.
+type duplicateFilter struct {
+	lock   sync.Mutex
+	window time.Duration
+	keyBy  string
+	// expiries holds the time each message is forgotten, which is zero while its pipelines execute
+	expiries map[string]time.Time
+	// expiryQueue holds the messages remembered for the window in the order they expire, as the window is fixed
+	expiryQueue []expiringKey
+	now         func() time.Time
+}
+
+type expiringKey struct {
+	key    string
+	expiry time.Time
+}
+
+func newDuplicateFilter(config sdkCommon.DeduplicationInfo) (*duplicateFilter, error) {
+	window, err := config.WindowDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	keyBy := strings.ToLower(strings.TrimSpace(config.Key))
+	switch keyBy {
+	case "":
+		keyBy = sdkCommon.DeduplicationKeyChecksum
+	case sdkCommon.DeduplicationKeyChecksum, sdkCommon.DeduplicationKeyEventId:
+	default:
+		return nil, fmt.Errorf("invalid Trigger Deduplication Key '%s', must be '%s' or '%s'",
+			config.Key, sdkCommon.DeduplicationKeyChecksum, sdkCommon.DeduplicationKeyEventId)
+	}
+
+	return &duplicateFilter{
+		window:   window,
+		keyBy:    keyBy,
+		expiries: make(map[string]time.Time),
+		now:      time.Now,
+	}, nil
+}
+
+// begin returns true if the message is a duplicate, otherwise remembers the message's key, which is returned to end
+// the message once its pipelines complete.
+func (filter *duplicateFilter) begin(envelope types.MessageEnvelope) (string, bool) {
+	key := filter.key(envelope)
+	now := filter.now()
+
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+
+	filter.removeExpired(now)
+
+	if _, found := filter.expiries[key]; found {
+		return key, true
+	}
+
+	filter.expiries[key] = time.Time{}
+	return key, false
+}
+
+// end remembers the message for the window when its pipelines succeeded, otherwise forgets it so its redelivery is
+// processed
+func (filter *duplicateFilter) end(key string, succeeded bool) {
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+
+	if !succeeded {
+		delete(filter.expiries, key)
+		return
+	}
+
+	expiry := filter.now().Add(filter.window)
+	filter.expiries[key] = expiry
+	filter.expiryQueue = append(filter.expiryQueue, expiringKey{key: key, expiry: expiry})
+}
+
+func (filter *duplicateFilter) removeExpired(now time.Time) {
+	expired := 0
+	for _, item := range filter.expiryQueue {
+		if now.Before(item.expiry) {
+			break
+		}
+
+		// The key may have been forgotten and received again since this expiry was queued
+		if filter.expiries[item.key].Equal(item.expiry) {
+			delete(filter.expiries, item.key)
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		filter.expiryQueue = filter.expiryQueue[expired:]
+	}
+}
+
+func (filter *duplicateFilter) key(envelope types.MessageEnvelope) string {
+	if filter.keyBy == sdkCommon.DeduplicationKeyEventId {
+		if id := eventId(envelope); id != "" {
+			return "event:" + id
+		}
+	}
+
+	checksum := sha256.Sum256(envelope.Payload)
+	return "checksum:" + hex.EncodeToString(checksum[:])
+}
+
+// eventId returns the id of the Event, or AddEventRequest's Event, in the message's payload, or empty if there is none
+func eventId(envelope types.MessageEnvelope) string {
+	payload := struct {
+		Id    string `json:"id"`
+		Event struct {
+			Id string `json:"id"`
+		} `json:"event"`
+	}{}
+
+	err := decodeEnvelope(envelope, &payload)
+	if err != nil {
+		return ""
+	}
+
+	if payload.Event.Id != "" {
+		return payload.Event.Id
+	}
+	return payload.Id
+}
+
+// ConfigureDeduplication enables the suppression of duplicate messages, when enabled in the configuration, for the
+// triggers that call BeginMessage. Returns an error if the configuration is invalid.
+func (gr *GolangRuntime) ConfigureDeduplication(config sdkCommon.DeduplicationInfo) error {
+	if !config.Enabled {
+		gr.duplicates = nil
+		return nil
+	}
+
+	filter, err := newDuplicateFilter(config)
+	if err != nil {
+		return err
+	}
+
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+	lc.Infof("Trigger deduplication enabled, suppressing messages redelivered within %s by %s", filter.window.String(), filter.keyBy)
+
+	gr.duplicates = filter
+	return nil
+}
+
+// checkDuplicate returns true if the message was received before, while its pipelines executed or within the
+// Deduplication Window once they completed successfully. Otherwise the returned function must be called once the
+// message's pipelines complete, with whether they all succeeded, so a message that failed is processed when redelivered.
+func (gr *GolangRuntime) checkDuplicate(envelope types.MessageEnvelope) (func(succeeded bool), bool) {
+	filter := gr.duplicates
+	if filter == nil {
+		return func(bool) {}, false
+	}
+
+	key, duplicate := filter.begin(envelope)
+	if duplicate {
+		lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+		lc.Infof("Duplicate message on topic '%s' not processed (%s=%s)", envelope.ReceivedTopic, common.CorrelationHeader, envelope.CorrelationID)
+		return func(bool) {}, true
+	}
+
+	return func(succeeded bool) {
+		filter.end(key, succeeded)
+	}, false
+}
@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+func TestConfigureDeduplication(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      sdkCommon.DeduplicationInfo
+		ExpectError bool
+	}{
+		{"Disabled", sdkCommon.DeduplicationInfo{Window: "bad", Key: "bad"}, false},
+		{"Defaults", sdkCommon.DeduplicationInfo{Enabled: true}, false},
+		{"Event id", sdkCommon.DeduplicationInfo{Enabled: true, Window: "1m", Key: "EventId"}, false},
+		{"Bad window", sdkCommon.DeduplicationInfo{Enabled: true, Window: "a minute"}, true},
+		{"Bad key", sdkCommon.DeduplicationInfo{Enabled: true, Key: "correlationid"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			runtime := NewGolangRuntime(serviceKey, nil, dic)
+			err := runtime.ConfigureDeduplication(test.Config)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Config.Enabled, runtime.duplicates != nil)
+		})
+	}
+}
+
+func TestBeginMessage_Deduplication(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	require.NoError(t, runtime.ConfigureDeduplication(sdkCommon.DeduplicationInfo{Enabled: true, Window: "1m"}))
+
+	now := time.Now()
+	runtime.duplicates.now = func() time.Time { return now }
+
+	message := types.MessageEnvelope{CorrelationID: "1", Payload: []byte(`{"event":{"id":"abc"}}`)}
+	redelivered := types.MessageEnvelope{CorrelationID: "2", Payload: message.Payload}
+
+	done, duplicate := runtime.BeginMessage(message)
+	require.False(t, duplicate)
+
+	_, duplicate = runtime.BeginMessage(redelivered)
+	assert.True(t, duplicate, "message should be a duplicate while its pipelines execute")
+
+	done(false)
+	done, duplicate = runtime.BeginMessage(redelivered)
+	require.False(t, duplicate, "message that failed should be processed when redelivered")
+
+	done(true)
+	_, duplicate = runtime.BeginMessage(redelivered)
+	assert.True(t, duplicate, "processed message should be a duplicate within the window")
+
+	_, duplicate = runtime.BeginMessage(types.MessageEnvelope{Payload: []byte(`{"event":{"id":"def"}}`)})
+	assert.False(t, duplicate, "other message should not be a duplicate")
+
+	now = now.Add(time.Minute)
+	done, duplicate = runtime.BeginMessage(redelivered)
+	assert.False(t, duplicate, "message should be forgotten once the window has passed")
+	done(true)
+	assert.Len(t, runtime.duplicates.expiryQueue, 1)
+
+	disabled := NewGolangRuntime(serviceKey, nil, dic)
+	done, duplicate = disabled.BeginMessage(message)
+	assert.False(t, duplicate)
+	done(true)
+	_, duplicate = disabled.BeginMessage(message)
+	assert.False(t, duplicate, "messages should not be suppressed when deduplication isn't enabled")
+}
+
+func TestDuplicateFilter_Key(t *testing.T) {
+	checksumFilter, err := newDuplicateFilter(sdkCommon.DeduplicationInfo{})
+	require.NoError(t, err)
+	eventIdFilter, err := newDuplicateFilter(sdkCommon.DeduplicationInfo{Key: sdkCommon.DeduplicationKeyEventId})
+	require.NoError(t, err)
+
+	cborPayload, err := cbor.Marshal(map[string]interface{}{"event": map[string]string{"id": "abc"}})
+	require.NoError(t, err)
+
+	request := types.MessageEnvelope{ContentType: common.ContentTypeJSON, Payload: []byte(`{"event":{"id":"abc","origin":1}}`)}
+	republished := types.MessageEnvelope{ContentType: common.ContentTypeJSON, Payload: []byte(`{"event":{"id":"abc","origin":2}}`)}
+	event := types.MessageEnvelope{ContentType: common.ContentTypeJSON, Payload: []byte(`{"id":"abc"}`)}
+	encoded := types.MessageEnvelope{ContentType: common.ContentTypeCBOR, Payload: cborPayload}
+	notEvent := types.MessageEnvelope{ContentType: common.ContentTypeJSON, Payload: []byte(`some text`)}
+
+	assert.NotEqual(t, checksumFilter.key(request), checksumFilter.key(republished))
+	assert.Equal(t, "event:abc", eventIdFilter.key(request))
+	assert.Equal(t, "event:abc", eventIdFilter.key(republished))
+	assert.Equal(t, "event:abc", eventIdFilter.key(event))
+	assert.Equal(t, "event:abc", eventIdFilter.key(encoded))
+	assert.Equal(t, checksumFilter.key(notEvent), eventIdFilter.key(notEvent), "should fall back to the checksum")
+}
@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"sync"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+)
+
+// executionTracker counts the pipeline executions that have been scheduled and not yet completed.
+// Unlike a sync.WaitGroup, executions can be added while waiting for the count to reach zero.
+type executionTracker struct {
+	lock  sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+func (tracker *executionTracker) add() {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if tracker.count == 0 {
+		tracker.idle = make(chan struct{})
+	}
+	tracker.count++
+}
+
+func (tracker *executionTracker) done() {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.count--
+	if tracker.count == 0 {
+		close(tracker.idle)
+	}
+}
+
+func (tracker *executionTracker) inFlight() int {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return tracker.count
+}
+
+// wait returns true once no executions are in flight, or false if the timeout expires first
+func (tracker *executionTracker) wait(timeout time.Duration) bool {
+	tracker.lock.Lock()
+	if tracker.count == 0 {
+		tracker.lock.Unlock()
+		return true
+	}
+	idle := tracker.idle
+	tracker.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// Drain stops new pipeline executions from being scheduled and waits, up to the timeout, for the executions already
+// scheduled to complete. A store and forward retry in progress stops after the item being retried so its state can
+// be saved. Returns false if executions were still in flight when the timeout expired.
+func (gr *GolangRuntime) Drain(timeout time.Duration) bool {
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	gr.draining.Set(true)
+
+	inFlight := gr.executions.inFlight()
+	if inFlight == 0 {
+		return true
+	}
+
+	lc.Infof("Waiting up to %s for %d in-flight pipeline execution(s) to complete", timeout.String(), inFlight)
+
+	if !gr.executions.wait(timeout) {
+		lc.Warnf("%d pipeline execution(s) did not complete within %s", gr.executions.inFlight(), timeout.String())
+		return false
+	}
+
+	lc.Info("All in-flight pipeline executions completed")
+	return true
+}
+
+// IsDraining returns true once Drain has been called, after which no new pipeline executions are scheduled
+func (gr *GolangRuntime) IsDraining() bool {
+	return gr.draining.Value()
+}
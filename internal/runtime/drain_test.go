@@ -0,0 +1,193 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestDrain_WaitsForInFlightExecutions(t *testing.T) {
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		appWg.Wait()
+	}()
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{Workers: 1, QueueSize: 10})
+
+	release := make(chan struct{})
+	completed := make(chan struct{}, 2)
+	for i := 0; i < 2; i++ {
+		scheduled := runtime.ScheduleExecution(types.MessageEnvelope{}, func() {
+			<-release
+			completed <- struct{}{}
+		})
+		require.True(t, scheduled)
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	assert.True(t, runtime.Drain(5*time.Second))
+	assert.Len(t, completed, 2, "queued and running executions should complete before Drain returns")
+	assert.True(t, runtime.IsDraining())
+}
+
+func TestDrain_Timeout(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	require.True(t, runtime.ScheduleExecution(types.MessageEnvelope{}, func() { <-release }))
+
+	start := time.Now()
+	assert.False(t, runtime.Drain(50*time.Millisecond))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDrain_NoExecutionsInFlight(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+
+	assert.True(t, runtime.Drain(0))
+}
+
+func TestScheduleExecution_Draining(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	require.True(t, runtime.Drain(time.Second))
+
+	executed := false
+	scheduled := runtime.ScheduleExecution(types.MessageEnvelope{}, func() { executed = true })
+
+	assert.False(t, scheduled)
+	assert.False(t, executed)
+	assert.True(t, runtime.executions.wait(0), "rejected execution should not be counted as in-flight")
+}
+
+func TestDrain_MessagesDuringShutdown(t *testing.T) {
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runtime := NewGolangRuntime(serviceKey, &[]byte{}, dic)
+	runtime.SetContext(appCtx)
+	require.NoError(t, runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{Workers: 1, QueueSize: 10}))
+
+	started := make(chan struct{}, 1)
+	blocking := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		started <- struct{}{}
+		<-ctx.Context().Done()
+		return false, errors.New("cancelled")
+	}
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{blocking})
+
+	// Receives the messages like the triggers do until its context is done, which the service does before draining
+	triggerWg := &sync.WaitGroup{}
+	triggerCtx, stopReceiving := context.WithCancel(appCtx)
+	messages := make(chan types.MessageEnvelope, 10)
+	var lock sync.Mutex
+	var processed, failed, dropped int
+	received := make(chan struct{}, 10)
+
+	triggerWg.Add(1)
+	go func() {
+		defer triggerWg.Done()
+		for {
+			select {
+			case <-triggerCtx.Done():
+				return
+			case message := <-messages:
+				pipelinesWaitGroup := &sync.WaitGroup{}
+				pipelinesWaitGroup.Add(1)
+				scheduled := runtime.ScheduleExecution(message, func() {
+					defer pipelinesWaitGroup.Done()
+					err := runtime.ProcessMessage(appfunction.NewContext("123", dic, ""), message, runtime.GetDefaultPipeline())
+
+					lock.Lock()
+					defer lock.Unlock()
+					if err != nil {
+						failed++
+					} else {
+						processed++
+					}
+				})
+
+				if !scheduled {
+					pipelinesWaitGroup.Done()
+					lock.Lock()
+					dropped++
+					lock.Unlock()
+				}
+
+				triggerWg.Add(1)
+				go func() {
+					defer triggerWg.Done()
+					pipelinesWaitGroup.Wait()
+				}()
+				received <- struct{}{}
+			}
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		messages <- types.MessageEnvelope{Payload: []byte("data")}
+		<-received
+	}
+	<-started
+
+	stopReceiving()
+	time.Sleep(10 * time.Millisecond)
+
+	// Messages arriving while draining are left for the broker to redeliver
+	messages <- types.MessageEnvelope{Payload: []byte("during drain")}
+
+	assert.False(t, runtime.Drain(50*time.Millisecond), "blocked execution should not complete before the timeout")
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		triggerWg.Wait()
+		appWg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "shutdown should not wait on the executions queued when the DrainTimeout expired")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 0, dropped, "no message should be rejected while draining")
+	assert.Equal(t, 0, processed)
+	assert.Equal(t, 3, failed, "running and queued executions should fail so their messages are redelivered")
+	assert.Len(t, messages, 1, "message arriving while draining should not be received")
+}
@@ -0,0 +1,211 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// SetFunctionErrorPolicy sets the policy applied when the function at the index of the pipeline fails
+func (gr *GolangRuntime) SetFunctionErrorPolicy(id string, functionIndex int, policy interfaces.ErrorPolicy) error {
+	pipeline := gr.GetPipelineById(id)
+	if pipeline == nil {
+		return fmt.Errorf("pipeline with Id='%s' not found", id)
+	}
+
+	gr.isBusyCopying.Lock()
+	functionCount := len(pipeline.Transforms)
+	gr.isBusyCopying.Unlock()
+
+	if functionIndex < 0 || functionIndex >= functionCount {
+		return fmt.Errorf("pipeline with Id='%s' has no function #%d", id, functionIndex)
+	}
+
+	if err := validateErrorPolicy(policy); err != nil {
+		return fmt.Errorf("invalid error policy for function #%d of pipeline with Id='%s': %s", functionIndex, id, err.Error())
+	}
+
+	gr.isBusyCopying.Lock()
+	defer gr.isBusyCopying.Unlock()
+
+	// The policies are replaced rather than updated, so executions keep the policies they started with
+	policies := make(map[int]interfaces.ErrorPolicy, len(gr.errorPolicies[id])+1)
+	for index, existing := range gr.errorPolicies[id] {
+		policies[index] = existing
+	}
+	policies[functionIndex] = policy
+
+	if gr.errorPolicies == nil {
+		gr.errorPolicies = make(map[string]map[int]interfaces.ErrorPolicy)
+	}
+	gr.errorPolicies[id] = policies
+
+	return nil
+}
+
+// ReplaceFunctionErrorPolicies replaces the error policies of all the pipelines at once, i.e. with those reloaded from
+// a changed configuration. The policies are keyed by pipeline id and then function index.
+func (gr *GolangRuntime) ReplaceFunctionErrorPolicies(policies map[string]map[int]interfaces.ErrorPolicy) error {
+	for id, pipelinePolicies := range policies {
+		for functionIndex, policy := range pipelinePolicies {
+			if err := validateErrorPolicy(policy); err != nil {
+				return fmt.Errorf("invalid error policy for function #%d of pipeline with Id='%s': %s", functionIndex, id, err.Error())
+			}
+		}
+	}
+
+	gr.isBusyCopying.Lock()
+	gr.errorPolicies = policies
+	gr.isBusyCopying.Unlock()
+
+	return nil
+}
+
+// SetPipelineErrorHandler sets the handler called each time a pipeline function fails
+func (gr *GolangRuntime) SetPipelineErrorHandler(handler interfaces.PipelineErrorHandler) {
+	gr.isBusyCopying.Lock()
+	gr.errorHandler = handler
+	gr.isBusyCopying.Unlock()
+}
+
+// errorHandling returns the error policies of the pipeline's functions and the pipeline error handler
+func (gr *GolangRuntime) errorHandling(id string) (map[int]interfaces.ErrorPolicy, interfaces.PipelineErrorHandler) {
+	gr.isBusyCopying.Lock()
+	defer gr.isBusyCopying.Unlock()
+
+	return gr.errorPolicies[id], gr.errorHandler
+}
+
+// handleFunctionError applies the error policy of the function that failed with its input, retrying it, skipping it
+// or diverting its input to the dead letter function, and calls the pipeline error handler. Returns whether the
+// pipeline continues and with what result, as the function would have, or the error the pipeline stops with.
+func (gr *GolangRuntime) handleFunctionError(
+	state *pipelineExecution,
+	functionIndex int,
+	input interface{},
+	err error) (bool, interface{}, *MessageError) {
+
+	appContext := state.appContext
+	pipeline := state.pipeline
+	lc := appContext.LoggingClient()
+	function := state.functions[functionIndex]
+	policy, hasPolicy := state.errorPolicies[functionIndex]
+
+	// A sandboxed function exceeding its limits would exceed them again, so isn't retried
+	var violation interfaces.SandboxViolation
+	if hasPolicy && !errors.As(err, &violation) {
+		for retry := 1; retry <= policy.Retries; retry++ {
+			lc.Debugf("Retrying pipeline (%s) function #%d (%d of %d) after error: %s", pipeline.Id, functionIndex, retry,
+				policy.Retries, err.Error())
+
+			time.Sleep(policy.RetryInterval)
+			appContext.SetRetryData(nil)
+
+			continuePipeline, result := state.costs.Measure(functionIndex, func() (bool, interface{}) {
+				return function(appContext, input)
+			})
+
+			retryErr, failed := result.(error)
+			if continuePipeline || !failed {
+				lc.Infof("Pipeline (%s) function #%d succeeded on retry %d", pipeline.Id, functionIndex, retry)
+				return continuePipeline, result, nil
+			}
+
+			err = retryErr
+		}
+	}
+
+	lc.Errorf(
+		"Pipeline (%s) function #%d resulted in error: %s (%s=%s)",
+		pipeline.Id,
+		functionIndex,
+		err.Error(),
+		common.CorrelationHeader,
+		appContext.CorrelationID())
+
+	if state.errorHandler != nil {
+		state.errorHandler(appContext, err, functionName(pipeline.Transforms[functionIndex]), input)
+	}
+
+	switch policy.Action {
+	case interfaces.ErrorPolicySkip:
+		lc.Warnf("Pipeline (%s) function #%d skipped after error", pipeline.Id, functionIndex)
+		return true, input, nil
+
+	case interfaces.ErrorPolicyDeadLetter:
+		if continuePipeline, result := policy.DeadLetter(appContext, input); !continuePipeline {
+			if deadLetterErr, failed := result.(error); failed {
+				err = fmt.Errorf("function #%d failed with '%s' and diverting its input to the dead letter function failed: %s",
+					functionIndex, err.Error(), deadLetterErr.Error())
+				return false, nil, &MessageError{Err: err, ErrorCode: http.StatusUnprocessableEntity}
+			}
+		}
+
+		lc.Infof("Pipeline (%s) function #%d input diverted to dead letter function (%s=%s)", pipeline.Id,
+			functionIndex, common.CorrelationHeader, appContext.CorrelationID())
+		return false, nil, nil
+
+	case interfaces.ErrorPolicyAbort:
+		return false, nil, &MessageError{Err: err, ErrorCode: http.StatusUnprocessableEntity}
+	}
+
+	if appContext.RetryData() != nil && !state.isRetry && pipeline.ShadowMode == "" {
+		gr.storeForward.storeForLaterRetry(appContext.RetryData(), appContext, pipeline, functionIndex)
+	}
+
+	return false, nil, &MessageError{Err: err, ErrorCode: http.StatusUnprocessableEntity}
+}
+
+func validateErrorPolicy(policy interfaces.ErrorPolicy) error {
+	if policy.Retries < 0 || policy.RetryInterval < 0 {
+		return errors.New("Retries and RetryInterval can not be negative")
+	}
+
+	switch policy.Action {
+	case interfaces.ErrorPolicyAbort, interfaces.ErrorPolicySkip:
+	case interfaces.ErrorPolicyRetry:
+		if policy.Retries == 0 {
+			return fmt.Errorf("Retries must be greater than 0 for '%s' action", policy.Action)
+		}
+	case interfaces.ErrorPolicyDeadLetter:
+		if policy.DeadLetter == nil {
+			return fmt.Errorf("DeadLetter function required for '%s' action", policy.Action)
+		}
+	default:
+		return fmt.Errorf("invalid action '%s', must be '%s', '%s', '%s' or '%s'", policy.Action,
+			interfaces.ErrorPolicyAbort, interfaces.ErrorPolicySkip, interfaces.ErrorPolicyRetry, interfaces.ErrorPolicyDeadLetter)
+	}
+
+	return nil
+}
+
+// functionName returns the name of the function without its package path, i.e. "transforms.HTTPSender.HTTPPost"
+func functionName(function interfaces.AppFunction) string {
+	name := runtime.FuncForPC(reflect.ValueOf(function).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
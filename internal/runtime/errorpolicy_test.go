@@ -0,0 +1,236 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// failingFunction fails the number of times specified before passing its input on
+type failingFunction struct {
+	failures int
+	calls    int
+}
+
+func (f *failingFunction) Execute(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	f.calls++
+	if f.calls <= f.failures {
+		return false, errors.New("failed")
+	}
+	return true, data
+}
+
+func TestGolangRuntime_SetFunctionErrorPolicy(t *testing.T) {
+	noop := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return true, data
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{noop})
+
+	tests := []struct {
+		Name          string
+		PipelineId    string
+		FunctionIndex int
+		Policy        interfaces.ErrorPolicy
+		ExpectError   bool
+	}{
+		{"Valid skip", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicySkip}, false},
+		{"Valid retry", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyRetry, Retries: 2}, false},
+		{"Valid dead letter", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyDeadLetter, DeadLetter: noop}, false},
+		{"Unknown pipeline", "unknown", 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicySkip}, true},
+		{"No function at index", interfaces.DefaultPipelineId, 1, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicySkip}, true},
+		{"Invalid action", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: "ignore"}, true},
+		{"Retry without retries", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyRetry}, true},
+		{"Dead letter without function", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyDeadLetter}, true},
+		{"Negative retries", interfaces.DefaultPipelineId, 0, interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyAbort, Retries: -1}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := runtime.SetFunctionErrorPolicy(test.PipelineId, test.FunctionIndex, test.Policy)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			policies, _ := runtime.errorHandling(test.PipelineId)
+			assert.Equal(t, test.Policy.Action, policies[test.FunctionIndex].Action)
+		})
+	}
+}
+
+func TestGolangRuntime_ErrorPolicies(t *testing.T) {
+	var deadLetters []interface{}
+	deadLetter := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		deadLetters = append(deadLetters, data)
+		return false, nil
+	}
+
+	var received interface{}
+	last := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		received = data
+		return false, nil
+	}
+
+	tests := []struct {
+		Name            string
+		Failures        int
+		Policy          *interfaces.ErrorPolicy
+		ExpectError     bool
+		ExpectCalls     int
+		ExpectReceived  bool
+		ExpectDiverted  bool
+		ExpectHandlerIn bool
+	}{
+		{"No policy", 1, nil, true, 1, false, false, true},
+		{"Abort", 1, &interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyAbort}, true, 1, false, false, true},
+		{"Skip", 5, &interfaces.ErrorPolicy{Action: interfaces.ErrorPolicySkip}, false, 1, true, false, true},
+		{"Retry succeeds", 2, &interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyRetry, Retries: 2}, false, 3, true, false, false},
+		{"Retry exhausted", 5, &interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyRetry, Retries: 2}, true, 3, false, false, true},
+		{"Dead letter", 1, &interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyDeadLetter, DeadLetter: deadLetter}, false, 1, false, true, true},
+		{"Retry then skip", 1, &interfaces.ErrorPolicy{Action: interfaces.ErrorPolicySkip, Retries: 1}, false, 2, true, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			deadLetters = nil
+			received = nil
+
+			failing := &failingFunction{failures: test.Failures}
+			runtime := NewGolangRuntime("", nil, dic)
+			runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{failing.Execute, last})
+
+			var handledErr error
+			var handledFunction string
+			var handledInput interface{}
+			runtime.SetPipelineErrorHandler(func(ctx interfaces.AppFunctionContext, err error, functionName string, input interface{}) {
+				handledErr = err
+				handledFunction = functionName
+				handledInput = input
+			})
+
+			if test.Policy != nil {
+				require.NoError(t, runtime.SetFunctionErrorPolicy(interfaces.DefaultPipelineId, 0, *test.Policy))
+			}
+
+			ctx := appfunction.NewContext("123", dic, "")
+			err := runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false)
+
+			if test.ExpectError {
+				require.NotNil(t, err)
+				assert.Equal(t, http.StatusUnprocessableEntity, err.ErrorCode)
+			} else {
+				require.Nil(t, err)
+			}
+
+			assert.Equal(t, test.ExpectCalls, failing.calls)
+
+			if test.ExpectReceived {
+				assert.Equal(t, "data", received)
+			} else {
+				assert.Nil(t, received)
+			}
+
+			if test.ExpectDiverted {
+				assert.Equal(t, []interface{}{"data"}, deadLetters)
+			} else {
+				assert.Empty(t, deadLetters)
+			}
+
+			if test.ExpectHandlerIn {
+				require.Error(t, handledErr)
+				assert.Equal(t, "runtime.(*failingFunction).Execute", handledFunction)
+				assert.Equal(t, "data", handledInput)
+			} else {
+				assert.NoError(t, handledErr)
+			}
+		})
+	}
+}
+
+func TestGolangRuntime_ErrorPolicyDeadLetterFails(t *testing.T) {
+	failing := &failingFunction{failures: 1}
+	deadLetter := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		return false, errors.New("dead letter topic unavailable")
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{failing.Execute})
+	require.NoError(t, runtime.SetFunctionErrorPolicy(interfaces.DefaultPipelineId, 0,
+		interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyDeadLetter, DeadLetter: deadLetter}))
+
+	ctx := appfunction.NewContext("123", dic, "")
+	err := runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Err.Error(), "dead letter topic unavailable")
+}
+
+func TestGolangRuntime_ErrorPolicySandboxViolation(t *testing.T) {
+	var calls int
+	sandboxed := func(ctx interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		calls++
+		return false, fmt.Errorf("function in pipeline '%s': %w", ctx.PipelineId(), interfaces.SandboxViolation{
+			Function: "script 'grow'",
+			Limit:    interfaces.SandboxLimitMemory,
+			Ceiling:  "1024 bytes",
+		})
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{sandboxed})
+	require.NoError(t, runtime.SetFunctionErrorPolicy(interfaces.DefaultPipelineId, 0,
+		interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyRetry, Retries: 3}))
+
+	var violation interfaces.SandboxViolation
+	runtime.SetPipelineErrorHandler(func(_ interfaces.AppFunctionContext, err error, _ string, _ interface{}) {
+		errors.As(err, &violation)
+	})
+
+	ctx := appfunction.NewContext("123", dic, "")
+	err := runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false)
+	require.NotNil(t, err)
+	assert.Equal(t, 1, calls, "sandbox violation should not be retried")
+	assert.Equal(t, interfaces.SandboxLimitMemory, violation.Limit, "violation should be reported to the error handler")
+}
+
+func TestGolangRuntime_ReplaceFunctionErrorPolicies(t *testing.T) {
+	runtime := NewGolangRuntime("", nil, dic)
+
+	err := runtime.ReplaceFunctionErrorPolicies(map[string]map[int]interfaces.ErrorPolicy{
+		"pipeline": {1: {Action: "ignore"}},
+	})
+	require.Error(t, err)
+
+	err = runtime.ReplaceFunctionErrorPolicies(map[string]map[int]interfaces.ErrorPolicy{
+		"pipeline": {1: {Action: interfaces.ErrorPolicySkip}},
+	})
+	require.NoError(t, err)
+
+	policies, _ := runtime.errorHandling("pipeline")
+	assert.Equal(t, interfaces.ErrorPolicySkip, policies[1].Action)
+}
@@ -0,0 +1,54 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// UseMiddleware adds middleware wrapping every pipeline function, the first added being the outermost
+func (gr *GolangRuntime) UseMiddleware(middleware ...interfaces.Middleware) {
+	gr.isBusyCopying.Lock()
+	defer gr.isBusyCopying.Unlock()
+
+	for _, item := range middleware {
+		if item != nil {
+			gr.middleware = append(gr.middleware, item)
+		}
+	}
+}
+
+// applyMiddleware returns the functions wrapped with the middleware, or the functions themselves when there is none
+func (gr *GolangRuntime) applyMiddleware(functions []interfaces.AppFunction) []interfaces.AppFunction {
+	gr.isBusyCopying.Lock()
+	middleware := gr.middleware
+	gr.isBusyCopying.Unlock()
+
+	if len(middleware) == 0 {
+		return functions
+	}
+
+	wrapped := make([]interfaces.AppFunction, len(functions))
+	for index, function := range functions {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			function = middleware[i](function)
+		}
+		wrapped[index] = function
+	}
+
+	return wrapped
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestGolangRuntime_UseMiddleware(t *testing.T) {
+	var calls []string
+	tracing := func(name string) interfaces.Middleware {
+		return func(next interfaces.AppFunction) interfaces.AppFunction {
+			return func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+				calls = append(calls, name+" before")
+				continuePipeline, result := next(ctx, data)
+				calls = append(calls, name+" after")
+				return continuePipeline, result
+			}
+		}
+	}
+
+	upper := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		calls = append(calls, "function")
+		return true, data.(string) + "!"
+	}
+
+	var received interface{}
+	last := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		received = data
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{upper, last})
+	runtime.UseMiddleware(tracing("outer"), nil, tracing("inner"))
+
+	ctx := appfunction.NewContext("123", dic, "")
+	require.Nil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.Equal(t, "data!", received)
+	assert.Equal(t, []string{
+		"outer before", "inner before", "function", "inner after", "outer after",
+		"outer before", "inner before", "inner after", "outer after",
+	}, calls)
+}
+
+func TestGolangRuntime_UseMiddlewareValidation(t *testing.T) {
+	validating := func(next interfaces.AppFunction) interfaces.AppFunction {
+		return func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+			if data == nil {
+				return false, errors.New("no data")
+			}
+			return next(ctx, data)
+		}
+	}
+
+	var handledFunction string
+	failing := &failingFunction{failures: 1}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{failing.Execute})
+	runtime.UseMiddleware(validating)
+	runtime.SetPipelineErrorHandler(func(_ interfaces.AppFunctionContext, _ error, functionName string, _ interface{}) {
+		handledFunction = functionName
+	})
+	require.NoError(t, runtime.SetFunctionErrorPolicy(interfaces.DefaultPipelineId, 0,
+		interfaces.ErrorPolicy{Action: interfaces.ErrorPolicyRetry, Retries: 1}))
+
+	ctx := appfunction.NewContext("123", dic, "")
+	require.Nil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.Equal(t, 2, failing.calls, "retries should execute the wrapped function")
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{(&failingFunction{failures: 5}).Execute})
+	require.NotNil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.Equal(t, "runtime.(*failingFunction).Execute", handledFunction, "error handler should be given the wrapped function's name")
+}
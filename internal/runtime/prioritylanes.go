@@ -0,0 +1,219 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/diegoholiveira/jsonlogic"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
+)
+
+// priorityLane is a class of messages executed by the lane's own worker pool, so they aren't queued behind the
+// messages of the other lanes when the service is congested
+type priorityLane struct {
+	name          string
+	config        sdkCommon.PriorityLaneInfo
+	deviceNames   map[string]bool
+	resourceNames map[string]bool
+	pool          *workerPool
+}
+
+// laneMessage is the content of a message the priority lanes are matched against
+type laneMessage struct {
+	deviceName    string
+	resourceNames []string
+	payload       []byte
+}
+
+// newPriorityLanes validates the configured lanes and returns them ordered by priority, highest first
+func newPriorityLanes(config map[string]sdkCommon.PriorityLaneInfo) ([]*priorityLane, error) {
+	var lanes []*priorityLane
+	for name, laneConfig := range config {
+		if laneConfig.Workers <= 0 {
+			return nil, fmt.Errorf("invalid Workers for priority lane '%s', must be greater than 0", name)
+		}
+
+		if len(laneConfig.DeviceNames) == 0 && len(laneConfig.ResourceNames) == 0 && strings.TrimSpace(laneConfig.Expression) == "" {
+			return nil, fmt.Errorf("priority lane '%s' must have DeviceNames, ResourceNames or an Expression", name)
+		}
+
+		if strings.TrimSpace(laneConfig.Expression) != "" && !jsonlogic.IsValid(strings.NewReader(laneConfig.Expression)) {
+			return nil, fmt.Errorf("invalid JSONLogic Expression for priority lane '%s'", name)
+		}
+
+		lane := &priorityLane{
+			name:          name,
+			config:        laneConfig,
+			deviceNames:   make(map[string]bool),
+			resourceNames: make(map[string]bool),
+		}
+		for _, deviceName := range laneConfig.DeviceNames {
+			lane.deviceNames[strings.TrimSpace(deviceName)] = true
+		}
+		for _, resourceName := range laneConfig.ResourceNames {
+			lane.resourceNames[strings.TrimSpace(resourceName)] = true
+		}
+
+		lanes = append(lanes, lane)
+	}
+
+	sort.Slice(lanes, func(i, j int) bool {
+		if lanes[i].config.Priority != lanes[j].config.Priority {
+			return lanes[i].config.Priority > lanes[j].config.Priority
+		}
+		return lanes[i].name < lanes[j].name
+	})
+
+	return lanes, nil
+}
+
+// ValidatePriorityLanes returns an error if a priority lane's configuration is invalid, without starting any workers
+func ValidatePriorityLanes(config map[string]sdkCommon.PriorityLaneInfo) error {
+	_, err := newPriorityLanes(config)
+	return err
+}
+
+// matches returns whether the message is from one of the lane's devices, has a reading for one of the lane's resources
+// or the lane's expression results in true for the message's payload
+func (lane *priorityLane) matches(message laneMessage) bool {
+	if lane.deviceNames[message.deviceName] {
+		return true
+	}
+
+	for _, resourceName := range message.resourceNames {
+		if lane.resourceNames[resourceName] {
+			return true
+		}
+	}
+
+	if lane.config.Expression == "" || message.payload == nil {
+		return false
+	}
+
+	var result bytes.Buffer
+	if err := jsonlogic.Apply(strings.NewReader(lane.config.Expression), bytes.NewReader(message.payload), &result); err != nil {
+		return false
+	}
+
+	var matched bool
+	return json.NewDecoder(&result).Decode(&matched) == nil && matched
+}
+
+// selectLane returns the highest priority lane the message matches, or nil when it matches none
+func (gr *GolangRuntime) selectLane(envelope types.MessageEnvelope) *priorityLane {
+	if len(gr.priorityLanes) == 0 {
+		return nil
+	}
+
+	message := parseLaneMessage(envelope)
+	for _, lane := range gr.priorityLanes {
+		if lane.matches(message) {
+			return lane
+		}
+	}
+
+	return nil
+}
+
+// parseLaneMessage returns the device and resource names of the Event in the envelope's payload, which is either an
+// Event or AddEventRequest, and the payload as JSON for the lanes' expressions
+func parseLaneMessage(envelope types.MessageEnvelope) laneMessage {
+	type laneEvent struct {
+		DeviceName string `json:"deviceName"`
+		Readings   []struct {
+			ResourceName string `json:"resourceName"`
+		} `json:"readings"`
+	}
+
+	payload := struct {
+		laneEvent
+		Event laneEvent `json:"event"`
+	}{}
+
+	message := laneMessage{payload: envelope.Payload}
+
+	err := decodeEnvelope(envelope, &payload)
+	if _, found := codec.Lookup(envelope.ContentType); found && !isJSON(envelope.ContentType) {
+		message.payload = toJSON(envelope)
+	}
+
+	if err != nil {
+		return message
+	}
+
+	event := payload.laneEvent
+	if payload.Event.DeviceName != "" {
+		event = payload.Event
+	}
+
+	message.deviceName = event.DeviceName
+	for _, reading := range event.Readings {
+		message.resourceNames = append(message.resourceNames, reading.ResourceName)
+	}
+
+	return message
+}
+
+// isJSON returns true if the content type is JSON, ignoring its parameters
+func isJSON(contentType string) bool {
+	return strings.TrimSpace(strings.Split(contentType, ";")[0]) == common.ContentTypeJSON
+}
+
+// toJSON converts the payload, decoded with the codec registered for its content type, to JSON for the JSONLogic
+// expressions, returning nil if it can't be converted
+func toJSON(envelope types.MessageEnvelope) []byte {
+	var value interface{}
+	if err := codec.Unmarshal(envelope.ContentType, envelope.Payload, &value); err != nil {
+		return nil
+	}
+
+	data, err := json.Marshal(stringKeys(value))
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// stringKeys replaces the maps decoded by codecs such as CBOR, which have interface{} keys, with maps with string keys
+// so they can be marshaled to JSON
+func stringKeys(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			converted[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return converted
+	case []interface{}:
+		for index, item := range typed {
+			typed[index] = stringKeys(item)
+		}
+		return typed
+	default:
+		return value
+	}
+}
@@ -0,0 +1,154 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+func TestNewPriorityLanes(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Lane        sdkCommon.PriorityLaneInfo
+		ExpectError bool
+	}{
+		{"Valid device names", sdkCommon.PriorityLaneInfo{Workers: 1, DeviceNames: []string{"fire-panel"}}, false},
+		{"Valid expression", sdkCommon.PriorityLaneInfo{Workers: 1, Expression: `{"==": [{"var": "event.sourceName"}, "alarm"]}`}, false},
+		{"No workers", sdkCommon.PriorityLaneInfo{DeviceNames: []string{"fire-panel"}}, true},
+		{"No criteria", sdkCommon.PriorityLaneInfo{Workers: 1}, true},
+		{"Invalid expression", sdkCommon.PriorityLaneInfo{Workers: 1, Expression: `{"==": `}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := newPriorityLanes(map[string]sdkCommon.PriorityLaneInfo{"lane": test.Lane})
+			if test.ExpectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	lanes, err := newPriorityLanes(map[string]sdkCommon.PriorityLaneInfo{
+		"low":    {Priority: 1, Workers: 1, DeviceNames: []string{"a"}},
+		"high":   {Priority: 10, Workers: 1, DeviceNames: []string{"a"}},
+		"medium": {Priority: 5, Workers: 1, DeviceNames: []string{"a"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, lanes, 3)
+	assert.Equal(t, "high", lanes[0].name)
+	assert.Equal(t, "medium", lanes[1].name)
+	assert.Equal(t, "low", lanes[2].name)
+}
+
+func TestSelectLane(t *testing.T) {
+	lanes, err := newPriorityLanes(map[string]sdkCommon.PriorityLaneInfo{
+		"alarms":    {Priority: 10, Workers: 1, ResourceNames: []string{"Alarm"}, Expression: `{"==": [{"var": "event.sourceName"}, "alarm"]}`},
+		"equipment": {Priority: 5, Workers: 1, DeviceNames: []string{"compressor"}},
+	})
+	require.NoError(t, err)
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.priorityLanes = lanes
+
+	cborAlarm, err := cbor.Marshal(map[string]interface{}{
+		"event": map[string]interface{}{"deviceName": "boiler", "sourceName": "alarm"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name        string
+		ContentType string
+		Payload     string
+		Expected    string
+	}{
+		{"Resource", common.ContentTypeJSON, `{"event":{"deviceName":"compressor","readings":[{"resourceName":"Alarm"}]}}`, "alarms"},
+		{"Expression", common.ContentTypeJSON, `{"event":{"deviceName":"boiler","sourceName":"alarm"}}`, "alarms"},
+		{"CBOR expression", common.ContentTypeCBOR, string(cborAlarm), "alarms"},
+		{"Device", common.ContentTypeJSON, `{"deviceName":"compressor","readings":[{"resourceName":"Pressure","value":50}]}`, "equipment"},
+		{"No lane", common.ContentTypeJSON, `{"event":{"deviceName":"boiler","readings":[{"resourceName":"Pressure","value":50}]}}`, ""},
+		{"Not JSON", common.ContentTypeJSON, `not json`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			lane := runtime.selectLane(types.MessageEnvelope{ContentType: test.ContentType, Payload: []byte(test.Payload)})
+			if test.Expected == "" {
+				assert.Nil(t, lane)
+				return
+			}
+
+			require.NotNil(t, lane)
+			assert.Equal(t, test.Expected, lane.name)
+		})
+	}
+}
+
+func TestScheduleExecution_PriorityLane(t *testing.T) {
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		appWg.Wait()
+	}()
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	err := runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{
+		Workers:   1,
+		QueueSize: 10,
+		PriorityLanes: map[string]sdkCommon.PriorityLaneInfo{
+			"alarms": {Workers: 1, DeviceNames: []string{"fire-panel"}},
+		},
+	})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	// Congest the worker executing the telemetry
+	for i := 0; i < 5; i++ {
+		runtime.ScheduleExecution(types.MessageEnvelope{ContentType: common.ContentTypeJSON, Payload: []byte(`{"deviceName":"meter"}`)},
+			func() { <-release })
+	}
+
+	done := make(chan struct{})
+	runtime.ScheduleExecution(types.MessageEnvelope{ContentType: common.ContentTypeJSON, Payload: []byte(`{"deviceName":"fire-panel"}`)},
+		func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("priority lane message should not be queued behind the telemetry")
+	}
+
+	err = runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{
+		PriorityLanes: map[string]sdkCommon.PriorityLaneInfo{"alarms": {DeviceNames: []string{"fire-panel"}}},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,74 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// SetPipelineRouter sets the router choosing the pipelines that handle each message, nil to only match the topics
+func (gr *GolangRuntime) SetPipelineRouter(router interfaces.PipelineRouter) {
+	gr.isBusyCopying.Lock()
+	gr.router = router
+	gr.isBusyCopying.Unlock()
+}
+
+// RoutePipelines returns the pipelines the router chooses for the message, or those whose topics match the message's
+// topic when no router is set or it chooses none. The ids the router returns with no pipeline are logged and skipped.
+func (gr *GolangRuntime) RoutePipelines(envelope types.MessageEnvelope) []*interfaces.FunctionPipeline {
+	gr.isBusyCopying.Lock()
+	router := gr.router
+	gr.isBusyCopying.Unlock()
+
+	if router == nil {
+		return gr.GetMatchingPipelines(envelope.ReceivedTopic)
+	}
+
+	ids := router(interfaces.RoutingInfo{
+		ReceivedTopic: envelope.ReceivedTopic,
+		ContentType:   envelope.ContentType,
+		DeviceName:    eventDeviceName(envelope),
+		CorrelationID: envelope.CorrelationID,
+	})
+	if len(ids) == 0 {
+		return gr.GetMatchingPipelines(envelope.ReceivedTopic)
+	}
+
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	var pipelines []*interfaces.FunctionPipeline
+	routed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if routed[id] {
+			continue
+		}
+		routed[id] = true
+
+		pipeline := gr.GetPipelineById(id)
+		if pipeline == nil {
+			lc.Warnf("Pipeline router chose pipeline '%s' which doesn't exist (%s=%s)", id, common.CorrelationHeader, envelope.CorrelationID)
+			continue
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+
+	return pipelines
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestGolangRuntime_RoutePipelines(t *testing.T) {
+	noop := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return true, data
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	require.NoError(t, runtime.AddFunctionsPipeline("thermostats", []string{"edgex/events/#"}, []interfaces.AppFunction{noop}))
+	require.NoError(t, runtime.AddFunctionsPipeline("cameras", []string{"edgex/cameras"}, []interfaces.AppFunction{noop}))
+
+	envelope := types.MessageEnvelope{
+		ReceivedTopic: "edgex/events/device/thermostat",
+		ContentType:   "application/json",
+		CorrelationID: "123",
+		Payload:       []byte(`{"apiVersion":"v2","event":{"deviceName":"camera-1"}}`),
+	}
+
+	pipelineIds := func(pipelines []*interfaces.FunctionPipeline) []string {
+		var ids []string
+		for _, pipeline := range pipelines {
+			ids = append(ids, pipeline.Id)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"thermostats"}, pipelineIds(runtime.RoutePipelines(envelope)), "topics should be matched without a router")
+
+	var received interfaces.RoutingInfo
+	runtime.SetPipelineRouter(func(message interfaces.RoutingInfo) []string {
+		received = message
+		if message.DeviceName == "camera-1" {
+			return []string{"cameras", "unknown", "cameras"}
+		}
+		return nil
+	})
+
+	assert.Equal(t, []string{"cameras"}, pipelineIds(runtime.RoutePipelines(envelope)), "unknown and repeated ids should be skipped")
+	assert.Equal(t, interfaces.RoutingInfo{
+		ReceivedTopic: envelope.ReceivedTopic,
+		ContentType:   envelope.ContentType,
+		DeviceName:    "camera-1",
+		CorrelationID: envelope.CorrelationID,
+	}, received)
+
+	envelope.Payload = []byte(`{"deviceName":"thermostat-1"}`)
+	assert.Equal(t, []string{"thermostats"}, pipelineIds(runtime.RoutePipelines(envelope)), "topics should be matched when the router chooses none")
+
+	runtime.SetPipelineRouter(nil)
+	envelope.Payload = []byte(`{"apiVersion":"v2","event":{"deviceName":"camera-1"}}`)
+	assert.Equal(t, []string{"thermostats"}, pipelineIds(runtime.RoutePipelines(envelope)))
+}
@@ -19,18 +19,28 @@ package runtime
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/accounting"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/watchdog"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
@@ -41,8 +51,6 @@ import (
 	edgexErrors "github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/models"
 	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
-
-	"github.com/fxamacker/cbor/v2"
 )
 
 const (
@@ -63,12 +71,24 @@ func NewFunctionPipeline(id string, topics []string, transforms []interfaces.App
 
 // GolangRuntime represents the golang runtime environment
 type GolangRuntime struct {
-	TargetType    interface{}
-	ServiceKey    string
-	pipelines     map[string]*interfaces.FunctionPipeline
-	isBusyCopying sync.Mutex
-	storeForward  storeForwardInfo
-	dic           *di.Container
+	TargetType      interface{}
+	ServiceKey      string
+	pipelines       map[string]*interfaces.FunctionPipeline
+	isBusyCopying   sync.Mutex
+	storeForward    storeForwardInfo
+	workerPool      *workerPool
+	priorityLanes   []*priorityLane
+	executions      executionTracker
+	duplicates      *duplicateFilter
+	commitLog       commitLogInfo
+	draining        sdkCommon.AtomicBool
+	errorPolicies   map[string]map[int]interfaces.ErrorPolicy
+	errorHandler    interfaces.PipelineErrorHandler
+	pipelineTimeout time.Duration
+	middleware      []interfaces.Middleware
+	router          interfaces.PipelineRouter
+	ctx             context.Context
+	dic             *di.Container
 }
 
 type MessageError struct {
@@ -87,6 +107,7 @@ func NewGolangRuntime(serviceKey string, targetType interface{}, dic *di.Contain
 
 	gr.storeForward.dic = dic
 	gr.storeForward.runtime = gr
+	gr.storeForward.monitor = newConnectivityMonitor(nil)
 
 	return gr
 }
@@ -102,7 +123,7 @@ func (gr *GolangRuntime) SetDefaultFunctionsPipeline(transforms []interfaces.App
 func (gr *GolangRuntime) SetFunctionsPipelineTransforms(id string, transforms []interfaces.AppFunction) {
 	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
 
-	pipeline := gr.pipelines[id]
+	pipeline := gr.GetPipelineById(id)
 	if pipeline != nil {
 		gr.isBusyCopying.Lock()
 		pipeline.Transforms = transforms
@@ -124,10 +145,26 @@ func (gr *GolangRuntime) ClearAllFunctionsPipelineTransforms() {
 	gr.isBusyCopying.Unlock()
 }
 
+// ReplaceFunctionsPipelines replaces all the function pipelines at once, i.e. with those reloaded from a changed
+// configuration, adding and removing pipelines as well as updating their transforms, topics and shadow mode.
+// Messages already being processed complete with the pipelines they matched, the next messages are processed
+// with the new pipelines.
+func (gr *GolangRuntime) ReplaceFunctionsPipelines(pipelines map[string]interfaces.FunctionPipeline) {
+	replacements := make(map[string]*interfaces.FunctionPipeline, len(pipelines))
+	for id, pipeline := range pipelines {
+		replacement := NewFunctionPipeline(id, pipeline.Topics, pipeline.Transforms)
+		replacement.ShadowMode = pipeline.ShadowMode
+		replacements[id] = &replacement
+	}
+
+	gr.isBusyCopying.Lock()
+	gr.pipelines = replacements
+	gr.isBusyCopying.Unlock()
+}
+
 // AddFunctionsPipeline is thread safe to set transforms
 func (gr *GolangRuntime) AddFunctionsPipeline(id string, topics []string, transforms []interfaces.AppFunction) error {
-	_, exists := gr.pipelines[id]
-	if exists {
+	if gr.GetPipelineById(id) != nil {
 		return fmt.Errorf("pipeline with Id='%s' already exists", id)
 	}
 
@@ -136,6 +173,25 @@ func (gr *GolangRuntime) AddFunctionsPipeline(id string, topics []string, transf
 	return nil
 }
 
+// AddShadowFunctionsPipeline adds a shadow pipeline, in the specified mode, that executes on copies of the messages
+// whose topic matches the pipeline's topics. See ProcessMessage for how shadow pipelines are sandboxed.
+func (gr *GolangRuntime) AddShadowFunctionsPipeline(id string, topics []string, mode string, transforms []interfaces.AppFunction) error {
+	if mode != interfaces.ShadowModeLog && mode != interfaces.ShadowModeSend {
+		return fmt.Errorf("invalid shadow mode '%s' for pipeline with Id='%s', must be '%s' or '%s'",
+			mode, id, interfaces.ShadowModeLog, interfaces.ShadowModeSend)
+	}
+
+	if err := gr.AddFunctionsPipeline(id, topics, transforms); err != nil {
+		return err
+	}
+
+	gr.isBusyCopying.Lock()
+	gr.pipelines[id].ShadowMode = mode
+	gr.isBusyCopying.Unlock()
+
+	return nil
+}
+
 func (gr *GolangRuntime) addFunctionsPipeline(id string, topics []string, transforms []interfaces.AppFunction) *interfaces.FunctionPipeline {
 	pipeline := NewFunctionPipeline(id, topics, transforms)
 	gr.isBusyCopying.Lock()
@@ -145,12 +201,54 @@ func (gr *GolangRuntime) addFunctionsPipeline(id string, topics []string, transf
 	return &pipeline
 }
 
-// ProcessMessage sends the contents of the message through the functions pipeline
+// ProcessMessage sends the contents of the message through the functions pipeline.
+// A shadow pipeline is sandboxed so it can't affect the trigger: the SHADOW context value is set for the export
+// functions, its error is logged rather than returned and its response data and topic responses are discarded.
 func (gr *GolangRuntime) ProcessMessage(
 	appContext *appfunction.Context,
 	envelope types.MessageEnvelope,
 	pipeline *interfaces.FunctionPipeline) *MessageError {
+	if pipeline.ShadowMode == "" {
+		return gr.processMessage(appContext, envelope, pipeline)
+	}
+
 	lc := appContext.LoggingClient()
+	appContext.AddValue(interfaces.SHADOW, pipeline.ShadowMode)
+
+	messageError := gr.processMessage(appContext, envelope, pipeline)
+
+	if len(appContext.ResponseData()) > 0 {
+		lc.Infof("Shadow pipeline '%s' response data of %d bytes not published (%s=%s)",
+			pipeline.Id, len(appContext.ResponseData()), common.CorrelationHeader, envelope.CorrelationID)
+		appContext.SetResponseData(nil)
+	}
+
+	if len(appContext.TopicResponses()) > 0 {
+		lc.Infof("Shadow pipeline '%s' data for %d topic(s) not published (%s=%s)",
+			pipeline.Id, len(appContext.TopicResponses()), common.CorrelationHeader, envelope.CorrelationID)
+		appContext.ClearTopicResponses()
+	}
+
+	if messageError != nil {
+		lc.Warnf("Shadow pipeline '%s' failed, error not returned to trigger: %s (%s=%s)",
+			pipeline.Id, messageError.Err.Error(), common.CorrelationHeader, envelope.CorrelationID)
+	}
+
+	return nil
+}
+
+func (gr *GolangRuntime) processMessage(
+	appContext *appfunction.Context,
+	envelope types.MessageEnvelope,
+	pipeline *interfaces.FunctionPipeline) *MessageError {
+	lc := appContext.LoggingClient()
+
+	// Executions still queued when the service stops after the DrainTimeout fail rather than start
+	if gr.ctx != nil && gr.ctx.Err() != nil {
+		err := fmt.Errorf("service is shutting down, pipeline '%s' not executed", pipeline.Id)
+		logError(lc, err, envelope.CorrelationID)
+		return &MessageError{Err: err, ErrorCode: http.StatusServiceUnavailable}
+	}
 
 	if len(pipeline.Transforms) == 0 {
 		err := fmt.Errorf("no transforms configured for pipleline Id='%s'. Please check log for earlier errors loading pipeline", pipeline.Id)
@@ -160,8 +258,19 @@ func (gr *GolangRuntime) ProcessMessage(
 
 	appContext.AddValue(interfaces.RECEIVEDTOPIC, envelope.ReceivedTopic)
 	appContext.AddValue(interfaces.PIPELINEID, pipeline.Id)
+	if config := container.ConfigurationFrom(gr.dic.Get); config != nil && config.Writable.Lineage.Enabled {
+		appContext.AddValue(interfaces.SERVICEKEY, gr.ServiceKey)
+		appContext.AddValue(interfaces.PIPELINEVERSION, pipelineVersion(pipeline.Hash))
+	}
+	// Data retried by Store and Forward keeps the time it was originally received
+	if _, found := appContext.GetValue(interfaces.RECEIVEDTIME); !found {
+		appContext.AddValue(interfaces.RECEIVEDTIME, time.Now().UTC().Format(time.RFC3339Nano))
+	}
 
-	lc.Debugf("Pipeline '%s' processing message %d Transforms", pipeline.Id, len(pipeline.Transforms))
+	sample := gr.startCapture(pipeline.Id, envelope)
+	if sample != nil {
+		defer gr.endCapture(sample)
+	}
 
 	// Default Target Type for the function pipeline is an Event DTO.
 	// The Event DTO can be wrapped in an AddEventRequest DTO or just be the un-wrapped Event DTO,
@@ -178,6 +287,7 @@ func (gr *GolangRuntime) ProcessMessage(
 
 	// Must make a copy of the type so that data isn't retained between calls for custom types
 	target := reflect.New(reflect.ValueOf(gr.TargetType).Elem().Type()).Interface()
+	var deviceName string
 
 	switch target.(type) {
 	case *[]byte:
@@ -205,10 +315,16 @@ func (gr *GolangRuntime) ProcessMessage(
 			gr.debugLogEvent(lc, event)
 		}
 
+		if deviceMonitor := container.DeviceMonitorFrom(gr.dic.Get); deviceMonitor != nil && pipeline.ShadowMode == "" {
+			deviceMonitor.Seen(event.DeviceName, time.Now())
+		}
+
 		appContext.AddValue(interfaces.DEVICENAME, event.DeviceName)
 		appContext.AddValue(interfaces.PROFILENAME, event.ProfileName)
 		appContext.AddValue(interfaces.SOURCENAME, event.SourceName)
+		appContext.AddValue(interfaces.EVENTID, event.Id)
 
+		deviceName = event.DeviceName
 		target = event
 
 	default:
@@ -223,6 +339,25 @@ func (gr *GolangRuntime) ProcessMessage(
 		}
 	}
 
+	router := container.TenantRouterFrom(gr.dic.Get)
+	var tenant string
+	if router != nil {
+		var err error
+		tenant, err = router.Resolve(appContext, envelope.ReceivedTopic, deviceName)
+		if err != nil {
+			appContext.RemoveValue(interfaces.TENANT)
+			err = fmt.Errorf("unable to route message to a tenant: %s", err.Error())
+			logError(lc, err, envelope.CorrelationID)
+			return &MessageError{Err: err, ErrorCode: http.StatusForbidden}
+		}
+
+		// The tenant is added last so a setting can't replace it
+		for name, value := range router.Settings(tenant) {
+			appContext.AddValue(name, value)
+		}
+		appContext.AddValue(interfaces.TENANT, tenant)
+	}
+
 	appContext.SetCorrelationID(envelope.CorrelationID)
 
 	// All functions expect an object, not a pointer to an object, so must use reflection to
@@ -236,11 +371,52 @@ func (gr *GolangRuntime) ProcessMessage(
 		Transforms: make([]interfaces.AppFunction, len(pipeline.Transforms)),
 		Topics:     pipeline.Topics,
 		Hash:       pipeline.Hash,
+		ShadowMode: pipeline.ShadowMode,
 	}
 	copy(execPipeline.Transforms, pipeline.Transforms)
 	gr.isBusyCopying.Unlock()
 
-	return gr.ExecutePipeline(target, envelope.ContentType, appContext, execPipeline, 0, false)
+	messageError := gr.executePipeline(target, envelope.ContentType, appContext, execPipeline, 0, false, sample)
+
+	if router != nil && pipeline.ShadowMode == "" {
+		router.Record(tenant, messageError == nil)
+	}
+
+	return messageError
+}
+
+// DryRun executes the pipeline for the message as a shadow pipeline in ShadowModeLog, so the export functions log the
+// data rather than send it and failed exports aren't stored for retry, and returns the pipeline's error. The response
+// data is discarded.
+func (gr *GolangRuntime) DryRun(envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) *MessageError {
+	dryRunPipeline := *pipeline
+	dryRunPipeline.ShadowMode = interfaces.ShadowModeLog
+
+	appContext := appfunction.NewContext(envelope.CorrelationID, gr.dic, envelope.ContentType)
+	appContext.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+
+	return gr.processMessage(appContext, envelope, &dryRunPipeline)
+}
+
+// startCapture returns a new sample of the message when capture is enabled and the message is selected, otherwise nil
+func (gr *GolangRuntime) startCapture(pipelineId string, envelope types.MessageEnvelope) *capture.Sample {
+	captureBuffer := container.CaptureBufferFrom(gr.dic.Get)
+	if captureBuffer == nil {
+		return nil
+	}
+
+	captureConfig := container.ConfigurationFrom(gr.dic.Get).Writable.Capture
+	if !captureConfig.Enabled {
+		return nil
+	}
+
+	return captureBuffer.Start(captureConfig.SampleRate, pipelineId, envelope.CorrelationID, envelope.ReceivedTopic,
+		envelope.ContentType, envelope.Payload)
+}
+
+func (gr *GolangRuntime) endCapture(sample *capture.Sample) {
+	captureBuffer := container.CaptureBufferFrom(gr.dic.Get)
+	captureBuffer.Add(sample, container.ConfigurationFrom(gr.dic.Get).Writable.Capture.MaxSamples)
 }
 
 func (gr *GolangRuntime) ExecutePipeline(
@@ -250,46 +426,244 @@ func (gr *GolangRuntime) ExecutePipeline(
 	pipeline *interfaces.FunctionPipeline,
 	startPosition int,
 	isRetry bool) *MessageError {
+	return gr.executePipeline(target, contentType, appContext, pipeline, startPosition, isRetry, nil)
+}
+
+// executePipeline executes the pipeline, adding the result of each function to the sample when it isn't nil
+func (gr *GolangRuntime) executePipeline(
+	target interface{},
+	contentType string,
+	appContext *appfunction.Context,
+	pipeline *interfaces.FunctionPipeline,
+	startPosition int,
+	isRetry bool,
+	sample *capture.Sample) *MessageError {
+
+	// The exports store their data for later retry when offline, which also holds them back during maintenance
+	if gr.storeForward.monitor.isOffline() || gr.storeForward.inMaintenance() {
+		appContext.AddValue(interfaces.NETWORKOFFLINE, "true")
+	} else {
+		appContext.RemoveValue(interfaces.NETWORKOFFLINE)
+	}
+
+	var execution *watchdog.Execution
+	if executionWatchdog := container.WatchdogFrom(gr.dic.Get); executionWatchdog != nil {
+		execution = executionWatchdog.Begin(pipeline.Id, appContext.CorrelationID())
+		defer execution.End()
+	}
+
+	var costs *accounting.Execution
+	if accountant := container.AccountantFrom(gr.dic.Get); accountant != nil {
+		costs = accountant.Begin(pipeline.Id, appContext.CorrelationID())
+		defer costs.End()
+	}
+
+	endContext := gr.startContext(appContext)
+	defer endContext()
+
+	timeout := gr.startTimeout(appContext, pipeline)
+	defer timeout.Stop()
+
+	errorPolicies, errorHandler := gr.errorHandling(pipeline.Id)
+
+	state := &pipelineExecution{
+		contentType:   contentType,
+		appContext:    appContext,
+		pipeline:      pipeline,
+		functions:     gr.applyMiddleware(pipeline.Transforms),
+		isRetry:       isRetry,
+		sample:        sample,
+		watchdog:      execution,
+		costs:         costs,
+		timeout:       timeout,
+		errorPolicies: errorPolicies,
+		errorHandler:  errorHandler,
+	}
+	state.recordEvent(target)
+
+	_, _, err := gr.executeFunctions(state, target, startPosition, false)
+
+	// The readings are cached as the functions last passed them on, unless a function filtered them out. A shadow
+	// pipeline's messages are also received by the production pipelines, which update the cache.
+	if state.lastEvent != nil && !state.filtered && pipeline.ShadowMode == "" {
+		if lastValueCache := container.LastValueCacheFrom(gr.dic.Get); lastValueCache != nil {
+			lastValueCache.Update(*state.lastEvent)
+		}
+	}
+
+	if metricsHistory := container.MetricsHistoryFrom(gr.dic.Get); metricsHistory != nil {
+		metricsHistory.Record(pipeline.Id, err != nil)
+	}
+
+	return err
+}
+
+// pipelineExecution is the state of a pipeline execution, shared by the continuations of the items of split data
+type pipelineExecution struct {
+	contentType   string
+	appContext    *appfunction.Context
+	pipeline      *interfaces.FunctionPipeline
+	functions     []interfaces.AppFunction
+	isRetry       bool
+	sample        *capture.Sample
+	watchdog      *watchdog.Execution
+	costs         *accounting.Execution
+	timeout       *executionTimeout
+	errorPolicies map[int]interfaces.ErrorPolicy
+	errorHandler  interfaces.PipelineErrorHandler
+	// lastEvent is the last Event passed on by the functions, or the Event received when none has been
+	lastEvent *dtos.Event
+	// filtered is set when a function other than the last stopped the pipeline without an error
+	filtered bool
+}
+
+// recordEvent records the data passed on by a function when it's an Event
+func (state *pipelineExecution) recordEvent(data interface{}) {
+	switch event := data.(type) {
+	case dtos.Event:
+		state.lastEvent = &event
+	case *dtos.Event:
+		if event != nil {
+			state.lastEvent = event
+		}
+	}
+}
+
+// executeFunctions executes the pipeline functions from the start position with the target until one stops the
+// pipeline or all have executed. When continuing the pipeline for an item of split data, execution also stops at the
+// function requesting the item be merged, returning the item and the function's index, which is otherwise -1.
+func (gr *GolangRuntime) executeFunctions(
+	state *pipelineExecution,
+	target interface{},
+	startPosition int,
+	continuingItem bool) (interface{}, int, *MessageError) {
 
 	var result interface{}
 	var continuePipeline bool
 
-	for functionIndex, trxFunc := range pipeline.Transforms {
-		if functionIndex < startPosition {
-			continue
+	// The error of an item of split data whose continuation failed, returned once the other items have been merged
+	// and the pipeline has completed
+	var itemErr *MessageError
+
+	appContext := state.appContext
+	pipeline := state.pipeline
+
+	for functionIndex := startPosition; functionIndex < len(pipeline.Transforms); functionIndex++ {
+		trxFunc := state.functions[functionIndex]
+
+		if state.timeout.Expired() {
+			err := fmt.Errorf("pipeline '%s' execution aborted, exceeded its %s timeout before function #%d %s",
+				pipeline.Id, state.timeout.timeout.String(), functionIndex, functionName(pipeline.Transforms[functionIndex]))
+			logError(appContext.LoggingClient(), err, appContext.CorrelationID())
+			return nil, -1, &MessageError{Err: err, ErrorCode: http.StatusGatewayTimeout}
 		}
 
+		state.timeout.Function(functionIndex)
+		state.watchdog.Function(functionIndex)
 		appContext.SetRetryData(nil)
 
-		if result == nil {
-			appContext.SetInputContentType(contentType)
-			continuePipeline, result = trxFunc(appContext, target)
-		} else {
-			continuePipeline, result = trxFunc(appContext, result)
+		input := result
+		if input == nil {
+			appContext.SetInputContentType(state.contentType)
+			input = target
+		}
+
+		continuePipeline, result = state.costs.Measure(functionIndex, func() (bool, interface{}) {
+			return trxFunc(appContext, input)
+		})
+
+		if state.sample != nil {
+			state.sample.AddStage(functionIndex, continuePipeline, result)
 		}
 
 		if !continuePipeline {
-			if result != nil {
-				if err, ok := result.(error); ok {
-					appContext.LoggingClient().Errorf(
-						"Pipeline (%s) function #%d resulted in error: %s (%s=%s)",
-						pipeline.Id,
-						functionIndex,
-						err.Error(),
-						common.CorrelationHeader,
-						appContext.CorrelationID())
-					if appContext.RetryData() != nil && !isRetry {
-						gr.storeForward.storeForLaterRetry(appContext.RetryData(), appContext, pipeline, functionIndex)
-					}
-
-					return &MessageError{Err: err, ErrorCode: http.StatusUnprocessableEntity}
+			if err, ok := result.(error); ok {
+				var messageErr *MessageError
+				continuePipeline, result, messageErr = gr.handleFunctionError(state, functionIndex, input, err)
+				if messageErr != nil {
+					return nil, -1, messageErr
+				}
+			}
+
+			if !continuePipeline {
+				if functionIndex < len(pipeline.Transforms)-1 {
+					state.filtered = true
 				}
+				break
 			}
-			break
+		}
+
+		state.recordEvent(result)
+
+		switch output := result.(type) {
+		case interfaces.MergeRequest:
+			if _, merging := input.(interfaces.SplitData); merging {
+				err := fmt.Errorf("function #%d requested merging the split data it was merging", functionIndex)
+				return nil, -1, &MessageError{Err: err, ErrorCode: http.StatusUnprocessableEntity}
+			}
+
+			if continuingItem {
+				return output.Item, functionIndex, nil
+			}
+
+			// The continuation of an item retried by Store and Forward is merged on its own
+			result = interfaces.SplitData{output.Item}
+			functionIndex--
+
+		case interfaces.SplitData:
+			merging, mergeIndex, err := gr.executeSplit(state, output, functionIndex+1)
+			if err != nil {
+				itemErr = err
+			}
+
+			if mergeIndex < 0 {
+				return nil, -1, itemErr
+			}
+
+			// Continue with the function that requested the merge, which receives the items to merge
+			result = merging
+			functionIndex = mergeIndex - 1
 		}
 	}
 
-	return nil
+	return nil, -1, itemErr
+}
+
+// executeSplit continues the pipeline from the start position for each of the items, in order. Returns the items whose
+// continuations requested merging, the index of the function that requested it, or -1 when none did, and the error of
+// the first item whose continuation failed. Failed continuations are stored for retry on their own.
+func (gr *GolangRuntime) executeSplit(
+	state *pipelineExecution,
+	items interfaces.SplitData,
+	startPosition int) (interfaces.SplitData, int, *MessageError) {
+
+	mergeIndex := -1
+	merging := interfaces.SplitData{}
+	var firstErr *MessageError
+
+	for _, item := range items {
+		result, itemMergeIndex, err := gr.executeFunctions(state, item, startPosition, true)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+
+		if itemMergeIndex < 0 {
+			continue
+		}
+
+		if mergeIndex >= 0 && itemMergeIndex != mergeIndex {
+			err := fmt.Errorf("split data items requested merging by different functions, #%d and #%d", mergeIndex, itemMergeIndex)
+			return nil, -1, &MessageError{Err: err, ErrorCode: http.StatusUnprocessableEntity}
+		}
+
+		mergeIndex = itemMergeIndex
+		merging = append(merging, result)
+	}
+
+	return merging, mergeIndex, firstErr
 }
 
 func (gr *GolangRuntime) StartStoreAndForward(
@@ -302,6 +676,118 @@ func (gr *GolangRuntime) StartStoreAndForward(
 	gr.storeForward.startStoreAndForwardRetryLoop(appWg, appCtx, enabledWg, enabledCtx, serviceKey)
 }
 
+// StartWorkerPool starts the configured number of workers used to execute the function pipelines for messages
+// received by the triggers, and the workers of each priority lane. No workers are started for the messages not in a
+// priority lane when none are configured and their executions are not limited.
+// Returns an error if a priority lane's configuration is invalid.
+func (gr *GolangRuntime) StartWorkerPool(appWg *sync.WaitGroup, appCtx context.Context, config sdkCommon.WorkerPoolConfig) error {
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	lanes, err := newPriorityLanes(config.PriorityLanes)
+	if err != nil {
+		return err
+	}
+
+	for _, lane := range lanes {
+		lc.Infof("Starting priority lane '%s' with priority %d, %d workers and queue size of %d",
+			lane.name, lane.config.Priority, lane.config.Workers, lane.config.QueueSize)
+
+		laneConfig := sdkCommon.WorkerPoolConfig{
+			Workers:           lane.config.Workers,
+			QueueSize:         lane.config.QueueSize,
+			OrderByDeviceName: config.OrderByDeviceName,
+		}
+		lane.pool = newWorkerPool(laneConfig, appCtx, lc)
+		lane.pool.start(appWg, laneConfig.Workers)
+	}
+	gr.priorityLanes = lanes
+
+	if config.Workers <= 0 {
+		return nil
+	}
+
+	lc.Infof("Starting worker pool with %d workers, queue size of %d and OrderByDeviceName=%v",
+		config.Workers, config.QueueSize, config.OrderByDeviceName)
+
+	gr.workerPool = newWorkerPool(config, appCtx, lc)
+	gr.workerPool.start(appWg, config.Workers)
+	return nil
+}
+
+// ScheduleExecution runs the pipeline execution job for the received message. The job is queued to the worker pool of
+// the highest priority lane the message matches, otherwise to the worker pool when one has been started, blocking
+// while the pool is at capacity. Otherwise the job is run in its own go routine.
+// Returns false if the job was not scheduled because the service is shutting down.
+func (gr *GolangRuntime) ScheduleExecution(envelope types.MessageEnvelope, job func()) bool {
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+
+	if gr.draining.Value() {
+		lc.Warnf("Service is shutting down, pipeline execution not scheduled (%s=%s)", common.CorrelationHeader, envelope.CorrelationID)
+		return false
+	}
+
+	gr.executions.add()
+	trackedJob := func() {
+		defer gr.executions.done()
+		job()
+	}
+
+	pool := gr.workerPool
+	if lane := gr.selectLane(envelope); lane != nil {
+		lc.Debugf("Message scheduled in priority lane '%s' (%s=%s)", lane.name, common.CorrelationHeader, envelope.CorrelationID)
+		pool = lane.pool
+	}
+
+	if pool == nil {
+		go runWithRecovery(trackedJob, lc)
+		return true
+	}
+
+	var key string
+	if pool.ordered {
+		key = orderingKey(envelope)
+	}
+
+	if !pool.submit(key, trackedJob) {
+		gr.executions.done()
+		return false
+	}
+
+	return true
+}
+
+// DecodePayload decodes the message's payload into the TargetType, as the pipelines receive it, without executing them
+func (gr *GolangRuntime) DecodePayload(envelope types.MessageEnvelope) (interface{}, error) {
+	targetType := gr.TargetType
+	if targetType == nil {
+		targetType = &dtos.Event{}
+	}
+
+	if reflect.TypeOf(targetType).Kind() != reflect.Ptr {
+		return nil, errors.New("TargetType must be a pointer, not a value of the target type")
+	}
+
+	target := reflect.New(reflect.ValueOf(targetType).Elem().Type()).Interface()
+
+	switch target.(type) {
+	case *[]byte:
+		return envelope.Payload, nil
+
+	case *dtos.Event:
+		event, err := gr.processEventPayload(envelope, bootstrapContainer.LoggingClientFrom(gr.dic.Get))
+		if err != nil {
+			return nil, err
+		}
+		return *event, nil
+
+	default:
+		if err := gr.unmarshalPayload(envelope, target); err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(target).Elem().Interface(), nil
+	}
+}
+
 func (gr *GolangRuntime) processEventPayload(envelope types.MessageEnvelope, lc logger.LoggingClient) (*dtos.Event, error) {
 
 	lc.Debug("Attempting to process Payload as an AddEventRequest DTO")
@@ -345,22 +831,16 @@ func (gr *GolangRuntime) processEventPayload(envelope types.MessageEnvelope, lc
 }
 
 func (gr *GolangRuntime) unmarshalPayload(envelope types.MessageEnvelope, target interface{}) error {
-	var err error
-
-	contentType := strings.Split(envelope.ContentType, ";")[0]
-
-	switch contentType {
-	case common.ContentTypeJSON:
-		err = json.Unmarshal(envelope.Payload, target)
-
-	case common.ContentTypeCBOR:
-		err = cbor.Unmarshal(envelope.Payload, target)
+	return codec.Unmarshal(envelope.ContentType, envelope.Payload, target)
+}
 
-	default:
-		err = fmt.Errorf("unsupported content-type '%s' recieved", envelope.ContentType)
+// decodeEnvelope unmarshals the message's payload with the codec registered for its content type, or as JSON when
+// there is none, for the runtime's own use of the payload's fields
+func decodeEnvelope(envelope types.MessageEnvelope, target interface{}) error {
+	if _, found := codec.Lookup(envelope.ContentType); !found {
+		return json.Unmarshal(envelope.Payload, target)
 	}
-
-	return err
+	return codec.Unmarshal(envelope.ContentType, envelope.Payload, target)
 }
 
 func (gr *GolangRuntime) debugLogEvent(lc logger.LoggingClient, event *dtos.Event) {
@@ -394,7 +874,7 @@ func (gr *GolangRuntime) debugLogEvent(lc logger.LoggingClient, event *dtos.Even
 }
 
 func (gr *GolangRuntime) GetDefaultPipeline() *interfaces.FunctionPipeline {
-	pipeline := gr.pipelines[interfaces.DefaultPipelineId]
+	pipeline := gr.GetPipelineById(interfaces.DefaultPipelineId)
 	if pipeline == nil {
 		pipeline = gr.addFunctionsPipeline(interfaces.DefaultPipelineId, []string{TopicWildCard}, nil)
 	}
@@ -404,6 +884,9 @@ func (gr *GolangRuntime) GetDefaultPipeline() *interfaces.FunctionPipeline {
 func (gr *GolangRuntime) GetMatchingPipelines(incomingTopic string) []*interfaces.FunctionPipeline {
 	var matches []*interfaces.FunctionPipeline
 
+	gr.isBusyCopying.Lock()
+	defer gr.isBusyCopying.Unlock()
+
 	if len(gr.pipelines) == 0 {
 		return matches
 	}
@@ -417,7 +900,45 @@ func (gr *GolangRuntime) GetMatchingPipelines(incomingTopic string) []*interface
 	return matches
 }
 
+// GetShadowPipelines returns the shadow pipelines, for triggers that don't match pipelines by topic
+func (gr *GolangRuntime) GetShadowPipelines() []*interfaces.FunctionPipeline {
+	var shadows []*interfaces.FunctionPipeline
+
+	gr.isBusyCopying.Lock()
+	defer gr.isBusyCopying.Unlock()
+
+	for _, pipeline := range gr.pipelines {
+		if pipeline.ShadowMode != "" {
+			shadows = append(shadows, pipeline)
+		}
+	}
+
+	return shadows
+}
+
+// GetProductionPipelines returns the pipelines that aren't shadow pipelines, ordered by id
+func (gr *GolangRuntime) GetProductionPipelines() []*interfaces.FunctionPipeline {
+	var pipelines []*interfaces.FunctionPipeline
+
+	gr.isBusyCopying.Lock()
+	for _, pipeline := range gr.pipelines {
+		if pipeline.ShadowMode == "" {
+			pipelines = append(pipelines, pipeline)
+		}
+	}
+	gr.isBusyCopying.Unlock()
+
+	sort.Slice(pipelines, func(i, j int) bool {
+		return pipelines[i].Id < pipelines[j].Id
+	})
+
+	return pipelines
+}
+
 func (gr *GolangRuntime) GetPipelineById(id string) *interfaces.FunctionPipeline {
+	gr.isBusyCopying.Lock()
+	defer gr.isBusyCopying.Unlock()
+
 	return gr.pipelines[id]
 }
 
@@ -466,6 +987,12 @@ func calculatePipelineHash(transforms []interfaces.AppFunction) string {
 	return hash
 }
 
+// pipelineVersion returns a short digest of the pipeline's hash, which changes whenever its transforms change
+func pipelineVersion(hash string) string {
+	digest := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(digest[:6])
+}
+
 func logError(lc logger.LoggingClient, err error, correlationID string) {
 	lc.Errorf("%s. %s=%s", err.Error(), common.CorrelationHeader, correlationID)
 }
@@ -18,17 +18,27 @@ package runtime
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
 
 	"github.com/google/uuid"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/accounting"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/cache"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/capture"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/devicestate"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/history"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/tenancy"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/transforms"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/config"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
@@ -131,6 +141,380 @@ func TestProcessMessageOneCustomTransform(t *testing.T) {
 	assertEventMetadataSet(t, context, envelope)
 }
 
+func TestProcessMessageShadowPipeline(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+		ReceivedTopic: "edgex/events/Thermostat",
+	}
+
+	var shadowMode interface{}
+	shadowTransform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		shadowMode, _ = appContext.GetValue(interfaces.SHADOW)
+		appContext.SetResponseData([]byte("shadow output"))
+		appContext.PublishToTopic("alerts", []byte("shadow alert"), "")
+		appContext.SetRetryData([]byte("shadow export"))
+		return false, fmt.Errorf("shadow export failed")
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	require.NoError(t, runtime.AddFunctionsPipeline("production", []string{"edgex/events/#"}, []interfaces.AppFunction{transforms.NewResponseData().SetResponseData}))
+	require.NoError(t, runtime.AddShadowFunctionsPipeline("shadow", []string{"edgex/events/#"}, interfaces.ShadowModeLog, []interfaces.AppFunction{shadowTransform}))
+
+	err = runtime.AddShadowFunctionsPipeline("invalid", []string{"#"}, "drop", []interfaces.AppFunction{shadowTransform})
+	require.Error(t, err)
+
+	shadows := runtime.GetShadowPipelines()
+	require.Len(t, shadows, 1)
+	assert.Equal(t, "shadow", shadows[0].Id)
+	require.Len(t, runtime.GetMatchingPipelines(envelope.ReceivedTopic), 2, "shadow pipelines should receive copies of the messages")
+
+	context := appfunction.NewContext("testId", dic, "")
+	result := runtime.ProcessMessage(context, envelope, runtime.GetPipelineById("shadow"))
+	require.Nil(t, result, "shadow pipeline errors should not be returned to the trigger")
+	assert.Equal(t, interfaces.ShadowModeLog, shadowMode)
+	assert.Nil(t, context.ResponseData(), "shadow pipeline response data should be discarded")
+	assert.Empty(t, context.TopicResponses(), "shadow pipeline topic responses should be discarded")
+
+	context = appfunction.NewContext("testId", dic, "")
+	result = runtime.ProcessMessage(context, envelope, runtime.GetPipelineById("production"))
+	require.Nil(t, result)
+	assert.NotNil(t, context.ResponseData())
+	_, found := context.GetValue(interfaces.SHADOW)
+	assert.False(t, found, "production pipelines should not be flagged as shadow")
+}
+
+func TestProcessMessageUpdatesLastValueCache(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+	}
+
+	lastValueCache := cache.NewLastValueCache("")
+	dic.Update(di.ServiceConstructorMap{
+		container.LastValueCacheName: func(get di.Get) interface{} {
+			return lastValueCache
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.LastValueCacheName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	convert := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		event := data.(dtos.Event)
+		event.Readings = []dtos.BaseReading{event.Readings[0]}
+		event.Readings[0].Value = "22"
+		return true, event
+	}
+	filter := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		return false, nil
+	}
+	export := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		return false, errors.New("export failed")
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{filter, transforms.NewResponseData().SetResponseData})
+	_ = runtime.ProcessMessage(appfunction.NewContext("testId", dic, ""), envelope, runtime.GetDefaultPipeline())
+
+	_, found := lastValueCache.Readings(testV2Event.DeviceName)
+	require.False(t, found, "Event filtered out by the pipeline should not be cached")
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{convert, export})
+	result := runtime.ProcessMessage(appfunction.NewContext("testId", dic, ""), envelope, runtime.GetDefaultPipeline())
+	require.NotNil(t, result)
+
+	readings, found := lastValueCache.Readings(testV2Event.DeviceName)
+	require.True(t, found, "Event passed on by the pipeline should be cached")
+	require.Len(t, readings, 1)
+	assert.Equal(t, "22", readings[0].Value, "readings should be cached as converted by the pipeline")
+}
+
+func TestProcessMessageDeviceMonitor(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+	}
+
+	deviceMonitor, err := devicestate.NewMonitor(sdkCommon.DeviceMonitorInfo{}, func(devicestate.StateChange) {}, logger.NewMockClient())
+	require.NoError(t, err)
+	dic.Update(di.ServiceConstructorMap{
+		container.DeviceMonitorName: func(get di.Get) interface{} {
+			return deviceMonitor
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.DeviceMonitorName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{transforms.NewResponseData().SetResponseData})
+	result := runtime.ProcessMessage(appfunction.NewContext("testId", dic, ""), envelope, runtime.GetDefaultPipeline())
+	require.Nil(t, result)
+
+	devices := deviceMonitor.Devices()
+	require.Len(t, devices, 1, "device of the Event received should be tracked")
+	assert.Equal(t, testV2Event.DeviceName, devices[0].DeviceName)
+	assert.Equal(t, devicestate.StateOnline, devices[0].State)
+}
+
+func TestProcessMessageCostAccounting(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+	}
+
+	accountant, err := accounting.NewAccountant(sdkCommon.CostAccountingInfo{}, logger.NewMockClient())
+	require.NoError(t, err)
+	dic.Update(di.ServiceConstructorMap{
+		container.AccountantName: func(get di.Get) interface{} {
+			return accountant
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.AccountantName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{
+		transforms.NewConversion().TransformToXML,
+		transforms.NewResponseData().SetResponseData,
+	})
+	result := runtime.ProcessMessage(appfunction.NewContext("testId", dic, ""), envelope, runtime.GetDefaultPipeline())
+	require.Nil(t, result)
+
+	stats := accountant.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, interfaces.DefaultPipelineId, stats[0].PipelineId)
+	assert.Equal(t, uint64(1), stats[0].Executions)
+	require.Len(t, stats[0].Functions, 2)
+	assert.NotZero(t, stats[0].Functions[0].BytesProduced, "XML produced by the conversion should be counted")
+	assert.Equal(t, stats[0].Functions[0].BytesProduced+stats[0].Functions[1].BytesProduced, stats[0].BytesProduced)
+}
+
+func TestProcessMessageMetricsHistory(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+	}
+
+	metricsHistory, err := history.NewHistory(sdkCommon.MetricsHistoryInfo{}, logger.NewMockClient())
+	require.NoError(t, err)
+	dic.Update(di.ServiceConstructorMap{
+		container.MetricsHistoryName: func(get di.Get) interface{} {
+			return metricsHistory
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.MetricsHistoryName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	failing := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return false, errors.New("export failed")
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{transforms.NewConversion().TransformToXML})
+	require.NoError(t, runtime.AddFunctionsPipeline("failing", []string{"#"}, []interfaces.AppFunction{failing}))
+
+	result := runtime.ProcessMessage(appfunction.NewContext("testId", dic, ""), envelope, runtime.GetDefaultPipeline())
+	require.Nil(t, result)
+	result = runtime.ProcessMessage(appfunction.NewContext("testId", dic, ""), envelope, runtime.GetPipelineById("failing"))
+	require.NotNil(t, result)
+
+	rollups := metricsHistory.Rollups()
+	require.Len(t, rollups, 1)
+	require.Len(t, rollups[0].Pipelines, 2)
+	assert.Equal(t, interfaces.DefaultPipelineId, rollups[0].Pipelines[0].PipelineId)
+	assert.Equal(t, uint64(1), rollups[0].Pipelines[0].Succeeded)
+	assert.Equal(t, "failing", rollups[0].Pipelines[1].PipelineId)
+	assert.Equal(t, uint64(1), rollups[0].Pipelines[1].Failed)
+}
+
+func TestProcessMessageCapture(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	captureBuffer := capture.NewBuffer()
+	dic.Update(di.ServiceConstructorMap{
+		container.CaptureBufferName: func(get di.Get) interface{} {
+			return captureBuffer
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.CaptureBufferName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	configuration := container.ConfigurationFrom(dic.Get)
+	configuration.Writable.Capture = sdkCommon.CaptureInfo{Enabled: true, SampleRate: 2, MaxSamples: 5}
+	defer func() { configuration.Writable.Capture = sdkCommon.CaptureInfo{} }()
+
+	failure := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return false, errors.New("failed")
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{transforms.NewConversion().TransformToJSON, failure})
+
+	for _, correlationId := range []string{"1", "2", "3", "4"} {
+		envelope := types.MessageEnvelope{
+			CorrelationID: correlationId,
+			ReceivedTopic: "edgex/events",
+			Payload:       payload,
+			ContentType:   common.ContentTypeJSON,
+		}
+		runtime.ProcessMessage(appfunction.NewContext(correlationId, dic, ""), envelope, runtime.GetDefaultPipeline())
+	}
+
+	samples := captureBuffer.Samples()
+	require.Len(t, samples, 2, "one in two messages should be captured")
+	assert.Equal(t, "2", samples[0].CorrelationId)
+	assert.Equal(t, "4", samples[1].CorrelationId)
+
+	sample := samples[1]
+	assert.Equal(t, interfaces.DefaultPipelineId, sample.PipelineId)
+	assert.Equal(t, "edgex/events", sample.ReceivedTopic)
+	assert.Equal(t, string(payload), sample.Payload.Value)
+	require.Len(t, sample.Stages, 2)
+	assert.True(t, sample.Stages[0].ContinuePipeline)
+	assert.Equal(t, "string", sample.Stages[0].Output.Type)
+	assert.Contains(t, sample.Stages[0].Output.Value, testV2Event.DeviceName)
+	assert.Equal(t, capture.Stage{Index: 1, Error: "failed"}, sample.Stages[1])
+
+	configuration.Writable.Capture.Enabled = false
+	runtime.ProcessMessage(appfunction.NewContext("5", dic, ""), types.MessageEnvelope{Payload: payload}, runtime.GetDefaultPipeline())
+	runtime.ProcessMessage(appfunction.NewContext("6", dic, ""), types.MessageEnvelope{Payload: payload}, runtime.GetDefaultPipeline())
+	assert.Len(t, captureBuffer.Samples(), 2, "no messages should be captured when disabled")
+}
+
+func TestProcessMessageLineage(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+	}
+
+	noop := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("app-lineage", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{noop})
+
+	context := appfunction.NewContext("1", dic, "")
+	runtime.ProcessMessage(context, envelope, runtime.GetDefaultPipeline())
+	_, found := context.GetValue(interfaces.SERVICEKEY)
+	assert.False(t, found, "lineage should not be set when disabled")
+
+	configuration := container.ConfigurationFrom(dic.Get)
+	configuration.Writable.Lineage.Enabled = true
+	defer func() { configuration.Writable.Lineage = sdkCommon.LineageInfo{} }()
+
+	context = appfunction.NewContext("2", dic, "")
+	runtime.ProcessMessage(context, envelope, runtime.GetDefaultPipeline())
+
+	serviceKey, _ := context.GetValue(interfaces.SERVICEKEY)
+	assert.Equal(t, "app-lineage", serviceKey)
+	version, _ := context.GetValue(interfaces.PIPELINEVERSION)
+	assert.Equal(t, pipelineVersion(runtime.GetDefaultPipeline().Hash), version)
+	assert.Len(t, version, 12)
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{noop, noop})
+	assert.NotEqual(t, version, pipelineVersion(runtime.GetDefaultPipeline().Hash), "version should change with the transforms")
+}
+
+func TestProcessMessageTenancy(t *testing.T) {
+	payload, err := json.Marshal(testAddEventRequest)
+	require.NoError(t, err)
+
+	router, err := tenancy.NewRouter(sdkCommon.TenancyInfo{
+		Source:     tenancy.SourceTopic,
+		TopicLevel: 1,
+		Tenants: map[string]sdkCommon.TenantInfo{
+			"acme":   {Settings: map[string]string{"ExportUrl": "https://acme.example.com", "Tenant": "other"}},
+			"globex": {},
+		},
+	})
+	require.NoError(t, err)
+
+	dic.Update(di.ServiceConstructorMap{
+		container.TenantRouterName: func(get di.Get) interface{} {
+			return router
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.TenantRouterName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	var values map[string]string
+	transform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		values = appContext.GetAllValues()
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{transform})
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: "123-234-345-456",
+		ReceivedTopic: "customers/acme/events",
+		Payload:       payload,
+		ContentType:   common.ContentTypeJSON,
+	}
+	messageError := runtime.ProcessMessage(appfunction.NewContext("1", dic, ""), envelope, runtime.GetDefaultPipeline())
+	require.Nil(t, messageError)
+	assert.Equal(t, "acme", values[interfaces.TENANT], "tenant setting should not replace the tenant")
+	assert.Equal(t, "https://acme.example.com", values["exporturl"])
+
+	values = nil
+	envelope.ReceivedTopic = "customers/initech/events"
+	messageError = runtime.ProcessMessage(appfunction.NewContext("2", dic, ""), envelope, runtime.GetDefaultPipeline())
+	require.NotNil(t, messageError)
+	assert.Equal(t, http.StatusForbidden, messageError.ErrorCode)
+	assert.Nil(t, values, "pipeline should not execute for unknown tenant")
+
+	stats, rejected := router.Stats()
+	assert.Equal(t, uint64(1), rejected)
+	require.Len(t, stats, 2)
+	assert.Equal(t, uint64(1), stats[0].Succeeded)
+	assert.Equal(t, uint64(0), stats[1].Succeeded+stats[1].Failed)
+}
+
 func TestProcessMessageTwoCustomTransforms(t *testing.T) {
 	payload, err := json.Marshal(testAddEventRequest)
 	require.NoError(t, err)
@@ -268,6 +652,10 @@ func assertEventMetadataSet(t *testing.T, context *appfunction.Context, envelope
 	v, f = context.GetValue(interfaces.SOURCENAME)
 	require.True(t, f)
 	assert.Equal(t, testAddEventRequest.Event.SourceName, v)
+
+	v, f = context.GetValue(interfaces.EVENTID)
+	require.True(t, f)
+	assert.Equal(t, testAddEventRequest.Event.Id, v)
 }
 
 func assertReceivedTopicSet(t *testing.T, context *appfunction.Context, envelope types.MessageEnvelope) {
@@ -439,6 +827,77 @@ func TestProcessMessageTargetType(t *testing.T) {
 	}
 }
 
+func TestExecutePipelineSplit(t *testing.T) {
+	event := dtos.NewEvent("Thermostat", "FamilyRoomThermostat", "Temperature")
+	require.NoError(t, event.AddSimpleReading("Temperature", common.ValueTypeInt64, int64(72)))
+	require.NoError(t, event.AddSimpleReading("Humidity", common.ValueTypeInt64, int64(40)))
+	require.NoError(t, event.AddSimpleReading("Pressure", common.ValueTypeInt64, int64(1013)))
+	require.NoError(t, event.AddSimpleReading("Battery", common.ValueTypeInt64, int64(5)))
+
+	var exported []string
+	exportReading := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		reading := data.(dtos.Event).Readings[0]
+		if reading.ResourceName == "Battery" {
+			return false, errors.New("battery export failed")
+		}
+		exported = append(exported, reading.ResourceName)
+		return true, data
+	}
+
+	var merged dtos.Event
+	record := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		merged = data.(dtos.Event)
+		return true, data
+	}
+
+	splitter := transforms.NewSplitter()
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{
+		splitter.SplitByReading,
+		transforms.NewFilterOut([]string{"Humidity"}).FilterByResourceName,
+		exportReading,
+		splitter.Merge,
+		record,
+	})
+	pipeline := runtime.GetDefaultPipeline()
+
+	msgErr := runtime.ExecutePipeline(event, "", appfunction.NewContext("testId", dic, ""), pipeline, 0, false)
+	require.NotNil(t, msgErr, "error of the reading that failed should be returned")
+	assert.EqualError(t, msgErr.Err, "battery export failed")
+
+	assert.Equal(t, []string{"Temperature", "Pressure"}, exported)
+	require.Len(t, merged.Readings, 2, "readings filtered or failed should not be merged")
+	assert.Equal(t, event.Readings[0], merged.Readings[0])
+	assert.Equal(t, event.Readings[2], merged.Readings[1])
+
+	// A reading retried by Store and Forward continues from the function that failed and is merged on its own
+	exported = nil
+	retried := event
+	retried.Readings = []dtos.BaseReading{event.Readings[1]}
+	msgErr = runtime.ExecutePipeline(retried, "", appfunction.NewContext("testId", dic, ""), pipeline, 2, true)
+	require.Nil(t, msgErr)
+	assert.Equal(t, []string{"Humidity"}, exported)
+	assert.Equal(t, retried.Readings, merged.Readings)
+}
+
+func TestExecutePipelineSplitWithoutMerge(t *testing.T) {
+	var received []interface{}
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{
+		func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+			return true, interfaces.SplitData{"one", "two"}
+		},
+		func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+			received = append(received, data)
+			return true, data
+		},
+	})
+
+	msgErr := runtime.ExecutePipeline("data", "", appfunction.NewContext("testId", dic, ""), runtime.GetDefaultPipeline(), 0, false)
+	require.Nil(t, msgErr)
+	assert.Equal(t, []interface{}{"one", "two"}, received, "each item should continue to the end of the pipeline")
+}
+
 func TestExecutePipelinePersist(t *testing.T) {
 	expectedItemCount := 1
 
@@ -715,3 +1174,37 @@ func TestGolangRuntime_ClearAllFunctionsPipelineTransforms(t *testing.T) {
 	pipeline = target.GetPipelineById(id2)
 	assert.Nil(t, pipeline.Transforms)
 }
+
+func TestGolangRuntime_ReplaceFunctionsPipelines(t *testing.T) {
+	target := NewGolangRuntime(serviceKey, nil, dic)
+
+	transforms1 := []interfaces.AppFunction{transforms.NewResponseData().SetResponseData}
+	transforms2 := []interfaces.AppFunction{transforms.NewConversion().TransformToJSON, transforms.NewResponseData().SetResponseData}
+
+	target.SetDefaultFunctionsPipeline(transforms1)
+	err := target.AddFunctionsPipeline("removed", []string{"edgex/events/#"}, transforms1)
+	require.NoError(t, err)
+
+	// A message already matched keeps the pipeline it started with
+	inFlight := target.GetDefaultPipeline()
+
+	target.ReplaceFunctionsPipelines(map[string]interfaces.FunctionPipeline{
+		interfaces.DefaultPipelineId: {Id: interfaces.DefaultPipelineId, Topics: []string{TopicWildCard}, Transforms: transforms2},
+		"added":                      {Id: "added", Topics: []string{"edgex/events/device/#"}, Transforms: transforms1, ShadowMode: interfaces.ShadowModeLog},
+	})
+
+	assert.Len(t, inFlight.Transforms, 1)
+	assert.Nil(t, target.GetPipelineById("removed"))
+
+	pipeline := target.GetDefaultPipeline()
+	assert.Len(t, pipeline.Transforms, 2)
+	assert.Equal(t, calculatePipelineHash(transforms2), pipeline.Hash)
+
+	added := target.GetPipelineById("added")
+	require.NotNil(t, added)
+	assert.Equal(t, interfaces.ShadowModeLog, added.ShadowMode)
+	assert.Equal(t, calculatePipelineHash(transforms1), added.Hash)
+
+	assert.Len(t, target.GetMatchingPipelines("edgex/events/device/thermostat"), 2)
+	assert.Len(t, target.GetMatchingPipelines("edgex/other"), 1)
+}
@@ -19,6 +19,7 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +41,21 @@ const (
 type storeForwardInfo struct {
 	runtime *GolangRuntime
 	dic     *di.Container
+	monitor *connectivityMonitor
+}
+
+// inMaintenance returns true when the Writable Maintenance mode is enabled, during which exports are held back
+func (sf *storeForwardInfo) inMaintenance() bool {
+	return container.ConfigurationFrom(sf.dic.Get).Writable.Maintenance.Enabled
+}
+
+// RetryStoredDataNow signals the retry loop to retry the stored data right away rather than at the next retry
+// interval, i.e. once the maintenance mode is disabled
+func (gr *GolangRuntime) RetryStoredDataNow() {
+	select {
+	case gr.storeForward.monitor.restored <- struct{}{}:
+	default:
+	}
 }
 
 func (sf *storeForwardInfo) startStoreAndForwardRetryLoop(
@@ -55,6 +71,10 @@ func (sf *storeForwardInfo) startStoreAndForwardRetryLoop(
 	config := container.ConfigurationFrom(sf.dic.Get)
 	lc := bootstrapContainer.LoggingClientFrom(sf.dic.Get)
 
+	if config.Writable.StoreAndForward.OfflineMode.Enabled {
+		sf.startConnectivityMonitor(appWg, appCtx, enabledWg, enabledCtx)
+	}
+
 	go func() {
 		defer appWg.Done()
 		defer enabledWg.Done()
@@ -81,6 +101,17 @@ func (sf *storeForwardInfo) startStoreAndForwardRetryLoop(
 			fmt.Sprintf("Starting StoreAndForward Retry Loop with %s RetryInterval and %d max retries",
 				retryInterval.String(), config.Writable.StoreAndForward.MaxRetryCount))
 
+		// A retry in progress stops between items when the service terminates or Store and Forward is disabled
+		retryCtx, cancelRetry := context.WithCancel(appCtx)
+		defer cancelRetry()
+		go func() {
+			select {
+			case <-enabledCtx.Done():
+				cancelRetry()
+			case <-retryCtx.Done():
+			}
+		}()
+
 	exit:
 		for {
 			select {
@@ -94,7 +125,19 @@ func (sf *storeForwardInfo) startStoreAndForwardRetryLoop(
 				break exit
 
 			case <-time.After(retryInterval):
-				sf.retryStoredData(serviceKey)
+				if sf.monitor.isOffline() {
+					lc.Debug("Network is offline, skipping retry of stored data")
+					continue
+				}
+				if sf.inMaintenance() {
+					lc.Debug("Service is in maintenance mode, skipping retry of stored data")
+					continue
+				}
+				sf.retryStoredData(retryCtx, serviceKey)
+
+			case <-sf.monitor.restored:
+				// Catch up as soon as connectivity returns rather than waiting for the next retry interval.
+				sf.retryStoredData(retryCtx, serviceKey)
 			}
 		}
 
@@ -102,13 +145,40 @@ func (sf *storeForwardInfo) startStoreAndForwardRetryLoop(
 	}()
 }
 
+func (sf *storeForwardInfo) startConnectivityMonitor(
+	appWg *sync.WaitGroup,
+	appCtx context.Context,
+	enabledWg *sync.WaitGroup,
+	enabledCtx context.Context) {
+
+	config := container.ConfigurationFrom(sf.dic.Get)
+	lc := bootstrapContainer.LoggingClientFrom(sf.dic.Get)
+	offlineMode := config.Writable.StoreAndForward.OfflineMode
+
+	interval, timeout := parseProbeDurations(offlineMode, lc)
+	probe, err := newConnectivityProbe(offlineMode.ProbeUrl, timeout)
+	if err != nil {
+		lc.Errorf("StoreAndForward OfflineMode not started: %s", err.Error())
+		return
+	}
+
+	sf.monitor.probe = probe
+
+	lc.Infof("Starting StoreAndForward OfflineMode probing %s every %s", offlineMode.ProbeUrl, interval.String())
+	sf.monitor.start(appWg, appCtx, enabledWg, enabledCtx, interval, lc)
+}
+
 func (sf *storeForwardInfo) storeForLaterRetry(
 	payload []byte,
 	appContext interfaces.AppFunctionContext,
 	pipeline *interfaces.FunctionPipeline,
 	pipelinePosition int) {
 
-	item := contracts.NewStoredObject(sf.runtime.ServiceKey, payload, pipeline.Id, pipelinePosition, pipeline.Hash, appContext.GetAllValues())
+	// The offline flag reflects the network state when stored, not when retried, so it isn't persisted.
+	contextData := appContext.GetAllValues()
+	delete(contextData, interfaces.NETWORKOFFLINE)
+
+	item := contracts.NewStoredObject(sf.runtime.ServiceKey, payload, pipeline.Id, pipelinePosition, pipeline.Hash, contextData)
 	item.CorrelationID = appContext.CorrelationID()
 
 	appContext.LoggingClient().Tracef("Storing data for later retry for pipeline '%s' (%s=%s)",
@@ -129,7 +199,7 @@ func (sf *storeForwardInfo) storeForLaterRetry(
 	}
 }
 
-func (sf *storeForwardInfo) retryStoredData(serviceKey string) {
+func (sf *storeForwardInfo) retryStoredData(ctx context.Context, serviceKey string) {
 
 	storeClient := container.StoreClientFrom(sf.dic.Get)
 	lc := bootstrapContainer.LoggingClientFrom(sf.dic.Get)
@@ -143,7 +213,12 @@ func (sf *storeForwardInfo) retryStoredData(serviceKey string) {
 	lc.Debugf("%d stored data items found for retrying", len(items))
 
 	if len(items) > 0 {
-		itemsToRemove, itemsToUpdate := sf.processRetryItems(items)
+		// Retry in the order the items were originally stored
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Created < items[j].Created
+		})
+
+		itemsToRemove, itemsToUpdate := sf.processRetryItems(ctx, items)
 
 		lc.Debugf(" %d stored data items will be removed post retry", len(itemsToRemove))
 		lc.Debugf(" %d stored data items will be update post retry", len(itemsToUpdate))
@@ -168,19 +243,53 @@ func (sf *storeForwardInfo) retryStoredData(serviceKey string) {
 	}
 }
 
-func (sf *storeForwardInfo) processRetryItems(items []contracts.StoredObject) ([]contracts.StoredObject, []contracts.StoredObject) {
+func (sf *storeForwardInfo) processRetryItems(ctx context.Context, items []contracts.StoredObject) ([]contracts.StoredObject, []contracts.StoredObject) {
 	lc := bootstrapContainer.LoggingClientFrom(sf.dic.Get)
 	config := container.ConfigurationFrom(sf.dic.Get)
 
 	var itemsToRemove []contracts.StoredObject
 	var itemsToUpdate []contracts.StoredObject
 
+	// Rate limit draining the store when catching up after being offline so the uplink isn't flooded
+	var drainTicks <-chan time.Time
+	offlineMode := config.Writable.StoreAndForward.OfflineMode
+	if offlineMode.Enabled && offlineMode.DrainRate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(offlineMode.DrainRate))
+		defer ticker.Stop()
+		drainTicks = ticker.C
+	}
+
 	// Item will be removed from store if:
 	//    - successfully retried
 	//    - max retries exceeded
 	//    - version no longer matches current Pipeline
 	// Item will not be removed if retry failed and more retries available (hit 'continue' above)
-	for _, item := range items {
+retry:
+	for index, item := range items {
+		if sf.monitor.isOffline() {
+			lc.Infof("Network went offline, leaving %d stored data items for later retry", len(items)-index)
+			break
+		}
+
+		if sf.inMaintenance() {
+			lc.Infof("Service entered maintenance mode, leaving %d stored data items for later retry", len(items)-index)
+			break
+		}
+
+		if sf.runtime.IsDraining() {
+			lc.Infof("Service is shutting down, leaving %d stored data items for later retry", len(items)-index)
+			break
+		}
+
+		if drainTicks != nil && index > 0 {
+			select {
+			case <-drainTicks:
+			case <-ctx.Done():
+				lc.Infof("StoreAndForward retry stopped, leaving %d stored data items for later retry", len(items)-index)
+				break retry
+			}
+		}
+
 		pipeline := sf.runtime.GetPipelineById(item.PipelineId)
 
 		if pipeline == nil {
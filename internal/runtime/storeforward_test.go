@@ -17,9 +17,12 @@
 package runtime
 
 import (
+	"context"
 	"errors"
 	"os"
+	"strconv"
 	"testing"
+	"time"
 
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
@@ -30,6 +33,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/contracts"
@@ -134,7 +138,7 @@ func TestProcessRetryItems(t *testing.T) {
 			storedObject := contracts.NewStoredObject("dummy", []byte(test.ExpectedPayload), pipeline.Id, 2, version, contextData)
 			storedObject.RetryCount = test.RetryCount
 
-			removes, updates := runtime.storeForward.processRetryItems([]contracts.StoredObject{storedObject})
+			removes, updates := runtime.storeForward.processRetryItems(context.Background(), []contracts.StoredObject{storedObject})
 			assert.Equal(t, test.TargetTransformWasCalled, targetTransformWasCalled, "Target transform not called")
 			if test.RetryCount != test.ExpectedRetryCount {
 				if assert.True(t, len(updates) > 0, "Remove count not as expected") {
@@ -197,7 +201,7 @@ func TestDoStoreAndForwardRetry(t *testing.T) {
 			_, _ = mockStoreObject(object)
 
 			// Target of this test
-			runtime.storeForward.retryStoredData(serviceKey)
+			runtime.storeForward.retryStoredData(context.Background(), serviceKey)
 
 			objects := mockRetrieveObjects(serviceKey)
 			if assert.Equal(t, test.ExpectedObjectCount, len(objects)) && test.ExpectedObjectCount > 0 {
@@ -209,6 +213,182 @@ func TestDoStoreAndForwardRetry(t *testing.T) {
 	}
 }
 
+func TestRetryStoredDataOfflineMode(t *testing.T) {
+	config := container.ConfigurationFrom(dic.Get)
+	config.Writable.StoreAndForward.OfflineMode = common.OfflineModeInfo{Enabled: true, DrainRate: 100}
+	defer func() { config.Writable.StoreAndForward.OfflineMode = common.OfflineModeInfo{} }()
+
+	runtime := NewGolangRuntime(serviceKey, nil, updateDicWithMockStoreClient())
+
+	var exported []string
+	exportTransform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		exported = append(exported, string(data.([]byte)))
+		if len(exported) == 2 {
+			// Simulate losing connectivity part way through catching up
+			runtime.storeForward.monitor.setOffline(true)
+		}
+		return false, nil
+	}
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{exportTransform})
+	pipeline := runtime.GetDefaultPipeline()
+
+	for created := 4; created > 0; created-- {
+		object := contracts.NewStoredObject(serviceKey, []byte(strconv.Itoa(created)), pipeline.Id, 0, pipeline.Hash, nil)
+		object.Created = int64(created)
+		_, err := mockStoreObject(object)
+		require.NoError(t, err)
+	}
+
+	runtime.storeForward.retryStoredData(context.Background(), serviceKey)
+
+	assert.Equal(t, []string{"1", "2"}, exported, "stored items not retried in order")
+
+	remaining := mockRetrieveObjects(serviceKey)
+	require.Len(t, remaining, 2)
+	for _, object := range remaining {
+		assert.Contains(t, []string{"3", "4"}, string(object.Payload))
+		assert.Equal(t, 0, object.RetryCount, "items skipped while offline should not count as a retry")
+	}
+}
+
+func TestRetryStoredDataDraining(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, updateDicWithMockStoreClient())
+
+	var exported []string
+	exportTransform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		exported = append(exported, string(data.([]byte)))
+		// Simulate the service shutting down while retrying
+		runtime.draining.Set(true)
+		return false, nil
+	}
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{exportTransform})
+	pipeline := runtime.GetDefaultPipeline()
+
+	for created := 3; created > 0; created-- {
+		object := contracts.NewStoredObject(serviceKey, []byte(strconv.Itoa(created)), pipeline.Id, 0, pipeline.Hash, nil)
+		object.Created = int64(created)
+		_, err := mockStoreObject(object)
+		require.NoError(t, err)
+	}
+
+	runtime.storeForward.retryStoredData(context.Background(), serviceKey)
+
+	assert.Equal(t, []string{"1"}, exported)
+
+	remaining := mockRetrieveObjects(serviceKey)
+	require.Len(t, remaining, 2, "successfully retried item should be removed before shutting down")
+	for _, object := range remaining {
+		assert.Contains(t, []string{"2", "3"}, string(object.Payload))
+		assert.Equal(t, 0, object.RetryCount)
+	}
+}
+
+func TestRetryStoredDataCancelled(t *testing.T) {
+	config := container.ConfigurationFrom(dic.Get)
+	config.Writable.StoreAndForward.OfflineMode = common.OfflineModeInfo{Enabled: true, DrainRate: 1}
+	defer func() { config.Writable.StoreAndForward.OfflineMode = common.OfflineModeInfo{} }()
+
+	runtime := NewGolangRuntime(serviceKey, nil, updateDicWithMockStoreClient())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var exported []string
+	exportTransform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		exported = append(exported, string(data.([]byte)))
+		// Simulate the service terminating while waiting to drain the next item
+		cancel()
+		return false, nil
+	}
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{exportTransform})
+	pipeline := runtime.GetDefaultPipeline()
+
+	for created := 3; created > 0; created-- {
+		object := contracts.NewStoredObject(serviceKey, []byte(strconv.Itoa(created)), pipeline.Id, 0, pipeline.Hash, nil)
+		object.Created = int64(created)
+		_, err := mockStoreObject(object)
+		require.NoError(t, err)
+	}
+
+	start := time.Now()
+	runtime.storeForward.retryStoredData(ctx, serviceKey)
+
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "retry should not wait for the drain rate once cancelled")
+	assert.Equal(t, []string{"1"}, exported)
+	require.Len(t, mockRetrieveObjects(serviceKey), 2, "items not retried should be left in the store")
+}
+
+func TestExecutePipelineNetworkOffline(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, updateDicWithMockStoreClient())
+	runtime.storeForward.monitor.setOffline(true)
+
+	offlineSeen := false
+	exportTransform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		_, offlineSeen = appContext.GetValue(interfaces.NETWORKOFFLINE)
+		appContext.SetRetryData(data.([]byte))
+		return false, errors.New("network is offline")
+	}
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{exportTransform})
+	pipeline := runtime.GetDefaultPipeline()
+
+	appContext := appfunction.NewContext("123", dic, "")
+	appContext.AddValue("x", "y")
+	msgErr := runtime.ExecutePipeline([]byte("data"), "", appContext, pipeline, 0, false)
+	require.NotNil(t, msgErr)
+	assert.True(t, offlineSeen, "export function should see the network offline flag")
+
+	stored := mockRetrieveObjects(serviceKey)
+	require.Len(t, stored, 1)
+	assert.Equal(t, map[string]string{"x": "y"}, stored[0].ContextData, "offline flag should not be persisted")
+
+	runtime.storeForward.monitor.setOffline(false)
+	_ = runtime.ExecutePipeline([]byte("data"), "", appContext, pipeline, 0, true)
+	assert.False(t, offlineSeen, "offline flag should be cleared once back online")
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	config := container.ConfigurationFrom(dic.Get)
+	config.Writable.Maintenance.Enabled = true
+	defer func() { config.Writable.Maintenance = common.MaintenanceInfo{} }()
+
+	runtime := NewGolangRuntime(serviceKey, nil, updateDicWithMockStoreClient())
+
+	var exported []string
+	exportTransform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		if _, held := appContext.GetValue(interfaces.NETWORKOFFLINE); held {
+			appContext.SetRetryData(data.([]byte))
+			return false, errors.New("export held back")
+		}
+		exported = append(exported, string(data.([]byte)))
+		return false, nil
+	}
+
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{exportTransform})
+	pipeline := runtime.GetDefaultPipeline()
+
+	msgErr := runtime.ExecutePipeline([]byte("data"), "", appfunction.NewContext("123", dic, ""), pipeline, 0, false)
+	require.NotNil(t, msgErr)
+	require.Len(t, mockRetrieveObjects(serviceKey), 1, "export should be diverted to the store")
+
+	runtime.storeForward.retryStoredData(context.Background(), serviceKey)
+	assert.Empty(t, exported, "stored data should not be retried during maintenance")
+	require.Len(t, mockRetrieveObjects(serviceKey), 1)
+
+	config.Writable.Maintenance.Enabled = false
+	runtime.RetryStoredDataNow()
+	select {
+	case <-runtime.storeForward.monitor.restored:
+	default:
+		require.Fail(t, "retry loop should be signaled to retry right away")
+	}
+
+	runtime.storeForward.retryStoredData(context.Background(), serviceKey)
+	assert.Equal(t, []string{"data"}, exported)
+	assert.Empty(t, mockRetrieveObjects(serviceKey))
+}
+
 var mockObjectStore map[string]contracts.StoredObject
 
 func updateDicWithMockStoreClient() *di.Container {
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// executionTimeout enforces the PipelineTimeout of a pipeline execution. The context passed to the functions is
+// done once the timeout expires, and the execution is aborted before its next function. The function running isn't
+// interrupted, as it shares the execution's context and data, so it only stops early if it honours its context.
+// A nil executionTimeout never expires.
+type executionTimeout struct {
+	timeout  time.Duration
+	cancel   context.CancelFunc
+	timer    *time.Timer
+	function int32
+	expired  int32
+}
+
+// SetPipelineTimeout sets the maximum time a pipeline execution may take, 0 doesn't time out
+func (gr *GolangRuntime) SetPipelineTimeout(timeout time.Duration) {
+	gr.pipelineTimeout = timeout
+}
+
+// startTimeout starts enforcing the PipelineTimeout of the execution, returning nil when no timeout is set
+func (gr *GolangRuntime) startTimeout(appContext *appfunction.Context, pipeline *interfaces.FunctionPipeline) *executionTimeout {
+	if gr.pipelineTimeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(appContext.Context(), gr.pipelineTimeout)
+	appContext.SetContext(ctx)
+
+	execution := &executionTimeout{
+		timeout: gr.pipelineTimeout,
+		cancel:  cancel,
+	}
+
+	lc := bootstrapContainer.LoggingClientFrom(gr.dic.Get)
+	execution.timer = time.AfterFunc(gr.pipelineTimeout, func() {
+		atomic.StoreInt32(&execution.expired, 1)
+
+		functionIndex := int(atomic.LoadInt32(&execution.function))
+		lc.Warnf("Pipeline '%s' exceeded its %s timeout in function #%d %s. %s=%s",
+			pipeline.Id, execution.timeout.String(), functionIndex, functionName(pipeline.Transforms[functionIndex]),
+			common.CorrelationHeader, appContext.CorrelationID())
+	})
+
+	return execution
+}
+
+// Function records the index of the function being executed
+func (execution *executionTimeout) Function(functionIndex int) {
+	if execution == nil {
+		return
+	}
+
+	atomic.StoreInt32(&execution.function, int32(functionIndex))
+}
+
+// Expired returns whether the execution exceeded its timeout
+func (execution *executionTimeout) Expired() bool {
+	if execution == nil {
+		return false
+	}
+
+	return atomic.LoadInt32(&execution.expired) == 1
+}
+
+// Stop stops enforcing the timeout once the execution has completed
+func (execution *executionTimeout) Stop() {
+	if execution == nil {
+		return
+	}
+
+	execution.timer.Stop()
+	execution.cancel()
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestGolangRuntime_PipelineTimeout(t *testing.T) {
+	var cancelled bool
+	slow := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		select {
+		case <-ctx.Context().Done():
+			cancelled = true
+		case <-time.After(time.Second):
+		}
+		return true, data
+	}
+
+	var executed bool
+	last := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		executed = true
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{slow, last})
+	runtime.SetPipelineTimeout(10 * time.Millisecond)
+
+	ctx := appfunction.NewContext("123", dic, "")
+	err := runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, err.ErrorCode)
+	assert.Contains(t, err.Err.Error(), "exceeded its 10ms timeout before function #1")
+	assert.True(t, cancelled, "context passed to the functions should be done once the timeout expires")
+	assert.False(t, executed, "execution should be aborted before the next function")
+}
+
+func TestGolangRuntime_PipelineTimeoutNotExceeded(t *testing.T) {
+	var deadline bool
+	last := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		_, deadline = ctx.Context().Deadline()
+		return false, nil
+	}
+
+	runtime := NewGolangRuntime("", nil, dic)
+	runtime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{last})
+
+	ctx := appfunction.NewContext("123", dic, "")
+	require.Nil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.False(t, deadline, "context should have no deadline when no timeout is set")
+
+	runtime.SetPipelineTimeout(time.Minute)
+	require.Nil(t, runtime.ExecutePipeline("data", "", ctx, runtime.GetDefaultPipeline(), 0, false))
+	assert.True(t, deadline)
+}
@@ -0,0 +1,184 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+// workerPool runs pipeline executions on a fixed number of workers. Jobs are queued to a shared queue unless
+// ordered processing is enabled, in which case each worker has its own queue and jobs with the same key
+// always go to the same worker so they are executed in the order received.
+type workerPool struct {
+	queues  []chan func()
+	ordered bool
+	ctx     context.Context
+	lc      logger.LoggingClient
+	lock    sync.RWMutex
+	stopped bool
+}
+
+func newWorkerPool(config sdkCommon.WorkerPoolConfig, ctx context.Context, lc logger.LoggingClient) *workerPool {
+	queueSize := config.QueueSize
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	pool := &workerPool{
+		ordered: config.OrderByDeviceName,
+		ctx:     ctx,
+		lc:      lc,
+	}
+
+	queueCount := 1
+	if pool.ordered {
+		queueCount = config.Workers
+	}
+
+	for i := 0; i < queueCount; i++ {
+		pool.queues = append(pool.queues, make(chan func(), queueSize))
+	}
+
+	return pool
+}
+
+// start launches the workers, which run until the context is done. The jobs still queued then are released by
+// running them, which fail straight away as the pipeline executions' context is done, so the triggers waiting on
+// them don't wait forever.
+func (pool *workerPool) start(appWg *sync.WaitGroup, workers int) {
+	for i := 0; i < workers; i++ {
+		queue := pool.queues[0]
+		if pool.ordered {
+			queue = pool.queues[i]
+		}
+
+		appWg.Add(1)
+		go func(queue chan func()) {
+			defer appWg.Done()
+
+			for {
+				select {
+				case <-pool.ctx.Done():
+					pool.release(queue)
+					return
+				case job := <-queue:
+					runWithRecovery(job, pool.lc)
+				}
+			}
+		}(queue)
+	}
+}
+
+// release stops jobs from being queued and runs the jobs left in the queue
+func (pool *workerPool) release(queue chan func()) {
+	// Waits for the jobs being submitted, which return once the context is done
+	pool.lock.Lock()
+	pool.stopped = true
+	pool.lock.Unlock()
+
+	released := 0
+	for {
+		select {
+		case job := <-queue:
+			runWithRecovery(job, pool.lc)
+			released++
+		default:
+			if released > 0 {
+				pool.lc.Warnf("Worker pool stopped, released %d queued pipeline execution(s)", released)
+			}
+			return
+		}
+	}
+}
+
+// submit queues the job, blocking while the target queue is full so the trigger is slowed to the rate
+// the pipelines can keep up with. The key is only used when ordered processing is enabled.
+// Returns false if the pool stopped before the job was queued.
+func (pool *workerPool) submit(key string, job func()) bool {
+	queue := pool.queues[0]
+	if pool.ordered {
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(key))
+		queue = pool.queues[hash.Sum32()%uint32(len(pool.queues))]
+	}
+
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	if pool.stopped {
+		pool.lc.Warn("Worker pool stopped, pipeline execution not scheduled")
+		return false
+	}
+
+	select {
+	case queue <- job:
+		return true
+	case <-pool.ctx.Done():
+		pool.lc.Warn("Worker pool stopped, pipeline execution not scheduled")
+		return false
+	}
+}
+
+// runWithRecovery runs the job and recovers from any panic so a bad message doesn't bring down the worker or service.
+func runWithRecovery(job func(), lc logger.LoggingClient) {
+	defer func() {
+		if r := recover(); r != nil {
+			lc.Errorf("Recovered from panic during pipeline execution: %v\n%s", r, string(debug.Stack()))
+		}
+	}()
+
+	job()
+}
+
+// orderingKey returns the device name for the Event in the envelope's payload, falling back to the received topic
+// when the payload isn't an Event or AddEventRequest.
+func orderingKey(envelope types.MessageEnvelope) string {
+	if deviceName := eventDeviceName(envelope); deviceName != "" {
+		return deviceName
+	}
+
+	return envelope.ReceivedTopic
+}
+
+// eventDeviceName returns the device name for the Event in the envelope's payload, empty when the payload isn't an
+// Event or AddEventRequest
+func eventDeviceName(envelope types.MessageEnvelope) string {
+	payload := struct {
+		DeviceName string `json:"deviceName"`
+		Event      struct {
+			DeviceName string `json:"deviceName"`
+		} `json:"event"`
+	}{}
+
+	if err := decodeEnvelope(envelope, &payload); err != nil {
+		return ""
+	}
+
+	if payload.Event.DeviceName != "" {
+		return payload.Event.DeviceName
+	}
+
+	return payload.DeviceName
+}
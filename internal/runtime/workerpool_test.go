@@ -0,0 +1,217 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+func TestScheduleExecution_NoWorkerPool(t *testing.T) {
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.StartWorkerPool(&sync.WaitGroup{}, context.Background(), sdkCommon.WorkerPoolConfig{})
+	require.Nil(t, runtime.workerPool)
+
+	done := make(chan struct{})
+	runtime.ScheduleExecution(types.MessageEnvelope{}, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job not executed")
+	}
+}
+
+func TestScheduleExecution_LimitsConcurrency(t *testing.T) {
+	const workers = 3
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		appWg.Wait()
+	}()
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{Workers: workers, QueueSize: 10})
+	require.NotNil(t, runtime.workerPool)
+
+	var running int32
+	var maxRunning int32
+	jobsWg := sync.WaitGroup{}
+
+	for i := 0; i < 10; i++ {
+		jobsWg.Add(1)
+		runtime.ScheduleExecution(types.MessageEnvelope{}, func() {
+			defer jobsWg.Done()
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+
+	jobsWg.Wait()
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(workers))
+}
+
+func TestScheduleExecution_BackPressure(t *testing.T) {
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		appWg.Wait()
+	}()
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{Workers: 1, QueueSize: 1})
+
+	release := make(chan struct{})
+	blockingJob := func() { <-release }
+
+	runtime.ScheduleExecution(types.MessageEnvelope{}, blockingJob) // picked up by the worker
+	require.Eventually(t, func() bool { return len(runtime.workerPool.queues[0]) == 0 }, time.Second, time.Millisecond)
+	runtime.ScheduleExecution(types.MessageEnvelope{}, blockingJob) // fills the queue
+
+	submitted := make(chan struct{})
+	go func() {
+		runtime.ScheduleExecution(types.MessageEnvelope{}, func() {})
+		close(submitted)
+	}()
+
+	select {
+	case <-submitted:
+		t.Fatal("schedule should block while the pool is at capacity")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case <-submitted:
+	case <-time.After(time.Second):
+		t.Fatal("schedule should unblock once the pool has capacity")
+	}
+}
+
+func TestScheduleExecution_PanicRecovery(t *testing.T) {
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		appWg.Wait()
+	}()
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{Workers: 1})
+
+	runtime.ScheduleExecution(types.MessageEnvelope{}, func() { panic("bad message") })
+
+	done := make(chan struct{})
+	runtime.ScheduleExecution(types.MessageEnvelope{}, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not survive the panic")
+	}
+}
+
+func TestScheduleExecution_OrderByDeviceName(t *testing.T) {
+	appWg := &sync.WaitGroup{}
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		appWg.Wait()
+	}()
+
+	runtime := NewGolangRuntime(serviceKey, nil, dic)
+	runtime.StartWorkerPool(appWg, appCtx, sdkCommon.WorkerPoolConfig{Workers: 4, QueueSize: 100, OrderByDeviceName: true})
+
+	devices := []string{"device-a", "device-b", "device-c"}
+	results := make(map[string][]int)
+	resultsLock := sync.Mutex{}
+	jobsWg := sync.WaitGroup{}
+
+	for i := 0; i < 20; i++ {
+		for _, device := range devices {
+			envelope := types.MessageEnvelope{
+				ContentType: common.ContentTypeJSON,
+				Payload:     []byte(fmt.Sprintf(`{"event":{"deviceName":"%s"}}`, device)),
+			}
+			index := i
+			deviceName := device
+			jobsWg.Add(1)
+			runtime.ScheduleExecution(envelope, func() {
+				defer jobsWg.Done()
+				resultsLock.Lock()
+				defer resultsLock.Unlock()
+				results[deviceName] = append(results[deviceName], index)
+			})
+		}
+	}
+
+	jobsWg.Wait()
+
+	for _, device := range devices {
+		require.Len(t, results[device], 20)
+		for i, index := range results[device] {
+			assert.Equal(t, i, index, "messages for %s not processed in order", device)
+		}
+	}
+}
+
+func TestOrderingKey(t *testing.T) {
+	cborEvent, err := cbor.Marshal(map[string]string{"deviceName": "cbor-device"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name        string
+		ContentType string
+		Payload     []byte
+		Expected    string
+	}{
+		{"AddEventRequest", common.ContentTypeJSON, []byte(`{"event":{"deviceName":"request-device"}}`), "request-device"},
+		{"Event", common.ContentTypeJSON, []byte(`{"deviceName":"event-device"}`), "event-device"},
+		{"CBOR Event", common.ContentTypeCBOR, cborEvent, "cbor-device"},
+		{"Custom type", common.ContentTypeJSON, []byte(`{"temperature":10}`), "some/topic"},
+		{"Not JSON", common.ContentTypeJSON, []byte(`not json`), "some/topic"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			envelope := types.MessageEnvelope{ContentType: test.ContentType, Payload: test.Payload, ReceivedTopic: "some/topic"}
+			assert.Equal(t, test.Expected, orderingKey(envelope))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secretchain
+
+import (
+	"sync"
+	"time"
+
+	bootstrapInterfaces "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// Chain is the service's SecretProvider, asking the registered SecretProviders, in the order registered, for the
+// secrets before the Secret Store (Vault, or InsecureSecrets). It replaces the Secret Store's provider in the DIC, so
+// the context's and service's GetSecret and the SDK's own functions see the registered providers' secrets too.
+// Secrets are only stored in the Secret Store.
+type Chain struct {
+	secretStore bootstrapInterfaces.SecretProvider
+	lock        sync.RWMutex
+	providers   []interfaces.SecretProvider
+}
+
+// NewChain creates, initializes and returns a new instance of Chain falling back to the Secret Store's provider
+func NewChain(secretStore bootstrapInterfaces.SecretProvider) *Chain {
+	return &Chain{
+		secretStore: secretStore,
+	}
+}
+
+// Register adds the provider, asked for the secrets after the providers already registered
+func (chain *Chain) Register(provider interfaces.SecretProvider) {
+	chain.lock.Lock()
+	defer chain.lock.Unlock()
+
+	chain.providers = append(chain.providers, provider)
+}
+
+// GetSecret returns the secrets from the first registered provider holding the path, or from the Secret Store when
+// none does. An error is returned if any of the keys (if specified) are not found.
+func (chain *Chain) GetSecret(path string, keys ...string) (map[string]string, error) {
+	for _, provider := range chain.registered() {
+		secrets, found, err := provider.GetSecrets(path, keys...)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return secrets, nil
+		}
+	}
+
+	return chain.secretStore.GetSecret(path, keys...)
+}
+
+// StoreSecret stores the secrets in the Secret Store
+func (chain *Chain) StoreSecret(path string, secrets map[string]string) error {
+	return chain.secretStore.StoreSecret(path, secrets)
+}
+
+// SecretsUpdated sets the secrets last updated time of the Secret Store to the current time
+func (chain *Chain) SecretsUpdated() {
+	chain.secretStore.SecretsUpdated()
+}
+
+// SecretsLastUpdated returns the last time the secrets were updated, in any of the registered providers or the
+// Secret Store
+func (chain *Chain) SecretsLastUpdated() time.Time {
+	lastUpdated := chain.secretStore.SecretsLastUpdated()
+	for _, provider := range chain.registered() {
+		if updated := provider.SecretsLastUpdated(); updated.After(lastUpdated) {
+			lastUpdated = updated
+		}
+	}
+
+	return lastUpdated
+}
+
+// GetAccessToken returns the access token from the Secret Store
+func (chain *Chain) GetAccessToken(tokenType string, serviceKey string) (string, error) {
+	return chain.secretStore.GetAccessToken(tokenType, serviceKey)
+}
+
+// registered returns a copy of the registered providers, so they are asked without holding the lock
+func (chain *Chain) registered() []interfaces.SecretProvider {
+	chain.lock.RLock()
+	defer chain.lock.RUnlock()
+
+	return append([]interfaces.SecretProvider(nil), chain.providers...)
+}
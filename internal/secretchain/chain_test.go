@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secretchain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider holds the secrets of the paths in its map
+type fakeProvider struct {
+	secrets     map[string]map[string]string
+	err         error
+	lastUpdated time.Time
+}
+
+func (provider *fakeProvider) GetSecrets(path string, _ ...string) (map[string]string, bool, error) {
+	if provider.err != nil {
+		return nil, true, provider.err
+	}
+	secrets, found := provider.secrets[path]
+	return secrets, found, nil
+}
+
+func (provider *fakeProvider) SecretsLastUpdated() time.Time {
+	return provider.lastUpdated
+}
+
+func TestChain_GetSecret(t *testing.T) {
+	secretStore := &mocks.SecretProvider{}
+	secretStore.On("GetSecret", "redisdb", "password").Return(map[string]string{"password": "vault"}, nil)
+
+	chain := NewChain(secretStore)
+	chain.Register(&fakeProvider{secrets: map[string]map[string]string{"mqtt": {"password": "first"}}})
+	chain.Register(&fakeProvider{secrets: map[string]map[string]string{
+		"mqtt": {"password": "second"},
+		"api":  {"key": "second"},
+	}})
+
+	secrets, err := chain.GetSecret("mqtt", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "first"}, secrets, "providers should be asked in the order registered")
+
+	secrets, err = chain.GetSecret("api", "key")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "second"}, secrets)
+
+	secrets, err = chain.GetSecret("redisdb", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "vault"}, secrets, "path no provider holds should be read from the Secret Store")
+
+	chain.Register(&fakeProvider{err: errors.New("unavailable")})
+	_, err = chain.GetSecret("redisdb", "password")
+	assert.EqualError(t, err, "unavailable", "provider's error should be returned")
+}
+
+func TestChain_SecretsLastUpdated(t *testing.T) {
+	storeUpdated := time.Now().Add(-time.Hour)
+	secretStore := &mocks.SecretProvider{}
+	secretStore.On("SecretsLastUpdated").Return(storeUpdated)
+
+	chain := NewChain(secretStore)
+	assert.Equal(t, storeUpdated, chain.SecretsLastUpdated())
+
+	providerUpdated := time.Now()
+	chain.Register(&fakeProvider{lastUpdated: providerUpdated})
+	chain.Register(&fakeProvider{lastUpdated: storeUpdated.Add(-time.Hour)})
+	assert.Equal(t, providerUpdated, chain.SecretsLastUpdated())
+}
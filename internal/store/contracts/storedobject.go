@@ -17,6 +17,7 @@ package contracts
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -41,6 +42,8 @@ type StoredObject struct {
 	CorrelationID string
 	// ContextData is a snapshot of data used by the pipeline at runtime
 	ContextData map[string]string
+	// Created is the Unix nano timestamp of when the object was first stored, used to retry in order
+	Created int64
 }
 
 // NewStoredObject creates a new instance of StoredObject and is the preferred way to create one.
@@ -54,6 +57,7 @@ func NewStoredObject(appServiceKey string, payload []byte, pipelineId string, pi
 		PipelinePosition: pipelinePosition,
 		Version:          version,
 		ContextData:      contextData,
+		Created:          time.Now().UnixNano(),
 	}
 }
 
@@ -3,6 +3,10 @@
 package mocks
 
 import (
+	time "time"
+
+	config "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
+
 	contracts "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/contracts"
 
 	mock "github.com/stretchr/testify/mock"
@@ -27,6 +31,27 @@ func (_m *StoreClient) Disconnect() error {
 	return r0
 }
 
+// ExportKeyExists provides a mock function with given fields: appServiceKey, key
+func (_m *StoreClient) ExportKeyExists(appServiceKey string, key string) (bool, error) {
+	ret := _m.Called(appServiceKey, key)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(appServiceKey, key)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(appServiceKey, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RemoveFromStore provides a mock function with given fields: o
 func (_m *StoreClient) RemoveFromStore(o contracts.StoredObject) error {
 	ret := _m.Called(o)
@@ -41,6 +66,27 @@ func (_m *StoreClient) RemoveFromStore(o contracts.StoredObject) error {
 	return r0
 }
 
+// RetrieveChainHead provides a mock function with given fields: appServiceKey, chainKey
+func (_m *StoreClient) RetrieveChainHead(appServiceKey string, chainKey string) (string, error) {
+	ret := _m.Called(appServiceKey, chainKey)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string) string); ok {
+		r0 = rf(appServiceKey, chainKey)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(appServiceKey, chainKey)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RetrieveFromStore provides a mock function with given fields: appServiceKey
 func (_m *StoreClient) RetrieveFromStore(appServiceKey string) ([]contracts.StoredObject, error) {
 	ret := _m.Called(appServiceKey)
@@ -80,6 +126,34 @@ func (_m *StoreClient) Store(o contracts.StoredObject) (string, error) {
 	return r0, r1
 }
 
+// StoreChainHead provides a mock function with given fields: appServiceKey, chainKey, head
+func (_m *StoreClient) StoreChainHead(appServiceKey string, chainKey string, head string) error {
+	ret := _m.Called(appServiceKey, chainKey, head)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(appServiceKey, chainKey, head)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StoreExportKey provides a mock function with given fields: appServiceKey, key, expiry
+func (_m *StoreClient) StoreExportKey(appServiceKey string, key string, expiry time.Duration) error {
+	ret := _m.Called(appServiceKey, key, expiry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) error); ok {
+		r0 = rf(appServiceKey, key, expiry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: o
 func (_m *StoreClient) Update(o contracts.StoredObject) error {
 	ret := _m.Called(o)
@@ -93,3 +167,17 @@ func (_m *StoreClient) Update(o contracts.StoredObject) error {
 
 	return r0
 }
+
+// UpdateCredentials provides a mock function with given fields: credentials
+func (_m *StoreClient) UpdateCredentials(credentials config.Credentials) error {
+	ret := _m.Called(credentials)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(config.Credentials) error); ok {
+		r0 = rf(credentials)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -16,7 +16,11 @@
 package interfaces
 
 import (
+	"time"
+
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/contracts"
+
+	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
 )
 
 // StoreClient establishes the contracts required to persist exported data before being forwarded.
@@ -33,6 +37,21 @@ type StoreClient interface {
 	// RemoveFromStore removes an object from the data store.
 	RemoveFromStore(o contracts.StoredObject) error
 
+	// StoreExportKey saves the idempotency key of data exported by the app service until the expiry has passed.
+	StoreExportKey(appServiceKey string, key string, expiry time.Duration) error
+
+	// ExportKeyExists returns whether the idempotency key of data exported by the app service is saved.
+	ExportKeyExists(appServiceKey string, key string) (bool, error)
+
+	// StoreChainHead saves the head of the app service's hash chain with the key, replacing the previous head.
+	StoreChainHead(appServiceKey string, chainKey string, head string) error
+
+	// RetrieveChainHead gets the head of the app service's hash chain with the key, or empty when not saved.
+	RetrieveChainHead(appServiceKey string, chainKey string) (string, error)
+
 	// Disconnect ends the connection.
 	Disconnect() error
+
+	// UpdateCredentials reconnects with the credentials, replacing the connections made with the previous credentials.
+	UpdateCredentials(credentials bootstrapConfig.Credentials) error
 }
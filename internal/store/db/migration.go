@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright (c) 2021 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package db
+
+import (
+	"fmt"
+)
+
+// Migration upgrades the data of a store to the schema Version, so changes to the stored objects' fields or indexes
+// don't break the decoding of the data stored by previous versions.
+type Migration struct {
+	// Version is the schema version the data is in once migrated, starting at 1
+	Version int
+	// Description describes the change made to the data
+	Description string
+	// Migrate changes the data to the schema version, nothing to change when nil. It may be run again if the
+	// service stops before the version is recorded, so must be idempotent.
+	Migrate func() error
+}
+
+// SchemaVersioner reads and records the schema version of the data in a store
+type SchemaVersioner interface {
+	// SchemaVersion returns the schema version of the data in the store, 0 when not recorded
+	SchemaVersion() (int, error)
+	// SetSchemaVersion records the schema version of the data in the store
+	SetSchemaVersion(version int) error
+}
+
+// RunMigrations runs the migrations newer than the store's schema version in order of their versions, recording the
+// version after each so an interrupted upgrade resumes where it failed. An error is returned when the migrations
+// aren't in increasing order of versions or the store's data is in a version newer than the latest migration, i.e.
+// was upgraded by a newer version of the service.
+func RunMigrations(versioner SchemaVersioner, migrations []Migration) (int, error) {
+	latest := 0
+	for _, migration := range migrations {
+		if migration.Version <= latest {
+			return 0, fmt.Errorf("migration '%s' has version %d, versions must start at 1 and increase",
+				migration.Description, migration.Version)
+		}
+		latest = migration.Version
+	}
+
+	current, err := versioner.SchemaVersion()
+	if err != nil {
+		return 0, fmt.Errorf("unable to get the store's schema version: %s", err.Error())
+	}
+
+	if current > latest {
+		return current, fmt.Errorf("store's schema version %d is newer than the latest version %d supported", current, latest)
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		if migration.Migrate != nil {
+			if err := migration.Migrate(); err != nil {
+				return current, fmt.Errorf("migration to schema version %d (%s) failed: %s",
+					migration.Version, migration.Description, err.Error())
+			}
+		}
+
+		if err := versioner.SetSchemaVersion(migration.Version); err != nil {
+			return current, fmt.Errorf("unable to set the store's schema version to %d: %s", migration.Version, err.Error())
+		}
+		current = migration.Version
+	}
+
+	return current, nil
+}
@@ -0,0 +1,92 @@
+/*******************************************************************************
+ * Copyright (c) 2021 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testVersioner struct {
+	version int
+	history []int
+}
+
+func (v *testVersioner) SchemaVersion() (int, error) {
+	return v.version, nil
+}
+
+func (v *testVersioner) SetSchemaVersion(version int) error {
+	v.version = version
+	v.history = append(v.history, version)
+	return nil
+}
+
+func TestRunMigrations(t *testing.T) {
+	var migrated []int
+	migration := func(version int) func() error {
+		return func() error {
+			migrated = append(migrated, version)
+			return nil
+		}
+	}
+
+	migrations := []Migration{
+		{Version: 1, Description: "baseline"},
+		{Version: 2, Description: "second", Migrate: migration(2)},
+		{Version: 3, Description: "third", Migrate: migration(3)},
+	}
+
+	versioner := &testVersioner{version: 1}
+	version, err := RunMigrations(versioner, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.Equal(t, []int{2, 3}, migrated, "only the migrations newer than the store's version should run")
+	assert.Equal(t, []int{2, 3}, versioner.history)
+
+	migrated = nil
+	version, err = RunMigrations(versioner, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, 3, version)
+	assert.Empty(t, migrated, "migrated store should not be migrated again")
+}
+
+func TestRunMigrationsFailed(t *testing.T) {
+	versioner := &testVersioner{}
+	version, err := RunMigrations(versioner, []Migration{
+		{Version: 1, Description: "baseline"},
+		{Version: 2, Description: "failing", Migrate: func() error { return errors.New("failed") }},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "schema version 2 (failing) failed")
+	assert.Equal(t, 1, version, "version of the migrations run before the failure should be recorded")
+	assert.Equal(t, 1, versioner.version)
+}
+
+func TestRunMigrationsValidation(t *testing.T) {
+	_, err := RunMigrations(&testVersioner{}, []Migration{{Version: 2}, {Version: 1}})
+	require.Error(t, err, "migrations out of order should fail")
+
+	_, err = RunMigrations(&testVersioner{}, []Migration{{Version: 0}})
+	require.Error(t, err, "versions should start at 1")
+
+	versioner := &testVersioner{version: 3}
+	_, err = RunMigrations(versioner, []Migration{{Version: 1}, {Version: 2}})
+	require.Error(t, err, "store migrated by a newer version should fail")
+	assert.Empty(t, versioner.history)
+}
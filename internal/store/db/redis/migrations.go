@@ -0,0 +1,121 @@
+/*******************************************************************************
+ * Copyright (c) 2021 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software distributed under the License
+ * is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+ * or implied. See the License for the specific language governing permissions and limitations under
+ * the License.
+ *******************************************************************************/
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+)
+
+const (
+	schemaVersionKey = nameSpace + ":schema:version"
+	migrationLockKey = nameSpace + ":schema:lock"
+
+	// migrationLockExpiry is the time the migration lock is held before it expires, so a service stopping while
+	// migrating doesn't block the others
+	migrationLockExpiry = time.Minute
+	migrationLockPoll   = 100 * time.Millisecond
+)
+
+// migrations returns the migrations of the data stored in Redis in order of their versions. Changes to the stored
+// objects' fields or keys must add a migration upgrading the data stored by previous versions.
+func (c Client) migrations() []db.Migration {
+	return []db.Migration{
+		{
+			// Stores created before the schema was versioned are already in this schema
+			Version:     1,
+			Description: "objects stored as JSON by ID and indexed by AppServiceKey",
+		},
+	}
+}
+
+// SchemaVersion returns the schema version of the data stored in Redis, 0 when not recorded
+func (c Client) SchemaVersion() (int, error) {
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	version, err := redis.Int(conn.Do("GET", schemaVersionKey))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// SetSchemaVersion records the schema version of the data stored in Redis
+func (c Client) SetSchemaVersion(version int) error {
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	_, err := conn.Do("SET", schemaVersionKey, version)
+	return err
+}
+
+// migrate runs the migrations newer than the schema version of the data stored in Redis while holding the migration
+// lock, so the app services sharing the database don't migrate the data concurrently.
+func (c Client) migrate() error {
+	token := uuid.NewString()
+	if err := c.lockMigration(token); err != nil {
+		return err
+	}
+	defer c.unlockMigration(token)
+
+	_, err := db.RunMigrations(c, c.migrations())
+	return err
+}
+
+// lockMigration waits for the migration lock to be acquired with the token, at most the lock's expiry
+func (c Client) lockMigration(token string) error {
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	deadline := time.Now().Add(migrationLockExpiry)
+	for {
+		_, err := redis.String(conn.Do("SET", migrationLockKey, token, "NX", "PX", migrationLockExpiry.Milliseconds()))
+		if err == nil {
+			return nil
+		}
+		if err != redis.ErrNil {
+			return fmt.Errorf("unable to acquire the store's migration lock: %s", err.Error())
+		}
+
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for the store's migration lock")
+		}
+		time.Sleep(migrationLockPoll)
+	}
+}
+
+// unlockMigration releases the migration lock when still held with the token
+func (c Client) unlockMigration(token string) {
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.Send("WATCH", migrationLockKey)
+	held, err := redis.String(conn.Do("GET", migrationLockKey))
+	if err != nil || held != token {
+		_, _ = conn.Do("UNWATCH")
+		return
+	}
+
+	_ = conn.Send("MULTI")
+	_ = conn.Send("DEL", migrationLockKey)
+	_, _ = conn.Do("EXEC")
+}
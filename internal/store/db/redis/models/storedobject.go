@@ -41,6 +41,8 @@ type StoredObject struct {
 	CorrelationID string `json:"correlationID"`
 	// ContextData is a snapshot of data used by the pipeline at runtime
 	ContextData map[string]string
+	// Created is the Unix nano timestamp of when the object was first stored
+	Created int64 `json:"created"`
 }
 
 // ToContract builds a contract out of the supplied model.
@@ -55,6 +57,7 @@ func (o StoredObject) ToContract() contracts.StoredObject {
 		Version:          o.Version,
 		CorrelationID:    o.CorrelationID,
 		ContextData:      o.ContextData,
+		Created:          o.Created,
 	}
 }
 
@@ -69,6 +72,7 @@ func (o *StoredObject) FromContract(c contracts.StoredObject) {
 	o.Version = c.Version
 	o.CorrelationID = c.CorrelationID
 	o.ContextData = c.ContextData
+	o.Created = c.Created
 }
 
 // MarshalJSON returns the object as a JSON encoded byte array.
@@ -85,12 +89,14 @@ func (o StoredObject) MarshalJSON() ([]byte, error) {
 		EventID          *string           `json:"eventID,omitempty"`
 		EventChecksum    *string           `json:"eventChecksum,omitempty"`
 		ContextData      map[string]string `json:"contextData,omitempty"`
+		Created          int64             `json:"created,omitempty"`
 	}{
 		Payload:          o.Payload,
 		RetryCount:       o.RetryCount,
 		PipelineId:       o.PipelineId,
 		PipelinePosition: o.PipelinePosition,
 		ContextData:      o.ContextData,
+		Created:          o.Created,
 	}
 
 	// Empty strings are null
@@ -124,6 +130,7 @@ func (o *StoredObject) UnmarshalJSON(data []byte) error {
 		EventID          *string           `json:"eventID"`
 		EventChecksum    *string           `json:"eventChecksum"`
 		ContextData      map[string]string `json:"contextData,omitempty"`
+		Created          int64             `json:"created"`
 	})
 
 	// Error with unmarshaling
@@ -150,6 +157,7 @@ func (o *StoredObject) UnmarshalJSON(data []byte) error {
 	o.PipelineId = alias.PipelineId
 	o.PipelinePosition = alias.PipelinePosition
 	o.ContextData = alias.ContextData
+	o.Created = alias.Created
 
 	return nil
 }
@@ -32,6 +32,7 @@ const (
 	TestPipelinePosition = 1337
 	TestVersion          = "your"
 	TestCorrelationID    = "test"
+	TestCreated          = 1620000000000000000
 )
 
 var TestContractValid = contracts.StoredObject{
@@ -43,6 +44,7 @@ var TestContractValid = contracts.StoredObject{
 	Version:          TestVersion,
 	CorrelationID:    TestCorrelationID,
 	ContextData:      TestContextData,
+	Created:          TestCreated,
 }
 
 var TestModelValid = StoredObject{
@@ -54,6 +56,7 @@ var TestModelValid = StoredObject{
 	Version:          TestVersion,
 	CorrelationID:    TestCorrelationID,
 	ContextData:      TestContextData,
+	Created:          TestCreated,
 }
 
 var TestModelEmpty = StoredObject{}
@@ -118,7 +121,7 @@ func TestStoredObject_MarshalJSON(t *testing.T) {
 			"Successful marshalling",
 			TestModelValid,
 			false,
-			`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":"YnJhbmRvbiB3cm90ZSB0aGlz","retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","contextData":{"test":"data"}}`,
+			`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":"YnJhbmRvbiB3cm90ZSB0aGlz","retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","contextData":{"test":"data"},"created":1620000000000000000}`,
 		},
 		{
 			"Successful, empty",
@@ -155,7 +158,7 @@ func TestStoredObject_UnmarshalJSON(t *testing.T) {
 		{
 			"Valid",
 			TestModelValid,
-			args{[]byte(`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":[98,114,97,110,100,111,110,32,119,114,111,116,101,32,116,104,105,115],"retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","eventID":"probably","eventChecksum":"failed :(","contextData":{"test":"data"}}`)},
+			args{[]byte(`{"id":"fb49a277-9edf-4489-a89c-235b365107f7","appServiceKey":"apps","payload":[98,114,97,110,100,111,110,32,119,114,111,116,101,32,116,104,105,115],"retryCount":2,"pipelinePosition":1337,"version":"your","correlationID":"test","eventID":"probably","eventChecksum":"failed :(","contextData":{"test":"data"},"created":1620000000000000000}`)},
 			false,
 		},
 		{
@@ -39,10 +39,33 @@ const nameSpace = "store"
 
 // Client provides an implementation for the Client interface for Redis
 type Client struct {
-	Pool      *redis.Pool // A thread-safe pool of connections to Redis
+	Pool      *connectionPool // A thread-safe pool of connections to Redis, replaced when the credentials are updated
 	BatchSize int
 }
 
+// connectionPool holds the pool of connections so it can be replaced while the client is in use
+type connectionPool struct {
+	lock    sync.RWMutex
+	config  db.DatabaseInfo
+	current *redis.Pool
+}
+
+// Get gets a connection from the current pool
+func (p *connectionPool) Get() redis.Conn {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.current.Get()
+}
+
+// Close closes the current pool
+func (p *connectionPool) Close() error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.current.Close()
+}
+
 // Store persists a stored object to the data store. Three ("Three shall be the number thou shalt
 // count, and the number of the counting shall be three") keys are used:
 // * the object id to point to a STRING which is the marshalled JSON.
@@ -197,52 +220,156 @@ func (c Client) RemoveFromStore(o contracts.StoredObject) error {
 	return nil
 }
 
+// StoreExportKey saves the idempotency key of data exported by the app service until the expiry has passed. The key
+// is prefixed with the AppServiceKey to avoid key collisions and Redis removes it once expired.
+func (c Client) StoreExportKey(appServiceKey string, key string, expiry time.Duration) error {
+	if appServiceKey == "" || key == "" {
+		return errors.New("no AppServiceKey or idempotency key provided")
+	}
+
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	_, err := conn.Do("SET", nameSpace+":export:"+appServiceKey+":"+key, 1, "PX", expiry.Milliseconds())
+	return err
+}
+
+// ExportKeyExists returns whether the idempotency key of data exported by the app service is saved.
+func (c Client) ExportKeyExists(appServiceKey string, key string) (bool, error) {
+	if appServiceKey == "" || key == "" {
+		return false, errors.New("no AppServiceKey or idempotency key provided")
+	}
+
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	return redis.Bool(conn.Do("EXISTS", nameSpace+":export:"+appServiceKey+":"+key))
+}
+
+// StoreChainHead saves the head of the app service's hash chain with the key, replacing the previous head. The head
+// is kept until replaced so the chain continues across restarts.
+func (c Client) StoreChainHead(appServiceKey string, chainKey string, head string) error {
+	if appServiceKey == "" || chainKey == "" {
+		return errors.New("no AppServiceKey or chain key provided")
+	}
+
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	_, err := conn.Do("SET", nameSpace+":chain:"+appServiceKey+":"+chainKey, head)
+	return err
+}
+
+// RetrieveChainHead gets the head of the app service's hash chain with the key, or empty when not saved.
+func (c Client) RetrieveChainHead(appServiceKey string, chainKey string) (string, error) {
+	if appServiceKey == "" || chainKey == "" {
+		return "", errors.New("no AppServiceKey or chain key provided")
+	}
+
+	conn := c.Pool.Get()
+	defer func() { _ = conn.Close() }()
+
+	head, err := redis.String(conn.Do("GET", nameSpace+":chain:"+appServiceKey+":"+chainKey))
+	if err == redis.ErrNil {
+		return "", nil
+	}
+	return head, err
+}
+
 // Disconnect ends the connection.
 func (c Client) Disconnect() error {
 	return c.Pool.Close()
 }
 
-// NewClient provides a factory for building a StoreClient
+// UpdateCredentials reconnects to Redis with the credentials and replaces the pool of connections, closing the
+// previous pool. The previous pool is kept when the credentials fail to connect.
+func (c Client) UpdateCredentials(credentials bootstrapConfig.Credentials) error {
+	pool, err := newPool(c.Pool.config, credentials)
+	if err != nil {
+		return err
+	}
+
+	conn := pool.Get()
+	_, err = conn.Do("PING")
+	_ = conn.Close()
+	if err != nil {
+		_ = pool.Close()
+		return fmt.Errorf("unable to connect to Redis with the updated credentials: %s", err.Error())
+	}
+
+	c.Pool.lock.Lock()
+	previous := c.Pool.current
+	c.Pool.current = pool
+	c.Pool.lock.Unlock()
+
+	// Connections in use are closed once returned to the closed pool
+	return previous.Close()
+}
+
+// NewClient provides a factory for building a StoreClient, migrating the data stored in Redis to the latest schema
 func NewClient(config db.DatabaseInfo, credentials bootstrapConfig.Credentials) (interfaces.StoreClient, error) {
 	var retErr error
 	once.Do(func() {
-		connectionString := fmt.Sprintf("%s:%d", config.Host, config.Port)
-		connectTimeout, err := time.ParseDuration(config.Timeout)
+		pool, err := newPool(config, credentials)
 		if err != nil {
-			retErr = fmt.Errorf("config.Timeout failed to parse: %v", err)
+			retErr = err
 			return
 		}
 
-		opts := []redis.DialOption{
-			redis.DialPassword(credentials.Password),
-			redis.DialConnectTimeout(connectTimeout),
-		}
-
-		dialFunc := func() (redis.Conn, error) {
-			conn, err := redis.Dial(
-				"tcp", connectionString, opts...,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("Could not dial Redis: %s", err)
-			}
-			return conn, nil
-		}
 		currClient = &Client{
-			Pool: &redis.Pool{
-				IdleTimeout: connectTimeout,
-				/* The current implementation processes nested structs using concurrent connections.
-				 * With the deepest nesting level being 3, three shall be the number of maximum open
-				 * idle connections in the pool, to allow reuse.
-				 * TODO: Once we have a concurrent benchmark, this should be revisited.
-				 * TODO: Longer term, once the objects are clean of external dependencies, the use
-				 * of another serializer should make this moot.
-				 */
-				MaxIdle: config.MaxIdle,
-				Dial:    dialFunc,
+			Pool: &connectionPool{
+				config:  config,
+				current: pool,
 			},
 			BatchSize: config.BatchSize,
 		}
 	})
 
-	return currClient, retErr
+	if retErr != nil || currClient == nil {
+		return currClient, retErr
+	}
+
+	// Upgrade the data stored by previous versions before it is decoded
+	if err := currClient.migrate(); err != nil {
+		return nil, fmt.Errorf("unable to migrate the data in Redis: %s", err.Error())
+	}
+
+	return currClient, nil
+}
+
+// newPool creates the pool of connections to Redis authenticating with the credentials
+func newPool(config db.DatabaseInfo, credentials bootstrapConfig.Credentials) (*redis.Pool, error) {
+	connectionString := fmt.Sprintf("%s:%d", config.Host, config.Port)
+	connectTimeout, err := time.ParseDuration(config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("config.Timeout failed to parse: %v", err)
+	}
+
+	opts := []redis.DialOption{
+		redis.DialPassword(credentials.Password),
+		redis.DialConnectTimeout(connectTimeout),
+	}
+
+	dialFunc := func() (redis.Conn, error) {
+		conn, err := redis.Dial(
+			"tcp", connectionString, opts...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("Could not dial Redis: %s", err)
+		}
+		return conn, nil
+	}
+
+	return &redis.Pool{
+		IdleTimeout: connectTimeout,
+		/* The current implementation processes nested structs using concurrent connections.
+		 * With the deepest nesting level being 3, three shall be the number of maximum open
+		 * idle connections in the pool, to allow reuse.
+		 * TODO: Once we have a concurrent benchmark, this should be revisited.
+		 * TODO: Longer term, once the objects are clean of external dependencies, the use
+		 * of another serializer should make this moot.
+		 */
+		MaxIdle: config.MaxIdle,
+		Dial:    dialFunc,
+	}, nil
 }
@@ -23,6 +23,7 @@ package redis
 
 import (
 	"testing"
+	"time"
 
 	bootstrapConfig "github.com/edgexfoundry/go-mod-bootstrap/v2/config"
 	"github.com/stretchr/testify/require"
@@ -377,3 +378,90 @@ func TestClient_RemoveFromStore(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ExportKey(t *testing.T) {
+	appServiceKey := uuid.New().String()
+	client, _ := NewClient(TestValidNoAuthConfig, bootstrapConfig.Credentials{})
+
+	exists, err := client.ExportKeyExists(appServiceKey, "checksum")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, client.StoreExportKey(appServiceKey, "checksum", 100*time.Millisecond))
+
+	exists, err = client.ExportKeyExists(appServiceKey, "checksum")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = client.ExportKeyExists(uuid.New().String(), "checksum")
+	require.NoError(t, err)
+	require.False(t, exists, "keys of other app services should not exist")
+
+	time.Sleep(200 * time.Millisecond)
+	exists, err = client.ExportKeyExists(appServiceKey, "checksum")
+	require.NoError(t, err)
+	require.False(t, exists, "key should expire")
+
+	require.Error(t, client.StoreExportKey("", "checksum", time.Minute))
+}
+
+func TestClient_ChainHead(t *testing.T) {
+	appServiceKey := uuid.New().String()
+	client, _ := NewClient(TestValidNoAuthConfig, bootstrapConfig.Credentials{})
+
+	head, err := client.RetrieveChainHead(appServiceKey, "device-1")
+	require.NoError(t, err)
+	require.Empty(t, head)
+
+	require.NoError(t, client.StoreChainHead(appServiceKey, "device-1", "first"))
+	require.NoError(t, client.StoreChainHead(appServiceKey, "device-1", "second"))
+
+	head, err = client.RetrieveChainHead(appServiceKey, "device-1")
+	require.NoError(t, err)
+	require.Equal(t, "second", head)
+
+	head, err = client.RetrieveChainHead(uuid.New().String(), "device-1")
+	require.NoError(t, err)
+	require.Empty(t, head, "chains of other app services should not exist")
+
+	require.Error(t, client.StoreChainHead(appServiceKey, "", "head"))
+}
+
+func TestClient_UpdateCredentials(t *testing.T) {
+	appServiceKey := uuid.New().String()
+	client, _ := NewClient(TestValidNoAuthConfig, bootstrapConfig.Credentials{})
+
+	require.NoError(t, client.StoreChainHead(appServiceKey, "device-1", "first"))
+	require.NoError(t, client.UpdateCredentials(bootstrapConfig.Credentials{}))
+
+	head, err := client.RetrieveChainHead(appServiceKey, "device-1")
+	require.NoError(t, err)
+	require.Equal(t, "first", head, "rebuilt client should use the same database")
+
+	require.Error(t, client.UpdateCredentials(bootstrapConfig.Credentials{Password: "not-set"}))
+
+	head, err = client.RetrieveChainHead(appServiceKey, "device-1")
+	require.NoError(t, err, "previous connections should be kept when the credentials fail")
+	require.Equal(t, "first", head)
+}
+
+func TestClient_Migrate(t *testing.T) {
+	client, err := NewClient(TestValidNoAuthConfig, bootstrapConfig.Credentials{})
+	require.NoError(t, err)
+	redisClient := client.(*Client)
+
+	migrations := redisClient.migrations()
+	latest := migrations[len(migrations)-1].Version
+
+	version, err := redisClient.SchemaVersion()
+	require.NoError(t, err)
+	require.Equal(t, latest, version, "data should be migrated to the latest schema")
+
+	require.NoError(t, redisClient.SetSchemaVersion(latest+1))
+	_, err = NewClient(TestValidNoAuthConfig, bootstrapConfig.Credentials{})
+	require.Error(t, err, "data migrated by a newer version should not be decoded")
+
+	require.NoError(t, redisClient.SetSchemaVersion(latest))
+	_, err = NewClient(TestValidNoAuthConfig, bootstrapConfig.Credentials{})
+	require.NoError(t, err)
+}
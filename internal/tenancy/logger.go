@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tenancy
+
+import (
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+)
+
+const tenantKey = "tenant"
+
+// taggedLogger adds the tenant to each message logged
+type taggedLogger struct {
+	logger.LoggingClient
+	tenant string
+}
+
+// NewTaggedLogger returns a logging client that adds the tenant to each message logged by the client
+func NewTaggedLogger(lc logger.LoggingClient, tenant string) logger.LoggingClient {
+	return taggedLogger{LoggingClient: lc, tenant: tenant}
+}
+
+func (l taggedLogger) tag(args []interface{}) []interface{} {
+	return append(append([]interface{}{}, args...), tenantKey, l.tenant)
+}
+
+func (l taggedLogger) tagFormat(msg string, args []interface{}) (string, []interface{}) {
+	return msg + " (" + tenantKey + "=%s)", append(append([]interface{}{}, args...), l.tenant)
+}
+
+func (l taggedLogger) Debug(msg string, args ...interface{}) {
+	l.LoggingClient.Debug(msg, l.tag(args)...)
+}
+
+func (l taggedLogger) Error(msg string, args ...interface{}) {
+	l.LoggingClient.Error(msg, l.tag(args)...)
+}
+
+func (l taggedLogger) Info(msg string, args ...interface{}) {
+	l.LoggingClient.Info(msg, l.tag(args)...)
+}
+
+func (l taggedLogger) Trace(msg string, args ...interface{}) {
+	l.LoggingClient.Trace(msg, l.tag(args)...)
+}
+
+func (l taggedLogger) Warn(msg string, args ...interface{}) {
+	l.LoggingClient.Warn(msg, l.tag(args)...)
+}
+
+func (l taggedLogger) Debugf(msg string, args ...interface{}) {
+	format, tagged := l.tagFormat(msg, args)
+	l.LoggingClient.Debugf(format, tagged...)
+}
+
+func (l taggedLogger) Errorf(msg string, args ...interface{}) {
+	format, tagged := l.tagFormat(msg, args)
+	l.LoggingClient.Errorf(format, tagged...)
+}
+
+func (l taggedLogger) Infof(msg string, args ...interface{}) {
+	format, tagged := l.tagFormat(msg, args)
+	l.LoggingClient.Infof(format, tagged...)
+}
+
+func (l taggedLogger) Tracef(msg string, args ...interface{}) {
+	format, tagged := l.tagFormat(msg, args)
+	l.LoggingClient.Tracef(format, tagged...)
+}
+
+func (l taggedLogger) Warnf(msg string, args ...interface{}) {
+	format, tagged := l.tagFormat(msg, args)
+	l.LoggingClient.Warnf(format, tagged...)
+}
@@ -0,0 +1,245 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	SourceTopic  = "topic"
+	SourceHeader = "header"
+	SourceLabel  = "label"
+
+	DefaultHeader      = "X-Tenant-ID"
+	DefaultLabelPrefix = "tenant:"
+
+	// deviceTenantExpiry is how long the tenant found in a device's labels is used before the device is retrieved again
+	deviceTenantExpiry = time.Minute
+)
+
+// Stats contains the counts of a tenant's pipeline executions
+type Stats struct {
+	Tenant    string `json:"tenant"`
+	Succeeded uint64 `json:"succeeded"`
+	Failed    uint64 `json:"failed"`
+	// LastReceived is the time, in nanoseconds since the epoch, the last message for the tenant was received
+	LastReceived int64 `json:"lastReceived"`
+}
+
+type deviceTenant struct {
+	tenant  string
+	expires time.Time
+}
+
+// Router resolves the tenant of each message and keeps the statistics of each tenant's pipeline executions
+type Router struct {
+	config   common.TenancyInfo
+	lock     sync.Mutex
+	devices  map[string]deviceTenant
+	stats    map[string]*Stats
+	rejected uint64
+}
+
+// NewRouter creates, initializes and returns a new instance of Router for the configuration
+func NewRouter(config common.TenancyInfo) (*Router, error) {
+	config.Source = strings.ToLower(strings.TrimSpace(config.Source))
+	switch config.Source {
+	case SourceTopic:
+		if config.TopicLevel < 0 {
+			return nil, fmt.Errorf("invalid Tenancy TopicLevel %d, must not be negative", config.TopicLevel)
+		}
+	case SourceHeader:
+		if strings.TrimSpace(config.Header) == "" {
+			config.Header = DefaultHeader
+		}
+	case SourceLabel:
+		if config.LabelPrefix == "" {
+			config.LabelPrefix = DefaultLabelPrefix
+		}
+	default:
+		return nil, fmt.Errorf("invalid Tenancy Source '%s', must be '%s', '%s' or '%s'", config.Source, SourceTopic, SourceHeader, SourceLabel)
+	}
+
+	if len(config.Tenants) == 0 {
+		return nil, errors.New("no Tenancy Tenants configured")
+	}
+
+	if _, found := config.Tenants[config.DefaultTenant]; config.DefaultTenant != "" && !found {
+		return nil, fmt.Errorf("invalid Tenancy DefaultTenant '%s', must be one of the Tenants", config.DefaultTenant)
+	}
+
+	return &Router{
+		config:  config,
+		devices: make(map[string]deviceTenant),
+		stats:   make(map[string]*Stats),
+	}, nil
+}
+
+// Header returns the HTTP header containing the tenant id, or empty when the tenant id isn't from a header
+func (router *Router) Header() string {
+	if router.config.Source != SourceHeader {
+		return ""
+	}
+	return router.config.Header
+}
+
+// Resolve returns the tenant of the message received on the topic for the device, which is empty when the data isn't
+// an Event. The tenant id from the header is expected in the context's TENANT value, added by the HTTP trigger.
+// An error is returned when no tenant is found or the tenant isn't configured.
+func (router *Router) Resolve(ctx interfaces.AppFunctionContext, topic string, deviceName string) (string, error) {
+	var tenant string
+	var err error
+
+	switch router.config.Source {
+	case SourceTopic:
+		levels := strings.Split(topic, "/")
+		if router.config.TopicLevel < len(levels) {
+			tenant = levels[router.config.TopicLevel]
+		}
+	case SourceHeader:
+		tenant, _ = ctx.GetValue(interfaces.TENANT)
+	case SourceLabel:
+		if deviceName != "" {
+			tenant, err = router.deviceTenant(ctx, deviceName)
+			if err != nil {
+				router.reject()
+				return "", err
+			}
+		}
+	}
+
+	tenant = strings.TrimSpace(tenant)
+	if tenant == "" {
+		tenant = router.config.DefaultTenant
+	}
+
+	if tenant == "" {
+		router.reject()
+		return "", errors.New("no tenant id found for message")
+	}
+
+	if _, found := router.config.Tenants[tenant]; !found {
+		router.reject()
+		return "", fmt.Errorf("tenant '%s' is not configured", tenant)
+	}
+
+	return tenant, nil
+}
+
+// deviceTenant returns the tenant id from the device's labels, retrieving the device from Core Metadata when the
+// tenant found previously has expired
+func (router *Router) deviceTenant(ctx interfaces.AppFunctionContext, deviceName string) (string, error) {
+	router.lock.Lock()
+	cached, found := router.devices[deviceName]
+	router.lock.Unlock()
+
+	if found && time.Now().Before(cached.expires) {
+		return cached.tenant, nil
+	}
+
+	client := ctx.DeviceClient()
+	if client == nil {
+		return "", errors.New("DeviceClient not initialized. Core Metadata is missing from clients configuration")
+	}
+
+	response, err := client.DeviceByName(context.Background(), deviceName)
+	if err != nil {
+		return "", fmt.Errorf("unable to retrieve device '%s' for its tenant label: %s", deviceName, err.Error())
+	}
+
+	var tenant string
+	for _, label := range response.Device.Labels {
+		if strings.HasPrefix(label, router.config.LabelPrefix) {
+			tenant = strings.TrimPrefix(label, router.config.LabelPrefix)
+			break
+		}
+	}
+
+	router.lock.Lock()
+	router.devices[deviceName] = deviceTenant{tenant: tenant, expires: time.Now().Add(deviceTenantExpiry)}
+	router.lock.Unlock()
+
+	return tenant, nil
+}
+
+// Settings returns the settings of the tenant
+func (router *Router) Settings(tenant string) map[string]string {
+	return router.config.Tenants[tenant].Settings
+}
+
+// SecretPath returns the path of the secret for the tenant, which is prefixed with the tenant's SecretPath when set
+func (router *Router) SecretPath(tenant string, path string) string {
+	prefix := strings.TrimSuffix(router.config.Tenants[tenant].SecretPath, "/")
+	if prefix == "" {
+		return path
+	}
+	return prefix + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Record counts the pipeline execution for the tenant
+func (router *Router) Record(tenant string, succeeded bool) {
+	router.lock.Lock()
+	defer router.lock.Unlock()
+
+	stats, found := router.stats[tenant]
+	if !found {
+		stats = &Stats{Tenant: tenant}
+		router.stats[tenant] = stats
+	}
+
+	if succeeded {
+		stats.Succeeded++
+	} else {
+		stats.Failed++
+	}
+	stats.LastReceived = time.Now().UnixNano()
+}
+
+func (router *Router) reject() {
+	router.lock.Lock()
+	defer router.lock.Unlock()
+	router.rejected++
+}
+
+// Stats returns the statistics of each configured tenant, ordered by tenant id, and the number of messages rejected
+// for not having a configured tenant
+func (router *Router) Stats() ([]Stats, uint64) {
+	router.lock.Lock()
+	defer router.lock.Unlock()
+
+	all := make([]Stats, 0, len(router.config.Tenants))
+	for tenant := range router.config.Tenants {
+		stats, found := router.stats[tenant]
+		if !found {
+			stats = &Stats{Tenant: tenant}
+		}
+		all = append(all, *stats)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Tenant < all[j].Tenant })
+	return all, router.rejected
+}
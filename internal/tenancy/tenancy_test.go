@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package tenancy
+
+import (
+	"testing"
+
+	clientMocks "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+)
+
+var testTenants = map[string]common.TenantInfo{
+	"acme":   {SecretPath: "tenants/acme/"},
+	"globex": {},
+}
+
+func TestNewRouter(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      common.TenancyInfo
+		ExpectError bool
+	}{
+		{"Topic", common.TenancyInfo{Source: "Topic", TopicLevel: 1, Tenants: testTenants}, false},
+		{"Header", common.TenancyInfo{Source: SourceHeader, Tenants: testTenants}, false},
+		{"Label with default", common.TenancyInfo{Source: SourceLabel, DefaultTenant: "globex", Tenants: testTenants}, false},
+		{"Invalid source", common.TenancyInfo{Source: "payload", Tenants: testTenants}, true},
+		{"Negative topic level", common.TenancyInfo{Source: SourceTopic, TopicLevel: -1, Tenants: testTenants}, true},
+		{"No tenants", common.TenancyInfo{Source: SourceTopic}, true},
+		{"Unknown default", common.TenancyInfo{Source: SourceTopic, DefaultTenant: "initech", Tenants: testTenants}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewRouter(test.Config)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRouter_Resolve(t *testing.T) {
+	deviceClient := &clientMocks.DeviceClient{}
+	deviceClient.On("DeviceByName", mock.Anything, "sensor-1").
+		Return(responses.DeviceResponse{Device: dtos.Device{Labels: []string{"floor:2", "tenant:acme"}}}, nil).Once()
+	deviceClient.On("DeviceByName", mock.Anything, "sensor-2").
+		Return(responses.DeviceResponse{Device: dtos.Device{Labels: []string{"floor:1"}}}, nil).Once()
+
+	ctx := &mocks.AppFunctionContext{}
+	ctx.On("GetValue", interfaces.TENANT).Return("globex", true)
+	ctx.On("DeviceClient").Return(deviceClient)
+
+	tests := []struct {
+		Name           string
+		Config         common.TenancyInfo
+		Topic          string
+		DeviceName     string
+		ExpectedTenant string
+		ExpectError    bool
+	}{
+		{"Topic", common.TenancyInfo{Source: SourceTopic, TopicLevel: 1}, "customers/acme/events", "", "acme", false},
+		{"Topic unknown tenant", common.TenancyInfo{Source: SourceTopic, TopicLevel: 1}, "customers/initech/events", "", "", true},
+		{"Topic too short", common.TenancyInfo{Source: SourceTopic, TopicLevel: 3}, "customers/acme/events", "", "", true},
+		{"Topic default", common.TenancyInfo{Source: SourceTopic, TopicLevel: 3, DefaultTenant: "globex"}, "customers/acme/events", "", "globex", false},
+		{"Header", common.TenancyInfo{Source: SourceHeader}, "", "", "globex", false},
+		{"Label", common.TenancyInfo{Source: SourceLabel}, "", "sensor-1", "acme", false},
+		{"Label cached", common.TenancyInfo{Source: SourceLabel}, "", "sensor-1", "acme", false},
+		{"No label", common.TenancyInfo{Source: SourceLabel}, "", "sensor-2", "", true},
+		{"No device", common.TenancyInfo{Source: SourceLabel, DefaultTenant: "globex"}, "", "", "globex", false},
+	}
+
+	// The router is shared by the label tests so the device lookups are cached
+	var labelRouter *Router
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			test.Config.Tenants = testTenants
+			router, err := NewRouter(test.Config)
+			require.NoError(t, err)
+
+			if test.Config.Source == SourceLabel && test.Config.DefaultTenant == "" {
+				if labelRouter == nil {
+					labelRouter = router
+				}
+				router = labelRouter
+			}
+
+			tenant, err := router.Resolve(ctx, test.Topic, test.DeviceName)
+			if test.ExpectError {
+				require.Error(t, err)
+				_, rejected := router.Stats()
+				assert.Equal(t, uint64(1), rejected)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedTenant, tenant)
+		})
+	}
+
+	deviceClient.AssertExpectations(t)
+}
+
+func TestRouter_SecretPath(t *testing.T) {
+	router, err := NewRouter(common.TenancyInfo{Source: SourceTopic, Tenants: testTenants})
+	require.NoError(t, err)
+
+	assert.Equal(t, "tenants/acme/mqtt", router.SecretPath("acme", "mqtt"))
+	assert.Equal(t, "tenants/acme/mqtt", router.SecretPath("acme", "/mqtt"))
+	assert.Equal(t, "mqtt", router.SecretPath("globex", "mqtt"))
+}
+
+func TestRouter_Stats(t *testing.T) {
+	router, err := NewRouter(common.TenancyInfo{Source: SourceTopic, Tenants: testTenants})
+	require.NoError(t, err)
+
+	router.Record("globex", true)
+	router.Record("globex", false)
+	router.Record("globex", true)
+
+	stats, rejected := router.Stats()
+	assert.Equal(t, uint64(0), rejected)
+	require.Len(t, stats, 2)
+	assert.Equal(t, Stats{Tenant: "acme"}, stats[0])
+	assert.Equal(t, "globex", stats[1].Tenant)
+	assert.Equal(t, uint64(2), stats[1].Succeeded)
+	assert.Equal(t, uint64(1), stats[1].Failed)
+	assert.NotZero(t, stats[1].LastReceived)
+}
@@ -0,0 +1,352 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package eventhubs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap"
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/Azure/azure-amqp-common-go/v3/conn"
+	eventhub "github.com/Azure/azure-event-hubs-go/v3"
+	"github.com/Azure/azure-event-hubs-go/v3/persist"
+	"github.com/google/uuid"
+)
+
+const (
+	// SecretConnectionString is the key in the SecretPath of the Event Hub's connection string
+	SecretConnectionString = "connectionstring"
+
+	CheckpointStoreFile   = "file"
+	CheckpointStoreMemory = "memory"
+
+	StartPositionEarliest = "earliest"
+	StartPositionLatest   = "latest"
+
+	defaultConsumerGroup       = "$Default"
+	defaultCheckpointDirectory = "./checkpoints"
+)
+
+// Trigger implements Trigger to support receiving from Azure Event Hubs
+type Trigger struct {
+	dic     *di.Container
+	lc      logger.LoggingClient
+	runtime *runtime.GolangRuntime
+	hub     *eventhub.Hub
+	// checkpointOnReceipt indicates events are checkpointed when received rather than once processed
+	checkpointOnReceipt bool
+}
+
+func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime) *Trigger {
+	return &Trigger{
+		dic:     dic,
+		runtime: runtime,
+		lc:      bootstrapContainer.LoggingClientFrom(dic.Get),
+	}
+}
+
+// Initialize initializes the Trigger for an Azure Event Hub
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context, background <-chan interfaces.BackgroundMessage) (bootstrap.Deferred, error) {
+	// Convenience short cuts
+	lc := trigger.lc
+	config := container.ConfigurationFrom(trigger.dic.Get)
+	hubConfig := config.Trigger.EventHubs
+
+	lc.Info("Initializing Azure Event Hubs Trigger")
+
+	if background != nil {
+		return nil, errors.New("background publishing not supported for services using Azure Event Hubs trigger")
+	}
+
+	// A dummy AppFunctionContext is used to provide access to GetSecret
+	secrets, err := appfunction.NewContext("", trigger.dic, "").GetSecret(hubConfig.SecretPath, SecretConnectionString)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get Event Hub connection string from secret path '%s': %s", hubConfig.SecretPath, err.Error())
+	}
+
+	connectionString := secrets[SecretConnectionString]
+	parsed, err := conn.ParsedConnectionFromStr(connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Event Hub connection string: %s", err.Error())
+	}
+
+	if parsed.HubName == "" {
+		return nil, errors.New("invalid Event Hub connection string: EntityPath for the Event Hub is missing")
+	}
+
+	ackAfterProcessing, err := config.Trigger.AckAfterProcessing(sdkCommon.AckPolicyProcessed)
+	if err != nil {
+		return nil, err
+	}
+	trigger.checkpointOnReceipt = !ackAfterProcessing
+
+	store, err := newCheckpointStore(hubConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	hub, err := eventhub.NewHubFromConnectionString(connectionString, eventhub.HubWithOffsetPersistence(store))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Event Hub client for Azure Event Hubs trigger: %s", err.Error())
+	}
+
+	trigger.hub = hub
+
+	partitions := util.DeleteEmptyAndTrim(strings.FieldsFunc(hubConfig.Partitions, util.SplitComma))
+	if len(partitions) == 0 {
+		info, err := hub.GetRuntimeInformation(appCtx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get partitions of Event Hub '%s': %s", parsed.HubName, err.Error())
+		}
+		partitions = info.PartitionIDs
+	}
+
+	options := []eventhub.ReceiveOption{eventhub.ReceiveWithConsumerGroup(consumerGroup(hubConfig))}
+	if hubConfig.PrefetchCount > 0 {
+		options = append(options, eventhub.ReceiveWithPrefetchCount(hubConfig.PrefetchCount))
+	}
+
+	for _, partitionID := range partitions {
+		handle, err := hub.Receive(appCtx, partitionID, trigger.eventHandler(parsed.HubName, partitionID), options...)
+		if err != nil {
+			_ = hub.Close(context.Background())
+			return nil, fmt.Errorf("unable to receive from partition '%s' of Event Hub '%s': %s", partitionID, parsed.HubName, err.Error())
+		}
+
+		trigger.watchListener(appWg, appCtx, partitionID, handle)
+	}
+
+	lc.Infof("Receiving from partition(s) %v of Event Hub '%s' for Azure Event Hubs trigger", partitions, parsed.HubName)
+
+	deferred := func() {
+		lc.Info("Closing Event Hub receivers for Azure Event Hubs trigger")
+		if err := trigger.hub.Close(context.Background()); err != nil {
+			lc.Errorf("Unable to close Event Hub client: %s", err.Error())
+		}
+	}
+
+	return deferred, nil
+}
+
+// watchListener logs when a partition listener stops receiving for reasons other than the service shutting down, and
+// closes the listener once the context is done so the events received while the service drains are left in the hub
+func (trigger *Trigger) watchListener(appWg *sync.WaitGroup, appCtx context.Context, partitionID string, handle *eventhub.ListenerHandle) {
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+
+		select {
+		case <-appCtx.Done():
+			if err := handle.Close(context.Background()); err != nil {
+				trigger.lc.Warnf("Unable to close listener for partition '%s': %s", partitionID, err.Error())
+			}
+		case <-handle.Done():
+			if err := handle.Err(); err != nil {
+				trigger.lc.Errorf("Azure Event Hubs trigger stopped receiving from partition '%s': %s", partitionID, err.Error())
+			}
+		}
+	}()
+}
+
+// eventHandler returns the handler for events received on the partition. The handler waits for all matching pipelines
+// to complete so the event's checkpoint is only saved once it has been processed successfully, unless the AckPolicy
+// is to checkpoint on receipt.
+func (trigger *Trigger) eventHandler(hubName string, partitionID string) eventhub.Handler {
+	receivedTopic := hubName + runtime.TopicLevelSeparator + partitionID
+
+	return func(_ context.Context, event *eventhub.Event) error {
+		// Convenience short cuts
+		lc := trigger.lc
+
+		envelope := toEnvelope(event, receivedTopic)
+
+		lc.Debugf("Azure Event Hubs Trigger: Received event with %d bytes from partition '%s'. Content-Type=%s",
+			len(envelope.Payload),
+			partitionID,
+			envelope.ContentType)
+		lc.Tracef("%s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+		done, duplicate := trigger.runtime.BeginMessage(envelope)
+		if duplicate {
+			return nil
+		}
+
+		pipelines := trigger.runtime.RoutePipelines(envelope)
+		lc.Debugf("Azure Event Hubs Trigger found %d pipeline(s) that match the incoming topic '%s'", len(pipelines), envelope.ReceivedTopic)
+
+		pipelinesWaitGroup := sync.WaitGroup{}
+		var failed int32
+
+		for _, pipeline := range pipelines {
+			p := pipeline
+			pipelinesWaitGroup.Add(1)
+			scheduled := trigger.runtime.ScheduleExecution(envelope, func() {
+				defer pipelinesWaitGroup.Done()
+				appContext := appfunction.NewContext(envelope.CorrelationID, trigger.dic, envelope.ContentType)
+				if err := trigger.runtime.ProcessMessage(appContext, envelope, p); err != nil {
+					// ProcessMessage logs the error, so no need to log it here.
+					atomic.StoreInt32(&failed, 1)
+				}
+			})
+
+			if !scheduled {
+				pipelinesWaitGroup.Done()
+				atomic.StoreInt32(&failed, 1)
+			}
+		}
+
+		if trigger.checkpointOnReceipt {
+			go func() {
+				pipelinesWaitGroup.Wait()
+				done(atomic.LoadInt32(&failed) == 0)
+			}()
+			return nil
+		}
+
+		pipelinesWaitGroup.Wait()
+		done(atomic.LoadInt32(&failed) == 0)
+
+		if atomic.LoadInt32(&failed) == 1 {
+			return fmt.Errorf("failed to process event from partition '%s' (%s=%s)", partitionID, common.CorrelationHeader, envelope.CorrelationID)
+		}
+
+		return nil
+	}
+}
+
+// toEnvelope builds the message envelope using the correlation ID and content type properties when present
+func toEnvelope(event *eventhub.Event, receivedTopic string) types.MessageEnvelope {
+	correlationID := eventProperty(event, common.CorrelationHeader)
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	contentType := eventProperty(event, common.ContentType)
+	if contentType == "" {
+		contentType = codec.DetectContentType(event.Data)
+	}
+
+	return types.MessageEnvelope{
+		CorrelationID: correlationID,
+		ContentType:   contentType,
+		Payload:       event.Data,
+		ReceivedTopic: receivedTopic,
+	}
+}
+
+func eventProperty(event *eventhub.Event, name string) string {
+	for key, value := range event.Properties {
+		if strings.EqualFold(key, name) {
+			if text, ok := value.(string); ok {
+				return text
+			}
+		}
+	}
+
+	return ""
+}
+
+func consumerGroup(hubConfig sdkCommon.EventHubsConfig) string {
+	if hubConfig.ConsumerGroup == "" {
+		return defaultConsumerGroup
+	}
+
+	return hubConfig.ConsumerGroup
+}
+
+// checkpointStore saves the checkpoints to the configured persister and provides the configured start position
+// for partitions that don't have a checkpoint saved yet.
+type checkpointStore struct {
+	persister persist.CheckpointPersister
+	start     persist.Checkpoint
+}
+
+func newCheckpointStore(hubConfig sdkCommon.EventHubsConfig) (*checkpointStore, error) {
+	store := &checkpointStore{}
+
+	switch strings.ToLower(hubConfig.StartPosition) {
+	case "", StartPositionEarliest:
+		store.start = persist.NewCheckpointFromStartOfStream()
+	case StartPositionLatest:
+		store.start = persist.NewCheckpointFromEndOfStream()
+	default:
+		return nil, fmt.Errorf("invalid Event Hubs StartPosition '%s', must be '%s' or '%s'",
+			hubConfig.StartPosition, StartPositionEarliest, StartPositionLatest)
+	}
+
+	switch strings.ToLower(hubConfig.CheckpointStore) {
+	case "", CheckpointStoreFile:
+		directory := hubConfig.CheckpointDirectory
+		if directory == "" {
+			directory = defaultCheckpointDirectory
+		}
+
+		persister, err := persist.NewFilePersister(directory)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Event Hubs checkpoint directory '%s': %s", directory, err.Error())
+		}
+		store.persister = persister
+	case CheckpointStoreMemory:
+		store.persister = persist.NewMemoryPersister()
+	default:
+		return nil, fmt.Errorf("invalid Event Hubs CheckpointStore '%s', must be '%s' or '%s'",
+			hubConfig.CheckpointStore, CheckpointStoreFile, CheckpointStoreMemory)
+	}
+
+	return store, nil
+}
+
+func (store *checkpointStore) Write(namespace, name, consumerGroup, partitionID string, checkpoint persist.Checkpoint) error {
+	return store.persister.Write(namespace, name, consumerGroup, partitionID, checkpoint)
+}
+
+func (store *checkpointStore) Read(namespace, name, consumerGroup, partitionID string) (persist.Checkpoint, error) {
+	checkpoint, err := store.persister.Read(namespace, name, consumerGroup, partitionID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store.start, nil
+		}
+		return checkpoint, err
+	}
+
+	// The in-memory persister returns the start of stream rather than an error when nothing has been saved
+	if checkpoint == persist.NewCheckpointFromStartOfStream() {
+		return store.start, nil
+	}
+
+	return checkpoint, nil
+}
@@ -0,0 +1,203 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package eventhubs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+
+	eventhub "github.com/Azure/azure-event-hubs-go/v3"
+	"github.com/Azure/azure-event-hubs-go/v3/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dic *di.Container
+
+func TestMain(m *testing.M) {
+	dic = di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+	os.Exit(m.Run())
+}
+
+func TestInitializeBackgroundNotSupported(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), make(chan interfaces.BackgroundMessage))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "background publishing not supported")
+}
+
+func TestEventHandler(t *testing.T) {
+	transform1WasCalled := make(chan bool, 1)
+	transform2WasCalled := make(chan bool, 1)
+	fail := false
+
+	transform1 := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		transform1WasCalled <- true
+		if fail {
+			return false, assert.AnError
+		}
+		return false, nil
+	}
+
+	transform2 := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		transform2WasCalled <- true
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", nil, dic)
+	err := goRuntime.AddFunctionsPipeline("P1", []string{"commands/0"}, []interfaces.AppFunction{transform1})
+	require.NoError(t, err)
+	err = goRuntime.AddFunctionsPipeline("P2", []string{"commands/1"}, []interfaces.AppFunction{transform2})
+	require.NoError(t, err)
+
+	event := dtos.NewEvent("thermostat", "LivingRoomThermostat", "temperature")
+	_ = event.AddSimpleReading("temperature", common.ValueTypeInt64, int64(38))
+	payload, err := json.Marshal(requests.NewAddEventRequest(event))
+	require.NoError(t, err)
+
+	trigger := NewTrigger(dic, goRuntime)
+	handler := trigger.eventHandler("commands", "0")
+
+	err = handler(context.Background(), eventhub.NewEvent(payload))
+	require.NoError(t, err)
+
+	select {
+	case <-transform1WasCalled:
+	case <-transform2WasCalled:
+		t.Fail() // should not have happened
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "Transform never called")
+	}
+
+	fail = true
+	err = handler(context.Background(), eventhub.NewEvent(payload))
+	require.Error(t, err, "event should not be checkpointed when the pipeline fails")
+	<-transform1WasCalled
+}
+
+func TestEventHandlerCheckpointOnReceipt(t *testing.T) {
+	transformWasCalled := make(chan bool, 1)
+
+	transform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		transformWasCalled <- true
+		return false, assert.AnError
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", nil, dic)
+	err := goRuntime.AddFunctionsPipeline("P1", []string{"commands/0"}, []interfaces.AppFunction{transform})
+	require.NoError(t, err)
+
+	event := dtos.NewEvent("thermostat", "LivingRoomThermostat", "temperature")
+	_ = event.AddSimpleReading("temperature", common.ValueTypeInt64, int64(38))
+	payload, err := json.Marshal(requests.NewAddEventRequest(event))
+	require.NoError(t, err)
+
+	trigger := NewTrigger(dic, goRuntime)
+	trigger.checkpointOnReceipt = true
+	handler := trigger.eventHandler("commands", "0")
+
+	err = handler(context.Background(), eventhub.NewEvent(payload))
+	require.NoError(t, err, "event should be checkpointed when received")
+
+	select {
+	case <-transformWasCalled:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "Transform never called")
+	}
+}
+
+func TestToEnvelope(t *testing.T) {
+	event := eventhub.NewEvent([]byte{0xA1})
+	envelope := toEnvelope(event, "commands/0")
+	assert.Equal(t, "commands/0", envelope.ReceivedTopic)
+	assert.Equal(t, common.ContentTypeCBOR, envelope.ContentType)
+	assert.NotEmpty(t, envelope.CorrelationID)
+
+	event = eventhub.NewEventFromString(`{"command":"on"}`)
+	event.Set("content-type", common.ContentTypeJSON+"; charset=utf-8")
+	event.Set(common.CorrelationHeader, "123")
+	envelope = toEnvelope(event, "commands/0")
+	assert.Equal(t, common.ContentTypeJSON+"; charset=utf-8", envelope.ContentType)
+	assert.Equal(t, "123", envelope.CorrelationID)
+}
+
+func TestCheckpointStore(t *testing.T) {
+	directory := t.TempDir()
+
+	tests := []struct {
+		Name          string
+		Config        sdkCommon.EventHubsConfig
+		ExpectedStart string
+		ExpectedError string
+	}{
+		{"Default file store", sdkCommon.EventHubsConfig{CheckpointDirectory: directory}, persist.StartOfStream, ""},
+		{"File store latest", sdkCommon.EventHubsConfig{CheckpointStore: "File", CheckpointDirectory: directory, StartPosition: StartPositionLatest}, persist.EndOfStream, ""},
+		{"Memory store latest", sdkCommon.EventHubsConfig{CheckpointStore: CheckpointStoreMemory, StartPosition: "Latest"}, persist.EndOfStream, ""},
+		{"Memory store earliest", sdkCommon.EventHubsConfig{CheckpointStore: CheckpointStoreMemory, StartPosition: StartPositionEarliest}, persist.StartOfStream, ""},
+		{"Bad store", sdkCommon.EventHubsConfig{CheckpointStore: "redis"}, "", "invalid Event Hubs CheckpointStore"},
+		{"Bad start position", sdkCommon.EventHubsConfig{StartPosition: "middle"}, "", "invalid Event Hubs StartPosition"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			store, err := newCheckpointStore(test.Config)
+			if test.ExpectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			partition := test.Name
+			checkpoint, err := store.Read("namespace", "hub", defaultConsumerGroup, partition)
+			require.NoError(t, err)
+			assert.Equal(t, test.ExpectedStart, checkpoint.Offset, "start position should be used when no checkpoint saved")
+
+			saved := persist.NewCheckpoint("1024", 12, time.Now().UTC().Truncate(time.Second))
+			require.NoError(t, store.Write("namespace", "hub", defaultConsumerGroup, partition, saved))
+
+			checkpoint, err = store.Read("namespace", "hub", defaultConsumerGroup, partition)
+			require.NoError(t, err)
+			assert.Equal(t, saved, checkpoint)
+		})
+	}
+}
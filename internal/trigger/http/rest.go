@@ -28,6 +28,7 @@ import (
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/webserver"
 
@@ -73,6 +74,13 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 	lc := bootstrapContainer.LoggingClientFrom(trigger.dic.Get)
 	defer func() { _ = r.Body.Close() }()
 
+	// The route stays registered once the trigger is stopped, so requests are refused while paused for maintenance
+	if maintenance := container.ConfigurationFrom(trigger.dic.Get).Writable.Maintenance; maintenance.Enabled && maintenance.PauseTrigger {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = writer.Write([]byte("Trigger is paused for maintenance"))
+		return
+	}
+
 	contentType := r.Header.Get(common.ContentType)
 
 	data, err := io.ReadAll(r.Body)
@@ -88,6 +96,15 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 	correlationID := r.Header.Get(common.CorrelationHeader)
 
 	appContext := appfunction.NewContext(correlationID, trigger.dic, contentType)
+	// The execution is cancelled when the client disconnects
+	appContext.SetContext(r.Context())
+
+	// The tenant id from the header is resolved by the runtime along with the tenant's settings
+	var tenant string
+	if router := container.TenantRouterFrom(trigger.dic.Get); router != nil && router.Header() != "" {
+		tenant = r.Header.Get(router.Header())
+		appContext.AddValue(interfaces.TENANT, tenant)
+	}
 
 	lc.Trace("Received message from http", common.CorrelationHeader, correlationID)
 	lc.Debug("Received message from http", common.ContentType, contentType)
@@ -98,6 +115,18 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 		Payload:       data,
 	}
 
+	// Shadow pipelines process copies of the request without affecting the response
+	for _, pipeline := range trigger.Runtime.GetShadowPipelines() {
+		shadow := pipeline
+		trigger.Runtime.ScheduleExecution(envelope, func() {
+			shadowContext := appfunction.NewContext(correlationID, trigger.dic, contentType)
+			if tenant != "" {
+				shadowContext.AddValue(interfaces.TENANT, tenant)
+			}
+			_ = trigger.Runtime.ProcessMessage(shadowContext, envelope, shadow)
+		})
+	}
+
 	messageError := trigger.Runtime.ProcessMessage(appContext, envelope, trigger.Runtime.GetDefaultPipeline())
 	if messageError != nil {
 		// ProcessMessage logs the error, so no need to log it here.
@@ -106,6 +135,14 @@ func (trigger *Trigger) requestHandler(writer http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	for key, value := range appContext.Tags() {
+		writer.Header().Set(interfaces.TagHeaderPrefix+key, value)
+	}
+
+	for key, value := range appContext.ResponseHeaders() {
+		writer.Header().Set(key, value)
+	}
+
 	if len(appContext.ResponseContentType()) > 0 {
 		writer.Header().Set(common.ContentType, appContext.ResponseContentType())
 	}
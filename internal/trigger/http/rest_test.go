@@ -18,14 +18,25 @@
 package http
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTriggerInitializeWitBackgroundChannel(t *testing.T) {
@@ -43,3 +54,116 @@ func TestTriggerInitializeWitBackgroundChannel(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "background publishing not supported for services using HTTP trigger", err.Error())
 }
+
+func TestRequestHandlerShadowPipeline(t *testing.T) {
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+
+	production := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		appContext.SetResponseData([]byte("production"))
+		return false, nil
+	}
+
+	shadowReceived := make(chan []byte, 1)
+	shadow := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		appContext.SetResponseData([]byte("shadow"))
+		shadowReceived <- data.([]byte)
+		return false, errors.New("shadow failed")
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", &[]byte{}, dic)
+	goRuntime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{production})
+	require.NoError(t, goRuntime.AddShadowFunctionsPipeline("shadow", []string{"#"}, interfaces.ShadowModeLog, []interfaces.AppFunction{shadow}))
+
+	trigger := NewTrigger(dic, goRuntime, nil)
+
+	request := httptest.NewRequest(http.MethodPost, internal.ApiTriggerRoute, strings.NewReader("payload"))
+	recorder := httptest.NewRecorder()
+	trigger.requestHandler(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code, "shadow pipeline errors should not affect the response")
+	assert.Equal(t, "production", recorder.Body.String())
+
+	select {
+	case data := <-shadowReceived:
+		assert.Equal(t, []byte("payload"), data)
+	case <-time.After(time.Second):
+		require.Fail(t, "shadow pipeline did not receive a copy of the request")
+	}
+}
+
+func TestRequestHandlerResponseHeaders(t *testing.T) {
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+
+	respond := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		appContext.SetResponseData([]byte("<ok/>"))
+		appContext.SetResponseContentType(common.ContentTypeXML)
+		appContext.SetResponseHeader("Cache-Control", "no-store")
+		appContext.SetTag("GatewayId", "HoustonStore000123")
+		appContext.SetResponseHeader(common.ContentType, common.ContentTypeJSON)
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", &[]byte{}, dic)
+	goRuntime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{respond})
+
+	trigger := NewTrigger(dic, goRuntime, nil)
+
+	request := httptest.NewRequest(http.MethodPost, internal.ApiTriggerRoute, strings.NewReader("payload"))
+	recorder := httptest.NewRecorder()
+	trigger.requestHandler(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, "HoustonStore000123", recorder.Header().Get(interfaces.TagHeaderPrefix+"GatewayId"))
+	assert.Equal(t, common.ContentTypeXML, recorder.Header().Get(common.ContentType), "response content type should take precedence")
+}
+
+func TestRequestHandlerMaintenance(t *testing.T) {
+	config := &sdkCommon.ConfigurationStruct{}
+	config.Writable.Maintenance = sdkCommon.MaintenanceInfo{Enabled: true, PauseTrigger: true}
+
+	dic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+
+	respond := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		appContext.SetResponseData([]byte("ok"))
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", &[]byte{}, dic)
+	goRuntime.SetDefaultFunctionsPipeline([]interfaces.AppFunction{respond})
+
+	trigger := NewTrigger(dic, goRuntime, nil)
+
+	request := httptest.NewRequest(http.MethodPost, internal.ApiTriggerRoute, strings.NewReader("payload"))
+	recorder := httptest.NewRecorder()
+	trigger.requestHandler(recorder, request)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, "requests should be refused while paused")
+
+	config.Writable.Maintenance.PauseTrigger = false
+	request = httptest.NewRequest(http.MethodPost, internal.ApiTriggerRoute, strings.NewReader("payload"))
+	recorder = httptest.NewRecorder()
+	trigger.requestHandler(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code, "requests should be processed when the trigger isn't paused")
+	assert.Equal(t, "ok", recorder.Body.String())
+}
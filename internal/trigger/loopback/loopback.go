@@ -0,0 +1,188 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package loopback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+)
+
+const (
+	DefaultQueueSize = 100
+	DefaultMaxHops   = 8
+)
+
+// Trigger implements interfaces.Loopback to execute the pipelines matching the topic the data exported by a pipeline
+// is sent to, in the same process. It runs alongside the configured trigger, as the data it processes is always the
+// output of a pipeline executed for a message received by that trigger.
+type Trigger struct {
+	dic     *di.Container
+	lc      logger.LoggingClient
+	runtime *runtime.GolangRuntime
+	maxHops int
+	queue   chan message
+	ctx     context.Context
+}
+
+// message is data sent back through the pipelines, which has been sent back the number of hops
+type message struct {
+	envelope types.MessageEnvelope
+	hops     int
+}
+
+// NewTrigger creates, initializes and returns a new instance of Trigger for the configuration
+func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime, config sdkCommon.LoopbackConfig) (*Trigger, error) {
+	if config.QueueSize < 0 {
+		return nil, fmt.Errorf("invalid Loopback QueueSize '%d', must not be negative", config.QueueSize)
+	}
+
+	if config.MaxHops < 0 {
+		return nil, fmt.Errorf("invalid Loopback MaxHops '%d', must not be negative", config.MaxHops)
+	}
+
+	queueSize := config.QueueSize
+	if queueSize == 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	maxHops := config.MaxHops
+	if maxHops == 0 {
+		maxHops = DefaultMaxHops
+	}
+
+	return &Trigger{
+		dic:     dic,
+		lc:      bootstrapContainer.LoggingClientFrom(dic.Get),
+		runtime: runtime,
+		maxHops: maxHops,
+		queue:   make(chan message, queueSize),
+	}, nil
+}
+
+// Initialize starts executing the pipelines for the data sent back in the background until the context is done
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context) {
+	trigger.lc.Info("Initializing Loopback Trigger")
+	trigger.ctx = appCtx
+
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+
+		for {
+			select {
+			case <-appCtx.Done():
+				if pending := len(trigger.queue); pending > 0 {
+					trigger.lc.Warnf("Loopback Trigger stopped with %d message(s) pending", pending)
+				}
+				return
+
+			case msg := <-trigger.queue:
+				trigger.process(msg)
+			}
+		}
+	}()
+}
+
+// Send queues the data to be processed by the pipelines matching the topic, with the correlation id and response
+// content type of the context's message, JSON when not set. The queue isn't waited on when full, so a pipeline
+// sending to its own topic can't block the pipelines it feeds.
+func (trigger *Trigger) Send(ctx interfaces.AppFunctionContext, topic string, data []byte) error {
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return errors.New("loopback topic must be set")
+	}
+
+	if trigger.ctx == nil || trigger.ctx.Err() != nil {
+		return errors.New("loopback not running, service is shutting down")
+	}
+
+	hops := 1
+	if value, found := ctx.GetValue(interfaces.LOOPBACKHOPS); found {
+		previous, _ := strconv.Atoi(value)
+		hops = previous + 1
+	}
+
+	if hops > trigger.maxHops {
+		return fmt.Errorf("data already sent back through the pipelines %d times, the maximum, not sent to topic '%s'",
+			trigger.maxHops, topic)
+	}
+
+	contentType := ctx.ResponseContentType()
+	if contentType == "" {
+		contentType = common.ContentTypeJSON
+	}
+
+	msg := message{
+		envelope: types.MessageEnvelope{
+			CorrelationID: ctx.CorrelationID(),
+			ContentType:   contentType,
+			Payload:       data,
+			ReceivedTopic: topic,
+		},
+		hops: hops,
+	}
+
+	if len(trigger.runtime.RoutePipelines(msg.envelope)) == 0 {
+		return fmt.Errorf("no pipelines match loopback topic '%s'", topic)
+	}
+
+	select {
+	case trigger.queue <- msg:
+		return nil
+	default:
+		return fmt.Errorf("loopback queue full, not sent to topic '%s'", topic)
+	}
+}
+
+// process executes the pipelines routed the data sent to the topic, without waiting for them to complete
+func (trigger *Trigger) process(msg message) {
+	envelope := msg.envelope
+
+	pipelines := trigger.runtime.RoutePipelines(envelope)
+	trigger.lc.Debugf("Loopback Trigger found %d pipeline(s) that match the topic '%s'", len(pipelines), envelope.ReceivedTopic)
+	trigger.lc.Tracef("%s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	for _, pipeline := range pipelines {
+		p := pipeline
+		scheduled := trigger.runtime.ScheduleExecution(envelope, func() {
+			appContext := appfunction.NewContext(envelope.CorrelationID, trigger.dic, envelope.ContentType)
+			appContext.AddValue(interfaces.LOOPBACKHOPS, strconv.Itoa(msg.hops))
+			// ProcessMessage logs any error, so no need to log it here.
+			_ = trigger.runtime.ProcessMessage(appContext, envelope, p)
+		})
+
+		if !scheduled {
+			trigger.lc.Warnf("Loopback Trigger: pipeline '%s' execution not scheduled for topic '%s', service is shutting down",
+				p.Id, envelope.ReceivedTopic)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package loopback
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dic *di.Container
+
+func TestMain(m *testing.M) {
+	dic = di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+	os.Exit(m.Run())
+}
+
+func TestNewTrigger(t *testing.T) {
+	trigger, err := NewTrigger(dic, nil, sdkCommon.LoopbackConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxHops, trigger.maxHops)
+	assert.Equal(t, DefaultQueueSize, cap(trigger.queue))
+
+	_, err = NewTrigger(dic, nil, sdkCommon.LoopbackConfig{QueueSize: -1})
+	require.Error(t, err)
+
+	_, err = NewTrigger(dic, nil, sdkCommon.LoopbackConfig{MaxHops: -1})
+	require.Error(t, err)
+}
+
+func TestSend(t *testing.T) {
+	type received struct {
+		data          string
+		hops          string
+		correlationId string
+	}
+	results := make(chan received, 1)
+
+	stage2 := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		hops, _ := ctx.GetValue(interfaces.LOOPBACKHOPS)
+		results <- received{data: string(data.([]byte)), hops: hops, correlationId: ctx.CorrelationID()}
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", &[]byte{}, dic)
+	require.NoError(t, goRuntime.AddFunctionsPipeline("stage2", []string{"loopback/stage2"}, []interfaces.AppFunction{stage2}))
+
+	trigger, err := NewTrigger(dic, goRuntime, sdkCommon.LoopbackConfig{})
+	require.NoError(t, err)
+
+	ctx := appfunction.NewContext("123-456", dic, common.ContentTypeJSON)
+	require.Error(t, trigger.Send(ctx, "loopback/stage2", []byte("data")), "send should fail until initialized")
+
+	appCtx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	trigger.Initialize(wg, appCtx)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	require.NoError(t, trigger.Send(ctx, "loopback/stage2", []byte("data")))
+
+	select {
+	case actual := <-results:
+		assert.Equal(t, "data", actual.data)
+		assert.Equal(t, "1", actual.hops)
+		assert.Equal(t, "123-456", actual.correlationId, "correlation id should be kept across the stages")
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "data sent back was not processed")
+	}
+
+	err = trigger.Send(ctx, "loopback/other", []byte("data"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no pipelines match")
+
+	err = trigger.Send(ctx, " ", []byte("data"))
+	require.Error(t, err)
+}
+
+func TestSendMaxHops(t *testing.T) {
+	goRuntime := runtime.NewGolangRuntime("", nil, dic)
+	require.NoError(t, goRuntime.AddFunctionsPipeline("P1", []string{"#"}, nil))
+
+	trigger, err := NewTrigger(dic, goRuntime, sdkCommon.LoopbackConfig{MaxHops: 2, QueueSize: 1})
+	require.NoError(t, err)
+	trigger.ctx = context.Background()
+
+	ctx := appfunction.NewContext("123", dic, "")
+	ctx.AddValue(interfaces.LOOPBACKHOPS, "2")
+	err = trigger.Send(ctx, "loopback", []byte("data"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 times, the maximum")
+
+	ctx.AddValue(interfaces.LOOPBACKHOPS, "1")
+	require.NoError(t, trigger.Send(ctx, "loopback", []byte("data")))
+	msg := <-trigger.queue
+	assert.Equal(t, 2, msg.hops)
+	assert.Equal(t, common.ContentTypeJSON, msg.envelope.ContentType)
+
+	require.NoError(t, trigger.Send(ctx, "loopback", []byte("data")))
+	err = trigger.Send(ctx, "loopback", []byte("data"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "queue full", "send should not wait for a full queue")
+}
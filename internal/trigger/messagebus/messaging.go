@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
 	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap"
@@ -64,13 +66,17 @@ func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context
 
 	lc.Infof("Initializing Message Bus Trigger for '%s'", config.Trigger.EdgexMessageBus.Type)
 
-	clientConfig := trigger.createMessagingClientConfig(config.Trigger.EdgexMessageBus)
-
-	if err := trigger.setOptionalAuthData(&clientConfig, lc); err != nil {
+	// The EdgeX MessageBus client acknowledges messages when they are received
+	ackAfterProcessing, err := config.Trigger.AckAfterProcessing(sdkCommon.AckPolicyReceipt)
+	if err != nil {
 		return nil, err
 	}
 
-	trigger.client, err = messaging.NewMessageClient(clientConfig)
+	if ackAfterProcessing {
+		return nil, fmt.Errorf("AckPolicy '%s' not supported for services using Message Bus trigger", sdkCommon.AckPolicyProcessed)
+	}
+
+	trigger.client, err = trigger.newMessageClient(config.Trigger.EdgexMessageBus, lc)
 	if err != nil {
 		return nil, err
 	}
@@ -110,23 +116,27 @@ func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context
 			config.Trigger.EdgexMessageBus.PublishHost.Port)
 	}
 
+	// The members of the device groups with a SubscribeTopic are subscribed to in addition to the SubscribeTopics,
+	// and the members added later once they are found
+	var stopWatching func()
+	if registry := container.DeviceGroupsFrom(trigger.dic.Get); registry != nil {
+		var groupTopics []string
+		groupTopics, stopWatching = registry.WatchTopics(func(topics []string) {
+			trigger.subscribeDeviceGroupTopics(appWg, appCtx, lc, topics, messageErrors)
+		})
+
+		for _, topic := range groupTopics {
+			trigger.topics = append(trigger.topics, types.TopicChannel{Topic: topic, Messages: make(chan types.MessageEnvelope)})
+		}
+
+		if len(groupTopics) > 0 {
+			lc.Infof("Subscribing to device group topic(s): '%s'", strings.Join(groupTopics, ", "))
+		}
+	}
+
 	// Need to have a go func for each subscription, so we know with topic the data was received for.
 	for _, topic := range trigger.topics {
-		appWg.Add(1)
-		go func(triggerTopic types.TopicChannel) {
-			defer appWg.Done()
-			lc.Infof("Waiting for messages from the MessageBus on the '%s' topic", triggerTopic.Topic)
-
-			for {
-				select {
-				case <-appCtx.Done():
-					lc.Infof("Exiting waiting for MessageBus '%s' topic messages", triggerTopic.Topic)
-					return
-				case message := <-triggerTopic.Messages:
-					trigger.messageHandler(lc, triggerTopic, message)
-				}
-			}
-		}(topic)
+		trigger.receive(appWg, appCtx, lc, topic)
 	}
 
 	// Need an addition go func to handle errors and background publishing to the message bus.
@@ -161,10 +171,17 @@ func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context
 	}()
 
 	if err := trigger.client.Subscribe(trigger.topics, messageErrors); err != nil {
+		if stopWatching != nil {
+			stopWatching()
+		}
 		return nil, fmt.Errorf("failed to subscribe to topic(s) '%s': %s", subscribeTopics, err.Error())
 	}
 
 	deferred := func() {
+		if stopWatching != nil {
+			stopWatching()
+		}
+
 		lc.Info("Disconnecting from the message bus")
 		err := trigger.client.Disconnect()
 		if err != nil {
@@ -174,6 +191,79 @@ func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context
 	return deferred, nil
 }
 
+// receive waits for the messages from the subscription to the topic and processes them until the context is cancelled
+func (trigger *Trigger) receive(appWg *sync.WaitGroup, appCtx context.Context, lc logger.LoggingClient, triggerTopic types.TopicChannel) {
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+		lc.Infof("Waiting for messages from the MessageBus on the '%s' topic", triggerTopic.Topic)
+
+		for {
+			select {
+			case <-appCtx.Done():
+				lc.Infof("Exiting waiting for MessageBus '%s' topic messages", triggerTopic.Topic)
+				return
+			case message := <-triggerTopic.Messages:
+				trigger.messageHandler(lc, triggerTopic, message)
+			}
+		}
+	}()
+}
+
+// subscribeDeviceGroupTopics subscribes to the topics of the members added to the device groups
+func (trigger *Trigger) subscribeDeviceGroupTopics(
+	appWg *sync.WaitGroup,
+	appCtx context.Context,
+	lc logger.LoggingClient,
+	topics []string,
+	messageErrors chan error) {
+	if appCtx.Err() != nil {
+		return
+	}
+
+	var topicChannels []types.TopicChannel
+	for _, topic := range topics {
+		topicChannel := types.TopicChannel{Topic: topic, Messages: make(chan types.MessageEnvelope)}
+		topicChannels = append(topicChannels, topicChannel)
+		trigger.receive(appWg, appCtx, lc, topicChannel)
+	}
+
+	if err := trigger.client.Subscribe(topicChannels, messageErrors); err != nil {
+		lc.Errorf("Failed to subscribe to device group topic(s) '%s': %s", strings.Join(topics, ", "), err.Error())
+		return
+	}
+
+	lc.Infof("Subscribed to device group topic(s): '%s'", strings.Join(topics, ", "))
+}
+
+// CheckConnection connects to the message bus, using the configured credentials, and disconnects without
+// subscribing, so the connection can be checked without messages being received
+func (trigger *Trigger) CheckConnection() error {
+	lc := bootstrapContainer.LoggingClientFrom(trigger.dic.Get)
+	config := container.ConfigurationFrom(trigger.dic.Get)
+
+	client, err := trigger.newMessageClient(config.Trigger.EdgexMessageBus, lc)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Connect(); err != nil {
+		return err
+	}
+
+	return client.Disconnect()
+}
+
+func (trigger *Trigger) newMessageClient(localConfig sdkCommon.MessageBusConfig, lc logger.LoggingClient) (messaging.MessageClient, error) {
+	clientConfig := trigger.createMessagingClientConfig(localConfig)
+
+	if err := trigger.setOptionalAuthData(&clientConfig, lc); err != nil {
+		return nil, err
+	}
+
+	return messaging.NewMessageClient(clientConfig)
+}
+
 func (trigger *Trigger) messageHandler(logger logger.LoggingClient, _ types.TopicChannel, message types.MessageEnvelope) {
 	logger.Debugf("MessageBus Trigger: Received message with %d bytes on topic '%s'. Content-Type=%s",
 		len(message.Payload),
@@ -181,66 +271,100 @@ func (trigger *Trigger) messageHandler(logger logger.LoggingClient, _ types.Topi
 		message.ContentType)
 	logger.Tracef("MessageBus Trigger: Received message with %s=%s", common.CorrelationHeader, message.CorrelationID)
 
-	pipelines := trigger.runtime.GetMatchingPipelines(message.ReceivedTopic)
+	done, duplicate := trigger.runtime.BeginMessage(message)
+	if duplicate {
+		return
+	}
+
+	pipelines := trigger.runtime.RoutePipelines(message)
 	logger.Debugf("MessageBus Trigger found %d pipeline(s) that match the incoming topic '%s'", len(pipelines), message.ReceivedTopic)
+
+	pipelinesWaitGroup := &sync.WaitGroup{}
+	var failed int32
+
 	for _, pipeline := range pipelines {
-		go trigger.processMessageWithPipeline(logger, message, pipeline)
+		p := pipeline
+		pipelinesWaitGroup.Add(1)
+		scheduled := trigger.runtime.ScheduleExecution(message, func() {
+			defer pipelinesWaitGroup.Done()
+			if !trigger.processMessageWithPipeline(logger, message, p) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			atomic.StoreInt32(&failed, 1)
+		}
 	}
+
+	go func() {
+		pipelinesWaitGroup.Wait()
+		done(atomic.LoadInt32(&failed) == 0)
+	}()
 }
 
-func (trigger *Trigger) processMessageWithPipeline(logger logger.LoggingClient, message types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) {
+// processMessageWithPipeline executes the pipeline and publishes any response data. Returns false if the pipeline
+// failed.
+func (trigger *Trigger) processMessageWithPipeline(logger logger.LoggingClient, message types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) bool {
 	appContext := appfunction.NewContext(message.CorrelationID, trigger.dic, message.ContentType)
 
 	messageError := trigger.runtime.ProcessMessage(appContext, message, pipeline)
 	if messageError != nil {
 		// ProcessMessage logs the error, so no need to log it here.
-		return
+		return false
 	}
 
 	if appContext.ResponseData() != nil {
-		var contentType string
-
-		if appContext.ResponseContentType() != "" {
-			contentType = appContext.ResponseContentType()
-		} else {
-			contentType = common.ContentTypeJSON
-			if appContext.ResponseData()[0] != byte('{') && appContext.ResponseData()[0] != byte('[') {
-				// If not JSON then assume it is CBOR
-				contentType = common.ContentTypeCBOR
-			}
-		}
-		outputEnvelope := types.MessageEnvelope{
-			CorrelationID: appContext.CorrelationID(),
-			Payload:       appContext.ResponseData(),
-			ContentType:   contentType,
-		}
-
 		config := container.ConfigurationFrom(trigger.dic.Get)
-		publishTopic, err := appContext.ApplyValues(config.Trigger.EdgexMessageBus.PublishHost.PublishTopic)
+		trigger.publishResponse(logger, appContext, pipeline, config.Trigger.EdgexMessageBus.PublishHost.PublishTopic,
+			appContext.ResponseData(), appContext.ResponseContentType())
+	}
 
-		if err != nil {
-			logger.Errorf("MessageBus Trigger: Unable to format output topic '%s' for pipeline '%s': %s",
-				config.Trigger.EdgexMessageBus.PublishHost.PublishTopic,
-				pipeline.Id,
-				err.Error())
-			return
-		}
+	for _, response := range appContext.TopicResponses() {
+		trigger.publishResponse(logger, appContext, pipeline, response.Topic, response.Data, response.ContentType)
+	}
 
-		err = trigger.client.Publish(outputEnvelope, publishTopic)
-		if err != nil {
-			logger.Errorf("MessageBus trigger: Could not publish to topic '%s' for pipeline '%s': %s",
-				publishTopic,
-				pipeline.Id,
-				err.Error())
-			return
-		}
+	return true
+}
+
+// publishResponse publishes the response data of the pipeline to the topic, formatted as a topic template with the
+// Event's names and the context's values and tags. The content type is detected from the data when empty. Failures are logged since the pipeline has already completed.
+func (trigger *Trigger) publishResponse(logger logger.LoggingClient, appContext interfaces.AppFunctionContext,
+	pipeline *interfaces.FunctionPipeline, topic string, data []byte, contentType string) {
+	if contentType == "" {
+		contentType = codec.DetectContentType(data)
+	}
+
+	outputEnvelope := types.MessageEnvelope{
+		CorrelationID: appContext.CorrelationID(),
+		Payload:       data,
+		ContentType:   contentType,
+	}
 
-		logger.Debugf("MessageBus Trigger: Published response message for pipeline '%s' on topic '%s' with %d bytes",
+	publishTopic, err := appContext.FormatTopic(topic)
+	if err != nil {
+		logger.Errorf("MessageBus Trigger: Unable to format output topic '%s' for pipeline '%s': %s",
+			topic,
 			pipeline.Id,
+			err.Error())
+		return
+	}
+
+	err = trigger.client.Publish(outputEnvelope, publishTopic)
+	if err != nil {
+		logger.Errorf("MessageBus trigger: Could not publish to topic '%s' for pipeline '%s': %s",
 			publishTopic,
-			len(appContext.ResponseData()))
-		logger.Tracef("MessageBus Trigger published message: %s=%s", common.CorrelationHeader, message.CorrelationID)
+			pipeline.Id,
+			err.Error())
+		return
 	}
+
+	logger.Debugf("MessageBus Trigger: Published response message for pipeline '%s' on topic '%s' with %d bytes",
+		pipeline.Id,
+		publishTopic,
+		len(data))
+	logger.Tracef("MessageBus Trigger published message: %s=%s", common.CorrelationHeader, appContext.CorrelationID())
 }
 
 func (_ *Trigger) createMessagingClientConfig(localConfig sdkCommon.MessageBusConfig) types.MessageBusConfig {
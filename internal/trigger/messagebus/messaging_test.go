@@ -205,6 +205,47 @@ func TestInitializeBadConfiguration(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestInitializeAckPolicyProcessedNotSupported(t *testing.T) {
+	config := sdkCommon.ConfigurationStruct{
+		Trigger: sdkCommon.TriggerInfo{
+			Type:      TriggerTypeMessageBus,
+			AckPolicy: sdkCommon.AckPolicyProcessed,
+		},
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &config
+		},
+	})
+
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not supported")
+}
+
+func TestCheckConnectionBadConfiguration(t *testing.T) {
+	config := sdkCommon.ConfigurationStruct{
+		Trigger: sdkCommon.TriggerInfo{
+			Type: TriggerTypeMessageBus,
+			EdgexMessageBus: sdkCommon.MessageBusConfig{
+				Type: "aaaa",
+			},
+		},
+	}
+
+	dic.Update(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &config
+		},
+	})
+
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+	assert.Error(t, trigger.CheckConnection())
+	assert.Nil(t, trigger.client, "checking the connection should not set the trigger's client")
+}
+
 func TestPipelinePerTopic(t *testing.T) {
 	testClientConfig := types.MessageBusConfig{
 		PublishHost: types.HostInfo{
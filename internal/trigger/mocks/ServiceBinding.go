@@ -24,6 +24,29 @@ type ServiceBinding struct {
 	mock.Mock
 }
 
+// BeginMessage provides a mock function with given fields: envelope
+func (_m *ServiceBinding) BeginMessage(envelope types.MessageEnvelope) (func(bool), bool) {
+	ret := _m.Called(envelope)
+
+	var r0 func(bool)
+	if rf, ok := ret.Get(0).(func(types.MessageEnvelope) func(bool)); ok {
+		r0 = rf(envelope)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(func(bool))
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(types.MessageEnvelope) bool); ok {
+		r1 = rf(envelope)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
 // BuildContext provides a mock function with given fields: env
 func (_m *ServiceBinding) BuildContext(env types.MessageEnvelope) interfaces.AppFunctionContext {
 	ret := _m.Called(env)
@@ -118,6 +141,36 @@ func (_m *ServiceBinding) ProcessMessage(appContext *appfunction.Context, envelo
 	return r0
 }
 
+// RoutePipelines provides a mock function with given fields: envelope
+func (_m *ServiceBinding) RoutePipelines(envelope types.MessageEnvelope) []*interfaces.FunctionPipeline {
+	ret := _m.Called(envelope)
+
+	var r0 []*interfaces.FunctionPipeline
+	if rf, ok := ret.Get(0).(func(types.MessageEnvelope) []*interfaces.FunctionPipeline); ok {
+		r0 = rf(envelope)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*interfaces.FunctionPipeline)
+		}
+	}
+
+	return r0
+}
+
+// ScheduleExecution provides a mock function with given fields: envelope, job
+func (_m *ServiceBinding) ScheduleExecution(envelope types.MessageEnvelope, job func()) bool {
+	ret := _m.Called(envelope, job)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(types.MessageEnvelope, func()) bool); ok {
+		r0 = rf(envelope, job)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // SecretProvider provides a mock function with given fields:
 func (_m *ServiceBinding) SecretProvider() messaging.SecretDataProvider {
 	ret := _m.Called()
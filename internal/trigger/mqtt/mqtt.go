@@ -23,13 +23,16 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/secure"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
 
@@ -46,13 +49,55 @@ import (
 
 // Trigger implements Trigger to support Triggers
 type Trigger struct {
-	dic          *di.Container
-	lc           logger.LoggingClient
-	mqttClient   pahoMqtt.Client
-	runtime      *runtime.GolangRuntime
-	qos          byte
-	retain       bool
-	publishTopic string
+	dic                *di.Container
+	lc                 logger.LoggingClient
+	mqttClient         pahoMqtt.Client
+	runtime            *runtime.GolangRuntime
+	qos                byte
+	retain             bool
+	publishTopic       string
+	ackAfterProcessing bool
+	acks               ackQueue
+	ctx                context.Context
+}
+
+// ackQueue acknowledges the received messages in the order they were received, as MQTT requires, once each has
+// completed processing
+type ackQueue struct {
+	mutex   sync.Mutex
+	pending []*pendingAck
+}
+
+type pendingAck struct {
+	message   pahoMqtt.Message
+	completed bool
+}
+
+// add queues the received message and returns the function to call once it has completed processing
+func (queue *ackQueue) add(message pahoMqtt.Message) func() {
+	entry := &pendingAck{message: message}
+
+	queue.mutex.Lock()
+	queue.pending = append(queue.pending, entry)
+	queue.mutex.Unlock()
+
+	return func() {
+		queue.complete(entry)
+	}
+}
+
+// complete marks the message as completed and acknowledges it along with the following completed messages
+// once all the messages received before it have been acknowledged
+func (queue *ackQueue) complete(entry *pendingAck) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	entry.completed = true
+	for len(queue.pending) > 0 && queue.pending[0].completed {
+		queue.pending[0].message.Ack()
+		queue.pending[0] = nil
+		queue.pending = queue.pending[1:]
+	}
 }
 
 func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime) *Trigger {
@@ -64,7 +109,7 @@ func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime) *Trigger {
 }
 
 // Initialize initializes the Trigger for an external MQTT broker
-func (trigger *Trigger) Initialize(_ *sync.WaitGroup, _ context.Context, background <-chan interfaces.BackgroundMessage) (bootstrap.Deferred, error) {
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context, background <-chan interfaces.BackgroundMessage) (bootstrap.Deferred, error) {
 	// Convenience short cuts
 	lc := trigger.lc
 	config := container.ConfigurationFrom(trigger.dic.Get)
@@ -75,6 +120,8 @@ func (trigger *Trigger) Initialize(_ *sync.WaitGroup, _ context.Context, backgro
 	trigger.retain = brokerConfig.Retain
 	trigger.publishTopic = config.Trigger.ExternalMqtt.PublishTopic
 
+	trigger.ctx = appCtx
+
 	lc.Info("Initializing MQTT Trigger")
 
 	if background != nil {
@@ -85,6 +132,16 @@ func (trigger *Trigger) Initialize(_ *sync.WaitGroup, _ context.Context, backgro
 		return nil, fmt.Errorf("missing SubscribeTopics for MQTT Trigger. Must be present in [Trigger.ExternalMqtt] section")
 	}
 
+	ackAfterProcessing, err := config.Trigger.AckAfterProcessing(sdkCommon.AckPolicyReceipt)
+	if err != nil {
+		return nil, err
+	}
+	trigger.ackAfterProcessing = ackAfterProcessing
+
+	if trigger.ackAfterProcessing && brokerConfig.QoS == 0 {
+		return nil, fmt.Errorf("AckPolicy '%s' requires QoS 1 or 2 for MQTT Trigger", sdkCommon.AckPolicyProcessed)
+	}
+
 	brokerUrl, err := url.Parse(brokerConfig.Url)
 	if err != nil {
 		return nil, fmt.Errorf("invalid MQTT Broker Url '%s': %s", config.Trigger.ExternalMqtt.Url, err.Error())
@@ -104,6 +161,12 @@ func (trigger *Trigger) Initialize(_ *sync.WaitGroup, _ context.Context, backgro
 	opts.KeepAlive = brokerConfig.KeepAlive
 	opts.Servers = []*url.URL{brokerUrl}
 
+	if trigger.ackAfterProcessing {
+		// The session is kept so the broker redelivers the messages not acknowledged when the trigger reconnects
+		opts.SetAutoAckDisabled(true)
+		opts.CleanSession = false
+	}
+
 	// Since this factory is shared between the MQTT pipeline function and this trigger we must provide
 	// a dummy AppFunctionContext which will provide access to GetSecret
 	mqttFactory := secure.NewMqttFactory(
@@ -126,13 +189,22 @@ func (trigger *Trigger) Initialize(_ *sync.WaitGroup, _ context.Context, backgro
 
 	lc.Info("Connected to mqtt server for MQTT trigger")
 
+	trigger.mqttClient = mqttClient
+
+	// Stop receiving once the context is done so the messages published while the service drains are left with
+	// the broker. The client stays connected to acknowledge the messages being processed until disconnected below.
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+		<-appCtx.Done()
+		trigger.unsubscribe(mqttClient)
+	}()
+
 	deferred := func() {
 		lc.Info("Disconnecting from broker for MQTT trigger")
 		trigger.mqttClient.Disconnect(0)
 	}
 
-	trigger.mqttClient = mqttClient
-
 	return deferred, nil
 }
 
@@ -143,6 +215,11 @@ func (trigger *Trigger) onConnectHandler(mqttClient pahoMqtt.Client) {
 	topics := util.DeleteEmptyAndTrim(strings.FieldsFunc(config.Trigger.ExternalMqtt.SubscribeTopics, util.SplitComma))
 	qos := config.Trigger.ExternalMqtt.QoS
 
+	if trigger.ctx != nil && trigger.ctx.Err() != nil {
+		lc.Info("MQTT trigger stopped, not subscribing to topic(s)")
+		return
+	}
+
 	for _, topic := range topics {
 		if token := mqttClient.Subscribe(topic, qos, trigger.messageHandler); token.Wait() && token.Error() != nil {
 			mqttClient.Disconnect(0)
@@ -155,16 +232,32 @@ func (trigger *Trigger) onConnectHandler(mqttClient pahoMqtt.Client) {
 	lc.Infof("Subscribed to topic(s) '%s' for MQTT trigger", config.Trigger.ExternalMqtt.SubscribeTopics)
 }
 
+// unsubscribe unsubscribes from the trigger's topics so no more messages are received
+func (trigger *Trigger) unsubscribe(mqttClient pahoMqtt.Client) {
+	config := container.ConfigurationFrom(trigger.dic.Get)
+	topics := util.DeleteEmptyAndTrim(strings.FieldsFunc(config.Trigger.ExternalMqtt.SubscribeTopics, util.SplitComma))
+
+	token := mqttClient.Unsubscribe(topics...)
+	if !token.WaitTimeout(time.Second) {
+		trigger.lc.Warnf("timed out unsubscribing from topic(s) '%s' for MQTT trigger", config.Trigger.ExternalMqtt.SubscribeTopics)
+		return
+	}
+
+	if token.Error() != nil {
+		trigger.lc.Errorf("could not unsubscribe from topic(s) '%s' for MQTT trigger: %s",
+			config.Trigger.ExternalMqtt.SubscribeTopics, token.Error().Error())
+		return
+	}
+
+	trigger.lc.Info("Unsubscribed from topic(s) for MQTT trigger, no longer receiving messages")
+}
+
 func (trigger *Trigger) messageHandler(_ pahoMqtt.Client, mqttMessage pahoMqtt.Message) {
 	// Convenience short cuts
 	lc := trigger.lc
 
 	data := mqttMessage.Payload()
-	contentType := common.ContentTypeJSON
-	if data[0] != byte('{') && data[0] != byte('[') {
-		// If not JSON then assume it is CBOR
-		contentType = common.ContentTypeCBOR
-	}
+	contentType := codec.DetectContentType(data)
 
 	correlationID := uuid.New().String()
 
@@ -181,44 +274,110 @@ func (trigger *Trigger) messageHandler(_ pahoMqtt.Client, mqttMessage pahoMqtt.M
 		message.ContentType)
 	lc.Tracef("%s=%s", common.CorrelationHeader, correlationID)
 
-	pipelines := trigger.runtime.GetMatchingPipelines(message.ReceivedTopic)
+	ack := func() {}
+	if trigger.ackAfterProcessing {
+		ack = trigger.acks.add(mqttMessage)
+	}
+
+	done, duplicate := trigger.runtime.BeginMessage(message)
+	if duplicate {
+		ack()
+		return
+	}
+
+	pipelines := trigger.runtime.RoutePipelines(message)
 	lc.Debugf("MQTT Trigger found %d pipeline(s) that match the incoming topic '%s'", len(pipelines), message.ReceivedTopic)
+
+	pipelinesWaitGroup := &sync.WaitGroup{}
+	var failed int32
+
 	for _, pipeline := range pipelines {
-		go trigger.processMessageWithPipeline(message, pipeline)
+		p := pipeline
+		pipelinesWaitGroup.Add(1)
+		scheduled := trigger.runtime.ScheduleExecution(message, func() {
+			defer pipelinesWaitGroup.Done()
+			if !trigger.processMessageWithPipeline(message, p) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			atomic.StoreInt32(&failed, 1)
+		}
 	}
+
+	// Acknowledge once all pipelines have completed. MQTT has no negative acknowledgement, so a message that failed
+	// is acknowledged as well rather than holding back the following acknowledgements and filling the broker's
+	// in-flight window. The broker only redelivers messages not yet acknowledged when the trigger reconnects.
+	go func() {
+		pipelinesWaitGroup.Wait()
+
+		succeeded := atomic.LoadInt32(&failed) == 0
+		done(succeeded)
+
+		if trigger.ackAfterProcessing && !succeeded {
+			lc.Errorf("MQTT Trigger: Message failed processing and is acknowledged without being redelivered (%s=%s)",
+				common.CorrelationHeader, correlationID)
+		}
+
+		ack()
+	}()
 }
 
-func (trigger *Trigger) processMessageWithPipeline(envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) {
+// processMessageWithPipeline executes the pipeline and publishes any response data. Returns false if either failed.
+func (trigger *Trigger) processMessageWithPipeline(envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) bool {
 	appContext := appfunction.NewContext(envelope.CorrelationID, trigger.dic, envelope.ContentType)
 
 	messageError := trigger.runtime.ProcessMessage(appContext, envelope, pipeline)
 	if messageError != nil {
 		// ProcessMessage logs the error, so no need to log it here.
 		// ToDo: Do we want to publish the error back to the Broker?
-		return
+		return false
 	}
 
 	if len(appContext.ResponseData()) > 0 && len(trigger.publishTopic) > 0 {
-		formattedTopic, err := appContext.ApplyValues(trigger.publishTopic)
-
-		if err != nil {
-			trigger.lc.Errorf("MQTT trigger: Unable to format topic '%s' for pipeline '%s': %s",
-				trigger.publishTopic,
-				pipeline.Id,
-				err.Error())
+		if !trigger.publishResponse(appContext, envelope, pipeline, trigger.publishTopic, appContext.ResponseData()) {
+			return false
 		}
+	}
 
-		if token := trigger.mqttClient.Publish(formattedTopic, trigger.qos, trigger.retain, appContext.ResponseData()); token.Wait() && token.Error() != nil {
-			trigger.lc.Errorf("MQTT trigger: Could not publish to topic '%s' for pipeline '%s': %s",
-				formattedTopic,
-				pipeline.Id,
-				token.Error().Error())
-		} else {
-			trigger.lc.Debugf("MQTT Trigger: Published response message for pipeline '%s' on topic '%s' with %d bytes",
-				pipeline.Id,
-				formattedTopic,
-				len(appContext.ResponseData()))
-			trigger.lc.Tracef("MQTT Trigger published message: %s=%s", common.CorrelationHeader, envelope.CorrelationID)
+	// MQTT messages don't carry the content type of the data published to topics
+	for _, response := range appContext.TopicResponses() {
+		if !trigger.publishResponse(appContext, envelope, pipeline, response.Topic, response.Data) {
+			return false
 		}
 	}
+
+	return true
+}
+
+// publishResponse publishes the response data of the pipeline to the topic, formatted as a topic template with the
+// Event's names and the context's values and tags. Returns false if it failed.
+func (trigger *Trigger) publishResponse(appContext interfaces.AppFunctionContext, envelope types.MessageEnvelope,
+	pipeline *interfaces.FunctionPipeline, topic string, data []byte) bool {
+	formattedTopic, err := appContext.FormatTopic(topic)
+	if err != nil {
+		trigger.lc.Errorf("MQTT trigger: Unable to format topic '%s' for pipeline '%s': %s",
+			topic,
+			pipeline.Id,
+			err.Error())
+		return false
+	}
+
+	if token := trigger.mqttClient.Publish(formattedTopic, trigger.qos, trigger.retain, data); token.Wait() && token.Error() != nil {
+		trigger.lc.Errorf("MQTT trigger: Could not publish to topic '%s' for pipeline '%s': %s",
+			formattedTopic,
+			pipeline.Id,
+			token.Error().Error())
+		return false
+	}
+
+	trigger.lc.Debugf("MQTT Trigger: Published response message for pipeline '%s' on topic '%s' with %d bytes",
+		pipeline.Id,
+		formattedTopic,
+		len(data))
+	trigger.lc.Tracef("MQTT Trigger published message: %s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	return true
 }
@@ -0,0 +1,59 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMessage struct {
+	id    uint16
+	acked *[]uint16
+}
+
+func (m testMessage) Duplicate() bool   { return false }
+func (m testMessage) Qos() byte         { return 1 }
+func (m testMessage) Retained() bool    { return false }
+func (m testMessage) Topic() string     { return "test" }
+func (m testMessage) MessageID() uint16 { return m.id }
+func (m testMessage) Payload() []byte   { return nil }
+func (m testMessage) Ack()              { *m.acked = append(*m.acked, m.id) }
+
+func TestAckQueue(t *testing.T) {
+	var acked []uint16
+	queue := ackQueue{}
+
+	first := queue.add(testMessage{id: 1, acked: &acked})
+	second := queue.add(testMessage{id: 2, acked: &acked})
+	third := queue.add(testMessage{id: 3, acked: &acked})
+
+	third()
+	assert.Empty(t, acked, "messages should not be acknowledged before the messages received earlier")
+
+	second()
+	assert.Empty(t, acked, "messages should not be acknowledged before the messages received earlier")
+
+	first()
+	assert.Equal(t, []uint16{1, 2, 3}, acked, "messages should be acknowledged in the order received")
+	assert.Empty(t, queue.pending)
+
+	fourth := queue.add(testMessage{id: 4, acked: &acked})
+	fourth()
+	assert.Equal(t, []uint16{1, 2, 3, 4}, acked)
+}
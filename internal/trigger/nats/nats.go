@@ -0,0 +1,588 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nats
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap"
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/google/uuid"
+	natsClient "github.com/nats-io/nats.go"
+)
+
+const (
+	subjectSeparator   = "."
+	deliverPolicyAll   = "all"
+	deliverPolicyNew   = "new"
+	deliverPolicyLast  = "last"
+	defaultContentType = common.ContentTypeJSON
+)
+
+// client abstracts the NATS core and JetStream APIs used by the trigger
+type client interface {
+	Subscribe(subject string, handler natsClient.MsgHandler) error
+	// StopReceiving stops the messages being passed to the subscriptions' handlers
+	StopReceiving()
+	Publish(msg *natsClient.Msg) error
+	Close()
+}
+
+// Trigger implements Trigger to support NATS and NATS JetStream
+type Trigger struct {
+	dic          *di.Container
+	lc           logger.LoggingClient
+	runtime      *runtime.GolangRuntime
+	client       client
+	publishTopic string
+	// ackRequired indicates received messages are JetStream messages, which must be acknowledged
+	ackRequired        bool
+	ackAfterProcessing bool
+}
+
+func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime) *Trigger {
+	return &Trigger{
+		dic:     dic,
+		runtime: runtime,
+		lc:      bootstrapContainer.LoggingClientFrom(dic.Get),
+	}
+}
+
+// Initialize initializes the Trigger for a NATS server
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context, background <-chan interfaces.BackgroundMessage) (bootstrap.Deferred, error) {
+	// Convenience short cuts
+	lc := trigger.lc
+	config := container.ConfigurationFrom(trigger.dic.Get)
+	natsConfig := config.Trigger.Nats
+
+	lc.Info("Initializing NATS Trigger")
+
+	topics := util.DeleteEmptyAndTrim(strings.FieldsFunc(natsConfig.SubscribeTopics, util.SplitComma))
+	if len(topics) == 0 {
+		return nil, errors.New("missing SubscribeTopics for NATS Trigger. Must be present in [Trigger.Nats] section")
+	}
+
+	defaultAckPolicy := sdkCommon.AckPolicyReceipt
+	if natsConfig.JetStream {
+		defaultAckPolicy = sdkCommon.AckPolicyProcessed
+	}
+
+	ackAfterProcessing, err := config.Trigger.AckAfterProcessing(defaultAckPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	if ackAfterProcessing && !natsConfig.JetStream {
+		return nil, fmt.Errorf("AckPolicy '%s' requires JetStream for NATS Trigger", sdkCommon.AckPolicyProcessed)
+	}
+
+	options, err := trigger.connectionOptions(natsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Infof("Connecting to NATS server for NATS trigger at: %s", natsConfig.Url)
+
+	conn, err := natsClient.Connect(natsConfig.Url, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to NATS server for NATS trigger: %s", err.Error())
+	}
+
+	if natsConfig.JetStream {
+		trigger.client, err = newJetStreamClient(conn, natsConfig)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	} else {
+		trigger.client = &coreClient{conn: conn, queueGroup: natsConfig.QueueGroup}
+	}
+
+	trigger.publishTopic = natsConfig.PublishTopic
+	trigger.ackRequired = natsConfig.JetStream
+	trigger.ackAfterProcessing = ackAfterProcessing
+
+	for _, topic := range topics {
+		if err := trigger.client.Subscribe(topic, trigger.messageHandler); err != nil {
+			trigger.client.Close()
+			return nil, fmt.Errorf("could not subscribe to subject '%s' for NATS trigger: %s", topic, err.Error())
+		}
+	}
+
+	lc.Infof("Subscribed to subject(s) '%s' for NATS trigger (JetStream=%v)", natsConfig.SubscribeTopics, natsConfig.JetStream)
+
+	if background != nil {
+		trigger.startBackgroundPublishing(appWg, appCtx, background)
+	}
+
+	// Stop receiving once the context is done so the messages published while the service drains are left with
+	// the server. The connection stays open to acknowledge the messages being processed until closed below.
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+		<-appCtx.Done()
+		trigger.client.StopReceiving()
+		lc.Info("NATS trigger no longer receiving messages")
+	}()
+
+	deferred := func() {
+		lc.Info("Disconnecting from NATS server for NATS trigger")
+		trigger.client.Close()
+	}
+
+	return deferred, nil
+}
+
+func (trigger *Trigger) connectionOptions(natsConfig sdkCommon.NatsConfig) ([]natsClient.Option, error) {
+	options := []natsClient.Option{natsClient.Name(natsConfig.ClientId)}
+
+	if len(natsConfig.ConnectTimeout) > 0 {
+		duration, err := time.ParseDuration(natsConfig.ConnectTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS ConnectTimeout '%s': %s", natsConfig.ConnectTimeout, err.Error())
+		}
+		options = append(options, natsClient.Timeout(duration))
+	}
+
+	authMode := natsConfig.AuthMode
+	if authMode == "" {
+		authMode = messaging.AuthModeNone
+		trigger.lc.Warn("AuthMode not set, defaulting to \"" + messaging.AuthModeNone + "\"")
+	}
+
+	// A dummy AppFunctionContext is used to provide access to GetSecret
+	secretData, err := messaging.GetSecretData(authMode, natsConfig.SecretPath, appfunction.NewContext("", trigger.dic, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	if secretData == nil {
+		return options, nil
+	}
+
+	if err := messaging.ValidateSecretData(authMode, natsConfig.SecretPath, secretData); err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		// nolint: gosec
+		InsecureSkipVerify: natsConfig.SkipCertVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	switch authMode {
+	case messaging.AuthModeUsernamePassword:
+		options = append(options, natsClient.UserInfo(secretData.Username, secretData.Password))
+	case messaging.AuthModeCert:
+		cert, err := tls.X509KeyPair(secretData.CertPemBlock, secretData.KeyPemBlock)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if len(secretData.CaPemBlock) > 0 {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(secretData.CaPemBlock) {
+			return nil, errors.New("error parsing CA PEM block")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	if len(tlsConfig.Certificates) > 0 || tlsConfig.RootCAs != nil {
+		options = append(options, natsClient.Secure(tlsConfig))
+	}
+
+	return options, nil
+}
+
+func (trigger *Trigger) messageHandler(msg *natsClient.Msg) {
+	// Convenience short cuts
+	lc := trigger.lc
+
+	envelope := trigger.toEnvelope(msg)
+
+	lc.Debugf("NATS Trigger: Received message with %d bytes on subject '%s'. Content-Type=%s",
+		len(envelope.Payload),
+		msg.Subject,
+		envelope.ContentType)
+	lc.Tracef("%s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	if trigger.ackRequired && !trigger.ackAfterProcessing {
+		if err := msg.Ack(); err != nil {
+			lc.Errorf("NATS Trigger: Unable to acknowledge message on subject '%s': %s", msg.Subject, err.Error())
+		}
+	}
+
+	done, duplicate := trigger.runtime.BeginMessage(envelope)
+	if duplicate {
+		if trigger.ackAfterProcessing {
+			if err := msg.Ack(); err != nil {
+				lc.Errorf("NATS Trigger: Unable to acknowledge message on subject '%s': %s", msg.Subject, err.Error())
+			}
+		}
+		return
+	}
+
+	pipelines := trigger.runtime.RoutePipelines(envelope)
+	lc.Debugf("NATS Trigger found %d pipeline(s) that match the incoming topic '%s'", len(pipelines), envelope.ReceivedTopic)
+
+	pipelinesWaitGroup := &sync.WaitGroup{}
+	var failed int32
+
+	for _, pipeline := range pipelines {
+		p := pipeline
+		pipelinesWaitGroup.Add(1)
+		scheduled := trigger.runtime.ScheduleExecution(envelope, func() {
+			defer pipelinesWaitGroup.Done()
+			if !trigger.processMessageWithPipeline(envelope, p) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			atomic.StoreInt32(&failed, 1)
+		}
+	}
+
+	// Acknowledge once all pipelines have completed so JetStream redelivers the message if any of them failed.
+	go func() {
+		pipelinesWaitGroup.Wait()
+
+		done(atomic.LoadInt32(&failed) == 0)
+
+		if !trigger.ackAfterProcessing {
+			return
+		}
+
+		var err error
+		if atomic.LoadInt32(&failed) == 1 {
+			lc.Debugf("NATS Trigger: Negatively acknowledging message for redelivery (%s=%s)", common.CorrelationHeader, envelope.CorrelationID)
+			err = msg.Nak()
+		} else {
+			err = msg.Ack()
+		}
+
+		if err != nil {
+			lc.Errorf("NATS Trigger: Unable to acknowledge message on subject '%s': %s", msg.Subject, err.Error())
+		}
+	}()
+}
+
+// toEnvelope builds the message envelope using the correlation ID and content type headers when present.
+// The subject's '.' separators are converted to '/' so the existing topic matching applies.
+func (trigger *Trigger) toEnvelope(msg *natsClient.Msg) types.MessageEnvelope {
+	var correlationID string
+	var contentType string
+
+	if msg.Header != nil {
+		correlationID = msg.Header.Get(common.CorrelationHeader)
+		contentType = msg.Header.Get(common.ContentType)
+	}
+
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
+
+	if contentType == "" {
+		contentType = codec.DetectContentType(msg.Data)
+	}
+
+	return types.MessageEnvelope{
+		CorrelationID: correlationID,
+		ContentType:   contentType,
+		Payload:       msg.Data,
+		ReceivedTopic: strings.ReplaceAll(msg.Subject, subjectSeparator, runtime.TopicLevelSeparator),
+	}
+}
+
+// processMessageWithPipeline executes the pipeline and publishes any response data. Returns false if either failed.
+func (trigger *Trigger) processMessageWithPipeline(envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) bool {
+	appContext := appfunction.NewContext(envelope.CorrelationID, trigger.dic, envelope.ContentType)
+
+	messageError := trigger.runtime.ProcessMessage(appContext, envelope, pipeline)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		return false
+	}
+
+	headers := make(map[string]string, len(appContext.Tags())+len(appContext.ResponseHeaders()))
+	for key, value := range appContext.Tags() {
+		headers[interfaces.TagHeaderPrefix+key] = value
+	}
+	for key, value := range appContext.ResponseHeaders() {
+		headers[key] = value
+	}
+
+	if len(appContext.ResponseData()) > 0 && len(trigger.publishTopic) > 0 {
+		if !trigger.publishResponse(appContext, envelope, pipeline, trigger.publishTopic, appContext.ResponseData(),
+			appContext.ResponseContentType(), headers) {
+			return false
+		}
+	}
+
+	for _, response := range appContext.TopicResponses() {
+		if !trigger.publishResponse(appContext, envelope, pipeline, response.Topic, response.Data, response.ContentType, headers) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// publishResponse publishes the response data of the pipeline with the headers to the subject, formatted with the
+// context's values. Returns false if it failed.
+func (trigger *Trigger) publishResponse(appContext interfaces.AppFunctionContext, envelope types.MessageEnvelope,
+	pipeline *interfaces.FunctionPipeline, subject string, data []byte, contentType string, headers map[string]string) bool {
+	formattedTopic, err := appContext.ApplyValues(subject)
+	if err != nil {
+		trigger.lc.Errorf("NATS trigger: Unable to format subject '%s' for pipeline '%s': %s",
+			subject,
+			pipeline.Id,
+			err.Error())
+		return false
+	}
+
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	if err := trigger.publish(formattedTopic, data, contentType, envelope.CorrelationID, headers); err != nil {
+		trigger.lc.Errorf("NATS trigger: Could not publish to subject '%s' for pipeline '%s': %s",
+			formattedTopic,
+			pipeline.Id,
+			err.Error())
+		return false
+	}
+
+	trigger.lc.Debugf("NATS Trigger: Published response message for pipeline '%s' on subject '%s' with %d bytes",
+		pipeline.Id,
+		formattedTopic,
+		len(data))
+	trigger.lc.Tracef("NATS Trigger published message: %s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	return true
+}
+
+// publish publishes the data with the headers, whose content type and correlation id are always those passed in
+func (trigger *Trigger) publish(subject string, data []byte, contentType string, correlationID string, headers map[string]string) error {
+	msg := natsClient.NewMsg(subject)
+	msg.Data = data
+	for key, value := range headers {
+		msg.Header.Set(key, value)
+	}
+	msg.Header.Set(common.ContentType, contentType)
+	msg.Header.Set(common.CorrelationHeader, correlationID)
+
+	return trigger.client.Publish(msg)
+}
+
+func (trigger *Trigger) startBackgroundPublishing(appWg *sync.WaitGroup, appCtx context.Context, background <-chan interfaces.BackgroundMessage) {
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+		for {
+			select {
+			case <-appCtx.Done():
+				trigger.lc.Info("Exiting waiting for NATS background publishing")
+				return
+
+			case bg := <-background:
+				msg := bg.Message()
+				if err := trigger.publish(bg.Topic(), msg.Payload, msg.ContentType, msg.CorrelationID, nil); err != nil {
+					trigger.lc.Errorf("Failed to publish background Message to NATS: %s", err.Error())
+					continue
+				}
+
+				trigger.lc.Debugf("Published background message to NATS on %s subject", bg.Topic())
+				trigger.lc.Tracef("%s=%s", common.CorrelationHeader, msg.CorrelationID)
+			}
+		}
+	}()
+}
+
+// coreClient subscribes and publishes using core NATS, which provides at-most-once delivery
+type coreClient struct {
+	conn          *natsClient.Conn
+	queueGroup    string
+	lock          sync.Mutex
+	subscriptions []*natsClient.Subscription
+}
+
+func (c *coreClient) Subscribe(subject string, handler natsClient.MsgHandler) error {
+	var subscription *natsClient.Subscription
+	var err error
+	if c.queueGroup != "" {
+		subscription, err = c.conn.QueueSubscribe(subject, c.queueGroup, handler)
+	} else {
+		subscription, err = c.conn.Subscribe(subject, handler)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.subscriptions = append(c.subscriptions, subscription)
+	c.lock.Unlock()
+	return nil
+}
+
+// StopReceiving unsubscribes, so the messages are delivered to the other members of the queue group, if any
+func (c *coreClient) StopReceiving() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, subscription := range c.subscriptions {
+		_ = subscription.Unsubscribe()
+	}
+	c.subscriptions = nil
+}
+
+func (c *coreClient) Publish(msg *natsClient.Msg) error {
+	return c.conn.PublishMsg(msg)
+}
+
+func (c *coreClient) Close() {
+	c.conn.Close()
+}
+
+// jetStreamClient subscribes with manual acknowledgement and publishes waiting for the stream's acknowledgement
+type jetStreamClient struct {
+	conn       *natsClient.Conn
+	js         natsClient.JetStreamContext
+	queueGroup string
+	durable    string
+	subOptions []natsClient.SubOpt
+	stopped    sdkCommon.AtomicBool
+}
+
+func newJetStreamClient(conn *natsClient.Conn, natsConfig sdkCommon.NatsConfig) (*jetStreamClient, error) {
+	subOptions, err := jetStreamSubOptions(natsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create JetStream context for NATS trigger: %s", err.Error())
+	}
+
+	return &jetStreamClient{
+		conn:       conn,
+		js:         js,
+		queueGroup: natsConfig.QueueGroup,
+		durable:    natsConfig.Durable,
+		subOptions: subOptions,
+	}, nil
+}
+
+func jetStreamSubOptions(natsConfig sdkCommon.NatsConfig) ([]natsClient.SubOpt, error) {
+	options := []natsClient.SubOpt{natsClient.ManualAck(), natsClient.AckExplicit()}
+
+	switch strings.ToLower(natsConfig.DeliverPolicy) {
+	case "", deliverPolicyAll:
+		options = append(options, natsClient.DeliverAll())
+	case deliverPolicyNew:
+		options = append(options, natsClient.DeliverNew())
+	case deliverPolicyLast:
+		options = append(options, natsClient.DeliverLast())
+	default:
+		return nil, fmt.Errorf("invalid NATS DeliverPolicy '%s', must be '%s', '%s' or '%s'",
+			natsConfig.DeliverPolicy, deliverPolicyAll, deliverPolicyNew, deliverPolicyLast)
+	}
+
+	if natsConfig.MaxDeliver > 0 {
+		options = append(options, natsClient.MaxDeliver(natsConfig.MaxDeliver))
+	}
+
+	return options, nil
+}
+
+func (c *jetStreamClient) Subscribe(subject string, handler natsClient.MsgHandler) error {
+	options := c.subOptions
+	if c.durable != "" {
+		// A durable consumer is bound to a single filter subject, so each subject has its own
+		options = append(append([]natsClient.SubOpt{}, c.subOptions...), natsClient.Durable(durableName(c.durable, subject)))
+	}
+
+	receive := func(msg *natsClient.Msg) {
+		if c.stopped.Value() {
+			return
+		}
+		handler(msg)
+	}
+
+	var err error
+	if c.queueGroup != "" {
+		_, err = c.js.QueueSubscribe(subject, c.queueGroup, receive, options...)
+	} else {
+		_, err = c.js.Subscribe(subject, receive, options...)
+	}
+	return err
+}
+
+// StopReceiving ignores the messages delivered from now on rather than unsubscribing, which would delete the durable
+// consumers. The messages ignored aren't acknowledged, so they are redelivered once the AckWait expires.
+func (c *jetStreamClient) StopReceiving() {
+	c.stopped.Set(true)
+}
+
+// durableName returns the name of the subject's durable consumer, the Durable followed by the subject with the
+// characters not allowed in consumer names, i.e. "." and wildcards, replaced with "_"
+func durableName(durable string, subject string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, subject)
+
+	return durable + "-" + sanitized
+}
+
+func (c *jetStreamClient) Publish(msg *natsClient.Msg) error {
+	_, err := c.js.PublishMsg(msg)
+	return err
+}
+
+func (c *jetStreamClient) Close() {
+	c.conn.Close()
+}
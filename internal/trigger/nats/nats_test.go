@@ -0,0 +1,276 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+
+	natsClient "github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dic *di.Container
+
+func TestMain(m *testing.M) {
+	dic = di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+	os.Exit(m.Run())
+}
+
+type fakeClient struct {
+	lock      sync.Mutex
+	published []*natsClient.Msg
+}
+
+func (client *fakeClient) Subscribe(_ string, _ natsClient.MsgHandler) error {
+	return nil
+}
+
+func (client *fakeClient) StopReceiving() {
+}
+
+func (client *fakeClient) Publish(msg *natsClient.Msg) error {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.published = append(client.published, msg)
+	return nil
+}
+
+func (client *fakeClient) Close() {
+}
+
+func (client *fakeClient) publishedMessages() []*natsClient.Msg {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.published
+}
+
+func TestInitializeMissingSubscribeTopics(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing SubscribeTopics")
+}
+
+func TestInitializeAckPolicyRequiresJetStream(t *testing.T) {
+	config := &sdkCommon.ConfigurationStruct{
+		Trigger: sdkCommon.TriggerInfo{
+			Nats:      sdkCommon.NatsConfig{SubscribeTopics: "edgex.events.>"},
+			AckPolicy: sdkCommon.AckPolicyProcessed,
+		},
+	}
+
+	testDic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+
+	trigger := NewTrigger(testDic, &runtime.GolangRuntime{})
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires JetStream")
+}
+
+func TestMessageHandlerPipelinePerSubject(t *testing.T) {
+	transform1WasCalled := make(chan bool, 1)
+	transform2WasCalled := make(chan bool, 1)
+
+	transform1 := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		transform1WasCalled <- true
+		appContext.SetResponseData([]byte("response"))
+		appContext.SetResponseHeader("X-Device", "LivingRoomThermostat")
+		appContext.SetTag("GatewayId", "HoustonStore000123")
+		return false, nil
+	}
+
+	transform2 := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		transform2WasCalled <- true
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", nil, dic)
+	err := goRuntime.AddFunctionsPipeline("P1", []string{"edgex/events/device/P1/#"}, []interfaces.AppFunction{transform1})
+	require.NoError(t, err)
+	err = goRuntime.AddFunctionsPipeline("P2", []string{"edgex/events/device/P2/#"}, []interfaces.AppFunction{transform2})
+	require.NoError(t, err)
+
+	client := &fakeClient{}
+	trigger := NewTrigger(dic, goRuntime)
+	trigger.client = client
+	trigger.publishTopic = "responses.{devicename}"
+
+	msg := natsClient.NewMsg("edgex.events.device.P1.LivingRoomThermostat.temperature")
+	event := dtos.NewEvent("thermostat", "LivingRoomThermostat", "temperature")
+	_ = event.AddSimpleReading("temperature", common.ValueTypeInt64, int64(38))
+	msg.Data, err = json.Marshal(requests.NewAddEventRequest(event))
+	require.NoError(t, err)
+	msg.Header.Set(common.CorrelationHeader, "123")
+
+	trigger.messageHandler(msg)
+
+	select {
+	case <-transform1WasCalled:
+	case <-transform2WasCalled:
+		t.Fail() // should not have happened
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "Transform never called")
+	}
+
+	require.Eventually(t, func() bool { return len(client.publishedMessages()) == 1 }, 3*time.Second, 10*time.Millisecond)
+	published := client.publishedMessages()[0]
+	assert.Equal(t, "responses.LivingRoomThermostat", published.Subject)
+	assert.Equal(t, []byte("response"), published.Data)
+	assert.Equal(t, "123", published.Header.Get(common.CorrelationHeader))
+	assert.Equal(t, common.ContentTypeJSON, published.Header.Get(common.ContentType))
+	assert.Equal(t, "LivingRoomThermostat", published.Header.Get("X-Device"))
+	assert.Equal(t, "HoustonStore000123", published.Header.Get(interfaces.TagHeaderPrefix+"GatewayId"))
+}
+
+func TestMessageHandlerShadowPipelineNotPublished(t *testing.T) {
+	shadowWasCalled := make(chan bool, 1)
+
+	production := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		appContext.PublishToTopic("alerts.{devicename}", []byte("production alert"), "")
+		return false, nil
+	}
+
+	shadow := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		appContext.SetResponseData([]byte("shadow response"))
+		appContext.PublishToTopic("alerts.{devicename}", []byte("shadow alert"), "")
+		shadowWasCalled <- true
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", nil, dic)
+	err := goRuntime.AddFunctionsPipeline("production", []string{"edgex/events/#"}, []interfaces.AppFunction{production})
+	require.NoError(t, err)
+	err = goRuntime.AddShadowFunctionsPipeline("shadow", []string{"edgex/events/#"}, interfaces.ShadowModeSend, []interfaces.AppFunction{shadow})
+	require.NoError(t, err)
+
+	client := &fakeClient{}
+	trigger := NewTrigger(dic, goRuntime)
+	trigger.client = client
+	trigger.publishTopic = "responses.{devicename}"
+
+	msg := natsClient.NewMsg("edgex.events.device.P1.LivingRoomThermostat.temperature")
+	event := dtos.NewEvent("thermostat", "LivingRoomThermostat", "temperature")
+	_ = event.AddSimpleReading("temperature", common.ValueTypeInt64, int64(38))
+	msg.Data, err = json.Marshal(requests.NewAddEventRequest(event))
+	require.NoError(t, err)
+
+	trigger.messageHandler(msg)
+
+	select {
+	case <-shadowWasCalled:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "Shadow pipeline never called")
+	}
+
+	require.Eventually(t, func() bool { return len(client.publishedMessages()) == 1 }, 3*time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool { return len(client.publishedMessages()) > 1 }, 200*time.Millisecond, 10*time.Millisecond,
+		"nothing from the shadow pipeline should be published")
+	published := client.publishedMessages()[0]
+	assert.Equal(t, "alerts.LivingRoomThermostat", published.Subject)
+	assert.Equal(t, []byte("production alert"), published.Data)
+}
+
+func TestToEnvelope(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+
+	msg := natsClient.NewMsg("edgex.events")
+	msg.Data = []byte{0xA1}
+	envelope := trigger.toEnvelope(msg)
+	assert.Equal(t, "edgex/events", envelope.ReceivedTopic)
+	assert.Equal(t, common.ContentTypeCBOR, envelope.ContentType)
+	assert.NotEmpty(t, envelope.CorrelationID)
+
+	msg = &natsClient.Msg{Subject: "edgex", Data: []byte(`{}`)}
+	envelope = trigger.toEnvelope(msg)
+	assert.Equal(t, common.ContentTypeJSON, envelope.ContentType)
+
+	msg = natsClient.NewMsg("edgex")
+	msg.Header.Set(common.ContentType, common.ContentTypeCBOR)
+	msg.Header.Set(common.CorrelationHeader, "abc")
+	envelope = trigger.toEnvelope(msg)
+	assert.Equal(t, common.ContentTypeCBOR, envelope.ContentType)
+	assert.Equal(t, "abc", envelope.CorrelationID)
+}
+
+func TestJetStreamSubOptions(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Config        sdkCommon.NatsConfig
+		ExpectedCount int
+		ExpectError   bool
+	}{
+		{"Defaults", sdkCommon.NatsConfig{}, 3, false},
+		{"Durable", sdkCommon.NatsConfig{Durable: "app-service", DeliverPolicy: "New", MaxDeliver: 5}, 4, false},
+		{"Last", sdkCommon.NatsConfig{DeliverPolicy: "last"}, 3, false},
+		{"Bad deliver policy", sdkCommon.NatsConfig{DeliverPolicy: "first"}, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			options, err := jetStreamSubOptions(test.Config)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, options, test.ExpectedCount)
+		})
+	}
+}
+
+func TestDurableName(t *testing.T) {
+	events := durableName("app-service", "edgex.events.>")
+	commands := durableName("app-service", "edgex.commands.*")
+
+	assert.Equal(t, "app-service-edgex_events__", events)
+	assert.Equal(t, "app-service-edgex_commands__", commands)
+	assert.NotEqual(t, events, commands, "each subject should have its own durable consumer")
+}
@@ -0,0 +1,485 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap"
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	clientInterfaces "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize  = 100
+	defaultBaseTopic = "edgex/events"
+)
+
+// Trigger implements Trigger to support replaying historical Events from Core Data through the function pipeline(s)
+type Trigger struct {
+	replayer
+}
+
+// replayer replays the Events from Core Data matching the query through the function pipeline(s)
+type replayer struct {
+	dic           *di.Container
+	lc            logger.LoggingClient
+	runtime       *runtime.GolangRuntime
+	client        clientInterfaces.EventClient
+	query         query
+	rate          float64
+	baseTopic     string
+	resourceNames []string
+}
+
+// query contains the parsed Core Data query for the Events to replay
+type query struct {
+	deviceNames []string
+	start       int64
+	end         int64
+	pageSize    int
+	maxEvents   int
+}
+
+func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime) *Trigger {
+	return &Trigger{
+		replayer: replayer{
+			dic:     dic,
+			runtime: runtime,
+			lc:      bootstrapContainer.LoggingClientFrom(dic.Get),
+		},
+	}
+}
+
+// Events replays the Events from Core Data for the devices, or all devices when none are specified, that originated
+// in the time range through the function pipeline(s), oldest first, waiting for the pipelines to complete for each
+// Event. The Events are replayed on the edgex/events/<profile>/<device>/<source> topic with the REPLAY context value
+// set to "true". Returns the number of Events replayed.
+func Events(ctx context.Context, dic *di.Container, runtime *runtime.GolangRuntime, start time.Time, end time.Time, deviceNames []string) (int, error) {
+	client := container.EventClientFrom(dic.Get)
+	if client == nil {
+		return 0, errors.New("EventClient not initialized. Core Data is missing from clients configuration")
+	}
+
+	if start.After(end) {
+		return 0, fmt.Errorf("invalid replay time range, start '%s' is after end '%s'", start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	target := replayer{
+		dic:       dic,
+		lc:        bootstrapContainer.LoggingClientFrom(dic.Get),
+		runtime:   runtime,
+		client:    client,
+		baseTopic: defaultBaseTopic,
+		query: query{
+			deviceNames: util.DeleteEmptyAndTrim(deviceNames),
+			start:       start.UnixNano(),
+			end:         end.UnixNano(),
+			pageSize:    defaultPageSize,
+		},
+	}
+
+	return target.replay(ctx)
+}
+
+// Initialize initializes the Trigger and starts replaying the Events in the background
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context, background <-chan interfaces.BackgroundMessage) (bootstrap.Deferred, error) {
+	// Convenience short cuts
+	lc := trigger.lc
+	config := container.ConfigurationFrom(trigger.dic.Get)
+	replayConfig := config.Trigger.Replay
+
+	lc.Info("Initializing Replay Trigger")
+
+	if background != nil {
+		return nil, errors.New("background publishing not supported for services using Replay trigger")
+	}
+
+	trigger.client = container.EventClientFrom(trigger.dic.Get)
+	if trigger.client == nil {
+		return nil, errors.New("EventClient not initialized. Core Data is missing from clients configuration")
+	}
+
+	var err error
+	trigger.query, err = parseQuery(replayConfig, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if replayConfig.Rate < 0 {
+		return nil, fmt.Errorf("invalid Replay Rate '%v', must not be negative", replayConfig.Rate)
+	}
+
+	trigger.rate = replayConfig.Rate
+	trigger.resourceNames = util.DeleteEmptyAndTrim(strings.FieldsFunc(replayConfig.ResourceNames, util.SplitComma))
+	trigger.baseTopic = strings.TrimSuffix(replayConfig.BaseTopic, runtime.TopicLevelSeparator)
+	if trigger.baseTopic == "" {
+		trigger.baseTopic = defaultBaseTopic
+	}
+
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+
+		if _, err := trigger.replay(appCtx); err != nil {
+			lc.Errorf("Replay Trigger failed: %s", err.Error())
+		}
+	}()
+
+	return nil, nil
+}
+
+func parseQuery(replayConfig sdkCommon.ReplayConfig, now time.Time) (query, error) {
+	result := query{
+		end:       now.UnixNano(),
+		pageSize:  replayConfig.PageSize,
+		maxEvents: replayConfig.MaxEvents,
+	}
+
+	if deviceName := strings.TrimSpace(replayConfig.DeviceName); deviceName != "" {
+		result.deviceNames = []string{deviceName}
+	}
+
+	if result.pageSize <= 0 {
+		result.pageSize = defaultPageSize
+	}
+
+	if replayConfig.Start != "" {
+		start, err := time.Parse(time.RFC3339, replayConfig.Start)
+		if err != nil {
+			return result, fmt.Errorf("invalid Replay Start '%s': %s", replayConfig.Start, err.Error())
+		}
+		result.start = start.UnixNano()
+	}
+
+	if replayConfig.End != "" {
+		end, err := time.Parse(time.RFC3339, replayConfig.End)
+		if err != nil {
+			return result, fmt.Errorf("invalid Replay End '%s': %s", replayConfig.End, err.Error())
+		}
+		result.end = end.UnixNano()
+	}
+
+	if result.start > result.end {
+		return result, fmt.Errorf("invalid Replay time range, Start '%s' is after End", replayConfig.Start)
+	}
+
+	return result, nil
+}
+
+// replay retrieves the Events from Core Data and executes the matching pipelines for each, in the order the Events
+// originated, at the configured rate. Returns the number of Events replayed.
+func (trigger *replayer) replay(ctx context.Context) (int, error) {
+	// Convenience short cuts
+	lc := trigger.lc
+
+	lc.Info("Replay Trigger replaying Events")
+
+	var ticker *time.Ticker
+	if trigger.rate > 0 {
+		ticker = time.NewTicker(time.Duration(math.Max(1, float64(time.Second)/trigger.rate)))
+		defer ticker.Stop()
+	}
+
+	replayed := 0
+	retrieved := 0
+	replayEvents := func(events []dtos.Event) bool {
+		for _, event := range events {
+			if ticker != nil && retrieved > 0 {
+				select {
+				case <-ctx.Done():
+				case <-ticker.C:
+				}
+			}
+
+			if ctx.Err() != nil {
+				return false
+			}
+
+			retrieved++
+			if err := trigger.replayEvent(event); err != nil {
+				lc.Errorf("Replay Trigger: unable to replay Event %s: %s", event.Id, err.Error())
+				continue
+			}
+
+			replayed++
+		}
+
+		return true
+	}
+
+	err := trigger.retrieveEvents(ctx, replayEvents)
+	if ctx.Err() != nil {
+		lc.Infof("Replay Trigger stopped after replaying %d of %d Event(s)", replayed, retrieved)
+		return replayed, nil
+	}
+
+	if err != nil {
+		return replayed, err
+	}
+
+	lc.Infof("Replay Trigger completed replaying %d of %d Event(s)", replayed, retrieved)
+	return replayed, nil
+}
+
+// retrieveEvents retrieves the Events matching the query and passes them to replayEvents oldest first, a batch at a
+// time, until replayEvents returns false. When MaxEvents is set the most recent Events are passed as one batch,
+// otherwise the Events are streamed one time window at a time so memory doesn't grow with the size of the time range.
+func (trigger *replayer) retrieveEvents(ctx context.Context, replayEvents func([]dtos.Event) bool) error {
+	if trigger.query.maxEvents > 0 {
+		events, err := trigger.retrieveRecentEvents(ctx)
+		if err != nil {
+			return err
+		}
+
+		replayEvents(events)
+		return nil
+	}
+
+	_, err := trigger.streamWindow(ctx, trigger.query.start, trigger.query.end, replayEvents)
+	return err
+}
+
+// retrieveRecentEvents pages through the Events matching the query, which Core Data returns newest first, until
+// MaxEvents have been retrieved and returns them oldest first.
+func (trigger *replayer) retrieveRecentEvents(ctx context.Context) ([]dtos.Event, error) {
+	var events []dtos.Event
+
+	for offset := 0; ; offset += trigger.query.pageSize {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		page, err := trigger.queryPage(ctx, offset)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve Events from Core Data: %s", err.Error())
+		}
+
+		for _, event := range page {
+			if event, ok := trigger.filter(event); ok {
+				events = append(events, event)
+			}
+
+			if len(events) >= trigger.query.maxEvents {
+				break
+			}
+		}
+
+		if len(page) < trigger.query.pageSize || len(events) >= trigger.query.maxEvents {
+			break
+		}
+	}
+
+	sortOldestFirst(events)
+	return events, nil
+}
+
+// streamWindow passes the Events that originated in the time window to replayEvents oldest first. Core Data returns
+// Events newest first, so a window holding a page or more of Events is split in half and each half streamed in turn,
+// oldest first, keeping at most one page of Events in memory. Returns false once replayEvents has stopped.
+func (trigger *replayer) streamWindow(ctx context.Context, start int64, end int64, replayEvents func([]dtos.Event) bool) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	page, err := trigger.queryTimeRange(ctx, start, end, 0)
+	if err != nil {
+		return false, err
+	}
+
+	if len(page) < trigger.query.pageSize {
+		return replayEvents(trigger.filterPage(page)), nil
+	}
+
+	if start == end {
+		// All Events in the window originated at the same time, so their order doesn't matter and
+		// the window can be paged through as is.
+		for offset := 0; len(page) > 0; {
+			if !replayEvents(trigger.filterPage(page)) {
+				return false, nil
+			}
+
+			if len(page) < trigger.query.pageSize {
+				break
+			}
+
+			offset += trigger.query.pageSize
+			if page, err = trigger.queryTimeRange(ctx, start, end, offset); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}
+
+	middle := start + (end-start)/2
+	if ok, err := trigger.streamWindow(ctx, start, middle, replayEvents); !ok || err != nil {
+		return ok, err
+	}
+
+	return trigger.streamWindow(ctx, middle+1, end, replayEvents)
+}
+
+func (trigger *replayer) queryTimeRange(ctx context.Context, start int64, end int64, offset int) ([]dtos.Event, error) {
+	response, err := trigger.client.EventsByTimeRange(ctx, int(start), int(end), offset, trigger.query.pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve Events from Core Data: %s", err.Error())
+	}
+	return response.Events, nil
+}
+
+// filterPage filters the page of Events and returns the remaining Events oldest first
+func (trigger *replayer) filterPage(page []dtos.Event) []dtos.Event {
+	var events []dtos.Event
+	for _, event := range page {
+		if event, ok := trigger.filter(event); ok {
+			events = append(events, event)
+		}
+	}
+
+	sortOldestFirst(events)
+	return events
+}
+
+func sortOldestFirst(events []dtos.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Origin < events[j].Origin
+	})
+}
+
+func (trigger *replayer) queryPage(ctx context.Context, offset int) ([]dtos.Event, error) {
+	if len(trigger.query.deviceNames) == 1 && trigger.query.start == 0 {
+		response, err := trigger.client.EventsByDeviceName(ctx, trigger.query.deviceNames[0], offset, trigger.query.pageSize)
+		if err != nil {
+			return nil, err
+		}
+		return response.Events, nil
+	}
+
+	response, err := trigger.client.EventsByTimeRange(ctx, int(trigger.query.start), int(trigger.query.end), offset, trigger.query.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return response.Events, nil
+}
+
+// filter applies the parts of the query Core Data doesn't support in combination and removes the readings not replayed
+func (trigger *replayer) filter(event dtos.Event) (dtos.Event, bool) {
+	if len(trigger.query.deviceNames) > 0 && !contains(trigger.query.deviceNames, event.DeviceName) {
+		return event, false
+	}
+
+	if event.Origin < trigger.query.start || event.Origin > trigger.query.end {
+		return event, false
+	}
+
+	if len(trigger.resourceNames) == 0 {
+		return event, true
+	}
+
+	var readings []dtos.BaseReading
+	for _, reading := range event.Readings {
+		if contains(trigger.resourceNames, reading.ResourceName) {
+			readings = append(readings, reading)
+		}
+	}
+
+	event.Readings = readings
+	return event, len(readings) > 0
+}
+
+func contains(names []string, name string) bool {
+	for _, item := range names {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// replayEvent executes the pipelines matching the Event's topic and waits for them to complete
+// so the Events are processed in the order they originated.
+func (trigger *replayer) replayEvent(event dtos.Event) error {
+	payload, err := json.Marshal(requests.NewAddEventRequest(event))
+	if err != nil {
+		return err
+	}
+
+	envelope := types.MessageEnvelope{
+		CorrelationID: uuid.New().String(),
+		ContentType:   common.ContentTypeJSON,
+		Payload:       payload,
+		ReceivedTopic: strings.Join([]string{trigger.baseTopic, event.ProfileName, event.DeviceName, event.SourceName}, runtime.TopicLevelSeparator),
+	}
+
+	trigger.lc.Debugf("Replay Trigger: Replaying Event %s on topic '%s'", event.Id, envelope.ReceivedTopic)
+	trigger.lc.Tracef("%s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	pipelines := trigger.runtime.RoutePipelines(envelope)
+	trigger.lc.Debugf("Replay Trigger found %d pipeline(s) that match the topic '%s'", len(pipelines), envelope.ReceivedTopic)
+
+	pipelinesWaitGroup := sync.WaitGroup{}
+	notScheduled := false
+	for _, pipeline := range pipelines {
+		p := pipeline
+		pipelinesWaitGroup.Add(1)
+		scheduled := trigger.runtime.ScheduleExecution(envelope, func() {
+			defer pipelinesWaitGroup.Done()
+			appContext := appfunction.NewContext(envelope.CorrelationID, trigger.dic, envelope.ContentType)
+			appContext.AddValue(interfaces.REPLAY, "true")
+			// ProcessMessage logs any error, so no need to log it here.
+			_ = trigger.runtime.ProcessMessage(appContext, envelope, p)
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			notScheduled = true
+		}
+	}
+
+	pipelinesWaitGroup.Wait()
+
+	if notScheduled {
+		return errors.New("pipeline execution not scheduled, service is shutting down")
+	}
+
+	return nil
+}
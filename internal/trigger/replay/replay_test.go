@@ -0,0 +1,304 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package replay
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var dic *di.Container
+
+func TestMain(m *testing.M) {
+	dic = di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+	os.Exit(m.Run())
+}
+
+func newEvent(deviceName string, origin int64) dtos.Event {
+	event := dtos.NewEvent("thermostat", deviceName, "status")
+	event.Origin = origin
+	_ = event.AddSimpleReading("temperature", common.ValueTypeInt64, origin)
+	_ = event.AddSimpleReading("humidity", common.ValueTypeInt64, int64(50))
+	return event
+}
+
+func TestInitializeBackgroundNotSupported(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), make(chan interfaces.BackgroundMessage))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "background publishing not supported")
+}
+
+func TestInitializeNoEventClient(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Core Data is missing")
+}
+
+func TestParseQuery(t *testing.T) {
+	now := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name          string
+		Config        sdkCommon.ReplayConfig
+		Expected      query
+		ExpectedError string
+	}{
+		{"Defaults", sdkCommon.ReplayConfig{}, query{end: now.UnixNano(), pageSize: defaultPageSize}, ""},
+		{"Device and time range", sdkCommon.ReplayConfig{DeviceName: " Thermostat ", Start: "2021-06-01T00:00:00Z", End: "2021-06-02T00:00:00Z", PageSize: 10, MaxEvents: 5},
+			query{deviceNames: []string{"Thermostat"}, start: start.UnixNano(), end: start.Add(24 * time.Hour).UnixNano(), pageSize: 10, maxEvents: 5}, ""},
+		{"Bad start", sdkCommon.ReplayConfig{Start: "yesterday"}, query{}, "invalid Replay Start"},
+		{"Bad end", sdkCommon.ReplayConfig{End: "2021-06-01"}, query{}, "invalid Replay End"},
+		{"Start after end", sdkCommon.ReplayConfig{Start: "2021-08-01T00:00:00Z"}, query{}, "Start '2021-08-01T00:00:00Z' is after End"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			actual, err := parseQuery(test.Config, now)
+			if test.ExpectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+// eventsByTimeRange returns the Events in the time range newest first, like Core Data does
+func eventsByTimeRange(events []dtos.Event) func(context.Context, int, int, int, int) responses.MultiEventsResponse {
+	return func(_ context.Context, start int, end int, offset int, limit int) responses.MultiEventsResponse {
+		var matching []dtos.Event
+		for index := len(events) - 1; index >= 0; index-- {
+			if events[index].Origin >= int64(start) && events[index].Origin <= int64(end) {
+				matching = append(matching, events[index])
+			}
+		}
+
+		if offset >= len(matching) {
+			return responses.MultiEventsResponse{}
+		}
+
+		if offset+limit < len(matching) {
+			matching = matching[:offset+limit]
+		}
+
+		return responses.MultiEventsResponse{Events: matching[offset:]}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	events := []dtos.Event{newEvent("Thermostat", 100), newEvent("Thermostat", 200), newEvent("Other", 300), newEvent("Thermostat", 400)}
+
+	client := &mocks.EventClient{}
+	client.On("EventsByTimeRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, 2).Return(eventsByTimeRange(events), nil)
+
+	var received []dtos.Event
+	transform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		received = append(received, data.(dtos.Event))
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", nil, dic)
+	err := goRuntime.AddFunctionsPipeline("P1", []string{"history/thermostat/#"}, []interfaces.AppFunction{transform})
+	require.NoError(t, err)
+
+	trigger := NewTrigger(dic, goRuntime)
+	trigger.client = client
+	trigger.query = query{deviceNames: []string{"Thermostat"}, start: 50, end: 1000, pageSize: 2}
+	trigger.rate = 1000
+	trigger.baseTopic = "history"
+	trigger.resourceNames = []string{"temperature"}
+
+	replayed, err := trigger.replay(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, replayed)
+
+	require.Len(t, received, 3)
+	for index, origin := range []int64{100, 200, 400} {
+		assert.Equal(t, origin, received[index].Origin, "Events should be replayed oldest first")
+		assert.Equal(t, "Thermostat", received[index].DeviceName)
+		require.Len(t, received[index].Readings, 1)
+		assert.Equal(t, "temperature", received[index].Readings[0].ResourceName)
+	}
+}
+
+func TestReplayMaxEventsByDevice(t *testing.T) {
+	events := []dtos.Event{newEvent("Thermostat", 300), newEvent("Thermostat", 200), newEvent("Thermostat", 100)}
+
+	client := &mocks.EventClient{}
+	client.On("EventsByDeviceName", mock.Anything, "Thermostat", 0, defaultPageSize).Return(responses.MultiEventsResponse{Events: events}, nil)
+
+	trigger := NewTrigger(dic, nil)
+	trigger.client = client
+	trigger.query = query{deviceNames: []string{"Thermostat"}, end: 1000, pageSize: defaultPageSize, maxEvents: 2}
+
+	var actual []dtos.Event
+	err := trigger.retrieveEvents(context.Background(), func(events []dtos.Event) bool {
+		actual = append(actual, events...)
+		return true
+	})
+	require.NoError(t, err)
+
+	require.Len(t, actual, 2, "only the most recent Events should be replayed")
+	assert.Equal(t, int64(200), actual[0].Origin)
+	assert.Equal(t, int64(300), actual[1].Origin)
+	client.AssertNotCalled(t, "EventsByTimeRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRetrieveEventsStreamsWindows(t *testing.T) {
+	var events []dtos.Event
+	for origin := int64(1); origin <= 50; origin++ {
+		events = append(events, newEvent("Thermostat", origin*10))
+	}
+
+	// Events originating at the same time can't be split into smaller windows
+	for count := 0; count < 7; count++ {
+		events = append(events, newEvent("Thermostat", 600))
+	}
+
+	tests := []struct {
+		Name string
+		Stop int
+	}{
+		{"All Events", 0},
+		{"Stopped", 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			client := &mocks.EventClient{}
+			client.On("EventsByTimeRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, 3).Return(eventsByTimeRange(events), nil)
+
+			trigger := NewTrigger(dic, nil)
+			trigger.client = client
+			trigger.query = query{start: 0, end: 1000, pageSize: 3}
+
+			var actual []dtos.Event
+			batches := 0
+			err := trigger.retrieveEvents(context.Background(), func(batch []dtos.Event) bool {
+				assert.LessOrEqual(t, len(batch), 3, "no more than a page of Events should be held at a time")
+				actual = append(actual, batch...)
+				batches++
+				return batches != test.Stop
+			})
+			require.NoError(t, err)
+
+			if test.Stop > 0 {
+				assert.Equal(t, test.Stop, batches, "no Events should be streamed once stopped")
+			} else {
+				require.Len(t, actual, len(events))
+			}
+
+			for index := 1; index < len(actual); index++ {
+				assert.LessOrEqual(t, actual[index-1].Origin, actual[index].Origin, "Events should be streamed oldest first")
+			}
+		})
+	}
+}
+
+func TestEvents(t *testing.T) {
+	events := []dtos.Event{newEvent("Thermostat", 400), newEvent("Other", 300), newEvent("Ignored", 200), newEvent("Thermostat", 100)}
+
+	client := &mocks.EventClient{}
+	client.On("EventsByTimeRange", mock.Anything, 50, 1000, 0, defaultPageSize).Return(responses.MultiEventsResponse{Events: events}, nil)
+
+	replayDic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+		container.EventClientName: func(get di.Get) interface{} {
+			return client
+		},
+	})
+
+	var received []dtos.Event
+	transform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		flag, _ := appContext.GetValue(interfaces.REPLAY)
+		assert.Equal(t, "true", flag, "replayed Events should be flagged on the context")
+		received = append(received, data.(dtos.Event))
+		return false, nil
+	}
+
+	goRuntime := runtime.NewGolangRuntime("", nil, replayDic)
+	err := goRuntime.AddFunctionsPipeline("P1", []string{"edgex/events/#"}, []interfaces.AppFunction{transform})
+	require.NoError(t, err)
+
+	replayed, err := Events(context.Background(), replayDic, goRuntime, time.Unix(0, 50), time.Unix(0, 1000), []string{"Thermostat", " Other "})
+	require.NoError(t, err)
+	assert.Equal(t, 3, replayed)
+
+	require.Len(t, received, 3)
+	for index, origin := range []int64{100, 300, 400} {
+		assert.Equal(t, origin, received[index].Origin)
+		assert.NotEqual(t, "Ignored", received[index].DeviceName)
+	}
+}
+
+func TestEventsErrors(t *testing.T) {
+	_, err := Events(context.Background(), dic, nil, time.Unix(0, 50), time.Unix(0, 1000), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Core Data is missing")
+
+	replayDic := di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.EventClientName: func(get di.Get) interface{} {
+			return &mocks.EventClient{}
+		},
+	})
+
+	_, err = Events(context.Background(), replayDic, nil, time.Unix(0, 1000), time.Unix(0, 50), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid replay time range")
+}
@@ -29,8 +29,14 @@ import (
 type ServiceBinding interface {
 	// ProcessMessage provides access to the runtime's ProcessMessage function
 	ProcessMessage(appContext *appfunction.Context, envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline) *runtime.MessageError
+	// ScheduleExecution provides access to the runtime's ScheduleExecution function
+	ScheduleExecution(envelope types.MessageEnvelope, job func()) bool
+	// BeginMessage provides access to the runtime's BeginMessage function
+	BeginMessage(envelope types.MessageEnvelope) (func(succeeded bool), bool)
 	// GetMatchingPipelines provides access to the runtime's GetMatchingPipelines function
 	GetMatchingPipelines(incomingTopic string) []*interfaces.FunctionPipeline
+	// RoutePipelines provides access to the runtime's RoutePipelines function
+	RoutePipelines(envelope types.MessageEnvelope) []*interfaces.FunctionPipeline
 	// BuildContext creates a context for a given message envelope
 	BuildContext(env types.MessageEnvelope) interfaces.AppFunctionContext
 	// SecretProvider provides access to this service's secret provider for the trigger
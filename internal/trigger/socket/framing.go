@@ -0,0 +1,128 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// framer reads and writes the messages delimited in a TCP stream
+type framer interface {
+	readMessage(reader *bufio.Reader) ([]byte, error)
+	writeMessage(writer io.Writer, data []byte) error
+}
+
+// newlineFramer delimits the messages with '\n', removing any '\r' preceding it
+type newlineFramer struct {
+	maxSize int
+}
+
+func (f newlineFramer) readMessage(reader *bufio.Reader) ([]byte, error) {
+	var message []byte
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		message = append(message, chunk...)
+
+		if len(bytes.TrimRight(message, "\r\n")) > f.maxSize {
+			return nil, fmt.Errorf("message larger than MaxMessageSize of %d bytes", f.maxSize)
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		// The last message may not be terminated when the sender closes the connection
+		if errors.Is(err, io.EOF) && len(message) > 0 {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		break
+	}
+
+	return bytes.TrimRight(message, "\r\n"), nil
+}
+
+func (f newlineFramer) writeMessage(writer io.Writer, data []byte) error {
+	_, err := writer.Write(append(data[:len(data):len(data)], '\n'))
+	return err
+}
+
+// lengthFramer precedes each message with its length as a big-endian unsigned integer of fieldSize bytes
+type lengthFramer struct {
+	fieldSize int
+	maxSize   int
+}
+
+func (f lengthFramer) readMessage(reader *bufio.Reader) ([]byte, error) {
+	header := make([]byte, f.fieldSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	var length uint64
+	switch f.fieldSize {
+	case 1:
+		length = uint64(header[0])
+	case 2:
+		length = uint64(binary.BigEndian.Uint16(header))
+	default:
+		length = uint64(binary.BigEndian.Uint32(header))
+	}
+
+	if length > uint64(f.maxSize) {
+		return nil, fmt.Errorf("message length of %d bytes larger than MaxMessageSize of %d bytes", length, f.maxSize)
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(reader, message); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return message, nil
+}
+
+func (f lengthFramer) writeMessage(writer io.Writer, data []byte) error {
+	if uint64(len(data)) >= uint64(1)<<(8*f.fieldSize) {
+		return fmt.Errorf("response of %d bytes too large for LengthFieldSize of %d bytes", len(data), f.fieldSize)
+	}
+
+	buffer := make([]byte, f.fieldSize, f.fieldSize+len(data))
+	switch f.fieldSize {
+	case 1:
+		buffer[0] = byte(len(data))
+	case 2:
+		binary.BigEndian.PutUint16(buffer, uint16(len(data)))
+	default:
+		binary.BigEndian.PutUint32(buffer, uint32(len(data)))
+	}
+
+	_, err := writer.Write(append(buffer, data...))
+	return err
+}
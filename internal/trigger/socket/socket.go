@@ -0,0 +1,453 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package socket
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap"
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ProtocolTCP = "tcp"
+	ProtocolUDP = "udp"
+
+	FramingNewline = "newline"
+	FramingLength  = "length"
+
+	defaultBaseTopic       = "socket"
+	defaultLengthFieldSize = 4
+	// defaultMaxMessageSize is the largest payload a UDP datagram can carry
+	defaultMaxMessageSize = 65507
+)
+
+// Trigger implements Trigger to support receiving raw telemetry over UDP or TCP sockets
+type Trigger struct {
+	dic            *di.Container
+	lc             logger.LoggingClient
+	runtime        *runtime.GolangRuntime
+	framer         framer
+	contentType    string
+	baseTopic      string
+	maxMessageSize int
+	idleTimeout    time.Duration
+	maxConnections int
+	sendResponse   bool
+
+	lock        sync.Mutex
+	listener    net.Listener
+	packetConn  net.PacketConn
+	connections map[net.Conn]struct{}
+	closed      bool
+}
+
+// responder sends the response data of a pipeline back to the sender of the message
+type responder func(data []byte) error
+
+func NewTrigger(dic *di.Container, runtime *runtime.GolangRuntime) *Trigger {
+	return &Trigger{
+		dic:     dic,
+		runtime: runtime,
+		lc:      bootstrapContainer.LoggingClientFrom(dic.Get),
+	}
+}
+
+// Initialize initializes the Trigger to listen for messages on the configured UDP or TCP address
+func (trigger *Trigger) Initialize(appWg *sync.WaitGroup, appCtx context.Context, background <-chan interfaces.BackgroundMessage) (bootstrap.Deferred, error) {
+	// Convenience short cuts
+	lc := trigger.lc
+	config := container.ConfigurationFrom(trigger.dic.Get)
+	socketConfig := config.Trigger.Socket
+
+	lc.Info("Initializing Socket Trigger")
+
+	if background != nil {
+		return nil, errors.New("background publishing not supported for services using Socket trigger")
+	}
+
+	if err := trigger.configure(socketConfig); err != nil {
+		return nil, err
+	}
+
+	protocol := strings.ToLower(strings.TrimSpace(socketConfig.Protocol))
+	if protocol == "" {
+		protocol = ProtocolTCP
+	}
+
+	switch protocol {
+	case ProtocolTCP:
+		listener, err := net.Listen(ProtocolTCP, socketConfig.Address)
+		if err != nil {
+			return nil, fmt.Errorf("could not listen on TCP address '%s' for Socket trigger: %s", socketConfig.Address, err.Error())
+		}
+		trigger.listener = listener
+		trigger.connections = make(map[net.Conn]struct{})
+
+		appWg.Add(1)
+		go func() {
+			defer appWg.Done()
+			trigger.acceptConnections(appWg)
+		}()
+
+		lc.Infof("Listening on TCP address '%s' for Socket trigger", listener.Addr().String())
+
+	case ProtocolUDP:
+		packetConn, err := net.ListenPacket(ProtocolUDP, socketConfig.Address)
+		if err != nil {
+			return nil, fmt.Errorf("could not listen on UDP address '%s' for Socket trigger: %s", socketConfig.Address, err.Error())
+		}
+		trigger.packetConn = packetConn
+
+		appWg.Add(1)
+		go func() {
+			defer appWg.Done()
+			trigger.receiveDatagrams()
+		}()
+
+		lc.Infof("Listening on UDP address '%s' for Socket trigger", packetConn.LocalAddr().String())
+
+	default:
+		return nil, fmt.Errorf("invalid Socket Protocol '%s', must be '%s' or '%s'", socketConfig.Protocol, ProtocolTCP, ProtocolUDP)
+	}
+
+	// Closing the listener and the connections unblocks the go routines reading from them so they exit
+	appWg.Add(1)
+	go func() {
+		defer appWg.Done()
+		<-appCtx.Done()
+		trigger.close()
+	}()
+
+	deferred := func() {
+		lc.Info("Closing Socket trigger")
+		trigger.close()
+	}
+
+	return deferred, nil
+}
+
+func (trigger *Trigger) configure(socketConfig sdkCommon.SocketConfig) error {
+	if strings.TrimSpace(socketConfig.Address) == "" {
+		return errors.New("missing Address for Socket Trigger. Must be present in [Trigger.Socket] section")
+	}
+
+	trigger.maxMessageSize = socketConfig.MaxMessageSize
+	if trigger.maxMessageSize <= 0 {
+		trigger.maxMessageSize = defaultMaxMessageSize
+	}
+
+	switch strings.ToLower(strings.TrimSpace(socketConfig.Framing)) {
+	case "", FramingNewline:
+		trigger.framer = newlineFramer{maxSize: trigger.maxMessageSize}
+	case FramingLength:
+		size := socketConfig.LengthFieldSize
+		if size == 0 {
+			size = defaultLengthFieldSize
+		}
+		if size != 1 && size != 2 && size != 4 {
+			return fmt.Errorf("invalid Socket LengthFieldSize '%d', must be 1, 2 or 4", socketConfig.LengthFieldSize)
+		}
+		trigger.framer = lengthFramer{fieldSize: size, maxSize: trigger.maxMessageSize}
+	default:
+		return fmt.Errorf("invalid Socket Framing '%s', must be '%s' or '%s'", socketConfig.Framing, FramingNewline, FramingLength)
+	}
+
+	if len(socketConfig.IdleTimeout) > 0 {
+		timeout, err := time.ParseDuration(socketConfig.IdleTimeout)
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("invalid Socket IdleTimeout '%s', must be a duration not negative", socketConfig.IdleTimeout)
+		}
+		trigger.idleTimeout = timeout
+	}
+
+	trigger.baseTopic = strings.TrimSuffix(socketConfig.BaseTopic, runtime.TopicLevelSeparator)
+	if trigger.baseTopic == "" {
+		trigger.baseTopic = defaultBaseTopic
+	}
+
+	trigger.contentType = socketConfig.ContentType
+	trigger.maxConnections = socketConfig.MaxConnections
+	trigger.sendResponse = socketConfig.SendResponse
+
+	return nil
+}
+
+// close closes the listener and any open connections. Safe to call more than once.
+func (trigger *Trigger) close() {
+	trigger.lock.Lock()
+	defer trigger.lock.Unlock()
+
+	trigger.closed = true
+
+	if trigger.listener != nil {
+		_ = trigger.listener.Close()
+	}
+
+	if trigger.packetConn != nil {
+		_ = trigger.packetConn.Close()
+	}
+
+	for conn := range trigger.connections {
+		_ = conn.Close()
+	}
+}
+
+func (trigger *Trigger) acceptConnections(appWg *sync.WaitGroup) {
+	for {
+		conn, err := trigger.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			trigger.lc.Errorf("Socket Trigger: Unable to accept TCP connection: %s", err.Error())
+			continue
+		}
+
+		if err := trigger.addConnection(conn); err != nil {
+			trigger.lc.Warnf("Socket Trigger: Refused TCP connection from %s: %s", conn.RemoteAddr().String(), err.Error())
+			_ = conn.Close()
+			continue
+		}
+
+		appWg.Add(1)
+		go func() {
+			defer appWg.Done()
+			defer trigger.removeConnection(conn)
+			trigger.serveConnection(conn)
+		}()
+	}
+}
+
+func (trigger *Trigger) addConnection(conn net.Conn) error {
+	trigger.lock.Lock()
+	defer trigger.lock.Unlock()
+
+	if trigger.closed {
+		return errors.New("trigger is closed")
+	}
+
+	if trigger.maxConnections > 0 && len(trigger.connections) >= trigger.maxConnections {
+		return fmt.Errorf("MaxConnections of %d reached", trigger.maxConnections)
+	}
+
+	trigger.connections[conn] = struct{}{}
+	return nil
+}
+
+func (trigger *Trigger) removeConnection(conn net.Conn) {
+	trigger.lock.Lock()
+	defer trigger.lock.Unlock()
+
+	delete(trigger.connections, conn)
+	_ = conn.Close()
+}
+
+// serveConnection processes the messages framed in the TCP stream until the connection is closed or times out
+func (trigger *Trigger) serveConnection(conn net.Conn) {
+	// Convenience short cuts
+	lc := trigger.lc
+	remoteAddr := conn.RemoteAddr()
+
+	lc.Debugf("Socket Trigger: Accepted TCP connection from %s", remoteAddr.String())
+
+	// Responses from concurrently executing pipelines must not be interleaved in the stream
+	writeLock := sync.Mutex{}
+	respond := func(data []byte) error {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		return trigger.framer.writeMessage(conn, data)
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		if trigger.idleTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(trigger.idleTimeout))
+		}
+
+		payload, err := trigger.framer.readMessage(reader)
+		if err != nil {
+			var netErr net.Error
+			switch {
+			case errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed):
+				lc.Debugf("Socket Trigger: TCP connection from %s closed", remoteAddr.String())
+			case errors.As(err, &netErr) && netErr.Timeout():
+				lc.Infof("Socket Trigger: Closing TCP connection from %s, idle for %s", remoteAddr.String(), trigger.idleTimeout.String())
+			default:
+				lc.Errorf("Socket Trigger: Closing TCP connection from %s: %s", remoteAddr.String(), err.Error())
+			}
+			return
+		}
+
+		if len(payload) == 0 {
+			continue
+		}
+
+		trigger.processMessage(payload, remoteAddr, respond)
+	}
+}
+
+// receiveDatagrams processes each UDP datagram received as a message until the socket is closed
+func (trigger *Trigger) receiveDatagrams() {
+	// One extra byte so datagrams larger than MaxMessageSize are detected
+	buffer := make([]byte, trigger.maxMessageSize+1)
+
+	for {
+		count, remoteAddr, err := trigger.packetConn.ReadFrom(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+
+			trigger.lc.Errorf("Socket Trigger: Unable to receive UDP datagram: %s", err.Error())
+			continue
+		}
+
+		if count > trigger.maxMessageSize {
+			trigger.lc.Warnf("Socket Trigger: Dropped UDP datagram from %s larger than MaxMessageSize of %d bytes",
+				remoteAddr.String(), trigger.maxMessageSize)
+			continue
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		payload := make([]byte, count)
+		copy(payload, buffer[:count])
+
+		trigger.processMessage(payload, remoteAddr, func(data []byte) error {
+			_, err := trigger.packetConn.WriteTo(data, remoteAddr)
+			return err
+		})
+	}
+}
+
+func (trigger *Trigger) processMessage(payload []byte, remoteAddr net.Addr, respond responder) {
+	// Convenience short cuts
+	lc := trigger.lc
+
+	envelope := trigger.toEnvelope(payload, remoteAddr)
+
+	lc.Debugf("Socket Trigger: Received message with %d bytes from %s. Content-Type=%s",
+		len(envelope.Payload),
+		remoteAddr.String(),
+		envelope.ContentType)
+	lc.Tracef("%s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	done, duplicate := trigger.runtime.BeginMessage(envelope)
+	if duplicate {
+		return
+	}
+
+	pipelines := trigger.runtime.RoutePipelines(envelope)
+	lc.Debugf("Socket Trigger found %d pipeline(s) that match the incoming topic '%s'", len(pipelines), envelope.ReceivedTopic)
+
+	pipelinesWaitGroup := &sync.WaitGroup{}
+	var failed int32
+
+	for _, pipeline := range pipelines {
+		p := pipeline
+		pipelinesWaitGroup.Add(1)
+		scheduled := trigger.runtime.ScheduleExecution(envelope, func() {
+			defer pipelinesWaitGroup.Done()
+			if !trigger.processMessageWithPipeline(envelope, p, respond) {
+				atomic.StoreInt32(&failed, 1)
+			}
+		})
+
+		if !scheduled {
+			pipelinesWaitGroup.Done()
+			atomic.StoreInt32(&failed, 1)
+		}
+	}
+
+	go func() {
+		pipelinesWaitGroup.Wait()
+		done(atomic.LoadInt32(&failed) == 0)
+	}()
+}
+
+// toEnvelope builds the message envelope with the sender's IP address appended to the BaseTopic as the received topic
+func (trigger *Trigger) toEnvelope(payload []byte, remoteAddr net.Addr) types.MessageEnvelope {
+	contentType := trigger.contentType
+	if contentType == "" {
+		contentType = codec.DetectContentType(payload)
+	}
+
+	host := remoteAddr.String()
+	if address, _, err := net.SplitHostPort(host); err == nil {
+		host = address
+	}
+
+	return types.MessageEnvelope{
+		CorrelationID: uuid.New().String(),
+		ContentType:   contentType,
+		Payload:       payload,
+		ReceivedTopic: strings.Join([]string{trigger.baseTopic, host}, runtime.TopicLevelSeparator),
+	}
+}
+
+// processMessageWithPipeline executes the pipeline and sends any response data back to the sender when SendResponse
+// is set. Returns false if either failed.
+func (trigger *Trigger) processMessageWithPipeline(envelope types.MessageEnvelope, pipeline *interfaces.FunctionPipeline, respond responder) bool {
+	appContext := appfunction.NewContext(envelope.CorrelationID, trigger.dic, envelope.ContentType)
+
+	messageError := trigger.runtime.ProcessMessage(appContext, envelope, pipeline)
+	if messageError != nil {
+		// ProcessMessage logs the error, so no need to log it here.
+		return false
+	}
+
+	if len(appContext.ResponseData()) == 0 || !trigger.sendResponse {
+		return true
+	}
+
+	if err := respond(appContext.ResponseData()); err != nil {
+		trigger.lc.Errorf("Socket Trigger: Could not send response for pipeline '%s': %s", pipeline.Id, err.Error())
+		return false
+	}
+
+	trigger.lc.Debugf("Socket Trigger: Sent response for pipeline '%s' with %d bytes", pipeline.Id, len(appContext.ResponseData()))
+	trigger.lc.Tracef("Socket Trigger sent response: %s=%s", common.CorrelationHeader, envelope.CorrelationID)
+
+	return true
+}
@@ -0,0 +1,321 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/runtime"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dic *di.Container
+
+func TestMain(m *testing.M) {
+	dic = di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+	})
+	os.Exit(m.Run())
+}
+
+func newTestDic(socketConfig sdkCommon.SocketConfig) *di.Container {
+	config := &sdkCommon.ConfigurationStruct{
+		Trigger: sdkCommon.TriggerInfo{
+			Socket: socketConfig,
+		},
+		Writable: sdkCommon.WritableInfo{
+			Pipeline: sdkCommon.PipelineInfo{UseTargetTypeOfByteArray: true},
+		},
+	}
+
+	return di.NewContainer(di.ServiceConstructorMap{
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return logger.NewMockClient()
+		},
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return config
+		},
+	})
+}
+
+// startTrigger starts the trigger with a pipeline, matching all messages, that responds with the data received in
+// upper case and sends the data received on the returned channel
+func startTrigger(t *testing.T, socketConfig sdkCommon.SocketConfig) (*Trigger, <-chan string, func()) {
+	received := make(chan string, 10)
+
+	transform := func(appContext interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		payload := data.([]byte)
+		received <- string(payload)
+		appContext.SetResponseData(bytes.ToUpper(payload))
+		return false, nil
+	}
+
+	testDic := newTestDic(socketConfig)
+	goRuntime := runtime.NewGolangRuntime("", &[]byte{}, testDic)
+	err := goRuntime.AddFunctionsPipeline("socket", []string{"socket/#"}, []interfaces.AppFunction{transform})
+	require.NoError(t, err)
+
+	trigger := NewTrigger(testDic, goRuntime)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	deferred, err := trigger.Initialize(wg, ctx, nil)
+	require.NoError(t, err)
+
+	stop := func() {
+		cancel()
+		wg.Wait()
+		deferred()
+	}
+
+	return trigger, received, stop
+}
+
+func TestInitializeInvalidConfig(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Config        sdkCommon.SocketConfig
+		ExpectedError string
+	}{
+		{"Missing Address", sdkCommon.SocketConfig{}, "missing Address"},
+		{"Invalid Protocol", sdkCommon.SocketConfig{Address: "127.0.0.1:0", Protocol: "sctp"}, "invalid Socket Protocol"},
+		{"Invalid Framing", sdkCommon.SocketConfig{Address: "127.0.0.1:0", Framing: "stx"}, "invalid Socket Framing"},
+		{"Invalid LengthFieldSize", sdkCommon.SocketConfig{Address: "127.0.0.1:0", Framing: FramingLength, LengthFieldSize: 3}, "invalid Socket LengthFieldSize"},
+		{"Invalid IdleTimeout", sdkCommon.SocketConfig{Address: "127.0.0.1:0", IdleTimeout: "soon"}, "invalid Socket IdleTimeout"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			trigger := NewTrigger(newTestDic(test.Config), &runtime.GolangRuntime{})
+			_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestInitializeBackgroundNotSupported(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+	_, err := trigger.Initialize(&sync.WaitGroup{}, context.Background(), make(chan interfaces.BackgroundMessage))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "background publishing not supported")
+}
+
+func TestTCPNewlineFraming(t *testing.T) {
+	trigger, received, stop := startTrigger(t, sdkCommon.SocketConfig{
+		Address:      "127.0.0.1:0",
+		SendResponse: true,
+	})
+	defer stop()
+
+	conn, err := net.Dial(ProtocolTCP, trigger.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("temp=21.5\r\n\nhumidity=40\n"))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"temp=21.5", "humidity=40"}, receiveAll(t, received, 2))
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	reader := bufio.NewReader(conn)
+	responses := make([]string, 0, 2)
+	for i := 0; i < 2; i++ {
+		response, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		responses = append(responses, response)
+	}
+	assert.ElementsMatch(t, []string{"TEMP=21.5\n", "HUMIDITY=40\n"}, responses)
+}
+
+func TestTCPLengthFraming(t *testing.T) {
+	trigger, received, stop := startTrigger(t, sdkCommon.SocketConfig{
+		Address:         "127.0.0.1:0",
+		Framing:         FramingLength,
+		LengthFieldSize: 2,
+		SendResponse:    true,
+	})
+	defer stop()
+
+	conn, err := net.Dial(ProtocolTCP, trigger.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte{0x00, 0x04, 'a', '\n', 'b', 'c'})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a\nbc"}, receiveAll(t, received, 1))
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	response := make([]byte, 6)
+	_, err = bufio.NewReader(conn).Read(response)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x04, 'A', '\n', 'B', 'C'}, response)
+}
+
+func TestTCPMaxConnections(t *testing.T) {
+	trigger, received, stop := startTrigger(t, sdkCommon.SocketConfig{
+		Address:        "127.0.0.1:0",
+		MaxConnections: 1,
+	})
+	defer stop()
+
+	first, err := net.Dial(ProtocolTCP, trigger.listener.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+
+	_, err = first.Write([]byte("first\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, receiveAll(t, received, 1))
+
+	second, err := net.Dial(ProtocolTCP, trigger.listener.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+
+	// The refused connection is closed by the trigger
+	_ = second.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, err = second.Read(make([]byte, 1))
+	require.Error(t, err)
+}
+
+func TestUDP(t *testing.T) {
+	trigger, received, stop := startTrigger(t, sdkCommon.SocketConfig{
+		Protocol:       ProtocolUDP,
+		Address:        "127.0.0.1:0",
+		MaxMessageSize: 8,
+		SendResponse:   true,
+	})
+	defer stop()
+
+	conn, err := net.Dial(ProtocolUDP, trigger.packetConn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Larger than MaxMessageSize so dropped
+	_, err = conn.Write([]byte("too large"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("a=1\nb=2"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"a=1\nb=2"}, receiveAll(t, received, 1))
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	response := make([]byte, 16)
+	count, err := conn.Read(response)
+	require.NoError(t, err)
+	assert.Equal(t, "A=1\nB=2", string(response[:count]))
+}
+
+func TestToEnvelope(t *testing.T) {
+	trigger := NewTrigger(dic, &runtime.GolangRuntime{})
+	require.NoError(t, trigger.configure(sdkCommon.SocketConfig{Address: ":5000", BaseTopic: "plant/line1/"}))
+
+	envelope := trigger.toEnvelope([]byte(`{}`), &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 40000})
+	assert.Equal(t, "plant/line1/192.168.1.10", envelope.ReceivedTopic)
+	assert.Equal(t, "application/json", envelope.ContentType)
+	assert.NotEmpty(t, envelope.CorrelationID)
+
+	trigger.contentType = "text/plain"
+	envelope = trigger.toEnvelope([]byte(`{}`), &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40000})
+	assert.Equal(t, "plant/line1/10.0.0.1", envelope.ReceivedTopic)
+	assert.Equal(t, "text/plain", envelope.ContentType)
+}
+
+func TestFramers(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Framer   framer
+		Stream   []byte
+		Expected []string
+		Error    bool
+	}{
+		{"Newline", newlineFramer{maxSize: 16}, []byte("one\r\ntwo\nthree"), []string{"one", "two", "three"}, false},
+		{"Newline too large", newlineFramer{maxSize: 4}, []byte("12345\n"), nil, true},
+		{"Length 1 byte", lengthFramer{fieldSize: 1, maxSize: 16}, []byte{2, 'h', 'i', 0}, []string{"hi", ""}, false},
+		{"Length 4 bytes", lengthFramer{fieldSize: 4, maxSize: 16}, []byte{0, 0, 0, 3, 'a', 'b', 'c'}, []string{"abc"}, false},
+		{"Length too large", lengthFramer{fieldSize: 2, maxSize: 16}, []byte{0, 17}, nil, true},
+		{"Length truncated", lengthFramer{fieldSize: 2, maxSize: 16}, []byte{0, 3, 'a'}, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			reader := bufio.NewReader(bytes.NewReader(test.Stream))
+
+			var messages []string
+			var err error
+			for {
+				var message []byte
+				message, err = test.Framer.readMessage(reader)
+				if err != nil {
+					break
+				}
+				messages = append(messages, string(message))
+			}
+
+			assert.Equal(t, test.Expected, messages)
+			if test.Error {
+				assert.NotEqual(t, "EOF", err.Error())
+			} else {
+				assert.Equal(t, "EOF", err.Error())
+			}
+		})
+	}
+}
+
+func TestLengthFramerWriteTooLarge(t *testing.T) {
+	var buffer bytes.Buffer
+	err := lengthFramer{fieldSize: 1}.writeMessage(&buffer, make([]byte, 256))
+	require.Error(t, err)
+
+	err = lengthFramer{fieldSize: 1}.writeMessage(&buffer, make([]byte, 255))
+	require.NoError(t, err)
+	assert.Equal(t, 256, buffer.Len())
+}
+
+func receiveAll(t *testing.T, received <-chan string, count int) []string {
+	var result []string
+	for i := 0; i < count; i++ {
+		select {
+		case data := <-received:
+			result = append(result, data)
+		case <-time.After(3 * time.Second):
+			require.Fail(t, "pipeline not executed")
+		}
+	}
+	return result
+}
@@ -0,0 +1,284 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+const DefaultExecutionTimeout = time.Minute
+
+// StalledExecution is a pipeline execution running longer than the ExecutionTimeout
+type StalledExecution struct {
+	PipelineId    string `json:"pipelineId"`
+	CorrelationId string `json:"correlationId"`
+	// Function is the index of the pipeline function executing
+	Function int `json:"function"`
+	// Started is the time, in nanoseconds since the epoch, the execution started
+	Started int64 `json:"started"`
+}
+
+// Report contains the counts of the stalled executions and the executions currently stalled
+type Report struct {
+	// StalledTotal is the number of executions that have stalled since the service started
+	StalledTotal uint64 `json:"stalledTotal"`
+	// TriggerRestarts is the number of times the trigger was restarted due to stalled executions
+	TriggerRestarts uint64             `json:"triggerRestarts"`
+	Stalled         []StalledExecution `json:"stalled"`
+}
+
+// Watchdog watches the pipeline executions and logs the stack of those that run longer than the ExecutionTimeout, so
+// executions that hang without an error are detected. Optionally the trigger is restarted when executions stall.
+type Watchdog struct {
+	lock            sync.Mutex
+	timeout         time.Duration
+	restartTrigger  func() error
+	lc              logger.LoggingClient
+	nextId          uint64
+	executions      map[uint64]*Execution
+	stalledTotal    uint64
+	triggerRestarts uint64
+	lastRestart     time.Time
+}
+
+// Execution is a pipeline execution being watched
+type Execution struct {
+	watchdog      *Watchdog
+	id            uint64
+	goroutineId   string
+	pipelineId    string
+	correlationId string
+	started       time.Time
+	function      int32
+	stalled       bool
+}
+
+// NewWatchdog creates, initializes and returns a new instance of Watchdog for the configuration. restartTrigger is
+// called to restart the trigger when executions stall and RestartTrigger is enabled.
+func NewWatchdog(config sdkCommon.WatchdogInfo, restartTrigger func() error, lc logger.LoggingClient) (*Watchdog, error) {
+	timeout := DefaultExecutionTimeout
+	if strings.TrimSpace(config.ExecutionTimeout) != "" {
+		var err error
+		timeout, err = time.ParseDuration(strings.TrimSpace(config.ExecutionTimeout))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid Watchdog ExecutionTimeout '%s', must be a duration greater than 0", config.ExecutionTimeout)
+		}
+	}
+
+	watchdog := &Watchdog{
+		timeout:    timeout,
+		lc:         lc,
+		executions: make(map[uint64]*Execution),
+	}
+
+	if config.RestartTrigger {
+		watchdog.restartTrigger = restartTrigger
+	}
+
+	return watchdog, nil
+}
+
+// Start checks the executions for those stalled every half of the ExecutionTimeout until the context is cancelled
+func (watchdog *Watchdog) Start(wg *sync.WaitGroup, ctx context.Context) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(watchdog.timeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				watchdog.check(now)
+			}
+		}
+	}()
+}
+
+// Begin starts watching the execution of the pipeline running on the calling go routine
+func (watchdog *Watchdog) Begin(pipelineId string, correlationId string) *Execution {
+	execution := &Execution{
+		watchdog:      watchdog,
+		goroutineId:   currentGoroutineId(),
+		pipelineId:    pipelineId,
+		correlationId: correlationId,
+		started:       time.Now(),
+	}
+
+	watchdog.lock.Lock()
+	defer watchdog.lock.Unlock()
+
+	watchdog.nextId++
+	execution.id = watchdog.nextId
+	watchdog.executions[execution.id] = execution
+
+	return execution
+}
+
+// Function records the index of the pipeline function the execution is about to call. Does nothing when the
+// execution is nil, so the runtime doesn't need to check whether the watchdog is enabled.
+func (execution *Execution) Function(index int) {
+	if execution == nil {
+		return
+	}
+
+	atomic.StoreInt32(&execution.function, int32(index))
+}
+
+// End stops watching the execution, which has completed. Does nothing when the execution is nil.
+func (execution *Execution) End() {
+	if execution == nil {
+		return
+	}
+
+	watchdog := execution.watchdog
+
+	watchdog.lock.Lock()
+	delete(watchdog.executions, execution.id)
+	stalled := execution.stalled
+	watchdog.lock.Unlock()
+
+	if stalled {
+		watchdog.lc.Infof("Stalled execution of pipeline '%s' completed after %s (%s=%s)", execution.pipelineId,
+			time.Since(execution.started).String(), common.CorrelationHeader, execution.correlationId)
+	}
+}
+
+// Report returns the counts of the stalled executions and the executions currently stalled, oldest first
+func (watchdog *Watchdog) Report() Report {
+	watchdog.lock.Lock()
+	defer watchdog.lock.Unlock()
+
+	report := Report{
+		StalledTotal:    watchdog.stalledTotal,
+		TriggerRestarts: watchdog.triggerRestarts,
+		Stalled:         []StalledExecution{},
+	}
+
+	for _, execution := range watchdog.executions {
+		if execution.stalled {
+			report.Stalled = append(report.Stalled, execution.stalledExecution())
+		}
+	}
+
+	sort.Slice(report.Stalled, func(i, j int) bool {
+		return report.Stalled[i].Started < report.Stalled[j].Started
+	})
+
+	return report
+}
+
+// check logs the stack of each execution that has stalled since the last check and restarts the trigger if enabled
+func (watchdog *Watchdog) check(now time.Time) {
+	var stalled []*Execution
+
+	watchdog.lock.Lock()
+	for _, execution := range watchdog.executions {
+		if !execution.stalled && now.Sub(execution.started) > watchdog.timeout {
+			execution.stalled = true
+			watchdog.stalledTotal++
+			stalled = append(stalled, execution)
+		}
+	}
+
+	restart := len(stalled) > 0 && watchdog.restartTrigger != nil && now.Sub(watchdog.lastRestart) >= watchdog.timeout
+	if restart {
+		watchdog.triggerRestarts++
+		watchdog.lastRestart = now
+	}
+	watchdog.lock.Unlock()
+
+	if len(stalled) == 0 {
+		return
+	}
+
+	stacks := allStacks()
+	for _, execution := range stalled {
+		details := execution.stalledExecution()
+		watchdog.lc.Errorf("Execution of pipeline '%s' stalled in function #%d, running for %s (%s=%s):\n%s",
+			details.PipelineId, details.Function, now.Sub(execution.started).String(), common.CorrelationHeader,
+			details.CorrelationId, goroutineStack(stacks, execution.goroutineId))
+	}
+
+	if restart {
+		watchdog.lc.Warnf("Restarting the trigger due to %d stalled pipeline execution(s)", len(stalled))
+		if err := watchdog.restartTrigger(); err != nil {
+			watchdog.lc.Errorf("Unable to restart the trigger: %s", err.Error())
+		}
+	}
+}
+
+func (execution *Execution) stalledExecution() StalledExecution {
+	return StalledExecution{
+		PipelineId:    execution.pipelineId,
+		CorrelationId: execution.correlationId,
+		Function:      int(atomic.LoadInt32(&execution.function)),
+		Started:       execution.started.UnixNano(),
+	}
+}
+
+// currentGoroutineId returns the id of the calling go routine from the first line of its stack,
+// i.e. "goroutine 18 [running]:"
+func currentGoroutineId() string {
+	buffer := make([]byte, 64)
+	buffer = buffer[:runtime.Stack(buffer, false)]
+
+	fields := bytes.Fields(buffer)
+	if len(fields) < 2 {
+		return ""
+	}
+
+	return string(fields[1])
+}
+
+// allStacks returns the stacks of all go routines, growing the buffer until they fit
+func allStacks() string {
+	for size := 1 << 16; ; size *= 2 {
+		buffer := make([]byte, size)
+		if length := runtime.Stack(buffer, true); length < size {
+			return string(buffer[:length])
+		}
+	}
+}
+
+// goroutineStack returns the stack of the go routine from the stacks of all go routines, which are separated by blank lines
+func goroutineStack(stacks string, goroutineId string) string {
+	prefix := "goroutine " + goroutineId + " ["
+	for _, stack := range strings.Split(stacks, "\n\n") {
+		if strings.HasPrefix(stack, prefix) {
+			return stack
+		}
+	}
+
+	return "stack not found"
+}
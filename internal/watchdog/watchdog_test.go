@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package watchdog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+var lc = logger.NewMockClient()
+
+func TestNewWatchdog(t *testing.T) {
+	restart := func() error { return nil }
+
+	watchdog, err := NewWatchdog(common.WatchdogInfo{}, restart, lc)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultExecutionTimeout, watchdog.timeout)
+	assert.Nil(t, watchdog.restartTrigger, "trigger should not be restarted unless RestartTrigger is enabled")
+
+	watchdog, err = NewWatchdog(common.WatchdogInfo{ExecutionTimeout: " 30s ", RestartTrigger: true}, restart, lc)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, watchdog.timeout)
+	assert.NotNil(t, watchdog.restartTrigger)
+
+	_, err = NewWatchdog(common.WatchdogInfo{ExecutionTimeout: "soon"}, restart, lc)
+	assert.Error(t, err)
+
+	_, err = NewWatchdog(common.WatchdogInfo{ExecutionTimeout: "0s"}, restart, lc)
+	assert.Error(t, err)
+}
+
+func TestWatchdog_Check(t *testing.T) {
+	restarts := 0
+	watchdog, err := NewWatchdog(common.WatchdogInfo{ExecutionTimeout: "1m", RestartTrigger: true}, func() error {
+		restarts++
+		return errors.New("unavailable")
+	}, lc)
+	require.NoError(t, err)
+
+	started := time.Now()
+	stalled := watchdog.Begin("default-pipeline", "1")
+	stalled.started = started
+	stalled.Function(2)
+	completed := watchdog.Begin("default-pipeline", "2")
+	completed.End()
+	running := watchdog.Begin("other-pipeline", "3")
+	running.started = started.Add(time.Minute)
+
+	now := started.Add(90 * time.Second)
+	watchdog.check(now)
+
+	report := watchdog.Report()
+	assert.Equal(t, uint64(1), report.StalledTotal)
+	assert.Equal(t, uint64(1), report.TriggerRestarts)
+	assert.Equal(t, 1, restarts)
+	require.Len(t, report.Stalled, 1)
+	assert.Equal(t, StalledExecution{
+		PipelineId:    "default-pipeline",
+		CorrelationId: "1",
+		Function:      2,
+		Started:       started.UnixNano(),
+	}, report.Stalled[0])
+
+	// A stalled execution is only counted once and the trigger isn't restarted again within the timeout
+	watchdog.check(now.Add(30 * time.Second))
+	report = watchdog.Report()
+	assert.Equal(t, uint64(1), report.StalledTotal)
+	assert.Equal(t, 1, restarts)
+
+	watchdog.check(now.Add(time.Minute))
+	report = watchdog.Report()
+	assert.Equal(t, uint64(2), report.StalledTotal)
+	assert.Equal(t, uint64(2), report.TriggerRestarts)
+	assert.Equal(t, 2, restarts)
+	assert.Len(t, report.Stalled, 2)
+
+	stalled.End()
+	running.End()
+	report = watchdog.Report()
+	assert.Equal(t, uint64(2), report.StalledTotal, "total should include the stalled executions that completed")
+	assert.Empty(t, report.Stalled)
+}
+
+func TestExecution_Nil(t *testing.T) {
+	var execution *Execution
+
+	assert.NotPanics(t, func() {
+		execution.Function(1)
+		execution.End()
+	})
+}
+
+func TestGoroutineStack(t *testing.T) {
+	blocked := make(chan struct{})
+	goroutineId := make(chan string)
+	go func() {
+		goroutineId <- currentGoroutineId()
+		<-blocked
+	}()
+	defer close(blocked)
+
+	id := <-goroutineId
+	require.NotEmpty(t, id)
+	assert.NotEqual(t, currentGoroutineId(), id)
+
+	stack := goroutineStack(allStacks(), id)
+	assert.True(t, strings.HasPrefix(stack, "goroutine "+id+" ["), stack)
+	assert.Contains(t, stack, "TestGoroutineStack")
+
+	assert.Equal(t, "stack not found", goroutineStack(allStacks(), "0"))
+}
@@ -19,6 +19,7 @@ package webserver
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/handlers"
@@ -81,9 +82,40 @@ func (webserver *WebServer) ConfigureStandardRoutes() {
 
 	router.HandleFunc(common.ApiPingRoute, controller.Ping).Methods(http.MethodGet)
 	router.HandleFunc(common.ApiVersionRoute, controller.Version).Methods(http.MethodGet)
+	router.HandleFunc(internal.ApiHealthRoute, controller.Health).Methods(http.MethodGet)
 	router.HandleFunc(common.ApiMetricsRoute, controller.Metrics).Methods(http.MethodGet)
 	router.HandleFunc(common.ApiConfigRoute, controller.Config).Methods(http.MethodGet)
 	router.HandleFunc(internal.ApiAddSecretRoute, controller.AddSecret).Methods(http.MethodPost)
+	router.HandleFunc(internal.ApiCaptureRoute, controller.CaptureSamples).Methods(http.MethodGet)
+	router.HandleFunc(internal.ApiCaptureRoute, controller.ClearCaptureSamples).Methods(http.MethodDelete)
+
+	if webserver.config.LastValueCache.Enabled {
+		router.HandleFunc(internal.ApiLastValueCacheRoute, controller.LastValues).Methods(http.MethodGet)
+	}
+
+	if webserver.config.Tenancy.Enabled {
+		router.HandleFunc(internal.ApiTenantsRoute, controller.Tenants).Methods(http.MethodGet)
+	}
+
+	if webserver.config.DeliveryReceipts.Enabled {
+		router.HandleFunc(internal.ApiReceiptsRoute, controller.DeliveryReceipts).Methods(http.MethodGet)
+	}
+
+	if webserver.config.Trigger.Watchdog.Enabled {
+		router.HandleFunc(internal.ApiWatchdogRoute, controller.Watchdog).Methods(http.MethodGet)
+	}
+
+	if webserver.config.CostAccounting.Enabled {
+		router.HandleFunc(internal.ApiStatsRoute, controller.Stats).Methods(http.MethodGet)
+	}
+
+	if webserver.config.MetricsHistory.Enabled {
+		router.HandleFunc(internal.ApiHistoryRoute, controller.History).Methods(http.MethodGet)
+	}
+
+	if webserver.config.DeviceMonitor.Enabled {
+		router.HandleFunc(internal.ApiDevicesRoute, controller.Devices).Methods(http.MethodGet)
+	}
 
 	router.Use(handlers.ProcessCORS(webserver.config.Service.CORSConfiguration))
 
@@ -101,6 +133,26 @@ func (webserver *WebServer) SetupTriggerRoute(path string, handlerForTrigger fun
 	webserver.router.HandleFunc(path, handlerForTrigger)
 }
 
+// SetupReplayRoute adds the route to replay historical Events from Core Data through the function pipeline(s). The
+// replays running are added to the wait group.
+func (webserver *WebServer) SetupReplayRoute(replayEvents rest.ReplayFunc, appWg *sync.WaitGroup) {
+	webserver.router.HandleFunc(internal.ApiReplayRoute, webserver.controller.Replay(replayEvents, appWg)).Methods(http.MethodPost)
+}
+
+// SetupPreviewRoute adds the route to preview the result of a pipeline function on a payload, when enabled
+func (webserver *WebServer) SetupPreviewRoute(preview rest.PreviewFunc) {
+	if webserver.config.Preview.Enabled {
+		webserver.router.HandleFunc(internal.ApiPreviewRoute, webserver.controller.Preview(preview)).Methods(http.MethodPost)
+	}
+}
+
+// SetupDebugRoute adds the WebSocket route to step a payload through a pipeline one function at a time, when enabled
+func (webserver *WebServer) SetupDebugRoute(start rest.DebugFunc) {
+	if webserver.config.Debug.Enabled {
+		webserver.router.HandleFunc(internal.ApiDebugRoute, webserver.controller.Debug(start)).Methods(http.MethodGet)
+	}
+}
+
 // StartWebServer starts the web server
 func (webserver *WebServer) StartWebServer(errChannel chan error) {
 	go func() {
@@ -125,6 +177,7 @@ func (webserver *WebServer) listenAndServe(serviceTimeout time.Duration, errChan
 		bindAddress = config.Service.ServerBindAddr
 	}
 	addr := fmt.Sprintf("%s:%d", bindAddress, config.Service.Port)
+	handler := webserver.timeoutHandler(serviceTimeout)
 
 	if config.HttpServer.Protocol == "https" {
 		provider := bootstrapContainer.SecretProviderFrom(webserver.dic.Get)
@@ -151,9 +204,26 @@ func (webserver *WebServer) listenAndServe(serviceTimeout time.Duration, errChan
 
 		lc.Infof("Starting HTTPS Web Server on address %s", addr)
 
-		errChannel <- http.ListenAndServeTLS(addr, httpsCert, httpsKey, http.TimeoutHandler(webserver.router, serviceTimeout, "Request timed out"))
+		errChannel <- http.ListenAndServeTLS(addr, httpsCert, httpsKey, handler)
 	} else {
 		lc.Infof("Starting HTTP Web Server on address %s", addr)
-		errChannel <- http.ListenAndServe(addr, http.TimeoutHandler(webserver.router, serviceTimeout, "Request timed out"))
+		errChannel <- http.ListenAndServe(addr, handler)
+	}
+}
+
+// timeoutHandler returns the router with the requests timing out after the service timeout, except the debug
+// WebSocket, which is long-lived and can't be hijacked through a TimeoutHandler
+func (webserver *WebServer) timeoutHandler(serviceTimeout time.Duration) http.Handler {
+	timeoutHandler := http.TimeoutHandler(webserver.router, serviceTimeout, "Request timed out")
+	if !webserver.config.Debug.Enabled {
+		return timeoutHandler
 	}
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == internal.ApiDebugRoute {
+			webserver.router.ServeHTTP(writer, request)
+			return
+		}
+		timeoutHandler.ServeHTTP(writer, request)
+	})
 }
@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Package codec is the registry of the codecs, by content type, used to decode the payloads received by the triggers
+// and to encode the data exported and returned as response data, so a proprietary encoding registered once is
+// supported throughout the SDK. Codecs for JSON and CBOR are registered by default.
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Codec marshals data to, and unmarshals data from, the encoding of a content type
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Detector is implemented by the codecs that can recognize data in their encoding, so the content type of payloads
+// received without one, i.e. by the external MQTT trigger, is detected
+type Detector interface {
+	Detect(data []byte) bool
+}
+
+type registration struct {
+	contentType string
+	codec       Codec
+}
+
+var (
+	lock sync.RWMutex
+	// registrations are in the order registered, which is the order the codecs' Detect are tried
+	registrations []registration
+)
+
+func init() {
+	_ = Register(common.ContentTypeJSON, jsonCodec{})
+	_ = Register(common.ContentTypeCBOR, cborCodec{})
+}
+
+// Register registers the codec for the content type, replacing any codec registered for it before. The content type's
+// parameters, i.e. "; charset=utf-8", are ignored. Codecs should be registered before the service is started.
+func Register(contentType string, codec Codec) error {
+	mediaType := normalize(contentType)
+	if mediaType == "" {
+		return errors.New("content type must be specified to register a codec")
+	}
+
+	if codec == nil {
+		return fmt.Errorf("codec for content type '%s' must not be nil", contentType)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	for index, item := range registrations {
+		if item.contentType == mediaType {
+			registrations[index].codec = codec
+			return nil
+		}
+	}
+
+	// Codecs registered later are tried first, so they can recognize data that would otherwise be detected as JSON
+	registrations = append([]registration{{contentType: mediaType, codec: codec}}, registrations...)
+	return nil
+}
+
+// Lookup returns the codec registered for the content type, ignoring its parameters
+func Lookup(contentType string) (Codec, bool) {
+	mediaType := normalize(contentType)
+
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, item := range registrations {
+		if item.contentType == mediaType {
+			return item.codec, true
+		}
+	}
+
+	return nil, false
+}
+
+// Marshal encodes the value with the codec registered for the content type
+func Marshal(contentType string, v interface{}) ([]byte, error) {
+	codec, found := Lookup(contentType)
+	if !found {
+		return nil, fmt.Errorf("unsupported content-type '%s', no codec registered", contentType)
+	}
+
+	return codec.Marshal(v)
+}
+
+// Unmarshal decodes the data into the value with the codec registered for the content type
+func Unmarshal(contentType string, data []byte, v interface{}) error {
+	codec, found := Lookup(contentType)
+	if !found {
+		return fmt.Errorf("unsupported content-type '%s' recieved", contentType)
+	}
+
+	return codec.Unmarshal(data, v)
+}
+
+// DetectContentType returns the content type of the first registered codec that recognizes the data, trying the codecs
+// registered last first. Data not recognized is assumed to be CBOR, as EdgeX only sends JSON and CBOR.
+func DetectContentType(data []byte) string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, item := range registrations {
+		if detector, ok := item.codec.(Detector); ok && detector.Detect(data) {
+			return item.contentType
+		}
+	}
+
+	return common.ContentTypeCBOR
+}
+
+// normalize returns the content type without its parameters, in lower case
+func normalize(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Detect returns true for data that starts as a JSON object or array, or is empty
+func (jsonCodec) Detect(data []byte) bool {
+	return len(data) == 0 || data[0] == byte('{') || data[0] == byte('[')
+}
+
+type cborCodec struct{}
+
+func (cborCodec) Marshal(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (cborCodec) Unmarshal(data []byte, v interface{}) error {
+	return cbor.Unmarshal(data, v)
+}
@@ -0,0 +1,134 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reverseCodec is a proprietary encoding, JSON with the bytes reversed, prefixed with '!' so it can be detected
+type reverseCodec struct{}
+
+func (reverseCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := jsonCodec{}.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{'!'}, reverse(data)...), nil
+}
+
+func (reverseCodec) Unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != '!' {
+		return errors.New("not reversed")
+	}
+	return jsonCodec{}.Unmarshal(reverse(data[1:]), v)
+}
+
+func (reverseCodec) Detect(data []byte) bool {
+	return len(data) > 0 && data[0] == '!'
+}
+
+func reverse(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for index, value := range data {
+		reversed[len(data)-1-index] = value
+	}
+	return reversed
+}
+
+func TestRegister(t *testing.T) {
+	const contentType = "application/x-reversed"
+	t.Cleanup(func() { unregister(contentType) })
+
+	require.Error(t, Register(" ; charset=utf-8", reverseCodec{}))
+	require.Error(t, Register(contentType, nil))
+
+	_, found := Lookup(contentType)
+	require.False(t, found)
+
+	require.NoError(t, Register(contentType, reverseCodec{}))
+	registered, found := Lookup("Application/X-Reversed; version=2")
+	require.True(t, found, "content type parameters and case should be ignored")
+	assert.Equal(t, reverseCodec{}, registered)
+
+	data, err := Marshal(contentType, map[string]string{"device": "thermostat"})
+	require.NoError(t, err)
+	assert.Equal(t, `!}"tatsomreht":"ecived"{`, string(data))
+
+	var decoded map[string]string
+	require.NoError(t, Unmarshal(contentType, data, &decoded))
+	assert.Equal(t, "thermostat", decoded["device"])
+
+	assert.Equal(t, contentType, DetectContentType(data))
+
+	// Registering again replaces the codec
+	require.NoError(t, Register(contentType, jsonCodec{}))
+	registered, _ = Lookup(contentType)
+	assert.Equal(t, jsonCodec{}, registered)
+}
+
+func TestUnsupportedContentType(t *testing.T) {
+	_, err := Marshal("application/unknown", "data")
+	require.Error(t, err)
+
+	var decoded interface{}
+	err = Unmarshal("application/unknown", []byte("data"), &decoded)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported content-type 'application/unknown'")
+}
+
+func TestDetectContentType(t *testing.T) {
+	cborData, err := Marshal(common.ContentTypeCBOR, map[string]string{"device": "thermostat"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name     string
+		Data     []byte
+		Expected string
+	}{
+		{"JSON object", []byte(`{"device":"thermostat"}`), common.ContentTypeJSON},
+		{"JSON array", []byte(`[1,2]`), common.ContentTypeJSON},
+		{"Empty", nil, common.ContentTypeJSON},
+		{"CBOR", cborData, common.ContentTypeCBOR},
+		{"Unknown", []byte("plain text"), common.ContentTypeCBOR},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.Equal(t, test.Expected, DetectContentType(test.Data))
+		})
+	}
+
+}
+
+// unregister removes the codec registered for the content type so tests don't affect each other
+func unregister(contentType string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	for index, item := range registrations {
+		if item.contentType == contentType {
+			registrations = append(registrations[:index], registrations[index+1:]...)
+			return
+		}
+	}
+}
@@ -20,6 +20,9 @@ package interfaces
 // BackgroundPublisher provides an interface to send messages from background processes
 // through the service's configured MessageBus output
 type BackgroundPublisher interface {
-	// Publish provided message through the configured MessageBus output
+	// Publish provided message through the configured MessageBus output, formatted the same as the pipelines'
+	// response data. The context provides the correlation id, content type and values of the topic's placeholders.
+	// It may be nil for data not produced by a pipeline, e.g. by pollers or timers, a new correlation id is then
+	// generated and the content type detected from the payload.
 	Publish(payload []byte, context AppFunctionContext) error
 }
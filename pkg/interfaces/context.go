@@ -17,6 +17,7 @@
 package interfaces
 
 import (
+	"context"
 	"time"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
@@ -29,10 +30,66 @@ const (
 	DEVICENAME    = "devicename"
 	PROFILENAME   = "profilename"
 	SOURCENAME    = "sourcename"
+	EVENTID       = "eventid"
 	RECEIVEDTOPIC = "receivedtopic"
 	PIPELINEID    = "pipelineid"
+	// NETWORKOFFLINE is set to "true" while Store and Forward OfflineMode has detected the network is down.
+	// Export functions check it to store the data for later retry rather than attempt to send.
+	NETWORKOFFLINE = "networkoffline"
+	// REPLAY is set to "true" when the Event being processed is a historical Event replayed from Core Data rather
+	// than a newly received Event.
+	REPLAY = "replay"
+	// SHADOW is set to the ShadowMode of the pipeline when the pipeline being executed is a shadow pipeline.
+	// Export functions check it to log the data rather than send it when the mode is ShadowModeLog.
+	SHADOW = "shadow"
+	// TENANT is set to the id of the tenant the message is for when Tenancy is enabled. The tenant's settings are
+	// also set, so export functions can use the tenant's endpoints via placeholders.
+	TENANT = "tenant"
+	// RECEIVEDTIME is set to the time, in RFC3339 format with nanoseconds, the processing of the received message began.
+	RECEIVEDTIME = "receivedtime"
+	// IDEMPOTENCYKEY is set by SkipExported to the checksum of the data about to be exported, which MarkExported saves
+	// once the data has been exported.
+	IDEMPOTENCYKEY = "idempotencykey"
+	// SIGNATURE is set by SignPayloadHMAC and SignPayloadECDSA to the Base64 encoded signature of the data about to
+	// be exported, and SIGNATUREALGORITHM to the algorithm used. HTTP exports send them as the X-Signature and
+	// X-Signature-Algorithm headers.
+	SIGNATURE          = "signature"
+	SIGNATUREALGORITHM = "signaturealgorithm"
+	// BRANCHRETRY is set by the branching functions, When and SwitchByDeviceName, to the branch and position of the
+	// function in the branch that set data to be retried, so Store and Forward resumes the retry in that branch.
+	BRANCHRETRY = "branchretry"
+	// FANOUTRETRY is set by the FanOut function when the data to be retried is that of its failed branches
+	FANOUTRETRY = "fanoutretry"
+	// SERVICEKEY and PIPELINEVERSION are set, when Lineage is enabled, to the key of the service and the version of the
+	// pipeline processing the data. HTTP exports send them as the X-Lineage- headers, MQTT exports can use them as
+	// placeholders in the topic.
+	SERVICEKEY      = "servicekey"
+	PIPELINEVERSION = "pipelineversion"
+	// LOOPBACKHOPS is set to the number of times the data being processed has been sent back through the pipelines by
+	// LoopbackSend, which is limited so pipelines sending to their own topics don't loop forever.
+	LOOPBACKHOPS = "loopbackhops"
 )
 
+// The placeholders of topic templates replaced with the names of the Event being processed
+const (
+	TopicPlaceholderDevice  = "device"
+	TopicPlaceholderProfile = "profile"
+	TopicPlaceholderSource  = "source"
+)
+
+// TagHeaderPrefix is prefixed to the key of each of the context's tags for the header the tag is sent as, i.e. X-Tag-Site
+const TagHeaderPrefix = "X-Tag-"
+
+// TopicResponse is data published to a topic, in addition to the response data, when pipeline execution is complete
+type TopicResponse struct {
+	// Topic is the topic the data is published to, which may contain placeholders replaced with the context's values
+	Topic string
+	// Data is the data published
+	Data []byte
+	// ContentType is the content type of the data, detected from the data when empty
+	ContentType string
+}
+
 // AppFunction is a type alias for a application pipeline function.
 // appCtx is a reference to the AppFunctionContext below.
 // data is the data to be operated on by the function.
@@ -48,6 +105,10 @@ type AppFunctionContext interface {
 	Clone() AppFunctionContext
 	// CorrelationID returns the correlation ID associated with the context.
 	CorrelationID() string
+	// Context returns the context.Context of the pipeline execution, which is done once the service is shutting down,
+	// the execution exceeds the Trigger's PipelineTimeout or the HTTP request triggering it is cancelled. Long running
+	// functions should stop their work when it is done, as the runtime doesn't interrupt a running function.
+	Context() context.Context
 	// InputContentType returns the content type of the data that initiated the pipeline execution. Only useful when
 	// the TargetType for the pipeline is []byte, otherwise the data with be the type specified by TargetType.
 	InputContentType() string
@@ -61,14 +122,40 @@ type AppFunctionContext interface {
 	// ResponseContentType returns the content type that will be returned to the trigger when pipeline
 	// execution is complete.
 	ResponseContentType() string
+	// SetResponseHeader sets a header, or metadata key-value, that will be returned with the response data to the
+	// trigger when pipeline execution is complete. The HTTP and NATS triggers send the headers with the response,
+	// the MessageBus and External MQTT triggers' messages don't carry headers.
+	SetResponseHeader(key string, value string)
+	// ResponseHeaders returns the headers that will be returned with the response data to the trigger when pipeline
+	// execution is complete.
+	ResponseHeaders() map[string]string
+	// PublishToTopic adds data that will be published to the topic, in addition to the response data, by the trigger
+	// when pipeline execution is complete, so a pipeline can route different outputs, e.g. alerts and telemetry, to
+	// different topics. The topic may contain placeholders replaced with the context's values. The MessageBus, External
+	// MQTT and NATS triggers publish the data, custom triggers get it from TopicResponses.
+	PublishToTopic(topic string, data []byte, contentType string)
+	// TopicResponses returns the data that will be published to topics by the trigger when pipeline execution is
+	// complete, in the order it was added.
+	TopicResponses() []TopicResponse
+	// SetTag sets a tag identifying the data, i.e. the gateway, site or tenant it is from, replacing any value previously
+	// set for the key. The tags flow through the pipeline and are sent, with TagHeaderPrefix prefixed to their keys, as
+	// headers of the data exported via HTTP and of the response returned by the HTTP and NATS triggers.
+	SetTag(key string, value string)
+	// Tags returns the tags set on the context
+	Tags() map[string]string
 	// SetRetryData set the data that is to be retried later as part of the Store and Forward capability.
 	// Used when there was failure sending the data to an external source.
 	SetRetryData(data []byte)
-	// GetSecret returns the secret data from the secret store (secure or insecure) for the specified path.
+	// GetSecrets returns the secret data for the specified path from the SecretProviders registered with the
+	// service's RegisterSecretProvider, in the order registered, or else from the secret store (secure or insecure),
+	// so functions fetch API keys, passwords and certificates at runtime rather than from the configuration.
 	// An error is returned if the path is not found or any of the keys (if specified) are not found.
 	// Omit keys if all secret data for the specified path is required.
+	GetSecrets(path string, keys ...string) (map[string]string, error)
+	// GetSecret returns the same secret data as GetSecrets, which it predates.
 	GetSecret(path string, keys ...string) (map[string]string, error)
-	// SecretsLastUpdated returns that timestamp for when the secrets in the SecretStore where last updated.
+	// SecretsLastUpdated returns that timestamp for when the secrets in the SecretStore, or any registered
+	// SecretProvider, where last updated.
 	// Useful when a connection to external source needs to be redone when the credentials have been updated.
 	SecretsLastUpdated() time.Time
 	// LoggingClient returns the Logger client
@@ -94,6 +181,21 @@ type AppFunctionContext interface {
 	// DeviceClient returns the Device client. Note if Core Metadata is not specified in the
 	// Clients configuration, this will return nil.
 	DeviceClient() interfaces.DeviceClient
+	// DeliveryReceipts returns the tracker of the deliveries of exported data. Note if DeliveryReceipts is not
+	// enabled in the configuration, this will return nil.
+	DeliveryReceipts() DeliveryReceiptTracker
+	// ExportGuard returns the guard against exporting data already exported. Note if ExportGuard is not enabled in
+	// the configuration, this will return nil.
+	ExportGuard() ExportGuard
+	// HashChain returns the hash chains linking the records exported. Note if HashChain is not enabled in the
+	// configuration, this will return nil.
+	HashChain() HashChain
+	// DeviceGroups returns the members of the device groups. Note if DeviceGroups is not enabled in the
+	// configuration, this will return nil.
+	DeviceGroups() DeviceGroups
+	// Loopback returns the loopback feeding data to the pipelines of the same service. Note if the Trigger's Loopback
+	// is not enabled in the configuration, this will return nil.
+	Loopback() Loopback
 	// PushToCore pushes a new event to Core Data.
 	PushToCore(event dtos.Event) (common.BaseWithIdResponse, error)
 	// GetDeviceResource retrieves the DeviceResource for given profileName and resourceName.
@@ -113,6 +215,11 @@ type AppFunctionContext interface {
 	// the key in context storage.  An error will be returned if any placeholders
 	// are not matched to a value in the context.
 	ApplyValues(format string) (string, error)
+	// FormatTopic replaces the placeholders of the topic template, i.e. "edgex/events/{profile}/{device}/{source}",
+	// with the Event's profile, device and source names, the context's values or the context's tags, in that order
+	// of precedence. The '/', '+' and '#' characters of the replaced values are replaced with '_', so each placeholder
+	// is a single level of the topic. An error will be returned if any placeholders are not matched.
+	FormatTopic(template string) (string, error)
 	// PipelineId returns the ID of the pipeline that is executing
 	PipelineId() string
 }
@@ -0,0 +1,31 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+// DeliveryReceiptTracker records an id for each payload exported and reconciles the acknowledgements received from
+// the destinations, so the deliveries never acknowledged can be audited. Export functions for destinations that
+// acknowledge deliveries, i.e. with Kafka offsets, record each delivery before sending and acknowledge it once the
+// destination has.
+type DeliveryReceiptTracker interface {
+	// Record records that a payload is being exported to the destination and returns the id of the delivery
+	Record(destination string, correlationId string) string
+	// Acknowledge reconciles the destination's acknowledgement of the delivery. receipt is the destination's
+	// identification of the payload, i.e. a Kafka offset or the receipt id of an HTTP response.
+	Acknowledge(deliveryId string, receipt string)
+	// Fail records that the delivery failed. Data retried by Store and Forward is recorded as a new delivery.
+	Fail(deliveryId string, err error)
+}
@@ -0,0 +1,24 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+// DeviceGroups provides the members of the device groups, which are the devices in Core Metadata with each group's
+// labels. The members change as devices are labelled, so they should be retrieved for each Event rather than kept.
+type DeviceGroups interface {
+	// DeviceNames returns the names of the members of the group, ordered by name, and whether the group is configured
+	DeviceNames(groupName string) ([]string, bool)
+}
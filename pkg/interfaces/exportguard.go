@@ -0,0 +1,26 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+// ExportGuard saves the idempotency keys of the data exported, so data redelivered by the trigger or retried isn't
+// exported again to destinations that can't deduplicate themselves.
+type ExportGuard interface {
+	// Exported returns whether data with the idempotency key was exported within the configured window
+	Exported(key string) (bool, error)
+	// MarkExported saves the idempotency key of data that has been exported
+	MarkExported(key string) error
+}
@@ -0,0 +1,38 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+// ChainLink is a record's link in a hash chain. Each link's hash covers the hash of the previous link, so a record
+// that is altered, removed or reordered breaks the chain from that record on.
+type ChainLink struct {
+	// Sequence is the record's position in the chain, starting at 1, so a missing record leaves a gap
+	Sequence uint64 `json:"sequence"`
+	// PreviousHash is the hash of the previous link, empty for the first record
+	PreviousHash string `json:"previousHash"`
+	// PayloadHash is the hex encoded SHA-256 digest of the record's payload
+	PayloadHash string `json:"payloadHash"`
+	// Hash is the hex encoded SHA-256 digest of the link's sequence, previous hash and payload hash
+	Hash string `json:"hash"`
+}
+
+// HashChain links the records exported, i.e. the Events from each device, into hash chains whose heads are saved in
+// the store database, so downstream auditors can detect missing or altered records.
+type HashChain interface {
+	// Link appends the record with the payload hash to the chain with the key, saves the record's link as the chain's
+	// new head and returns it
+	Link(chainKey string, payloadHash string) (ChainLink, error)
+}
@@ -0,0 +1,26 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+// Loopback feeds data exported by a pipeline as the input of the pipelines of the same service whose topics match,
+// without a round-trip through the message bus, so data can be processed in stages isolated in their own pipelines.
+type Loopback interface {
+	// Send queues the data to be processed by the pipelines matching the topic, with the correlation id and content
+	// type of the context's message. Returns an error when the queue is full, the data has already been sent back
+	// the maximum number of times or the service is shutting down.
+	Send(ctx AppFunctionContext, topic string, data []byte) error
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	clientsinterfaces "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
 	common "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
 
@@ -80,6 +82,22 @@ func (_m *AppFunctionContext) CommandClient() clientsinterfaces.CommandClient {
 	return r0
 }
 
+// Context provides a mock function with given fields:
+func (_m *AppFunctionContext) Context() context.Context {
+	ret := _m.Called()
+
+	var r0 context.Context
+	if rf, ok := ret.Get(0).(func() context.Context); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+
+	return r0
+}
+
 // CorrelationID provides a mock function with given fields:
 func (_m *AppFunctionContext) CorrelationID() string {
 	ret := _m.Called()
@@ -94,6 +112,22 @@ func (_m *AppFunctionContext) CorrelationID() string {
 	return r0
 }
 
+// DeliveryReceipts provides a mock function with given fields:
+func (_m *AppFunctionContext) DeliveryReceipts() interfaces.DeliveryReceiptTracker {
+	ret := _m.Called()
+
+	var r0 interfaces.DeliveryReceiptTracker
+	if rf, ok := ret.Get(0).(func() interfaces.DeliveryReceiptTracker); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.DeliveryReceiptTracker)
+		}
+	}
+
+	return r0
+}
+
 // DeviceClient provides a mock function with given fields:
 func (_m *AppFunctionContext) DeviceClient() clientsinterfaces.DeviceClient {
 	ret := _m.Called()
@@ -110,6 +144,22 @@ func (_m *AppFunctionContext) DeviceClient() clientsinterfaces.DeviceClient {
 	return r0
 }
 
+// DeviceGroups provides a mock function with given fields:
+func (_m *AppFunctionContext) DeviceGroups() interfaces.DeviceGroups {
+	ret := _m.Called()
+
+	var r0 interfaces.DeviceGroups
+	if rf, ok := ret.Get(0).(func() interfaces.DeviceGroups); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.DeviceGroups)
+		}
+	}
+
+	return r0
+}
+
 // DeviceProfileClient provides a mock function with given fields:
 func (_m *AppFunctionContext) DeviceProfileClient() clientsinterfaces.DeviceProfileClient {
 	ret := _m.Called()
@@ -158,6 +208,43 @@ func (_m *AppFunctionContext) EventClient() clientsinterfaces.EventClient {
 	return r0
 }
 
+// ExportGuard provides a mock function with given fields:
+func (_m *AppFunctionContext) ExportGuard() interfaces.ExportGuard {
+	ret := _m.Called()
+
+	var r0 interfaces.ExportGuard
+	if rf, ok := ret.Get(0).(func() interfaces.ExportGuard); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.ExportGuard)
+		}
+	}
+
+	return r0
+}
+
+// FormatTopic provides a mock function with given fields: template
+func (_m *AppFunctionContext) FormatTopic(template string) (string, error) {
+	ret := _m.Called(template)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(template)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(template)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllValues provides a mock function with given fields:
 func (_m *AppFunctionContext) GetAllValues() map[string]string {
 	ret := _m.Called()
@@ -225,6 +312,36 @@ func (_m *AppFunctionContext) GetSecret(path string, keys ...string) (map[string
 	return r0, r1
 }
 
+// GetSecrets provides a mock function with given fields: path, keys
+func (_m *AppFunctionContext) GetSecrets(path string, keys ...string) (map[string]string, error) {
+	_va := make([]interface{}, len(keys))
+	for _i := range keys {
+		_va[_i] = keys[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, path)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func(string, ...string) map[string]string); ok {
+		r0 = rf(path, keys...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, ...string) error); ok {
+		r1 = rf(path, keys...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetValue provides a mock function with given fields: key
 func (_m *AppFunctionContext) GetValue(key string) (string, bool) {
 	ret := _m.Called(key)
@@ -246,6 +363,22 @@ func (_m *AppFunctionContext) GetValue(key string) (string, bool) {
 	return r0, r1
 }
 
+// HashChain provides a mock function with given fields:
+func (_m *AppFunctionContext) HashChain() interfaces.HashChain {
+	ret := _m.Called()
+
+	var r0 interfaces.HashChain
+	if rf, ok := ret.Get(0).(func() interfaces.HashChain); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.HashChain)
+		}
+	}
+
+	return r0
+}
+
 // InputContentType provides a mock function with given fields:
 func (_m *AppFunctionContext) InputContentType() string {
 	ret := _m.Called()
@@ -276,6 +409,22 @@ func (_m *AppFunctionContext) LoggingClient() logger.LoggingClient {
 	return r0
 }
 
+// Loopback provides a mock function with given fields:
+func (_m *AppFunctionContext) Loopback() interfaces.Loopback {
+	ret := _m.Called()
+
+	var r0 interfaces.Loopback
+	if rf, ok := ret.Get(0).(func() interfaces.Loopback); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interfaces.Loopback)
+		}
+	}
+
+	return r0
+}
+
 // NotificationClient provides a mock function with given fields:
 func (_m *AppFunctionContext) NotificationClient() clientsinterfaces.NotificationClient {
 	ret := _m.Called()
@@ -306,6 +455,11 @@ func (_m *AppFunctionContext) PipelineId() string {
 	return r0
 }
 
+// PublishToTopic provides a mock function with given fields: topic, data, contentType
+func (_m *AppFunctionContext) PublishToTopic(topic string, data []byte, contentType string) {
+	_m.Called(topic, data, contentType)
+}
+
 // PushToCore provides a mock function with given fields: event
 func (_m *AppFunctionContext) PushToCore(event dtos.Event) (common.BaseWithIdResponse, error) {
 	ret := _m.Called(event)
@@ -362,6 +516,22 @@ func (_m *AppFunctionContext) ResponseData() []byte {
 	return r0
 }
 
+// ResponseHeaders provides a mock function with given fields:
+func (_m *AppFunctionContext) ResponseHeaders() map[string]string {
+	ret := _m.Called()
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func() map[string]string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	return r0
+}
+
 // SecretsLastUpdated provides a mock function with given fields:
 func (_m *AppFunctionContext) SecretsLastUpdated() time.Time {
 	ret := _m.Called()
@@ -386,11 +556,21 @@ func (_m *AppFunctionContext) SetResponseData(data []byte) {
 	_m.Called(data)
 }
 
+// SetResponseHeader provides a mock function with given fields: key, value
+func (_m *AppFunctionContext) SetResponseHeader(key string, value string) {
+	_m.Called(key, value)
+}
+
 // SetRetryData provides a mock function with given fields: data
 func (_m *AppFunctionContext) SetRetryData(data []byte) {
 	_m.Called(data)
 }
 
+// SetTag provides a mock function with given fields: key, value
+func (_m *AppFunctionContext) SetTag(key string, value string) {
+	_m.Called(key, value)
+}
+
 // SubscriptionClient provides a mock function with given fields:
 func (_m *AppFunctionContext) SubscriptionClient() clientsinterfaces.SubscriptionClient {
 	ret := _m.Called()
@@ -406,3 +586,35 @@ func (_m *AppFunctionContext) SubscriptionClient() clientsinterfaces.Subscriptio
 
 	return r0
 }
+
+// Tags provides a mock function with given fields:
+func (_m *AppFunctionContext) Tags() map[string]string {
+	ret := _m.Called()
+
+	var r0 map[string]string
+	if rf, ok := ret.Get(0).(func() map[string]string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	return r0
+}
+
+// TopicResponses provides a mock function with given fields:
+func (_m *AppFunctionContext) TopicResponses() []interfaces.TopicResponse {
+	ret := _m.Called()
+
+	var r0 []interfaces.TopicResponse
+	if rf, ok := ret.Get(0).(func() []interfaces.TopicResponse); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.TopicResponse)
+		}
+	}
+
+	return r0
+}
@@ -7,6 +7,8 @@ import (
 
 	clientsinterfaces "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
 
+	codec "github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
+
 	interfaces "github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	logger "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
@@ -14,6 +16,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	registry "github.com/edgexfoundry/go-mod-registry/v2/registry"
+
+	time "time"
 )
 
 // ApplicationService is an autogenerated mock type for the ApplicationService type
@@ -88,6 +92,27 @@ func (_m *ApplicationService) AddFunctionsPipelineForTopics(id string, topics []
 	return r0
 }
 
+// AddShadowFunctionsPipelineForTopics provides a mock function with given fields: id, topics, mode, transforms
+func (_m *ApplicationService) AddShadowFunctionsPipelineForTopics(id string, topics []string, mode string, transforms ...func(interfaces.AppFunctionContext, interface{}) (bool, interface{})) error {
+	_va := make([]interface{}, len(transforms))
+	for _i := range transforms {
+		_va[_i] = transforms[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, id, topics, mode)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []string, string, ...func(interfaces.AppFunctionContext, interface{}) (bool, interface{})) error); ok {
+		r0 = rf(id, topics, mode, transforms...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AddRoute provides a mock function with given fields: route, handler, methods
 func (_m *ApplicationService) AddRoute(route string, handler func(http.ResponseWriter, *http.Request), methods ...string) error {
 	_va := make([]interface{}, len(methods))
@@ -420,6 +445,20 @@ func (_m *ApplicationService) NotificationClient() clientsinterfaces.Notificatio
 	return r0
 }
 
+// RegisterCodec provides a mock function with given fields: contentType, contentCodec
+func (_m *ApplicationService) RegisterCodec(contentType string, contentCodec codec.Codec) error {
+	ret := _m.Called(contentType, contentCodec)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, codec.Codec) error); ok {
+		r0 = rf(contentType, contentCodec)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RegisterCustomTriggerFactory provides a mock function with given fields: name, factory
 func (_m *ApplicationService) RegisterCustomTriggerFactory(name string, factory func(interfaces.TriggerConfig) (interfaces.Trigger, error)) error {
 	ret := _m.Called(name, factory)
@@ -434,6 +473,20 @@ func (_m *ApplicationService) RegisterCustomTriggerFactory(name string, factory
 	return r0
 }
 
+// RegisterSecretProvider provides a mock function with given fields: provider
+func (_m *ApplicationService) RegisterSecretProvider(provider interfaces.SecretProvider) error {
+	ret := _m.Called(provider)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(interfaces.SecretProvider) error); ok {
+		r0 = rf(provider)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RegistryClient provides a mock function with given fields:
 func (_m *ApplicationService) RegistryClient() registry.Client {
 	ret := _m.Called()
@@ -450,6 +503,34 @@ func (_m *ApplicationService) RegistryClient() registry.Client {
 	return r0
 }
 
+// ReplayEvents provides a mock function with given fields: start, end, deviceNames
+func (_m *ApplicationService) ReplayEvents(start time.Time, end time.Time, deviceNames ...string) (int, error) {
+	_va := make([]interface{}, len(deviceNames))
+	for _i := range deviceNames {
+		_va[_i] = deviceNames[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, start, end)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(time.Time, time.Time, ...string) int); ok {
+		r0 = rf(start, end, deviceNames...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, time.Time, ...string) error); ok {
+		r1 = rf(start, end, deviceNames...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetDefaultFunctionsPipeline provides a mock function with given fields: transforms
 func (_m *ApplicationService) SetDefaultFunctionsPipeline(transforms ...func(interfaces.AppFunctionContext, interface{}) (bool, interface{})) error {
 	_va := make([]interface{}, len(transforms))
@@ -470,6 +551,20 @@ func (_m *ApplicationService) SetDefaultFunctionsPipeline(transforms ...func(int
 	return r0
 }
 
+// SetFunctionErrorPolicy provides a mock function with given fields: pipelineId, functionIndex, policy
+func (_m *ApplicationService) SetFunctionErrorPolicy(pipelineId string, functionIndex int, policy interfaces.ErrorPolicy) error {
+	ret := _m.Called(pipelineId, functionIndex, policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int, interfaces.ErrorPolicy) error); ok {
+		r0 = rf(pipelineId, functionIndex, policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetFunctionsPipeline provides a mock function with given fields: transforms
 func (_m *ApplicationService) SetFunctionsPipeline(transforms ...func(interfaces.AppFunctionContext, interface{}) (bool, interface{})) error {
 	_va := make([]interface{}, len(transforms))
@@ -490,6 +585,16 @@ func (_m *ApplicationService) SetFunctionsPipeline(transforms ...func(interfaces
 	return r0
 }
 
+// SetPipelineErrorHandler provides a mock function with given fields: handler
+func (_m *ApplicationService) SetPipelineErrorHandler(handler interfaces.PipelineErrorHandler) {
+	_m.Called(handler)
+}
+
+// SetPipelineRouter provides a mock function with given fields: router
+func (_m *ApplicationService) SetPipelineRouter(router interfaces.PipelineRouter) {
+	_m.Called(router)
+}
+
 // StoreSecret provides a mock function with given fields: path, secretData
 func (_m *ApplicationService) StoreSecret(path string, secretData map[string]string) error {
 	ret := _m.Called(path, secretData)
@@ -519,3 +624,14 @@ func (_m *ApplicationService) SubscriptionClient() clientsinterfaces.Subscriptio
 
 	return r0
 }
+
+// UseMiddleware provides a mock function with given fields: middleware
+func (_m *ApplicationService) UseMiddleware(middleware ...interfaces.Middleware) {
+	_va := make([]interface{}, len(middleware))
+	for _i := range middleware {
+		_va[_i] = middleware[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	_m.Called(_ca...)
+}
@@ -0,0 +1,55 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	SandboxLimitCPUTime = "cpu time"
+	SandboxLimitMemory  = "memory"
+)
+
+// SandboxLimits are the resource ceilings enforced on each invocation of a sandboxed function, i.e. a script, so
+// one bad transform can't destabilize the service. A ceiling of 0 isn't enforced.
+type SandboxLimits struct {
+	// CPUTime is the maximum time the function executes for each invocation
+	CPUTime time.Duration
+	// Memory is the maximum bytes of values the function holds during each invocation
+	Memory int64
+}
+
+// SandboxViolation is the error of a sandboxed function invocation aborted for exceeding one of its SandboxLimits.
+// The pipeline error handler can check for it with errors.As. Violations aren't retried by the error policies.
+type SandboxViolation struct {
+	// Function identifies the sandboxed function, i.e. "script 'enrich'"
+	Function string
+	// Limit is SandboxLimitCPUTime or SandboxLimitMemory
+	Limit string
+	// Ceiling is the value of the limit exceeded, i.e. "500ms"
+	Ceiling string
+}
+
+func (violation SandboxViolation) Error() string {
+	if violation.Limit == SandboxLimitCPUTime {
+		return fmt.Sprintf("%s ran longer than %s", violation.Function, violation.Ceiling)
+	}
+
+	return fmt.Sprintf("%s exceeded its %s limit of %s", violation.Function, violation.Limit, violation.Ceiling)
+}
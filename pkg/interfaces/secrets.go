@@ -0,0 +1,33 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+import "time"
+
+// SecretProvider provides the secrets returned by the context's GetSecrets from a source other than the Secret Store,
+// i.e. files mounted in the container or an external vault, so credentials aren't embedded in the configuration.
+// The providers registered with RegisterSecretProvider are asked in the order registered, the Secret Store (Vault, or
+// InsecureSecrets) providing the paths none of them hold.
+type SecretProvider interface {
+	// GetSecrets returns the secrets at the path, only the keys specified when any are. found is false when the
+	// provider holds no secrets at the path, so the next provider is asked. An error is returned if any of the keys
+	// specified isn't found.
+	GetSecrets(path string, keys ...string) (secrets map[string]string, found bool, err error)
+	// SecretsLastUpdated returns the last time the provider's secrets were updated, so the connections using them
+	// are redone
+	SecretsLastUpdated() time.Time
+}
@@ -17,11 +17,14 @@ package interfaces
 
 import (
 	"net/http"
+	"time"
 
 	bootstrapInterfaces "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
 	"github.com/edgexfoundry/go-mod-registry/v2/registry"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 )
 
 const (
@@ -41,6 +44,25 @@ const (
 
 	// DefaultPipelineId is the ID used for the default pipeline create by SetFunctionsPipeline
 	DefaultPipelineId = "default-pipeline"
+
+	// ShadowModeLog is the shadow pipeline mode in which the SDK's export functions log the data they would have
+	// exported rather than send it.
+	ShadowModeLog = "log"
+	// ShadowModeSend is the shadow pipeline mode in which the SDK's export functions send the data as configured,
+	// which for a shadow pipeline should be test endpoints.
+	ShadowModeSend = "send"
+
+	// ErrorPolicyAbort stops the pipeline when the function fails, without storing the function's retry data for
+	// Store and Forward to retry the export later.
+	ErrorPolicyAbort = "abort"
+	// ErrorPolicySkip continues the pipeline with the failed function's input, as if the function wasn't in the pipeline.
+	ErrorPolicySkip = "skip"
+	// ErrorPolicyRetry executes the failed function again, up to the policy's Retries, before stopping the pipeline
+	// as it would without a policy.
+	ErrorPolicyRetry = "retry"
+	// ErrorPolicyDeadLetter diverts the failed function's input to the policy's DeadLetter function and stops the
+	// pipeline without an error once it is diverted.
+	ErrorPolicyDeadLetter = "deadletter"
 )
 
 // FunctionPipeline defines an instance of a Functions Pipeline
@@ -53,8 +75,51 @@ type FunctionPipeline struct {
 	Topics []string
 	// Hash of the list of transforms set and used internally for Store and Forward
 	Hash string
+	// ShadowMode is ShadowModeLog or ShadowModeSend for a shadow pipeline and empty for a production pipeline.
+	// Shadow pipelines receive copies of the messages but their errors and response data are only logged and their
+	// failed exports are not stored for retry, so they can't affect the production pipelines.
+	ShadowMode string
+}
+
+// ErrorPolicy defines how a pipeline handles its function failing, i.e. the function stopping the pipeline with an
+// error. Without a policy the pipeline stops and the function's retry data is stored for Store and Forward.
+type ErrorPolicy struct {
+	// Action is ErrorPolicyAbort, ErrorPolicySkip, ErrorPolicyRetry or ErrorPolicyDeadLetter
+	Action string
+	// Retries is the number of times the function is executed again with the same input before the Action is taken.
+	// Required for ErrorPolicyRetry.
+	Retries int
+	// RetryInterval is the time waited before each retry
+	RetryInterval time.Duration
+	// DeadLetter is the function the failed function's input is diverted to. Required for ErrorPolicyDeadLetter.
+	DeadLetter AppFunction
 }
 
+// PipelineErrorHandler is called with the error, the name of the function that failed and its input each time a
+// pipeline function fails, once the function's error policy has been applied.
+type PipelineErrorHandler func(ctx AppFunctionContext, err error, functionName string, input interface{})
+
+// RoutingInfo describes a message received by the trigger for the PipelineRouter
+type RoutingInfo struct {
+	// ReceivedTopic is the topic the message was received on
+	ReceivedTopic string
+	// ContentType is the content type of the message's payload
+	ContentType string
+	// DeviceName is the device name of the Event in the payload, empty when the payload isn't an Event
+	DeviceName string
+	// CorrelationID is the correlation id of the message
+	CorrelationID string
+}
+
+// PipelineRouter returns the ids of the pipelines that handle the message, chosen by its topic, content type or
+// device rather than the pipelines' topics. Returning no ids falls back to the pipelines whose topics match the
+// message's topic.
+type PipelineRouter func(message RoutingInfo) []string
+
+// Middleware wraps a pipeline function with a cross-cutting concern, i.e. timing, logging, tracing or validation,
+// returning the function executed in its place, which calls next to execute the wrapped function.
+type Middleware func(next AppFunction) AppFunction
+
 // UpdatableConfig interface allows services to have custom configuration populated from configuration stored
 // in the Configuration Provider (aka Consul). Services using custom configuration must implement this interface
 // on their custom configuration, even if they do not use Configuration Provider. If they do not use the
@@ -94,6 +159,28 @@ type ApplicationService interface {
 	// so that it matches multiple incoming topics. If just "#" is used for the specified topic it will match all incoming
 	// topics and the specified functions pipeline will execute on every message received.
 	AddFunctionsPipelineForTopics(id string, topic []string, transforms ...AppFunction) error
+	// AddShadowFunctionsPipelineForTopics adds a shadow functions pipeline with the specified unique id and list of
+	// Application Functions to be executed on copies of the messages whose incoming topic matches any of the specified
+	// topics, so a new version of a pipeline can be validated against production traffic before cutover. The
+	// pipeline's errors, response data and PublishToTopic data are only logged. The mode is ShadowModeLog to have the
+	// SDK's export functions log the data rather than send it, or ShadowModeSend to send it to the configured test
+	// endpoints.
+	// The HTTP trigger executes all shadow pipelines for each request.
+	AddShadowFunctionsPipelineForTopics(id string, topics []string, mode string, transforms ...AppFunction) error
+	// SetFunctionErrorPolicy sets the policy applied when the function at the specified index of the pipeline with
+	// the specified id fails, i.e. to skip it, retry it or divert its input to a dead letter function.
+	// An error is returned if the pipeline doesn't exist, it has no function at the index or the policy is invalid.
+	SetFunctionErrorPolicy(pipelineId string, functionIndex int, policy ErrorPolicy) error
+	// SetPipelineErrorHandler sets the handler called each time a function of any pipeline fails, i.e. to count
+	// or report the failures. Setting a nil handler removes the handler.
+	SetPipelineErrorHandler(handler PipelineErrorHandler)
+	// UseMiddleware adds middleware wrapping every function of every pipeline, without modifying each one, like HTTP
+	// middleware chains. The first middleware added is the outermost, so executes first.
+	UseMiddleware(middleware ...Middleware)
+	// SetPipelineRouter sets the router choosing the pipelines that handle each message received by the trigger,
+	// rather than only matching the message's topic with the pipelines' topics. The HTTP trigger always uses the
+	// default pipeline. Setting a nil router removes the router.
+	SetPipelineRouter(router PipelineRouter)
 	// MakeItRun starts the configured trigger to allow the functions pipeline to execute when the trigger
 	// receives data and starts the internal webserver. This is a long running function which does not return until
 	// the service is stopped or MakeItStop() is called.
@@ -105,6 +192,10 @@ type ApplicationService interface {
 	MakeItStop()
 	// RegisterCustomTriggerFactory registers a trigger factory for a custom trigger to be used.
 	RegisterCustomTriggerFactory(name string, factory func(TriggerConfig) (Trigger, error)) error
+	// RegisterCodec registers the codec for a content type, i.e. a proprietary encoding, replacing the codec registered
+	// for it before. The codec is used to decode the trigger payloads and to encode the exported and response data
+	// with that content type. The JSON and CBOR codecs are registered by default.
+	RegisterCodec(contentType string, contentCodec codec.Codec) error
 	// AddBackgroundPublisher Adds and returns a BackgroundPublisher which is used to publish
 	// asynchronously to the Edgex MessageBus.
 	// Not valid for use with the HTTP or External MQTT triggers
@@ -113,10 +204,15 @@ type ApplicationService interface {
 	// asynchronously to the Edgex MessageBus on the specified topic.
 	// Not valid for use with the HTTP or External MQTT triggers
 	AddBackgroundPublisherWithTopic(capacity int, topic string) (BackgroundPublisher, error)
-	// GetSecret returns the secret data from the secret store (secure or insecure) for the specified path.
+	// GetSecret returns the secret data for the specified path from the registered SecretProviders, i.e. the files in
+	// the SecretsDirectory when enabled, or else the secret store (secure or insecure).
 	// An error is returned if the path is not found or any of the keys (if specified) are not found.
 	// Omit keys if all secret data for the specified path is required.
 	GetSecret(path string, keys ...string) (map[string]string, error)
+	// RegisterSecretProvider registers a SecretProvider, i.e. a secure.FileSecretProvider or one reading an external
+	// vault, asked for the secrets returned by the context's GetSecrets, and the service's GetSecret, in the order
+	// registered before the secret store. The SecretsDirectory's provider, when enabled, is registered first.
+	RegisterSecretProvider(provider SecretProvider) error
 	// StoreSecret stores the specified secret data into the secret store (secure only) for the specified path
 	// An error is returned if:
 	//   - Specified secret data is empty
@@ -172,4 +268,11 @@ type ApplicationService interface {
 	ListenForCustomConfigChanges(configToWatch interface{}, sectionName string, changedCallback func(interface{})) error
 	// BuildContext allows external callers that may need a context (eg background publishers) to easily create one
 	BuildContext(correlationId string, contentType string) AppFunctionContext
+	// ReplayEvents pulls the Events for the specified devices, or all devices if none are specified, that originated
+	// in the start to end time range from Core Data and runs them through the matching functions pipeline(s), oldest
+	// first, with the REPLAY context value set to "true". Useful for backfilling cloud systems after an outage.
+	// Returns the number of Events replayed once complete.
+	// An error is returned if Core Data is not specified in the Clients configuration, the time range is invalid
+	// or the Events can not be retrieved.
+	ReplayEvents(start time.Time, end time.Time, deviceNames ...string) (int, error)
 }
@@ -0,0 +1,29 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package interfaces
+
+// SplitData is returned by a pipeline function, i.e. SplitByReading, to continue the pipeline separately with each
+// of the items, in order. Each item's continuation ends when a function stops the pipeline, all the functions have
+// executed or a function returns a MergeRequest.
+type SplitData []interface{}
+
+// MergeRequest is returned by a pipeline function, i.e. Merge, when it receives an item of SplitData, to end the
+// item's continuation. Once the continuations of all the items have ended, the function is executed with the
+// SplitData of the items it received, in order, and the pipeline continues with its result.
+type MergeRequest struct {
+	Item interface{}
+}
@@ -0,0 +1,155 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secure
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSecretProvider is a SecretProvider reading the secrets from files in a directory, i.e. the Docker or Kubernetes
+// secrets mounted in the container. Each secret path is a subdirectory whose files are the secret's keys, the file's
+// content, trimmed of surrounding whitespace, being the key's value. The paths not found in the directory are left to
+// the next provider, or the Secret Store. The files are read on each GetSecrets, so updated files are picked up on the
+// fly.
+type FileSecretProvider struct {
+	directory    string
+	pollInterval time.Duration
+	now          func() time.Time
+	lock         sync.Mutex
+	polledAt     time.Time
+	filesUpdated time.Time
+}
+
+// NewFileSecretProvider creates, initializes and returns a new instance of FileSecretProvider reading the secrets
+// from the directory. The files are checked for updates at most once per pollInterval.
+func NewFileSecretProvider(directory string, pollInterval time.Duration) (*FileSecretProvider, error) {
+	info, err := os.Stat(directory)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets directory '%s': %s", directory, err.Error())
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("secrets directory '%s' is not a directory", directory)
+	}
+
+	return &FileSecretProvider{
+		directory:    directory,
+		pollInterval: pollInterval,
+		now:          time.Now,
+	}, nil
+}
+
+// GetSecrets returns the secrets from the path's subdirectory, found being false when the subdirectory doesn't exist.
+// An error is returned if any of the keys (if specified) are not found.
+func (provider *FileSecretProvider) GetSecrets(path string, keys ...string) (map[string]string, bool, error) {
+	directory, err := provider.pathDirectory(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if _, err := os.Stat(directory); os.IsNotExist(err) {
+		return nil, false, nil
+	}
+
+	if len(keys) == 0 {
+		files, err := ioutil.ReadDir(directory)
+		if err != nil {
+			return nil, true, fmt.Errorf("unable to read secrets of path '%s': %s", path, err.Error())
+		}
+		for _, file := range files {
+			// Kubernetes mounts the files as links to a hidden timestamped directory
+			if !file.IsDir() && !strings.HasPrefix(file.Name(), ".") {
+				keys = append(keys, file.Name())
+			}
+		}
+	}
+
+	secrets := make(map[string]string, len(keys))
+	var missing []string
+	for _, key := range keys {
+		if !validName(key) {
+			return nil, true, fmt.Errorf("invalid secret key '%s'", key)
+		}
+
+		value, err := ioutil.ReadFile(filepath.Join(directory, key))
+		if os.IsNotExist(err) {
+			missing = append(missing, key)
+			continue
+		}
+		if err != nil {
+			return nil, true, fmt.Errorf("unable to read secret '%s' of path '%s': %s", key, path, err.Error())
+		}
+
+		secrets[key] = strings.TrimSpace(string(value))
+	}
+
+	if len(missing) > 0 {
+		return nil, true, fmt.Errorf("no secret data found for the following keys at path '%s': %v", path, missing)
+	}
+
+	return secrets, true, nil
+}
+
+// SecretsLastUpdated returns the last time the files in the directory were updated, so the connections using them
+// are redone. The exports call it for each message, so the directory is only walked once per poll interval, the
+// files' last update being cached in between.
+func (provider *FileSecretProvider) SecretsLastUpdated() time.Time {
+	provider.lock.Lock()
+	defer provider.lock.Unlock()
+
+	now := provider.now()
+	if now.Sub(provider.polledAt) >= provider.pollInterval {
+		provider.filesUpdated = provider.lastModified()
+		provider.polledAt = now
+	}
+
+	return provider.filesUpdated
+}
+
+// lastModified returns the latest modification time of the directory and the files and subdirectories in it
+func (provider *FileSecretProvider) lastModified() time.Time {
+	var lastModified time.Time
+	_ = filepath.Walk(provider.directory, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.ModTime().After(lastModified) {
+			lastModified = info.ModTime()
+		}
+		return nil
+	})
+
+	return lastModified
+}
+
+// pathDirectory returns the directory of the secret path, which can't be outside the secrets directory
+func (provider *FileSecretProvider) pathDirectory(path string) (string, error) {
+	for _, segment := range strings.Split(path, "/") {
+		if !validName(segment) {
+			return "", fmt.Errorf("invalid secret path '%s'", path)
+		}
+	}
+
+	return filepath.Join(provider.directory, filepath.FromSlash(path)), nil
+}
+
+// validName returns whether the path segment or key names a file in its directory
+func validName(name string) bool {
+	return len(name) > 0 && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secure
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// secretsDirectory returns a directory holding the "mqtt" secret's username and password files
+func secretsDirectory(t *testing.T) string {
+	directory := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(directory, "mqtt"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "mqtt", "username"), []byte("edgex\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "mqtt", "password"), []byte("secret"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(directory, "mqtt", ".hidden"), []byte("ignored"), 0600))
+	return directory
+}
+
+func TestNewFileSecretProvider(t *testing.T) {
+	directory := secretsDirectory(t)
+
+	var provider interfaces.SecretProvider
+	provider, err := NewFileSecretProvider(directory, time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, provider)
+
+	_, err = NewFileSecretProvider(filepath.Join(directory, "missing"), time.Minute)
+	assert.Error(t, err)
+
+	_, err = NewFileSecretProvider(filepath.Join(directory, "mqtt", "username"), time.Minute)
+	assert.Error(t, err, "file should not be a secrets directory")
+}
+
+func TestFileSecretProvider_GetSecrets(t *testing.T) {
+	provider, err := NewFileSecretProvider(secretsDirectory(t), time.Minute)
+	require.NoError(t, err)
+
+	secrets, found, err := provider.GetSecrets("mqtt")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]string{"username": "edgex", "password": "secret"}, secrets,
+		"values should be trimmed and hidden files skipped")
+
+	secrets, _, err = provider.GetSecrets("mqtt", "password")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"password": "secret"}, secrets)
+
+	_, found, err = provider.GetSecrets("mqtt", "password", "cacert")
+	require.Error(t, err)
+	assert.True(t, found)
+	assert.Contains(t, err.Error(), "[cacert]")
+
+	_, found, err = provider.GetSecrets("redisdb", "password")
+	require.NoError(t, err)
+	assert.False(t, found, "path not in the directory should be left to the next provider")
+
+	_, _, err = provider.GetSecrets("../etc", "passwd")
+	assert.Error(t, err)
+	_, _, err = provider.GetSecrets("mqtt", "../../passwd")
+	assert.Error(t, err)
+}
+
+func TestFileSecretProvider_SecretsLastUpdated(t *testing.T) {
+	directory := secretsDirectory(t)
+	provider, err := NewFileSecretProvider(directory, time.Minute)
+	require.NoError(t, err)
+
+	created := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	passwordFile := filepath.Join(directory, "mqtt", "password")
+	for _, path := range []string{directory, filepath.Join(directory, "mqtt"), filepath.Join(directory, "mqtt", "username"),
+		filepath.Join(directory, "mqtt", ".hidden"), passwordFile} {
+		require.NoError(t, os.Chtimes(path, created, created))
+	}
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	assert.True(t, provider.SecretsLastUpdated().Equal(created))
+
+	updated := time.Now().Add(-time.Minute).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(passwordFile, updated, updated))
+	assert.True(t, provider.SecretsLastUpdated().Equal(created), "files should not be checked before the poll interval")
+
+	now = now.Add(time.Minute)
+	assert.True(t, provider.SecretsLastUpdated().Equal(updated), "updated file should update the secrets")
+}
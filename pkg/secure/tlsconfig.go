@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secure
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
+)
+
+// NewClientTLSConfig creates the TLS configuration for a client using mutual TLS. The client certificate and key are
+// read from the clientcert and clientkey secrets at secretPath. When the optional cacert secret is present the server
+// is verified using that CA rather than the system's CAs.
+func NewClientTLSConfig(provider messaging.SecretDataProvider, secretPath string, skipCertVerify bool) (*tls.Config, error) {
+	secretData, err := messaging.GetSecretData(messaging.AuthModeCert, secretPath, provider)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get client certificate secrets from '%s': %s", secretPath, err.Error())
+	}
+
+	if err := messaging.ValidateSecretData(messaging.AuthModeCert, secretPath, secretData); err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair(secretData.CertPemBlock, secretData.KeyPemBlock)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate or key in '%s': %s", secretPath, err.Error())
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		// nolint: gosec
+		InsecureSkipVerify: skipCertVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if len(secretData.CaPemBlock) > 0 {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(secretData.CaPemBlock) {
+			return nil, errors.New("Error parsing CA PEM block")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	return tlsConfig, nil
+}
+
+// NewCATLSConfig creates the TLS configuration for a client verifying the server using the CA in the cacert secret at
+// secretPath rather than the system's CAs, i.e. for a server with a certificate issued by a private CA.
+func NewCATLSConfig(provider messaging.SecretDataProvider, secretPath string, skipCertVerify bool) (*tls.Config, error) {
+	secretData, err := messaging.GetSecretData(messaging.AuthModeCA, secretPath, provider)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get CA certificate secret from '%s': %s", secretPath, err.Error())
+	}
+
+	if err := messaging.ValidateSecretData(messaging.AuthModeCA, secretPath, secretData); err != nil {
+		return nil, err
+	}
+
+	caCertPool := x509.NewCertPool()
+	if !caCertPool.AppendCertsFromPEM(secretData.CaPemBlock) {
+		return nil, errors.New("Error parsing CA PEM block")
+	}
+
+	return &tls.Config{
+		RootCAs: caCertPool,
+		// nolint: gosec
+		InsecureSkipVerify: skipCertVerify,
+		MinVersion:         tls.VersionTLS12,
+	}, nil
+}
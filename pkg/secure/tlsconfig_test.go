@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package secure
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientTLSConfig(t *testing.T) {
+	mockSP := &mocks.SecretProvider{}
+	mockSP.On("GetSecret", "mtls").Return(map[string]string{
+		messaging.SecretClientCert: testClientCert,
+		messaging.SecretClientKey:  testClientKey,
+		messaging.SecretCACert:     testCACert,
+	}, nil)
+	mockSP.On("GetSecret", "no-ca").Return(map[string]string{
+		messaging.SecretClientCert: testClientCert,
+		messaging.SecretClientKey:  testClientKey,
+	}, nil)
+	mockSP.On("GetSecret", "no-key").Return(map[string]string{messaging.SecretClientCert: testClientCert}, nil)
+	mockSP.On("GetSecret", "bad-key").Return(map[string]string{
+		messaging.SecretClientCert: testClientCert,
+		messaging.SecretClientKey:  "bad",
+	}, nil)
+	mockSP.On("GetSecret", "bad-ca").Return(map[string]string{
+		messaging.SecretClientCert: testClientCert,
+		messaging.SecretClientKey:  testClientKey,
+		messaging.SecretCACert:     "bad",
+	}, nil)
+	mockSP.On("GetSecret", "missing").Return(nil, errors.New("not found"))
+
+	tlsConfig, err := NewClientTLSConfig(mockSP, "mtls", true)
+	require.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+
+	tlsConfig, err = NewClientTLSConfig(mockSP, "no-ca", false)
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig.RootCAs, "system CAs should be used")
+
+	for _, secretPath := range []string{"no-key", "bad-key", "bad-ca", "missing"} {
+		_, err := NewClientTLSConfig(mockSP, secretPath, false)
+		assert.Error(t, err, secretPath)
+	}
+}
+
+func TestNewCATLSConfig(t *testing.T) {
+	mockSP := &mocks.SecretProvider{}
+	mockSP.On("GetSecret", "ca").Return(map[string]string{messaging.SecretCACert: testCACert}, nil)
+	mockSP.On("GetSecret", "no-ca").Return(map[string]string{}, nil)
+	mockSP.On("GetSecret", "bad-ca").Return(map[string]string{messaging.SecretCACert: "bad"}, nil)
+	mockSP.On("GetSecret", "missing").Return(nil, errors.New("not found"))
+
+	tlsConfig, err := NewCATLSConfig(mockSP, "ca", false)
+	require.NoError(t, err)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Empty(t, tlsConfig.Certificates)
+
+	for _, secretPath := range []string{"no-ca", "bad-ca", "missing"} {
+		_, err := NewCATLSConfig(mockSP, secretPath, false)
+		assert.Error(t, err, secretPath)
+	}
+}
@@ -0,0 +1,182 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// AESGCMProtection encrypts data with AES in Galois/Counter Mode, which authenticates the encrypted data so any
+// modification is detected when decrypting.
+type AESGCMProtection struct {
+	SecretPath    string
+	SecretName    string
+	EncryptionKey string
+}
+
+// NewAESGCMProtection creates, initializes and returns a new instance of AESGCMProtection configured
+// to retrieve the hex encoded 128, 192 or 256 bit encryption key from the Secret Store
+func NewAESGCMProtection(secretPath string, secretName string) AESGCMProtection {
+	return AESGCMProtection{
+		SecretPath: secretPath,
+		SecretName: secretName,
+	}
+}
+
+// Encrypt encrypts a string, []byte, or json.Marshaller type using AES-GCM with a random nonce for each message.
+// It will return a Base64 encoded []byte of the nonce followed by the encrypted data and authentication tag.
+func (protection AESGCMProtection) Encrypt(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		return false, fmt.Errorf("function Encrypt in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Encrypting with AES-GCM in pipeline '%s'", ctx.PipelineId())
+
+	byteData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := protection.getKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer clearKey(key)
+
+	encrypted, err := sealAESGCM(key, byteData)
+	if err != nil {
+		return false, fmt.Errorf("function Encrypt in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	// Set response "content-type" header to "text/plain"
+	ctx.SetResponseContentType(common.ContentTypeText)
+
+	return true, []byte(base64.StdEncoding.EncodeToString(encrypted))
+}
+
+// Decrypt decrypts data encrypted by Encrypt, received as either a string or []byte, Base64 encoded or not, and
+// verifies its authentication tag. It will return the decrypted data as a []byte.
+// This function will return an error and stop the pipeline if no data is received, the key isn't set or the data
+// can't be decrypted, including when it has been modified.
+func (protection AESGCMProtection) Decrypt(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		return false, fmt.Errorf("function Decrypt in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Decrypting with AES-GCM in pipeline '%s'", ctx.PipelineId())
+
+	byteData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := protection.getKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer clearKey(key)
+
+	decrypted, err := openAESGCM(key, decodeBase64(byteData))
+	if err != nil {
+		return false, fmt.Errorf("function Decrypt in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	return true, decrypted
+}
+
+func (protection AESGCMProtection) getKey(ctx interfaces.AppFunctionContext) ([]byte, error) {
+	encodedKey := protection.EncryptionKey
+
+	// If using Secret Store for the encryption key
+	if len(protection.SecretPath) != 0 && len(protection.SecretName) != 0 {
+		var err error
+		encodedKey, err = getSecretKey(ctx, protection.SecretPath, protection.SecretName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(encodedKey) == 0 {
+		return nil, fmt.Errorf("AES-GCM encryption key not set in pipeline '%s'", ctx.PipelineId())
+	}
+
+	key, err := hex.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("AES-GCM encryption key in pipeline '%s' is not hex encoded: %s", ctx.PipelineId(), err.Error())
+	}
+
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %s", err.Error())
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM cipher: %s", err.Error())
+	}
+
+	return aead, nil
+}
+
+// sealAESGCM encrypts the data with a random nonce, which is prepended to the result
+func sealAESGCM(key []byte, data []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %s", err.Error())
+	}
+
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// openAESGCM decrypts the data encrypted by sealAESGCM
+func openAESGCM(key []byte, data []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("encrypted data is too short")
+	}
+
+	decrypted, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data: %s", err.Error())
+	}
+
+	return decrypted, nil
+}
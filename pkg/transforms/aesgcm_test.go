@@ -0,0 +1,154 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+)
+
+const aesGCMKey = "6368616e676520746869732070617373776f726420746f206120736563726574"
+
+func decryptAESGCM(t *testing.T, key []byte, encrypted []byte) []byte {
+	decrypted, err := openAESGCM(key, encrypted)
+	require.NoError(t, err)
+	return decrypted
+}
+
+func decodeEncrypted(t *testing.T, result interface{}) []byte {
+	encoded, ok := result.([]byte)
+	require.True(t, ok, result)
+	encrypted, err := base64.StdEncoding.DecodeString(string(encoded))
+	require.NoError(t, err)
+	return encrypted
+}
+
+func TestAESGCMProtection_Encrypt(t *testing.T) {
+	key, err := hex.DecodeString(aesGCMKey)
+	require.NoError(t, err)
+
+	protection := AESGCMProtection{EncryptionKey: aesGCMKey}
+
+	continuePipeline, first := protection.Encrypt(ctx, []byte(plainString))
+	require.True(t, continuePipeline, first)
+	continuePipeline, second := protection.Encrypt(ctx, []byte(plainString))
+	require.True(t, continuePipeline, second)
+
+	firstEncrypted := decodeEncrypted(t, first)
+	secondEncrypted := decodeEncrypted(t, second)
+	assert.NotEqual(t, firstEncrypted[:12], secondEncrypted[:12], "each message should use a new nonce")
+	assert.Equal(t, plainString, string(decryptAESGCM(t, key, firstEncrypted)))
+	assert.Equal(t, plainString, string(decryptAESGCM(t, key, secondEncrypted)))
+
+	// Modified data must fail authentication
+	firstEncrypted[len(firstEncrypted)-1] ^= 0xff
+	_, err = openAESGCM(key, firstEncrypted)
+	assert.Error(t, err)
+}
+
+func TestAESGCMProtection_EncryptWithSecrets(t *testing.T) {
+	secretPath := "aes"
+	secretName := "key"
+	key, err := hex.DecodeString(aesGCMKey[:32])
+	require.NoError(t, err)
+
+	mockCtx := &mocks.AppFunctionContext{}
+	mockCtx.On("SetResponseContentType", common.ContentTypeText).Return()
+	mockCtx.On("PipelineId").Return("pipeline-id")
+	mockCtx.On("LoggingClient").Return(logger.NewMockClient())
+	mockCtx.On("GetSecret", secretPath, secretName).Return(map[string]string{secretName: aesGCMKey[:32]}, nil)
+
+	continuePipeline, result := NewAESGCMProtection(secretPath, secretName).Encrypt(mockCtx, plainString)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, plainString, string(decryptAESGCM(t, key, decodeEncrypted(t, result))), "128 bit keys should be supported")
+}
+
+func TestAESGCMProtection_EncryptErrors(t *testing.T) {
+	mockCtx := &mocks.AppFunctionContext{}
+	mockCtx.On("PipelineId").Return("pipeline-id")
+	mockCtx.On("LoggingClient").Return(logger.NewMockClient())
+	mockCtx.On("GetSecret", "aes", "key").Return(nil, errors.New("secret store unavailable"))
+
+	tests := []struct {
+		Name       string
+		Protection AESGCMProtection
+		Data       interface{}
+	}{
+		{"No data", AESGCMProtection{EncryptionKey: aesGCMKey}, nil},
+		{"No key", AESGCMProtection{}, plainString},
+		{"Key not hex", AESGCMProtection{EncryptionKey: "not a key"}, plainString},
+		{"Bad key size", AESGCMProtection{EncryptionKey: aesGCMKey[:20]}, plainString},
+		{"Secret error", NewAESGCMProtection("aes", "key"), plainString},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := test.Protection.Encrypt(mockCtx, test.Data)
+			assert.False(t, continuePipeline)
+			assert.Error(t, result.(error))
+		})
+	}
+}
+
+func TestAESGCMProtection_Decrypt(t *testing.T) {
+	protection := AESGCMProtection{EncryptionKey: aesGCMKey}
+
+	continuePipeline, encrypted := protection.Encrypt(ctx, []byte(plainString))
+	require.True(t, continuePipeline, encrypted)
+	raw := decodeEncrypted(t, encrypted)
+
+	tampered := append([]byte{}, raw...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		Name        string
+		Protection  AESGCMProtection
+		Data        interface{}
+		ExpectError bool
+	}{
+		{"Base64", protection, encrypted, false},
+		{"Base64 string", protection, string(encrypted.([]byte)), false},
+		{"Raw", protection, raw, false},
+		{"Modified", protection, tampered, true},
+		{"Too short", protection, raw[:10], true},
+		{"Wrong key", AESGCMProtection{EncryptionKey: aesGCMKey[:32]}, raw, true},
+		{"No data", protection, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := test.Protection.Decrypt(ctx, test.Data)
+			if test.ExpectError {
+				require.False(t, continuePipeline)
+				assert.Error(t, result.(error))
+				return
+			}
+
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, []byte(plainString), result)
+		})
+	}
+}
@@ -95,37 +95,86 @@ func (protection AESProtection) Encrypt(ctx interfaces.AppFunctionContext, data
 	return true, encodedData
 }
 
+// Decrypt decrypts data encrypted by Encrypt, received as either a string or []byte, Base64 encoded or not, and
+// verifies its SHA512 signature. It will return the decrypted data as a []byte.
+// This function will return an error and stop the pipeline if no data is received, the key isn't set or the data
+// can't be decrypted, including when it has been modified.
+func (protection AESProtection) Decrypt(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		return false, fmt.Errorf("function Decrypt in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Decrypting with AES256 in pipeline '%s'", ctx.PipelineId())
+
+	byteData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	key, err := protection.getKey(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer clearKey(key)
+
+	if len(key) == 0 {
+		return false, fmt.Errorf("AES256 encryption key not set in pipeline '%s'", ctx.PipelineId())
+	}
+
+	aead, err := etm.NewAES256SHA512(key)
+	if err != nil {
+		return false, err
+	}
+
+	decrypted, err := aead.Open(nil, nil, decodeBase64(byteData), nil)
+	if err != nil {
+		return false, fmt.Errorf("function Decrypt in pipeline '%s': unable to decrypt data: %s", ctx.PipelineId(), err.Error())
+	}
+
+	return true, decrypted
+}
+
 func (protection *AESProtection) getKey(ctx interfaces.AppFunctionContext) ([]byte, error) {
 	// If using Secret Store for the encryption key
 	if len(protection.SecretPath) != 0 && len(protection.SecretName) != 0 {
-		// Note secrets are cached so this call doesn't result in unneeded calls to SecretStore Service and
-		// the cache is invalidated when StoreSecrets is used.
-		secretData, err := ctx.GetSecret(protection.SecretPath, protection.SecretName)
+		key, err := getSecretKey(ctx, protection.SecretPath, protection.SecretName)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"unable to retieve encryption key at secret path=%s and name=%s in pipeline '%s'",
-				protection.SecretPath,
-				protection.SecretName,
-				ctx.PipelineId())
+			return nil, err
 		}
 
-		key, ok := secretData[protection.SecretName]
-		if !ok {
-			return nil, fmt.Errorf(
-				"unable find encryption key in secret data for name=%s in pipeline '%s'",
-				protection.SecretName,
-				ctx.PipelineId())
-		}
+		return hex.DecodeString(key)
+	}
+	return nil, fmt.Errorf("No key configured")
+}
 
-		ctx.LoggingClient().Debugf(
-			"Using encryption key from Secret Store at path=%s & name=%s in pipeline '%s'",
-			protection.SecretPath,
-			protection.SecretName,
+// getSecretKey retrieves the encryption key stored under secretName at the secretPath in the Secret Store
+func getSecretKey(ctx interfaces.AppFunctionContext, secretPath string, secretName string) (string, error) {
+	// Note secrets are cached so this call doesn't result in unneeded calls to SecretStore Service and
+	// the cache is invalidated when StoreSecrets is used.
+	secretData, err := ctx.GetSecret(secretPath, secretName)
+	if err != nil {
+		return "", fmt.Errorf(
+			"unable to retieve encryption key at secret path=%s and name=%s in pipeline '%s'",
+			secretPath,
+			secretName,
 			ctx.PipelineId())
+	}
 
-		return hex.DecodeString(key)
+	key, ok := secretData[secretName]
+	if !ok {
+		return "", fmt.Errorf(
+			"unable find encryption key in secret data for name=%s in pipeline '%s'",
+			secretName,
+			ctx.PipelineId())
 	}
-	return nil, fmt.Errorf("No key configured")
+
+	ctx.LoggingClient().Debugf(
+		"Using encryption key from Secret Store at path=%s & name=%s in pipeline '%s'",
+		secretPath,
+		secretName,
+		ctx.PipelineId())
+
+	return key, nil
 }
 
 func clearKey(key []byte) {
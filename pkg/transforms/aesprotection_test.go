@@ -154,6 +154,42 @@ func TestAESProtection_Encrypt(t *testing.T) {
 	assert.Equal(t, plainString, string(decrypted))
 }
 
+func TestAESProtection_Decrypt(t *testing.T) {
+	secretPath := uuid.NewString()
+	secretName := uuid.NewString()
+	key := "217A24432646294A404E635266556A586E3272357538782F413F442A472D4B6150645367566B59703373367639792442264529482B4D6251655468576D5A7134"
+
+	ctx := &mocks.AppFunctionContext{}
+	ctx.On("SetResponseContentType", common.ContentTypeText).Return()
+	ctx.On("PipelineId").Return("pipeline-id")
+	ctx.On("LoggingClient").Return(logger.NewMockClient())
+	ctx.On("GetSecret", secretPath, secretName).Return(map[string]string{secretName: key}, nil)
+
+	protection := NewAESProtection(secretPath, secretName)
+
+	continuePipeline, encrypted := protection.Encrypt(ctx, []byte(plainString))
+	require.True(t, continuePipeline)
+
+	continuePipeline, decrypted := protection.Decrypt(ctx, encrypted)
+	require.True(t, continuePipeline, decrypted)
+	assert.Equal(t, []byte(plainString), decrypted)
+
+	raw, err := base64.StdEncoding.DecodeString(string(encrypted.([]byte)))
+	require.NoError(t, err)
+	continuePipeline, decrypted = protection.Decrypt(ctx, raw)
+	require.True(t, continuePipeline, decrypted)
+	assert.Equal(t, []byte(plainString), decrypted, "data not Base64 encoded should be decrypted")
+
+	raw[len(raw)-1] ^= 0xff
+	continuePipeline, result := protection.Decrypt(ctx, raw)
+	require.False(t, continuePipeline)
+	assert.Error(t, result.(error), "modified data should not be decrypted")
+
+	continuePipeline, result = protection.Decrypt(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Error(t, result.(error))
+}
+
 func aes256Decrypt(t *testing.T, dbytes []byte, key string) []byte {
 	k, err := hex.DecodeString(key)
 
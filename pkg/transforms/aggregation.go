@@ -0,0 +1,286 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// Aggregate functions computed by Aggregation
+const (
+	AggregateMin = "min"
+	AggregateMax = "max"
+	AggregateAvg = "avg"
+	AggregateSum = "sum"
+)
+
+// Aggregation computes aggregates of the readings of each device and resource over a time window and returns a summary
+// Event in place of the raw readings. A tumbling window summarizes the readings received in consecutive windows of
+// the duration, a sliding window summarizes the readings received in the duration up to each Event.
+type Aggregation struct {
+	window        time.Duration
+	sliding       bool
+	functions     []string
+	resourceNames []string
+	lock          sync.Mutex
+	devices       map[string]*aggregationWindow
+	now           func() time.Time
+	flush         *FlushCoordinator
+}
+
+// aggregationWindow is the readings of a single device in the current window
+type aggregationWindow struct {
+	profileName string
+	sourceName  string
+	tags        map[string]interface{}
+	resources   []string
+	samples     map[string][]timedValue
+}
+
+type timedValue struct {
+	received time.Time
+	value    float64
+}
+
+// NewTumblingAggregation creates, initializes and returns a new instance of Aggregation with a tumbling window of the
+// duration. functions are the aggregates computed, min, max, avg and/or sum, all of them when empty.
+// resourceNames, when not empty, limits the readings aggregated, otherwise all numeric readings are aggregated.
+func NewTumblingAggregation(window time.Duration, functions []string, resourceNames []string) (*Aggregation, error) {
+	return newAggregation(window, false, functions, resourceNames)
+}
+
+// NewSlidingAggregation creates, initializes and returns a new instance of Aggregation with a sliding window of the
+// duration. functions are the aggregates computed, min, max, avg and/or sum, all of them when empty.
+// resourceNames, when not empty, limits the readings aggregated, otherwise all numeric readings are aggregated.
+func NewSlidingAggregation(window time.Duration, functions []string, resourceNames []string) (*Aggregation, error) {
+	return newAggregation(window, true, functions, resourceNames)
+}
+
+func newAggregation(window time.Duration, sliding bool, functions []string, resourceNames []string) (*Aggregation, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid aggregation window '%s', must be greater than 0", window.String())
+	}
+
+	if len(functions) == 0 {
+		functions = []string{AggregateMin, AggregateMax, AggregateAvg, AggregateSum}
+	}
+
+	var normalized []string
+	for _, function := range functions {
+		name := strings.ToLower(strings.TrimSpace(function))
+		switch name {
+		case AggregateMin, AggregateMax, AggregateAvg, AggregateSum:
+			normalized = append(normalized, name)
+		default:
+			return nil, fmt.Errorf("invalid aggregate function '%s', must be '%s', '%s', '%s' or '%s'",
+				function, AggregateMin, AggregateMax, AggregateAvg, AggregateSum)
+		}
+	}
+
+	return &Aggregation{
+		window:        window,
+		sliding:       sliding,
+		functions:     normalized,
+		resourceNames: resourceNames,
+		devices:       make(map[string]*aggregationWindow),
+		now:           time.Now,
+	}, nil
+}
+
+// AlignFlush aligns the end of tumbling windows to the wall clock boundaries of the window duration using the
+// coordinator, so the summaries of all devices, and of all the functions sharing the coordinator, are returned
+// together. A device's first window ends at the first boundary, so it covers less than the window duration.
+func (aggregation *Aggregation) AlignFlush(coordinator *FlushCoordinator) {
+	aggregation.flush = coordinator
+}
+
+// Aggregate adds the Event's numeric readings to its device's window and returns a summary Event with the aggregates
+// of each resource in the window as Float64 readings named <resource>_<function>, i.e. temperature_avg.
+// With a sliding window the summary is returned for each Event. With a tumbling window the pipeline execution for the
+// Event opening the device's window waits for the window to end and returns the summary, the pipeline is stopped for
+// the other Events in the window. This function will return an error and stop the pipeline if a non-edgex event is
+// received, no data is received or a reading's value can't be parsed.
+func (aggregation *Aggregation) Aggregate(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Aggregate in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function Aggregate in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	// Parse all the values first so an Event with a bad value isn't partially added to the window
+	var resources []string
+	var values []float64
+	for _, reading := range event.Readings {
+		if !aggregation.shouldAggregate(reading) {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			return false, fmt.Errorf("function Aggregate in pipeline '%s': unable to parse value of reading '%s': %s",
+				ctx.PipelineId(), reading.ResourceName, err.Error())
+		}
+
+		resources = append(resources, reading.ResourceName)
+		values = append(values, value)
+	}
+
+	if len(values) == 0 {
+		ctx.LoggingClient().Debugf("No readings to aggregate in pipeline '%s'", ctx.PipelineId())
+		return false, nil
+	}
+
+	now := aggregation.now()
+
+	aggregation.lock.Lock()
+	window, found := aggregation.devices[event.DeviceName]
+	if !found {
+		window = &aggregationWindow{
+			profileName: event.ProfileName,
+			sourceName:  event.SourceName,
+			tags:        event.Tags,
+			samples:     make(map[string][]timedValue),
+		}
+		aggregation.devices[event.DeviceName] = window
+	}
+	for index, resource := range resources {
+		window.add(resource, timedValue{received: now, value: values[index]})
+	}
+
+	if aggregation.sliding {
+		window.expire(now.Add(-aggregation.window))
+		summary, err := aggregation.summary(event.DeviceName, window)
+		aggregation.lock.Unlock()
+		if err != nil {
+			return false, fmt.Errorf("function Aggregate in pipeline '%s': unable to add aggregate reading: %s",
+				ctx.PipelineId(), err.Error())
+		}
+
+		summary.Origin = event.Origin
+		return true, summary
+	}
+	aggregation.lock.Unlock()
+
+	if found {
+		return false, nil
+	}
+
+	ctx.LoggingClient().Debugf("Aggregation window of %s opened for device '%s' in pipeline '%s'",
+		aggregation.window.String(), event.DeviceName, ctx.PipelineId())
+	elapsed, stop := flushTimer(aggregation.flush, aggregation.window)
+	<-elapsed
+	stop()
+
+	aggregation.lock.Lock()
+	delete(aggregation.devices, event.DeviceName)
+	aggregation.lock.Unlock()
+
+	summary, err := aggregation.summary(event.DeviceName, window)
+	if err != nil {
+		return false, fmt.Errorf("function Aggregate in pipeline '%s': unable to add aggregate reading: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	return true, summary
+}
+
+func (aggregation *Aggregation) shouldAggregate(reading dtos.BaseReading) bool {
+	if !isNumericValueType(reading.ValueType) {
+		return false
+	}
+
+	if len(aggregation.resourceNames) == 0 {
+		return true
+	}
+
+	for _, name := range aggregation.resourceNames {
+		if reading.ResourceName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (aggregation *Aggregation) summary(deviceName string, window *aggregationWindow) (dtos.Event, error) {
+	summary := dtos.NewEvent(window.profileName, deviceName, window.sourceName)
+	summary.Tags = window.tags
+
+	for _, resource := range window.resources {
+		samples := window.samples[resource]
+		if len(samples) == 0 {
+			continue
+		}
+
+		current := &aggregate{min: math.Inf(1), max: math.Inf(-1)}
+		for _, sample := range samples {
+			current.add(sample.value)
+		}
+
+		for _, function := range aggregation.functions {
+			if err := summary.AddSimpleReading(resource+"_"+function, common.ValueTypeFloat64, current.result(function)); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+func (window *aggregationWindow) add(resource string, sample timedValue) {
+	if _, found := window.samples[resource]; !found {
+		window.resources = append(window.resources, resource)
+	}
+	window.samples[resource] = append(window.samples[resource], sample)
+}
+
+// expire removes the samples received before the start of the sliding window
+func (window *aggregationWindow) expire(start time.Time) {
+	for resource, samples := range window.samples {
+		index := 0
+		for index < len(samples) && samples[index].received.Before(start) {
+			index++
+		}
+		window.samples[resource] = samples[index:]
+	}
+}
+
+// isNumericValueType returns true for the value types of readings with a single numeric value
+func isNumericValueType(valueType string) bool {
+	switch valueType {
+	case common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+		common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64,
+		common.ValueTypeFloat32, common.ValueTypeFloat64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func aggregationEvent(t *testing.T, deviceName string, temperature float64, humidity uint8) dtos.Event {
+	event := dtos.NewEvent("thermostat", deviceName, "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, temperature))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeUint8, humidity))
+	require.NoError(t, event.AddSimpleReading("mode", common.ValueTypeString, "heat"))
+	return event
+}
+
+func readingValues(event dtos.Event) map[string]string {
+	values := make(map[string]string)
+	for _, reading := range event.Readings {
+		values[reading.ResourceName] = reading.Value
+	}
+	return values
+}
+
+func TestAggregation_Tumbling(t *testing.T) {
+	aggregation, err := NewTumblingAggregation(100*time.Millisecond, nil, []string{"temperature"})
+	require.NoError(t, err)
+
+	type aggregateResult struct {
+		continuePipeline bool
+		result           interface{}
+	}
+
+	opened := make(chan aggregateResult)
+	go func() {
+		continuePipeline, result := aggregation.Aggregate(ctx, aggregationEvent(t, "thermostat-1", 20, 40))
+		opened <- aggregateResult{continuePipeline, result}
+	}()
+
+	require.Eventually(t, func() bool {
+		aggregation.lock.Lock()
+		defer aggregation.lock.Unlock()
+		return aggregation.devices["thermostat-1"] != nil
+	}, time.Second, time.Millisecond)
+
+	for _, temperature := range []float64{22, 27} {
+		continuePipeline, result := aggregation.Aggregate(ctx, aggregationEvent(t, "thermostat-1", temperature, 40))
+		assert.False(t, continuePipeline)
+		assert.Nil(t, result)
+	}
+
+	select {
+	case closed := <-opened:
+		require.True(t, closed.continuePipeline, closed.result)
+		summary := closed.result.(dtos.Event)
+		assert.Equal(t, "thermostat-1", summary.DeviceName)
+		assert.Equal(t, map[string]string{
+			"temperature_min": "2.000000e+01",
+			"temperature_max": "2.700000e+01",
+			"temperature_avg": "2.300000e+01",
+			"temperature_sum": "6.900000e+01",
+		}, readingValues(summary))
+	case <-time.After(time.Second):
+		t.Fatal("aggregation window not closed")
+	}
+
+	aggregation.lock.Lock()
+	assert.Empty(t, aggregation.devices, "closed window should be removed")
+	aggregation.lock.Unlock()
+}
+
+func TestAggregation_Sliding(t *testing.T) {
+	aggregation, err := NewSlidingAggregation(time.Minute, []string{"MAX", "avg"}, nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	aggregation.now = func() time.Time { return now }
+
+	expected := []map[string]string{
+		{"temperature_max": "2.000000e+01", "temperature_avg": "2.000000e+01", "humidity_max": "4.000000e+01", "humidity_avg": "4.000000e+01"},
+		{"temperature_max": "2.600000e+01", "temperature_avg": "2.300000e+01", "humidity_max": "5.000000e+01", "humidity_avg": "4.500000e+01"},
+		// The first Event has left the window
+		{"temperature_max": "2.600000e+01", "temperature_avg": "2.400000e+01", "humidity_max": "5.000000e+01", "humidity_avg": "4.000000e+01"},
+	}
+
+	events := []dtos.Event{
+		aggregationEvent(t, "thermostat-1", 20, 40),
+		aggregationEvent(t, "thermostat-1", 26, 50),
+		aggregationEvent(t, "thermostat-1", 22, 30),
+	}
+
+	for index, event := range events {
+		continuePipeline, result := aggregation.Aggregate(ctx, event)
+		require.True(t, continuePipeline, result)
+		summary := result.(dtos.Event)
+		assert.Equal(t, event.Origin, summary.Origin)
+		assert.Equal(t, expected[index], readingValues(summary), "Event %d", index)
+
+		now = now.Add(40 * time.Second)
+	}
+
+	continuePipeline, result := aggregation.Aggregate(ctx, aggregationEvent(t, "thermostat-2", 10, 10))
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "1.000000e+01", readingValues(result.(dtos.Event))["temperature_max"], "each device should be aggregated separately")
+}
+
+func TestAggregation_Errors(t *testing.T) {
+	_, err := NewTumblingAggregation(0, nil, nil)
+	assert.Error(t, err)
+
+	_, err = NewSlidingAggregation(time.Minute, []string{"median"}, nil)
+	assert.Error(t, err)
+
+	badValue := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "temperature", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "warm"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "20", "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'temperature'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			aggregation, err := NewSlidingAggregation(time.Minute, nil, nil)
+			require.NoError(t, err)
+
+			continuePipeline, result := aggregation.Aggregate(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestAggregation_NoNumericReadings(t *testing.T) {
+	aggregation, err := NewTumblingAggregation(time.Minute, nil, []string{"pressure"})
+	require.NoError(t, err)
+
+	continuePipeline, result := aggregation.Aggregate(ctx, aggregationEvent(t, "thermostat-1", 20, 40))
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+	assert.Empty(t, aggregation.devices)
+}
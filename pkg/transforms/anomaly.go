@@ -0,0 +1,247 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// AnomaliesTag is the Event tag listing the resource names of the readings detected as anomalies when they are tagged
+const AnomaliesTag = "anomalies"
+
+// baseline is the statistics of the values of a single device resource that a new value is compared to
+type baseline interface {
+	// score returns how many standard deviations the value is from the expected value, or false while there are
+	// too few values to tell
+	score(value float64) (float64, bool)
+	add(value float64)
+}
+
+// AnomalyDetector detects the numeric readings whose values deviate from the recent values of the same device and
+// resource by more than a threshold number of standard deviations, and either tags the Event with the readings'
+// resource names or drops the readings. The statistics are kept per device and resource.
+type AnomalyDetector struct {
+	newBaseline   func() baseline
+	threshold     float64
+	drop          bool
+	resourceNames []string
+	lock          sync.Mutex
+	baselines     map[string]baseline
+}
+
+// NewZScoreAnomalyDetector creates, initializes and returns a new instance of AnomalyDetector that compares each value
+// to the mean and standard deviation of the previous windowSize values. Values are only checked once windowSize
+// values have been received. drop removes the anomalous readings rather than tagging the Event. resourceNames, when not
+// empty, limits the readings checked, otherwise all numeric readings are checked.
+func NewZScoreAnomalyDetector(windowSize int, threshold float64, drop bool, resourceNames []string) *AnomalyDetector {
+	return newAnomalyDetector(func() baseline { return &windowBaseline{size: windowSize} }, threshold, drop, resourceNames)
+}
+
+// NewEWMAAnomalyDetector creates, initializes and returns a new instance of AnomalyDetector that compares each value
+// to the exponentially weighted moving average and standard deviation of the previous values. alpha, from 0 to 1, is
+// the weight of the newest value, so lower values follow changes in the values more slowly. Values are only checked
+// once 1/alpha values have been received. drop removes the anomalous readings rather than tagging the Event.
+// resourceNames, when not empty, limits the readings checked, otherwise all numeric readings are checked.
+func NewEWMAAnomalyDetector(alpha float64, threshold float64, drop bool, resourceNames []string) *AnomalyDetector {
+	warmUp := int(math.Ceil(1 / alpha))
+	return newAnomalyDetector(func() baseline { return &ewmaBaseline{alpha: alpha, warmUp: warmUp} }, threshold, drop, resourceNames)
+}
+
+func newAnomalyDetector(newBaseline func() baseline, threshold float64, drop bool, resourceNames []string) *AnomalyDetector {
+	return &AnomalyDetector{
+		newBaseline:   newBaseline,
+		threshold:     threshold,
+		drop:          drop,
+		resourceNames: resourceNames,
+		baselines:     make(map[string]baseline),
+	}
+}
+
+// DetectAnomalies checks the Event's numeric readings and either sets the AnomaliesTag of the Event to the comma
+// separated resource names of the anomalous readings or removes them. Every value checked, anomalous or not, is added
+// to the statistics so they follow lasting changes in the values. The pipeline is stopped if all the readings are
+// dropped. This function will return an error and stop the pipeline if a non-edgex event is received, no data is
+// received or a reading's value can't be parsed.
+func (detector *AnomalyDetector) DetectAnomalies(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function DetectAnomalies in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function DetectAnomalies in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Detecting anomalous readings in pipeline '%s'", ctx.PipelineId())
+
+	var anomalies []string
+	readings := make([]dtos.BaseReading, 0, len(event.Readings))
+
+	detector.lock.Lock()
+	for _, reading := range event.Readings {
+		anomalous, err := detector.check(event.DeviceName, reading)
+		if err != nil {
+			detector.lock.Unlock()
+			return false, fmt.Errorf("function DetectAnomalies in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+
+		if anomalous {
+			anomalies = append(anomalies, reading.ResourceName)
+			if detector.drop {
+				continue
+			}
+		}
+
+		readings = append(readings, reading)
+	}
+	detector.lock.Unlock()
+
+	if len(anomalies) == 0 {
+		return true, event
+	}
+
+	ctx.LoggingClient().Debugf("Anomalous readings %v of device '%s' detected in pipeline '%s'", anomalies, event.DeviceName, ctx.PipelineId())
+
+	if detector.drop {
+		if len(readings) == 0 {
+			return false, nil
+		}
+		event.Readings = readings
+		return true, event
+	}
+
+	// Copy the tags so the tags of the received Event are not modified
+	tags := make(map[string]interface{}, len(event.Tags)+1)
+	for name, value := range event.Tags {
+		tags[name] = value
+	}
+	tags[AnomaliesTag] = strings.Join(anomalies, ",")
+	event.Tags = tags
+
+	return true, event
+}
+
+// check returns true if the reading's value is anomalous and adds the value to the statistics of its resource
+func (detector *AnomalyDetector) check(deviceName string, reading dtos.BaseReading) (bool, error) {
+	if !isNumericReading(reading) || !selectsResource(detector.resourceNames, reading.ResourceName) {
+		return false, nil
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse value of reading '%s': %s", reading.ResourceName, err.Error())
+	}
+
+	key := deviceName + "/" + reading.ResourceName
+	state, found := detector.baselines[key]
+	if !found {
+		state = detector.newBaseline()
+		detector.baselines[key] = state
+	}
+
+	score, ready := state.score(value)
+	state.add(value)
+
+	return ready && score > detector.threshold, nil
+}
+
+// windowBaseline keeps the last size values in a ring buffer
+type windowBaseline struct {
+	size   int
+	values []float64
+	next   int
+}
+
+func (window *windowBaseline) score(value float64) (float64, bool) {
+	if len(window.values) < window.size {
+		return 0, false
+	}
+
+	var mean float64
+	for _, item := range window.values {
+		mean += item
+	}
+	mean /= float64(len(window.values))
+
+	var variance float64
+	for _, item := range window.values {
+		variance += (item - mean) * (item - mean)
+	}
+	variance /= float64(len(window.values))
+
+	return deviations(value, mean, variance), true
+}
+
+func (window *windowBaseline) add(value float64) {
+	if len(window.values) < window.size {
+		window.values = append(window.values, value)
+		return
+	}
+
+	window.values[window.next] = value
+	window.next = (window.next + 1) % window.size
+}
+
+type ewmaBaseline struct {
+	alpha    float64
+	warmUp   int
+	count    int
+	mean     float64
+	variance float64
+}
+
+func (ewma *ewmaBaseline) score(value float64) (float64, bool) {
+	if ewma.count < ewma.warmUp {
+		return 0, false
+	}
+	return deviations(value, ewma.mean, ewma.variance), true
+}
+
+func (ewma *ewmaBaseline) add(value float64) {
+	ewma.count++
+	if ewma.count == 1 {
+		ewma.mean = value
+		return
+	}
+
+	difference := value - ewma.mean
+	increment := ewma.alpha * difference
+	ewma.mean += increment
+	ewma.variance = (1 - ewma.alpha) * (ewma.variance + difference*increment)
+}
+
+// deviations returns the number of standard deviations the value is from the mean. Any other value is infinitely far
+// from values that haven't varied.
+func deviations(value float64, mean float64, variance float64) float64 {
+	difference := math.Abs(value - mean)
+	if variance == 0 {
+		if difference == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return difference / math.Sqrt(variance)
+}
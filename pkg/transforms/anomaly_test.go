@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func anomalyEvent(t *testing.T, deviceName string, temperature float64, humidity int32) dtos.Event {
+	event := dtos.NewEvent("thermostat", deviceName, "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, temperature))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeInt32, humidity))
+	return event
+}
+
+func TestAnomalyDetector_ZScoreTag(t *testing.T) {
+	detector := NewZScoreAnomalyDetector(4, 3, false, nil)
+
+	for _, temperature := range []float64{20, 21, 19, 20} {
+		continuePipeline, result := detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-1", temperature, 40))
+		require.True(t, continuePipeline, result)
+		assert.Empty(t, result.(dtos.Event).Tags, "values should not be checked until the window is full")
+	}
+
+	continuePipeline, result := detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-1", 20.5, 40))
+	require.True(t, continuePipeline, result)
+	assert.Empty(t, result.(dtos.Event).Tags, "value within the threshold should not be an anomaly")
+
+	received := anomalyEvent(t, "thermostat-1", 35, 90)
+	received.Tags = map[string]interface{}{"site": "plant-1"}
+	continuePipeline, result = detector.DetectAnomalies(ctx, received)
+	require.True(t, continuePipeline, result)
+	tagged := result.(dtos.Event)
+	assert.Equal(t, "temperature,humidity", tagged.Tags[AnomaliesTag])
+	assert.Equal(t, "plant-1", tagged.Tags["site"])
+	assert.Len(t, tagged.Readings, 2, "readings should be kept when tagging")
+	assert.NotContains(t, received.Tags, AnomaliesTag, "received Event should not be modified")
+
+	continuePipeline, result = detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-2", 35, 90))
+	require.True(t, continuePipeline, result)
+	assert.Empty(t, result.(dtos.Event).Tags, "each device should have its own statistics")
+}
+
+func TestAnomalyDetector_EWMADrop(t *testing.T) {
+	detector := NewEWMAAnomalyDetector(0.5, 2, true, []string{"temperature"})
+
+	for _, temperature := range []float64{20, 22} {
+		continuePipeline, result := detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-1", temperature, 40))
+		require.True(t, continuePipeline, result)
+		assert.Len(t, result.(dtos.Event).Readings, 2)
+	}
+
+	continuePipeline, result := detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-1", 50, 99))
+	require.True(t, continuePipeline, result)
+	dropped := result.(dtos.Event)
+	require.Len(t, dropped.Readings, 1, "anomalous reading should be dropped")
+	assert.Equal(t, "humidity", dropped.Readings[0].ResourceName, "readings not in resource names should not be checked")
+	assert.Empty(t, dropped.Tags)
+
+	onlyTemperature := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	require.NoError(t, onlyTemperature.AddSimpleReading("temperature", common.ValueTypeFloat64, -100.0))
+	continuePipeline, result = detector.DetectAnomalies(ctx, onlyTemperature)
+	assert.False(t, continuePipeline, "pipeline should stop when all readings are dropped")
+	assert.Nil(t, result)
+}
+
+func TestAnomalyDetector_ConstantValues(t *testing.T) {
+	detector := NewZScoreAnomalyDetector(2, 3, false, nil)
+
+	for _, humidity := range []int32{40, 40, 40} {
+		continuePipeline, result := detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-1", 20, humidity))
+		require.True(t, continuePipeline, result)
+		assert.Empty(t, result.(dtos.Event).Tags)
+	}
+
+	continuePipeline, result := detector.DetectAnomalies(ctx, anomalyEvent(t, "thermostat-1", 20, 41))
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "humidity", result.(dtos.Event).Tags[AnomaliesTag], "any change from constant values should be an anomaly")
+}
+
+func TestAnomalyDetector_Errors(t *testing.T) {
+	badValue := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "temperature", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "hot"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "20", "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'temperature'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewZScoreAnomalyDetector(10, 3, false, nil).DetectAnomalies(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,250 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// ContentTypeAvro is the content type of the payloads created by TransformToAvro
+	ContentTypeAvro = "avro/binary"
+
+	schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+	schemaRegistryUsername    = "username"
+	schemaRegistryPassword    = "password"
+	// schemaRegistryMagicByte starts the Confluent wire format, followed by the 4 byte schema id
+	schemaRegistryMagicByte = 0
+)
+
+// AvroSchemaRegistryConfig contains the configuration for using a Confluent compatible schema registry
+type AvroSchemaRegistryConfig struct {
+	// URL of the schema registry, i.e. http://schema-registry:8081
+	URL string
+	// Subject the schema is registered under, i.e. "edgex-events-value" when following the topic name strategy
+	Subject string
+	// AutoRegister registers the schema under the subject when it isn't already, otherwise the schema must have been
+	// registered in advance
+	AutoRegister bool
+	// SecretPath is the optional path in the secret store of the username and password secrets used for basic
+	// authentication with the schema registry
+	SecretPath string
+}
+
+// AvroSerializer serializes data to the Avro binary encoding of a schema. When a schema registry is used the data is
+// prefixed with the id of the schema in the registry, using the Confluent wire format expected by Kafka consumers
+// using the registry.
+type AvroSerializer struct {
+	registry *AvroSchemaRegistryConfig
+	lock     sync.Mutex
+	codec    *goavro.Codec
+	schemaId uint32
+	resolved bool
+}
+
+// NewAvroSerializer creates, initializes and returns a new instance of AvroSerializer for the schema
+func NewAvroSerializer(schema string) (*AvroSerializer, error) {
+	codec, err := newAvroCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AvroSerializer{codec: codec, resolved: true}, nil
+}
+
+// NewAvroSerializerWithRegistry creates, initializes and returns a new instance of AvroSerializer that looks up, or
+// registers, the schema in a schema registry. When the schema is empty the latest version of the schema registered
+// under the subject is used. The registry is contacted when the first data is serialized, and again for the next data
+// if that fails.
+func NewAvroSerializerWithRegistry(schema string, registry AvroSchemaRegistryConfig) (*AvroSerializer, error) {
+	if _, err := url.Parse(registry.URL); err != nil || registry.URL == "" {
+		return nil, fmt.Errorf("invalid schema registry URL '%s'", registry.URL)
+	}
+
+	if registry.Subject == "" {
+		return nil, errors.New("schema registry subject required")
+	}
+
+	serializer := &AvroSerializer{registry: &registry}
+	if schema != "" {
+		codec, err := newAvroCodec(schema)
+		if err != nil {
+			return nil, err
+		}
+		serializer.codec = codec
+	}
+
+	return serializer, nil
+}
+
+func newAvroCodec(schema string) (*goavro.Codec, error) {
+	// Unions are given as their plain JSON value, rather than wrapped in an object naming the type
+	codec, err := goavro.NewCodecForStandardJSON(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema: %s", err.Error())
+	}
+	return codec, nil
+}
+
+// TransformToAvro serializes the data, which is JSON, or any value that marshals to JSON such as an Event, to the
+// Avro binary encoding of the schema and returns the encoded []byte. The JSON must match the schema, fields that are
+// not in the schema are rejected, so the data may have to be shaped by a previous function such as
+// ExtractByJSONPath or TransformWithTemplate.
+// This function will return an error and stop the pipeline if no data is received, the data doesn't match the schema
+// or the schema registry can't be reached.
+func (serializer *AvroSerializer) TransformToAvro(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function TransformToAvro in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Transforming to Avro in pipeline '%s'", ctx.PipelineId())
+
+	codec, schemaId, err := serializer.resolve(ctx)
+	if err != nil {
+		return false, fmt.Errorf("function TransformToAvro in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	content, err := util.CoerceType(data)
+	if err != nil {
+		return false, fmt.Errorf("function TransformToAvro in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	native, _, err := codec.NativeFromTextual(content)
+	if err != nil {
+		return false, fmt.Errorf("function TransformToAvro in pipeline '%s': data does not match the Avro schema: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	var encoded []byte
+	if serializer.registry != nil {
+		encoded = make([]byte, 5)
+		encoded[0] = schemaRegistryMagicByte
+		binary.BigEndian.PutUint32(encoded[1:], schemaId)
+	}
+
+	encoded, err = codec.BinaryFromNative(encoded, native)
+	if err != nil {
+		return false, fmt.Errorf("function TransformToAvro in pipeline '%s': unable to encode Avro data: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	ctx.SetResponseContentType(ContentTypeAvro)
+	return true, encoded
+}
+
+// resolve returns the codec and the schema's id in the registry, looking up the schema in the registry the first time
+func (serializer *AvroSerializer) resolve(ctx interfaces.AppFunctionContext) (*goavro.Codec, uint32, error) {
+	serializer.lock.Lock()
+	defer serializer.lock.Unlock()
+
+	if serializer.resolved {
+		return serializer.codec, serializer.schemaId, nil
+	}
+
+	registry := serializer.registry
+	subjectUrl := strings.TrimSuffix(registry.URL, "/") + "/subjects/" + url.PathEscape(registry.Subject)
+
+	var response struct {
+		Id     uint32 `json:"id"`
+		Schema string `json:"schema"`
+	}
+
+	var err error
+	switch {
+	case serializer.codec == nil:
+		err = serializer.callRegistry(ctx, http.MethodGet, subjectUrl+"/versions/latest", "", &response)
+		if err == nil {
+			serializer.codec, err = newAvroCodec(response.Schema)
+		}
+	case registry.AutoRegister:
+		err = serializer.callRegistry(ctx, http.MethodPost, subjectUrl+"/versions", serializer.codec.Schema(), &response)
+	default:
+		err = serializer.callRegistry(ctx, http.MethodPost, subjectUrl, serializer.codec.Schema(), &response)
+	}
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to resolve schema of subject '%s' in schema registry: %s", registry.Subject, err.Error())
+	}
+
+	ctx.LoggingClient().Infof("Using Avro schema id %d of subject '%s' from schema registry", response.Id, registry.Subject)
+	serializer.schemaId = response.Id
+	serializer.resolved = true
+
+	return serializer.codec, serializer.schemaId, nil
+}
+
+func (serializer *AvroSerializer) callRegistry(ctx interfaces.AppFunctionContext, method string, requestUrl string,
+	schema string, response interface{}) error {
+	var body *bytes.Reader
+	if schema != "" {
+		content, err := json.Marshal(map[string]string{"schema": schema})
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(content)
+	} else {
+		body = bytes.NewReader(nil)
+	}
+
+	request, err := http.NewRequestWithContext(ctx.Context(), method, requestUrl, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", schemaRegistryContentType)
+	if schema != "" {
+		request.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+
+	if serializer.registry.SecretPath != "" {
+		secrets, err := ctx.GetSecret(serializer.registry.SecretPath, schemaRegistryUsername, schemaRegistryPassword)
+		if err != nil {
+			return err
+		}
+		request.SetBasicAuth(secrets[schemaRegistryUsername], secrets[schemaRegistryPassword])
+	}
+
+	httpResponse, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = httpResponse.Body.Close() }()
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
+		// The registry's error responses contain an error_code and message
+		var registryError struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(httpResponse.Body).Decode(&registryError)
+		return fmt.Errorf("schema registry responded with %d HTTP status code: %s", httpResponse.StatusCode, registryError.Message)
+	}
+
+	return json.NewDecoder(httpResponse.Body).Decode(response)
+}
@@ -0,0 +1,182 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const avroTestSchema = `{
+	"type": "record",
+	"name": "Reading",
+	"fields": [
+		{"name": "deviceName", "type": "string"},
+		{"name": "value", "type": "double"},
+		{"name": "units", "type": ["null", "string"], "default": null}
+	]
+}`
+
+func decodeAvro(t *testing.T, encoded []byte) map[string]interface{} {
+	codec, err := goavro.NewCodecForStandardJSON(avroTestSchema)
+	require.NoError(t, err)
+	native, remaining, err := codec.NativeFromBinary(encoded)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+	return native.(map[string]interface{})
+}
+
+func TestAvroSerializer_TransformToAvro(t *testing.T) {
+	serializer, err := NewAvroSerializer(avroTestSchema)
+	require.NoError(t, err)
+
+	continuePipeline, result := serializer.TransformToAvro(ctx, `{"deviceName": "thermostat-1", "value": 21.5, "units": "C"}`)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, ContentTypeAvro, ctx.ResponseContentType())
+
+	decoded := decodeAvro(t, result.([]byte))
+	assert.Equal(t, "thermostat-1", decoded["deviceName"])
+	assert.Equal(t, 21.5, decoded["value"])
+	assert.Equal(t, map[string]interface{}{"string": "C"}, decoded["units"])
+
+	continuePipeline, result = serializer.TransformToAvro(ctx, map[string]interface{}{"deviceName": "thermostat-2", "value": 3})
+	require.True(t, continuePipeline, result)
+	decoded = decodeAvro(t, result.([]byte))
+	assert.Equal(t, "thermostat-2", decoded["deviceName"])
+	assert.Nil(t, decoded["units"], "missing optional field should have its default")
+}
+
+func TestAvroSerializer_TransformToAvro_Errors(t *testing.T) {
+	serializer, err := NewAvroSerializer(avroTestSchema)
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Missing field", `{"deviceName": "thermostat-1"}`, "data does not match the Avro schema"},
+		{"Wrong type", `{"deviceName": "thermostat-1", "value": "warm"}`, "data does not match the Avro schema"},
+		{"Event", dtos.NewEvent("thermostat", "thermostat-1", "status"), "data does not match the Avro schema"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := serializer.TransformToAvro(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestNewAvroSerializer_Errors(t *testing.T) {
+	_, err := NewAvroSerializer(`{"type": "record"}`)
+	assert.Error(t, err)
+
+	_, err = NewAvroSerializerWithRegistry(avroTestSchema, AvroSchemaRegistryConfig{Subject: "readings-value"})
+	assert.Error(t, err, "registry URL is required")
+
+	_, err = NewAvroSerializerWithRegistry(avroTestSchema, AvroSchemaRegistryConfig{URL: "http://localhost:8081"})
+	assert.Error(t, err, "subject is required")
+}
+
+// fakeSchemaRegistry responds to the schema registry requests, with the schema registered as id 7 once registered
+type fakeSchemaRegistry struct {
+	registered bool
+	requests   []string
+}
+
+func (registry *fakeSchemaRegistry) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	registry.requests = append(registry.requests, request.Method+" "+request.URL.Path)
+
+	writer.Header().Set("Content-Type", schemaRegistryContentType)
+	switch {
+	case request.Method == http.MethodPost && request.URL.Path == "/subjects/readings-value/versions":
+		var body map[string]string
+		if err := json.NewDecoder(request.Body).Decode(&body); err != nil || body["schema"] == "" {
+			writer.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		registry.registered = true
+		_, _ = writer.Write([]byte(`{"id": 7}`))
+	case !registry.registered:
+		writer.WriteHeader(http.StatusNotFound)
+		_, _ = writer.Write([]byte(`{"error_code": 40401, "message": "Subject 'readings-value' not found."}`))
+	case request.Method == http.MethodPost && request.URL.Path == "/subjects/readings-value":
+		_, _ = writer.Write([]byte(`{"subject": "readings-value", "version": 1, "id": 7}`))
+	case request.Method == http.MethodGet && request.URL.Path == "/subjects/readings-value/versions/latest":
+		content, _ := json.Marshal(map[string]interface{}{"subject": "readings-value", "version": 1, "id": 7, "schema": avroTestSchema})
+		_, _ = writer.Write(content)
+	default:
+		writer.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestAvroSerializer_TransformToAvro_SchemaRegistry(t *testing.T) {
+	registry := &fakeSchemaRegistry{}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	data := `{"deviceName": "thermostat-1", "value": 21.5}`
+
+	assertFramed := func(result interface{}) {
+		encoded := result.([]byte)
+		require.Greater(t, len(encoded), 5)
+		assert.Equal(t, byte(schemaRegistryMagicByte), encoded[0])
+		assert.Equal(t, uint32(7), binary.BigEndian.Uint32(encoded[1:5]))
+		assert.Equal(t, "thermostat-1", decodeAvro(t, encoded[5:])["deviceName"])
+	}
+
+	lookup, err := NewAvroSerializerWithRegistry(avroTestSchema, AvroSchemaRegistryConfig{URL: server.URL, Subject: "readings-value"})
+	require.NoError(t, err)
+	continuePipeline, result := lookup.TransformToAvro(ctx, data)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Subject 'readings-value' not found")
+
+	register, err := NewAvroSerializerWithRegistry(avroTestSchema, AvroSchemaRegistryConfig{URL: server.URL, Subject: "readings-value", AutoRegister: true})
+	require.NoError(t, err)
+	continuePipeline, result = register.TransformToAvro(ctx, data)
+	require.True(t, continuePipeline, result)
+	assertFramed(result)
+
+	// The lookup is retried after the failure
+	continuePipeline, result = lookup.TransformToAvro(ctx, data)
+	require.True(t, continuePipeline, result)
+	assertFramed(result)
+
+	latest, err := NewAvroSerializerWithRegistry("", AvroSchemaRegistryConfig{URL: server.URL, Subject: "readings-value"})
+	require.NoError(t, err)
+	continuePipeline, result = latest.TransformToAvro(ctx, data)
+	require.True(t, continuePipeline, result)
+	assertFramed(result)
+
+	// The schema id is cached after being resolved
+	requests := len(registry.requests)
+	continuePipeline, result = latest.TransformToAvro(ctx, data)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, requests, len(registry.requests))
+}
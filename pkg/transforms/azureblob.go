@@ -0,0 +1,444 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// AzureBlobTypeBlock writes each export to a new block blob
+	AzureBlobTypeBlock = "block"
+	// AzureBlobTypeAppend appends each export to an append blob, created when it doesn't exist, i.e. a telemetry file
+	AzureBlobTypeAppend = "append"
+
+	// AzureAuthModeSAS authorizes the requests with the shared access signature in the secret
+	AzureAuthModeSAS = "sas"
+	// AzureAuthModeManagedIdentity authorizes the requests with a token of the VM's managed identity
+	AzureAuthModeManagedIdentity = "managedidentity"
+
+	// AzureSASTokenKey is the key of the shared access signature in the Azure secret
+	AzureSASTokenKey = "sastoken"
+
+	// DefaultAzureIdentityEndpoint is the Azure Instance Metadata Service issuing the managed identity tokens
+	DefaultAzureIdentityEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+	// AzureMaxAppendBlockSize is the largest export that can be appended to an append blob
+	AzureMaxAppendBlockSize = 4 * 1024 * 1024
+
+	azureStorageVersion  = "2020-10-02"
+	azureStorageResource = "https://storage.azure.com/"
+	// azureTokenRenewal is how long before their expiry the managed identity tokens are renewed
+	azureTokenRenewal = 5 * time.Minute
+)
+
+// AzureBlobConfig contains the configuration of an AzureBlobSender
+type AzureBlobConfig struct {
+	// AccountURL is the blob service of the storage account, i.e. "https://myaccount.blob.core.windows.net" or
+	// Azurite's "http://azurite:10000/devstoreaccount1". Data Lake Storage Gen2 accounts are written via their blob
+	// service.
+	AccountURL string
+	// Container the blobs are written to
+	Container string
+	// BlobTemplate is the name of the blobs, with the same placeholders as the ObjectStorageConfig's KeyTemplate, i.e.
+	// "{date}/{device}/{uuid}.json" for block blobs or "{device}/{date}.ndjson" for append blobs
+	BlobTemplate string
+	// BlobType is AzureBlobTypeBlock, the default, or AzureBlobTypeAppend
+	BlobType string
+	// AuthMode is AzureAuthModeSAS, the default, or AzureAuthModeManagedIdentity
+	AuthMode string
+	// SecretPath is the path of the "sastoken" secret for AzureAuthModeSAS
+	SecretPath string
+	// ClientId is the optional client id of the user-assigned managed identity for AzureAuthModeManagedIdentity
+	ClientId string
+	// IdentityEndpoint issues the managed identity tokens, DefaultAzureIdentityEndpoint when empty
+	IdentityEndpoint string
+	// ContentType of the blobs, application/json when empty
+	ContentType string
+	// Format is HTTPBatchFormatJSON or HTTPBatchFormatNDJSON to combine a batch, i.e. from the Batch function, into a
+	// single write. The data is written as is when empty.
+	Format string
+	// Compress enables gzip encoding of the blobs, appended blocks being separate gzip members of the append blob
+	Compress bool
+	// PersistOnError enables use of store & forward when the write fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// AzureBlobSender writes data to Azure Blob Storage, as block blobs or appended to append blobs
+type AzureBlobSender struct {
+	config AzureBlobConfig
+	client *http.Client
+	now    func() time.Time
+	lock   sync.Mutex
+	// created holds the append blobs known to exist, so they are only created once
+	created map[string]bool
+	token   string
+	expiry  time.Time
+}
+
+// NewAzureBlobSender creates, initializes and returns a new instance of AzureBlobSender
+func NewAzureBlobSender(config AzureBlobConfig) (*AzureBlobSender, error) {
+	config.AccountURL = strings.TrimRight(strings.TrimSpace(config.AccountURL), "/")
+	config.Container = strings.TrimSpace(config.Container)
+	config.BlobTemplate = strings.TrimLeft(strings.TrimSpace(config.BlobTemplate), "/")
+	config.SecretPath = strings.TrimSpace(config.SecretPath)
+
+	if accountUrl, err := url.Parse(config.AccountURL); err != nil || len(accountUrl.Host) == 0 {
+		return nil, fmt.Errorf("invalid account URL '%s'", config.AccountURL)
+	}
+	if len(config.Container) == 0 {
+		return nil, errors.New("container must be set")
+	}
+	if len(config.BlobTemplate) == 0 {
+		return nil, errors.New("blob template must be set")
+	}
+
+	config.BlobType = strings.ToLower(strings.TrimSpace(config.BlobType))
+	switch config.BlobType {
+	case "":
+		config.BlobType = AzureBlobTypeBlock
+	case AzureBlobTypeBlock, AzureBlobTypeAppend:
+	default:
+		return nil, fmt.Errorf("invalid blob type '%s', must be '%s' or '%s'", config.BlobType, AzureBlobTypeBlock, AzureBlobTypeAppend)
+	}
+
+	config.AuthMode = strings.ToLower(strings.TrimSpace(config.AuthMode))
+	switch config.AuthMode {
+	case "", AzureAuthModeSAS:
+		config.AuthMode = AzureAuthModeSAS
+		if len(config.SecretPath) == 0 {
+			return nil, fmt.Errorf("secret path must be set for '%s' auth", AzureAuthModeSAS)
+		}
+	case AzureAuthModeManagedIdentity:
+		if len(strings.TrimSpace(config.IdentityEndpoint)) == 0 {
+			config.IdentityEndpoint = DefaultAzureIdentityEndpoint
+		}
+	default:
+		return nil, fmt.Errorf("invalid auth mode '%s', must be '%s' or '%s'", config.AuthMode, AzureAuthModeSAS, AzureAuthModeManagedIdentity)
+	}
+
+	config.Format = strings.ToLower(strings.TrimSpace(config.Format))
+	if config.Format != "" && config.Format != HTTPBatchFormatJSON && config.Format != HTTPBatchFormatNDJSON {
+		return nil, fmt.Errorf("invalid format '%s', must be '%s' or '%s'", config.Format, HTTPBatchFormatJSON, HTTPBatchFormatNDJSON)
+	}
+
+	if len(strings.TrimSpace(config.ContentType)) == 0 {
+		config.ContentType = common.ContentTypeJSON
+		if config.Format == HTTPBatchFormatNDJSON {
+			config.ContentType = ContentTypeNDJSON
+		}
+	}
+
+	return &AzureBlobSender{
+		config:  config,
+		client:  &http.Client{},
+		now:     time.Now,
+		created: make(map[string]bool),
+	}, nil
+}
+
+// PutBlob writes the data from the previous function to a new block blob, or appends it to the append blob, whose
+// name is resolved from the blob template. A newline is added to the data appended to an append blob when it doesn't
+// end with one, so each export is a line of the telemetry file.
+// This function will return an error and stop the pipeline if no data is received or the write fails.
+func (sender *AzureBlobSender) PutBlob(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function PutBlob in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	var exportData []byte
+	var err error
+	if len(sender.config.Format) > 0 {
+		exportData, _, err = combineBatch(data, sender.config.Format)
+	} else {
+		exportData, err = util.CoerceType(data)
+	}
+	if err != nil {
+		return false, fmt.Errorf("function PutBlob in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if sender.config.BlobType == AzureBlobTypeAppend && !bytes.HasSuffix(exportData, []byte("\n")) {
+		exportData = append(exportData, '\n')
+	}
+
+	blobName, err := formatObjectKey(ctx, sender.config.BlobTemplate, sender.now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("function PutBlob in pipeline '%s': unable to format blob name: %s", ctx.PipelineId(), err.Error())
+	}
+	destination := sender.config.Container + "/" + blobName
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not write %d bytes of data to %s", ctx.PipelineId(), len(exportData), destination)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, sender.config.CircuitBreaker, sender.config.AccountURL, sender.config.PersistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, sender.config.AccountURL+"/"+sender.config.Container)
+
+	if sender.config.BlobType == AzureBlobTypeAppend {
+		err = sender.appendBlock(ctx, blobName, exportData)
+	} else {
+		err = sender.putBlockBlob(ctx, blobName, exportData)
+	}
+	if err != nil {
+		err = fmt.Errorf("function PutBlob in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.config.CircuitBreaker.Failed()
+		sender.setRetryData(ctx, exportData)
+		return false, err
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(destination)
+
+	ctx.LoggingClient().Debugf("Wrote %d bytes of data to %s in pipeline '%s'", len(exportData), destination, ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// putBlockBlob writes the data to a new block blob, replacing any blob with the same name
+func (sender *AzureBlobSender) putBlockBlob(ctx interfaces.AppFunctionContext, blobName string, exportData []byte) error {
+	content, err := sender.encode(exportData)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("x-ms-blob-type", "BlockBlob")
+	header.Set("Content-Type", sender.config.ContentType)
+	if sender.config.Compress {
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	_, err = sender.send(ctx, blobName, "", header, content, http.StatusCreated)
+	return err
+}
+
+// appendBlock appends the data to the append blob, creating it first when it isn't known to exist
+func (sender *AzureBlobSender) appendBlock(ctx interfaces.AppFunctionContext, blobName string, exportData []byte) error {
+	content, err := sender.encode(exportData)
+	if err != nil {
+		return err
+	}
+	if len(content) > AzureMaxAppendBlockSize {
+		return fmt.Errorf("%d bytes of data exceed the %d bytes append block limit", len(content), AzureMaxAppendBlockSize)
+	}
+
+	sender.lock.Lock()
+	created := sender.created[blobName]
+	sender.lock.Unlock()
+
+	if !created {
+		header := http.Header{}
+		header.Set("x-ms-blob-type", "AppendBlob")
+		header.Set("x-ms-blob-content-type", sender.config.ContentType)
+		if sender.config.Compress {
+			header.Set("x-ms-blob-content-encoding", "gzip")
+		}
+		// Only create the blob when it doesn't exist, so the blob written before a restart is appended to
+		header.Set("If-None-Match", "*")
+
+		status, err := sender.send(ctx, blobName, "", header, nil, http.StatusCreated, http.StatusConflict)
+		if err != nil {
+			return fmt.Errorf("unable to create append blob: %s", err.Error())
+		}
+		if status == http.StatusCreated {
+			ctx.LoggingClient().Infof("Created append blob %s/%s", sender.config.Container, blobName)
+		}
+
+		sender.lock.Lock()
+		sender.created[blobName] = true
+		sender.lock.Unlock()
+	}
+
+	status, err := sender.send(ctx, blobName, "comp=appendblock", http.Header{}, content, http.StatusCreated, http.StatusNotFound)
+	if err != nil {
+		return err
+	}
+
+	if status == http.StatusNotFound {
+		// The blob has been deleted, so it is created again when the export is retried
+		sender.lock.Lock()
+		delete(sender.created, blobName)
+		sender.lock.Unlock()
+		return fmt.Errorf("append blob %s/%s not found", sender.config.Container, blobName)
+	}
+
+	return nil
+}
+
+// encode gzip encodes the data when compression is enabled
+func (sender *AzureBlobSender) encode(exportData []byte) ([]byte, error) {
+	if !sender.config.Compress {
+		return exportData, nil
+	}
+
+	content, err := gzipBody(exportData)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compress blob: %s", err.Error())
+	}
+	return content, nil
+}
+
+// send sends the authorized PUT request for the blob, returning the status code when it is one of those expected
+func (sender *AzureBlobSender) send(ctx interfaces.AppFunctionContext, blobName string, query string, header http.Header,
+	content []byte, expected ...int) (int, error) {
+	blobUrl, err := url.Parse(sender.config.AccountURL)
+	if err != nil {
+		return 0, err
+	}
+	blobUrl.Path = blobUrl.Path + "/" + sender.config.Container + "/" + blobName
+	blobUrl.RawQuery = query
+
+	if sender.config.AuthMode == AzureAuthModeSAS {
+		secrets, err := ctx.GetSecret(sender.config.SecretPath, AzureSASTokenKey)
+		if err != nil {
+			return 0, err
+		}
+		if sas := strings.TrimPrefix(secrets[AzureSASTokenKey], "?"); len(sas) > 0 {
+			if len(blobUrl.RawQuery) > 0 {
+				blobUrl.RawQuery += "&"
+			}
+			blobUrl.RawQuery += sas
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPut, blobUrl.String(), bytes.NewReader(content))
+	if err != nil {
+		return 0, err
+	}
+	req.Header = header
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", sender.now().UTC().Format(http.TimeFormat))
+
+	if sender.config.AuthMode == AzureAuthModeManagedIdentity {
+		token, err := sender.managedIdentityToken(ctx)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	response, err := sender.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	for _, status := range expected {
+		if response.StatusCode == status {
+			_, _ = io.Copy(io.Discard, response.Body)
+			return status, nil
+		}
+	}
+
+	responseBody, _ := io.ReadAll(response.Body)
+	return response.StatusCode, fmt.Errorf("blob storage responded with %d HTTP status code (%s): %s",
+		response.StatusCode, response.Header.Get("x-ms-error-code"), strings.TrimSpace(string(responseBody)))
+}
+
+// azureIdentityToken is the response of the managed identity endpoint
+type azureIdentityToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresOn   string `json:"expires_on"`
+}
+
+// managedIdentityToken returns the cached managed identity token, requesting a new one when it is about to expire
+func (sender *AzureBlobSender) managedIdentityToken(ctx interfaces.AppFunctionContext) (string, error) {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	now := sender.now()
+	if len(sender.token) > 0 && now.Add(azureTokenRenewal).Before(sender.expiry) {
+		return sender.token, nil
+	}
+
+	identityUrl, err := url.Parse(sender.config.IdentityEndpoint)
+	if err != nil {
+		return "", err
+	}
+	query := identityUrl.Query()
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", azureStorageResource)
+	if clientId := strings.TrimSpace(sender.config.ClientId); len(clientId) > 0 {
+		query.Set("client_id", clientId)
+	}
+	identityUrl.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, identityUrl.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+
+	response, err := sender.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to request managed identity token: %s", err.Error())
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("managed identity endpoint responded with %d HTTP status code: %s",
+			response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+
+	var token azureIdentityToken
+	if err := json.Unmarshal(responseBody, &token); err != nil {
+		return "", fmt.Errorf("unable to parse managed identity token: %s", err.Error())
+	}
+	expiresOn, err := strconv.ParseInt(token.ExpiresOn, 10, 64)
+	if err != nil || len(token.AccessToken) == 0 {
+		return "", errors.New("managed identity endpoint returned an invalid token")
+	}
+
+	sender.token = token.AccessToken
+	sender.expiry = time.Unix(expiresOn, 0)
+	return sender.token, nil
+}
+
+func (sender *AzureBlobSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
+	if sender.config.PersistOnError {
+		ctx.SetRetryData(exportData)
+	}
+}
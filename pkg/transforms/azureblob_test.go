@@ -0,0 +1,220 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	mocks2 "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// fakeBlobService stores the blobs written to it, append blobs only accepting appended blocks once created
+type fakeBlobService struct {
+	blobs    map[string]string
+	requests []*http.Request
+}
+
+func (service *fakeBlobService) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	service.requests = append(service.requests, request)
+	body, _ := io.ReadAll(request.Body)
+
+	switch {
+	case request.URL.Query().Get("comp") == "appendblock":
+		if _, found := service.blobs[request.URL.Path]; !found {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		service.blobs[request.URL.Path] += string(body)
+	case request.Header.Get("If-None-Match") == "*":
+		if _, found := service.blobs[request.URL.Path]; found {
+			writer.WriteHeader(http.StatusConflict)
+			return
+		}
+		service.blobs[request.URL.Path] = ""
+	default:
+		service.blobs[request.URL.Path] = string(body)
+	}
+
+	writer.WriteHeader(http.StatusCreated)
+}
+
+func mockSASSecret() {
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "azure", AzureSASTokenKey).Return(map[string]string{AzureSASTokenKey: "?sv=2020-10-02&sig=abc"}, nil)
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+}
+
+func TestNewAzureBlobSender(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      AzureBlobConfig
+		ExpectError bool
+	}{
+		{"SAS", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry",
+			BlobTemplate: "{uuid}.json", SecretPath: "azure"}, false},
+		{"Managed identity append", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry",
+			BlobTemplate: "{device}/{date}.ndjson", BlobType: "Append", AuthMode: "ManagedIdentity"}, false},
+		{"No account URL", AzureBlobConfig{Container: "telemetry", BlobTemplate: "{uuid}.json", SecretPath: "azure"}, true},
+		{"No container", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", BlobTemplate: "{uuid}.json", SecretPath: "azure"}, true},
+		{"No blob template", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry", SecretPath: "azure"}, true},
+		{"No SAS secret path", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry", BlobTemplate: "{uuid}.json"}, true},
+		{"Bad blob type", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry",
+			BlobTemplate: "{uuid}.json", SecretPath: "azure", BlobType: "page"}, true},
+		{"Bad auth mode", AzureBlobConfig{AccountURL: "https://account.blob.core.windows.net", Container: "telemetry",
+			BlobTemplate: "{uuid}.json", AuthMode: "sharedkey"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewAzureBlobSender(test.Config)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAzureBlobSender_PutBlobBlock(t *testing.T) {
+	mockSASSecret()
+	service := &fakeBlobService{blobs: make(map[string]string)}
+	ts := httptest.NewServer(service)
+	defer ts.Close()
+
+	sender, err := NewAzureBlobSender(AzureBlobConfig{
+		AccountURL:   ts.URL + "/devstoreaccount1",
+		Container:    "telemetry",
+		BlobTemplate: "{date}/{device}.json",
+		SecretPath:   "azure",
+		Format:       HTTPBatchFormatJSON,
+	})
+	require.NoError(t, err)
+	sender.now = func() time.Time { return time.Date(2021, 8, 24, 12, 0, 0, 0, time.UTC) }
+
+	blobCtx := appfunction.NewContext("123", dic, "")
+	blobCtx.AddValue(interfaces.DEVICENAME, "thermostat")
+
+	continuePipeline, result := sender.PutBlob(blobCtx, [][]byte{[]byte(`{"id":1}`), []byte(`{"id":2}`)})
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, map[string]string{"/devstoreaccount1/telemetry/2021-08-24/thermostat.json": `[{"id":1},{"id":2}]`}, service.blobs)
+
+	require.Len(t, service.requests, 1)
+	request := service.requests[0]
+	assert.Equal(t, "sv=2020-10-02&sig=abc", request.URL.RawQuery)
+	assert.Equal(t, "BlockBlob", request.Header.Get("x-ms-blob-type"))
+	assert.Equal(t, azureStorageVersion, request.Header.Get("x-ms-version"))
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+	continuePipeline, result = sender.PutBlob(blobCtx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestAzureBlobSender_PutBlobAppend(t *testing.T) {
+	mockSASSecret()
+	service := &fakeBlobService{blobs: map[string]string{"/telemetry/existing.ndjson": "{\"id\":0}\n"}}
+	ts := httptest.NewServer(service)
+	defer ts.Close()
+
+	sender, err := NewAzureBlobSender(AzureBlobConfig{
+		AccountURL:     ts.URL,
+		Container:      "telemetry",
+		BlobTemplate:   "{device}.ndjson",
+		BlobType:       AzureBlobTypeAppend,
+		SecretPath:     "azure",
+		PersistOnError: true,
+	})
+	require.NoError(t, err)
+
+	blobCtx := appfunction.NewContext("123", dic, "")
+	blobCtx.AddValue(interfaces.DEVICENAME, "existing")
+
+	for id := 1; id <= 2; id++ {
+		continuePipeline, result := sender.PutBlob(blobCtx, fmt.Sprintf(`{"id":%d}`, id))
+		require.True(t, continuePipeline, result)
+	}
+	assert.Equal(t, "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n", service.blobs["/telemetry/existing.ndjson"],
+		"existing blob should be appended to")
+	assert.Len(t, service.requests, 3, "append blob should only be created once")
+	assert.Equal(t, "AppendBlob", service.requests[0].Header.Get("x-ms-blob-type"))
+	assert.Equal(t, "comp=appendblock&sv=2020-10-02&sig=abc", service.requests[1].URL.RawQuery)
+
+	delete(service.blobs, "/telemetry/existing.ndjson")
+	continuePipeline, result := sender.PutBlob(blobCtx, `{"id":3}`)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "not found")
+	assert.Equal(t, []byte("{\"id\":3}\n"), blobCtx.RetryData())
+
+	continuePipeline, result = sender.PutBlob(blobCtx, `{"id":3}`)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "{\"id\":3}\n", service.blobs["/telemetry/existing.ndjson"], "deleted blob should be created again")
+}
+
+func TestAzureBlobSender_PutBlobManagedIdentity(t *testing.T) {
+	var tokenRequests int
+	identity := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		tokenRequests++
+		assert.Equal(t, "true", request.Header.Get("Metadata"))
+		assert.Equal(t, azureStorageResource, request.URL.Query().Get("resource"))
+		assert.Equal(t, "client-1", request.URL.Query().Get("client_id"))
+		expiresOn := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		_, _ = writer.Write([]byte(`{"access_token": "identity-token", "expires_on": "` + expiresOn + `"}`))
+	}))
+	defer identity.Close()
+
+	service := &fakeBlobService{blobs: make(map[string]string)}
+	ts := httptest.NewServer(service)
+	defer ts.Close()
+
+	sender, err := NewAzureBlobSender(AzureBlobConfig{
+		AccountURL:       ts.URL,
+		Container:        "telemetry",
+		BlobTemplate:     "{uuid}.json",
+		AuthMode:         AzureAuthModeManagedIdentity,
+		ClientId:         "client-1",
+		IdentityEndpoint: identity.URL,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		continuePipeline, result := sender.PutBlob(ctx, msgStr)
+		require.True(t, continuePipeline, result)
+	}
+
+	assert.Len(t, service.blobs, 2)
+	assert.Equal(t, 1, tokenRequests, "token should be cached until it is about to expire")
+	assert.Equal(t, "Bearer identity-token", service.requests[0].Header.Get("Authorization"))
+	assert.Empty(t, service.requests[0].URL.RawQuery)
+}
@@ -36,6 +36,7 @@ const (
 	BatchByCountOnly = iota
 	BatchByTimeOnly
 	BatchByTimeAndCount
+	BatchByCountOrTime
 )
 
 type atomicBatchData struct {
@@ -81,6 +82,9 @@ type BatchConfig struct {
 	batchData      atomicBatchData
 	timerActive    common.AtomicBool
 	done           chan bool
+	releaseMutex   sync.Mutex
+	release        chan struct{}
+	flush          *FlushCoordinator
 }
 
 // NewBatchByTime create, initializes  and returns a new instance for BatchConfig
@@ -126,6 +130,35 @@ func NewBatchByTimeAndCount(timeInterval string, batchThreshold int) (*BatchConf
 	return &config, nil
 }
 
+// NewBatchByCountOrTime create, initializes and returns a new instance for BatchConfig which holds the data received
+// and releases it as a batch once batchThreshold items are held or the timeInterval since the first item was held
+// elapses, whichever comes first.
+func NewBatchByCountOrTime(timeInterval string, batchThreshold int) (*BatchConfig, error) {
+	if batchThreshold < 1 {
+		return nil, fmt.Errorf("invalid batch threshold %d, must be greater than 0", batchThreshold)
+	}
+
+	config := BatchConfig{
+		timeInterval:   timeInterval,
+		batchThreshold: batchThreshold,
+		batchMode:      BatchByCountOrTime,
+	}
+	var err error
+	config.parsedDuration, err = time.ParseDuration(config.timeInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// AlignFlush aligns the release of the batches on the time interval to the wall clock boundaries of the interval
+// using the coordinator, so the batches of all the functions sharing the coordinator are released together.
+// The first batch is released at the first boundary, so it covers less than the time interval.
+func (batch *BatchConfig) AlignFlush(coordinator *FlushCoordinator) {
+	batch.flush = coordinator
+}
+
 // Batch ...
 func (batch *BatchConfig) Batch(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
 	if data == nil {
@@ -138,6 +171,10 @@ func (batch *BatchConfig) Batch(ctx interfaces.AppFunctionContext, data interfac
 	if err != nil {
 		return false, err
 	}
+	if batch.batchMode == BatchByCountOrTime {
+		return batch.holdUntilCountOrTime(ctx, byteData)
+	}
+
 	// always append data
 	batch.batchData.append(byteData)
 
@@ -145,12 +182,14 @@ func (batch *BatchConfig) Batch(ctx interfaces.AppFunctionContext, data interfac
 	if batch.batchMode != BatchByCountOnly {
 		if !batch.timerActive.Value() {
 			batch.timerActive.Set(true)
+			elapsed, stop := flushTimer(batch.flush, batch.parsedDuration)
 			select {
 			case <-batch.done:
 				ctx.LoggingClient().Debugf("Batch count has been reached in pipeline '%s'", ctx.PipelineId())
-			case <-time.After(batch.parsedDuration):
+			case <-elapsed:
 				ctx.LoggingClient().Debugf("Timer has elapsed in pipeline '%s'", ctx.PipelineId())
 			}
+			stop()
 			batch.timerActive.Set(false)
 		} else {
 			if batch.batchMode == BatchByTimeOnly {
@@ -174,28 +213,88 @@ func (batch *BatchConfig) Batch(ctx interfaces.AppFunctionContext, data interfac
 		}
 	}
 
-	ctx.LoggingClient().Debugf("Forwarding Batched Data in pipeline '%s'", ctx.PipelineId())
 	// we've met the threshold, lets clear out the buffer and send it forward in the pipeline
 	if batch.batchData.length() > 0 {
-		var resultData interface{}
-		copyOfData := batch.batchData.all()
-		resultData = copyOfData
-		if batch.IsEventData {
-			ctx.LoggingClient().Debug("Marshaling batched data to []Event")
-			var events []dtos.Event
-			for _, data := range copyOfData {
-				event := dtos.Event{}
-				if err := json.Unmarshal(data, &event); err != nil {
-					return false, fmt.Errorf("unable to marshal batched data to slice of Events in pipeline '%s': %s", ctx.PipelineId(), err.Error())
-				}
-				events = append(events, event)
-			}
-
-			resultData = events
+		continuePipeline, resultData := batch.batchResult(ctx, batch.batchData.all())
+		if continuePipeline {
+			batch.batchData.removeAll()
 		}
+		return continuePipeline, resultData
+	}
+	return false, nil
+}
+
+// holdUntilCountOrTime holds the data and releases the batch from the pipeline execution that fills it or, when the
+// time interval elapses first, from the pipeline execution that started it. All other executions are stopped, so each
+// batch is passed on exactly once.
+func (batch *BatchConfig) holdUntilCountOrTime(ctx interfaces.AppFunctionContext, data []byte) (bool, interface{}) {
+	batch.releaseMutex.Lock()
+	held := batch.batchData.append(data)
 
+	if len(held) >= batch.batchThreshold {
 		batch.batchData.removeAll()
-		return true, resultData
+		if batch.release != nil {
+			close(batch.release)
+			batch.release = nil
+		}
+		batch.releaseMutex.Unlock()
+
+		ctx.LoggingClient().Debugf("Batch count has been reached in pipeline '%s'", ctx.PipelineId())
+		return batch.batchResult(ctx, held)
 	}
-	return false, nil
+
+	if batch.release != nil {
+		// The time interval for the batch being held is already running
+		batch.releaseMutex.Unlock()
+		return false, nil
+	}
+
+	release := make(chan struct{})
+	batch.release = release
+	batch.releaseMutex.Unlock()
+
+	elapsed, stop := flushTimer(batch.flush, batch.parsedDuration)
+	defer stop()
+
+	select {
+	case <-release:
+		// The batch was passed on by the pipeline execution that filled it
+		return false, nil
+	case <-elapsed:
+	}
+
+	batch.releaseMutex.Lock()
+	if batch.release != release {
+		// The batch was filled while the timer was firing
+		batch.releaseMutex.Unlock()
+		return false, nil
+	}
+	held = batch.batchData.all()
+	batch.batchData.removeAll()
+	batch.release = nil
+	batch.releaseMutex.Unlock()
+
+	ctx.LoggingClient().Debugf("Timer has elapsed in pipeline '%s'", ctx.PipelineId())
+	return batch.batchResult(ctx, held)
+}
+
+// batchResult returns the batched data as a slice of Events when IsEventData is set, otherwise as a slice of []byte
+func (batch *BatchConfig) batchResult(ctx interfaces.AppFunctionContext, batchedData [][]byte) (bool, interface{}) {
+	ctx.LoggingClient().Debugf("Forwarding Batched Data in pipeline '%s'", ctx.PipelineId())
+
+	if !batch.IsEventData {
+		return true, batchedData
+	}
+
+	ctx.LoggingClient().Debug("Marshaling batched data to []Event")
+	var events []dtos.Event
+	for _, data := range batchedData {
+		event := dtos.Event{}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return false, fmt.Errorf("unable to marshal batched data to slice of Events in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+		events = append(events, event)
+	}
+
+	return true, events
 }
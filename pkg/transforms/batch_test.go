@@ -184,6 +184,69 @@ func TestBatchInTimeMode(t *testing.T) {
 	wgAll.Wait()
 }
 
+func TestBatchInCountOrTimeMode_CountMet(t *testing.T) {
+	bs, err := NewBatchByCountOrTime("90s", 3)
+	require.NoError(t, err)
+
+	first := make(chan bool)
+	go func() {
+		continuePipeline, result := bs.Batch(ctx, []byte(dataToBatch[0]))
+		assert.Nil(t, result)
+		first <- continuePipeline
+	}()
+
+	require.Eventually(t, func() bool { return bs.batchData.length() == 1 }, time.Second, time.Millisecond)
+
+	continuePipeline, result := bs.Batch(ctx, []byte(dataToBatch[1]))
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+
+	continuePipeline, result = bs.Batch(ctx, []byte(dataToBatch[2]))
+	require.True(t, continuePipeline)
+	assert.Equal(t, [][]byte{[]byte(dataToBatch[0]), []byte(dataToBatch[1]), []byte(dataToBatch[2])}, result)
+	assert.Equal(t, 0, bs.batchData.length(), "Records should have been cleared")
+
+	select {
+	case continuePipeline := <-first:
+		assert.False(t, continuePipeline, "batch should only be passed on by the execution that filled it")
+	case <-time.After(time.Second):
+		t.Fatal("execution holding the batch was not released when the count was reached")
+	}
+}
+
+func TestBatchInCountOrTimeMode_TimeElapsed(t *testing.T) {
+	bs, err := NewBatchByCountOrTime("100ms", 10)
+	require.NoError(t, err)
+
+	first := make(chan interface{})
+	go func() {
+		continuePipeline, result := bs.Batch(ctx, []byte(dataToBatch[0]))
+		assert.True(t, continuePipeline)
+		first <- result
+	}()
+
+	require.Eventually(t, func() bool { return bs.batchData.length() == 1 }, time.Second, time.Millisecond)
+
+	continuePipeline, _ := bs.Batch(ctx, []byte(dataToBatch[1]))
+	assert.False(t, continuePipeline)
+
+	select {
+	case result := <-first:
+		assert.Len(t, result, 2)
+		assert.Equal(t, 0, bs.batchData.length(), "Records should have been cleared")
+	case <-time.After(time.Second):
+		t.Fatal("batch not released when the time interval elapsed")
+	}
+}
+
+func TestNewBatchByCountOrTime_Errors(t *testing.T) {
+	_, err := NewBatchByCountOrTime("10s", 0)
+	assert.Error(t, err)
+
+	_, err = NewBatchByCountOrTime("ten seconds", 10)
+	assert.Error(t, err)
+}
+
 func TestBatchIsEventData(t *testing.T) {
 	events := []dtos.Event{
 		dtos.NewEvent("p1", "d1", "s1"),
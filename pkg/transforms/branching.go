@@ -0,0 +1,206 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	thenBranch    = "then"
+	elseBranch    = "else"
+	defaultBranch = "default"
+)
+
+// Predicate decides which branch of a Conditional the data is routed down
+type Predicate func(ctx interfaces.AppFunctionContext, data interface{}) bool
+
+// Conditional routes the data down one of two chains of functions depending on a predicate, so a single pipeline can
+// process the data differently without the branching being hand rolled in a custom function.
+type Conditional struct {
+	predicate     Predicate
+	thenFunctions []interfaces.AppFunction
+	elseFunctions []interfaces.AppFunction
+}
+
+// NewConditional creates, initializes and returns a new instance of Conditional. The data is passed through unchanged
+// when the chosen chain of functions is empty.
+func NewConditional(predicate Predicate, thenFunctions []interfaces.AppFunction, elseFunctions []interfaces.AppFunction) Conditional {
+	return Conditional{
+		predicate:     predicate,
+		thenFunctions: thenFunctions,
+		elseFunctions: elseFunctions,
+	}
+}
+
+// When executes the then functions when the predicate is true for the data, otherwise the else functions, and returns
+// the result of the last function executed so the pipeline continues with it. A function in the branch that stops
+// the pipeline, i.e. a filter or a failed export, stops the whole pipeline.
+// This function will return an error and stop the pipeline if no data is received.
+func (conditional Conditional) When(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function When in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	branches := map[string][]interfaces.AppFunction{
+		thenBranch: conditional.thenFunctions,
+		elseBranch: conditional.elseFunctions,
+	}
+
+	return executeBranch(ctx, data, branches, func() string {
+		if conditional.predicate(ctx, data) {
+			return thenBranch
+		}
+		return elseBranch
+	})
+}
+
+// DeviceNameSwitch routes the data down the chain of functions for the name of the device it is from
+type DeviceNameSwitch struct {
+	cases            map[string][]interfaces.AppFunction
+	defaultFunctions []interfaces.AppFunction
+}
+
+// NewDeviceNameSwitch creates, initializes and returns a new instance of DeviceNameSwitch. cases maps device names to
+// their chains of functions, defaultFunctions is the chain for the devices not in cases. The data is passed through
+// unchanged when the chosen chain of functions is empty.
+func NewDeviceNameSwitch(cases map[string][]interfaces.AppFunction, defaultFunctions []interfaces.AppFunction) DeviceNameSwitch {
+	return DeviceNameSwitch{
+		cases:            cases,
+		defaultFunctions: defaultFunctions,
+	}
+}
+
+// SwitchByDeviceName executes the functions for the device name of the Event received, or the device name context
+// value for other data, and returns the result of the last function executed so the pipeline continues with it.
+// A function in the branch that stops the pipeline, i.e. a filter or a failed export, stops the whole pipeline.
+// This function will return an error and stop the pipeline if no data is received.
+func (deviceSwitch DeviceNameSwitch) SwitchByDeviceName(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function SwitchByDeviceName in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	// The default branch is keyed separately so a device can't be named the same as it
+	branches := map[string][]interfaces.AppFunction{
+		defaultBranch: deviceSwitch.defaultFunctions,
+	}
+	for deviceName, functions := range deviceSwitch.cases {
+		branches["device:"+deviceName] = functions
+	}
+
+	return executeBranch(ctx, data, branches, func() string {
+		deviceName, _ := ctx.GetValue(interfaces.DEVICENAME)
+		if event, ok := data.(dtos.Event); ok {
+			deviceName = event.DeviceName
+		}
+
+		if _, found := deviceSwitch.cases[deviceName]; found {
+			return "device:" + deviceName
+		}
+		return defaultBranch
+	})
+}
+
+// branchPosition is the branch and position of the function in the branch that set data to be retried
+type branchPosition struct {
+	Branch   string `json:"branch"`
+	Position int    `json:"position"`
+}
+
+// executeBranch executes the functions of the selected branch. When a function sets data to be retried, its branch and
+// position are added to the front of the BRANCHRETRY context value, so when Store and Forward retries this function
+// with the data, execution resumes at that function rather than the branch being selected again. Nested branching
+// functions each take their own position from the front of the value.
+func executeBranch(
+	ctx interfaces.AppFunctionContext,
+	data interface{},
+	branches map[string][]interfaces.AppFunction,
+	selectBranch func() string) (bool, interface{}) {
+	branch := ""
+	startPosition := 0
+
+	var retryPositions []branchPosition
+	if value, found := ctx.GetValue(interfaces.BRANCHRETRY); found {
+		ctx.RemoveValue(interfaces.BRANCHRETRY)
+		if err := json.Unmarshal([]byte(value), &retryPositions); err == nil && len(retryPositions) > 0 {
+			if _, found := branches[retryPositions[0].Branch]; found {
+				branch = retryPositions[0].Branch
+				startPosition = retryPositions[0].Position
+				retryPositions = retryPositions[1:]
+				if len(retryPositions) > 0 {
+					setBranchRetry(ctx, retryPositions)
+				}
+			}
+		}
+	}
+
+	if branch == "" {
+		branch = selectBranch()
+	}
+
+	ctx.LoggingClient().Debugf("Executing '%s' branch in pipeline '%s'", branch, ctx.PipelineId())
+
+	result := data
+	functions := branches[branch]
+	for position := startPosition; position < len(functions); position++ {
+		ctx.SetRetryData(nil)
+
+		continuePipeline, output := functions[position](ctx, result)
+		if !continuePipeline {
+			if _, isError := output.(error); isError && retryData(ctx) != nil {
+				nested := branchRetryPositions(ctx)
+				setBranchRetry(ctx, append([]branchPosition{{Branch: branch, Position: position}}, nested...))
+			}
+			return false, output
+		}
+
+		result = output
+	}
+
+	return true, result
+}
+
+// retryData returns the data to be retried set by the function that failed. RetryData is not part of the
+// AppFunctionContext interface, so contexts that don't implement it never have data to be retried.
+func retryData(ctx interfaces.AppFunctionContext) []byte {
+	retryContext, ok := ctx.(interface{ RetryData() []byte })
+	if !ok {
+		return nil
+	}
+	return retryContext.RetryData()
+}
+
+func branchRetryPositions(ctx interfaces.AppFunctionContext) []branchPosition {
+	var positions []branchPosition
+	if value, found := ctx.GetValue(interfaces.BRANCHRETRY); found {
+		_ = json.Unmarshal([]byte(value), &positions)
+	}
+	return positions
+}
+
+func setBranchRetry(ctx interfaces.AppFunctionContext, positions []branchPosition) {
+	// Can't fail as branchPosition only has simple fields
+	encoded, _ := json.Marshal(positions)
+	ctx.AddValue(interfaces.BRANCHRETRY, string(encoded))
+}
@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func appendFunction(suffix string) interfaces.AppFunction {
+	return func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return true, data.(string) + suffix
+	}
+}
+
+func stopFunction(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	return false, nil
+}
+
+// failingFunction fails and sets the data to be retried until it has failed the given number of times.
+// The data is a string, or []byte when retried.
+func failingFunction(failures *int) interfaces.AppFunction {
+	return func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		text, ok := data.(string)
+		if !ok {
+			text = string(data.([]byte))
+		}
+
+		if *failures > 0 {
+			*failures--
+			ctx.SetRetryData([]byte(text))
+			return false, errors.New("export failed")
+		}
+		return true, text + "-exported"
+	}
+}
+
+func TestConditional_When(t *testing.T) {
+	isEvent := func(ctx interfaces.AppFunctionContext, data interface{}) bool {
+		return data.(string) == "event"
+	}
+
+	tests := []struct {
+		Name          string
+		Data          string
+		ElseFunctions []interfaces.AppFunction
+		Expected      string
+	}{
+		{"Then", "event", []interfaces.AppFunction{appendFunction("-else")}, "event-then-then"},
+		{"Else", "other", []interfaces.AppFunction{appendFunction("-else")}, "other-else"},
+		{"No else", "other", nil, "other"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			target := NewConditional(isEvent,
+				[]interfaces.AppFunction{appendFunction("-then"), appendFunction("-then")}, test.ElseFunctions)
+
+			continuePipeline, result := target.When(appfunction.NewContext("123", dic, ""), test.Data)
+			require.True(t, continuePipeline)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestConditional_WhenStopped(t *testing.T) {
+	always := func(ctx interfaces.AppFunctionContext, data interface{}) bool { return true }
+
+	target := NewConditional(always, []interfaces.AppFunction{stopFunction, appendFunction("-then")}, nil)
+	continuePipeline, result := target.When(ctx, "data")
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+
+	continuePipeline, result = target.When(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestDeviceNameSwitch_SwitchByDeviceName(t *testing.T) {
+	target := NewDeviceNameSwitch(map[string][]interfaces.AppFunction{
+		"camera": {func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+			return true, "camera:" + data.(dtos.Event).DeviceName
+		}},
+		"thermostat": {appendFunction("-thermostat")},
+		"default":    {appendFunction("-named-default")},
+	}, []interfaces.AppFunction{appendFunction("-default")})
+
+	continuePipeline, result := target.SwitchByDeviceName(ctx, dtos.NewEvent("camera", "camera", "image"))
+	require.True(t, continuePipeline)
+	assert.Equal(t, "camera:camera", result)
+
+	tests := []struct {
+		Name       string
+		DeviceName string
+		Expected   string
+	}{
+		{"Device name value", "thermostat", "data-thermostat"},
+		{"Unknown device", "pump", "data-default"},
+		{"No device name", "", "data-default"},
+		{"Device named default", "default", "data-named-default"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testCtx := appfunction.NewContext("123", dic, "")
+			if test.DeviceName != "" {
+				testCtx.AddValue(interfaces.DEVICENAME, test.DeviceName)
+			}
+
+			continuePipeline, result := target.SwitchByDeviceName(testCtx, "data")
+			require.True(t, continuePipeline)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestBranchingRetry(t *testing.T) {
+	failures := 1
+	selected := 0
+	inner := NewConditional(func(ctx interfaces.AppFunctionContext, data interface{}) bool {
+		selected++
+		return true
+	}, []interfaces.AppFunction{appendFunction("-inner"), failingFunction(&failures)}, nil)
+
+	outer := NewDeviceNameSwitch(map[string][]interfaces.AppFunction{
+		"thermostat": {appendFunction("-outer"), inner.When},
+	}, nil)
+
+	testCtx := appfunction.NewContext("123", dic, "")
+	testCtx.AddValue(interfaces.DEVICENAME, "thermostat")
+
+	continuePipeline, result := outer.SwitchByDeviceName(testCtx, "data")
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Equal(t, []byte("data-outer-inner"), testCtx.RetryData())
+	branchRetry, found := testCtx.GetValue(interfaces.BRANCHRETRY)
+	require.True(t, found)
+	assert.JSONEq(t, `[{"branch":"device:thermostat","position":1},{"branch":"then","position":1}]`, branchRetry)
+
+	// Store and Forward retries the switch with the retry data and the context values from the failed execution,
+	// which no longer selects a branch by device name
+	retryCtx := appfunction.NewContext("123", dic, "")
+	retryCtx.AddValue(interfaces.BRANCHRETRY, branchRetry)
+
+	continuePipeline, result = outer.SwitchByDeviceName(retryCtx, "data-outer-inner")
+	require.True(t, continuePipeline)
+	assert.Equal(t, "data-outer-inner-exported", result)
+	assert.Equal(t, 1, selected, "branch should not be selected again when retrying")
+	_, found = retryCtx.GetValue(interfaces.BRANCHRETRY)
+	assert.False(t, found)
+}
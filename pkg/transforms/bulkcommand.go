@@ -0,0 +1,285 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	clientInterfaces "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	BulkCommandMethodGet = "get"
+	BulkCommandMethodSet = "set"
+
+	defaultBulkCommandMaxConcurrent = 10
+)
+
+// BulkCommandOptions contains the options of the BulkCommand function
+type BulkCommandOptions struct {
+	// DeviceNames are the devices the command is issued to
+	DeviceNames []string
+	// DeviceGroup is the optional device group whose members the command is also issued to
+	DeviceGroup string
+	// CommandName is the name of the command issued
+	CommandName string
+	// Method is BulkCommandMethodGet or BulkCommandMethodSet, the default
+	Method string
+	// Settings are the resource values of a set command
+	Settings map[string]string
+	// MaxConcurrent is the number of commands issued concurrently, 10 when not set
+	MaxConcurrent int
+}
+
+// BulkCommandRequest is the data that, when received by the BulkCommand function, replaces the configured devices and
+// settings, so a fleet of devices can be actuated with the request sent to the HTTP trigger
+type BulkCommandRequest struct {
+	DeviceNames []string          `json:"deviceNames,omitempty"`
+	DeviceGroup string            `json:"deviceGroup,omitempty"`
+	Settings    map[string]string `json:"settings,omitempty"`
+}
+
+// BulkCommandReport is the result of the command issued to each device by the BulkCommand function
+type BulkCommandReport struct {
+	CommandName string              `json:"commandName"`
+	Method      string              `json:"method"`
+	Succeeded   int                 `json:"succeeded"`
+	Failed      int                 `json:"failed"`
+	Results     []BulkCommandResult `json:"results"`
+}
+
+// BulkCommandResult is the result of the command issued to a device
+type BulkCommandResult struct {
+	DeviceName string `json:"deviceName"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+	// Event is the Event returned by a get command
+	Event *dtos.Event `json:"event,omitempty"`
+}
+
+// BulkCommand issues the same command to a list or group of devices via Core Command
+type BulkCommand struct {
+	options BulkCommandOptions
+}
+
+// NewBulkCommand creates, initializes and returns a new instance of BulkCommand
+func NewBulkCommand(options BulkCommandOptions) (*BulkCommand, error) {
+	if strings.TrimSpace(options.CommandName) == "" {
+		return nil, errors.New("CommandName must be set")
+	}
+
+	options.Method = strings.ToLower(strings.TrimSpace(options.Method))
+	if options.Method == "" {
+		options.Method = BulkCommandMethodSet
+	}
+
+	if options.Method != BulkCommandMethodGet && options.Method != BulkCommandMethodSet {
+		return nil, fmt.Errorf("invalid Method '%s', must be '%s' or '%s'", options.Method, BulkCommandMethodGet, BulkCommandMethodSet)
+	}
+
+	if options.MaxConcurrent <= 0 {
+		options.MaxConcurrent = defaultBulkCommandMaxConcurrent
+	}
+
+	return &BulkCommand{options: options}, nil
+}
+
+// IssueCommand issues the command to the devices concurrently and continues the pipeline with a BulkCommandReport of
+// the result for each device, ordered by device name. The devices and settings are those of a BulkCommandRequest
+// received, as a struct or JSON, otherwise those configured. The commands failing doesn't stop the pipeline, their
+// errors are in the report.
+// This function will return an error and stop the pipeline if no data is received, the request received is invalid,
+// there are no devices or Core Command is not configured.
+func (bulk *BulkCommand) IssueCommand(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function IssueCommand in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	request, err := bulk.requestFrom(data)
+	if err != nil {
+		return false, fmt.Errorf("function IssueCommand in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	deviceNames, err := bulk.deviceNames(ctx, request)
+	if err != nil {
+		return false, fmt.Errorf("function IssueCommand in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	client := ctx.CommandClient()
+	if client == nil {
+		return false, fmt.Errorf("function IssueCommand in pipeline '%s': CommandClient not initialized. Core Command is missing from clients configuration", ctx.PipelineId())
+	}
+
+	lc := ctx.LoggingClient()
+	lc.Debugf("Issuing '%s' %s command to %d devices in pipeline '%s'", bulk.options.CommandName, bulk.options.Method,
+		len(deviceNames), ctx.PipelineId())
+
+	report := BulkCommandReport{
+		CommandName: bulk.options.CommandName,
+		Method:      bulk.options.Method,
+		Results:     make([]BulkCommandResult, len(deviceNames)),
+	}
+
+	limiter := make(chan struct{}, bulk.options.MaxConcurrent)
+	wg := sync.WaitGroup{}
+	for index, deviceName := range deviceNames {
+		wg.Add(1)
+		limiter <- struct{}{}
+		go func(index int, deviceName string) {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+
+			result := bulk.issue(client, deviceName, request.Settings)
+			if result.Error != "" {
+				lc.Warnf("'%s' command to device '%s' failed in pipeline '%s': %s", bulk.options.CommandName, deviceName,
+					ctx.PipelineId(), result.Error)
+			}
+
+			report.Results[index] = result
+		}(index, deviceName)
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		if result.Error == "" {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+
+	lc.Debugf("'%s' command succeeded for %d and failed for %d devices in pipeline '%s'", bulk.options.CommandName,
+		report.Succeeded, report.Failed, ctx.PipelineId())
+
+	return true, report
+}
+
+// issue issues the command to the device and returns its result
+func (bulk *BulkCommand) issue(client clientInterfaces.CommandClient, deviceName string, settings map[string]string) BulkCommandResult {
+	result := BulkCommandResult{DeviceName: deviceName}
+
+	if bulk.options.Method == BulkCommandMethodGet {
+		response, err := client.IssueGetCommandByName(context.Background(), deviceName, bulk.options.CommandName, "no", "yes")
+		if err != nil {
+			result.StatusCode = err.Code()
+			result.Error = err.Error()
+			return result
+		}
+
+		result.StatusCode = response.StatusCode
+		result.Event = &response.Event
+		return result
+	}
+
+	response, err := client.IssueSetCommandByName(context.Background(), deviceName, bulk.options.CommandName, settings)
+	if err != nil {
+		result.StatusCode = err.Code()
+		result.Error = err.Error()
+		return result
+	}
+
+	result.StatusCode = response.StatusCode
+	return result
+}
+
+// requestFrom returns the BulkCommandRequest received, or the configured settings when the data isn't a request,
+// i.e. an Event received from the MessageBus
+func (bulk *BulkCommand) requestFrom(data interface{}) (BulkCommandRequest, error) {
+	configured := BulkCommandRequest{Settings: bulk.options.Settings}
+
+	var request BulkCommandRequest
+	switch typed := data.(type) {
+	case BulkCommandRequest:
+		request = typed
+	case *BulkCommandRequest:
+		request = *typed
+	case []byte:
+		if err := json.Unmarshal(typed, &request); err != nil {
+			return request, fmt.Errorf("unable to unmarshal bulk command request: %s", err.Error())
+		}
+	case string:
+		if err := json.Unmarshal([]byte(typed), &request); err != nil {
+			return request, fmt.Errorf("unable to unmarshal bulk command request: %s", err.Error())
+		}
+	default:
+		return configured, nil
+	}
+
+	if request.Settings == nil {
+		request.Settings = configured.Settings
+	}
+
+	return request, nil
+}
+
+// deviceNames returns the sorted, unique names of the devices listed or in the device group of the request, otherwise
+// of the configured devices and device group
+func (bulk *BulkCommand) deviceNames(ctx interfaces.AppFunctionContext, request BulkCommandRequest) ([]string, error) {
+	names := request.DeviceNames
+	group := request.DeviceGroup
+	if len(names) == 0 && group == "" {
+		names = bulk.options.DeviceNames
+		group = bulk.options.DeviceGroup
+	}
+
+	unique := make(map[string]bool)
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			unique[name] = true
+		}
+	}
+
+	if group != "" {
+		groups := ctx.DeviceGroups()
+		if groups == nil {
+			return nil, errors.New("DeviceGroups not enabled")
+		}
+
+		members, found := groups.DeviceNames(group)
+		if !found {
+			return nil, fmt.Errorf("device group '%s' not configured", group)
+		}
+
+		for _, member := range members {
+			unique[member] = true
+		}
+	}
+
+	if len(unique) == 0 {
+		return nil, errors.New("no devices to issue the command to")
+	}
+
+	deviceNames := make([]string, 0, len(unique))
+	for name := range unique {
+		deviceNames = append(deviceNames, name)
+	}
+	sort.Strings(deviceNames)
+
+	return deviceNames, nil
+}
@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	clientInterfaces "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+)
+
+// fakeBulkCommandClient records the commands issued concurrently and fails those to the "offline" device
+type fakeBulkCommandClient struct {
+	clientInterfaces.CommandClient
+	mutex    sync.Mutex
+	settings map[string]map[string]string
+}
+
+func (client *fakeBulkCommandClient) IssueSetCommandByName(_ context.Context, deviceName string, _ string, settings map[string]string) (commonDtos.BaseResponse, errors.EdgeX) {
+	if deviceName == "offline" {
+		return commonDtos.BaseResponse{}, errors.NewCommonEdgeX(errors.KindServiceUnavailable, "device offline", nil)
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.settings[deviceName] = settings
+	return commonDtos.NewBaseResponse("", "", http.StatusOK), nil
+}
+
+func (client *fakeBulkCommandClient) IssueGetCommandByName(_ context.Context, deviceName string, _ string, _ string, _ string) (*responses.EventResponse, errors.EdgeX) {
+	response := responses.NewEventResponse("", "", http.StatusOK, dtos.NewEvent("profile", deviceName, "State"))
+	return &response, nil
+}
+
+func newBulkCommandContext(client clientInterfaces.CommandClient) *mocks.AppFunctionContext {
+	bulkCtx := &mocks.AppFunctionContext{}
+	bulkCtx.On("LoggingClient").Return(lc)
+	bulkCtx.On("PipelineId").Return("test-pipeline")
+	bulkCtx.On("CommandClient").Return(client)
+	bulkCtx.On("DeviceGroups").Return(staticDeviceGroups{
+		"fans": {"fan-2", "fan-3"},
+	})
+	return bulkCtx
+}
+
+func TestNewBulkCommand(t *testing.T) {
+	_, err := NewBulkCommand(BulkCommandOptions{})
+	require.Error(t, err)
+
+	_, err = NewBulkCommand(BulkCommandOptions{CommandName: "Switch", Method: "delete"})
+	require.Error(t, err)
+
+	bulk, err := NewBulkCommand(BulkCommandOptions{CommandName: "Switch"})
+	require.NoError(t, err)
+	assert.Equal(t, BulkCommandMethodSet, bulk.options.Method)
+	assert.Equal(t, defaultBulkCommandMaxConcurrent, bulk.options.MaxConcurrent)
+}
+
+func TestBulkCommand_IssueCommand(t *testing.T) {
+	client := &fakeBulkCommandClient{settings: make(map[string]map[string]string)}
+	bulkCtx := newBulkCommandContext(client)
+
+	bulk, err := NewBulkCommand(BulkCommandOptions{
+		DeviceNames:   []string{"fan-1", "offline", "fan-2"},
+		DeviceGroup:   "fans",
+		CommandName:   "Switch",
+		Settings:      map[string]string{"State": "on"},
+		MaxConcurrent: 2,
+	})
+	require.NoError(t, err)
+
+	continuePipeline, result := bulk.IssueCommand(bulkCtx, dtos.NewEvent("profile", "thermostat", "source"))
+	require.True(t, continuePipeline, result)
+
+	report, ok := result.(BulkCommandReport)
+	require.True(t, ok)
+	assert.Equal(t, "Switch", report.CommandName)
+	assert.Equal(t, 3, report.Succeeded)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Results, 4)
+
+	var deviceNames []string
+	for _, result := range report.Results {
+		deviceNames = append(deviceNames, result.DeviceName)
+	}
+	assert.Equal(t, []string{"fan-1", "fan-2", "fan-3", "offline"}, deviceNames, "devices should be unique and ordered")
+	assert.Equal(t, http.StatusServiceUnavailable, report.Results[3].StatusCode)
+	assert.Contains(t, report.Results[3].Error, "device offline")
+	assert.Equal(t, map[string]string{"State": "on"}, client.settings["fan-3"])
+}
+
+func TestBulkCommand_IssueCommandRequest(t *testing.T) {
+	client := &fakeBulkCommandClient{settings: make(map[string]map[string]string)}
+	bulkCtx := newBulkCommandContext(client)
+
+	bulk, err := NewBulkCommand(BulkCommandOptions{
+		DeviceNames: []string{"fan-1"},
+		CommandName: "Switch",
+		Settings:    map[string]string{"State": "on"},
+	})
+	require.NoError(t, err)
+
+	continuePipeline, result := bulk.IssueCommand(bulkCtx, []byte(`{"deviceGroup":"fans","settings":{"State":"off"}}`))
+	require.True(t, continuePipeline, result)
+
+	report := result.(BulkCommandReport)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, map[string]map[string]string{
+		"fan-2": {"State": "off"},
+		"fan-3": {"State": "off"},
+	}, client.settings)
+
+	continuePipeline, result = bulk.IssueCommand(bulkCtx, "not json")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to unmarshal bulk command request")
+
+	continuePipeline, result = bulk.IssueCommand(bulkCtx, BulkCommandRequest{DeviceGroup: "pumps"})
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "device group 'pumps' not configured")
+
+	continuePipeline, result = bulk.IssueCommand(bulkCtx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestBulkCommand_IssueGetCommand(t *testing.T) {
+	bulkCtx := newBulkCommandContext(&fakeBulkCommandClient{})
+
+	bulk, err := NewBulkCommand(BulkCommandOptions{CommandName: "State", Method: BulkCommandMethodGet})
+	require.NoError(t, err)
+
+	continuePipeline, result := bulk.IssueCommand(bulkCtx, BulkCommandRequest{DeviceNames: []string{"fan-1"}})
+	require.True(t, continuePipeline, result)
+
+	report := result.(BulkCommandReport)
+	require.Len(t, report.Results, 1)
+	require.NotNil(t, report.Results[0].Event)
+	assert.Equal(t, "fan-1", report.Results[0].Event.DeviceName)
+
+	noClientCtx := newBulkCommandContext(nil)
+	continuePipeline, result = bulk.IssueCommand(noClientCtx, BulkCommandRequest{DeviceNames: []string{"fan-1"}})
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "CommandClient not initialized")
+}
@@ -0,0 +1,273 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// ChunkHeaderSize is the size of the header at the start of each chunk
+	ChunkHeaderSize = 33
+	// DefaultChunkTimeout is the default time to wait for the remaining chunks of a payload
+	DefaultChunkTimeout = time.Minute
+
+	chunkVersion = 1
+)
+
+// chunkMagic identifies the data as a chunk
+var chunkMagic = []byte("EXCK")
+
+// chunkHeader is the header at the start of each chunk, encoded in big endian order as the magic bytes, version,
+// transfer id, chunk index, chunk count and CRC-32 checksum of the complete payload.
+type chunkHeader struct {
+	transferId uuid.UUID
+	index      uint32
+	count      uint32
+	checksum   uint32
+}
+
+func (header chunkHeader) encode(data []byte) []byte {
+	chunk := make([]byte, ChunkHeaderSize, ChunkHeaderSize+len(data))
+	copy(chunk, chunkMagic)
+	chunk[4] = chunkVersion
+	copy(chunk[5:21], header.transferId[:])
+	binary.BigEndian.PutUint32(chunk[21:25], header.index)
+	binary.BigEndian.PutUint32(chunk[25:29], header.count)
+	binary.BigEndian.PutUint32(chunk[29:33], header.checksum)
+	return append(chunk, data...)
+}
+
+func decodeChunk(chunk []byte) (chunkHeader, []byte, error) {
+	var header chunkHeader
+	if len(chunk) < ChunkHeaderSize || !bytes.Equal(chunk[:4], chunkMagic) {
+		return header, nil, errors.New("data is not a chunk")
+	}
+
+	if chunk[4] != chunkVersion {
+		return header, nil, fmt.Errorf("unsupported chunk version %d", chunk[4])
+	}
+
+	copy(header.transferId[:], chunk[5:21])
+	header.index = binary.BigEndian.Uint32(chunk[21:25])
+	header.count = binary.BigEndian.Uint32(chunk[25:29])
+	header.checksum = binary.BigEndian.Uint32(chunk[29:33])
+
+	if header.count == 0 || header.index >= header.count {
+		return header, nil, fmt.Errorf("invalid chunk %d of %d", header.index, header.count)
+	}
+
+	return header, chunk[ChunkHeaderSize:], nil
+}
+
+// Chunker splits large payloads into sequenced chunks, each exported separately, for transports with payload size
+// limits such as MQTT brokers. The receiving app service reassembles the payloads with a ChunkAssembler.
+type Chunker struct {
+	chunkSize      int
+	persistOnError bool
+	export         interfaces.AppFunction
+}
+
+// NewChunker creates, initializes and returns a new instance of Chunker exporting the chunks with the export
+// function. chunkSize is the maximum size of each chunk, including the ChunkHeaderSize bytes of header.
+// persistOnError enables use of store & forward when a chunk fails to export, which exports all the chunks again
+// so the export function must not persist on error itself.
+func NewChunker(chunkSize int, persistOnError bool, export interfaces.AppFunction) *Chunker {
+	return &Chunker{
+		chunkSize:      chunkSize,
+		persistOnError: persistOnError,
+		export:         export,
+	}
+}
+
+// ExportChunks splits the data from the previous function into chunks and exports them in order. Data that fits in
+// a single chunk is still sent as a chunk so the receiver always gets chunks. The input data is returned once all
+// the chunks are exported, so other export functions can follow.
+// This function will return an error and stop the pipeline if no data is received, the data can't be converted to
+// bytes, the chunk size is too small or a chunk fails to export.
+func (chunker *Chunker) ExportChunks(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function ExportChunks in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	if chunker.chunkSize <= ChunkHeaderSize {
+		return false, fmt.Errorf("function ExportChunks in pipeline '%s': chunk size must be greater than the %d byte header",
+			ctx.PipelineId(), ChunkHeaderSize)
+	}
+
+	payload, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	dataSize := chunker.chunkSize - ChunkHeaderSize
+	count := (len(payload) + dataSize - 1) / dataSize
+	if count == 0 {
+		count = 1
+	}
+
+	header := chunkHeader{
+		transferId: uuid.New(),
+		count:      uint32(count),
+		checksum:   crc32.ChecksumIEEE(payload),
+	}
+
+	ctx.LoggingClient().Debugf("Exporting %d bytes in %d chunks with transfer id %s in pipeline '%s'",
+		len(payload), count, header.transferId.String(), ctx.PipelineId())
+
+	for index := 0; index < count; index++ {
+		start := index * dataSize
+		end := start + dataSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		header.index = uint32(index)
+		if continuePipeline, result := chunker.export(ctx, header.encode(payload[start:end])); !continuePipeline {
+			err, ok := result.(error)
+			if !ok {
+				err = errors.New("export stopped the pipeline")
+			}
+
+			// The retry data, if any, set by the export function is for the chunk rather than the payload
+			ctx.SetRetryData(nil)
+			if chunker.persistOnError {
+				ctx.SetRetryData(payload)
+			}
+
+			return false, fmt.Errorf("function ExportChunks in pipeline '%s': export of chunk %d of %d failed: %s",
+				ctx.PipelineId(), index+1, count, err.Error())
+		}
+	}
+
+	return true, data
+}
+
+type chunkTransfer struct {
+	count   uint32
+	chunks  map[uint32][]byte
+	started time.Time
+}
+
+// ChunkAssembler reassembles the payloads split into chunks by a Chunker. Chunks may arrive in any order, and
+// the chunks of incomplete payloads are discarded after the timeout.
+type ChunkAssembler struct {
+	timeout   time.Duration
+	lock      sync.Mutex
+	transfers map[uuid.UUID]*chunkTransfer
+}
+
+// NewChunkAssembler creates, initializes and returns a new instance of ChunkAssembler waiting up to the timeout for
+// the remaining chunks of a payload
+func NewChunkAssembler(timeout time.Duration) *ChunkAssembler {
+	return &ChunkAssembler{
+		timeout:   timeout,
+		transfers: make(map[uuid.UUID]*chunkTransfer),
+	}
+}
+
+// Reassemble collects the chunk received and continues the pipeline with the complete payload as []byte once all its
+// chunks have been received, otherwise it stops the pipeline without error. The pipeline's TargetType must be
+// []byte so the chunks are received unmodified.
+// This function will return an error and stop the pipeline if no data is received, the data isn't a chunk or the
+// reassembled payload doesn't match its checksum.
+func (assembler *ChunkAssembler) Reassemble(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Reassemble in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	chunk, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	header, chunkData, err := decodeChunk(chunk)
+	if err != nil {
+		return false, fmt.Errorf("function Reassemble in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	assembler.lock.Lock()
+	defer assembler.lock.Unlock()
+
+	now := time.Now()
+	assembler.expire(ctx, now)
+
+	transfer, found := assembler.transfers[header.transferId]
+	if !found {
+		transfer = &chunkTransfer{count: header.count, chunks: make(map[uint32][]byte), started: now}
+		assembler.transfers[header.transferId] = transfer
+	}
+
+	if header.count != transfer.count {
+		return false, fmt.Errorf("function Reassemble in pipeline '%s': chunk count %d of transfer %s doesn't match %d",
+			ctx.PipelineId(), header.count, header.transferId.String(), transfer.count)
+	}
+
+	// Copy the chunk's data since the received data may be reused
+	transfer.chunks[header.index] = append([]byte{}, chunkData...)
+
+	if len(transfer.chunks) < int(transfer.count) {
+		ctx.LoggingClient().Debugf("Received chunk %d of %d with transfer id %s in pipeline '%s'",
+			header.index+1, header.count, header.transferId.String(), ctx.PipelineId())
+		return false, nil
+	}
+
+	delete(assembler.transfers, header.transferId)
+
+	size := 0
+	for _, chunkData := range transfer.chunks {
+		size += len(chunkData)
+	}
+
+	payload := make([]byte, 0, size)
+	for index := uint32(0); index < transfer.count; index++ {
+		payload = append(payload, transfer.chunks[index]...)
+	}
+	if crc32.ChecksumIEEE(payload) != header.checksum {
+		return false, fmt.Errorf("function Reassemble in pipeline '%s': checksum of reassembled payload with transfer id %s doesn't match",
+			ctx.PipelineId(), header.transferId.String())
+	}
+
+	ctx.LoggingClient().Debugf("Reassembled %d bytes from %d chunks with transfer id %s in pipeline '%s'",
+		len(payload), header.count, header.transferId.String(), ctx.PipelineId())
+
+	return true, payload
+}
+
+// expire discards the transfers not completed within the timeout
+func (assembler *ChunkAssembler) expire(ctx interfaces.AppFunctionContext, now time.Time) {
+	for transferId, transfer := range assembler.transfers {
+		if now.Sub(transfer.started) > assembler.timeout {
+			ctx.LoggingClient().Warnf("Discarding %d of %d chunks with transfer id %s not completed within %s in pipeline '%s'",
+				len(transfer.chunks), transfer.count, transferId.String(), assembler.timeout.String(), ctx.PipelineId())
+			delete(assembler.transfers, transferId)
+		}
+	}
+}
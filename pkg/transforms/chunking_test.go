@@ -0,0 +1,191 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func collectChunks(chunks *[][]byte) interfaces.AppFunction {
+	return func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		*chunks = append(*chunks, data.([]byte))
+		return true, nil
+	}
+}
+
+func TestChunking(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 25)
+
+	tests := []struct {
+		Name          string
+		ChunkSize     int
+		Payload       []byte
+		ExpectedCount int
+	}{
+		{"Multiple chunks", ChunkHeaderSize + 100, payload, 3},
+		{"Exact chunks", ChunkHeaderSize + 50, payload, 5},
+		{"Single chunk", 1024, payload, 1},
+		{"Empty", 1024, []byte{}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var chunks [][]byte
+			continuePipeline, result := NewChunker(test.ChunkSize, false, collectChunks(&chunks)).ExportChunks(ctx, test.Payload)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, test.Payload, result)
+			require.Len(t, chunks, test.ExpectedCount)
+			for _, chunk := range chunks {
+				assert.LessOrEqual(t, len(chunk), test.ChunkSize)
+			}
+
+			// Deliver the chunks out of order
+			assembler := NewChunkAssembler(DefaultChunkTimeout)
+			for index := len(chunks) - 1; index > 0; index-- {
+				continuePipeline, result = assembler.Reassemble(ctx, chunks[index])
+				require.False(t, continuePipeline)
+				require.Nil(t, result, "pipeline should stop without error until all chunks are received")
+			}
+
+			continuePipeline, result = assembler.Reassemble(ctx, chunks[0])
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, test.Payload, result)
+			assert.Empty(t, assembler.transfers)
+		})
+	}
+}
+
+func TestChunking_Interleaved(t *testing.T) {
+	var first, second [][]byte
+	chunker := NewChunker(ChunkHeaderSize+4, false, nil)
+	chunker.export = collectChunks(&first)
+	chunker.ExportChunks(ctx, "first payload")
+	chunker.export = collectChunks(&second)
+	chunker.ExportChunks(ctx, "second payload")
+
+	assembler := NewChunkAssembler(DefaultChunkTimeout)
+	var results []string
+	for index := 0; index < len(second); index++ {
+		for _, chunks := range [][][]byte{first, second} {
+			if index >= len(chunks) {
+				continue
+			}
+			if continuePipeline, result := assembler.Reassemble(ctx, chunks[index]); continuePipeline {
+				results = append(results, string(result.([]byte)))
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"first payload", "second payload"}, results)
+}
+
+func TestChunker_ExportFailed(t *testing.T) {
+	exports := 0
+	failSecond := func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		exports++
+		if exports == 2 {
+			ctx.SetRetryData(data.([]byte))
+			return false, errors.New("broker unavailable")
+		}
+		return true, nil
+	}
+
+	for _, persistOnError := range []bool{true, false} {
+		exports = 0
+		ctx.SetRetryData(nil)
+
+		continuePipeline, result := NewChunker(ChunkHeaderSize+4, persistOnError, failSecond).ExportChunks(ctx, msgStr)
+		require.False(t, continuePipeline)
+		require.Error(t, result.(error))
+		assert.Contains(t, result.(error).Error(), "export of chunk 2 of")
+		assert.Equal(t, 2, exports, "remaining chunks should not be exported")
+
+		if persistOnError {
+			assert.Equal(t, []byte(msgStr), ctx.RetryData(), "the payload rather than the chunk should be retried")
+		} else {
+			assert.Nil(t, ctx.RetryData())
+		}
+	}
+
+	continuePipeline, result := NewChunker(ChunkHeaderSize, false, failSecond).ExportChunks(ctx, msgStr)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "chunk size must be greater")
+}
+
+func TestChunkAssembler_Errors(t *testing.T) {
+	var chunks [][]byte
+	NewChunker(ChunkHeaderSize+4, false, collectChunks(&chunks)).ExportChunks(ctx, msgStr)
+
+	corrupted := append([]byte{}, chunks[0]...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	badIndex := append([]byte{}, chunks[0]...)
+	badIndex[24] = 0xff
+
+	tests := []struct {
+		Name          string
+		Chunks        [][]byte
+		ExpectedError string
+	}{
+		{"No data", [][]byte{nil}, "No Data Received"},
+		{"Not a chunk", [][]byte{[]byte(msgStr)}, "data is not a chunk"},
+		{"Bad index", [][]byte{badIndex}, "invalid chunk"},
+		{"Checksum", append([][]byte{corrupted}, chunks[1:]...), "checksum of reassembled payload"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assembler := NewChunkAssembler(DefaultChunkTimeout)
+
+			var continuePipeline bool
+			var result interface{}
+			for _, chunk := range test.Chunks {
+				var data interface{}
+				if chunk != nil {
+					data = chunk
+				}
+				continuePipeline, result = assembler.Reassemble(ctx, data)
+			}
+
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestChunkAssembler_Timeout(t *testing.T) {
+	var chunks [][]byte
+	NewChunker(ChunkHeaderSize+4, false, collectChunks(&chunks)).ExportChunks(ctx, msgStr)
+
+	assembler := NewChunkAssembler(time.Millisecond)
+	assembler.Reassemble(ctx, chunks[0])
+	time.Sleep(5 * time.Millisecond)
+
+	for _, chunk := range chunks[1:] {
+		continuePipeline, result := assembler.Reassemble(ctx, chunk)
+		require.False(t, continuePipeline, "expired chunks should not be reassembled")
+		require.Nil(t, result)
+	}
+}
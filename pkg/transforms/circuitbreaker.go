@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+
+	// DefaultProbeInterval is the time an open circuit waits before probing the destination when no interval is given
+	DefaultProbeInterval = 30 * time.Second
+)
+
+// CircuitBreaker stops the exports to a destination after consecutive failures, so the pipeline doesn't keep
+// hammering a dead endpoint. The circuit opens after the failure threshold is reached and the exports are skipped,
+// storing their data for Store and Forward to retry, until the probe interval has passed. The circuit is then
+// half-open and a single export probes the destination, closing the circuit when it succeeds or opening it for
+// another interval when it fails. A nil CircuitBreaker is always closed.
+// The same CircuitBreaker can be shared by the export functions sending to the same destination.
+type CircuitBreaker struct {
+	failureThreshold int
+	probeInterval    time.Duration
+	lock             sync.Mutex
+	state            string
+	failures         int
+	openedAt         time.Time
+	now              func() time.Time
+}
+
+// NewCircuitBreaker creates, initializes and returns a new instance of CircuitBreaker opening after failureThreshold
+// consecutive failures, 1 when less, and probing the destination every probeInterval while open, DefaultProbeInterval
+// when 0.
+func NewCircuitBreaker(failureThreshold int, probeInterval time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	if probeInterval <= 0 {
+		probeInterval = DefaultProbeInterval
+	}
+
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		probeInterval:    probeInterval,
+		state:            CircuitClosed,
+		now:              time.Now,
+	}
+}
+
+// State returns CircuitClosed, CircuitOpen or CircuitHalfOpen
+func (breaker *CircuitBreaker) State() string {
+	if breaker == nil {
+		return CircuitClosed
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	return breaker.state
+}
+
+// Allow returns whether an export may be sent, i.e. the circuit is closed, or it is the probe of an open circuit
+// whose probe interval has passed. The outcome of an allowed export must be recorded with Succeeded or Failed.
+func (breaker *CircuitBreaker) Allow() bool {
+	if breaker == nil {
+		return true
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	switch breaker.state {
+	case CircuitOpen:
+		if breaker.now().Sub(breaker.openedAt) < breaker.probeInterval {
+			return false
+		}
+		// Only the first export after the interval probes the destination
+		breaker.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// Succeeded records an export that succeeded, closing the circuit
+func (breaker *CircuitBreaker) Succeeded() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	breaker.state = CircuitClosed
+	breaker.failures = 0
+}
+
+// Failed records an export that failed, opening the circuit when the failure threshold is reached or the probe failed
+func (breaker *CircuitBreaker) Failed() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	breaker.failures++
+	if breaker.state == CircuitHalfOpen || breaker.failures >= breaker.failureThreshold {
+		breaker.state = CircuitOpen
+		breaker.openedAt = breaker.now()
+	}
+}
+
+// record records the outcome of an export returning the result, a failure being an error stopping the pipeline.
+// An export stopping the pipeline without an error didn't reach the destination, so a probe is left to the next export.
+func (breaker *CircuitBreaker) record(continuePipeline bool, result interface{}) {
+	if continuePipeline {
+		breaker.Succeeded()
+		return
+	}
+
+	if _, failed := result.(error); failed {
+		breaker.Failed()
+		return
+	}
+
+	if breaker == nil {
+		return
+	}
+
+	breaker.lock.Lock()
+	defer breaker.lock.Unlock()
+
+	if breaker.state == CircuitHalfOpen {
+		breaker.state = CircuitOpen
+	}
+}
+
+// CircuitBreakerExporter protects any export function with a CircuitBreaker
+type CircuitBreakerExporter struct {
+	breaker        *CircuitBreaker
+	persistOnError bool
+	export         interfaces.AppFunction
+}
+
+// NewCircuitBreakerExporter creates, initializes and returns a new instance of CircuitBreakerExporter. persistOnError
+// enables use of store & forward for the data of the exports skipped while the circuit is open. The export function
+// should persist its own data on error.
+func NewCircuitBreakerExporter(breaker *CircuitBreaker, persistOnError bool, export interfaces.AppFunction) *CircuitBreakerExporter {
+	return &CircuitBreakerExporter{
+		breaker:        breaker,
+		persistOnError: persistOnError,
+		export:         export,
+	}
+}
+
+// Export executes the export function when the circuit allows it and records its outcome, otherwise skips the export.
+// This function will return an error and stop the pipeline if no data is received, the circuit is open or the export
+// function fails.
+func (exporter *CircuitBreakerExporter) Export(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Export in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	if !exporter.breaker.Allow() {
+		if exporter.persistOnError {
+			exportData, err := util.CoerceType(data)
+			if err != nil {
+				return false, err
+			}
+			ctx.SetRetryData(exportData)
+		}
+
+		return false, fmt.Errorf("function Export in pipeline '%s': circuit breaker open, export skipped", ctx.PipelineId())
+	}
+
+	continuePipeline, result := exporter.export(ctx, data)
+	exporter.breaker.record(continuePipeline, result)
+
+	return continuePipeline, result
+}
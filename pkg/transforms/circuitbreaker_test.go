@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestCircuitBreaker_States(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	require.True(t, breaker.Allow())
+	breaker.Failed()
+	assert.Equal(t, CircuitClosed, breaker.State(), "circuit should stay closed below the threshold")
+
+	breaker.Succeeded()
+	breaker.Failed()
+	assert.Equal(t, CircuitClosed, breaker.State(), "only consecutive failures should open the circuit")
+
+	breaker.Failed()
+	assert.Equal(t, CircuitOpen, breaker.State())
+	assert.False(t, breaker.Allow(), "open circuit should skip the exports")
+
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Allow(), "first export after the probe interval should probe")
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	assert.False(t, breaker.Allow(), "only one export should probe")
+
+	breaker.Failed()
+	assert.Equal(t, CircuitOpen, breaker.State(), "failed probe should open the circuit again")
+	assert.False(t, breaker.Allow())
+
+	now = now.Add(time.Minute)
+	require.True(t, breaker.Allow())
+	breaker.Succeeded()
+	assert.Equal(t, CircuitClosed, breaker.State(), "successful probe should close the circuit")
+	assert.True(t, breaker.Allow())
+
+	var noBreaker *CircuitBreaker
+	assert.True(t, noBreaker.Allow(), "nil circuit breaker should always be closed")
+	noBreaker.Failed()
+	assert.Equal(t, CircuitClosed, noBreaker.State())
+}
+
+func TestCircuitBreakerExporter_Export(t *testing.T) {
+	var calls int
+	failing := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		calls++
+		return false, errors.New("endpoint down")
+	}
+
+	now := time.Now()
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	exporter := NewCircuitBreakerExporter(breaker, true, failing)
+
+	for i := 0; i < 2; i++ {
+		continuePipeline, result := exporter.Export(ctx, "data")
+		require.False(t, continuePipeline)
+		assert.Contains(t, result.(error).Error(), "endpoint down")
+	}
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := exporter.Export(ctx, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "circuit breaker open")
+	assert.Equal(t, 2, calls, "export should be skipped while the circuit is open")
+	assert.Equal(t, []byte("data"), ctx.RetryData(), "skipped export should be stored for retry")
+	ctx.SetRetryData(nil)
+
+	continuePipeline, result = exporter.Export(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestCircuitBreakerExporter_ProbeFiltered(t *testing.T) {
+	filter := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		return false, nil
+	}
+
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	breaker.Failed()
+
+	now = now.Add(time.Minute)
+	exporter := NewCircuitBreakerExporter(breaker, false, filter)
+	continuePipeline, _ := exporter.Export(ctx, "data")
+	require.False(t, continuePipeline)
+
+	assert.True(t, breaker.Allow(), "probe not reaching the destination should be left to the next export")
+}
+
+func TestHTTPPostCircuitBreaker(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	breaker := NewCircuitBreaker(2, time.Minute)
+	sender := NewHTTPSenderWithOptions(HTTPSenderOptions{
+		URL:            ts.URL,
+		MimeType:       "application/json",
+		PersistOnError: true,
+		CircuitBreaker: breaker,
+	})
+
+	for i := 0; i < 3; i++ {
+		ctx.SetRetryData(nil)
+		continuePipeline, _ := sender.HTTPPost(ctx, "data")
+		require.False(t, continuePipeline)
+		assert.Equal(t, []byte("data"), ctx.RetryData(), "failed or skipped export should be stored for retry")
+	}
+
+	ctx.SetRetryData(nil)
+	assert.Equal(t, 2, requests, "endpoint should not be called while the circuit is open")
+	assert.Equal(t, CircuitOpen, breaker.State())
+}
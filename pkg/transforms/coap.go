@@ -0,0 +1,705 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/pion/dtls/v2"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/secure"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	CoAPSecurityNone = "none"
+	CoAPSecurityPSK  = "psk"
+	CoAPSecurityCert = "cert"
+
+	// CoAPIdentityKey and CoAPPSKKey are the keys of the PSK identity and the hex encoded pre-shared key in the secret
+	// for PSK security. Cert security reads the clientcert, clientkey and optional cacert secrets.
+	CoAPIdentityKey = "identity"
+	CoAPPSKKey      = "psk"
+
+	// DefaultCoAPTimeout is the time to wait for the response to an export when no timeout is given
+	DefaultCoAPTimeout = 30 * time.Second
+	// DefaultCoAPBlockSize is the size of the blocks a payload larger than it is sent in when no block size is given
+	DefaultCoAPBlockSize = 1024
+)
+
+// CoAP message types, codes and options, per RFC 7252 and RFC 7959
+const (
+	coapConfirmable     = 0
+	coapNonConfirmable  = 1
+	coapAcknowledgement = 2
+	coapReset           = 3
+
+	coapCodeEmpty    = 0x00
+	coapCodePost     = 0x02
+	coapCodePut      = 0x03
+	coapCodeContinue = 0x5F // 2.31
+
+	coapOptionUriPath       = 11
+	coapOptionContentFormat = 12
+	coapOptionUriQuery      = 15
+	coapOptionBlock1        = 27
+
+	coapPayloadMarker = 0xFF
+	coapAckTimeout    = 2 * time.Second
+	coapMaxRetransmit = 4
+)
+
+// coapContentFormats are the CoAP content formats of the supported mime types
+var coapContentFormats = map[string]uint32{
+	common.ContentTypeText:     0,
+	common.ContentTypeXML:      41,
+	"application/octet-stream": 42,
+	common.ContentTypeJSON:     50,
+	common.ContentTypeCBOR:     60,
+}
+
+// CoAPConfig contains the configuration of a CoAPSender
+type CoAPConfig struct {
+	// URL of the resource, i.e. "coap://collector/telemetry" or "coaps://collector:5684/telemetry", which may contain
+	// placeholders, i.e. "{deviceName}", replaced with the context's values or tags
+	URL string
+	// Method is http.MethodPost, the default, or http.MethodPut
+	Method string
+	// MimeType of the payload, application/json when empty, sent as the CoAP content format
+	MimeType string
+	// Security is CoAPSecurityPSK or CoAPSecurityCert for DTLS, required by the coaps scheme, with the secrets at
+	// SecretPath, otherwise CoAPSecurityNone or empty
+	Security string
+	// SecretPath is the path of the "identity" and "psk" secrets for PSK security, or the "clientcert", "clientkey" and
+	// optional "cacert" secrets for cert security
+	SecretPath string
+	// SkipCertVerify disables the verification of the server's certificate for cert security
+	SkipCertVerify bool
+	// Timeout is the time to wait for the response, including retransmissions, DefaultCoAPTimeout when 0
+	Timeout time.Duration
+	// BlockSize is the size of the blocks, a power of 2 from 16 to 1024, a payload larger than it is sent in using
+	// block-wise transfer, DefaultCoAPBlockSize when 0
+	BlockSize int
+	// PersistOnError enables use of store & forward when the export fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// CoAPSender sends data to a CoAP (RFC 7252) resource, optionally secured with DTLS, i.e. for constrained collectors
+// that don't speak HTTP. Confirmable requests are retransmitted until acknowledged, and payloads larger than the block
+// size are sent with block-wise transfer (RFC 7959).
+type CoAPSender struct {
+	config        CoAPConfig
+	code          uint8
+	contentFormat uint32
+	dial          func(ctx interfaces.AppFunctionContext, address string) (net.Conn, error)
+
+	lock                 sync.Mutex
+	conn                 net.Conn
+	address              string
+	messageId            uint16
+	secretsLastRetrieved time.Time
+}
+
+// coapMessage is a CoAP message
+type coapMessage struct {
+	Type      uint8
+	Code      uint8
+	MessageId uint16
+	Token     []byte
+	Options   []coapOption
+	Payload   []byte
+}
+
+type coapOption struct {
+	Number uint16
+	Value  []byte
+}
+
+// NewCoAPSender creates, initializes and returns a new instance of CoAPSender
+func NewCoAPSender(config CoAPConfig) (*CoAPSender, error) {
+	config.URL = strings.TrimSpace(config.URL)
+	parsed, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL '%s': %s", config.URL, err.Error())
+	}
+	if parsed.Scheme != "coap" && parsed.Scheme != "coaps" {
+		return nil, fmt.Errorf("invalid URL '%s', must start with coap:// or coaps://", config.URL)
+	}
+	if len(parsed.Host) == 0 {
+		return nil, fmt.Errorf("invalid URL '%s', host must be set", config.URL)
+	}
+
+	sender := &CoAPSender{}
+
+	switch strings.ToUpper(strings.TrimSpace(config.Method)) {
+	case "", "POST":
+		sender.code = coapCodePost
+	case "PUT":
+		sender.code = coapCodePut
+	default:
+		return nil, fmt.Errorf("invalid method '%s', must be 'POST' or 'PUT'", config.Method)
+	}
+
+	if len(config.MimeType) == 0 {
+		config.MimeType = common.ContentTypeJSON
+	}
+	contentFormat, found := coapContentFormats[config.MimeType]
+	if !found {
+		return nil, fmt.Errorf("mime type '%s' has no CoAP content format", config.MimeType)
+	}
+	sender.contentFormat = contentFormat
+
+	config.Security = strings.ToLower(strings.TrimSpace(config.Security))
+	switch config.Security {
+	case "", CoAPSecurityNone:
+		config.Security = CoAPSecurityNone
+		if parsed.Scheme == "coaps" {
+			return nil, errors.New("security must be 'psk' or 'cert' for the coaps scheme")
+		}
+	case CoAPSecurityPSK, CoAPSecurityCert:
+		if parsed.Scheme != "coaps" {
+			return nil, fmt.Errorf("'%s' security requires the coaps scheme", config.Security)
+		}
+		if len(strings.TrimSpace(config.SecretPath)) == 0 {
+			return nil, fmt.Errorf("secret path must be set for '%s' security", config.Security)
+		}
+	default:
+		return nil, fmt.Errorf("invalid security '%s', must be '%s', '%s' or '%s'", config.Security,
+			CoAPSecurityNone, CoAPSecurityPSK, CoAPSecurityCert)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultCoAPTimeout
+	}
+
+	if config.BlockSize == 0 {
+		config.BlockSize = DefaultCoAPBlockSize
+	}
+	if coapBlockSizeExponent(config.BlockSize) < 0 {
+		return nil, fmt.Errorf("invalid block size %d, must be a power of 2 from 16 to 1024", config.BlockSize)
+	}
+
+	sender.config = config
+	sender.dial = sender.dialConn
+	sender.messageId = uint16(coapRandom(1 << 16))
+
+	return sender, nil
+}
+
+// CoAPSend sends the data from the previous function to the resource in a confirmable request.
+// This function will return an error and stop the pipeline if no data is received, the request isn't acknowledged or
+// the server responds with an error.
+func (sender *CoAPSender) CoAPSend(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function CoAPSend in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, err := util.CoerceTypeTo(sender.config.MimeType, data)
+	if err != nil {
+		return false, err
+	}
+
+	// The URL's placeholders, i.e. "{deviceName}", are replaced with the context's values or tags
+	formattedUrl, err := applyValuesAndTags(ctx, sender.config.URL)
+	if err != nil {
+		return false, fmt.Errorf("function CoAPSend in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+	target, err := url.Parse(formattedUrl)
+	if err != nil {
+		return false, fmt.Errorf("function CoAPSend in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not send %d bytes of data to %s", ctx.PipelineId(), len(exportData), target.Redacted())
+		return true, nil
+	}
+
+	destination := target.Scheme + "://" + target.Host + target.Path
+	if err := skipExport(ctx, sender.config.CircuitBreaker, destination, sender.config.PersistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, destination)
+
+	response, err := sender.send(ctx, target, exportData)
+	if err != nil {
+		err = fmt.Errorf("function CoAPSend in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.config.CircuitBreaker.Failed()
+		sender.setRetryData(ctx, exportData)
+		return false, err
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge("")
+
+	ctx.LoggingClient().Debugf("Sent %d bytes of data to %s in pipeline '%s'. Response code is %s",
+		len(exportData), destination, ctx.PipelineId(), coapCodeString(response.Code))
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// send sends the payload to the target, in blocks when larger than the block size, and returns the final response.
+// The connection is closed on error, so the next export connects again.
+func (sender *CoAPSender) send(ctx interfaces.AppFunctionContext, target *url.URL, payload []byte) (coapMessage, error) {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	conn, err := sender.connect(ctx, target)
+	if err != nil {
+		return coapMessage{}, err
+	}
+
+	response, err := sender.transfer(conn, target, payload, time.Now().Add(sender.config.Timeout))
+	if err != nil {
+		_ = sender.conn.Close()
+		sender.conn = nil
+		return coapMessage{}, err
+	}
+
+	return response, nil
+}
+
+// connect returns the connection to the target's host, dialing it when not connected to it, or when the secrets
+// used to secure it have been updated
+func (sender *CoAPSender) connect(ctx interfaces.AppFunctionContext, target *url.URL) (net.Conn, error) {
+	address := target.Host
+	if len(target.Port()) == 0 {
+		port := "5683"
+		if target.Scheme == "coaps" {
+			port = "5684"
+		}
+		address = net.JoinHostPort(target.Hostname(), port)
+	}
+
+	if sender.conn != nil {
+		if sender.address == address && (sender.config.Security == CoAPSecurityNone ||
+			!sender.secretsLastRetrieved.Before(ctx.SecretsLastUpdated())) {
+			return sender.conn, nil
+		}
+		_ = sender.conn.Close()
+		sender.conn = nil
+	}
+
+	conn, err := sender.dial(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to %s: %s", address, err.Error())
+	}
+
+	sender.conn = conn
+	sender.address = address
+	sender.secretsLastRetrieved = time.Now()
+	return conn, nil
+}
+
+// dialConn dials the address over UDP, secured with DTLS for PSK or cert security
+func (sender *CoAPSender) dialConn(ctx interfaces.AppFunctionContext, address string) (net.Conn, error) {
+	if sender.config.Security == CoAPSecurityNone {
+		return net.DialTimeout("udp", address, sender.config.Timeout)
+	}
+
+	udpAddress, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	dtlsConfig := &dtls.Config{
+		ExtendedMasterSecret: dtls.RequestExtendedMasterSecret,
+		ConnectContextMaker: func() (context.Context, func()) {
+			return context.WithTimeout(ctx.Context(), sender.config.Timeout)
+		},
+	}
+
+	switch sender.config.Security {
+	case CoAPSecurityPSK:
+		secrets, err := ctx.GetSecret(sender.config.SecretPath, CoAPIdentityKey, CoAPPSKKey)
+		if err != nil {
+			return nil, err
+		}
+		psk, err := hex.DecodeString(secrets[CoAPPSKKey])
+		if err != nil || len(psk) == 0 {
+			return nil, fmt.Errorf("'%s' secret must be a hex encoded key", CoAPPSKKey)
+		}
+
+		dtlsConfig.PSK = func([]byte) ([]byte, error) { return psk, nil }
+		dtlsConfig.PSKIdentityHint = []byte(secrets[CoAPIdentityKey])
+		dtlsConfig.CipherSuites = []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_CCM_8, dtls.TLS_PSK_WITH_AES_128_GCM_SHA256}
+	case CoAPSecurityCert:
+		tlsConfig, err := secure.NewClientTLSConfig(ctx, sender.config.SecretPath, sender.config.SkipCertVerify)
+		if err != nil {
+			return nil, err
+		}
+
+		host, _, _ := net.SplitHostPort(address)
+		dtlsConfig.Certificates = tlsConfig.Certificates
+		dtlsConfig.RootCAs = tlsConfig.RootCAs
+		dtlsConfig.InsecureSkipVerify = tlsConfig.InsecureSkipVerify
+		dtlsConfig.ServerName = host
+	}
+
+	return dtls.Dial("udp", udpAddress, dtlsConfig)
+}
+
+// transfer sends the payload in a single request, or in blocks with the Block1 option when larger than the block
+// size, and returns the final response, which must be a success
+func (sender *CoAPSender) transfer(conn net.Conn, target *url.URL, payload []byte, deadline time.Time) (coapMessage, error) {
+	options := []coapOption{{Number: coapOptionContentFormat, Value: coapUint(sender.contentFormat)}}
+	for _, segment := range strings.Split(strings.Trim(target.Path, "/"), "/") {
+		if len(segment) > 0 {
+			options = append(options, coapOption{Number: coapOptionUriPath, Value: []byte(segment)})
+		}
+	}
+	if len(target.RawQuery) > 0 {
+		for _, query := range strings.Split(target.RawQuery, "&") {
+			if unescaped, err := url.QueryUnescape(query); err == nil && len(unescaped) > 0 {
+				options = append(options, coapOption{Number: coapOptionUriQuery, Value: []byte(unescaped)})
+			}
+		}
+	}
+
+	token := make([]byte, 4)
+	if _, err := rand.Read(token); err != nil {
+		return coapMessage{}, err
+	}
+
+	blockSize := sender.config.BlockSize
+	for offset := 0; ; {
+		request := coapMessage{
+			Type:    coapConfirmable,
+			Code:    sender.code,
+			Token:   token,
+			Options: options,
+			Payload: payload,
+		}
+
+		last := true
+		if len(payload) > sender.config.BlockSize {
+			end := offset + blockSize
+			last = end >= len(payload)
+			if last {
+				end = len(payload)
+			}
+
+			more := uint32(0)
+			if !last {
+				more = 1
+			}
+			block := uint32(offset/blockSize)<<4 | more<<3 | uint32(coapBlockSizeExponent(blockSize))
+			request.Options = append(append([]coapOption{}, options...), coapOption{Number: coapOptionBlock1, Value: coapUint(block)})
+			request.Payload = payload[offset:end]
+		}
+
+		response, err := sender.exchange(conn, request, deadline)
+		if err != nil {
+			return coapMessage{}, err
+		}
+
+		if response.Code>>5 != 2 {
+			return coapMessage{}, fmt.Errorf("CoAP server responded with %s: %s", coapCodeString(response.Code), strings.TrimSpace(string(response.Payload)))
+		}
+
+		if last {
+			return response, nil
+		}
+
+		if response.Code != coapCodeContinue {
+			return coapMessage{}, fmt.Errorf("CoAP server responded with %s rather than 2.31 Continue to block %d", coapCodeString(response.Code), offset/blockSize)
+		}
+
+		offset += len(request.Payload)
+
+		// The server may ask for smaller blocks in its response's Block1 option
+		if value, found := response.option(coapOptionBlock1); found {
+			if size := 1 << (coapUintValue(value)&0x07 + 4); size < blockSize {
+				blockSize = size
+			}
+		}
+	}
+}
+
+// exchange sends the confirmable request, retransmitting it with exponential backoff until acknowledged, and returns
+// its response, either piggybacked on the acknowledgement or sent separately
+func (sender *CoAPSender) exchange(conn net.Conn, request coapMessage, deadline time.Time) (coapMessage, error) {
+	sender.messageId++
+	request.MessageId = sender.messageId
+
+	data, err := request.marshal()
+	if err != nil {
+		return coapMessage{}, err
+	}
+
+	// The initial timeout is randomized between ACK_TIMEOUT and 1.5 times ACK_TIMEOUT per RFC 7252
+	timeout := coapAckTimeout + time.Duration(coapRandom(int64(coapAckTimeout/2)))
+	buffer := make([]byte, 65535)
+	acknowledged := false
+	for retransmissions := 0; ; {
+		if !acknowledged {
+			if _, err := conn.Write(data); err != nil {
+				return coapMessage{}, err
+			}
+		}
+
+		wait := time.Now().Add(timeout)
+		if acknowledged || wait.After(deadline) {
+			wait = deadline
+		}
+		if err := conn.SetReadDeadline(wait); err != nil {
+			return coapMessage{}, err
+		}
+
+		n, err := conn.Read(buffer)
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return coapMessage{}, err
+			}
+			if !time.Now().Before(deadline) {
+				return coapMessage{}, fmt.Errorf("no response within %s", sender.config.Timeout.String())
+			}
+			if retransmissions++; retransmissions > coapMaxRetransmit {
+				return coapMessage{}, fmt.Errorf("request not acknowledged after %d retransmissions", coapMaxRetransmit)
+			}
+			timeout *= 2
+			continue
+		}
+
+		response, err := unmarshalCoAPMessage(buffer[:n])
+		if err != nil {
+			// Malformed messages are silently ignored per RFC 7252
+			continue
+		}
+
+		switch {
+		case response.Type == coapReset && response.MessageId == request.MessageId:
+			return coapMessage{}, errors.New("request rejected with a reset message")
+		case response.Type == coapAcknowledgement && response.MessageId == request.MessageId:
+			if response.Code == coapCodeEmpty {
+				// The response is sent separately once the server has processed the request
+				acknowledged = true
+				continue
+			}
+			if string(response.Token) == string(request.Token) {
+				return response, nil
+			}
+		case (response.Type == coapConfirmable || response.Type == coapNonConfirmable) && string(response.Token) == string(request.Token):
+			if response.Type == coapConfirmable {
+				ack, _ := coapMessage{Type: coapAcknowledgement, Code: coapCodeEmpty, MessageId: response.MessageId}.marshal()
+				if _, err := conn.Write(ack); err != nil {
+					return coapMessage{}, err
+				}
+			}
+			return response, nil
+		}
+	}
+}
+
+func (sender *CoAPSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
+	if sender.config.PersistOnError {
+		ctx.SetRetryData(exportData)
+	}
+}
+
+// marshal encodes the message, its options being sorted by number and delta encoded
+func (message coapMessage) marshal() ([]byte, error) {
+	if len(message.Token) > 8 {
+		return nil, errors.New("token longer than 8 bytes")
+	}
+
+	data := []byte{1<<6 | message.Type<<4 | uint8(len(message.Token)), message.Code, 0, 0}
+	binary.BigEndian.PutUint16(data[2:], message.MessageId)
+	data = append(data, message.Token...)
+
+	options := append([]coapOption{}, message.Options...)
+	sort.SliceStable(options, func(i, j int) bool { return options[i].Number < options[j].Number })
+
+	previous := uint16(0)
+	for _, option := range options {
+		delta, deltaExtended := coapOptionNibble(int(option.Number - previous))
+		length, lengthExtended := coapOptionNibble(len(option.Value))
+		data = append(data, delta<<4|length)
+		data = append(data, deltaExtended...)
+		data = append(data, lengthExtended...)
+		data = append(data, option.Value...)
+		previous = option.Number
+	}
+
+	if len(message.Payload) > 0 {
+		data = append(data, coapPayloadMarker)
+		data = append(data, message.Payload...)
+	}
+
+	return data, nil
+}
+
+// coapOptionNibble returns the 4 bit value of an option's delta or length and its extended bytes
+func coapOptionNibble(value int) (uint8, []byte) {
+	switch {
+	case value < 13:
+		return uint8(value), nil
+	case value < 269:
+		return 13, []byte{uint8(value - 13)}
+	default:
+		extended := make([]byte, 2)
+		binary.BigEndian.PutUint16(extended, uint16(value-269))
+		return 14, extended
+	}
+}
+
+// unmarshalCoAPMessage decodes a message
+func unmarshalCoAPMessage(data []byte) (coapMessage, error) {
+	if len(data) < 4 || data[0]>>6 != 1 {
+		return coapMessage{}, errors.New("not a CoAP message")
+	}
+
+	message := coapMessage{
+		Type:      data[0] >> 4 & 0x03,
+		Code:      data[1],
+		MessageId: binary.BigEndian.Uint16(data[2:4]),
+	}
+
+	tokenLength := int(data[0] & 0x0F)
+	if tokenLength > 8 || len(data) < 4+tokenLength {
+		return coapMessage{}, errors.New("invalid token length")
+	}
+	message.Token = append([]byte{}, data[4:4+tokenLength]...)
+
+	rest := data[4+tokenLength:]
+	number := 0
+	for len(rest) > 0 {
+		if rest[0] == coapPayloadMarker {
+			if len(rest) == 1 {
+				return coapMessage{}, errors.New("payload marker without payload")
+			}
+			message.Payload = append([]byte{}, rest[1:]...)
+			break
+		}
+
+		header := rest[0]
+		rest = rest[1:]
+
+		var delta, length int
+		var err error
+		if delta, rest, err = coapOptionValue(header>>4, rest); err != nil {
+			return coapMessage{}, err
+		}
+		if length, rest, err = coapOptionValue(header&0x0F, rest); err != nil {
+			return coapMessage{}, err
+		}
+		if len(rest) < length {
+			return coapMessage{}, errors.New("option value truncated")
+		}
+
+		number += delta
+		message.Options = append(message.Options, coapOption{Number: uint16(number), Value: append([]byte{}, rest[:length]...)})
+		rest = rest[length:]
+	}
+
+	return message, nil
+}
+
+// coapOptionValue returns an option's delta or length from its 4 bit value and extended bytes, and the rest of the data
+func coapOptionValue(nibble uint8, data []byte) (int, []byte, error) {
+	switch nibble {
+	case 13:
+		if len(data) < 1 {
+			return 0, nil, errors.New("option truncated")
+		}
+		return int(data[0]) + 13, data[1:], nil
+	case 14:
+		if len(data) < 2 {
+			return 0, nil, errors.New("option truncated")
+		}
+		return int(binary.BigEndian.Uint16(data)) + 269, data[2:], nil
+	case 15:
+		return 0, nil, errors.New("reserved option nibble")
+	default:
+		return int(nibble), data, nil
+	}
+}
+
+// option returns the value of the message's first option with the number
+func (message coapMessage) option(number uint16) ([]byte, bool) {
+	for _, option := range message.Options {
+		if option.Number == number {
+			return option.Value, true
+		}
+	}
+	return nil, false
+}
+
+// coapUint encodes an unsigned integer option value in as few bytes as possible, no bytes for 0
+func coapUint(value uint32) []byte {
+	encoded := make([]byte, 4)
+	binary.BigEndian.PutUint32(encoded, value)
+	for len(encoded) > 0 && encoded[0] == 0 {
+		encoded = encoded[1:]
+	}
+	return encoded
+}
+
+// coapUintValue decodes an unsigned integer option value
+func coapUintValue(value []byte) uint32 {
+	var decoded uint32
+	for _, b := range value {
+		decoded = decoded<<8 | uint32(b)
+	}
+	return decoded
+}
+
+// coapBlockSizeExponent returns the SZX of the block size, or -1 when it isn't a power of 2 from 16 to 1024
+func coapBlockSizeExponent(size int) int {
+	for exponent := 0; exponent <= 6; exponent++ {
+		if size == 1<<(exponent+4) {
+			return exponent
+		}
+	}
+	return -1
+}
+
+// coapCodeString returns the code in its c.dd form, i.e. "2.04"
+func coapCodeString(code uint8) string {
+	return strconv.Itoa(int(code>>5)) + "." + fmt.Sprintf("%02d", code&0x1F)
+}
+
+// coapRandom returns a random number from 0 to max, exclusive
+func coapRandom(max int64) int64 {
+	value, err := rand.Int(rand.Reader, big.NewInt(max))
+	if err != nil {
+		return 0
+	}
+	return value.Int64()
+}
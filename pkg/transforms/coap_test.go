@@ -0,0 +1,321 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	mocks2 "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/pion/dtls/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// coapTestServer answers each request received on a UDP socket with the messages returned by the handler
+func coapTestServer(t *testing.T, handler func(request coapMessage) []coapMessage) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, address, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			request, err := unmarshalCoAPMessage(buffer[:n])
+			if err != nil {
+				continue
+			}
+			for _, response := range handler(request) {
+				data, _ := response.marshal()
+				_, _ = conn.WriteTo(data, address)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { _ = conn.Close() }
+}
+
+// coapPiggybacked returns the response piggybacked on the acknowledgement of the request
+func coapPiggybacked(request coapMessage, code uint8, options ...coapOption) coapMessage {
+	return coapMessage{Type: coapAcknowledgement, Code: code, MessageId: request.MessageId, Token: request.Token, Options: options}
+}
+
+func TestCoAPMessage_Marshal(t *testing.T) {
+	message := coapMessage{
+		Type:      coapConfirmable,
+		Code:      coapCodePut,
+		MessageId: 0x1234,
+		Token:     []byte{1, 2, 3, 4},
+		Options: []coapOption{
+			{Number: coapOptionUriQuery, Value: []byte("q=1")},
+			{Number: coapOptionUriPath, Value: []byte("a-path-segment-longer-than-12")},
+			{Number: coapOptionContentFormat, Value: coapUint(50)},
+			{Number: 2048, Value: make([]byte, 300)},
+		},
+		Payload: []byte("payload"),
+	}
+
+	data, err := message.marshal()
+	require.NoError(t, err)
+
+	decoded, err := unmarshalCoAPMessage(data)
+	require.NoError(t, err)
+	assert.Equal(t, message.Type, decoded.Type)
+	assert.Equal(t, message.Code, decoded.Code)
+	assert.Equal(t, message.MessageId, decoded.MessageId)
+	assert.Equal(t, message.Token, decoded.Token)
+	assert.Equal(t, message.Payload, decoded.Payload)
+	require.Len(t, decoded.Options, 4)
+	assert.Equal(t, []uint16{coapOptionUriPath, coapOptionContentFormat, coapOptionUriQuery, 2048},
+		[]uint16{decoded.Options[0].Number, decoded.Options[1].Number, decoded.Options[2].Number, decoded.Options[3].Number},
+		"options should be sorted by number")
+	assert.Equal(t, message.Options[3].Value, decoded.Options[3].Value)
+
+	_, err = unmarshalCoAPMessage([]byte{0x40, 0x01})
+	assert.Error(t, err)
+	_, err = unmarshalCoAPMessage(append(data[:8:8], coapPayloadMarker))
+	assert.Error(t, err)
+
+	assert.Equal(t, "2.04", coapCodeString(0x44))
+	assert.Equal(t, []byte{}, coapUint(0))
+	assert.Equal(t, uint32(1030), coapUintValue(coapUint(1030)))
+}
+
+func TestNewCoAPSender(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Config      CoAPConfig
+		ExpectError bool
+	}{
+		{"Valid", CoAPConfig{URL: "coap://collector/telemetry"}, false},
+		{"Valid PSK", CoAPConfig{URL: "coaps://collector/telemetry", Method: "put", Security: "PSK", SecretPath: "coap"}, false},
+		{"Valid cert", CoAPConfig{URL: "coaps://collector/telemetry", Security: CoAPSecurityCert, SecretPath: "coap", BlockSize: 64}, false},
+		{"Not CoAP", CoAPConfig{URL: "http://collector/telemetry"}, true},
+		{"No host", CoAPConfig{URL: "coap:///telemetry"}, true},
+		{"Bad method", CoAPConfig{URL: "coap://collector", Method: "get"}, true},
+		{"Bad mime type", CoAPConfig{URL: "coap://collector", MimeType: "image/png"}, true},
+		{"Coaps without security", CoAPConfig{URL: "coaps://collector"}, true},
+		{"Security without coaps", CoAPConfig{URL: "coap://collector", Security: CoAPSecurityPSK, SecretPath: "coap"}, true},
+		{"Security without secret path", CoAPConfig{URL: "coaps://collector", Security: CoAPSecurityPSK}, true},
+		{"Bad security", CoAPConfig{URL: "coaps://collector", Security: "oscore", SecretPath: "coap"}, true},
+		{"Bad block size", CoAPConfig{URL: "coap://collector", BlockSize: 100}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewCoAPSender(test.Config)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCoAPSender_CoAPSend(t *testing.T) {
+	var received coapMessage
+	address, closeServer := coapTestServer(t, func(request coapMessage) []coapMessage {
+		received = request
+		return []coapMessage{coapPiggybacked(request, 0x44)}
+	})
+	defer closeServer()
+
+	sender, err := NewCoAPSender(CoAPConfig{URL: "coap://" + address + "/telemetry/{deviceName}?type=event", Method: "put"})
+	require.NoError(t, err)
+
+	coapCtx := appfunction.NewContext("123", dic, "")
+	coapCtx.AddValue(interfaces.DEVICENAME, "meter-1")
+	continuePipeline, result := sender.CoAPSend(coapCtx, `{"value":1}`)
+	require.True(t, continuePipeline, result)
+	assert.Nil(t, result)
+
+	assert.Equal(t, uint8(coapConfirmable), received.Type)
+	assert.Equal(t, uint8(coapCodePut), received.Code)
+	assert.Equal(t, `{"value":1}`, string(received.Payload))
+	var paths []string
+	for _, option := range received.Options {
+		switch option.Number {
+		case coapOptionUriPath:
+			paths = append(paths, string(option.Value))
+		case coapOptionUriQuery:
+			assert.Equal(t, "type=event", string(option.Value))
+		case coapOptionContentFormat:
+			assert.Equal(t, uint32(50), coapUintValue(option.Value))
+		}
+	}
+	assert.Equal(t, []string{"telemetry", "meter-1"}, paths)
+
+	continuePipeline, result = sender.CoAPSend(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestCoAPSender_CoAPSendBlockwise(t *testing.T) {
+	var payload []byte
+	var blocks []uint32
+	address, closeServer := coapTestServer(t, func(request coapMessage) []coapMessage {
+		value, found := request.option(coapOptionBlock1)
+		require.True(t, found)
+		block := coapUintValue(value)
+		blocks = append(blocks, block)
+		payload = append(payload, request.Payload...)
+
+		if block&0x08 == 0 {
+			return []coapMessage{coapPiggybacked(request, 0x44)}
+		}
+		// Asks for blocks of 16 bytes rather than 32
+		return []coapMessage{coapPiggybacked(request, coapCodeContinue, coapOption{Number: coapOptionBlock1, Value: coapUint(block &^ 0x07)})}
+	})
+	defer closeServer()
+
+	sender, err := NewCoAPSender(CoAPConfig{URL: "coap://" + address + "/telemetry", MimeType: "text/plain", BlockSize: 32})
+	require.NoError(t, err)
+
+	data := "0123456789abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJ"
+	continuePipeline, result := sender.CoAPSend(ctx, data)
+	require.True(t, continuePipeline, result)
+
+	assert.Equal(t, data, string(payload))
+	// 32 bytes in block 0 of 32, then 16 bytes in block 2 of 16 and the remaining 8 in block 3
+	assert.Equal(t, []uint32{0x09, 0x28, 0x30}, blocks)
+}
+
+func TestCoAPSender_CoAPSendSeparateResponse(t *testing.T) {
+	acknowledged := make(chan uint16, 1)
+	address, closeServer := coapTestServer(t, func(request coapMessage) []coapMessage {
+		if request.Type == coapAcknowledgement {
+			acknowledged <- request.MessageId
+			return nil
+		}
+		return []coapMessage{
+			{Type: coapAcknowledgement, Code: coapCodeEmpty, MessageId: request.MessageId},
+			{Type: coapConfirmable, Code: 0x41, MessageId: 0x4242, Token: request.Token},
+		}
+	})
+	defer closeServer()
+
+	sender, err := NewCoAPSender(CoAPConfig{URL: "coap://" + address + "/telemetry"})
+	require.NoError(t, err)
+
+	continuePipeline, result := sender.CoAPSend(ctx, "data")
+	require.True(t, continuePipeline, result)
+
+	select {
+	case messageId := <-acknowledged:
+		assert.Equal(t, uint16(0x4242), messageId, "confirmable response should be acknowledged")
+	case <-time.After(time.Second):
+		assert.Fail(t, "confirmable response not acknowledged")
+	}
+}
+
+func TestCoAPSender_CoAPSendFailed(t *testing.T) {
+	address, closeServer := coapTestServer(t, func(request coapMessage) []coapMessage {
+		response := coapPiggybacked(request, 0x80)
+		response.Payload = []byte("bad payload")
+		return []coapMessage{response}
+	})
+	defer closeServer()
+
+	breaker := NewCircuitBreaker(1, time.Minute)
+	sender, err := NewCoAPSender(CoAPConfig{URL: "coap://" + address + "/telemetry", PersistOnError: true, CircuitBreaker: breaker})
+	require.NoError(t, err)
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := sender.CoAPSend(ctx, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "4.00: bad payload")
+	assert.Equal(t, []byte("data"), ctx.RetryData())
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result = sender.CoAPSend(ctx, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "circuit breaker open")
+	assert.Equal(t, []byte("data"), ctx.RetryData())
+	ctx.SetRetryData(nil)
+}
+
+func TestCoAPSender_CoAPSendPSK(t *testing.T) {
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "coap", CoAPIdentityKey, CoAPPSKKey).Return(map[string]string{
+		CoAPIdentityKey: "gateway-1",
+		CoAPPSKKey:      "000102030405060708090a0b0c0d0e0f",
+	}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	identities := make(chan string, 1)
+	listener, err := dtls.Listen("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")}, &dtls.Config{
+		PSK: func(identity []byte) ([]byte, error) {
+			identities <- string(identity)
+			return []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, nil
+		},
+		CipherSuites:         []dtls.CipherSuiteID{dtls.TLS_PSK_WITH_AES_128_CCM_8},
+		ExtendedMasterSecret: dtls.RequireExtendedMasterSecret,
+	})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buffer := make([]byte, 65535)
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return
+		}
+		request, err := unmarshalCoAPMessage(buffer[:n])
+		if err != nil {
+			return
+		}
+		received <- string(request.Payload)
+		data, _ := coapPiggybacked(request, 0x41).marshal()
+		_, _ = conn.Write(data)
+	}()
+
+	sender, err := NewCoAPSender(CoAPConfig{
+		URL:        "coaps://" + listener.Addr().String() + "/telemetry",
+		Security:   CoAPSecurityPSK,
+		SecretPath: "coap",
+		Timeout:    5 * time.Second,
+	})
+	require.NoError(t, err)
+
+	continuePipeline, result := sender.CoAPSend(ctx, "data")
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "gateway-1", <-identities)
+	assert.Equal(t, "data", <-received)
+}
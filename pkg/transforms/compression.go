@@ -23,6 +23,7 @@ import (
 	"compress/zlib"
 	"encoding/base64"
 	"fmt"
+	"io"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
 
@@ -116,6 +117,81 @@ func (compression *Compression) CompressWithZLIB(ctx interfaces.AppFunctionConte
 
 }
 
+// GUnzip decompresses gzip data received as either a string or []byte, Base64 encoded as returned by
+// CompressWithGZIP or not encoded, and returns the decompressed data as a []byte.
+// This function will return an error and stop the pipeline if no data is received or the data isn't valid gzip data.
+func (compression *Compression) GUnzip(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function GUnzip in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+	ctx.LoggingClient().Debugf("Decompression with GZIP in pipeline '%s'", ctx.PipelineId())
+	compressed, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	// gzip data always starts with the magic bytes 0x1f 0x8b, which aren't valid Base64
+	if len(compressed) < 2 || compressed[0] != 0x1f || compressed[1] != 0x8b {
+		compressed = decodeBase64(compressed)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return false, fmt.Errorf("unable to read GZIP data in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	return readDecompressed(ctx, "GZIP", reader)
+}
+
+// UnZLIB decompresses zlib data received as either a string or []byte, Base64 encoded as returned by
+// CompressWithZLIB or not encoded, and returns the decompressed data as a []byte.
+// This function will return an error and stop the pipeline if no data is received or the data isn't valid zlib data.
+func (compression *Compression) UnZLIB(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function UnZLIB in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+	ctx.LoggingClient().Debugf("Decompression with ZLIB in pipeline '%s'", ctx.PipelineId())
+	compressed, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	// A zlib header uses the deflate method and is a multiple of 31, which Base64 text rarely is
+	if len(compressed) < 2 || compressed[0]&0x0f != 8 || (uint16(compressed[0])<<8|uint16(compressed[1]))%31 != 0 {
+		compressed = decodeBase64(compressed)
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return false, fmt.Errorf("unable to read ZLIB data in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	return readDecompressed(ctx, "ZLIB", reader)
+}
+
+func readDecompressed(ctx interfaces.AppFunctionContext, algorithm string, reader io.ReadCloser) (bool, interface{}) {
+	defer func() { _ = reader.Close() }()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return false, fmt.Errorf("unable to decompress %s data in pipeline '%s': %s", algorithm, ctx.PipelineId(), err.Error())
+	}
+
+	return true, decompressed
+}
+
+// decodeBase64 returns the Base64 decoded data, or the data unchanged if it isn't valid Base64
+func decodeBase64(data []byte) []byte {
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return data
+	}
+
+	return decoded
+}
+
 func bytesBufferToBase64(lc logger.LoggingClient, buf bytes.Buffer) []byte {
 	lc.Debugf("Encoding compressed bytes of length %d vs %d", len(buf.Bytes()), buf.Len())
 	dst := make([]byte, base64.StdEncoding.EncodedLen(buf.Len()))
@@ -29,6 +29,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 )
 
 const (
@@ -86,6 +88,66 @@ func TestZlib(t *testing.T) {
 	assert.Equal(t, ctx.ResponseContentType(), common.ContentTypeText)
 }
 
+func TestDecompress(t *testing.T) {
+	comp := NewCompression()
+	_, gzipped := comp.CompressWithGZIP(ctx, []byte(clearString))
+	_, zlibbed := comp.CompressWithZLIB(ctx, []byte(clearString))
+
+	rawGzipped, err := base64.StdEncoding.DecodeString(string(gzipped.([]byte)))
+	require.NoError(t, err)
+	rawZlibbed, err := base64.StdEncoding.DecodeString(string(zlibbed.([]byte)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name       string
+		Decompress interfaces.AppFunction
+		Data       interface{}
+	}{
+		{"GZIP Base64", comp.GUnzip, gzipped},
+		{"GZIP Base64 string", comp.GUnzip, string(gzipped.([]byte))},
+		{"GZIP raw", comp.GUnzip, rawGzipped},
+		{"ZLIB Base64", comp.UnZLIB, zlibbed},
+		{"ZLIB raw", comp.UnZLIB, rawZlibbed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := test.Decompress(ctx, test.Data)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, []byte(clearString), result)
+		})
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	comp := NewCompression()
+	_, gzipped := comp.CompressWithGZIP(ctx, []byte(clearString))
+	truncated, err := base64.StdEncoding.DecodeString(string(gzipped.([]byte)))
+	require.NoError(t, err)
+	truncated = truncated[:len(truncated)-10]
+
+	tests := []struct {
+		Name       string
+		Decompress interfaces.AppFunction
+		Data       interface{}
+	}{
+		{"GZIP no data", comp.GUnzip, nil},
+		{"GZIP not compressed", comp.GUnzip, clearString},
+		{"GZIP truncated", comp.GUnzip, truncated},
+		{"ZLIB no data", comp.UnZLIB, nil},
+		{"ZLIB not compressed", comp.UnZLIB, clearString},
+		{"ZLIB gzip data", comp.UnZLIB, gzipped},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := test.Decompress(ctx, test.Data)
+			require.False(t, continuePipeline)
+			assert.Error(t, result.(error))
+		})
+	}
+}
+
 var result []byte
 
 func BenchmarkGzip(b *testing.B) {
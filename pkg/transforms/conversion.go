@@ -28,6 +28,8 @@ import (
 
 // Conversion houses various built in conversion transforms (XML, JSON, CSV)
 type Conversion struct {
+	xmlOptions  *XMLOptions
+	jsonOptions *JSONOptions
 }
 
 // NewConversion creates, initializes and returns a new instance of Conversion
@@ -35,7 +37,22 @@ func NewConversion() Conversion {
 	return Conversion{}
 }
 
-// TransformToXML transforms an EdgeX event to XML.
+// NewConversionWithXMLOptions creates, initializes and returns a new instance of Conversion whose TransformToXML
+// names the XML elements and attributes as specified by options
+func NewConversionWithXMLOptions(options XMLOptions) Conversion {
+	return Conversion{xmlOptions: &options}
+}
+
+// NewConversionWithJSONOptions creates, initializes and returns a new instance of Conversion whose TransformToJSON
+// names the fields, omits the empty ones and flattens the tags as specified by options
+func NewConversionWithJSONOptions(options JSONOptions) (Conversion, error) {
+	if err := options.validate(); err != nil {
+		return Conversion{}, err
+	}
+	return Conversion{jsonOptions: &options}, nil
+}
+
+// TransformToXML transforms an EdgeX event to XML, using the XMLOptions when the Conversion was created with them.
 // It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
 func (f Conversion) TransformToXML(ctx interfaces.AppFunctionContext, data interface{}) (continuePipeline bool, stringType interface{}) {
 	if data == nil {
@@ -45,7 +62,13 @@ func (f Conversion) TransformToXML(ctx interfaces.AppFunctionContext, data inter
 	ctx.LoggingClient().Debugf("Transforming to XML in pipeline '%s'", ctx.PipelineId())
 
 	if event, ok := data.(dtos.Event); ok {
-		xml, err := event.ToXML()
+		var xml string
+		var err error
+		if f.xmlOptions != nil {
+			xml, err = f.xmlOptions.marshalEvent(event)
+		} else {
+			xml, err = event.ToXML()
+		}
 		if err != nil {
 			return false, fmt.Errorf("unable to marshal Event to XML in pipeline '%s': %s", ctx.PipelineId(), err.Error())
 		}
@@ -57,7 +80,7 @@ func (f Conversion) TransformToXML(ctx interfaces.AppFunctionContext, data inter
 	return false, fmt.Errorf("function TransformToXML in pipeline '%s': unexpected type received", ctx.PipelineId())
 }
 
-// TransformToJSON transforms an EdgeX event to JSON.
+// TransformToJSON transforms an EdgeX event to JSON, using the JSONOptions when the Conversion was created with them.
 // It will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
 func (f Conversion) TransformToJSON(ctx interfaces.AppFunctionContext, data interface{}) (continuePipeline bool, stringType interface{}) {
 	if data == nil {
@@ -67,7 +90,13 @@ func (f Conversion) TransformToJSON(ctx interfaces.AppFunctionContext, data inte
 	ctx.LoggingClient().Debugf("Transforming to JSON in pipeline '%s'", ctx.PipelineId())
 
 	if result, ok := data.(dtos.Event); ok {
-		b, err := json.Marshal(result)
+		var b []byte
+		var err error
+		if f.jsonOptions != nil {
+			b, err = f.jsonOptions.marshalEvent(result)
+		} else {
+			b, err = json.Marshal(result)
+		}
 		if err != nil {
 			return false, fmt.Errorf("unable to marshal Event to JSON in pipeline '%s': %s", ctx.PipelineId(), err.Error())
 		}
@@ -88,3 +88,55 @@ func TestTransformToJSONNotAnEvent(t *testing.T) {
 	require.Contains(t, result.(error).Error(), "unexpected type received")
 	assert.False(t, continuePipeline)
 }
+
+func TestTransformToJSONWithOptions(t *testing.T) {
+	eventIn := dtos.Event{
+		DeviceName: deviceName1,
+		Origin:     1620000000000000001,
+		Tags:       map[string]interface{}{"site": "houston", "deviceName": "ignored"},
+		Readings: []dtos.BaseReading{{
+			ResourceName:  "temperature",
+			ValueType:     common.ValueTypeFloat32,
+			SimpleReading: dtos.SimpleReading{Value: "21.5"},
+		}},
+	}
+
+	conv, err := NewConversionWithJSONOptions(JSONOptions{FieldNaming: JSONFieldNamingSnakeCase, OmitEmpty: true, FlattenTags: true})
+	require.NoError(t, err)
+
+	continuePipeline, result := conv.TransformToJSON(ctx, eventIn)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, common.ContentTypeJSON, ctx.ResponseContentType())
+	assert.JSONEq(t, `{
+		"device_name":"device1",
+		"origin":1620000000000000001,
+		"site":"houston",
+		"readings":[{"resource_name":"temperature","value_type":"Float32","value":"21.5","origin":0}]
+	}`, result.(string))
+
+	conv, err = NewConversionWithJSONOptions(JSONOptions{FieldNaming: JSONFieldNamingCamelCase})
+	require.NoError(t, err)
+
+	_, result = conv.TransformToJSON(ctx, eventIn)
+	assert.Contains(t, result.(string), `"tags":{"deviceName":"ignored","site":"houston"}`, "tags should be kept without FlattenTags")
+	assert.Contains(t, result.(string), `"profileName":""`, "empty fields should be kept without OmitEmpty")
+
+	_, err = NewConversionWithJSONOptions(JSONOptions{FieldNaming: "kebab-case"})
+	require.Error(t, err)
+}
+
+func TestJSONOptions_FieldName(t *testing.T) {
+	options := JSONOptions{FieldNaming: JSONFieldNamingSnakeCase}
+
+	tests := map[string]string{
+		"apiVersion":    "api_version",
+		"id":            "id",
+		"correlationID": "correlation_id",
+		"HTTPStatus":    "http_status",
+		"value2Unit":    "value2_unit",
+	}
+
+	for name, expected := range tests {
+		assert.Equal(t, expected, options.fieldName(name))
+	}
+}
@@ -18,16 +18,26 @@ package transforms
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+
+	"github.com/google/uuid"
 )
 
+const defaultBackfillMaxConcurrent = 10
+
 type CoreData struct {
 	profileName  string
 	deviceName   string
@@ -115,6 +125,12 @@ func (cdc *CoreData) PushToCoreData(ctx interfaces.AppFunctionContext, data inte
 
 	}
 
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not push Event with %d reading(s) to Core Data",
+			ctx.PipelineId(), len(event.Readings))
+		return true, commonDtos.NewBaseWithIdResponse("", "", http.StatusCreated, event.Id)
+	}
+
 	request := requests.NewAddEventRequest(event)
 	result, err := client.Add(context.Background(), request)
 	if err != nil {
@@ -123,3 +139,223 @@ func (cdc *CoreData) PushToCoreData(ctx interfaces.AppFunctionContext, data inte
 
 	return true, result
 }
+
+// CoreDataBackfill pushes Events built by the pipeline, such as historical aggregates, to Core Data with the Origin
+// timestamps they were given, rather than the time they are pushed
+type CoreDataBackfill struct {
+	maxConcurrent  int
+	persistOnError bool
+}
+
+// NewCoreDataBackfill creates, initializes and returns a new instance of CoreDataBackfill pushing up to maxConcurrent
+// Events concurrently, 10 when not greater than 0. persistOnError enables use of store & forward for the Events that
+// failed to be pushed.
+func NewCoreDataBackfill(maxConcurrent int, persistOnError bool) *CoreDataBackfill {
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultBackfillMaxConcurrent
+	}
+
+	return &CoreDataBackfill{
+		maxConcurrent:  maxConcurrent,
+		persistOnError: persistOnError,
+	}
+}
+
+// PushEventsToCoreData pushes the Events received, as a dtos.Event, AddEventRequest, a slice of either or their JSON,
+// to Core Data and continues the pipeline with the responses, in the same order. The Origin of each Event must be set,
+// the readings without an Origin are given the Event's. The Ids and the names of the readings not set are filled in.
+// This function will return an error and stop the pipeline if no data is received, the data isn't Events, an Event has
+// no Origin, Core Data is not configured or any Event fails to be pushed. Only the Events that failed are stored for
+// retry.
+func (backfill *CoreDataBackfill) PushEventsToCoreData(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		return false, fmt.Errorf("function PushEventsToCoreData in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	events, err := backfillEvents(data)
+	if err != nil {
+		return false, fmt.Errorf("function PushEventsToCoreData in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	for index := range events {
+		if err := prepareBackfillEvent(&events[index]); err != nil {
+			return false, fmt.Errorf("function PushEventsToCoreData in pipeline '%s': Event %d: %s", ctx.PipelineId(), index, err.Error())
+		}
+	}
+
+	responses := make([]commonDtos.BaseWithIdResponse, len(events))
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not push %d Event(s) to Core Data", ctx.PipelineId(), len(events))
+		for index, event := range events {
+			responses[index] = commonDtos.NewBaseWithIdResponse("", "", http.StatusCreated, event.Id)
+		}
+		return true, responses
+	}
+
+	client := ctx.EventClient()
+	if client == nil {
+		return false, fmt.Errorf("function PushEventsToCoreData in pipeline '%s': EventClient not initialized. Core Data is missing from clients configuration", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Pushing %d Event(s) to Core Data in pipeline '%s'", len(events), ctx.PipelineId())
+
+	failures := make([]error, len(events))
+	limiter := make(chan struct{}, backfill.maxConcurrent)
+	wg := sync.WaitGroup{}
+	for index := range events {
+		wg.Add(1)
+		limiter <- struct{}{}
+		go func(index int) {
+			defer func() {
+				<-limiter
+				wg.Done()
+			}()
+
+			response, err := client.Add(context.Background(), requests.NewAddEventRequest(events[index]))
+			if err != nil {
+				failures[index] = err
+				return
+			}
+			responses[index] = response
+		}(index)
+	}
+	wg.Wait()
+
+	var failed []dtos.Event
+	var messages []string
+	for index, failure := range failures {
+		if failure != nil {
+			failed = append(failed, events[index])
+			messages = append(messages, fmt.Sprintf("Event %s: %s", events[index].Id, failure.Error()))
+		}
+	}
+
+	if len(failed) == 0 {
+		return true, responses
+	}
+
+	if backfill.persistOnError {
+		retryData, err := json.Marshal(failed)
+		if err != nil {
+			ctx.LoggingClient().Errorf("Unable to marshal the Events that failed to be pushed for retry in pipeline '%s': %s",
+				ctx.PipelineId(), err.Error())
+		} else {
+			ctx.SetRetryData(retryData)
+		}
+	}
+
+	return false, fmt.Errorf("function PushEventsToCoreData in pipeline '%s': %d of %d Event(s) failed to be pushed: %s",
+		ctx.PipelineId(), len(failed), len(events), strings.Join(messages, "; "))
+}
+
+// backfillEvents returns the Events received as any of the types supported by PushEventsToCoreData
+func backfillEvents(data interface{}) ([]dtos.Event, error) {
+	switch typed := data.(type) {
+	case dtos.Event:
+		return []dtos.Event{typed}, nil
+	case *dtos.Event:
+		return []dtos.Event{*typed}, nil
+	case []dtos.Event:
+		return append([]dtos.Event{}, typed...), nil
+	case requests.AddEventRequest:
+		return []dtos.Event{typed.Event}, nil
+	case []requests.AddEventRequest:
+		events := make([]dtos.Event, len(typed))
+		for index, request := range typed {
+			events[index] = request.Event
+		}
+		return events, nil
+	case []byte:
+		return unmarshalBackfillEvents(typed)
+	case string:
+		return unmarshalBackfillEvents([]byte(typed))
+	default:
+		return nil, fmt.Errorf("type received is not an Event or slice of Events: %T", data)
+	}
+}
+
+// exportEvents returns the Events received as a batch of Events, i.e. from Batch, or any of the types supported by
+// PushEventsToCoreData
+func exportEvents(data interface{}) ([]dtos.Event, error) {
+	batch, ok := data.([][]byte)
+	if !ok {
+		return backfillEvents(data)
+	}
+
+	var events []dtos.Event
+	for _, item := range batch {
+		itemEvents, err := unmarshalBackfillEvents(item)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, itemEvents...)
+	}
+	return events, nil
+}
+
+// unmarshalBackfillEvents returns the Events in the JSON of an Event, an AddEventRequest or an array of either
+func unmarshalBackfillEvents(data []byte) ([]dtos.Event, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		items = []json.RawMessage{data}
+	}
+
+	events := make([]dtos.Event, 0, len(items))
+	for _, item := range items {
+		// An AddEventRequest wraps the Event, otherwise the JSON is the Event itself. The AddEventRequest isn't
+		// unmarshalled as such since that validates the Event before its Ids are filled in.
+		var request struct {
+			Event *dtos.Event `json:"event"`
+		}
+		if err := json.Unmarshal(item, &request); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal Event: %s", err.Error())
+		}
+
+		if request.Event != nil {
+			events = append(events, *request.Event)
+			continue
+		}
+
+		var event dtos.Event
+		if err := json.Unmarshal(item, &event); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal Event: %s", err.Error())
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// prepareBackfillEvent checks the Event has an Origin and fills in the Ids, names and Origins not set
+func prepareBackfillEvent(event *dtos.Event) error {
+	if event.Origin <= 0 {
+		return errors.New("Origin must be set")
+	}
+
+	if event.Id == "" {
+		event.Id = uuid.NewString()
+	}
+
+	if event.Versionable.ApiVersion == "" {
+		event.Versionable = commonDtos.NewVersionable()
+	}
+
+	for index := range event.Readings {
+		reading := &event.Readings[index]
+		if reading.Id == "" {
+			reading.Id = uuid.NewString()
+		}
+		if reading.Origin <= 0 {
+			reading.Origin = event.Origin
+		}
+		if reading.DeviceName == "" {
+			reading.DeviceName = event.DeviceName
+		}
+		if reading.ProfileName == "" {
+			reading.ProfileName = event.ProfileName
+		}
+	}
+
+	return nil
+}
@@ -16,10 +16,28 @@
 package transforms
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	sdkCommon "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces/mocks"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPushToCore_ShouldFailPipelineOnError(t *testing.T) {
@@ -56,3 +74,150 @@ func TestPushToCore_Object(t *testing.T) {
 	_, isError := result.(error)
 	assert.False(t, isError)
 }
+
+func TestPushToCore_ShadowPipeline(t *testing.T) {
+	shadowCtx := ctx.Clone().(*appfunction.Context)
+	shadowCtx.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+
+	calls := len(mockEventClient.Calls)
+	coreData := NewCoreDataSimpleReading("MyProfile", "MyDevice", "MyResource", common.ValueTypeInt32)
+	continuePipeline, result := coreData.PushToCoreData(shadowCtx, int32(10))
+
+	assert.True(t, continuePipeline)
+	response, ok := result.(commonDtos.BaseWithIdResponse)
+	assert.True(t, ok, "result should be the same type as when the Event is pushed")
+	assert.NotEmpty(t, response.Id)
+	assert.Len(t, mockEventClient.Calls, calls, "Event should not be pushed to Core Data")
+}
+
+func newBackfillContext(client *mocks.EventClient) *appfunction.Context {
+	backfillDic := di.NewContainer(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &sdkCommon.ConfigurationStruct{}
+		},
+		container.EventClientName: func(get di.Get) interface{} {
+			return client
+		},
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return lc
+		},
+	})
+
+	return appfunction.NewContext("123", backfillDic, "")
+}
+
+func newBackfillEvent(deviceName string, origin int64) dtos.Event {
+	event := dtos.Event{
+		Versionable: commonDtos.NewVersionable(),
+		DeviceName:  deviceName,
+		ProfileName: "Aggregates",
+		SourceName:  "HourlyAverage",
+		Origin:      origin,
+	}
+	_ = event.AddSimpleReading("temperature", common.ValueTypeFloat64, 21.5)
+	event.Readings[0].Id = ""
+	event.Readings[0].Origin = 0
+	return event
+}
+
+func TestCoreDataBackfill_PushEventsToCoreData(t *testing.T) {
+	pushed := make(chan dtos.Event, 3)
+	client := &mocks.EventClient{}
+	client.On("Add", mock.Anything, mock.MatchedBy(func(request requests.AddEventRequest) bool {
+		return request.Event.DeviceName == "offline"
+	})).Return(commonDtos.BaseWithIdResponse{}, errors.NewCommonEdgeX(errors.KindServiceUnavailable, "core data unavailable", nil))
+	client.On("Add", mock.Anything, mock.Anything).Return(func(_ context.Context, request requests.AddEventRequest) commonDtos.BaseWithIdResponse {
+		pushed <- request.Event
+		return commonDtos.NewBaseWithIdResponse("", "", http.StatusCreated, request.Event.Id)
+	}, nil)
+
+	yesterday := time.Now().Add(-24 * time.Hour).UnixNano()
+	backfill := NewCoreDataBackfill(2, true)
+
+	backfillCtx := newBackfillContext(client)
+	events := []dtos.Event{newBackfillEvent("thermostat-1", yesterday), newBackfillEvent("thermostat-2", yesterday+1)}
+	continuePipeline, result := backfill.PushEventsToCoreData(backfillCtx, events)
+	require.True(t, continuePipeline, result)
+
+	responses, ok := result.([]commonDtos.BaseWithIdResponse)
+	require.True(t, ok)
+	require.Len(t, responses, 2)
+	assert.Equal(t, http.StatusCreated, responses[0].StatusCode)
+	assert.NotEmpty(t, responses[0].Id)
+	assert.Empty(t, events[0].Id, "the Events received should not be modified")
+
+	event := <-pushed
+	assert.Contains(t, []int64{yesterday, yesterday + 1}, event.Origin, "Origin should be kept")
+	assert.Equal(t, event.Origin, event.Readings[0].Origin)
+	assert.NotEmpty(t, event.Readings[0].Id)
+	assert.Equal(t, event.DeviceName, event.Readings[0].DeviceName)
+
+	failedCtx := newBackfillContext(client)
+	continuePipeline, result = backfill.PushEventsToCoreData(failedCtx,
+		[]dtos.Event{newBackfillEvent("thermostat-1", yesterday), newBackfillEvent("offline", yesterday)})
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "1 of 2 Event(s) failed to be pushed")
+
+	var retried []dtos.Event
+	require.NoError(t, json.Unmarshal(failedCtx.RetryData(), &retried))
+	require.Len(t, retried, 1, "only the Events that failed should be retried")
+	assert.Equal(t, "offline", retried[0].DeviceName)
+	assert.Equal(t, yesterday, retried[0].Origin)
+}
+
+func TestCoreDataBackfill_PushEventsToCoreDataInput(t *testing.T) {
+	client := &mocks.EventClient{}
+	client.On("Add", mock.Anything, mock.Anything).Return(commonDtos.NewBaseWithIdResponse("", "", http.StatusCreated, ""), nil)
+	backfillCtx := newBackfillContext(client)
+
+	origin := time.Now().Add(-time.Hour).UnixNano()
+	event := newBackfillEvent("thermostat-1", origin)
+	request := requests.NewAddEventRequest(newBackfillEvent("thermostat-2", origin))
+	requestJSON, err := json.Marshal([]interface{}{event, request})
+	require.NoError(t, err)
+	eventJSON, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedCount int
+		ExpectedError string
+	}{
+		{"Event", event, 1, ""},
+		{"Event pointer", &event, 1, ""},
+		{"AddEventRequests", []requests.AddEventRequest{request, request}, 2, ""},
+		{"JSON array", requestJSON, 2, ""},
+		{"JSON Event", string(eventJSON), 1, ""},
+		{"No Origin", newBackfillEvent("thermostat-1", 0), 0, "Origin must be set"},
+		{"Not an Event", 42, 0, "type received is not an Event"},
+		{"Bad JSON", "not json", 0, "unable to unmarshal Event"},
+		{"No data", nil, 0, "No Data Received"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewCoreDataBackfill(0, false).PushEventsToCoreData(backfillCtx, test.Data)
+			if test.ExpectedError != "" {
+				require.False(t, continuePipeline)
+				assert.Contains(t, result.(error).Error(), test.ExpectedError)
+				return
+			}
+
+			require.True(t, continuePipeline, result)
+			assert.Len(t, result, test.ExpectedCount)
+		})
+	}
+}
+
+func TestCoreDataBackfill_ShadowPipeline(t *testing.T) {
+	client := &mocks.EventClient{}
+	shadowCtx := newBackfillContext(client)
+	shadowCtx.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+
+	continuePipeline, result := NewCoreDataBackfill(0, false).PushEventsToCoreData(shadowCtx,
+		newBackfillEvent("thermostat-1", time.Now().UnixNano()))
+	require.True(t, continuePipeline, result)
+	assert.Len(t, result, 1)
+	client.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
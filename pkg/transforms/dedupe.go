@@ -0,0 +1,298 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	// DefaultDedupeMaxEntries is the number of devices, or device resources, remembered when no maximum is given
+	DefaultDedupeMaxEntries = 10000
+
+	dedupePersistInterval = 10 * time.Second
+)
+
+// Deduplicator suppresses consecutive duplicate Events or readings from a device, cutting the bandwidth used by chatty
+// sensors whose values rarely change. The last value forwarded is remembered for each device, or device resource, in
+// a least recently used cache. A duplicate is forwarded again once the window has passed since the value was last
+// forwarded, so destinations still see that the device is alive. The cache is optionally persisted to a file so
+// duplicates are still suppressed after a restart of the service.
+type Deduplicator struct {
+	window      time.Duration
+	maxEntries  int
+	persistFile string
+	lock        sync.Mutex
+	entries     map[string]*list.Element
+	recent      *list.List
+	loaded      bool
+	dirty       bool
+	lastSaved   time.Time
+}
+
+// dedupeEntry is the checksum of the value last forwarded for a device or device resource
+type dedupeEntry struct {
+	Key      string
+	Checksum string
+	Sent     time.Time
+}
+
+// NewDeduplicator creates, initializes and returns a new instance of Deduplicator. window is the time after which a
+// duplicate is forwarded again, duplicates are suppressed until the value changes when 0. maxEntries is the number of
+// devices, or device resources, remembered, DefaultDedupeMaxEntries when 0. persistFile is the file the cache is saved
+// to and loaded from, the cache isn't persisted when empty.
+func NewDeduplicator(window time.Duration, maxEntries int, persistFile string) *Deduplicator {
+	if maxEntries <= 0 {
+		maxEntries = DefaultDedupeMaxEntries
+	}
+
+	return &Deduplicator{
+		window:      window,
+		maxEntries:  maxEntries,
+		persistFile: persistFile,
+		entries:     make(map[string]*list.Element),
+		recent:      list.New(),
+	}
+}
+
+// DedupeByChecksum stops the pipeline if the Event has the same readings as the last Event forwarded for the device
+// and source. Reading ids and origins are not compared.
+// This function will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+func (dedupe *Deduplicator) DedupeByChecksum(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function DedupeByChecksum in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function DedupeByChecksum in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	checksum := sha256.New()
+	for _, reading := range event.Readings {
+		writeReadingValue(checksum, reading)
+	}
+
+	dedupe.lock.Lock()
+	defer dedupe.lock.Unlock()
+
+	dedupe.load(ctx)
+	defer dedupe.persist(ctx)
+
+	key := event.DeviceName + "/" + event.SourceName
+	if dedupe.isDuplicate(key, hex.EncodeToString(checksum.Sum(nil)), time.Now()) {
+		ctx.LoggingClient().Debugf("Duplicate Event from device '%s' suppressed in pipeline '%s'", event.DeviceName, ctx.PipelineId())
+		return false, nil
+	}
+
+	return true, event
+}
+
+// DedupeByReadingValue removes the readings that have the same value as the last reading forwarded for the device
+// resource and stops the pipeline if all the readings are removed.
+// This function will return an error and stop the pipeline if a non-edgex event is received or if no data is received.
+func (dedupe *Deduplicator) DedupeByReadingValue(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function DedupeByReadingValue in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function DedupeByReadingValue in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	dedupe.lock.Lock()
+	defer dedupe.lock.Unlock()
+
+	dedupe.load(ctx)
+	defer dedupe.persist(ctx)
+
+	now := time.Now()
+	readings := make([]dtos.BaseReading, 0, len(event.Readings))
+	for _, reading := range event.Readings {
+		deviceName := reading.DeviceName
+		if deviceName == "" {
+			deviceName = event.DeviceName
+		}
+
+		checksum := sha256.New()
+		writeReadingValue(checksum, reading)
+
+		if dedupe.isDuplicate(deviceName+"/"+reading.ResourceName, hex.EncodeToString(checksum.Sum(nil)), now) {
+			ctx.LoggingClient().Debugf("Duplicate reading for resource %s suppressed in pipeline '%s'", reading.ResourceName, ctx.PipelineId())
+			continue
+		}
+
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		return false, nil
+	}
+
+	event.Readings = readings
+	return true, event
+}
+
+// Save writes the cache to the persist file if it changed since last saved. The file is replaced atomically so a
+// failure while saving doesn't lose the previously saved cache.
+func (dedupe *Deduplicator) Save() error {
+	dedupe.lock.Lock()
+	defer dedupe.lock.Unlock()
+
+	return dedupe.save()
+}
+
+// isDuplicate returns whether the checksum is the same as the one last forwarded for the key within the window,
+// otherwise remembers the checksum as forwarded. Must be called with the lock held.
+func (dedupe *Deduplicator) isDuplicate(key string, checksum string, now time.Time) bool {
+	if element, found := dedupe.entries[key]; found {
+		entry := element.Value.(*dedupeEntry)
+		dedupe.recent.MoveToFront(element)
+
+		if entry.Checksum == checksum && (dedupe.window == 0 || now.Sub(entry.Sent) < dedupe.window) {
+			return true
+		}
+
+		entry.Checksum = checksum
+		entry.Sent = now
+		dedupe.dirty = true
+		return false
+	}
+
+	dedupe.entries[key] = dedupe.recent.PushFront(&dedupeEntry{Key: key, Checksum: checksum, Sent: now})
+	for dedupe.recent.Len() > dedupe.maxEntries {
+		oldest := dedupe.recent.Back()
+		dedupe.recent.Remove(oldest)
+		delete(dedupe.entries, oldest.Value.(*dedupeEntry).Key)
+	}
+
+	dedupe.dirty = true
+	return false
+}
+
+// load restores the cache saved to the persist file the first time the Deduplicator is used.
+// Must be called with the lock held.
+func (dedupe *Deduplicator) load(ctx interfaces.AppFunctionContext) {
+	if dedupe.loaded || dedupe.persistFile == "" {
+		return
+	}
+	dedupe.loaded = true
+	dedupe.lastSaved = time.Now()
+
+	data, err := ioutil.ReadFile(dedupe.persistFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.LoggingClient().Errorf("Unable to load deduplication cache from '%s': %s", dedupe.persistFile, err.Error())
+		}
+		return
+	}
+
+	// Entries are saved most recently used first
+	var entries []*dedupeEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		ctx.LoggingClient().Errorf("Unable to load deduplication cache from '%s': %s", dedupe.persistFile, err.Error())
+		return
+	}
+
+	for _, entry := range entries {
+		if _, found := dedupe.entries[entry.Key]; found || dedupe.recent.Len() >= dedupe.maxEntries {
+			continue
+		}
+		dedupe.entries[entry.Key] = dedupe.recent.PushBack(entry)
+	}
+}
+
+// persist saves the cache if it wasn't saved within the persist interval. Must be called with the lock held.
+func (dedupe *Deduplicator) persist(ctx interfaces.AppFunctionContext) {
+	if dedupe.persistFile == "" || time.Since(dedupe.lastSaved) < dedupePersistInterval {
+		return
+	}
+
+	if err := dedupe.save(); err != nil {
+		ctx.LoggingClient().Errorf("Unable to save deduplication cache to '%s': %s", dedupe.persistFile, err.Error())
+	}
+}
+
+func (dedupe *Deduplicator) save() error {
+	if dedupe.persistFile == "" || !dedupe.dirty {
+		return nil
+	}
+
+	entries := make([]*dedupeEntry, 0, dedupe.recent.Len())
+	for element := dedupe.recent.Front(); element != nil; element = element.Next() {
+		entries = append(entries, element.Value.(*dedupeEntry))
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(dedupe.persistFile), filepath.Base(dedupe.persistFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	if err := os.Rename(temp.Name(), dedupe.persistFile); err != nil {
+		return err
+	}
+
+	dedupe.dirty = false
+	dedupe.lastSaved = time.Now()
+	return nil
+}
+
+// writeReadingValue writes the reading's resource name, value type and value to the hash
+func writeReadingValue(checksum hash.Hash, reading dtos.BaseReading) {
+	_, _ = fmt.Fprintf(checksum, "%s\x00%s\x00%s\x00%s\x00", reading.ResourceName, reading.ValueType, reading.Value, reading.MediaType)
+	_, _ = checksum.Write(reading.BinaryValue)
+
+	if reading.ObjectValue != nil {
+		// Map keys are marshaled in sorted order so equal objects have the same checksum
+		object, _ := json.Marshal(reading.ObjectValue)
+		_, _ = checksum.Write(object)
+	}
+	_, _ = checksum.Write([]byte{0})
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dedupeEvent(t *testing.T, deviceName string, temperature int32, humidity int32) dtos.Event {
+	event := dtos.NewEvent("thermostat", deviceName, "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeInt32, temperature))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeInt32, humidity))
+	return event
+}
+
+func TestDeduplicator_DedupeByChecksum(t *testing.T) {
+	dedupe := NewDeduplicator(0, 0, "")
+
+	continuePipeline, result := dedupe.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	require.True(t, continuePipeline)
+	assert.Len(t, result.(dtos.Event).Readings, 2)
+
+	continuePipeline, result = dedupe.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	assert.False(t, continuePipeline, "duplicate Event should be suppressed")
+	assert.Nil(t, result)
+
+	continuePipeline, _ = dedupe.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-2", 20, 50))
+	assert.True(t, continuePipeline, "each device should be deduplicated separately")
+
+	continuePipeline, _ = dedupe.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 51))
+	assert.True(t, continuePipeline, "changed Event should be forwarded")
+
+	continuePipeline, _ = dedupe.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	assert.True(t, continuePipeline, "only consecutive duplicates should be suppressed")
+}
+
+func TestDeduplicator_DedupeByReadingValue(t *testing.T) {
+	dedupe := NewDeduplicator(0, 0, "")
+
+	continuePipeline, result := dedupe.DedupeByReadingValue(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	require.True(t, continuePipeline)
+	assert.Len(t, result.(dtos.Event).Readings, 2)
+
+	continuePipeline, result = dedupe.DedupeByReadingValue(ctx, dedupeEvent(t, "thermostat-1", 21, 50))
+	require.True(t, continuePipeline)
+	readings := result.(dtos.Event).Readings
+	require.Len(t, readings, 1, "duplicate humidity should be removed")
+	assert.Equal(t, "temperature", readings[0].ResourceName)
+	assert.Equal(t, "21", readings[0].Value)
+
+	continuePipeline, result = dedupe.DedupeByReadingValue(ctx, dedupeEvent(t, "thermostat-1", 21, 50))
+	assert.False(t, continuePipeline, "Event with only duplicate readings should be suppressed")
+	assert.Nil(t, result)
+}
+
+func TestDeduplicator_Window(t *testing.T) {
+	dedupe := NewDeduplicator(time.Minute, 0, "")
+	now := time.Now()
+
+	assert.False(t, dedupe.isDuplicate("thermostat-1/temperature", "20", now))
+	assert.True(t, dedupe.isDuplicate("thermostat-1/temperature", "20", now.Add(30*time.Second)))
+	assert.False(t, dedupe.isDuplicate("thermostat-1/temperature", "20", now.Add(time.Minute)),
+		"duplicate should be forwarded once the window has passed")
+	assert.True(t, dedupe.isDuplicate("thermostat-1/temperature", "20", now.Add(90*time.Second)),
+		"window should restart once the duplicate is forwarded")
+}
+
+func TestDeduplicator_MaxEntries(t *testing.T) {
+	dedupe := NewDeduplicator(0, 2, "")
+	now := time.Now()
+
+	assert.False(t, dedupe.isDuplicate("device-1", "1", now))
+	assert.False(t, dedupe.isDuplicate("device-2", "2", now))
+	assert.True(t, dedupe.isDuplicate("device-1", "1", now))
+	assert.False(t, dedupe.isDuplicate("device-3", "3", now))
+
+	assert.True(t, dedupe.isDuplicate("device-1", "1", now), "recently used device should be remembered")
+	assert.False(t, dedupe.isDuplicate("device-2", "2", now), "least recently used device should be forgotten")
+}
+
+func TestDeduplicator_Persistence(t *testing.T) {
+	persistFile := filepath.Join(t.TempDir(), "dedupe.json")
+
+	dedupe := NewDeduplicator(0, 0, persistFile)
+	continuePipeline, _ := dedupe.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	require.True(t, continuePipeline)
+	require.NoError(t, dedupe.Save())
+
+	restarted := NewDeduplicator(0, 0, persistFile)
+	continuePipeline, _ = restarted.DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	assert.False(t, continuePipeline, "duplicate should be suppressed after a restart")
+
+	continuePipeline, _ = NewDeduplicator(0, 0, filepath.Join(t.TempDir(), "missing.json")).DedupeByChecksum(ctx, dedupeEvent(t, "thermostat-1", 20, 50))
+	assert.True(t, continuePipeline, "missing persist file should not be an error")
+}
+
+func TestDeduplicator_Errors(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "20", "type received is not an Event"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			dedupe := NewDeduplicator(0, 0, "")
+			for _, function := range []func(interface{}) (bool, interface{}){
+				func(data interface{}) (bool, interface{}) { return dedupe.DedupeByChecksum(ctx, data) },
+				func(data interface{}) (bool, interface{}) { return dedupe.DedupeByReadingValue(ctx, data) },
+			} {
+				continuePipeline, result := function(test.Data)
+				require.False(t, continuePipeline)
+				require.Error(t, result.(error))
+				assert.Contains(t, result.(error).Error(), test.ExpectedError)
+			}
+		})
+	}
+}
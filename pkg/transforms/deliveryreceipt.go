@@ -0,0 +1,49 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// exportDelivery is an export recorded with the context's DeliveryReceiptTracker, or nothing when delivery receipts aren't
+// tracked
+type exportDelivery struct {
+	tracker interfaces.DeliveryReceiptTracker
+	id      string
+}
+
+func recordDelivery(ctx interfaces.AppFunctionContext, destination string) exportDelivery {
+	tracker := ctx.DeliveryReceipts()
+	if tracker == nil {
+		return exportDelivery{}
+	}
+
+	return exportDelivery{tracker: tracker, id: tracker.Record(destination, ctx.CorrelationID())}
+}
+
+func (d exportDelivery) acknowledge(receipt string) {
+	if d.tracker != nil {
+		d.tracker.Acknowledge(d.id, receipt)
+	}
+}
+
+func (d exportDelivery) fail(err error) {
+	if d.tracker != nil {
+		d.tracker.Fail(d.id, err)
+	}
+}
@@ -0,0 +1,354 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	ElasticsearchAuthModeNone   = "none"
+	ElasticsearchAuthModeBasic  = "basic"
+	ElasticsearchAuthModeAPIKey = "apikey"
+
+	// ElasticsearchUsernameKey and ElasticsearchPasswordKey are the keys of the credentials in the secret for basic auth
+	ElasticsearchUsernameKey = "username"
+	ElasticsearchPasswordKey = "password"
+	// ElasticsearchAPIKeyKey is the key of the encoded API key in the secret for API key auth
+	ElasticsearchAPIKeyKey = "apikey"
+
+	// DefaultElasticsearchIndex is the index template used when none is given
+	DefaultElasticsearchIndex = "edgex-{date}"
+)
+
+// elasticsearchInvalidIndex matches the characters Elasticsearch and OpenSearch don't allow in index names
+var elasticsearchInvalidIndex = regexp.MustCompile(`[\\/*?"<>| ,#:]`)
+
+// ElasticsearchConfig contains the configuration of an ElasticsearchSender
+type ElasticsearchConfig struct {
+	// URL of the Elasticsearch or OpenSearch cluster, i.e. "https://elasticsearch:9200"
+	URL string
+	// Index is the template of the index each Event is indexed into, DefaultElasticsearchIndex when empty. The
+	// {device}, {profile} and {source} placeholders are replaced with the Event's names, and the {date}, {year},
+	// {month} and {day} placeholders with the Event's origin in UTC. The name is lower cased.
+	Index string
+	// AuthMode is ElasticsearchAuthModeBasic or ElasticsearchAuthModeAPIKey to authenticate with the secrets at
+	// SecretPath, otherwise ElasticsearchAuthModeNone or empty
+	AuthMode string
+	// SecretPath is the path of the "username" and "password" secrets for basic auth, or the "apikey" secret for API
+	// key auth
+	SecretPath string
+	// Pipeline is the optional ingest pipeline the Events are processed by
+	Pipeline string
+	// DataStream creates the documents, as required by data streams, rather than indexing them. Documents that
+	// already exist are then skipped.
+	DataStream bool
+	// PersistOnError enables use of store & forward for the Events that failed to be indexed
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// ElasticsearchSender bulk indexes Events into Elasticsearch or OpenSearch, as searchable telemetry
+type ElasticsearchSender struct {
+	config ElasticsearchConfig
+	client *http.Client
+}
+
+// elasticsearchDocument is the indexed document of an Event, with the Event's origin as the @timestamp field used by
+// the time based views of Kibana and OpenSearch Dashboards
+type elasticsearchDocument struct {
+	Timestamp string `json:"@timestamp"`
+	dtos.Event
+}
+
+// elasticsearchBulkResponse is the body of the response to a bulk request
+type elasticsearchBulkResponse struct {
+	Errors bool                                     `json:"errors"`
+	Items  []map[string]elasticsearchBulkItemResult `json:"items"`
+}
+
+type elasticsearchBulkItemResult struct {
+	Index  string `json:"_index"`
+	Id     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// NewElasticsearchSender creates, initializes and returns a new instance of ElasticsearchSender
+func NewElasticsearchSender(config ElasticsearchConfig) (*ElasticsearchSender, error) {
+	config.URL = strings.TrimSuffix(strings.TrimSpace(config.URL), "/")
+	if !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return nil, fmt.Errorf("invalid URL '%s', must start with http:// or https://", config.URL)
+	}
+
+	config.Index = strings.TrimSpace(config.Index)
+	if len(config.Index) == 0 {
+		config.Index = DefaultElasticsearchIndex
+	}
+	if strings.HasPrefix(config.Index, "_") || strings.HasPrefix(config.Index, "-") || strings.HasPrefix(config.Index, "+") {
+		return nil, fmt.Errorf("invalid index '%s', can not start with '_', '-' or '+'", config.Index)
+	}
+
+	config.AuthMode = strings.ToLower(strings.TrimSpace(config.AuthMode))
+	switch config.AuthMode {
+	case "", ElasticsearchAuthModeNone:
+		config.AuthMode = ElasticsearchAuthModeNone
+	case ElasticsearchAuthModeBasic, ElasticsearchAuthModeAPIKey:
+		if len(strings.TrimSpace(config.SecretPath)) == 0 {
+			return nil, fmt.Errorf("secret path must be set for '%s' auth mode", config.AuthMode)
+		}
+	default:
+		return nil, fmt.Errorf("invalid auth mode '%s', must be '%s', '%s' or '%s'", config.AuthMode,
+			ElasticsearchAuthModeNone, ElasticsearchAuthModeBasic, ElasticsearchAuthModeAPIKey)
+	}
+
+	return &ElasticsearchSender{
+		config: config,
+		client: &http.Client{},
+	}, nil
+}
+
+// BulkIndex indexes the Event, or Events, from the previous function in a single bulk request, the Event's id being
+// the document's id so retries don't duplicate it. Only the Events that failed to be indexed are stored for retry,
+// as a JSON array.
+// This function will return an error and stop the pipeline if no data is received, the data isn't Events or any of
+// the Events fails to be indexed.
+func (sender *ElasticsearchSender) BulkIndex(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function BulkIndex in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	events, err := exportEvents(data)
+	if err != nil {
+		return false, fmt.Errorf("function BulkIndex in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if len(events) == 0 {
+		ctx.LoggingClient().Debugf("No Events to index in pipeline '%s'", ctx.PipelineId())
+		return true, nil
+	}
+
+	body, err := sender.bulkBody(events)
+	if err != nil {
+		return false, fmt.Errorf("function BulkIndex in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not index %d Events to %s", ctx.PipelineId(), len(events), sender.config.URL)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, sender.config.CircuitBreaker, sender.config.URL, sender.config.PersistOnError,
+		func() { sender.storeEvents(ctx, events, true) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, sender.config.URL+"/_bulk")
+
+	failed, err := sender.bulk(ctx, events, body)
+	if err != nil {
+		err = fmt.Errorf("function BulkIndex in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.config.CircuitBreaker.Failed()
+		sender.storeEvents(ctx, failed, false)
+		return false, err
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(strconv.Itoa(len(events)))
+
+	ctx.LoggingClient().Debugf("Indexed %d Events to %s in pipeline '%s'", len(events), sender.config.URL, ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// bulkBody returns the NDJSON body of the bulk request, an action and document line per Event
+func (sender *ElasticsearchSender) bulkBody(events []dtos.Event) ([]byte, error) {
+	operation := "index"
+	if sender.config.DataStream {
+		operation = "create"
+	}
+
+	var body bytes.Buffer
+	for _, event := range events {
+		metadata := map[string]string{"_index": sender.index(event)}
+		if len(event.Id) > 0 {
+			metadata["_id"] = event.Id
+		}
+		if len(sender.config.Pipeline) > 0 {
+			metadata["pipeline"] = sender.config.Pipeline
+		}
+
+		action, err := json.Marshal(map[string]map[string]string{operation: metadata})
+		if err != nil {
+			return nil, err
+		}
+
+		document, err := json.Marshal(elasticsearchDocument{
+			Timestamp: time.Unix(0, event.Origin).UTC().Format(time.RFC3339Nano),
+			Event:     event,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal Event '%s': %s", event.Id, err.Error())
+		}
+
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(document)
+		body.WriteByte('\n')
+	}
+
+	return body.Bytes(), nil
+}
+
+// index returns the name of the Event's index, the characters not allowed in index names being replaced
+func (sender *ElasticsearchSender) index(event dtos.Event) string {
+	origin := time.Unix(0, event.Origin).UTC()
+	index := strings.NewReplacer(
+		"{device}", event.DeviceName,
+		"{profile}", event.ProfileName,
+		"{source}", event.SourceName,
+		"{date}", origin.Format("2006.01.02"),
+		"{year}", origin.Format("2006"),
+		"{month}", origin.Format("01"),
+		"{day}", origin.Format("02"),
+	).Replace(sender.config.Index)
+
+	return elasticsearchInvalidIndex.ReplaceAllString(strings.ToLower(index), "_")
+}
+
+// bulk sends the bulk request and returns the Events that failed to be indexed, all of them when the request fails
+func (sender *ElasticsearchSender) bulk(ctx interfaces.AppFunctionContext, events []dtos.Event, body []byte) ([]dtos.Event, error) {
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPost, sender.config.URL+"/_bulk", bytes.NewReader(body))
+	if err != nil {
+		return events, err
+	}
+	req.Header.Set("Content-Type", ContentTypeNDJSON)
+
+	if err := sender.setAuthHeader(ctx, req); err != nil {
+		return events, err
+	}
+
+	response, err := sender.client.Do(req)
+	if err != nil {
+		return events, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return events, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return events, fmt.Errorf("bulk request failed with %d HTTP status code: %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+
+	var result elasticsearchBulkResponse
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return events, fmt.Errorf("unable to parse bulk response: %s", err.Error())
+	}
+
+	if !result.Errors {
+		return nil, nil
+	}
+
+	if len(result.Items) != len(events) {
+		return events, fmt.Errorf("bulk response has %d items for %d Events", len(result.Items), len(events))
+	}
+
+	var failed []dtos.Event
+	var reasons []string
+	for index, item := range result.Items {
+		for _, itemResult := range item {
+			// A document that already exists was created by a previous attempt
+			if itemResult.Error == nil || (sender.config.DataStream && itemResult.Status == http.StatusConflict) {
+				continue
+			}
+
+			failed = append(failed, events[index])
+			reasons = append(reasons, fmt.Sprintf("%s: %s (%s)", itemResult.Index, itemResult.Error.Reason, itemResult.Error.Type))
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	return failed, fmt.Errorf("%d of %d Events failed to be indexed: %s", len(failed), len(events), strings.Join(reasons, "; "))
+}
+
+// setAuthHeader sets the Authorization header with the credentials from the secrets per the auth mode
+func (sender *ElasticsearchSender) setAuthHeader(ctx interfaces.AppFunctionContext, req *http.Request) error {
+	if sender.config.AuthMode == ElasticsearchAuthModeNone {
+		return nil
+	}
+
+	secrets, err := ctx.GetSecret(sender.config.SecretPath)
+	if err != nil {
+		return err
+	}
+
+	switch sender.config.AuthMode {
+	case ElasticsearchAuthModeBasic:
+		if len(secrets[ElasticsearchUsernameKey]) == 0 {
+			return fmt.Errorf("secret at '%s' must contain '%s'", sender.config.SecretPath, ElasticsearchUsernameKey)
+		}
+		req.SetBasicAuth(secrets[ElasticsearchUsernameKey], secrets[ElasticsearchPasswordKey])
+	case ElasticsearchAuthModeAPIKey:
+		if len(secrets[ElasticsearchAPIKeyKey]) == 0 {
+			return fmt.Errorf("secret at '%s' must contain '%s'", sender.config.SecretPath, ElasticsearchAPIKeyKey)
+		}
+		req.Header.Set("Authorization", "ApiKey "+secrets[ElasticsearchAPIKeyKey])
+	}
+
+	return nil
+}
+
+// storeEvents stores the Events for retry when persistOnError is enabled or always is set
+func (sender *ElasticsearchSender) storeEvents(ctx interfaces.AppFunctionContext, events []dtos.Event, always bool) {
+	if len(events) == 0 || (!sender.config.PersistOnError && !always) {
+		return
+	}
+
+	retryData, err := json.Marshal(events)
+	if err != nil {
+		ctx.LoggingClient().Errorf("Unable to marshal the Events that failed to be indexed for retry in pipeline '%s': %s",
+			ctx.PipelineId(), err.Error())
+		return
+	}
+	ctx.SetRetryData(retryData)
+}
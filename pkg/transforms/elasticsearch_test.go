@@ -0,0 +1,195 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	mocks2 "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// elasticsearchTestEvent returns an Event of the device originating on 2021-08-24
+func elasticsearchTestEvent(deviceName string) dtos.Event {
+	event := dtos.NewEvent("meter-profile", deviceName, "energy")
+	event.Origin = time.Date(2021, 8, 24, 10, 30, 0, 0, time.UTC).UnixNano()
+	_ = event.AddSimpleReading("kwh", common.ValueTypeFloat64, 12.5)
+	return event
+}
+
+// elasticsearchRequest is a decoded bulk request
+type elasticsearchRequest struct {
+	actions   []map[string]map[string]string
+	documents []map[string]interface{}
+}
+
+func decodeElasticsearchRequest(t *testing.T, request *http.Request) elasticsearchRequest {
+	var decoded elasticsearchRequest
+	scanner := bufio.NewScanner(request.Body)
+	for line := 0; scanner.Scan(); line++ {
+		if line%2 == 0 {
+			var action map[string]map[string]string
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			decoded.actions = append(decoded.actions, action)
+			continue
+		}
+
+		var document map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &document))
+		decoded.documents = append(decoded.documents, document)
+	}
+	return decoded
+}
+
+func TestNewElasticsearchSender(t *testing.T) {
+	valid := ElasticsearchConfig{URL: "https://elasticsearch:9200/"}
+
+	sender, err := NewElasticsearchSender(valid)
+	require.NoError(t, err)
+	assert.Equal(t, "https://elasticsearch:9200", sender.config.URL)
+	assert.Equal(t, DefaultElasticsearchIndex, sender.config.Index)
+	assert.Equal(t, ElasticsearchAuthModeNone, sender.config.AuthMode)
+
+	tests := []struct {
+		Name   string
+		Modify func(config *ElasticsearchConfig)
+	}{
+		{"Bad URL", func(config *ElasticsearchConfig) { config.URL = "elasticsearch:9200" }},
+		{"Bad index", func(config *ElasticsearchConfig) { config.Index = "_edgex" }},
+		{"Bad auth mode", func(config *ElasticsearchConfig) { config.AuthMode = "bearer" }},
+		{"Basic auth without secret path", func(config *ElasticsearchConfig) { config.AuthMode = ElasticsearchAuthModeBasic }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			config := valid
+			test.Modify(&config)
+			_, err := NewElasticsearchSender(config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestElasticsearchSender_Index(t *testing.T) {
+	sender, err := NewElasticsearchSender(ElasticsearchConfig{URL: "http://opensearch:9200", Index: "EdgeX-{profile}-{device}-{year}.{month}"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "edgex-meter-profile-meter_1_-2021.08", sender.index(elasticsearchTestEvent("Meter 1?")),
+		"index should be lower cased and invalid characters replaced")
+
+	sender.config.Index = DefaultElasticsearchIndex
+	assert.Equal(t, "edgex-2021.08.24", sender.index(elasticsearchTestEvent("meter-1")))
+}
+
+func TestElasticsearchSender_BulkIndex(t *testing.T) {
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "elasticsearch").Return(map[string]string{ElasticsearchAPIKeyKey: "a2V5OnNlY3JldA=="}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	var received elasticsearchRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/_bulk", request.URL.Path)
+		assert.Equal(t, ContentTypeNDJSON, request.Header.Get("Content-Type"))
+		assert.Equal(t, "ApiKey a2V5OnNlY3JldA==", request.Header.Get("Authorization"))
+		received = decodeElasticsearchRequest(t, request)
+		_, _ = writer.Write([]byte(`{"took": 3, "errors": false, "items": [{"index": {"status": 201}}, {"index": {"status": 201}}]}`))
+	}))
+	defer ts.Close()
+
+	sender, err := NewElasticsearchSender(ElasticsearchConfig{
+		URL:        ts.URL,
+		Index:      "telemetry-{device}-{date}",
+		AuthMode:   ElasticsearchAuthModeAPIKey,
+		SecretPath: "elasticsearch",
+		Pipeline:   "edgex",
+	})
+	require.NoError(t, err)
+
+	first := elasticsearchTestEvent("meter-1")
+	second := elasticsearchTestEvent("meter-2")
+	continuePipeline, result := sender.BulkIndex(ctx, []dtos.Event{first, second})
+	require.True(t, continuePipeline, result)
+
+	require.Len(t, received.actions, 2)
+	assert.Equal(t, map[string]map[string]string{"index": {"_index": "telemetry-meter-1-2021.08.24", "_id": first.Id, "pipeline": "edgex"}},
+		received.actions[0])
+	assert.Equal(t, "telemetry-meter-2-2021.08.24", received.actions[1]["index"]["_index"])
+	assert.Equal(t, "2021-08-24T10:30:00Z", received.documents[0]["@timestamp"])
+	assert.Equal(t, "meter-1", received.documents[0]["deviceName"])
+	assert.Len(t, received.documents[0]["readings"], 1)
+
+	continuePipeline, result = sender.BulkIndex(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestElasticsearchSender_BulkIndexPartialFailure(t *testing.T) {
+	var requests []elasticsearchRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests = append(requests, decodeElasticsearchRequest(t, request))
+		if len(requests) > 1 {
+			_, _ = writer.Write([]byte(`{"errors": false, "items": [{"create": {"status": 201}}]}`))
+			return
+		}
+		_, _ = writer.Write([]byte(`{"errors": true, "items": [
+			{"create": {"_index": "edgex-2021.08.24", "status": 409, "error": {"type": "version_conflict_engine_exception", "reason": "document already exists"}}},
+			{"create": {"_index": "edgex-2021.08.24", "status": 429, "error": {"type": "es_rejected_execution_exception", "reason": "rejected execution"}}},
+			{"create": {"_index": "edgex-2021.08.24", "status": 201}}]}`))
+	}))
+	defer ts.Close()
+
+	sender, err := NewElasticsearchSender(ElasticsearchConfig{URL: ts.URL, DataStream: true, PersistOnError: true})
+	require.NoError(t, err)
+
+	events := []dtos.Event{elasticsearchTestEvent("meter-1"), elasticsearchTestEvent("meter-2"), elasticsearchTestEvent("meter-3")}
+	batch := make([][]byte, len(events))
+	for index, event := range events {
+		batch[index], err = json.Marshal(event)
+		require.NoError(t, err)
+	}
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := sender.BulkIndex(ctx, batch)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "1 of 3 Events failed to be indexed: edgex-2021.08.24: rejected execution")
+	assert.Contains(t, requests[0].actions[0], "create", "data streams should create the documents")
+
+	var stored []dtos.Event
+	require.NoError(t, json.Unmarshal(ctx.RetryData(), &stored))
+	assert.Equal(t, []dtos.Event{events[1]}, stored, "only the Event that failed should be stored for retry")
+
+	continuePipeline, result = sender.BulkIndex(ctx, ctx.RetryData())
+	require.True(t, continuePipeline, result)
+	require.Len(t, requests[1].actions, 1)
+	assert.Equal(t, events[1].Id, requests[1].actions[0]["create"]["_id"])
+	ctx.SetRetryData(nil)
+}
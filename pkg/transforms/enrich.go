@@ -0,0 +1,153 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// The device metadata fields added to the Event's tags
+const (
+	DeviceMetadataLabels      = "labels"
+	DeviceMetadataLocation    = "location"
+	DeviceMetadataDescription = "description"
+	DeviceMetadataProfileName = "profileName"
+	DeviceMetadataServiceName = "serviceName"
+)
+
+// DefaultDeviceMetadataTTL is how long a device's metadata is cached when no TTL is given
+const DefaultDeviceMetadataTTL = 5 * time.Minute
+
+// DeviceMetadataEnricher adds the metadata of the Event's device from Core Metadata to the Event's tags, so the
+// consumers of the exported Events get the device's context without a second query. The devices are cached for the
+// TTL so Core Metadata isn't queried for every Event.
+type DeviceMetadataEnricher struct {
+	fields  []string
+	ttl     time.Duration
+	lock    sync.Mutex
+	devices map[string]cachedDevice
+}
+
+type cachedDevice struct {
+	device  dtos.Device
+	expires time.Time
+}
+
+// NewDeviceMetadataEnricher creates, initializes and returns a new instance of DeviceMetadataEnricher. fields are the
+// DeviceMetadata fields added to the tags, all of them when empty. ttl is how long a device is cached,
+// DefaultDeviceMetadataTTL when 0.
+func NewDeviceMetadataEnricher(fields []string, ttl time.Duration) *DeviceMetadataEnricher {
+	if len(fields) == 0 {
+		fields = []string{DeviceMetadataLabels, DeviceMetadataLocation, DeviceMetadataDescription, DeviceMetadataProfileName, DeviceMetadataServiceName}
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultDeviceMetadataTTL
+	}
+
+	return &DeviceMetadataEnricher{
+		fields:  fields,
+		ttl:     ttl,
+		devices: make(map[string]cachedDevice),
+	}
+}
+
+// EnrichWithDeviceMetadata adds the device's metadata fields to the Event's tags. Empty fields are not added. If the
+// device can't be retrieved the last cached metadata is used, or the Event is passed on without it, so the Event isn't
+// lost while Core Metadata is unavailable.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received or
+// Core Metadata is missing from the Clients configuration.
+func (enricher *DeviceMetadataEnricher) EnrichWithDeviceMetadata(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function EnrichWithDeviceMetadata in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function EnrichWithDeviceMetadata in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	client := ctx.DeviceClient()
+	if client == nil {
+		return false, fmt.Errorf("function EnrichWithDeviceMetadata in pipeline '%s': DeviceClient not initialized. Core Metadata is missing from clients configuration", ctx.PipelineId())
+	}
+
+	enricher.lock.Lock()
+	cached, found := enricher.devices[event.DeviceName]
+	enricher.lock.Unlock()
+
+	if !found || time.Now().After(cached.expires) {
+		response, err := client.DeviceByName(context.Background(), event.DeviceName)
+		if err != nil {
+			if !found {
+				ctx.LoggingClient().Errorf("Unable to retrieve device '%s' in pipeline '%s', Event not enriched: %s",
+					event.DeviceName, ctx.PipelineId(), err.Error())
+				return true, event
+			}
+
+			ctx.LoggingClient().Warnf("Unable to retrieve device '%s' in pipeline '%s', using cached metadata: %s",
+				event.DeviceName, ctx.PipelineId(), err.Error())
+		} else {
+			cached = cachedDevice{device: response.Device, expires: time.Now().Add(enricher.ttl)}
+
+			enricher.lock.Lock()
+			enricher.devices[event.DeviceName] = cached
+			enricher.lock.Unlock()
+		}
+	}
+
+	// Copy the tags so the received Event's tags are not modified
+	tags := make(map[string]interface{}, len(event.Tags)+len(enricher.fields))
+	for key, value := range event.Tags {
+		tags[key] = value
+	}
+
+	device := cached.device
+	for _, field := range enricher.fields {
+		switch field {
+		case DeviceMetadataLabels:
+			if len(device.Labels) > 0 {
+				tags[field] = device.Labels
+			}
+		case DeviceMetadataLocation:
+			if device.Location != nil {
+				tags[field] = device.Location
+			}
+		case DeviceMetadataDescription:
+			if device.Description != "" {
+				tags[field] = device.Description
+			}
+		case DeviceMetadataProfileName:
+			tags[field] = device.ProfileName
+		case DeviceMetadataServiceName:
+			tags[field] = device.ServiceName
+		}
+	}
+	event.Tags = tags
+
+	ctx.LoggingClient().Debugf("Event enriched with metadata of device '%s' in pipeline '%s'", event.DeviceName, ctx.PipelineId())
+
+	return true, event
+}
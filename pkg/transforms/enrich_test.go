@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+)
+
+func newEnrichContext(deviceClient *mocks.DeviceClient) *appfunction.Context {
+	dic.Update(di.ServiceConstructorMap{
+		container.DeviceClientName: func(get di.Get) interface{} {
+			return deviceClient
+		},
+	})
+
+	return appfunction.NewContext("123", dic, "")
+}
+
+func TestDeviceMetadataEnricher_EnrichWithDeviceMetadata(t *testing.T) {
+	device := dtos.Device{
+		Name:        "thermostat-1",
+		Labels:      []string{"floor:2"},
+		Location:    map[string]interface{}{"building": "A"},
+		ProfileName: "thermostat",
+		ServiceName: "device-virtual",
+	}
+
+	deviceClient := &mocks.DeviceClient{}
+	deviceClient.On("DeviceByName", mock.Anything, "thermostat-1").
+		Return(responses.DeviceResponse{Device: device}, nil).Once()
+	deviceClient.On("DeviceByName", mock.Anything, "thermostat-1").
+		Return(responses.DeviceResponse{}, errors.NewCommonEdgeX(errors.KindServiceUnavailable, "unavailable", nil))
+	deviceClient.On("DeviceByName", mock.Anything, "thermostat-2").
+		Return(responses.DeviceResponse{}, errors.NewCommonEdgeX(errors.KindEntityDoesNotExist, "not found", nil))
+	defer dic.Update(di.ServiceConstructorMap{
+		container.DeviceClientName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+	context := newEnrichContext(deviceClient)
+
+	enricher := NewDeviceMetadataEnricher(nil, time.Hour)
+
+	received := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	received.Tags = map[string]interface{}{"site": "plant-1"}
+
+	continuePipeline, result := enricher.EnrichWithDeviceMetadata(context, received)
+	require.True(t, continuePipeline, result)
+	expected := map[string]interface{}{
+		"site":                    "plant-1",
+		DeviceMetadataLabels:      device.Labels,
+		DeviceMetadataLocation:    device.Location,
+		DeviceMetadataProfileName: "thermostat",
+		DeviceMetadataServiceName: "device-virtual",
+	}
+	assert.Equal(t, expected, result.(dtos.Event).Tags)
+	assert.Len(t, received.Tags, 1, "received Event's tags should not be modified")
+
+	// The cached device is used without another lookup
+	continuePipeline, result = enricher.EnrichWithDeviceMetadata(context, received)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, expected, result.(dtos.Event).Tags)
+	deviceClient.AssertNumberOfCalls(t, "DeviceByName", 1)
+
+	// The expired device is still used when the lookup fails
+	enricher.devices["thermostat-1"] = cachedDevice{device: device, expires: time.Now().Add(-time.Second)}
+	continuePipeline, result = enricher.EnrichWithDeviceMetadata(context, received)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, expected, result.(dtos.Event).Tags)
+	deviceClient.AssertNumberOfCalls(t, "DeviceByName", 2)
+
+	// An unknown device is passed on without the metadata
+	unknown := dtos.NewEvent("thermostat", "thermostat-2", "status")
+	continuePipeline, result = enricher.EnrichWithDeviceMetadata(context, unknown)
+	require.True(t, continuePipeline, result)
+	assert.Empty(t, result.(dtos.Event).Tags)
+}
+
+func TestDeviceMetadataEnricher_Fields(t *testing.T) {
+	deviceClient := &mocks.DeviceClient{}
+	deviceClient.On("DeviceByName", mock.Anything, "thermostat-1").
+		Return(responses.DeviceResponse{Device: dtos.Device{Labels: []string{"floor:2"}, ProfileName: "thermostat"}}, nil)
+	defer dic.Update(di.ServiceConstructorMap{
+		container.DeviceClientName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+	context := newEnrichContext(deviceClient)
+
+	enricher := NewDeviceMetadataEnricher([]string{DeviceMetadataProfileName, DeviceMetadataLocation}, 0)
+	assert.Equal(t, DefaultDeviceMetadataTTL, enricher.ttl)
+
+	continuePipeline, result := enricher.EnrichWithDeviceMetadata(context, dtos.NewEvent("thermostat", "thermostat-1", "status"))
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, map[string]interface{}{DeviceMetadataProfileName: "thermostat"}, result.(dtos.Event).Tags,
+		"only the fields requested and set should be added")
+}
+
+func TestDeviceMetadataEnricher_Errors(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "thermostat-1", "type received is not an Event"},
+		{"No DeviceClient", dtos.NewEvent("thermostat", "thermostat-1", "status"), "DeviceClient not initialized"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewDeviceMetadataEnricher(nil, 0).EnrichWithDeviceMetadata(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// IdempotentExport guards an export function against exporting data already exported, for destinations that can't
+// deduplicate the data redelivered by the trigger or retried. SkipExported is added to the pipeline before the export
+// function and MarkExported after it. Requires ExportGuard to be enabled in the configuration.
+// Two copies of the same data processed concurrently may both be exported as neither has been marked exported yet.
+type IdempotentExport struct {
+}
+
+// NewIdempotentExport creates, initializes and returns a new instance of IdempotentExport
+func NewIdempotentExport() IdempotentExport {
+	return IdempotentExport{}
+}
+
+// SkipExported stops the pipeline if the data's checksum, its idempotency key, was saved as exported within the
+// ExportGuard Window, otherwise sets the key in the context for MarkExported and passes the data through.
+// This function will return an error and stop the pipeline if no data is received, ExportGuard isn't enabled or the
+// store database can't be read.
+func (guard IdempotentExport) SkipExported(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function SkipExported in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportGuard := ctx.ExportGuard()
+	if exportGuard == nil {
+		return false, fmt.Errorf("function SkipExported in pipeline '%s': ExportGuard is not enabled in the configuration", ctx.PipelineId())
+	}
+
+	payload, err := util.CoerceType(data)
+	if err != nil {
+		return false, fmt.Errorf("function SkipExported in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	checksum := sha256.Sum256(payload)
+	key := hex.EncodeToString(checksum[:])
+
+	exported, err := exportGuard.Exported(key)
+	if err != nil {
+		return false, fmt.Errorf("function SkipExported in pipeline '%s': unable to check if the data was exported: %s", ctx.PipelineId(), err.Error())
+	}
+
+	if exported {
+		ctx.LoggingClient().Debugf("Data already exported, skipping export in pipeline '%s'", ctx.PipelineId())
+		return false, nil
+	}
+
+	ctx.AddValue(interfaces.IDEMPOTENCYKEY, key)
+	return true, data
+}
+
+// MarkExported saves the idempotency key set by SkipExported as exported and passes the data through.
+// A failure to save the key is logged rather than stopping the pipeline, since the data has already been exported.
+// This function will return an error and stop the pipeline if ExportGuard isn't enabled or SkipExported didn't set
+// the key.
+func (guard IdempotentExport) MarkExported(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	exportGuard := ctx.ExportGuard()
+	if exportGuard == nil {
+		return false, fmt.Errorf("function MarkExported in pipeline '%s': ExportGuard is not enabled in the configuration", ctx.PipelineId())
+	}
+
+	key, found := ctx.GetValue(interfaces.IDEMPOTENCYKEY)
+	if !found {
+		return false, fmt.Errorf("function MarkExported in pipeline '%s': no idempotency key found, SkipExported must precede the export function",
+			ctx.PipelineId())
+	}
+
+	if err := exportGuard.MarkExported(key); err != nil {
+		ctx.LoggingClient().Errorf("Unable to mark data as exported in pipeline '%s', it may be exported again: %s", ctx.PipelineId(), err.Error())
+	}
+
+	return true, data
+}
@@ -0,0 +1,127 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/exportguard"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db/interfaces/mocks"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestIdempotentExport(t *testing.T) {
+	exported := make(map[string]bool)
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("ExportKeyExists", "app", mock.Anything).Return(
+		func(_ string, key string) bool { return exported[key] }, nil)
+	storeClient.On("StoreExportKey", "app", mock.Anything, exportguard.DefaultWindow).Return(
+		func(_ string, key string, _ time.Duration) error {
+			exported[key] = true
+			return nil
+		})
+
+	guard, err := exportguard.NewGuard(common.ExportGuardInfo{Enabled: true}, storeClient, "app")
+	require.NoError(t, err)
+	dic.Update(di.ServiceConstructorMap{
+		container.ExportGuardName: func(get di.Get) interface{} {
+			return guard
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.ExportGuardName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	target := NewIdempotentExport()
+	export := func(data string) bool {
+		context := appfunction.NewContext("123", dic, "")
+		continuePipeline, result := target.SkipExported(context, data)
+		if !continuePipeline {
+			require.Nil(t, result)
+			return false
+		}
+
+		key, found := context.GetValue(interfaces.IDEMPOTENCYKEY)
+		require.True(t, found)
+		assert.NotEmpty(t, key)
+
+		continuePipeline, result = target.MarkExported(context, result)
+		require.True(t, continuePipeline)
+		assert.Equal(t, data, result)
+		return true
+	}
+
+	assert.True(t, export("first"))
+	assert.False(t, export("first"), "data already exported should be skipped")
+	assert.True(t, export("second"))
+}
+
+func TestIdempotentExport_Errors(t *testing.T) {
+	target := NewIdempotentExport()
+
+	continuePipeline, result := target.SkipExported(ctx, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "ExportGuard is not enabled")
+
+	storeClient := &mocks.StoreClient{}
+	storeClient.On("ExportKeyExists", "app", mock.Anything).Return(false, errors.New("connection refused"))
+	storeClient.On("StoreExportKey", "app", mock.Anything, exportguard.DefaultWindow).Return(errors.New("connection refused"))
+
+	guard, err := exportguard.NewGuard(common.ExportGuardInfo{Enabled: true}, storeClient, "app")
+	require.NoError(t, err)
+	dic.Update(di.ServiceConstructorMap{
+		container.ExportGuardName: func(get di.Get) interface{} {
+			return guard
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.ExportGuardName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	context := appfunction.NewContext("123", dic, "")
+
+	continuePipeline, result = target.SkipExported(context, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+
+	continuePipeline, result = target.SkipExported(context, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to check if the data was exported")
+
+	continuePipeline, result = target.MarkExported(context, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "SkipExported must precede")
+
+	context.AddValue(interfaces.IDEMPOTENCYKEY, "checksum")
+	continuePipeline, result = target.MarkExported(context, "data")
+	assert.True(t, continuePipeline, "failure to mark exported data should not stop the pipeline")
+	assert.Equal(t, "data", result)
+}
@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// FailoverDestination is an export destination of a FailoverExporter
+type FailoverDestination struct {
+	// Name identifies the destination in the log messages
+	Name string
+	// Export is the export function sending the data to the destination. It must not persist the data on error,
+	// or continue the pipeline on error, so the next destination is tried.
+	Export interfaces.AppFunction
+}
+
+// FailoverExporter sends data to the first of an ordered list of export destinations that accepts it, such as
+// a primary URL, a secondary URL and finally a local file, before resorting to store and forward.
+type FailoverExporter struct {
+	destinations   []FailoverDestination
+	persistOnError bool
+}
+
+// NewFailoverExporter creates, initializes and returns a new instance of FailoverExporter trying the destinations
+// in order. persistOnError enables use of store & forward when all destinations fail, which retries the
+// destinations from the first.
+func NewFailoverExporter(persistOnError bool, destinations ...FailoverDestination) *FailoverExporter {
+	return &FailoverExporter{
+		destinations:   destinations,
+		persistOnError: persistOnError,
+	}
+}
+
+// Export sends the data to the first destination that accepts it and returns the result of that destination's
+// export function. This function will return an error and stop the pipeline if no data is received or all the
+// destinations fail.
+func (exporter *FailoverExporter) Export(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Export in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	if len(exporter.destinations) == 0 {
+		return false, fmt.Errorf("function Export in pipeline '%s': no export destinations configured", ctx.PipelineId())
+	}
+
+	lc := ctx.LoggingClient()
+
+	var failures []string
+	for index, destination := range exporter.destinations {
+		continuePipeline, result := destination.Export(ctx, data)
+		if continuePipeline {
+			if index > 0 {
+				lc.Warnf("Exported to fallback destination '%s' in pipeline '%s'", destination.Name, ctx.PipelineId())
+			}
+			return true, result
+		}
+
+		err, ok := result.(error)
+		if !ok {
+			err = errors.New("export stopped the pipeline")
+		}
+
+		lc.Warnf("Export to destination '%s' failed in pipeline '%s': %s", destination.Name, ctx.PipelineId(), err.Error())
+		failures = append(failures, destination.Name+": "+err.Error())
+	}
+
+	if exporter.persistOnError {
+		exportData, err := util.CoerceType(data)
+		if err != nil {
+			return false, err
+		}
+		ctx.SetRetryData(exportData)
+	}
+
+	return false, fmt.Errorf("function Export in pipeline '%s': all %d export destinations failed: %s",
+		ctx.PipelineId(), len(exporter.destinations), strings.Join(failures, "; "))
+}
@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func failingExport(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	return false, errors.New("destination unavailable")
+}
+
+func TestFailoverExporter_Export(t *testing.T) {
+	var primaryCalls, secondaryCalls int
+	primary := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		primaryCalls++
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		secondaryCalls++
+		_, _ = writer.Write([]byte("accepted"))
+	}))
+	defer secondary.Close()
+
+	directory := t.TempDir()
+	exporter := NewFailoverExporter(true,
+		FailoverDestination{Name: "primary", Export: NewHTTPSender(primary.URL, "", false).HTTPPost},
+		FailoverDestination{Name: "secondary", Export: NewHTTPSender(secondary.URL, "", false).HTTPPost},
+		FailoverDestination{Name: "file", Export: NewFileSender(directory).Write},
+	)
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := exporter.Export(ctx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []byte("accepted"), result, "the result of the destination accepting the data should be returned")
+	assert.Equal(t, 1, primaryCalls)
+	assert.Equal(t, 1, secondaryCalls)
+	assert.Nil(t, ctx.RetryData())
+
+	files, err := ioutil.ReadDir(directory)
+	require.NoError(t, err)
+	assert.Empty(t, files, "later destinations should not be used once one accepts the data")
+}
+
+func TestFailoverExporter_ExportToFile(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "exports")
+	exporter := NewFailoverExporter(true,
+		FailoverDestination{Name: "primary", Export: failingExport},
+		FailoverDestination{Name: "file", Export: NewFileSender(directory).Write},
+	)
+
+	continuePipeline, result := exporter.Export(ctx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, msgStr, result)
+
+	files, err := ioutil.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Contains(t, files[0].Name(), ctx.CorrelationID())
+	written, err := ioutil.ReadFile(filepath.Join(directory, files[0].Name()))
+	require.NoError(t, err)
+	assert.Equal(t, msgStr, string(written))
+}
+
+func TestFailoverExporter_ExportFailed(t *testing.T) {
+	tests := []struct {
+		Name           string
+		PersistOnError bool
+		Data           interface{}
+		ExpectedError  string
+	}{
+		{"Persisted", true, msgStr, "all 2 export destinations failed: primary: destination unavailable; secondary: destination unavailable"},
+		{"Not persisted", false, msgStr, "all 2 export destinations failed"},
+		{"No data", true, nil, "No Data Received"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			exporter := NewFailoverExporter(test.PersistOnError,
+				FailoverDestination{Name: "primary", Export: failingExport},
+				FailoverDestination{Name: "secondary", Export: failingExport},
+			)
+
+			ctx.SetRetryData(nil)
+			continuePipeline, result := exporter.Export(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+
+			if test.PersistOnError && test.Data != nil {
+				assert.Equal(t, []byte(msgStr), ctx.RetryData())
+			} else {
+				assert.Nil(t, ctx.RetryData())
+			}
+		})
+	}
+}
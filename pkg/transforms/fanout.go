@@ -0,0 +1,245 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// FanOutBranch is a chain of functions executed by a FanOut
+type FanOutBranch struct {
+	// Name identifies the branch in the log messages and errors
+	Name      string
+	Functions []interfaces.AppFunction
+}
+
+// BranchError is the error of a FanOut branch
+type BranchError struct {
+	Branch string
+	// Function is the index of the function in the branch that returned the error
+	Function int
+	Err      error
+}
+
+// FanOutError is returned by the FanOut function when branches fail, so the error of each branch can be found with
+// errors.As by the code handling the pipeline's error
+type FanOutError struct {
+	PipelineId string
+	Errors     []BranchError
+}
+
+func (e *FanOutError) Error() string {
+	failures := make([]string, 0, len(e.Errors))
+	for _, branchError := range e.Errors {
+		failures = append(failures, fmt.Sprintf("%s: %s", branchError.Branch, branchError.Err.Error()))
+	}
+
+	return fmt.Sprintf("function FanOut in pipeline '%s': %d branch(es) failed: %s",
+		e.PipelineId, len(e.Errors), strings.Join(failures, "; "))
+}
+
+// FanOut executes several chains of functions concurrently with the same data, such as exporting to HTTP and MQTT
+// and archiving locally, so a slow destination doesn't delay the others.
+type FanOut struct {
+	branches []FanOutBranch
+}
+
+// fanOutRetry is the data to be retried of the branches that failed, which is the data to be retried by FanOut
+type fanOutRetry struct {
+	Branches []branchRetry `json:"branches"`
+}
+
+type branchRetry struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+	Payload  []byte `json:"payload"`
+}
+
+// NewFanOut creates, initializes and returns a new instance of FanOut executing the branches
+func NewFanOut(branches ...FanOutBranch) *FanOut {
+	return &FanOut{
+		branches: branches,
+	}
+}
+
+// FanOut executes the functions of each branch, in order, with the data received, each branch concurrently and with
+// its own copy of the context, so changes made to the context by the branches aren't kept. A branch stopped by one
+// of its functions without an error, i.e. a filter, isn't a failure. Once all the branches complete the data
+// received is returned so the pipeline continues with it. When branches fail, a *FanOutError with the error of each
+// failed branch is returned and the pipeline stops. Store and Forward retries only the branches that failed and set
+// data to be retried, from the function that failed.
+// This function will return an error and stop the pipeline if no data is received.
+func (fanOut *FanOut) FanOut(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function FanOut in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Fanning out to %d branches in pipeline '%s'", len(fanOut.branches), ctx.PipelineId())
+
+	executions, err := fanOut.executions(ctx, data)
+	if err != nil {
+		return false, err
+	}
+
+	var wg sync.WaitGroup
+	for index := range executions {
+		wg.Add(1)
+		go func(execution *branchExecution) {
+			defer wg.Done()
+			execution.execute()
+		}(&executions[index])
+	}
+	wg.Wait()
+
+	fanOutError := &FanOutError{PipelineId: ctx.PipelineId()}
+	var retry fanOutRetry
+
+	for _, execution := range executions {
+		if execution.err == nil {
+			continue
+		}
+
+		ctx.LoggingClient().Warnf("Branch '%s' of FanOut failed in pipeline '%s': %s",
+			execution.name, ctx.PipelineId(), execution.err.Error())
+
+		fanOutError.Errors = append(fanOutError.Errors, BranchError{
+			Branch:   execution.name,
+			Function: execution.failed,
+			Err:      execution.err,
+		})
+
+		if execution.retryData != nil {
+			retry.Branches = append(retry.Branches, branchRetry{
+				Name:     execution.name,
+				Position: execution.failed,
+				Payload:  execution.retryData,
+			})
+		}
+	}
+
+	ctx.RemoveValue(interfaces.FANOUTRETRY)
+
+	if len(fanOutError.Errors) == 0 {
+		return true, data
+	}
+
+	if len(retry.Branches) > 0 {
+		// Can't fail as branchRetry only has simple fields
+		retryData, _ := json.Marshal(retry)
+		ctx.SetRetryData(retryData)
+		ctx.AddValue(interfaces.FANOUTRETRY, "true")
+	}
+
+	return false, fanOutError
+}
+
+// branchExecution is the execution of a branch's functions from the start position
+type branchExecution struct {
+	name      string
+	ctx       interfaces.AppFunctionContext
+	functions []interfaces.AppFunction
+	start     int
+	data      interface{}
+	failed    int
+	err       error
+	retryData []byte
+}
+
+// executions returns the executions of all the branches, or when the data is that to be retried of the branches
+// that failed, the executions resuming those branches
+func (fanOut *FanOut) executions(ctx interfaces.AppFunctionContext, data interface{}) ([]branchExecution, error) {
+	var executions []branchExecution
+
+	if _, isRetry := ctx.GetValue(interfaces.FANOUTRETRY); !isRetry {
+		for _, branch := range fanOut.branches {
+			executions = append(executions, branchExecution{
+				name:      branch.Name,
+				ctx:       ctx.Clone(),
+				functions: branch.Functions,
+				data:      data,
+			})
+		}
+
+		return executions, nil
+	}
+
+	payload, ok := data.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("function FanOut in pipeline '%s': data to be retried is not []byte", ctx.PipelineId())
+	}
+
+	var retry fanOutRetry
+	if err := json.Unmarshal(payload, &retry); err != nil {
+		return nil, fmt.Errorf("function FanOut in pipeline '%s': unable to decode data to be retried: %s", ctx.PipelineId(), err.Error())
+	}
+
+	for _, branchRetry := range retry.Branches {
+		branch, found := fanOut.branch(branchRetry.Name)
+		if !found {
+			ctx.LoggingClient().Warnf("Branch '%s' of FanOut to be retried no longer exists in pipeline '%s'", branchRetry.Name, ctx.PipelineId())
+			continue
+		}
+
+		executions = append(executions, branchExecution{
+			name:      branch.Name,
+			ctx:       ctx.Clone(),
+			functions: branch.Functions,
+			start:     branchRetry.Position,
+			data:      branchRetry.Payload,
+		})
+	}
+
+	return executions, nil
+}
+
+func (fanOut *FanOut) branch(name string) (FanOutBranch, bool) {
+	for _, branch := range fanOut.branches {
+		if branch.Name == name {
+			return branch, true
+		}
+	}
+
+	return FanOutBranch{}, false
+}
+
+func (execution *branchExecution) execute() {
+	// The values set by the FanOut for its own retry aren't for the functions of the branch
+	execution.ctx.RemoveValue(interfaces.FANOUTRETRY)
+
+	result := execution.data
+	for position := execution.start; position < len(execution.functions); position++ {
+		execution.ctx.SetRetryData(nil)
+
+		continuePipeline, output := execution.functions[position](execution.ctx, result)
+		if !continuePipeline {
+			if err, isError := output.(error); isError {
+				execution.failed = position
+				execution.err = err
+				execution.retryData = retryData(execution.ctx)
+			}
+			return
+		}
+
+		result = output
+	}
+}
@@ -0,0 +1,131 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// recordingFunction records the data it receives and passes it through
+type recordingFunction struct {
+	lock     sync.Mutex
+	received []interface{}
+}
+
+func (recorder *recordingFunction) record(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.received = append(recorder.received, data)
+	return true, data
+}
+
+func TestFanOut(t *testing.T) {
+	http := &recordingFunction{}
+	mqtt := &recordingFunction{}
+	started := make(chan struct{})
+
+	target := NewFanOut(
+		FanOutBranch{Name: "http", Functions: []interfaces.AppFunction{
+			func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+				// Waits for the mqtt branch, which would deadlock if the branches weren't executed concurrently
+				select {
+				case <-started:
+				case <-time.After(5 * time.Second):
+					return false, errors.New("branches not executed concurrently")
+				}
+				return true, data
+			},
+			appendFunction("-http"),
+			http.record,
+		}},
+		FanOutBranch{Name: "mqtt", Functions: []interfaces.AppFunction{
+			func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+				close(started)
+				ctx.AddValue("branch", "mqtt")
+				return true, data
+			},
+			mqtt.record,
+		}},
+		FanOutBranch{Name: "filtered", Functions: []interfaces.AppFunction{stopFunction}},
+	)
+
+	testCtx := appfunction.NewContext("123", dic, "")
+	continuePipeline, result := target.FanOut(testCtx, "data")
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "data", result, "data received should be passed through")
+	assert.Equal(t, []interface{}{"data-http"}, http.received)
+	assert.Equal(t, []interface{}{"data"}, mqtt.received)
+
+	_, found := testCtx.GetValue("branch")
+	assert.False(t, found, "branches should have their own copy of the context")
+
+	continuePipeline, result = target.FanOut(testCtx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestFanOutErrors(t *testing.T) {
+	failures := 1
+	archive := &recordingFunction{}
+
+	target := NewFanOut(
+		FanOutBranch{Name: "http", Functions: []interfaces.AppFunction{appendFunction("-http"), failingFunction(&failures)}},
+		FanOutBranch{Name: "mqtt", Functions: []interfaces.AppFunction{
+			func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+				return false, errors.New("broker unavailable")
+			},
+		}},
+		FanOutBranch{Name: "archive", Functions: []interfaces.AppFunction{archive.record}},
+	)
+
+	testCtx := appfunction.NewContext("123", dic, "")
+	testCtx.AddValue(interfaces.PIPELINEID, "fan-out")
+	continuePipeline, result := target.FanOut(testCtx, "data")
+	require.False(t, continuePipeline)
+
+	var fanOutError *FanOutError
+	require.True(t, errors.As(result.(error), &fanOutError))
+	require.Len(t, fanOutError.Errors, 2)
+	assert.Equal(t, "http", fanOutError.Errors[0].Branch)
+	assert.Equal(t, 1, fanOutError.Errors[0].Function)
+	assert.Equal(t, "mqtt", fanOutError.Errors[1].Branch)
+	assert.EqualError(t, fanOutError.Errors[1].Err, "broker unavailable")
+	assert.Contains(t, fanOutError.Error(), "function FanOut in pipeline 'fan-out': 2 branch(es) failed")
+	assert.Equal(t, []interface{}{"data"}, archive.received)
+
+	// Only the http branch set data to be retried, so only it is retried, from the function that failed
+	retryData := testCtx.RetryData()
+	require.NotNil(t, retryData)
+	_, found := testCtx.GetValue(interfaces.FANOUTRETRY)
+	require.True(t, found)
+
+	continuePipeline, result = target.FanOut(testCtx, retryData)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []interface{}{"data"}, archive.received, "branches that succeeded should not be retried")
+	assert.Equal(t, 0, failures)
+	_, found = testCtx.GetValue(interfaces.FANOUTRETRY)
+	assert.False(t, found)
+}
@@ -0,0 +1,89 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// FileSender exports data to files in a local directory, one file per export
+type FileSender struct {
+	directory string
+}
+
+// NewFileSender creates, initializes and returns a new instance of FileSender writing to the directory,
+// which is created if it doesn't exist
+func NewFileSender(directory string) FileSender {
+	return FileSender{
+		directory: directory,
+	}
+}
+
+// Write writes the data from the previous function to a new file in the directory, named with the time of the
+// export and the correlation id so the files sort in export order. The file is written under a temporary name and
+// renamed once complete, so a process collecting the files never reads a partial export.
+// The input data is returned so other export functions can follow.
+func (sender FileSender) Write(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Write in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not write %d bytes of data to %s", ctx.PipelineId(), len(exportData), sender.directory)
+		return true, data
+	}
+
+	if err := os.MkdirAll(sender.directory, 0750); err != nil {
+		return false, fmt.Errorf("function Write in pipeline '%s': unable to create directory: %s", ctx.PipelineId(), err.Error())
+	}
+
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + ctx.CorrelationID()
+	temporary, err := ioutil.TempFile(sender.directory, "."+name)
+	if err != nil {
+		return false, fmt.Errorf("function Write in pipeline '%s': unable to create file: %s", ctx.PipelineId(), err.Error())
+	}
+
+	_, err = temporary.Write(exportData)
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary.Name(), filepath.Join(sender.directory, name))
+	}
+	if err != nil {
+		_ = os.Remove(temporary.Name())
+		return false, fmt.Errorf("function Write in pipeline '%s': unable to write file: %s", ctx.PipelineId(), err.Error())
+	}
+
+	ctx.LoggingClient().Debugf("Wrote %d bytes of data to %s in pipeline '%s'", len(exportData), sender.directory, ctx.PipelineId())
+
+	return true, data
+}
@@ -0,0 +1,72 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestFileSender_Write(t *testing.T) {
+	directory := t.TempDir()
+	sender := NewFileSender(directory)
+
+	for _, data := range []interface{}{"first", []byte("second")} {
+		continuePipeline, result := sender.Write(ctx, data)
+		require.True(t, continuePipeline, result)
+		assert.Equal(t, data, result)
+	}
+
+	files, err := ioutil.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for index, expected := range []string{"first", "second"} {
+		written, err := ioutil.ReadFile(filepath.Join(directory, files[index].Name()))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(written), "files should sort in export order")
+	}
+}
+
+func TestFileSender_WriteShadow(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "exports")
+	shadowCtx := ctx.Clone().(*appfunction.Context)
+	shadowCtx.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+
+	continuePipeline, result := NewFileSender(directory).Write(shadowCtx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.NoDirExists(t, directory)
+}
+
+func TestFileSender_WriteErrors(t *testing.T) {
+	continuePipeline, result := NewFileSender(t.TempDir()).Write(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+
+	// A file where the directory should be
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, ioutil.WriteFile(file, []byte{}, 0640))
+	continuePipeline, result = NewFileSender(file).Write(ctx, msgStr)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to create directory")
+}
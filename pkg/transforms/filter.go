@@ -18,10 +18,15 @@ package transforms
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 )
 
@@ -29,7 +34,45 @@ import (
 type Filter struct {
 	FilterValues []string
 	FilterOut    bool
-	ctx          interfaces.AppFunctionContext
+	// FilterPatterns are regular expressions names are matched against in addition to the FilterValues. A name
+	// matches a pattern found anywhere in it, so the pattern must be anchored with ^ and $ to match the whole name.
+	FilterPatterns []*regexp.Regexp
+	// ValueRange, when set, limits the readings matched by FilterByResourceName to those with a numeric value in the
+	// range. Readings with non-numeric values never match.
+	ValueRange *ValueRange
+	// DeviceGroup, when set, adds the members of the device group to the device names matched by FilterByDeviceName.
+	// The members are those when the Event is filtered, so they follow the devices labelled in Core Metadata.
+	DeviceGroup string
+	ctx         interfaces.AppFunctionContext
+}
+
+// ValueRange is a predicate on the numeric value of a reading. Each of the limits is optional.
+type ValueRange struct {
+	// Min is the smallest value in the range
+	Min *float64
+	// Max is the largest value in the range
+	Max *float64
+	// Equal, when set, limits the range to the values within Tolerance of it
+	Equal *float64
+	// Tolerance is the largest difference from Equal of the values in the range
+	Tolerance float64
+}
+
+// Contains returns whether the value is within all the limits of the range
+func (r ValueRange) Contains(value float64) bool {
+	if r.Min != nil && value < *r.Min {
+		return false
+	}
+
+	if r.Max != nil && value > *r.Max {
+		return false
+	}
+
+	if r.Equal != nil && math.Abs(value-*r.Equal) > r.Tolerance {
+		return false
+	}
+
+	return true
 }
 
 // NewFilterFor creates, initializes and returns a new instance of Filter
@@ -66,6 +109,8 @@ func (f Filter) FilterByProfileName(ctx interfaces.AppFunctionContext, data inte
 // FilterByDeviceName filters based on the specified Device Names, aka Instance of a Device.
 // If FilterOut is false, it filters out those Events not associated with the specified Device Names listed in FilterValues.
 // If FilterOut is true, it out those Events that are associated with the specified Device Names listed in FilterValues.
+// The members of the DeviceGroup, when set, are added to the FilterValues. This function will return an error and stop
+// the pipeline if the DeviceGroup isn't configured.
 func (f Filter) FilterByDeviceName(ctx interfaces.AppFunctionContext, data interface{}) (continuePipeline bool, result interface{}) {
 	f.ctx = ctx
 	event, err := f.setupForFiltering("FilterByDeviceName", "DeviceName", ctx.LoggingClient(), data)
@@ -73,6 +118,30 @@ func (f Filter) FilterByDeviceName(ctx interfaces.AppFunctionContext, data inter
 		return false, err
 	}
 
+	if f.DeviceGroup != "" {
+		groups := ctx.DeviceGroups()
+		if groups == nil {
+			return false, fmt.Errorf("FilterByDeviceName: DeviceGroups not enabled in pipeline '%s'", ctx.PipelineId())
+		}
+
+		members, found := groups.DeviceNames(f.DeviceGroup)
+		if !found {
+			return false, fmt.Errorf("FilterByDeviceName: device group '%s' not configured in pipeline '%s'", f.DeviceGroup, ctx.PipelineId())
+		}
+
+		// A group without members matches no devices rather than all of them
+		if len(members) == 0 && !f.hasNameFilter() {
+			if f.FilterOut {
+				return true, *event
+			}
+			ctx.LoggingClient().Debugf("Event not accepted for DeviceName=%s in pipeline '%s', device group '%s' has no members",
+				event.DeviceName, ctx.PipelineId(), f.DeviceGroup)
+			return false, nil
+		}
+
+		f.FilterValues = append(append([]string{}, f.FilterValues...), members...)
+	}
+
 	ok := f.doEventFilter("DeviceName", event.DeviceName, ctx.LoggingClient())
 	if ok {
 		return true, *event
@@ -111,7 +180,7 @@ func (f Filter) FilterByResourceName(ctx interfaces.AppFunctionContext, data int
 	}
 
 	// No filter values, so pass all event and all readings through, rather than filtering them all out.
-	if len(f.FilterValues) == 0 {
+	if !f.hasNameFilter() && f.ValueRange == nil {
 		return true, *existingEvent
 	}
 
@@ -121,39 +190,15 @@ func (f Filter) FilterByResourceName(ctx interfaces.AppFunctionContext, data int
 	auxEvent.Origin = existingEvent.Origin
 	auxEvent.Readings = []dtos.BaseReading{}
 
-	if f.FilterOut {
-		for _, reading := range existingEvent.Readings {
-			readingFilteredOut := false
-			for _, name := range f.FilterValues {
-				if reading.ResourceName == name {
-					readingFilteredOut = true
-					break
-				}
-			}
+	for _, reading := range existingEvent.Readings {
+		matched := f.matchesName(reading.ResourceName) && f.matchesValue(reading)
 
-			if !readingFilteredOut {
-				ctx.LoggingClient().Debugf("Reading accepted in pipeline '%s' for resource %s", f.ctx.PipelineId(), reading.ResourceName)
-				auxEvent.Readings = append(auxEvent.Readings, reading)
-			} else {
-				ctx.LoggingClient().Debugf("Reading not accepted in pipeline '%s' for resource %s", f.ctx.PipelineId(), reading.ResourceName)
-			}
-		}
-	} else {
-		for _, reading := range existingEvent.Readings {
-			readingFilteredFor := false
-			for _, name := range f.FilterValues {
-				if reading.ResourceName == name {
-					readingFilteredFor = true
-					break
-				}
-			}
-
-			if readingFilteredFor {
-				ctx.LoggingClient().Debugf("Reading accepted in pipeline '%s' for resource %s", f.ctx.PipelineId(), reading.ResourceName)
-				auxEvent.Readings = append(auxEvent.Readings, reading)
-			} else {
-				ctx.LoggingClient().Debugf("Reading not accepted in pipeline '%s' for resource %s", f.ctx.PipelineId(), reading.ResourceName)
-			}
+		// Matching readings are kept when filtering for and removed when filtering out
+		if matched != f.FilterOut {
+			ctx.LoggingClient().Debugf("Reading accepted in pipeline '%s' for resource %s", f.ctx.PipelineId(), reading.ResourceName)
+			auxEvent.Readings = append(auxEvent.Readings, reading)
+		} else {
+			ctx.LoggingClient().Debugf("Reading not accepted in pipeline '%s' for resource %s", f.ctx.PipelineId(), reading.ResourceName)
 		}
 	}
 
@@ -187,28 +232,64 @@ func (f Filter) setupForFiltering(funcName string, filterProperty string, lc log
 
 func (f Filter) doEventFilter(filterProperty string, value string, lc logger.LoggingClient) bool {
 	// No names to filter for, so pass events through rather than filtering them all out.
-	if len(f.FilterValues) == 0 {
+	if !f.hasNameFilter() {
 		return true
 	}
 
-	for _, name := range f.FilterValues {
-		if value == name {
-			if f.FilterOut {
-				lc.Debugf("Event not accepted for %s=%s in pipeline '%s'", filterProperty, value, f.ctx.PipelineId())
-				return false
-			} else {
-				lc.Debugf("Event accepted for %s=%s in pipeline '%s'", filterProperty, value, f.ctx.PipelineId())
-				return true
-			}
+	// Matching Events are accepted when filtering for and not accepted when filtering out
+	if f.matchesName(value) != f.FilterOut {
+		lc.Debugf("Event accepted for %s=%s in pipeline '%s'", filterProperty, value, f.ctx.PipelineId())
+		return true
+	}
+
+	lc.Debugf("Event not accepted for %s=%s in pipeline '%s'", filterProperty, value, f.ctx.PipelineId())
+	return false
+}
+
+func (f Filter) hasNameFilter() bool {
+	return len(f.FilterValues) > 0 || len(f.FilterPatterns) > 0
+}
+
+// matchesName returns whether the name is one of the FilterValues or matches one of the FilterPatterns.
+// All names match when neither are set.
+func (f Filter) matchesName(name string) bool {
+	if !f.hasNameFilter() {
+		return true
+	}
+
+	for _, filterValue := range f.FilterValues {
+		if name == filterValue {
+			return true
 		}
 	}
 
-	// Will only get here if Event's SourceName didn't match any names in FilterValues
-	if f.FilterOut {
-		lc.Debugf("Event accepted for %s=%s in pipeline '%s'", filterProperty, value, f.ctx.PipelineId())
+	for _, pattern := range f.FilterPatterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesValue returns whether the reading's value is in the ValueRange. All values match when it isn't set.
+func (f Filter) matchesValue(reading dtos.BaseReading) bool {
+	if f.ValueRange == nil {
 		return true
-	} else {
-		lc.Debugf("Event not accepted for %s=%s in pipeline '%s'", filterProperty, value, f.ctx.PipelineId())
+	}
+
+	switch reading.ValueType {
+	case common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+		common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64,
+		common.ValueTypeFloat32, common.ValueTypeFloat64:
+	default:
 		return false
 	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+	if err != nil {
+		return false
+	}
+
+	return f.ValueRange.Contains(value)
 }
@@ -17,12 +17,15 @@
 package transforms
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -278,3 +281,145 @@ func TestFilter_FilterByResourceName(t *testing.T) {
 		})
 	}
 }
+
+func TestFilter_FilterPatterns(t *testing.T) {
+	event := dtos.NewEvent(profileName1, deviceName1, sourceName1)
+	require.NoError(t, event.AddSimpleReading(resource1, common.ValueTypeInt32, int32(1)))
+	require.NoError(t, event.AddSimpleReading(resource2, common.ValueTypeInt32, int32(2)))
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeInt32, int32(3)))
+
+	filter := NewFilterFor(nil)
+	filter.FilterPatterns = []*regexp.Regexp{regexp.MustCompile("^resource[12]$")}
+	continuePipeline, result := filter.FilterByResourceName(ctx, event)
+	require.True(t, continuePipeline)
+	assert.Len(t, result.(dtos.Event).Readings, 2)
+
+	filter = NewFilterOut([]string{"temperature"})
+	filter.FilterPatterns = []*regexp.Regexp{regexp.MustCompile("2$")}
+	continuePipeline, result = filter.FilterByResourceName(ctx, event)
+	require.True(t, continuePipeline)
+	require.Len(t, result.(dtos.Event).Readings, 1)
+	assert.Equal(t, resource1, result.(dtos.Event).Readings[0].ResourceName)
+
+	filter = NewFilterFor(nil)
+	filter.FilterPatterns = []*regexp.Regexp{regexp.MustCompile("^device")}
+	continuePipeline, _ = filter.FilterByDeviceName(ctx, event)
+	assert.True(t, continuePipeline)
+
+	filter.FilterOut = true
+	continuePipeline, _ = filter.FilterByDeviceName(ctx, event)
+	assert.False(t, continuePipeline)
+}
+
+// staticDeviceGroups implements interfaces.DeviceGroups with fixed members
+type staticDeviceGroups map[string][]string
+
+func (groups staticDeviceGroups) DeviceNames(groupName string) ([]string, bool) {
+	members, found := groups[groupName]
+	return members, found
+}
+
+func TestFilter_FilterByDeviceNameDeviceGroup(t *testing.T) {
+	groupCtx := &mocks.AppFunctionContext{}
+	groupCtx.On("LoggingClient").Return(lc)
+	groupCtx.On("PipelineId").Return("test-pipeline")
+	groupCtx.On("DeviceGroups").Return(staticDeviceGroups{
+		"thermostats": {deviceName1},
+		"empty":       {},
+	})
+
+	event1 := dtos.NewEvent(profileName1, deviceName1, sourceName1)
+	event2 := dtos.NewEvent(profileName1, deviceName2, sourceName1)
+
+	tests := []struct {
+		Name            string
+		FilterValues    []string
+		DeviceGroup     string
+		FilterOut       bool
+		Event           dtos.Event
+		ExpectContinue  bool
+		ExpectedErrorIn string
+	}{
+		{"Member", nil, "thermostats", false, event1, true, ""},
+		{"Not a member", nil, "thermostats", false, event2, false, ""},
+		{"Device name and group", []string{deviceName2}, "thermostats", false, event2, true, ""},
+		{"Filter out member", nil, "thermostats", true, event1, false, ""},
+		{"Filter out not a member", nil, "thermostats", true, event2, true, ""},
+		{"No members", nil, "empty", false, event1, false, ""},
+		{"Filter out no members", nil, "empty", true, event1, true, ""},
+		{"Group not configured", nil, "pumps", false, event1, false, "device group 'pumps' not configured"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			filter := Filter{FilterValues: test.FilterValues, DeviceGroup: test.DeviceGroup, FilterOut: test.FilterOut}
+			continuePipeline, result := filter.FilterByDeviceName(groupCtx, test.Event)
+			assert.Equal(t, test.ExpectContinue, continuePipeline)
+			if test.ExpectedErrorIn != "" {
+				require.Error(t, result.(error))
+				assert.Contains(t, result.(error).Error(), test.ExpectedErrorIn)
+			}
+		})
+	}
+
+	filter := Filter{DeviceGroup: "thermostats"}
+	continuePipeline, result := filter.FilterByDeviceName(ctx, event1)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "DeviceGroups not enabled")
+}
+
+func TestFilter_ValueRange(t *testing.T) {
+	min := 10.0
+	max := 20.0
+	equal := 15.0
+
+	event := dtos.NewEvent(profileName1, deviceName1, sourceName1)
+	require.NoError(t, event.AddSimpleReading(resource1, common.ValueTypeInt32, int32(5)))
+	require.NoError(t, event.AddSimpleReading(resource1, common.ValueTypeFloat64, 15.2))
+	require.NoError(t, event.AddSimpleReading(resource2, common.ValueTypeUint8, uint8(25)))
+	require.NoError(t, event.AddSimpleReading(resource2, common.ValueTypeString, "15"))
+
+	tests := []struct {
+		Name              string
+		Names             []string
+		FilterOut         bool
+		Range             ValueRange
+		ExpectedNilResult bool
+		ExpectedValues    []string
+	}{
+		{"min", nil, false, ValueRange{Min: &min}, false, []string{"1.520000e+01", "25"}},
+		{"max", nil, false, ValueRange{Max: &max}, false, []string{"5", "1.520000e+01"}},
+		{"min and max", nil, false, ValueRange{Min: &min, Max: &max}, false, []string{"1.520000e+01"}},
+		{"equal", nil, false, ValueRange{Equal: &equal}, true, nil},
+		{"equal with tolerance", nil, false, ValueRange{Equal: &equal, Tolerance: 0.5}, false, []string{"1.520000e+01"}},
+		{"names and range", []string{resource2}, false, ValueRange{Min: &min}, false, []string{"25"}},
+		{"filter out range", nil, true, ValueRange{Min: &min, Max: &max}, false, []string{"5", "25", "15"}},
+		{"filter out names and range", []string{resource1}, true, ValueRange{Max: &max}, false, []string{"25", "15"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var filter Filter
+			if test.FilterOut {
+				filter = NewFilterOut(test.Names)
+			} else {
+				filter = NewFilterFor(test.Names)
+			}
+			valueRange := test.Range
+			filter.ValueRange = &valueRange
+
+			continuePipeline, result := filter.FilterByResourceName(ctx, event)
+			assert.Equal(t, !test.ExpectedNilResult, continuePipeline)
+			if test.ExpectedNilResult {
+				assert.Nil(t, result)
+				return
+			}
+
+			var actual []string
+			for _, reading := range result.(dtos.Event).Readings {
+				actual = append(actual, reading.Value)
+			}
+			assert.Equal(t, test.ExpectedValues, actual)
+		})
+	}
+}
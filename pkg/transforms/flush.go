@@ -0,0 +1,81 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"sync"
+	"time"
+)
+
+type flushBoundary struct {
+	interval time.Duration
+	at       int64
+}
+
+// FlushCoordinator aligns the flushes of the batch based functions using it to wall clock boundaries, which are
+// multiples of the flush interval since midnight UTC for intervals dividing a day, i.e. :00, :15, :30 and :45 for a
+// 15m interval. All the functions with the same interval flush together, sharing a single timer for each boundary,
+// so downstream systems receive consistent interval data.
+type FlushCoordinator struct {
+	lock    sync.Mutex
+	pending map[flushBoundary]chan time.Time
+	now     func() time.Time
+}
+
+// NewFlushCoordinator creates, initializes and returns a new instance of FlushCoordinator
+func NewFlushCoordinator() *FlushCoordinator {
+	return &FlushCoordinator{
+		pending: make(map[flushBoundary]chan time.Time),
+		now:     time.Now,
+	}
+}
+
+// NextFlush returns the next wall clock boundary of the interval and a channel that is closed when it is reached
+func (coordinator *FlushCoordinator) NextFlush(interval time.Duration) (time.Time, <-chan time.Time) {
+	now := coordinator.now()
+	next := now.Truncate(interval).Add(interval)
+	boundary := flushBoundary{interval: interval, at: next.UnixNano()}
+
+	coordinator.lock.Lock()
+	defer coordinator.lock.Unlock()
+
+	flushed, found := coordinator.pending[boundary]
+	if !found {
+		flushed = make(chan time.Time)
+		coordinator.pending[boundary] = flushed
+		time.AfterFunc(next.Sub(now), func() {
+			coordinator.lock.Lock()
+			delete(coordinator.pending, boundary)
+			coordinator.lock.Unlock()
+			close(flushed)
+		})
+	}
+
+	return next, flushed
+}
+
+// flushTimer returns a channel that receives once the interval elapses, or once the next boundary of the interval
+// is reached when flushes are aligned by the coordinator, and the function to stop the timer
+func flushTimer(coordinator *FlushCoordinator, interval time.Duration) (<-chan time.Time, func()) {
+	if coordinator == nil {
+		timer := time.NewTimer(interval)
+		return timer.C, func() { timer.Stop() }
+	}
+
+	_, flushed := coordinator.NextFlush(interval)
+	return flushed, func() {}
+}
@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushCoordinator_NextFlush(t *testing.T) {
+	coordinator := NewFlushCoordinator()
+	coordinator.now = func() time.Time { return time.Date(2021, 6, 1, 10, 7, 30, 0, time.UTC) }
+
+	tests := []struct {
+		Interval time.Duration
+		Expected time.Time
+	}{
+		{15 * time.Minute, time.Date(2021, 6, 1, 10, 15, 0, 0, time.UTC)},
+		{time.Hour, time.Date(2021, 6, 1, 11, 0, 0, 0, time.UTC)},
+		{24 * time.Hour, time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{10 * time.Second, time.Date(2021, 6, 1, 10, 7, 40, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Interval.String(), func(t *testing.T) {
+			next, _ := coordinator.NextFlush(test.Interval)
+			assert.Equal(t, test.Expected, next.UTC())
+		})
+	}
+}
+
+func TestFlushCoordinator_Shared(t *testing.T) {
+	coordinator := NewFlushCoordinator()
+	interval := 50 * time.Millisecond
+
+	first, firstFlushed := coordinator.NextFlush(interval)
+	second, secondFlushed := coordinator.NextFlush(interval)
+	if !first.Equal(second) {
+		// The boundary was reached between the calls
+		first, firstFlushed = coordinator.NextFlush(interval)
+		second, secondFlushed = coordinator.NextFlush(interval)
+	}
+
+	require.Equal(t, first, second)
+	assert.Equal(t, firstFlushed, secondFlushed, "waiters for the same boundary should share the timer")
+
+	select {
+	case <-firstFlushed:
+		assert.False(t, time.Now().Before(first), "flushed before the boundary")
+	case <-time.After(time.Second):
+		require.Fail(t, "boundary not reached")
+	}
+
+	coordinator.lock.Lock()
+	defer coordinator.lock.Unlock()
+	assert.Empty(t, coordinator.pending)
+}
+
+func TestBatch_AlignFlush(t *testing.T) {
+	coordinator := NewFlushCoordinator()
+	interval := 200 * time.Millisecond
+
+	first, err := NewBatchByTime(interval.String())
+	require.NoError(t, err)
+	first.AlignFlush(coordinator)
+	second, err := NewBatchByCountOrTime(interval.String(), 10)
+	require.NoError(t, err)
+	second.AlignFlush(coordinator)
+
+	// Start just after a boundary so both batches are started within the same interval
+	_, flushed := coordinator.NextFlush(interval)
+	<-flushed
+
+	var wg sync.WaitGroup
+	released := make([]time.Time, 2)
+	for index, batch := range []*BatchConfig{first, second} {
+		wg.Add(1)
+		go func(index int, batch *BatchConfig) {
+			defer wg.Done()
+			continuePipeline, result := batch.Batch(ctx, []byte(dataToBatch[0]))
+			assert.True(t, continuePipeline, result)
+			released[index] = time.Now()
+		}(index, batch)
+		// Start the batches at different times within the interval
+		time.Sleep(20 * time.Millisecond)
+	}
+	wg.Wait()
+
+	difference := released[0].Sub(released[1])
+	if difference < 0 {
+		difference = -difference
+	}
+	assert.Less(t, int64(difference), int64(10*time.Millisecond), "batches should be released on the same boundary")
+}
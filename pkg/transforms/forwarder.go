@@ -0,0 +1,274 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-messaging/v2/pkg/types"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/secure"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// AppServiceForwarderConfig contains the configuration for forwarding data to another application service
+type AppServiceForwarderConfig struct {
+	// TargetAddress is the address of the other application service's trigger. An http:// or https:// address posts to
+	// its HTTP trigger, using the /api/v2/trigger path when the address has no path, i.e. https://site-server:59700.
+	// Any other address is the MQTT broker its trigger subscribes to, i.e. mqtts://site-server:8883.
+	TargetAddress string
+	// Topic is the topic published to when forwarding via an MQTT broker
+	Topic string
+	// MessageBus indicates the data is wrapped in a message envelope as expected by the EdgeX MessageBus trigger,
+	// otherwise the data is published as is, as expected by the External MQTT trigger
+	MessageBus bool
+	// ClientId is the client id used to connect to the MQTT broker
+	ClientId string
+	// QoS is the MQTT quality of service the data is published with
+	QoS byte
+	// SecretPath is the path in the secret store of the clientcert and clientkey secrets used for mutual TLS, along
+	// with the optional cacert secret used to verify the target. Mutual TLS isn't used when empty.
+	SecretPath string
+	// SkipCertVerify disables the verification of the target's certificate
+	SkipCertVerify bool
+}
+
+// AppServiceForwarder forwards data to the trigger of another application service, such as from a gateway to the
+// application service of its site server, so services can be arranged in a hierarchy. Data that fails to be forwarded
+// is always stored for later retry when Store and Forward is enabled.
+type AppServiceForwarder struct {
+	config               AppServiceForwarderConfig
+	targetUrl            string
+	mqttSender           *MQTTSecretSender
+	lock                 sync.Mutex
+	httpClient           *http.Client
+	secretsLastRetrieved time.Time
+}
+
+// NewAppServiceForwarder creates, initializes and returns a new instance of AppServiceForwarder
+func NewAppServiceForwarder(config AppServiceForwarderConfig) (*AppServiceForwarder, error) {
+	target, err := url.Parse(config.TargetAddress)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid target address '%s', must be in the form scheme://host:port", config.TargetAddress)
+	}
+
+	forwarder := &AppServiceForwarder{config: config}
+
+	switch strings.ToLower(target.Scheme) {
+	case "https":
+	case "http":
+		if config.SecretPath != "" {
+			return nil, fmt.Errorf("target address '%s' must use https for mutual TLS", config.TargetAddress)
+		}
+	default:
+		if config.Topic == "" {
+			return nil, fmt.Errorf("topic required when forwarding to MQTT broker '%s'", config.TargetAddress)
+		}
+
+		authMode := messaging.AuthModeNone
+		if config.SecretPath != "" {
+			authMode = messaging.AuthModeCert
+		}
+
+		forwarder.mqttSender = NewMQTTSecretSender(MQTTSecretConfig{
+			BrokerAddress:  config.TargetAddress,
+			ClientId:       config.ClientId,
+			SecretPath:     config.SecretPath,
+			AutoReconnect:  true,
+			Topic:          config.Topic,
+			QoS:            config.QoS,
+			SkipCertVerify: config.SkipCertVerify,
+			AuthMode:       authMode,
+		}, false)
+		return forwarder, nil
+	}
+
+	if target.Path == "" || target.Path == "/" {
+		target.Path = internal.ApiTriggerRoute
+	}
+	forwarder.targetUrl = target.String()
+
+	return forwarder, nil
+}
+
+// Forward sends the data from the previous function to the other application service's trigger and passes it on
+// unchanged, so more functions, such as another Forward, can follow. The correlation id and content type of the
+// data are forwarded along with it.
+// This function will return an error and stop the pipeline if no data is received or the data can't be forwarded,
+// in which case the data is stored for later retry.
+func (forwarder *AppServiceForwarder) Forward(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Forward in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, err := util.CoerceTypeTo(contentTypeOf(ctx), data)
+	if err != nil {
+		return false, err
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not forward %d bytes of data to %s",
+			ctx.PipelineId(), len(exportData), forwarder.config.TargetAddress)
+		return true, data
+	}
+
+	if isNetworkOffline(ctx) {
+		ctx.SetRetryData(exportData)
+		return false, fmt.Errorf("function Forward in pipeline '%s': network is offline, persisting data for later retry", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Forwarding %d bytes of data to %s in pipeline '%s'",
+		len(exportData), forwarder.config.TargetAddress, ctx.PipelineId())
+
+	if forwarder.mqttSender != nil {
+		err = forwarder.publish(ctx, exportData)
+	} else {
+		err = forwarder.post(ctx, exportData)
+	}
+
+	if err != nil {
+		ctx.SetRetryData(exportData)
+		return false, fmt.Errorf("function Forward in pipeline '%s': unable to forward to %s, persisting data for later retry: %s",
+			ctx.PipelineId(), forwarder.config.TargetAddress, err.Error())
+	}
+
+	ctx.LoggingClient().Tracef("Data forwarded for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+	return true, data
+}
+
+func (forwarder *AppServiceForwarder) post(ctx interfaces.AppFunctionContext, exportData []byte) error {
+	client, err := forwarder.getHTTPClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx.Context(), http.MethodPost, forwarder.targetUrl, bytes.NewReader(exportData))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set(common.ContentType, contentTypeOf(ctx))
+	request.Header.Set(common.CorrelationHeader, ctx.CorrelationID())
+	for key, value := range ctx.Tags() {
+		request.Header.Set(interfaces.TagHeaderPrefix+key, value)
+	}
+	setLineageHeaders(ctx, request.Header)
+
+	delivery := recordDelivery(ctx, forwarder.targetUrl)
+
+	response, err := client.Do(request)
+	if err != nil {
+		delivery.fail(err)
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		err = fmt.Errorf("trigger responded with %d HTTP status code", response.StatusCode)
+		delivery.fail(err)
+		return err
+	}
+
+	delivery.acknowledge("")
+	return nil
+}
+
+// getHTTPClient returns the client for the target, which is recreated when the secrets have been updated so a
+// rotated client certificate is used
+func (forwarder *AppServiceForwarder) getHTTPClient(ctx interfaces.AppFunctionContext) (*http.Client, error) {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+
+	if forwarder.httpClient != nil &&
+		(forwarder.config.SecretPath == "" || !forwarder.secretsLastRetrieved.Before(ctx.SecretsLastUpdated())) {
+		return forwarder.httpClient, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if forwarder.config.SecretPath != "" {
+		tlsConfig, err := secure.NewClientTLSConfig(ctx, forwarder.config.SecretPath, forwarder.config.SkipCertVerify)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+		forwarder.secretsLastRetrieved = time.Now()
+	} else if forwarder.config.SkipCertVerify {
+		// nolint: gosec
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}
+	}
+
+	// The secrets have been updated, so close the connections made with the previous client certificate
+	if forwarder.httpClient != nil {
+		forwarder.httpClient.CloseIdleConnections()
+		ctx.LoggingClient().Infof("HTTP Client for %s in pipeline '%s' rebuilt with the updated secrets",
+			forwarder.config.TargetAddress, ctx.PipelineId())
+	}
+
+	forwarder.httpClient = &http.Client{Transport: transport}
+	return forwarder.httpClient, nil
+}
+
+func (forwarder *AppServiceForwarder) publish(ctx interfaces.AppFunctionContext, exportData []byte) error {
+	payload := exportData
+	if forwarder.config.MessageBus {
+		var err error
+		payload, err = json.Marshal(types.MessageEnvelope{
+			CorrelationID: ctx.CorrelationID(),
+			Payload:       exportData,
+			ContentType:   contentTypeOf(ctx),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if ok, result := forwarder.mqttSender.MQTTSend(ctx, payload); !ok {
+		if err, isError := result.(error); isError {
+			return err
+		}
+		return errors.New("MQTT publish failed")
+	}
+
+	return nil
+}
+
+// contentTypeOf returns the content type set by a previous function, or JSON as that is what util.CoerceTypeTo
+// marshals other types to when no content type is set
+func contentTypeOf(ctx interfaces.AppFunctionContext) string {
+	if contentType := ctx.ResponseContentType(); contentType != "" {
+		return contentType
+	}
+	return common.ContentTypeJSON
+}
@@ -0,0 +1,208 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCertificate creates a certificate signed by the parent, or a self-signed CA certificate when parent is nil
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestAppServiceForwarder_HTTP(t *testing.T) {
+	ca := newTestCertificate(t, "ca", nil)
+	serverCert := newTestCertificate(t, "site-server", ca)
+	clientCert := newTestCertificate(t, "gateway", ca)
+
+	mockSP := &mocks.SecretProvider{}
+	mockSP.On("GetSecret", "mtls").Return(map[string]string{
+		messaging.SecretClientCert: string(clientCert.certPEM),
+		messaging.SecretClientKey:  string(clientCert.keyPEM),
+		messaging.SecretCACert:     string(ca.certPEM),
+	}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	var received []byte
+	var receivedRequest *http.Request
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedRequest = request
+		received, _ = ioutil.ReadAll(request.Body)
+		if request.Header.Get(common.CorrelationHeader) == "fail" {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+
+	serverKeyPair, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverKeyPair},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	forwarder, err := NewAppServiceForwarder(AppServiceForwarderConfig{TargetAddress: server.URL, SecretPath: "mtls"})
+	require.NoError(t, err)
+
+	forwardCtx := appfunction.NewContext("123", dic, "")
+	forwardCtx.SetResponseContentType(common.ContentTypeCBOR)
+	continuePipeline, result := forwarder.Forward(forwardCtx, []byte("payload"))
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []byte("payload"), result, "data should be passed on")
+	assert.Equal(t, []byte("payload"), received)
+	assert.Equal(t, internal.ApiTriggerRoute, receivedRequest.URL.Path)
+	assert.Equal(t, common.ContentTypeCBOR, receivedRequest.Header.Get(common.ContentType))
+	assert.Equal(t, "123", receivedRequest.Header.Get(common.CorrelationHeader))
+	assert.Nil(t, forwardCtx.RetryData())
+
+	// The trigger failing to process the data
+	failCtx := appfunction.NewContext("fail", dic, "")
+	continuePipeline, result = forwarder.Forward(failCtx, "payload")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "500")
+	assert.Equal(t, []byte("payload"), failCtx.RetryData(), "data should be stored for retry")
+
+	// Without a client certificate the server refuses the connection
+	noClientCert, err := NewAppServiceForwarder(AppServiceForwarderConfig{TargetAddress: server.URL, SkipCertVerify: true})
+	require.NoError(t, err)
+	retryCtx := appfunction.NewContext("123", dic, "")
+	continuePipeline, result = noClientCert.Forward(retryCtx, "payload")
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Equal(t, []byte("payload"), retryCtx.RetryData())
+}
+
+func TestAppServiceForwarder_MQTT(t *testing.T) {
+	// Nothing listens on the port so the connection fails
+	forwarder, err := NewAppServiceForwarder(AppServiceForwarderConfig{
+		TargetAddress: "tcp://127.0.0.1:1",
+		Topic:         "edgex/events",
+		MessageBus:    true,
+	})
+	require.NoError(t, err)
+
+	forwardCtx := appfunction.NewContext("123", dic, "")
+	continuePipeline, result := forwarder.Forward(forwardCtx, []byte("payload"))
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Equal(t, []byte("payload"), forwardCtx.RetryData(), "data should be stored for retry without the envelope")
+}
+
+func TestAppServiceForwarder_Errors(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Config AppServiceForwarderConfig
+	}{
+		{"No scheme", AppServiceForwarderConfig{TargetAddress: "site-server:59700"}},
+		{"No host", AppServiceForwarderConfig{TargetAddress: "https://"}},
+		{"Mutual TLS over http", AppServiceForwarderConfig{TargetAddress: "http://site-server:59700", SecretPath: "mtls"}},
+		{"No MQTT topic", AppServiceForwarderConfig{TargetAddress: "mqtts://site-server:8883"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewAppServiceForwarder(test.Config)
+			assert.Error(t, err)
+		})
+	}
+
+	forwarder, err := NewAppServiceForwarder(AppServiceForwarderConfig{TargetAddress: "http://site-server:59700/api/v2/trigger"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://site-server:59700/api/v2/trigger", forwarder.targetUrl)
+
+	continuePipeline, result := forwarder.Forward(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
@@ -0,0 +1,516 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// GCPPrivateKeyKey is the key of the PEM encoded RSA or EC P-256 private key in the GCP secret
+	GCPPrivateKeyKey = "privatekey"
+	// GCPClientEmailKey is the key of the service account's email in the GCP secret
+	GCPClientEmailKey = "clientemail"
+	// GCPPrivateKeyIdKey is the key of the optional id of the service account's private key in the GCP secret
+	GCPPrivateKeyIdKey = "privatekeyid"
+
+	// DefaultGCPPubSubEndpoint is the Pub/Sub API used when no endpoint is given
+	DefaultGCPPubSubEndpoint = "https://pubsub.googleapis.com"
+	// DefaultGCPIoTCoreBroker is the IoT Core MQTT bridge used when no broker address is given
+	DefaultGCPIoTCoreBroker = "ssl://mqtt.googleapis.com:8883"
+	// DefaultGCPTokenLifetime is the lifetime of the JWTs when no lifetime is given
+	DefaultGCPTokenLifetime = time.Hour
+
+	gcpPubSubAudience = "https://pubsub.googleapis.com/"
+	// gcpTokenRenewal is how long before their expiry the JWTs are renewed
+	gcpTokenRenewal = 5 * time.Minute
+)
+
+// GCPPubSubConfig contains the configuration of a GCPPubSubSender
+type GCPPubSubConfig struct {
+	// ProjectId is the Google Cloud project of the topic
+	ProjectId string
+	// Topic is the name of the Pub/Sub topic, which may be a template, i.e. "readings-{profile}", resolved by the
+	// context's FormatTopic
+	Topic string
+	// OrderingKey is the optional ordering key of the messages, which may be a template, i.e. "{device}", so the
+	// messages with the same key are delivered in order to subscriptions with message ordering enabled
+	OrderingKey string
+	// SecretPath is the path of the service account's "privatekey" and "clientemail", and the optional "privatekeyid",
+	// secrets used to sign the JWT sent as the bearer token
+	SecretPath string
+	// Endpoint is the Pub/Sub API, DefaultGCPPubSubEndpoint when empty, i.e. the Pub/Sub emulator
+	Endpoint string
+	// PersistOnError enables use of store & forward when the publish fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// GCPPubSubSender publishes data to a Google Cloud Pub/Sub topic using the Pub/Sub REST API authenticated with a JWT
+// self-signed by the service account
+type GCPPubSubSender struct {
+	config GCPPubSubConfig
+	tokens *gcpTokenCache
+	client *http.Client
+}
+
+// gcpTokenCache holds the JWT signed with the secrets, which is renewed before it expires or when the secrets have been
+// updated
+type gcpTokenCache struct {
+	lock                 sync.Mutex
+	token                string
+	expiry               time.Time
+	secretsLastRetrieved time.Time
+	now                  func() time.Time
+}
+
+// NewGCPPubSubSender creates, initializes and returns a new instance of GCPPubSubSender
+func NewGCPPubSubSender(config GCPPubSubConfig) (*GCPPubSubSender, error) {
+	config.ProjectId = strings.TrimSpace(config.ProjectId)
+	config.Topic = strings.TrimSpace(config.Topic)
+	config.SecretPath = strings.TrimSpace(config.SecretPath)
+
+	if len(config.ProjectId) == 0 {
+		return nil, errors.New("project id must be set")
+	}
+	if len(config.Topic) == 0 {
+		return nil, errors.New("topic must be set")
+	}
+	if len(config.SecretPath) == 0 {
+		return nil, errors.New("secret path must be set")
+	}
+
+	config.Endpoint = strings.TrimRight(strings.TrimSpace(config.Endpoint), "/")
+	if len(config.Endpoint) == 0 {
+		config.Endpoint = DefaultGCPPubSubEndpoint
+	}
+
+	return &GCPPubSubSender{
+		config: config,
+		tokens: &gcpTokenCache{now: time.Now},
+		client: &http.Client{},
+	}, nil
+}
+
+// gcpPublishRequest is the body of the Pub/Sub publish request
+type gcpPublishRequest struct {
+	Messages []gcpPubSubMessage `json:"messages"`
+}
+
+type gcpPubSubMessage struct {
+	Data        string            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// gcpPublishResponse is the body of the Pub/Sub publish response
+type gcpPublishResponse struct {
+	MessageIds []string `json:"messageIds"`
+}
+
+// PublishToPubSub publishes the data from the previous function to the Pub/Sub topic, with the context's tags as the
+// message's attributes. The message id returned by Pub/Sub is the delivery receipt.
+// This function will return an error and stop the pipeline if no data is received or the publish fails.
+func (sender *GCPPubSubSender) PublishToPubSub(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function PublishToPubSub in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	topic, err := ctx.FormatTopic(sender.config.Topic)
+	if err != nil {
+		return false, fmt.Errorf("function PublishToPubSub in pipeline '%s': unable to format topic: %s", ctx.PipelineId(), err.Error())
+	}
+	topicPath := fmt.Sprintf("projects/%s/topics/%s", sender.config.ProjectId, topic)
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not publish %d bytes of data to %s", ctx.PipelineId(), len(exportData), topicPath)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, sender.config.CircuitBreaker, topicPath, sender.config.PersistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, topicPath)
+
+	messageId, err := sender.publish(ctx, topicPath, exportData)
+	if err != nil {
+		err = fmt.Errorf("function PublishToPubSub in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.config.CircuitBreaker.Failed()
+		sender.setRetryData(ctx, exportData)
+		return false, err
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(messageId)
+
+	ctx.LoggingClient().Debugf("Published data to %s in pipeline '%s' with message id %s", topicPath, ctx.PipelineId(), messageId)
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// publish sends the publish request and returns the message id
+func (sender *GCPPubSubSender) publish(ctx interfaces.AppFunctionContext, topicPath string, exportData []byte) (string, error) {
+	token, err := sender.tokens.get(ctx, sender.config.SecretPath, gcpPubSubAudience, DefaultGCPTokenLifetime, true)
+	if err != nil {
+		return "", err
+	}
+
+	message := gcpPubSubMessage{Data: base64.StdEncoding.EncodeToString(exportData)}
+	if tags := ctx.Tags(); len(tags) > 0 {
+		message.Attributes = tags
+	}
+	if len(sender.config.OrderingKey) > 0 {
+		if message.OrderingKey, err = ctx.FormatTopic(sender.config.OrderingKey); err != nil {
+			return "", fmt.Errorf("unable to format ordering key: %s", err.Error())
+		}
+	}
+
+	body, err := json.Marshal(gcpPublishRequest{Messages: []gcpPubSubMessage{message}})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/v1/%s:publish", sender.config.Endpoint, topicPath)
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", common.ContentTypeJSON)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := sender.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("Pub/Sub responded with %d HTTP status code: %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+
+	var published gcpPublishResponse
+	if err := json.Unmarshal(responseBody, &published); err != nil {
+		return "", fmt.Errorf("unable to parse Pub/Sub response: %s", err.Error())
+	}
+	if len(published.MessageIds) == 0 {
+		return "", errors.New("Pub/Sub response contains no message id")
+	}
+
+	return published.MessageIds[0], nil
+}
+
+func (sender *GCPPubSubSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
+	if sender.config.PersistOnError {
+		ctx.SetRetryData(exportData)
+	}
+}
+
+// GCPIoTCoreConfig contains the configuration of a GCPIoTCoreSender
+type GCPIoTCoreConfig struct {
+	// ProjectId, Region, RegistryId and DeviceId identify the IoT Core device publishing the telemetry events
+	ProjectId  string
+	Region     string
+	RegistryId string
+	DeviceId   string
+	// SecretPath is the path of the device's "privatekey" secret used to sign the JWT sent as the MQTT password
+	SecretPath string
+	// BrokerAddress is the MQTT bridge, DefaultGCPIoTCoreBroker when empty
+	BrokerAddress string
+	// SubFolder is the optional sub-folder of the telemetry events, which may be a template, i.e. "{profile}"
+	SubFolder string
+	// QoS of the publish, IoT Core supports 0 and 1
+	QoS byte
+	// KeepAlive and ConnectTimeout are the optional durations of the MQTT connection
+	KeepAlive      string
+	ConnectTimeout string
+	// TokenLifetime is the lifetime of the JWT, DefaultGCPTokenLifetime when 0. IoT Core closes the connection when the
+	// JWT expires, so the client reconnects with a new JWT.
+	TokenLifetime time.Duration
+	// PersistOnError enables use of store & forward when the connect or publish fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// GCPIoTCoreSender publishes telemetry events to the Google Cloud IoT Core MQTT bridge as a device, authenticated with
+// a JWT signed by the device's private key
+type GCPIoTCoreSender struct {
+	mqtt          *MQTTSecretSender
+	secretPath    string
+	projectId     string
+	tokenLifetime time.Duration
+	tokens        *gcpTokenCache
+	lock          sync.Mutex
+	// signingCtx is the context of the latest export, used to retrieve the private key when the client (re)connects
+	signingCtx interfaces.AppFunctionContext
+}
+
+// NewGCPIoTCoreSender creates, initializes and returns a new instance of GCPIoTCoreSender
+func NewGCPIoTCoreSender(config GCPIoTCoreConfig) (*GCPIoTCoreSender, error) {
+	for name, value := range map[string]string{
+		"project id":  config.ProjectId,
+		"region":      config.Region,
+		"registry id": config.RegistryId,
+		"device id":   config.DeviceId,
+		"secret path": config.SecretPath,
+	} {
+		if len(strings.TrimSpace(value)) == 0 {
+			return nil, fmt.Errorf("%s must be set", name)
+		}
+	}
+
+	if config.QoS > 1 {
+		return nil, fmt.Errorf("QoS %d not supported by IoT Core, must be 0 or 1", config.QoS)
+	}
+
+	if len(strings.TrimSpace(config.BrokerAddress)) == 0 {
+		config.BrokerAddress = DefaultGCPIoTCoreBroker
+	}
+
+	if config.TokenLifetime <= 0 {
+		config.TokenLifetime = DefaultGCPTokenLifetime
+	}
+
+	topic := fmt.Sprintf("/devices/%s/events", config.DeviceId)
+	if subFolder := strings.Trim(strings.TrimSpace(config.SubFolder), "/"); len(subFolder) > 0 {
+		topic += "/" + subFolder
+	}
+
+	mqttConfig := MQTTSecretConfig{
+		BrokerAddress: config.BrokerAddress,
+		ClientId: fmt.Sprintf("projects/%s/locations/%s/registries/%s/devices/%s",
+			config.ProjectId, config.Region, config.RegistryId, config.DeviceId),
+		// The JWT is the password, so the client reconnects with a new one when IoT Core closes the connection on expiry
+		AutoReconnect:  true,
+		KeepAlive:      config.KeepAlive,
+		ConnectTimeout: config.ConnectTimeout,
+		Topic:          topic,
+		QoS:            config.QoS,
+		AuthMode:       messaging.AuthModeNone,
+	}
+
+	sender := &GCPIoTCoreSender{
+		mqtt:          NewMQTTSecretSenderWithCircuitBreaker(mqttConfig, config.PersistOnError, config.CircuitBreaker),
+		secretPath:    config.SecretPath,
+		projectId:     config.ProjectId,
+		tokenLifetime: config.TokenLifetime,
+		tokens:        &gcpTokenCache{now: time.Now},
+	}
+
+	// IoT Core ignores the username, the JWT is checked against the device's public key
+	sender.mqtt.opts.SetCredentialsProvider(sender.credentials)
+
+	return sender, nil
+}
+
+// PublishToIoTCore publishes the data from the previous function to the device's telemetry events topic.
+// This function will return an error and stop the pipeline if no data is received, the JWT can't be signed or the
+// publish fails.
+func (sender *GCPIoTCoreSender) PublishToIoTCore(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function PublishToIoTCore in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	if !isShadowExportLogged(ctx) && !isNetworkOffline(ctx) {
+		// Sign the JWT now, so an invalid private key fails the export rather than the connect
+		if _, err := sender.tokens.get(ctx, sender.secretPath, sender.projectId, sender.tokenLifetime, false); err != nil {
+			exportData, coerceErr := util.CoerceType(data)
+			if coerceErr == nil {
+				sender.mqtt.setRetryData(ctx, exportData)
+			}
+			return false, fmt.Errorf("function PublishToIoTCore in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+
+		sender.lock.Lock()
+		sender.signingCtx = ctx
+		sender.lock.Unlock()
+	}
+
+	return sender.mqtt.MQTTSend(ctx, data)
+}
+
+// credentials returns the username and JWT password when the MQTT client (re)connects
+func (sender *GCPIoTCoreSender) credentials() (string, string) {
+	sender.lock.Lock()
+	ctx := sender.signingCtx
+	sender.lock.Unlock()
+
+	if ctx == nil {
+		return "unused", ""
+	}
+
+	token, err := sender.tokens.get(ctx, sender.secretPath, sender.projectId, sender.tokenLifetime, false)
+	if err != nil {
+		ctx.LoggingClient().Errorf("Unable to sign JWT for IoT Core: %s", err.Error())
+		return "unused", ""
+	}
+
+	return "unused", token
+}
+
+// get returns the cached JWT, signing a new one when it is about to expire or the secrets have been updated. The
+// service account's email is the issuer and subject of the Pub/Sub JWT, the IoT Core JWT has no issuer.
+func (cache *gcpTokenCache) get(ctx interfaces.AppFunctionContext, secretPath string, audience string,
+	lifetime time.Duration, serviceAccount bool) (string, error) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	now := cache.now()
+	if len(cache.token) > 0 && now.Add(gcpTokenRenewal).Before(cache.expiry) &&
+		!cache.secretsLastRetrieved.Before(ctx.SecretsLastUpdated()) {
+		return cache.token, nil
+	}
+
+	keys := []string{GCPPrivateKeyKey}
+	if serviceAccount {
+		keys = append(keys, GCPClientEmailKey)
+	}
+	secrets, err := ctx.GetSecret(secretPath, keys...)
+	if err != nil {
+		return "", err
+	}
+
+	claims := map[string]interface{}{
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(lifetime).Unix(),
+	}
+	if serviceAccount {
+		claims["iss"] = secrets[GCPClientEmailKey]
+		claims["sub"] = secrets[GCPClientEmailKey]
+	}
+
+	token, err := signJWT(secrets[GCPPrivateKeyKey], secrets[GCPPrivateKeyIdKey], claims)
+	if err != nil {
+		return "", fmt.Errorf("unable to sign JWT with secret at '%s': %s", secretPath, err.Error())
+	}
+
+	cache.token = token
+	cache.expiry = now.Add(lifetime)
+	cache.secretsLastRetrieved = now
+	return token, nil
+}
+
+// signJWT signs the claims with the PEM encoded RSA key, RS256, or EC P-256 key, ES256
+func signJWT(privateKey string, keyId string, claims map[string]interface{}) (string, error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return "", errors.New("private key isn't PEM encoded")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	header := map[string]string{"typ": "JWT"}
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		header["alg"] = "RS256"
+	case *ecdsa.PrivateKey:
+		header["alg"] = "ES256"
+	default:
+		return "", fmt.Errorf("unsupported private key type %T, must be RSA or EC", key)
+	}
+	if len(keyId) > 0 {
+		header["kid"] = keyId
+	}
+
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch signer := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		if signer.Curve.Params().BitSize != 256 {
+			return "", errors.New("EC private key must use the P-256 curve")
+		}
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, signer, digest[:])
+		if err == nil {
+			// JWS uses the fixed size concatenation of r and s rather than ASN.1
+			signature = make([]byte, 64)
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
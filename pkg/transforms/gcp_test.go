@@ -0,0 +1,249 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	mocks2 "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// decodeJWT returns the header, claims and signing input of the JWT
+func decodeJWT(t *testing.T, token string) (map[string]interface{}, map[string]interface{}, string, []byte) {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	var header, claims map[string]interface{}
+	for index, target := range []*map[string]interface{}{&header, &claims} {
+		decoded, err := base64.RawURLEncoding.DecodeString(parts[index])
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(decoded, target))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+
+	return header, claims, parts[0] + "." + parts[1], signature
+}
+
+func TestGCPPubSubSender_PublishToPubSub(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "gcp", GCPPrivateKeyKey, GCPClientEmailKey).Return(map[string]string{
+		GCPPrivateKeyKey:   string(keyPem),
+		GCPClientEmailKey:  "exporter@project.iam.gserviceaccount.com",
+		GCPPrivateKeyIdKey: "key-1",
+	}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	var path, authorization string
+	var published gcpPublishRequest
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		path = request.URL.Path
+		authorization = request.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(request.Body).Decode(&published))
+		_, _ = writer.Write([]byte(`{"messageIds": ["1234"]}`))
+	}))
+	defer ts.Close()
+
+	sender, err := NewGCPPubSubSender(GCPPubSubConfig{
+		ProjectId:   "project",
+		Topic:       "readings-{profile}",
+		OrderingKey: "{device}",
+		SecretPath:  "gcp",
+		Endpoint:    ts.URL,
+	})
+	require.NoError(t, err)
+
+	pubSubCtx := appfunction.NewContext("123", dic, "")
+	pubSubCtx.AddValue(interfaces.DEVICENAME, "thermostat")
+	pubSubCtx.AddValue(interfaces.PROFILENAME, "hvac")
+	pubSubCtx.SetTag("site", "houston")
+
+	continuePipeline, result := sender.PublishToPubSub(pubSubCtx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "/v1/projects/project/topics/readings-hvac:publish", path)
+	require.Len(t, published.Messages, 1)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(msgStr)), published.Messages[0].Data)
+	assert.Equal(t, "thermostat", published.Messages[0].OrderingKey)
+	assert.Equal(t, map[string]string{"site": "houston"}, published.Messages[0].Attributes)
+
+	require.True(t, strings.HasPrefix(authorization, "Bearer "))
+	header, claims, signingInput, signature := decodeJWT(t, strings.TrimPrefix(authorization, "Bearer "))
+	assert.Equal(t, "RS256", header["alg"])
+	assert.Equal(t, "key-1", header["kid"])
+	assert.Equal(t, gcpPubSubAudience, claims["aud"])
+	assert.Equal(t, "exporter@project.iam.gserviceaccount.com", claims["iss"])
+	digest := sha256.Sum256([]byte(signingInput))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+	continuePipeline, _ = sender.PublishToPubSub(pubSubCtx, msgStr)
+	require.True(t, continuePipeline)
+	assert.Equal(t, 2, requests)
+	mockSP.AssertNumberOfCalls(t, "GetSecret", 1)
+
+	continuePipeline, result = sender.PublishToPubSub(pubSubCtx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestGCPPubSubSender_PublishToPubSubPersistOnError(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "gcp", GCPPrivateKeyKey, GCPClientEmailKey).Return(map[string]string{
+		GCPPrivateKeyKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		GCPClientEmailKey: "exporter@project.iam.gserviceaccount.com",
+	}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	sender, err := NewGCPPubSubSender(GCPPubSubConfig{ProjectId: "project", Topic: "readings", SecretPath: "gcp",
+		Endpoint: ts.URL, PersistOnError: true})
+	require.NoError(t, err)
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := sender.PublishToPubSub(ctx, msgStr)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "403")
+	assert.Equal(t, []byte(msgStr), ctx.RetryData())
+	ctx.SetRetryData(nil)
+}
+
+func TestNewGCPPubSubSender(t *testing.T) {
+	_, err := NewGCPPubSubSender(GCPPubSubConfig{ProjectId: "project", Topic: "readings", SecretPath: "gcp"})
+	require.NoError(t, err)
+
+	_, err = NewGCPPubSubSender(GCPPubSubConfig{Topic: "readings", SecretPath: "gcp"})
+	assert.Error(t, err)
+	_, err = NewGCPPubSubSender(GCPPubSubConfig{ProjectId: "project", SecretPath: "gcp"})
+	assert.Error(t, err)
+	_, err = NewGCPPubSubSender(GCPPubSubConfig{ProjectId: "project", Topic: "readings"})
+	assert.Error(t, err)
+}
+
+func TestGCPIoTCoreSender(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "device", GCPPrivateKeyKey).Return(map[string]string{
+		GCPPrivateKeyKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})),
+	}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	_, err = NewGCPIoTCoreSender(GCPIoTCoreConfig{ProjectId: "project", Region: "us-central1", RegistryId: "registry"})
+	require.Error(t, err)
+	_, err = NewGCPIoTCoreSender(GCPIoTCoreConfig{ProjectId: "project", Region: "us-central1", RegistryId: "registry",
+		DeviceId: "gateway", SecretPath: "device", QoS: 2})
+	require.Error(t, err)
+
+	sender, err := NewGCPIoTCoreSender(GCPIoTCoreConfig{ProjectId: "project", Region: "us-central1", RegistryId: "registry",
+		DeviceId: "gateway", SecretPath: "device", SubFolder: "{profile}", QoS: 1, ConnectTimeout: "1s"})
+	require.NoError(t, err)
+	assert.Equal(t, "projects/project/locations/us-central1/registries/registry/devices/gateway", sender.mqtt.mqttConfig.ClientId)
+	assert.Equal(t, "/devices/gateway/events/{profile}", sender.mqtt.mqttConfig.Topic)
+	assert.Equal(t, DefaultGCPIoTCoreBroker, sender.mqtt.mqttConfig.BrokerAddress)
+
+	client := &fakeMQTTClient{connected: true, publishToken: &fakeToken{done: true}}
+	sender.mqtt.connection.client = client
+	sender.mqtt.connection.secretsLastRetrieved = time.Now()
+
+	iotCoreCtx := appfunction.NewContext("123", dic, "")
+	iotCoreCtx.AddValue(interfaces.PROFILENAME, "hvac")
+
+	continuePipeline, result := sender.PublishToIoTCore(iotCoreCtx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []byte(msgStr), client.published)
+
+	username, password := sender.credentials()
+	assert.Equal(t, "unused", username)
+	header, claims, signingInput, signature := decodeJWT(t, password)
+	assert.Equal(t, "ES256", header["alg"])
+	assert.Equal(t, "project", claims["aud"])
+	assert.NotContains(t, claims, "iss")
+	require.Len(t, signature, 64)
+	digest := sha256.Sum256([]byte(signingInput))
+	assert.True(t, ecdsa.Verify(&key.PublicKey, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])))
+
+	continuePipeline, result = sender.PublishToIoTCore(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestSignJWTInvalidKey(t *testing.T) {
+	_, err := signJWT("not a key", "", map[string]interface{}{})
+	assert.Error(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	_, err = signJWT(string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})), "", map[string]interface{}{})
+	assert.Error(t, err, "only P-256 keys should be supported")
+}
@@ -0,0 +1,201 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/hashchain"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// ChainedRecord is an exported Event linked into its device's hash chain, returned by ChainEvent
+type ChainedRecord struct {
+	DeviceName string `json:"deviceName"`
+	interfaces.ChainLink
+	// Algorithm is the algorithm of the Signature, empty when the chain isn't signed
+	Algorithm string `json:"algorithm,omitempty"`
+	// Signature is the signature of the link's Hash, Base64 encoded in JSON
+	Signature []byte `json:"signature,omitempty"`
+	// Payload is the Event as JSON, Base64 encoded in JSON
+	Payload []byte `json:"payload"`
+}
+
+// EventChainer links the Events exported from each device into a hash chain, optionally signing each link, so
+// downstream auditors can detect missing or altered Events. Requires HashChain to be enabled in the configuration.
+type EventChainer struct {
+	SecretPath string
+	SecretName string
+	Algorithm  string
+}
+
+// NewEventChainer creates, initializes and returns a new instance of EventChainer that doesn't sign the links
+func NewEventChainer() EventChainer {
+	return EventChainer{}
+}
+
+// NewSignedEventChainer creates, initializes and returns a new instance of EventChainer that signs the hash of each
+// link with the algorithm, SignatureAlgorithmHMAC or SignatureAlgorithmECDSA, using the key retrieved from the
+// Secret Store.
+func NewSignedEventChainer(secretPath string, secretName string, algorithm string) EventChainer {
+	return EventChainer{
+		SecretPath: secretPath,
+		SecretName: secretName,
+		Algorithm:  algorithm,
+	}
+}
+
+// ChainEvent links the Event into its device's hash chain and returns a ChainedRecord as JSON containing the Event,
+// so this should be the last function before the export. The chain's head is saved before the Event is exported, so
+// an Event whose export fails, and isn't retried by Store and Forward, shows as missing from the chain.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received,
+// HashChain isn't enabled or the link can't be saved or signed.
+func (chainer EventChainer) ChainEvent(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	chain := ctx.HashChain()
+	if chain == nil {
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s': HashChain is not enabled in the configuration", ctx.PipelineId())
+	}
+
+	var signFunc func(key string, payload []byte) ([]byte, error)
+	switch chainer.Algorithm {
+	case "":
+	case SignatureAlgorithmHMAC:
+		signFunc = signHMAC
+	case SignatureAlgorithmECDSA:
+		signFunc = signECDSA
+	default:
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s': invalid signature algorithm '%s'", ctx.PipelineId(), chainer.Algorithm)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s': unable to marshal Event: %s", ctx.PipelineId(), err.Error())
+	}
+
+	// Retrieve the key before linking so a missing key doesn't leave a gap in the chain
+	var key string
+	if signFunc != nil {
+		key, err = getSecretKey(ctx, chainer.SecretPath, chainer.SecretName)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	digest := sha256.Sum256(payload)
+	link, err := chain.Link(event.DeviceName, hex.EncodeToString(digest[:]))
+	if err != nil {
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	ctx.LoggingClient().Debugf("Event linked as record %d of device '%s' hash chain in pipeline '%s'", link.Sequence, event.DeviceName, ctx.PipelineId())
+
+	record := ChainedRecord{
+		DeviceName: event.DeviceName,
+		ChainLink:  link,
+		Payload:    payload,
+	}
+
+	if signFunc != nil {
+		record.Algorithm = chainer.Algorithm
+		record.Signature, err = signFunc(key, []byte(link.Hash))
+		if err != nil {
+			return false, fmt.Errorf("function ChainEvent in pipeline '%s': unable to sign link: %s", ctx.PipelineId(), err.Error())
+		}
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return false, fmt.Errorf("function ChainEvent in pipeline '%s': unable to marshal record: %s", ctx.PipelineId(), err.Error())
+	}
+
+	ctx.SetResponseContentType(common.ContentTypeJSON)
+
+	return true, encoded
+}
+
+// VerifyHashChain verifies the hash chains of the records received, for auditors to detect missing or altered
+// records. The records of each device are verified in sequence order, from the first record received, so a window of
+// a chain can be verified. Signatures are not verified, as that requires the signing key.
+// Returns an error describing the first break found in a chain.
+func VerifyHashChain(records []ChainedRecord) error {
+	chains := make(map[string][]ChainedRecord)
+	var deviceNames []string
+	for _, record := range records {
+		if _, found := chains[record.DeviceName]; !found {
+			deviceNames = append(deviceNames, record.DeviceName)
+		}
+		chains[record.DeviceName] = append(chains[record.DeviceName], record)
+	}
+
+	for _, deviceName := range deviceNames {
+		chain := chains[deviceName]
+		sort.SliceStable(chain, func(i, j int) bool {
+			return chain[i].Sequence < chain[j].Sequence
+		})
+
+		for index, record := range chain {
+			digest := sha256.Sum256(record.Payload)
+			if hex.EncodeToString(digest[:]) != record.PayloadHash {
+				return fmt.Errorf("record %d of device '%s' chain has been altered, payload hash doesn't match", record.Sequence, deviceName)
+			}
+
+			if hashchain.LinkHash(record.Sequence, record.PreviousHash, record.PayloadHash) != record.Hash {
+				return fmt.Errorf("record %d of device '%s' chain has been altered, link hash doesn't match", record.Sequence, deviceName)
+			}
+
+			if index == 0 {
+				continue
+			}
+
+			previous := chain[index-1]
+			if record.Sequence == previous.Sequence {
+				if record.Hash != previous.Hash {
+					return fmt.Errorf("record %d of device '%s' chain has been received with different hashes", record.Sequence, deviceName)
+				}
+				// Duplicate delivery of the same record
+				continue
+			}
+
+			if record.Sequence != previous.Sequence+1 {
+				return fmt.Errorf("records %d to %d of device '%s' chain are missing", previous.Sequence+1, record.Sequence-1, deviceName)
+			}
+
+			if record.PreviousHash != previous.Hash {
+				return fmt.Errorf("record %d of device '%s' chain doesn't link to record %d", record.Sequence, deviceName, previous.Sequence)
+			}
+		}
+	}
+
+	return nil
+}
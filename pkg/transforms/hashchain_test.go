@@ -0,0 +1,182 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/hashchain"
+	storeMocks "github.com/edgexfoundry/app-functions-sdk-go/v2/internal/store/db/interfaces/mocks"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+)
+
+func hashChainContext(t *testing.T) *mocks.AppFunctionContext {
+	heads := make(map[string]string)
+	storeClient := &storeMocks.StoreClient{}
+	storeClient.On("RetrieveChainHead", "app", mock.Anything).Return(
+		func(_ string, chainKey string) string { return heads[chainKey] }, nil)
+	storeClient.On("StoreChainHead", "app", mock.Anything, mock.Anything).Return(
+		func(_ string, chainKey string, head string) error {
+			heads[chainKey] = head
+			return nil
+		})
+
+	chain, err := hashchain.NewChain(storeClient, "app")
+	require.NoError(t, err)
+
+	mockCtx := &mocks.AppFunctionContext{}
+	mockCtx.On("PipelineId").Return("pipeline-id")
+	mockCtx.On("LoggingClient").Return(logger.NewMockClient())
+	mockCtx.On("SetResponseContentType", common.ContentTypeJSON).Return()
+	mockCtx.On("GetSecret", "signing", "key").Return(map[string]string{"key": "shared secret"}, nil)
+	mockCtx.On("HashChain").Return(chain)
+	return mockCtx
+}
+
+func chainEvent(t *testing.T, chainer EventChainer, ctx *mocks.AppFunctionContext, event dtos.Event) ChainedRecord {
+	continuePipeline, result := chainer.ChainEvent(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	var record ChainedRecord
+	require.NoError(t, json.Unmarshal(result.([]byte), &record))
+	return record
+}
+
+func TestEventChainer_ChainEvent(t *testing.T) {
+	mockCtx := hashChainContext(t)
+	chainer := NewEventChainer()
+
+	var records []ChainedRecord
+	for _, deviceName := range []string{"device-1", "device-2", "device-1", "device-1"} {
+		records = append(records, chainEvent(t, chainer, mockCtx, dtos.NewEvent("profile", deviceName, "source")))
+	}
+
+	assert.Equal(t, uint64(1), records[0].Sequence)
+	assert.Equal(t, uint64(1), records[1].Sequence, "each device should have its own chain")
+	assert.Equal(t, uint64(3), records[3].Sequence)
+	assert.Equal(t, records[2].Hash, records[3].PreviousHash)
+	assert.Empty(t, records[3].Signature)
+
+	var event dtos.Event
+	require.NoError(t, json.Unmarshal(records[3].Payload, &event))
+	assert.Equal(t, "device-1", event.DeviceName)
+
+	require.NoError(t, VerifyHashChain(records))
+}
+
+func TestEventChainer_ChainEventSigned(t *testing.T) {
+	mockCtx := hashChainContext(t)
+
+	record := chainEvent(t, NewSignedEventChainer("signing", "key", SignatureAlgorithmHMAC), mockCtx, dtos.NewEvent("profile", "device-1", "source"))
+
+	mac := hmac.New(sha256.New, []byte("shared secret"))
+	mac.Write([]byte(record.Hash))
+	assert.Equal(t, SignatureAlgorithmHMAC, record.Algorithm)
+	assert.Equal(t, mac.Sum(nil), record.Signature)
+
+	continuePipeline, result := NewSignedEventChainer("signing", "key", "RSA").ChainEvent(mockCtx, dtos.NewEvent("profile", "device-1", "source"))
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "invalid signature algorithm")
+}
+
+func TestEventChainer_ChainEventErrors(t *testing.T) {
+	notEnabled := &mocks.AppFunctionContext{}
+	notEnabled.On("PipelineId").Return("pipeline-id")
+	notEnabled.On("HashChain").Return(nil)
+
+	tests := []struct {
+		Name          string
+		Context       *mocks.AppFunctionContext
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", hashChainContext(t), nil, "No Data Received"},
+		{"Not an Event", hashChainContext(t), msgStr, "type received is not an Event"},
+		{"Not enabled", notEnabled, dtos.NewEvent("profile", "device-1", "source"), "HashChain is not enabled"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewEventChainer().ChainEvent(test.Context, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestVerifyHashChain(t *testing.T) {
+	mockCtx := hashChainContext(t)
+
+	var records []ChainedRecord
+	for i := 0; i < 4; i++ {
+		records = append(records, chainEvent(t, NewEventChainer(), mockCtx, dtos.NewEvent("profile", "device-1", "source")))
+	}
+
+	altered := append([]ChainedRecord{}, records...)
+	altered[1].Payload = []byte(`{"deviceName":"device-2"}`)
+
+	alteredLink := append([]ChainedRecord{}, records...)
+	alteredLink[1].PreviousHash = "tampered"
+
+	relinked := append([]ChainedRecord{}, records...)
+	relinked[2].PreviousHash = relinked[0].Hash
+	relinked[2].Hash = hashchain.LinkHash(relinked[2].Sequence, relinked[2].PreviousHash, relinked[2].PayloadHash)
+
+	forked := append([]ChainedRecord{}, records...)
+	forked[1].Sequence = 1
+	forked[1].Hash = hashchain.LinkHash(1, forked[1].PreviousHash, forked[1].PayloadHash)
+
+	tests := []struct {
+		Name          string
+		Records       []ChainedRecord
+		ExpectedError string
+	}{
+		{"Valid", records, ""},
+		{"Valid out of order", []ChainedRecord{records[2], records[0], records[3], records[1]}, ""},
+		{"Valid window", records[2:], ""},
+		{"Valid duplicate", append([]ChainedRecord{records[1]}, records...), ""},
+		{"Missing", []ChainedRecord{records[0], records[3]}, "records 2 to 3 of device 'device-1' chain are missing"},
+		{"Altered payload", altered, "record 2 of device 'device-1' chain has been altered, payload hash"},
+		{"Altered link", alteredLink, "record 2 of device 'device-1' chain has been altered, link hash"},
+		{"Relinked", relinked, "record 3 of device 'device-1' chain doesn't link to record 2"},
+		{"Forked", forked, "record 1 of device 'device-1' chain has been received with different hashes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := VerifyHashChain(test.Records)
+			if test.ExpectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
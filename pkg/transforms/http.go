@@ -18,17 +18,39 @@ package transforms
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/secure"
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
 
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
 )
 
+const (
+	HTTPAuthModeNone   = "none"
+	HTTPAuthModeBearer = "bearer"
+	HTTPAuthModeBasic  = "basic"
+
+	// HTTPAuthTokenKey is the key of the bearer token in the auth secret
+	HTTPAuthTokenKey = "token"
+	// HTTPAuthUsernameKey and HTTPAuthPasswordKey are the keys of the basic auth credentials in the auth secret
+	HTTPAuthUsernameKey = "username"
+	HTTPAuthPasswordKey = "password"
+)
+
+// placeholderSpec matches the placeholders in the URL and the values of the configured headers
+var placeholderSpec = regexp.MustCompile("{[^}]*}")
+
 // HTTPSender ...
 type HTTPSender struct {
 	url                 string
@@ -40,6 +62,22 @@ type HTTPSender struct {
 	secretName          string
 	secretPath          string
 	urlFormatter        StringValuesFormatter
+	receiptHeader       string
+	breaker             *CircuitBreaker
+	headers             map[string]string
+	authMode            string
+	authSecretPath      string
+	tlsSecretPath       string
+	skipCertVerify      bool
+	clients             *httpClientCache
+}
+
+// httpClientCache holds the client of a sender using TLS, which is recreated when the secrets have been updated so
+// a rotated CA or client certificate is used
+type httpClientCache struct {
+	lock                 sync.Mutex
+	client               *http.Client
+	secretsLastRetrieved time.Time
 }
 
 // NewHTTPSender creates, initializes and returns a new instance of HTTPSender
@@ -75,6 +113,14 @@ func NewHTTPSenderWithOptions(options HTTPSenderOptions) HTTPSender {
 		secretName:          options.SecretName,
 		secretPath:          options.SecretPath,
 		urlFormatter:        options.URLFormatter,
+		receiptHeader:       options.ReceiptHeader,
+		breaker:             options.CircuitBreaker,
+		headers:             options.Headers,
+		authMode:            strings.ToLower(strings.TrimSpace(options.AuthMode)),
+		authSecretPath:      options.AuthSecretPath,
+		tlsSecretPath:       options.TLSSecretPath,
+		skipCertVerify:      options.SkipCertVerify,
+		clients:             &httpClientCache{},
 	}
 }
 
@@ -94,24 +140,50 @@ type HTTPSenderOptions struct {
 	SecretName string
 	// URLFormatter specifies custom formatting behavior to be applied to configured URL.
 	// If nothing specified, default behavior is to attempt to replace placeholders in the
-	// form '{some-context-key}' with the values found in the context storage.
+	// form '{some-context-key}' with the values found in the context storage, i.e. "{deviceName}" or "{eventId}",
+	// or else the context's tags.
 	URLFormatter StringValuesFormatter
-	// ContinueOnSendError allows execution of subsequent chained senders after errors if true
+	// ContinueOnSendError allows execution of subsequent chained senders after errors if true. Can't be used with
+	// PersistOnError, so the data of a failed export is not stored for retry. An export skipped while the network is
+	// offline still stops the pipeline and is stored for retry.
 	ContinueOnSendError bool
 	// ReturnInputData enables chaining multiple HTTP senders if true
 	ReturnInputData bool
+	// ReceiptHeader is the optional response header containing the destination's receipt id for the data. When
+	// delivery receipts are tracked, a response without the header leaves the delivery unacknowledged. Otherwise
+	// any 2xx response acknowledges the delivery.
+	ReceiptHeader string
+	// CircuitBreaker optionally stops the exports to the destination after consecutive failures, persisting their
+	// data per PersistOnError while the circuit is open
+	CircuitBreaker *CircuitBreaker
+	// Headers are static headers sent with each request. Their values may contain placeholders, i.e. "{site}",
+	// replaced with the context's values or tags for each request.
+	Headers map[string]string
+	// AuthMode is HTTPAuthModeBearer or HTTPAuthModeBasic to send the Authorization header with the credentials read
+	// from the secret at AuthSecretPath, otherwise HTTPAuthModeNone or empty
+	AuthMode string
+	// AuthSecretPath is the path of the secret containing the HTTPAuthTokenKey value for bearer auth, or the
+	// HTTPAuthUsernameKey and HTTPAuthPasswordKey values for basic auth
+	AuthSecretPath string
+	// TLSSecretPath is the path of the secret containing the cacert value, used to verify the destination rather than
+	// the system's CAs, and optionally the clientcert and clientkey values, used for mutual TLS
+	TLSSecretPath string
+	// SkipCertVerify disables the verification of the destination's certificate
+	SkipCertVerify bool
 }
 
 // HTTPPost will send data from the previous function to the specified Endpoint via http POST.
 // If no previous function exists, then the event that triggered the pipeline will be used.
-// An empty string for the mimetype will default to application/json.
+// An empty string for the mimetype will default to application/json. Data other than []byte or string is encoded with
+// the codec registered for the mimetype, or as JSON when there is none.
 func (sender HTTPSender) HTTPPost(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
 	return sender.httpSend(ctx, data, http.MethodPost)
 }
 
 // HTTPPut will send data from the previous function to the specified Endpoint via http PUT.
 // If no previous function exists, then the event that triggered the pipeline will be used.
-// An empty string for the mimetype will default to application/json.
+// An empty string for the mimetype will default to application/json. Data other than []byte or string is encoded with
+// the codec registered for the mimetype, or as JSON when there is none.
 func (sender HTTPSender) HTTPPut(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
 	return sender.httpSend(ctx, data, http.MethodPut)
 }
@@ -138,18 +210,37 @@ func (sender HTTPSender) httpSend(ctx interfaces.AppFunctionContext, data interf
 		sender.mimeType = "application/json"
 	}
 
-	exportData, err := util.CoerceType(data)
+	exportData, err := util.CoerceTypeTo(sender.mimeType, data)
 	if err != nil {
 		return false, err
 	}
 
+	if isShadowExportLogged(ctx) {
+		lc.Infof("Shadow pipeline '%s' did not %s %d bytes of data to %s", ctx.PipelineId(), method, len(exportData), sender.url)
+		return true, data
+	}
+
+	if isNetworkOffline(ctx) {
+		// Offline mode always persists the export data so it is sent once connectivity returns. Store and Forward only
+		// retries the data of a pipeline that stopped with an error, so the pipeline stops even when continuing on
+		// send error, the retry executing the chained senders once connectivity returns.
+		ctx.SetRetryData(exportData)
+		return false, fmt.Errorf("export skipped in pipeline '%s': network is offline", ctx.PipelineId())
+	}
+
 	usingSecrets, err := sender.determineIfUsingSecrets(ctx)
 	if err != nil {
 		return false, err
 	}
 
-	formattedUrl, err := sender.urlFormatter.invoke(sender.url, ctx, data)
-
+	// The URL's placeholders, i.e. "{deviceName}" or "{eventId}", are replaced with the context's values or tags unless
+	// a URL formatter is given
+	var formattedUrl string
+	if sender.urlFormatter == nil {
+		formattedUrl, err = applyValuesAndTags(ctx, sender.url)
+	} else {
+		formattedUrl, err = sender.urlFormatter.invoke(sender.url, ctx, data)
+	}
 	if err != nil {
 		return false, err
 	}
@@ -160,8 +251,12 @@ func (sender HTTPSender) httpSend(ctx interfaces.AppFunctionContext, data interf
 		return false, err
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(method, parsedUrl.String(), bytes.NewReader(exportData))
+	client, err := sender.httpClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context(), method, parsedUrl.String(), bytes.NewReader(exportData))
 	if err != nil {
 		return false, err
 	}
@@ -181,10 +276,47 @@ func (sender HTTPSender) httpSend(ctx interfaces.AppFunctionContext, data interf
 		req.Header.Set(sender.httpHeaderName, theSecrets[sender.secretName])
 	}
 
+	if err := sender.setAuthHeader(ctx, req); err != nil {
+		return false, err
+	}
+
 	req.Header.Set("Content-Type", sender.mimeType)
 
+	for name, value := range sender.headers {
+		formatted, err := applyValuesAndTags(ctx, value)
+		if err != nil {
+			return false, fmt.Errorf("in pipeline '%s', unable to format HTTP Header '%s': %s", ctx.PipelineId(), name, err.Error())
+		}
+		req.Header.Set(name, formatted)
+	}
+
+	for key, value := range ctx.Tags() {
+		req.Header.Set(interfaces.TagHeaderPrefix+key, value)
+	}
+
+	setLineageHeaders(ctx, req.Header)
+
+	if signature, found := ctx.GetValue(interfaces.SIGNATURE); found {
+		algorithm, _ := ctx.GetValue(interfaces.SIGNATUREALGORITHM)
+		req.Header.Set(SignatureHeader, signature)
+		req.Header.Set(SignatureAlgorithmHeader, algorithm)
+	}
+
+	if !sender.breaker.Allow() {
+		err = fmt.Errorf("export skipped in pipeline '%s': circuit breaker open for %s", ctx.PipelineId(), sender.url)
+		if !sender.continueOnSendError {
+			sender.setRetryData(ctx, exportData)
+			return false, err
+		}
+
+		ctx.LoggingClient().Errorf("Continuing pipeline on error in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		return true, data
+	}
+
 	ctx.LoggingClient().Debugf("POSTing data to %s in pipeline '%s'", sender.url, ctx.PipelineId())
 
+	delivery := recordDelivery(ctx, parsedUrl.Scheme+"://"+parsedUrl.Host+parsedUrl.Path)
+
 	response, err := client.Do(req)
 	// Pipeline continues if we get a 2xx response, non-2xx response may stop pipeline
 	if err != nil || response.StatusCode < 200 || response.StatusCode >= 300 {
@@ -193,6 +325,8 @@ func (sender HTTPSender) httpSend(ctx interfaces.AppFunctionContext, data interf
 		} else {
 			err = fmt.Errorf("export failed in pipeline '%s': %s", ctx.PipelineId(), err.Error())
 		}
+		delivery.fail(err)
+		sender.breaker.Failed()
 
 		// If continuing on send error then can't be persisting on error since Store and Forward retries starting
 		// with the function that failed and stopped the execution of the pipeline.
@@ -209,6 +343,17 @@ func (sender HTTPSender) httpSend(ctx interfaces.AppFunctionContext, data interf
 		return true, data
 	}
 
+	sender.breaker.Succeeded()
+
+	if sender.receiptHeader == "" {
+		delivery.acknowledge("")
+	} else if receipt := response.Header.Get(sender.receiptHeader); receipt != "" {
+		delivery.acknowledge(receipt)
+	} else {
+		lc.Warnf("Response to export in pipeline '%s' has no '%s' receipt header, delivery not acknowledged",
+			ctx.PipelineId(), sender.receiptHeader)
+	}
+
 	ctx.LoggingClient().Debugf("Sent %d bytes of data in pipeline '%s'. Response status is %s", len(exportData), ctx.PipelineId(), response.Status)
 	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
 
@@ -255,8 +400,139 @@ func (sender HTTPSender) determineIfUsingSecrets(ctx interfaces.AppFunctionConte
 	return true, nil
 }
 
+// setAuthHeader sets the Authorization header with the credentials of the auth mode
+func (sender HTTPSender) setAuthHeader(ctx interfaces.AppFunctionContext, req *http.Request) error {
+	switch sender.authMode {
+	case "", HTTPAuthModeNone:
+		return nil
+	case HTTPAuthModeBearer, HTTPAuthModeBasic:
+	default:
+		return fmt.Errorf("in pipeline '%s', invalid HTTP auth mode '%s', must be '%s', '%s' or '%s'",
+			ctx.PipelineId(), sender.authMode, HTTPAuthModeNone, HTTPAuthModeBearer, HTTPAuthModeBasic)
+	}
+
+	if len(sender.authSecretPath) == 0 {
+		return fmt.Errorf("in pipeline '%s', auth secret path must be specified for '%s' HTTP auth", ctx.PipelineId(), sender.authMode)
+	}
+
+	if sender.authMode == HTTPAuthModeBearer {
+		secrets, err := ctx.GetSecret(sender.authSecretPath, HTTPAuthTokenKey)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+secrets[HTTPAuthTokenKey])
+		return nil
+	}
+
+	secrets, err := ctx.GetSecret(sender.authSecretPath, HTTPAuthUsernameKey, HTTPAuthPasswordKey)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(secrets[HTTPAuthUsernameKey], secrets[HTTPAuthPasswordKey])
+	return nil
+}
+
+// httpClient returns the client for the destination, which uses the CA and client certificate from the TLS secret
+func (sender HTTPSender) httpClient(ctx interfaces.AppFunctionContext) (*http.Client, error) {
+	if sender.clients == nil || (len(sender.tlsSecretPath) == 0 && !sender.skipCertVerify) {
+		return &http.Client{}, nil
+	}
+
+	sender.clients.lock.Lock()
+	defer sender.clients.lock.Unlock()
+
+	if sender.clients.client != nil &&
+		(len(sender.tlsSecretPath) == 0 || !sender.clients.secretsLastRetrieved.Before(ctx.SecretsLastUpdated())) {
+		return sender.clients.client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(sender.tlsSecretPath) > 0 {
+		secrets, err := ctx.GetSecret(sender.tlsSecretPath)
+		if err != nil {
+			return nil, err
+		}
+
+		var tlsConfig *tls.Config
+		if len(secrets[messaging.SecretClientCert]) > 0 {
+			tlsConfig, err = secure.NewClientTLSConfig(ctx, sender.tlsSecretPath, sender.skipCertVerify)
+		} else {
+			tlsConfig, err = secure.NewCATLSConfig(ctx, sender.tlsSecretPath, sender.skipCertVerify)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("in pipeline '%s', unable to create TLS configuration: %s", ctx.PipelineId(), err.Error())
+		}
+
+		transport.TLSClientConfig = tlsConfig
+		sender.clients.secretsLastRetrieved = time.Now()
+	} else {
+		// nolint: gosec
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}
+	}
+
+	// The secrets have been updated, so close the connections made with the previous certificates
+	if sender.clients.client != nil {
+		sender.clients.client.CloseIdleConnections()
+		ctx.LoggingClient().Infof("HTTP Client for %s in pipeline '%s' rebuilt with the updated secrets", sender.url, ctx.PipelineId())
+	}
+
+	sender.clients.client = &http.Client{Transport: transport}
+	return sender.clients.client, nil
+}
+
+// applyValuesAndTags replaces the placeholders of the URL or header value with the context's values or, when there is
+// no such value, the context's tags
+func applyValuesAndTags(ctx interfaces.AppFunctionContext, value string) (string, error) {
+	tags := ctx.Tags()
+	value = placeholderSpec.ReplaceAllStringFunc(value, func(placeholder string) string {
+		key := strings.TrimRight(strings.TrimLeft(placeholder, "{"), "}")
+		if _, found := ctx.GetValue(key); found {
+			return placeholder
+		}
+		if tag, found := tags[key]; found {
+			return tag
+		}
+		return placeholder
+	})
+
+	return ctx.ApplyValues(value)
+}
+
 func (sender HTTPSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
 	if sender.persistOnError {
 		ctx.SetRetryData(exportData)
 	}
 }
+
+// isShadowExportLogged returns true when executing a shadow pipeline whose exports are logged rather than sent.
+func isShadowExportLogged(ctx interfaces.AppFunctionContext) bool {
+	mode, ok := ctx.GetValue(interfaces.SHADOW)
+	return ok && mode == interfaces.ShadowModeLog
+}
+
+// isNetworkOffline returns true when Store and Forward OfflineMode has flagged the network as down.
+func isNetworkOffline(ctx interfaces.AppFunctionContext) bool {
+	offline, ok := ctx.GetValue(interfaces.NETWORKOFFLINE)
+	return ok && offline == "true"
+}
+
+// skipExport returns the error skipping the export when Store and Forward OfflineMode has flagged the network as down
+// or the destination's circuit breaker is open, nil when the export may be sent. The skipped export's data is stored
+// for retry with storeForRetry, always when offline so it is sent once connectivity returns, and only when
+// persistOnError is set when the circuit is open.
+func skipExport(ctx interfaces.AppFunctionContext, breaker *CircuitBreaker, destination string, persistOnError bool,
+	storeForRetry func()) error {
+	if isNetworkOffline(ctx) {
+		storeForRetry()
+		return fmt.Errorf("export skipped in pipeline '%s': network is offline", ctx.PipelineId())
+	}
+
+	if !breaker.Allow() {
+		if persistOnError {
+			storeForRetry()
+		}
+		return fmt.Errorf("export skipped in pipeline '%s': circuit breaker open for %s", ctx.PipelineId(), destination)
+	}
+
+	return nil
+}
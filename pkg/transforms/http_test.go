@@ -17,6 +17,9 @@
 package transforms
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"io"
 	"net/http"
@@ -25,13 +28,21 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
 	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
 
 	mocks2 "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/messaging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/receipts"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 )
 
 const (
@@ -283,3 +294,420 @@ func TestHTTPPutInvalidParameter(t *testing.T) {
 	assert.Equal(t, "marshaling input data to JSON failed, "+
 		"passed in data must be of type []byte, string, or support marshaling to JSON", result.(error).Error())
 }
+
+func TestHTTPPostShadowPipeline(t *testing.T) {
+	requestReceived := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requestReceived = true
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	tests := []struct {
+		Name             string
+		Mode             string
+		ExpectedReceived bool
+	}{
+		{"Logged", interfaces.ShadowModeLog, false},
+		{"Sent", interfaces.ShadowModeSend, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			requestReceived = false
+			shadowCtx := ctx.Clone().(*appfunction.Context)
+			shadowCtx.AddValue(interfaces.SHADOW, test.Mode)
+
+			sender := NewHTTPSenderWithOptions(HTTPSenderOptions{
+				URL:             ts.URL + path,
+				ReturnInputData: true,
+			})
+
+			continuePipeline, result := sender.HTTPPost(shadowCtx, msgStr)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, msgStr, result)
+			assert.Equal(t, test.ExpectedReceived, requestReceived)
+		})
+	}
+}
+
+func TestHTTPPostTagHeaders(t *testing.T) {
+	var receivedHeader http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		receivedHeader = r.Header
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	taggedCtx := appfunction.NewContext("123", dic, "")
+	taggedCtx.SetTag("GatewayId", "HoustonStore000123")
+
+	sender := NewHTTPSender(ts.URL+path, "", false)
+	continuePipeline, result := sender.HTTPPost(taggedCtx, msgStr)
+	require.True(t, continuePipeline, result)
+
+	require.NotNil(t, receivedHeader)
+	assert.Equal(t, "HoustonStore000123", receivedHeader.Get(interfaces.TagHeaderPrefix+"GatewayId"))
+}
+
+func TestHTTPPostURLTemplate(t *testing.T) {
+	var receivedPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	templateCtx := appfunction.NewContext("123", dic, "")
+	templateCtx.AddValue(interfaces.DEVICENAME, "thermostat")
+	templateCtx.AddValue(interfaces.EVENTID, "4b6ab4ef-5d7b-4b0e-9e6e-2a6d3e1b8a6c")
+	templateCtx.SetTag("site", "houston")
+
+	sender := NewHTTPSender(ts.URL+"/sites/{site}/devices/{deviceName}/events/{eventId}", "", false)
+	continuePipeline, result := sender.HTTPPost(templateCtx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "/sites/houston/devices/thermostat/events/4b6ab4ef-5d7b-4b0e-9e6e-2a6d3e1b8a6c", receivedPath)
+
+	sender = NewHTTPSender(ts.URL+"/devices/{deviceName}/{unknown}", "", false)
+	continuePipeline, _ = sender.HTTPPost(templateCtx, msgStr)
+	assert.False(t, continuePipeline, "unknown placeholder should fail the export")
+}
+
+func TestHTTPPostHeadersAndAuth(t *testing.T) {
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "bearer", HTTPAuthTokenKey).Return(map[string]string{HTTPAuthTokenKey: "secret-token"}, nil)
+	mockSP.On("GetSecret", "basic", HTTPAuthUsernameKey, HTTPAuthPasswordKey).
+		Return(map[string]string{HTTPAuthUsernameKey: "user", HTTPAuthPasswordKey: "pass"}, nil)
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	var receivedHeader http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeader = r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	headers := map[string]string{"X-Site": "{site}", "X-Device": "{devicename}", "X-Static": "value"}
+
+	tests := []struct {
+		Name                  string
+		AuthMode              string
+		AuthSecretPath        string
+		Headers               map[string]string
+		ExpectedAuthorization string
+		ExpectedError         string
+	}{
+		{"Headers", "", "", headers, "", ""},
+		{"Bearer", HTTPAuthModeBearer, "bearer", nil, "Bearer secret-token", ""},
+		{"Basic", "Basic", "basic", nil, "Basic dXNlcjpwYXNz", ""},
+		{"Unknown placeholder", "", "", map[string]string{"X-Region": "{region}"}, "", "X-Region"},
+		{"Invalid auth mode", "digest", "bearer", nil, "", "invalid HTTP auth mode"},
+		{"No auth secret path", HTTPAuthModeBearer, "", nil, "", "auth secret path"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			receivedHeader = nil
+			headersCtx := appfunction.NewContext("123", dic, "")
+			headersCtx.AddValue(interfaces.DEVICENAME, "thermostat")
+			headersCtx.SetTag("site", "houston")
+
+			sender := NewHTTPSenderWithOptions(HTTPSenderOptions{
+				URL:            ts.URL,
+				Headers:        test.Headers,
+				AuthMode:       test.AuthMode,
+				AuthSecretPath: test.AuthSecretPath,
+			})
+			continuePipeline, result := sender.HTTPPost(headersCtx, msgStr)
+
+			if test.ExpectedError != "" {
+				require.False(t, continuePipeline)
+				assert.Contains(t, result.(error).Error(), test.ExpectedError)
+				assert.Nil(t, receivedHeader, "request should not be sent")
+				return
+			}
+
+			require.True(t, continuePipeline, result)
+			require.NotNil(t, receivedHeader)
+			assert.Equal(t, test.ExpectedAuthorization, receivedHeader.Get("Authorization"))
+			if test.Headers != nil {
+				assert.Equal(t, "houston", receivedHeader.Get("X-Site"), "tag should replace the placeholder")
+				assert.Equal(t, "thermostat", receivedHeader.Get("X-Device"), "context value should replace the placeholder")
+				assert.Equal(t, "value", receivedHeader.Get("X-Static"))
+			}
+		})
+	}
+}
+
+func TestHTTPPostTLS(t *testing.T) {
+	ca := newTestCertificate(t, "ca", nil)
+	serverCert := newTestCertificate(t, "site-server", ca)
+	clientCert := newTestCertificate(t, "gateway", ca)
+
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "mtls").Return(map[string]string{
+		messaging.SecretClientCert: string(clientCert.certPEM),
+		messaging.SecretClientKey:  string(clientCert.keyPEM),
+		messaging.SecretCACert:     string(ca.certPEM),
+	}, nil)
+	mockSP.On("GetSecret", "ca").Return(map[string]string{messaging.SecretCACert: string(ca.certPEM)}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	serverKeyPair, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	for _, clientAuth := range []tls.ClientAuthType{tls.RequireAndVerifyClientCert, tls.NoClientCert} {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.TLS = &tls.Config{
+			Certificates: []tls.Certificate{serverKeyPair},
+			ClientCAs:    clientCAs,
+			ClientAuth:   clientAuth,
+			MinVersion:   tls.VersionTLS12,
+		}
+		server.StartTLS()
+
+		secretPath := "mtls"
+		if clientAuth == tls.NoClientCert {
+			secretPath = "ca"
+		}
+
+		sender := NewHTTPSenderWithOptions(HTTPSenderOptions{URL: server.URL, TLSSecretPath: secretPath})
+		continuePipeline, result := sender.HTTPPost(appfunction.NewContext("123", dic, ""), msgStr)
+		assert.True(t, continuePipeline, result)
+
+		// The server's certificate isn't issued by a system CA
+		sender = NewHTTPSenderWithOptions(HTTPSenderOptions{URL: server.URL})
+		continuePipeline, _ = sender.HTTPPost(appfunction.NewContext("123", dic, ""), msgStr)
+		assert.False(t, continuePipeline)
+
+		server.Close()
+	}
+}
+
+func TestHTTPPostSignature(t *testing.T) {
+	var received http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	signedCtx := ctx.Clone().(*appfunction.Context)
+	signedCtx.AddValue(interfaces.SIGNATURE, "c2lnbmF0dXJl")
+	signedCtx.AddValue(interfaces.SIGNATUREALGORITHM, SignatureAlgorithmHMAC)
+
+	sender := NewHTTPSenderWithOptions(HTTPSenderOptions{URL: ts.URL + path, ReturnInputData: true})
+
+	continuePipeline, result := sender.HTTPPost(signedCtx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "c2lnbmF0dXJl", received.Get(SignatureHeader))
+	assert.Equal(t, SignatureAlgorithmHMAC, received.Get(SignatureAlgorithmHeader))
+
+	continuePipeline, result = sender.HTTPPost(ctx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Empty(t, received.Get(SignatureHeader), "unsigned data should not have a signature header")
+}
+
+func TestHTTPPostNetworkOffline(t *testing.T) {
+	requestReceived := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requestReceived = true
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	tests := []struct {
+		Name                string
+		ContinueOnSendError bool
+	}{
+		{"Stores for retry", false},
+		{"Stores for retry when continuing on send error", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			requestReceived = false
+			offlineCtx := ctx.Clone().(*appfunction.Context)
+			offlineCtx.SetRetryData(nil)
+			offlineCtx.AddValue(interfaces.NETWORKOFFLINE, "true")
+
+			sender := NewHTTPSenderWithOptions(HTTPSenderOptions{
+				URL:                 ts.URL + path,
+				MimeType:            "",
+				ContinueOnSendError: test.ContinueOnSendError,
+				ReturnInputData:     test.ContinueOnSendError,
+			})
+
+			continuePipeline, result := sender.HTTPPost(offlineCtx, msgStr)
+
+			assert.False(t, requestReceived, "Export should not be attempted while offline")
+			assert.False(t, continuePipeline)
+			assert.Equal(t, []byte(msgStr), offlineCtx.RetryData(), "skipped export should be stored for retry")
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), "network is offline")
+		})
+	}
+}
+
+func TestSkipExport(t *testing.T) {
+	openBreaker := NewCircuitBreaker(1, time.Minute)
+	openBreaker.Failed()
+
+	tests := []struct {
+		Name           string
+		Offline        bool
+		Breaker        *CircuitBreaker
+		PersistOnError bool
+		ExpectedError  string
+		ExpectedStored bool
+	}{
+		{"Allowed", false, NewCircuitBreaker(1, time.Minute), true, "", false},
+		{"Allowed without breaker", false, nil, true, "", false},
+		{"Offline always stores", true, nil, false, "network is offline", true},
+		{"Circuit open stores", false, openBreaker, true, "circuit breaker open for dest", true},
+		{"Circuit open without persist", false, openBreaker, false, "circuit breaker open for dest", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			testCtx := ctx.Clone()
+			if test.Offline {
+				testCtx.AddValue(interfaces.NETWORKOFFLINE, "true")
+			}
+
+			stored := false
+			err := skipExport(testCtx, test.Breaker, "dest", test.PersistOnError, func() { stored = true })
+
+			assert.Equal(t, test.ExpectedStored, stored)
+			if test.ExpectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestHTTPPostDeliveryReceipts(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/receipt":
+			w.Header().Set("X-Receipt-Id", "r-1")
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	tracker, err := receipts.NewTracker(common.DeliveryReceiptsInfo{AckTimeout: "1ns"})
+	require.NoError(t, err)
+	dic.Update(di.ServiceConstructorMap{
+		container.DeliveryTrackerName: func(get di.Get) interface{} {
+			return tracker
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.DeliveryTrackerName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	tests := []struct {
+		Name                   string
+		Path                   string
+		ReceiptHeader          string
+		ExpectedAcknowledged   uint64
+		ExpectedFailed         uint64
+		ExpectedUnacknowledged uint64
+		ExpectedReceipt        string
+	}{
+		{"Acknowledged by status", "/status", "", 1, 0, 0, ""},
+		{"Acknowledged by receipt", "/receipt", "X-Receipt-Id", 1, 0, 0, "r-1"},
+		{"Missing receipt", "/noreceipt", "X-Receipt-Id", 0, 0, 1, ""},
+		{"Failed", "/fail", "", 0, 1, 0, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			sender := NewHTTPSenderWithOptions(HTTPSenderOptions{
+				URL:             ts.URL + test.Path,
+				ReturnInputData: true,
+				ReceiptHeader:   test.ReceiptHeader,
+			})
+			sender.HTTPPost(appfunction.NewContext(test.Name, dic, ""), msgStr)
+
+			destinations, overdue := tracker.Report()
+			var stats *receipts.DestinationStats
+			for index := range destinations {
+				if destinations[index].Destination == ts.URL+test.Path {
+					stats = &destinations[index]
+				}
+			}
+			require.NotNil(t, stats)
+			assert.Equal(t, test.ExpectedAcknowledged, stats.Acknowledged)
+			assert.Equal(t, test.ExpectedFailed, stats.Failed)
+			assert.Equal(t, test.ExpectedUnacknowledged, stats.Unacknowledged)
+			assert.Equal(t, test.ExpectedReceipt, stats.LastReceipt)
+			if test.ExpectedUnacknowledged > 0 {
+				require.Len(t, overdue, 1)
+				assert.Equal(t, test.Name, overdue[0].CorrelationId)
+			}
+		})
+	}
+}
+
+func TestHTTPPostCancelled(t *testing.T) {
+	requestReceived := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requestReceived = true
+		w.WriteHeader(http.StatusOK)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	shutdownCtx := ctx.Clone().(*appfunction.Context)
+	shutdownCtx.SetRetryData(nil)
+	shutdownCtx.SetContext(cancelledCtx)
+
+	sender := NewHTTPSenderWithOptions(HTTPSenderOptions{
+		URL:            ts.URL + path,
+		PersistOnError: true,
+	})
+
+	continuePipeline, result := sender.HTTPPost(shutdownCtx, msgStr)
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), context.Canceled.Error())
+	assert.False(t, requestReceived, "Export should not be sent once the execution is cancelled")
+	assert.Equal(t, []byte(msgStr), shutdownCtx.RetryData(), "Cancelled export should be stored for retry")
+}
@@ -0,0 +1,358 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// HTTPBatchFormatJSON sends the batch as a JSON array
+	HTTPBatchFormatJSON = "json"
+	// HTTPBatchFormatNDJSON sends the batch as newline delimited JSON, one item per line
+	HTTPBatchFormatNDJSON = "ndjson"
+
+	// ContentTypeNDJSON is the content type of a batch sent as newline delimited JSON
+	ContentTypeNDJSON = "application/x-ndjson"
+
+	// DefaultBatchRetries is the number of times a refused batch is retried when no number is given
+	DefaultBatchRetries = 3
+	// DefaultMaxRetryAfter is the longest Retry-After waited for when no maximum is given
+	DefaultMaxRetryAfter = time.Minute
+)
+
+// HTTPBatchOptions contains the options of an HTTPBatchSender
+type HTTPBatchOptions struct {
+	// HTTPSenderOptions are the destination's URL, headers, auth, TLS and circuit breaker, and whether the batch is
+	// stored for retry on error. The MimeType, ContinueOnSendError and ReturnInputData options aren't used.
+	HTTPSenderOptions
+	// Format is HTTPBatchFormatJSON, the default, or HTTPBatchFormatNDJSON
+	Format string
+	// Compress enables gzip encoding of the request body
+	Compress bool
+	// Retries is the number of times a batch refused with a 429 or 503 status and a Retry-After header is sent again
+	// after the delay, DefaultBatchRetries when 0 and no retries when negative
+	Retries int
+	// MaxRetryAfter is the longest Retry-After delay waited for, a longer delay fails the export so the batch is
+	// stored for retry. DefaultMaxRetryAfter when 0.
+	MaxRetryAfter time.Duration
+}
+
+// HTTPBatchSender exports a batch of data, i.e. from the Batch function, in a single HTTP POST request
+type HTTPBatchSender struct {
+	sender        HTTPSender
+	format        string
+	compress      bool
+	retries       int
+	maxRetryAfter time.Duration
+	sleep         func(time.Duration)
+}
+
+// NewHTTPBatchSender creates, initializes and returns a new instance of HTTPBatchSender
+func NewHTTPBatchSender(options HTTPBatchOptions) (*HTTPBatchSender, error) {
+	if len(strings.TrimSpace(options.URL)) == 0 {
+		return nil, errors.New("URL must be set")
+	}
+
+	format := strings.ToLower(strings.TrimSpace(options.Format))
+	switch format {
+	case "":
+		format = HTTPBatchFormatJSON
+	case HTTPBatchFormatJSON, HTTPBatchFormatNDJSON:
+	default:
+		return nil, fmt.Errorf("invalid format '%s', must be '%s' or '%s'", options.Format, HTTPBatchFormatJSON, HTTPBatchFormatNDJSON)
+	}
+
+	retries := options.Retries
+	if retries == 0 {
+		retries = DefaultBatchRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+
+	if options.MaxRetryAfter < 0 {
+		return nil, errors.New("max Retry-After can not be negative")
+	}
+	maxRetryAfter := options.MaxRetryAfter
+	if maxRetryAfter == 0 {
+		maxRetryAfter = DefaultMaxRetryAfter
+	}
+
+	senderOptions := options.HTTPSenderOptions
+	senderOptions.MimeType = common.ContentTypeJSON
+	if format == HTTPBatchFormatNDJSON {
+		senderOptions.MimeType = ContentTypeNDJSON
+	}
+	senderOptions.ContinueOnSendError = false
+	senderOptions.ReturnInputData = false
+
+	return &HTTPBatchSender{
+		sender:        NewHTTPSenderWithOptions(senderOptions),
+		format:        format,
+		compress:      options.Compress,
+		retries:       retries,
+		maxRetryAfter: maxRetryAfter,
+		sleep:         time.Sleep,
+	}, nil
+}
+
+// HTTPBatchPost combines the items of the batch from the previous function, a slice such as the [][]byte or
+// []dtos.Event returned by Batch, into a single request body and sends it to the destination via http POST. Data that
+// isn't a slice, i.e. a batch stored for retry, is sent as the body as is. The whole batch is stored for retry on error
+// when PersistOnError is set.
+// This function will return an error and stop the pipeline if no data is received, the items aren't JSON or the
+// export fails.
+func (batchSender *HTTPBatchSender) HTTPBatchPost(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	sender := batchSender.sender
+	lc := ctx.LoggingClient()
+
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function HTTPBatchPost in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	body, count, err := combineBatch(data, batchSender.format)
+	if err != nil {
+		return false, fmt.Errorf("function HTTPBatchPost in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if isShadowExportLogged(ctx) {
+		lc.Infof("Shadow pipeline '%s' did not POST batch of %d bytes of data to %s", ctx.PipelineId(), len(body), sender.url)
+		return true, data
+	}
+
+	if err := skipExport(ctx, sender.breaker, sender.url, sender.persistOnError, func() { ctx.SetRetryData(body) }); err != nil {
+		return false, err
+	}
+
+	content := body
+	if batchSender.compress {
+		if content, err = gzipBody(body); err != nil {
+			return false, fmt.Errorf("function HTTPBatchPost in pipeline '%s': unable to compress batch: %s", ctx.PipelineId(), err.Error())
+		}
+	}
+
+	formattedUrl, err := applyValuesAndTags(ctx, sender.url)
+	if err != nil {
+		return false, err
+	}
+
+	parsedUrl, err := url.Parse(formattedUrl)
+	if err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, parsedUrl.Scheme+"://"+parsedUrl.Host+parsedUrl.Path)
+
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = batchSender.post(ctx, parsedUrl.String(), content)
+		if err == nil || retryAfter == 0 || attempt == batchSender.retries {
+			break
+		}
+
+		lc.Debugf("Destination refused batch in pipeline '%s', retrying after %s: %s", ctx.PipelineId(), retryAfter.String(), err.Error())
+		batchSender.sleep(retryAfter)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("batch export failed in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.breaker.Failed()
+		sender.setRetryData(ctx, body)
+		return false, err
+	}
+
+	sender.breaker.Succeeded()
+	delivery.acknowledge("")
+
+	lc.Debugf("Sent batch of %d items, %d bytes, in pipeline '%s'", count, len(content), ctx.PipelineId())
+	lc.Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// post sends the request, returning the Retry-After delay when the destination refused it with a 429 or 503 status
+// and a delay no longer than the max Retry-After
+func (batchSender *HTTPBatchSender) post(ctx interfaces.AppFunctionContext, url string, content []byte) (time.Duration, error) {
+	sender := batchSender.sender
+
+	client, err := sender.httpClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPost, url, bytes.NewReader(content))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := sender.setAuthHeader(ctx, req); err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", sender.mimeType)
+	if batchSender.compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	for name, value := range sender.headers {
+		formatted, err := applyValuesAndTags(ctx, value)
+		if err != nil {
+			return 0, fmt.Errorf("unable to format HTTP Header '%s': %s", name, err.Error())
+		}
+		req.Header.Set(name, formatted)
+	}
+
+	for key, value := range ctx.Tags() {
+		req.Header.Set(interfaces.TagHeaderPrefix+key, value)
+	}
+
+	setLineageHeaders(ctx, req.Header)
+
+	response, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = response.Body.Close() }()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("destination responded with %d HTTP status code", response.StatusCode)
+	if response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusServiceUnavailable {
+		return 0, err
+	}
+
+	retryAfter, found := parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+	if !found || retryAfter > batchSender.maxRetryAfter {
+		return 0, err
+	}
+
+	// A delay of 0 is still retried
+	if retryAfter == 0 {
+		retryAfter = time.Millisecond
+	}
+
+	return retryAfter, err
+}
+
+// combineBatch combines the items of the batch into a JSON array or NDJSON, returning the number of items. Data that
+// isn't a slice, i.e. a batch stored for retry, is returned as is.
+func combineBatch(data interface{}, format string) ([]byte, int, error) {
+	switch batch := data.(type) {
+	case []byte:
+		return batch, 1, nil
+	case string:
+		return []byte(batch), 1, nil
+	}
+
+	items := reflect.ValueOf(data)
+	if items.Kind() != reflect.Slice {
+		return nil, 0, fmt.Errorf("expected a batch of items, got %T", data)
+	}
+
+	var body bytes.Buffer
+	if format == HTTPBatchFormatJSON {
+		body.WriteByte('[')
+	}
+
+	for index := 0; index < items.Len(); index++ {
+		item, err := util.CoerceType(items.Index(index).Interface())
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to encode batch item %d: %s", index, err.Error())
+		}
+
+		// The NDJSON items must be on a single line
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, item); err != nil {
+			return nil, 0, fmt.Errorf("batch item %d isn't JSON: %s", index, err.Error())
+		}
+
+		if format == HTTPBatchFormatJSON {
+			if index > 0 {
+				body.WriteByte(',')
+			}
+			body.Write(compacted.Bytes())
+		} else {
+			body.Write(compacted.Bytes())
+			body.WriteByte('\n')
+		}
+	}
+
+	if format == HTTPBatchFormatJSON {
+		body.WriteByte(']')
+	}
+
+	return body.Bytes(), items.Len(), nil
+}
+
+// gzipBody returns the gzip encoded body
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseRetryAfter parses the Retry-After header, either a number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
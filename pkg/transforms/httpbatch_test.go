@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPBatchSender(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Options     HTTPBatchOptions
+		ExpectError bool
+	}{
+		{"Defaults", HTTPBatchOptions{HTTPSenderOptions: HTTPSenderOptions{URL: "http://localhost"}}, false},
+		{"NDJSON", HTTPBatchOptions{HTTPSenderOptions: HTTPSenderOptions{URL: "http://localhost"}, Format: "NDJSON", Compress: true}, false},
+		{"No URL", HTTPBatchOptions{}, true},
+		{"Bad format", HTTPBatchOptions{HTTPSenderOptions: HTTPSenderOptions{URL: "http://localhost"}, Format: "csv"}, true},
+		{"Negative max Retry-After", HTTPBatchOptions{HTTPSenderOptions: HTTPSenderOptions{URL: "http://localhost"}, MaxRetryAfter: -time.Second}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewHTTPBatchSender(test.Options)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestHTTPBatchPost(t *testing.T) {
+	batch := [][]byte{[]byte(`{"id": 1}`), []byte("{\n\"id\": 2\n}")}
+
+	tests := []struct {
+		Name         string
+		Format       string
+		Compress     bool
+		ExpectedType string
+		ExpectedBody string
+	}{
+		{"JSON array", HTTPBatchFormatJSON, false, "application/json", `[{"id":1},{"id":2}]`},
+		{"NDJSON", HTTPBatchFormatNDJSON, false, ContentTypeNDJSON, "{\"id\":1}\n{\"id\":2}\n"},
+		{"Gzip", HTTPBatchFormatJSON, true, "application/json", `[{"id":1},{"id":2}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			var contentType, body string
+			ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				contentType = request.Header.Get("Content-Type")
+
+				var reader io.Reader = request.Body
+				if test.Compress {
+					assert.Equal(t, "gzip", request.Header.Get("Content-Encoding"))
+					gzipReader, err := gzip.NewReader(request.Body)
+					require.NoError(t, err)
+					reader = gzipReader
+				}
+
+				content, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				body = string(content)
+			}))
+			defer ts.Close()
+
+			sender, err := NewHTTPBatchSender(HTTPBatchOptions{
+				HTTPSenderOptions: HTTPSenderOptions{URL: ts.URL},
+				Format:            test.Format,
+				Compress:          test.Compress,
+			})
+			require.NoError(t, err)
+
+			continuePipeline, result := sender.HTTPBatchPost(ctx, batch)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, test.ExpectedType, contentType)
+			assert.Equal(t, test.ExpectedBody, body)
+		})
+	}
+}
+
+func TestHTTPBatchPostRetryAfter(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		if requests == 1 {
+			writer.Header().Set("Retry-After", "5")
+			writer.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer ts.Close()
+
+	sender, err := NewHTTPBatchSender(HTTPBatchOptions{HTTPSenderOptions: HTTPSenderOptions{URL: ts.URL}})
+	require.NoError(t, err)
+
+	var delays []time.Duration
+	sender.sleep = func(delay time.Duration) { delays = append(delays, delay) }
+
+	continuePipeline, result := sender.HTTPBatchPost(ctx, []string{`"a"`, `"b"`})
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []time.Duration{5 * time.Second}, delays)
+}
+
+func TestHTTPBatchPostPersistOnError(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		writer.Header().Set("Retry-After", "120")
+		writer.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	sender, err := NewHTTPBatchSender(HTTPBatchOptions{
+		HTTPSenderOptions: HTTPSenderOptions{URL: ts.URL, PersistOnError: true},
+		Compress:          true,
+	})
+	require.NoError(t, err)
+	sender.sleep = func(time.Duration) { t.Fatal("Retry-After beyond the maximum should not be waited for") }
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := sender.HTTPBatchPost(ctx, [][]byte{[]byte(`{"id":1}`), []byte(`{"id":2}`)})
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "503")
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, []byte(`[{"id":1},{"id":2}]`), ctx.RetryData(), "whole uncompressed batch should be stored for retry")
+
+	stored := ctx.RetryData()
+	ctx.SetRetryData(nil)
+	continuePipeline, _ = sender.HTTPBatchPost(ctx, stored)
+	require.False(t, continuePipeline)
+	assert.Equal(t, stored, ctx.RetryData(), "stored batch should be sent as is when retried")
+	ctx.SetRetryData(nil)
+
+	continuePipeline, result = sender.HTTPBatchPost(ctx, []string{"not json"})
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "isn't JSON")
+
+	continuePipeline, result = sender.HTTPBatchPost(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 8, 24, 12, 0, 0, 0, time.UTC)
+
+	delay, found := parseRetryAfter("30", now)
+	require.True(t, found)
+	assert.Equal(t, 30*time.Second, delay)
+
+	delay, found = parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+	require.True(t, found)
+	assert.Equal(t, time.Minute, delay)
+
+	delay, found = parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now)
+	require.True(t, found)
+	assert.Equal(t, time.Duration(0), delay)
+
+	_, found = parseRetryAfter("", now)
+	assert.False(t, found)
+	_, found = parseRetryAfter("soon", now)
+	assert.False(t, found)
+}
@@ -0,0 +1,190 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// hybridKeySize is the size of the random AES-256 key each message is encrypted with
+const hybridKeySize = 32
+
+// HybridProtection encrypts data to a recipient's public key, so only the holder of the private key can decrypt it.
+// Each message is encrypted with AES-256-GCM using a random key, which is itself encrypted with the recipient's
+// RSA public key using RSA-OAEP with SHA-256, or agreed with the recipient's elliptic curve public key using ECIES.
+type HybridProtection struct {
+	PublicKey  string
+	SecretPath string
+	SecretName string
+}
+
+// NewHybridProtection creates, initializes and returns a new instance of HybridProtection encrypting to the
+// PEM encoded RSA or elliptic curve public key.
+func NewHybridProtection(publicKey string) HybridProtection {
+	return HybridProtection{
+		PublicKey: publicKey,
+	}
+}
+
+// NewHybridProtectionWithSecrets creates, initializes and returns a new instance of HybridProtection configured
+// to retrieve the PEM encoded RSA or elliptic curve public key from the Secret Store
+func NewHybridProtectionWithSecrets(secretPath string, secretName string) HybridProtection {
+	return HybridProtection{
+		SecretPath: secretPath,
+		SecretName: secretName,
+	}
+}
+
+// Encrypt encrypts a string, []byte, or json.Marshaller type to the recipient's public key.
+// It will return a Base64 encoded []byte of the encrypted key followed by the AES-GCM nonce, encrypted data and
+// authentication tag. For an RSA public key the encrypted key is the RSA-OAEP encryption of the AES key, the size of
+// the key's modulus. For an elliptic curve public key it is the uncompressed ephemeral public key, and the AES key is
+// derived from the shared secret using the ANSI X9.63 KDF with SHA-256 and the ephemeral public key as shared info.
+func (protection HybridProtection) Encrypt(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		return false, fmt.Errorf("function Encrypt in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Encrypting with public key in pipeline '%s'", ctx.PipelineId())
+
+	byteData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	publicKey, err := protection.getPublicKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var encryptedKey, key []byte
+	switch recipient := publicKey.(type) {
+	case *rsa.PublicKey:
+		key = make([]byte, hybridKeySize)
+		if _, err = rand.Read(key); err != nil {
+			return false, fmt.Errorf("function Encrypt in pipeline '%s': failed to generate key: %s", ctx.PipelineId(), err.Error())
+		}
+
+		encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, recipient, key, nil)
+	case *ecdsa.PublicKey:
+		encryptedKey, key, err = agreeECIESKey(recipient)
+	default:
+		err = fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("function Encrypt in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+	defer clearKey(key)
+
+	encrypted, err := sealAESGCM(key, byteData)
+	if err != nil {
+		return false, fmt.Errorf("function Encrypt in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	// Set response "content-type" header to "text/plain"
+	ctx.SetResponseContentType(common.ContentTypeText)
+
+	return true, []byte(base64.StdEncoding.EncodeToString(append(encryptedKey, encrypted...)))
+}
+
+func (protection HybridProtection) getPublicKey(ctx interfaces.AppFunctionContext) (interface{}, error) {
+	encodedKey := protection.PublicKey
+
+	// If using Secret Store for the public key
+	if len(protection.SecretPath) != 0 && len(protection.SecretName) != 0 {
+		var err error
+		encodedKey, err = getSecretKey(ctx, protection.SecretPath, protection.SecretName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(encodedKey) == 0 {
+		return nil, fmt.Errorf("public key not set in pipeline '%s'", ctx.PipelineId())
+	}
+
+	publicKey, err := parsePublicKey([]byte(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	return publicKey, nil
+}
+
+// parsePublicKey parses a PEM encoded PKIX public key, or PKCS #1 RSA public key
+func parsePublicKey(encodedKey []byte) (interface{}, error) {
+	block, _ := pem.Decode(encodedKey)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// agreeECIESKey generates an ephemeral key pair on the recipient's curve and returns the ephemeral public key and
+// the AES key derived from the secret shared with the recipient
+func agreeECIESKey(recipient *ecdsa.PublicKey) ([]byte, []byte, error) {
+	curve := recipient.Curve
+	private, x, y, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate ephemeral key: %s", err.Error())
+	}
+	defer clearKey(private)
+
+	ephemeralKey := elliptic.Marshal(curve, x, y)
+	sharedX, _ := curve.ScalarMult(recipient.X, recipient.Y, private)
+
+	return ephemeralKey, deriveECIESKey(curve, sharedX, ephemeralKey), nil
+}
+
+// deriveECIESKey derives the AES key from the shared secret using the ANSI X9.63 KDF with SHA-256, which needs a
+// single round for the key size
+func deriveECIESKey(curve elliptic.Curve, sharedX *big.Int, sharedInfo []byte) []byte {
+	secret := make([]byte, (curve.Params().BitSize+7)/8)
+	sharedX.FillBytes(secret)
+	defer clearKey(secret)
+
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, 1)
+
+	hash := sha256.New()
+	hash.Write(secret)
+	hash.Write(counter)
+	hash.Write(sharedInfo)
+	return hash.Sum(nil)
+}
@@ -0,0 +1,119 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+)
+
+func encodePublicKey(t *testing.T, publicKey interface{}) string {
+	encoded, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: encoded}))
+}
+
+func TestHybridProtection_EncryptRSA(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&private.PublicKey)}))
+
+	for name, publicKey := range map[string]string{"PKIX": encodePublicKey(t, &private.PublicKey), "PKCS1": pkcs1} {
+		t.Run(name, func(t *testing.T) {
+			continuePipeline, result := NewHybridProtection(publicKey).Encrypt(ctx, plainString)
+			require.True(t, continuePipeline, result)
+
+			encrypted := decodeEncrypted(t, result)
+			keySize := private.PublicKey.Size()
+			key, err := rsa.DecryptOAEP(sha256.New(), nil, private, encrypted[:keySize], nil)
+			require.NoError(t, err)
+			assert.Equal(t, plainString, string(decryptAESGCM(t, key, encrypted[keySize:])))
+		})
+	}
+}
+
+func TestHybridProtection_EncryptECIES(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			private, err := ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+
+			continuePipeline, result := NewHybridProtection(encodePublicKey(t, &private.PublicKey)).Encrypt(ctx, []byte(plainString))
+			require.True(t, continuePipeline, result)
+
+			encrypted := decodeEncrypted(t, result)
+			ephemeralSize := 1 + 2*((curve.Params().BitSize+7)/8)
+			ephemeralKey := encrypted[:ephemeralSize]
+			x, y := elliptic.Unmarshal(curve, ephemeralKey)
+			require.NotNil(t, x)
+
+			sharedX, _ := curve.ScalarMult(x, y, private.D.Bytes())
+			key := deriveECIESKey(curve, sharedX, ephemeralKey)
+			assert.Equal(t, plainString, string(decryptAESGCM(t, key, encrypted[ephemeralSize:])))
+		})
+	}
+}
+
+func TestHybridProtection_EncryptWithSecrets(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	mockCtx := &mocks.AppFunctionContext{}
+	mockCtx.On("SetResponseContentType", common.ContentTypeText).Return()
+	mockCtx.On("PipelineId").Return("pipeline-id")
+	mockCtx.On("LoggingClient").Return(logger.NewMockClient())
+	mockCtx.On("GetSecret", "recipient", "publickey").Return(map[string]string{"publickey": encodePublicKey(t, &private.PublicKey)}, nil)
+
+	continuePipeline, result := NewHybridProtectionWithSecrets("recipient", "publickey").Encrypt(mockCtx, plainString)
+	require.True(t, continuePipeline, result)
+	assert.Len(t, decodeEncrypted(t, result), private.PublicKey.Size()+12+len(plainString)+16)
+}
+
+func TestHybridProtection_EncryptErrors(t *testing.T) {
+	tests := []struct {
+		Name      string
+		PublicKey string
+		Data      interface{}
+	}{
+		{"No data", "", nil},
+		{"No key", "", plainString},
+		{"Not PEM", "public key", plainString},
+		{"Bad key", string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("public key")})), plainString},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewHybridProtection(test.PublicKey).Encrypt(ctx, test.Data)
+			assert.False(t, continuePipeline)
+			assert.Error(t, result.(error))
+		})
+	}
+}
@@ -0,0 +1,280 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	// ImageFormatJPEG re-encodes the processed images as JPEG
+	ImageFormatJPEG = "jpeg"
+	// ImageFormatPNG re-encodes the processed images as PNG
+	ImageFormatPNG = "png"
+
+	mediaTypeJPEG = "image/jpeg"
+	mediaTypePNG  = "image/png"
+)
+
+// ImageOperation is an operation applied to a decoded image by ImageProcessor
+type ImageOperation func(img image.Image) (image.Image, error)
+
+// ImageProcessor decodes the JPEG or PNG binary readings of an Event, applies the image operations in order
+// and re-encodes the result so camera pipelines can prepare frames for inference or export.
+type ImageProcessor struct {
+	operations    []ImageOperation
+	format        string
+	jpegQuality   int
+	resourceNames []string
+}
+
+// NewImageProcessor creates, initializes and returns a new instance of ImageProcessor.
+// format is the format the images are re-encoded in, ImageFormatJPEG or ImageFormatPNG, or empty to keep the
+// received format. jpegQuality is the JPEG encoding quality from 1 to 100, 0 uses the default quality.
+// resourceNames, when not empty, limits the readings processed, otherwise all image readings are processed.
+func NewImageProcessor(format string, jpegQuality int, resourceNames []string, operations ...ImageOperation) ImageProcessor {
+	if jpegQuality <= 0 {
+		jpegQuality = jpeg.DefaultQuality
+	}
+
+	return ImageProcessor{
+		operations:    operations,
+		format:        strings.ToLower(format),
+		jpegQuality:   jpegQuality,
+		resourceNames: resourceNames,
+	}
+}
+
+// Process applies the image operations to the image readings of the Event and returns the Event with
+// the processed images. This function will return an error and stop the pipeline if a non-edgex
+// event is received, no data is received or an image can't be processed.
+func (processor ImageProcessor) Process(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function ProcessImages in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function ProcessImages in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Processing images in pipeline '%s'", ctx.PipelineId())
+
+	// Copy the readings so the images of the received Event are not replaced
+	event.Readings = append([]dtos.BaseReading{}, event.Readings...)
+
+	processed := 0
+	for index, reading := range event.Readings {
+		if !processor.shouldProcess(reading) {
+			continue
+		}
+
+		content, mediaType, err := processor.processImage(reading.BinaryValue)
+		if err != nil {
+			return false, fmt.Errorf("function ProcessImages in pipeline '%s': reading '%s': %s",
+				ctx.PipelineId(), reading.ResourceName, err.Error())
+		}
+
+		event.Readings[index].BinaryValue = content
+		event.Readings[index].MediaType = mediaType
+		processed++
+	}
+
+	ctx.LoggingClient().Debugf("Processed %d image(s) in pipeline '%s'", processed, ctx.PipelineId())
+
+	return true, event
+}
+
+func (processor ImageProcessor) shouldProcess(reading dtos.BaseReading) bool {
+	if reading.ValueType != common.ValueTypeBinary {
+		return false
+	}
+
+	if len(processor.resourceNames) == 0 {
+		return strings.HasPrefix(reading.MediaType, "image/")
+	}
+
+	for _, name := range processor.resourceNames {
+		if reading.ResourceName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (processor ImageProcessor) processImage(content []byte) ([]byte, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to decode image: %s", err.Error())
+	}
+
+	for _, operation := range processor.operations {
+		img, err = operation(img)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if processor.format != "" {
+		format = processor.format
+	}
+
+	buffer := &bytes.Buffer{}
+	switch format {
+	case ImageFormatJPEG:
+		err = jpeg.Encode(buffer, img, &jpeg.Options{Quality: processor.jpegQuality})
+		return buffer.Bytes(), mediaTypeJPEG, err
+	case ImageFormatPNG:
+		err = png.Encode(buffer, img)
+		return buffer.Bytes(), mediaTypePNG, err
+	default:
+		return nil, "", fmt.Errorf("unsupported image format '%s', must be '%s' or '%s'", format, ImageFormatJPEG, ImageFormatPNG)
+	}
+}
+
+// CropImage returns the operation that crops the image to the rectangle of the given size with its top left corner at x, y
+func CropImage(x int, y int, width int, height int) ImageOperation {
+	return func(img image.Image) (image.Image, error) {
+		bounds := img.Bounds()
+		rect := image.Rect(x, y, x+width, y+height).Add(bounds.Min)
+		if width <= 0 || height <= 0 || !rect.In(bounds) {
+			return nil, fmt.Errorf("crop rectangle %dx%d at %d,%d is not within the %dx%d image",
+				width, height, x, y, bounds.Dx(), bounds.Dy())
+		}
+
+		cropped := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(cropped, cropped.Bounds(), img, rect.Min, draw.Src)
+		return cropped, nil
+	}
+}
+
+// ResizeImage returns the operation that resizes the image using bilinear interpolation. When either width or height
+// is 0 it is calculated from the other so the aspect ratio of the image is kept.
+func ResizeImage(width int, height int) ImageOperation {
+	return func(img image.Image) (image.Image, error) {
+		bounds := img.Bounds()
+
+		if width < 0 || height < 0 || (width == 0 && height == 0) {
+			return nil, errors.New("resize width and height must not be negative and at least one must be set")
+		}
+
+		targetWidth, targetHeight := width, height
+		if targetWidth == 0 {
+			targetWidth = maxInt(1, bounds.Dx()*targetHeight/bounds.Dy())
+		}
+		if targetHeight == 0 {
+			targetHeight = maxInt(1, bounds.Dy()*targetWidth/bounds.Dx())
+		}
+
+		return resizeBilinear(img, targetWidth, targetHeight), nil
+	}
+}
+
+// GrayscaleImage returns the operation that converts the image to grayscale
+func GrayscaleImage() ImageOperation {
+	return func(img image.Image) (image.Image, error) {
+		bounds := img.Bounds()
+		gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+		return gray, nil
+	}
+}
+
+func resizeBilinear(img image.Image, width int, height int) image.Image {
+	bounds := img.Bounds()
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	scaleX := float64(bounds.Dx()) / float64(width)
+	scaleY := float64(bounds.Dy()) / float64(height)
+
+	for y := 0; y < height; y++ {
+		// Sample at the pixel centers
+		sourceY := (float64(y)+0.5)*scaleY - 0.5
+		y0, weightY := splitCoordinate(sourceY, bounds.Dy())
+
+		for x := 0; x < width; x++ {
+			sourceX := (float64(x)+0.5)*scaleX - 0.5
+			x0, weightX := splitCoordinate(sourceX, bounds.Dx())
+
+			x1 := minInt(x0+1, bounds.Dx()-1)
+			y1 := minInt(y0+1, bounds.Dy()-1)
+
+			topLeft := color.RGBA64Model.Convert(img.At(bounds.Min.X+x0, bounds.Min.Y+y0)).(color.RGBA64)
+			topRight := color.RGBA64Model.Convert(img.At(bounds.Min.X+x1, bounds.Min.Y+y0)).(color.RGBA64)
+			bottomLeft := color.RGBA64Model.Convert(img.At(bounds.Min.X+x0, bounds.Min.Y+y1)).(color.RGBA64)
+			bottomRight := color.RGBA64Model.Convert(img.At(bounds.Min.X+x1, bounds.Min.Y+y1)).(color.RGBA64)
+
+			interpolate := func(tl, tr, bl, br uint16) uint16 {
+				top := float64(tl)*(1-weightX) + float64(tr)*weightX
+				bottom := float64(bl)*(1-weightX) + float64(br)*weightX
+				return uint16(top*(1-weightY) + bottom*weightY + 0.5)
+			}
+
+			resized.Set(x, y, color.RGBA64{
+				R: interpolate(topLeft.R, topRight.R, bottomLeft.R, bottomRight.R),
+				G: interpolate(topLeft.G, topRight.G, bottomLeft.G, bottomRight.G),
+				B: interpolate(topLeft.B, topRight.B, bottomLeft.B, bottomRight.B),
+				A: interpolate(topLeft.A, topRight.A, bottomLeft.A, bottomRight.A),
+			})
+		}
+	}
+
+	return resized
+}
+
+// splitCoordinate returns the source pixel at or before the coordinate, clamped to the image, and the weight of the next pixel
+func splitCoordinate(coordinate float64, size int) (int, float64) {
+	if coordinate <= 0 {
+		return 0, 0
+	}
+
+	if coordinate >= float64(size-1) {
+		return size - 1, 0
+	}
+
+	pixel := int(coordinate)
+	return pixel, coordinate - float64(pixel)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage returns a PNG encoded image with a red left half and blue right half
+func testImage(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	require.NoError(t, png.Encode(buffer, img))
+	return buffer.Bytes()
+}
+
+func imageEvent(t *testing.T) dtos.Event {
+	event := dtos.NewEvent("camera", "camera-1", "frame")
+	event.AddBinaryReading("frame", testImage(t, 8, 4), mediaTypePNG)
+	event.AddBinaryReading("thumbnail", testImage(t, 4, 2), mediaTypePNG)
+	require.NoError(t, event.AddSimpleReading("exposure", common.ValueTypeInt32, int32(10)))
+	return event
+}
+
+func TestImageProcessor_Process(t *testing.T) {
+	event := imageEvent(t)
+	original := event.Readings[0].BinaryValue
+
+	processor := NewImageProcessor(ImageFormatJPEG, 95, []string{"frame"}, CropImage(2, 0, 4, 4), ResizeImage(2, 0), GrayscaleImage())
+	continuePipeline, result := processor.Process(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	processed := result.(dtos.Event)
+	require.Len(t, processed.Readings, 3)
+	assert.Equal(t, original, event.Readings[0].BinaryValue, "received Event should not be modified")
+
+	frame := processed.Readings[0]
+	assert.Equal(t, mediaTypeJPEG, frame.MediaType)
+	img, err := jpeg.Decode(bytes.NewReader(frame.BinaryValue))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 2), img.Bounds())
+	assert.IsType(t, &image.Gray{}, img, "grayscale JPEG should decode as gray")
+
+	assert.Equal(t, event.Readings[1], processed.Readings[1], "thumbnail not in resource names and should not be processed")
+	assert.Equal(t, event.Readings[2], processed.Readings[2])
+}
+
+func TestImageProcessor_ProcessAllImagesKeepFormat(t *testing.T) {
+	event := imageEvent(t)
+
+	processor := NewImageProcessor("", 0, nil, ResizeImage(0, 1))
+	continuePipeline, result := processor.Process(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	processed := result.(dtos.Event)
+	for _, index := range []int{0, 1} {
+		reading := processed.Readings[index]
+		assert.Equal(t, mediaTypePNG, reading.MediaType)
+		img, err := png.Decode(bytes.NewReader(reading.BinaryValue))
+		require.NoError(t, err)
+		assert.Equal(t, 1, img.Bounds().Dy())
+		assert.Equal(t, 2, img.Bounds().Dx(), "aspect ratio should be kept")
+
+		left := color.RGBAModel.Convert(img.At(0, 0)).(color.RGBA)
+		right := color.RGBAModel.Convert(img.At(1, 0)).(color.RGBA)
+		assert.Equal(t, uint8(255), left.R)
+		assert.Equal(t, uint8(255), right.B)
+	}
+}
+
+func TestImageProcessor_ProcessErrors(t *testing.T) {
+	badImage := dtos.NewEvent("camera", "camera-1", "frame")
+	badImage.AddBinaryReading("frame", []byte("not an image"), mediaTypePNG)
+
+	tests := []struct {
+		Name          string
+		Operations    []ImageOperation
+		Format        string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "", nil, "No Data Received"},
+		{"Not an Event", nil, "", []byte("image"), "type received is not an Event"},
+		{"Bad image", nil, "", badImage, "unable to decode image"},
+		{"Crop outside image", []ImageOperation{CropImage(6, 0, 4, 4)}, "", imageEvent(t), "is not within the 8x4 image"},
+		{"Bad resize", []ImageOperation{ResizeImage(0, 0)}, "", imageEvent(t), "at least one must be set"},
+		{"Bad format", nil, "gif", imageEvent(t), "unsupported image format 'gif'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			processor := NewImageProcessor(test.Format, 0, nil, test.Operations...)
+			continuePipeline, result := processor.Process(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
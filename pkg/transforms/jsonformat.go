@@ -0,0 +1,164 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+)
+
+const (
+	// JSONFieldNamingCamelCase names the fields in camelCase, e.g. "deviceName", as EdgeX does
+	JSONFieldNamingCamelCase = "camelCase"
+	// JSONFieldNamingSnakeCase names the fields in snake_case, e.g. "device_name"
+	JSONFieldNamingSnakeCase = "snake_case"
+)
+
+// JSONOptions customizes the JSON created by TransformToJSON for destination APIs strict about field naming that
+// differs from EdgeX's. The options apply to the fields of the Event and its readings, not to the content of the
+// tags or the readings' object values.
+type JSONOptions struct {
+	// FieldNaming is JSONFieldNamingCamelCase or JSONFieldNamingSnakeCase, JSONFieldNamingCamelCase when empty
+	FieldNaming string
+	// OmitEmpty omits the fields whose values are empty strings, nulls, or empty objects or arrays. Zero numbers and
+	// false are kept since they are meaningful values.
+	OmitEmpty bool
+	// FlattenTags writes the Event's tags as fields of the Event rather than in its "tags" object. The tags keep their
+	// names, a tag named like a field of the Event is dropped.
+	FlattenTags bool
+}
+
+// validate returns an error when the field naming isn't supported
+func (options JSONOptions) validate() error {
+	switch options.FieldNaming {
+	case "", JSONFieldNamingCamelCase, JSONFieldNamingSnakeCase:
+		return nil
+	default:
+		return fmt.Errorf("invalid JSON field naming '%s', must be '%s' or '%s'",
+			options.FieldNaming, JSONFieldNamingCamelCase, JSONFieldNamingSnakeCase)
+	}
+}
+
+// marshalEvent returns the Event as JSON formatted with the options
+func (options JSONOptions) marshalEvent(event dtos.Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers, such as the origins, are kept as written rather than converted to float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	if readings, ok := fields["readings"].([]interface{}); ok {
+		for index, reading := range readings {
+			if readingFields, ok := reading.(map[string]interface{}); ok {
+				readings[index] = options.formatFields(readingFields)
+			}
+		}
+	}
+
+	return json.Marshal(options.formatFields(fields))
+}
+
+// formatFields returns the fields of an Event or reading renamed, without the empty ones and with the tags flattened
+// as specified by the options
+func (options JSONOptions) formatFields(fields map[string]interface{}) map[string]interface{} {
+	formatted := make(map[string]interface{}, len(fields))
+
+	var tags map[string]interface{}
+	for name, value := range fields {
+		if options.FlattenTags && name == "tags" {
+			if tagFields, ok := value.(map[string]interface{}); ok {
+				tags = tagFields
+				continue
+			}
+		}
+
+		if options.OmitEmpty && isEmptyJSON(value) {
+			continue
+		}
+
+		formatted[options.fieldName(name)] = value
+	}
+
+	for name, value := range tags {
+		if _, exists := fields[name]; exists {
+			continue
+		}
+		if _, exists := formatted[name]; exists {
+			continue
+		}
+
+		if options.OmitEmpty && isEmptyJSON(value) {
+			continue
+		}
+
+		formatted[name] = value
+	}
+
+	return formatted
+}
+
+// fieldName returns the name of the EdgeX field with the options' naming
+func (options JSONOptions) fieldName(name string) string {
+	if options.FieldNaming != JSONFieldNamingSnakeCase {
+		return name
+	}
+
+	runes := []rune(name)
+	var snake strings.Builder
+	for index, r := range runes {
+		if unicode.IsUpper(r) && index > 0 {
+			previous := runes[index-1]
+			// A new word starts after a lower case letter or digit, or at the last capital of an acronym, e.g. "ID" in
+			// "correlationID" is one word and "HTTPStatus" is "http_status"
+			nextIsLower := index+1 < len(runes) && unicode.IsLower(runes[index+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				snake.WriteRune('_')
+			}
+		}
+		snake.WriteRune(unicode.ToLower(r))
+	}
+
+	return snake.String()
+}
+
+// isEmptyJSON returns whether the decoded JSON value is an empty string, null, or an empty object or array
+func isEmptyJSON(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
@@ -53,24 +53,31 @@ func (logic JSONLogic) Evaluate(ctx interfaces.AppFunctionContext, data interfac
 		return false, err
 	}
 
-	reader := strings.NewReader(string(coercedData))
-	rule := strings.NewReader(logic.Rule)
+	ctx.LoggingClient().Debugf("Applying JSONLogic Rule in pipeline '%s'", ctx.PipelineId())
+	result, err := applyJSONLogic(logic.Rule, coercedData)
+	if err != nil {
+		return false, fmt.Errorf("%s in pipeline '%s'", err.Error(), ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Condition met in pipeline '%s': %s", ctx.PipelineId(), strconv.FormatBool(result))
+
+	return result, data
+}
 
+// applyJSONLogic applies the JSONLogic rule to the JSON data and returns the boolean result
+func applyJSONLogic(rule string, data []byte) (bool, error) {
 	var logicResult bytes.Buffer
-	ctx.LoggingClient().Debugf("Applying JSONLogic Rule in pipeline '%s'", ctx.PipelineId())
-	err = jsonlogic.Apply(rule, reader, &logicResult)
+	err := jsonlogic.Apply(strings.NewReader(rule), bytes.NewReader(data), &logicResult)
 	if err != nil {
-		return false, fmt.Errorf("unable to apply JSONLogic rule in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		return false, fmt.Errorf("unable to apply JSONLogic rule: %s", err.Error())
 	}
 
 	var result bool
 	decoder := json.NewDecoder(&logicResult)
 	err = decoder.Decode(&result)
 	if err != nil {
-		return false, fmt.Errorf("unable to decode JSONLogic result in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		return false, fmt.Errorf("unable to decode JSONLogic result: %s", err.Error())
 	}
 
-	ctx.LoggingClient().Debugf("Condition met in pipeline '%s': %s", ctx.PipelineId(), strconv.FormatBool(result))
-
-	return result, data
+	return result, nil
 }
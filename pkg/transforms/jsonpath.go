@@ -0,0 +1,121 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// JSONPath filters and shapes arbitrary JSON data, not only EdgeX Events, by a JSONPath expression such as
+// $.readings[?(@.resourceName == 'temperature' && @.value > 20)].value
+type JSONPath struct {
+	expression string
+	path       *compiledJSONPath
+}
+
+// NewJSONPath creates, initializes and returns a new instance of JSONPath for the expression.
+// An error is returned if the expression is not valid JSONPath.
+func NewJSONPath(expression string) (*JSONPath, error) {
+	path, err := compileJSONPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONPath{
+		expression: expression,
+		path:       path,
+	}, nil
+}
+
+// FilterByJSONPath passes the data on unchanged when the expression selects at least one value from it, otherwise
+// the pipeline stops. The data can be JSON as a string or []byte or any type that can be marshaled to JSON.
+// This function will return an error and stop the pipeline if no data is received or the data is not valid JSON.
+func (jsonPath *JSONPath) FilterByJSONPath(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	selected, err := jsonPath.evaluate(data)
+	if err != nil {
+		return false, fmt.Errorf("function FilterByJSONPath in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	ctx.LoggingClient().Debugf("JSONPath '%s' selected %d value(s) in pipeline '%s'", jsonPath.expression, len(selected), ctx.PipelineId())
+
+	if len(selected) == 0 {
+		return false, nil
+	}
+
+	return true, data
+}
+
+// ExtractByJSONPath replaces the data with the JSON of the values the expression selects from it. The value itself is
+// returned for an expression that can only select a single value, such as $.readings[0].value, otherwise a JSON
+// array of the values selected. The pipeline stops when nothing is selected. The data can be JSON as a string or
+// []byte or any type that can be marshaled to JSON.
+// This function will return an error and stop the pipeline if no data is received or the data is not valid JSON.
+func (jsonPath *JSONPath) ExtractByJSONPath(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	selected, err := jsonPath.evaluate(data)
+	if err != nil {
+		return false, fmt.Errorf("function ExtractByJSONPath in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	ctx.LoggingClient().Debugf("JSONPath '%s' selected %d value(s) in pipeline '%s'", jsonPath.expression, len(selected), ctx.PipelineId())
+
+	if len(selected) == 0 {
+		return false, nil
+	}
+
+	var extracted interface{} = selected
+	if jsonPath.path.definite {
+		extracted = selected[0]
+	}
+
+	result, err := json.Marshal(extracted)
+	if err != nil {
+		return false, fmt.Errorf("function ExtractByJSONPath in pipeline '%s': unable to marshal extracted values: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	ctx.SetResponseContentType(common.ContentTypeJSON)
+	return true, result
+}
+
+func (jsonPath *JSONPath) evaluate(data interface{}) ([]interface{}, error) {
+	if data == nil {
+		return nil, fmt.Errorf("No Data Received")
+	}
+
+	coercedData, err := util.CoerceType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as json.Number so extracted values are not reformatted
+	decoder := json.NewDecoder(bytes.NewReader(coercedData))
+	decoder.UseNumber()
+
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("unable to parse data as JSON: %s", err.Error())
+	}
+
+	return jsonPath.path.evaluate(document, document), nil
+}
@@ -0,0 +1,128 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONPath_Invalid(t *testing.T) {
+	_, err := NewJSONPath("readings")
+	require.Error(t, err)
+}
+
+func TestJSONPath_FilterByJSONPath(t *testing.T) {
+	event := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeInt64, int64(30)))
+
+	tests := []struct {
+		Name       string
+		Expression string
+		Data       interface{}
+		Expected   bool
+	}{
+		{"String matched", "$.readings[?(@.value > 20)]", jsonPathDocument, true},
+		{"Bytes not matched", "$.readings[?(@.value > 50)]", []byte(jsonPathDocument), false},
+		{"Event matched", "$.readings[?(@.resourceName == 'temperature' && @.value == '30')]", event, true},
+		{"Event not matched", "$[?(@.deviceName == 'thermostat-2')]", event, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			jsonPath, err := NewJSONPath(test.Expression)
+			require.NoError(t, err)
+
+			continuePipeline, result := jsonPath.FilterByJSONPath(ctx, test.Data)
+			assert.Equal(t, test.Expected, continuePipeline)
+			if test.Expected {
+				assert.Equal(t, test.Data, result, "the data should be passed on unchanged")
+			} else {
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestJSONPath_ExtractByJSONPath(t *testing.T) {
+	tests := []struct {
+		Name       string
+		Expression string
+		Expected   string
+	}{
+		{"Single value", "$.readings[0].value", `20.5`},
+		{"Single object", "$.tags", `{"line":"A","site":"plant-1"}`},
+		{"Multiple values", "$.readings[?(@.resourceName == 'temperature')].value", `[20.5,25]`},
+		{"Filter selecting one value", "$.readings[?(@.value == 40)].resourceName", `["humidity"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			jsonPath, err := NewJSONPath(test.Expression)
+			require.NoError(t, err)
+
+			ctx.SetResponseContentType("")
+			continuePipeline, result := jsonPath.ExtractByJSONPath(ctx, jsonPathDocument)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, test.Expected, string(result.([]byte)))
+			assert.Equal(t, common.ContentTypeJSON, ctx.ResponseContentType())
+		})
+	}
+}
+
+func TestJSONPath_ExtractByJSONPath_NotSelected(t *testing.T) {
+	jsonPath, err := NewJSONPath("$.readings[?(@.value > 100)].value")
+	require.NoError(t, err)
+
+	continuePipeline, result := jsonPath.ExtractByJSONPath(ctx, jsonPathDocument)
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestJSONPath_Errors(t *testing.T) {
+	jsonPath, err := NewJSONPath("$.readings")
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not JSON", "temperature=20", "unable to parse data as JSON"},
+		{"Not marshalable", make(chan int), "marshaling input data to JSON failed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := jsonPath.FilterByJSONPath(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), "function FilterByJSONPath in pipeline")
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+
+			continuePipeline, result = jsonPath.ExtractByJSONPath(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
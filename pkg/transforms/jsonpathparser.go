@@ -0,0 +1,807 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// compiledJSONPath is a parsed JSONPath expression. It supports the root ($) and current (@) node, dot and bracket
+// child names, array indexes and slices, the * wildcard, .. recursive descent and ?() filter expressions comparing
+// paths and literals with ==, !=, <, <=, > and >=, combined with &&, || and !.
+type compiledJSONPath struct {
+	segments []jsonPathSegment
+	// definite is true when the path can only select a single value
+	definite bool
+}
+
+type jsonPathSegment struct {
+	selector  jsonPathSelector
+	recursive bool
+}
+
+// jsonPathSelector selects the values from a node of the decoded JSON
+type jsonPathSelector interface {
+	selectFrom(node interface{}, root interface{}) []interface{}
+}
+
+// jsonPathCondition is a condition of a filter expression evaluated against the current node
+type jsonPathCondition interface {
+	matches(current interface{}, root interface{}) bool
+}
+
+// compileJSONPath parses the JSONPath expression, returning an error describing the position of invalid syntax
+func compileJSONPath(expression string) (*compiledJSONPath, error) {
+	parser := &jsonPathParser{expression: strings.TrimSpace(expression)}
+
+	if parser.peek() != '$' {
+		return nil, fmt.Errorf("JSONPath expression '%s' must start with '$'", expression)
+	}
+
+	path, err := parser.parsePath()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath expression '%s': %s", expression, err.Error())
+	}
+
+	if !parser.atEnd() {
+		return nil, fmt.Errorf("invalid JSONPath expression '%s': unexpected '%c' at position %d",
+			expression, parser.peek(), parser.position)
+	}
+
+	return path, nil
+}
+
+// evaluate returns the values selected from the node, which is the root for absolute paths
+func (path *compiledJSONPath) evaluate(node interface{}, root interface{}) []interface{} {
+	nodes := []interface{}{node}
+	for _, segment := range path.segments {
+		var selected []interface{}
+		for _, current := range nodes {
+			if segment.recursive {
+				walkJSON(current, func(descendant interface{}) {
+					selected = append(selected, segment.selector.selectFrom(descendant, root)...)
+				})
+				continue
+			}
+
+			selected = append(selected, segment.selector.selectFrom(current, root)...)
+		}
+		nodes = selected
+	}
+
+	return nodes
+}
+
+// walkJSON calls visit for the node and each of its descendants, visiting object members in key order
+func walkJSON(node interface{}, visit func(interface{})) {
+	visit(node)
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(value) {
+			walkJSON(value[key], visit)
+		}
+	case []interface{}:
+		for _, item := range value {
+			walkJSON(item, visit)
+		}
+	}
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type childSelector struct {
+	names []string
+}
+
+func (selector childSelector) selectFrom(node interface{}, _ interface{}) []interface{} {
+	object, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var selected []interface{}
+	for _, name := range selector.names {
+		if value, found := object[name]; found {
+			selected = append(selected, value)
+		}
+	}
+	return selected
+}
+
+type indexSelector struct {
+	indexes []int
+}
+
+func (selector indexSelector) selectFrom(node interface{}, _ interface{}) []interface{} {
+	array, ok := node.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var selected []interface{}
+	for _, index := range selector.indexes {
+		if index < 0 {
+			index += len(array)
+		}
+		if index >= 0 && index < len(array) {
+			selected = append(selected, array[index])
+		}
+	}
+	return selected
+}
+
+type sliceSelector struct {
+	start *int
+	end   *int
+	step  int
+}
+
+func (selector sliceSelector) selectFrom(node interface{}, _ interface{}) []interface{} {
+	array, ok := node.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	start := sliceBound(selector.start, 0, len(array))
+	end := sliceBound(selector.end, len(array), len(array))
+
+	var selected []interface{}
+	for index := start; index < end; index += selector.step {
+		selected = append(selected, array[index])
+	}
+	return selected
+}
+
+// sliceBound resolves a negative bound relative to the end of the array and clamps it to the array
+func sliceBound(bound *int, defaultValue int, length int) int {
+	if bound == nil {
+		return defaultValue
+	}
+
+	value := *bound
+	if value < 0 {
+		value += length
+	}
+	if value < 0 {
+		return 0
+	}
+	if value > length {
+		return length
+	}
+	return value
+}
+
+type wildcardSelector struct{}
+
+func (selector wildcardSelector) selectFrom(node interface{}, _ interface{}) []interface{} {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		selected := make([]interface{}, 0, len(value))
+		for _, key := range sortedKeys(value) {
+			selected = append(selected, value[key])
+		}
+		return selected
+	case []interface{}:
+		return append([]interface{}{}, value...)
+	default:
+		return nil
+	}
+}
+
+// filterSelector selects the elements of an array that match the condition. An object is selected itself when it
+// matches, so a filter can be applied to a single JSON object as well as to an array of them.
+type filterSelector struct {
+	condition jsonPathCondition
+}
+
+func (selector filterSelector) selectFrom(node interface{}, root interface{}) []interface{} {
+	switch value := node.(type) {
+	case []interface{}:
+		var selected []interface{}
+		for _, item := range value {
+			if selector.condition.matches(item, root) {
+				selected = append(selected, item)
+			}
+		}
+		return selected
+	case map[string]interface{}:
+		if selector.condition.matches(value, root) {
+			return []interface{}{value}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+type jsonPathAnd struct {
+	left  jsonPathCondition
+	right jsonPathCondition
+}
+
+func (condition jsonPathAnd) matches(current interface{}, root interface{}) bool {
+	return condition.left.matches(current, root) && condition.right.matches(current, root)
+}
+
+type jsonPathOr struct {
+	left  jsonPathCondition
+	right jsonPathCondition
+}
+
+func (condition jsonPathOr) matches(current interface{}, root interface{}) bool {
+	return condition.left.matches(current, root) || condition.right.matches(current, root)
+}
+
+type jsonPathNot struct {
+	condition jsonPathCondition
+}
+
+func (condition jsonPathNot) matches(current interface{}, root interface{}) bool {
+	return !condition.condition.matches(current, root)
+}
+
+// jsonPathExists matches when the path selects at least one value
+type jsonPathExists struct {
+	operand jsonPathOperand
+}
+
+func (condition jsonPathExists) matches(current interface{}, root interface{}) bool {
+	_, found := condition.operand.value(current, root)
+	return found
+}
+
+type jsonPathComparison struct {
+	left     jsonPathOperand
+	operator string
+	right    jsonPathOperand
+}
+
+func (condition jsonPathComparison) matches(current interface{}, root interface{}) bool {
+	left, found := condition.left.value(current, root)
+	if !found {
+		return false
+	}
+
+	right, found := condition.right.value(current, root)
+	if !found {
+		return false
+	}
+
+	switch condition.operator {
+	case "==":
+		return jsonValuesEqual(left, right)
+	case "!=":
+		return !jsonValuesEqual(left, right)
+	}
+
+	order, ok := compareJSONValues(left, right)
+	if !ok {
+		return false
+	}
+
+	switch condition.operator {
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+// jsonPathOperand is either a literal or a path relative to the current (@) or root ($) node
+type jsonPathOperand struct {
+	literal  interface{}
+	path     *compiledJSONPath
+	fromRoot bool
+}
+
+// value returns the literal or the first value selected by the path
+func (operand jsonPathOperand) value(current interface{}, root interface{}) (interface{}, bool) {
+	if operand.path == nil {
+		return operand.literal, true
+	}
+
+	node := current
+	if operand.fromRoot {
+		node = root
+	}
+
+	selected := operand.path.evaluate(node, root)
+	if len(selected) == 0 {
+		return nil, false
+	}
+	return selected[0], true
+}
+
+func jsonNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case json.Number:
+		result, err := number.Float64()
+		return result, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func jsonValuesEqual(left interface{}, right interface{}) bool {
+	leftNumber, leftIsNumber := jsonNumber(left)
+	rightNumber, rightIsNumber := jsonNumber(right)
+	if leftIsNumber || rightIsNumber {
+		return leftIsNumber && rightIsNumber && leftNumber == rightNumber
+	}
+
+	switch leftValue := left.(type) {
+	case string, bool, nil:
+		return leftValue == right
+	default:
+		// Objects and arrays are only compared for existence
+		return false
+	}
+}
+
+// compareJSONValues orders two numbers or two strings, returning false for any other values
+func compareJSONValues(left interface{}, right interface{}) (int, bool) {
+	leftNumber, leftIsNumber := jsonNumber(left)
+	rightNumber, rightIsNumber := jsonNumber(right)
+	if leftIsNumber && rightIsNumber {
+		switch {
+		case leftNumber < rightNumber:
+			return -1, true
+		case leftNumber > rightNumber:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return strings.Compare(leftString, rightString), true
+	}
+
+	return 0, false
+}
+
+// jsonPathParser is a recursive descent parser over the characters of a JSONPath expression
+type jsonPathParser struct {
+	expression string
+	position   int
+}
+
+func (parser *jsonPathParser) atEnd() bool {
+	return parser.position >= len(parser.expression)
+}
+
+func (parser *jsonPathParser) peek() byte {
+	if parser.atEnd() {
+		return 0
+	}
+	return parser.expression[parser.position]
+}
+
+func (parser *jsonPathParser) skipSpaces() {
+	for !parser.atEnd() && parser.peek() == ' ' {
+		parser.position++
+	}
+}
+
+func (parser *jsonPathParser) consume(expected string) bool {
+	if strings.HasPrefix(parser.expression[parser.position:], expected) {
+		parser.position += len(expected)
+		return true
+	}
+	return false
+}
+
+func (parser *jsonPathParser) expect(expected string) error {
+	parser.skipSpaces()
+	if !parser.consume(expected) {
+		return parser.unexpected("'" + expected + "'")
+	}
+	return nil
+}
+
+func (parser *jsonPathParser) unexpected(expected string) error {
+	if parser.atEnd() {
+		return fmt.Errorf("expected %s at end of expression", expected)
+	}
+	return fmt.Errorf("expected %s at position %d, found '%c'", expected, parser.position, parser.peek())
+}
+
+// parsePath parses the segments following the $ or @ at the current position
+func (parser *jsonPathParser) parsePath() (*compiledJSONPath, error) {
+	parser.position++
+	path := &compiledJSONPath{definite: true}
+
+	for {
+		var segment jsonPathSegment
+		var err error
+
+		switch {
+		case parser.consume(".."):
+			segment.recursive = true
+			if parser.peek() == '[' {
+				segment.selector, err = parser.parseBracket()
+			} else {
+				segment.selector, err = parser.parseDotName()
+			}
+		case parser.consume("."):
+			segment.selector, err = parser.parseDotName()
+		case parser.peek() == '[':
+			segment.selector, err = parser.parseBracket()
+		default:
+			return path, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		path.segments = append(path.segments, segment)
+		path.definite = path.definite && !segment.recursive && isDefiniteSelector(segment.selector)
+	}
+}
+
+func isDefiniteSelector(selector jsonPathSelector) bool {
+	switch value := selector.(type) {
+	case childSelector:
+		return len(value.names) == 1
+	case indexSelector:
+		return len(value.indexes) == 1
+	default:
+		return false
+	}
+}
+
+func (parser *jsonPathParser) parseDotName() (jsonPathSelector, error) {
+	if parser.consume("*") {
+		return wildcardSelector{}, nil
+	}
+
+	start := parser.position
+	for !parser.atEnd() {
+		char := rune(parser.peek())
+		if !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_' && char != '-' {
+			break
+		}
+		parser.position++
+	}
+
+	if parser.position == start {
+		return nil, parser.unexpected("a name or '*'")
+	}
+
+	return childSelector{names: []string{parser.expression[start:parser.position]}}, nil
+}
+
+func (parser *jsonPathParser) parseBracket() (jsonPathSelector, error) {
+	parser.position++
+	parser.skipSpaces()
+
+	var selector jsonPathSelector
+	var err error
+
+	switch char := parser.peek(); {
+	case char == '*':
+		parser.position++
+		selector = wildcardSelector{}
+	case char == '?':
+		parser.position++
+		if err = parser.expect("("); err != nil {
+			return nil, err
+		}
+
+		var condition jsonPathCondition
+		if condition, err = parser.parseOr(); err != nil {
+			return nil, err
+		}
+
+		if err = parser.expect(")"); err != nil {
+			return nil, err
+		}
+
+		selector = filterSelector{condition: condition}
+	case char == '\'' || char == '"':
+		selector, err = parser.parseNames()
+	case char == '-' || char == ':' || (char >= '0' && char <= '9'):
+		selector, err = parser.parseIndexes()
+	default:
+		return nil, parser.unexpected("'*', '?', a quoted name or an index")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = parser.expect("]"); err != nil {
+		return nil, err
+	}
+
+	return selector, nil
+}
+
+func (parser *jsonPathParser) parseNames() (jsonPathSelector, error) {
+	var names []string
+	for {
+		parser.skipSpaces()
+		name, err := parser.parseString()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+
+		parser.skipSpaces()
+		if !parser.consume(",") {
+			return childSelector{names: names}, nil
+		}
+	}
+}
+
+func (parser *jsonPathParser) parseIndexes() (jsonPathSelector, error) {
+	first, err := parser.parseOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipSpaces()
+	if parser.consume(":") {
+		return parser.parseSlice(first)
+	}
+
+	if first == nil {
+		return nil, parser.unexpected("an index")
+	}
+
+	indexes := []int{*first}
+	for {
+		parser.skipSpaces()
+		if !parser.consume(",") {
+			return indexSelector{indexes: indexes}, nil
+		}
+
+		parser.skipSpaces()
+		index, err := parser.parseOptionalInt()
+		if err != nil {
+			return nil, err
+		}
+		if index == nil {
+			return nil, parser.unexpected("an index")
+		}
+		indexes = append(indexes, *index)
+	}
+}
+
+func (parser *jsonPathParser) parseSlice(start *int) (jsonPathSelector, error) {
+	selector := sliceSelector{start: start, step: 1}
+
+	var err error
+	parser.skipSpaces()
+	if selector.end, err = parser.parseOptionalInt(); err != nil {
+		return nil, err
+	}
+
+	parser.skipSpaces()
+	if parser.consume(":") {
+		parser.skipSpaces()
+		step, err := parser.parseOptionalInt()
+		if err != nil {
+			return nil, err
+		}
+		if step != nil {
+			if *step < 1 {
+				return nil, fmt.Errorf("slice step must be greater than 0 at position %d", parser.position)
+			}
+			selector.step = *step
+		}
+	}
+
+	return selector, nil
+}
+
+func (parser *jsonPathParser) parseOptionalInt() (*int, error) {
+	start := parser.position
+	parser.consume("-")
+	for !parser.atEnd() && parser.peek() >= '0' && parser.peek() <= '9' {
+		parser.position++
+	}
+
+	if parser.position == start {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(parser.expression[start:parser.position])
+	if err != nil {
+		return nil, fmt.Errorf("invalid index '%s' at position %d", parser.expression[start:parser.position], start)
+	}
+	return &value, nil
+}
+
+func (parser *jsonPathParser) parseString() (string, error) {
+	quote := parser.peek()
+	if quote != '\'' && quote != '"' {
+		return "", parser.unexpected("a quoted string")
+	}
+	parser.position++
+
+	var builder strings.Builder
+	for !parser.atEnd() {
+		char := parser.peek()
+		parser.position++
+
+		switch {
+		case char == quote:
+			return builder.String(), nil
+		case char == '\\' && !parser.atEnd():
+			builder.WriteByte(parser.peek())
+			parser.position++
+		default:
+			builder.WriteByte(char)
+		}
+	}
+
+	return "", fmt.Errorf("unterminated string at end of expression")
+}
+
+func (parser *jsonPathParser) parseOr() (jsonPathCondition, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		parser.skipSpaces()
+		if !parser.consume("||") {
+			return left, nil
+		}
+
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = jsonPathOr{left: left, right: right}
+	}
+}
+
+func (parser *jsonPathParser) parseAnd() (jsonPathCondition, error) {
+	left, err := parser.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		parser.skipSpaces()
+		if !parser.consume("&&") {
+			return left, nil
+		}
+
+		right, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = jsonPathAnd{left: left, right: right}
+	}
+}
+
+func (parser *jsonPathParser) parseUnary() (jsonPathCondition, error) {
+	parser.skipSpaces()
+
+	if parser.peek() == '!' && !strings.HasPrefix(parser.expression[parser.position:], "!=") {
+		parser.position++
+		condition, err := parser.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return jsonPathNot{condition: condition}, nil
+	}
+
+	if parser.consume("(") {
+		condition, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = parser.expect(")"); err != nil {
+			return nil, err
+		}
+		return condition, nil
+	}
+
+	return parser.parseComparison()
+}
+
+func (parser *jsonPathParser) parseComparison() (jsonPathCondition, error) {
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipSpaces()
+	operator := ""
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if parser.consume(candidate) {
+			operator = candidate
+			break
+		}
+	}
+
+	if operator == "" {
+		if left.path == nil {
+			return nil, parser.unexpected("a comparison operator")
+		}
+		return jsonPathExists{operand: left}, nil
+	}
+
+	right, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonPathComparison{left: left, operator: operator, right: right}, nil
+}
+
+func (parser *jsonPathParser) parseOperand() (jsonPathOperand, error) {
+	parser.skipSpaces()
+
+	switch char := parser.peek(); {
+	case char == '@' || char == '$':
+		path, err := parser.parsePath()
+		if err != nil {
+			return jsonPathOperand{}, err
+		}
+		return jsonPathOperand{path: path, fromRoot: char == '$'}, nil
+	case char == '\'' || char == '"':
+		value, err := parser.parseString()
+		return jsonPathOperand{literal: value}, err
+	case char == '-' || (char >= '0' && char <= '9'):
+		start := parser.position
+		parser.consume("-")
+		for !parser.atEnd() && strings.IndexByte("0123456789.eE+-", parser.peek()) >= 0 {
+			parser.position++
+		}
+
+		value, err := strconv.ParseFloat(parser.expression[start:parser.position], 64)
+		if err != nil {
+			return jsonPathOperand{}, fmt.Errorf("invalid number '%s' at position %d", parser.expression[start:parser.position], start)
+		}
+		return jsonPathOperand{literal: value}, nil
+	}
+
+	for keyword, value := range map[string]interface{}{"true": true, "false": false, "null": nil} {
+		if parser.consume(keyword) {
+			return jsonPathOperand{literal: value}, nil
+		}
+	}
+
+	return jsonPathOperand{}, parser.unexpected("a path, string, number, true, false or null")
+}
@@ -0,0 +1,129 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jsonPathDocument = `{
+	"deviceName": "thermostat-1",
+	"origin": 1000,
+	"readings": [
+		{"resourceName": "temperature", "value": 20.5, "units": "C"},
+		{"resourceName": "humidity", "value": 40},
+		{"resourceName": "temperature", "value": 25, "units": "C"}
+	],
+	"tags": {"site": "plant-1", "line": "A"}
+}`
+
+func evaluateJSONPath(t *testing.T, expression string) []interface{} {
+	path, err := compileJSONPath(expression)
+	require.NoError(t, err)
+
+	decoder := json.NewDecoder(strings.NewReader(jsonPathDocument))
+	decoder.UseNumber()
+	var document interface{}
+	require.NoError(t, decoder.Decode(&document))
+
+	return path.evaluate(document, document)
+}
+
+func TestCompiledJSONPath_Evaluate(t *testing.T) {
+	tests := []struct {
+		Expression string
+		Expected   string
+		Definite   bool
+	}{
+		{"$", "", true},
+		{"$.deviceName", `["thermostat-1"]`, true},
+		{"$['tags']['site']", `["plant-1"]`, true},
+		{"$.tags['site', 'line']", `["plant-1","A"]`, false},
+		{"$.tags.*", `["A","plant-1"]`, false},
+		{"$.readings[0].value", `[20.5]`, true},
+		{"$.readings[-1].value", `[25]`, true},
+		{"$.readings[0, 2].value", `[20.5,25]`, false},
+		{"$.readings[1:].resourceName", `["humidity","temperature"]`, false},
+		{"$.readings[:-1].value", `[20.5,40]`, false},
+		{"$.readings[::2].value", `[20.5,25]`, false},
+		{"$.readings[*].value", `[20.5,40,25]`, false},
+		{"$..units", `["C","C"]`, false},
+		{"$..[0].resourceName", `["temperature"]`, false},
+		{"$.readings[?(@.resourceName == 'temperature')].value", `[20.5,25]`, false},
+		{"$.readings[?(@.value > 20 && @.value < 25)].value", `[20.5]`, false},
+		{"$.readings[?(@.value <= 20.5 || @.resourceName == \"humidity\")].value", `[20.5,40]`, false},
+		{"$.readings[?(!@.units)].value", `[40]`, false},
+		{"$.readings[?(!(@.value >= 25))].value", `[20.5]`, false},
+		{"$.readings[?(@.resourceName != 'humidity' && @.value == 25)].value", `[25]`, false},
+		{"$.readings[?(@.value > $.origin)]", `null`, false},
+		{"$[?(@.origin == 1000)].deviceName", `["thermostat-1"]`, false},
+		{"$[?(@.tags.site == 'plant-2')]", `null`, false},
+		{"$.missing", `null`, true},
+		{"$.deviceName[0]", `null`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Expression, func(t *testing.T) {
+			path, err := compileJSONPath(test.Expression)
+			require.NoError(t, err)
+			assert.Equal(t, test.Definite, path.definite)
+
+			actual := evaluateJSONPath(t, test.Expression)
+			if test.Expected == "" {
+				require.Len(t, actual, 1, "the root should be selected")
+				return
+			}
+
+			data, err := json.Marshal(actual)
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, string(data))
+		})
+	}
+}
+
+func TestCompileJSONPath_Errors(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Expression    string
+		ExpectedError string
+	}{
+		{"Empty", "", "must start with '$'"},
+		{"No root", "readings[0]", "must start with '$'"},
+		{"Missing name", "$.", "expected a name or '*' at end of expression"},
+		{"Unclosed bracket", "$.readings[0", "expected ']' at end of expression"},
+		{"Bad bracket", "$[readings]", "expected '*', '?', a quoted name or an index at position 2"},
+		{"Unterminated string", "$['readings]", "unterminated string"},
+		{"Bad slice step", "$.readings[0:2:0]", "slice step must be greater than 0"},
+		{"Missing filter parentheses", "$.readings[?@.value > 1]", "expected '('"},
+		{"Bad operand", "$.readings[?(@.value > warm)]", "expected a path, string, number, true, false or null"},
+		{"Literal without operator", "$.readings[?(5)]", "expected a comparison operator"},
+		{"Trailing characters", "$.readings 0", "unexpected ' ' at position 10"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := compileJSONPath(test.Expression)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"net/http"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+)
+
+const (
+	// LineageServiceKeyHeader is the HTTP header the key of the service that exported the data is sent in
+	LineageServiceKeyHeader = "X-Lineage-Service-Key"
+	// LineagePipelineHeader is the HTTP header the id of the pipeline that exported the data is sent in
+	LineagePipelineHeader = "X-Lineage-Pipeline"
+	// LineagePipelineVersionHeader is the HTTP header the version of the pipeline that exported the data is sent in
+	LineagePipelineVersionHeader = "X-Lineage-Pipeline-Version"
+)
+
+// setLineageHeaders sets the headers identifying the service and pipeline that exported the data, along with the
+// correlation id, when Lineage is enabled, i.e. the runtime has set the SERVICEKEY context value
+func setLineageHeaders(ctx interfaces.AppFunctionContext, header http.Header) {
+	serviceKey, found := ctx.GetValue(interfaces.SERVICEKEY)
+	if !found {
+		return
+	}
+
+	pipelineVersion, _ := ctx.GetValue(interfaces.PIPELINEVERSION)
+
+	header.Set(LineageServiceKeyHeader, serviceKey)
+	header.Set(LineagePipelineHeader, ctx.PipelineId())
+	header.Set(LineagePipelineVersionHeader, pipelineVersion)
+	header.Set(common.CorrelationHeader, ctx.CorrelationID())
+}
@@ -0,0 +1,46 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestSetLineageHeaders(t *testing.T) {
+	lineageCtx := appfunction.NewContext("123", dic, "")
+	lineageCtx.AddValue(interfaces.PIPELINEID, "P1")
+
+	header := http.Header{}
+	setLineageHeaders(lineageCtx, header)
+	assert.Empty(t, header, "lineage headers should not be set when Lineage isn't enabled")
+
+	lineageCtx.AddValue(interfaces.SERVICEKEY, "app-lineage")
+	lineageCtx.AddValue(interfaces.PIPELINEVERSION, "0a1b2c3d4e5f")
+	setLineageHeaders(lineageCtx, header)
+
+	assert.Equal(t, "app-lineage", header.Get(LineageServiceKeyHeader))
+	assert.Equal(t, "P1", header.Get(LineagePipelineHeader))
+	assert.Equal(t, "0a1b2c3d4e5f", header.Get(LineagePipelineVersionHeader))
+	assert.Equal(t, "123", header.Get(common.CorrelationHeader))
+}
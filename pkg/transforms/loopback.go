@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// LoopbackSender sends the data to the pipelines of the same service whose topics match its topic, without going
+// through the message bus, so the output of one pipeline is the input of the next stage's pipeline.
+type LoopbackSender struct {
+	topic          string
+	persistOnError bool
+	topicFormatter StringValuesFormatter
+}
+
+// NewLoopbackSender creates, initializes and returns a new instance of LoopbackSender sending to the topic, which may
+// contain placeholders replaced with the context's values. persistOnError enables use of store & forward when the
+// data can't be sent back, i.e. the loopback queue is full.
+func NewLoopbackSender(topic string, persistOnError bool) *LoopbackSender {
+	return &LoopbackSender{
+		topic:          topic,
+		persistOnError: persistOnError,
+	}
+}
+
+// NewLoopbackSenderWithTopicFormatter allows passing a function to build the final topic from the combination of the
+// configured topic and the input parameters passed to LoopbackSend
+func NewLoopbackSenderWithTopicFormatter(topic string, persistOnError bool, topicFormatter StringValuesFormatter) *LoopbackSender {
+	sender := NewLoopbackSender(topic, persistOnError)
+	sender.topicFormatter = topicFormatter
+	return sender
+}
+
+// LoopbackSend sends the data from the previous function to the pipelines matching the topic, which are executed
+// once the data is queued. The Trigger's Loopback must be enabled.
+// This function will return an error and stop the pipeline if no data is received, the Loopback isn't enabled or
+// the data can't be sent back.
+func (sender *LoopbackSender) LoopbackSend(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function LoopbackSend in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	loopback := ctx.Loopback()
+	if loopback == nil {
+		return false, fmt.Errorf("function LoopbackSend in pipeline '%s': Loopback is not enabled in the Trigger configuration", ctx.PipelineId())
+	}
+
+	exportData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	topic, err := sender.topicFormatter.invoke(sender.topic, ctx, data)
+	if err != nil {
+		return false, fmt.Errorf("function LoopbackSend in pipeline '%s': topic formatting failed: %s", ctx.PipelineId(), err.Error())
+	}
+
+	if err := loopback.Send(ctx, topic, exportData); err != nil {
+		if sender.persistOnError {
+			ctx.SetRetryData(exportData)
+		}
+		return false, fmt.Errorf("function LoopbackSend in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	ctx.LoggingClient().Debugf("Sent %d bytes of data back to topic '%s' in pipeline '%s'", len(exportData), topic, ctx.PipelineId())
+
+	return true, nil
+}
@@ -0,0 +1,88 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// fakeLoopback records the data sent back, failing when err is set
+type fakeLoopback struct {
+	topics []string
+	data   [][]byte
+	err    error
+}
+
+func (loopback *fakeLoopback) Send(_ interfaces.AppFunctionContext, topic string, data []byte) error {
+	if loopback.err != nil {
+		return loopback.err
+	}
+	loopback.topics = append(loopback.topics, topic)
+	loopback.data = append(loopback.data, data)
+	return nil
+}
+
+func TestLoopbackSend(t *testing.T) {
+	sender := NewLoopbackSender("stage2/{devicename}", true)
+
+	continuePipeline, result := sender.LoopbackSend(ctx, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "Loopback is not enabled")
+
+	loopback := &fakeLoopback{}
+	dic.Update(di.ServiceConstructorMap{
+		container.LoopbackName: func(get di.Get) interface{} {
+			return loopback
+		},
+	})
+	defer dic.Update(di.ServiceConstructorMap{
+		container.LoopbackName: func(get di.Get) interface{} {
+			return nil
+		},
+	})
+
+	context := appfunction.NewContext("123", dic, "")
+	context.AddValue(interfaces.DEVICENAME, "Thermostat")
+
+	continuePipeline, result = sender.LoopbackSend(context, "data")
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []string{"stage2/Thermostat"}, loopback.topics)
+	assert.Equal(t, [][]byte{[]byte("data")}, loopback.data)
+
+	loopback.err = errors.New("loopback queue full")
+	continuePipeline, result = sender.LoopbackSend(context, "more data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "loopback queue full")
+	assert.Equal(t, []byte("more data"), context.RetryData(), "data not sent back should be stored for retry")
+
+	continuePipeline, result = NewLoopbackSender("stage2/{missing}", false).LoopbackSend(context, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "topic formatting failed")
+
+	continuePipeline, result = sender.LoopbackSend(context, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
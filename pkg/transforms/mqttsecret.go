@@ -32,15 +32,30 @@ import (
 
 // MQTTSecretSender ...
 type MQTTSecretSender struct {
+	connection     *mqttConnection
+	mqttConfig     MQTTSecretConfig
+	persistOnError bool
+	opts           *MQTT.ClientOptions
+	topicFormatter StringValuesFormatter
+	breaker        *CircuitBreaker
+}
+
+// mqttConnection is the client connected to the broker for the exports, shared by the senders with the same
+// connection key when SharedConnection is enabled
+type mqttConnection struct {
 	lock                 sync.Mutex
 	client               MQTT.Client
-	mqttConfig           MQTTSecretConfig
-	persistOnError       bool
-	opts                 *MQTT.ClientOptions
 	secretsLastRetrieved time.Time
-	topicFormatter       StringValuesFormatter
+	// inflight holds a slot for each QoS 1 or 2 publish awaiting the broker's acknowledgement, nil when unlimited
+	inflight chan struct{}
 }
 
+// mqttConnections are the connections shared by the senders with SharedConnection enabled, by connection key
+var mqttConnections = struct {
+	sync.Mutex
+	byKey map[string]*mqttConnection
+}{byKey: make(map[string]*mqttConnection)}
+
 // MQTTSecretConfig ...
 type MQTTSecretConfig struct {
 	// BrokerAddress should be set to the complete broker address i.e. mqtts://mosquitto:8883/mybroker
@@ -55,7 +70,8 @@ type MQTTSecretConfig struct {
 	KeepAlive string
 	// ConnectTimeout is the duration for timing out on connecting to the broker
 	ConnectTimeout string
-	// Topic that you wish to publish to
+	// Topic that you wish to publish to, which may be a template, i.e. "edgex/events/{profile}/{device}/{source}",
+	// resolved by the context's FormatTopic
 	Topic string
 	// QoS for MQTT Connection
 	QoS byte
@@ -66,6 +82,18 @@ type MQTTSecretConfig struct {
 	// AuthMode indicates what to use when connecting to the broker. Options are "none", "cacert" , "usernamepassword", "clientcert".
 	// If a CA Cert exists in the SecretPath then it will be used for all modes except "none".
 	AuthMode string
+	// PersistentSession connects with a persistent session, i.e. without a clean session, so the broker and the
+	// client keep the session's unacknowledged QoS 1 and 2 publishes across reconnects. Requires a fixed ClientId.
+	PersistentSession bool
+	// StoreDirectory is the optional directory the client persists the session's unacknowledged QoS 1 and 2 publishes
+	// to, so they are resent after a restart rather than lost. Requires PersistentSession.
+	StoreDirectory string
+	// InflightWindow is the maximum number of QoS 1 and 2 publishes awaiting the broker's acknowledgement at once,
+	// unlimited when 0. Exports that find the window full for the ConnectTimeout fail.
+	InflightWindow int
+	// SharedConnection shares one connection to the broker with the other senders, i.e. of other pipelines, with the
+	// same BrokerAddress, ClientId, AuthMode and SecretPath. The options of the sender connecting first are used.
+	SharedConnection bool
 }
 
 // NewMQTTSecretSender ...
@@ -75,11 +103,19 @@ func NewMQTTSecretSender(mqttConfig MQTTSecretConfig, persistOnError bool) *MQTT
 	opts.AddBroker(mqttConfig.BrokerAddress)
 	opts.SetClientID(mqttConfig.ClientId)
 	opts.SetAutoReconnect(mqttConfig.AutoReconnect)
+	opts.SetCleanSession(!mqttConfig.PersistentSession)
+	if mqttConfig.PersistentSession && len(mqttConfig.StoreDirectory) > 0 {
+		opts.SetStore(MQTT.NewFileStore(mqttConfig.StoreDirectory))
+	}
+	if mqttConfig.InflightWindow > 0 {
+		// The publishes resumed from the session after a reconnect are limited to the window too
+		opts.SetMaxResumePubInFlight(mqttConfig.InflightWindow)
+	}
 
 	//avoid casing issues
 	mqttConfig.AuthMode = strings.ToLower(mqttConfig.AuthMode)
 	sender := &MQTTSecretSender{
-		client:         nil,
+		connection:     mqttConnectionFor(mqttConfig),
 		mqttConfig:     mqttConfig,
 		persistOnError: persistOnError,
 		opts:           opts,
@@ -88,6 +124,34 @@ func NewMQTTSecretSender(mqttConfig MQTTSecretConfig, persistOnError bool) *MQTT
 	return sender
 }
 
+// mqttConnectionFor returns the connection shared by the senders with the same connection key when SharedConnection
+// is enabled, otherwise a new connection
+func mqttConnectionFor(mqttConfig MQTTSecretConfig) *mqttConnection {
+	if !mqttConfig.SharedConnection {
+		return newMQTTConnection(mqttConfig.InflightWindow)
+	}
+
+	key := strings.Join([]string{mqttConfig.BrokerAddress, mqttConfig.ClientId, mqttConfig.AuthMode, mqttConfig.SecretPath}, "|")
+
+	mqttConnections.Lock()
+	defer mqttConnections.Unlock()
+
+	connection, found := mqttConnections.byKey[key]
+	if !found {
+		connection = newMQTTConnection(mqttConfig.InflightWindow)
+		mqttConnections.byKey[key] = connection
+	}
+	return connection
+}
+
+func newMQTTConnection(inflightWindow int) *mqttConnection {
+	connection := &mqttConnection{}
+	if inflightWindow > 0 {
+		connection.inflight = make(chan struct{}, inflightWindow)
+	}
+	return connection
+}
+
 // NewMQTTSecretSenderWithTopicFormatter allows passing a function to build a final publish topic
 // from the combination of the configured topic and the input parameters passed to MQTTSend
 func NewMQTTSecretSenderWithTopicFormatter(mqttConfig MQTTSecretConfig, persistOnError bool, topicFormatter StringValuesFormatter) *MQTTSecretSender {
@@ -96,13 +160,22 @@ func NewMQTTSecretSenderWithTopicFormatter(mqttConfig MQTTSecretConfig, persistO
 	return sender
 }
 
+// NewMQTTSecretSenderWithCircuitBreaker creates, initializes and returns a new instance of MQTTSecretSender whose
+// exports are stopped by the circuit breaker after consecutive failures to connect or publish to the broker
+func NewMQTTSecretSenderWithCircuitBreaker(mqttConfig MQTTSecretConfig, persistOnError bool, breaker *CircuitBreaker) *MQTTSecretSender {
+	sender := NewMQTTSecretSender(mqttConfig, persistOnError)
+	sender.breaker = breaker
+	return sender
+}
+
 func (sender *MQTTSecretSender) initializeMQTTClient(ctx interfaces.AppFunctionContext) error {
-	sender.lock.Lock()
-	defer sender.lock.Unlock()
+	connection := sender.connection
+	connection.lock.Lock()
+	defer connection.lock.Unlock()
 
 	// If the conditions changed while waiting for the lock, i.e. other thread completed the initialization,
 	// then skip doing anything
-	if sender.client != nil && !sender.secretsLastRetrieved.Before(ctx.SecretsLastUpdated()) {
+	if connection.client != nil && !connection.secretsLastRetrieved.Before(ctx.SecretsLastUpdated()) {
 		return nil
 	}
 
@@ -127,29 +200,49 @@ func (sender *MQTTSecretSender) initializeMQTTClient(ctx interfaces.AppFunctionC
 		sender.opts.SetConnectTimeout(timeout)
 	}
 
+	// The broker being down is logged once when the connection is lost, the exports are then stored for retry until
+	// the client reconnects, or connects again on the next export when AutoReconnect is disabled
+	lc := ctx.LoggingClient()
+	sender.opts.SetConnectionLostHandler(func(_ MQTT.Client, err error) {
+		lc.Warnf("Connection to mqtt server %s for export lost: %s", config.BrokerAddress, err.Error())
+	})
+	sender.opts.SetReconnectingHandler(func(_ MQTT.Client, _ *MQTT.ClientOptions) {
+		lc.Infof("Reconnecting to mqtt server %s for export", config.BrokerAddress)
+	})
+
 	client, err := mqttFactory.Create(sender.opts)
 	if err != nil {
 		return fmt.Errorf("in pipeline '%s', unable to create MQTT Client: %s", ctx.PipelineId(), err.Error())
 	}
 
-	sender.client = client
-	sender.secretsLastRetrieved = time.Now()
+	// The secrets have been updated, so swap the client connected with the previous credentials for the new one
+	if connection.client != nil {
+		if connection.client.IsConnected() {
+			connection.client.Disconnect(0)
+		}
+		ctx.LoggingClient().Infof("MQTT Client for %s in pipeline '%s' rebuilt with the updated secrets",
+			config.BrokerAddress, ctx.PipelineId())
+	}
+
+	connection.client = client
+	connection.secretsLastRetrieved = time.Now()
 
 	return nil
 }
 
 func (sender *MQTTSecretSender) connectToBroker(ctx interfaces.AppFunctionContext, exportData []byte) error {
-	sender.lock.Lock()
-	defer sender.lock.Unlock()
+	connection := sender.connection
+	connection.lock.Lock()
+	defer connection.lock.Unlock()
 
 	// If other thread made the connection while this one was waiting for the lock
 	// then skip trying to connect
-	if sender.client.IsConnected() {
+	if connection.client.IsConnected() {
 		return nil
 	}
 
 	ctx.LoggingClient().Info("Connecting to mqtt server for export")
-	if token := sender.client.Connect(); token.Wait() && token.Error() != nil {
+	if token := connection.client.Connect(); token.Wait() && token.Error() != nil {
 		sender.setRetryData(ctx, exportData)
 		subMessage := "dropping event"
 		if sender.persistOnError {
@@ -163,6 +256,8 @@ func (sender *MQTTSecretSender) connectToBroker(ctx interfaces.AppFunctionContex
 
 // MQTTSend sends data from the previous function to the specified MQTT broker.
 // If no previous function exists, then the event that triggered the pipeline will be used.
+// When persistOnError is enabled, the data of a failed connect or publish is stored for Store and Forward, whose
+// retries connect to the broker again.
 func (sender *MQTTSecretSender) MQTTSend(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
 	if data == nil {
 		// We didn't receive a result
@@ -173,22 +268,52 @@ func (sender *MQTTSecretSender) MQTTSend(ctx interfaces.AppFunctionContext, data
 	if err != nil {
 		return false, err
 	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not publish %d bytes of data to topic '%s' on %s",
+			ctx.PipelineId(), len(exportData), sender.mqttConfig.Topic, sender.mqttConfig.BrokerAddress)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, sender.breaker, sender.mqttConfig.BrokerAddress, sender.persistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	continuePipeline, result := sender.publish(ctx, data, exportData)
+	sender.breaker.record(continuePipeline, result)
+
+	return continuePipeline, result
+}
+
+// publish publishes the export data to the broker, connecting to it first when not connected
+func (sender *MQTTSecretSender) publish(ctx interfaces.AppFunctionContext, data interface{}, exportData []byte) (bool, interface{}) {
+	connection := sender.connection
+
 	// if we haven't initialized the client yet OR the cache has been invalidated (due to new/updated secrets) we need to (re)initialize the client
-	if sender.client == nil || sender.secretsLastRetrieved.Before(ctx.SecretsLastUpdated()) {
+	connection.lock.Lock()
+	initialized := connection.client != nil && !connection.secretsLastRetrieved.Before(ctx.SecretsLastUpdated())
+	connection.lock.Unlock()
+	if !initialized {
 		err := sender.initializeMQTTClient(ctx)
 		if err != nil {
+			// The secrets may not be available yet, so the export is retried once they are
+			sender.setRetryData(ctx, exportData)
 			return false, err
 		}
 	}
 
-	if !sender.client.IsConnected() {
+	client := connection.getClient()
+	if !client.IsConnected() {
 		err := sender.connectToBroker(ctx, exportData)
 		if err != nil {
 			return false, err
 		}
 	}
 
-	if !sender.client.IsConnectionOpen() {
+	// While reconnecting, QoS 1 and 2 publishes are kept by the persistent session until the connection is open
+	queued := !client.IsConnectionOpen() && sender.mqttConfig.PersistentSession && sender.mqttConfig.QoS > 0
+	if !client.IsConnectionOpen() && !queued {
 		sender.setRetryData(ctx, exportData)
 		subMessage := "dropping event"
 		if sender.persistOnError {
@@ -197,17 +322,52 @@ func (sender *MQTTSecretSender) MQTTSend(ctx interfaces.AppFunctionContext, data
 		return false, fmt.Errorf("in pipeline '%s', connection to mqtt server for export not open, %s", ctx.PipelineId(), subMessage)
 	}
 
-	publishTopic, err := sender.topicFormatter.invoke(sender.mqttConfig.Topic, ctx, data)
+	// The topic is a template of the Event's names, context values and tags unless a topic formatter is given
+	var publishTopic string
+	var err error
+	if sender.topicFormatter == nil {
+		publishTopic, err = ctx.FormatTopic(sender.mqttConfig.Topic)
+	} else {
+		publishTopic, err = sender.topicFormatter.invoke(sender.mqttConfig.Topic, ctx, data)
+	}
 	if err != nil {
 		return false, fmt.Errorf("in pipeline '%s', MQTT topic formatting failed: %s", ctx.PipelineId(), err.Error())
 	}
 
-	token := sender.client.Publish(publishTopic, sender.mqttConfig.QoS, sender.mqttConfig.Retain, exportData)
-	token.Wait()
-	if token.Error() != nil {
+	// Only QoS 1 and 2 publishes are acknowledged by the broker
+	var delivery exportDelivery
+	if sender.mqttConfig.QoS > 0 {
+		delivery = recordDelivery(ctx, sender.mqttConfig.BrokerAddress+"/"+publishTopic)
+	}
+
+	if !sender.acquireInflight() {
+		err = fmt.Errorf("inflight window of %d publishes full for %s", cap(connection.inflight), sender.opts.ConnectTimeout.String())
+		delivery.fail(err)
+		sender.setRetryData(ctx, exportData)
+		return false, fmt.Errorf("in pipeline '%s', could not publish to mqtt server for export: %s", ctx.PipelineId(), err.Error())
+	}
+
+	token := client.Publish(publishTopic, sender.mqttConfig.QoS, sender.mqttConfig.Retain, exportData)
+	sender.releaseInflight(token)
+
+	if queued {
+		// The publish is completed by the client once reconnected, so its delivery is left unacknowledged
+		ctx.LoggingClient().Debugf("Publish queued in the persistent session until reconnected to %s in pipeline '%s'",
+			sender.mqttConfig.BrokerAddress, ctx.PipelineId())
+		return true, nil
+	}
+
+	if err := sender.waitForPublish(token); err != nil {
+		if token.Error() == nil && sender.mqttConfig.PersistentSession && sender.mqttConfig.QoS > 0 {
+			// The publish isn't stored for retry since the persistent session resends it, so it isn't duplicated
+			return false, fmt.Errorf("in pipeline '%s', publish to mqtt server for export not acknowledged, left in the persistent session to be resent: %s",
+				ctx.PipelineId(), err.Error())
+		}
+		delivery.fail(err)
 		sender.setRetryData(ctx, exportData)
-		return false, token.Error()
+		return false, fmt.Errorf("in pipeline '%s', could not publish to mqtt server for export: %s", ctx.PipelineId(), err.Error())
 	}
+	delivery.acknowledge("")
 
 	ctx.LoggingClient().Debugf("Sent data to MQTT Broker in pipeline '%s'", ctx.PipelineId())
 	ctx.LoggingClient().Tracef("Data exported", "Transport", "MQTT", "pipeline", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
@@ -215,6 +375,74 @@ func (sender *MQTTSecretSender) MQTTSend(ctx interfaces.AppFunctionContext, data
 	return true, nil
 }
 
+// waitForPublish waits for the publish to complete, which for QoS 1 and 2 is the broker's acknowledgement, for up to
+// the connect timeout, so an export isn't blocked forever when the connection is lost before the acknowledgement
+func (sender *MQTTSecretSender) waitForPublish(token MQTT.Token) error {
+	timeout := sender.opts.ConnectTimeout
+	if timeout <= 0 {
+		token.Wait()
+		return token.Error()
+	}
+
+	if !token.WaitTimeout(timeout) {
+		return fmt.Errorf("timed out after %s waiting for the publish to complete", timeout.String())
+	}
+
+	return token.Error()
+}
+
+// acquireInflight takes a slot of the inflight window for a QoS 1 or 2 publish, waiting for up to the connect timeout
+// for one to be released, and returns false when the window stayed full
+func (sender *MQTTSecretSender) acquireInflight() bool {
+	inflight := sender.connection.inflight
+	if inflight == nil || sender.mqttConfig.QoS == 0 {
+		return true
+	}
+
+	select {
+	case inflight <- struct{}{}:
+		return true
+	default:
+	}
+
+	timeout := sender.opts.ConnectTimeout
+	if timeout <= 0 {
+		inflight <- struct{}{}
+		return true
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case inflight <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// releaseInflight releases the publish's slot of the inflight window once the publish completes, which may be after
+// the export gave up waiting for it
+func (sender *MQTTSecretSender) releaseInflight(token MQTT.Token) {
+	inflight := sender.connection.inflight
+	if inflight == nil || sender.mqttConfig.QoS == 0 {
+		return
+	}
+
+	go func() {
+		<-token.Done()
+		<-inflight
+	}()
+}
+
+// getClient returns the connection's client, which is swapped when the secrets are updated
+func (connection *mqttConnection) getClient() MQTT.Client {
+	connection.lock.Lock()
+	defer connection.lock.Unlock()
+
+	return connection.client
+}
+
 func (sender *MQTTSecretSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
 	if sender.persistOnError {
 		ctx.SetRetryData(exportData)
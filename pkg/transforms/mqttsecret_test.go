@@ -20,10 +20,20 @@
 package transforms
 
 import (
+	"errors"
 	"testing"
+	"time"
 
+	MQTT "github.com/eclipse/paho.mqtt.golang"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 )
 
 func TestMQTTSecretSender_setRetryDataPersistFalse(t *testing.T) {
@@ -49,3 +59,220 @@ func TestMQTTSecretSender_MQTTSendNodata(t *testing.T) {
 	require.False(t, continuePipeline)
 	require.Error(t, result.(error))
 }
+
+func TestMQTTSecretSender_MQTTSendShadowPipeline(t *testing.T) {
+	shadowCtx := ctx.Clone().(*appfunction.Context)
+	shadowCtx.SetRetryData(nil)
+	shadowCtx.AddValue(interfaces.SHADOW, interfaces.ShadowModeLog)
+
+	sender := NewMQTTSecretSender(MQTTSecretConfig{BrokerAddress: "tcp://localhost:1883", Topic: "export"}, true)
+	continuePipeline, result := sender.MQTTSend(shadowCtx, []byte("data"))
+	require.True(t, continuePipeline)
+	assert.Nil(t, result)
+	assert.Nil(t, shadowCtx.RetryData())
+	assert.Nil(t, sender.connection.client, "MQTT client should not be initialized for a logged shadow export")
+}
+
+func TestMQTTSecretSender_MQTTSendNetworkOffline(t *testing.T) {
+	offlineCtx := ctx.Clone().(*appfunction.Context)
+	offlineCtx.SetRetryData(nil)
+	offlineCtx.AddValue(interfaces.NETWORKOFFLINE, "true")
+
+	// Offline mode persists even when persistOnError is false
+	sender := NewMQTTSecretSender(MQTTSecretConfig{}, false)
+	continuePipeline, result := sender.MQTTSend(offlineCtx, []byte("data"))
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "network is offline")
+	assert.Equal(t, []byte("data"), offlineCtx.RetryData())
+	assert.Nil(t, sender.connection.client, "MQTT client should not be initialized while offline")
+}
+
+// fakeToken completes with the error, or never completes when not done
+type fakeToken struct {
+	done bool
+	err  error
+}
+
+func (token *fakeToken) Wait() bool { return token.done }
+
+func (token *fakeToken) WaitTimeout(time.Duration) bool { return token.done }
+
+func (token *fakeToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	if token.done {
+		close(done)
+	}
+	return done
+}
+
+func (token *fakeToken) Error() error { return token.err }
+
+// fakeMQTTClient is a connected client completing its connects and publishes with the tokens, whose connection isn't
+// open while reconnecting
+type fakeMQTTClient struct {
+	MQTT.Client
+	connected    bool
+	reconnecting bool
+	connectToken *fakeToken
+	publishToken *fakeToken
+	published    []byte
+}
+
+func (client *fakeMQTTClient) IsConnected() bool { return client.connected }
+
+func (client *fakeMQTTClient) IsConnectionOpen() bool {
+	return client.connected && !client.reconnecting
+}
+
+func (client *fakeMQTTClient) Connect() MQTT.Token {
+	client.connected = client.connectToken.err == nil
+	return client.connectToken
+}
+
+func (client *fakeMQTTClient) Publish(_ string, _ byte, _ bool, payload interface{}) MQTT.Token {
+	client.published = payload.([]byte)
+	return client.publishToken
+}
+
+func TestMQTTSecretSender_MQTTSendPersistOnError(t *testing.T) {
+	mockSP := &mocks.SecretProvider{}
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	tests := []struct {
+		Name          string
+		Client        *fakeMQTTClient
+		ExpectedError string
+	}{
+		{"Broker down", &fakeMQTTClient{connectToken: &fakeToken{done: true, err: errors.New("connection refused")}}, "could not connect"},
+		{"Publish failed", &fakeMQTTClient{connected: true, publishToken: &fakeToken{done: true, err: errors.New("not connected")}}, "not connected"},
+		{"Publish timed out", &fakeMQTTClient{connected: true, publishToken: &fakeToken{}}, "timed out"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ctx.SetRetryData(nil)
+			sender := NewMQTTSecretSender(MQTTSecretConfig{Topic: "export"}, true)
+			sender.connection.client = test.Client
+			sender.connection.secretsLastRetrieved = time.Now()
+
+			continuePipeline, result := sender.MQTTSend(ctx, []byte("data"))
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+			assert.Equal(t, []byte("data"), ctx.RetryData(), "failed export should be stored for retry")
+		})
+	}
+
+	// The retry by Store and Forward connects to the broker again
+	ctx.SetRetryData(nil)
+	client := &fakeMQTTClient{connectToken: &fakeToken{done: true}, publishToken: &fakeToken{done: true}}
+	sender := NewMQTTSecretSender(MQTTSecretConfig{Topic: "export"}, true)
+	sender.connection.client = client
+	sender.connection.secretsLastRetrieved = time.Now()
+
+	continuePipeline, result := sender.MQTTSend(ctx, []byte("retried"))
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []byte("retried"), client.published)
+	assert.Nil(t, ctx.RetryData())
+}
+
+func TestMQTTSecretSender_MQTTSendPersistentSession(t *testing.T) {
+	mockSP := &mocks.SecretProvider{}
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	config := MQTTSecretConfig{Topic: "export", ClientId: "gateway-1", QoS: 1, PersistentSession: true, StoreDirectory: t.TempDir()}
+	sender := NewMQTTSecretSender(config, true)
+	assert.False(t, sender.opts.CleanSession, "persistent session should not be clean")
+
+	// While reconnecting the publish is queued in the session rather than failing
+	client := &fakeMQTTClient{connected: true, reconnecting: true, publishToken: &fakeToken{}}
+	sender.connection.client = client
+	sender.connection.secretsLastRetrieved = time.Now()
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := sender.MQTTSend(ctx, []byte("queued"))
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []byte("queued"), client.published)
+
+	// A publish not acknowledged in time is resent by the session, so it isn't stored for retry
+	client.reconnecting = false
+	continuePipeline, result = sender.MQTTSend(ctx, []byte("unacknowledged"))
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "left in the persistent session")
+	assert.Nil(t, ctx.RetryData())
+
+	// Without a persistent session the publish fails while reconnecting
+	sender = NewMQTTSecretSender(MQTTSecretConfig{Topic: "export", QoS: 1}, true)
+	sender.connection.client = &fakeMQTTClient{connected: true, reconnecting: true}
+	sender.connection.secretsLastRetrieved = time.Now()
+	continuePipeline, result = sender.MQTTSend(ctx, []byte("data"))
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "not open")
+	assert.Equal(t, []byte("data"), ctx.RetryData())
+	ctx.SetRetryData(nil)
+}
+
+func TestMQTTSecretSender_MQTTSendInflightWindow(t *testing.T) {
+	sender := NewMQTTSecretSender(MQTTSecretConfig{Topic: "export", QoS: 1, InflightWindow: 2}, true)
+	sender.opts.SetConnectTimeout(10 * time.Millisecond)
+	assert.Equal(t, 2, sender.opts.MaxResumePubInFlight)
+
+	// The publishes are never acknowledged, so their slots aren't released
+	client := &fakeMQTTClient{connected: true, publishToken: &fakeToken{}}
+	sender.connection.client = client
+	sender.connection.secretsLastRetrieved = time.Now()
+
+	for i := 0; i < 2; i++ {
+		continuePipeline, result := sender.MQTTSend(ctx, []byte("data"))
+		require.False(t, continuePipeline)
+		assert.Contains(t, result.(error).Error(), "timed out")
+	}
+
+	ctx.SetRetryData(nil)
+	client.published = nil
+	continuePipeline, result := sender.MQTTSend(ctx, []byte("data"))
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "inflight window of 2 publishes full")
+	assert.Nil(t, client.published, "publish should not be sent when the window is full")
+	assert.Equal(t, []byte("data"), ctx.RetryData())
+	ctx.SetRetryData(nil)
+
+	// A completed publish releases its slot
+	sender = NewMQTTSecretSender(MQTTSecretConfig{Topic: "export", QoS: 1, InflightWindow: 1}, true)
+	sender.opts.SetConnectTimeout(time.Second)
+	sender.connection.client = &fakeMQTTClient{connected: true, publishToken: &fakeToken{done: true}}
+	sender.connection.secretsLastRetrieved = time.Now()
+	for i := 0; i < 3; i++ {
+		continuePipeline, result = sender.MQTTSend(ctx, []byte("data"))
+		require.True(t, continuePipeline, result)
+	}
+}
+
+func TestMQTTSecretSender_SharedConnection(t *testing.T) {
+	config := MQTTSecretConfig{BrokerAddress: "tcp://shared:1883", ClientId: "gateway-1", Topic: "events", SharedConnection: true}
+	first := NewMQTTSecretSender(config, false)
+
+	config.Topic = "alerts"
+	second := NewMQTTSecretSender(config, false)
+	assert.Same(t, first.connection, second.connection, "senders with the same connection key should share the connection")
+
+	config.ClientId = "gateway-2"
+	other := NewMQTTSecretSender(config, false)
+	assert.NotSame(t, first.connection, other.connection)
+
+	config.SharedConnection = false
+	config.ClientId = "gateway-1"
+	unshared := NewMQTTSecretSender(config, false)
+	assert.NotSame(t, first.connection, unshared.connection)
+}
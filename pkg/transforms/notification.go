@@ -0,0 +1,631 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// DefaultNotificationBody is the body template used when none is given, rendering the data as JSON
+	DefaultNotificationBody = "{{json .}}"
+
+	// EmailUsernameKey and EmailPasswordKey are the keys of the optional SMTP credentials in the email secret
+	EmailUsernameKey = "username"
+	EmailPasswordKey = "password"
+	// DefaultSMTPPort is the SMTP submission port used when no port is given
+	DefaultSMTPPort = 587
+	// DefaultEmailTimeout is the timeout of the SMTP session
+	DefaultEmailTimeout = 30 * time.Second
+
+	// TwilioAuthTokenKey is the key of the Twilio auth token in the SMS secret
+	TwilioAuthTokenKey = "authtoken"
+	// DefaultTwilioURL is the base URL of the Twilio API used when no URL is given
+	DefaultTwilioURL = "https://api.twilio.com"
+	// MaxSMSLength is the maximum length of an SMS body accepted by Twilio, longer bodies are truncated
+	MaxSMSLength = 1600
+)
+
+// parseNotificationTemplate parses the template of a notification with the same functions as TemplateTransform
+func parseNotificationTemplate(name string, text string) (*template.Template, error) {
+	parsed, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(templateFunctions(nil)).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s template: %s", name, err.Error())
+	}
+
+	return parsed, nil
+}
+
+// renderNotification renders the template with the decoded data, the functions being bound to the context
+func renderNotification(ctx interfaces.AppFunctionContext, notificationTemplate *template.Template, data interface{}) (string, error) {
+	executable, err := notificationTemplate.Clone()
+	if err != nil {
+		return "", err
+	}
+	executable.Funcs(templateFunctions(ctx))
+
+	var rendered bytes.Buffer
+	if err := executable.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("unable to render %s template: %s", notificationTemplate.Name(), err.Error())
+	}
+
+	return rendered.String(), nil
+}
+
+// notificationData returns the data as bytes, which are stored for retry, and decoded for the templates. The data is
+// always rendered from its JSON, so a notification retried by Store and Forward renders the same as the original.
+func notificationData(data interface{}) ([]byte, interface{}, error) {
+	raw, err := util.CoerceType(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return raw, templateData(raw), nil
+}
+
+// EmailConfig contains the configuration of an EmailSender
+type EmailConfig struct {
+	// Host of the SMTP server
+	Host string
+	// Port of the SMTP server, DefaultSMTPPort when 0
+	Port int
+	// ImplicitTLS connects with TLS, i.e. to port 465, rather than upgrading the connection with STARTTLS when the
+	// server supports it
+	ImplicitTLS bool
+	// From is the sender's address
+	From string
+	// To are the recipients' addresses
+	To []string
+	// Subject is the template of the subject
+	Subject string
+	// Body is the template of the body, DefaultNotificationBody when empty
+	Body string
+	// ContentType of the body, "text/plain" when empty, or "text/html"
+	ContentType string
+	// SecretPath is the optional path of the "username" and "password" secrets used to authenticate, which requires
+	// TLS unless the server is local
+	SecretPath string
+	// PersistOnError enables use of store & forward when the email can't be sent
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// EmailSender sends notification emails via SMTP, i.e. to notify operators of alerts
+type EmailSender struct {
+	config  EmailConfig
+	address string
+	subject *template.Template
+	body    *template.Template
+	send    func(auth smtp.Auth, to []string, message []byte) error
+}
+
+// NewEmailSender creates, initializes and returns a new instance of EmailSender
+func NewEmailSender(config EmailConfig) (*EmailSender, error) {
+	config.Host = strings.TrimSpace(config.Host)
+	if len(config.Host) == 0 {
+		return nil, errors.New("SMTP host must be set")
+	}
+
+	if config.Port == 0 {
+		config.Port = DefaultSMTPPort
+	}
+	if config.Port < 0 {
+		return nil, fmt.Errorf("invalid SMTP port %d", config.Port)
+	}
+
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("invalid from address '%s': %s", config.From, err.Error())
+	}
+
+	if len(config.To) == 0 {
+		return nil, errors.New("at least one recipient must be set")
+	}
+	for _, to := range config.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient address '%s': %s", to, err.Error())
+		}
+	}
+
+	if len(strings.TrimSpace(config.Subject)) == 0 {
+		return nil, errors.New("subject must be set")
+	}
+	subject, err := parseNotificationTemplate("subject", config.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(config.Body)) == 0 {
+		config.Body = DefaultNotificationBody
+	}
+	body, err := parseNotificationTemplate("body", config.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.ContentType {
+	case "":
+		config.ContentType = common.ContentTypeText
+	case common.ContentTypeText, "text/html":
+	default:
+		return nil, fmt.Errorf("invalid content type '%s', must be 'text/plain' or 'text/html'", config.ContentType)
+	}
+
+	sender := &EmailSender{
+		config:  config,
+		address: net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		subject: subject,
+		body:    body,
+	}
+	sender.send = sender.sendMail
+
+	return sender, nil
+}
+
+// SendEmail renders the subject and body templates with the data from the previous function and emails them to the
+// recipients. The data is passed to the templates decoded from its JSON, so an Event's fields are referenced by their
+// JSON names, e.g. .deviceName. The data is returned so notifications can be chained.
+// This function will return an error and stop the pipeline if no data is received, a template can't be rendered or
+// the email can't be sent.
+func (sender *EmailSender) SendEmail(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function SendEmail in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, decoded, err := notificationData(data)
+	if err != nil {
+		return false, err
+	}
+
+	subject, err := renderNotification(ctx, sender.subject, decoded)
+	if err != nil {
+		return false, fmt.Errorf("function SendEmail in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+	body, err := renderNotification(ctx, sender.body, decoded)
+	if err != nil {
+		return false, fmt.Errorf("function SendEmail in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not email '%s' to %s", ctx.PipelineId(), subject, strings.Join(sender.config.To, ", "))
+		return true, data
+	}
+
+	if err := skipExport(ctx, sender.config.CircuitBreaker, sender.address, sender.config.PersistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, "smtp://"+sender.address)
+
+	err = sender.email(ctx, subject, body)
+	if err != nil {
+		err = fmt.Errorf("function SendEmail in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.config.CircuitBreaker.Failed()
+		sender.setRetryData(ctx, exportData)
+		return false, err
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge("")
+
+	ctx.LoggingClient().Debugf("Emailed '%s' to %s in pipeline '%s'", subject, strings.Join(sender.config.To, ", "), ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, data
+}
+
+// email builds the message and sends it, authenticating with the credentials from the secrets when set
+func (sender *EmailSender) email(ctx interfaces.AppFunctionContext, subject string, body string) error {
+	var auth smtp.Auth
+	if len(sender.config.SecretPath) > 0 {
+		secrets, err := ctx.GetSecret(sender.config.SecretPath, EmailUsernameKey, EmailPasswordKey)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", secrets[EmailUsernameKey], secrets[EmailPasswordKey], sender.config.Host)
+	}
+
+	message, err := sender.message(subject, body, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return sender.send(auth, sender.config.To, message)
+}
+
+// message returns the MIME message, the body being quoted-printable encoded
+func (sender *EmailSender) message(subject string, body string, now time.Time) ([]byte, error) {
+	// Line breaks in the rendered subject would inject headers
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", sender.config.From},
+		{"To", strings.Join(sender.config.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", sender.config.ContentType + "; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+	for _, header := range headers {
+		message.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	message.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(&message)
+	if _, err := writer.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return message.Bytes(), nil
+}
+
+// sendMail sends the message over a connection secured with implicit TLS, or STARTTLS when the server supports it
+func (sender *EmailSender) sendMail(auth smtp.Auth, to []string, message []byte) error {
+	dialer := &net.Dialer{Timeout: DefaultEmailTimeout}
+	tlsConfig := &tls.Config{ServerName: sender.config.Host}
+
+	var conn net.Conn
+	var err error
+	if sender.config.ImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", sender.address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", sender.address)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(DefaultEmailTimeout))
+
+	client, err := smtp.NewClient(conn, sender.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if !sender.config.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(sender.config.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		address, _ := mail.ParseAddress(recipient)
+		if err := client.Rcpt(address.Address); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (sender *EmailSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
+	if sender.config.PersistOnError {
+		ctx.SetRetryData(exportData)
+	}
+}
+
+// WebhookSender posts notifications with a templated body to a webhook, i.e. a chat or incident management service
+type WebhookSender struct {
+	body           *template.Template
+	sender         HTTPSender
+	persistOnError bool
+}
+
+// NewWebhookSender creates, initializes and returns a new instance of WebhookSender rendering the body template,
+// DefaultNotificationBody when empty, and sending it with the HTTP options, the MimeType being application/json when
+// empty.
+func NewWebhookSender(body string, options HTTPSenderOptions) (*WebhookSender, error) {
+	if len(strings.TrimSpace(options.URL)) == 0 {
+		return nil, errors.New("webhook URL must be set")
+	}
+
+	if len(strings.TrimSpace(body)) == 0 {
+		body = DefaultNotificationBody
+	}
+	parsed, err := parseNotificationTemplate("body", body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The data received is returned rather than the webhook's response so notifications can be chained
+	options.ReturnInputData = true
+	options.ContinueOnSendError = false
+
+	return &WebhookSender{
+		body:           parsed,
+		sender:         NewHTTPSenderWithOptions(options),
+		persistOnError: options.PersistOnError,
+	}, nil
+}
+
+// PostWebhook renders the body template with the data from the previous function and POSTs it to the webhook. The
+// data is passed to the template decoded from its JSON, so an Event's fields are referenced by their JSON names,
+// e.g. .deviceName. The data is returned so notifications can be chained.
+// This function will return an error and stop the pipeline if no data is received, the template can't be rendered or
+// the POST fails.
+func (webhook *WebhookSender) PostWebhook(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function PostWebhook in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, decoded, err := notificationData(data)
+	if err != nil {
+		return false, err
+	}
+
+	body, err := renderNotification(ctx, webhook.body, decoded)
+	if err != nil {
+		return false, fmt.Errorf("function PostWebhook in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	continuePipeline, result := webhook.sender.HTTPPost(ctx, body)
+	if !continuePipeline {
+		// The data is stored rather than the rendered body, so the retry renders the template again
+		if webhook.persistOnError || isNetworkOffline(ctx) {
+			ctx.SetRetryData(exportData)
+		}
+		return false, result
+	}
+
+	return true, data
+}
+
+// SMSConfig contains the configuration of an SMSSender
+type SMSConfig struct {
+	// AccountSid of the Twilio account
+	AccountSid string
+	// From is the Twilio phone number, or messaging service sid, sending the messages
+	From string
+	// To are the recipients' phone numbers, i.e. "+15551234567"
+	To []string
+	// Body is the template of the message, DefaultNotificationBody when empty
+	Body string
+	// SecretPath is the path of the "authtoken" secret
+	SecretPath string
+	// URL is the base URL of the Twilio API, DefaultTwilioURL when empty
+	URL string
+	// PersistOnError enables use of store & forward when a message can't be sent
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// SMSSender sends notification text messages via Twilio
+type SMSSender struct {
+	config SMSConfig
+	body   *template.Template
+	client *http.Client
+}
+
+// twilioMessage is the body of the response to a Twilio message request, or its error
+type twilioMessage struct {
+	Sid     string `json:"sid"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewSMSSender creates, initializes and returns a new instance of SMSSender
+func NewSMSSender(config SMSConfig) (*SMSSender, error) {
+	if len(strings.TrimSpace(config.AccountSid)) == 0 {
+		return nil, errors.New("Twilio account sid must be set")
+	}
+
+	if len(strings.TrimSpace(config.From)) == 0 {
+		return nil, errors.New("from phone number must be set")
+	}
+
+	if len(config.To) == 0 {
+		return nil, errors.New("at least one recipient must be set")
+	}
+
+	if len(strings.TrimSpace(config.SecretPath)) == 0 {
+		return nil, errors.New("secret path must be set")
+	}
+
+	if len(strings.TrimSpace(config.Body)) == 0 {
+		config.Body = DefaultNotificationBody
+	}
+	body, err := parseNotificationTemplate("body", config.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.URL) == 0 {
+		config.URL = DefaultTwilioURL
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+
+	return &SMSSender{
+		config: config,
+		body:   body,
+		client: &http.Client{Timeout: DefaultEmailTimeout},
+	}, nil
+}
+
+// SendSMS renders the body template with the data from the previous function and texts it to each recipient, the
+// body being truncated to MaxSMSLength characters. The data is passed to the template decoded from its JSON, so an
+// Event's fields are referenced by their JSON names, e.g. .deviceName. The sids of the messages are the delivery
+// receipt. The data is returned so notifications can be chained.
+// This function will return an error and stop the pipeline if no data is received, the template can't be rendered or
+// any of the messages can't be sent.
+func (sender *SMSSender) SendSMS(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function SendSMS in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	exportData, decoded, err := notificationData(data)
+	if err != nil {
+		return false, err
+	}
+
+	body, err := renderNotification(ctx, sender.body, decoded)
+	if err != nil {
+		return false, fmt.Errorf("function SendSMS in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+	if runes := []rune(body); len(runes) > MaxSMSLength {
+		body = string(runes[:MaxSMSLength])
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not text %s", ctx.PipelineId(), strings.Join(sender.config.To, ", "))
+		return true, data
+	}
+
+	if err := skipExport(ctx, sender.config.CircuitBreaker, sender.config.URL, sender.config.PersistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, sender.config.URL)
+
+	var sids []string
+	for _, to := range sender.config.To {
+		sid, err := sender.text(ctx, to, body)
+		if err != nil {
+			err = fmt.Errorf("function SendSMS in pipeline '%s': unable to text %s: %s", ctx.PipelineId(), to, err.Error())
+			delivery.fail(err)
+			sender.config.CircuitBreaker.Failed()
+			sender.setRetryData(ctx, exportData)
+			return false, err
+		}
+		sids = append(sids, sid)
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(strings.Join(sids, ","))
+
+	ctx.LoggingClient().Debugf("Texted %s in pipeline '%s'", strings.Join(sender.config.To, ", "), ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, data
+}
+
+// text sends the message to the recipient and returns the message's sid
+func (sender *SMSSender) text(ctx interfaces.AppFunctionContext, to string, body string) (string, error) {
+	secrets, err := ctx.GetSecret(sender.config.SecretPath, TwilioAuthTokenKey)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", body)
+	if strings.HasPrefix(sender.config.From, "MG") {
+		form.Set("MessagingServiceSid", sender.config.From)
+	} else {
+		form.Set("From", sender.config.From)
+	}
+
+	messagesURL := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", sender.config.URL, url.PathEscape(sender.config.AccountSid))
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPost, messagesURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(sender.config.AccountSid, secrets[TwilioAuthTokenKey])
+
+	response, err := sender.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var message twilioMessage
+	_ = json.Unmarshal(responseBody, &message)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		if len(message.Message) > 0 {
+			return "", fmt.Errorf("Twilio responded with %d HTTP status code: %s (code %d)", response.StatusCode, message.Message, message.Code)
+		}
+		return "", fmt.Errorf("Twilio responded with %d HTTP status code: %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+
+	if len(message.Sid) == 0 {
+		return "", errors.New("Twilio response contains no message sid")
+	}
+
+	return message.Sid, nil
+}
+
+func (sender *SMSSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
+	if sender.config.PersistOnError {
+		ctx.SetRetryData(exportData)
+	}
+}
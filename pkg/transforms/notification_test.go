@@ -0,0 +1,274 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	mocks2 "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func notificationTestEvent() dtos.Event {
+	event := dtos.NewEvent("boiler-profile", "boiler-1", "alarm")
+	_ = event.AddSimpleReading("pressure", common.ValueTypeFloat64, 12.5)
+	return event
+}
+
+func TestNewEmailSender(t *testing.T) {
+	valid := EmailConfig{Host: "smtp.example.com", From: "edgex@example.com", To: []string{"ops@example.com"}, Subject: "Alert"}
+
+	sender, err := NewEmailSender(valid)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp.example.com:587", sender.address)
+	assert.Equal(t, common.ContentTypeText, sender.config.ContentType)
+
+	tests := []struct {
+		Name   string
+		Modify func(config *EmailConfig)
+	}{
+		{"No host", func(config *EmailConfig) { config.Host = "" }},
+		{"Bad port", func(config *EmailConfig) { config.Port = -1 }},
+		{"Bad from", func(config *EmailConfig) { config.From = "edgex" }},
+		{"No recipients", func(config *EmailConfig) { config.To = nil }},
+		{"Bad recipient", func(config *EmailConfig) { config.To = []string{"ops@example.com", "ops"} }},
+		{"No subject", func(config *EmailConfig) { config.Subject = " " }},
+		{"Bad subject", func(config *EmailConfig) { config.Subject = "{{.deviceName" }},
+		{"Bad body", func(config *EmailConfig) { config.Body = "{{end}}" }},
+		{"Bad content type", func(config *EmailConfig) { config.ContentType = "application/json" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			config := valid
+			test.Modify(&config)
+			_, err := NewEmailSender(config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestEmailSender_SendEmail(t *testing.T) {
+	sender, err := NewEmailSender(EmailConfig{
+		Host:    "smtp.example.com",
+		From:    "EdgeX <edgex@example.com>",
+		To:      []string{"ops@example.com", "oncall@example.com"},
+		Subject: "Alarm from {{.deviceName}}\r\nBcc: attacker@example.com",
+		Body:    "{{range .readings}}{{.resourceName}} is {{.value}}\n{{end}}",
+	})
+	require.NoError(t, err)
+
+	var sent []byte
+	var recipients []string
+	sender.send = func(auth smtp.Auth, to []string, message []byte) error {
+		assert.Nil(t, auth, "no credentials should be used without a secret path")
+		recipients = to
+		sent = message
+		return nil
+	}
+
+	event := notificationTestEvent()
+	continuePipeline, result := sender.SendEmail(ctx, event)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, event, result, "data should be returned so notifications can be chained")
+	assert.Equal(t, []string{"ops@example.com", "oncall@example.com"}, recipients)
+
+	parts := strings.SplitN(string(sent), "\r\n\r\n", 2)
+	require.Len(t, parts, 2)
+	headers, body := parts[0], parts[1]
+	assert.Contains(t, headers, "From: EdgeX <edgex@example.com>\r\n")
+	assert.Contains(t, headers, "To: ops@example.com, oncall@example.com\r\n")
+	assert.Contains(t, headers, "Subject: Alarm from boiler-1 Bcc: attacker@example.com\r\n", "subject should stay on one line")
+	assert.NotContains(t, headers, "\r\nBcc:")
+
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+	require.NoError(t, err)
+	assert.Equal(t, "pressure is 1.250000e+01\r\n", string(decoded))
+
+	continuePipeline, result = sender.SendEmail(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestEmailSender_SendEmailPersistOnError(t *testing.T) {
+	sender, err := NewEmailSender(EmailConfig{
+		Host:           "smtp.example.com",
+		From:           "edgex@example.com",
+		To:             []string{"ops@example.com"},
+		Subject:        "Alarm from {{.deviceName}}",
+		PersistOnError: true,
+	})
+	require.NoError(t, err)
+
+	var subjects []string
+	sender.send = func(_ smtp.Auth, _ []string, message []byte) error {
+		subjects = append(subjects, strings.Split(string(message), "\r\n")[2])
+		if len(subjects) == 1 {
+			return errors.New("421 service not available")
+		}
+		return nil
+	}
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := sender.SendEmail(ctx, notificationTestEvent())
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "421 service not available")
+	require.NotNil(t, ctx.RetryData())
+
+	continuePipeline, result = sender.SendEmail(ctx, ctx.RetryData())
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []string{"Subject: Alarm from boiler-1", "Subject: Alarm from boiler-1"}, subjects,
+		"retried email should render the same as the original")
+	ctx.SetRetryData(nil)
+}
+
+func TestWebhookSender_PostWebhook(t *testing.T) {
+	var requests []string
+	fail := true
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := io.ReadAll(request.Body)
+		requests = append(requests, string(body))
+		if fail {
+			writer.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = writer.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	_, err := NewWebhookSender("", HTTPSenderOptions{})
+	require.Error(t, err, "webhook URL should be required")
+	_, err = NewWebhookSender("{{.text", HTTPSenderOptions{URL: ts.URL})
+	require.Error(t, err)
+
+	webhook, err := NewWebhookSender(`{"text": "{{.deviceName}} alarm: {{(index .readings 0).value}}"}`,
+		HTTPSenderOptions{URL: ts.URL, PersistOnError: true})
+	require.NoError(t, err)
+
+	event := notificationTestEvent()
+	ctx.SetRetryData(nil)
+	continuePipeline, result := webhook.PostWebhook(ctx, event)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "502")
+
+	var stored dtos.Event
+	require.NoError(t, json.Unmarshal(ctx.RetryData(), &stored), "data rather than the rendered body should be stored")
+	assert.Equal(t, event, stored)
+
+	fail = false
+	continuePipeline, result = webhook.PostWebhook(ctx, ctx.RetryData())
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []string{`{"text": "boiler-1 alarm: 1.250000e+01"}`, `{"text": "boiler-1 alarm: 1.250000e+01"}`}, requests)
+	ctx.SetRetryData(nil)
+}
+
+func TestNewSMSSender(t *testing.T) {
+	valid := SMSConfig{AccountSid: "AC123", From: "+15550001111", To: []string{"+15552223333"}, SecretPath: "twilio"}
+
+	sender, err := NewSMSSender(valid)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTwilioURL, sender.config.URL)
+
+	tests := []struct {
+		Name   string
+		Modify func(config *SMSConfig)
+	}{
+		{"No account sid", func(config *SMSConfig) { config.AccountSid = "" }},
+		{"No from", func(config *SMSConfig) { config.From = "" }},
+		{"No recipients", func(config *SMSConfig) { config.To = nil }},
+		{"No secret path", func(config *SMSConfig) { config.SecretPath = "" }},
+		{"Bad body", func(config *SMSConfig) { config.Body = "{{if}}" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			config := valid
+			test.Modify(&config)
+			_, err := NewSMSSender(config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSMSSender_SendSMS(t *testing.T) {
+	mockSP := &mocks2.SecretProvider{}
+	mockSP.On("GetSecret", "twilio", TwilioAuthTokenKey).Return(map[string]string{TwilioAuthTokenKey: "token"}, nil)
+	mockSP.On("SecretsLastUpdated").Return(time.Time{})
+	dic.Update(di.ServiceConstructorMap{
+		bootstrapContainer.SecretProviderName: func(get di.Get) interface{} {
+			return mockSP
+		},
+	})
+
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", request.URL.Path)
+		username, password, ok := request.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", username)
+		assert.Equal(t, "token", password)
+
+		require.NoError(t, request.ParseForm())
+		assert.Equal(t, "+15550001111", request.PostForm.Get("From"))
+		if request.PostForm.Get("To") == "+15559999999" {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = writer.Write([]byte(`{"code": 21211, "message": "The 'To' number is not a valid phone number."}`))
+			return
+		}
+
+		bodies = append(bodies, request.PostForm.Get("Body"))
+		writer.WriteHeader(http.StatusCreated)
+		_, _ = writer.Write([]byte(`{"sid": "SM` + request.PostForm.Get("To")[1:] + `"}`))
+	}))
+	defer ts.Close()
+
+	sender, err := NewSMSSender(SMSConfig{
+		AccountSid: "AC123",
+		From:       "+15550001111",
+		To:         []string{"+15552223333", "+15554445555"},
+		Body:       "{{.deviceName}}: {{.text}}",
+		SecretPath: "twilio",
+		URL:        ts.URL,
+	})
+	require.NoError(t, err)
+
+	long := strings.Repeat("x", 2000)
+	continuePipeline, result := sender.SendSMS(ctx, `{"deviceName": "boiler-1", "text": "`+long+`"}`)
+	require.True(t, continuePipeline, result)
+	require.Len(t, bodies, 2)
+	assert.Len(t, bodies[0], MaxSMSLength, "body should be truncated")
+	assert.True(t, strings.HasPrefix(bodies[0], "boiler-1: xxx"))
+
+	sender.config.To = []string{"+15559999999"}
+	continuePipeline, result = sender.SendSMS(ctx, `{"deviceName": "boiler-1", "text": "high pressure"}`)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "not a valid phone number")
+}
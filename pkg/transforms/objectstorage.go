@@ -0,0 +1,368 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/google/uuid"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// ObjectStorageAccessKeyIdKey and ObjectStorageSecretAccessKeyKey are the keys of the credentials in the object
+	// storage secret
+	ObjectStorageAccessKeyIdKey     = "accesskeyid"
+	ObjectStorageSecretAccessKeyKey = "secretaccesskey"
+	// ObjectStorageSessionTokenKey is the key of the optional session token of temporary credentials in the object
+	// storage secret
+	ObjectStorageSessionTokenKey = "sessiontoken"
+
+	// DefaultObjectStorageRegion is the region used when no region is given
+	DefaultObjectStorageRegion = "us-east-1"
+)
+
+// ObjectStorageConfig contains the configuration of an ObjectStorageSender
+type ObjectStorageConfig struct {
+	// Endpoint is the object storage service, i.e. "http://minio:9000", the AWS S3 endpoint of the Region when empty
+	Endpoint string
+	// Region is the region the requests are signed for, DefaultObjectStorageRegion when empty
+	Region string
+	// Bucket the objects are written to
+	Bucket string
+	// KeyTemplate is the key of the objects, i.e. "{date}/{device}/{uuid}.json". The {date}, {year}, {month}, {day},
+	// {hour}, {timestamp} and {uuid} placeholders are replaced with the UTC time of the export and a new UUID, the
+	// others with the Event's names, the context's values or tags as the context's FormatTopic does.
+	KeyTemplate string
+	// PathStyle addresses the bucket in the path rather than the host name, as required by most S3-compatible services
+	PathStyle bool
+	// SecretPath is the path of the "accesskeyid" and "secretaccesskey", and the optional "sessiontoken", secrets
+	SecretPath string
+	// ContentType of the objects, application/json when empty
+	ContentType string
+	// Format is HTTPBatchFormatJSON or HTTPBatchFormatNDJSON to combine a batch, i.e. from the Batch function, into a
+	// single object. The data is written as is when empty.
+	Format string
+	// Compress enables gzip encoding of the objects, stored with the gzip Content-Encoding
+	Compress bool
+	// PersistOnError enables use of store & forward when the write fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// ObjectStorageSender writes data to S3-compatible object storage, signing the requests with AWS Signature Version 4
+type ObjectStorageSender struct {
+	config ObjectStorageConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewObjectStorageSender creates, initializes and returns a new instance of ObjectStorageSender
+func NewObjectStorageSender(config ObjectStorageConfig) (*ObjectStorageSender, error) {
+	config.Bucket = strings.TrimSpace(config.Bucket)
+	config.KeyTemplate = strings.TrimLeft(strings.TrimSpace(config.KeyTemplate), "/")
+	config.SecretPath = strings.TrimSpace(config.SecretPath)
+
+	if len(config.Bucket) == 0 {
+		return nil, errors.New("bucket must be set")
+	}
+	if len(config.KeyTemplate) == 0 {
+		return nil, errors.New("key template must be set")
+	}
+	if len(config.SecretPath) == 0 {
+		return nil, errors.New("secret path must be set")
+	}
+
+	config.Region = strings.TrimSpace(config.Region)
+	if len(config.Region) == 0 {
+		config.Region = DefaultObjectStorageRegion
+	}
+
+	config.Endpoint = strings.TrimRight(strings.TrimSpace(config.Endpoint), "/")
+	if len(config.Endpoint) == 0 {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || len(endpoint.Host) == 0 {
+		return nil, fmt.Errorf("invalid endpoint '%s'", config.Endpoint)
+	}
+
+	config.Format = strings.ToLower(strings.TrimSpace(config.Format))
+	if config.Format != "" && config.Format != HTTPBatchFormatJSON && config.Format != HTTPBatchFormatNDJSON {
+		return nil, fmt.Errorf("invalid format '%s', must be '%s' or '%s'", config.Format, HTTPBatchFormatJSON, HTTPBatchFormatNDJSON)
+	}
+
+	if len(strings.TrimSpace(config.ContentType)) == 0 {
+		config.ContentType = common.ContentTypeJSON
+		if config.Format == HTTPBatchFormatNDJSON {
+			config.ContentType = ContentTypeNDJSON
+		}
+	}
+
+	return &ObjectStorageSender{
+		config: config,
+		client: &http.Client{},
+		now:    time.Now,
+	}, nil
+}
+
+// PutObject writes the data from the previous function to a new object whose key is resolved from the key template.
+// The object's ETag is the delivery receipt.
+// This function will return an error and stop the pipeline if no data is received or the write fails.
+func (sender *ObjectStorageSender) PutObject(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function PutObject in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	var exportData []byte
+	var err error
+	if len(sender.config.Format) > 0 {
+		exportData, _, err = combineBatch(data, sender.config.Format)
+	} else {
+		exportData, err = util.CoerceType(data)
+	}
+	if err != nil {
+		return false, fmt.Errorf("function PutObject in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	key, err := formatObjectKey(ctx, sender.config.KeyTemplate, sender.now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("function PutObject in pipeline '%s': unable to format key: %s", ctx.PipelineId(), err.Error())
+	}
+	destination := sender.config.Bucket + "/" + key
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not write %d bytes of data to %s", ctx.PipelineId(), len(exportData), destination)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, sender.config.CircuitBreaker, sender.config.Endpoint, sender.config.PersistOnError,
+		func() { ctx.SetRetryData(exportData) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, sender.config.Endpoint+"/"+sender.config.Bucket)
+
+	etag, err := sender.put(ctx, key, exportData)
+	if err != nil {
+		err = fmt.Errorf("function PutObject in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		sender.config.CircuitBreaker.Failed()
+		sender.setRetryData(ctx, exportData)
+		return false, err
+	}
+
+	sender.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(etag)
+
+	ctx.LoggingClient().Debugf("Wrote %d bytes of data to %s in pipeline '%s'", len(exportData), destination, ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// formatObjectKey resolves the time and UUID placeholders of the key template, then the Event's names, context values
+// and tags
+func formatObjectKey(ctx interfaces.AppFunctionContext, template string, now time.Time) (string, error) {
+	key := strings.NewReplacer(
+		"{date}", now.Format("2006-01-02"),
+		"{year}", now.Format("2006"),
+		"{month}", now.Format("01"),
+		"{day}", now.Format("02"),
+		"{hour}", now.Format("15"),
+		"{timestamp}", strconv.FormatInt(now.UnixNano(), 10),
+		"{uuid}", uuid.NewString(),
+	).Replace(template)
+
+	return ctx.FormatTopic(key)
+}
+
+// put writes the object and returns its ETag
+func (sender *ObjectStorageSender) put(ctx interfaces.AppFunctionContext, key string, exportData []byte) (string, error) {
+	secrets, err := ctx.GetSecret(sender.config.SecretPath)
+	if err != nil {
+		return "", err
+	}
+	if len(secrets[ObjectStorageAccessKeyIdKey]) == 0 || len(secrets[ObjectStorageSecretAccessKeyKey]) == 0 {
+		return "", fmt.Errorf("secret at '%s' must contain '%s' and '%s'",
+			sender.config.SecretPath, ObjectStorageAccessKeyIdKey, ObjectStorageSecretAccessKeyKey)
+	}
+
+	content := exportData
+	if sender.config.Compress {
+		if content, err = gzipBody(exportData); err != nil {
+			return "", fmt.Errorf("unable to compress object: %s", err.Error())
+		}
+	}
+
+	objectUrl, err := url.Parse(sender.config.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	if sender.config.PathStyle {
+		objectUrl.Path = strings.TrimRight(objectUrl.Path, "/") + "/" + sender.config.Bucket + "/" + key
+	} else {
+		objectUrl.Host = sender.config.Bucket + "." + objectUrl.Host
+		objectUrl.Path = strings.TrimRight(objectUrl.Path, "/") + "/" + key
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodPut, objectUrl.String(), bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", sender.config.ContentType)
+	if sender.config.Compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if token := secrets[ObjectStorageSessionTokenKey]; len(token) > 0 {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	signAWSRequest(req, content, secrets[ObjectStorageAccessKeyIdKey], secrets[ObjectStorageSecretAccessKeyKey],
+		sender.config.Region, "s3", sender.now().UTC())
+
+	response, err := sender.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(response.Body)
+		return "", fmt.Errorf("object storage responded with %d HTTP status code: %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	return strings.Trim(response.Header.Get("ETag"), `"`), nil
+}
+
+func (sender *ObjectStorageSender) setRetryData(ctx interfaces.AppFunctionContext, exportData []byte) {
+	if sender.config.PersistOnError {
+		ctx.SetRetryData(exportData)
+	}
+}
+
+// signAWSRequest signs the request, its host and all its headers, with AWS Signature Version 4
+func signAWSRequest(req *http.Request, payload []byte, accessKeyId string, secretAccessKey string, region string,
+	service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256.Sum256(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalPath := awsURIEncode(req.URL.Path, true)
+	if len(canonicalPath) == 0 {
+		canonicalPath = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		canonicalAWSQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	signingKey := []byte("AWS4" + secretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyId, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode encodes the value as AWS expects, every byte but the unreserved characters, and '/' in paths
+func awsURIEncode(value string, path bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || (b == '/' && path) {
+			encoded.WriteByte(b)
+			continue
+		}
+		encoded.WriteString(fmt.Sprintf("%%%02X", b))
+	}
+	return encoded.String()
+}
+
+// canonicalAWSQuery returns the query parameters sorted by name and encoded as AWS expects
+func canonicalAWSQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parameters []string
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		for _, value := range values {
+			parameters = append(parameters, awsURIEncode(name, false)+"="+awsURIEncode(value, false))
+		}
+	}
+	return strings.Join(parameters, "&")
+}
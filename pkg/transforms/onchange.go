@@ -0,0 +1,175 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// ChangeFilter forwards a reading only when its value has changed from the value last forwarded for the device
+// resource, the "report by exception" behavior of SCADA systems. A numeric reading must change by more than the delta,
+// and by more than the delta plus the hysteresis when the change reverses the direction of the last change forwarded,
+// so a value oscillating around a level isn't reported on every reversal. Other readings are forwarded when their
+// value is different.
+type ChangeFilter struct {
+	delta         float64
+	hysteresis    float64
+	resourceNames []string
+	lock          sync.Mutex
+	last          map[string]*changeState
+}
+
+// changeState is the value last forwarded for a device resource
+type changeState struct {
+	value float64
+	// direction is the sign of the last change forwarded, 0 until a change has been forwarded
+	direction float64
+	checksum  string
+}
+
+// NewChangeFilter creates, initializes and returns a new instance of ChangeFilter. delta is the change of a numeric
+// value required to forward it, any change when 0, and hysteresis the additional change required when the value
+// reverses direction. resourceNames, when not empty, limits the readings filtered, otherwise all readings are filtered.
+func NewChangeFilter(delta float64, hysteresis float64, resourceNames []string) *ChangeFilter {
+	return &ChangeFilter{
+		delta:         delta,
+		hysteresis:    hysteresis,
+		resourceNames: resourceNames,
+		last:          make(map[string]*changeState),
+	}
+}
+
+// FilterOnChange removes the readings whose value hasn't changed enough from the value last forwarded for the device
+// resource and stops the pipeline if all the readings are removed. The first reading for a device resource is always
+// forwarded. Readings not in the resource names are passed through.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received or
+// a numeric reading's value can't be parsed.
+func (filter *ChangeFilter) FilterOnChange(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function FilterOnChange in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function FilterOnChange in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+
+	// Parse all the values before remembering any, so a reading that can't be parsed doesn't leave the state of the
+	// other readings updated for an Event that isn't forwarded
+	values := make([]float64, len(event.Readings))
+	for index, reading := range event.Readings {
+		if !isNumericReading(reading) || !selectsResource(filter.resourceNames, reading.ResourceName) {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			return false, fmt.Errorf("function FilterOnChange in pipeline '%s': unable to parse value of reading '%s': %s",
+				ctx.PipelineId(), reading.ResourceName, err.Error())
+		}
+		values[index] = value
+	}
+
+	readings := make([]dtos.BaseReading, 0, len(event.Readings))
+	for index, reading := range event.Readings {
+		if !selectsResource(filter.resourceNames, reading.ResourceName) {
+			readings = append(readings, reading)
+			continue
+		}
+
+		deviceName := reading.DeviceName
+		if deviceName == "" {
+			deviceName = event.DeviceName
+		}
+
+		key := deviceName + "/" + reading.ResourceName
+		var changed bool
+		if isNumericReading(reading) {
+			changed = filter.numericChanged(key, values[index])
+		} else {
+			changed = filter.valueChanged(key, reading)
+		}
+
+		if !changed {
+			ctx.LoggingClient().Debugf("Unchanged reading for resource %s removed in pipeline '%s'", reading.ResourceName, ctx.PipelineId())
+			continue
+		}
+
+		readings = append(readings, reading)
+	}
+
+	if len(readings) == 0 {
+		return false, nil
+	}
+
+	event.Readings = readings
+	return true, event
+}
+
+// numericChanged returns whether the value has changed enough to be forwarded, remembering it if so. Must be called
+// with the lock held.
+func (filter *ChangeFilter) numericChanged(key string, value float64) bool {
+	state, found := filter.last[key]
+	if !found {
+		filter.last[key] = &changeState{value: value}
+		return true
+	}
+
+	change := value - state.value
+	threshold := filter.delta
+	if state.direction != 0 && change*state.direction < 0 {
+		threshold += filter.hysteresis
+	}
+
+	if math.Abs(change) <= threshold {
+		return false
+	}
+
+	state.value = value
+	state.direction = math.Copysign(1, change)
+	return true
+}
+
+// valueChanged returns whether the reading's value is different from the value last forwarded, remembering it if so.
+// Must be called with the lock held.
+func (filter *ChangeFilter) valueChanged(key string, reading dtos.BaseReading) bool {
+	hash := sha256.New()
+	writeReadingValue(hash, reading)
+	checksum := hex.EncodeToString(hash.Sum(nil))
+
+	state, found := filter.last[key]
+	if found && state.checksum == checksum {
+		return false
+	}
+
+	filter.last[key] = &changeState{checksum: checksum}
+	return true
+}
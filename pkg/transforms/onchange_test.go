@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changeEvent(t *testing.T, deviceName string, level float64, state string) dtos.Event {
+	event := dtos.NewEvent("tank", deviceName, "status")
+	require.NoError(t, event.AddSimpleReading("level", common.ValueTypeFloat64, level))
+	require.NoError(t, event.AddSimpleReading("pump", common.ValueTypeString, state))
+	return event
+}
+
+// forwardedResources returns the resource names of the readings forwarded, nil if the pipeline was stopped
+func forwardedResources(t *testing.T, filter *ChangeFilter, event dtos.Event) []string {
+	continuePipeline, result := filter.FilterOnChange(ctx, event)
+	if !continuePipeline {
+		require.Nil(t, result)
+		return nil
+	}
+
+	var names []string
+	for _, reading := range result.(dtos.Event).Readings {
+		names = append(names, reading.ResourceName)
+	}
+	return names
+}
+
+func TestChangeFilter_Delta(t *testing.T) {
+	filter := NewChangeFilter(1, 0, nil)
+
+	assert.Equal(t, []string{"level", "pump"}, forwardedResources(t, filter, changeEvent(t, "tank-1", 10, "on")),
+		"first readings should be forwarded")
+	assert.Nil(t, forwardedResources(t, filter, changeEvent(t, "tank-1", 10.5, "on")), "pipeline should be stopped")
+	assert.Nil(t, forwardedResources(t, filter, changeEvent(t, "tank-1", 11, "on")), "change must be more than the delta")
+	assert.Equal(t, []string{"level"}, forwardedResources(t, filter, changeEvent(t, "tank-1", 11.5, "on")),
+		"change from the last forwarded value should be compared")
+	assert.Equal(t, []string{"pump"}, forwardedResources(t, filter, changeEvent(t, "tank-1", 11, "off")))
+	assert.Equal(t, []string{"level", "pump"}, forwardedResources(t, filter, changeEvent(t, "tank-2", 11, "off")),
+		"each device should be filtered separately")
+}
+
+func TestChangeFilter_Hysteresis(t *testing.T) {
+	filter := NewChangeFilter(1, 2, []string{"level"})
+
+	levels := []float64{10, 11.5, 10, 9, 8, 9.5, 11.5, 12}
+	expected := [][]string{
+		{"level", "pump"},
+		{"level", "pump"},
+		{"pump"}, // reverses direction, the change must be more than 3
+		{"pump"},
+		{"level", "pump"},
+		{"pump"},
+		{"level", "pump"},
+		{"pump"},
+	}
+
+	for index, level := range levels {
+		assert.Equal(t, expected[index], forwardedResources(t, filter, changeEvent(t, "tank-1", level, "on")),
+			"level %v", level)
+	}
+}
+
+func TestChangeFilter_Errors(t *testing.T) {
+	badValue := dtos.NewEvent("tank", "tank-1", "status")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "level", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "full"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "10", "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'level'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewChangeFilter(0, 0, nil).FilterOnChange(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
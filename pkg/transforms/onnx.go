@@ -0,0 +1,355 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	// Register the JPEG and PNG decoders used by image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	onnx "github.com/owulveryck/onnx-go"
+	"github.com/owulveryck/onnx-go/backend/x/gorgonnx"
+	"gorgonia.org/tensor"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	defaultPredictionName = "prediction"
+	// DefaultModelDownloadTimeout is the time allowed to download a model from an http(s) URL
+	DefaultModelDownloadTimeout = time.Minute
+)
+
+// ONNXInference runs an ONNX model against the numeric or image readings of an Event and appends
+// the model's predictions to the Event as new readings.
+type ONNXInference struct {
+	modelLocation  string
+	resourceNames  []string
+	predictionName string
+	client         *http.Client
+	loadLock       sync.Mutex
+	lock           sync.Mutex
+	model          *onnx.Model
+	backend        *gorgonnx.Graph
+}
+
+// NewONNXInference creates, initializes and returns a new instance of ONNXInference.
+// modelLocation is the file path or http(s) URL of the ONNX model, which is loaded when the first Event is received.
+// resourceNames, when not empty, specifies the readings used as the model's input, in order. Otherwise all the numeric
+// readings, or the first image reading, of the Event are used. predictionName is the resource name of the prediction
+// reading(s), suffixed with the output's index when the model has multiple outputs. Defaults to "prediction".
+func NewONNXInference(modelLocation string, resourceNames []string, predictionName string) *ONNXInference {
+	if predictionName == "" {
+		predictionName = defaultPredictionName
+	}
+
+	return &ONNXInference{
+		modelLocation:  modelLocation,
+		resourceNames:  resourceNames,
+		predictionName: predictionName,
+		client:         &http.Client{Timeout: DefaultModelDownloadTimeout},
+	}
+}
+
+// Predict runs the model using the Event's readings as the input and adds a reading for each of the model's outputs.
+// A single value output is added as a Float32 reading, otherwise as a Float32Array reading.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received or
+// the model can't be run.
+func (inference *ONNXInference) Predict(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Predict in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function Predict in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Running ONNX model inference in pipeline '%s'", ctx.PipelineId())
+
+	// The model is loaded before taking the inference lock, so a slow download doesn't block the inferences
+	if err := inference.loadModel(); err != nil {
+		return false, fmt.Errorf("function Predict in pipeline '%s': unable to load ONNX model '%s': %s",
+			ctx.PipelineId(), inference.modelLocation, err.Error())
+	}
+
+	// The model's graph holds the input and output values so only one inference can run at a time.
+	inference.lock.Lock()
+	defer inference.lock.Unlock()
+
+	outputs, err := inference.run(event)
+	if err != nil {
+		return false, fmt.Errorf("function Predict in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	// Copy the readings so the predictions are not added to the backing array of the received Event
+	event.Readings = append([]dtos.BaseReading{}, event.Readings...)
+
+	for index, values := range outputs {
+		name := inference.predictionName
+		if len(outputs) > 1 {
+			name = fmt.Sprintf("%s_%d", name, index)
+		}
+
+		if len(values) == 1 {
+			err = event.AddSimpleReading(name, common.ValueTypeFloat32, values[0])
+		} else {
+			err = event.AddSimpleReading(name, common.ValueTypeFloat32Array, values)
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("function Predict in pipeline '%s': unable to add prediction reading: %s", ctx.PipelineId(), err.Error())
+		}
+	}
+
+	return true, event
+}
+
+// loadModel loads the model unless already loaded. Only one load runs at a time, so the model is loaded once.
+func (inference *ONNXInference) loadModel() error {
+	inference.loadLock.Lock()
+	defer inference.loadLock.Unlock()
+
+	if inference.model != nil {
+		return nil
+	}
+
+	var content []byte
+	var err error
+
+	if strings.HasPrefix(inference.modelLocation, "http://") || strings.HasPrefix(inference.modelLocation, "https://") {
+		content, err = downloadModel(inference.client, inference.modelLocation)
+	} else {
+		content, err = ioutil.ReadFile(inference.modelLocation)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	backend := gorgonnx.NewGraph()
+	model := onnx.NewModel(backend)
+	if err := model.UnmarshalBinary(content); err != nil {
+		return err
+	}
+
+	if len(model.Input) == 0 {
+		return errors.New("model has no input")
+	}
+
+	inference.model = model
+	inference.backend = backend
+	return nil
+}
+
+func downloadModel(client *http.Client, url string) ([]byte, error) {
+	response, err := client.Get(url) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("download failed with status %d", response.StatusCode)
+	}
+
+	return ioutil.ReadAll(response.Body)
+}
+
+func (inference *ONNXInference) run(event dtos.Event) ([][]float32, error) {
+	shape := inference.model.GetInputTensors()[0].Shape()
+
+	values, err := inference.inputValues(event, shape)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != shape.TotalSize() {
+		return nil, fmt.Errorf("model input of shape %v requires %d values, but %d were found in the Event",
+			shape, shape.TotalSize(), len(values))
+	}
+
+	input := tensor.New(tensor.WithShape(shape...), tensor.WithBacking(values))
+	if err := inference.model.SetInput(0, input); err != nil {
+		return nil, err
+	}
+
+	if err := inference.backend.Run(); err != nil {
+		return nil, fmt.Errorf("model run failed: %s", err.Error())
+	}
+
+	outputTensors, err := inference.model.GetOutputTensors()
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs [][]float32
+	for _, output := range outputTensors {
+		switch backing := output.Data().(type) {
+		case []float32:
+			outputs = append(outputs, append([]float32{}, backing...))
+		case float32:
+			outputs = append(outputs, []float32{backing})
+		case []float64:
+			converted := make([]float32, len(backing))
+			for i, value := range backing {
+				converted[i] = float32(value)
+			}
+			outputs = append(outputs, converted)
+		case float64:
+			outputs = append(outputs, []float32{float32(backing)})
+		default:
+			return nil, fmt.Errorf("unsupported model output type %T", backing)
+		}
+	}
+
+	return outputs, nil
+}
+
+// inputValues returns the input for the model from the Event's image reading, if there is one, or numeric readings
+func (inference *ONNXInference) inputValues(event dtos.Event, shape tensor.Shape) ([]float32, error) {
+	var readings []dtos.BaseReading
+
+	if len(inference.resourceNames) == 0 {
+		readings = event.Readings
+	} else {
+		for _, name := range inference.resourceNames {
+			found := false
+			for _, reading := range event.Readings {
+				if reading.ResourceName == name {
+					readings = append(readings, reading)
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("reading '%s' not found in the Event", name)
+			}
+		}
+	}
+
+	for _, reading := range readings {
+		if reading.ValueType == common.ValueTypeBinary && strings.HasPrefix(reading.MediaType, "image/") {
+			return imageValues(reading.BinaryValue, shape)
+		}
+	}
+
+	var values []float32
+	for _, reading := range readings {
+		if reading.ValueType == common.ValueTypeBinary || reading.ValueType == common.ValueTypeObject ||
+			reading.ValueType == common.ValueTypeString || reading.ValueType == common.ValueTypeBool {
+			if len(inference.resourceNames) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("reading '%s' of type %s is not numeric", reading.ResourceName, reading.ValueType)
+		}
+
+		readingValues, err := numericValues(reading)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range readingValues {
+			values = append(values, float32(value))
+		}
+	}
+
+	return values, nil
+}
+
+// numericValues parses the value(s) of a numeric or numeric array reading
+func numericValues(reading dtos.BaseReading) ([]float64, error) {
+	text := reading.Value
+	if strings.HasSuffix(reading.ValueType, "Array") {
+		text = strings.Trim(text, "[]")
+	}
+
+	var values []float64
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' }) {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse value of reading '%s': %s", reading.ResourceName, err.Error())
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// imageValues decodes the image into channel, height, width order with values scaled to 0-1. The image's
+// size must match the last two dimensions of the model's input shape and its number of channels (1 or 3) the third last.
+func imageValues(content []byte, shape tensor.Shape) ([]float32, error) {
+	if len(shape) < 3 {
+		return nil, fmt.Errorf("model input of shape %v is not for an image", shape)
+	}
+
+	channels := shape[len(shape)-3]
+	height := shape[len(shape)-2]
+	width := shape[len(shape)-1]
+
+	if channels != 1 && channels != 3 {
+		return nil, fmt.Errorf("model input of shape %v must have 1 or 3 channels for an image", shape)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode image: %s", err.Error())
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() != width || bounds.Dy() != height {
+		return nil, fmt.Errorf("image size %dx%d doesn't match the model's input size %dx%d", bounds.Dx(), bounds.Dy(), width, height)
+	}
+
+	values := make([]float32, channels*height*width)
+	plane := height * width
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pixel := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			offset := y*width + x
+
+			if channels == 1 {
+				gray := color.GrayModel.Convert(pixel).(color.Gray)
+				values[offset] = float32(gray.Y) / 255
+				continue
+			}
+
+			r, g, b, _ := pixel.RGBA()
+			values[offset] = float32(r) / 0xffff
+			values[plane+offset] = float32(g) / 0xffff
+			values[2*plane+offset] = float32(b) / 0xffff
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// reluModel encodes an ONNX model with a single Relu node for the input shape, which avoids checking in a binary model
+func reluModel(shape ...int64) []byte {
+	message := func(number protowire.Number, content []byte) []byte {
+		return protowire.AppendBytes(protowire.AppendTag(nil, number, protowire.BytesType), content)
+	}
+	text := func(number protowire.Number, value string) []byte {
+		return message(number, []byte(value))
+	}
+	varint := func(number protowire.Number, value uint64) []byte {
+		return protowire.AppendVarint(protowire.AppendTag(nil, number, protowire.VarintType), value)
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	var dims []byte
+	for _, dim := range shape {
+		dims = append(dims, message(1, varint(1, uint64(dim)))...)
+	}
+
+	tensorType := message(2, message(1, join(varint(1, 1), message(2, dims))))
+	input := join(text(1, "X"), tensorType)
+	output := join(text(1, "Y"), tensorType)
+	node := join(text(1, "X"), text(2, "Y"), text(3, "relu"), text(4, "Relu"))
+	graph := join(message(1, node), text(2, "test"), message(11, input), message(12, output))
+
+	return join(varint(1, 3), message(7, graph), message(8, varint(2, 8)))
+}
+
+func writeModel(t *testing.T, shape ...int64) string {
+	path := filepath.Join(t.TempDir(), "model.onnx")
+	require.NoError(t, os.WriteFile(path, reluModel(shape...), 0600))
+	return path
+}
+
+func TestONNXInference_PredictNumericReadings(t *testing.T) {
+	event := dtos.NewEvent("profile", "device", "source")
+	require.NoError(t, event.AddSimpleReading("x", common.ValueTypeFloat32, float32(-1.5)))
+	require.NoError(t, event.AddSimpleReading("label", common.ValueTypeString, "ignored"))
+	require.NoError(t, event.AddSimpleReading("y", common.ValueTypeInt32, int32(2)))
+	require.NoError(t, event.AddSimpleReading("z", common.ValueTypeFloat64Array, []float64{3, -4}))
+
+	inference := NewONNXInference(writeModel(t, 1, 4), nil, "")
+	continuePipeline, result := inference.Predict(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	predicted, ok := result.(dtos.Event)
+	require.True(t, ok)
+	require.Len(t, predicted.Readings, 5)
+	assert.Len(t, event.Readings, 4, "received Event should not be modified")
+
+	prediction := predicted.Readings[4]
+	assert.Equal(t, defaultPredictionName, prediction.ResourceName)
+	assert.Equal(t, common.ValueTypeFloat32Array, prediction.ValueType)
+	assert.Equal(t, "[-0.000000e+00, 2.000000e+00, 3.000000e+00, -0.000000e+00]", prediction.Value)
+}
+
+func TestONNXInference_PredictResourceNames(t *testing.T) {
+	event := dtos.NewEvent("profile", "device", "source")
+	require.NoError(t, event.AddSimpleReading("x", common.ValueTypeFloat32, float32(1)))
+	require.NoError(t, event.AddSimpleReading("y", common.ValueTypeFloat32, float32(2)))
+
+	inference := NewONNXInference(writeModel(t, 1, 1), []string{"y"}, "score")
+	continuePipeline, result := inference.Predict(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	predicted := result.(dtos.Event)
+	require.Len(t, predicted.Readings, 3)
+	assert.Equal(t, "score", predicted.Readings[2].ResourceName)
+	assert.Equal(t, common.ValueTypeFloat32, predicted.Readings[2].ValueType)
+
+	inference = NewONNXInference(writeModel(t, 1, 1), []string{"missing"}, "")
+	continuePipeline, result = inference.Predict(ctx, event)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "reading 'missing' not found")
+}
+
+func TestONNXInference_PredictImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.Gray{Y: 255})
+	img.Set(1, 1, color.Gray{Y: 51})
+	encoded := &bytes.Buffer{}
+	require.NoError(t, png.Encode(encoded, img))
+
+	event := dtos.NewEvent("profile", "camera", "frame")
+	event.AddBinaryReading("frame", encoded.Bytes(), "image/png")
+
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(reluModel(1, 1, 2, 2))
+	}))
+	defer modelServer.Close()
+
+	inference := NewONNXInference(modelServer.URL, nil, "")
+	continuePipeline, result := inference.Predict(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	predicted := result.(dtos.Event)
+	require.Len(t, predicted.Readings, 2)
+	assert.Equal(t, "[1.000000e+00, 0.000000e+00, 0.000000e+00, 2.000000e-01]", predicted.Readings[1].Value)
+
+	inference = NewONNXInference(writeModel(t, 1, 1, 3, 3), nil, "")
+	continuePipeline, result = inference.Predict(ctx, event)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "doesn't match the model's input size")
+}
+
+func TestONNXInference_PredictErrors(t *testing.T) {
+	event := dtos.NewEvent("profile", "device", "source")
+	require.NoError(t, event.AddSimpleReading("x", common.ValueTypeFloat32, float32(1)))
+
+	tests := []struct {
+		Name          string
+		ModelLocation string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", writeModel(t, 1, 1), nil, "No Data Received"},
+		{"Not an Event", writeModel(t, 1, 1), "not an event", "type received is not an Event"},
+		{"Missing model", filepath.Join(t.TempDir(), "missing.onnx"), event, "unable to load ONNX model"},
+		{"Wrong input size", writeModel(t, 1, 2), event, "requires 2 values, but 1 were found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			inference := NewONNXInference(test.ModelLocation, nil, "")
+			continuePipeline, result := inference.Predict(ctx, test.Data)
+			require.False(t, continuePipeline)
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestONNXInference_PredictDownloadTimeout(t *testing.T) {
+	event := dtos.NewEvent("profile", "device", "source")
+	require.NoError(t, event.AddSimpleReading("x", common.ValueTypeFloat32, float32(1)))
+
+	release := make(chan struct{})
+	modelServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer modelServer.Close()
+	defer close(release)
+
+	inference := NewONNXInference(modelServer.URL, nil, "")
+	inference.client.Timeout = 50 * time.Millisecond
+
+	continuePipeline, result := inference.Predict(ctx, event)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to load ONNX model")
+}
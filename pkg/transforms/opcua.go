@@ -0,0 +1,428 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	// OPCUAUsernameKey and OPCUAPasswordKey are the keys of the optional user credentials in the OPC-UA secret
+	OPCUAUsernameKey = "username"
+	OPCUAPasswordKey = "password"
+	// OPCUAClientCertKey and OPCUAClientKeyKey are the keys of the PEM encoded client certificate and RSA private key
+	// in the OPC-UA secret, required by the Sign and SignAndEncrypt security modes
+	OPCUAClientCertKey = "clientcert"
+	OPCUAClientKeyKey  = "clientkey"
+
+	// DefaultOPCUATimeout is the timeout of the connection and the write requests when no timeout is given
+	DefaultOPCUATimeout = 10 * time.Second
+)
+
+// OPCUAWriterConfig contains the configuration of an OPCUAWriter
+type OPCUAWriterConfig struct {
+	// Endpoint of the OPC-UA server, i.e. "opc.tcp://plc:4840"
+	Endpoint string
+	// Nodes maps the readings to the ids of the nodes their values are written to, i.e. "ns=2;s=Pump.Speed". A
+	// reading is mapped by "deviceName/resourceName", or by its resource name for all the devices. The readings that
+	// aren't mapped are skipped.
+	Nodes map[string]string
+	// SecurityPolicy is the security policy of the endpoint, i.e. "Basic256Sha256", "None" when empty
+	SecurityPolicy string
+	// SecurityMode is "None", the default, "Sign" or "SignAndEncrypt"
+	SecurityMode string
+	// SecretPath is the optional path of the "username" and "password" secrets, and the "clientcert" and "clientkey"
+	// secrets for the Sign and SignAndEncrypt modes
+	SecretPath string
+	// Timeout of the connection and the write requests, DefaultOPCUATimeout when 0
+	Timeout time.Duration
+	// PersistOnError enables use of store & forward when the write fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// opcuaClient is the part of the OPC-UA client used by the OPCUAWriter
+type opcuaClient interface {
+	Write(req *ua.WriteRequest) (*ua.WriteResponse, error)
+	Close() error
+}
+
+// OPCUAWriter writes the values of readings to the nodes of an OPC-UA server, i.e. to update PLC or SCADA tags
+type OPCUAWriter struct {
+	config  OPCUAWriterConfig
+	nodes   map[string]*ua.NodeID
+	mode    ua.MessageSecurityMode
+	connect func(ctx interfaces.AppFunctionContext) (opcuaClient, error)
+
+	lock                 sync.Mutex
+	client               opcuaClient
+	secretsLastRetrieved time.Time
+}
+
+// NewOPCUAWriter creates, initializes and returns a new instance of OPCUAWriter
+func NewOPCUAWriter(config OPCUAWriterConfig) (*OPCUAWriter, error) {
+	config.Endpoint = strings.TrimSpace(config.Endpoint)
+	if !strings.HasPrefix(config.Endpoint, "opc.tcp://") {
+		return nil, fmt.Errorf("invalid endpoint '%s', must start with opc.tcp://", config.Endpoint)
+	}
+
+	if len(config.Nodes) == 0 {
+		return nil, errors.New("at least one node must be mapped")
+	}
+
+	nodes := make(map[string]*ua.NodeID)
+	for reading, node := range config.Nodes {
+		nodeId, err := ua.ParseNodeID(strings.TrimSpace(node))
+		if err != nil {
+			return nil, fmt.Errorf("invalid node id '%s' for '%s': %s", node, reading, err.Error())
+		}
+		nodes[strings.TrimSpace(reading)] = nodeId
+	}
+
+	config.SecurityPolicy = strings.TrimSpace(config.SecurityPolicy)
+	if len(config.SecurityPolicy) == 0 {
+		config.SecurityPolicy = "None"
+	}
+
+	config.SecurityMode = strings.TrimSpace(config.SecurityMode)
+	if len(config.SecurityMode) == 0 {
+		config.SecurityMode = "None"
+	}
+	mode := ua.MessageSecurityModeFromString(config.SecurityMode)
+	if mode == ua.MessageSecurityModeInvalid {
+		return nil, fmt.Errorf("invalid security mode '%s', must be 'None', 'Sign' or 'SignAndEncrypt'", config.SecurityMode)
+	}
+	if mode != ua.MessageSecurityModeNone && len(strings.TrimSpace(config.SecretPath)) == 0 {
+		return nil, fmt.Errorf("secret path must be set for the '%s' security mode", config.SecurityMode)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultOPCUATimeout
+	}
+
+	writer := &OPCUAWriter{
+		config: config,
+		nodes:  nodes,
+		mode:   mode,
+	}
+	writer.connect = writer.connectClient
+
+	return writer, nil
+}
+
+// WriteNodes writes the values of the mapped readings of the Event, or Events, from the previous function to their
+// nodes in a single write request. The value's type is the reading's value type, which must match the node's data
+// type. The Events are stored for retry as a JSON array on error.
+// This function will return an error and stop the pipeline if no data is received, the data isn't Events, a value
+// can't be converted or any of the writes fails.
+func (writer *OPCUAWriter) WriteNodes(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function WriteNodes in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	events, err := exportEvents(data)
+	if err != nil {
+		return false, fmt.Errorf("function WriteNodes in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	values, err := writer.writeValues(events)
+	if err != nil {
+		return false, fmt.Errorf("function WriteNodes in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if len(values) == 0 {
+		ctx.LoggingClient().Debugf("No mapped readings to write to %s in pipeline '%s'", writer.config.Endpoint, ctx.PipelineId())
+		return true, nil
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not write %d values to %s", ctx.PipelineId(), len(values), writer.config.Endpoint)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, writer.config.CircuitBreaker, writer.config.Endpoint, writer.config.PersistOnError,
+		func() { writer.storeEvents(ctx, events, true) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, writer.config.Endpoint)
+
+	if err := writer.write(ctx, values); err != nil {
+		err = fmt.Errorf("function WriteNodes in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		writer.config.CircuitBreaker.Failed()
+		writer.storeEvents(ctx, events, false)
+		return false, err
+	}
+
+	writer.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(strconv.Itoa(len(values)))
+
+	ctx.LoggingClient().Debugf("Wrote %d values to %s in pipeline '%s'", len(values), writer.config.Endpoint, ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// writeValues returns the values of the mapped readings, the latest value of a node being written when several
+// readings are mapped to it
+func (writer *OPCUAWriter) writeValues(events []dtos.Event) ([]*ua.WriteValue, error) {
+	var values []*ua.WriteValue
+	indexes := make(map[string]int)
+	for _, event := range events {
+		for _, reading := range event.Readings {
+			nodeId, found := writer.nodes[reading.DeviceName+"/"+reading.ResourceName]
+			if !found {
+				if nodeId, found = writer.nodes[reading.ResourceName]; !found {
+					continue
+				}
+			}
+
+			value, err := opcuaValue(reading)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert value of reading '%s' for node '%s': %s",
+					reading.ResourceName, nodeId.String(), err.Error())
+			}
+
+			variant, err := ua.NewVariant(value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert value of reading '%s' for node '%s': %s",
+					reading.ResourceName, nodeId.String(), err.Error())
+			}
+
+			writeValue := &ua.WriteValue{
+				NodeID:      nodeId,
+				AttributeID: ua.AttributeIDValue,
+				Value: &ua.DataValue{
+					EncodingMask: ua.DataValueValue,
+					Value:        variant,
+				},
+			}
+
+			if index, found := indexes[nodeId.String()]; found {
+				values[index] = writeValue
+				continue
+			}
+			indexes[nodeId.String()] = len(values)
+			values = append(values, writeValue)
+		}
+	}
+
+	return values, nil
+}
+
+// opcuaValue returns the value of the reading as the Go type of its value type
+func opcuaValue(reading dtos.BaseReading) (interface{}, error) {
+	value := reading.Value
+	switch reading.ValueType {
+	case common.ValueTypeBool:
+		return strconv.ParseBool(value)
+	case common.ValueTypeString:
+		return value, nil
+	case common.ValueTypeUint8:
+		parsed, err := strconv.ParseUint(value, 10, 8)
+		return uint8(parsed), err
+	case common.ValueTypeUint16:
+		parsed, err := strconv.ParseUint(value, 10, 16)
+		return uint16(parsed), err
+	case common.ValueTypeUint32:
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		return uint32(parsed), err
+	case common.ValueTypeUint64:
+		return strconv.ParseUint(value, 10, 64)
+	case common.ValueTypeInt8:
+		parsed, err := strconv.ParseInt(value, 10, 8)
+		return int8(parsed), err
+	case common.ValueTypeInt16:
+		parsed, err := strconv.ParseInt(value, 10, 16)
+		return int16(parsed), err
+	case common.ValueTypeInt32:
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		return int32(parsed), err
+	case common.ValueTypeInt64:
+		return strconv.ParseInt(value, 10, 64)
+	case common.ValueTypeFloat32:
+		parsed, err := strconv.ParseFloat(value, 32)
+		return float32(parsed), err
+	case common.ValueTypeFloat64:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return nil, fmt.Errorf("value type '%s' not supported", reading.ValueType)
+	}
+}
+
+// write sends the write request, connecting to the server first when not connected. The connection is closed when the
+// request fails, so the next export connects again.
+func (writer *OPCUAWriter) write(ctx interfaces.AppFunctionContext, values []*ua.WriteValue) error {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	// The connection made with the previous credentials is closed when the secrets have been updated
+	if writer.client != nil && len(writer.config.SecretPath) > 0 && writer.secretsLastRetrieved.Before(ctx.SecretsLastUpdated()) {
+		_ = writer.client.Close()
+		writer.client = nil
+	}
+
+	if writer.client == nil {
+		client, err := writer.connect(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to connect to %s: %s", writer.config.Endpoint, err.Error())
+		}
+		writer.client = client
+		writer.secretsLastRetrieved = time.Now()
+		ctx.LoggingClient().Infof("Connected to OPC-UA server %s for export in pipeline '%s'", writer.config.Endpoint, ctx.PipelineId())
+	}
+
+	response, err := writer.client.Write(&ua.WriteRequest{NodesToWrite: values})
+	if err != nil {
+		_ = writer.client.Close()
+		writer.client = nil
+		return fmt.Errorf("unable to write to %s: %s", writer.config.Endpoint, err.Error())
+	}
+
+	var failures []string
+	for index, status := range response.Results {
+		if status != ua.StatusOK && index < len(values) {
+			failures = append(failures, fmt.Sprintf("%s: %s", values[index].NodeID.String(), status.Error()))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d writes failed: %s", len(failures), len(values), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// connectClient connects to the endpoint matching the security policy and mode, with the credentials from the secrets
+func (writer *OPCUAWriter) connectClient(ctx interfaces.AppFunctionContext) (opcuaClient, error) {
+	connectCtx, cancel := context.WithTimeout(ctx.Context(), writer.config.Timeout)
+	defer cancel()
+
+	endpoints, err := opcua.GetEndpoints(connectCtx, writer.config.Endpoint, opcua.DialTimeout(writer.config.Timeout))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get endpoints: %s", err.Error())
+	}
+
+	endpoint := opcua.SelectEndpoint(endpoints, writer.config.SecurityPolicy, writer.mode)
+	if endpoint == nil {
+		return nil, fmt.Errorf("no endpoint with the '%s' security policy and '%s' security mode",
+			writer.config.SecurityPolicy, writer.config.SecurityMode)
+	}
+
+	options := []opcua.Option{
+		opcua.DialTimeout(writer.config.Timeout),
+		opcua.RequestTimeout(writer.config.Timeout),
+		opcua.SecurityPolicy(writer.config.SecurityPolicy),
+		opcua.SecurityMode(writer.mode),
+	}
+
+	authType := ua.UserTokenTypeAnonymous
+	if len(writer.config.SecretPath) > 0 {
+		secrets, err := ctx.GetSecret(writer.config.SecretPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if username := secrets[OPCUAUsernameKey]; len(username) > 0 {
+			authType = ua.UserTokenTypeUserName
+			options = append(options, opcua.AuthUsername(username, secrets[OPCUAPasswordKey]))
+		} else {
+			options = append(options, opcua.AuthAnonymous())
+		}
+
+		if writer.mode != ua.MessageSecurityModeNone {
+			certificate, key, err := opcuaClientCertificate(secrets)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, opcua.Certificate(certificate), opcua.PrivateKey(key))
+		}
+	} else {
+		options = append(options, opcua.AuthAnonymous())
+	}
+
+	options = append(options, opcua.SecurityFromEndpoint(endpoint, authType))
+
+	client := opcua.NewClient(writer.config.Endpoint, options...)
+	if err := client.Connect(connectCtx); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// opcuaClientCertificate returns the DER encoded client certificate and the RSA private key from the secrets
+func opcuaClientCertificate(secrets map[string]string) ([]byte, *rsa.PrivateKey, error) {
+	certBlock, _ := pem.Decode([]byte(secrets[OPCUAClientCertKey]))
+	if certBlock == nil {
+		return nil, nil, fmt.Errorf("'%s' secret isn't a PEM encoded certificate", OPCUAClientCertKey)
+	}
+
+	keyBlock, _ := pem.Decode([]byte(secrets[OPCUAClientKeyKey]))
+	if keyBlock == nil {
+		return nil, nil, fmt.Errorf("'%s' secret isn't a PEM encoded private key", OPCUAClientKeyKey)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes); err == nil {
+		return certBlock.Bytes, key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse '%s' secret: %s", OPCUAClientKeyKey, err.Error())
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, fmt.Errorf("'%s' secret must be an RSA private key", OPCUAClientKeyKey)
+	}
+
+	return certBlock.Bytes, key, nil
+}
+
+// storeEvents stores the Events for retry when persistOnError is enabled or always is set
+func (writer *OPCUAWriter) storeEvents(ctx interfaces.AppFunctionContext, events []dtos.Event, always bool) {
+	if !writer.config.PersistOnError && !always {
+		return
+	}
+
+	retryData, err := json.Marshal(events)
+	if err != nil {
+		ctx.LoggingClient().Errorf("Unable to marshal the Events that failed to be written for retry in pipeline '%s': %s",
+			ctx.PipelineId(), err.Error())
+		return
+	}
+	ctx.SetRetryData(retryData)
+}
@@ -0,0 +1,205 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/gopcua/opcua/ua"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// fakeOPCUAClient records the write requests, answering with the statuses set
+type fakeOPCUAClient struct {
+	requests []*ua.WriteRequest
+	statuses []ua.StatusCode
+	err      error
+	closed   bool
+}
+
+func (client *fakeOPCUAClient) Write(req *ua.WriteRequest) (*ua.WriteResponse, error) {
+	client.requests = append(client.requests, req)
+	if client.err != nil {
+		return nil, client.err
+	}
+
+	results := client.statuses
+	if results == nil {
+		results = make([]ua.StatusCode, len(req.NodesToWrite))
+	}
+	return &ua.WriteResponse{Results: results}, nil
+}
+
+func (client *fakeOPCUAClient) Close() error {
+	client.closed = true
+	return nil
+}
+
+func newOPCUATestWriter(t *testing.T, config OPCUAWriterConfig, client *fakeOPCUAClient) (*OPCUAWriter, *int) {
+	config.Endpoint = "opc.tcp://plc:4840"
+	writer, err := NewOPCUAWriter(config)
+	require.NoError(t, err)
+
+	var connects int
+	writer.connect = func(_ interfaces.AppFunctionContext) (opcuaClient, error) {
+		connects++
+		return client, nil
+	}
+
+	return writer, &connects
+}
+
+func opcuaTestEvent() dtos.Event {
+	event := dtos.NewEvent("pump-profile", "pump-1", "status")
+	_ = event.AddSimpleReading("speed", common.ValueTypeFloat64, 1450.5)
+	_ = event.AddSimpleReading("running", common.ValueTypeBool, true)
+	_ = event.AddSimpleReading("alarms", common.ValueTypeUint16, uint16(3))
+	_ = event.AddSimpleReading("unmapped", common.ValueTypeInt32, int32(7))
+	return event
+}
+
+func TestNewOPCUAWriter(t *testing.T) {
+	valid := OPCUAWriterConfig{Endpoint: "opc.tcp://plc:4840", Nodes: map[string]string{"speed": "ns=2;s=Pump.Speed"}}
+
+	writer, err := NewOPCUAWriter(valid)
+	require.NoError(t, err)
+	assert.Equal(t, "None", writer.config.SecurityPolicy)
+	assert.Equal(t, ua.MessageSecurityModeNone, writer.mode)
+	assert.Equal(t, DefaultOPCUATimeout, writer.config.Timeout)
+
+	tests := []struct {
+		Name   string
+		Modify func(config *OPCUAWriterConfig)
+	}{
+		{"Bad endpoint", func(config *OPCUAWriterConfig) { config.Endpoint = "http://plc:4840" }},
+		{"No nodes", func(config *OPCUAWriterConfig) { config.Nodes = nil }},
+		{"Bad node id", func(config *OPCUAWriterConfig) { config.Nodes = map[string]string{"speed": "ns=x;s=Pump"} }},
+		{"Bad security mode", func(config *OPCUAWriterConfig) { config.SecurityMode = "Encrypt" }},
+		{"Sign without secrets", func(config *OPCUAWriterConfig) { config.SecurityMode = "Sign" }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			config := valid
+			test.Modify(&config)
+			_, err := NewOPCUAWriter(config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestOPCUAWriter_WriteNodes(t *testing.T) {
+	client := &fakeOPCUAClient{}
+	writer, connects := newOPCUATestWriter(t, OPCUAWriterConfig{Nodes: map[string]string{
+		"pump-1/speed": "ns=2;s=Pump1.Speed",
+		"speed":        "ns=2;s=Pump.Speed",
+		"running":      "ns=2;i=1001",
+		"alarms":       "ns=2;s=Pump1.Alarms",
+	}}, client)
+
+	other := dtos.NewEvent("pump-profile", "pump-2", "status")
+	_ = other.AddSimpleReading("speed", common.ValueTypeFloat64, 900.0)
+
+	continuePipeline, result := writer.WriteNodes(ctx, []dtos.Event{opcuaTestEvent(), other})
+	require.True(t, continuePipeline, result)
+	require.Len(t, client.requests, 1, "all values should be written in a single request")
+
+	written := make(map[string]interface{})
+	for _, value := range client.requests[0].NodesToWrite {
+		assert.Equal(t, ua.AttributeIDValue, value.AttributeID)
+		written[value.NodeID.String()] = value.Value.Value.Value()
+	}
+	assert.Equal(t, map[string]interface{}{
+		"ns=2;s=Pump1.Speed":  1450.5,
+		"ns=2;s=Pump.Speed":   900.0,
+		"ns=2;i=1001":         true,
+		"ns=2;s=Pump1.Alarms": uint16(3),
+	}, written)
+
+	continuePipeline, result = writer.WriteNodes(ctx, opcuaTestEvent())
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, 1, *connects, "connection should be reused")
+
+	continuePipeline, result = writer.WriteNodes(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestOPCUAWriter_WriteNodesUnmapped(t *testing.T) {
+	client := &fakeOPCUAClient{}
+	writer, connects := newOPCUATestWriter(t, OPCUAWriterConfig{Nodes: map[string]string{"pump-2/speed": "ns=2;s=Pump2.Speed"}}, client)
+
+	continuePipeline, result := writer.WriteNodes(ctx, opcuaTestEvent())
+	require.True(t, continuePipeline, result)
+	assert.Nil(t, result)
+	assert.Equal(t, 0, *connects, "nothing should be written when no reading is mapped")
+}
+
+func TestOPCUAWriter_WriteNodesBadValue(t *testing.T) {
+	writer, _ := newOPCUATestWriter(t, OPCUAWriterConfig{Nodes: map[string]string{"level": "ns=2;s=Tank.Level"}}, &fakeOPCUAClient{})
+
+	event := dtos.NewEvent("tank-profile", "tank", "level")
+	event.Readings = append(event.Readings, dtos.BaseReading{
+		ResourceName: "level",
+		ValueType:    common.ValueTypeUint8,
+		SimpleReading: dtos.SimpleReading{
+			Value: "300",
+		},
+	})
+
+	continuePipeline, result := writer.WriteNodes(ctx, event)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to convert value of reading 'level'")
+}
+
+func TestOPCUAWriter_WriteNodesPersistOnError(t *testing.T) {
+	client := &fakeOPCUAClient{statuses: []ua.StatusCode{ua.StatusOK, ua.StatusBadTypeMismatch, ua.StatusOK}}
+	writer, connects := newOPCUATestWriter(t, OPCUAWriterConfig{
+		Nodes:          map[string]string{"speed": "ns=2;s=Pump.Speed", "running": "ns=2;i=1001", "alarms": "ns=2;i=1002"},
+		PersistOnError: true,
+	}, client)
+	event := opcuaTestEvent()
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := writer.WriteNodes(ctx, event)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "1 of 3 writes failed")
+
+	var stored []dtos.Event
+	require.NoError(t, json.Unmarshal(ctx.RetryData(), &stored))
+	assert.Equal(t, []dtos.Event{event}, stored, "Events should be stored for retry")
+
+	client.statuses = nil
+	client.err = errors.New("connection reset")
+	continuePipeline, result = writer.WriteNodes(ctx, ctx.RetryData())
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "connection reset")
+	assert.True(t, client.closed, "connection should be closed after a failed request")
+
+	client.err = nil
+	continuePipeline, result = writer.WriteNodes(ctx, ctx.RetryData())
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, 2, *connects, "writer should connect again after a failed request")
+	ctx.SetRetryData(nil)
+}
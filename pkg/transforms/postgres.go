@@ -0,0 +1,464 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/lib/pq"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	// The fields of the readings that can be mapped to the table's columns
+	PostgresFieldDevice    = "device"
+	PostgresFieldProfile   = "profile"
+	PostgresFieldSource    = "source"
+	PostgresFieldName      = "name"
+	PostgresFieldValue     = "value"
+	PostgresFieldValueType = "valuetype"
+	PostgresFieldOrigin    = "origin"
+	PostgresFieldTags      = "tags"
+
+	// PostgresUsernameKey and PostgresPasswordKey are the keys of the database credentials in the Postgres secret
+	PostgresUsernameKey = "username"
+	PostgresPasswordKey = "password"
+
+	// DefaultPostgresPort is the port of the database when no port is given
+	DefaultPostgresPort = 5432
+	// DefaultPostgresBatchSize is the number of rows per INSERT statement when no batch size is given
+	DefaultPostgresBatchSize = 500
+
+	// postgresMaxParameters is the most parameters of a Postgres statement
+	postgresMaxParameters = 65535
+)
+
+// postgresFields are the fields of the readings in the order of the columns
+var postgresFields = []string{
+	PostgresFieldDevice,
+	PostgresFieldProfile,
+	PostgresFieldSource,
+	PostgresFieldName,
+	PostgresFieldValue,
+	PostgresFieldValueType,
+	PostgresFieldOrigin,
+	PostgresFieldTags,
+}
+
+// postgresIdentifierSpec matches the table, optionally schema qualified, and column names
+var postgresIdentifierSpec = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PostgresWriterConfig contains the configuration of a PostgresWriter
+type PostgresWriterConfig struct {
+	// Host, Port, DefaultPostgresPort when 0, and Database of the Postgres or TimescaleDB server
+	Host     string
+	Port     int
+	Database string
+	// SSLMode is the sslmode of the connection, i.e. "disable", "require" or "verify-full", lib/pq's default of
+	// "require" when empty
+	SSLMode string
+	// SecretPath is the path of the "username" and "password" secrets
+	SecretPath string
+	// Table the readings are written to, optionally schema qualified, i.e. "telemetry.readings"
+	Table string
+	// Columns maps the fields of the readings, the PostgresField constants, to the table's columns. The device, name,
+	// value, origin and tags fields are written to the columns of the same names when empty. The value is written as
+	// text cast by Postgres to the column's type, the origin as a timestamp and the Event's tags as JSON.
+	Columns map[string]string
+	// BatchSize is the number of rows per INSERT statement, DefaultPostgresBatchSize when 0
+	BatchSize int
+	// UseCopy writes the rows with COPY rather than INSERT statements for higher throughput
+	UseCopy bool
+	// PersistOnError enables use of store & forward when the write fails
+	PersistOnError bool
+	// CircuitBreaker optionally stops the exports after consecutive failures
+	CircuitBreaker *CircuitBreaker
+}
+
+// PostgresWriter writes the readings of Events to a Postgres or TimescaleDB table, one row per reading
+type PostgresWriter struct {
+	config  PostgresWriterConfig
+	schema  string
+	table   string
+	fields  []string
+	columns []string
+	open    func(dsn string) (*sql.DB, error)
+
+	lock                 sync.Mutex
+	db                   *sql.DB
+	secretsLastRetrieved time.Time
+}
+
+// NewPostgresWriter creates, initializes and returns a new instance of PostgresWriter
+func NewPostgresWriter(config PostgresWriterConfig) (*PostgresWriter, error) {
+	config.Host = strings.TrimSpace(config.Host)
+	config.Database = strings.TrimSpace(config.Database)
+	config.SecretPath = strings.TrimSpace(config.SecretPath)
+	config.Table = strings.TrimSpace(config.Table)
+
+	if len(config.Host) == 0 {
+		return nil, errors.New("host must be set")
+	}
+	if len(config.Database) == 0 {
+		return nil, errors.New("database must be set")
+	}
+	if len(config.SecretPath) == 0 {
+		return nil, errors.New("secret path must be set")
+	}
+
+	writer := &PostgresWriter{
+		config: config,
+		open: func(dsn string) (*sql.DB, error) {
+			return sql.Open("postgres", dsn)
+		},
+	}
+
+	tableParts := strings.Split(config.Table, ".")
+	for _, part := range tableParts {
+		if !postgresIdentifierSpec.MatchString(part) {
+			return nil, fmt.Errorf("invalid table name '%s'", config.Table)
+		}
+	}
+	switch len(tableParts) {
+	case 1:
+		writer.table = tableParts[0]
+	case 2:
+		writer.schema, writer.table = tableParts[0], tableParts[1]
+	default:
+		return nil, fmt.Errorf("invalid table name '%s'", config.Table)
+	}
+
+	columns := config.Columns
+	if len(columns) == 0 {
+		columns = map[string]string{
+			PostgresFieldDevice: "device",
+			PostgresFieldName:   "name",
+			PostgresFieldValue:  "value",
+			PostgresFieldOrigin: "origin",
+			PostgresFieldTags:   "tags",
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, field := range postgresFields {
+		known[field] = true
+	}
+	for field, column := range columns {
+		if !known[field] {
+			return nil, fmt.Errorf("invalid field '%s', must be one of %s", field, strings.Join(postgresFields, ", "))
+		}
+		if !postgresIdentifierSpec.MatchString(column) {
+			return nil, fmt.Errorf("invalid column name '%s' for field '%s'", column, field)
+		}
+	}
+	for _, field := range postgresFields {
+		if column, ok := columns[field]; ok {
+			writer.fields = append(writer.fields, field)
+			writer.columns = append(writer.columns, column)
+		}
+	}
+
+	if writer.config.BatchSize <= 0 {
+		writer.config.BatchSize = DefaultPostgresBatchSize
+	}
+	if maxRows := postgresMaxParameters / len(writer.columns); writer.config.BatchSize > maxRows {
+		writer.config.BatchSize = maxRows
+	}
+
+	if writer.config.Port <= 0 {
+		writer.config.Port = DefaultPostgresPort
+	}
+
+	return writer, nil
+}
+
+// PostgresWrite writes the readings of the Event, slice of Events or batch of Events from the previous function, i.e.
+// Batch, to the table in a single transaction. The Events are stored for retry as a JSON array on error.
+// This function will return an error and stop the pipeline if no data is received, the data isn't Events or the write
+// fails.
+func (writer *PostgresWriter) PostgresWrite(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function PostgresWrite in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	events, err := exportEvents(data)
+	if err != nil {
+		return false, fmt.Errorf("function PostgresWrite in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	rows, err := writer.rows(events)
+	if err != nil {
+		return false, fmt.Errorf("function PostgresWrite in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	if isShadowExportLogged(ctx) {
+		ctx.LoggingClient().Infof("Shadow pipeline '%s' did not write %d rows to %s", ctx.PipelineId(), len(rows), writer.config.Table)
+		return true, nil
+	}
+
+	if err := skipExport(ctx, writer.config.CircuitBreaker, writer.config.Host, writer.config.PersistOnError,
+		func() { writer.storeEvents(ctx, events, true) }); err != nil {
+		return false, err
+	}
+
+	delivery := recordDelivery(ctx, fmt.Sprintf("postgres://%s:%d/%s/%s", writer.config.Host, writer.config.Port,
+		writer.config.Database, writer.config.Table))
+
+	if err := writer.write(ctx, rows); err != nil {
+		err = fmt.Errorf("function PostgresWrite in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		delivery.fail(err)
+		writer.config.CircuitBreaker.Failed()
+		writer.storeEvents(ctx, events, false)
+		return false, err
+	}
+
+	writer.config.CircuitBreaker.Succeeded()
+	delivery.acknowledge(strconv.Itoa(len(rows)))
+
+	ctx.LoggingClient().Debugf("Wrote %d rows to %s in pipeline '%s'", len(rows), writer.config.Table, ctx.PipelineId())
+	ctx.LoggingClient().Tracef("Data exported for pipeline '%s' (%s=%s)", ctx.PipelineId(), common.CorrelationHeader, ctx.CorrelationID())
+
+	return true, nil
+}
+
+// rows returns the rows of the Events' readings, binary readings being skipped
+func (writer *PostgresWriter) rows(events []dtos.Event) ([][]interface{}, error) {
+	var rows [][]interface{}
+	for _, event := range events {
+		tags := "{}"
+		if len(event.Tags) > 0 {
+			encoded, err := json.Marshal(event.Tags)
+			if err != nil {
+				return nil, fmt.Errorf("unable to encode tags of Event '%s': %s", event.Id, err.Error())
+			}
+			tags = string(encoded)
+		}
+
+		for _, reading := range event.Readings {
+			value := reading.Value
+			switch {
+			case reading.ValueType == common.ValueTypeBinary:
+				continue
+			case reading.ObjectValue != nil:
+				encoded, err := json.Marshal(reading.ObjectValue)
+				if err != nil {
+					return nil, fmt.Errorf("unable to encode value of reading '%s': %s", reading.ResourceName, err.Error())
+				}
+				value = string(encoded)
+			}
+
+			row := make([]interface{}, len(writer.fields))
+			for index, field := range writer.fields {
+				switch field {
+				case PostgresFieldDevice:
+					row[index] = reading.DeviceName
+				case PostgresFieldProfile:
+					row[index] = reading.ProfileName
+				case PostgresFieldSource:
+					row[index] = event.SourceName
+				case PostgresFieldName:
+					row[index] = reading.ResourceName
+				case PostgresFieldValue:
+					row[index] = value
+				case PostgresFieldValueType:
+					row[index] = reading.ValueType
+				case PostgresFieldOrigin:
+					row[index] = time.Unix(0, reading.Origin).UTC()
+				case PostgresFieldTags:
+					row[index] = tags
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows, nil
+}
+
+// write writes the rows in a single transaction
+func (writer *PostgresWriter) write(ctx interfaces.AppFunctionContext, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	db, err := writer.database(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx.Context(), nil)
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %s", err.Error())
+	}
+
+	if writer.config.UseCopy {
+		err = writer.copyRows(ctx.Context(), tx, rows)
+	} else {
+		err = writer.insertRows(ctx.Context(), tx, rows)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("unable to commit transaction: %s", err.Error())
+	}
+	return nil
+}
+
+// insertRows inserts the rows with multi-row INSERT statements of up to the batch size
+func (writer *PostgresWriter) insertRows(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	for start := 0; start < len(rows); start += writer.config.BatchSize {
+		end := start + writer.config.BatchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		query, args := writer.insertStatement(rows[start:end])
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("unable to insert rows: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// insertStatement returns the INSERT statement of the rows and its arguments
+func (writer *PostgresWriter) insertStatement(rows [][]interface{}) (string, []interface{}) {
+	quoted := make([]string, len(writer.columns))
+	for index, column := range writer.columns {
+		quoted[index] = pq.QuoteIdentifier(column)
+	}
+
+	var statement strings.Builder
+	statement.WriteString("INSERT INTO " + writer.quotedTable() + " (" + strings.Join(quoted, ", ") + ") VALUES ")
+
+	args := make([]interface{}, 0, len(rows)*len(writer.columns))
+	for rowIndex, row := range rows {
+		if rowIndex > 0 {
+			statement.WriteString(", ")
+		}
+
+		placeholders := make([]string, len(row))
+		for index, value := range row {
+			args = append(args, value)
+			placeholders[index] = "$" + strconv.Itoa(len(args))
+		}
+		statement.WriteString("(" + strings.Join(placeholders, ", ") + ")")
+	}
+
+	return statement.String(), args
+}
+
+// copyRows copies the rows with COPY FROM STDIN
+func (writer *PostgresWriter) copyRows(ctx context.Context, tx *sql.Tx, rows [][]interface{}) error {
+	statement, err := tx.PrepareContext(ctx, pq.CopyInSchema(writer.schema, writer.table, writer.columns...))
+	if err != nil {
+		return fmt.Errorf("unable to prepare COPY: %s", err.Error())
+	}
+	defer func() { _ = statement.Close() }()
+
+	for _, row := range rows {
+		if _, err := statement.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("unable to copy row: %s", err.Error())
+		}
+	}
+
+	// The rows are flushed by executing the statement without arguments
+	if _, err := statement.ExecContext(ctx); err != nil {
+		return fmt.Errorf("unable to copy rows: %s", err.Error())
+	}
+	return nil
+}
+
+func (writer *PostgresWriter) quotedTable() string {
+	if len(writer.schema) > 0 {
+		return pq.QuoteIdentifier(writer.schema) + "." + pq.QuoteIdentifier(writer.table)
+	}
+	return pq.QuoteIdentifier(writer.table)
+}
+
+// database returns the connection pool, which is opened again with the new credentials when the secrets have been
+// updated
+func (writer *PostgresWriter) database(ctx interfaces.AppFunctionContext) (*sql.DB, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	if writer.db != nil && !writer.secretsLastRetrieved.Before(ctx.SecretsLastUpdated()) {
+		return writer.db, nil
+	}
+
+	secrets, err := ctx.GetSecret(writer.config.SecretPath, PostgresUsernameKey, PostgresPasswordKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(secrets[PostgresUsernameKey], secrets[PostgresPasswordKey]),
+		Host:   fmt.Sprintf("%s:%d", writer.config.Host, writer.config.Port),
+		Path:   "/" + writer.config.Database,
+	}
+	if sslMode := strings.TrimSpace(writer.config.SSLMode); len(sslMode) > 0 {
+		dsn.RawQuery = url.Values{"sslmode": []string{sslMode}}.Encode()
+	}
+
+	db, err := writer.open(dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %s", err.Error())
+	}
+
+	// The secrets have been updated, so close the connections made with the previous credentials
+	if writer.db != nil {
+		_ = writer.db.Close()
+		ctx.LoggingClient().Infof("Postgres connections to %s in pipeline '%s' reopened with the updated secrets",
+			writer.config.Host, ctx.PipelineId())
+	}
+
+	writer.db = db
+	writer.secretsLastRetrieved = time.Now()
+	return db, nil
+}
+
+// storeEvents stores the Events for retry when persistOnError is enabled or always is set
+func (writer *PostgresWriter) storeEvents(ctx interfaces.AppFunctionContext, events []dtos.Event, always bool) {
+	if !writer.config.PersistOnError && !always {
+		return
+	}
+
+	retryData, err := json.Marshal(events)
+	if err != nil {
+		ctx.LoggingClient().Errorf("Unable to marshal the Events that failed to be written for retry in pipeline '%s': %s",
+			ctx.PipelineId(), err.Error())
+		return
+	}
+	ctx.SetRetryData(retryData)
+}
@@ -0,0 +1,491 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// ContentTypeProtobuf is the content type of the payloads created by TransformToProtobuf
+	ContentTypeProtobuf = "application/x-protobuf"
+	// EventMessageName is the full name of the protobuf message for an EdgeX Event
+	EventMessageName = "edgex.v2.Event"
+)
+
+// eventDescriptor describes an EdgeX Event as the protobuf messages:
+//
+//	syntax = "proto3";
+//	package edgex.v2;
+//	import "google/protobuf/struct.proto";
+//
+//	message Event {
+//	  string api_version = 1;
+//	  string id = 2;
+//	  string device_name = 3;
+//	  string profile_name = 4;
+//	  string source_name = 5;
+//	  int64 origin = 6;
+//	  repeated Reading readings = 7;
+//	  map<string, google.protobuf.Value> tags = 8;
+//	}
+//
+//	message Reading {
+//	  string id = 1;
+//	  int64 origin = 2;
+//	  string device_name = 3;
+//	  string resource_name = 4;
+//	  string profile_name = 5;
+//	  string value_type = 6;
+//	  string value = 7;
+//	  bytes binary_value = 8;
+//	  string media_type = 9;
+//	  google.protobuf.Value object_value = 10;
+//	}
+var eventDescriptor = newEventDescriptor()
+
+// ProtobufConversion converts data to and from the protobuf binary encoding of a message, either the EdgeX Event
+// or a message from a registered schema, for consumers such as gRPC services or Kafka topics expecting compact payloads.
+type ProtobufConversion struct {
+	descriptor protoreflect.MessageDescriptor
+}
+
+// NewProtobufConversion creates, initializes and returns a new instance of ProtobufConversion for the EdgeX Event
+// message, see EventMessageName.
+func NewProtobufConversion() *ProtobufConversion {
+	return &ProtobufConversion{descriptor: eventDescriptor}
+}
+
+// NewProtobufConversionForMessage creates, initializes and returns a new instance of ProtobufConversion for the
+// message with the full name, e.g. "mycompany.telemetry.Measurement". The message's schema must be registered in the
+// global protobuf registry, which the Go code generated by protoc does when it is linked into the application service.
+func NewProtobufConversionForMessage(messageName string) (*ProtobufConversion, error) {
+	if messageName == EventMessageName {
+		return NewProtobufConversion(), nil
+	}
+
+	descriptor, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find protobuf message '%s': %s", messageName, err.Error())
+	}
+
+	return newProtobufConversion(descriptor, messageName)
+}
+
+// NewProtobufConversionFromDescriptorSet creates, initializes and returns a new instance of ProtobufConversion for the
+// message with the full name from descriptorSet, a serialized FileDescriptorSet such as the one written by
+// protoc --include_imports --descriptor_set_out. Files imported but not included in the set are resolved from the
+// global protobuf registry.
+func NewProtobufConversionFromDescriptorSet(descriptorSet []byte, messageName string) (*ProtobufConversion, error) {
+	fileSet := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(descriptorSet, fileSet); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal protobuf descriptor set: %s", err.Error())
+	}
+
+	files := new(protoregistry.Files)
+	for _, fileProto := range fileSet.GetFile() {
+		file, err := protodesc.NewFile(fileProto, &chainedFileResolver{files, protoregistry.GlobalFiles})
+		if err != nil {
+			return nil, fmt.Errorf("invalid protobuf descriptor for '%s': %s", fileProto.GetName(), err.Error())
+		}
+
+		if err := files.RegisterFile(file); err != nil {
+			return nil, fmt.Errorf("unable to register protobuf descriptor for '%s': %s", fileProto.GetName(), err.Error())
+		}
+	}
+
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find protobuf message '%s' in descriptor set: %s", messageName, err.Error())
+	}
+
+	return newProtobufConversion(descriptor, messageName)
+}
+
+func newProtobufConversion(descriptor protoreflect.Descriptor, messageName string) (*ProtobufConversion, error) {
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a protobuf message", messageName)
+	}
+
+	return &ProtobufConversion{descriptor: messageDescriptor}, nil
+}
+
+// TransformToProtobuf encodes the data as the protobuf message and returns the encoded []byte. For the EdgeX Event
+// message the data must be an Event or the JSON of an Event. For other messages the data is mapped to the message
+// using the protobuf JSON mapping, so it can be the JSON, or any value that marshals to the JSON, of the message.
+// JSON fields that are not in the message are ignored.
+// This function will return an error and stop the pipeline if no data is received or the data can't be encoded.
+func (conversion *ProtobufConversion) TransformToProtobuf(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function TransformToProtobuf in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Transforming to protobuf message '%s' in pipeline '%s'",
+		conversion.descriptor.FullName(), ctx.PipelineId())
+
+	message, err := conversion.toMessage(data)
+	if err != nil {
+		return false, fmt.Errorf("function TransformToProtobuf in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	encoded, err := proto.Marshal(message)
+	if err != nil {
+		return false, fmt.Errorf("function TransformToProtobuf in pipeline '%s': unable to encode protobuf message: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	ctx.SetResponseContentType(ContentTypeProtobuf)
+	return true, encoded
+}
+
+// TransformFromProtobuf decodes the data, the protobuf encoding of the message as []byte, and returns an Event for
+// the EdgeX Event message, or otherwise the JSON of the message, using the protobuf JSON mapping, as []byte.
+// This function will return an error and stop the pipeline if no data is received or the data can't be decoded.
+func (conversion *ProtobufConversion) TransformFromProtobuf(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function TransformFromProtobuf in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Transforming from protobuf message '%s' in pipeline '%s'",
+		conversion.descriptor.FullName(), ctx.PipelineId())
+
+	encoded, err := util.CoerceType(data)
+	if err != nil {
+		return false, fmt.Errorf("function TransformFromProtobuf in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	message := dynamicpb.NewMessage(conversion.descriptor)
+	if err := proto.Unmarshal(encoded, message); err != nil {
+		return false, fmt.Errorf("function TransformFromProtobuf in pipeline '%s': unable to decode protobuf message: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	if conversion.isEvent() {
+		event, err := messageToEvent(message)
+		if err != nil {
+			return false, fmt.Errorf("function TransformFromProtobuf in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+		return true, event
+	}
+
+	result, err := protojson.Marshal(message)
+	if err != nil {
+		return false, fmt.Errorf("function TransformFromProtobuf in pipeline '%s': unable to marshal protobuf message to JSON: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	ctx.SetResponseContentType(common.ContentTypeJSON)
+	return true, result
+}
+
+func (conversion *ProtobufConversion) isEvent() bool {
+	return conversion.descriptor == eventDescriptor
+}
+
+func (conversion *ProtobufConversion) toMessage(data interface{}) (*dynamicpb.Message, error) {
+	if conversion.isEvent() {
+		event, ok := data.(dtos.Event)
+		if !ok {
+			content, err := util.CoerceType(data)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := json.Unmarshal(content, &event); err != nil {
+				return nil, fmt.Errorf("type received is not an Event: %s", err.Error())
+			}
+		}
+		return eventToMessage(event)
+	}
+
+	content, err := util.CoerceType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	message := dynamicpb.NewMessage(conversion.descriptor)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(content, message); err != nil {
+		return nil, fmt.Errorf("unable to map JSON to protobuf message '%s': %s", conversion.descriptor.FullName(), err.Error())
+	}
+
+	return message, nil
+}
+
+func eventToMessage(event dtos.Event) (*dynamicpb.Message, error) {
+	message := dynamicpb.NewMessage(eventDescriptor)
+	fields := eventDescriptor.Fields()
+	setString(message, fields.ByName("api_version"), event.ApiVersion)
+	setString(message, fields.ByName("id"), event.Id)
+	setString(message, fields.ByName("device_name"), event.DeviceName)
+	setString(message, fields.ByName("profile_name"), event.ProfileName)
+	setString(message, fields.ByName("source_name"), event.SourceName)
+	message.Set(fields.ByName("origin"), protoreflect.ValueOfInt64(event.Origin))
+
+	readingsField := fields.ByName("readings")
+	readings := message.Mutable(readingsField).List()
+	for _, reading := range event.Readings {
+		readingMessage, err := readingToMessage(readingsField.Message(), reading)
+		if err != nil {
+			return nil, err
+		}
+		readings.Append(protoreflect.ValueOfMessage(readingMessage))
+	}
+
+	if len(event.Tags) > 0 {
+		tags := message.Mutable(fields.ByName("tags")).Map()
+		for name, tag := range event.Tags {
+			value, err := structpb.NewValue(tag)
+			if err != nil {
+				return nil, fmt.Errorf("unable to encode Event tag '%s': %s", name, err.Error())
+			}
+			tags.Set(protoreflect.ValueOfString(name).MapKey(), protoreflect.ValueOfMessage(value.ProtoReflect()))
+		}
+	}
+
+	return message, nil
+}
+
+func readingToMessage(descriptor protoreflect.MessageDescriptor, reading dtos.BaseReading) (*dynamicpb.Message, error) {
+	message := dynamicpb.NewMessage(descriptor)
+	fields := descriptor.Fields()
+	setString(message, fields.ByName("id"), reading.Id)
+	message.Set(fields.ByName("origin"), protoreflect.ValueOfInt64(reading.Origin))
+	setString(message, fields.ByName("device_name"), reading.DeviceName)
+	setString(message, fields.ByName("resource_name"), reading.ResourceName)
+	setString(message, fields.ByName("profile_name"), reading.ProfileName)
+	setString(message, fields.ByName("value_type"), reading.ValueType)
+	setString(message, fields.ByName("value"), reading.Value)
+	setString(message, fields.ByName("media_type"), reading.MediaType)
+
+	if len(reading.BinaryValue) > 0 {
+		message.Set(fields.ByName("binary_value"), protoreflect.ValueOfBytes(reading.BinaryValue))
+	}
+
+	if reading.ObjectValue != nil {
+		value, err := structpb.NewValue(reading.ObjectValue)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode object value of reading '%s': %s", reading.ResourceName, err.Error())
+		}
+		message.Set(fields.ByName("object_value"), protoreflect.ValueOfMessage(value.ProtoReflect()))
+	}
+
+	return message, nil
+}
+
+// setString sets the field only when the value isn't empty, as proto3 doesn't encode empty strings
+func setString(message *dynamicpb.Message, field protoreflect.FieldDescriptor, value string) {
+	if value != "" {
+		message.Set(field, protoreflect.ValueOfString(value))
+	}
+}
+
+func messageToEvent(message protoreflect.Message) (dtos.Event, error) {
+	fields := eventDescriptor.Fields()
+	event := dtos.Event{
+		Id:          message.Get(fields.ByName("id")).String(),
+		DeviceName:  message.Get(fields.ByName("device_name")).String(),
+		ProfileName: message.Get(fields.ByName("profile_name")).String(),
+		SourceName:  message.Get(fields.ByName("source_name")).String(),
+		Origin:      message.Get(fields.ByName("origin")).Int(),
+	}
+	event.ApiVersion = message.Get(fields.ByName("api_version")).String()
+
+	readings := message.Get(fields.ByName("readings")).List()
+	for index := 0; index < readings.Len(); index++ {
+		reading, err := messageToReading(readings.Get(index).Message())
+		if err != nil {
+			return dtos.Event{}, err
+		}
+		event.Readings = append(event.Readings, reading)
+	}
+
+	var err error
+	message.Get(fields.ByName("tags")).Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+		if event.Tags == nil {
+			event.Tags = make(map[string]interface{})
+		}
+		event.Tags[key.String()], err = toInterface(value.Message())
+		return err == nil
+	})
+	if err != nil {
+		return dtos.Event{}, fmt.Errorf("unable to decode Event tags: %s", err.Error())
+	}
+
+	return event, nil
+}
+
+func messageToReading(message protoreflect.Message) (dtos.BaseReading, error) {
+	fields := message.Descriptor().Fields()
+	reading := dtos.BaseReading{
+		Id:           message.Get(fields.ByName("id")).String(),
+		Origin:       message.Get(fields.ByName("origin")).Int(),
+		DeviceName:   message.Get(fields.ByName("device_name")).String(),
+		ResourceName: message.Get(fields.ByName("resource_name")).String(),
+		ProfileName:  message.Get(fields.ByName("profile_name")).String(),
+		ValueType:    message.Get(fields.ByName("value_type")).String(),
+	}
+	reading.Value = message.Get(fields.ByName("value")).String()
+	reading.MediaType = message.Get(fields.ByName("media_type")).String()
+	reading.BinaryValue = message.Get(fields.ByName("binary_value")).Bytes()
+
+	objectField := fields.ByName("object_value")
+	if message.Has(objectField) {
+		value, err := toInterface(message.Get(objectField).Message())
+		if err != nil {
+			return dtos.BaseReading{}, fmt.Errorf("unable to decode object value of reading '%s': %s",
+				reading.ResourceName, err.Error())
+		}
+		reading.ObjectValue = value
+	}
+
+	return reading, nil
+}
+
+// toInterface converts a google.protobuf.Value message, which is a dynamic message when decoded, to a Go value
+func toInterface(message protoreflect.Message) (interface{}, error) {
+	value := &structpb.Value{}
+	content, err := proto.Marshal(message.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := proto.Unmarshal(content, value); err != nil {
+		return nil, err
+	}
+
+	return value.AsInterface(), nil
+}
+
+// chainedFileResolver resolves the files imported by a descriptor set from the files already registered from the
+// set, then from the global registry
+type chainedFileResolver struct {
+	files  *protoregistry.Files
+	global *protoregistry.Files
+}
+
+func (resolver *chainedFileResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	file, err := resolver.files.FindFileByPath(path)
+	if errors.Is(err, protoregistry.NotFound) {
+		return resolver.global.FindFileByPath(path)
+	}
+	return file, err
+}
+
+func (resolver *chainedFileResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	descriptor, err := resolver.files.FindDescriptorByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		return resolver.global.FindDescriptorByName(name)
+	}
+	return descriptor, err
+}
+
+func newEventDescriptor() protoreflect.MessageDescriptor {
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   fieldType.Enum(),
+		}
+	}
+	messageField := func(name string, number int32, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		result := field(name, number, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+		result.TypeName = proto.String(typeName)
+		if repeated {
+			result.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+		}
+		return result
+	}
+
+	const valueType = ".google.protobuf.Value"
+	stringType := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	int64Type := descriptorpb.FieldDescriptorProto_TYPE_INT64
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("edgex/v2/event.proto"),
+		Package:    proto.String("edgex.v2"),
+		Dependency: []string{"google/protobuf/struct.proto"},
+		Syntax:     proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("api_version", 1, stringType),
+					field("id", 2, stringType),
+					field("device_name", 3, stringType),
+					field("profile_name", 4, stringType),
+					field("source_name", 5, stringType),
+					field("origin", 6, int64Type),
+					messageField("readings", 7, ".edgex.v2.Reading", true),
+					messageField("tags", 8, ".edgex.v2.Event.TagsEntry", true),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("TagsEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("key", 1, stringType),
+							messageField("value", 2, valueType, false),
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+			{
+				Name: proto.String("Reading"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("id", 1, stringType),
+					field("origin", 2, int64Type),
+					field("device_name", 3, stringType),
+					field("resource_name", 4, stringType),
+					field("profile_name", 5, stringType),
+					field("value_type", 6, stringType),
+					field("value", 7, stringType),
+					field("binary_value", 8, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+					field("media_type", 9, stringType),
+					messageField("object_value", 10, valueType, false),
+				},
+			},
+		},
+	}
+
+	// structpb is imported so google/protobuf/struct.proto is always in the global registry
+	descriptor, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(fmt.Sprintf("invalid EdgeX Event protobuf descriptor: %s", err.Error()))
+	}
+
+	return descriptor.Messages().ByName("Event")
+}
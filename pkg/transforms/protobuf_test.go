@@ -0,0 +1,158 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestProtobufConversion_Event(t *testing.T) {
+	event := dtos.NewEvent("camera", "camera-1", "snapshot")
+	event.Tags = map[string]interface{}{"site": "plant-1", "floor": float64(2)}
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, 21.5))
+	event.AddBinaryReading("image", []byte{0xFF, 0xD8, 0x01}, "image/jpeg")
+	event.Readings = append(event.Readings, dtos.BaseReading{
+		DeviceName:    "camera-1",
+		ResourceName:  "status",
+		ProfileName:   "camera",
+		ValueType:     common.ValueTypeObject,
+		ObjectReading: dtos.ObjectReading{ObjectValue: map[string]interface{}{"ok": true, "codes": []interface{}{float64(1), float64(2)}}},
+	})
+
+	conversion := NewProtobufConversion()
+
+	continuePipeline, result := conversion.TransformToProtobuf(ctx, event)
+	require.True(t, continuePipeline, result)
+	require.IsType(t, []byte{}, result)
+	assert.Equal(t, ContentTypeProtobuf, ctx.ResponseContentType())
+
+	continuePipeline, result = conversion.TransformFromProtobuf(ctx, result)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, event, result)
+
+	// The JSON of an Event is also accepted
+	continuePipeline, fromJSON := conversion.TransformToProtobuf(ctx, `{"apiVersion":"v2","id":"1","deviceName":"d","origin":5}`)
+	require.True(t, continuePipeline, fromJSON)
+	continuePipeline, result = conversion.TransformFromProtobuf(ctx, fromJSON)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "d", result.(dtos.Event).DeviceName)
+	assert.Equal(t, int64(5), result.(dtos.Event).Origin)
+}
+
+func measurementDescriptorSet(t *testing.T) []byte {
+	field := func(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   fieldType.Enum(),
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("telemetry/measurement.proto"),
+				Package: proto.String("telemetry"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Measurement"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("sensor_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+							field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	content, err := proto.Marshal(set)
+	require.NoError(t, err)
+	return content
+}
+
+func TestProtobufConversion_RegisteredSchema(t *testing.T) {
+	conversion, err := NewProtobufConversionFromDescriptorSet(measurementDescriptorSet(t), "telemetry.Measurement")
+	require.NoError(t, err)
+
+	continuePipeline, result := conversion.TransformToProtobuf(ctx, []byte(`{"sensorId":"s-1","value":2.5,"unit":"C"}`))
+	require.True(t, continuePipeline, result)
+
+	continuePipeline, result = conversion.TransformFromProtobuf(ctx, result)
+	require.True(t, continuePipeline, result)
+	assert.JSONEq(t, `{"sensorId":"s-1","value":2.5}`, string(result.([]byte)))
+	assert.Equal(t, common.ContentTypeJSON, ctx.ResponseContentType())
+
+	global, err := NewProtobufConversionForMessage("google.protobuf.Struct")
+	require.NoError(t, err)
+	continuePipeline, result = global.TransformToProtobuf(ctx, map[string]interface{}{"level": "high"})
+	require.True(t, continuePipeline, result)
+	continuePipeline, result = global.TransformFromProtobuf(ctx, result)
+	require.True(t, continuePipeline, result)
+	assert.JSONEq(t, `{"level":"high"}`, string(result.([]byte)))
+
+	event, err := NewProtobufConversionForMessage(EventMessageName)
+	require.NoError(t, err)
+	assert.True(t, event.isEvent())
+}
+
+func TestProtobufConversion_Errors(t *testing.T) {
+	_, err := NewProtobufConversionForMessage("telemetry.Unknown")
+	assert.Error(t, err)
+
+	_, err = NewProtobufConversionForMessage("google.protobuf.Struct.FieldsEntry.key")
+	assert.Error(t, err)
+
+	_, err = NewProtobufConversionFromDescriptorSet([]byte("not a descriptor set"), "telemetry.Measurement")
+	assert.Error(t, err)
+
+	_, err = NewProtobufConversionFromDescriptorSet(measurementDescriptorSet(t), "telemetry.Unknown")
+	assert.Error(t, err)
+
+	conversion, err := NewProtobufConversionFromDescriptorSet(measurementDescriptorSet(t), "telemetry.Measurement")
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name          string
+		Function      func() (bool, interface{})
+		ExpectedError string
+	}{
+		{"To - no data", func() (bool, interface{}) { return conversion.TransformToProtobuf(ctx, nil) }, "No Data Received"},
+		{"To - bad JSON", func() (bool, interface{}) { return conversion.TransformToProtobuf(ctx, `{"value":"high"}`) }, "unable to map JSON"},
+		{"To - not an Event", func() (bool, interface{}) { return NewProtobufConversion().TransformToProtobuf(ctx, "[1]") }, "type received is not an Event"},
+		{"From - no data", func() (bool, interface{}) { return conversion.TransformFromProtobuf(ctx, nil) }, "No Data Received"},
+		{"From - bad data", func() (bool, interface{}) { return conversion.TransformFromProtobuf(ctx, []byte{0xFF}) }, "unable to decode protobuf message"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := test.Function()
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
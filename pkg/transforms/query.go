@@ -0,0 +1,259 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// queryRow is a row queried, with the columns of a single reading
+type queryRow map[string]interface{}
+
+// Query runs a SQL like statement over the readings of the Events received, with a row per reading.
+// The statement has the form:
+//
+//	SELECT <columns and aggregates> [FROM readings] [WHERE <condition>] [GROUP BY <columns>] [WINDOW '<duration>']
+//
+// The columns are deviceName, profileName, sourceName, resourceName, valueType, value and origin. The value of numeric
+// readings is a number, otherwise a string. The aggregates are COUNT(*), COUNT, SUM, AVG, MIN and MAX, and columns
+// and aggregates can be renamed with AS. The WHERE condition compares columns, numbers and 'strings' with =, !=, <>,
+// <, <=, > and >=, combined with AND, OR, NOT and parentheses. Without a WINDOW the statement is run over the readings
+// of each Event, otherwise over the readings of all the Events received in each tumbling window of the duration.
+type Query struct {
+	statement  string
+	query      *compiledQuery
+	lock       sync.Mutex
+	rows       []queryRow
+	windowOpen bool
+}
+
+// NewQuery creates, initializes and returns a new instance of Query, returning an error if the statement is invalid
+func NewQuery(statement string) (*Query, error) {
+	query, err := compileQuery(statement)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query statement '%s': %s", statement, err.Error())
+	}
+
+	return &Query{
+		statement: statement,
+		query:     query,
+	}, nil
+}
+
+// Execute runs the statement over the Event's readings and returns the resulting rows as a
+// []map[string]interface{}, keyed by the column names, aggregates such as avg(value) or aliases. The pipeline is
+// stopped when no readings match. With a WINDOW the pipeline execution for the Event opening the window waits for the
+// window to end and returns the rows for the window, the pipeline is stopped for the other Events in the window.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received or a
+// numeric reading's value can't be parsed.
+func (q *Query) Execute(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Query in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function Query in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Running query '%s' in pipeline '%s'", q.statement, ctx.PipelineId())
+
+	var rows []queryRow
+	for _, reading := range event.Readings {
+		row, err := newQueryRow(event, reading)
+		if err != nil {
+			return false, fmt.Errorf("function Query in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+
+		if q.query.where == nil || q.query.where.matches(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	if q.query.window > 0 {
+		return q.executeWindow(ctx, rows)
+	}
+
+	if len(rows) == 0 {
+		ctx.LoggingClient().Debugf("No readings matched query in pipeline '%s'", ctx.PipelineId())
+		return false, nil
+	}
+
+	return true, q.results(rows)
+}
+
+// executeWindow adds the rows to the window, opening the window if one isn't open and waiting for it to end
+func (q *Query) executeWindow(ctx interfaces.AppFunctionContext, rows []queryRow) (bool, interface{}) {
+	q.lock.Lock()
+	q.rows = append(q.rows, rows...)
+	if q.windowOpen || len(q.rows) == 0 {
+		q.lock.Unlock()
+		return false, nil
+	}
+	q.windowOpen = true
+	q.lock.Unlock()
+
+	ctx.LoggingClient().Debugf("Query window of %s opened in pipeline '%s'", q.query.window.String(), ctx.PipelineId())
+	<-time.After(q.query.window)
+
+	q.lock.Lock()
+	rows = q.rows
+	q.rows = nil
+	q.windowOpen = false
+	q.lock.Unlock()
+
+	ctx.LoggingClient().Debugf("Query window closed with %d matching reading(s) in pipeline '%s'", len(rows), ctx.PipelineId())
+	return true, q.results(rows)
+}
+
+func newQueryRow(event dtos.Event, reading dtos.BaseReading) (queryRow, error) {
+	row := queryRow{
+		columnDeviceName:   reading.DeviceName,
+		columnProfileName:  reading.ProfileName,
+		columnSourceName:   event.SourceName,
+		columnResourceName: reading.ResourceName,
+		columnValueType:    reading.ValueType,
+		columnOrigin:       reading.Origin,
+		columnValue:        reading.Value,
+	}
+
+	switch reading.ValueType {
+	case common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+		common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64,
+		common.ValueTypeFloat32, common.ValueTypeFloat64:
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse value of reading '%s': %s", reading.ResourceName, err.Error())
+		}
+		row[columnValue] = value
+	}
+
+	return row, nil
+}
+
+// results selects the columns of each row or, when the statement has aggregates or GROUP BY, of each group of rows
+func (q *Query) results(rows []queryRow) []map[string]interface{} {
+	var results []map[string]interface{}
+
+	if !q.query.aggregated() {
+		for _, row := range rows {
+			result := make(map[string]interface{})
+			if q.query.all {
+				for column, value := range row {
+					result[column] = value
+				}
+			} else {
+				for _, item := range q.query.items {
+					result[item.name] = row[item.column]
+				}
+			}
+			results = append(results, result)
+		}
+
+		return results
+	}
+
+	var keys []string
+	groups := make(map[string][]queryRow)
+	for _, row := range rows {
+		var values []string
+		for _, column := range q.query.groupBy {
+			values = append(values, fmt.Sprint(row[column]))
+		}
+
+		key := strings.Join(values, "\x00")
+		if _, found := groups[key]; !found {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		result := make(map[string]interface{})
+		for _, item := range q.query.items {
+			if item.aggregate == "" {
+				result[item.name] = group[0][item.column]
+				continue
+			}
+			result[item.name] = aggregateRows(item, group)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// aggregateRows returns the aggregate of the column over the rows. COUNT(*) counts all rows, COUNT counts the rows
+// with a value and the other aggregates are computed over the numeric values only, nil when there are none.
+func aggregateRows(item selectItem, rows []queryRow) interface{} {
+	if item.aggregate == "count" {
+		if item.column == "" {
+			return len(rows)
+		}
+
+		count := 0
+		for _, row := range rows {
+			if value, ok := row[item.column]; ok && value != "" {
+				count++
+			}
+		}
+		return count
+	}
+
+	count := 0
+	sum := 0.0
+	min := math.Inf(1)
+	max := math.Inf(-1)
+	for _, row := range rows {
+		value, ok := queryNumber(row[item.column])
+		if !ok {
+			continue
+		}
+
+		count++
+		sum += value
+		min = math.Min(min, value)
+		max = math.Max(max, value)
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	switch item.aggregate {
+	case "sum":
+		return sum
+	case "avg":
+		return sum / float64(count)
+	case "min":
+		return min
+	default:
+		return max
+	}
+}
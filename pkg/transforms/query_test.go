@@ -0,0 +1,187 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryEvent(t *testing.T, deviceName string, temperature float64, humidity int32, state string) dtos.Event {
+	event := dtos.NewEvent("sensor", deviceName, "readings")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, temperature))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeInt32, humidity))
+	require.NoError(t, event.AddSimpleReading("state", common.ValueTypeString, state))
+	return event
+}
+
+func TestQuery_Execute(t *testing.T) {
+	event := queryEvent(t, "sensor-1", 21.5, 40, "ok")
+
+	tests := []struct {
+		Name      string
+		Statement string
+		Expected  []map[string]interface{}
+	}{
+		{
+			"Select columns",
+			"SELECT resourceName AS name, value WHERE valueType != 'String'",
+			[]map[string]interface{}{{"name": "temperature", "value": 21.5}, {"name": "humidity", "value": 40.0}},
+		},
+		{
+			"Aggregates",
+			"SELECT COUNT(*), COUNT(value) AS readings, SUM(value), AVG(value), MIN(value), MAX(value) FROM readings",
+			[]map[string]interface{}{{"count(*)": 3, "readings": 3, "sum(value)": 61.5, "avg(value)": 30.75, "min(value)": 21.5, "max(value)": 40.0}},
+		},
+		{
+			"Group by",
+			"SELECT valueType, count(*) GROUP BY valueType",
+			[]map[string]interface{}{
+				{"valueType": common.ValueTypeFloat64, "count(*)": 1},
+				{"valueType": common.ValueTypeInt32, "count(*)": 1},
+				{"valueType": common.ValueTypeString, "count(*)": 1},
+			},
+		},
+		{
+			"No numeric values",
+			"SELECT max(value) WHERE resourceName = 'state'",
+			[]map[string]interface{}{{"max(value)": nil}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			query, err := NewQuery(test.Statement)
+			require.NoError(t, err)
+
+			continuePipeline, result := query.Execute(ctx, event)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestQuery_ExecuteSelectAll(t *testing.T) {
+	event := queryEvent(t, "sensor-1", 21.5, 40, "ok")
+
+	query, err := NewQuery("SELECT * WHERE resourceName = 'state'")
+	require.NoError(t, err)
+
+	continuePipeline, result := query.Execute(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	rows := result.([]map[string]interface{})
+	require.Len(t, rows, 1)
+	assert.Equal(t, map[string]interface{}{
+		"deviceName":   "sensor-1",
+		"profileName":  "sensor",
+		"sourceName":   "readings",
+		"resourceName": "state",
+		"valueType":    common.ValueTypeString,
+		"value":        "ok",
+		"origin":       event.Readings[2].Origin,
+	}, rows[0])
+}
+
+func TestQuery_ExecuteNoMatch(t *testing.T) {
+	query, err := NewQuery("SELECT value WHERE value > 100")
+	require.NoError(t, err)
+
+	continuePipeline, result := query.Execute(ctx, queryEvent(t, "sensor-1", 21.5, 40, "ok"))
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+}
+
+func TestQuery_ExecuteWindow(t *testing.T) {
+	query, err := NewQuery("SELECT deviceName, avg(value) AS average WHERE resourceName = 'temperature' GROUP BY deviceName WINDOW '100ms'")
+	require.NoError(t, err)
+
+	type executeResult struct {
+		continuePipeline bool
+		result           interface{}
+	}
+
+	opened := make(chan executeResult)
+	go func() {
+		continuePipeline, result := query.Execute(ctx, queryEvent(t, "sensor-1", 20, 40, "ok"))
+		opened <- executeResult{continuePipeline, result}
+	}()
+
+	require.Eventually(t, func() bool {
+		query.lock.Lock()
+		defer query.lock.Unlock()
+		return query.windowOpen
+	}, time.Second, time.Millisecond)
+
+	for _, event := range []dtos.Event{
+		queryEvent(t, "sensor-2", 30, 40, "ok"),
+		queryEvent(t, "sensor-1", 22, 40, "ok"),
+	} {
+		continuePipeline, result := query.Execute(ctx, event)
+		assert.False(t, continuePipeline)
+		assert.Nil(t, result)
+	}
+
+	select {
+	case closed := <-opened:
+		require.True(t, closed.continuePipeline, closed.result)
+		assert.Equal(t, []map[string]interface{}{
+			{"deviceName": "sensor-1", "average": 21.0},
+			{"deviceName": "sensor-2", "average": 30.0},
+		}, closed.result)
+	case <-time.After(time.Second):
+		t.Fatal("query window not closed")
+	}
+
+	assert.Empty(t, query.rows, "rows should be cleared when the window closes")
+}
+
+func TestQuery_Errors(t *testing.T) {
+	badValue := dtos.NewEvent("sensor", "sensor-1", "readings")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "temperature", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "hot"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "sensor-1", "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'temperature'"},
+	}
+
+	query, err := NewQuery("SELECT *")
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := query.Execute(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+
+	_, err = NewQuery("SELECT nothing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid query statement 'SELECT nothing'")
+}
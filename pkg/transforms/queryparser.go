@@ -0,0 +1,600 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Columns of the rows queried, one row per reading
+const (
+	columnDeviceName   = "deviceName"
+	columnProfileName  = "profileName"
+	columnSourceName   = "sourceName"
+	columnResourceName = "resourceName"
+	columnValueType    = "valueType"
+	columnValue        = "value"
+	columnOrigin       = "origin"
+)
+
+var queryColumns = map[string]string{
+	strings.ToLower(columnDeviceName):   columnDeviceName,
+	strings.ToLower(columnProfileName):  columnProfileName,
+	strings.ToLower(columnSourceName):   columnSourceName,
+	strings.ToLower(columnResourceName): columnResourceName,
+	strings.ToLower(columnValueType):    columnValueType,
+	strings.ToLower(columnValue):        columnValue,
+	strings.ToLower(columnOrigin):       columnOrigin,
+}
+
+var queryAggregates = map[string]bool{"count": true, "sum": true, "avg": true, "min": true, "max": true}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdentifier
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenPunctuation
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// is returns true if the token is the keyword, operator or punctuation, ignoring case
+func (t token) is(text string) bool {
+	return t.kind != tokenString && t.kind != tokenEnd && strings.EqualFold(t.text, text)
+}
+
+func tokenize(statement string) ([]token, error) {
+	var tokens []token
+	runes := []rune(statement)
+
+	for index := 0; index < len(runes); {
+		current := runes[index]
+		switch {
+		case unicode.IsSpace(current):
+			index++
+
+		case unicode.IsLetter(current) || current == '_':
+			start := index
+			for index < len(runes) && (unicode.IsLetter(runes[index]) || unicode.IsDigit(runes[index]) || runes[index] == '_') {
+				index++
+			}
+			tokens = append(tokens, token{tokenIdentifier, string(runes[start:index])})
+
+		case unicode.IsDigit(current) || (current == '-' && index+1 < len(runes) && unicode.IsDigit(runes[index+1])):
+			start := index
+			index++
+			for index < len(runes) {
+				exponentSign := (runes[index] == '-' || runes[index] == '+') && (runes[index-1] == 'e' || runes[index-1] == 'E')
+				if !unicode.IsDigit(runes[index]) && runes[index] != '.' && runes[index] != 'e' && runes[index] != 'E' && !exponentSign {
+					break
+				}
+				index++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:index])})
+
+		case current == '\'':
+			// Strings are quoted with single quotes, a quote within the string is escaped by doubling it
+			var text strings.Builder
+			index++
+			for {
+				if index >= len(runes) {
+					return nil, fmt.Errorf("unterminated string starting with '%s'", text.String())
+				}
+				if runes[index] == '\'' {
+					if index+1 < len(runes) && runes[index+1] == '\'' {
+						text.WriteRune('\'')
+						index += 2
+						continue
+					}
+					index++
+					break
+				}
+				text.WriteRune(runes[index])
+				index++
+			}
+			tokens = append(tokens, token{tokenString, text.String()})
+
+		case strings.ContainsRune("=<>!", current):
+			start := index
+			index++
+			if index < len(runes) && (runes[index] == '=' || (current == '<' && runes[index] == '>')) {
+				index++
+			}
+			operator := string(runes[start:index])
+			switch operator {
+			case "=", "!=", "<>", "<", "<=", ">", ">=":
+			default:
+				return nil, fmt.Errorf("invalid operator '%s'", operator)
+			}
+			tokens = append(tokens, token{tokenOperator, operator})
+
+		case strings.ContainsRune("(),*", current):
+			tokens = append(tokens, token{tokenPunctuation, string(current)})
+			index++
+
+		default:
+			return nil, fmt.Errorf("unexpected character '%c'", current)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd}), nil
+}
+
+// selectItem is a column or aggregate of a column in the SELECT list
+type selectItem struct {
+	aggregate string
+	column    string
+	name      string
+}
+
+// compiledQuery is the parsed form of a statement:
+// SELECT <items> [FROM readings] [WHERE <condition>] [GROUP BY <columns>] [WINDOW '<duration>']
+type compiledQuery struct {
+	all     bool
+	items   []selectItem
+	where   condition
+	groupBy []string
+	window  time.Duration
+}
+
+// aggregated returns true if the query's rows are grouped in to a row per group
+func (query *compiledQuery) aggregated() bool {
+	if len(query.groupBy) > 0 {
+		return true
+	}
+
+	for _, item := range query.items {
+		if item.aggregate != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+type parser struct {
+	tokens   []token
+	position int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() token {
+	current := p.tokens[p.position]
+	if current.kind != tokenEnd {
+		p.position++
+	}
+	return current
+}
+
+func (p *parser) accept(text string) bool {
+	if p.peek().is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected(fmt.Sprintf("'%s'", text))
+	}
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	current := p.peek()
+	if current.kind == tokenEnd {
+		return fmt.Errorf("expected %s but statement ended", expected)
+	}
+	return fmt.Errorf("expected %s but found '%s'", expected, current.text)
+}
+
+func compileQuery(statement string) (*compiledQuery, error) {
+	tokens, err := tokenize(statement)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	query := &compiledQuery{}
+
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseSelectList(query); err != nil {
+		return nil, err
+	}
+
+	if p.accept("FROM") {
+		if !p.accept("readings") {
+			return nil, p.unexpected("'readings'")
+		}
+	}
+
+	if p.accept("WHERE") {
+		if query.where, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.accept("GROUP") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			column, err := p.parseColumn()
+			if err != nil {
+				return nil, err
+			}
+			query.groupBy = append(query.groupBy, column)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.accept("WINDOW") {
+		window := p.next()
+		if window.kind != tokenString {
+			return nil, fmt.Errorf("expected WINDOW duration as a string, i.e. '30s'")
+		}
+		if query.window, err = time.ParseDuration(window.text); err != nil || query.window <= 0 {
+			return nil, fmt.Errorf("invalid WINDOW duration '%s', must be greater than 0, i.e. '30s'", window.text)
+		}
+	}
+
+	if p.peek().kind != tokenEnd {
+		return nil, p.unexpected("end of statement")
+	}
+
+	return query, query.validate()
+}
+
+// validate checks the columns selected when grouping are all grouped by, as each group results in a single row
+func (query *compiledQuery) validate() error {
+	if !query.aggregated() {
+		return nil
+	}
+
+	if query.all {
+		return fmt.Errorf("SELECT * can't be used with aggregates or GROUP BY")
+	}
+
+	for _, item := range query.items {
+		if item.aggregate != "" {
+			continue
+		}
+
+		grouped := false
+		for _, column := range query.groupBy {
+			if column == item.column {
+				grouped = true
+				break
+			}
+		}
+
+		if !grouped {
+			return fmt.Errorf("column '%s' must be in GROUP BY or used in an aggregate", item.column)
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) parseSelectList(query *compiledQuery) error {
+	if p.accept("*") {
+		query.all = true
+		return nil
+	}
+
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return err
+		}
+		query.items = append(query.items, item)
+
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseSelectItem() (selectItem, error) {
+	var item selectItem
+
+	current := p.peek()
+	if current.kind == tokenIdentifier && queryAggregates[strings.ToLower(current.text)] && p.tokens[p.position+1].is("(") {
+		item.aggregate = strings.ToLower(p.next().text)
+		p.next()
+
+		if item.aggregate == "count" && p.accept("*") {
+			item.name = "count(*)"
+		} else {
+			column, err := p.parseColumn()
+			if err != nil {
+				return item, err
+			}
+			item.column = column
+			item.name = item.aggregate + "(" + column + ")"
+		}
+
+		if err := p.expect(")"); err != nil {
+			return item, err
+		}
+	} else {
+		column, err := p.parseColumn()
+		if err != nil {
+			return item, err
+		}
+		item.column = column
+		item.name = column
+	}
+
+	if p.accept("AS") {
+		alias := p.next()
+		if alias.kind != tokenIdentifier {
+			return item, fmt.Errorf("expected alias after AS")
+		}
+		item.name = alias.text
+	}
+
+	return item, nil
+}
+
+func (p *parser) parseColumn() (string, error) {
+	current := p.peek()
+	if current.kind != tokenIdentifier {
+		return "", p.unexpected("column")
+	}
+
+	column, ok := queryColumns[strings.ToLower(current.text)]
+	if !ok {
+		return "", fmt.Errorf("unknown column '%s'", current.text)
+	}
+
+	p.next()
+	return column, nil
+}
+
+func (p *parser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (condition, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (condition, error) {
+	if p.accept("NOT") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{operand}, nil
+	}
+
+	if p.accept("(") {
+		result, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return result, p.expect(")")
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (condition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	operator := p.next()
+	if operator.kind != tokenOperator {
+		p.position--
+		return nil, p.unexpected("comparison operator")
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return comparison{left: left, operator: operator.text, right: right}, nil
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	current := p.peek()
+	switch current.kind {
+	case tokenNumber:
+		p.next()
+		value, err := strconv.ParseFloat(current.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", current.text)
+		}
+		return literalOperand{value}, nil
+
+	case tokenString:
+		p.next()
+		return literalOperand{current.text}, nil
+
+	case tokenIdentifier:
+		column, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+		return columnOperand{column}, nil
+
+	default:
+		return nil, p.unexpected("column, number or string")
+	}
+}
+
+// condition is a WHERE clause condition evaluated against a row
+type condition interface {
+	matches(row queryRow) bool
+}
+
+type andCondition struct {
+	left  condition
+	right condition
+}
+
+func (c andCondition) matches(row queryRow) bool {
+	return c.left.matches(row) && c.right.matches(row)
+}
+
+type orCondition struct {
+	left  condition
+	right condition
+}
+
+func (c orCondition) matches(row queryRow) bool {
+	return c.left.matches(row) || c.right.matches(row)
+}
+
+type notCondition struct {
+	operand condition
+}
+
+func (c notCondition) matches(row queryRow) bool {
+	return !c.operand.matches(row)
+}
+
+type comparison struct {
+	left     operand
+	operator string
+	right    operand
+}
+
+// matches compares numbers numerically and anything else as strings. Numbers are only ordered against numbers.
+func (c comparison) matches(row queryRow) bool {
+	left := c.left.value(row)
+	right := c.right.value(row)
+
+	leftNumber, leftIsNumber := queryNumber(left)
+	rightNumber, rightIsNumber := queryNumber(right)
+
+	var result int
+	switch {
+	case leftIsNumber && rightIsNumber:
+		switch {
+		case leftNumber < rightNumber:
+			result = -1
+		case leftNumber > rightNumber:
+			result = 1
+		}
+	case leftIsNumber != rightIsNumber:
+		if c.operator != "=" && c.operator != "!=" && c.operator != "<>" {
+			return false
+		}
+		result = strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+	default:
+		result = strings.Compare(fmt.Sprint(left), fmt.Sprint(right))
+	}
+
+	switch c.operator {
+	case "=":
+		return result == 0
+	case "!=", "<>":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
+
+// operand is a column or literal compared in a WHERE clause condition
+type operand interface {
+	value(row queryRow) interface{}
+}
+
+type columnOperand struct {
+	column string
+}
+
+func (o columnOperand) value(row queryRow) interface{} {
+	return row[o.column]
+}
+
+type literalOperand struct {
+	literal interface{}
+}
+
+func (o literalOperand) value(_ queryRow) interface{} {
+	return o.literal
+}
+
+// queryNumber returns the numeric value of a row's column or literal
+func queryNumber(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileQuery(t *testing.T) {
+	query, err := compileQuery("select DeviceName, avg(value) as average, COUNT(*) from readings " +
+		"where resourcename = 'temperature' and (value >= -10.5 or not value < 1e-3) group by devicename window '30s'")
+	require.NoError(t, err)
+
+	assert.False(t, query.all)
+	assert.Equal(t, []selectItem{
+		{column: columnDeviceName, name: columnDeviceName},
+		{aggregate: "avg", column: columnValue, name: "average"},
+		{aggregate: "count", name: "count(*)"},
+	}, query.items)
+	assert.Equal(t, []string{columnDeviceName}, query.groupBy)
+	assert.Equal(t, 30*time.Second, query.window)
+	assert.True(t, query.aggregated())
+	require.NotNil(t, query.where)
+}
+
+func TestCompileQuery_Errors(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Statement     string
+		ExpectedError string
+	}{
+		{"Empty", "", "expected 'SELECT' but statement ended"},
+		{"No columns", "SELECT", "expected column but statement ended"},
+		{"Unknown column", "SELECT temperature", "unknown column 'temperature'"},
+		{"Unknown table", "SELECT * FROM events", "expected 'readings' but found 'events'"},
+		{"Bad operator", "SELECT * WHERE value == 1", "invalid operator '=='"},
+		{"No operator", "SELECT * WHERE value 1", "expected comparison operator but found '1'"},
+		{"Unterminated string", "SELECT * WHERE deviceName = 'pump", "unterminated string"},
+		{"Unbalanced parentheses", "SELECT * WHERE (value > 1", "expected ')' but statement ended"},
+		{"Bad character", "SELECT * WHERE value > 1;", "unexpected character ';'"},
+		{"Bad window", "SELECT * WINDOW '-5s'", "invalid WINDOW duration '-5s'"},
+		{"Window not a string", "SELECT * WINDOW 5", "expected WINDOW duration as a string"},
+		{"Trailing tokens", "SELECT value value", "expected end of statement but found 'value'"},
+		{"Star with aggregates", "SELECT * GROUP BY deviceName", "SELECT * can't be used with aggregates or GROUP BY"},
+		{"Column not grouped", "SELECT deviceName, max(value)", "column 'deviceName' must be in GROUP BY"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := compileQuery(test.Statement)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestComparison(t *testing.T) {
+	row := queryRow{columnDeviceName: "pump-1", columnValue: 20.0, columnOrigin: int64(1000)}
+
+	tests := []struct {
+		Condition string
+		Expected  bool
+	}{
+		{"value = 20", true},
+		{"value <> 20", false},
+		{"value > 19.5", true},
+		{"value <= 19.5", false},
+		{"origin < 1001", true},
+		{"deviceName = 'pump-1'", true},
+		{"deviceName != 'pump-2'", true},
+		{"deviceName < 'pump-2'", true},
+		{"value = '20'", true},
+		{"value > 'pump'", false},
+		{"NOT (value > 10 AND deviceName = 'pump-2') OR value < 0", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Condition, func(t *testing.T) {
+			query, err := compileQuery("SELECT * WHERE " + test.Condition)
+			require.NoError(t, err)
+			assert.Equal(t, test.Expected, query.where.matches(row))
+		})
+	}
+}
@@ -0,0 +1,307 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	QuotaUnitBytes    = "bytes"
+	QuotaUnitMessages = "messages"
+
+	QuotaPeriodDay   = "day"
+	QuotaPeriodMonth = "month"
+
+	QuotaExceededDrop       = "drop"
+	QuotaExceededDownsample = "downsample"
+	QuotaExceededStore      = "store"
+
+	// DefaultQuotaDownsampleRate is the rate at which data is downsampled once the quota is exceeded when no rate is given
+	DefaultQuotaDownsampleRate = 10
+
+	quotaPersistInterval = 10 * time.Second
+)
+
+// QuotaOptions contains the options of the Quota function
+type QuotaOptions struct {
+	// Destination is the name of the export destination whose quota is enforced, used in the logs
+	Destination string
+	// Limit is the number of bytes or messages that may be exported per period
+	Limit int64
+	// Unit is QuotaUnitBytes, the default, or QuotaUnitMessages
+	Unit string
+	// Period is QuotaPeriodDay, the default, or QuotaPeriodMonth. Periods start at midnight UTC.
+	Period string
+	// Exceeded is what is done with the data once the quota is exceeded: QuotaExceededDrop, the default,
+	// QuotaExceededDownsample or QuotaExceededStore
+	Exceeded string
+	// DownsampleRate is the rate at which data is passed once the quota is exceeded, 1 in DownsampleRate messages,
+	// DefaultQuotaDownsampleRate when 0. Only used by QuotaExceededDownsample.
+	DownsampleRate int
+	// PersistFile is the file the usage is saved to and loaded from, so the quota is still enforced after a restart
+	// of the service. The usage isn't persisted when empty.
+	PersistFile string
+}
+
+// Quota enforces a daily or monthly quota of the bytes or messages exported to a destination, protecting metered
+// cloud ingestion plans from overage. EnforceQuota is added to the pipeline right before the destination's export
+// function. Once the quota is exceeded the data is dropped, downsampled or stored for Store and Forward to retry
+// until the next period.
+type Quota struct {
+	options    QuotaOptions
+	lock       sync.Mutex
+	usage      quotaUsage
+	downsample int
+	warned     bool
+	loaded     bool
+	dirty      bool
+	lastSaved  time.Time
+}
+
+// quotaUsage is the bytes or messages exported in the current period
+type quotaUsage struct {
+	Period string
+	Used   int64
+	// Over is the bytes or messages exported beyond the quota by downsampling
+	Over int64
+}
+
+// NewQuota creates, initializes and returns a new instance of Quota
+func NewQuota(options QuotaOptions) (*Quota, error) {
+	if options.Limit <= 0 {
+		return nil, errors.New("Limit must be greater than 0")
+	}
+
+	options.Unit = strings.ToLower(strings.TrimSpace(options.Unit))
+	if options.Unit == "" {
+		options.Unit = QuotaUnitBytes
+	}
+	if options.Unit != QuotaUnitBytes && options.Unit != QuotaUnitMessages {
+		return nil, fmt.Errorf("invalid Unit '%s', must be '%s' or '%s'", options.Unit, QuotaUnitBytes, QuotaUnitMessages)
+	}
+
+	options.Period = strings.ToLower(strings.TrimSpace(options.Period))
+	if options.Period == "" {
+		options.Period = QuotaPeriodDay
+	}
+	if options.Period != QuotaPeriodDay && options.Period != QuotaPeriodMonth {
+		return nil, fmt.Errorf("invalid Period '%s', must be '%s' or '%s'", options.Period, QuotaPeriodDay, QuotaPeriodMonth)
+	}
+
+	options.Exceeded = strings.ToLower(strings.TrimSpace(options.Exceeded))
+	switch options.Exceeded {
+	case "":
+		options.Exceeded = QuotaExceededDrop
+	case QuotaExceededDrop, QuotaExceededDownsample, QuotaExceededStore:
+	default:
+		return nil, fmt.Errorf("invalid Exceeded '%s', must be '%s', '%s' or '%s'", options.Exceeded,
+			QuotaExceededDrop, QuotaExceededDownsample, QuotaExceededStore)
+	}
+
+	if options.DownsampleRate < 0 {
+		return nil, errors.New("DownsampleRate can not be negative")
+	}
+	if options.DownsampleRate == 0 {
+		options.DownsampleRate = DefaultQuotaDownsampleRate
+	}
+
+	return &Quota{options: options}, nil
+}
+
+// EnforceQuota passes the data on if it is within the destination's quota for the current period, counting it as
+// exported. Once the quota is exceeded the data is dropped, 1 in DownsampleRate messages is passed when downsampling,
+// or the data is set as the retry data and the pipeline stopped with an error, so Store and Forward retries it until
+// the next period, when it passes again.
+// This function will return an error and stop the pipeline if no data is received or the data can't be converted to
+// bytes when the unit is bytes.
+func (quota *Quota) EnforceQuota(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function EnforceQuota in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	size := int64(1)
+	var payload []byte
+	if quota.options.Unit == QuotaUnitBytes || quota.options.Exceeded == QuotaExceededStore {
+		var err error
+		payload, err = util.CoerceType(data)
+		if err != nil {
+			return false, fmt.Errorf("function EnforceQuota in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+
+		if quota.options.Unit == QuotaUnitBytes {
+			size = int64(len(payload))
+		}
+	}
+
+	quota.lock.Lock()
+	defer quota.lock.Unlock()
+
+	quota.load(ctx)
+	defer quota.persist(ctx)
+
+	if quota.reserve(size, time.Now()) {
+		return true, data
+	}
+
+	lc := ctx.LoggingClient()
+	if !quota.warned {
+		lc.Warnf("%s quota of %d %s for '%s' exceeded for period %s in pipeline '%s', data is now handled by '%s'",
+			quota.options.Period, quota.options.Limit, quota.options.Unit, quota.options.Destination, quota.usage.Period,
+			ctx.PipelineId(), quota.options.Exceeded)
+		quota.warned = true
+	}
+
+	switch quota.options.Exceeded {
+	case QuotaExceededDownsample:
+		quota.downsample++
+		if quota.downsample%quota.options.DownsampleRate == 0 {
+			quota.usage.Over += size
+			quota.dirty = true
+			return true, data
+		}
+
+		lc.Debugf("Data to '%s' dropped by downsampling, quota exceeded in pipeline '%s'", quota.options.Destination, ctx.PipelineId())
+		return false, nil
+
+	case QuotaExceededStore:
+		ctx.SetRetryData(payload)
+		return false, fmt.Errorf("function EnforceQuota in pipeline '%s': %s quota of '%s' exceeded for period %s, data stored for retry",
+			ctx.PipelineId(), quota.options.Period, quota.options.Destination, quota.usage.Period)
+
+	default:
+		lc.Debugf("Data to '%s' dropped, quota exceeded in pipeline '%s'", quota.options.Destination, ctx.PipelineId())
+		return false, nil
+	}
+}
+
+// Save writes the usage to the persist file if it changed since last saved. The file is replaced atomically so a
+// failure while saving doesn't lose the previously saved usage.
+func (quota *Quota) Save() error {
+	quota.lock.Lock()
+	defer quota.lock.Unlock()
+
+	return quota.save()
+}
+
+// reserve counts the size as exported if it is within the quota of the period now is in, starting a new period when
+// it has changed. Must be called with the lock held.
+func (quota *Quota) reserve(size int64, now time.Time) bool {
+	period := quota.period(now)
+	if quota.usage.Period != period {
+		quota.usage = quotaUsage{Period: period}
+		quota.downsample = 0
+		quota.warned = false
+		quota.dirty = true
+	}
+
+	if quota.usage.Used+size > quota.options.Limit {
+		return false
+	}
+
+	quota.usage.Used += size
+	quota.dirty = true
+	return true
+}
+
+// period returns the UTC day or month now is in, i.e. "2021-08-24" or "2021-08"
+func (quota *Quota) period(now time.Time) string {
+	if quota.options.Period == QuotaPeriodMonth {
+		return now.UTC().Format("2006-01")
+	}
+	return now.UTC().Format("2006-01-02")
+}
+
+// load restores the usage saved to the persist file the first time the Quota is used.
+// Must be called with the lock held.
+func (quota *Quota) load(ctx interfaces.AppFunctionContext) {
+	if quota.loaded || quota.options.PersistFile == "" {
+		return
+	}
+	quota.loaded = true
+	quota.lastSaved = time.Now()
+
+	data, err := ioutil.ReadFile(quota.options.PersistFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ctx.LoggingClient().Errorf("Unable to load quota usage from '%s': %s", quota.options.PersistFile, err.Error())
+		}
+		return
+	}
+
+	if err := json.Unmarshal(data, &quota.usage); err != nil {
+		ctx.LoggingClient().Errorf("Unable to load quota usage from '%s': %s", quota.options.PersistFile, err.Error())
+	}
+}
+
+// persist saves the usage if it wasn't saved within the persist interval. Must be called with the lock held.
+func (quota *Quota) persist(ctx interfaces.AppFunctionContext) {
+	if quota.options.PersistFile == "" || time.Since(quota.lastSaved) < quotaPersistInterval {
+		return
+	}
+
+	if err := quota.save(); err != nil {
+		ctx.LoggingClient().Errorf("Unable to save quota usage to '%s': %s", quota.options.PersistFile, err.Error())
+	}
+}
+
+func (quota *Quota) save() error {
+	if quota.options.PersistFile == "" || !quota.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(quota.usage)
+	if err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(quota.options.PersistFile), filepath.Base(quota.options.PersistFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	if err := os.Rename(temp.Name(), quota.options.PersistFile); err != nil {
+		return err
+	}
+
+	quota.dirty = false
+	quota.lastSaved = time.Now()
+	return nil
+}
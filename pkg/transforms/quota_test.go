@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewQuota(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Options     QuotaOptions
+		ExpectError bool
+	}{
+		{"Defaults", QuotaOptions{Limit: 100}, false},
+		{"Monthly messages", QuotaOptions{Limit: 100, Unit: "Messages", Period: "Month", Exceeded: "Store"}, false},
+		{"No limit", QuotaOptions{}, true},
+		{"Bad unit", QuotaOptions{Limit: 100, Unit: "kb"}, true},
+		{"Bad period", QuotaOptions{Limit: 100, Period: "week"}, true},
+		{"Bad exceeded", QuotaOptions{Limit: 100, Exceeded: "queue"}, true},
+		{"Bad downsample rate", QuotaOptions{Limit: 100, Exceeded: QuotaExceededDownsample, DownsampleRate: -1}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			quota, err := NewQuota(test.Options)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Contains(t, []string{QuotaUnitBytes, QuotaUnitMessages}, quota.options.Unit)
+			assert.Contains(t, []string{QuotaPeriodDay, QuotaPeriodMonth}, quota.options.Period)
+		})
+	}
+}
+
+func TestQuota_EnforceQuotaDrop(t *testing.T) {
+	quota, err := NewQuota(QuotaOptions{Destination: "cloud", Limit: 10})
+	require.NoError(t, err)
+
+	continuePipeline, result := quota.EnforceQuota(ctx, "12345")
+	require.True(t, continuePipeline)
+	assert.Equal(t, "12345", result)
+
+	continuePipeline, _ = quota.EnforceQuota(ctx, "12345")
+	require.True(t, continuePipeline, "data up to the limit should pass")
+
+	continuePipeline, result = quota.EnforceQuota(ctx, "1")
+	assert.False(t, continuePipeline, "data beyond the limit should be dropped")
+	assert.Nil(t, result)
+	assert.Equal(t, int64(10), quota.usage.Used)
+
+	continuePipeline, result = quota.EnforceQuota(ctx, nil)
+	assert.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestQuota_EnforceQuotaDownsample(t *testing.T) {
+	quota, err := NewQuota(QuotaOptions{Limit: 2, Unit: QuotaUnitMessages, Exceeded: QuotaExceededDownsample, DownsampleRate: 3})
+	require.NoError(t, err)
+
+	var passed int
+	for i := 0; i < 11; i++ {
+		if continuePipeline, _ := quota.EnforceQuota(ctx, "data"); continuePipeline {
+			passed++
+		}
+	}
+
+	// 2 within the quota and 1 in 3 of the 9 beyond it
+	assert.Equal(t, 5, passed)
+	assert.Equal(t, int64(3), quota.usage.Over)
+}
+
+func TestQuota_EnforceQuotaStore(t *testing.T) {
+	quota, err := NewQuota(QuotaOptions{Destination: "cloud", Limit: 1, Unit: QuotaUnitMessages, Exceeded: QuotaExceededStore})
+	require.NoError(t, err)
+
+	continuePipeline, _ := quota.EnforceQuota(ctx, "first")
+	require.True(t, continuePipeline)
+
+	ctx.SetRetryData(nil)
+	continuePipeline, result := quota.EnforceQuota(ctx, "second")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "quota of 'cloud' exceeded")
+	assert.Equal(t, []byte("second"), ctx.RetryData(), "data should be stored for retry")
+	ctx.SetRetryData(nil)
+}
+
+func TestQuota_Period(t *testing.T) {
+	quota, err := NewQuota(QuotaOptions{Limit: 1, Unit: QuotaUnitMessages})
+	require.NoError(t, err)
+
+	day := time.Date(2021, 8, 24, 23, 59, 0, 0, time.UTC)
+	assert.True(t, quota.reserve(1, day))
+	assert.False(t, quota.reserve(1, day))
+	assert.True(t, quota.reserve(1, day.Add(time.Minute)), "quota should reset at midnight UTC")
+	assert.Equal(t, "2021-08-25", quota.usage.Period)
+
+	monthly, err := NewQuota(QuotaOptions{Limit: 1, Unit: QuotaUnitMessages, Period: QuotaPeriodMonth})
+	require.NoError(t, err)
+	assert.True(t, monthly.reserve(1, day))
+	assert.False(t, monthly.reserve(1, day.Add(time.Minute)), "quota should only reset at the start of the month")
+	assert.True(t, monthly.reserve(1, day.AddDate(0, 1, 0)))
+}
+
+func TestQuota_Persist(t *testing.T) {
+	persistFile := filepath.Join(t.TempDir(), "quota.json")
+
+	quota, err := NewQuota(QuotaOptions{Limit: 10, PersistFile: persistFile})
+	require.NoError(t, err)
+
+	continuePipeline, _ := quota.EnforceQuota(ctx, "12345678")
+	require.True(t, continuePipeline)
+	require.NoError(t, quota.Save())
+
+	restarted, err := NewQuota(QuotaOptions{Limit: 10, PersistFile: persistFile})
+	require.NoError(t, err)
+
+	continuePipeline, _ = restarted.EnforceQuota(ctx, "12345")
+	assert.False(t, continuePipeline, "usage should be restored after a restart")
+
+	continuePipeline, _ = restarted.EnforceQuota(ctx, "12")
+	assert.True(t, continuePipeline)
+}
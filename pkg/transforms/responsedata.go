@@ -33,8 +33,9 @@ func NewResponseData() ResponseData {
 	return ResponseData{}
 }
 
-// SetResponseData sets the response data to that passed in from the previous function.
-// It will return an error and stop the pipeline if the input data is not of type []byte, string or json.Marshaller
+// SetResponseData sets the response data to that passed in from the previous function. Data other than []byte or
+// string is encoded with the codec registered for ResponseContentType, or as JSON when there is none.
+// It will return an error and stop the pipeline if the input data can't be encoded
 func (f ResponseData) SetResponseData(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
 
 	ctx.LoggingClient().Debugf("Setting response data in pipeline '%s'", ctx.PipelineId())
@@ -44,7 +45,7 @@ func (f ResponseData) SetResponseData(ctx interfaces.AppFunctionContext, data in
 		return false, fmt.Errorf("function SetResponseData in pipeline '%s': No Data Received", ctx.PipelineId())
 	}
 
-	byteData, err := util.CoerceType(data)
+	byteData, err := util.CoerceTypeTo(f.ResponseContentType, data)
 	if err != nil {
 		return false, err
 	}
@@ -58,3 +59,40 @@ func (f ResponseData) SetResponseData(ctx interfaces.AppFunctionContext, data in
 
 	return true, data
 }
+
+// ResponseMetadata houses transform for setting the content type and headers, or metadata, returned with the
+// response data to the trigger, i.e. the HTTP response
+type ResponseMetadata struct {
+	ContentType string
+	Headers     map[string]string
+}
+
+// NewResponseMetadata creates, initializes and returns a new instance of ResponseMetadata. The header values may
+// contain context value placeholders, i.e. "{devicename}".
+func NewResponseMetadata(contentType string, headers map[string]string) ResponseMetadata {
+	return ResponseMetadata{
+		ContentType: contentType,
+		Headers:     headers,
+	}
+}
+
+// SetResponseMetadata sets the response content type, when not empty, and the response headers and passes the data
+// through, so it can be used before or after SetResponseData.
+// It will return an error and stop the pipeline if a header value's placeholders can't be replaced.
+func (f ResponseMetadata) SetResponseMetadata(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	ctx.LoggingClient().Debugf("Setting response metadata in pipeline '%s'", ctx.PipelineId())
+
+	for key, value := range f.Headers {
+		value, err := ctx.ApplyValues(value)
+		if err != nil {
+			return false, fmt.Errorf("function SetResponseMetadata in pipeline '%s': unable to set header '%s': %s", ctx.PipelineId(), key, err.Error())
+		}
+		ctx.SetResponseHeader(key, value)
+	}
+
+	if len(f.ContentType) > 0 {
+		ctx.SetResponseContentType(f.ContentType)
+	}
+
+	return true, data
+}
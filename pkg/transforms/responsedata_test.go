@@ -25,6 +25,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
 )
 
 func TestSetResponseDataString(t *testing.T) {
@@ -86,6 +89,27 @@ func TestSetResponseDataBadType(t *testing.T) {
 	assert.Contains(t, result.(error).Error(), "passed in data must be of type")
 }
 
+func TestSetResponseMetadata(t *testing.T) {
+	context := appfunction.NewContext("123", dic, "")
+	context.AddValue(interfaces.DEVICENAME, deviceName1)
+
+	target := NewResponseMetadata(common.ContentTypeXML, map[string]string{"X-Device": "{devicename}", "Cache-Control": "no-store"})
+	continuePipeline, result := target.SetResponseMetadata(context, msgStr)
+	require.True(t, continuePipeline)
+	assert.Equal(t, msgStr, result, "data should be passed through")
+	assert.Equal(t, common.ContentTypeXML, context.ResponseContentType())
+	assert.Equal(t, map[string]string{"X-Device": deviceName1, "Cache-Control": "no-store"}, context.ResponseHeaders())
+
+	clone := context.Clone()
+	clone.SetResponseHeader("X-Device", "other")
+	assert.Equal(t, deviceName1, context.ResponseHeaders()["X-Device"], "clone's headers should be independent")
+
+	target = NewResponseMetadata("", map[string]string{"X-Missing": "{unknown}"})
+	continuePipeline, result = target.SetResponseMetadata(context, msgStr)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to set header 'X-Missing'")
+}
+
 func getExpectedEventXml(t *testing.T) string {
 	event := dtos.NewEvent("profile1", "dev1", "source1")
 	err := event.AddSimpleReading("resource1", common.ValueTypeInt32, int32(32))
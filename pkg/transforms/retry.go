@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// DefaultBackoffMultiplier is the factor the delay is multiplied by after each attempt when no multiplier is given
+const DefaultBackoffMultiplier = 2
+
+// Backoff is the exponential backoff between the attempts of a Retrier
+type Backoff struct {
+	// InitialDelay is the delay before the first retry
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between attempts, the delay isn't capped when 0
+	MaxDelay time.Duration
+	// Multiplier is the factor the delay is multiplied by after each attempt, DefaultBackoffMultiplier when 0
+	Multiplier float64
+	// Jitter is the fraction of the delay, from 0 to 1, randomly added to or removed from it, so services retrying
+	// the same destination don't retry in lockstep
+	Jitter float64
+}
+
+// Retrier executes a function again, with exponential backoff and jitter, when it fails, so any transform or export
+// function can be retried transparently before the pipeline fails.
+type Retrier struct {
+	function interfaces.AppFunction
+	attempts int
+	backoff  Backoff
+	timer    func(time.Duration) (<-chan time.Time, func())
+}
+
+// NewRetrier creates, initializes and returns a new instance of Retrier executing the function up to the number of
+// attempts, which must be at least 1, waiting for the backoff between them.
+func NewRetrier(function interfaces.AppFunction, attempts int, backoff Backoff) (*Retrier, error) {
+	if function == nil {
+		return nil, errors.New("function to retry must be set")
+	}
+
+	if attempts < 1 {
+		return nil, errors.New("attempts must be at least 1")
+	}
+
+	if backoff.InitialDelay < 0 || backoff.MaxDelay < 0 {
+		return nil, errors.New("backoff delays can not be negative")
+	}
+
+	if backoff.Multiplier == 0 {
+		backoff.Multiplier = DefaultBackoffMultiplier
+	}
+	if backoff.Multiplier < 1 {
+		return nil, errors.New("backoff multiplier must be at least 1")
+	}
+
+	if backoff.Jitter < 0 || backoff.Jitter > 1 {
+		return nil, errors.New("backoff jitter must be from 0 to 1")
+	}
+
+	return &Retrier{
+		function: function,
+		attempts: attempts,
+		backoff:  backoff,
+		timer:    backoffTimer,
+	}, nil
+}
+
+// Retry executes the function and returns its result, executing it again after the backoff delay while it fails,
+// i.e. stops the pipeline with an error, until the attempts are exhausted. A function stopping the pipeline without
+// an error, i.e. a filter, isn't retried. The retry data set by the last attempt is kept, so a failed export is still
+// stored for Store and Forward. The backoff is cut short when the pipeline execution's context is done, i.e. on a
+// pipeline timeout or service shutdown, the function not being executed again.
+// This function will return an error and stop the pipeline if no data is received, all the attempts fail or the
+// context is done while waiting to retry.
+func (retrier *Retrier) Retry(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Retry in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	var err error
+	for attempt := 1; attempt <= retrier.attempts; attempt++ {
+		if attempt > 1 {
+			delay := retrier.delay(attempt - 1)
+			ctx.LoggingClient().Debugf("Retrying function in pipeline '%s' in %s (attempt %d of %d) after error: %s",
+				ctx.PipelineId(), delay.String(), attempt, retrier.attempts, err.Error())
+			if cancelErr := retrier.wait(ctx, delay); cancelErr != nil {
+				return false, fmt.Errorf("function Retry in pipeline '%s': retry cancelled after %d of %d attempts: %s (last error: %s)",
+					ctx.PipelineId(), attempt-1, retrier.attempts, cancelErr.Error(), err.Error())
+			}
+		}
+
+		ctx.SetRetryData(nil)
+
+		continuePipeline, result := retrier.function(ctx, data)
+		if continuePipeline {
+			return true, result
+		}
+
+		var failed bool
+		if err, failed = result.(error); !failed {
+			return false, result
+		}
+	}
+
+	return false, fmt.Errorf("function Retry in pipeline '%s': failed after %d attempts: %s",
+		ctx.PipelineId(), retrier.attempts, err.Error())
+}
+
+// wait waits for the delay, returning the context's error if it is done first
+func (retrier *Retrier) wait(ctx interfaces.AppFunctionContext, delay time.Duration) error {
+	elapsed, stop := retrier.timer(delay)
+	defer stop()
+
+	select {
+	case <-elapsed:
+		return nil
+	case <-ctx.Context().Done():
+		return ctx.Context().Err()
+	}
+}
+
+// backoffTimer returns a channel that receives once the delay elapses and the function to stop the timer
+func backoffTimer(delay time.Duration) (<-chan time.Time, func()) {
+	timer := time.NewTimer(delay)
+	return timer.C, func() { timer.Stop() }
+}
+
+// delay returns the backoff before the specified retry, starting at 1, with the jitter applied
+func (retrier *Retrier) delay(retry int) time.Duration {
+	delay := float64(retrier.backoff.InitialDelay)
+	for i := 1; i < retry; i++ {
+		delay *= retrier.backoff.Multiplier
+		if retrier.backoff.MaxDelay > 0 && delay >= float64(retrier.backoff.MaxDelay) {
+			break
+		}
+	}
+
+	if retrier.backoff.MaxDelay > 0 && delay > float64(retrier.backoff.MaxDelay) {
+		delay = float64(retrier.backoff.MaxDelay)
+	}
+
+	if retrier.backoff.Jitter > 0 {
+		delay += delay * retrier.backoff.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
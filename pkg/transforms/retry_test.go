@@ -0,0 +1,168 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// flakyFunction fails the number of times specified, setting the data as its retry data, before passing it on
+func flakyFunction(failures int, calls *int) interfaces.AppFunction {
+	return func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		*calls++
+		if *calls <= failures {
+			ctx.SetRetryData([]byte(data.(string)))
+			return false, errors.New("destination unavailable")
+		}
+		return true, data
+	}
+}
+
+func TestNewRetrier(t *testing.T) {
+	noop := func(_ interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		return true, data
+	}
+
+	tests := []struct {
+		Name        string
+		Function    interfaces.AppFunction
+		Attempts    int
+		Backoff     Backoff
+		ExpectError bool
+	}{
+		{"Valid", noop, 3, Backoff{InitialDelay: time.Second, MaxDelay: time.Minute, Jitter: 0.2}, false},
+		{"Valid no backoff", noop, 1, Backoff{}, false},
+		{"No function", nil, 3, Backoff{}, true},
+		{"No attempts", noop, 0, Backoff{}, true},
+		{"Negative delay", noop, 3, Backoff{InitialDelay: -time.Second}, true},
+		{"Multiplier", noop, 3, Backoff{Multiplier: 0.5}, true},
+		{"Jitter", noop, 3, Backoff{Jitter: 1.5}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewRetrier(test.Function, test.Attempts, test.Backoff)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRetrier_Retry(t *testing.T) {
+	var calls int
+	retrier, err := NewRetrier(flakyFunction(2, &calls), 3, Backoff{InitialDelay: time.Second, MaxDelay: 3 * time.Second})
+	require.NoError(t, err)
+
+	var delays []time.Duration
+	retrier.timer = recordDelays(&delays)
+
+	continuePipeline, result := retrier.Retry(ctx, "data")
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, "data", result)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	assert.Nil(t, ctx.RetryData(), "retry data of the failed attempts should be cleared")
+}
+
+func TestRetrier_RetryCancelled(t *testing.T) {
+	var calls int
+	retrier, err := NewRetrier(flakyFunction(5, &calls), 3, Backoff{InitialDelay: time.Hour})
+	require.NoError(t, err)
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	retryCtx := appfunction.NewContext("123", dic, "")
+	retryCtx.SetContext(cancelCtx)
+	// The pipeline times out, or the service shuts down, during the first attempt
+	cancel()
+
+	continuePipeline, result := retrier.Retry(retryCtx, "data")
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "retry cancelled after 1 of 3 attempts: context canceled")
+	assert.Equal(t, 1, calls, "function should not be executed again once cancelled")
+	assert.Equal(t, []byte("data"), retryCtx.RetryData(), "retry data of the last attempt should be kept")
+}
+
+func TestRetrier_RetryExhausted(t *testing.T) {
+	var calls int
+	retrier, err := NewRetrier(flakyFunction(5, &calls), 4, Backoff{InitialDelay: time.Second, MaxDelay: 3 * time.Second})
+	require.NoError(t, err)
+
+	var delays []time.Duration
+	retrier.timer = recordDelays(&delays)
+
+	continuePipeline, result := retrier.Retry(ctx, "data")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "failed after 4 attempts: destination unavailable")
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, delays, "delay should be capped")
+	assert.Equal(t, []byte("data"), ctx.RetryData(), "retry data of the last attempt should be kept")
+	ctx.SetRetryData(nil)
+
+	continuePipeline, result = retrier.Retry(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestRetrier_RetryFiltered(t *testing.T) {
+	var calls int
+	filter := func(_ interfaces.AppFunctionContext, _ interface{}) (bool, interface{}) {
+		calls++
+		return false, nil
+	}
+
+	retrier, err := NewRetrier(filter, 3, Backoff{})
+	require.NoError(t, err)
+
+	continuePipeline, result := retrier.Retry(ctx, "data")
+	assert.False(t, continuePipeline)
+	assert.Nil(t, result)
+	assert.Equal(t, 1, calls, "function stopping the pipeline without an error should not be retried")
+}
+
+func TestRetrier_DelayJitter(t *testing.T) {
+	retrier, err := NewRetrier(flakyFunction(0, new(int)), 5, Backoff{InitialDelay: time.Second, Multiplier: 3, Jitter: 0.5})
+	require.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		delay := retrier.delay(2)
+		assert.GreaterOrEqual(t, int64(delay), int64(1500*time.Millisecond))
+		assert.LessOrEqual(t, int64(delay), int64(4500*time.Millisecond))
+	}
+}
+
+// recordDelays returns a timer that elapses right away, recording the delays waited for
+func recordDelays(delays *[]time.Duration) func(time.Duration) (<-chan time.Time, func()) {
+	return func(delay time.Duration) (<-chan time.Time, func()) {
+		*delays = append(*delays, delay)
+		elapsed := make(chan time.Time, 1)
+		elapsed <- time.Now()
+		return elapsed, func() {}
+	}
+}
@@ -0,0 +1,296 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/models"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// RuleActionCommand issues a command to a device via Core Command
+	RuleActionCommand = "command"
+	// RuleActionNotification sends a notification via Support Notifications
+	RuleActionNotification = "notification"
+	// RuleActionExport HTTP POSTs the data to an export destination
+	RuleActionExport = "export"
+)
+
+// Rule action parameter names. Values may contain '{context-key}' placeholders which are replaced with
+// the values from the context, i.e. '{devicename}'.
+const (
+	RuleParamDeviceName  = "devicename"
+	RuleParamCommandName = "commandname"
+	RuleParamMethod      = "method"
+	RuleParamSettings    = "settings"
+	RuleParamCategory    = "category"
+	RuleParamLabels      = "labels"
+	RuleParamSender      = "sender"
+	RuleParamSeverity    = "severity"
+	RuleParamContent     = "content"
+	RuleParamContentType = "contenttype"
+	RuleParamUrl         = "url"
+	RuleParamMimeType    = "mimetype"
+)
+
+const (
+	ruleCommandMethodGet  = "get"
+	ruleCommandMethodSet  = "set"
+	ruleSettingsSeparator = ":"
+	defaultRuleSender     = "app-service-rules"
+	defaultRuleSeverity   = models.Normal
+	defaultRuleContent    = "Rule '%s' triggered"
+)
+
+// RuleAction is an action run when the condition of the Rule it belongs to is met
+type RuleAction struct {
+	// Type is one of RuleActionCommand, RuleActionNotification or RuleActionExport
+	Type string
+	// Parameters are the action type specific parameters. Keys are case insensitive.
+	Parameters map[string]string
+}
+
+// Rule is a condition evaluated against the data in the pipeline and the actions to run when it is met
+type Rule struct {
+	// Name identifies the rule in logs
+	Name string
+	// Condition is a JSONLogic expression evaluated against the data, which is met when it results in true
+	Condition string
+	// Actions to run, in order, when the condition is met
+	Actions []RuleAction
+}
+
+// Validate checks that the rule has a condition and the actions have the parameters they require
+func (rule Rule) Validate() error {
+	if strings.TrimSpace(rule.Condition) == "" {
+		return fmt.Errorf("rule '%s' has no Condition", rule.Name)
+	}
+
+	if len(rule.Actions) == 0 {
+		return fmt.Errorf("rule '%s' has no Actions", rule.Name)
+	}
+
+	for index, action := range rule.Actions {
+		var required []string
+		switch strings.ToLower(action.Type) {
+		case RuleActionCommand:
+			required = []string{RuleParamDeviceName, RuleParamCommandName}
+			method := strings.ToLower(action.parameter(RuleParamMethod))
+			if method != "" && method != ruleCommandMethodGet && method != ruleCommandMethodSet {
+				return fmt.Errorf("rule '%s' action #%d has invalid command method '%s', must be 'get' or 'set'", rule.Name, index, method)
+			}
+			if _, err := parseRuleCommandSettings(action.parameter(RuleParamSettings)); err != nil {
+				return fmt.Errorf("rule '%s' action #%d: %s", rule.Name, index, err.Error())
+			}
+		case RuleActionNotification:
+			if action.parameter(RuleParamCategory) == "" && action.parameter(RuleParamLabels) == "" {
+				return fmt.Errorf("rule '%s' action #%d requires either the '%s' or '%s' parameter", rule.Name, index, RuleParamCategory, RuleParamLabels)
+			}
+		case RuleActionExport:
+			required = []string{RuleParamUrl}
+		default:
+			return fmt.Errorf("rule '%s' action #%d has unsupported type '%s'", rule.Name, index, action.Type)
+		}
+
+		for _, name := range required {
+			if action.parameter(name) == "" {
+				return fmt.Errorf("rule '%s' action #%d is missing the required '%s' parameter", rule.Name, index, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (action RuleAction) parameter(name string) string {
+	for key, value := range action.Parameters {
+		if strings.EqualFold(key, name) {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
+
+// RulesEngine evaluates a set of local rules against the data flowing through the pipeline so simple
+// edge logic, such as issuing a device command or sending a notification, doesn't require an external rules engine.
+type RulesEngine struct {
+	rules []Rule
+}
+
+// NewRulesEngine creates, initializes and returns a new instance of RulesEngine
+func NewRulesEngine(rules []Rule) RulesEngine {
+	return RulesEngine{
+		rules: rules,
+	}
+}
+
+// Evaluate evaluates each rule's condition against the data and runs the actions of the rules whose condition is met.
+// Failed actions are logged and don't stop the pipeline. The data is always passed on to the next function.
+func (engine RulesEngine) Evaluate(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function EvaluateRules in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	lc := ctx.LoggingClient()
+
+	jsonData, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rule := range engine.rules {
+		met, err := applyJSONLogic(rule.Condition, jsonData)
+		if err != nil {
+			lc.Errorf("Rule '%s' condition failed in pipeline '%s': %s", rule.Name, ctx.PipelineId(), err.Error())
+			continue
+		}
+
+		if !met {
+			continue
+		}
+
+		lc.Debugf("Rule '%s' condition met in pipeline '%s'", rule.Name, ctx.PipelineId())
+
+		for index, action := range rule.Actions {
+			if err := engine.runAction(ctx, rule, action, data); err != nil {
+				lc.Errorf("Rule '%s' action #%d (%s) failed in pipeline '%s': %s",
+					rule.Name, index, action.Type, ctx.PipelineId(), err.Error())
+			}
+		}
+	}
+
+	return true, data
+}
+
+func (engine RulesEngine) runAction(ctx interfaces.AppFunctionContext, rule Rule, action RuleAction, data interface{}) error {
+	parameters := make(map[string]string, len(action.Parameters))
+	for key, value := range action.Parameters {
+		formatted, err := ctx.ApplyValues(value)
+		if err != nil {
+			return err
+		}
+		parameters[strings.ToLower(key)] = strings.TrimSpace(formatted)
+	}
+
+	switch strings.ToLower(action.Type) {
+	case RuleActionCommand:
+		return engine.issueCommand(ctx, parameters)
+	case RuleActionNotification:
+		return engine.sendNotification(ctx, rule, parameters)
+	case RuleActionExport:
+		sender := NewHTTPSender(parameters[RuleParamUrl], parameters[RuleParamMimeType], false)
+		if _, result := sender.HTTPPost(ctx, data); result != nil {
+			if err, ok := result.(error); ok {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported action type '%s'", action.Type)
+	}
+}
+
+func (engine RulesEngine) issueCommand(ctx interfaces.AppFunctionContext, parameters map[string]string) error {
+	client := ctx.CommandClient()
+	if client == nil {
+		return errors.New("CommandClient not initialized. Core Command is missing from clients configuration")
+	}
+
+	deviceName := parameters[RuleParamDeviceName]
+	commandName := parameters[RuleParamCommandName]
+
+	if strings.ToLower(parameters[RuleParamMethod]) == ruleCommandMethodGet {
+		if _, err := client.IssueGetCommandByName(context.Background(), deviceName, commandName, "no", "no"); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	settings, err := parseRuleCommandSettings(parameters[RuleParamSettings])
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.IssueSetCommandByName(context.Background(), deviceName, commandName, settings); err != nil {
+		return err
+	}
+
+	ctx.LoggingClient().Debugf("Rule issued '%s' command to device '%s'", commandName, deviceName)
+	return nil
+}
+
+func (engine RulesEngine) sendNotification(ctx interfaces.AppFunctionContext, rule Rule, parameters map[string]string) error {
+	client := ctx.NotificationClient()
+	if client == nil {
+		return errors.New("NotificationClient not initialized. Support Notifications is missing from clients configuration")
+	}
+
+	sender := parameters[RuleParamSender]
+	if sender == "" {
+		sender = defaultRuleSender
+	}
+
+	severity := strings.ToUpper(parameters[RuleParamSeverity])
+	if severity == "" {
+		severity = defaultRuleSeverity
+	}
+
+	content := parameters[RuleParamContent]
+	if content == "" {
+		content = fmt.Sprintf(defaultRuleContent, rule.Name)
+	}
+
+	labels := util.DeleteEmptyAndTrim(strings.FieldsFunc(parameters[RuleParamLabels], util.SplitComma))
+
+	notification := dtos.NewNotification(labels, parameters[RuleParamCategory], content, sender, severity)
+	notification.ContentType = parameters[RuleParamContentType]
+	notification.Description = fmt.Sprintf(defaultRuleContent, rule.Name)
+
+	request := requests.NewAddNotificationRequest(notification)
+	if _, err := client.SendNotification(context.Background(), []requests.AddNotificationRequest{request}); err != nil {
+		return err
+	}
+
+	ctx.LoggingClient().Debugf("Rule '%s' sent notification", rule.Name)
+	return nil
+}
+
+// parseRuleCommandSettings parses command settings in the form "resource1:value1, resource2:value2"
+func parseRuleCommandSettings(spec string) (map[string]string, error) {
+	settings := make(map[string]string)
+
+	for _, setting := range util.DeleteEmptyAndTrim(strings.FieldsFunc(spec, util.SplitComma)) {
+		keyValue := strings.SplitN(setting, ruleSettingsSeparator, 2)
+		if len(keyValue) != 2 || strings.TrimSpace(keyValue[0]) == "" {
+			return nil, fmt.Errorf("bad command setting specification '%s', must be in the form 'resource:value'", setting)
+		}
+		settings[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,209 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bootstrapContainer "github.com/edgexfoundry/go-mod-bootstrap/v2/bootstrap/container"
+	"github.com/edgexfoundry/go-mod-bootstrap/v2/di"
+	clientInterfaces "github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/interfaces/mocks"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	commonDtos "github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/requests"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos/responses"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/bootstrap/container"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/common"
+)
+
+// fakeCommandClient records the commands issued since the core-contracts module doesn't provide a CommandClient mock
+type fakeCommandClient struct {
+	clientInterfaces.CommandClient
+	setDevice   string
+	setCommand  string
+	setSettings map[string]string
+	getCommand  string
+}
+
+func (client *fakeCommandClient) IssueSetCommandByName(_ context.Context, deviceName string, commandName string, settings map[string]string) (commonDtos.BaseResponse, errors.EdgeX) {
+	client.setDevice = deviceName
+	client.setCommand = commandName
+	client.setSettings = settings
+	return commonDtos.BaseResponse{}, nil
+}
+
+func (client *fakeCommandClient) IssueGetCommandByName(_ context.Context, _ string, commandName string, _ string, _ string) (*responses.EventResponse, errors.EdgeX) {
+	client.getCommand = commandName
+	return nil, nil
+}
+
+func newRulesContext(t *testing.T, commandClient clientInterfaces.CommandClient, notificationClient clientInterfaces.NotificationClient) *appfunction.Context {
+	rulesDic := di.NewContainer(di.ServiceConstructorMap{
+		container.ConfigurationName: func(get di.Get) interface{} {
+			return &common.ConfigurationStruct{}
+		},
+		container.CommandClientName: func(get di.Get) interface{} {
+			return commandClient
+		},
+		container.NotificationClientName: func(get di.Get) interface{} {
+			return notificationClient
+		},
+		bootstrapContainer.LoggingClientInterfaceName: func(get di.Get) interface{} {
+			return lc
+		},
+	})
+
+	rulesCtx := appfunction.NewContext("123", rulesDic, "")
+	rulesCtx.AddValue("devicename", "thermostat")
+	require.NotNil(t, rulesCtx.CommandClient())
+	return rulesCtx
+}
+
+func TestRulesEngine_Evaluate(t *testing.T) {
+	event := dtos.NewEvent("profile", "thermostat", "source")
+	_ = event.AddSimpleReading("temperature", "Int32", int32(90))
+
+	var exported []byte
+	exportServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exported, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer exportServer.Close()
+
+	commandClient := &fakeCommandClient{}
+	notificationClient := &mocks.NotificationClient{}
+	var sent []requests.AddNotificationRequest
+	notificationClient.On("SendNotification", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { sent = args.Get(1).([]requests.AddNotificationRequest) }).
+		Return(nil, nil)
+
+	rules := []Rule{
+		{
+			Name:      "thermostat-fan",
+			Condition: `{ "==" : [ { "var" : "deviceName" }, "thermostat" ] }`,
+			Actions: []RuleAction{
+				{Type: RuleActionCommand, Parameters: map[string]string{"DeviceName": "{devicename}-fan", "CommandName": "Switch", "Settings": "State:on, Speed:high"}},
+				{Type: RuleActionNotification, Parameters: map[string]string{"Category": "temperature", "Severity": "critical", "Content": "{devicename} is too hot"}},
+				{Type: RuleActionExport, Parameters: map[string]string{"Url": exportServer.URL}},
+			},
+		},
+		{
+			Name:      "freezer-heater",
+			Condition: `{ "==" : [ { "var" : "deviceName" }, "freezer" ] }`,
+			Actions: []RuleAction{
+				{Type: RuleActionCommand, Parameters: map[string]string{"DeviceName": "heater", "CommandName": "Heat", "Method": "get"}},
+			},
+		},
+	}
+
+	engine := NewRulesEngine(rules)
+	continuePipeline, result := engine.Evaluate(newRulesContext(t, commandClient, notificationClient), event)
+
+	require.True(t, continuePipeline)
+	assert.Equal(t, event, result, "data should be passed through unchanged")
+
+	assert.Equal(t, "thermostat-fan", commandClient.setDevice)
+	assert.Equal(t, "Switch", commandClient.setCommand)
+	assert.Equal(t, map[string]string{"State": "on", "Speed": "high"}, commandClient.setSettings)
+	assert.Empty(t, commandClient.getCommand, "freezer-heater rule should not have been triggered")
+
+	require.Len(t, sent, 1)
+	assert.Equal(t, "thermostat is too hot", sent[0].Notification.Content)
+	assert.Equal(t, "CRITICAL", sent[0].Notification.Severity)
+	assert.Equal(t, "temperature", sent[0].Notification.Category)
+	assert.Equal(t, defaultRuleSender, sent[0].Notification.Sender)
+
+	assert.Contains(t, string(exported), `"deviceName":"thermostat"`)
+}
+
+func TestRulesEngine_EvaluateActionFailureContinues(t *testing.T) {
+	rules := []Rule{
+		{
+			Name:      "always",
+			Condition: `{ "==" : [1, 1] }`,
+			Actions: []RuleAction{
+				{Type: RuleActionCommand, Parameters: map[string]string{"DeviceName": "{missing}", "CommandName": "Switch"}},
+			},
+		},
+		{
+			Name:      "bad condition",
+			Condition: `not json logic`,
+			Actions:   []RuleAction{{Type: RuleActionExport, Parameters: map[string]string{"Url": "http://localhost"}}},
+		},
+	}
+
+	engine := NewRulesEngine(rules)
+	continuePipeline, result := engine.Evaluate(newRulesContext(t, &fakeCommandClient{}, &mocks.NotificationClient{}), `{"value":1}`)
+
+	assert.True(t, continuePipeline)
+	assert.Equal(t, `{"value":1}`, result)
+}
+
+func TestRulesEngine_EvaluateNoData(t *testing.T) {
+	engine := NewRulesEngine(nil)
+	continuePipeline, result := engine.Evaluate(ctx, nil)
+
+	assert.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
+
+func TestRule_Validate(t *testing.T) {
+	condition := `{ "==" : [1, 1] }`
+
+	tests := []struct {
+		Name          string
+		Rule          Rule
+		ExpectedError string
+	}{
+		{"Valid command", Rule{"r", condition, []RuleAction{{RuleActionCommand, map[string]string{"devicename": "d", "commandname": "c", "method": "SET", "settings": "a:1"}}}}, ""},
+		{"Valid notification", Rule{"r", condition, []RuleAction{{RuleActionNotification, map[string]string{"labels": "a,b"}}}}, ""},
+		{"Valid export", Rule{"r", condition, []RuleAction{{"Export", map[string]string{"URL": "http://localhost"}}}}, ""},
+		{"No condition", Rule{"r", " ", []RuleAction{{RuleActionExport, map[string]string{"url": "http://localhost"}}}}, "no Condition"},
+		{"No actions", Rule{"r", condition, nil}, "no Actions"},
+		{"Unsupported type", Rule{"r", condition, []RuleAction{{"email", nil}}}, "unsupported type"},
+		{"Missing command name", Rule{"r", condition, []RuleAction{{RuleActionCommand, map[string]string{"devicename": "d"}}}}, "'commandname'"},
+		{"Bad method", Rule{"r", condition, []RuleAction{{RuleActionCommand, map[string]string{"devicename": "d", "commandname": "c", "method": "put"}}}}, "invalid command method"},
+		{"Bad settings", Rule{"r", condition, []RuleAction{{RuleActionCommand, map[string]string{"devicename": "d", "commandname": "c", "settings": "novalue"}}}}, "bad command setting"},
+		{"Notification missing category and labels", Rule{"r", condition, []RuleAction{{RuleActionNotification, nil}}}, "requires either"},
+		{"Export missing url", Rule{"r", condition, []RuleAction{{RuleActionExport, nil}}}, "'url'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			err := test.Rule.Validate()
+			if test.ExpectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,358 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// DefaultScriptTimeout is the maximum time a script runs for each execution when no timeout is given
+const DefaultScriptTimeout = time.Second
+
+// The base library functions removed so scripts can't access the file system or load other code
+var unsafeScriptGlobals = []string{"dofile", "loadfile", "load", "loadstring", "module", "require", "print"}
+
+// LuaScript runs a Lua script on the data, so small changes to the payload can be deployed via configuration without
+// rebuilding the service. The script is given the data in the global 'data', as a table when the data is an Event or
+// JSON, otherwise as a string, and the pipeline's id, the message's correlation id and the context values in the
+// 'context' table. The script returns the new data, or nil to stop the pipeline, and can call log(message) to log at
+// the info level. Only the base, string, table and math libraries are available. Lua numbers are floating point, so
+// integers too large to be exact, i.e. the Event's Origin, are given to the script as strings and returned as numbers.
+type LuaScript struct {
+	name   string
+	proto  *lua.FunctionProto
+	limits interfaces.SandboxLimits
+}
+
+// NewLuaScript creates, initializes and returns a new instance of LuaScript with the script compiled, so syntax
+// errors are returned when the pipeline is configured. name identifies the script in errors. timeout is the maximum
+// time the script runs for each execution, DefaultScriptTimeout when 0.
+func NewLuaScript(name string, script string, timeout time.Duration) (*LuaScript, error) {
+	return NewSandboxedLuaScript(name, script, interfaces.SandboxLimits{CPUTime: timeout})
+}
+
+// NewSandboxedLuaScript creates, initializes and returns a new instance of LuaScript enforcing the limits on each
+// execution. The CPUTime is the maximum time the script runs, DefaultScriptTimeout when 0. The Memory is the maximum
+// bytes of values the script holds, including the roughly 7KB of the libraries, not enforced when 0.
+func NewSandboxedLuaScript(name string, script string, limits interfaces.SandboxLimits) (*LuaScript, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse script '%s': %s", name, err.Error())
+	}
+
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compile script '%s': %s", name, err.Error())
+	}
+
+	if limits.CPUTime <= 0 {
+		limits.CPUTime = DefaultScriptTimeout
+	}
+
+	if limits.Memory < 0 {
+		return nil, fmt.Errorf("memory limit of script '%s' can not be negative", name)
+	}
+
+	return &LuaScript{
+		name:   name,
+		proto:  proto,
+		limits: limits,
+	}, nil
+}
+
+// ScriptTransform runs the script on the data and continues the pipeline with the data returned by the script. Tables
+// are returned as an Event when the data received was an Event, otherwise as JSON, and strings are returned as bytes.
+// Each execution runs in its own Lua state so executions don't share globals.
+// This function will return an error and stop the pipeline if no data is received, the script fails, or a table
+// returned for an Event is not a valid Event. An interfaces.SandboxViolation is returned when the script runs longer
+// than its CPUTime or holds more than its Memory.
+func (script *LuaScript) ScriptTransform(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function ScriptTransform in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Running script '%s' in pipeline '%s'", script.name, ctx.PipelineId())
+
+	_, isEvent := data.(dtos.Event)
+	input, largeNumbers, err := scriptInput(data)
+	if err != nil {
+		return false, fmt.Errorf("function ScriptTransform in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	state := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer state.Close()
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), script.limits.CPUTime)
+	defer cancel()
+
+	var meter *scriptMeter
+	if script.limits.Memory > 0 {
+		meter = newScriptMeter(timeoutCtx, state, script.limits.Memory)
+		state.SetContext(meter)
+	} else {
+		state.SetContext(timeoutCtx)
+	}
+
+	openScriptLibraries(state)
+	state.SetGlobal("data", toLuaValue(state, input, largeNumbers))
+	state.SetGlobal("context", scriptContext(state, ctx))
+	state.SetGlobal("log", state.NewFunction(func(state *lua.LState) int {
+		ctx.LoggingClient().Infof("Script '%s': %s", script.name, state.CheckString(1))
+		return 0
+	}))
+
+	state.Push(state.NewFunctionFromProto(script.proto))
+	if err := state.PCall(0, 1, nil); err != nil {
+		if violation := script.violation(timeoutCtx, meter); violation != nil {
+			return false, fmt.Errorf("function ScriptTransform in pipeline '%s': %w", ctx.PipelineId(), violation)
+		}
+		return false, fmt.Errorf("function ScriptTransform in pipeline '%s': script '%s' failed: %s",
+			ctx.PipelineId(), script.name, err.Error())
+	}
+
+	result := state.Get(-1)
+	if result == lua.LNil {
+		ctx.LoggingClient().Debugf("Script '%s' returned nil, stopping pipeline '%s'", script.name, ctx.PipelineId())
+		return false, nil
+	}
+
+	output, err := scriptOutput(result, isEvent, largeNumbers)
+	if err != nil {
+		return false, fmt.Errorf("function ScriptTransform in pipeline '%s': script '%s' result: %s",
+			ctx.PipelineId(), script.name, err.Error())
+	}
+
+	return true, output
+}
+
+// violation returns the limit exceeded by the script's execution, if any
+func (script *LuaScript) violation(timeoutCtx context.Context, meter *scriptMeter) error {
+	function := fmt.Sprintf("script '%s'", script.name)
+
+	if meter != nil && meter.exceeded {
+		return interfaces.SandboxViolation{
+			Function: function,
+			Limit:    interfaces.SandboxLimitMemory,
+			Ceiling:  fmt.Sprintf("%d bytes", script.limits.Memory),
+		}
+	}
+
+	if timeoutCtx.Err() != nil {
+		return interfaces.SandboxViolation{
+			Function: function,
+			Limit:    interfaces.SandboxLimitCPUTime,
+			Ceiling:  script.limits.CPUTime.String(),
+		}
+	}
+
+	return nil
+}
+
+// scriptInput converts the data to the value given to the script, the decoded JSON when the data is JSON, otherwise the
+// data as a string. Also returns the integers in the JSON too large to be exact as Lua numbers.
+func scriptInput(data interface{}) (interface{}, map[string]bool, error) {
+	content, err := util.CoerceType(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil || decoder.More() {
+		return string(content), nil, nil
+	}
+
+	largeNumbers := make(map[string]bool)
+	findLargeNumbers(decoded, largeNumbers)
+	return decoded, largeNumbers, nil
+}
+
+// scriptOutput converts the script's result to the data passed on by the pipeline
+func scriptOutput(result lua.LValue, isEvent bool, largeNumbers map[string]bool) (interface{}, error) {
+	if value, ok := result.(lua.LString); ok {
+		return []byte(value), nil
+	}
+
+	content, err := json.Marshal(fromLuaValue(result, largeNumbers))
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEvent {
+		return content, nil
+	}
+
+	event := dtos.Event{}
+	if err := json.Unmarshal(content, &event); err != nil {
+		return nil, fmt.Errorf("not a valid Event: %s", err.Error())
+	}
+
+	return event, nil
+}
+
+func findLargeNumbers(value interface{}, largeNumbers map[string]bool) {
+	switch value := value.(type) {
+	case json.Number:
+		if isLargeNumber(value) {
+			largeNumbers[value.String()] = true
+		}
+	case []interface{}:
+		for _, item := range value {
+			findLargeNumbers(item, largeNumbers)
+		}
+	case map[string]interface{}:
+		for _, item := range value {
+			findLargeNumbers(item, largeNumbers)
+		}
+	}
+}
+
+// isLargeNumber returns true if the number is an integer that can't be represented exactly as a float64
+func isLargeNumber(number json.Number) bool {
+	integer, err := number.Int64()
+	return err == nil && (integer > maxExactInteger || integer < -maxExactInteger)
+}
+
+func openScriptLibraries(state *lua.LState) {
+	libraries := []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	}
+
+	for _, library := range libraries {
+		state.Push(state.NewFunction(library.open))
+		state.Push(lua.LString(library.name))
+		state.Call(1, 0)
+	}
+
+	for _, name := range unsafeScriptGlobals {
+		state.SetGlobal(name, lua.LNil)
+	}
+}
+
+func scriptContext(state *lua.LState, ctx interfaces.AppFunctionContext) *lua.LTable {
+	values := state.NewTable()
+	for key, value := range ctx.GetAllValues() {
+		values.RawSetString(key, lua.LString(value))
+	}
+
+	table := state.NewTable()
+	table.RawSetString("pipelineId", lua.LString(ctx.PipelineId()))
+	table.RawSetString("correlationId", lua.LString(ctx.CorrelationID()))
+	table.RawSetString("values", values)
+	return table
+}
+
+// maxExactInteger is the largest integer a float64, and so a Lua number, represents exactly
+const maxExactInteger = 1 << 53
+
+// toLuaValue converts the decoded JSON value to a Lua value, arrays to tables indexed from 1
+func toLuaValue(state *lua.LState, value interface{}, largeNumbers map[string]bool) lua.LValue {
+	switch value := value.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(value)
+	case json.Number:
+		if largeNumbers[value.String()] {
+			return lua.LString(value.String())
+		}
+		number, _ := value.Float64()
+		return lua.LNumber(number)
+	case string:
+		return lua.LString(value)
+	case []interface{}:
+		table := state.CreateTable(len(value), 0)
+		for _, item := range value {
+			table.Append(toLuaValue(state, item, largeNumbers))
+		}
+		return table
+	case map[string]interface{}:
+		table := state.CreateTable(0, len(value))
+		for key, item := range value {
+			table.RawSetString(key, toLuaValue(state, item, largeNumbers))
+		}
+		return table
+	default:
+		return lua.LString(fmt.Sprintf("%v", value))
+	}
+}
+
+// fromLuaValue converts the Lua value to a value that can be marshaled to JSON. Tables with only the keys 1 to n are
+// converted to arrays, other tables to objects. Whole numbers are converted to integers so they aren't marshaled with
+// an exponent, and the large integers given to the script as strings are converted back to numbers.
+func fromLuaValue(value lua.LValue, largeNumbers map[string]bool) interface{} {
+	switch value := value.(type) {
+	case lua.LBool:
+		return bool(value)
+	case lua.LNumber:
+		number := float64(value)
+		if number == math.Trunc(number) && math.Abs(number) <= maxExactInteger {
+			return int64(number)
+		}
+		return number
+	case lua.LString:
+		if largeNumbers[string(value)] {
+			return json.Number(value)
+		}
+		return string(value)
+	case *lua.LTable:
+		if length := value.MaxN(); length > 0 && length == countTableKeys(value) {
+			array := make([]interface{}, length)
+			for index := 1; index <= length; index++ {
+				array[index-1] = fromLuaValue(value.RawGetInt(index), largeNumbers)
+			}
+			return array
+		}
+
+		object := make(map[string]interface{})
+		value.ForEach(func(key lua.LValue, item lua.LValue) {
+			object[key.String()] = fromLuaValue(item, largeNumbers)
+		})
+		return object
+	default:
+		return nil
+	}
+}
+
+func countTableKeys(table *lua.LTable) int {
+	count := 0
+	table.ForEach(func(_ lua.LValue, _ lua.LValue) {
+		count++
+	})
+	return count
+}
@@ -0,0 +1,161 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestNewLuaScript(t *testing.T) {
+	_, err := NewLuaScript("good", "return data", 0)
+	require.NoError(t, err)
+
+	_, err = NewLuaScript("bad", "return data +", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to parse script 'bad'")
+}
+
+func TestLuaScript_ScriptTransform_Event(t *testing.T) {
+	script, err := NewLuaScript("fahrenheit", `
+		local readings = {}
+		for _, reading in ipairs(data.readings) do
+			if reading.resourceName == "temperature" then
+				reading.value = string.format("%.1f", tonumber(reading.value) * 9 / 5 + 32)
+				table.insert(readings, reading)
+			end
+		end
+		data.readings = readings
+		data.tags = {pipeline = context.pipelineId}
+		return data
+	`, 0)
+	require.NoError(t, err)
+
+	received := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	require.NoError(t, received.AddSimpleReading("temperature", common.ValueTypeFloat64, 20.0))
+	require.NoError(t, received.AddSimpleReading("humidity", common.ValueTypeFloat64, 50.0))
+
+	continuePipeline, result := script.ScriptTransform(ctx, received)
+	require.True(t, continuePipeline, result)
+	event, ok := result.(dtos.Event)
+	require.True(t, ok, "result should be an Event")
+	assert.Equal(t, received.Id, event.Id)
+	assert.Equal(t, received.Origin, event.Origin, "large integers should not lose precision")
+	require.Len(t, event.Readings, 1)
+	assert.Equal(t, "68.0", event.Readings[0].Value)
+	assert.Equal(t, received.Readings[0].Origin, event.Readings[0].Origin)
+	assert.Equal(t, map[string]interface{}{"pipeline": ctx.PipelineId()}, event.Tags)
+}
+
+func TestLuaScript_ScriptTransform(t *testing.T) {
+	tests := []struct {
+		Name             string
+		Script           string
+		Data             interface{}
+		ExpectedContinue bool
+		ExpectedResult   interface{}
+		ExpectedError    string
+	}{
+		{"JSON", `data.count = data.count + 1; data.ratio = data.count / 4; return data`, []byte(`{"count": 1}`), true, []byte(`{"count":2,"ratio":0.5}`), ""},
+		{"Array", `table.insert(data, "c"); return data`, `["a","b"]`, true, []byte(`["a","b","c"]`), ""},
+		{"String", `return string.upper(data)`, "hello", true, []byte("HELLO"), ""},
+		{"Filter", `if data.count < 10 then return nil end return data`, []byte(`{"count": 1}`), false, nil, ""},
+		{"No data", `return data`, nil, false, nil, "No Data Received"},
+		{"Runtime error", `error("bad data")`, "hello", false, nil, "script 'test' failed"},
+		{"Invalid Event", `data.readings = "none"; return data`, dtos.NewEvent("thermostat", "thermostat-1", "status"), false, nil, "not a valid Event"},
+		{"No file access", `return dofile("/etc/passwd")`, "hello", false, nil, "script 'test' failed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			script, err := NewLuaScript("test", test.Script, 0)
+			require.NoError(t, err)
+
+			continuePipeline, result := script.ScriptTransform(ctx, test.Data)
+			assert.Equal(t, test.ExpectedContinue, continuePipeline)
+			if test.ExpectedError != "" {
+				require.Error(t, result.(error))
+				assert.Contains(t, result.(error).Error(), test.ExpectedError)
+				return
+			}
+
+			assert.Equal(t, test.ExpectedResult, result)
+		})
+	}
+}
+
+func TestLuaScript_ScriptTransform_Timeout(t *testing.T) {
+	script, err := NewLuaScript("loop", `while true do end`, 50*time.Millisecond)
+	require.NoError(t, err)
+
+	continuePipeline, result := script.ScriptTransform(ctx, "hello")
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+	assert.Contains(t, result.(error).Error(), "ran longer than 50ms")
+}
+
+func TestLuaScript_ScriptTransform_MemoryLimit(t *testing.T) {
+	script, err := NewSandboxedLuaScript("grow", `
+local items = {}
+for i = 1, 100000 do
+  items[i] = string.rep("x", 100) .. i
+end
+return "done"`, interfaces.SandboxLimits{Memory: 1024 * 1024})
+	require.NoError(t, err)
+
+	continuePipeline, result := script.ScriptTransform(ctx, "hello")
+	require.False(t, continuePipeline)
+	require.Error(t, result.(error))
+
+	var violation interfaces.SandboxViolation
+	require.True(t, errors.As(result.(error), &violation), "memory violation should be reported")
+	assert.Equal(t, interfaces.SandboxLimitMemory, violation.Limit)
+	assert.Contains(t, result.(error).Error(), "script 'grow' exceeded its memory limit of 1048576 bytes")
+
+	small, err := NewSandboxedLuaScript("small", `return data .. " world"`, interfaces.SandboxLimits{Memory: 1024 * 1024})
+	require.NoError(t, err)
+
+	continuePipeline, result = small.ScriptTransform(ctx, "hello")
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, []byte("hello world"), result)
+
+	_, err = NewSandboxedLuaScript("negative", `return data`, interfaces.SandboxLimits{Memory: -1})
+	require.Error(t, err)
+}
+
+func TestScriptMemory(t *testing.T) {
+	state := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer state.Close()
+	openScriptLibraries(state)
+
+	libraries := scriptMemory(state, math.MaxInt64)
+	assert.Less(t, libraries, int64(16*1024), "libraries should take roughly 7KB")
+
+	state.SetGlobal("data", lua.LString(strings.Repeat("x", 1000)))
+	assert.Equal(t, libraries+scriptValueSize*2+1000+4, scriptMemory(state, math.MaxInt64))
+}
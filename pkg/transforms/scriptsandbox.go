@@ -0,0 +1,149 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"context"
+	"errors"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// scriptMemoryCheckInterval is the number of instructions the script executes between estimates of its memory
+const scriptMemoryCheckInterval = 100
+
+// Estimated bytes held by the script's values, which are stored as interfaces
+const (
+	scriptValueSize    = 16
+	scriptTableSize    = 64
+	scriptFunctionSize = 64
+)
+
+var errScriptMemory = errors.New("script memory limit exceeded")
+
+// scriptMeter is the context of a sandboxed script execution enforcing its memory ceiling. The interpreter checks
+// the context before each instruction, so the memory held by the script is estimated from the interpreter's goroutine
+// every scriptMemoryCheckInterval instructions and the context is done once the ceiling is exceeded. The script can
+// exceed the ceiling between estimates.
+type scriptMeter struct {
+	context.Context
+	state        *lua.LState
+	limit        int64
+	instructions int
+	exceeded     bool
+	done         chan struct{}
+}
+
+func newScriptMeter(ctx context.Context, state *lua.LState, limit int64) *scriptMeter {
+	return &scriptMeter{
+		Context: ctx,
+		state:   state,
+		limit:   limit,
+		done:    make(chan struct{}),
+	}
+}
+
+func (meter *scriptMeter) Done() <-chan struct{} {
+	if meter.exceeded {
+		return meter.done
+	}
+
+	meter.instructions++
+	if meter.instructions%scriptMemoryCheckInterval == 0 && scriptMemory(meter.state, meter.limit) > meter.limit {
+		meter.exceeded = true
+		close(meter.done)
+		return meter.done
+	}
+
+	return meter.Context.Done()
+}
+
+func (meter *scriptMeter) Err() error {
+	if meter.exceeded {
+		return errScriptMemory
+	}
+
+	return meter.Context.Err()
+}
+
+// scriptMemory estimates the bytes held by the values reachable from the script's globals and the locals of its
+// running functions, stopping once the limit is exceeded
+func scriptMemory(state *lua.LState, limit int64) int64 {
+	estimate := &memoryEstimate{
+		limit:   limit,
+		visited: make(map[lua.LValue]bool),
+	}
+
+	estimate.add(state.G.Global)
+	for level := 0; estimate.size <= limit; level++ {
+		frame, ok := state.GetStack(level)
+		if !ok {
+			break
+		}
+
+		for local := 1; ; local++ {
+			name, value := state.GetLocal(frame, local)
+			if name == "" {
+				break
+			}
+			estimate.add(value)
+		}
+	}
+
+	return estimate.size
+}
+
+type memoryEstimate struct {
+	limit   int64
+	size    int64
+	visited map[lua.LValue]bool
+}
+
+func (estimate *memoryEstimate) add(value lua.LValue) {
+	if estimate.size > estimate.limit {
+		return
+	}
+
+	switch value := value.(type) {
+	case lua.LString:
+		estimate.size += scriptValueSize + int64(len(value))
+	case *lua.LTable:
+		if estimate.visited[value] {
+			return
+		}
+		estimate.visited[value] = true
+		estimate.size += scriptTableSize
+		value.ForEach(func(key lua.LValue, item lua.LValue) {
+			estimate.add(key)
+			estimate.add(item)
+		})
+		if metatable, ok := value.Metatable.(*lua.LTable); ok {
+			estimate.add(metatable)
+		}
+	case *lua.LFunction:
+		if estimate.visited[value] {
+			return
+		}
+		estimate.visited[value] = true
+		estimate.size += scriptFunctionSize
+		for _, upvalue := range value.Upvalues {
+			estimate.add(upvalue.Value())
+		}
+	default:
+		estimate.size += scriptValueSize
+	}
+}
@@ -0,0 +1,278 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	sessionDuration = "session_duration"
+	sessionCount    = "session_count"
+	aggregateMin    = "min"
+	aggregateMax    = "max"
+	aggregateMean   = "mean"
+)
+
+// SessionWindow groups the Events of each device into sessions of activity, a session closing once no Event has been
+// received from the device for the gap, and summarizes each closed session, for occupancy and machine cycle analytics.
+type SessionWindow struct {
+	gap           time.Duration
+	resourceNames []string
+	lock          sync.Mutex
+	sessions      map[string]*session
+}
+
+// session is the state of the open session for a single device
+type session struct {
+	profileName  string
+	sourceName   string
+	tags         map[string]interface{}
+	start        int64
+	end          int64
+	count        int64
+	lastActivity time.Time
+	aggregates   map[string]*aggregate
+	resources    []string
+}
+
+// aggregate is the running aggregate of a resource's reading values within a session
+type aggregate struct {
+	count int
+	sum   float64
+	min   float64
+	max   float64
+}
+
+// NewSessionWindow creates, initializes and returns a new instance of SessionWindow.
+// gap is the time without an Event from a device after which the device's session is closed.
+// resourceNames, when not empty, limits the readings aggregated, otherwise all numeric readings are aggregated.
+func NewSessionWindow(gap time.Duration, resourceNames []string) *SessionWindow {
+	return &SessionWindow{
+		gap:           gap,
+		resourceNames: resourceNames,
+		sessions:      make(map[string]*session),
+	}
+}
+
+// Summarize adds the Event to its device's open session, or opens a new session, and returns a summary Event once the
+// session closes. The summary has the session's duration in seconds, from the first to the last Event's origin, and
+// number of Events as the session_duration and session_count readings and the min, max and mean of the numeric
+// readings as Float64 readings named <resource>_min, <resource>_max and <resource>_mean.
+// The pipeline execution for the Event opening the session waits for the session to close, the pipeline is stopped
+// for the other Events of the session. This function will return an error and stop the pipeline if a non-edgex event
+// is received, no data is received or a reading's value can't be parsed.
+func (window *SessionWindow) Summarize(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function SessionWindow in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function SessionWindow in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	// Parse all the values first so an Event with a bad value isn't partially added to the session
+	values := make(map[string]float64)
+	var resources []string
+	for _, reading := range event.Readings {
+		if !window.shouldAggregate(reading) {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			return false, fmt.Errorf("function SessionWindow in pipeline '%s': unable to parse value of reading '%s': %s",
+				ctx.PipelineId(), reading.ResourceName, err.Error())
+		}
+
+		if _, found := values[reading.ResourceName]; !found {
+			resources = append(resources, reading.ResourceName)
+		}
+		values[reading.ResourceName] = value
+	}
+
+	origin := event.Origin
+	if origin == 0 {
+		origin = time.Now().UnixNano()
+	}
+
+	window.lock.Lock()
+	current, found := window.sessions[event.DeviceName]
+	if !found {
+		current = &session{
+			profileName: event.ProfileName,
+			sourceName:  event.SourceName,
+			tags:        event.Tags,
+			start:       origin,
+			end:         origin,
+			aggregates:  make(map[string]*aggregate),
+		}
+		window.sessions[event.DeviceName] = current
+	}
+	current.add(origin, resources, values)
+	window.lock.Unlock()
+
+	if found {
+		ctx.LoggingClient().Debugf("Added Event to session of device '%s' in pipeline '%s'", event.DeviceName, ctx.PipelineId())
+		return false, nil
+	}
+
+	ctx.LoggingClient().Debugf("Opened session for device '%s' in pipeline '%s'", event.DeviceName, ctx.PipelineId())
+
+	closed := window.waitForClose(event.DeviceName)
+
+	ctx.LoggingClient().Debugf("Closed session of %d Event(s) for device '%s' in pipeline '%s'",
+		closed.count, event.DeviceName, ctx.PipelineId())
+
+	summary, err := closed.summary(event.DeviceName)
+	if err != nil {
+		return false, fmt.Errorf("function SessionWindow in pipeline '%s': unable to add summary reading: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	return true, summary
+}
+
+// waitForClose waits until no Event has been added to the device's session for the gap, then removes and returns it
+func (window *SessionWindow) waitForClose(deviceName string) *session {
+	timer := time.NewTimer(window.gap)
+	defer timer.Stop()
+
+	for {
+		<-timer.C
+
+		window.lock.Lock()
+		current := window.sessions[deviceName]
+		remaining := time.Until(current.lastActivity.Add(window.gap))
+		if remaining <= 0 {
+			delete(window.sessions, deviceName)
+			window.lock.Unlock()
+			return current
+		}
+		window.lock.Unlock()
+
+		timer.Reset(remaining)
+	}
+}
+
+func (window *SessionWindow) shouldAggregate(reading dtos.BaseReading) bool {
+	if !isNumericValueType(reading.ValueType) {
+		return false
+	}
+
+	if len(window.resourceNames) == 0 {
+		return true
+	}
+
+	for _, name := range window.resourceNames {
+		if reading.ResourceName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *aggregate) add(value float64) {
+	a.count++
+	a.sum += value
+	a.min = math.Min(a.min, value)
+	a.max = math.Max(a.max, value)
+}
+
+// result returns the aggregate function's result for the values added
+func (a *aggregate) result(function string) float64 {
+	switch function {
+	case AggregateMin:
+		return a.min
+	case AggregateMax:
+		return a.max
+	case AggregateSum:
+		return a.sum
+	default:
+		return a.sum / float64(a.count)
+	}
+}
+
+func (s *session) add(origin int64, resources []string, values map[string]float64) {
+	s.count++
+	s.lastActivity = time.Now()
+	if origin < s.start {
+		s.start = origin
+	}
+	if origin > s.end {
+		s.end = origin
+	}
+
+	for _, resource := range resources {
+		value := values[resource]
+		current, found := s.aggregates[resource]
+		if !found {
+			current = &aggregate{min: math.Inf(1), max: math.Inf(-1)}
+			s.aggregates[resource] = current
+			s.resources = append(s.resources, resource)
+		}
+
+		current.add(value)
+	}
+}
+
+func (s *session) summary(deviceName string) (dtos.Event, error) {
+	summary := dtos.NewEvent(s.profileName, deviceName, s.sourceName)
+	summary.Origin = s.end
+	summary.Tags = s.tags
+
+	if err := summary.AddSimpleReading(sessionDuration, common.ValueTypeFloat64, time.Duration(s.end-s.start).Seconds()); err != nil {
+		return summary, err
+	}
+
+	if err := summary.AddSimpleReading(sessionCount, common.ValueTypeInt64, s.count); err != nil {
+		return summary, err
+	}
+
+	for _, resource := range s.resources {
+		current := s.aggregates[resource]
+		aggregates := []struct {
+			name     string
+			function string
+		}{
+			{aggregateMin, AggregateMin},
+			{aggregateMax, AggregateMax},
+			{aggregateMean, AggregateAvg},
+		}
+
+		for _, item := range aggregates {
+			if err := summary.AddSimpleReading(resource+"_"+item.name, common.ValueTypeFloat64, current.result(item.function)); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,152 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sessionEvent(t *testing.T, deviceName string, origin int64, power float64, state string) dtos.Event {
+	event := dtos.NewEvent("press", deviceName, "cycle")
+	event.Origin = origin
+	require.NoError(t, event.AddSimpleReading("power", common.ValueTypeFloat64, power))
+	require.NoError(t, event.AddSimpleReading("state", common.ValueTypeString, state))
+	return event
+}
+
+type sessionResult struct {
+	continuePipeline bool
+	result           interface{}
+}
+
+func TestSessionWindow_Summarize(t *testing.T) {
+	window := NewSessionWindow(100*time.Millisecond, nil)
+	second := int64(time.Second)
+
+	opened := make(chan sessionResult)
+	go func() {
+		continuePipeline, result := window.Summarize(ctx, sessionEvent(t, "press-1", 10*second, 2, "running"))
+		opened <- sessionResult{continuePipeline, result}
+	}()
+
+	// Wait for the session to be opened before adding the next Events
+	require.Eventually(t, func() bool {
+		window.lock.Lock()
+		defer window.lock.Unlock()
+		return window.sessions["press-1"] != nil
+	}, time.Second, time.Millisecond)
+
+	for _, event := range []dtos.Event{
+		sessionEvent(t, "press-1", 12*second, 6, "running"),
+		sessionEvent(t, "press-1", 14*second, 4, "idle"),
+	} {
+		time.Sleep(30 * time.Millisecond)
+		continuePipeline, result := window.Summarize(ctx, event)
+		require.False(t, continuePipeline)
+		require.Nil(t, result)
+	}
+
+	var closed sessionResult
+	select {
+	case closed = <-opened:
+	case <-time.After(time.Second):
+		t.Fatal("session not closed")
+	}
+
+	require.True(t, closed.continuePipeline, closed.result)
+	summary, ok := closed.result.(dtos.Event)
+	require.True(t, ok)
+
+	assert.Equal(t, "press-1", summary.DeviceName)
+	assert.Equal(t, 14*second, summary.Origin)
+
+	expected := map[string]string{
+		"session_duration": "4.000000e+00",
+		"session_count":    "3",
+		"power_min":        "2.000000e+00",
+		"power_max":        "6.000000e+00",
+		"power_mean":       "4.000000e+00",
+	}
+	require.Len(t, summary.Readings, len(expected))
+	for _, reading := range summary.Readings {
+		assert.Equal(t, expected[reading.ResourceName], reading.Value, reading.ResourceName)
+	}
+
+	window.lock.Lock()
+	assert.Empty(t, window.sessions, "closed session should be removed")
+	window.lock.Unlock()
+}
+
+func TestSessionWindow_SessionPerDevice(t *testing.T) {
+	window := NewSessionWindow(20*time.Millisecond, []string{"power"})
+
+	results := make(chan sessionResult, 2)
+	for _, deviceName := range []string{"press-1", "press-2"} {
+		event := sessionEvent(t, deviceName, 0, 5, "running")
+		go func() {
+			continuePipeline, result := window.Summarize(ctx, event)
+			results <- sessionResult{continuePipeline, result}
+		}()
+	}
+
+	devices := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case closed := <-results:
+			require.True(t, closed.continuePipeline, closed.result)
+			summary := closed.result.(dtos.Event)
+			devices[summary.DeviceName] = true
+			assert.Len(t, summary.Readings, 5)
+		case <-time.After(time.Second):
+			t.Fatal("session not closed")
+		}
+	}
+
+	assert.Len(t, devices, 2, "each device should have its own session")
+}
+
+func TestSessionWindow_Errors(t *testing.T) {
+	badValue := dtos.NewEvent("press", "press-1", "cycle")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "power", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "high"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "press-1", "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'power'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			window := NewSessionWindow(time.Millisecond, nil)
+			continuePipeline, result := window.Summarize(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+			assert.Empty(t, window.sessions)
+		})
+	}
+}
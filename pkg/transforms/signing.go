@@ -0,0 +1,183 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+const (
+	// SignatureHeader is the HTTP header the signature is sent in
+	SignatureHeader = "X-Signature"
+	// SignatureAlgorithmHeader is the HTTP header the signature's algorithm is sent in
+	SignatureAlgorithmHeader = "X-Signature-Algorithm"
+
+	SignatureAlgorithmHMAC  = "HMAC-SHA256"
+	SignatureAlgorithmECDSA = "ECDSA-SHA256"
+)
+
+// SignedEnvelope is the data and its signature, returned by the signing functions when the envelope is enabled
+type SignedEnvelope struct {
+	// Payload is the signed data, Base64 encoded in JSON
+	Payload   []byte `json:"payload"`
+	Algorithm string `json:"algorithm"`
+	// Signature is the signature of the Payload, Base64 encoded in JSON
+	Signature []byte `json:"signature"`
+}
+
+// PayloadSigner signs the data to be exported so receivers can verify its integrity and origin
+type PayloadSigner struct {
+	SecretPath string
+	SecretName string
+	Envelope   bool
+}
+
+// NewPayloadSigner creates, initializes and returns a new instance of PayloadSigner configured to retrieve the
+// signing key from the Secret Store. When envelope is true the data is replaced by a SignedEnvelope containing the
+// data and signature, for transports without headers.
+func NewPayloadSigner(secretPath string, secretName string, envelope bool) PayloadSigner {
+	return PayloadSigner{
+		SecretPath: secretPath,
+		SecretName: secretName,
+		Envelope:   envelope,
+	}
+}
+
+// SignPayloadHMAC signs a string, []byte, or json.Marshaller type with HMAC-SHA256 using the secret key.
+// The signature is set in the context for HTTP exports to send as a header, so this should be the last function
+// before the export. The data is returned unchanged, or in a SignedEnvelope as JSON when the envelope is enabled.
+func (signer PayloadSigner) SignPayloadHMAC(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	return signer.sign(ctx, data, "SignPayloadHMAC", SignatureAlgorithmHMAC, signHMAC)
+}
+
+// SignPayloadECDSA signs a string, []byte, or json.Marshaller type with ECDSA over the SHA-256 digest using the
+// PEM encoded elliptic curve private key, giving an ASN.1 DER encoded signature.
+// The signature is set in the context for HTTP exports to send as a header, so this should be the last function
+// before the export. The data is returned unchanged, or in a SignedEnvelope as JSON when the envelope is enabled.
+func (signer PayloadSigner) SignPayloadECDSA(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	return signer.sign(ctx, data, "SignPayloadECDSA", SignatureAlgorithmECDSA, signECDSA)
+}
+
+func (signer PayloadSigner) sign(
+	ctx interfaces.AppFunctionContext,
+	data interface{},
+	functionName string,
+	algorithm string,
+	signFunc func(key string, payload []byte) ([]byte, error)) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function %s in pipeline '%s': No Data Received", functionName, ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Signing data with %s in pipeline '%s'", algorithm, ctx.PipelineId())
+
+	payload, err := util.CoerceType(data)
+	if err != nil {
+		return false, err
+	}
+
+	if len(signer.SecretPath) == 0 || len(signer.SecretName) == 0 {
+		return false, fmt.Errorf("function %s in pipeline '%s': secret path and name of the signing key not set", functionName, ctx.PipelineId())
+	}
+
+	key, err := getSecretKey(ctx, signer.SecretPath, signer.SecretName)
+	if err != nil {
+		return false, err
+	}
+
+	signature, err := signFunc(key, payload)
+	if err != nil {
+		return false, fmt.Errorf("function %s in pipeline '%s': unable to sign data: %s", functionName, ctx.PipelineId(), err.Error())
+	}
+
+	encodedSignature := base64.StdEncoding.EncodeToString(signature)
+	ctx.AddValue(interfaces.SIGNATURE, encodedSignature)
+	ctx.AddValue(interfaces.SIGNATUREALGORITHM, algorithm)
+
+	if !signer.Envelope {
+		return true, data
+	}
+
+	envelope, err := json.Marshal(SignedEnvelope{Payload: payload, Algorithm: algorithm, Signature: signature})
+	if err != nil {
+		return false, fmt.Errorf("function %s in pipeline '%s': unable to marshal envelope: %s", functionName, ctx.PipelineId(), err.Error())
+	}
+
+	ctx.SetResponseContentType(common.ContentTypeJSON)
+
+	return true, envelope
+}
+
+// signHMAC signs the payload with HMAC-SHA256 using the key
+func signHMAC(key string, payload []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("HMAC key is empty")
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// signECDSA signs the SHA-256 digest of the payload with ECDSA using the PEM encoded private key
+func signECDSA(key string, payload []byte) ([]byte, error) {
+	privateKey, err := parseECPrivateKey([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(payload)
+	return ecdsa.SignASN1(rand.Reader, privateKey, digest[:])
+}
+
+// parseECPrivateKey parses a PEM encoded SEC 1 or PKCS #8 elliptic curve private key
+func parseECPrivateKey(encodedKey []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(encodedKey)
+	if block == nil {
+		return nil, errors.New("no PEM data found in ECDSA key")
+	}
+
+	if block.Type == "EC PRIVATE KEY" {
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key type %T is not an ECDSA private key", key)
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/clients/logger"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces/mocks"
+)
+
+func signingContext(key string) (*mocks.AppFunctionContext, map[string]string) {
+	values := make(map[string]string)
+
+	mockCtx := &mocks.AppFunctionContext{}
+	mockCtx.On("PipelineId").Return("pipeline-id")
+	mockCtx.On("LoggingClient").Return(logger.NewMockClient())
+	mockCtx.On("SetResponseContentType", common.ContentTypeJSON).Return()
+	mockCtx.On("GetSecret", "signing", "key").Return(map[string]string{"key": key}, nil)
+	mockCtx.On("AddValue", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		values[args.String(0)] = args.String(1)
+	}).Return()
+
+	return mockCtx, values
+}
+
+func TestPayloadSigner_SignPayloadHMAC(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("shared secret"))
+	mac.Write([]byte(msgStr))
+	expected := mac.Sum(nil)
+
+	mockCtx, values := signingContext("shared secret")
+	continuePipeline, result := NewPayloadSigner("signing", "key", false).SignPayloadHMAC(mockCtx, msgStr)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, msgStr, result, "data should be returned unchanged")
+	assert.Equal(t, base64.StdEncoding.EncodeToString(expected), values[interfaces.SIGNATURE])
+	assert.Equal(t, SignatureAlgorithmHMAC, values[interfaces.SIGNATUREALGORITHM])
+
+	continuePipeline, result = NewPayloadSigner("signing", "key", true).SignPayloadHMAC(mockCtx, []byte(msgStr))
+	require.True(t, continuePipeline, result)
+
+	var envelope SignedEnvelope
+	require.NoError(t, json.Unmarshal(result.([]byte), &envelope))
+	assert.Equal(t, msgStr, string(envelope.Payload))
+	assert.Equal(t, SignatureAlgorithmHMAC, envelope.Algorithm)
+	assert.Equal(t, expected, envelope.Signature)
+}
+
+func TestPayloadSigner_SignPayloadECDSA(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	sec1, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	keys := map[string]string{
+		"SEC1":  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})),
+		"PKCS8": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+	}
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			mockCtx, values := signingContext(key)
+			continuePipeline, result := NewPayloadSigner("signing", "key", false).SignPayloadECDSA(mockCtx, msgStr)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, SignatureAlgorithmECDSA, values[interfaces.SIGNATUREALGORITHM])
+
+			signature, err := base64.StdEncoding.DecodeString(values[interfaces.SIGNATURE])
+			require.NoError(t, err)
+			digest := sha256.Sum256([]byte(msgStr))
+			assert.True(t, ecdsa.VerifyASN1(&privateKey.PublicKey, digest[:], signature))
+		})
+	}
+}
+
+func TestPayloadSigner_Errors(t *testing.T) {
+	secretErrorCtx := &mocks.AppFunctionContext{}
+	secretErrorCtx.On("PipelineId").Return("pipeline-id")
+	secretErrorCtx.On("LoggingClient").Return(logger.NewMockClient())
+	secretErrorCtx.On("GetSecret", "signing", "key").Return(nil, errors.New("secret store unavailable"))
+
+	emptyKeyCtx, _ := signingContext("")
+	badKeyCtx, _ := signingContext("not a key")
+
+	tests := []struct {
+		Name          string
+		Ctx           interfaces.AppFunctionContext
+		Signer        PayloadSigner
+		Data          interface{}
+		Sign          func(signer PayloadSigner) interfaces.AppFunction
+		ExpectedError string
+	}{
+		{"No data", emptyKeyCtx, NewPayloadSigner("signing", "key", false), nil,
+			func(signer PayloadSigner) interfaces.AppFunction { return signer.SignPayloadHMAC }, "No Data Received"},
+		{"No secret", emptyKeyCtx, NewPayloadSigner("", "", false), msgStr,
+			func(signer PayloadSigner) interfaces.AppFunction { return signer.SignPayloadHMAC }, "signing key not set"},
+		{"Secret error", secretErrorCtx, NewPayloadSigner("signing", "key", false), msgStr,
+			func(signer PayloadSigner) interfaces.AppFunction { return signer.SignPayloadHMAC }, "unable to retieve"},
+		{"Empty HMAC key", emptyKeyCtx, NewPayloadSigner("signing", "key", false), msgStr,
+			func(signer PayloadSigner) interfaces.AppFunction { return signer.SignPayloadHMAC }, "HMAC key is empty"},
+		{"Bad ECDSA key", badKeyCtx, NewPayloadSigner("signing", "key", false), msgStr,
+			func(signer PayloadSigner) interfaces.AppFunction { return signer.SignPayloadECDSA }, "no PEM data found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := test.Sign(test.Signer)(test.Ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
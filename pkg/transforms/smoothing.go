@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// smoother is the state of a smoothing filter for the readings of a single device resource
+type smoother interface {
+	update(measurement float64) float64
+}
+
+// SmoothingFilter replaces the values of an Event's numeric readings with the values smoothed by a filter
+// kept per device and resource, to reduce sensor noise before thresholds are checked or the readings are exported.
+type SmoothingFilter struct {
+	newSmoother   func() smoother
+	resourceNames []string
+	lock          sync.Mutex
+	smoothers     map[string]smoother
+}
+
+// NewEWMAFilter creates, initializes and returns a new instance of SmoothingFilter using an exponentially weighted
+// moving average. alpha, from 0 to 1, is the weight of the newest value, so lower values smooth more.
+// resourceNames, when not empty, limits the readings smoothed, otherwise all numeric readings are smoothed.
+func NewEWMAFilter(alpha float64, resourceNames []string) *SmoothingFilter {
+	return newSmoothingFilter(func() smoother { return &ewmaSmoother{alpha: alpha} }, resourceNames)
+}
+
+// NewKalmanFilter creates, initializes and returns a new instance of SmoothingFilter using a one dimensional Kalman
+// filter for a value that is expected to stay constant. processNoise is the variance of the actual value's change
+// between readings and measurementNoise the variance of the sensor's noise. A lower ratio of processNoise to
+// measurementNoise smooths more. resourceNames, when not empty, limits the readings smoothed, otherwise all numeric
+// readings are smoothed.
+func NewKalmanFilter(processNoise float64, measurementNoise float64, resourceNames []string) *SmoothingFilter {
+	return newSmoothingFilter(func() smoother {
+		return &kalmanSmoother{processNoise: processNoise, measurementNoise: measurementNoise}
+	}, resourceNames)
+}
+
+func newSmoothingFilter(newSmoother func() smoother, resourceNames []string) *SmoothingFilter {
+	return &SmoothingFilter{
+		newSmoother:   newSmoother,
+		resourceNames: resourceNames,
+		smoothers:     make(map[string]smoother),
+	}
+}
+
+// Smooth replaces the values of the Event's numeric readings with the smoothed values. Integer readings are rounded
+// to the nearest integer so the readings keep their value types. Array readings are not smoothed.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received or
+// a reading's value can't be parsed.
+func (filter *SmoothingFilter) Smooth(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Smooth in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function Smooth in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Smoothing readings in pipeline '%s'", ctx.PipelineId())
+
+	// Copy the readings so the values of the received Event are not replaced
+	event.Readings = append([]dtos.BaseReading{}, event.Readings...)
+
+	filter.lock.Lock()
+	defer filter.lock.Unlock()
+
+	for index, reading := range event.Readings {
+		if !filter.shouldSmooth(reading) {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			return false, fmt.Errorf("function Smooth in pipeline '%s': unable to parse value of reading '%s': %s",
+				ctx.PipelineId(), reading.ResourceName, err.Error())
+		}
+
+		key := event.DeviceName + "/" + reading.ResourceName
+		state, found := filter.smoothers[key]
+		if !found {
+			state = filter.newSmoother()
+			filter.smoothers[key] = state
+		}
+
+		event.Readings[index].Value = formatSmoothedValue(reading.ValueType, state.update(value))
+	}
+
+	return true, event
+}
+
+func (filter *SmoothingFilter) shouldSmooth(reading dtos.BaseReading) bool {
+	return isNumericReading(reading) && selectsResource(filter.resourceNames, reading.ResourceName)
+}
+
+// isNumericReading returns true if the reading has a single integer or float value
+func isNumericReading(reading dtos.BaseReading) bool {
+	switch reading.ValueType {
+	case common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+		common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64,
+		common.ValueTypeFloat32, common.ValueTypeFloat64:
+		return true
+	default:
+		return false
+	}
+}
+
+// selectsResource returns true if the resource names include the name, or are empty so select all resources
+func selectsResource(resourceNames []string, name string) bool {
+	if len(resourceNames) == 0 {
+		return true
+	}
+
+	for _, resourceName := range resourceNames {
+		if resourceName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatSmoothedValue formats the value the same way as the readings created by dtos.NewSimpleReading.
+// The smoothed value is always between the smallest and largest value received, so it fits the value type.
+func formatSmoothedValue(valueType string, value float64) string {
+	switch valueType {
+	case common.ValueTypeFloat32:
+		return fmt.Sprintf("%e", float32(value))
+	case common.ValueTypeFloat64:
+		return fmt.Sprintf("%e", value)
+	case common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64:
+		return strconv.FormatUint(uint64(math.Round(value)), 10)
+	default:
+		return strconv.FormatInt(int64(math.Round(value)), 10)
+	}
+}
+
+type ewmaSmoother struct {
+	alpha       float64
+	value       float64
+	initialized bool
+}
+
+func (smoother *ewmaSmoother) update(measurement float64) float64 {
+	if !smoother.initialized {
+		smoother.value = measurement
+		smoother.initialized = true
+		return smoother.value
+	}
+
+	smoother.value = smoother.alpha*measurement + (1-smoother.alpha)*smoother.value
+	return smoother.value
+}
+
+type kalmanSmoother struct {
+	processNoise     float64
+	measurementNoise float64
+	estimate         float64
+	errorCovariance  float64
+	initialized      bool
+}
+
+func (smoother *kalmanSmoother) update(measurement float64) float64 {
+	if !smoother.initialized {
+		smoother.estimate = measurement
+		smoother.errorCovariance = smoother.measurementNoise
+		smoother.initialized = true
+		return smoother.estimate
+	}
+
+	// Predict, the value is expected to stay the same with the uncertainty growing by the process noise
+	smoother.errorCovariance += smoother.processNoise
+
+	// Correct the estimate towards the measurement by the Kalman gain
+	gain := smoother.errorCovariance / (smoother.errorCovariance + smoother.measurementNoise)
+	smoother.estimate += gain * (measurement - smoother.estimate)
+	smoother.errorCovariance *= 1 - gain
+
+	return smoother.estimate
+}
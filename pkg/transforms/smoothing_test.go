@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func smoothingEvent(t *testing.T, deviceName string, temperature float64, count int32) dtos.Event {
+	event := dtos.NewEvent("thermostat", deviceName, "status")
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeFloat64, temperature))
+	require.NoError(t, event.AddSimpleReading("count", common.ValueTypeInt32, count))
+	require.NoError(t, event.AddSimpleReading("history", common.ValueTypeFloat64Array, []float64{temperature}))
+	return event
+}
+
+func smooth(t *testing.T, filter *SmoothingFilter, event dtos.Event) dtos.Event {
+	continuePipeline, result := filter.Smooth(ctx, event)
+	require.True(t, continuePipeline, result)
+	return result.(dtos.Event)
+}
+
+func TestSmoothingFilter_EWMA(t *testing.T) {
+	filter := NewEWMAFilter(0.25, nil)
+
+	first := smooth(t, filter, smoothingEvent(t, "thermostat-1", 20, 10))
+	assert.Equal(t, "2.000000e+01", first.Readings[0].Value, "first value should be passed through")
+	assert.Equal(t, "10", first.Readings[1].Value)
+
+	received := smoothingEvent(t, "thermostat-1", 24, 13)
+	second := smooth(t, filter, received)
+	assert.Equal(t, "2.100000e+01", second.Readings[0].Value)
+	assert.Equal(t, "11", second.Readings[1].Value, "integer readings should be rounded")
+	assert.Equal(t, received.Readings[2], second.Readings[2], "array readings should not be smoothed")
+	assert.Equal(t, "2.400000e+01", received.Readings[0].Value, "received Event should not be modified")
+
+	other := smooth(t, filter, smoothingEvent(t, "thermostat-2", 30, 0))
+	assert.Equal(t, "3.000000e+01", other.Readings[0].Value, "each device should be smoothed separately")
+}
+
+func TestSmoothingFilter_Kalman(t *testing.T) {
+	filter := NewKalmanFilter(0, 1, []string{"temperature"})
+
+	// With no process noise the estimate is the average of the measurements
+	values := []float64{20, 22, 18, 24}
+	expected := []string{"2.000000e+01", "2.100000e+01", "2.000000e+01", "2.100000e+01"}
+	for index, value := range values {
+		smoothed := smooth(t, filter, smoothingEvent(t, "thermostat-1", value, int32(index)))
+		assert.Equal(t, expected[index], smoothed.Readings[0].Value)
+		assert.Equal(t, smoothingEvent(t, "thermostat-1", value, int32(index)).Readings[1].Value, smoothed.Readings[1].Value,
+			"readings not in resource names should not be smoothed")
+	}
+}
+
+func TestSmoothingFilter_Errors(t *testing.T) {
+	badValue := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "temperature", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "warm"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "20", "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'temperature'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewEWMAFilter(0.5, nil).Smooth(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,274 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"sync"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+const (
+	featureRMS               = "rms"
+	featureDominantFrequency = "dominantfrequency"
+	featureBandEnergy        = "bandenergy"
+)
+
+// FrequencyBand is a range of frequencies, in Hz, from Low up to but not including High
+type FrequencyBand struct {
+	Low  float64
+	High float64
+}
+
+// SpectralFeatures buffers the samples of waveform readings and computes FFT based features over each full window
+// of samples, for vibration and condition monitoring where sending the raw waveform upstream is too costly.
+type SpectralFeatures struct {
+	sampleRate    float64
+	windowSize    int
+	bands         []FrequencyBand
+	resourceNames []string
+	lock          sync.Mutex
+	buffers       map[string][]float64
+}
+
+// NewSpectralFeatures creates, initializes and returns a new instance of SpectralFeatures.
+// sampleRate is the rate, in Hz, the waveform samples were taken at. windowSize is the number of samples the features
+// are computed over, which are buffered per device and resource across Events. When windowSize is 0 the features are
+// computed over the samples of each reading as received. bands are the frequency bands the energy is reported for.
+// resourceNames, when not empty, limits the readings used, otherwise all numeric readings are used.
+func NewSpectralFeatures(sampleRate float64, windowSize int, bands []FrequencyBand, resourceNames []string) *SpectralFeatures {
+	return &SpectralFeatures{
+		sampleRate:    sampleRate,
+		windowSize:    windowSize,
+		bands:         bands,
+		resourceNames: resourceNames,
+		buffers:       make(map[string][]float64),
+	}
+}
+
+// Extract adds the samples of the Event's waveform readings to the buffers and, for each full window, returns a
+// feature Event with the RMS, dominant frequency and band energies of the window as Float64 readings named
+// <resource>_rms, <resource>_dominantfrequency and <resource>_bandenergy_<low>-<high>. The pipeline is stopped while
+// the windows are filling. This function will return an error and stop the pipeline if a non-edgex event is received,
+// no data is received or a reading's value can't be parsed.
+func (spectral *SpectralFeatures) Extract(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function ExtractSpectralFeatures in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function ExtractSpectralFeatures in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Extracting spectral features in pipeline '%s'", ctx.PipelineId())
+
+	features := dtos.NewEvent(event.ProfileName, event.DeviceName, event.SourceName)
+	features.Origin = event.Origin
+	features.Tags = event.Tags
+
+	for _, reading := range event.Readings {
+		if !spectral.shouldExtract(reading) {
+			continue
+		}
+
+		samples, err := numericValues(reading)
+		if err != nil {
+			return false, fmt.Errorf("function ExtractSpectralFeatures in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+		}
+
+		for _, window := range spectral.windows(event.DeviceName, reading.ResourceName, samples) {
+			if err := spectral.addFeatures(&features, reading.ResourceName, window); err != nil {
+				return false, fmt.Errorf("function ExtractSpectralFeatures in pipeline '%s': unable to add feature reading: %s",
+					ctx.PipelineId(), err.Error())
+			}
+		}
+	}
+
+	if len(features.Readings) == 0 {
+		ctx.LoggingClient().Debugf("Buffering waveform samples in pipeline '%s'", ctx.PipelineId())
+		return false, nil
+	}
+
+	return true, features
+}
+
+func (spectral *SpectralFeatures) shouldExtract(reading dtos.BaseReading) bool {
+	switch reading.ValueType {
+	case common.ValueTypeBinary, common.ValueTypeObject, common.ValueTypeString, common.ValueTypeBool, common.ValueTypeBoolArray:
+		return false
+	}
+
+	if len(spectral.resourceNames) == 0 {
+		return true
+	}
+
+	for _, name := range spectral.resourceNames {
+		if reading.ResourceName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// windows adds the samples to the device's buffer for the resource and removes and returns any full windows
+func (spectral *SpectralFeatures) windows(deviceName string, resourceName string, samples []float64) [][]float64 {
+	if spectral.windowSize <= 0 {
+		if len(samples) == 0 {
+			return nil
+		}
+		return [][]float64{samples}
+	}
+
+	spectral.lock.Lock()
+	defer spectral.lock.Unlock()
+
+	key := deviceName + "/" + resourceName
+	buffer := append(spectral.buffers[key], samples...)
+
+	var windows [][]float64
+	for len(buffer) >= spectral.windowSize {
+		windows = append(windows, buffer[:spectral.windowSize])
+		buffer = buffer[spectral.windowSize:]
+	}
+
+	// Copy the remaining samples so the windows returned don't share the buffer's backing array
+	spectral.buffers[key] = append([]float64(nil), buffer...)
+	return windows
+}
+
+func (spectral *SpectralFeatures) addFeatures(event *dtos.Event, resourceName string, window []float64) error {
+	power := powerSpectrum(window)
+	resolution := spectral.sampleRate / float64(len(window))
+
+	meanSquare := 0.0
+	for _, sample := range window {
+		meanSquare += sample * sample
+	}
+	meanSquare /= float64(len(window))
+
+	// The DC component at bin 0 is excluded so an offset in the signal isn't reported as the dominant frequency
+	dominant := 0
+	for bin := 1; bin < len(power); bin++ {
+		if dominant == 0 || power[bin] > power[dominant] {
+			dominant = bin
+		}
+	}
+
+	if err := event.AddSimpleReading(resourceName+"_"+featureRMS, common.ValueTypeFloat64, math.Sqrt(meanSquare)); err != nil {
+		return err
+	}
+
+	if err := event.AddSimpleReading(resourceName+"_"+featureDominantFrequency, common.ValueTypeFloat64, float64(dominant)*resolution); err != nil {
+		return err
+	}
+
+	for _, band := range spectral.bands {
+		energy := 0.0
+		for bin, value := range power {
+			frequency := float64(bin) * resolution
+			if frequency >= band.Low && frequency < band.High {
+				energy += value
+			}
+		}
+
+		name := fmt.Sprintf("%s_%s_%s-%s", resourceName, featureBandEnergy,
+			strconv.FormatFloat(band.Low, 'f', -1, 64), strconv.FormatFloat(band.High, 'f', -1, 64))
+		if err := event.AddSimpleReading(name, common.ValueTypeFloat64, energy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// powerSpectrum returns the one-sided power spectrum of the samples, from 0 Hz to the Nyquist frequency, scaled so
+// the sum of all bins equals the mean square of the samples.
+func powerSpectrum(samples []float64) []float64 {
+	n := len(samples)
+	spectrum := fft(samples)
+
+	power := make([]float64, n/2+1)
+	for bin := range power {
+		magnitude := cmplx.Abs(spectrum[bin]) / float64(n)
+		power[bin] = magnitude * magnitude
+		// The energy of the negative frequencies is folded into the positive ones, except for DC and Nyquist
+		if bin != 0 && !(n%2 == 0 && bin == n/2) {
+			power[bin] *= 2
+		}
+	}
+
+	return power
+}
+
+// fft returns the discrete Fourier transform of the samples, using the radix-2 algorithm when the number of samples
+// is a power of two and the direct computation otherwise.
+func fft(samples []float64) []complex128 {
+	n := len(samples)
+	result := make([]complex128, n)
+
+	if n&(n-1) != 0 {
+		for k := 0; k < n; k++ {
+			var sum complex128
+			for t, sample := range samples {
+				sum += complex(sample, 0) * cmplx.Exp(complex(0, -2*math.Pi*float64(k*t)/float64(n)))
+			}
+			result[k] = sum
+		}
+		return result
+	}
+
+	// Bit reversal permutation
+	bits := 0
+	for 1<<bits < n {
+		bits++
+	}
+	for index, sample := range samples {
+		reversed := 0
+		for bit := 0; bit < bits; bit++ {
+			if index&(1<<bit) != 0 {
+				reversed |= 1 << (bits - 1 - bit)
+			}
+		}
+		result[reversed] = complex(sample, 0)
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			twiddle := complex(1, 0)
+			for offset := 0; offset < size/2; offset++ {
+				even := result[start+offset]
+				odd := result[start+offset+size/2] * twiddle
+				result[start+offset] = even + odd
+				result[start+offset+size/2] = even - odd
+				twiddle *= step
+			}
+		}
+	}
+
+	return result
+}
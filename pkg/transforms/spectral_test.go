@@ -0,0 +1,144 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sineWave returns the samples of a sine wave with the amplitude and frequency sampled at the sample rate
+func sineWave(amplitude float64, frequency float64, sampleRate float64, count int, offset int) []float64 {
+	samples := make([]float64, count)
+	for index := range samples {
+		samples[index] = amplitude * math.Sin(2*math.Pi*frequency*float64(index+offset)/sampleRate)
+	}
+	return samples
+}
+
+func featureValue(t *testing.T, event dtos.Event, name string) float64 {
+	for _, reading := range event.Readings {
+		if reading.ResourceName == name {
+			require.Equal(t, common.ValueTypeFloat64, reading.ValueType)
+			value, err := strconv.ParseFloat(reading.Value, 64)
+			require.NoError(t, err)
+			return value
+		}
+	}
+
+	require.Failf(t, "feature reading not found", "reading '%s' not found", name)
+	return 0
+}
+
+func TestSpectralFeatures_ExtractBuffersWindow(t *testing.T) {
+	spectral := NewSpectralFeatures(1024, 64, []FrequencyBand{{Low: 50, High: 100}, {Low: 100, High: 512}}, []string{"vibration"})
+
+	first := dtos.NewEvent("pump", "pump-1", "waveform")
+	require.NoError(t, first.AddSimpleReading("vibration", common.ValueTypeFloat64Array, sineWave(2, 64, 1024, 32, 0)))
+	require.NoError(t, first.AddSimpleReading("temperature", common.ValueTypeFloat64, 40.5))
+
+	continuePipeline, result := spectral.Extract(ctx, first)
+	require.False(t, continuePipeline)
+	require.Nil(t, result, "pipeline should stop while the window is filling")
+
+	second := dtos.NewEvent("pump", "pump-1", "waveform")
+	require.NoError(t, second.AddSimpleReading("vibration", common.ValueTypeFloat64Array, sineWave(2, 64, 1024, 40, 32)))
+
+	continuePipeline, result = spectral.Extract(ctx, second)
+	require.True(t, continuePipeline, result)
+
+	features := result.(dtos.Event)
+	assert.Equal(t, "pump-1", features.DeviceName)
+	assert.Equal(t, second.Origin, features.Origin)
+	require.Len(t, features.Readings, 4)
+	assert.InDelta(t, math.Sqrt2, featureValue(t, features, "vibration_rms"), 1e-6)
+	assert.InDelta(t, 64, featureValue(t, features, "vibration_dominantfrequency"), 1e-6)
+	assert.InDelta(t, 2, featureValue(t, features, "vibration_bandenergy_50-100"), 1e-6)
+	assert.InDelta(t, 0, featureValue(t, features, "vibration_bandenergy_100-512"), 1e-6)
+
+	assert.Len(t, spectral.buffers["pump-1/vibration"], 8, "samples past the window should stay buffered")
+}
+
+func TestSpectralFeatures_ExtractEachReading(t *testing.T) {
+	spectral := NewSpectralFeatures(1000, 0, nil, nil)
+
+	// 10 samples isn't a power of two, so the direct DFT is used
+	event := dtos.NewEvent("pump", "pump-1", "waveform")
+	samples := sineWave(1, 200, 1000, 10, 0)
+	for index := range samples {
+		samples[index] += 3
+	}
+	require.NoError(t, event.AddSimpleReading("x", common.ValueTypeFloat32Array, []float32{1, -1, 1, -1}))
+	require.NoError(t, event.AddSimpleReading("y", common.ValueTypeFloat64Array, samples))
+	require.NoError(t, event.AddSimpleReading("status", common.ValueTypeString, "running"))
+
+	continuePipeline, result := spectral.Extract(ctx, event)
+	require.True(t, continuePipeline, result)
+
+	features := result.(dtos.Event)
+	require.Len(t, features.Readings, 4)
+	assert.InDelta(t, 1, featureValue(t, features, "x_rms"), 1e-6)
+	assert.InDelta(t, 500, featureValue(t, features, "x_dominantfrequency"), 1e-6)
+	assert.InDelta(t, math.Sqrt(9.5), featureValue(t, features, "y_rms"), 1e-6)
+	assert.InDelta(t, 200, featureValue(t, features, "y_dominantfrequency"), 1e-6, "DC offset should be ignored")
+}
+
+func TestSpectralFeatures_ExtractErrors(t *testing.T) {
+	badValue := dtos.NewEvent("pump", "pump-1", "waveform")
+	badValue.Readings = append(badValue.Readings, dtos.BaseReading{ResourceName: "vibration", ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "loud"}})
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", []byte("waveform"), "type received is not an Event"},
+		{"Bad value", badValue, "unable to parse value of reading 'vibration'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			spectral := NewSpectralFeatures(1000, 0, nil, nil)
+			continuePipeline, result := spectral.Extract(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestFFT(t *testing.T) {
+	samples := []float64{0.5, -1.25, 3, 2, -0.75, 0, 1.5, -2}
+
+	actual := fft(samples)
+	for k := range samples {
+		var expected complex128
+		for n, sample := range samples {
+			angle := -2 * math.Pi * float64(k*n) / float64(len(samples))
+			expected += complex(sample*math.Cos(angle), sample*math.Sin(angle))
+		}
+		assert.InDelta(t, real(expected), real(actual[k]), 1e-9)
+		assert.InDelta(t, imag(expected), imag(actual[k]), 1e-9)
+	}
+}
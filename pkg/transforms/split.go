@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// Splitter splits Events into an Event per reading, so the functions between SplitByReading and Merge filter,
+// transform or export each reading on its own, and merges the Events of the readings that reach Merge.
+type Splitter struct {
+}
+
+// NewSplitter creates, initializes and returns a new instance of Splitter
+func NewSplitter() Splitter {
+	return Splitter{}
+}
+
+// SplitByReading continues the pipeline separately for each of the Event's readings, in order, with a copy of the
+// Event containing only that reading. The continuations end at the Merge function, or otherwise with the pipeline.
+// An Event without readings stops the pipeline.
+// This function will return an error and stop the pipeline if a non-edgex event is received or no data is received.
+func (splitter Splitter) SplitByReading(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function SplitByReading in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function SplitByReading in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	if len(event.Readings) == 0 {
+		ctx.LoggingClient().Debugf("Event has no readings to split in pipeline '%s'", ctx.PipelineId())
+		return false, nil
+	}
+
+	ctx.LoggingClient().Debugf("Splitting Event into %d readings in pipeline '%s'", len(event.Readings), ctx.PipelineId())
+
+	items := make(interfaces.SplitData, 0, len(event.Readings))
+	for _, reading := range event.Readings {
+		readingEvent := event
+		readingEvent.Readings = []dtos.BaseReading{reading}
+		items = append(items, readingEvent)
+	}
+
+	return true, items
+}
+
+// Merge ends the continuation of each reading split by SplitByReading and, once they have all ended, continues the
+// pipeline with an Event containing the readings, in order, that reached it. The pipeline stops when none did.
+// This function will return an error and stop the pipeline if no data is received or a reading reaching it is no
+// longer an Event.
+func (splitter Splitter) Merge(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Merge in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	items, merging := data.(interfaces.SplitData)
+	if !merging {
+		return true, interfaces.MergeRequest{Item: data}
+	}
+
+	if len(items) == 0 {
+		ctx.LoggingClient().Debugf("No readings to merge in pipeline '%s'", ctx.PipelineId())
+		return false, nil
+	}
+
+	var merged dtos.Event
+	for index, item := range items {
+		event, ok := item.(dtos.Event)
+		if !ok {
+			return false, fmt.Errorf("function Merge in pipeline '%s', type of item %d received is not an Event", ctx.PipelineId(), index)
+		}
+
+		if index == 0 {
+			merged = event
+			merged.Readings = make([]dtos.BaseReading, 0, len(items))
+		}
+
+		merged.Readings = append(merged.Readings, event.Readings...)
+	}
+
+	ctx.LoggingClient().Debugf("Merged %d readings in pipeline '%s'", len(merged.Readings), ctx.PipelineId())
+
+	return true, merged
+}
@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestSplitter_SplitByReading(t *testing.T) {
+	event := dtos.NewEvent(profileName1, deviceName1, sourceName1)
+	require.NoError(t, event.AddSimpleReading(resource1, common.ValueTypeInt32, int32(1)))
+	require.NoError(t, event.AddSimpleReading(resource2, common.ValueTypeInt32, int32(2)))
+
+	continuePipeline, result := NewSplitter().SplitByReading(ctx, event)
+	require.True(t, continuePipeline)
+	items, ok := result.(interfaces.SplitData)
+	require.True(t, ok)
+	require.Len(t, items, 2)
+
+	for index, item := range items {
+		readingEvent := item.(dtos.Event)
+		assert.Equal(t, event.Id, readingEvent.Id)
+		assert.Equal(t, event.DeviceName, readingEvent.DeviceName)
+		assert.Equal(t, []dtos.BaseReading{event.Readings[index]}, readingEvent.Readings)
+	}
+	assert.Len(t, event.Readings, 2, "received Event should not be modified")
+
+	continuePipeline, result = NewSplitter().SplitByReading(ctx, dtos.NewEvent(profileName1, deviceName1, sourceName1))
+	assert.False(t, continuePipeline, "Event without readings should stop the pipeline")
+	assert.Nil(t, result)
+}
+
+func TestSplitter_Merge(t *testing.T) {
+	event := dtos.NewEvent(profileName1, deviceName1, sourceName1)
+	require.NoError(t, event.AddSimpleReading(resource1, common.ValueTypeInt32, int32(1)))
+	require.NoError(t, event.AddSimpleReading(resource2, common.ValueTypeInt32, int32(2)))
+	require.NoError(t, event.AddSimpleReading(resource1, common.ValueTypeInt32, int32(3)))
+
+	_, split := NewSplitter().SplitByReading(ctx, event)
+	items := split.(interfaces.SplitData)
+
+	continuePipeline, result := NewSplitter().Merge(ctx, items[0])
+	require.True(t, continuePipeline)
+	assert.Equal(t, interfaces.MergeRequest{Item: items[0]}, result, "item should be requested to be merged")
+
+	continuePipeline, result = NewSplitter().Merge(ctx, interfaces.SplitData{items[0], items[2]})
+	require.True(t, continuePipeline)
+	merged := result.(dtos.Event)
+	assert.Equal(t, event.Id, merged.Id)
+	assert.Equal(t, []dtos.BaseReading{event.Readings[0], event.Readings[2]}, merged.Readings)
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Nothing to merge", interfaces.SplitData{}, ""},
+		{"Not an Event", interfaces.SplitData{items[0], "reading"}, "type of item 1 received is not an Event"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := NewSplitter().Merge(ctx, test.Data)
+			require.False(t, continuePipeline)
+			if test.ExpectedError == "" {
+				assert.Nil(t, result)
+				return
+			}
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
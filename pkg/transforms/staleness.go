@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// StalenessFilter stops the pipeline for Events older than the maximum age, so late telemetry isn't exported to
+// integrations for which it is worthless, i.e. control loops.
+type StalenessFilter struct {
+	// MaxAge is the age beyond which data is stale
+	MaxAge time.Duration
+	// ByReceivedTime indicates the age is measured from the time the message was received rather than from the
+	// Event's Origin. Data other than Events can only be filtered by the received time.
+	ByReceivedTime bool
+	// StaleHandler, when set, is run with the stale data in place of the rest of the pipeline, i.e. to export it to
+	// a different destination. Its result is ignored other than errors, which are logged.
+	StaleHandler interfaces.AppFunction
+}
+
+// NewStalenessFilter creates, initializes and returns a new instance of StalenessFilter which measures the age of
+// Events from their Origin
+func NewStalenessFilter(maxAge time.Duration) *StalenessFilter {
+	return &StalenessFilter{
+		MaxAge: maxAge,
+	}
+}
+
+// DropStale passes the data on if it isn't older than MaxAge, otherwise runs the StaleHandler, if set, with the data
+// and stops the pipeline. Data with no Origin or received time is never stale.
+// This function will return an error and stop the pipeline if no data is received, or if a non-edgex event is received
+// when measuring the age from the Event's Origin.
+func (filter *StalenessFilter) DropStale(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function DropStale in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	var since time.Time
+	if filter.ByReceivedTime {
+		if received, found := ctx.GetValue(interfaces.RECEIVEDTIME); found {
+			var err error
+			since, err = time.Parse(time.RFC3339Nano, received)
+			if err != nil {
+				return false, fmt.Errorf("function DropStale in pipeline '%s': unable to parse received time '%s': %s",
+					ctx.PipelineId(), received, err.Error())
+			}
+		}
+	} else {
+		event, ok := data.(dtos.Event)
+		if !ok {
+			return false, fmt.Errorf("function DropStale in pipeline '%s', type received is not an Event", ctx.PipelineId())
+		}
+
+		if event.Origin != 0 {
+			since = time.Unix(0, event.Origin)
+		}
+	}
+
+	if since.IsZero() {
+		return true, data
+	}
+
+	age := time.Since(since)
+	if age <= filter.MaxAge {
+		return true, data
+	}
+
+	ctx.LoggingClient().Debugf("Stale data, %s old, dropped in pipeline '%s'", age.String(), ctx.PipelineId())
+
+	if filter.StaleHandler != nil {
+		if _, result := filter.StaleHandler(ctx, data); result != nil {
+			if err, ok := result.(error); ok {
+				ctx.LoggingClient().Errorf("Staleness handler failed in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+			}
+		}
+	}
+
+	return false, nil
+}
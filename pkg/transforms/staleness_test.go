@@ -0,0 +1,112 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func stalenessEvent(age time.Duration) dtos.Event {
+	event := dtos.NewEvent("valve", "valve-1", "position")
+	event.Origin = time.Now().Add(-age).UnixNano()
+	return event
+}
+
+func TestStalenessFilter_ByOrigin(t *testing.T) {
+	filter := NewStalenessFilter(time.Minute)
+
+	tests := []struct {
+		Name     string
+		Event    dtos.Event
+		Expected bool
+	}{
+		{"Fresh", stalenessEvent(time.Second), true},
+		{"Stale", stalenessEvent(2 * time.Minute), false},
+		{"No origin", dtos.Event{DeviceName: "valve-1"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := filter.DropStale(ctx, test.Event)
+			assert.Equal(t, test.Expected, continuePipeline)
+			if test.Expected {
+				assert.Equal(t, test.Event, result)
+			} else {
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestStalenessFilter_ByReceivedTime(t *testing.T) {
+	filter := NewStalenessFilter(time.Minute)
+	filter.ByReceivedTime = true
+
+	context := appfunction.NewContext("123", dic, "")
+	continuePipeline, _ := filter.DropStale(context, []byte("position"))
+	assert.True(t, continuePipeline, "data with no received time should never be stale")
+
+	context.AddValue(interfaces.RECEIVEDTIME, time.Now().Add(-time.Second).Format(time.RFC3339Nano))
+	continuePipeline, _ = filter.DropStale(context, stalenessEvent(time.Hour))
+	assert.True(t, continuePipeline, "age should be measured from the received time rather than the Origin")
+
+	context.AddValue(interfaces.RECEIVEDTIME, time.Now().Add(-2*time.Minute).Format(time.RFC3339Nano))
+	continuePipeline, _ = filter.DropStale(context, []byte("position"))
+	assert.False(t, continuePipeline)
+
+	context.AddValue(interfaces.RECEIVEDTIME, "yesterday")
+	continuePipeline, result := filter.DropStale(context, []byte("position"))
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "unable to parse received time")
+}
+
+func TestStalenessFilter_StaleHandler(t *testing.T) {
+	var handled []interface{}
+	filter := NewStalenessFilter(time.Minute)
+	filter.StaleHandler = func(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+		handled = append(handled, data)
+		return false, errors.New("late destination unavailable")
+	}
+
+	stale := stalenessEvent(time.Hour)
+	continuePipeline, _ := filter.DropStale(ctx, stale)
+	assert.False(t, continuePipeline)
+	continuePipeline, _ = filter.DropStale(ctx, stalenessEvent(time.Second))
+	assert.True(t, continuePipeline)
+
+	require.Len(t, handled, 1, "only stale data should be handled")
+	assert.Equal(t, stale, handled[0])
+}
+
+func TestStalenessFilter_Errors(t *testing.T) {
+	continuePipeline, result := NewStalenessFilter(time.Minute).DropStale(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+
+	continuePipeline, result = NewStalenessFilter(time.Minute).DropStale(ctx, "position")
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "type received is not an Event")
+}
@@ -17,6 +17,7 @@
 package transforms
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
@@ -50,7 +51,8 @@ func NewGenericTags(tags map[string]interface{}) Tags {
 	}
 }
 
-// AddTags adds the pre-configured list of tags to the Event's tags collection.
+// AddTags adds the pre-configured list of tags to the Event's tags collection and to the context's tags, so they
+// are also sent as headers with the data exported via HTTP, even once the Event has been transformed to another format.
 func (t *Tags) AddTags(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
 	ctx.LoggingClient().Debugf("Adding tags to Event in pipeline '%s'", ctx.PipelineId())
 
@@ -70,6 +72,7 @@ func (t *Tags) AddTags(ctx interfaces.AppFunctionContext, data interface{}) (boo
 
 		for tag, value := range t.tags {
 			event.Tags[tag] = value
+			ctx.SetTag(tag, tagString(value))
 		}
 		ctx.LoggingClient().Debugf("Tags added to Event in pipeline '%s'. Event tags=%v", ctx.PipelineId(), event.Tags)
 	} else {
@@ -78,3 +81,17 @@ func (t *Tags) AddTags(ctx interfaces.AppFunctionContext, data interface{}) (boo
 
 	return true, event
 }
+
+// tagString returns the tag's value as a string, non-string values are JSON encoded
+func tagString(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(encoded)
+}
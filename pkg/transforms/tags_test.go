@@ -21,6 +21,8 @@ import (
 
 	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
 
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -165,3 +167,22 @@ func TestTags_AddGenericTags(t *testing.T) {
 		})
 	}
 }
+
+func TestTags_AddTagsSetsContextTags(t *testing.T) {
+	taggedCtx := appfunction.NewContext("123", dic, "")
+
+	target := NewGenericTags(map[string]interface{}{
+		"GatewayId": "HoustonStore000123",
+		"Floor":     3,
+		"Zones":     []string{"A", "B"},
+	})
+	continuePipeline, result := target.AddTags(taggedCtx, dtos.Event{})
+	require.True(t, continuePipeline, result)
+
+	expected := map[string]string{
+		"GatewayId": "HoustonStore000123",
+		"Floor":     "3",
+		"Zones":     `["A","B"]`,
+	}
+	assert.Equal(t, expected, taggedCtx.Tags())
+}
@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// TemplateTransform renders the data with a Go text/template to produce payloads in custom formats
+type TemplateTransform struct {
+	template *template.Template
+}
+
+// NewTemplateTransform creates, initializes and returns a new instance of TemplateTransform for the Go text/template.
+// Besides the standard template functions, the template can use:
+//
+//	json             marshals the value to JSON
+//	upper, lower     change the case of a string
+//	trim             removes leading and trailing white space
+//	replace          replaces all instances of old with new in a string
+//	formatTime       formats nanoseconds since the epoch with a Go time layout, e.g. formatTime .Origin "2006-01-02"
+//	contextValue     returns a value stored in the function context, e.g. contextValue "receivedtopic"
+//
+// Referencing a missing map key is an error rather than rendering "<no value>".
+// An error is returned if the template can't be parsed.
+func NewTemplateTransform(templateText string) (*TemplateTransform, error) {
+	parsed, err := template.New("payload").
+		Option("missingkey=error").
+		Funcs(templateFunctions(nil)).
+		Parse(templateText)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template: %s", err.Error())
+	}
+
+	return &TemplateTransform{template: parsed}, nil
+}
+
+// TransformWithTemplate renders the template with the data and returns the rendered string. An Event is passed to
+// the template as is, so its fields are referenced as .DeviceName, .Readings etc. JSON received as []byte or a
+// string is decoded, so its fields are referenced by their JSON names, e.g. .deviceName. Any other data is passed
+// as is. This function will return an error and stop the pipeline if no data is received or the template can't be
+// rendered with the data.
+func (transform *TemplateTransform) TransformWithTemplate(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function TransformWithTemplate in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Transforming with template in pipeline '%s'", ctx.PipelineId())
+
+	// The template is cloned so the context functions are bound to this execution's context
+	executable, err := transform.template.Clone()
+	if err != nil {
+		return false, fmt.Errorf("function TransformWithTemplate in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+	executable.Funcs(templateFunctions(ctx))
+
+	var rendered bytes.Buffer
+	if err := executable.Execute(&rendered, templateData(data)); err != nil {
+		return false, fmt.Errorf("function TransformWithTemplate in pipeline '%s': unable to render template: %s",
+			ctx.PipelineId(), err.Error())
+	}
+
+	return true, rendered.String()
+}
+
+// templateData returns the value the template is rendered with
+func templateData(data interface{}) interface{} {
+	switch value := data.(type) {
+	case dtos.Event:
+		return value
+	case []byte, string:
+		raw, _ := util.CoerceType(value)
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+
+		var decoded interface{}
+		if err := decoder.Decode(&decoded); err != nil || decoder.More() {
+			// Not JSON, so the template renders the text itself
+			return string(raw)
+		}
+		return decoded
+	default:
+		return data
+	}
+}
+
+func templateFunctions(ctx interfaces.AppFunctionContext) template.FuncMap {
+	return template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"replace":    func(value string, old string, new string) string { return strings.ReplaceAll(value, old, new) },
+		"formatTime": formatTemplateTime,
+		"contextValue": func(key string) string {
+			if ctx == nil {
+				return ""
+			}
+			value, _ := ctx.GetValue(key)
+			return value
+		},
+	}
+}
+
+// formatTemplateTime formats nanoseconds since the epoch, as an integer, json.Number or string, with the layout
+func formatTemplateTime(nanoseconds interface{}, layout string) (string, error) {
+	var value int64
+	switch number := nanoseconds.(type) {
+	case int64:
+		value = number
+	case int:
+		value = int64(number)
+	case json.Number:
+		parsed, err := number.Int64()
+		if err != nil {
+			return "", fmt.Errorf("formatTime: invalid nanoseconds '%s'", number.String())
+		}
+		value = parsed
+	case string:
+		parsed, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("formatTime: invalid nanoseconds '%s'", number)
+		}
+		value = parsed
+	default:
+		return "", fmt.Errorf("formatTime: unsupported type %T", nanoseconds)
+	}
+
+	return time.Unix(0, value).UTC().Format(layout), nil
+}
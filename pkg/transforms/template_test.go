@@ -0,0 +1,93 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestTemplateTransform_TransformWithTemplate(t *testing.T) {
+	event := dtos.NewEvent("thermostat", "thermostat-1", "status")
+	event.Origin = 1625097600000000000
+	require.NoError(t, event.AddSimpleReading("temperature", common.ValueTypeInt64, int64(21)))
+	require.NoError(t, event.AddSimpleReading("humidity", common.ValueTypeInt64, int64(40)))
+
+	templateCtx := ctx.Clone().(*appfunction.Context)
+	templateCtx.AddValue(interfaces.RECEIVEDTOPIC, "edgex/events/thermostat")
+
+	tests := []struct {
+		Name     string
+		Template string
+		Data     interface{}
+		Expected string
+	}{
+		{"Event", `{"id":{{json (upper .DeviceName)}},"time":"{{formatTime .Origin "2006-01-02"}}","values":{ {{- range $i, $r := .Readings}}{{if $i}},{{end}}"{{$r.ResourceName}}":{{$r.Value}}{{end -}} }}`,
+			event, `{"id":"THERMOSTAT-1","time":"2021-07-01","values":{"temperature":21,"humidity":40}}`},
+		{"JSON bytes", `{{.device}} reported {{index .values 1}} at {{formatTime .origin "15:04"}}`,
+			[]byte(`{"device":"pump-1","values":[1.5,2.25],"origin":1625097600000000000}`), "pump-1 reported 2.25 at 00:00"},
+		{"Text", `line={{replace (trim .) " " "_"}}`, " fan speed high ", "line=fan_speed_high"},
+		{"Context value", `topic={{contextValue "receivedtopic"}}`, "x", "topic=edgex/events/thermostat"},
+		{"Other type", `{{.}}`, 42, "42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			transform, err := NewTemplateTransform(test.Template)
+			require.NoError(t, err)
+
+			continuePipeline, result := transform.TransformWithTemplate(templateCtx, test.Data)
+			require.True(t, continuePipeline, result)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestTemplateTransform_Errors(t *testing.T) {
+	_, err := NewTemplateTransform("{{.deviceName")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to parse template")
+
+	transform, err := NewTemplateTransform("{{.deviceName}} {{formatTime .origin \"2006\"}}")
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Missing key", `{"device":"pump-1"}`, "unable to render template"},
+		{"Bad time", `{"deviceName":"pump-1","origin":"yesterday"}`, "formatTime: invalid nanoseconds 'yesterday'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := transform.TransformWithTemplate(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// Throttle passes at most a limited number of Events per interval for each device, protecting the downstream
+// endpoints from bursts. The Events beyond the limit are either dropped or queued, the pipeline execution waiting
+// until the device's next interval has capacity, so the trigger is slowed to the rate allowed.
+type Throttle struct {
+	limit     int
+	interval  time.Duration
+	maxQueued int
+	lock      sync.Mutex
+	devices   map[string]*throttleWindow
+	lastSweep time.Time
+}
+
+// throttleWindow is the count of the Events passed for a device in its current interval
+type throttleWindow struct {
+	start time.Time
+	// count includes the slots reserved in the following intervals by queued Events
+	count int
+}
+
+// NewThrottle creates, initializes and returns a new instance of Throttle which drops the Events beyond the limit
+// for each interval. limit and interval must be greater than 0.
+func NewThrottle(limit int, interval time.Duration) *Throttle {
+	return NewQueueingThrottle(limit, interval, 0)
+}
+
+// NewQueueingThrottle creates, initializes and returns a new instance of Throttle which queues up to maxQueued Events
+// per device beyond the limit for each interval, until the following intervals have capacity. Events beyond
+// maxQueued are dropped.
+func NewQueueingThrottle(limit int, interval time.Duration, maxQueued int) *Throttle {
+	return &Throttle{
+		limit:     limit,
+		interval:  interval,
+		maxQueued: maxQueued,
+		devices:   make(map[string]*throttleWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Throttle passes the data on if the device's Event count for the current interval is within the limit, otherwise
+// waits for a following interval when queueing or stops the pipeline. The device is the Event's device, or the
+// device name in the context for data that isn't an Event.
+// This function will return an error and stop the pipeline if no data is received.
+func (throttle *Throttle) Throttle(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function Throttle in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	deviceName, _ := ctx.GetValue(interfaces.DEVICENAME)
+	if event, ok := data.(dtos.Event); ok {
+		deviceName = event.DeviceName
+	}
+
+	wait, ok := throttle.reserve(deviceName, time.Now())
+	if !ok {
+		ctx.LoggingClient().Debugf("Data for device '%s' dropped by Throttle in pipeline '%s'", deviceName, ctx.PipelineId())
+		return false, nil
+	}
+
+	if wait > 0 {
+		ctx.LoggingClient().Debugf("Data for device '%s' queued by Throttle for %s in pipeline '%s'", deviceName, wait.String(), ctx.PipelineId())
+		time.Sleep(wait)
+	}
+
+	return true, data
+}
+
+// reserve reserves a slot for the device in the current interval, or the next interval with capacity when queueing,
+// and returns how long to wait for the slot's interval. Returns false if no slot is available.
+func (throttle *Throttle) reserve(deviceName string, now time.Time) (time.Duration, bool) {
+	throttle.lock.Lock()
+	defer throttle.lock.Unlock()
+
+	throttle.sweep(now)
+
+	window, found := throttle.devices[deviceName]
+	if !found {
+		window = &throttleWindow{start: now}
+		throttle.devices[deviceName] = window
+	}
+
+	// Move on to the interval now is in, the queued slots having been reserved in the intervals since passed
+	if elapsed := now.Sub(window.start); elapsed >= throttle.interval {
+		passed := int(elapsed / throttle.interval)
+		window.start = window.start.Add(time.Duration(passed) * throttle.interval)
+		window.count -= passed * throttle.limit
+		if window.count < 0 {
+			window.count = 0
+		}
+	}
+
+	if window.count < throttle.limit {
+		window.count++
+		return 0, true
+	}
+
+	// Slots beyond the current interval's limit are in the following intervals
+	queued := window.count - throttle.limit
+	if queued >= throttle.maxQueued {
+		return 0, false
+	}
+
+	window.count++
+	return window.start.Add(time.Duration(queued/throttle.limit+1) * throttle.interval).Sub(now), true
+}
+
+// sweep removes the devices with no slots reserved in the current interval, so devices no longer sending data
+// aren't kept. Must be called with the lock held.
+func (throttle *Throttle) sweep(now time.Time) {
+	if now.Sub(throttle.lastSweep) < throttle.interval {
+		return
+	}
+	throttle.lastSweep = now
+
+	for deviceName, window := range throttle.devices {
+		if now.Sub(window.start) >= time.Duration(window.count/throttle.limit+1)*throttle.interval {
+			delete(throttle.devices, deviceName)
+		}
+	}
+}
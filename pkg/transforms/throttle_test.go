@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/internal/appfunction"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+func TestThrottle_Drop(t *testing.T) {
+	throttle := NewThrottle(2, time.Hour)
+
+	passed := 0
+	for i := 0; i < 5; i++ {
+		continuePipeline, result := throttle.Throttle(ctx, dtos.NewEvent("meter", "meter-1", "power"))
+		if continuePipeline {
+			passed++
+			continue
+		}
+		assert.Nil(t, result)
+	}
+	assert.Equal(t, 2, passed)
+
+	continuePipeline, _ := throttle.Throttle(ctx, dtos.NewEvent("meter", "meter-2", "power"))
+	assert.True(t, continuePipeline, "each device should be throttled separately")
+
+	context := appfunction.NewContext("123", dic, "")
+	context.AddValue(interfaces.DEVICENAME, "meter-3")
+	continuePipeline, result := throttle.Throttle(context, []byte("reading"))
+	assert.True(t, continuePipeline, "device name should be taken from the context for data that isn't an Event")
+	assert.Equal(t, []byte("reading"), result)
+}
+
+func TestThrottle_Queue(t *testing.T) {
+	throttle := NewQueueingThrottle(1, 50*time.Millisecond, 1)
+
+	continuePipeline, _ := throttle.Throttle(ctx, dtos.NewEvent("meter", "meter-1", "power"))
+	require.True(t, continuePipeline)
+
+	start := time.Now()
+	done := make(chan bool)
+	go func() {
+		continuePipeline, _ := throttle.Throttle(ctx, dtos.NewEvent("meter", "meter-1", "power"))
+		done <- continuePipeline
+	}()
+
+	require.Eventually(t, func() bool {
+		throttle.lock.Lock()
+		defer throttle.lock.Unlock()
+		return throttle.devices["meter-1"].count == 2
+	}, time.Second, time.Millisecond)
+
+	continuePipeline, _ = throttle.Throttle(ctx, dtos.NewEvent("meter", "meter-1", "power"))
+	assert.False(t, continuePipeline, "Events beyond the queue limit should be dropped")
+
+	assert.True(t, <-done, "queued Event should be passed on")
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(40*time.Millisecond), "queued Event should wait for the next interval")
+}
+
+func TestThrottle_Reserve(t *testing.T) {
+	throttle := NewQueueingThrottle(2, time.Second, 3)
+	start := time.Now()
+
+	tests := []struct {
+		Offset       time.Duration
+		ExpectedWait time.Duration
+		ExpectedOk   bool
+	}{
+		{0, 0, true},
+		{100 * time.Millisecond, 0, true},
+		{200 * time.Millisecond, 800 * time.Millisecond, true},  // queued in the next interval
+		{300 * time.Millisecond, 700 * time.Millisecond, true},  // queued in the next interval
+		{400 * time.Millisecond, 1600 * time.Millisecond, true}, // queued in the interval after next
+		{500 * time.Millisecond, 0, false},                      // beyond the queue limit
+		{1500 * time.Millisecond, 500 * time.Millisecond, true}, // queued with the remaining Event in the next interval
+		{3000 * time.Millisecond, 0, true},                      // all queued Events have been passed
+	}
+
+	for _, test := range tests {
+		wait, ok := throttle.reserve("meter-1", start.Add(test.Offset))
+		assert.Equal(t, test.ExpectedOk, ok, "offset %s", test.Offset)
+		assert.Equal(t, test.ExpectedWait, wait, "offset %s", test.Offset)
+	}
+}
+
+func TestThrottle_NoData(t *testing.T) {
+	continuePipeline, result := NewThrottle(1, time.Second).Throttle(ctx, nil)
+	require.False(t, continuePipeline)
+	assert.Contains(t, result.(error).Error(), "No Data Received")
+}
@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+)
+
+// unit is a unit of measure, converted to the base unit of its quantity as (value + offset) * factor
+type unit struct {
+	quantity string
+	factor   float64
+	offset   float64
+}
+
+var units = map[string]unit{}
+
+func init() {
+	addUnit := func(quantity string, factor float64, offset float64, names ...string) {
+		for _, name := range names {
+			units[name] = unit{quantity: quantity, factor: factor, offset: offset}
+		}
+	}
+
+	// Temperature in kelvin
+	addUnit("temperature", 1, 0, "k", "kelvin")
+	addUnit("temperature", 1, 273.15, "c", "°c", "degc", "celsius")
+	addUnit("temperature", 5.0/9.0, 459.67, "f", "°f", "degf", "fahrenheit")
+
+	// Pressure in pascals
+	addUnit("pressure", 1, 0, "pa", "pascal")
+	addUnit("pressure", 1e3, 0, "kpa")
+	addUnit("pressure", 1e6, 0, "mpa")
+	addUnit("pressure", 1e5, 0, "bar")
+	addUnit("pressure", 1e2, 0, "mbar", "hpa")
+	addUnit("pressure", 6894.757293168, 0, "psi")
+	addUnit("pressure", 101325, 0, "atm")
+	addUnit("pressure", 133.322387415, 0, "mmhg")
+
+	// Length in metres
+	addUnit("length", 1e-3, 0, "mm")
+	addUnit("length", 1e-2, 0, "cm")
+	addUnit("length", 1, 0, "m")
+	addUnit("length", 1e3, 0, "km")
+	addUnit("length", 0.0254, 0, "in")
+	addUnit("length", 0.3048, 0, "ft")
+	addUnit("length", 0.9144, 0, "yd")
+	addUnit("length", 1609.344, 0, "mi")
+
+	// Mass in kilograms
+	addUnit("mass", 1e-3, 0, "g")
+	addUnit("mass", 1, 0, "kg")
+	addUnit("mass", 1e3, 0, "t")
+	addUnit("mass", 0.45359237, 0, "lb")
+	addUnit("mass", 0.028349523125, 0, "oz")
+
+	// Speed in metres per second
+	addUnit("speed", 1, 0, "m/s")
+	addUnit("speed", 1/3.6, 0, "km/h", "kph")
+	addUnit("speed", 0.44704, 0, "mph")
+	addUnit("speed", 1852.0/3600.0, 0, "kn", "knot")
+
+	// Volume in litres
+	addUnit("volume", 1e-3, 0, "ml")
+	addUnit("volume", 1, 0, "l")
+	addUnit("volume", 1e3, 0, "m3")
+	addUnit("volume", 3.785411784, 0, "gal")
+}
+
+// ConvertValue converts the value between the units of the same quantity, i.e. from "°F" to "°C" or "psi" to "kPa".
+// Unit names are not case sensitive. Temperature (K, °C, °F), pressure (Pa, hPa, kPa, MPa, mbar, bar, psi, atm,
+// mmHg), length (mm, cm, m, km, in, ft, yd, mi), mass (g, kg, t, lb, oz), speed (m/s, km/h, mph, kn) and volume
+// (mL, L, m3, gal) are supported.
+func ConvertValue(value float64, from string, to string) (float64, error) {
+	fromUnit, toUnit, err := lookupUnits(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	base := (value + fromUnit.offset) * fromUnit.factor
+	return base/toUnit.factor - toUnit.offset, nil
+}
+
+func lookupUnits(from string, to string) (unit, unit, error) {
+	fromUnit, found := units[strings.ToLower(strings.TrimSpace(from))]
+	if !found {
+		return unit{}, unit{}, fmt.Errorf("unit '%s' is not supported", from)
+	}
+
+	toUnit, found := units[strings.ToLower(strings.TrimSpace(to))]
+	if !found {
+		return unit{}, unit{}, fmt.Errorf("unit '%s' is not supported", to)
+	}
+
+	if fromUnit.quantity != toUnit.quantity {
+		return unit{}, unit{}, fmt.Errorf("unable to convert %s in '%s' to %s in '%s'", fromUnit.quantity, from, toUnit.quantity, to)
+	}
+
+	return fromUnit, toUnit, nil
+}
+
+// UnitConversion is an entry of the mapping table of a UnitConverter
+type UnitConversion struct {
+	// ResourceName is the name of the resource whose readings are converted
+	ResourceName string
+	// From is the unit the readings are received in
+	From string
+	// To is the unit the readings are converted to
+	To string
+	// RenameTo, when set, replaces the resource name of the converted readings, i.e. "TemperatureF" to "TemperatureC"
+	RenameTo string
+}
+
+// UnitConverter converts the values of readings between units driven by a mapping table, so the readings from
+// different devices can be normalized without bespoke code in every service.
+type UnitConverter struct {
+	conversions map[string]UnitConversion
+}
+
+// NewUnitConverter creates, initializes and returns a new instance of UnitConverter for the mapping table.
+// Returns an error if a conversion's units aren't supported or are for different quantities.
+func NewUnitConverter(conversions []UnitConversion) (*UnitConverter, error) {
+	converter := &UnitConverter{
+		conversions: make(map[string]UnitConversion),
+	}
+
+	for _, conversion := range conversions {
+		if _, _, err := lookupUnits(conversion.From, conversion.To); err != nil {
+			return nil, fmt.Errorf("invalid conversion for resource '%s': %s", conversion.ResourceName, err.Error())
+		}
+
+		if _, found := converter.conversions[conversion.ResourceName]; found {
+			return nil, fmt.Errorf("duplicate conversion for resource '%s'", conversion.ResourceName)
+		}
+
+		converter.conversions[conversion.ResourceName] = conversion
+	}
+
+	return converter, nil
+}
+
+// ConvertUnits converts the values of the Event's readings for the resources in the mapping table and renames the
+// resources as mapped. Integer readings are converted to Float64 readings so precision isn't lost.
+// This function will return an error and stop the pipeline if a non-edgex event is received, no data is received or
+// the value of a reading to convert isn't a number.
+func (converter *UnitConverter) ConvertUnits(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function ConvertUnits in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	event, ok := data.(dtos.Event)
+	if !ok {
+		return false, fmt.Errorf("function ConvertUnits in pipeline '%s', type received is not an Event", ctx.PipelineId())
+	}
+
+	// Copy the readings so the values of the received Event are not replaced
+	event.Readings = append([]dtos.BaseReading{}, event.Readings...)
+
+	for index, reading := range event.Readings {
+		conversion, found := converter.conversions[reading.ResourceName]
+		if !found {
+			continue
+		}
+
+		switch reading.ValueType {
+		case common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64,
+			common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64:
+			event.Readings[index].ValueType = common.ValueTypeFloat64
+		case common.ValueTypeFloat32, common.ValueTypeFloat64:
+		default:
+			return false, fmt.Errorf("function ConvertUnits in pipeline '%s': reading '%s' of type %s can't be converted",
+				ctx.PipelineId(), reading.ResourceName, reading.ValueType)
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(reading.Value), 64)
+		if err != nil {
+			return false, fmt.Errorf("function ConvertUnits in pipeline '%s': unable to parse value of reading '%s': %s",
+				ctx.PipelineId(), reading.ResourceName, err.Error())
+		}
+
+		// The units were validated when the converter was created
+		converted, _ := ConvertValue(value, conversion.From, conversion.To)
+		event.Readings[index].Value = formatSmoothedValue(event.Readings[index].ValueType, converted)
+
+		if conversion.RenameTo != "" {
+			event.Readings[index].ResourceName = conversion.RenameTo
+		}
+	}
+
+	ctx.LoggingClient().Debugf("Converted units of readings in pipeline '%s'", ctx.PipelineId())
+
+	return true, event
+}
@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Value       float64
+		From        string
+		To          string
+		Expected    float64
+		ExpectError bool
+	}{
+		{"F to C", 212, "°F", "°C", 100, false},
+		{"C to F", -40, "C", "F", -40, false},
+		{"C to K", 0, "celsius", "K", 273.15, false},
+		{"psi to kPa", 1, "psi", "kPa", 6.894757293168, false},
+		{"bar to psi", 1, "bar", "PSI", 14.503773773, false},
+		{"mi to km", 1, "mi", "km", 1.609344, false},
+		{"lb to kg", 1, "lb", "kg", 0.45359237, false},
+		{"mph to km/h", 60, "mph", "km/h", 96.56064, false},
+		{"gal to L", 1, "gal", "L", 3.785411784, false},
+		{"Unknown unit", 1, "furlong", "m", 0, true},
+		{"Different quantities", 1, "psi", "°C", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			actual, err := ConvertValue(test.Value, test.From, test.To)
+			if test.ExpectError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, test.Expected, actual, 1e-6)
+		})
+	}
+}
+
+func TestUnitConverter_ConvertUnits(t *testing.T) {
+	converter, err := NewUnitConverter([]UnitConversion{
+		{ResourceName: "TemperatureF", From: "°F", To: "°C", RenameTo: "TemperatureC"},
+		{ResourceName: "Pressure", From: "psi", To: "kPa"},
+	})
+	require.NoError(t, err)
+
+	received := dtos.NewEvent("boiler", "boiler-1", "status")
+	require.NoError(t, received.AddSimpleReading("TemperatureF", common.ValueTypeFloat64, 212.0))
+	require.NoError(t, received.AddSimpleReading("Pressure", common.ValueTypeInt32, int32(10)))
+	require.NoError(t, received.AddSimpleReading("Running", common.ValueTypeBool, true))
+
+	continuePipeline, result := converter.ConvertUnits(ctx, received)
+	require.True(t, continuePipeline, result)
+	readings := result.(dtos.Event).Readings
+
+	assert.Equal(t, "TemperatureC", readings[0].ResourceName)
+	assert.Equal(t, "1.000000e+02", readings[0].Value)
+	assert.Equal(t, "Pressure", readings[1].ResourceName)
+	assert.Equal(t, common.ValueTypeFloat64, readings[1].ValueType, "integer readings should be converted to Float64")
+	assert.Equal(t, "6.894757e+01", readings[1].Value)
+	assert.Equal(t, received.Readings[2], readings[2], "readings not in the mapping table should not be converted")
+	assert.Equal(t, "TemperatureF", received.Readings[0].ResourceName, "received Event should not be modified")
+}
+
+func TestUnitConverter_Errors(t *testing.T) {
+	_, err := NewUnitConverter([]UnitConversion{{ResourceName: "Temperature", From: "°F", To: "psi"}})
+	require.Error(t, err)
+
+	_, err = NewUnitConverter([]UnitConversion{
+		{ResourceName: "Temperature", From: "°F", To: "°C"},
+		{ResourceName: "Temperature", From: "K", To: "°C"},
+	})
+	require.Error(t, err)
+
+	converter, err := NewUnitConverter([]UnitConversion{{ResourceName: "Temperature", From: "°F", To: "°C"}})
+	require.NoError(t, err)
+
+	badValue := dtos.NewEvent("boiler", "boiler-1", "status")
+	require.NoError(t, badValue.AddSimpleReading("Temperature", common.ValueTypeString, "hot"))
+
+	tests := []struct {
+		Name          string
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", nil, "No Data Received"},
+		{"Not an Event", "20", "type received is not an Event"},
+		{"Not a number", badValue, "can't be converted"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			continuePipeline, result := converter.ConvertUnits(ctx, test.Data)
+			require.False(t, continuePipeline)
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,471 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/google/uuid"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/interfaces"
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/util"
+)
+
+// XMLOptions customizes the XML created by TransformToXML for systems expecting their own element names, such as
+// legacy MES or SCADA systems. Fields are identified by their Go field names in the Event and BaseReading DTOs, e.g.
+// "DeviceName", "Value" or "Tags".
+type XMLOptions struct {
+	// EventElement is the name of the root element, "Event" when empty
+	EventElement string
+	// ReadingElement is the name of the element for each reading, "Readings" when empty
+	ReadingElement string
+	// ReadingsElement, when not empty, is the name of an element wrapping all the reading elements
+	ReadingsElement string
+	// FieldNames maps field names to the names of their elements or attributes. A field mapped to "-" is omitted.
+	FieldNames map[string]string
+	// Attributes are the names of the fields written as attributes of the event or reading element rather than as
+	// child elements. Tags can't be attributes.
+	Attributes []string
+}
+
+// xmlField is a field of an Event or reading to write to XML
+type xmlField struct {
+	name  string
+	value string
+	// omitEmpty is true for the fields only written when set, such as the value fields of a reading
+	omitEmpty bool
+}
+
+func (options XMLOptions) elementName(name string, defaultName string) xml.Name {
+	if name == "" {
+		name = defaultName
+	}
+	return xml.Name{Local: name}
+}
+
+func (options XMLOptions) fieldName(field string) string {
+	if name, ok := options.FieldNames[field]; ok {
+		return name
+	}
+	return field
+}
+
+func (options XMLOptions) isAttribute(field string) bool {
+	for _, attribute := range options.Attributes {
+		if attribute == field {
+			return true
+		}
+	}
+	return false
+}
+
+// marshalEvent writes the Event as XML using the options
+func (options XMLOptions) marshalEvent(event dtos.Event) (string, error) {
+	var buffer bytes.Buffer
+	encoder := xml.NewEncoder(&buffer)
+
+	fields := []xmlField{
+		{name: "ApiVersion", value: event.ApiVersion},
+		{name: "Id", value: event.Id},
+		{name: "DeviceName", value: event.DeviceName},
+		{name: "ProfileName", value: event.ProfileName},
+		{name: "SourceName", value: event.SourceName},
+		{name: "Origin", value: strconv.FormatInt(event.Origin, 10)},
+	}
+
+	eventElement := options.startElement(options.elementName(options.EventElement, "Event"), fields)
+	if err := encoder.EncodeToken(eventElement); err != nil {
+		return "", err
+	}
+	if err := options.encodeFields(encoder, fields); err != nil {
+		return "", err
+	}
+
+	if options.ReadingsElement != "" {
+		if err := encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: options.ReadingsElement}}); err != nil {
+			return "", err
+		}
+	}
+
+	for _, reading := range event.Readings {
+		if err := options.encodeReading(encoder, reading); err != nil {
+			return "", err
+		}
+	}
+
+	if options.ReadingsElement != "" {
+		if err := encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: options.ReadingsElement}}); err != nil {
+			return "", err
+		}
+	}
+
+	if err := options.encodeTags(encoder, event.Tags); err != nil {
+		return "", err
+	}
+
+	if err := encoder.EncodeToken(eventElement.End()); err != nil {
+		return "", err
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+func (options XMLOptions) encodeReading(encoder *xml.Encoder, reading dtos.BaseReading) error {
+	objectValue := ""
+	if reading.ObjectValue != nil {
+		encoded, err := json.Marshal(reading.ObjectValue)
+		if err != nil {
+			return fmt.Errorf("unable to marshal object value of reading '%s': %s", reading.ResourceName, err.Error())
+		}
+		objectValue = string(encoded)
+	}
+
+	fields := []xmlField{
+		{name: "Id", value: reading.Id},
+		{name: "Origin", value: strconv.FormatInt(reading.Origin, 10)},
+		{name: "DeviceName", value: reading.DeviceName},
+		{name: "ResourceName", value: reading.ResourceName},
+		{name: "ProfileName", value: reading.ProfileName},
+		{name: "ValueType", value: reading.ValueType},
+		{name: "Value", value: reading.Value, omitEmpty: true},
+		{name: "BinaryValue", value: base64.StdEncoding.EncodeToString(reading.BinaryValue), omitEmpty: true},
+		{name: "MediaType", value: reading.MediaType, omitEmpty: true},
+		{name: "ObjectValue", value: objectValue, omitEmpty: true},
+	}
+
+	readingElement := options.startElement(options.elementName(options.ReadingElement, "Readings"), fields)
+	if err := encoder.EncodeToken(readingElement); err != nil {
+		return err
+	}
+	if err := options.encodeFields(encoder, fields); err != nil {
+		return err
+	}
+	return encoder.EncodeToken(readingElement.End())
+}
+
+// startElement returns the start of the element with the fields written as attributes
+func (options XMLOptions) startElement(name xml.Name, fields []xmlField) xml.StartElement {
+	element := xml.StartElement{Name: name}
+	for _, field := range fields {
+		name := options.fieldName(field.name)
+		if name == "-" || (field.omitEmpty && field.value == "") || !options.isAttribute(field.name) {
+			continue
+		}
+		element.Attr = append(element.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: field.value})
+	}
+	return element
+}
+
+// encodeFields writes the fields that aren't attributes as child elements
+func (options XMLOptions) encodeFields(encoder *xml.Encoder, fields []xmlField) error {
+	for _, field := range fields {
+		name := options.fieldName(field.name)
+		if name == "-" || (field.omitEmpty && field.value == "") || options.isAttribute(field.name) {
+			continue
+		}
+		if err := encoder.EncodeElement(field.value, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeTags writes the tags as child elements of a Tags element, named by their keys in sorted order
+func (options XMLOptions) encodeTags(encoder *xml.Encoder, tags map[string]interface{}) error {
+	name := options.fieldName("Tags")
+	if len(tags) == 0 || name == "-" {
+		return nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tagsElement := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(tagsElement); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := encoder.EncodeElement(fmt.Sprint(tags[key]), xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return fmt.Errorf("unable to marshal tag '%s': %s", key, err.Error())
+		}
+	}
+	return encoder.EncodeToken(tagsElement.End())
+}
+
+// XMLReadingMapping maps the values selected by an XPath expression to readings of a device resource
+type XMLReadingMapping struct {
+	ResourceName string
+	// ValueType is the EdgeX value type of the readings, String when empty. Only the Bool, String, integer and float
+	// value types are supported.
+	ValueType string
+	// Path is the XPath expression selecting the reading values. A reading is created for each value selected.
+	Path string
+}
+
+// XMLEventMapping maps an XML document to an Event. The names are XPath expressions, which can be quoted string
+// literals for names that aren't in the document, e.g. "'legacy-mes'".
+type XMLEventMapping struct {
+	ProfileName string
+	DeviceName  string
+	SourceName  string
+	// Origin is optional and selects the Event's origin as Unix nanoseconds or an RFC 3339 timestamp. When empty,
+	// the time the document is decoded is the origin.
+	Origin   string
+	Readings []XMLReadingMapping
+}
+
+type xmlReadingPath struct {
+	resourceName string
+	valueType    string
+	path         *compiledXPath
+}
+
+// XMLDecoder decodes XML documents into Events using XPath expressions to select the Event's names and readings,
+// for integration with systems such as legacy MES or SCADA systems publishing XML. The XPath expressions support
+// location paths of element names, the * wildcard, // descendants, . and .., a final @attribute or text() step, and
+// predicates selecting by position, last(), or the presence or value of an attribute or child element.
+// Namespace prefixes are ignored.
+type XMLDecoder struct {
+	profileName *compiledXPath
+	deviceName  *compiledXPath
+	sourceName  *compiledXPath
+	origin      *compiledXPath
+	readings    []xmlReadingPath
+}
+
+// NewXMLDecoder creates, initializes and returns a new instance of XMLDecoder for the mapping. It returns an error if
+// a name, XPath expression or value type is missing or invalid.
+func NewXMLDecoder(mapping XMLEventMapping) (*XMLDecoder, error) {
+	decoder := &XMLDecoder{}
+
+	names := []struct {
+		field      string
+		expression string
+		path       **compiledXPath
+	}{
+		{"ProfileName", mapping.ProfileName, &decoder.profileName},
+		{"DeviceName", mapping.DeviceName, &decoder.deviceName},
+		{"SourceName", mapping.SourceName, &decoder.sourceName},
+	}
+	for _, name := range names {
+		path, err := compileXPath(name.expression)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name.field, err.Error())
+		}
+		*name.path = path
+	}
+
+	if mapping.Origin != "" {
+		path, err := compileXPath(mapping.Origin)
+		if err != nil {
+			return nil, fmt.Errorf("Origin: %s", err.Error())
+		}
+		decoder.origin = path
+	}
+
+	if len(mapping.Readings) == 0 {
+		return nil, fmt.Errorf("at least one reading mapping must be specified")
+	}
+
+	for _, reading := range mapping.Readings {
+		if reading.ResourceName == "" {
+			return nil, fmt.Errorf("reading mapping for '%s' must have a resource name", reading.Path)
+		}
+
+		valueType := reading.ValueType
+		if valueType == "" {
+			valueType = common.ValueTypeString
+		}
+		if valueType != common.ValueTypeString && valueType != common.ValueTypeBool && !isNumericValueType(valueType) {
+			return nil, fmt.Errorf("value type '%s' of resource '%s' is not supported", valueType, reading.ResourceName)
+		}
+
+		path, err := compileXPath(reading.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resource '%s': %s", reading.ResourceName, err.Error())
+		}
+
+		decoder.readings = append(decoder.readings, xmlReadingPath{
+			resourceName: reading.ResourceName,
+			valueType:    valueType,
+			path:         path,
+		})
+	}
+
+	return decoder, nil
+}
+
+// FromXML decodes the data, an XML document as a string or []byte, and returns the Event mapped from it.
+// This function will return an error and stop the pipeline if no data is received, the data isn't valid XML, a name
+// isn't found, a value doesn't match its value type or no reading values are found.
+func (decoder *XMLDecoder) FromXML(ctx interfaces.AppFunctionContext, data interface{}) (bool, interface{}) {
+	if data == nil {
+		// We didn't receive a result
+		return false, fmt.Errorf("function FromXML in pipeline '%s': No Data Received", ctx.PipelineId())
+	}
+
+	ctx.LoggingClient().Debugf("Decoding XML to an Event in pipeline '%s'", ctx.PipelineId())
+
+	content, err := util.CoerceType(data)
+	if err != nil {
+		return false, fmt.Errorf("function FromXML in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	event, err := decoder.decode(content)
+	if err != nil {
+		return false, fmt.Errorf("function FromXML in pipeline '%s': %s", ctx.PipelineId(), err.Error())
+	}
+
+	return true, event
+}
+
+func (decoder *XMLDecoder) decode(content []byte) (dtos.Event, error) {
+	document, err := parseXMLDocument(content)
+	if err != nil {
+		return dtos.Event{}, fmt.Errorf("unable to parse XML: %s", err.Error())
+	}
+
+	var names [3]string
+	for index, path := range []*compiledXPath{decoder.profileName, decoder.deviceName, decoder.sourceName} {
+		values := path.evaluate(document)
+		if len(values) == 0 || values[0] == "" {
+			return dtos.Event{}, fmt.Errorf("no value found for %s", []string{"ProfileName", "DeviceName", "SourceName"}[index])
+		}
+		names[index] = values[0]
+	}
+
+	event := dtos.NewEvent(names[0], names[1], names[2])
+
+	if decoder.origin != nil {
+		values := decoder.origin.evaluate(document)
+		if len(values) == 0 {
+			return dtos.Event{}, fmt.Errorf("no value found for Origin")
+		}
+		event.Origin, err = parseXMLOrigin(values[0])
+		if err != nil {
+			return dtos.Event{}, err
+		}
+	}
+
+	for _, reading := range decoder.readings {
+		for _, text := range reading.path.evaluate(document) {
+			value, err := normalizeXMLValue(reading.valueType, text)
+			if err != nil {
+				return dtos.Event{}, fmt.Errorf("invalid %s value '%s' for resource '%s': %s",
+					reading.valueType, text, reading.resourceName, err.Error())
+			}
+
+			event.Readings = append(event.Readings, dtos.BaseReading{
+				Id:            uuid.NewString(),
+				Origin:        event.Origin,
+				DeviceName:    event.DeviceName,
+				ResourceName:  reading.resourceName,
+				ProfileName:   event.ProfileName,
+				ValueType:     reading.valueType,
+				SimpleReading: dtos.SimpleReading{Value: value},
+			})
+		}
+	}
+
+	if len(event.Readings) == 0 {
+		return dtos.Event{}, fmt.Errorf("no reading values found")
+	}
+
+	return event, nil
+}
+
+// parseXMLOrigin parses an origin as Unix nanoseconds or an RFC 3339 timestamp
+func parseXMLOrigin(value string) (int64, error) {
+	if origin, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return origin, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Origin '%s', must be Unix nanoseconds or an RFC 3339 timestamp", value)
+	}
+	return timestamp.UnixNano(), nil
+}
+
+// normalizeXMLValue validates the value against the value type and formats it the same way as the readings created by
+// dtos.NewSimpleReading
+func normalizeXMLValue(valueType string, value string) (string, error) {
+	switch valueType {
+	case common.ValueTypeString:
+		return value, nil
+	case common.ValueTypeBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(parsed), nil
+	case common.ValueTypeInt8, common.ValueTypeInt16, common.ValueTypeInt32, common.ValueTypeInt64:
+		parsed, err := strconv.ParseInt(value, 10, xmlValueBits(valueType))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(parsed, 10), nil
+	case common.ValueTypeUint8, common.ValueTypeUint16, common.ValueTypeUint32, common.ValueTypeUint64:
+		parsed, err := strconv.ParseUint(value, 10, xmlValueBits(valueType))
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(parsed, 10), nil
+	case common.ValueTypeFloat32:
+		parsed, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%e", float32(parsed)), nil
+	case common.ValueTypeFloat64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%e", parsed), nil
+	default:
+		return "", fmt.Errorf("value type not supported")
+	}
+}
+
+func xmlValueBits(valueType string) int {
+	switch valueType {
+	case common.ValueTypeInt8, common.ValueTypeUint8:
+		return 8
+	case common.ValueTypeInt16, common.ValueTypeUint16:
+		return 16
+	case common.ValueTypeInt32, common.ValueTypeUint32:
+		return 32
+	default:
+		return 64
+	}
+}
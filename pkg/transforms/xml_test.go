@@ -0,0 +1,217 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/common"
+	"github.com/edgexfoundry/go-mod-core-contracts/v2/dtos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversion_TransformToXMLWithOptions(t *testing.T) {
+	event := dtos.Event{
+		Id:          "event-1",
+		DeviceName:  deviceName1,
+		ProfileName: "press",
+		SourceName:  "status",
+		Origin:      1000,
+		Tags:        map[string]interface{}{"site": "plant-1", "line": "A"},
+		Readings: []dtos.BaseReading{
+			{Id: "reading-1", Origin: 1000, DeviceName: deviceName1, ResourceName: "temperature", ProfileName: "press",
+				ValueType: common.ValueTypeFloat64, SimpleReading: dtos.SimpleReading{Value: "2.050000e+01"}},
+			{Id: "reading-2", Origin: 1000, DeviceName: deviceName1, ResourceName: "snapshot", ProfileName: "press",
+				ValueType: common.ValueTypeBinary, BinaryReading: dtos.BinaryReading{BinaryValue: []byte("abc"), MediaType: "image/png"}},
+		},
+	}
+
+	conversion := NewConversionWithXMLOptions(XMLOptions{
+		EventElement:    "Report",
+		ReadingElement:  "Measurement",
+		ReadingsElement: "Measurements",
+		FieldNames: map[string]string{
+			"DeviceName":   "Station",
+			"ResourceName": "name",
+			"ApiVersion":   "-",
+			"ProfileName":  "-",
+			"Tags":         "Context",
+		},
+		Attributes: []string{"Id", "DeviceName", "ResourceName", "ValueType"},
+	})
+
+	continuePipeline, result := conversion.TransformToXML(ctx, event)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, common.ContentTypeXML, ctx.ResponseContentType())
+
+	expected := `<Report Id="event-1" Station="device1"><SourceName>status</SourceName><Origin>1000</Origin>` +
+		`<Measurements>` +
+		`<Measurement Id="reading-1" Station="device1" name="temperature" ValueType="Float64"><Origin>1000</Origin><Value>2.050000e+01</Value></Measurement>` +
+		`<Measurement Id="reading-2" Station="device1" name="snapshot" ValueType="Binary"><Origin>1000</Origin><BinaryValue>YWJj</BinaryValue><MediaType>image/png</MediaType></Measurement>` +
+		`</Measurements>` +
+		`<Context><line>A</line><site>plant-1</site></Context></Report>`
+	assert.Equal(t, expected, result)
+
+	// The XML can be decoded back into an Event
+	decoder, err := NewXMLDecoder(XMLEventMapping{
+		ProfileName: "'press'",
+		DeviceName:  "/Report/@Station",
+		SourceName:  "/Report/SourceName",
+		Origin:      "/Report/Origin",
+		Readings: []XMLReadingMapping{
+			{ResourceName: "temperature", ValueType: common.ValueTypeFloat64, Path: "//Measurement[@name='temperature']/Value"},
+		},
+	})
+	require.NoError(t, err)
+	continuePipeline, result = decoder.FromXML(ctx, result)
+	require.True(t, continuePipeline, result)
+	decoded := result.(dtos.Event)
+	assert.Equal(t, deviceName1, decoded.DeviceName)
+	assert.Equal(t, int64(1000), decoded.Origin)
+	require.Len(t, decoded.Readings, 1)
+	assert.Equal(t, "2.050000e+01", decoded.Readings[0].Value)
+}
+
+func TestConversion_TransformToXMLWithDefaultOptions(t *testing.T) {
+	event := dtos.Event{DeviceName: deviceName1}
+	expected, err := event.ToXML()
+	require.NoError(t, err)
+
+	continuePipeline, result := NewConversionWithXMLOptions(XMLOptions{}).TransformToXML(ctx, event)
+	require.True(t, continuePipeline, result)
+	assert.Equal(t, expected, result, "default options should create the same XML as Event.ToXML")
+}
+
+const mesReport = `<?xml version="1.0" encoding="UTF-8"?>
+<Report station="press-1" line="A">
+	<Timestamp>2021-06-01T12:00:00Z</Timestamp>
+	<Measurement name="temperature">20.5</Measurement>
+	<Measurement name="temperature">21</Measurement>
+	<Measurement name="cycles">42</Measurement>
+	<Alarm active="true"/>
+</Report>`
+
+func TestXMLDecoder_FromXML(t *testing.T) {
+	decoder, err := NewXMLDecoder(XMLEventMapping{
+		ProfileName: "'legacy-mes'",
+		DeviceName:  "/Report/@station",
+		SourceName:  "'report'",
+		Origin:      "/Report/Timestamp",
+		Readings: []XMLReadingMapping{
+			{ResourceName: "temperature", ValueType: common.ValueTypeFloat32, Path: "/Report/Measurement[@name='temperature']"},
+			{ResourceName: "cycles", ValueType: common.ValueTypeUint16, Path: "//Measurement[@name='cycles']"},
+			{ResourceName: "alarm", ValueType: common.ValueTypeBool, Path: "//Alarm/@active"},
+			{ResourceName: "line", Path: "/Report/@line"},
+			{ResourceName: "missing", Path: "/Report/Missing"},
+		},
+	})
+	require.NoError(t, err)
+
+	continuePipeline, result := decoder.FromXML(ctx, []byte(mesReport))
+	require.True(t, continuePipeline, result)
+
+	event := result.(dtos.Event)
+	assert.Equal(t, "legacy-mes", event.ProfileName)
+	assert.Equal(t, "press-1", event.DeviceName)
+	assert.Equal(t, "report", event.SourceName)
+	assert.Equal(t, int64(1622548800000000000), event.Origin)
+	assert.NotEmpty(t, event.Id)
+
+	expected := []struct {
+		ResourceName string
+		ValueType    string
+		Value        string
+	}{
+		{"temperature", common.ValueTypeFloat32, "2.050000e+01"},
+		{"temperature", common.ValueTypeFloat32, "2.100000e+01"},
+		{"cycles", common.ValueTypeUint16, "42"},
+		{"alarm", common.ValueTypeBool, "true"},
+		{"line", common.ValueTypeString, "A"},
+	}
+	require.Len(t, event.Readings, len(expected))
+	for index, reading := range event.Readings {
+		assert.Equal(t, expected[index].ResourceName, reading.ResourceName)
+		assert.Equal(t, expected[index].ValueType, reading.ValueType)
+		assert.Equal(t, expected[index].Value, reading.Value)
+		assert.Equal(t, "press-1", reading.DeviceName)
+		assert.Equal(t, "legacy-mes", reading.ProfileName)
+		assert.Equal(t, event.Origin, reading.Origin)
+		assert.NotEmpty(t, reading.Id)
+	}
+}
+
+func TestXMLDecoder_FromXMLErrors(t *testing.T) {
+	mapping := func(deviceName string, valueType string) XMLEventMapping {
+		return XMLEventMapping{
+			ProfileName: "'legacy-mes'",
+			DeviceName:  deviceName,
+			SourceName:  "'report'",
+			Readings:    []XMLReadingMapping{{ResourceName: "cycles", ValueType: valueType, Path: "//Measurement"}},
+		}
+	}
+
+	tests := []struct {
+		Name          string
+		Mapping       XMLEventMapping
+		Data          interface{}
+		ExpectedError string
+	}{
+		{"No data", mapping("/Report/@station", common.ValueTypeString), nil, "No Data Received"},
+		{"Invalid XML", mapping("/Report/@station", common.ValueTypeString), "<Report>", "unable to parse XML"},
+		{"No device name", mapping("/Report/@device", common.ValueTypeString), mesReport, "no value found for DeviceName"},
+		{"Invalid value", mapping("/Report/@station", common.ValueTypeInt8), mesReport, "invalid Int8 value '20.5' for resource 'cycles'"},
+		{"No readings", mapping("/Report/@station", common.ValueTypeString), `<Report station="press-1"/>`, "no reading values found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			decoder, err := NewXMLDecoder(test.Mapping)
+			require.NoError(t, err)
+
+			continuePipeline, result := decoder.FromXML(ctx, test.Data)
+			require.False(t, continuePipeline)
+			require.Error(t, result.(error))
+			assert.Contains(t, result.(error).Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestNewXMLDecoder_Invalid(t *testing.T) {
+	reading := XMLReadingMapping{ResourceName: "cycles", Path: "//Measurement"}
+
+	tests := []struct {
+		Name          string
+		Mapping       XMLEventMapping
+		ExpectedError string
+	}{
+		{"Missing profile name", XMLEventMapping{DeviceName: "'d'", SourceName: "'s'", Readings: []XMLReadingMapping{reading}}, "ProfileName"},
+		{"Invalid origin", XMLEventMapping{ProfileName: "'p'", DeviceName: "'d'", SourceName: "'s'", Origin: "/Report[", Readings: []XMLReadingMapping{reading}}, "Origin"},
+		{"No readings", XMLEventMapping{ProfileName: "'p'", DeviceName: "'d'", SourceName: "'s'"}, "at least one reading mapping"},
+		{"No resource name", XMLEventMapping{ProfileName: "'p'", DeviceName: "'d'", SourceName: "'s'", Readings: []XMLReadingMapping{{Path: "//Measurement"}}}, "must have a resource name"},
+		{"Unsupported value type", XMLEventMapping{ProfileName: "'p'", DeviceName: "'d'", SourceName: "'s'", Readings: []XMLReadingMapping{{ResourceName: "image", ValueType: common.ValueTypeBinary, Path: "//Image"}}}, "value type 'Binary'"},
+		{"Invalid path", XMLEventMapping{ProfileName: "'p'", DeviceName: "'d'", SourceName: "'s'", Readings: []XMLReadingMapping{{ResourceName: "cycles", Path: "//"}}}, "resource 'cycles'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := NewXMLDecoder(test.Mapping)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
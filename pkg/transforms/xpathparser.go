@@ -0,0 +1,421 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is an element of a parsed XML document. The document itself is the node without a name whose only child
+// is the root element.
+type xmlNode struct {
+	name       string
+	attributes []xml.Attr
+	children   []*xmlNode
+	parent     *xmlNode
+	// content is the element's character data, as strings, and child elements in document order
+	content []interface{}
+}
+
+// parseXMLDocument parses the XML data into a tree of elements, keeping the character data of each element. Names are
+// matched without their namespace.
+func parseXMLDocument(data []byte) (*xmlNode, error) {
+	document := &xmlNode{}
+	current := document
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch value := token.(type) {
+		case xml.StartElement:
+			element := &xmlNode{name: value.Name.Local, attributes: value.Attr, parent: current}
+			current.children = append(current.children, element)
+			current.content = append(current.content, element)
+			current = element
+		case xml.EndElement:
+			current = current.parent
+		case xml.CharData:
+			current.content = append(current.content, string(value))
+		}
+	}
+
+	if len(document.children) == 0 {
+		return nil, fmt.Errorf("no root element found")
+	}
+
+	return document, nil
+}
+
+// value returns the string value of the element, which is its text and that of its descendants
+func (node *xmlNode) value() string {
+	var builder strings.Builder
+	node.writeText(&builder)
+	return strings.TrimSpace(builder.String())
+}
+
+func (node *xmlNode) writeText(builder *strings.Builder) {
+	for _, content := range node.content {
+		switch value := content.(type) {
+		case string:
+			builder.WriteString(value)
+		case *xmlNode:
+			value.writeText(builder)
+		}
+	}
+}
+
+// text returns the element's own character data, without that of its descendants
+func (node *xmlNode) text() string {
+	var builder strings.Builder
+	for _, content := range node.content {
+		if value, ok := content.(string); ok {
+			builder.WriteString(value)
+		}
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// compiledXPath is a parsed XPath expression. It supports a subset of XPath: absolute and relative location paths of
+// element names and the * wildcard, // descendants, . and .., a final @attribute or text() step, and predicates
+// selecting by [position], [last()], [@attribute], [child] or comparing them with a literal using = or !=. A quoted
+// string literal is also an expression, selecting itself.
+type compiledXPath struct {
+	literal  *string
+	absolute bool
+	steps    []xpathStep
+}
+
+type xpathStep struct {
+	// descendant is true for a step following //, which selects from the node and all its descendants
+	descendant bool
+	// kind is one of the xpath step kinds
+	kind       int
+	name       string
+	predicates []xpathPredicate
+}
+
+const (
+	xpathElement = iota
+	xpathAttribute
+	xpathText
+	xpathSelf
+	xpathParent
+)
+
+// xpathPredicate filters the nodes selected by a step, given the node's position among them from 1
+type xpathPredicate func(node *xmlNode, position int, count int) bool
+
+// compileXPath parses the XPath expression, returning an error describing the position of invalid syntax
+func compileXPath(expression string) (*compiledXPath, error) {
+	parser := &jsonPathParser{expression: strings.TrimSpace(expression)}
+	if parser.atEnd() {
+		return nil, fmt.Errorf("XPath expression must not be empty")
+	}
+
+	path, err := parseXPath(parser)
+	if err != nil {
+		return nil, fmt.Errorf("invalid XPath expression '%s': %s", expression, err.Error())
+	}
+
+	if !parser.atEnd() {
+		return nil, fmt.Errorf("invalid XPath expression '%s': unexpected '%c' at position %d",
+			expression, parser.peek(), parser.position)
+	}
+
+	return path, nil
+}
+
+func parseXPath(parser *jsonPathParser) (*compiledXPath, error) {
+	if quote := parser.peek(); quote == '\'' || quote == '"' {
+		literal, err := parser.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &compiledXPath{literal: &literal}, nil
+	}
+
+	path := &compiledXPath{}
+	descendant := false
+	switch {
+	case parser.consume("//"):
+		path.absolute = true
+		descendant = true
+	case parser.consume("/"):
+		path.absolute = true
+		if parser.atEnd() {
+			return nil, parser.unexpected("a step")
+		}
+	}
+
+	for {
+		step, err := parseXPathStep(parser)
+		if err != nil {
+			return nil, err
+		}
+		step.descendant = descendant
+		path.steps = append(path.steps, step)
+
+		if parser.atEnd() || parser.peek() == ']' || parser.peek() == ' ' || parser.peek() == '=' || parser.peek() == '!' {
+			return path, nil
+		}
+
+		if step.kind == xpathAttribute || step.kind == xpathText {
+			return nil, fmt.Errorf("an attribute or text() must be the last step, found '%c' at position %d",
+				parser.peek(), parser.position)
+		}
+
+		switch {
+		case parser.consume("//"):
+			descendant = true
+		case parser.consume("/"):
+			descendant = false
+		default:
+			return nil, parser.unexpected("'/'")
+		}
+	}
+}
+
+func parseXPathStep(parser *jsonPathParser) (xpathStep, error) {
+	var step xpathStep
+
+	switch {
+	case parser.consume(".."):
+		step.kind = xpathParent
+		return step, nil
+	case parser.consume("."):
+		step.kind = xpathSelf
+		return step, nil
+	case parser.consume("text()"):
+		step.kind = xpathText
+		return step, nil
+	case parser.consume("@"):
+		step.kind = xpathAttribute
+	default:
+		step.kind = xpathElement
+	}
+
+	name := parseXPathName(parser)
+	if name == "" {
+		return step, parser.unexpected("a name or '*'")
+	}
+	step.name = name
+
+	for step.kind == xpathElement && parser.consume("[") {
+		predicate, err := parseXPathPredicate(parser)
+		if err != nil {
+			return step, err
+		}
+		if err := parser.expect("]"); err != nil {
+			return step, err
+		}
+		step.predicates = append(step.predicates, predicate)
+	}
+
+	return step, nil
+}
+
+// parseXPathName parses a name or *, dropping any namespace prefix
+func parseXPathName(parser *jsonPathParser) string {
+	if parser.consume("*") {
+		return "*"
+	}
+
+	start := parser.position
+	for !parser.atEnd() {
+		char := parser.peek()
+		if !(char == '_' || char == '-' || char == '.' || char == ':' ||
+			(char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')) {
+			break
+		}
+		parser.position++
+	}
+
+	name := parser.expression[start:parser.position]
+	if index := strings.LastIndex(name, ":"); index >= 0 {
+		name = name[index+1:]
+	}
+	return name
+}
+
+func parseXPathPredicate(parser *jsonPathParser) (xpathPredicate, error) {
+	parser.skipSpaces()
+
+	if parser.consume("last()") {
+		return func(_ *xmlNode, position int, count int) bool { return position == count }, nil
+	}
+
+	if char := parser.peek(); char >= '0' && char <= '9' {
+		start := parser.position
+		for !parser.atEnd() && parser.peek() >= '0' && parser.peek() <= '9' {
+			parser.position++
+		}
+		index, _ := strconv.Atoi(parser.expression[start:parser.position])
+		return func(_ *xmlNode, position int, _ int) bool { return position == index }, nil
+	}
+
+	operand, err := parseXPath(parser)
+	if err != nil {
+		return nil, err
+	}
+	if operand.literal != nil || operand.absolute {
+		return nil, fmt.Errorf("predicate must be a position or a relative path at position %d", parser.position)
+	}
+
+	parser.skipSpaces()
+	var equal bool
+	switch {
+	case parser.consume("!="):
+	case parser.consume("="):
+		equal = true
+	default:
+		// The predicate tests whether the path selects anything
+		return func(node *xmlNode, _ int, _ int) bool { return len(operand.evaluate(node)) > 0 }, nil
+	}
+
+	parser.skipSpaces()
+	literal, err := parser.parseString()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipSpaces()
+
+	return func(node *xmlNode, _ int, _ int) bool {
+		for _, value := range operand.evaluate(node) {
+			if (value == literal) == equal {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// evaluate returns the string values of the nodes and attributes the path selects from the context node, or the
+// document's root for an absolute path
+func (path *compiledXPath) evaluate(context *xmlNode) []string {
+	if path.literal != nil {
+		return []string{*path.literal}
+	}
+
+	nodes := []*xmlNode{context}
+	if path.absolute {
+		for nodes[0].parent != nil {
+			nodes[0] = nodes[0].parent
+		}
+	}
+
+	for index, step := range path.steps {
+		if step.kind == xpathAttribute || step.kind == xpathText {
+			// Only the last step can be an attribute or text()
+			return step.selectValues(nodes)
+		}
+
+		nodes = step.selectNodes(nodes)
+		if len(nodes) == 0 || index == len(path.steps)-1 {
+			break
+		}
+	}
+
+	values := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		values = append(values, node.value())
+	}
+	return values
+}
+
+// contextNodes returns the nodes the step selects from, which include all the descendants following //
+func (step xpathStep) contextNodes(nodes []*xmlNode) []*xmlNode {
+	if !step.descendant {
+		return nodes
+	}
+
+	var all []*xmlNode
+	var walk func(node *xmlNode)
+	walk = func(node *xmlNode) {
+		all = append(all, node)
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	for _, node := range nodes {
+		walk(node)
+	}
+	return all
+}
+
+func (step xpathStep) selectNodes(nodes []*xmlNode) []*xmlNode {
+	var selected []*xmlNode
+	for _, node := range step.contextNodes(nodes) {
+		switch step.kind {
+		case xpathSelf:
+			selected = append(selected, node)
+		case xpathParent:
+			if node.parent != nil {
+				selected = append(selected, node.parent)
+			}
+		default:
+			var matching []*xmlNode
+			for _, child := range node.children {
+				if step.name == "*" || child.name == step.name {
+					matching = append(matching, child)
+				}
+			}
+			// Positions are relative to the children of each node, as in XPath
+			for _, predicate := range step.predicates {
+				var filtered []*xmlNode
+				for position, child := range matching {
+					if predicate(child, position+1, len(matching)) {
+						filtered = append(filtered, child)
+					}
+				}
+				matching = filtered
+			}
+			selected = append(selected, matching...)
+		}
+	}
+	return selected
+}
+
+func (step xpathStep) selectValues(nodes []*xmlNode) []string {
+	var values []string
+	for _, node := range step.contextNodes(nodes) {
+		if step.kind == xpathText {
+			if text := node.text(); text != "" {
+				values = append(values, text)
+			}
+			continue
+		}
+
+		for _, attribute := range node.attributes {
+			if step.name == "*" || attribute.Name.Local == step.name {
+				values = append(values, attribute.Value)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2021 Intel Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package transforms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const xpathDocument = `<?xml version="1.0"?>
+<mes:Report xmlns:mes="urn:mes" station="press-1">
+	<Header><Line>A</Line><Shift id="2">Night</Shift></Header>
+	<Measurements>
+		<Value name="temperature" unit="C">20.5</Value>
+		<Value name="pressure">1013</Value>
+		<Value name="temperature" unit="C">21</Value>
+	</Measurements>
+	<Status>Running <Detail>ok</Detail></Status>
+</mes:Report>`
+
+func TestCompiledXPath_Evaluate(t *testing.T) {
+	document, err := parseXMLDocument([]byte(xpathDocument))
+	require.NoError(t, err)
+
+	tests := []struct {
+		Expression string
+		Expected   []string
+	}{
+		{"'literal'", []string{"literal"}},
+		{"/Report/@station", []string{"press-1"}},
+		{"/mes:Report/Header/Line", []string{"A"}},
+		{"Report/Header/Shift", []string{"Night"}},
+		{"//Shift/@id", []string{"2"}},
+		{"//Value", []string{"20.5", "1013", "21"}},
+		{"//Value[1]", []string{"20.5"}},
+		{"//Value[last()]", []string{"21"}},
+		{"//Value[@name='temperature']", []string{"20.5", "21"}},
+		{"//Value[@name != 'temperature']/@name", []string{"pressure"}},
+		{"//Value[@unit]/@name", []string{"temperature", "temperature"}},
+		{"//Value[@name='temperature'][2]", []string{"21"}},
+		{"/Report/*[Line='A']/Shift", []string{"Night"}},
+		{"/Report/Header[Missing]", nil},
+		{"/Report/Measurements/Value/@*", []string{"temperature", "C", "pressure", "temperature", "C"}},
+		{"/Report/Status", []string{"Running ok"}},
+		{"/Report/Status/text()", []string{"Running"}},
+		{"//Detail/../@station", nil},
+		{"//Detail/..", []string{"Running ok"}},
+		{"//Detail/../../Header", []string{"ANight"}},
+		{"/Report/Header/Line/.", []string{"A"}},
+		{"/Missing", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Expression, func(t *testing.T) {
+			path, err := compileXPath(test.Expression)
+			require.NoError(t, err)
+
+			actual := path.evaluate(document)
+			if test.Expected == nil {
+				assert.Empty(t, actual)
+				return
+			}
+			assert.Equal(t, test.Expected, actual)
+		})
+	}
+}
+
+func TestCompileXPath_Invalid(t *testing.T) {
+	tests := []struct {
+		Expression    string
+		ExpectedError string
+	}{
+		{"", "must not be empty"},
+		{"/", "expected a step"},
+		{"/Report/", "expected a name or '*'"},
+		{"/Report/@station/Line", "an attribute or text() must be the last step"},
+		{"//Value[", "expected a name or '*'"},
+		{"//Value[1", "expected ']'"},
+		{"//Value[@name=temperature]", "at position 14"},
+		{"//Value['x']", "predicate must be a position or a relative path"},
+		{"'unterminated", "unterminated string"},
+		{"/Report Header", "unexpected ' ' at position 7"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Expression, func(t *testing.T) {
+			_, err := compileXPath(test.Expression)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.ExpectedError)
+		})
+	}
+}
+
+func TestParseXMLDocument_Invalid(t *testing.T) {
+	_, err := parseXMLDocument([]byte("<Report><Line></Report>"))
+	require.Error(t, err)
+
+	_, err = parseXMLDocument([]byte("   "))
+	require.Error(t, err)
+}
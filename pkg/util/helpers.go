@@ -19,7 +19,10 @@ package util
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+
+	"github.com/edgexfoundry/app-functions-sdk-go/v2/pkg/codec"
 )
 
 //SplitComma - use custom split func, on commas, instead of .Split to eliminate empty values (i.e Test,,,)
@@ -69,3 +72,25 @@ func CoerceType(param interface{}) ([]byte, error) {
 
 	return data, nil
 }
+
+//CoerceTypeTo will accept a string or []byte and convert it to a []byte, like CoerceType, otherwise encodes the data
+//with the codec registered for the content type. Data for JSON, or a content type with no codec registered, is
+//marshaled to JSON by CoerceType.
+func CoerceTypeTo(contentType string, param interface{}) ([]byte, error) {
+	switch param.(type) {
+	case string, []byte:
+		return CoerceType(param)
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	if _, found := codec.Lookup(mediaType); !found || strings.EqualFold(mediaType, "application/json") {
+		return CoerceType(param)
+	}
+
+	data, err := codec.Marshal(contentType, param)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling input data to '%s' failed: %s", contentType, err.Error())
+	}
+
+	return data, nil
+}
@@ -85,3 +85,22 @@ func TestCoerceTypeNotSupportedToByteArray(t *testing.T) {
 	assert.Error(t, err)
 	assert.IsType(t, reflect.TypeOf(expectedType), reflect.TypeOf(result))
 }
+
+func TestCoerceTypeTo(t *testing.T) {
+	event := dtos.Event{DeviceName: "deviceId"}
+
+	result, err := CoerceTypeTo("", "raw")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("raw"), result)
+
+	result, err = CoerceTypeTo("application/unknown", event)
+	assert.NoError(t, err)
+	assert.Equal(t, byte('{'), result[0], "data should be marshaled to JSON when no codec is registered")
+
+	result, err = CoerceTypeTo("application/cbor", event)
+	assert.NoError(t, err)
+	assert.NotEqual(t, byte('{'), result[0], "data should be marshaled with the CBOR codec")
+
+	_, err = CoerceTypeTo("application/cbor", make(chan int))
+	assert.Error(t, err)
+}